@@ -0,0 +1,25 @@
+-- ==============================================================================
+-- Migration 018: Geofence versioning
+-- ==============================================================================
+-- Boundary edits bump the geofence version and keep an immutable snapshot so
+-- enter/exit transitions can be evaluated against the version a driver entered
+
+ALTER TABLE geofences ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS geofence_versions (
+    geofence_id         UUID        NOT NULL REFERENCES geofences(id) ON DELETE CASCADE,
+    version             INTEGER     NOT NULL,
+    type                VARCHAR(20) NOT NULL,
+    center_latitude     DECIMAL(10,8),
+    center_longitude    DECIMAL(11,8),
+    radius_meters       DECIMAL(10,2),
+    polygon             JSONB,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (geofence_id, version)
+);
+
+-- Seed version 1 snapshots for existing geofences
+INSERT INTO geofence_versions (geofence_id, version, type, center_latitude, center_longitude, radius_meters, polygon, created_at)
+SELECT id, version, type, center_latitude, center_longitude, radius_meters, polygon, updated_at
+FROM geofences
+ON CONFLICT (geofence_id, version) DO NOTHING;
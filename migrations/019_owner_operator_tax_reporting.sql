@@ -0,0 +1,32 @@
+-- ==============================================================================
+-- Migration 019: Owner-operator tax reporting
+-- ==============================================================================
+-- Classifies drivers as employees or owner-operators and records the payee
+-- entity each owner-operator is paid through, so paid settlements can be
+-- accumulated per entity for 1099-NEC export
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS worker_classification VARCHAR(20) NOT NULL DEFAULT 'EMPLOYEE';
+
+CREATE TABLE IF NOT EXISTS payee_entities (
+    id                  UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    driver_id           UUID        NOT NULL REFERENCES drivers(id),
+    classification      VARCHAR(20) NOT NULL DEFAULT 'OWNER_OPERATOR',
+    legal_name          VARCHAR(200) NOT NULL,
+    business_name       VARCHAR(200),
+    tax_id_type         VARCHAR(3)  NOT NULL CHECK (tax_id_type IN ('SSN', 'EIN')),
+    tax_id              VARCHAR(20),
+    address_line1       VARCHAR(200),
+    address_line2       VARCHAR(200),
+    city                VARCHAR(100),
+    state               VARCHAR(10),
+    zip                 VARCHAR(20),
+    backup_withholding  BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE driver_settlements ADD COLUMN IF NOT EXISTS classification VARCHAR(20) NOT NULL DEFAULT 'EMPLOYEE';
+ALTER TABLE driver_settlements ADD COLUMN IF NOT EXISTS payee_entity_id UUID REFERENCES payee_entities(id);
+
+CREATE INDEX IF NOT EXISTS idx_payee_entities_driver     ON payee_entities(driver_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_payee_paid    ON driver_settlements(payee_entity_id, paid_date);
@@ -0,0 +1,11 @@
+-- ==============================================================================
+-- Migration 020: Certified scales
+-- ==============================================================================
+-- Flags truck scale locations whose tickets are accepted as a certified weight,
+-- so dispatch can route overweight and hazardous waste loads to the nearest one
+
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS is_certified_scale BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_locations_certified_scale
+    ON locations(latitude, longitude)
+    WHERE type = 'SCALE' AND is_certified_scale;
@@ -0,0 +1,14 @@
+-- ==============================================================================
+-- Migration 021: Driver PII encryption
+-- ==============================================================================
+-- License numbers, contact details and medical certificate data are stored
+-- envelope-encrypted by driver-service ("enc:v1:<key id>:..."), which needs
+-- wider columns. Existing plaintext values stay readable and are encrypted
+-- in place by the service's key rotation job.
+
+ALTER TABLE drivers ALTER COLUMN email TYPE TEXT;
+ALTER TABLE drivers ALTER COLUMN phone TYPE TEXT;
+ALTER TABLE drivers ALTER COLUMN license_number TYPE TEXT;
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS medical_card_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS medical_restrictions TEXT NOT NULL DEFAULT '';
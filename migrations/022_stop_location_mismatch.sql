@@ -0,0 +1,7 @@
+-- ==============================================================================
+-- Migration 022: Stop location mismatch exceptions
+-- ==============================================================================
+-- Exception type raised when a driver records arrival at or completion of a
+-- stop from a position outside the stop location's geofence
+
+ALTER TYPE exception_type ADD VALUE IF NOT EXISTS 'LOCATION_MISMATCH';
@@ -0,0 +1,40 @@
+-- ==============================================================================
+-- Migration 023: Document templates and tenant branding
+-- ==============================================================================
+-- Versioned HTML templates for rendered PDFs (invoices, driver manifests, rate
+-- confirmations, HOS logs) and the letterhead printed on each tenant's
+-- documents. Tenants without their own rows use the 'default' tenant, then
+-- the templates built into the services.
+
+CREATE TABLE IF NOT EXISTS document_templates (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id       VARCHAR(64) NOT NULL,
+    kind            VARCHAR(40) NOT NULL,
+    version         INTEGER     NOT NULL,
+    body            TEXT        NOT NULL,
+    page_options    JSONB       NOT NULL DEFAULT '{}',
+    active          BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_by      VARCHAR(100) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, kind, version)
+);
+
+-- At most one active version per tenant and kind
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_templates_active
+    ON document_templates(tenant_id, kind) WHERE active;
+
+CREATE TABLE IF NOT EXISTS tenant_branding (
+    tenant_id       VARCHAR(64) PRIMARY KEY,
+    company_name    VARCHAR(200) NOT NULL,
+    logo_url        TEXT        NOT NULL DEFAULT '',
+    primary_color   VARCHAR(20) NOT NULL DEFAULT '#1f3a5f',
+    accent_color    VARCHAR(20) NOT NULL DEFAULT '#e8eef5',
+    address_line1   VARCHAR(200) NOT NULL DEFAULT '',
+    address_line2   VARCHAR(200) NOT NULL DEFAULT '',
+    phone           VARCHAR(30) NOT NULL DEFAULT '',
+    email           VARCHAR(200) NOT NULL DEFAULT '',
+    mc_number       VARCHAR(20) NOT NULL DEFAULT '',
+    dot_number      VARCHAR(20) NOT NULL DEFAULT '',
+    footer_text     TEXT        NOT NULL DEFAULT '',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
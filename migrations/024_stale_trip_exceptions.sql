@@ -0,0 +1,7 @@
+-- ==============================================================================
+-- Migration 024: Stale trip exceptions
+-- ==============================================================================
+-- Exception type raised when the trip watchdog hands an in-progress trip with
+-- no recent stop updates or GPS activity to dispatch for review
+
+ALTER TYPE exception_type ADD VALUE IF NOT EXISTS 'STALE_TRIP';
@@ -0,0 +1,20 @@
+-- ==============================================================================
+-- Migration 025: Route corridor monitoring
+-- ==============================================================================
+-- Planned route and buffer for hazmat and high-value trips, with the state of
+-- the current deviation so alerts survive a tracking-service restart
+
+CREATE TABLE IF NOT EXISTS route_corridors (
+    id                  UUID        PRIMARY KEY,
+    trip_id             UUID        NOT NULL UNIQUE REFERENCES trips(id) ON DELETE CASCADE,
+    driver_id           UUID        REFERENCES drivers(id),
+    reason              VARCHAR(20) NOT NULL,
+    polyline            JSONB       NOT NULL,
+    buffer_meters       DECIMAL(10,2) NOT NULL,
+    max_deviation_mins  INTEGER     NOT NULL,
+    is_active           BOOLEAN     NOT NULL DEFAULT TRUE,
+    off_route_since     TIMESTAMPTZ,
+    alerted_at          TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- ==============================================================================
+-- Migration 026: Seal mismatch exceptions
+-- ==============================================================================
+-- Exception type raised when the seal a driver observes at a loaded pickup
+-- differs from the seal recorded on the shipment
+
+ALTER TYPE exception_type ADD VALUE IF NOT EXISTS 'SEAL_MISMATCH';
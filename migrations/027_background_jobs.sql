@@ -0,0 +1,37 @@
+-- ==============================================================================
+-- Migration 027: Background jobs
+-- ==============================================================================
+-- Queue for the shared jobs package (shared/pkg/jobs). Workers claim due jobs
+-- with FOR UPDATE SKIP LOCKED and hold them under a lease that is extended
+-- while the handler runs; jobs whose lease expires are claimed again.
+
+CREATE TABLE IF NOT EXISTS background_jobs (
+    id              UUID        PRIMARY KEY,
+    queue           VARCHAR(64) NOT NULL,
+    kind            VARCHAR(100) NOT NULL,
+    payload         JSONB       NOT NULL DEFAULT '{}',
+    priority        INTEGER     NOT NULL DEFAULT 0,
+    status          VARCHAR(20) NOT NULL DEFAULT 'QUEUED',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    max_attempts    INTEGER     NOT NULL DEFAULT 5,
+    unique_key      VARCHAR(200),
+    run_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_by       VARCHAR(200),
+    locked_until    TIMESTAMPTZ,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at     TIMESTAMPTZ
+);
+
+-- Claim scans due jobs of one queue by priority
+CREATE INDEX IF NOT EXISTS idx_background_jobs_due
+    ON background_jobs(queue, priority DESC, run_at) WHERE status IN ('QUEUED', 'RUNNING');
+
+-- At most one live job per unique key
+CREATE UNIQUE INDEX IF NOT EXISTS idx_background_jobs_unique_key
+    ON background_jobs(unique_key) WHERE status IN ('QUEUED', 'RUNNING');
+
+-- Finished jobs are pruned by age
+CREATE INDEX IF NOT EXISTS idx_background_jobs_finished
+    ON background_jobs(finished_at) WHERE finished_at IS NOT NULL;
@@ -0,0 +1,22 @@
+-- ==============================================================================
+-- Migration 028: HOS countdown warnings
+-- ==============================================================================
+-- Log of push warnings sent to drivers 2h, 1h and 30m before their driving
+-- limit or duty window closes. Each threshold is sent once per clock and
+-- window, and failed deliveries are kept so they are not retried every check.
+
+CREATE TABLE IF NOT EXISTS hos_warnings (
+    id              UUID        PRIMARY KEY,
+    driver_id       UUID        NOT NULL REFERENCES drivers(id),
+    clock           VARCHAR(10) NOT NULL,
+    threshold_mins  INTEGER     NOT NULL,
+    remaining_mins  INTEGER     NOT NULL,
+    limit_at        TIMESTAMPTZ NOT NULL,
+    window_start    TIMESTAMPTZ NOT NULL,
+    delivered       BOOLEAN     NOT NULL DEFAULT FALSE,
+    delivery_error  TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_hos_warnings_driver_window
+    ON hos_warnings(driver_id, window_start);
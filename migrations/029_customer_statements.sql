@@ -0,0 +1,47 @@
+-- ==============================================================================
+-- Migration 029: Customer statements
+-- ==============================================================================
+-- Monthly statements of each customer's account: open invoices aged by days
+-- past due, unapplied credit memos and disputed amounts. Statement lines are
+-- stored as generated so a period can be retrieved exactly as it was sent.
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS disputed_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS customer_credits (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id     UUID        NOT NULL REFERENCES customers(id),
+    credit_number   VARCHAR(30) UNIQUE NOT NULL,
+    credit_date     TIMESTAMPTZ NOT NULL,
+    amount          DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    applied_amount  DECIMAL(12,2) NOT NULL DEFAULT 0,
+    invoice_id      UUID        REFERENCES invoices(id),
+    reason          TEXT,
+    voided_at       TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by      VARCHAR(100)
+);
+
+CREATE TABLE IF NOT EXISTS customer_statements (
+    id                  UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    statement_number    VARCHAR(30) NOT NULL,
+    customer_id         UUID        NOT NULL REFERENCES customers(id),
+    customer_name       VARCHAR(255) NOT NULL,
+    period_start        DATE        NOT NULL,
+    period_end          DATE        NOT NULL,
+    currency            VARCHAR(3)  NOT NULL DEFAULT 'USD',
+    invoices            JSONB       NOT NULL DEFAULT '[]',
+    credits             JSONB       NOT NULL DEFAULT '[]',
+    aging               JSONB       NOT NULL DEFAULT '{}',
+    total_open          DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_credits       DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_disputed      DECIMAL(12,2) NOT NULL DEFAULT 0,
+    amount_due          DECIMAL(12,2) NOT NULL DEFAULT 0,
+    payments_in_period  DECIMAL(12,2) NOT NULL DEFAULT 0,
+    generated_by        VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (customer_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_credits_open     ON customer_credits(customer_id, credit_date) WHERE voided_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_invoice_date     ON payments(invoice_id, payment_date);
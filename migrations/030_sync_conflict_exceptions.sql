@@ -0,0 +1,7 @@
+-- ==============================================================================
+-- Migration 030: Driver app sync conflict exceptions
+-- ==============================================================================
+-- Exception type raised when a driver uploads an action recorded offline on a
+-- stop or trip that dispatch cancelled or reassigned in the meantime
+
+ALTER TYPE exception_type ADD VALUE IF NOT EXISTS 'SYNC_CONFLICT';
@@ -0,0 +1,13 @@
+-- ==============================================================================
+-- Migration 031: Location source fusion
+-- ==============================================================================
+-- Drivers report through the app while the tractor reports through its ELD
+-- or telematics unit. Every point is kept, tagged with how it was treated
+-- when the sources were merged; only PRIMARY points form the driver's track.
+
+ALTER TABLE location_records
+    ADD COLUMN IF NOT EXISTS fusion_status VARCHAR(20) NOT NULL DEFAULT 'PRIMARY';
+
+CREATE INDEX IF NOT EXISTS idx_loc_records_driver_primary
+    ON location_records(driver_id, recorded_at DESC)
+    WHERE fusion_status = 'PRIMARY';
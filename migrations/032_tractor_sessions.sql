@@ -0,0 +1,55 @@
+-- ==============================================================================
+-- Migration 032: Tractor sessions and ELD driving attribution
+-- ==============================================================================
+-- Drivers log on and off the tractors they share with a team partner or the
+-- next slip-seat shift. ELD driving is attributed to the session at the wheel
+-- when it started; driving no session accounts for, or that overlapping
+-- sessions both claim, is held for review and kept off every driver's log.
+
+CREATE TABLE IF NOT EXISTS tractor_sessions (
+    id          UUID        PRIMARY KEY,
+    tractor_id  UUID        NOT NULL,
+    driver_id   UUID        NOT NULL REFERENCES drivers(id),
+    seat        VARCHAR(20) NOT NULL DEFAULT 'DRIVER',
+    team        BOOLEAN     NOT NULL DEFAULT FALSE,
+    source      VARCHAR(20) NOT NULL DEFAULT '',
+    started_at  TIMESTAMPTZ NOT NULL,
+    ended_at    TIMESTAMPTZ,
+    end_reason  VARCHAR(20) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tractor_sessions_tractor
+    ON tractor_sessions(tractor_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_tractor_sessions_driver_open
+    ON tractor_sessions(driver_id)
+    WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS eld_driving_segments (
+    id                  UUID        PRIMARY KEY,
+    tractor_id          UUID        NOT NULL,
+    external_id         VARCHAR(100) NOT NULL DEFAULT '',
+    reported_driver_id  UUID,
+    driver_id           UUID        REFERENCES drivers(id),
+    session_id          UUID        REFERENCES tractor_sessions(id),
+    attribution         VARCHAR(20) NOT NULL,
+    note                TEXT        NOT NULL DEFAULT '',
+    start_time          TIMESTAMPTZ NOT NULL,
+    end_time            TIMESTAMPTZ,
+    location            VARCHAR(255) NOT NULL DEFAULT '',
+    latitude            DECIMAL(10,7) NOT NULL DEFAULT 0,
+    longitude           DECIMAL(10,7) NOT NULL DEFAULT 0,
+    odometer            INTEGER     NOT NULL DEFAULT 0,
+    engine_hours        DECIMAL(10,2) NOT NULL DEFAULT 0,
+    hos_log_id          UUID,
+    resolved_by         VARCHAR(100) NOT NULL DEFAULT '',
+    resolved_at         TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_eld_segments_external
+    ON eld_driving_segments(tractor_id, external_id)
+    WHERE external_id <> '';
+CREATE INDEX IF NOT EXISTS idx_eld_segments_review
+    ON eld_driving_segments(start_time)
+    WHERE attribution IN ('UNASSIGNED', 'CONFLICT');
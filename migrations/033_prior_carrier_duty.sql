@@ -0,0 +1,20 @@
+-- ==============================================================================
+-- Migration 033: Prior-carrier duty hours for new hires
+-- ==============================================================================
+-- Drivers hired mid-cycle bring up to 7 days of on-duty time from their
+-- previous carrier, keyed from their statement or an ELD transfer file. The
+-- 70-hour/8-day cycle counts it for the first 8 days of employment.
+
+CREATE TABLE IF NOT EXISTS driver_prior_duty (
+    id            UUID        PRIMARY KEY,
+    driver_id     UUID        NOT NULL REFERENCES drivers(id),
+    duty_date     DATE        NOT NULL,
+    on_duty_mins  INTEGER     NOT NULL,
+    driving_mins  INTEGER     NOT NULL DEFAULT 0,
+    carrier_name  VARCHAR(255) NOT NULL,
+    carrier_dot   VARCHAR(20) NOT NULL DEFAULT '',
+    source        VARCHAR(20) NOT NULL,
+    imported_by   VARCHAR(100) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (driver_id, duty_date)
+);
@@ -0,0 +1,23 @@
+-- ==============================================================================
+-- Migration 035: Geofence events
+-- ==============================================================================
+-- Enter and exit events are kept so visits can be paired up and aggregated
+-- into per-location entry counts and dwell times, e.g. to check contract free
+-- time against how long drivers actually spend at a facility
+
+CREATE TABLE IF NOT EXISTS geofence_events (
+    id                  UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    geofence_id         UUID        NOT NULL REFERENCES geofences(id) ON DELETE CASCADE,
+    geofence_version    INTEGER     NOT NULL DEFAULT 1,
+    location_id         UUID,
+    driver_id           UUID        NOT NULL,
+    trip_id             UUID,
+    event_type          VARCHAR(10) NOT NULL CHECK (event_type IN ('enter', 'exit')),
+    occurred_at         TIMESTAMPTZ NOT NULL,
+    latitude            DECIMAL(10,8),
+    longitude           DECIMAL(11,8),
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_geofence_events_occurred ON geofence_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_geofence_events_location ON geofence_events(location_id, occurred_at);
@@ -0,0 +1,77 @@
+-- ==============================================================================
+-- Migration 036: Rate effective dating and re-rating
+-- ==============================================================================
+-- Every rate table gets an effective window. An amendment replaces a rate with
+-- a new version from a given date; the old version is expired and points at
+-- its successor. Charges record the rate they were priced under so a
+-- retroactive amendment can re-rate them, and every re-rated charge is kept
+-- as an adjustment for audit.
+
+-- ─── Effective dating ───────────────────────────────────────────────────────
+
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS superseded_by UUID REFERENCES rates(id);
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS expiry_alerted_at TIMESTAMPTZ;
+
+ALTER TABLE accessorial_rates ADD COLUMN IF NOT EXISTS effective_date TIMESTAMPTZ NOT NULL DEFAULT '2000-01-01';
+ALTER TABLE accessorial_rates ADD COLUMN IF NOT EXISTS expiration_date TIMESTAMPTZ;
+ALTER TABLE accessorial_rates ADD COLUMN IF NOT EXISTS superseded_by UUID REFERENCES accessorial_rates(id);
+ALTER TABLE accessorial_rates ADD COLUMN IF NOT EXISTS expiry_alerted_at TIMESTAMPTZ;
+
+ALTER TABLE lane_rates ADD COLUMN IF NOT EXISTS effective_date TIMESTAMPTZ NOT NULL DEFAULT '2000-01-01';
+ALTER TABLE lane_rates ADD COLUMN IF NOT EXISTS expiration_date TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_rates_expiration ON rates(expiration_date)
+    WHERE is_active AND superseded_by IS NULL;
+CREATE INDEX IF NOT EXISTS idx_accessorial_rates_expiration ON accessorial_rates(expiration_date)
+    WHERE is_active AND superseded_by IS NULL;
+
+-- ─── Rate a charge was priced under ─────────────────────────────────────────
+
+ALTER TABLE order_charges ADD COLUMN IF NOT EXISTS rate_kind VARCHAR(20);
+ALTER TABLE order_charges ADD COLUMN IF NOT EXISTS rate_id UUID;
+ALTER TABLE invoice_line_items ADD COLUMN IF NOT EXISTS rate_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_order_charges_rate ON order_charges(rate_kind, rate_id);
+
+-- ─── Amendments and re-rating audit ─────────────────────────────────────────
+
+CREATE TABLE IF NOT EXISTS rate_amendments (
+    id                      UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    rate_kind               VARCHAR(20) NOT NULL CHECK (rate_kind IN ('CONTRACT', 'ACCESSORIAL')),
+    previous_rate_id        UUID        NOT NULL,
+    new_rate_id             UUID        NOT NULL,
+    customer_id             UUID,
+    effective_date          TIMESTAMPTZ NOT NULL,
+    reason                  TEXT        NOT NULL,
+    charges_rerated         INTEGER     NOT NULL DEFAULT 0,
+    orders_rerated          INTEGER     NOT NULL DEFAULT 0,
+    total_delta             DECIMAL(12,2) NOT NULL DEFAULT 0,
+    pending_invoice_delta   DECIMAL(12,2) NOT NULL DEFAULT 0,
+    amended_by              VARCHAR(100) NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_amendments_previous ON rate_amendments(previous_rate_id);
+CREATE INDEX IF NOT EXISTS idx_rate_amendments_new ON rate_amendments(new_rate_id);
+
+CREATE TABLE IF NOT EXISTS rerate_adjustments (
+    id                  UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    amendment_id        UUID        NOT NULL REFERENCES rate_amendments(id) ON DELETE CASCADE,
+    order_id            UUID        NOT NULL,
+    order_number        VARCHAR(20) NOT NULL,
+    charge_id           UUID        NOT NULL,
+    charge_type         VARCHAR(30) NOT NULL,
+    invoice_id          UUID,
+    line_item_id        UUID,
+    quantity            DECIMAL(10,2) NOT NULL,
+    old_unit_rate       DECIMAL(10,2) NOT NULL,
+    new_unit_rate       DECIMAL(10,2) NOT NULL,
+    old_amount          DECIMAL(10,2) NOT NULL,
+    new_amount          DECIMAL(10,2) NOT NULL,
+    delta               DECIMAL(10,2) NOT NULL,
+    status              VARCHAR(30) NOT NULL CHECK (status IN ('APPLIED', 'PENDING_INVOICE_ADJUSTMENT')),
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rerate_adjustments_amendment ON rerate_adjustments(amendment_id);
+CREATE INDEX IF NOT EXISTS idx_rerate_adjustments_order ON rerate_adjustments(order_id);
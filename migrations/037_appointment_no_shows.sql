@@ -0,0 +1,44 @@
+-- ==============================================================================
+-- Migration 037: Terminal appointment no-shows
+-- ==============================================================================
+-- A confirmed appointment whose window passes with no gate transaction or
+-- geofence arrival is recorded as a no-show. Each one carries the fee the
+-- terminal assessed, who ops attributed it to, and whether the fee was passed
+-- through to the customer.
+
+CREATE TABLE IF NOT EXISTS appointment_no_shows (
+    id                  UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    appointment_id      UUID        NOT NULL UNIQUE REFERENCES terminal_appointments(id),
+    order_id            UUID        NOT NULL REFERENCES orders(id),
+    order_number        VARCHAR(20) NOT NULL DEFAULT '',
+    customer_id         UUID        REFERENCES customers(id),
+    terminal_id         UUID        NOT NULL REFERENCES locations(id),
+    container_number    VARCHAR(15) NOT NULL DEFAULT '',
+    driver_id           UUID,
+    trip_id             UUID,
+    window_start_time   TIMESTAMPTZ NOT NULL,
+    window_end_time     TIMESTAMPTZ NOT NULL,
+    detected_at         TIMESTAMPTZ NOT NULL,
+    suggested_fault     VARCHAR(20) NOT NULL,
+    suggested_reason    TEXT        NOT NULL DEFAULT '',
+    fault               VARCHAR(20) NOT NULL DEFAULT 'UNDETERMINED'
+                        CHECK (fault IN ('UNDETERMINED', 'DRIVER', 'CUSTOMER', 'DISPATCH', 'TERMINAL')),
+    fault_notes         TEXT        NOT NULL DEFAULT '',
+    attributed_by       VARCHAR(100) NOT NULL DEFAULT '',
+    attributed_at       TIMESTAMPTZ,
+    fee_amount          DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fee_reference       VARCHAR(100) NOT NULL DEFAULT '',
+    fee_assessed_at     TIMESTAMPTZ,
+    fee_recorded_by     VARCHAR(100) NOT NULL DEFAULT '',
+    billing_status      VARCHAR(20) NOT NULL DEFAULT 'NOT_BILLED'
+                        CHECK (billing_status IN ('NOT_BILLED', 'BILLED', 'WAIVED')),
+    billed_amount       DECIMAL(10,2) NOT NULL DEFAULT 0,
+    billed_by           VARCHAR(100) NOT NULL DEFAULT '',
+    billed_at           TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_no_shows_window ON appointment_no_shows(window_start_time);
+CREATE INDEX IF NOT EXISTS idx_appointment_no_shows_terminal ON appointment_no_shows(terminal_id, window_start_time);
+CREATE INDEX IF NOT EXISTS idx_appointment_no_shows_customer ON appointment_no_shows(customer_id, window_start_time);
@@ -0,0 +1,52 @@
+-- ==============================================================================
+-- Migration 038: Trip to billing reconciliation
+-- ==============================================================================
+-- A nightly run cross-checks finished trips against their charges and
+-- invoices. Each gap it finds (a missing line haul, fuel surcharge or captured
+-- accessorial, charges never invoiced, a charge invoiced twice) is kept as a
+-- finance exception until finance closes it or a later run no longer finds it.
+
+CREATE TABLE IF NOT EXISTS billing_reconciliation_runs (
+    id                   UUID        PRIMARY KEY,
+    period_start         TIMESTAMPTZ NOT NULL,
+    period_end           TIMESTAMPTZ NOT NULL,
+    trips_checked        INTEGER     NOT NULL DEFAULT 0,
+    issues_found         INTEGER     NOT NULL DEFAULT 0,
+    issues_opened        INTEGER     NOT NULL DEFAULT 0,
+    issues_cleared       INTEGER     NOT NULL DEFAULT 0,
+    unbilled_amount      DECIMAL(12,2) NOT NULL DEFAULT 0,
+    double_billed_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    run_by               VARCHAR(100) NOT NULL,
+    started_at           TIMESTAMPTZ NOT NULL,
+    completed_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_recon_runs_started ON billing_reconciliation_runs(started_at DESC);
+
+CREATE TABLE IF NOT EXISTS billing_reconciliation_issues (
+    id               UUID        PRIMARY KEY,
+    trip_id          UUID        NOT NULL REFERENCES trips(id),
+    trip_number      VARCHAR(20) NOT NULL,
+    customer_id      UUID,
+    issue_type       VARCHAR(30) NOT NULL CHECK (issue_type IN (
+        'MISSING_LINE_HAUL', 'MISSING_FUEL_SURCHARGE', 'MISSING_ACCESSORIAL', 'NOT_INVOICED', 'DOUBLE_BILLED'
+    )),
+    charge_type      VARCHAR(30) NOT NULL DEFAULT '',
+    amount           DECIMAL(12,2) NOT NULL DEFAULT 0,
+    detail           TEXT        NOT NULL,
+    invoice_ids      UUID[],
+    status           VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED', 'DISMISSED', 'CLEARED')),
+    first_run_id     UUID        NOT NULL REFERENCES billing_reconciliation_runs(id),
+    last_run_id      UUID        NOT NULL REFERENCES billing_reconciliation_runs(id),
+    detected_at      TIMESTAMPTZ NOT NULL,
+    resolved_by      VARCHAR(100),
+    resolved_at      TIMESTAMPTZ,
+    resolution_notes TEXT
+);
+
+-- One open issue of a kind per trip; later runs refresh it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_billing_recon_issues_open
+    ON billing_reconciliation_issues(trip_id, issue_type, charge_type) WHERE status = 'OPEN';
+CREATE INDEX IF NOT EXISTS idx_billing_recon_issues_status ON billing_reconciliation_issues(status, detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_billing_recon_issues_customer ON billing_reconciliation_issues(customer_id)
+    WHERE customer_id IS NOT NULL;
@@ -0,0 +1,35 @@
+-- ==============================================================================
+-- Migration 039: Customer telemetry exports
+-- ==============================================================================
+-- Per-customer export of anonymized, order-scoped positions and milestones to
+-- the customer's S3 bucket or Kafka topic. The cursors are the last location
+-- record (by received_at) and milestone (by created_at) delivered, so a
+-- paused or failing export resumes where it stopped.
+
+CREATE TABLE IF NOT EXISTS customer_data_exports (
+    id                   UUID        PRIMARY KEY,
+    customer_id          UUID        NOT NULL UNIQUE REFERENCES customers(id) ON DELETE CASCADE,
+    destination          VARCHAR(10) NOT NULL CHECK (destination IN ('S3', 'KAFKA')),
+    bucket               VARCHAR(255) NOT NULL DEFAULT '',
+    region               VARCHAR(50)  NOT NULL DEFAULT '',
+    prefix               VARCHAR(255) NOT NULL DEFAULT '',
+    topic                VARCHAR(255) NOT NULL DEFAULT '',
+    enabled              BOOLEAN     NOT NULL DEFAULT FALSE,
+    salt                 VARCHAR(64) NOT NULL,
+    location_cursor_at   TIMESTAMPTZ,
+    location_cursor_id   UUID,
+    milestone_cursor_at  TIMESTAMPTZ,
+    milestone_cursor_id  UUID,
+    last_exported_at     TIMESTAMPTZ,
+    last_error           TEXT        NOT NULL DEFAULT '',
+    records_exported     BIGINT      NOT NULL DEFAULT 0,
+    created_by           VARCHAR(100) NOT NULL DEFAULT '',
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (destination <> 'S3' OR (bucket <> '' AND region <> '')),
+    CHECK (destination <> 'KAFKA' OR topic <> '')
+);
+
+-- Exports read new merged-track points and milestones in cursor order
+CREATE INDEX IF NOT EXISTS idx_location_records_received ON location_records(received_at, id) WHERE fusion_status = 'PRIMARY';
+CREATE INDEX IF NOT EXISTS idx_milestones_created ON milestones(created_at, id);
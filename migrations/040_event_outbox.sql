@@ -0,0 +1,28 @@
+-- ==============================================================================
+-- Migration 040: Event outbox
+-- ==============================================================================
+-- Transactional outbox for shared/pkg/kafka. Services insert events in the
+-- same transaction as the write they describe; an OutboxRelay publishes them
+-- in id order per topic and stamps published_at. Failed events are retried
+-- at next_attempt_at and hold back later events of their topic.
+
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id              BIGSERIAL    PRIMARY KEY,
+    topic           VARCHAR(200) NOT NULL,
+    event_id        VARCHAR(64)  NOT NULL,
+    event_type      VARCHAR(200) NOT NULL,
+    payload         JSONB        NOT NULL,
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    last_error      TEXT         NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMPTZ
+);
+
+-- The relay scans undelivered events in order, per topic
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+    ON event_outbox(topic, id) WHERE published_at IS NULL;
+
+-- Delivered events are pruned by age
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published
+    ON event_outbox(published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,10 @@
+-- ==============================================================================
+-- Migration 041: Invoice lines per delivery stop
+-- ==============================================================================
+-- A container split across delivery points (a partial devan) is billed per
+-- stop: its line haul and container charges are shared across the stops in
+-- proportion to what each received, one invoice line per stop.
+
+ALTER TABLE invoice_line_items ADD COLUMN IF NOT EXISTS stop_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_invoice_line_items_stop ON invoice_line_items(stop_id) WHERE stop_id IS NOT NULL;
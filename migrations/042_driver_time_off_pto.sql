@@ -0,0 +1,158 @@
+-- ==============================================================================
+-- Migration 042: Driver time off and PTO
+-- ==============================================================================
+-- Company drivers accrue PTO weekly from hours on completed trips and draw on
+-- it for approved vacation and other time off. Every movement on a driver's
+-- balance is a ledger row, so the balance is the sum of the ledger. Paid time
+-- off is posted to the driver's draft settlement for the week it is taken
+-- when approved, and taken off again if cancelled before it starts.
+
+CREATE TABLE IF NOT EXISTS driver_time_off (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    driver_id       UUID        NOT NULL REFERENCES drivers(id),
+    driver_name     VARCHAR(200) NOT NULL DEFAULT '',
+    type            VARCHAR(20) NOT NULL CHECK (type IN ('VACATION', 'PERSONAL', 'SICK')),
+    start_date      DATE        NOT NULL,
+    end_date        DATE        NOT NULL,
+    paid            BOOLEAN     NOT NULL DEFAULT TRUE,
+    paid_hours      DECIMAL(8,2) NOT NULL DEFAULT 0,
+    pay_rate        DECIMAL(8,2) NOT NULL DEFAULT 0,
+    pay_amount      DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status          VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+                    CHECK (status IN ('PENDING', 'APPROVED', 'DENIED', 'CANCELLED')),
+    notes           TEXT        NOT NULL DEFAULT '',
+    requested_by    VARCHAR(100) NOT NULL DEFAULT '',
+    reviewed_by     VARCHAR(100) NOT NULL DEFAULT '',
+    reviewed_at     TIMESTAMPTZ,
+    review_notes    TEXT        NOT NULL DEFAULT '',
+    cancelled_by    VARCHAR(100) NOT NULL DEFAULT '',
+    cancelled_at    TIMESTAMPTZ,
+    cancel_reason   TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_time_off_driver ON driver_time_off(driver_id, start_date);
+CREATE INDEX IF NOT EXISTS idx_driver_time_off_dates ON driver_time_off(start_date, end_date) WHERE status IN ('PENDING', 'APPROVED');
+
+CREATE TABLE IF NOT EXISTS driver_pto_ledger (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    driver_id       UUID        NOT NULL REFERENCES drivers(id),
+    type            VARCHAR(20) NOT NULL CHECK (type IN ('ACCRUAL', 'USAGE', 'REVERSAL', 'ADJUSTMENT')),
+    hours           DECIMAL(8,2) NOT NULL,
+    week_start      DATE,
+    worked_hours    DECIMAL(8,2) NOT NULL DEFAULT 0,
+    time_off_id     UUID        REFERENCES driver_time_off(id),
+    notes           TEXT        NOT NULL DEFAULT '',
+    created_by      VARCHAR(100) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_pto_ledger_driver ON driver_pto_ledger(driver_id, created_at DESC);
+-- One accrual per driver per week, so a rerun of the weekly accrual is a no-op
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_pto_ledger_accrual ON driver_pto_ledger(driver_id, week_start) WHERE type = 'ACCRUAL';
+
+ALTER TABLE settlement_line_items ADD COLUMN IF NOT EXISTS time_off_id UUID REFERENCES driver_time_off(id);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_line_items_time_off ON settlement_line_items(time_off_id) WHERE time_off_id IS NOT NULL;
+
+-- Function: Recalculate a settlement's totals from its line items, as the
+-- trip completion trigger does
+CREATE OR REPLACE FUNCTION recalculate_settlement_totals(p_settlement_id UUID)
+RETURNS VOID AS $$
+BEGIN
+    UPDATE driver_settlements
+    SET
+        total_trips = (SELECT COUNT(DISTINCT trip_id) FROM settlement_line_items WHERE settlement_id = p_settlement_id),
+        total_miles = (SELECT COALESCE(SUM(miles), 0) FROM settlement_line_items WHERE settlement_id = p_settlement_id),
+        gross_earnings = (SELECT COALESCE(SUM(amount), 0) FROM settlement_line_items WHERE settlement_id = p_settlement_id AND type NOT LIKE '%DEDUCTION%'),
+        waiting_pay = (SELECT COALESCE(SUM(amount), 0) FROM settlement_line_items WHERE settlement_id = p_settlement_id AND type = 'WAITING_TIME'),
+        net_pay = (SELECT COALESCE(SUM(amount), 0) FROM settlement_line_items WHERE settlement_id = p_settlement_id) -
+                 COALESCE(fuel_deductions, 0) - COALESCE(advance_deductions, 0) - COALESCE(other_deductions, 0),
+        updated_at = NOW()
+    WHERE id = p_settlement_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function: Post approved paid time off to the driver's draft settlement for
+-- the week it starts, or the current week when that has already closed, and
+-- take it off again when the time off is cancelled
+CREATE OR REPLACE FUNCTION sync_time_off_settlement_items()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_day DATE;
+    v_settlement_id UUID;
+BEGIN
+    IF NEW.status = 'APPROVED' AND OLD.status != 'APPROVED' AND NEW.paid AND NEW.pay_amount > 0 THEN
+        v_day := GREATEST(NEW.start_date, DATE_TRUNC('week', CURRENT_DATE)::DATE);
+
+        SELECT id INTO v_settlement_id
+        FROM driver_settlements
+        WHERE driver_id = NEW.driver_id
+        AND status = 'DRAFT'
+        AND period_start <= v_day
+        AND period_end >= v_day;
+
+        IF v_settlement_id IS NULL THEN
+            INSERT INTO driver_settlements (
+                driver_id,
+                settlement_number,
+                period_start,
+                period_end,
+                status
+            ) VALUES (
+                NEW.driver_id,
+                'STL-' || TO_CHAR(NOW(), 'YYYYMMDD') || '-' ||
+                LPAD((SELECT COUNT(*) + 1 FROM driver_settlements
+                     WHERE DATE(created_at) = CURRENT_DATE)::TEXT, 4, '0'),
+                DATE_TRUNC('week', v_day),
+                DATE_TRUNC('week', v_day) + INTERVAL '6 days',
+                'DRAFT'
+            ) RETURNING id INTO v_settlement_id;
+        END IF;
+
+        INSERT INTO settlement_line_items (
+            settlement_id,
+            time_off_id,
+            trip_date,
+            type,
+            description,
+            rate,
+            amount
+        ) VALUES (
+            v_settlement_id,
+            NEW.id,
+            NEW.start_date,
+            'PTO_PAY',
+            INITCAP(NEW.type) || ' ' || TO_CHAR(NEW.start_date, 'MM/DD') ||
+                CASE WHEN NEW.end_date > NEW.start_date THEN '-' || TO_CHAR(NEW.end_date, 'MM/DD') ELSE '' END ||
+                ' - ' || NEW.paid_hours || ' hrs',
+            NEW.pay_rate,
+            NEW.pay_amount
+        );
+
+        PERFORM recalculate_settlement_totals(v_settlement_id);
+
+    ELSIF NEW.status = 'CANCELLED' AND OLD.status = 'APPROVED' THEN
+        FOR v_settlement_id IN
+            DELETE FROM settlement_line_items sli
+            USING driver_settlements ds
+            WHERE sli.settlement_id = ds.id
+            AND ds.status = 'DRAFT'
+            AND sli.time_off_id = NEW.id
+            RETURNING sli.settlement_id
+        LOOP
+            PERFORM recalculate_settlement_totals(v_settlement_id);
+        END LOOP;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_time_off_settlement_items ON driver_time_off;
+CREATE TRIGGER trg_time_off_settlement_items
+    AFTER UPDATE OF status ON driver_time_off
+    FOR EACH ROW
+    EXECUTE FUNCTION sync_time_off_settlement_items();
@@ -0,0 +1,35 @@
+-- ==============================================================================
+-- Migration 044: Backfill runs
+-- ==============================================================================
+-- Checkpoints for the shared backfill package (shared/pkg/backfill). A run
+-- reprocesses historical rows into a new analytics table a chunk at a time;
+-- each chunk moves last_cursor forward so a paused or failed run resumes
+-- where it stopped. Runs are processed as background jobs on the "backfill"
+-- queue.
+
+CREATE TABLE IF NOT EXISTS backfill_runs (
+    id                  UUID         PRIMARY KEY,
+    name                VARCHAR(100) NOT NULL,
+    status              VARCHAR(20)  NOT NULL DEFAULT 'RUNNING',
+    last_cursor         TEXT         NOT NULL DEFAULT '',
+    rows_processed      BIGINT       NOT NULL DEFAULT 0,
+    chunks_processed    INTEGER      NOT NULL DEFAULT 0,
+    chunk_size          INTEGER      NOT NULL,
+    total_rows          BIGINT,
+    active_ms           BIGINT       NOT NULL DEFAULT 0,
+    failures            INTEGER      NOT NULL DEFAULT 0,
+    last_error          TEXT         NOT NULL DEFAULT '',
+    started_by          VARCHAR(100) NOT NULL,
+    started_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMPTZ,
+
+    CONSTRAINT backfill_runs_status_check
+        CHECK (status IN ('RUNNING', 'PAUSED', 'FAILED', 'COMPLETED', 'CANCELLED'))
+);
+
+-- One unfinished run per backfill
+CREATE UNIQUE INDEX IF NOT EXISTS idx_backfill_runs_active
+    ON backfill_runs(name) WHERE status IN ('RUNNING', 'PAUSED', 'FAILED');
+
+CREATE INDEX IF NOT EXISTS idx_backfill_runs_name ON backfill_runs(name, started_at DESC);
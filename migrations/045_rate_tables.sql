@@ -0,0 +1,76 @@
+-- ==============================================================================
+-- Migration 045: Customer rate tables
+-- ==============================================================================
+-- Customer contracts group lane rates between zones - sets of locations by
+-- ZIP prefix or by location - and may price fuel off a surcharge schedule
+-- that follows the published diesel price. Lane rates and accessorial rates
+-- keep their effective-date versioning through rate amendments (036).
+
+CREATE TABLE IF NOT EXISTS rate_zones (
+    id              UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    code            VARCHAR(50)  NOT NULL UNIQUE,
+    name            VARCHAR(200) NOT NULL,
+    description     TEXT,
+    postal_prefixes TEXT[]       NOT NULL DEFAULT '{}',
+    location_ids    UUID[]       NOT NULL DEFAULT '{}',
+    is_active       BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS fuel_surcharge_schedules (
+    id              UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id     UUID         REFERENCES customers(id),  -- NULL = default schedule
+    name            VARCHAR(200) NOT NULL,
+    region          VARCHAR(50)  NOT NULL DEFAULT 'US',
+    effective_date  TIMESTAMPTZ  NOT NULL,
+    expiration_date TIMESTAMPTZ,
+    is_active       BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS fuel_surcharge_tiers (
+    id                  UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id         UUID          NOT NULL REFERENCES fuel_surcharge_schedules(id) ON DELETE CASCADE,
+    min_price           DECIMAL(6,3)  NOT NULL,
+    max_price           DECIMAL(6,3),
+    surcharge_percent   DECIMAL(6,2)  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_fuel_surcharge_tiers_schedule ON fuel_surcharge_tiers(schedule_id, min_price);
+
+-- Published diesel prices, e.g. the weekly DOE on-highway average
+CREATE TABLE IF NOT EXISTS fuel_prices (
+    id                  UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    region              VARCHAR(50)   NOT NULL,
+    effective_date      DATE          NOT NULL,
+    price_per_gallon    DECIMAL(6,3)  NOT NULL,
+    source              VARCHAR(100),
+    created_by          VARCHAR(100)  NOT NULL,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+
+    UNIQUE (region, effective_date)
+);
+
+CREATE TABLE IF NOT EXISTS rate_contracts (
+    id                  UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id         UUID         NOT NULL REFERENCES customers(id),
+    contract_number     VARCHAR(50)  NOT NULL UNIQUE,
+    name                VARCHAR(200) NOT NULL,
+    fuel_schedule_id    UUID         REFERENCES fuel_surcharge_schedules(id),
+    effective_date      TIMESTAMPTZ  NOT NULL,
+    expiration_date     TIMESTAMPTZ,
+    is_active           BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_by          VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_contracts_customer ON rate_contracts(customer_id, effective_date DESC);
+
+ALTER TABLE rates ADD COLUMN IF NOT EXISTS contract_id UUID REFERENCES rate_contracts(id);
+
+CREATE INDEX IF NOT EXISTS idx_rates_contract ON rates(contract_id) WHERE contract_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_rates_zone_lane ON rates(customer_id, origin_zone, destination_zone)
+    WHERE origin_type = 'zone' OR destination_type = 'zone';
@@ -0,0 +1,48 @@
+-- ==============================================================================
+-- Migration 046: Vessel tracking
+-- ==============================================================================
+-- Live AIS positions of vessels carrying open imports. order-service keeps
+-- one track per vessel voyage and terminal, refines the ETA from the
+-- vessel's position and speed, and moves the shipments' vessel_eta when the
+-- refined ETA drifts past the threshold, keeping each change.
+
+CREATE TABLE IF NOT EXISTS vessel_tracks (
+    id                  UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    vessel_name         VARCHAR(255)  NOT NULL,
+    voyage_number       VARCHAR(50)   NOT NULL DEFAULT '',
+    terminal_id         UUID          NOT NULL REFERENCES locations(id),
+    imo                 VARCHAR(10)   NOT NULL DEFAULT '',
+    mmsi                VARCHAR(12)   NOT NULL DEFAULT '',
+    latitude            DECIMAL(10,7),
+    longitude           DECIMAL(10,7),
+    speed_knots         DECIMAL(5,1),
+    nav_status          VARCHAR(30)   NOT NULL DEFAULT '',
+    position_at         TIMESTAMPTZ,
+    distance_nm         DECIMAL(8,1),
+    predicted_eta       TIMESTAMPTZ,
+    eta_source          VARCHAR(20)   NOT NULL DEFAULT '',
+    last_checked_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+
+    UNIQUE (vessel_name, voyage_number, terminal_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vessel_tracks_checked ON vessel_tracks(last_checked_at DESC);
+
+CREATE TABLE IF NOT EXISTS vessel_eta_changes (
+    id              UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    shipment_id     UUID         NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    track_id        UUID         NOT NULL REFERENCES vessel_tracks(id) ON DELETE CASCADE,
+    previous_eta    TIMESTAMPTZ,
+    new_eta         TIMESTAMPTZ  NOT NULL,
+    drift_minutes   INTEGER      NOT NULL,  -- Negative when early
+    source          VARCHAR(20)  NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vessel_eta_changes_shipment ON vessel_eta_changes(shipment_id, created_at DESC);
+
+-- Open imports not yet arrived, by vessel voyage
+CREATE INDEX IF NOT EXISTS idx_shipments_inbound_vessel ON shipments(vessel_name, voyage_number, terminal_id)
+    WHERE type = 'IMPORT' AND vessel_ata IS NULL AND status IN ('PENDING', 'IN_PROGRESS');
@@ -0,0 +1,56 @@
+-- ==============================================================================
+-- Migration 047: Terminal fee schedules
+-- ==============================================================================
+-- Gate, exam and chassis flip fees each terminal charges, versioned by
+-- effective date, and how each customer is billed for them. billing-service
+-- attaches the fee in effect to the order when dispatch reports the stop
+-- that triggered it, billing the customer through order_charges.
+
+CREATE TABLE IF NOT EXISTS terminal_fees (
+    id              UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    terminal_id     UUID          NOT NULL REFERENCES locations(id),
+    fee_type        VARCHAR(20)   NOT NULL CHECK (fee_type IN ('GATE', 'EXAM', 'CHASSIS_FLIP')),
+    description     VARCHAR(255)  NOT NULL,
+    container_size  VARCHAR(5)    NOT NULL DEFAULT '',  -- '' = any size
+    amount          DECIMAL(10,2) NOT NULL,
+    effective_date  TIMESTAMPTZ   NOT NULL,
+    end_date        TIMESTAMPTZ,
+    created_by      VARCHAR(100)  NOT NULL,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_terminal_fees_lookup ON terminal_fees(terminal_id, fee_type, container_size, effective_date DESC);
+
+-- Customers without a row are billed terminal fees at cost
+CREATE TABLE IF NOT EXISTS customer_fee_pass_through (
+    customer_id     UUID          NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    fee_type        VARCHAR(20)   NOT NULL,
+    mode            VARCHAR(10)   NOT NULL CHECK (mode IN ('AT_COST', 'MARKUP', 'ABSORB')),
+    markup_pct      DECIMAL(6,2)  NOT NULL DEFAULT 0,
+    updated_by      VARCHAR(100)  NOT NULL,
+    updated_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (customer_id, fee_type)
+);
+
+CREATE TABLE IF NOT EXISTS terminal_fee_charges (
+    id                  UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    fee_id              UUID          NOT NULL REFERENCES terminal_fees(id),
+    fee_type            VARCHAR(20)   NOT NULL,
+    terminal_id         UUID          NOT NULL REFERENCES locations(id),
+    order_id            UUID          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    trip_id             UUID          NOT NULL,
+    stop_id             UUID          NOT NULL,
+    container_number    VARCHAR(20)   NOT NULL DEFAULT '',
+    cost_amount         DECIMAL(10,2) NOT NULL,
+    billed_amount       DECIMAL(10,2) NOT NULL,
+    mode                VARCHAR(10)   NOT NULL,
+    order_charge_id     UUID          REFERENCES order_charges(id) ON DELETE SET NULL,
+    occurred_at         TIMESTAMPTZ   NOT NULL,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+
+    UNIQUE (stop_id, fee_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_terminal_fee_charges_order ON terminal_fee_charges(order_id, occurred_at);
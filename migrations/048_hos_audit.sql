@@ -0,0 +1,17 @@
+-- ==============================================================================
+-- Migration 048: Daily log certifications for the HOS audit report
+-- ==============================================================================
+-- Drivers certify each day's record of duty status (49 CFR 395.30). The
+-- safety department's HOS audit lists days left uncertified, or edited after
+-- they were certified, alongside violations, edits and unidentified driving.
+
+CREATE TABLE IF NOT EXISTS hos_log_certifications (
+    id            UUID        PRIMARY KEY,
+    driver_id     UUID        NOT NULL REFERENCES drivers(id) ON DELETE CASCADE,
+    log_date      DATE        NOT NULL,
+    certified_at  TIMESTAMPTZ NOT NULL,
+    source        VARCHAR(20) NOT NULL DEFAULT 'manual',
+    UNIQUE (driver_id, log_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_eld_segments_start ON eld_driving_segments(start_time);
@@ -0,0 +1,11 @@
+-- ==============================================================================
+-- Migration 049: Blind index for driver phone lookups
+-- ==============================================================================
+-- drivers.phone is envelope-encrypted (migration 021), so it can no longer be
+-- matched in SQL. driver-service stores an HMAC of the normalized E.164
+-- number in phone_index, which inbound SMS uses to find the sender. Rows
+-- written before this migration are indexed by the PII rotation job.
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS phone_index TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_drivers_phone_index ON drivers(phone_index) WHERE phone_index <> '';
@@ -0,0 +1,13 @@
+-- ==============================================================================
+-- Migration 050: Payee tax ID encryption and recorded backup withholding
+-- ==============================================================================
+-- billing-service stores payee_entities.tax_id envelope-encrypted
+-- ("enc:v1:<key id>:..."), which needs a wider column. Existing plaintext
+-- values stay readable and are sealed the next time the payee is saved.
+--
+-- Settlements record the backup withholding actually taken from each
+-- payment, which 1099-NEC box 4 reports instead of an estimate.
+
+ALTER TABLE payee_entities ALTER COLUMN tax_id TYPE TEXT;
+
+ALTER TABLE driver_settlements ADD COLUMN IF NOT EXISTS federal_tax_withheld DECIMAL(10,2) NOT NULL DEFAULT 0;
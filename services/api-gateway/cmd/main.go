@@ -0,0 +1,130 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	dispatchv1 "github.com/draymaster/shared/proto/dispatch/v1"
+	orderv1 "github.com/draymaster/shared/proto/order/v1"
+	trackingv1 "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/api-gateway/internal/api"
+	"github.com/draymaster/services/api-gateway/internal/rest"
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "api-gateway"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting API gateway",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	// gRPC connections — dialed lazily, so the gateway starts before the services
+	orderConn := dial(log, "order-service", getEnv("ORDER_SERVICE_ADDR", "localhost:9091"))
+	defer orderConn.Close()
+	dispatchConn := dial(log, "dispatch-service", getEnv("DISPATCH_SERVICE_ADDR", "localhost:9092"))
+	defer dispatchConn.Close()
+	trackingConn := dial(log, "tracking-service", getEnv("TRACKING_SERVICE_ADDR", "localhost:9093"))
+	defer trackingConn.Close()
+
+	// The services authorize every call; the gateway verifies tokens only
+	// to decide which GraphQL fields a caller may read
+	var verifier *auth.Verifier
+	if cfg.Auth.Enabled {
+		verifier = auth.NewVerifier(cfg.Auth)
+	}
+
+	handler := api.NewHandler(
+		orderv1.NewOrderServiceClient(orderConn),
+		dispatchv1.NewDispatchServiceClient(dispatchConn),
+		dispatchv1.NewDispatchCRUDServiceClient(dispatchConn),
+		trackingv1.NewTrackingServiceClient(trackingConn),
+		verifier,
+		getDuration("UPSTREAM_TIMEOUT", 15*time.Second),
+		log,
+	)
+
+	// HTTP server — browsers on the web app's origin may call it directly
+	origins := strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"), ",")
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      rest.CORS(origins, handler.Routes()),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("API gateway stopped")
+}
+
+func dial(log *logger.Logger, name, addr string) *grpc.ClientConn {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalw("Invalid gRPC address", "service", name, "addr", addr, "error", err)
+	}
+	log.Infow("gRPC client created", "service", name, "addr", addr)
+	return conn
+}
+
+func getEnv(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+func getDuration(key string, defaultVal time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return defaultVal
+}
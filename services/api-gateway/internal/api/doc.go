@@ -0,0 +1,10 @@
+// Package api serves the gateway's REST and GraphQL routes over the
+// order, dispatch and tracking gRPC clients. It needs the Go code
+// generated from shared/proto by make proto, which is not checked in, so
+// its files build only with the grpc tag:
+//
+//	make proto && go build -tags grpc ./...
+//
+// The routing, binding and batching it builds on live in internal/rest and
+// internal/dataloader, which build and test without it.
+package api
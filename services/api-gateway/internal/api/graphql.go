@@ -0,0 +1,63 @@
+//go:build grpc
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/draymaster/services/api-gateway/internal/graph"
+	"github.com/draymaster/services/api-gateway/internal/graphql"
+	"github.com/draymaster/services/api-gateway/internal/rest"
+	"github.com/draymaster/shared/pkg/auth"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// graphQL serves the web app's GraphQL schema. The services still check
+// every call they answer; the gateway verifies the token itself only to
+// null out the fields a caller's roles do not cover.
+func (h *Handler) graphQL() http.Handler {
+	resolver := graph.NewResolver(h.orders, h.dispatch, h.tripAdmin, h.tracking, h.verifier != nil)
+	schema, err := resolver.Schema()
+	if err != nil {
+		h.logger.Fatalw("Invalid GraphQL schema", "error", err)
+	}
+
+	serve := graphql.NewHandler(schema, func(r *http.Request) (context.Context, error) {
+		ctx := rest.OutgoingContext(r.Context(), r)
+		if h.verifier != nil {
+			id, err := h.authenticate(r)
+			if err != nil {
+				return nil, err
+			}
+			ctx = auth.WithIdentity(ctx, id)
+		}
+		return resolver.WithLoaders(ctx), nil
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+		defer cancel()
+		serve.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate verifies the request's bearer token, answering as the
+// services' interceptor would
+func (h *Handler) authenticate(r *http.Request) (*auth.Identity, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, apperrors.New("UNAUTHORIZED", "missing bearer token")
+	}
+	id, err := h.verifier.Verify(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidToken) {
+			h.logger.Errorw("Token verification failed", "path", r.URL.Path, "error", err)
+			return nil, apperrors.New("SERVICE_UNAVAILABLE", "cannot verify token")
+		}
+		h.logger.Infow("Rejected token", "path", r.URL.Path, "reason", err.Error())
+		return nil, apperrors.New("UNAUTHORIZED", "invalid or expired token")
+	}
+	return id, nil
+}
@@ -0,0 +1,176 @@
+//go:build grpc
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	dispatchv1 "github.com/draymaster/shared/proto/dispatch/v1"
+	orderv1 "github.com/draymaster/shared/proto/order/v1"
+	trackingv1 "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/api-gateway/internal/rest"
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Handler serves the REST+JSON API in front of the order, dispatch and
+// tracking gRPC services
+type Handler struct {
+	orders    orderv1.OrderServiceClient
+	dispatch  dispatchv1.DispatchServiceClient
+	tripAdmin dispatchv1.DispatchCRUDServiceClient
+	tracking  trackingv1.TrackingServiceClient
+	verifier  *auth.Verifier
+	timeout   time.Duration
+	logger    *logger.Logger
+}
+
+// NewHandler creates a new gateway HTTP handler. Calls to the services are
+// cut off after timeout. verifier checks tokens for GraphQL field access;
+// nil when auth is disabled.
+func NewHandler(orders orderv1.OrderServiceClient, dispatch dispatchv1.DispatchServiceClient, tripAdmin dispatchv1.DispatchCRUDServiceClient, tracking trackingv1.TrackingServiceClient, verifier *auth.Verifier, timeout time.Duration, log *logger.Logger) *Handler {
+	return &Handler{orders: orders, dispatch: dispatch, tripAdmin: tripAdmin, tracking: tracking, verifier: verifier, timeout: timeout, logger: log}
+}
+
+// Routes returns the HTTP routes for the API. Request bodies and responses
+// are the gRPC messages as JSON with their proto field names; path and
+// query parameters set request fields of the same name. The Authorization
+// bearer token is passed on to the services, which check it; X-User-ID is
+// passed on as the x-user-id metadata but a valid token overrides it.
+//
+// Shipments and containers (order-service):
+//
+//	GET|POST            /v1/shipments                 (?type=&status=&customer_id=&lfd_before=&lfd_after=&page=&page_size=)
+//	GET|PATCH           /v1/shipments/{id}
+//	POST                /v1/shipments/{shipment_id}/containers
+//	POST                /v1/shipments/{shipment_id}/orders (generate the shipment's orders)
+//	GET                 /v1/containers/availability   (?container_ids=)
+//	GET|PATCH           /v1/containers/{id}           (PATCH sets customs, state, location or available date)
+//
+// Orders (order-service):
+//
+//	GET|POST            /v1/orders                    (?shipment_id=&customer_id=&status=&type=&date_from=&date_to=&tags=&match_all_tags=&page=&page_size=)
+//	POST                /v1/orders/rate-quote         expected revenue at the customer's contract rates
+//	GET                 /v1/orders/{id}
+//	POST                /v1/orders/{id}/status
+//
+// Trips (dispatch-service):
+//
+//	GET|POST            /v1/trips                     (?status=&type=&driver_id=&customer_id=&date_from=&date_to=&trip_number=&tags=&page=&page_size=&sort_by=&sort_order=)
+//	GET                 /v1/trips/active
+//	GET                 /v1/trips/unassigned
+//	GET                 /v1/trips/search              (?query=&limit=)
+//	GET                 /v1/trips/statistics          (?start_date=&end_date=)
+//	GET|PATCH           /v1/trips/{id}                (GET includes stops and orders; PATCH takes the version edited)
+//	DELETE              /v1/trips/{trip_id}
+//	GET                 /v1/trips/{id}/cost
+//	POST                /v1/trips/{id}/status
+//	POST                /v1/trips/{trip_id}/assign
+//	POST                /v1/trips/{trip_id}/unassign
+//	POST                /v1/trips/{trip_id}/dispatch
+//	POST                /v1/trips/{trip_id}/cancel
+//	PATCH               /v1/trips/{trip_id}/stops/{stop_id}
+//	POST                /v1/trips/{trip_id}/stops/{stop_id}/status
+//	POST                /v1/trips/{trip_id}/stops/{stop_id}/arrive
+//	POST                /v1/trips/{trip_id}/stops/{stop_id}/complete
+//	POST                /v1/trips/{trip_id}/stops/{stop_id}/skip
+//	GET                 /v1/dispatch-board            (?date=&dispatcher_id=)
+//
+// Drivers (dispatch-service and tracking-service):
+//
+//	GET                 /v1/drivers/availability      (?pickup_latitude=&pickup_longitude=&pickup_time=&required_drive_minutes=&require_twic=&terminal_id=)
+//	GET                 /v1/drivers/{driver_id}/trips (?date_from=&date_to=)
+//	GET                 /v1/drivers/{driver_id}/location
+//	GET|POST            /v1/drivers/{driver_id}/locations (GET ?start_time=&end_time=&interval_seconds=; POST records a position)
+//
+// Tracking (tracking-service):
+//
+//	GET                 /v1/fleet/locations           (?driver_ids=&status_filter=)
+//	GET                 /v1/trips/{trip_id}/eta
+//	GET|POST            /v1/trips/{trip_id}/milestones
+//	GET                 /v1/trips/{trip_id}/locations (?start_time=&end_time=&interval_seconds=)
+//	GET                 /v1/containers/{container_id}/location
+//	GET                 /v1/containers/{container_id}/history (?start_time=&end_time=)
+//	POST                /v1/eta                       (origin and destination coordinates)
+//
+// GraphQL, for the web app:
+//
+//	GET|POST            /graphql                      queries over trips and orders with their stops,
+//	                                                  locations, geofences, drivers and orders
+//
+// Errors are the service's application error, {code, message, details},
+// with the HTTP status the service's own REST API would answer it with.
+// Streaming methods are only served over gRPC.
+func (h *Handler) Routes() http.Handler {
+	r := rest.NewRouter(h.timeout, h.logger)
+
+	// Shipments and containers
+	r.Handle(http.MethodGet, "/v1/shipments", rest.Unary(h.orders.ListShipments))
+	r.Handle(http.MethodPost, "/v1/shipments", rest.Unary(h.orders.CreateShipment))
+	r.Handle(http.MethodGet, "/v1/shipments/{id}", rest.Unary(h.orders.GetShipment))
+	r.Handle(http.MethodPatch, "/v1/shipments/{id}", rest.Unary(h.orders.UpdateShipment))
+	r.Handle(http.MethodPost, "/v1/shipments/{shipment_id}/containers", rest.Unary(h.orders.AddContainers))
+	r.Handle(http.MethodPost, "/v1/shipments/{shipment_id}/orders", rest.Unary(h.orders.GenerateOrdersFromShipment))
+	r.Handle(http.MethodGet, "/v1/containers/availability", rest.Unary(h.orders.CheckContainerAvailability))
+	r.Handle(http.MethodGet, "/v1/containers/{id}", rest.Unary(h.orders.GetContainer))
+	r.Handle(http.MethodPatch, "/v1/containers/{id}", rest.Unary(h.orders.UpdateContainerStatus))
+
+	// Orders
+	r.Handle(http.MethodGet, "/v1/orders", rest.Unary(h.orders.ListOrders))
+	r.Handle(http.MethodPost, "/v1/orders", rest.Unary(h.orders.CreateOrder))
+	r.Handle(http.MethodPost, "/v1/orders/rate-quote", rest.Unary(h.orders.GetRateQuote))
+	r.Handle(http.MethodGet, "/v1/orders/{id}", rest.Unary(h.orders.GetOrder))
+	r.Handle(http.MethodPost, "/v1/orders/{id}/status", rest.Unary(h.orders.UpdateOrderStatus))
+
+	// Trips
+	r.Handle(http.MethodGet, "/v1/trips", rest.Unary(h.dispatch.ListTrips))
+	r.Handle(http.MethodPost, "/v1/trips", rest.Unary(h.dispatch.CreateTrip))
+	r.Handle(http.MethodGet, "/v1/trips/active", rest.Unary(h.tripAdmin.GetActiveTrips))
+	r.Handle(http.MethodGet, "/v1/trips/unassigned", rest.Unary(h.tripAdmin.GetUnassignedTrips))
+	r.Handle(http.MethodGet, "/v1/trips/search", rest.Unary(h.tripAdmin.SearchTrips))
+	r.Handle(http.MethodGet, "/v1/trips/statistics", rest.Unary(h.tripAdmin.GetTripStatistics))
+	r.Handle(http.MethodGet, "/v1/trips/{id}", rest.Unary(h.tripAdmin.GetTripWithDetails))
+	r.Handle(http.MethodPatch, "/v1/trips/{id}", rest.Unary(h.dispatch.UpdateTrip))
+	r.Handle(http.MethodDelete, "/v1/trips/{trip_id}", rest.Unary(h.tripAdmin.DeleteTrip))
+	r.Handle(http.MethodGet, "/v1/trips/{id}/cost", rest.Unary(h.dispatch.GetTripCost))
+	r.Handle(http.MethodPost, "/v1/trips/{id}/status", rest.Unary(h.dispatch.UpdateTripStatus))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/assign", rest.Unary(h.dispatch.AssignDriver))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/unassign", rest.Unary(h.dispatch.UnassignDriver))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/dispatch", rest.Unary(h.dispatch.DispatchTrip))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/cancel", rest.Unary(h.tripAdmin.CancelTrip))
+	r.Handle(http.MethodPatch, "/v1/trips/{trip_id}/stops/{stop_id}", rest.Unary(h.tripAdmin.UpdateStop))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/stops/{stop_id}/status", rest.Unary(h.dispatch.UpdateStopStatus))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/stops/{stop_id}/arrive", rest.Unary(h.dispatch.RecordStopArrival))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/stops/{stop_id}/complete", rest.Unary(h.dispatch.CompleteStop))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/stops/{stop_id}/skip", rest.Unary(h.tripAdmin.SkipStop))
+	r.Handle(http.MethodGet, "/v1/dispatch-board", rest.Unary(h.dispatch.GetDispatchBoard))
+
+	// Drivers
+	r.Handle(http.MethodGet, "/v1/drivers/availability", rest.Unary(h.dispatch.GetDriverAvailability))
+	r.Handle(http.MethodGet, "/v1/drivers/{driver_id}/trips", rest.Unary(h.tripAdmin.GetTripsByDriver))
+	r.Handle(http.MethodGet, "/v1/drivers/{driver_id}/location", rest.Unary(h.tracking.GetCurrentLocation))
+	r.Handle(http.MethodGet, "/v1/drivers/{driver_id}/locations", rest.Unary(h.tracking.GetLocationHistory))
+	r.Handle(http.MethodPost, "/v1/drivers/{driver_id}/locations", rest.Unary(h.tracking.RecordLocation))
+
+	// Tracking
+	r.Handle(http.MethodGet, "/v1/fleet/locations", rest.Unary(h.tracking.GetFleetLocations))
+	r.Handle(http.MethodGet, "/v1/trips/{trip_id}/eta", rest.Unary(h.tracking.GetTripETA))
+	r.Handle(http.MethodGet, "/v1/trips/{trip_id}/milestones", rest.Unary(h.tracking.GetTripMilestones))
+	r.Handle(http.MethodPost, "/v1/trips/{trip_id}/milestones", rest.Unary(h.tracking.RecordMilestone))
+	r.Handle(http.MethodGet, "/v1/trips/{trip_id}/locations", rest.Unary(h.tracking.GetLocationHistory))
+	r.Handle(http.MethodGet, "/v1/containers/{container_id}/location", rest.Unary(h.tracking.GetContainerLocation))
+	r.Handle(http.MethodGet, "/v1/containers/{container_id}/history", rest.Unary(h.tracking.GetContainerHistory))
+	r.Handle(http.MethodPost, "/v1/eta", rest.Unary(h.tracking.CalculateETA))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+	})
+	mux.Handle("/v1/", r)
+	mux.Handle("/graphql", h.graphQL())
+	return mux
+}
@@ -0,0 +1,132 @@
+// Package dataloader batches lookups by key. Loads made within a short
+// window of each other go to the backend as one call, and each key is
+// fetched at most once for the life of the loader, so a loader belongs to
+// one request.
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches values for keys. Keys it leaves out of the map load as
+// the zero value; an error fails every key in the batch.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches loads of V by K
+type Loader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*result[V]
+	pending *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+// New creates a loader whose batches run with ctx. A batch goes out wait
+// after its first load, or as soon as it holds maxBatch keys.
+func New[K comparable, V any](ctx context.Context, fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value for key
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return l.await(ctx, l.enqueue(key))
+}
+
+// LoadMany returns the values for keys, in order
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	results := make([]*result[V], len(keys))
+	for i, key := range keys {
+		results[i] = l.enqueue(key)
+	}
+	values := make([]V, len(keys))
+	for i, r := range results {
+		value, err := l.await(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (l *Loader[K, V]) enqueue(key K) *result[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.cache[key]; ok {
+		return r
+	}
+	r := &result[V]{done: make(chan struct{})}
+	l.cache[key] = r
+
+	if l.pending == nil {
+		b := &batch[K, V]{}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.run(b)
+	}
+	return r
+}
+
+// dispatch sends a batch whose window closed, unless it already went out
+// full
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+	l.run(b)
+}
+
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	values, err := l.fetch(l.ctx, b.keys)
+	for i, r := range b.results {
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[b.keys[i]]
+		}
+		close(r.done)
+	}
+}
+
+func (l *Loader[K, V]) await(ctx context.Context, r *result[V]) (V, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
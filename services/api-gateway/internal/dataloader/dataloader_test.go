@@ -0,0 +1,131 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a batch function that remembers the batches it was sent
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	batch := append([]int(nil), keys...)
+	sort.Ints(batch)
+	r.batches = append(r.batches, batch)
+	r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]string, len(keys))
+	for _, k := range keys {
+		if k >= 0 {
+			values[k] = string(rune('a' + k))
+		}
+	}
+	return values, nil
+}
+
+func TestLoader_BatchesConcurrentLoads(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), rec.fetch, 5*time.Millisecond, 100)
+
+	var wg sync.WaitGroup
+	got := make([]string, 4)
+	for i, key := range []int{0, 1, 2, 1} {
+		wg.Add(1)
+		go func(i, key int) {
+			defer wg.Done()
+			value, err := loader.Load(context.Background(), key)
+			if err != nil {
+				t.Errorf("load %d: %v", key, err)
+			}
+			got[i] = value
+		}(i, key)
+	}
+	wg.Wait()
+
+	if want := []string{"a", "b", "c", "b"}; !equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if len(rec.batches) != 1 || len(rec.batches[0]) != 3 {
+		t.Errorf("expected one batch of the 3 distinct keys, got %v", rec.batches)
+	}
+}
+
+func TestLoader_CachesAndLeavesOutUnknownKeys(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), rec.fetch, time.Millisecond, 100)
+	ctx := context.Background()
+
+	values, err := loader.LoadMany(ctx, []int{3, -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values[0] != "d" || values[1] != "" {
+		t.Errorf("expected [d \"\"], got %q", values)
+	}
+	if value, _ := loader.Load(ctx, 3); value != "d" {
+		t.Errorf("expected the cached d, got %q", value)
+	}
+	if len(rec.batches) != 1 {
+		t.Errorf("expected cached keys not to be fetched again, got batches %v", rec.batches)
+	}
+}
+
+func TestLoader_SplitsAtMaxBatch(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), rec.fetch, time.Hour, 2)
+
+	// Both batches fill up, so neither waits out the hour-long window
+	values, err := loader.LoadMany(context.Background(), []int{0, 1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values[3] != "d" {
+		t.Errorf("expected d, got %q", values[3])
+	}
+	if len(rec.batches) != 2 {
+		t.Errorf("expected 2 full batches, got %v", rec.batches)
+	}
+}
+
+func TestLoader_BatchErrorFailsEveryKey(t *testing.T) {
+	rec := &recorder{err: errors.New("upstream down")}
+	loader := New(context.Background(), rec.fetch, time.Millisecond, 100)
+
+	if _, err := loader.LoadMany(context.Background(), []int{0, 1}); err == nil || err.Error() != "upstream down" {
+		t.Errorf("expected the batch error, got %v", err)
+	}
+}
+
+func TestLoader_LoadStopsWithContext(t *testing.T) {
+	rec := &recorder{}
+	loader := New(context.Background(), rec.fetch, time.Hour, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := loader.Load(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
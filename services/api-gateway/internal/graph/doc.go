@@ -0,0 +1,11 @@
+// Package graph is the gateway's GraphQL schema for the web app: trips and
+// orders with their stops, locations, geofences and drivers, composed from
+// the services' gRPC APIs. Nested objects load through per-request
+// dataloaders, so a query costs one call per level rather than one per
+// object. Fields the caller's roles do not cover come back null with a
+// FORBIDDEN error.
+//
+// The schema's resolvers need the Go code generated from shared/proto by
+// make proto, which is not checked in, so they build only with the grpc
+// tag; the scalars and paging helpers build without it.
+package graph
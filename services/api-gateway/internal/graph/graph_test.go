@@ -0,0 +1,321 @@
+//go:build grpc
+
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	dispatchv1 "github.com/draymaster/shared/proto/dispatch/v1"
+	orderv1 "github.com/draymaster/shared/proto/order/v1"
+	trackingv1 "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/api-gateway/internal/graphql"
+	"github.com/draymaster/shared/pkg/auth"
+)
+
+const (
+	trip1     = "00000000-0000-0000-0000-0000000000a1"
+	trip2     = "00000000-0000-0000-0000-0000000000a2"
+	driver1   = "00000000-0000-0000-0000-0000000000d1"
+	location1 = "00000000-0000-0000-0000-0000000000c1"
+	geofence1 = "00000000-0000-0000-0000-0000000000f1"
+	order1    = "00000000-0000-0000-0000-0000000000b1"
+	order2    = "00000000-0000-0000-0000-0000000000b2"
+)
+
+// fakeServices answers the gRPC calls the resolver makes from fixed data
+// and records the IDs of each batch lookup. Embedding the client
+// interfaces leaves every other method nil, so a stray call panics.
+type fakeServices struct {
+	dispatchv1.DispatchServiceClient
+	dispatchv1.DispatchCRUDServiceClient
+	trackingv1.TrackingServiceClient
+	orderv1.OrderServiceClient
+
+	mu    sync.Mutex
+	calls map[string][][]string
+}
+
+func newFakeServices() *fakeServices {
+	return &fakeServices{calls: make(map[string][][]string)}
+}
+
+func (f *fakeServices) record(method string, ids ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	f.calls[method] = append(f.calls[method], sorted)
+}
+
+func (f *fakeServices) batches(method string) [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+var fakeTrips = map[string]*dispatchv1.Trip{
+	trip1: {Id: trip1, TripNumber: "TRP-1", Status: dispatchv1.TripStatus_TRIP_STATUS_DISPATCHED, DriverId: driver1, OrderIds: []string{order1, order2}},
+	trip2: {Id: trip2, TripNumber: "TRP-2", Status: dispatchv1.TripStatus_TRIP_STATUS_PLANNED, DriverId: driver1},
+}
+
+func (f *fakeServices) GetTrip(ctx context.Context, in *dispatchv1.GetTripRequest, opts ...grpc.CallOption) (*dispatchv1.Trip, error) {
+	f.record("GetTrip", in.GetId())
+	if trip, ok := fakeTrips[in.GetId()]; ok {
+		return trip, nil
+	}
+	if in.GetId() == "00000000-0000-0000-0000-00000000dead" {
+		return nil, status.Error(codes.Unavailable, "dispatch is down")
+	}
+	return nil, status.Error(codes.NotFound, "trip not found")
+}
+
+func (f *fakeServices) ListTrips(ctx context.Context, in *dispatchv1.ListTripsRequest, opts ...grpc.CallOption) (*dispatchv1.ListTripsResponse, error) {
+	f.record("ListTrips")
+	return &dispatchv1.ListTripsResponse{Trips: []*dispatchv1.Trip{fakeTrips[trip1], fakeTrips[trip2]}, Total: 2}, nil
+}
+
+func (f *fakeServices) GetTripStops(ctx context.Context, in *dispatchv1.GetTripStopsRequest, opts ...grpc.CallOption) (*dispatchv1.GetTripStopsResponse, error) {
+	f.record("GetTripStops", in.GetTripIds()...)
+	var stops []*dispatchv1.TripStop
+	for _, id := range in.GetTripIds() {
+		if id == trip1 {
+			stops = append(stops,
+				&dispatchv1.TripStop{Id: "00000000-0000-0000-0000-0000000000e1", TripId: trip1, Sequence: 1, LocationId: location1, OrderId: order1},
+				&dispatchv1.TripStop{Id: "00000000-0000-0000-0000-0000000000e2", TripId: trip1, Sequence: 2, LocationId: location1})
+		}
+	}
+	return &dispatchv1.GetTripStopsResponse{Stops: stops}, nil
+}
+
+func (f *fakeServices) GetLocations(ctx context.Context, in *dispatchv1.GetLocationsRequest, opts ...grpc.CallOption) (*dispatchv1.GetLocationsResponse, error) {
+	f.record("GetLocations", in.GetIds()...)
+	return &dispatchv1.GetLocationsResponse{Locations: []*dispatchv1.Location{
+		{Id: location1, Name: "APM Terminal", GeofenceId: geofence1},
+	}}, nil
+}
+
+func (f *fakeServices) GetDrivers(ctx context.Context, in *dispatchv1.GetDriversRequest, opts ...grpc.CallOption) (*dispatchv1.GetDriversResponse, error) {
+	f.record("GetDrivers", in.GetIds()...)
+	return &dispatchv1.GetDriversResponse{Drivers: []*dispatchv1.Driver{
+		{Id: driver1, Name: "Ana Ruiz", Phone: "+15555550100", AvailableDriveMinutes: 420},
+	}}, nil
+}
+
+func (f *fakeServices) GetGeofences(ctx context.Context, in *trackingv1.GetGeofencesRequest, opts ...grpc.CallOption) (*trackingv1.GetGeofencesResponse, error) {
+	f.record("GetGeofences", in.GetIds()...)
+	return &trackingv1.GetGeofencesResponse{Geofences: []*trackingv1.Geofence{
+		{Id: geofence1, Name: "APM gate", IsActive: true},
+	}}, nil
+}
+
+func (f *fakeServices) GetOrder(ctx context.Context, in *orderv1.GetOrderRequest, opts ...grpc.CallOption) (*orderv1.Order, error) {
+	f.record("GetOrder", in.GetId())
+	if in.GetId() == order1 {
+		return &orderv1.Order{Id: order1, OrderNumber: "ORD-1"}, nil
+	}
+	return nil, status.Error(codes.NotFound, "order not found")
+}
+
+// run executes query as a caller holding roles; nil roles is a request
+// with no identity at all
+func run(t *testing.T, f *fakeServices, enforceRoles bool, roles []auth.Role, query string) (string, []*graphql.Error) {
+	t.Helper()
+	r := NewResolver(f, f, f, f, enforceRoles)
+	schema, err := r.Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	ctx := context.Background()
+	if roles != nil {
+		ctx = auth.WithIdentity(ctx, &auth.Identity{Subject: "user-1", Roles: roles})
+	}
+	result := schema.Execute(r.WithLoaders(ctx), graphql.Params{Query: query})
+	if result.RequestFailed() {
+		t.Fatalf("query rejected: %v", result.Errors[0])
+	}
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), result.Errors
+}
+
+func TestResolverNestedFieldsBatch(t *testing.T) {
+	f := newFakeServices()
+	data, errs := run(t, f, false, nil, `{
+		trips { edges { node {
+			tripNumber status
+			driver { name }
+			stops { sequence location { name geofence { name } } order { orderNumber } }
+			orders { orderNumber }
+		} } }
+	}`)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %v", errs[0])
+	}
+	want := `{"trips":{"edges":[` +
+		`{"node":{"tripNumber":"TRP-1","status":"DISPATCHED","driver":{"name":"Ana Ruiz"},"stops":[` +
+		`{"sequence":1,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}},"order":{"orderNumber":"ORD-1"}},` +
+		`{"sequence":2,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}},"order":null}],` +
+		`"orders":[{"orderNumber":"ORD-1"}]}},` +
+		`{"node":{"tripNumber":"TRP-2","status":"PLANNED","driver":{"name":"Ana Ruiz"},"stops":[],"orders":[]}}]}}`
+	if data != want {
+		t.Errorf("data =\n%s\nwant\n%s", data, want)
+	}
+
+	// One call per level, with the IDs of every object at that level
+	for method, want := range map[string][]string{
+		"GetTripStops": {trip1, trip2},
+		"GetDrivers":   {driver1},
+		"GetLocations": {location1},
+		"GetGeofences": {geofence1},
+	} {
+		batches := f.batches(method)
+		if len(batches) != 1 || len(batches[0]) != len(want) {
+			t.Errorf("%s batches = %v, want one of %v", method, batches, want)
+		}
+	}
+	// order-service has no batch lookup, but each order is fetched once
+	if got := len(f.batches("GetOrder")); got != 2 {
+		t.Errorf("GetOrder calls = %d, want 2", got)
+	}
+}
+
+func TestResolverFieldAuthorization(t *testing.T) {
+	query := `{ trip(id: "` + trip1 + `") {
+		tripNumber
+		driver { name phone availableDriveMins }
+		stops { sequence location { name geofence { name } } }
+	} }`
+
+	tests := []struct {
+		name         string
+		enforceRoles bool
+		roles        []auth.Role
+		want         string
+		forbidden    []string // Paths of the FORBIDDEN errors
+		uncalled     []string // Lookups a denied field must not reach
+	}{
+		{
+			name:         "dispatcher sees everything",
+			enforceRoles: true,
+			roles:        []auth.Role{auth.RoleDispatcher},
+			want:         `{"trip":{"tripNumber":"TRP-1","driver":{"name":"Ana Ruiz","phone":"+15555550100","availableDriveMins":420},"stops":[{"sequence":1,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}},{"sequence":2,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}}]}}`,
+		},
+		{
+			name:         "admin sees everything",
+			enforceRoles: true,
+			roles:        []auth.Role{auth.RoleAdmin},
+			want:         `{"trip":{"tripNumber":"TRP-1","driver":{"name":"Ana Ruiz","phone":"+15555550100","availableDriveMins":420},"stops":[{"sequence":1,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}},{"sequence":2,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}}]}}`,
+		},
+		{
+			name:         "billing loses phone, HOS and geofences",
+			enforceRoles: true,
+			roles:        []auth.Role{auth.RoleBilling},
+			want:         `{"trip":{"tripNumber":"TRP-1","driver":{"name":"Ana Ruiz","phone":null,"availableDriveMins":null},"stops":[{"sequence":1,"location":{"name":"APM Terminal","geofence":null}},{"sequence":2,"location":{"name":"APM Terminal","geofence":null}}]}}`,
+			forbidden: []string{
+				`["trip","driver","phone"]`,
+				`["trip","driver","availableDriveMins"]`,
+				`["trip","stops",0,"location","geofence"]`,
+				`["trip","stops",1,"location","geofence"]`,
+			},
+			uncalled: []string{"GetGeofences"},
+		},
+		{
+			name:         "driver cannot read trips",
+			enforceRoles: true,
+			roles:        []auth.Role{auth.RoleDriver},
+			want:         `{"trip":null}`,
+			forbidden:    []string{`["trip"]`},
+			uncalled:     []string{"GetTrip", "GetDrivers", "GetTripStops"},
+		},
+		{
+			name:         "no identity",
+			enforceRoles: true,
+			want:         `{"trip":null}`,
+			forbidden:    []string{`["trip"]`},
+			uncalled:     []string{"GetTrip", "GetDrivers", "GetTripStops"},
+		},
+		{
+			name: "auth off",
+			want: `{"trip":{"tripNumber":"TRP-1","driver":{"name":"Ana Ruiz","phone":"+15555550100","availableDriveMins":420},"stops":[{"sequence":1,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}},{"sequence":2,"location":{"name":"APM Terminal","geofence":{"name":"APM gate"}}}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeServices()
+			data, errs := run(t, f, tt.enforceRoles, tt.roles, query)
+			if data != tt.want {
+				t.Errorf("data =\n%s\nwant\n%s", data, tt.want)
+			}
+
+			var got []string
+			for _, e := range errs {
+				if e.Extensions["code"] != "FORBIDDEN" {
+					t.Errorf("unexpected error %q at %v", e.Message, e.Path)
+					continue
+				}
+				path, _ := json.Marshal(e.Path)
+				got = append(got, string(path))
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.forbidden...)
+			sort.Strings(want)
+			if len(got) != len(want) {
+				t.Fatalf("FORBIDDEN at %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("FORBIDDEN at %v, want %v", got, want)
+					break
+				}
+			}
+
+			for _, method := range tt.uncalled {
+				if batches := f.batches(method); len(batches) > 0 {
+					t.Errorf("%s called %d times for a caller without access", method, len(batches))
+				}
+			}
+		})
+	}
+}
+
+func TestResolverLookups(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		want     string
+		wantCode string
+	}{
+		{"found", `{ trip(id: "` + trip2 + `") { tripNumber } }`, `{"trip":{"tripNumber":"TRP-2"}}`, ""},
+		{"not found is null", `{ trip(id: "00000000-0000-0000-0000-000000000000") { tripNumber } }`, `{"trip":null}`, ""},
+		{"service error", `{ trip(id: "00000000-0000-0000-0000-00000000dead") { tripNumber } }`, `{"trip":null}`, "SERVICE_UNAVAILABLE"},
+		{"no key", `{ trip { tripNumber } }`, `{"trip":null}`, "VALIDATION_ERROR"},
+		{"missing order", `{ order(id: "` + order2 + `") { orderNumber } }`, `{"order":null}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errs := run(t, newFakeServices(), true, []auth.Role{auth.RoleDispatcher}, tt.query)
+			if data != tt.want {
+				t.Errorf("data = %s, want %s", data, tt.want)
+			}
+			switch {
+			case tt.wantCode == "" && len(errs) > 0:
+				t.Errorf("errors = %v, want none", errs[0])
+			case tt.wantCode != "" && (len(errs) != 1 || errs[0].Extensions["code"] != tt.wantCode):
+				t.Errorf("errors = %v, want one %s", errs, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+//go:build grpc
+
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dispatchv1 "github.com/draymaster/shared/proto/dispatch/v1"
+	orderv1 "github.com/draymaster/shared/proto/order/v1"
+	trackingv1 "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/api-gateway/internal/dataloader"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+)
+
+const (
+	// batchWait is how long a loader collects keys before calling the service
+	batchWait = 2 * time.Millisecond
+	// maxBatch is the most IDs the services' batch lookups take at once
+	maxBatch = 500
+	// orderLookups caps the GetOrder calls in flight for one batch of orders
+	orderLookups = 8
+)
+
+// loaders are one request's batched lookups
+type loaders struct {
+	stops     *dataloader.Loader[string, []*dispatchv1.TripStop]
+	locations *dataloader.Loader[string, *dispatchv1.Location]
+	drivers   *dataloader.Loader[string, *dispatchv1.Driver]
+	geofences *dataloader.Loader[string, *trackingv1.Geofence]
+	orders    *dataloader.Loader[string, *orderv1.Order]
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a context carrying fresh loaders for one request.
+// Their batches call the services with ctx, so it should carry the
+// caller's forwarded credentials and deadline.
+func (r *Resolver) WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		stops:     dataloader.New(ctx, r.fetchStops, batchWait, maxBatch),
+		locations: dataloader.New(ctx, r.fetchLocations, batchWait, maxBatch),
+		drivers:   dataloader.New(ctx, r.fetchDrivers, batchWait, maxBatch),
+		geofences: dataloader.New(ctx, r.fetchGeofences, batchWait, maxBatch),
+		orders:    dataloader.New(ctx, r.fetchOrders, batchWait, maxBatch),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (r *Resolver) fetchStops(ctx context.Context, tripIDs []string) (map[string][]*dispatchv1.TripStop, error) {
+	resp, err := r.tripAdmin.GetTripStops(ctx, &dispatchv1.GetTripStopsRequest{TripIds: tripIDs})
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	stops := make(map[string][]*dispatchv1.TripStop, len(tripIDs))
+	for _, stop := range resp.GetStops() {
+		stops[stop.GetTripId()] = append(stops[stop.GetTripId()], stop)
+	}
+	return stops, nil
+}
+
+func (r *Resolver) fetchLocations(ctx context.Context, ids []string) (map[string]*dispatchv1.Location, error) {
+	resp, err := r.tripAdmin.GetLocations(ctx, &dispatchv1.GetLocationsRequest{Ids: ids})
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	locations := make(map[string]*dispatchv1.Location, len(ids))
+	for _, location := range resp.GetLocations() {
+		locations[location.GetId()] = location
+	}
+	return locations, nil
+}
+
+func (r *Resolver) fetchDrivers(ctx context.Context, ids []string) (map[string]*dispatchv1.Driver, error) {
+	resp, err := r.tripAdmin.GetDrivers(ctx, &dispatchv1.GetDriversRequest{Ids: ids})
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	drivers := make(map[string]*dispatchv1.Driver, len(ids))
+	for _, driver := range resp.GetDrivers() {
+		drivers[driver.GetId()] = driver
+	}
+	return drivers, nil
+}
+
+func (r *Resolver) fetchGeofences(ctx context.Context, ids []string) (map[string]*trackingv1.Geofence, error) {
+	resp, err := r.tracking.GetGeofences(ctx, &trackingv1.GetGeofencesRequest{Ids: ids})
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	geofences := make(map[string]*trackingv1.Geofence, len(ids))
+	for _, geofence := range resp.GetGeofences() {
+		geofences[geofence.GetId()] = geofence
+	}
+	return geofences, nil
+}
+
+// fetchOrders looks orders up one call each, a few at a time, since
+// order-service has no batch lookup. Orders it cannot find are left out.
+func (r *Resolver) fetchOrders(ctx context.Context, ids []string) (map[string]*orderv1.Order, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		orders   = make(map[string]*orderv1.Order, len(ids))
+		firstErr error
+	)
+	sem := make(chan struct{}, orderLookups)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+			order, err := r.orders.GetOrder(ctx, &orderv1.GetOrderRequest{Id: id})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if appErr := grpcerrors.FromStatus(err); appErr.Code != "NOT_FOUND" && firstErr == nil {
+					firstErr = appErr
+				}
+				return
+			}
+			orders[id] = order
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return orders, nil
+}
@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/draymaster/services/api-gateway/internal/graphql"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// dateTime is an RFC 3339 timestamp. Arguments may also be a plain date,
+// taken as midnight UTC.
+var dateTime = &graphql.Scalar{
+	Name: "DateTime",
+	Serialize: func(value interface{}) (interface{}, error) {
+		switch t := value.(type) {
+		case *timestamppb.Timestamp:
+			return t.AsTime().UTC().Format(time.RFC3339), nil
+		case time.Time:
+			return t.UTC().Format(time.RFC3339), nil
+		}
+		return nil, fmt.Errorf("cannot represent %T as DateTime", value)
+	},
+	Parse: func(value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an RFC 3339 timestamp")
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%q is not an RFC 3339 timestamp or a YYYY-MM-DD date", s)
+	},
+}
+
+// uuidScalar is an ID in canonical UUID form
+var uuidScalar = &graphql.Scalar{
+	Name:      "UUID",
+	Serialize: graphql.String.Serialize,
+	Parse: func(value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok || !isUUID(s) {
+			return nil, fmt.Errorf("expected a UUID")
+		}
+		return strings.ToLower(s), nil
+	},
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'):
+			return false
+		}
+	}
+	return true
+}
+
+// protoEnum is a GraphQL enum over a proto enum, with the values named
+// without the proto prefix: TRIP_STATUS_COMPLETED is COMPLETED
+type protoEnum struct {
+	*graphql.Enum
+	prefix  string
+	numbers map[string]int32
+}
+
+// newProtoEnum builds an enum from a generated *_value map. The
+// UNSPECIFIED value is left out; fields holding it are null.
+func newProtoEnum(name string, values map[string]int32) *protoEnum {
+	e := &protoEnum{Enum: &graphql.Enum{Name: name}, numbers: values}
+	for protoName, number := range values {
+		if number == 0 {
+			e.prefix = strings.TrimSuffix(protoName, "UNSPECIFIED")
+		}
+	}
+	for protoName, number := range values {
+		if number != 0 {
+			e.Values = append(e.Values, strings.TrimPrefix(protoName, e.prefix))
+		}
+	}
+	sort.Slice(e.Values, func(i, j int) bool {
+		return values[e.prefix+e.Values[i]] < values[e.prefix+e.Values[j]]
+	})
+	return e
+}
+
+// enumField reads an enum field off a source of type S
+func enumField[S any, E interface{ String() string }](e *protoEnum, get func(S) E) *graphql.Field {
+	return &graphql.Field{Type: e.Enum, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		protoName := get(p.Source.(S)).String()
+		if e.numbers[protoName] == 0 {
+			return nil, nil
+		}
+		return strings.TrimPrefix(protoName, e.prefix), nil
+	}}
+}
+
+// number returns the proto value of an enum argument, or 0 when absent
+func (e *protoEnum) number(arg interface{}) int32 {
+	name, _ := arg.(string)
+	return e.numbers[e.prefix+name]
+}
+
+// The services page by number; connections page by cursor. A cursor is
+// the offset of an edge, so paging forward with the same first lands on
+// the services' pages.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var paginationInput = &graphql.InputObject{Name: "PaginationInput", Fields: graphql.Args{
+	"first": {Type: graphql.Int},    // Edges to return; 20 by default, at most 100
+	"after": {Type: graphql.String}, // The endCursor of the previous page
+}}
+
+// page reads a pagination argument as the services' page and page size,
+// and the offset of the page's first edge
+func page(arg interface{}) (pageNum, size int32, offset int, err error) {
+	args, _ := arg.(map[string]interface{})
+	size = defaultPageSize
+	if first, ok := args["first"].(int); ok {
+		if first < 1 || first > maxPageSize {
+			return 0, 0, 0, apperrors.ValidationError(fmt.Sprintf("first must be between 1 and %d", maxPageSize), "first", first)
+		}
+		size = int32(first)
+	}
+	if after, ok := args["after"].(string); ok {
+		last, err := decodeCursor(after)
+		if err != nil {
+			return 0, 0, 0, apperrors.ValidationError("after is not a cursor", "after", after)
+		}
+		offset = last + 1
+		if offset%int(size) != 0 {
+			return 0, 0, 0, apperrors.ValidationError("after must end a page of the same size as first", "after", after)
+		}
+	}
+	return int32(offset/int(size)) + 1, size, offset, nil
+}
+
+// connection builds a connection from one page of nodes
+func connection[T any](nodes []T, offset int, total int32) map[string]interface{} {
+	edges := make([]interface{}, len(nodes))
+	for i, node := range nodes {
+		edges[i] = map[string]interface{}{"node": node, "cursor": encodeCursor(offset + i)}
+	}
+	info := map[string]interface{}{
+		"hasNextPage":     offset+len(nodes) < int(total),
+		"hasPreviousPage": offset > 0,
+	}
+	if len(nodes) > 0 {
+		info["startCursor"] = encodeCursor(offset)
+		info["endCursor"] = encodeCursor(offset + len(nodes) - 1)
+	}
+	return map[string]interface{}{"edges": edges, "pageInfo": info, "totalCount": total}
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+var pageInfo = &graphql.Object{Name: "PageInfo", Fields: graphql.Fields{
+	"hasNextPage":     {Type: nonNull(graphql.Boolean)},
+	"hasPreviousPage": {Type: nonNull(graphql.Boolean)},
+	"startCursor":     {Type: graphql.String},
+	"endCursor":       {Type: graphql.String},
+}}
+
+// connectionType makes the Connection and Edge types for node
+func connectionType(node *graphql.Object) *graphql.Object {
+	edge := &graphql.Object{Name: node.Name + "Edge", Fields: graphql.Fields{
+		"node":   {Type: nonNull(node)},
+		"cursor": {Type: nonNull(graphql.String)},
+	}}
+	return &graphql.Object{Name: node.Name + "Connection", Fields: graphql.Fields{
+		"edges":      {Type: listOf(edge)},
+		"pageInfo":   {Type: nonNull(pageInfo)},
+		"totalCount": {Type: nonNull(graphql.Int)},
+	}}
+}
+
+func nonNull(t graphql.Type) graphql.Type { return graphql.NewNonNull(t) }
+
+func listOf(t graphql.Type) graphql.Type { return nonNull(graphql.NewList(nonNull(t))) }
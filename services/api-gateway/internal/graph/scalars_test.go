@@ -0,0 +1,53 @@
+package graph
+
+import "testing"
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		arg        interface{}
+		wantPage   int32
+		wantSize   int32
+		wantOffset int
+		wantErr    bool
+	}{
+		{"default", nil, 1, defaultPageSize, 0, false},
+		{"first", map[string]interface{}{"first": 10}, 1, 10, 0, false},
+		{"after a page", map[string]interface{}{"first": 10, "after": encodeCursor(9)}, 2, 10, 10, false},
+		{"after mid page", map[string]interface{}{"first": 10, "after": encodeCursor(4)}, 0, 0, 0, true},
+		{"too many", map[string]interface{}{"first": maxPageSize + 1}, 0, 0, 0, true},
+		{"none", map[string]interface{}{"first": 0}, 0, 0, 0, true},
+		{"bad cursor", map[string]interface{}{"after": "bm9wZQ"}, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pageNum, size, offset, err := page(tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("page() = %d, %d, %d, want error", pageNum, size, offset)
+				}
+				return
+			}
+			if err != nil || pageNum != tt.wantPage || size != tt.wantSize || offset != tt.wantOffset {
+				t.Errorf("page() = %d, %d, %d, %v, want %d, %d, %d", pageNum, size, offset, err, tt.wantPage, tt.wantSize, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestConnectionPageInfo(t *testing.T) {
+	conn := connection([]string{"a", "b"}, 2, 5)
+	info := conn["pageInfo"].(map[string]interface{})
+	if info["hasNextPage"] != true || info["hasPreviousPage"] != true {
+		t.Errorf("pageInfo = %v", info)
+	}
+	if end, _ := decodeCursor(info["endCursor"].(string)); end != 3 {
+		t.Errorf("endCursor offset = %d, want 3", end)
+	}
+
+	last := connection([]string{"e"}, 4, 5)["pageInfo"].(map[string]interface{})
+	if last["hasNextPage"] != false {
+		t.Errorf("last page hasNextPage = %v", last["hasNextPage"])
+	}
+}
@@ -0,0 +1,384 @@
+//go:build grpc
+
+package graph
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	dispatchv1 "github.com/draymaster/shared/proto/dispatch/v1"
+	orderv1 "github.com/draymaster/shared/proto/order/v1"
+	trackingv1 "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/api-gateway/internal/graphql"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+)
+
+// maxDepth is how deeply a query may nest, enough for
+// trips { edges { node { stops { location { geofence { id } } } } } }
+const maxDepth = 8
+
+var (
+	tripTypeEnum     = newProtoEnum("TripType", dispatchv1.TripType_value)
+	tripStatusEnum   = newProtoEnum("TripStatus", dispatchv1.TripStatus_value)
+	stopTypeEnum     = newProtoEnum("StopType", dispatchv1.StopType_value)
+	activityTypeEnum = newProtoEnum("ActivityType", dispatchv1.ActivityType_value)
+	stopStatusEnum   = newProtoEnum("StopStatus", dispatchv1.StopStatus_value)
+	orderTypeEnum    = newProtoEnum("OrderType", orderv1.OrderType_value)
+	orderStatusEnum  = newProtoEnum("OrderStatus", orderv1.OrderStatus_value)
+	geofenceTypeEnum = newProtoEnum("GeofenceType", trackingv1.GeofenceType_value)
+)
+
+// Schema builds the GraphQL schema. Queries only; writes go through the
+// REST API.
+func (r *Resolver) Schema() (*graphql.Schema, error) {
+	geofence := &graphql.Object{Name: "Geofence", Fields: graphql.Fields{
+		"id":              field(nonNull(uuidScalar), (*trackingv1.Geofence).GetId),
+		"locationId":      optional(uuidScalar, (*trackingv1.Geofence).GetLocationId),
+		"name":            field(nonNull(graphql.String), (*trackingv1.Geofence).GetName),
+		"type":            enumField(geofenceTypeEnum, (*trackingv1.Geofence).GetType),
+		"centerLatitude":  field(graphql.Float, (*trackingv1.Geofence).GetCenterLatitude),
+		"centerLongitude": field(graphql.Float, (*trackingv1.Geofence).GetCenterLongitude),
+		"radiusMeters":    field(graphql.Float, (*trackingv1.Geofence).GetRadiusMeters),
+		"isActive":        field(nonNull(graphql.Boolean), (*trackingv1.Geofence).GetIsActive),
+	}}
+
+	location := &graphql.Object{Name: "Location", Fields: graphql.Fields{
+		"id":           field(nonNull(uuidScalar), (*dispatchv1.Location).GetId),
+		"name":         field(nonNull(graphql.String), (*dispatchv1.Location).GetName),
+		"type":         optional(graphql.String, (*dispatchv1.Location).GetType),
+		"address":      optional(graphql.String, (*dispatchv1.Location).GetAddress),
+		"city":         optional(graphql.String, (*dispatchv1.Location).GetCity),
+		"state":        optional(graphql.String, (*dispatchv1.Location).GetState),
+		"zip":          optional(graphql.String, (*dispatchv1.Location).GetZip),
+		"latitude":     field(graphql.Float, (*dispatchv1.Location).GetLatitude),
+		"longitude":    field(graphql.Float, (*dispatchv1.Location).GetLongitude),
+		"contactName":  optional(graphql.String, (*dispatchv1.Location).GetContactName),
+		"contactPhone": optional(graphql.String, (*dispatchv1.Location).GetContactPhone),
+		"geofence": {
+			Type:      geofence,
+			Authorize: r.allow(dispatchers...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(*dispatchv1.Location).GetGeofenceId()
+				if id == "" {
+					return nil, nil
+				}
+				return loadersFrom(p.Context).geofences.Load(p.Context, id)
+			},
+		},
+	}}
+
+	driver := &graphql.Object{Name: "Driver", Fields: graphql.Fields{
+		"id":     field(nonNull(uuidScalar), (*dispatchv1.Driver).GetId),
+		"name":   field(nonNull(graphql.String), (*dispatchv1.Driver).GetName),
+		"status": optional(graphql.String, (*dispatchv1.Driver).GetStatus),
+		"phone": {
+			Type:      graphql.String,
+			Authorize: r.allow(dispatchers...),
+			Resolve:   optional(graphql.String, (*dispatchv1.Driver).GetPhone).Resolve,
+		},
+		// HOS clocks as dispatch last synced them from the ELD
+		"availableDriveMins": {
+			Type:      graphql.Int,
+			Authorize: r.allow(dispatchers...),
+			Resolve:   field(graphql.Int, (*dispatchv1.Driver).GetAvailableDriveMinutes).Resolve,
+		},
+		"availableDutyMins": {
+			Type:      graphql.Int,
+			Authorize: r.allow(dispatchers...),
+			Resolve:   field(graphql.Int, (*dispatchv1.Driver).GetAvailableDutyMinutes).Resolve,
+		},
+	}}
+
+	order := &graphql.Object{Name: "Order", Fields: graphql.Fields{
+		"id":                    field(nonNull(uuidScalar), (*orderv1.Order).GetId),
+		"orderNumber":           field(nonNull(graphql.String), (*orderv1.Order).GetOrderNumber),
+		"type":                  enumField(orderTypeEnum, (*orderv1.Order).GetType),
+		"status":                enumField(orderStatusEnum, (*orderv1.Order).GetStatus),
+		"moveType":              optional(graphql.String, (*orderv1.Order).GetMoveType),
+		"customerReference":     optional(graphql.String, (*orderv1.Order).GetCustomerReference),
+		"shipmentId":            optional(uuidScalar, (*orderv1.Order).GetShipmentId),
+		"containerId":           optional(uuidScalar, (*orderv1.Order).GetContainerId),
+		"requestedPickupDate":   field(dateTime, (*orderv1.Order).GetRequestedPickupDate),
+		"requestedDeliveryDate": field(dateTime, (*orderv1.Order).GetRequestedDeliveryDate),
+		"billingStatus":         optional(graphql.String, (*orderv1.Order).GetBillingStatus),
+		"specialInstructions":   optional(graphql.String, (*orderv1.Order).GetSpecialInstructions),
+		"tags":                  field(listOf(graphql.String), (*orderv1.Order).GetTags),
+		"createdAt":             field(dateTime, (*orderv1.Order).GetCreatedAt),
+		"updatedAt":             field(dateTime, (*orderv1.Order).GetUpdatedAt),
+	}}
+
+	stop := &graphql.Object{Name: "TripStop", Fields: graphql.Fields{
+		"id":                    field(nonNull(uuidScalar), (*dispatchv1.TripStop).GetId),
+		"sequence":              field(nonNull(graphql.Int), (*dispatchv1.TripStop).GetSequence),
+		"type":                  enumField(stopTypeEnum, (*dispatchv1.TripStop).GetType),
+		"activity":              enumField(activityTypeEnum, (*dispatchv1.TripStop).GetActivity),
+		"status":                enumField(stopStatusEnum, (*dispatchv1.TripStop).GetStatus),
+		"containerNumber":       optional(graphql.String, (*dispatchv1.TripStop).GetContainerNumber),
+		"appointmentTime":       field(dateTime, (*dispatchv1.TripStop).GetAppointmentTime),
+		"appointmentNumber":     optional(graphql.String, (*dispatchv1.TripStop).GetAppointmentNumber),
+		"appointmentWindowMins": field(graphql.Int, (*dispatchv1.TripStop).GetAppointmentWindowMinutes),
+		"plannedArrival":        field(dateTime, (*dispatchv1.TripStop).GetPlannedArrival),
+		"actualArrival":         field(dateTime, (*dispatchv1.TripStop).GetActualArrival),
+		"actualDeparture":       field(dateTime, (*dispatchv1.TripStop).GetActualDeparture),
+		"estimatedDurationMins": field(graphql.Int, (*dispatchv1.TripStop).GetEstimatedDurationMinutes),
+		"actualDurationMins":    field(graphql.Int, (*dispatchv1.TripStop).GetActualDurationMinutes),
+		"freeTimeMins":          field(graphql.Int, (*dispatchv1.TripStop).GetFreeTimeMinutes),
+		"detentionStartTime":    field(dateTime, (*dispatchv1.TripStop).GetDetentionStartTime),
+		"detentionMins":         field(graphql.Int, (*dispatchv1.TripStop).GetDetentionMinutes),
+		"gateTicketNumber":      optional(graphql.String, (*dispatchv1.TripStop).GetGateTicketNumber),
+		"sealNumber":            optional(graphql.String, (*dispatchv1.TripStop).GetSealNumber),
+		"failureReason":         optional(graphql.String, (*dispatchv1.TripStop).GetFailureReason),
+		"notes":                 optional(graphql.String, (*dispatchv1.TripStop).GetNotes),
+		"location": {
+			Type:      location,
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(*dispatchv1.TripStop).GetLocationId()
+				if id == "" {
+					return nil, nil
+				}
+				return loadersFrom(p.Context).locations.Load(p.Context, id)
+			},
+		},
+		"order": {
+			Type:      order,
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(*dispatchv1.TripStop).GetOrderId()
+				if id == "" {
+					return nil, nil
+				}
+				return loadersFrom(p.Context).orders.Load(p.Context, id)
+			},
+		},
+	}}
+
+	trip := &graphql.Object{Name: "Trip", Fields: graphql.Fields{
+		"id":                    field(nonNull(uuidScalar), (*dispatchv1.Trip).GetId),
+		"tripNumber":            field(nonNull(graphql.String), (*dispatchv1.Trip).GetTripNumber),
+		"type":                  enumField(tripTypeEnum, (*dispatchv1.Trip).GetType),
+		"status":                enumField(tripStatusEnum, (*dispatchv1.Trip).GetStatus),
+		"chassisId":             optional(uuidScalar, (*dispatchv1.Trip).GetChassisId),
+		"currentStopSequence":   field(graphql.Int, (*dispatchv1.Trip).GetCurrentStopSequence),
+		"plannedStartTime":      field(dateTime, (*dispatchv1.Trip).GetPlannedStartTime),
+		"actualStartTime":       field(dateTime, (*dispatchv1.Trip).GetActualStartTime),
+		"plannedEndTime":        field(dateTime, (*dispatchv1.Trip).GetPlannedEndTime),
+		"actualEndTime":         field(dateTime, (*dispatchv1.Trip).GetActualEndTime),
+		"estimatedDurationMins": field(graphql.Int, (*dispatchv1.Trip).GetEstimatedDurationMinutes),
+		"totalMiles":            field(graphql.Float, (*dispatchv1.Trip).GetTotalMiles),
+		"completedMiles":        field(graphql.Float, (*dispatchv1.Trip).GetCompletedMiles),
+		"isStreetTurn":          field(nonNull(graphql.Boolean), (*dispatchv1.Trip).GetIsStreetTurn),
+		"isDualTransaction":     field(nonNull(graphql.Boolean), (*dispatchv1.Trip).GetIsDualTransaction),
+		"tags":                  field(listOf(graphql.String), (*dispatchv1.Trip).GetTags),
+		"version":               field(graphql.Int, (*dispatchv1.Trip).GetVersion),
+		"createdBy":             optional(graphql.String, (*dispatchv1.Trip).GetCreatedBy),
+		"createdAt":             field(dateTime, (*dispatchv1.Trip).GetCreatedAt),
+		"updatedAt":             field(dateTime, (*dispatchv1.Trip).GetUpdatedAt),
+		"driver": {
+			Type:      driver,
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(*dispatchv1.Trip).GetDriverId()
+				if id == "" {
+					return nil, nil
+				}
+				return loadersFrom(p.Context).drivers.Load(p.Context, id)
+			},
+		},
+		"stops": {
+			Type:      listOf(stop),
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				stops, err := loadersFrom(p.Context).stops.Load(p.Context, p.Source.(*dispatchv1.Trip).GetId())
+				if stops == nil && err == nil {
+					stops = []*dispatchv1.TripStop{}
+				}
+				return stops, err
+			},
+		},
+		"orders": {
+			Type:      listOf(order),
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				orders, err := loadersFrom(p.Context).orders.LoadMany(p.Context, p.Source.(*dispatchv1.Trip).GetOrderIds())
+				if err != nil {
+					return nil, err
+				}
+				found := make([]*orderv1.Order, 0, len(orders))
+				for _, o := range orders {
+					if o != nil {
+						found = append(found, o)
+					}
+				}
+				return found, nil
+			},
+		},
+	}}
+
+	tripFilter := &graphql.InputObject{Name: "TripFilter", Fields: graphql.Args{
+		"status":       {Type: tripStatusEnum.Enum},
+		"type":         {Type: tripTypeEnum.Enum},
+		"driverId":     {Type: uuidScalar},
+		"customerId":   {Type: uuidScalar},
+		"tripNumber":   {Type: graphql.String},
+		"dateFrom":     {Type: dateTime},
+		"dateTo":       {Type: dateTime},
+		"tags":         {Type: graphql.NewList(nonNull(graphql.String))},
+		"matchAllTags": {Type: graphql.Boolean},
+	}}
+	orderFilter := &graphql.InputObject{Name: "OrderFilter", Fields: graphql.Args{
+		"status":       {Type: orderStatusEnum.Enum},
+		"type":         {Type: orderTypeEnum.Enum},
+		"shipmentId":   {Type: uuidScalar},
+		"customerId":   {Type: uuidScalar},
+		"dateFrom":     {Type: dateTime},
+		"dateTo":       {Type: dateTime},
+		"tags":         {Type: graphql.NewList(nonNull(graphql.String))},
+		"matchAllTags": {Type: graphql.Boolean},
+	}}
+
+	query := &graphql.Object{Name: "Query", Fields: graphql.Fields{
+		"trip": {
+			Type:      trip,
+			Args:      graphql.Args{"id": {Type: uuidScalar}, "tripNumber": {Type: graphql.String}},
+			Authorize: r.allow(opsAndBilling...),
+			Resolve:   r.trip,
+		},
+		"trips": {
+			Type:      nonNull(connectionType(trip)),
+			Args:      graphql.Args{"filter": {Type: tripFilter}, "pagination": {Type: paginationInput}},
+			Authorize: r.allow(opsAndBilling...),
+			Resolve:   r.trips,
+		},
+		"order": {
+			Type:      order,
+			Args:      graphql.Args{"id": {Type: uuidScalar}, "orderNumber": {Type: graphql.String}},
+			Authorize: r.allow(opsAndBilling...),
+			Resolve:   r.order,
+		},
+		"orders": {
+			Type:      nonNull(connectionType(order)),
+			Args:      graphql.Args{"filter": {Type: orderFilter}, "pagination": {Type: paginationInput}},
+			Authorize: r.allow(opsAndBilling...),
+			Resolve:   r.listOrders,
+		},
+		"driver": {
+			Type:      driver,
+			Args:      graphql.Args{"id": {Type: nonNull(uuidScalar)}},
+			Authorize: r.allow(opsAndBilling...),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadersFrom(p.Context).drivers.Load(p.Context, p.Args["id"].(string))
+			},
+		},
+	}}
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, MaxDepth: maxDepth})
+}
+
+func (r *Resolver) trip(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	number, _ := p.Args["tripNumber"].(string)
+	if id == "" && number == "" {
+		return nil, apperrors.ValidationError("id or tripNumber is required", "id", nil)
+	}
+	trip, err := r.dispatch.GetTrip(p.Context, &dispatchv1.GetTripRequest{Id: id, TripNumber: number})
+	return found(trip, err)
+}
+
+func (r *Resolver) trips(p graphql.ResolveParams) (interface{}, error) {
+	pageNum, size, offset, err := page(p.Args["pagination"])
+	if err != nil {
+		return nil, err
+	}
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	req := &dispatchv1.ListTripsRequest{
+		Status:       dispatchv1.TripStatus(tripStatusEnum.number(filter["status"])),
+		Type:         dispatchv1.TripType(tripTypeEnum.number(filter["type"])),
+		DriverId:     stringArg(filter["driverId"]),
+		CustomerId:   stringArg(filter["customerId"]),
+		TripNumber:   stringArg(filter["tripNumber"]),
+		DateFrom:     timeArg(filter["dateFrom"]),
+		DateTo:       timeArg(filter["dateTo"]),
+		Tags:         stringsArg(filter["tags"]),
+		MatchAllTags: filter["matchAllTags"] == true,
+		Page:         pageNum,
+		PageSize:     size,
+	}
+	resp, err := r.dispatch.ListTrips(p.Context, req)
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	return connection(resp.GetTrips(), offset, resp.GetTotal()), nil
+}
+
+func (r *Resolver) order(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	number, _ := p.Args["orderNumber"].(string)
+	if id == "" && number == "" {
+		return nil, apperrors.ValidationError("id or orderNumber is required", "id", nil)
+	}
+	order, err := r.orders.GetOrder(p.Context, &orderv1.GetOrderRequest{Id: id, OrderNumber: number})
+	return found(order, err)
+}
+
+func (r *Resolver) listOrders(p graphql.ResolveParams) (interface{}, error) {
+	pageNum, size, offset, err := page(p.Args["pagination"])
+	if err != nil {
+		return nil, err
+	}
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	req := &orderv1.ListOrdersRequest{
+		Status:       orderv1.OrderStatus(orderStatusEnum.number(filter["status"])),
+		Type:         orderv1.OrderType(orderTypeEnum.number(filter["type"])),
+		ShipmentId:   stringArg(filter["shipmentId"]),
+		CustomerId:   stringArg(filter["customerId"]),
+		DateFrom:     timeArg(filter["dateFrom"]),
+		DateTo:       timeArg(filter["dateTo"]),
+		Tags:         stringsArg(filter["tags"]),
+		MatchAllTags: filter["matchAllTags"] == true,
+		Page:         pageNum,
+		PageSize:     size,
+	}
+	resp, err := r.orders.ListOrders(p.Context, req)
+	if err != nil {
+		return nil, grpcerrors.FromStatus(err)
+	}
+	return connection(resp.GetOrders(), offset, resp.GetTotal()), nil
+}
+
+// found answers a lookup by ID: the object, or null when there is none
+func found[T any](obj *T, err error) (interface{}, error) {
+	if err != nil {
+		if appErr := grpcerrors.FromStatus(err); appErr.Code != "NOT_FOUND" {
+			return nil, appErr
+		}
+		return nil, nil
+	}
+	return obj, nil
+}
+
+func stringArg(arg interface{}) string {
+	s, _ := arg.(string)
+	return s
+}
+
+func stringsArg(arg interface{}) []string {
+	items, _ := arg.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func timeArg(arg interface{}) *timestamppb.Timestamp {
+	if t, ok := arg.(time.Time); ok {
+		return timestamppb.New(t)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type executor struct {
+	schema    *Schema
+	fragments map[string]*fragmentDef
+	vars      map[string]interface{}
+
+	mu   sync.Mutex
+	errs []*Error
+}
+
+func (e *executor) addError(err error, path []interface{}, loc Location) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = append(e.errs, toError(err, path, loc))
+}
+
+// executeSelections resolves an object's selected fields concurrently. It
+// reports failed when a non-null field errored, which nulls the object.
+func (e *executor) executeSelections(ctx context.Context, obj *Object, source interface{}, sels []selection, path []interface{}) (*OrderedMap, bool) {
+	keys, fields := e.collectFields(obj, sels, make(map[string]bool), nil, nil)
+	out := &OrderedMap{Keys: keys, Values: make([]interface{}, len(keys))}
+	failures := make([]bool, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			out.Values[i], failures[i] = e.executeField(ctx, obj, source, fields[key], appendPath(path, key))
+		}(i, key)
+	}
+	wg.Wait()
+
+	for _, failed := range failures {
+		if failed {
+			return nil, true
+		}
+	}
+	return out, false
+}
+
+// collectFields flattens fragments and drops skipped fields, grouping the
+// rest by response key in the order they first appear
+func (e *executor) collectFields(obj *Object, sels []selection, visited map[string]bool, keys []string, fields map[string][]*fieldNode) ([]string, map[string][]*fieldNode) {
+	if fields == nil {
+		fields = make(map[string][]*fieldNode)
+	}
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *fieldNode:
+			if !e.included(s.directives) {
+				continue
+			}
+			key := s.key()
+			if _, seen := fields[key]; !seen {
+				keys = append(keys, key)
+			}
+			fields[key] = append(fields[key], s)
+		case *inlineFragment:
+			if !e.included(s.directives) {
+				continue
+			}
+			keys, fields = e.collectFields(obj, s.selections, visited, keys, fields)
+		case *fragmentSpread:
+			if visited[s.name] || !e.included(s.directives) {
+				continue
+			}
+			visited[s.name] = true
+			keys, fields = e.collectFields(obj, e.fragments[s.name].selections, visited, keys, fields)
+		}
+	}
+	return keys, fields
+}
+
+// included applies @skip and @include
+func (e *executor) included(dirs []*directive) bool {
+	for _, d := range dirs {
+		var cond bool
+		for _, arg := range d.args {
+			if arg.name == "if" {
+				cond, _ = e.substitute(arg.value).(bool)
+			}
+		}
+		if (d.name == "skip" && cond) || (d.name == "include" && !cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// executeField resolves one field and completes its value. A field that
+// errors is null; failed means it is non-null and so nulls its parent.
+func (e *executor) executeField(ctx context.Context, obj *Object, source interface{}, nodes []*fieldNode, path []interface{}) (interface{}, bool) {
+	node := nodes[0]
+	if node.name == "__typename" {
+		return obj.Name, false
+	}
+	def := obj.Fields[node.name]
+
+	value, err := e.resolve(ctx, def, source, node)
+	if err != nil {
+		e.addError(err, path, node.loc)
+		return nil, isNonNull(def.Type)
+	}
+	completed, failed := e.completeValue(ctx, def.Type, nodes, value, path)
+	return absorb(def.Type, completed, failed)
+}
+
+func (e *executor) resolve(ctx context.Context, def *Field, source interface{}, node *fieldNode) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error resolving %q: %v", node.name, r)
+		}
+	}()
+
+	if def.Authorize != nil {
+		if err := def.Authorize(ctx); err != nil {
+			return nil, err
+		}
+	}
+	args, err := e.coerceArgs(def.Args, node.args)
+	if err != nil {
+		return nil, err
+	}
+	if def.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[node.name], nil
+		}
+		return nil, nil
+	}
+	return def.Resolve(ResolveParams{Context: ctx, Source: source, Args: args})
+}
+
+// coerceArgs coerces a field's arguments, filling in defaults. Arguments
+// left out, or given as variables that were not provided, are absent.
+func (e *executor) coerceArgs(defs Args, args []*argNode) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(defs))
+	for name, def := range defs {
+		if def.Default != nil {
+			out[name] = def.Default
+		}
+	}
+	for _, arg := range args {
+		if ref, isVar := arg.value.(variableRef); isVar {
+			if _, provided := e.vars[string(ref)]; !provided {
+				continue
+			}
+		}
+		coerced, err := coerceValue(defs[arg.name].Type, e.substitute(arg.value))
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", arg.name, err)
+		}
+		out[arg.name] = coerced
+	}
+	return out, nil
+}
+
+// substitute replaces variables in a literal with their values
+func (e *executor) substitute(value interface{}) interface{} {
+	switch v := value.(type) {
+	case variableRef:
+		return e.vars[string(v)]
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = e.substitute(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if ref, isVar := item.(variableRef); isVar {
+				if _, provided := e.vars[string(ref)]; !provided {
+					continue
+				}
+			}
+			out[k] = e.substitute(item)
+		}
+		return out
+	}
+	return value
+}
+
+// completeValue shapes a resolved value to its type. failed means the
+// value is null because of an error already recorded; the nearest
+// nullable position takes the null.
+func (e *executor) completeValue(ctx context.Context, t Type, nodes []*fieldNode, value interface{}, path []interface{}) (interface{}, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		completed, failed := e.completeValue(ctx, nn.OfType, nodes, value, path)
+		if failed {
+			return nil, true
+		}
+		if completed == nil {
+			e.addError(fmt.Errorf("cannot return null for non-nullable field"), path, nodes[0].loc)
+			return nil, true
+		}
+		return completed, false
+	}
+	if isNil(value) {
+		return nil, false
+	}
+
+	switch tt := t.(type) {
+	case *Scalar:
+		serialized, err := tt.Serialize(value)
+		if err != nil {
+			e.addError(err, path, nodes[0].loc)
+			return nil, true
+		}
+		return serialized, false
+	case *Enum:
+		name, ok := value.(string)
+		if !ok || !tt.has(name) {
+			e.addError(fmt.Errorf("%v is not a %s", value, tt.Name), path, nodes[0].loc)
+			return nil, true
+		}
+		return name, false
+	case *Object:
+		var sels []selection
+		for _, node := range nodes {
+			sels = append(sels, node.selections...)
+		}
+		fields, failed := e.executeSelections(ctx, tt, value, sels, path)
+		if failed {
+			return nil, true
+		}
+		return fields, false
+	case *List:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("expected a list, got %T", value), path, nodes[0].loc)
+			return nil, true
+		}
+		out := make([]interface{}, items.Len())
+		failures := make([]bool, items.Len())
+		var wg sync.WaitGroup
+		for i := 0; i < items.Len(); i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				item, failed := e.completeValue(ctx, tt.OfType, nodes, items.Index(i).Interface(), appendPath(path, i))
+				out[i], failures[i] = absorb(tt.OfType, item, failed)
+			}(i)
+		}
+		wg.Wait()
+		for _, failed := range failures {
+			if failed {
+				return nil, true
+			}
+		}
+		return out, false
+	}
+	e.addError(fmt.Errorf("%s is not an output type", t), path, nodes[0].loc)
+	return nil, true
+}
+
+// absorb stops a failure at a nullable position, which becomes null
+func absorb(t Type, value interface{}, failed bool) (interface{}, bool) {
+	if failed && !isNonNull(t) {
+		return nil, false
+	}
+	return value, failed
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// appendPath copies path, since sibling fields share its backing array
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, key)
+}
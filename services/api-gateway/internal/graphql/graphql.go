@@ -0,0 +1,264 @@
+// Package graphql executes GraphQL queries against a schema defined in Go.
+// It serves what the gateway needs and no more: queries with variables,
+// aliases, fragments and @skip/@include over object, scalar, enum and
+// input object types. Mutations, subscriptions, interfaces, unions and
+// introspection are not supported; writes go through the REST API.
+//
+// Sibling fields and list items resolve concurrently, so resolvers that
+// load through a dataloader are batched one call per level of the query.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// defaultMaxDepth is how deeply a query may nest fields unless the schema
+// says otherwise
+const defaultMaxDepth = 12
+
+// Schema is a set of types reachable from a query root
+type Schema struct {
+	query    *Object
+	maxDepth int
+	types    map[string]Type // Named types, for variable definitions
+}
+
+// SchemaConfig configures a schema
+type SchemaConfig struct {
+	Query    *Object
+	MaxDepth int // How deeply a query may nest fields; 12 when zero
+}
+
+// NewSchema checks a schema and indexes its types
+func NewSchema(cfg SchemaConfig) (*Schema, error) {
+	if cfg.Query == nil {
+		return nil, errors.New("graphql: schema has no query type")
+	}
+	s := &Schema{query: cfg.Query, maxDepth: cfg.MaxDepth, types: map[string]Type{
+		Int.Name: Int, Float.Name: Float, String.Name: String, Boolean.Name: Boolean, ID.Name: ID,
+	}}
+	if s.maxDepth <= 0 {
+		s.maxDepth = defaultMaxDepth
+	}
+	if err := s.index(cfg.Query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// index adds t and every type reachable from it
+func (s *Schema) index(t Type) error {
+	named := namedType(t)
+	var name string
+	switch n := named.(type) {
+	case *Scalar:
+		name = n.Name
+	case *Enum:
+		name = n.Name
+	case *Object:
+		name = n.Name
+	case *InputObject:
+		name = n.Name
+	default:
+		return fmt.Errorf("graphql: unsupported type %T", named)
+	}
+	if existing, ok := s.types[name]; ok {
+		if existing != named {
+			return fmt.Errorf("graphql: two types are named %s", name)
+		}
+		return nil
+	}
+	s.types[name] = named
+
+	switch n := named.(type) {
+	case *Object:
+		for fieldName, f := range n.Fields {
+			if f == nil || f.Type == nil {
+				return fmt.Errorf("graphql: %s.%s has no type", n.Name, fieldName)
+			}
+			if err := s.index(f.Type); err != nil {
+				return err
+			}
+			if err := s.indexArgs(n.Name+"."+fieldName, f.Args); err != nil {
+				return err
+			}
+		}
+	case *InputObject:
+		return s.indexArgs(n.Name, n.Fields)
+	}
+	return nil
+}
+
+func (s *Schema) indexArgs(owner string, args Args) error {
+	for name, arg := range args {
+		if arg == nil || arg.Type == nil {
+			return fmt.Errorf("graphql: %s(%s) has no type", owner, name)
+		}
+		if _, isObject := namedType(arg.Type).(*Object); isObject {
+			return fmt.Errorf("graphql: %s(%s) cannot take an object type", owner, name)
+		}
+		if err := s.index(arg.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Params is a GraphQL request
+type Params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"` // Numbers as json.Number
+}
+
+// Location is a position in a query
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error in a response. Application errors carry their code in
+// extensions.code.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// toError makes a response error from a resolver's
+func toError(err error, path []interface{}, loc Location) *Error {
+	out := &Error{Message: err.Error(), Path: path, Locations: []Location{loc}}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		out.Message = appErr.Message
+		out.Extensions = map[string]interface{}{"code": appErr.Code}
+		if len(appErr.Details) > 0 {
+			out.Extensions["details"] = appErr.Details
+		}
+	}
+	return out
+}
+
+// Result is a response: the data, the errors, or both
+type Result struct {
+	Data     *OrderedMap // Nil when an error nulled the root
+	Errors   []*Error
+	executed bool
+}
+
+// RequestFailed reports whether the request was rejected before it ran:
+// it did not parse, did not validate, or its variables did not coerce
+func (r *Result) RequestFailed() bool { return !r.executed }
+
+// MarshalJSON writes data only once the request ran, as the spec asks
+func (r *Result) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Data   interface{} `json:"data,omitempty"`
+		Errors []*Error    `json:"errors,omitempty"`
+	}{Errors: r.Errors}
+	if r.executed {
+		if r.Data == nil {
+			out.Data = json.RawMessage("null")
+		} else {
+			out.Data = r.Data
+		}
+	}
+	return json.Marshal(out)
+}
+
+// OrderedMap is a response object, keeping its fields in query order
+type OrderedMap struct {
+	Keys   []string
+	Values []interface{}
+}
+
+// Get returns the value of key
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	for i, k := range m.Keys {
+		if k == key {
+			return m.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON writes the fields in order
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute runs a query
+func (s *Schema) Execute(ctx context.Context, params Params) *Result {
+	doc, err := parse(params.Query)
+	if err != nil {
+		return &Result{Errors: []*Error{asError(err)}}
+	}
+	op, err := doc.operation(params.OperationName)
+	if err != nil {
+		return &Result{Errors: []*Error{asError(err)}}
+	}
+	if errs := validate(s, doc, op); len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+	vars, errs := s.coerceVariables(op, params.Variables)
+	if len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	e := &executor{schema: s, fragments: doc.fragments, vars: vars}
+	data, failed := e.executeSelections(ctx, s.query, nil, op.selections, nil)
+	result := &Result{Errors: e.errs, executed: true}
+	if !failed {
+		result.Data = data
+	}
+	return result
+}
+
+// operation picks the operation to run
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+func asError(err error) *Error {
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+	return &Error{Message: err.Error()}
+}
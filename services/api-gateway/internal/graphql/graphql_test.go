@@ -0,0 +1,345 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/api-gateway/internal/dataloader"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+type testTrip struct {
+	ID     string
+	Number string
+	Status string
+}
+
+type testStop struct {
+	TripID   string
+	Sequence int
+	Name     string
+}
+
+// testSchema serves trips whose stops load through a dataloader, so tests
+// can see how many batches a query sends
+type testSchema struct {
+	schema *Schema
+
+	fetchStops dataloader.BatchFunc[string, []*testStop]
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func newTestSchema(t *testing.T) *testSchema {
+	t.Helper()
+	ts := &testSchema{}
+	trips := map[string]*testTrip{
+		"t1": {ID: "t1", Number: "TRP-1", Status: "DISPATCHED"},
+		"t2": {ID: "t2", Number: "TRP-2", Status: "PLANNED"},
+	}
+	stops := map[string][]*testStop{
+		"t1": {{TripID: "t1", Sequence: 1, Name: "APM Terminal"}, {TripID: "t1", Sequence: 2, Name: "Warehouse"}},
+		"t2": {{TripID: "t2", Sequence: 1, Name: "Yard"}},
+	}
+
+	status := &Enum{Name: "TripStatus", Values: []string{"PLANNED", "DISPATCHED"}}
+	stop := &Object{Name: "Stop", Fields: Fields{
+		"sequence": {Type: NewNonNull(Int), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testStop).Sequence, nil
+		}},
+		"name": {Type: NewNonNull(String), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testStop).Name, nil
+		}},
+		"broken": {Type: NewNonNull(String), Resolve: func(p ResolveParams) (interface{}, error) {
+			return nil, errors.New("cannot load")
+		}},
+	}}
+	trip := &Object{Name: "Trip", Fields: Fields{
+		"id": {Type: NewNonNull(ID), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testTrip).ID, nil
+		}},
+		"tripNumber": {Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testTrip).Number, nil
+		}},
+		"status": {Type: NewNonNull(status), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testTrip).Status, nil
+		}},
+		"stops": {Type: NewNonNull(NewList(NewNonNull(stop))), Resolve: func(p ResolveParams) (interface{}, error) {
+			loader := p.Context.Value(loaderKey{}).(*dataloader.Loader[string, []*testStop])
+			return loader.Load(p.Context, p.Source.(*testTrip).ID)
+		}},
+		"driverPhone": {Type: String,
+			Authorize: func(ctx context.Context) error {
+				return apperrors.New("FORBIDDEN", "not permitted to read this field")
+			},
+			Resolve: func(p ResolveParams) (interface{}, error) { return "555-0100", nil },
+		},
+	}}
+	filter := &InputObject{Name: "TripFilter", Fields: Args{
+		"status": {Type: status},
+		"first":  {Type: Int, Default: 10},
+	}}
+	query := &Object{Name: "Query", Fields: Fields{
+		"trip": {
+			Type: trip,
+			Args: Args{"id": {Type: NewNonNull(ID)}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				if found, ok := trips[p.Args["id"].(string)]; ok {
+					return found, nil
+				}
+				return nil, apperrors.NotFoundError("trip", p.Args["id"].(string))
+			},
+		},
+		"trips": {
+			Type: NewNonNull(NewList(NewNonNull(trip))),
+			Args: Args{"filter": {Type: filter}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				out := []*testTrip{trips["t1"], trips["t2"]}
+				if f, ok := p.Args["filter"].(map[string]interface{}); ok && f["status"] != nil {
+					out = out[:0]
+					for _, id := range []string{"t1", "t2"} {
+						if trips[id].Status == f["status"] {
+							out = append(out, trips[id])
+						}
+					}
+				}
+				return out, nil
+			},
+		},
+	}}
+
+	schema, err := NewSchema(SchemaConfig{Query: query, MaxDepth: 4})
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	ts.schema = schema
+
+	ts.fetchStops = func(ctx context.Context, tripIDs []string) (map[string][]*testStop, error) {
+		ts.mu.Lock()
+		ts.batches = append(ts.batches, tripIDs)
+		ts.mu.Unlock()
+		out := make(map[string][]*testStop, len(tripIDs))
+		for _, id := range tripIDs {
+			out[id] = stops[id]
+		}
+		return out, nil
+	}
+	return ts
+}
+
+type loaderKey struct{}
+
+func (ts *testSchema) run(query string, vars map[string]interface{}) *Result {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, loaderKey{}, dataloader.New(ctx, ts.fetchStops, 2*time.Millisecond, 100))
+	return ts.schema.Execute(ctx, Params{Query: query, Variables: vars})
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(body)
+}
+
+func TestExecute_NestedQueryBatchesEachLevel(t *testing.T) {
+	ts := newTestSchema(t)
+	result := ts.run(`
+		query Board($status: TripStatus) {
+			all: trips { ...tripFields }
+			dispatched: trips(filter: {status: $status}) { id __typename }
+		}
+		fragment tripFields on Trip {
+			id
+			number: tripNumber
+			... on Trip { stops { sequence name } }
+		}`, map[string]interface{}{"status": "DISPATCHED"})
+
+	want := `{"data":{` +
+		`"all":[` +
+		`{"id":"t1","number":"TRP-1","stops":[{"sequence":1,"name":"APM Terminal"},{"sequence":2,"name":"Warehouse"}]},` +
+		`{"id":"t2","number":"TRP-2","stops":[{"sequence":1,"name":"Yard"}]}],` +
+		`"dispatched":[{"id":"t1","__typename":"Trip"}]}}`
+	if got := toJSON(t, result); got != want {
+		t.Errorf("unexpected response\n got: %s\nwant: %s", got, want)
+	}
+	if len(ts.batches) != 1 || len(ts.batches[0]) != 2 {
+		t.Errorf("expected the stops of both trips in one batch, got %v", ts.batches)
+	}
+}
+
+func TestExecute_ErrorsNullTheNearestNullableField(t *testing.T) {
+	ts := newTestSchema(t)
+	result := ts.run(`{
+		trip(id: "t1") { id stops { name broken } }
+		missing: trip(id: "nope") { id }
+	}`, nil)
+
+	if got, want := toJSON(t, result.Data), `{"trip":null,"missing":null}`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if len(result.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got %s", toJSON(t, result.Errors))
+	}
+	var notFound *Error
+	for _, e := range result.Errors {
+		if e.Path[0] == "missing" {
+			notFound = e
+		}
+	}
+	if notFound == nil || notFound.Extensions["code"] != "NOT_FOUND" {
+		t.Errorf("expected the NOT_FOUND application error on missing, got %s", toJSON(t, result.Errors))
+	}
+}
+
+func TestExecute_UnauthorizedFieldIsNull(t *testing.T) {
+	ts := newTestSchema(t)
+	result := ts.run(`{ trip(id: "t2") { tripNumber driverPhone } }`, nil)
+
+	if got, want := toJSON(t, result.Data), `{"trip":{"tripNumber":"TRP-2","driverPhone":null}}`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Fatalf("expected one FORBIDDEN error, got %s", toJSON(t, result.Errors))
+	}
+	if got := toJSON(t, result.Errors[0].Path); got != `["trip","driverPhone"]` {
+		t.Errorf("expected the error at trip.driverPhone, got %s", got)
+	}
+}
+
+func TestExecute_SkipAndInclude(t *testing.T) {
+	ts := newTestSchema(t)
+	result := ts.run(`query($withStops: Boolean!) {
+		trip(id: "t2") { id @skip(if: true) tripNumber stops @include(if: $withStops) { name } }
+	}`, map[string]interface{}{"withStops": false})
+
+	if got, want := toJSON(t, result), `{"data":{"trip":{"tripNumber":"TRP-2"}}}`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestExecute_RejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{name: "syntax", query: `{ trip(id: "t1") { id }`, want: "Syntax Error"},
+		{name: "unknown field", query: `{ trip(id: "t1") { driver } }`, want: `Cannot query field "driver" on type "Trip"`},
+		{name: "missing argument", query: `{ trip { id } }`, want: `Argument "id" of type "ID!" is required`},
+		{name: "bad enum", query: `{ trips(filter: {status: PARKED}) { id } }`, want: `"PARKED" is not a TripStatus`},
+		{name: "leaf without selection", query: `{ trips }`, want: "must have a selection of subfields"},
+		{name: "fragment cycle", query: `{ trips { ...a } } fragment a on Trip { ...a }`, want: "within itself"},
+		{name: "mutation", query: `mutation { trips { id } }`, want: "Mutations are not supported"},
+		{name: "missing variable", query: `query($id: ID!) { trip(id: $id) { id } }`, want: `Variable "$id" of required type "ID!" was not provided`},
+		{name: "undefined variable", query: `{ trip(id: $id) { id } }`, want: `Variable "$id" is not defined`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := newTestSchema(t).run(tt.query, tt.vars)
+			if !result.RequestFailed() || len(result.Errors) == 0 {
+				t.Fatalf("expected the request to fail, got %s", toJSON(t, result))
+			}
+			if !strings.Contains(result.Errors[0].Message, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, result.Errors[0].Message)
+			}
+			if strings.Contains(toJSON(t, result), `"data"`) {
+				t.Errorf("expected no data for a rejected request, got %s", toJSON(t, result))
+			}
+		})
+	}
+
+	shallow, err := NewSchema(SchemaConfig{Query: newTestSchema(t).schema.query, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	result := shallow.Execute(context.Background(), Params{Query: `{ trips { stops { name } } }`})
+	if !result.RequestFailed() || result.Errors[0].Message != "Query is nested deeper than 2 levels." {
+		t.Errorf("expected the depth limit to reject the query, got %s", toJSON(t, result))
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	ts := newTestSchema(t)
+	handler := NewHandler(ts.schema, func(r *http.Request) (context.Context, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, apperrors.New("UNAUTHORIZED", "missing bearer token")
+		}
+		ctx := r.Context()
+		return context.WithValue(ctx, loaderKey{}, dataloader.New(ctx, ts.fetchStops, time.Millisecond, 100)), nil
+	})
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "post",
+			req:        httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"query($id: ID!) { trip(id: $id) { tripNumber } }","variables":{"id":"t1"}}`)),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"trip":{"tripNumber":"TRP-1"}}}`,
+		},
+		{
+			name:       "get",
+			req:        httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`{ trips(filter: {status: PLANNED}) { id } }`), nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"trips":[{"id":"t2"}]}}`,
+		},
+		{
+			name:       "invalid query",
+			req:        httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ nothing }"}`)),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"errors":[{"message":"Cannot query field \"nothing\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:       "not json",
+			req:        httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`query`)),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method",
+			req:        httptest.NewRequest(http.MethodDelete, "/graphql", nil),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("unexpected body\n got: %s\nwant: %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("prepare error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ trips { id } }"}`)))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", rec.Code)
+		}
+		if want := `{"errors":[{"message":"missing bearer token","extensions":{"code":"UNAUTHORIZED"}}]}`; rec.Body.String() != want {
+			t.Errorf("expected %s, got %s", want, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// maxRequestBytes caps a request body
+const maxRequestBytes = 1 << 20
+
+// PrepareFunc readies a request's context before it runs, typically
+// authenticating the caller. An error rejects the request with the status
+// its application error code maps to.
+type PrepareFunc func(r *http.Request) (context.Context, error)
+
+// NewHandler serves a schema over HTTP: POST with a JSON body of query,
+// operationName and variables, or GET with them as query parameters.
+// Requests that fail to parse or validate are answered 400; once a query
+// runs the answer is 200, with any field errors beside the data.
+func NewHandler(schema *Schema, prepare PrepareFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, err := readParams(w, r)
+		if err != nil {
+			writeResult(w, requestStatus(err), &Result{Errors: []*Error{toRequestError(err)}})
+			return
+		}
+
+		ctx := r.Context()
+		if prepare != nil {
+			if ctx, err = prepare(r); err != nil {
+				writeResult(w, requestStatus(err), &Result{Errors: []*Error{toRequestError(err)}})
+				return
+			}
+		}
+
+		result := schema.Execute(ctx, params)
+		status := http.StatusOK
+		if result.RequestFailed() {
+			status = http.StatusBadRequest
+		}
+		writeResult(w, status, result)
+	})
+}
+
+func readParams(w http.ResponseWriter, r *http.Request) (Params, error) {
+	var params Params
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		params.Query = q.Get("query")
+		params.OperationName = q.Get("operationName")
+		if raw := q.Get("variables"); raw != "" {
+			if err := decodeJSON(strings.NewReader(raw), &params.Variables); err != nil {
+				return params, apperrors.ValidationError("variables must be a JSON object", "variables", raw)
+			}
+		}
+	case http.MethodPost:
+		body := http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		if err := decodeJSON(body, &params); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return params, apperrors.ValidationError("request body is too large", "body", tooLarge.Limit)
+			}
+			return params, apperrors.Wrap(err, "VALIDATION_ERROR", "request body must be a JSON object of query, operationName and variables")
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		return params, apperrors.New("METHOD_NOT_ALLOWED", r.Method+" is not allowed on this path")
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return params, apperrors.ValidationError("query is required", "query", "")
+	}
+	return params, nil
+}
+
+// decodeJSON decodes numbers as json.Number, so Int and Float coerce
+// exactly
+func decodeJSON(body interface{ Read([]byte) (int, error) }, v interface{}) error {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func requestStatus(err error) int {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+	if appErr.Code == "METHOD_NOT_ALLOWED" {
+		return http.StatusMethodNotAllowed
+	}
+	return apperrors.HTTPStatus(appErr.Code)
+}
+
+func toRequestError(err error) *Error {
+	out := toError(err, nil, Location{})
+	out.Locations = nil
+	return out
+}
+
+func writeResult(w http.ResponseWriter, status int, result *Result) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		body, _ = json.Marshal(&Result{Errors: []*Error{{Message: "cannot encode the response: " + err.Error()}}})
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bytes.TrimSpace(body))
+}
@@ -0,0 +1,701 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed executable document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragmentDef
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	vars       []*varDef
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type varDef struct {
+	name   string
+	typ    *typeRef
+	def    interface{}
+	hasDef bool
+	loc    Location
+}
+
+// typeRef is a type as written in a variable definition
+type typeRef struct {
+	name    string
+	elem    *typeRef // Set for lists
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type selection interface{}
+
+type fieldNode struct {
+	alias      string
+	name       string
+	args       []*argNode
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// key is the field's name in the response
+func (f *fieldNode) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argNode struct {
+	name  string
+	value interface{}
+	loc   Location
+}
+
+type directive struct {
+	name string
+	args []*argNode
+	loc  Location
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	typeCond   string
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type fragmentDef struct {
+	name       string
+	typeCond   string
+	selections []selection
+	loc        Location
+}
+
+// Values in a document are parsed to what JSON decodes to, with numbers as
+// json.Number, plus these two
+type (
+	variableRef string
+	enumLiteral string
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return strconv.Quote(t.value)
+	}
+	return `"` + t.value + `"`
+}
+
+// lex splits a document into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line, lineStart := 1, 0
+	for i := 0; i < len(src); {
+		c := src[i]
+		loc := Location{Line: line, Column: i - lineStart + 1}
+		switch {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "\uFEFF"):
+			i += len("\uFEFF")
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{kind: tokPunct, value: "...", loc: loc})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tokens = append(tokens, token{kind: tokPunct, value: string(c), loc: loc})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokName, value: src[start:i], loc: loc})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokInt
+			if c == '-' {
+				i++
+			}
+			digits := i
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i == digits {
+				return nil, syntaxError(loc, "expected a digit after \"-\"")
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokFloat
+				i++
+				digits = i
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+				if i == digits {
+					return nil, syntaxError(loc, "expected a digit after \".\"")
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				digits = i
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+				if i == digits {
+					return nil, syntaxError(loc, "expected a digit in the exponent")
+				}
+			}
+			tokens = append(tokens, token{kind: kind, value: src[start:i], loc: loc})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := -1
+			for j := i + 3; j+3 <= len(src); j++ {
+				if strings.HasPrefix(src[j:], `\"""`) {
+					j += 3
+					continue
+				}
+				if strings.HasPrefix(src[j:], `"""`) {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				return nil, syntaxError(loc, "unterminated block string")
+			}
+			raw := src[i+3 : end]
+			tokens = append(tokens, token{kind: tokString, value: blockString(raw), loc: loc})
+			line += strings.Count(raw, "\n")
+			if n := strings.LastIndexByte(raw, '\n'); n >= 0 {
+				lineStart = i + 3 + n + 1
+			}
+			i = end + 3
+		case c == '"':
+			value, n, err := quotedString(src[i:], loc)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, value: value, loc: loc})
+			i += n
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, syntaxError(loc, fmt.Sprintf("unexpected character %q", r))
+		}
+	}
+	return append(tokens, token{kind: tokEOF, loc: Location{Line: line, Column: len(src) - lineStart + 1}}), nil
+}
+
+// quotedString reads a "..." string, returning its value and length
+func quotedString(src string, loc Location) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			return b.String(), i + 1, nil
+		case c == '\n' || c == '\r':
+			return "", 0, syntaxError(loc, "unterminated string")
+		case c == '\\' && i+1 < len(src):
+			esc := src[i+1]
+			i += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 > len(src) {
+					return "", 0, syntaxError(loc, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(src[i:i+4], 16, 32)
+				if err != nil {
+					return "", 0, syntaxError(loc, "invalid unicode escape")
+				}
+				b.WriteRune(rune(code))
+				i += 4
+			default:
+				return "", 0, syntaxError(loc, fmt.Sprintf("invalid escape \\%c", esc))
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, syntaxError(loc, "unterminated string")
+}
+
+// blockString strips a """...""" string's common indentation and its
+// leading and trailing blank lines
+func blockString(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), `\"""`, `"""`), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed != "" && (indent < 0 || len(l)-len(trimmed) < indent) {
+			indent = len(l) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses an executable document
+func parse(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragmentDef)}
+	for p.peek().kind != tokEOF {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokPunct && tok.value == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels, loc: tok.loc})
+		case tok.kind == tokName && (tok.value == "query" || tok.value == "mutation" || tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case tok.kind == tokName && tok.value == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[frag.name]; dup {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q.", frag.name), Locations: []Location{frag.loc}}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &Error{Message: "The document has no operation."}
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// skip consumes the punctuator if it is next
+func (p *parser) skip(punct string) bool {
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == punct {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.skip(punct) {
+		return syntaxError(p.peek().loc, fmt.Sprintf("expected %q, found %s", punct, p.peek().describe()))
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	tok := p.peek()
+	if tok.kind != tokName {
+		return "", syntaxError(tok.loc, fmt.Sprintf("expected a name, found %s", tok.describe()))
+	}
+	p.pos++
+	return tok.value, nil
+}
+
+func (p *parser) unexpected() error {
+	tok := p.peek()
+	return syntaxError(tok.loc, fmt.Sprintf("unexpected %s", tok.describe()))
+}
+
+func (p *parser) operation() (*operation, error) {
+	tok := p.advance()
+	op := &operation{kind: tok.value, loc: tok.loc}
+	if p.peek().kind == tokName {
+		op.name = p.advance().value
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+	}
+	var err error
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) varDef() (*varDef, error) {
+	loc := p.peek().loc
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	v := &varDef{name: name, typ: typ, loc: loc}
+	if p.skip("=") {
+		if v.def, err = p.value(true); err != nil {
+			return nil, err
+		}
+		v.hasDef = true
+	}
+	return v, nil
+}
+
+func (p *parser) typeRef() (*typeRef, error) {
+	t := &typeRef{}
+	if p.skip("[") {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t.elem = elem
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+	t.nonNull = p.skip("!")
+	return t, nil
+}
+
+func (p *parser) fragment() (*fragmentDef, error) {
+	loc := p.advance().loc
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, syntaxError(loc, `a fragment cannot be named "on"`)
+	}
+	if on, err := p.name(); err != nil || on != "on" {
+		return nil, syntaxError(p.peek().loc, `expected "on" and a type condition`)
+	}
+	typeCond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragmentDef{name: name, typeCond: typeCond, selections: sels, loc: loc}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.skip("}") {
+		if p.peek().kind == tokEOF {
+			return nil, p.unexpected()
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, syntaxError(p.tokens[p.pos-1].loc, "a selection set cannot be empty")
+	}
+	return sels, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	loc := p.peek().loc
+	if p.skip("...") {
+		if tok := p.peek(); tok.kind == tokName && tok.value != "on" {
+			p.pos++
+			dirs, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &fragmentSpread{name: tok.value, directives: dirs, loc: loc}, nil
+		}
+		frag := &inlineFragment{loc: loc}
+		if tok := p.peek(); tok.kind == tokName && tok.value == "on" {
+			p.pos++
+			typeCond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			frag.typeCond = typeCond
+		}
+		var err error
+		if frag.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if frag.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return frag, nil
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &fieldNode{name: name, loc: loc}
+	if p.skip(":") {
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == "{" {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]*argNode, error) {
+	if !p.skip("(") {
+		return nil, nil
+	}
+	var args []*argNode
+	for !p.skip(")") {
+		loc := p.peek().loc
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range args {
+			if a.name == name {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one argument named %q.", name), Locations: []Location{loc}}
+			}
+		}
+		args = append(args, &argNode{name: name, value: value, loc: loc})
+	}
+	return args, nil
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var dirs []*directive
+	for {
+		loc := p.peek().loc
+		if !p.skip("@") {
+			return dirs, nil
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, &directive{name: name, args: args, loc: loc})
+	}
+}
+
+// value parses a value; constant ones, such as variable defaults, cannot
+// refer to variables
+func (p *parser) value(constant bool) (interface{}, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokInt, tokFloat:
+		p.pos++
+		return json.Number(tok.value), nil
+	case tokString:
+		p.pos++
+		return tok.value, nil
+	case tokName:
+		p.pos++
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumLiteral(tok.value), nil
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, syntaxError(tok.loc, "a default value cannot refer to a variable")
+			}
+			p.pos++
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			p.pos++
+			list := []interface{}{}
+			for !p.skip("]") {
+				if p.peek().kind == tokEOF {
+					return nil, p.unexpected()
+				}
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, nil
+		case "{":
+			p.pos++
+			obj := map[string]interface{}{}
+			for !p.skip("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[name], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return obj, nil
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func syntaxError(loc Location, message string) *Error {
+	return &Error{Message: "Syntax Error: " + message, Locations: []Location{loc}}
+}
@@ -0,0 +1,297 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Type is a GraphQL type: a Scalar, Enum, Object or InputObject, or a
+// List or NonNull of one
+type Type interface {
+	String() string // As written in a query, such as [TripStop!]!
+}
+
+// Scalar is a leaf type
+type Scalar struct {
+	Name string
+	// Serialize turns a resolved value into its JSON form
+	Serialize func(value interface{}) (interface{}, error)
+	// Parse coerces an argument or variable, given as decoded from JSON
+	// with numbers as json.Number
+	Parse func(value interface{}) (interface{}, error)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Enum is a leaf type whose values are names. Resolvers return, and
+// arguments receive, the value's name as a string.
+type Enum struct {
+	Name   string
+	Values []string
+}
+
+func (e *Enum) String() string { return e.Name }
+
+func (e *Enum) has(value string) bool {
+	for _, v := range e.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Object is an output type with fields
+type Object struct {
+	Name   string
+	Fields Fields
+}
+
+func (o *Object) String() string { return o.Name }
+
+// Fields are an object's fields by name
+type Fields map[string]*Field
+
+// Field is a field of an object
+type Field struct {
+	Type Type
+	Args Args
+	// Resolve returns the field's value. Without one the field is read from
+	// a map[string]interface{} source by its name.
+	Resolve ResolveFunc
+	// Authorize, when set, is checked before the field resolves. A field
+	// the caller may not read is null with the error in the response.
+	Authorize func(ctx context.Context) error
+}
+
+// Args are a field's arguments, or an input object's fields, by name
+type Args map[string]*Argument
+
+// Argument is an argument of a field or a field of an input object
+type Argument struct {
+	Type    Type
+	Default interface{} // Used when the argument is left out
+}
+
+// ResolveParams are passed to a field's resolver
+type ResolveParams struct {
+	Context context.Context
+	Source  interface{}            // The value of the object the field is on
+	Args    map[string]interface{} // Coerced arguments; left-out ones without a default are absent
+}
+
+// ResolveFunc resolves a field
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// InputObject is an argument type with fields. Arguments receive it as a
+// map[string]interface{}.
+type InputObject struct {
+	Name   string
+	Fields Args
+}
+
+func (o *InputObject) String() string { return o.Name }
+
+// List is a list of another type
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string { return "[" + l.OfType.String() + "]" }
+
+// NonNull is another type that may not be null
+type NonNull struct {
+	OfType Type
+}
+
+func (n *NonNull) String() string { return n.OfType.String() + "!" }
+
+// NewList wraps t in a list
+func NewList(t Type) *List { return &List{OfType: t} }
+
+// NewNonNull makes t non-null
+func NewNonNull(t Type) *NonNull { return &NonNull{OfType: t} }
+
+// The built-in scalars
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(value interface{}) (interface{}, error) {
+			n, err := toInt(value)
+			if err != nil {
+				return nil, err
+			}
+			return n, nil
+		},
+		Parse: func(value interface{}) (interface{}, error) {
+			n, err := toInt(value)
+			if err != nil {
+				return nil, err
+			}
+			return int(n), nil
+		},
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(value interface{}) (interface{}, error) {
+			return toFloat(value)
+		},
+		Parse: func(value interface{}) (interface{}, error) {
+			return toFloat(value)
+		},
+	}
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return nil, fmt.Errorf("cannot represent %T as String", value)
+		},
+		Parse: func(value interface{}) (interface{}, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected a string, got %s", describe(value))
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("cannot represent %T as Boolean", value)
+		},
+		Parse: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a boolean, got %s", describe(value))
+		},
+	}
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			n, err := toInt(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot represent %T as ID", value)
+			}
+			return strconv.FormatInt(n, 10), nil
+		},
+		Parse: func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case json.Number:
+				if _, err := v.Int64(); err == nil {
+					return v.String(), nil
+				}
+			}
+			return nil, fmt.Errorf("expected an ID, got %s", describe(value))
+		},
+	}
+)
+
+// toInt reads a 32-bit integer, as GraphQL's Int is
+func toInt(value interface{}) (int64, error) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint32:
+		n = int64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected an integer, got %v", v)
+		}
+		n = int64(v)
+	case json.Number:
+		parsed, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %s", v)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("expected an integer, got %s", describe(value))
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%d does not fit in a 32-bit Int", n)
+	}
+	return n, nil
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %s", v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %s", describe(value))
+}
+
+// describe names a value in a coercion error
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case enumLiteral:
+		return string(v)
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// namedType unwraps lists and non-nulls
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
+
+func isNonNull(t Type) bool {
+	_, ok := t.(*NonNull)
+	return ok
+}
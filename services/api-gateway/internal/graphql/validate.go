@@ -0,0 +1,339 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type validator struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]*varDef
+	errs   []*Error
+	active map[string]bool // Fragments being walked, to catch cycles
+}
+
+// validate checks an operation against the schema before it runs
+func validate(s *Schema, doc *document, op *operation) []*Error {
+	v := &validator{schema: s, doc: doc, vars: make(map[string]*varDef), active: make(map[string]bool)}
+	if op.kind != "query" {
+		v.errorf(op.loc, "%ss are not supported; use the REST API.", strings.ToUpper(op.kind[:1])+op.kind[1:])
+		return v.errs
+	}
+	for _, def := range op.vars {
+		if _, dup := v.vars[def.name]; dup {
+			v.errorf(def.loc, "There can be only one variable named \"$%s\".", def.name)
+		}
+		v.vars[def.name] = def
+		t, err := s.inputType(def.typ)
+		if err != nil {
+			v.errorf(def.loc, "Variable \"$%s\": %s", def.name, err.Error())
+			continue
+		}
+		if def.hasDef {
+			if _, err := coerceValue(t, def.def); err != nil {
+				v.errorf(def.loc, "Variable \"$%s\" has an invalid default value: %s", def.name, err.Error())
+			}
+		}
+	}
+	v.directives(op.directives)
+	v.selections(s.query, op.selections, 1)
+	return v.errs
+}
+
+func (v *validator) errorf(loc Location, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+func (v *validator) selections(parent *Object, sels []selection, depth int) {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *fieldNode:
+			v.field(parent, s, depth)
+		case *inlineFragment:
+			v.directives(s.directives)
+			if s.typeCond != "" && s.typeCond != parent.Name {
+				v.typeCondition(s.loc, "An inline fragment", s.typeCond, parent)
+				continue
+			}
+			v.selections(parent, s.selections, depth)
+		case *fragmentSpread:
+			v.directives(s.directives)
+			frag, ok := v.doc.fragments[s.name]
+			if !ok {
+				v.errorf(s.loc, "Unknown fragment %q.", s.name)
+				continue
+			}
+			if v.active[s.name] {
+				v.errorf(s.loc, "Cannot spread fragment %q within itself.", s.name)
+				continue
+			}
+			if frag.typeCond != parent.Name {
+				v.typeCondition(s.loc, fmt.Sprintf("Fragment %q", s.name), frag.typeCond, parent)
+				continue
+			}
+			v.active[s.name] = true
+			v.selections(parent, frag.selections, depth)
+			delete(v.active, s.name)
+		}
+	}
+}
+
+func (v *validator) typeCondition(loc Location, what, cond string, parent *Object) {
+	if _, known := v.schema.types[cond]; !known {
+		v.errorf(loc, "Unknown type %q.", cond)
+		return
+	}
+	v.errorf(loc, "%s cannot be spread here as objects of type %q can never be of type %q.", what, parent.Name, cond)
+}
+
+func (v *validator) field(parent *Object, f *fieldNode, depth int) {
+	v.directives(f.directives)
+	if depth > v.schema.maxDepth {
+		v.errorf(f.loc, "Query is nested deeper than %d levels.", v.schema.maxDepth)
+		return
+	}
+	if f.name == "__typename" {
+		if len(f.args) > 0 || len(f.selections) > 0 {
+			v.errorf(f.loc, "Field \"__typename\" takes no arguments or selections.")
+		}
+		return
+	}
+	def, ok := parent.Fields[f.name]
+	if !ok {
+		v.errorf(f.loc, "Cannot query field %q on type %q.", f.name, parent.Name)
+		return
+	}
+	v.arguments(fmt.Sprintf("%s.%s", parent.Name, f.name), def.Args, f.args, f.loc)
+
+	switch t := namedType(def.Type).(type) {
+	case *Object:
+		if len(f.selections) == 0 {
+			v.errorf(f.loc, "Field %q of type %q must have a selection of subfields.", f.name, def.Type)
+			return
+		}
+		v.selections(t, f.selections, depth+1)
+	default:
+		if len(f.selections) > 0 {
+			v.errorf(f.loc, "Field %q must not have a selection since type %q has no subfields.", f.name, def.Type)
+		}
+	}
+}
+
+func (v *validator) arguments(owner string, defs Args, args []*argNode, loc Location) {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg.name] = true
+		def, ok := defs[arg.name]
+		if !ok {
+			v.errorf(arg.loc, "Unknown argument %q on %s.", arg.name, owner)
+			continue
+		}
+		if missing := v.undefinedVariables(arg.value); len(missing) > 0 {
+			for _, name := range missing {
+				v.errorf(arg.loc, "Variable \"$%s\" is not defined.", name)
+			}
+			continue
+		}
+		if !hasVariables(arg.value) {
+			if _, err := coerceValue(def.Type, arg.value); err != nil {
+				v.errorf(arg.loc, "Argument %q on %s has an invalid value: %s", arg.name, owner, err.Error())
+			}
+		}
+	}
+
+	var names []string
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if def := defs[name]; isNonNull(def.Type) && def.Default == nil && !given[name] {
+			v.errorf(loc, "Argument %q of type %q is required on %s.", name, def.Type, owner)
+		}
+	}
+}
+
+func (v *validator) directives(dirs []*directive) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		v.arguments("@"+d.name, Args{"if": {Type: NewNonNull(Boolean)}}, d.args, d.loc)
+	}
+}
+
+func (v *validator) undefinedVariables(value interface{}) []string {
+	var missing []string
+	switch val := value.(type) {
+	case variableRef:
+		if _, ok := v.vars[string(val)]; !ok {
+			missing = append(missing, string(val))
+		}
+	case []interface{}:
+		for _, item := range val {
+			missing = append(missing, v.undefinedVariables(item)...)
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			missing = append(missing, v.undefinedVariables(item)...)
+		}
+	}
+	return missing
+}
+
+func hasVariables(value interface{}) bool {
+	switch val := value.(type) {
+	case variableRef:
+		return true
+	case []interface{}:
+		for _, item := range val {
+			if hasVariables(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			if hasVariables(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inputType resolves a variable's declared type
+func (s *Schema) inputType(ref *typeRef) (Type, error) {
+	var t Type
+	if ref.elem != nil {
+		elem, err := s.inputType(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = NewList(elem)
+	} else {
+		named, ok := s.types[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", ref.name)
+		}
+		if _, isObject := named.(*Object); isObject {
+			return nil, fmt.Errorf("type %q is not an input type", ref.name)
+		}
+		t = named
+	}
+	if ref.nonNull {
+		t = NewNonNull(t)
+	}
+	return t, nil
+}
+
+// coerceVariables checks the request's variables against their declared
+// types. Values are kept as given and coerced again with the arguments
+// they are used in.
+func (s *Schema) coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, []*Error) {
+	vars := make(map[string]interface{}, len(op.vars))
+	var errs []*Error
+	for _, def := range op.vars {
+		t, _ := s.inputType(def.typ) // Checked when validating
+		value, ok := given[def.name]
+		if !ok {
+			if def.hasDef {
+				vars[def.name] = def.def
+			} else if isNonNull(t) {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", def.name, t), Locations: []Location{def.loc}})
+			}
+			continue
+		}
+		if _, err := coerceValue(t, value); err != nil {
+			errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %s", def.name, err.Error()), Locations: []Location{def.loc}})
+			continue
+		}
+		vars[def.name] = value
+	}
+	return vars, errs
+}
+
+// coerceValue coerces an input value, with variables already substituted,
+// to t
+func coerceValue(t Type, value interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected a non-null %s", nn.OfType)
+		}
+		return coerceValue(nn.OfType, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch tt := t.(type) {
+	case *List:
+		items, ok := value.([]interface{})
+		if !ok {
+			item, err := coerceValue(tt.OfType, value)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			coerced, err := coerceValue(tt.OfType, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = coerced
+		}
+		return out, nil
+	case *Scalar:
+		if _, isEnum := value.(enumLiteral); isEnum {
+			return nil, fmt.Errorf("expected a %s, got %s", tt.Name, describe(value))
+		}
+		return tt.Parse(value)
+	case *Enum:
+		var name string
+		switch v := value.(type) {
+		case enumLiteral:
+			name = string(v)
+		case string:
+			name = v
+		default:
+			return nil, fmt.Errorf("expected a %s, got %s", tt.Name, describe(value))
+		}
+		if !tt.has(name) {
+			return nil, fmt.Errorf("%q is not a %s", name, tt.Name)
+		}
+		return name, nil
+	case *InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a %s, got %s", tt.Name, describe(value))
+		}
+		for name := range fields {
+			if _, known := tt.Fields[name]; !known {
+				return nil, fmt.Errorf("%q is not a field of %s", name, tt.Name)
+			}
+		}
+		out := make(map[string]interface{}, len(tt.Fields))
+		for name, def := range tt.Fields {
+			fieldValue, ok := fields[name]
+			if !ok {
+				if def.Default != nil {
+					out[name] = def.Default
+				} else if isNonNull(def.Type) {
+					return nil, fmt.Errorf("%s.%s is required", tt.Name, name)
+				}
+				continue
+			}
+			coerced, err := coerceValue(def.Type, fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", tt.Name, name, err)
+			}
+			out[name] = coerced
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
@@ -0,0 +1,232 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// maxBodyBytes caps a request body
+const maxBodyBytes = 1 << 20
+
+// timestampName is the full name of google.protobuf.Timestamp, which query
+// and path values set from a date or RFC 3339 time
+const timestampName = "google.protobuf.Timestamp"
+
+var unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// Unary serves a unary gRPC method. The request message is bound from the
+// request by Bind and the method's response is the route's response.
+func Unary[Req any, PReq interface {
+	*Req
+	proto.Message
+}, Resp proto.Message](call func(context.Context, PReq, ...grpc.CallOption) (Resp, error)) HandlerFunc {
+	return func(r *http.Request, params Params) (proto.Message, error) {
+		req := PReq(new(Req))
+		if err := Bind(r, params, req); err != nil {
+			return nil, err
+		}
+		resp, err := call(r.Context(), req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// Bind fills msg from the request: the JSON body of a POST, PUT or PATCH,
+// then the query string, then the path parameters, each overriding the
+// last. Query parameters name a field by its proto or JSON name; ones that
+// match no field are ignored. Repeated fields take repeated or
+// comma-separated values, enums their value name with or without the type
+// prefix (status=in_progress for TRIP_STATUS_IN_PROGRESS), and timestamps a
+// date or an RFC 3339 time.
+func Bind(r *http.Request, params Params, msg proto.Message) error {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		if err != nil {
+			return apperrors.ValidationError("invalid request body", "body", nil)
+		}
+		if len(body) > maxBodyBytes {
+			return apperrors.ValidationError("request body is too large", "body", nil)
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := unmarshalOptions.Unmarshal(body, msg); err != nil {
+				return apperrors.ValidationError("invalid request body", "body", nil).WithDetail("reason", err.Error())
+			}
+		}
+	}
+
+	for key, values := range r.URL.Query() {
+		fd := field(msg, key)
+		if fd == nil {
+			continue
+		}
+		if err := setField(msg, fd, values); err != nil {
+			return apperrors.ValidationError("invalid "+key+": "+err.Error(), key, strings.Join(values, ","))
+		}
+	}
+
+	for name, value := range params {
+		fd := field(msg, name)
+		if fd == nil {
+			return fmt.Errorf("path parameter %s is not a field of %s", name, msg.ProtoReflect().Descriptor().FullName())
+		}
+		if err := setField(msg, fd, []string{value}); err != nil {
+			return apperrors.ValidationError("invalid "+name+": "+err.Error(), name, value)
+		}
+	}
+	return nil
+}
+
+func field(msg proto.Message, name string) protoreflect.FieldDescriptor {
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(name)
+}
+
+func setField(msg proto.Message, fd protoreflect.FieldDescriptor, values []string) error {
+	m := msg.ProtoReflect()
+	if fd.IsMap() {
+		return fmt.Errorf("map fields cannot be set from the query string")
+	}
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		for _, raw := range values {
+			for _, part := range strings.Split(raw, ",") {
+				if part = strings.TrimSpace(part); part == "" {
+					continue
+				}
+				value, err := parseValue(fd, part)
+				if err != nil {
+					return err
+				}
+				list.Append(value)
+			}
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	value, err := parseValue(fd, strings.TrimSpace(values[len(values)-1]))
+	if err != nil {
+		return err
+	}
+	m.Set(fd, value)
+	return nil
+}
+
+func parseValue(fd protoreflect.FieldDescriptor, raw string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(raw), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected true or false")
+		}
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a whole number")
+		}
+		return protoreflect.ValueOfInt32(int32(v)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a whole number")
+		}
+		return protoreflect.ValueOfInt64(v), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a whole number")
+		}
+		return protoreflect.ValueOfUint32(uint32(v)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a whole number")
+		}
+		return protoreflect.ValueOfUint64(v), nil
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(raw, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a number")
+		}
+		return protoreflect.ValueOfFloat32(float32(v)), nil
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a number")
+		}
+		return protoreflect.ValueOfFloat64(v), nil
+	case protoreflect.EnumKind:
+		number, err := enumNumber(fd.Enum(), raw)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfEnum(number), nil
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() == timestampName {
+			t, err := parseTime(raw)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfMessage(timestamppb.New(t).ProtoReflect()), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("%s fields cannot be set from the query string", fd.Kind())
+}
+
+// enumNumber looks up an enum value by its name, its name without the
+// prefix the type's UNSPECIFIED value carries, or its number
+func enumNumber(ed protoreflect.EnumDescriptor, raw string) (protoreflect.EnumNumber, error) {
+	values := ed.Values()
+	name := strings.ToUpper(strings.ReplaceAll(raw, "-", "_"))
+	if v := values.ByName(protoreflect.Name(name)); v != nil {
+		return v.Number(), nil
+	}
+	if zero := values.ByNumber(0); zero != nil {
+		if prefix := strings.TrimSuffix(string(zero.Name()), "UNSPECIFIED"); prefix != string(zero.Name()) {
+			if v := values.ByName(protoreflect.Name(prefix + name)); v != nil {
+				return v.Number(), nil
+			}
+		}
+	}
+	if n, err := strconv.Atoi(raw); err == nil {
+		if v := values.ByNumber(protoreflect.EnumNumber(n)); v != nil {
+			return v.Number(), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q", raw)
+}
+
+// parseTime reads a date as local midnight, or an RFC 3339 time
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 time")
+	}
+	return t, nil
+}
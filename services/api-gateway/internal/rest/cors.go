@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsHeaders are the request headers browsers may send cross-origin
+const corsHeaders = "Authorization, Content-Type, X-User-ID"
+
+// corsMethods are the methods browsers may use cross-origin
+const corsMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// CORS lets browsers on the allowed origins call next and answers their
+// preflight requests. An origin of "*" allows any.
+func CORS(origins []string, next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !(allowed["*"] || allowed[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", corsMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,335 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// listRequest builds a message shaped like the services' list requests:
+//
+//	message ListRequest {
+//	  string id = 1;
+//	  Status status = 2;          // STATUS_UNSPECIFIED, STATUS_PENDING, STATUS_IN_PROGRESS
+//	  int32 page = 3;
+//	  repeated string tags = 4;
+//	  google.protobuf.Timestamp date_from = 5;
+//	  bool match_all_tags = 6;
+//	}
+func listRequest(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName(name)),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	enumValue := func(name string, number int32) *descriptorpb.EnumValueDescriptorProto {
+		return &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(number)}
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("gateway_test.proto"),
+		Package:    proto.String("gateway.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				enumValue("STATUS_UNSPECIFIED", 0),
+				enumValue("STATUS_PENDING", 1),
+				enumValue("STATUS_IN_PROGRESS", 2),
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("ListRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+				field("status", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".gateway.test.Status", false),
+				field("page", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", false),
+				field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", true),
+				field("date_from", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", false),
+				field("match_all_tags", 6, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", false),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+	return dynamicpb.NewMessage(fd.Messages().ByName("ListRequest"))
+}
+
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func get(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func TestBindQueryAndPath(t *testing.T) {
+	msg := listRequest(t)
+	r := httptest.NewRequest(http.MethodGet, "/v1/things/abc?status=in_progress&page=3&tags=hot,vip&tags=reefer&dateFrom=2026-03-02&match_all_tags=true&unknown=x&id=ignored", nil)
+
+	if err := Bind(r, Params{"id": "abc-123"}, msg); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	if got := get(msg, "id").String(); got != "abc-123" {
+		t.Errorf("id = %q, want the path parameter to win over the query", got)
+	}
+	if got := get(msg, "status").Enum(); got != 2 {
+		t.Errorf("status = %d, want STATUS_IN_PROGRESS (2)", got)
+	}
+	if got := get(msg, "page").Int(); got != 3 {
+		t.Errorf("page = %d, want 3", got)
+	}
+	tags := get(msg, "tags").List()
+	if tags.Len() != 3 || tags.Get(0).String() != "hot" || tags.Get(1).String() != "vip" || tags.Get(2).String() != "reefer" {
+		t.Errorf("tags = %v, want [hot vip reefer]", tags)
+	}
+	if !get(msg, "match_all_tags").Bool() {
+		t.Error("match_all_tags = false, want true")
+	}
+	ts := get(msg, "date_from").Message().Interface()
+	want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	if got := timestampOf(t, ts); !got.Equal(want) {
+		t.Errorf("date_from = %v, want %v", got, want)
+	}
+}
+
+func timestampOf(t *testing.T, m proto.Message) time.Time {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal timestamp: %v", err)
+	}
+	var ts timestamppb.Timestamp
+	if err := proto.Unmarshal(b, &ts); err != nil {
+		t.Fatalf("unmarshal timestamp: %v", err)
+	}
+	return ts.AsTime()
+}
+
+func TestBindBody(t *testing.T) {
+	msg := listRequest(t)
+	r := httptest.NewRequest(http.MethodPost, "/v1/things?page=2", strings.NewReader(`{"status":"STATUS_PENDING","page":7,"tags":["a"],"extra":true}`))
+
+	if err := Bind(r, nil, msg); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if got := get(msg, "status").Enum(); got != 1 {
+		t.Errorf("status = %d, want STATUS_PENDING (1)", got)
+	}
+	if got := get(msg, "page").Int(); got != 2 {
+		t.Errorf("page = %d, want the query to override the body", got)
+	}
+	if got := get(msg, "tags").List().Len(); got != 1 {
+		t.Errorf("tags has %d values, want 1", got)
+	}
+}
+
+func TestBindRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		field  string
+	}{
+		{"enum", http.MethodGet, "/v1/things?status=shipped", "", "status"},
+		{"int", http.MethodGet, "/v1/things?page=two", "", "page"},
+		{"time", http.MethodGet, "/v1/things?date_from=03/02/2026", "", "date_from"},
+		{"body", http.MethodPost, "/v1/things", `{"page":`, "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			err := Bind(r, nil, listRequest(t))
+			appErr, ok := err.(*apperrors.AppError)
+			if !ok || appErr.Code != "VALIDATION_ERROR" {
+				t.Fatalf("Bind error = %v, want a validation error", err)
+			}
+			if appErr.Details["field"] != tt.field {
+				t.Errorf("field = %v, want %s", appErr.Details["field"], tt.field)
+			}
+		})
+	}
+}
+
+func serve(t *testing.T, rt *Router, method, target string, header http.Header) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+
+	var body map[string]interface{}
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("response is not JSON: %s", w.Body.String())
+		}
+	}
+	return w, body
+}
+
+func TestRouterMatchesAndForwardsUser(t *testing.T) {
+	rt := NewRouter(time.Second, logger.Default())
+	rt.Handle(http.MethodGet, "/v1/trips/active", func(r *http.Request, params Params) (proto.Message, error) {
+		return structpb.NewStruct(map[string]interface{}{"value": "active"})
+	})
+	rt.Handle(http.MethodGet, "/v1/trips/{id}", func(r *http.Request, params Params) (proto.Message, error) {
+		md, _ := metadata.FromOutgoingContext(r.Context())
+		if got := md.Get("x-user-id"); len(got) != 1 || got[0] != "ops-7" {
+			t.Errorf("x-user-id metadata = %v, want [ops-7]", got)
+		}
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("upstream call has no deadline")
+		}
+		return structpb.NewStruct(map[string]interface{}{"value": params["id"]})
+	})
+
+	w, body := serve(t, rt, http.MethodGet, "/v1/trips/active", nil)
+	if w.Code != http.StatusOK || body["value"] != "active" {
+		t.Errorf("GET /v1/trips/active = %d %v, want the fixed route", w.Code, body)
+	}
+
+	w, body = serve(t, rt, http.MethodGet, "/v1/trips/T-100/", http.Header{"X-User-Id": {"ops-7"}})
+	if w.Code != http.StatusOK || body["value"] != "T-100" {
+		t.Errorf("GET /v1/trips/T-100 = %d %v, want the id route", w.Code, body)
+	}
+
+	w, _ = serve(t, rt, http.MethodDelete, "/v1/trips/T-100", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET" {
+		t.Errorf("DELETE = %d Allow %q, want 405 Allow GET", w.Code, w.Header().Get("Allow"))
+	}
+
+	w, body = serve(t, rt, http.MethodGet, "/v1/orders", nil)
+	if w.Code != http.StatusNotFound || body["code"] != "NOT_FOUND" {
+		t.Errorf("unknown path = %d %v, want 404 NOT_FOUND", w.Code, body)
+	}
+}
+
+func TestRouterMapsUpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "application error keeps its code and details",
+			err: grpcerrors.ToStatus(apperrors.ConflictError("driver is already assigned to an overlapping trip").
+				WithDetail("conflicting_trips", []string{"TRP-1", "TRP-2"})),
+			wantStatus: http.StatusConflict,
+			wantCode:   "CONFLICT",
+		},
+		{
+			name:       "service-specific code",
+			err:        grpcerrors.ToStatus(apperrors.New("INSUFFICIENT_PTO", "not enough PTO")),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "INSUFFICIENT_PTO",
+		},
+		{
+			name:       "plain status",
+			err:        status.Error(codes.InvalidArgument, "invalid driver_id"),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "VALIDATION_ERROR",
+		},
+		{
+			name:       "service down",
+			err:        status.Error(codes.Unavailable, "connection refused"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "SERVICE_UNAVAILABLE",
+		},
+		{
+			name:       "internal error hides its cause",
+			err:        grpcerrors.ToStatus(apperrors.DatabaseError("get trip", errors.New("connection reset by peer"))),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := NewRouter(time.Second, logger.Default())
+			rt.Handle(http.MethodGet, "/v1/trips/{id}", func(r *http.Request, params Params) (proto.Message, error) {
+				return nil, tt.err
+			})
+
+			w, body := serve(t, rt, http.MethodGet, "/v1/trips/T-100", nil)
+			if w.Code != tt.wantStatus || body["code"] != tt.wantCode {
+				t.Fatalf("got %d %v, want %d %s", w.Code, body, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantCode == "CONFLICT" {
+				details, _ := body["details"].(map[string]interface{})
+				trips, _ := details["conflicting_trips"].([]interface{})
+				if len(trips) != 2 || trips[0] != "TRP-1" {
+					t.Errorf("details = %v, want the conflicting trips restored", body["details"])
+				}
+			}
+			if tt.wantCode == "INTERNAL_ERROR" && body["message"] != "internal error" {
+				t.Errorf("message = %v, want the cause hidden", body["message"])
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := CORS([]string{"http://localhost:3000"}, next)
+
+	r := httptest.NewRequest(http.MethodOptions, "/v1/trips", nil)
+	r.Header.Set("Origin", "http://localhost:3000")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Errorf("preflight = %d %v, want 204 allowing the origin", w.Code, w.Header())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/trips", nil)
+	r.Header.Set("Origin", "https://elsewhere.example")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("other origins must not be allowed")
+	}
+}
@@ -0,0 +1,184 @@
+// Package rest serves gRPC methods as REST+JSON. Routes bind the request
+// message from the path, query string and body, and answer with the
+// response message as JSON or the upstream error with the status its
+// application error code maps to.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// forwardedHeaders are the request headers passed on to the services as
+// gRPC metadata
+var forwardedHeaders = map[string]string{
+	"Authorization": "authorization",
+	"X-User-ID":     "x-user-id",
+}
+
+// marshalOptions writes field names as in the protos, and every field even
+// when unset so clients see a stable shape
+var marshalOptions = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// Params are a route's path parameters, by the request field they set
+type Params map[string]string
+
+// HandlerFunc answers a matched request with a response message
+type HandlerFunc func(r *http.Request, params Params) (proto.Message, error)
+
+// Router matches requests against path templates such as
+// /v1/trips/{trip_id}/assign, where {trip_id} matches one path segment and
+// sets the request field of that name. Routes are tried in the order they
+// were added, so fixed paths go before templates that would also match.
+type Router struct {
+	routes  []route
+	timeout time.Duration
+	logger  *logger.Logger
+}
+
+type route struct {
+	method   string
+	segments []string
+	handler  HandlerFunc
+}
+
+// NewRouter creates a router whose upstream calls are cut off after timeout
+func NewRouter(timeout time.Duration, log *logger.Logger) *Router {
+	return &Router{timeout: timeout, logger: log}
+}
+
+// Handle adds a route for method and path template
+func (rt *Router) Handle(method, pattern string, handler HandlerFunc) {
+	rt.routes = append(rt.routes, route{
+		method:   method,
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+// ServeHTTP calls the first route matching the request
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+	var allowed []string
+	for _, rte := range rt.routes {
+		params, ok := rte.match(segments)
+		if !ok {
+			continue
+		}
+		if rte.method != r.Method {
+			allowed = append(allowed, rte.method)
+			continue
+		}
+		rt.serve(w, r, rte, params)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeJSON(w, http.StatusMethodNotAllowed, apperrors.New("METHOD_NOT_ALLOWED", r.Method+" is not allowed on this path"))
+		return
+	}
+	writeJSON(w, http.StatusNotFound, apperrors.NotFoundError("route", r.URL.Path))
+}
+
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request, rte route, params Params) {
+	ctx, cancel := context.WithTimeout(r.Context(), rt.timeout)
+	defer cancel()
+	ctx = OutgoingContext(ctx, r)
+
+	resp, err := rte.handler(r.WithContext(ctx), params)
+	if err != nil {
+		rt.writeError(w, r, err)
+		return
+	}
+
+	body, err := marshalOptions.Marshal(resp)
+	if err != nil {
+		rt.writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// writeError answers with the application error behind err: one raised
+// while binding the request, or the one the service returned over gRPC
+func (rt *Router) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = grpcerrors.FromStatus(err)
+	}
+
+	status := apperrors.HTTPStatus(appErr.Code)
+	if status >= http.StatusInternalServerError {
+		rt.logger.Errorw("Gateway request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"code", appErr.Code,
+			"error", err,
+		)
+	}
+	writeJSON(w, status, appErr)
+}
+
+// OutgoingContext passes the request's forwarded headers on to the
+// services as gRPC metadata on calls made with the returned context
+func OutgoingContext(ctx context.Context, r *http.Request) context.Context {
+	var pairs []string
+	for header, key := range forwardedHeaders {
+		if value := strings.TrimSpace(r.Header.Get(header)); value != "" {
+			pairs = append(pairs, key, value)
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func (rte route) match(segments []string) (Params, bool) {
+	if len(segments) != len(rte.segments) {
+		return nil, false
+	}
+	var params Params
+	for i, segment := range rte.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if params == nil {
+				params = make(Params)
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
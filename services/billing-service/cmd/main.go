@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/crypto"
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/document"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+
+	"github.com/draymaster/services/billing-service/internal/api"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	"github.com/draymaster/services/billing-service/internal/service"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+const (
+	// reconciliationInterval is how often trips are reconciled against billing
+	reconciliationInterval = 24 * time.Hour
+	// invoicingInterval is how often completed orders are invoiced
+	invoicingInterval = 24 * time.Hour
+)
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "billing-service"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting billing service",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Database
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+	log.Info("Database connected")
+
+	// Kafka
+	producer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
+	defer producer.Close()
+	log.Info("Kafka producer initialized")
+
+	blobStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalw("Failed to initialize blob storage", "error", err)
+	}
+	documents := document.NewGenerator(nil, nil, document.NewChromiumRenderer(cfg.Documents), log)
+
+	// Repositories
+	statementRepo := repository.NewPostgresStatementRepository(db.Pool)
+	rateRepo := repository.NewPostgresRateRepository(db.Pool)
+	rateTableRepo := repository.NewPostgresRateTableRepository(db.Pool)
+	splitRepo := repository.NewPostgresSplitBillingRepository(db.Pool)
+
+	// Payee tax IDs are envelope-encrypted when master keys are configured:
+	// a Vault transit key (BILLING_PII_KMS=vault) or a local key ring
+	// ("id:base64key,..." with the current key first)
+	var taxIDs *crypto.FieldEncryptor
+	if keys := billingPIIKeys(log); keys != nil {
+		taxIDs = crypto.NewFieldEncryptor(keys)
+	} else {
+		log.Warn("BILLING_PII_KEYS not set, payee tax IDs will be stored unencrypted")
+	}
+
+	// Services
+	statementService := service.NewStatementService(statementRepo, documents, log)
+	disputeService := service.NewDisputeService(repository.NewPostgresDisputeRepository(db.Pool), blobStore, log)
+	rateService := service.NewRateService(rateRepo, rateTableRepo, producer, log)
+	reconService := service.NewReconciliationService(repository.NewPostgresReconciliationRepository(db.Pool), producer, log)
+	rateTableService := service.NewRateTableService(rateRepo, rateTableRepo, log)
+	splitService := service.NewSplitBillingService(splitRepo, log)
+	terminalFeeService := service.NewTerminalFeeService(repository.NewPostgresTerminalFeeRepository(db.Pool), log)
+	taxReportingService := service.NewTaxReportingService(repository.NewPostgresTaxReportingRepository(db.Pool, taxIDs), log)
+	invoiceService := service.NewInvoiceService(
+		repository.NewPostgresInvoiceRepository(db.Pool),
+		rateRepo,
+		rateTableRepo,
+		splitRepo,
+		statementRepo,
+		service.NewInvoiceDocumentService(documents, log),
+		blobStore,
+		producer,
+		log,
+	)
+
+	// Nightly jobs run on one replica at a time; the others stand by to take over
+	elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+	go elector.Run(ctx, "billing.reconciliation", func(ctx context.Context) {
+		reconService.Start(ctx, reconciliationInterval)
+	})
+	go elector.Run(ctx, "billing.invoicing", func(ctx context.Context) {
+		invoiceService.Start(ctx, invoicingInterval)
+	})
+	log.Infow("Nightly jobs started", "reconciliation", reconciliationInterval, "invoicing", invoicingInterval)
+
+	// Terminal fees are attached as dispatch reports the stops that incur them
+	stopDepartedConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name+"-terminal-fees", kafka.Topics.StopDeparted, log)
+	defer stopDepartedConsumer.Close()
+	go func() {
+		if err := stopDepartedConsumer.Consume(ctx, terminalFeeService.HandleStopDeparted); err != nil && ctx.Err() == nil {
+			log.Errorw("Stop departed consumer stopped", "error", err)
+		}
+	}()
+
+	// HTTP API
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      api.NewHandler(statementService, disputeService, rateService, rateTableService, reconService, splitService, invoiceService, terminalFeeService, taxReportingService, log).Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("Billing service stopped")
+}
+
+// billingPIIKeys returns the master key provider for payee tax IDs, or nil
+// when none is configured
+func billingPIIKeys(log *logger.Logger) crypto.KeyProvider {
+	if os.Getenv("BILLING_PII_KMS") == "vault" {
+		keyID := os.Getenv("BILLING_PII_KMS_KEY")
+		if keyID == "" || os.Getenv("VAULT_ADDR") == "" {
+			log.Fatal("BILLING_PII_KMS=vault requires VAULT_ADDR and BILLING_PII_KMS_KEY")
+		}
+		vault := crypto.NewVaultTransit(crypto.VaultConfig{
+			Address: os.Getenv("VAULT_ADDR"),
+			Token:   os.Getenv("VAULT_TOKEN"),
+			Mount:   os.Getenv("VAULT_TRANSIT_MOUNT"),
+		})
+		return crypto.NewKMSKeyProvider(vault, keyID)
+	}
+	if keyRing := os.Getenv("BILLING_PII_KEYS"); keyRing != "" {
+		keys, err := crypto.ParseKeyRing(keyRing)
+		if err != nil {
+			log.Fatalw("Invalid BILLING_PII_KEYS", "error", err)
+		}
+		return keys
+	}
+	return nil
+}
@@ -0,0 +1,1330 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/service"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// userHeader carries the authenticated user ID, set by the API gateway
+	userHeader = "X-User-ID"
+	// customerHeader carries the customer a customer portal user belongs to,
+	// set by the API gateway for portal sessions only
+	customerHeader = "X-Customer-ID"
+	// tenantHeader selects the tenant whose templates and branding render documents
+	tenantHeader = "X-Tenant-ID"
+	// periodLayout is the format of statement periods in URLs, e.g. "2026-09"
+	periodLayout = "2006-01"
+	// maxAttachmentBytes caps a dispute attachment upload
+	maxAttachmentBytes = 25 << 20
+)
+
+// Handler serves the billing HTTP API
+type Handler struct {
+	statements   *service.StatementService
+	disputes     *service.DisputeService
+	rates        *service.RateService
+	rateTables   *service.RateTableService
+	recon        *service.ReconciliationService
+	splits       *service.SplitBillingService
+	invoices     *service.InvoiceService
+	terminalFees *service.TerminalFeeService
+	taxReporting *service.TaxReportingService
+	logger       *logger.Logger
+}
+
+// NewHandler creates a new billing HTTP handler
+func NewHandler(statements *service.StatementService, disputes *service.DisputeService, rates *service.RateService, rateTables *service.RateTableService, recon *service.ReconciliationService, splits *service.SplitBillingService, invoices *service.InvoiceService, terminalFees *service.TerminalFeeService, taxReporting *service.TaxReportingService, log *logger.Logger) *Handler {
+	return &Handler{statements: statements, disputes: disputes, rates: rates, rateTables: rateTables, recon: recon, splits: splits, invoices: invoices, terminalFees: terminalFees, taxReporting: taxReporting, logger: log}
+}
+
+// Routes returns the HTTP routes for the API
+//
+//	POST     /v1/statements/{period}                              generate for every customer with activity
+//	GET      /v1/customers/{id}/statements
+//	GET      /v1/customers/{id}/statements/{period}               (?format=pdf for a download)
+//	POST     /v1/customers/{id}/statements/{period}               generate or regenerate
+//	GET      /v1/invoices                                         (?status=&customer_id=&from=&to=&limit=)
+//	POST     /v1/invoices/generate                                staff only; (?customer_id=&completed_before=)
+//	                                                              invoice completed, unbilled orders
+//	GET      /v1/invoices/{id}                                    with line items
+//	GET      /v1/invoices/{id}/document                           the invoice PDF
+//	GET      /v1/invoices/{id}/disputes
+//	POST     /v1/invoices/{id}/disputes                           open a dispute against a line item
+//	GET      /v1/disputes                                         (?status=&customer_id=&limit=)
+//	GET      /v1/disputes/{id}
+//	POST     /v1/disputes/{id}/review                             staff only
+//	POST     /v1/disputes/{id}/resolve                            staff only; CREDITED issues a credit memo
+//	POST     /v1/disputes/{id}/attachments                        (?file_name=; raw file body)
+//	GET      /v1/disputes/{id}/attachments/{attachmentID}         the file itself
+//	GET      /v1/rates/contract                                   (?customer_id=&origin_id=&destination_id=
+//	                                                              &container_size=&container_type=&at=) the
+//	                                                              contract rate that prices the move
+//	POST     /v1/rates/quote                                      expected revenue for a move: lane rate,
+//	                                                              fuel and expected accessorials
+//	GET      /v1/rates/accessorial                                (?customer_id=&at=) the price list
+//	POST     /v1/rates/accessorial                                add a charge type to a price list
+//	GET      /v1/rates/expiring                                   (?within_days=, default 30)
+//	POST     /v1/rates/expiration-alerts                          (?within_days=) alert rates not yet alerted
+//	GET      /v1/rates/{id}/amendments
+//	POST     /v1/rates/{id}/amendments                            new version from effective_date; re-rates
+//	                                                              charges since then (dry_run to preview)
+//	GET      /v1/rate-amendments/{id}                             with the re-rated charges
+//	GET      /v1/rate-zones
+//	POST     /v1/rate-zones
+//	PUT      /v1/rate-zones/{id}                                  name and members; the code is fixed
+//	GET      /v1/rate-contracts                                   (?customer_id=)
+//	POST     /v1/rate-contracts                                   with its lane rates
+//	GET      /v1/rate-contracts/{id}                              with current lane rates
+//	GET      /v1/fuel-schedules                                   (?customer_id=) with the defaults
+//	POST     /v1/fuel-schedules
+//	GET      /v1/fuel-schedules/{id}
+//	GET      /v1/fuel-prices                                      (?region=&limit=) newest first
+//	POST     /v1/fuel-prices                                      record a published diesel price
+//	GET      /v1/terminal-fees                                    (?terminal_id=) every version
+//	POST     /v1/terminal-fees                                    ends the fee it replaces
+//	PUT      /v1/terminal-fees/{id}                               description and end date only
+//	GET      /v1/fee-pass-through                                 (?customer_id=)
+//	PUT      /v1/fee-pass-through                                 AT_COST, MARKUP or ABSORB per fee type
+//	GET      /v1/orders/{id}/terminal-fees                        fees attached to the order
+//	GET      /v1/reconciliation/runs                              (?limit=) most recent first
+//	POST     /v1/reconciliation/runs                              (?from=&to=, RFC 3339) default is the
+//	                                                              nightly window
+//	GET      /v1/reconciliation/issues                            (?status=&type=&trip_id=&customer_id=&limit=)
+//	POST     /v1/reconciliation/issues/{id}/close                 RESOLVED or DISMISSED, with notes
+//	GET      /v1/trips/{id}/split-billing                         per-stop invoice lines for containers
+//	                                                              delivered across several stops
+//	POST     /v1/payee-entities                                   an owner-operator's W-9; the tax ID is
+//	                                                              stored encrypted and never returned
+//	GET      /v1/tax-reports/{year}/1099-nec                      1099-NEC data from paid settlements
+//
+// Requests from the customer portal carry X-Customer-ID and only reach that
+// customer's invoices and disputes. Rate, rate table, reconciliation, split
+// billing and tax reporting endpoints are for billing staff only.
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	mux.HandleFunc("/v1/statements/", h.monthlyStatements)
+	mux.HandleFunc("/v1/customers/", h.customerStatements)
+	mux.HandleFunc("/v1/invoices", h.listInvoices)
+	mux.HandleFunc("/v1/invoices/", h.invoice)
+	mux.HandleFunc("/v1/disputes", h.listDisputes)
+	mux.HandleFunc("/v1/disputes/", h.dispute)
+	mux.HandleFunc("/v1/rates/", h.rate)
+	mux.HandleFunc("/v1/rate-amendments/", h.rateAmendment)
+	mux.HandleFunc("/v1/rate-zones", h.rateZones)
+	mux.HandleFunc("/v1/rate-zones/", h.rateZones)
+	mux.HandleFunc("/v1/rate-contracts", h.rateContracts)
+	mux.HandleFunc("/v1/rate-contracts/", h.rateContracts)
+	mux.HandleFunc("/v1/fuel-schedules", h.fuelSchedules)
+	mux.HandleFunc("/v1/fuel-schedules/", h.fuelSchedules)
+	mux.HandleFunc("/v1/fuel-prices", h.fuelPrices)
+	mux.HandleFunc("/v1/terminal-fees", h.terminalFeeSchedule)
+	mux.HandleFunc("/v1/terminal-fees/", h.terminalFeeSchedule)
+	mux.HandleFunc("/v1/fee-pass-through", h.feePassThrough)
+	mux.HandleFunc("/v1/orders/", h.orderTerminalFees)
+	mux.HandleFunc("/v1/reconciliation/", h.reconciliation)
+	mux.HandleFunc("/v1/trips/", h.tripSplitBilling)
+	mux.HandleFunc("/v1/payee-entities", h.payeeEntities)
+	mux.HandleFunc("/v1/tax-reports/", h.taxReports)
+
+	return mux
+}
+
+func (h *Handler) monthlyStatements(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/statements/")
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	period, ok := h.parsePeriod(w, parts[0])
+	if !ok {
+		return
+	}
+
+	generated, err := h.statements.GenerateMonthlyStatements(r.Context(), period.Year(), period.Month(), user)
+	h.respond(w, map[string]interface{}{"period": parts[0], "generated": generated}, err)
+}
+
+func (h *Handler) customerStatements(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/customers/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != "statements" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	customerID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		statements, err := h.statements.ListStatements(r.Context(), customerID)
+		h.respond(w, statements, err)
+		return
+	}
+
+	period, ok := h.parsePeriod(w, parts[2])
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		stmt, err := h.statements.GetStatement(r.Context(), customerID, period.Year(), period.Month())
+		if err != nil || r.URL.Query().Get("format") != "pdf" {
+			h.respond(w, stmt, err)
+			return
+		}
+		doc, err := h.statements.RenderStatement(r.Context(), r.Header.Get(tenantHeader), stmt)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", doc.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(doc.Data)
+	case http.MethodPost:
+		stmt, err := h.statements.GenerateStatement(r.Context(), customerID, period.Year(), period.Month(), user)
+		h.respondCreated(w, stmt, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// INVOICES
+// ============================================================================
+
+func (h *Handler) listInvoices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	portalCustomer, ok := h.portalCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.InvoiceFilter{Status: domain.InvoiceStatus(strings.ToUpper(query.Get("status")))}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.CustomerID = &customerID
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := query.Get(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				h.writeError(w, apperrors.ValidationError(param+" must be an RFC 3339 time", param, raw))
+				return
+			}
+			*target = &t
+		}
+	}
+	limit, ok := h.limit(w, r)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+
+	invoices, err := h.invoices.ListInvoices(r.Context(), filter, portalCustomer)
+	h.respond(w, invoices, err)
+}
+
+func (h *Handler) invoice(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/invoices/")
+	switch {
+	case len(parts) == 1 && parts[0] == "generate":
+		h.generateInvoices(w, r)
+		return
+	case len(parts) == 2 && parts[1] == "disputes":
+		h.invoiceDisputes(w, r)
+		return
+	case len(parts) == 0 || len(parts) > 2 || (len(parts) == 2 && parts[1] != "document"):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	portalCustomer, ok := h.portalCustomer(w, r)
+	if !ok {
+		return
+	}
+	invoiceID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		invoice, err := h.invoices.GetInvoice(r.Context(), invoiceID, portalCustomer)
+		h.respond(w, invoice, err)
+		return
+	}
+
+	invoice, body, err := h.invoices.OpenInvoiceDocument(r.Context(), invoiceID, portalCustomer, r.Header.Get(tenantHeader))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.InvoiceNumber+".pdf"))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		h.logger.Warnw("Invoice download interrupted", "invoice_id", invoiceID, "error", err)
+	}
+}
+
+func (h *Handler) generateInvoices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	input := service.GenerateInvoicesInput{TenantID: r.Header.Get(tenantHeader), GeneratedBy: user}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		input.CustomerID = &customerID
+	}
+	if raw := query.Get("completed_before"); raw != "" {
+		before, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("completed_before must be an RFC 3339 time", "completed_before", raw))
+			return
+		}
+		input.CompletedBefore = before
+	}
+
+	run, err := h.invoices.GenerateInvoices(r.Context(), input)
+	h.respondCreated(w, run, err)
+}
+
+// ============================================================================
+// DISPUTES
+// ============================================================================
+
+func (h *Handler) invoiceDisputes(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	portalCustomer, ok := h.portalCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/invoices/")
+	invoiceID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		disputes, err := h.disputes.ListDisputes(r.Context(), domain.DisputeFilter{InvoiceID: &invoiceID}, portalCustomer)
+		h.respond(w, disputes, err)
+	case http.MethodPost:
+		var input service.OpenDisputeInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.InvoiceID = invoiceID
+		input.PortalCustomerID = portalCustomer
+		input.OpenedBy = user
+		dispute, err := h.disputes.OpenDispute(r.Context(), input)
+		h.respondCreated(w, dispute, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listDisputes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	portalCustomer, ok := h.portalCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.DisputeFilter{Status: domain.DisputeStatus(strings.ToUpper(query.Get("status")))}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.CustomerID = &customerID
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			h.writeError(w, apperrors.ValidationError("invalid limit", "limit", raw))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	disputes, err := h.disputes.ListDisputes(r.Context(), filter, portalCustomer)
+	h.respond(w, disputes, err)
+}
+
+func (h *Handler) dispute(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	portalCustomer, ok := h.portalCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/disputes/")
+	if len(parts) == 0 || len(parts) > 3 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	disputeID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	switch {
+	case action == "" && len(parts) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		dispute, err := h.disputes.GetDispute(r.Context(), disputeID, portalCustomer)
+		h.respond(w, dispute, err)
+
+	case (action == "review" || action == "resolve") && len(parts) == 2:
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if portalCustomer != nil {
+			writeJSON(w, http.StatusForbidden, apperrors.New("FORBIDDEN", "disputes are reviewed by billing staff"))
+			return
+		}
+		if action == "review" {
+			dispute, err := h.disputes.StartReview(r.Context(), disputeID, user)
+			h.respond(w, dispute, err)
+			return
+		}
+		var input service.ResolveDisputeInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.Outcome = domain.DisputeStatus(strings.ToUpper(string(input.Outcome)))
+		input.ResolvedBy = user
+		dispute, err := h.disputes.ResolveDispute(r.Context(), disputeID, input)
+		h.respond(w, dispute, err)
+
+	case action == "attachments" && len(parts) == 2:
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// The raw file is the request body so it streams to storage
+		body := http.MaxBytesReader(w, r.Body, maxAttachmentBytes)
+		attachment, err := h.disputes.AddAttachment(r.Context(), disputeID, portalCustomer,
+			r.URL.Query().Get("file_name"), r.Header.Get("Content-Type"), r.ContentLength, user, body)
+		h.respondCreated(w, attachment, err)
+
+	case action == "attachments" && len(parts) == 3:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		attachmentID, ok := h.parseID(w, parts[2])
+		if !ok {
+			return
+		}
+		attachment, body, err := h.disputes.OpenAttachment(r.Context(), disputeID, attachmentID, portalCustomer)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		defer body.Close()
+
+		if attachment.ContentType != "" {
+			w.Header().Set("Content-Type", attachment.ContentType)
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, body); err != nil {
+			h.logger.Warnw("Dispute attachment download interrupted", "attachment_id", attachmentID, "error", err)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// RATES
+// ============================================================================
+
+func (h *Handler) rate(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/rates/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "contract":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		customerID, ok := h.parseID(w, query.Get("customer_id"))
+		if !ok {
+			return
+		}
+		lookup := domain.ContractRateQuery{
+			CustomerID:    customerID,
+			ContainerSize: query.Get("container_size"),
+			ContainerType: query.Get("container_type"),
+		}
+		for param, target := range map[string]**uuid.UUID{"origin_id": &lookup.OriginID, "destination_id": &lookup.DestinationID} {
+			if raw := query.Get(param); raw != "" {
+				id, ok := h.parseID(w, raw)
+				if !ok {
+					return
+				}
+				*target = &id
+			}
+		}
+		if raw := query.Get("at"); raw != "" {
+			at, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				h.writeError(w, apperrors.ValidationError("at must be an RFC 3339 time", "at", raw))
+				return
+			}
+			lookup.At = at
+		}
+		rate, err := h.rates.FindContractRate(r.Context(), lookup)
+		h.respond(w, rate, err)
+
+	case len(parts) == 1 && parts[0] == "quote":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input struct {
+			CustomerID    uuid.UUID                 `json:"customer_id"`
+			OriginID      *uuid.UUID                `json:"origin_id"`
+			DestinationID *uuid.UUID                `json:"destination_id"`
+			ContainerSize string                    `json:"container_size"`
+			ContainerType string                    `json:"container_type"`
+			At            time.Time                 `json:"at"`
+			Accessorials  []domain.QuoteAccessorial `json:"accessorials"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		quote, err := h.rateTables.QuoteRate(r.Context(), domain.RateQuoteRequest{
+			ContractRateQuery: domain.ContractRateQuery{
+				CustomerID:    input.CustomerID,
+				OriginID:      input.OriginID,
+				DestinationID: input.DestinationID,
+				ContainerSize: input.ContainerSize,
+				ContainerType: input.ContainerType,
+				At:            input.At,
+			},
+			Accessorials: input.Accessorials,
+		})
+		h.respond(w, quote, err)
+
+	case len(parts) == 1 && parts[0] == "accessorial":
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			customerID, ok := h.parseID(w, query.Get("customer_id"))
+			if !ok {
+				return
+			}
+			var at time.Time
+			if raw := query.Get("at"); raw != "" {
+				var err error
+				if at, err = time.Parse(time.RFC3339, raw); err != nil {
+					h.writeError(w, apperrors.ValidationError("at must be an RFC 3339 time", "at", raw))
+					return
+				}
+			}
+			rates, err := h.rateTables.AccessorialPriceList(r.Context(), customerID, at)
+			h.respond(w, rates, err)
+		case http.MethodPost:
+			var input service.CreateAccessorialRateInput
+			if !h.decode(w, r, &input) {
+				return
+			}
+			rate, err := h.rateTables.CreateAccessorialRate(r.Context(), input)
+			h.respondCreated(w, rate, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1 && parts[0] == "expiring":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		withinDays, ok := h.withinDays(w, r)
+		if !ok {
+			return
+		}
+		expiring, err := h.rates.ListExpiringRates(r.Context(), withinDays)
+		h.respond(w, expiring, err)
+
+	case len(parts) == 1 && parts[0] == "expiration-alerts":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		withinDays, ok := h.withinDays(w, r)
+		if !ok {
+			return
+		}
+		alerted, err := h.rates.SendExpirationAlerts(r.Context(), withinDays)
+		h.respond(w, alerted, err)
+
+	case len(parts) == 2 && parts[1] == "amendments":
+		rateID, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			amendments, err := h.rates.ListAmendments(r.Context(), rateID)
+			h.respond(w, amendments, err)
+		case http.MethodPost:
+			var input struct {
+				RateKind          domain.RateKind `json:"rate_kind"`
+				EffectiveDate     time.Time       `json:"effective_date"`
+				ExpirationDate    *time.Time      `json:"expiration_date"`
+				BaseRate          *float64        `json:"base_rate"`
+				FuelSurcharge     *float64        `json:"fuel_surcharge"`
+				FuelSurchargeType *string         `json:"fuel_surcharge_type"`
+				Rate              *float64        `json:"rate"`
+				MinCharge         *float64        `json:"min_charge"`
+				MaxCharge         *float64        `json:"max_charge"`
+				Reason            string          `json:"reason"`
+				DryRun            bool            `json:"dry_run"`
+			}
+			if !h.decode(w, r, &input) {
+				return
+			}
+			amendment, err := h.rates.AmendRate(r.Context(), service.AmendRateInput{
+				RateKind:          domain.RateKind(strings.ToUpper(string(input.RateKind))),
+				RateID:            rateID,
+				EffectiveDate:     input.EffectiveDate,
+				ExpirationDate:    input.ExpirationDate,
+				BaseRate:          input.BaseRate,
+				FuelSurcharge:     input.FuelSurcharge,
+				FuelSurchargeType: input.FuelSurchargeType,
+				Rate:              input.Rate,
+				MinCharge:         input.MinCharge,
+				MaxCharge:         input.MaxCharge,
+				Reason:            input.Reason,
+				AmendedBy:         user,
+				DryRun:            input.DryRun,
+			})
+			if input.DryRun {
+				h.respond(w, amendment, err)
+				return
+			}
+			h.respondCreated(w, amendment, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) rateAmendment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/rate-amendments/")
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	amendment, err := h.rates.GetAmendment(r.Context(), id)
+	h.respond(w, amendment, err)
+}
+
+func (h *Handler) tripSplitBilling(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/trips/")
+	if len(parts) != 2 || parts[1] != "split-billing" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tripID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	plans, err := h.splits.PlanTripBilling(r.Context(), tripID)
+	h.respond(w, plans, err)
+}
+
+func (h *Handler) withinDays(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("within_days")
+	if raw == "" {
+		return 0, true
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days <= 0 {
+		h.writeError(w, apperrors.ValidationError("invalid within_days", "within_days", raw))
+		return 0, false
+	}
+	return days, true
+}
+
+// ============================================================================
+// RATE TABLES
+// ============================================================================
+
+func (h *Handler) rateZones(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/rate-zones")
+
+	switch {
+	case len(parts) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			zones, err := h.rateTables.ListZones(r.Context())
+			h.respond(w, zones, err)
+		case http.MethodPost:
+			var input service.ZoneInput
+			if !h.decode(w, r, &input) {
+				return
+			}
+			zone, err := h.rateTables.CreateZone(r.Context(), input)
+			h.respondCreated(w, zone, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1:
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input service.ZoneInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		zone, err := h.rateTables.UpdateZone(r.Context(), id, input)
+		h.respond(w, zone, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) rateContracts(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/rate-contracts")
+
+	switch {
+	case len(parts) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			var customerID *uuid.UUID
+			if raw := r.URL.Query().Get("customer_id"); raw != "" {
+				id, ok := h.parseID(w, raw)
+				if !ok {
+					return
+				}
+				customerID = &id
+			}
+			contracts, err := h.rateTables.ListContracts(r.Context(), customerID)
+			h.respond(w, contracts, err)
+		case http.MethodPost:
+			var input service.CreateContractInput
+			if !h.decode(w, r, &input) {
+				return
+			}
+			input.CreatedBy = user
+			contract, err := h.rateTables.CreateContract(r.Context(), input)
+			h.respondCreated(w, contract, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		contract, err := h.rateTables.GetContract(r.Context(), id)
+		h.respond(w, contract, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) fuelSchedules(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/fuel-schedules")
+
+	switch {
+	case len(parts) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			var customerID *uuid.UUID
+			if raw := r.URL.Query().Get("customer_id"); raw != "" {
+				id, ok := h.parseID(w, raw)
+				if !ok {
+					return
+				}
+				customerID = &id
+			}
+			schedules, err := h.rateTables.ListFuelSchedules(r.Context(), customerID)
+			h.respond(w, schedules, err)
+		case http.MethodPost:
+			var input service.CreateFuelScheduleInput
+			if !h.decode(w, r, &input) {
+				return
+			}
+			schedule, err := h.rateTables.CreateFuelSchedule(r.Context(), input)
+			h.respondCreated(w, schedule, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		schedule, err := h.rateTables.GetFuelSchedule(r.Context(), id)
+		h.respond(w, schedule, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) fuelPrices(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		limit, ok := h.limit(w, r)
+		if !ok {
+			return
+		}
+		prices, err := h.rateTables.ListFuelPrices(r.Context(), r.URL.Query().Get("region"), limit)
+		h.respond(w, prices, err)
+	case http.MethodPost:
+		var input service.RecordFuelPriceInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.CreatedBy = user
+		price, err := h.rateTables.RecordFuelPrice(r.Context(), input)
+		h.respondCreated(w, price, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// TERMINAL FEES
+// ============================================================================
+
+func (h *Handler) terminalFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/terminal-fees")
+
+	switch {
+	case len(parts) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			var terminalID *uuid.UUID
+			if raw := r.URL.Query().Get("terminal_id"); raw != "" {
+				id, ok := h.parseID(w, raw)
+				if !ok {
+					return
+				}
+				terminalID = &id
+			}
+			fees, err := h.terminalFees.ListFees(r.Context(), terminalID)
+			h.respond(w, fees, err)
+		case http.MethodPost:
+			var input service.CreateTerminalFeeInput
+			if !h.decode(w, r, &input) {
+				return
+			}
+			input.CreatedBy = user
+			fee, err := h.terminalFees.CreateFee(r.Context(), input)
+			h.respondCreated(w, fee, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1:
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input service.UpdateTerminalFeeInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		fee, err := h.terminalFees.UpdateFee(r.Context(), id, input)
+		h.respond(w, fee, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) feePassThrough(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		raw := r.URL.Query().Get("customer_id")
+		if raw == "" {
+			h.writeError(w, apperrors.ValidationError("customer_id is required", "customer_id", nil))
+			return
+		}
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		settings, err := h.terminalFees.ListPassThroughs(r.Context(), customerID)
+		h.respond(w, settings, err)
+	case http.MethodPut:
+		var input service.SetPassThroughInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.UpdatedBy = user
+		setting, err := h.terminalFees.SetPassThrough(r.Context(), input)
+		h.respond(w, setting, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) orderTerminalFees(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/orders/")
+	if len(parts) != 2 || parts[1] != "terminal-fees" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	orderID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	charges, err := h.terminalFees.ListOrderCharges(r.Context(), orderID)
+	h.respond(w, charges, err)
+}
+
+// ============================================================================
+// RECONCILIATION
+// ============================================================================
+
+func (h *Handler) reconciliation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.staff(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/reconciliation/")
+	query := r.URL.Query()
+
+	switch {
+	case len(parts) == 1 && parts[0] == "runs":
+		switch r.Method {
+		case http.MethodGet:
+			limit, ok := h.limit(w, r)
+			if !ok {
+				return
+			}
+			runs, err := h.recon.ListRuns(r.Context(), limit)
+			h.respond(w, runs, err)
+		case http.MethodPost:
+			if query.Get("from") == "" && query.Get("to") == "" {
+				run, err := h.recon.ReconcileNightly(r.Context(), time.Now())
+				h.respondCreated(w, run, err)
+				return
+			}
+			from, err := time.Parse(time.RFC3339, query.Get("from"))
+			if err != nil {
+				h.writeError(w, apperrors.ValidationError("from must be an RFC 3339 time", "from", query.Get("from")))
+				return
+			}
+			to := time.Now()
+			if raw := query.Get("to"); raw != "" {
+				if to, err = time.Parse(time.RFC3339, raw); err != nil {
+					h.writeError(w, apperrors.ValidationError("to must be an RFC 3339 time", "to", raw))
+					return
+				}
+			}
+			run, err := h.recon.Reconcile(r.Context(), from, to, user)
+			h.respondCreated(w, run, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 1 && parts[0] == "issues":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		filter := domain.ReconciliationIssueFilter{}
+		if raw := query.Get("status"); raw != "" {
+			for _, status := range strings.Split(raw, ",") {
+				filter.Status = append(filter.Status, domain.ReconciliationIssueStatus(strings.ToUpper(strings.TrimSpace(status))))
+			}
+		}
+		if raw := query.Get("type"); raw != "" {
+			issueType := domain.ReconciliationIssueType(strings.ToUpper(raw))
+			filter.Type = &issueType
+		}
+		for param, target := range map[string]**uuid.UUID{"trip_id": &filter.TripID, "customer_id": &filter.CustomerID} {
+			if raw := query.Get(param); raw != "" {
+				id, ok := h.parseID(w, raw)
+				if !ok {
+					return
+				}
+				*target = &id
+			}
+		}
+		limit, ok := h.limit(w, r)
+		if !ok {
+			return
+		}
+		filter.Limit = limit
+		issues, err := h.recon.ListIssues(r.Context(), filter)
+		h.respond(w, issues, err)
+
+	case len(parts) == 3 && parts[0] == "issues" && parts[2] == "close":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		issueID, ok := h.parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		var input struct {
+			Status domain.ReconciliationIssueStatus `json:"status"`
+			Notes  string                           `json:"notes"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		issue, err := h.recon.CloseIssue(r.Context(), service.CloseIssueInput{
+			IssueID:  issueID,
+			Status:   domain.ReconciliationIssueStatus(strings.ToUpper(string(input.Status))),
+			Notes:    input.Notes,
+			ClosedBy: user,
+		})
+		h.respond(w, issue, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) payeeEntities(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var input service.SavePayeeEntityInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	payee, err := h.taxReporting.SavePayeeEntity(r.Context(), input)
+	h.respondCreated(w, payee, err)
+}
+
+func (h *Handler) taxReports(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.staff(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/tax-reports/")
+	if len(parts) != 2 || parts[1] != "1099-nec" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || year < 2000 || year > 9999 {
+		h.writeError(w, apperrors.ValidationError("invalid tax year", "year", parts[0]))
+		return
+	}
+	export, err := h.taxReporting.Export1099NEC(r.Context(), year)
+	h.respond(w, export, err)
+}
+
+func (h *Handler) limit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 {
+		h.writeError(w, apperrors.ValidationError("invalid limit", "limit", raw))
+		return 0, false
+	}
+	return limit, true
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func (h *Handler) user(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := strings.TrimSpace(r.Header.Get(userHeader))
+	if user == "" {
+		writeJSON(w, http.StatusUnauthorized, apperrors.New("UNAUTHORIZED", "missing user identity"))
+		return "", false
+	}
+	return user, true
+}
+
+// staff returns the user for a billing staff request, refusing portal sessions
+func (h *Handler) staff(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return "", false
+	}
+	if strings.TrimSpace(r.Header.Get(customerHeader)) != "" {
+		writeJSON(w, http.StatusForbidden, apperrors.New("FORBIDDEN", "this endpoint is for billing staff only"))
+		return "", false
+	}
+	return user, true
+}
+
+// portalCustomer returns the customer a portal request is for, or nil for staff
+func (h *Handler) portalCustomer(w http.ResponseWriter, r *http.Request) (*uuid.UUID, bool) {
+	raw := strings.TrimSpace(r.Header.Get(customerHeader))
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, apperrors.New("UNAUTHORIZED", "invalid customer identity"))
+		return nil, false
+	}
+	return &id, true
+}
+
+func pathParts(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid id", "id", raw))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) parsePeriod(w http.ResponseWriter, raw string) (time.Time, bool) {
+	period, err := time.Parse(periodLayout, raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("period must be YYYY-MM", "period", raw))
+		return time.Time{}, false
+	}
+	return period, true
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) respondCreated(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		status = http.StatusBadRequest
+	case "NOT_FOUND":
+		status = http.StatusNotFound
+	case "CONFLICT", "INVALID_STATE":
+		status = http.StatusConflict
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Billing request failed", "error", err)
+	}
+
+	writeJSON(w, status, appErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeStatus represents where an invoice dispute is in review
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "OPEN"
+	DisputeStatusUnderReview DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusCredited    DisputeStatus = "CREDITED" // Resolved for the customer with a credit memo
+	DisputeStatusUpheld      DisputeStatus = "UPHELD"   // Resolved for us; the charge stands
+)
+
+// IsActive reports whether the dispute still holds its amount out of aging
+func (s DisputeStatus) IsActive() bool {
+	return s == DisputeStatusOpen || s == DisputeStatusUnderReview
+}
+
+// DisputeChannel is where a dispute was opened
+type DisputeChannel string
+
+const (
+	DisputeChannelPortal DisputeChannel = "PORTAL" // By the customer in the customer portal
+	DisputeChannelStaff  DisputeChannel = "STAFF"  // By billing staff, e.g. from a call or email
+)
+
+// DisputeReasonCode classifies why a charge is disputed
+type DisputeReasonCode string
+
+const (
+	DisputeReasonRateDiscrepancy     DisputeReasonCode = "RATE_DISCREPANCY"      // Billed rate differs from the quote or contract
+	DisputeReasonNotAuthorized       DisputeReasonCode = "NOT_AUTHORIZED"        // Accessorial the customer did not approve
+	DisputeReasonServiceNotPerformed DisputeReasonCode = "SERVICE_NOT_PERFORMED" // e.g. detention billed with no wait
+	DisputeReasonDuplicateCharge     DisputeReasonCode = "DUPLICATE_CHARGE"
+	DisputeReasonIncorrectQuantity   DisputeReasonCode = "INCORRECT_QUANTITY" // Wrong days, hours or units
+	DisputeReasonCarrierFault        DisputeReasonCode = "CARRIER_FAULT"      // Charge caused by our delay or error
+	DisputeReasonOther               DisputeReasonCode = "OTHER"
+)
+
+// IsValid checks if the reason code is known
+func (c DisputeReasonCode) IsValid() bool {
+	switch c {
+	case DisputeReasonRateDiscrepancy, DisputeReasonNotAuthorized, DisputeReasonServiceNotPerformed,
+		DisputeReasonDuplicateCharge, DisputeReasonIncorrectQuantity, DisputeReasonCarrierFault, DisputeReasonOther:
+		return true
+	}
+	return false
+}
+
+// InvoiceDispute is a customer's challenge to a charge on an invoice. While
+// it is open or under review its amount is carried as the invoice's disputed
+// amount and left out of aging. Resolving it either credits the customer,
+// with a credit memo for the credited amount, or upholds the charge.
+type InvoiceDispute struct {
+	ID              uuid.UUID           `json:"id" db:"id"`
+	DisputeNumber   string              `json:"dispute_number" db:"dispute_number"`
+	InvoiceID       uuid.UUID           `json:"invoice_id" db:"invoice_id"`
+	LineItemID      uuid.UUID           `json:"line_item_id" db:"line_item_id"`
+	CustomerID      uuid.UUID           `json:"customer_id" db:"customer_id"`
+	Amount          float64             `json:"amount" db:"amount"` // Amount of the line item disputed
+	ReasonCode      DisputeReasonCode   `json:"reason_code" db:"reason_code"`
+	Reason          string              `json:"reason" db:"reason"`
+	Status          DisputeStatus       `json:"status" db:"status"`
+	OpenedVia       DisputeChannel      `json:"opened_via" db:"opened_via"`
+	OpenedBy        string              `json:"opened_by" db:"opened_by"`
+	ReviewedBy      string              `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewStartedAt *time.Time          `json:"review_started_at,omitempty" db:"review_started_at"`
+	ResolutionNotes string              `json:"resolution_notes,omitempty" db:"resolution_notes"`
+	CreditedAmount  float64             `json:"credited_amount,omitempty" db:"credited_amount"`
+	CreditID        *uuid.UUID          `json:"credit_id,omitempty" db:"credit_id"` // Credit memo issued on resolution
+	ResolvedBy      string              `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt      *time.Time          `json:"resolved_at,omitempty" db:"resolved_at"`
+	Attachments     []DisputeAttachment `json:"attachments"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// DisputeAttachment is supporting evidence for a dispute, e.g. a rate
+// confirmation, gate ticket or email. The file is kept in blob storage under
+// StorageKey.
+type DisputeAttachment struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	DisputeID   uuid.UUID `json:"dispute_id" db:"dispute_id"`
+	FileName    string    `json:"file_name" db:"file_name"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	SizeBytes   int64     `json:"size_bytes" db:"size_bytes"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	UploadedBy  string    `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// DisputeFilter narrows a dispute listing. Zero fields match everything.
+type DisputeFilter struct {
+	CustomerID *uuid.UUID
+	InvoiceID  *uuid.UUID
+	Status     DisputeStatus
+	Limit      int
+}
+
+// DisputeNumber returns the dispute number for a new dispute, e.g.
+// "DSP-20260914-1A2B3C4D"
+func DisputeNumber(id uuid.UUID, openedAt time.Time) string {
+	return fmt.Sprintf("DSP-%s-%s", openedAt.Format("20060102"), strings.ToUpper(id.String()[:8]))
+}
+
+// CreditMemoNumber returns the number of the credit memo issued to resolve a
+// dispute, e.g. "CM-20260914-1A2B3C4D" for dispute "DSP-20260914-1A2B3C4D"
+func CreditMemoNumber(disputeNumber string) string {
+	return "CM-" + strings.TrimPrefix(disputeNumber, "DSP-")
+}
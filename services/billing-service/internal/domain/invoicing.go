@@ -0,0 +1,474 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// InvoiceSkipReason is why invoice generation left a completed order unbilled
+type InvoiceSkipReason string
+
+const (
+	InvoiceSkipNoCustomer      InvoiceSkipReason = "NO_CUSTOMER"
+	InvoiceSkipNoContractRate  InvoiceSkipReason = "NO_CONTRACT_RATE" // No line haul charge and no contract rate to price one
+	InvoiceSkipChargesAccruing InvoiceSkipReason = "CHARGES_ACCRUING" // Per diem or demurrage still accruing or disputed with the line
+	InvoiceSkipPODMissing      InvoiceSkipReason = "POD_MISSING"      // Split delivery not yet signed for at every stop
+	InvoiceSkipAlreadyBilled   InvoiceSkipReason = "ALREADY_BILLED"   // Billed by another run meanwhile
+	InvoiceSkipNothingToBill   InvoiceSkipReason = "NOTHING_TO_BILL"
+)
+
+// Container charge statuses, as kept on the steamship line's per diem and
+// demurrage for a container
+const (
+	ContainerChargeAccruing = "ACCRUING"
+	ContainerChargeClosed   = "CLOSED"
+	ContainerChargeWaived   = "WAIVED"
+	ContainerChargeDisputed = "DISPUTED"
+)
+
+// detentionBillingIncrementMins is the increment detention time is billed
+// in, rounded up
+const detentionBillingIncrementMins = 15
+
+// OrderCharge is a customer charge on an order. Charges priced by invoice
+// generation carry no ID until they are stored.
+type OrderCharge struct {
+	ID          uuid.UUID  `json:"id"`
+	OrderID     uuid.UUID  `json:"order_id"`
+	ChargeType  ChargeType `json:"charge_type"`
+	Description string     `json:"description"`
+	Quantity    float64    `json:"quantity"`
+	UnitRate    float64    `json:"unit_rate"`
+	Amount      float64    `json:"amount"`
+	RateKind    *RateKind  `json:"rate_kind,omitempty"`
+	RateID      *uuid.UUID `json:"rate_id,omitempty"`
+	TripID      *uuid.UUID `json:"trip_id,omitempty"` // Trip the charge was earned on, such as detention at one of its stops
+}
+
+// BillableTrip is a completed trip that moved an order
+type BillableTrip struct {
+	TripID     uuid.UUID `json:"trip_id"`
+	TripNumber string    `json:"trip_number"`
+}
+
+// StopDetention is time a driver was held at one of the order's stops
+// after free time ran out. DetentionMins is what dispatch recorded, every
+// minute past free time; a customer contract may count fewer.
+type StopDetention struct {
+	TripID        uuid.UUID  `json:"trip_id"`
+	TripNumber    string     `json:"trip_number"`
+	StopID        uuid.UUID  `json:"stop_id"`
+	Sequence      int        `json:"sequence"`
+	DetentionMins int        `json:"detention_mins"`
+	ArrivedAt     *time.Time `json:"arrived_at,omitempty"`
+	DepartedAt    *time.Time `json:"departed_at,omitempty"`
+	FreeTimeMins  int        `json:"free_time_mins"`
+}
+
+// ChassisUsage is a chassis pulled for the order not yet billed to the customer
+type ChassisUsage struct {
+	ID            uuid.UUID  `json:"id"`
+	TripID        *uuid.UUID `json:"trip_id,omitempty"`
+	ChassisNumber string     `json:"chassis_number,omitempty"`
+	Days          int        `json:"days"`
+	DailyRate     float64    `json:"daily_rate"` // What the pool charged
+	Amount        float64    `json:"amount"`
+}
+
+// ContainerCharge is per diem or demurrage the steamship line charged on the
+// order's container, passed through to the customer at cost
+type ContainerCharge struct {
+	ID         uuid.UUID  `json:"id"`
+	ChargeType ChargeType `json:"charge_type"`
+	Days       int        `json:"days"`
+	DailyRate  float64    `json:"daily_rate"`
+	Amount     float64    `json:"amount"`
+	Status     string     `json:"status"`
+}
+
+// BillableOrder is a completed order not yet invoiced, with everything
+// invoice generation prices it from
+type BillableOrder struct {
+	OrderID          uuid.UUID
+	OrderNumber      string
+	CustomerID       *uuid.UUID // Nil when the shipment names no customer record
+	CustomerName     string
+	PaymentTermsDays int
+	ShipmentID       uuid.UUID
+	ShipmentRef      string
+	BillOfLading     string
+	ContainerID      uuid.UUID
+	ContainerNumber  string
+	ContainerSize    string // As on the container, such as 40HC
+	ContainerType    string // As on the container, such as DRY or REEFER
+	OriginID         *uuid.UUID
+	DestinationID    *uuid.UUID
+	CompletedAt      time.Time
+
+	// The customer's contract free time and detention terms; nil when the
+	// customer has none and detention is billed at the accessorial rate
+	DetentionProfile *config.CustomerProfile
+
+	Trips            []BillableTrip
+	Charges          []OrderCharge
+	Detention        []StopDetention
+	Chassis          []ChassisUsage
+	ContainerCharges []ContainerCharge
+}
+
+// ContractRateQuery returns the lookup for the contract rate that prices the
+// order's line haul
+func (o *BillableOrder) ContractRateQuery() ContractRateQuery {
+	q := ContractRateQuery{
+		OriginID:      o.OriginID,
+		DestinationID: o.DestinationID,
+		ContainerSize: rateContainerSize(o.ContainerSize),
+		ContainerType: rateContainerType(o.ContainerType),
+		At:            o.CompletedAt,
+	}
+	if o.CustomerID != nil {
+		q.CustomerID = *o.CustomerID
+	}
+	return q
+}
+
+// HasCharge reports whether the order already carries a charge of the type
+func (o *BillableOrder) HasCharge(chargeType ChargeType) bool {
+	for _, c := range o.Charges {
+		if c.ChargeType == chargeType {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerChargesSettled reports whether the container's per diem and
+// demurrage are final. Charges still accruing or disputed with the line
+// hold the order back so the customer is billed once, at the final amount.
+func (o *BillableOrder) ContainerChargesSettled() bool {
+	for _, c := range o.ContainerCharges {
+		if c.Status == ContainerChargeAccruing || c.Status == ContainerChargeDisputed {
+			return false
+		}
+	}
+	return true
+}
+
+// PriceOrder returns the charges invoice generation adds to an order from the
+// customer's rate tables. A charge type the order already carries is left
+// as it is, so charges entered or rated by hand win.
+//
+//   - Line haul is the contract rate's base rate; fuel is a percent of the
+//     order's line haul or a flat amount, as on the contract rate
+//   - Detention is billed per stop. Under a customer contract profile, only
+//     the minutes its weekend and holiday rules count are billed, past free
+//     time and the contract grace period; contract tiers price them with the
+//     contract daily cap, else they go at the DETENTION rate. Without a
+//     profile, dispatch's detention minutes go at the DETENTION rate. Rated
+//     detention is billed by the hour, in quarter hours.
+//   - Chassis is billed per day at the customer's CHASSIS rate, or at what
+//     the pool charged without one
+//   - Closed per diem and demurrage are passed through at cost
+//
+// accessorials holds the customer's current accessorial rate for each
+// charge type, falling back to the default rate. detention holds the
+// global rules a contract profile falls back to; profiles are ignored
+// without them.
+func PriceOrder(order *BillableOrder, contract *Rate, accessorials map[ChargeType]AccessorialRate, detention *config.DetentionRules) []OrderCharge {
+	var priced []OrderCharge
+	add := func(c OrderCharge) {
+		c.OrderID = order.OrderID
+		c.Amount = roundCents(c.Amount)
+		priced = append(priced, c)
+	}
+
+	if contract != nil {
+		contractKind := RateKindContract
+		rateID := contract.ID
+		lineHaul := 0.0
+		for _, c := range order.Charges {
+			if c.ChargeType == ChargeTypeLineHaul {
+				lineHaul += c.Amount
+			}
+		}
+		if !order.HasCharge(ChargeTypeLineHaul) {
+			lineHaul = contract.BaseRate
+			add(OrderCharge{
+				ChargeType:  ChargeTypeLineHaul,
+				Description: contract.Name,
+				Quantity:    1,
+				UnitRate:    contract.BaseRate,
+				Amount:      contract.BaseRate,
+				RateKind:    &contractKind,
+				RateID:      &rateID,
+			})
+		}
+		if !order.HasCharge(ChargeTypeFuelSurcharge) && contract.FuelSurcharge > 0 {
+			fuel := OrderCharge{ChargeType: ChargeTypeFuelSurcharge, Quantity: 1, RateKind: &contractKind, RateID: &rateID}
+			if contract.FuelSurchargeType == "flat" {
+				fuel.Description = "Fuel surcharge"
+				fuel.UnitRate = contract.FuelSurcharge
+				fuel.Amount = contract.FuelSurcharge
+			} else {
+				fuel.Description = fmt.Sprintf("Fuel surcharge (%g%%)", contract.FuelSurcharge)
+				fuel.Amount = roundCents(lineHaul * contract.FuelSurcharge / 100)
+				fuel.UnitRate = fuel.Amount
+			}
+			add(fuel)
+		}
+	}
+
+	if !order.HasCharge(ChargeTypeDetention) {
+		rate, rated := accessorials[ChargeTypeDetention]
+		for _, stop := range order.Detention {
+			tripID := stop.TripID
+			mins := stop.DetentionMins
+			if profile := order.DetentionProfile; profile != nil && detention != nil && stop.ArrivedAt != nil && stop.DepartedAt != nil {
+				charge, contractMins := profile.CalculateDetention(*stop.ArrivedAt, *stop.DepartedAt, stop.FreeTimeMins, detention)
+				mins = contractMins
+				if len(profile.DetentionTiers) > 0 {
+					if mins <= 0 || charge <= 0 {
+						continue
+					}
+					hours := math.Round(float64(mins)/60*100) / 100
+					add(OrderCharge{
+						ChargeType:  ChargeTypeDetention,
+						Description: fmt.Sprintf("Detention at stop %d, %.2f hours at %s contract tiers (trip %s)", stop.Sequence, hours, profile.Name, stop.TripNumber),
+						Quantity:    hours,
+						UnitRate:    roundCents(charge / hours),
+						Amount:      roundCents(charge),
+						TripID:      &tripID,
+					})
+					continue
+				}
+			}
+			hours := billableDetentionHours(mins)
+			if !rated || hours == 0 {
+				continue
+			}
+			add(accessorialCharge(rate, hours,
+				fmt.Sprintf("Detention at stop %d, %.2f hours (trip %s)", stop.Sequence, hours, stop.TripNumber), &tripID))
+		}
+	}
+
+	if !order.HasCharge(ChargeTypeChassis) {
+		rate, rated := accessorials[ChargeTypeChassis]
+		for _, usage := range order.Chassis {
+			if usage.Days <= 0 {
+				continue
+			}
+			description := fmt.Sprintf("Chassis, %d days", usage.Days)
+			if usage.ChassisNumber != "" {
+				description = fmt.Sprintf("Chassis %s, %d days", usage.ChassisNumber, usage.Days)
+			}
+			if rated {
+				add(accessorialCharge(rate, float64(usage.Days), description, usage.TripID))
+				continue
+			}
+			amount := usage.Amount
+			if amount == 0 {
+				amount = float64(usage.Days) * usage.DailyRate
+			}
+			if amount == 0 {
+				continue
+			}
+			add(OrderCharge{
+				ChargeType:  ChargeTypeChassis,
+				Description: description,
+				Quantity:    float64(usage.Days),
+				UnitRate:    roundCents(amount / float64(usage.Days)),
+				Amount:      amount,
+				TripID:      usage.TripID,
+			})
+		}
+	}
+
+	for _, cc := range order.ContainerCharges {
+		if cc.Status != ContainerChargeClosed || cc.Amount <= 0 || order.HasCharge(cc.ChargeType) {
+			continue
+		}
+		label := "Per diem"
+		if cc.ChargeType == ChargeTypeDemurrage {
+			label = "Demurrage"
+		}
+		quantity, unitRate := float64(cc.Days), cc.DailyRate
+		if cc.Days <= 0 {
+			quantity, unitRate = 1, cc.Amount
+		}
+		add(OrderCharge{
+			ChargeType:  cc.ChargeType,
+			Description: fmt.Sprintf("%s, %d days (container %s)", label, cc.Days, order.ContainerNumber),
+			Quantity:    quantity,
+			UnitRate:    unitRate,
+			Amount:      cc.Amount,
+		})
+	}
+	return priced
+}
+
+// accessorialCharge prices a quantity at an accessorial rate, held between
+// the minimum and any maximum charge
+func accessorialCharge(rate AccessorialRate, quantity float64, description string, tripID *uuid.UUID) OrderCharge {
+	kind := RateKindAccessorial
+	rateID := rate.ID
+	amount := quantity * rate.Rate
+	if amount < rate.MinCharge {
+		amount = rate.MinCharge
+	}
+	if rate.MaxCharge > 0 && amount > rate.MaxCharge {
+		amount = rate.MaxCharge
+	}
+	return OrderCharge{
+		ChargeType:  rate.ChargeType,
+		Description: description,
+		Quantity:    quantity,
+		UnitRate:    rate.Rate,
+		Amount:      amount,
+		RateKind:    &kind,
+		RateID:      &rateID,
+		TripID:      tripID,
+	}
+}
+
+// billableDetentionHours rounds detention up to the billing increment
+func billableDetentionHours(mins int) float64 {
+	if mins <= 0 {
+		return 0
+	}
+	increments := math.Ceil(float64(mins) / detentionBillingIncrementMins)
+	return increments * detentionBillingIncrementMins / 60
+}
+
+// rateContainerSize maps a container size to the sizes rates are kept for;
+// high cubes are priced as forties
+func rateContainerSize(size string) string {
+	if size == "40HC" {
+		return "40"
+	}
+	return size
+}
+
+// rateContainerType maps a container type to the types rates are kept for,
+// which only tell reefers from dry boxes
+func rateContainerType(containerType string) string {
+	switch containerType {
+	case "REEFER":
+		return "reefer"
+	case "DRY", "HIGH_CUBE":
+		return "dry"
+	}
+	return containerType
+}
+
+// InvoiceLines turns an order's charges into invoice lines, each on the trip
+// it was earned on or else the order's first trip
+func InvoiceLines(order *BillableOrder, charges []OrderCharge) []InvoiceLineItem {
+	orderID := order.OrderID
+	var defaultTrip *uuid.UUID
+	tripNumbers := make(map[uuid.UUID]string, len(order.Trips))
+	for i, trip := range order.Trips {
+		tripNumbers[trip.TripID] = trip.TripNumber
+		if i == 0 {
+			id := trip.TripID
+			defaultTrip = &id
+		}
+	}
+
+	lines := make([]InvoiceLineItem, 0, len(charges))
+	for _, c := range charges {
+		tripID := c.TripID
+		if tripID == nil {
+			tripID = defaultTrip
+		}
+		line := InvoiceLineItem{
+			TripID:          tripID,
+			OrderID:         &orderID,
+			ChargeType:      c.ChargeType,
+			Description:     c.Description,
+			Quantity:        c.Quantity,
+			UnitPrice:       c.UnitRate,
+			Amount:          roundCents(c.Amount),
+			ContainerNumber: order.ContainerNumber,
+			RateID:          c.RateID,
+		}
+		if line.Description == "" {
+			line.Description = string(c.ChargeType)
+		}
+		if line.Quantity == 0 {
+			line.Quantity = 1
+		}
+		if tripID != nil {
+			line.TripNumber = tripNumbers[*tripID]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// ApplyTotals sets the invoice's subtotal, tax, total and balance due from
+// its line items
+func (i *Invoice) ApplyTotals() {
+	subtotal := 0.0
+	for _, line := range i.LineItems {
+		subtotal += line.Amount
+	}
+	i.Subtotal = roundCents(subtotal)
+	i.TaxAmount = roundCents(i.Subtotal * i.TaxRate)
+	i.TotalAmount = roundCents(i.Subtotal + i.TaxAmount)
+	i.BalanceDue = roundCents(i.TotalAmount - i.PaidAmount)
+}
+
+// PaymentTerms returns the terms printed on an invoice due in the given
+// number of days, e.g. "NET30"
+func PaymentTerms(days int) string {
+	if days <= 0 {
+		return "DUE_ON_RECEIPT"
+	}
+	return fmt.Sprintf("NET%d", days)
+}
+
+// InvoiceNumber returns the number of an invoice from the invoice sequence,
+// e.g. "INV-2026-000123"
+func InvoiceNumber(seq int64, invoiceDate time.Time) string {
+	return fmt.Sprintf("INV-%d-%06d", invoiceDate.Year(), seq)
+}
+
+// InvoiceSources are the records an invoice bills, marked with it so they
+// are billed once
+type InvoiceSources struct {
+	OrderIDs           []uuid.UUID
+	ChassisUsageIDs    []uuid.UUID
+	ContainerChargeIDs []uuid.UUID
+}
+
+// SkippedOrder is a completed order an invoice run left unbilled
+type SkippedOrder struct {
+	OrderID     uuid.UUID         `json:"order_id"`
+	OrderNumber string            `json:"order_number"`
+	Reason      InvoiceSkipReason `json:"reason"`
+	Detail      string            `json:"detail,omitempty"`
+}
+
+// InvoiceRun is what one invoice generation run billed and left unbilled
+type InvoiceRun struct {
+	CompletedBefore time.Time      `json:"completed_before"`
+	OrdersChecked   int            `json:"orders_checked"`
+	Invoices        []Invoice      `json:"invoices"`
+	Skipped         []SkippedOrder `json:"skipped"`
+	TotalBilled     float64        `json:"total_billed"`
+	GeneratedBy     string         `json:"generated_by"`
+}
+
+// InvoiceFilter narrows an invoice listing
+type InvoiceFilter struct {
+	CustomerID *uuid.UUID
+	Status     InvoiceStatus
+	From       *time.Time // Invoice date on or after
+	To         *time.Time // Invoice date before
+	Limit      int
+}
@@ -0,0 +1,440 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+func detentionStop(arrived, departed time.Time) StopDetention {
+	return StopDetention{
+		TripID:        uuid.New(),
+		TripNumber:    "TRP-1001",
+		StopID:        uuid.New(),
+		Sequence:      2,
+		DetentionMins: int(departed.Sub(arrived).Minutes()) - 120,
+		ArrivedAt:     &arrived,
+		DepartedAt:    &departed,
+		FreeTimeMins:  120,
+	}
+}
+
+func TestPriceOrderDetention(t *testing.T) {
+	rules := &config.DetentionRules{FreeTimeMins: 120, RatePerHour: 75}
+	rates := map[ChargeType]AccessorialRate{
+		ChargeTypeDetention: {ID: uuid.New(), ChargeType: ChargeTypeDetention, RateType: "per_hour", Rate: 80},
+	}
+	tiers := []config.DetentionTier{
+		{FromHour: 1, ToHour: 2, RatePerHour: 65},
+		{FromHour: 3, ToHour: 0, RatePerHour: 95},
+	}
+	wednesday := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	friday := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	christmasEve := time.Date(2026, 12, 24, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		profile     *config.CustomerProfile
+		stop        StopDetention
+		rates       map[ChargeType]AccessorialRate
+		rules       *config.DetentionRules
+		existing    bool
+		wantAmount  float64 // Zero means no detention is billed
+		wantHours   float64
+		wantRated   bool
+		wantContain string
+	}{
+		{
+			name:        "no profile bills dispatch minutes at the accessorial rate",
+			stop:        detentionStop(wednesday, wednesday.Add(210*time.Minute)),
+			rates:       rates,
+			rules:       rules,
+			wantAmount:  120,
+			wantHours:   1.5,
+			wantRated:   true,
+			wantContain: "1.50 hours (trip TRP-1001)",
+		},
+		{
+			name:        "contract tiers price detention without an accessorial rate",
+			profile:     &config.CustomerProfile{Name: "Acme", DetentionTiers: tiers, CountWeekends: true, CountHolidays: true},
+			stop:        detentionStop(wednesday, wednesday.Add(5*time.Hour)),
+			rules:       rules,
+			wantAmount:  225,
+			wantHours:   3,
+			wantContain: "Acme contract tiers",
+		},
+		{
+			name:       "contract daily cap limits the tiered charge",
+			profile:    &config.CustomerProfile{Name: "Acme", DetentionTiers: tiers, MaxDailyCharge: 200, CountWeekends: true, CountHolidays: true},
+			stop:       detentionStop(wednesday, wednesday.Add(5*time.Hour)),
+			rules:      rules,
+			wantAmount: 200,
+			wantHours:  3,
+		},
+		{
+			name:       "contract grace period is not billed",
+			profile:    &config.CustomerProfile{Name: "Acme", DetentionTiers: tiers, GracePeriodMins: intPtr(60), CountWeekends: true, CountHolidays: true},
+			stop:       detentionStop(wednesday, wednesday.Add(5*time.Hour)),
+			rules:      rules,
+			wantAmount: 130,
+			wantHours:  2,
+		},
+		{
+			name:        "weekend minutes are not counted when the contract excludes them",
+			profile:     &config.CustomerProfile{Name: "Acme", CountWeekends: false, CountHolidays: true},
+			stop:        detentionStop(friday, friday.Add(8*time.Hour)),
+			rates:       rates,
+			rules:       rules,
+			wantAmount:  160,
+			wantHours:   2,
+			wantRated:   true,
+			wantContain: "2.00 hours",
+		},
+		{
+			name: "holiday minutes are not counted when the contract excludes them",
+			profile: &config.CustomerProfile{
+				Name: "Acme", DetentionTiers: tiers, CountWeekends: true, CountHolidays: false,
+				Holidays: []time.Time{time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)},
+			},
+			stop:  detentionStop(christmasEve, christmasEve.Add(8*time.Hour)),
+			rates: rates,
+			rules: rules,
+		},
+		{
+			name:       "profile without detention rules falls back to dispatch minutes",
+			profile:    &config.CustomerProfile{Name: "Acme", DetentionTiers: tiers, CountWeekends: false, CountHolidays: true},
+			stop:       detentionStop(friday, friday.Add(8*time.Hour)),
+			rates:      rates,
+			wantAmount: 480,
+			wantHours:  6,
+			wantRated:  true,
+		},
+		{
+			name:    "profile without tiers needs an accessorial rate",
+			profile: &config.CustomerProfile{Name: "Acme", CountWeekends: true, CountHolidays: true},
+			stop:    detentionStop(wednesday, wednesday.Add(5*time.Hour)),
+			rules:   rules,
+		},
+		{
+			name:     "existing detention charge is kept",
+			profile:  &config.CustomerProfile{Name: "Acme", DetentionTiers: tiers, CountWeekends: true, CountHolidays: true},
+			stop:     detentionStop(wednesday, wednesday.Add(5*time.Hour)),
+			rates:    rates,
+			rules:    rules,
+			existing: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &BillableOrder{
+				OrderID:          uuid.New(),
+				DetentionProfile: tt.profile,
+				Detention:        []StopDetention{tt.stop},
+			}
+			if tt.existing {
+				order.Charges = []OrderCharge{{ChargeType: ChargeTypeDetention, Amount: 50}}
+			}
+
+			var detention []OrderCharge
+			for _, c := range PriceOrder(order, nil, tt.rates, tt.rules) {
+				if c.ChargeType == ChargeTypeDetention {
+					detention = append(detention, c)
+				}
+			}
+
+			if tt.wantAmount == 0 {
+				if len(detention) != 0 {
+					t.Fatalf("expected no detention charge, got %+v", detention)
+				}
+				return
+			}
+			if len(detention) != 1 {
+				t.Fatalf("expected one detention charge, got %+v", detention)
+			}
+			c := detention[0]
+			if c.Amount != tt.wantAmount {
+				t.Errorf("amount = %.2f, want %.2f", c.Amount, tt.wantAmount)
+			}
+			if c.Quantity != tt.wantHours {
+				t.Errorf("hours = %.2f, want %.2f", c.Quantity, tt.wantHours)
+			}
+			if rated := c.RateKind != nil; rated != tt.wantRated {
+				t.Errorf("billed at the accessorial rate = %v, want %v", rated, tt.wantRated)
+			}
+			if c.TripID == nil || *c.TripID != tt.stop.TripID {
+				t.Errorf("trip = %v, want %s", c.TripID, tt.stop.TripID)
+			}
+			if c.OrderID != order.OrderID {
+				t.Errorf("order = %s, want %s", c.OrderID, order.OrderID)
+			}
+			if !strings.Contains(c.Description, tt.wantContain) {
+				t.Errorf("description %q does not contain %q", c.Description, tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestPriceOrderContractFuel(t *testing.T) {
+	contract := &Rate{ID: uuid.New(), Name: "Port of LA to Inland Empire", BaseRate: 450}
+	tests := []struct {
+		name         string
+		fuel         float64
+		fuelType     string
+		existing     []OrderCharge
+		wantLineHaul float64 // Zero means the order's own line haul is kept
+		wantFuel     float64 // Zero means no fuel is priced
+		wantFuelDesc string
+	}{
+		{
+			name:         "percent fuel on the contract line haul",
+			fuel:         18.5,
+			fuelType:     "percent",
+			wantLineHaul: 450,
+			wantFuel:     83.25,
+			wantFuelDesc: "Fuel surcharge (18.5%)",
+		},
+		{
+			name:         "flat fuel",
+			fuel:         65,
+			fuelType:     "flat",
+			wantLineHaul: 450,
+			wantFuel:     65,
+			wantFuelDesc: "Fuel surcharge",
+		},
+		{
+			name:         "percent fuel on the order's own line haul",
+			fuel:         20,
+			fuelType:     "percent",
+			existing:     []OrderCharge{{ChargeType: ChargeTypeLineHaul, Amount: 500}, {ChargeType: ChargeTypeLineHaul, Amount: 125.55}},
+			wantFuel:     125.11,
+			wantFuelDesc: "Fuel surcharge (20%)",
+		},
+		{
+			name:         "no fuel surcharge on the contract",
+			fuelType:     "percent",
+			wantLineHaul: 450,
+		},
+		{
+			name:     "charges already on the order are kept",
+			fuel:     18.5,
+			fuelType: "percent",
+			existing: []OrderCharge{{ChargeType: ChargeTypeLineHaul, Amount: 500}, {ChargeType: ChargeTypeFuelSurcharge, Amount: 40}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := *contract
+			rate.FuelSurcharge, rate.FuelSurchargeType = tt.fuel, tt.fuelType
+			order := &BillableOrder{OrderID: uuid.New(), Charges: tt.existing}
+
+			var lineHaul, fuel []OrderCharge
+			for _, c := range PriceOrder(order, &rate, nil, nil) {
+				if c.OrderID != order.OrderID || c.RateKind == nil || *c.RateKind != RateKindContract || c.RateID == nil || *c.RateID != rate.ID {
+					t.Errorf("charge %+v is not on the order at the contract rate", c)
+				}
+				switch c.ChargeType {
+				case ChargeTypeLineHaul:
+					lineHaul = append(lineHaul, c)
+				case ChargeTypeFuelSurcharge:
+					fuel = append(fuel, c)
+				}
+			}
+
+			if tt.wantLineHaul == 0 && len(lineHaul) != 0 {
+				t.Errorf("priced line haul %+v over the order's own", lineHaul)
+			}
+			if tt.wantLineHaul != 0 && (len(lineHaul) != 1 || lineHaul[0].Amount != tt.wantLineHaul || lineHaul[0].Description != rate.Name) {
+				t.Errorf("line haul = %+v, want %.2f", lineHaul, tt.wantLineHaul)
+			}
+			if tt.wantFuel == 0 {
+				if len(fuel) != 0 {
+					t.Errorf("expected no fuel charge, got %+v", fuel)
+				}
+				return
+			}
+			if len(fuel) != 1 || fuel[0].Amount != tt.wantFuel || fuel[0].UnitRate != tt.wantFuel || fuel[0].Description != tt.wantFuelDesc {
+				t.Errorf("fuel = %+v, want %.2f %q", fuel, tt.wantFuel, tt.wantFuelDesc)
+			}
+		})
+	}
+}
+
+func TestPriceOrderChassisAndContainerCharges(t *testing.T) {
+	tripID := uuid.New()
+	chassisRate := AccessorialRate{ID: uuid.New(), ChargeType: ChargeTypeChassis, Rate: 40, MinCharge: 100, MaxCharge: 250}
+	tests := []struct {
+		name    string
+		order   BillableOrder
+		rates   map[ChargeType]AccessorialRate
+		want    []OrderCharge // Compared on type, description, quantity, unit rate and amount
+		wantTag map[ChargeType]bool
+	}{
+		{
+			name: "chassis at the customer rate, held to its min and max",
+			order: BillableOrder{Chassis: []ChassisUsage{
+				{TripID: &tripID, ChassisNumber: "TRAZ 123456", Days: 2, Amount: 90},
+				{Days: 9},
+				{Days: 0, Amount: 60},
+			}},
+			rates: map[ChargeType]AccessorialRate{ChargeTypeChassis: chassisRate},
+			want: []OrderCharge{
+				{ChargeType: ChargeTypeChassis, Description: "Chassis TRAZ 123456, 2 days", Quantity: 2, UnitRate: 40, Amount: 100},
+				{ChargeType: ChargeTypeChassis, Description: "Chassis, 9 days", Quantity: 9, UnitRate: 40, Amount: 250},
+			},
+			wantTag: map[ChargeType]bool{ChargeTypeChassis: true},
+		},
+		{
+			name: "chassis at what the pool charged without a customer rate",
+			order: BillableOrder{Chassis: []ChassisUsage{
+				{Days: 3, Amount: 100},
+				{Days: 2, DailyRate: 32.5},
+				{Days: 4},
+			}},
+			want: []OrderCharge{
+				{ChargeType: ChargeTypeChassis, Description: "Chassis, 3 days", Quantity: 3, UnitRate: 33.33, Amount: 100},
+				{ChargeType: ChargeTypeChassis, Description: "Chassis, 2 days", Quantity: 2, UnitRate: 32.5, Amount: 65},
+			},
+		},
+		{
+			name: "closed container charges pass through at cost",
+			order: BillableOrder{ContainerNumber: "MSCU1234565", ContainerCharges: []ContainerCharge{
+				{ChargeType: ChargeTypePerDiem, Days: 3, DailyRate: 150, Amount: 450, Status: ContainerChargeClosed},
+				{ChargeType: ChargeTypeDemurrage, Amount: 275, Status: ContainerChargeClosed},
+				{ChargeType: ChargeTypeDemurrage, Days: 2, DailyRate: 100, Amount: 200, Status: ContainerChargeWaived},
+				{ChargeType: ChargeTypePerDiem, Days: 1, Status: ContainerChargeClosed},
+			}},
+			want: []OrderCharge{
+				{ChargeType: ChargeTypePerDiem, Description: "Per diem, 3 days (container MSCU1234565)", Quantity: 3, UnitRate: 150, Amount: 450},
+				{ChargeType: ChargeTypeDemurrage, Description: "Demurrage, 0 days (container MSCU1234565)", Quantity: 1, UnitRate: 275, Amount: 275},
+			},
+		},
+		{
+			name: "container charges already on the order are kept",
+			order: BillableOrder{
+				Charges: []OrderCharge{{ChargeType: ChargeTypePerDiem, Amount: 400}, {ChargeType: ChargeTypeChassis, Amount: 80}},
+				Chassis: []ChassisUsage{{Days: 2, Amount: 90}},
+				ContainerCharges: []ContainerCharge{
+					{ChargeType: ChargeTypePerDiem, Days: 3, DailyRate: 150, Amount: 450, Status: ContainerChargeClosed},
+				},
+			},
+			rates: map[ChargeType]AccessorialRate{ChargeTypeChassis: chassisRate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PriceOrder(&tt.order, nil, tt.rates, nil)
+			if len(got) != len(tt.want) {
+				t.Fatalf("PriceOrder() = %+v, want %d charges", got, len(tt.want))
+			}
+			for i, c := range got {
+				w := tt.want[i]
+				if c.ChargeType != w.ChargeType || c.Description != w.Description || c.Quantity != w.Quantity || c.UnitRate != w.UnitRate || c.Amount != w.Amount {
+					t.Errorf("charge %d = %+v, want %+v", i, c, w)
+				}
+				if rated := c.RateKind != nil; rated != tt.wantTag[c.ChargeType] {
+					t.Errorf("charge %d billed at an accessorial rate = %v, want %v", i, rated, tt.wantTag[c.ChargeType])
+				}
+			}
+			if len(got) > 0 && got[0].ChargeType == ChargeTypeChassis && tt.order.Chassis[0].TripID != nil {
+				if got[0].TripID == nil || *got[0].TripID != tripID {
+					t.Errorf("chassis trip = %v, want %s", got[0].TripID, tripID)
+				}
+			}
+		})
+	}
+}
+
+func TestInvoiceLinesAndTotals(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	rateID := uuid.New()
+	order := &BillableOrder{
+		OrderID:         uuid.New(),
+		ContainerNumber: "MSCU1234565",
+		Trips:           []BillableTrip{{TripID: first, TripNumber: "TRP-1001"}, {TripID: second, TripNumber: "TRP-1002"}},
+	}
+	lines := InvoiceLines(order, []OrderCharge{
+		{ChargeType: ChargeTypeLineHaul, Description: "Line haul", Quantity: 1, UnitRate: 450, Amount: 450, RateID: &rateID},
+		{ChargeType: ChargeTypeDetention, Description: "Detention", Quantity: 1.5, UnitRate: 80, Amount: 120, TripID: &second},
+		{ChargeType: ChargeTypeGateFee, Amount: 35.005},
+	})
+
+	want := []struct {
+		trip        uuid.UUID
+		tripNumber  string
+		description string
+		quantity    float64
+		amount      float64
+	}{
+		{first, "TRP-1001", "Line haul", 1, 450},
+		{second, "TRP-1002", "Detention", 1.5, 120},
+		{first, "TRP-1001", "GATE_FEE", 1, 35.01},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("InvoiceLines() = %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		w := want[i]
+		if line.TripID == nil || *line.TripID != w.trip || line.TripNumber != w.tripNumber {
+			t.Errorf("line %d trip = %v %q, want %s %q", i, line.TripID, line.TripNumber, w.trip, w.tripNumber)
+		}
+		if line.Description != w.description || line.Quantity != w.quantity || line.Amount != w.amount {
+			t.Errorf("line %d = %q x%g %.2f, want %q x%g %.2f", i, line.Description, line.Quantity, line.Amount, w.description, w.quantity, w.amount)
+		}
+		if line.OrderID == nil || *line.OrderID != order.OrderID || line.ContainerNumber != order.ContainerNumber {
+			t.Errorf("line %d order = %v, container %q", i, line.OrderID, line.ContainerNumber)
+		}
+	}
+	if lines[0].RateID == nil || *lines[0].RateID != rateID {
+		t.Errorf("line haul rate = %v, want %s", lines[0].RateID, rateID)
+	}
+
+	invoice := &Invoice{LineItems: lines, TaxRate: 0.0725, PaidAmount: 100}
+	invoice.ApplyTotals()
+	if invoice.Subtotal != 605.01 || invoice.TaxAmount != 43.86 || invoice.TotalAmount != 648.87 || invoice.BalanceDue != 548.87 {
+		t.Errorf("totals = subtotal %.2f, tax %.2f, total %.2f, balance %.2f",
+			invoice.Subtotal, invoice.TaxAmount, invoice.TotalAmount, invoice.BalanceDue)
+	}
+}
+
+func TestBillableOrderContractRateQuery(t *testing.T) {
+	customerID := uuid.New()
+	tests := []struct {
+		size, containerType string
+		wantSize, wantType  string
+	}{
+		{"20", "DRY", "20", "dry"},
+		{"40HC", "HIGH_CUBE", "40", "dry"},
+		{"45", "REEFER", "45", "reefer"},
+		{"40", "FLAT_RACK", "40", "FLAT_RACK"},
+	}
+	for _, tt := range tests {
+		order := &BillableOrder{CustomerID: &customerID, ContainerSize: tt.size, ContainerType: tt.containerType}
+		q := order.ContractRateQuery()
+		if q.ContainerSize != tt.wantSize || q.ContainerType != tt.wantType || q.CustomerID != customerID {
+			t.Errorf("ContractRateQuery(%s %s) = %+v, want %s %s", tt.size, tt.containerType, q, tt.wantSize, tt.wantType)
+		}
+	}
+}
+
+func TestPaymentTermsAndInvoiceNumber(t *testing.T) {
+	if got := PaymentTerms(0); got != "DUE_ON_RECEIPT" {
+		t.Errorf("PaymentTerms(0) = %q, want DUE_ON_RECEIPT", got)
+	}
+	if got := PaymentTerms(30); got != "NET30" {
+		t.Errorf("PaymentTerms(30) = %q, want NET30", got)
+	}
+	if got := InvoiceNumber(123, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); got != "INV-2026-000123" {
+		t.Errorf("InvoiceNumber() = %q, want INV-2026-000123", got)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
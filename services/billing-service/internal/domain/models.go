@@ -16,6 +16,7 @@ const (
 	InvoiceStatusPaid      InvoiceStatus = "PAID"
 	InvoiceStatusPartial   InvoiceStatus = "PARTIAL"
 	InvoiceStatusOverdue   InvoiceStatus = "OVERDUE"
+	InvoiceStatusDisputed  InvoiceStatus = "DISPUTED"
 	InvoiceStatusVoid      InvoiceStatus = "VOID"
 )
 
@@ -32,11 +33,15 @@ const (
 	ChargeTypeStorage      ChargeType = "STORAGE"
 	ChargeTypeRedelivery   ChargeType = "REDELIVERY"
 	ChargeTypeDryRun       ChargeType = "DRY_RUN"
+	ChargeTypeCancellation ChargeType = "CANCELLATION"
 	ChargeTypeWaiting      ChargeType = "WAITING"
 	ChargeTypeOverweight   ChargeType = "OVERWEIGHT"
 	ChargeTypeHazmat       ChargeType = "HAZMAT"
 	ChargeTypeReefer       ChargeType = "REEFER"
 	ChargeTypePrepull      ChargeType = "PREPULL"
+	ChargeTypeGateFee      ChargeType = "GATE_FEE"
+	ChargeTypeExamFee      ChargeType = "EXAM_FEE"
+	ChargeTypeChassisFlip  ChargeType = "CHASSIS_FLIP"
 	ChargeTypeOther        ChargeType = "OTHER"
 )
 
@@ -62,6 +67,7 @@ type Invoice struct {
 	TotalAmount     float64    `json:"total_amount" db:"total_amount"`
 	PaidAmount      float64    `json:"paid_amount" db:"paid_amount"`
 	BalanceDue      float64    `json:"balance_due" db:"balance_due"`
+	DisputedAmount  float64    `json:"disputed_amount,omitempty" db:"disputed_amount"`
 	
 	// Terms
 	PaymentTerms    string     `json:"payment_terms" db:"payment_terms"` // NET30, NET45, etc.
@@ -76,6 +82,9 @@ type Invoice struct {
 	QBInvoiceID     string     `json:"qb_invoice_id,omitempty" db:"qb_invoice_id"`
 	SyncedAt        *time.Time `json:"synced_at,omitempty" db:"synced_at"`
 	
+	// Rendered PDF, in blob storage
+	DocumentKey     string     `json:"-" db:"document_key"`
+	
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 	CreatedBy       string     `json:"created_by" db:"created_by"`
@@ -105,6 +114,8 @@ type InvoiceLineItem struct {
 	Amount          float64    `json:"amount" db:"amount"`
 	ContainerNumber string     `json:"container_number,omitempty" db:"container_number"`
 	TripNumber      string     `json:"trip_number,omitempty" db:"trip_number"`
+	StopID          *uuid.UUID `json:"stop_id,omitempty" db:"stop_id"` // Delivery stop billed, for a split container
+	RateID          *uuid.UUID `json:"rate_id,omitempty" db:"rate_id"` // Rate the charge was priced under
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
 
@@ -129,6 +140,8 @@ type Rate struct {
 	Name            string     `json:"name" db:"name"`
 	Description     string     `json:"description,omitempty" db:"description"`
 	RateType        string     `json:"rate_type" db:"rate_type"` // flat, per_mile, per_hour
+	ContractID      *uuid.UUID `json:"contract_id,omitempty" db:"contract_id"`
+	FuelScheduleID  *uuid.UUID `json:"fuel_schedule_id,omitempty" db:"-"` // The contract's; fuel is priced off it when set
 	
 	// Origin/Destination
 	OriginType      string     `json:"origin_type" db:"origin_type"` // terminal, port, zone, any
@@ -151,6 +164,8 @@ type Rate struct {
 	EffectiveDate   time.Time  `json:"effective_date" db:"effective_date"`
 	ExpirationDate  *time.Time `json:"expiration_date,omitempty" db:"expiration_date"`
 	IsActive        bool       `json:"is_active" db:"is_active"`
+	SupersededBy    *uuid.UUID `json:"superseded_by,omitempty" db:"superseded_by"` // Version an amendment replaced this one with
+	ExpiryAlertedAt *time.Time `json:"expiry_alerted_at,omitempty" db:"expiry_alerted_at"`
 	
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
@@ -167,7 +182,14 @@ type AccessorialRate struct {
 	MinCharge       float64    `json:"min_charge" db:"min_charge"`
 	MaxCharge       float64    `json:"max_charge" db:"max_charge"`
 	FreeTime        int        `json:"free_time" db:"free_time"` // minutes of free time before charge applies
+	
+	// Validity
+	EffectiveDate   time.Time  `json:"effective_date" db:"effective_date"`
+	ExpirationDate  *time.Time `json:"expiration_date,omitempty" db:"expiration_date"`
 	IsActive        bool       `json:"is_active" db:"is_active"`
+	SupersededBy    *uuid.UUID `json:"superseded_by,omitempty" db:"superseded_by"`
+	ExpiryAlertedAt *time.Time `json:"expiry_alerted_at,omitempty" db:"expiry_alerted_at"`
+	
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -177,6 +199,8 @@ type DriverSettlement struct {
 	ID              uuid.UUID `json:"id" db:"id"`
 	DriverID        uuid.UUID `json:"driver_id" db:"driver_id"`
 	SettlementNumber string   `json:"settlement_number" db:"settlement_number"`
+	Classification  WorkerClassification `json:"classification" db:"classification"`
+	PayeeEntityID   *uuid.UUID `json:"payee_entity_id,omitempty" db:"payee_entity_id"` // Owner-operator business entity
 	PeriodStart     time.Time `json:"period_start" db:"period_start"`
 	PeriodEnd       time.Time `json:"period_end" db:"period_end"`
 	Status          string    `json:"status" db:"status"` // draft, approved, paid
@@ -191,6 +215,7 @@ type DriverSettlement struct {
 	AdvanceDeductions float64 `json:"advance_deductions" db:"advance_deductions"`
 	OtherDeductions float64   `json:"other_deductions" db:"other_deductions"`
 	TotalDeductions float64   `json:"total_deductions" db:"total_deductions"`
+	FederalTaxWithheld float64 `json:"federal_tax_withheld" db:"federal_tax_withheld"` // Backup withholding taken from the payment, within TotalDeductions
 	
 	// Net
 	NetPay          float64   `json:"net_pay" db:"net_pay"`
@@ -217,7 +242,8 @@ type SettlementLineItem struct {
 	TripID          *uuid.UUID `json:"trip_id,omitempty" db:"trip_id"`
 	TripNumber      string    `json:"trip_number,omitempty" db:"trip_number"`
 	TripDate        *time.Time `json:"trip_date,omitempty" db:"trip_date"`
-	Type            string    `json:"type" db:"type"` // trip_pay, accessorial, bonus, deduction
+	TimeOffID       *uuid.UUID `json:"time_off_id,omitempty" db:"time_off_id"` // Paid time off payouts
+	Type            string    `json:"type" db:"type"` // trip_pay, accessorial, bonus, deduction, pto_pay
 	Description     string    `json:"description" db:"description"`
 	Miles           float64   `json:"miles" db:"miles"`
 	Rate            float64   `json:"rate" db:"rate"`
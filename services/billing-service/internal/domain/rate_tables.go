@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rate location types for lane rates
+const (
+	RateLocationAny      = "any"      // Matches every location
+	RateLocationZone     = "zone"     // Matches the locations in a rate zone
+	RateLocationSpecific = "location" // Matches one location by ID
+)
+
+// RateZone groups locations that price alike, such as the warehouses of one
+// inland region. A location is in the zone when it is listed or its ZIP code
+// starts with one of the postal prefixes.
+type RateZone struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	Code           string      `json:"code" db:"code"` // Referenced by lane rates, e.g. "IE-EAST"
+	Name           string      `json:"name" db:"name"`
+	Description    string      `json:"description,omitempty" db:"description"`
+	PostalPrefixes []string    `json:"postal_prefixes" db:"postal_prefixes"`
+	LocationIDs    []uuid.UUID `json:"location_ids" db:"location_ids"`
+	IsActive       bool        `json:"is_active" db:"is_active"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// NormalizeZoneCode uppercases a zone code and trims it
+func NormalizeZoneCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// RateContract is a customer's pricing agreement: its lane rates and, when
+// fuel is priced off the diesel index, its fuel surcharge schedule. Lane
+// rates are versioned individually by rate amendments.
+type RateContract struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	CustomerID     uuid.UUID  `json:"customer_id" db:"customer_id"`
+	CustomerName   string     `json:"customer_name,omitempty" db:"-"`
+	ContractNumber string     `json:"contract_number" db:"contract_number"`
+	Name           string     `json:"name" db:"name"`
+	FuelScheduleID *uuid.UUID `json:"fuel_schedule_id,omitempty" db:"fuel_schedule_id"`
+	EffectiveDate  time.Time  `json:"effective_date" db:"effective_date"`
+	ExpirationDate *time.Time `json:"expiration_date,omitempty" db:"expiration_date"`
+	IsActive       bool       `json:"is_active" db:"is_active"`
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+
+	LaneRates []Rate `json:"lane_rates" db:"-"` // Current versions
+}
+
+// LaneDescription describes the lane a rate prices, e.g. "zone PORT-LA to zone IE-EAST"
+func (r *Rate) LaneDescription() string {
+	end := func(locType string, id *uuid.UUID, zone string) string {
+		switch {
+		case locType == RateLocationZone && zone != "":
+			return "zone " + zone
+		case id != nil:
+			return locType + " " + id.String()
+		default:
+			return "any"
+		}
+	}
+	return end(r.OriginType, r.OriginID, r.OriginZone) + " to " + end(r.DestinationType, r.DestinationID, r.DestinationZone)
+}
+
+// FuelSurchargeTier sets the surcharge while the diesel price is at least
+// MinPrice and below MaxPrice; the top tier has no MaxPrice
+type FuelSurchargeTier struct {
+	MinPrice float64  `json:"min_price" db:"min_price"` // Dollars per gallon
+	MaxPrice *float64 `json:"max_price,omitempty" db:"max_price"`
+	Percent  float64  `json:"percent" db:"surcharge_percent"` // Of line haul
+}
+
+// FuelSurchargeSchedule prices fuel as a percent of line haul that moves with
+// the published diesel price for its region. CustomerID is nil for the
+// default schedule.
+type FuelSurchargeSchedule struct {
+	ID             uuid.UUID           `json:"id" db:"id"`
+	CustomerID     *uuid.UUID          `json:"customer_id,omitempty" db:"customer_id"`
+	Name           string              `json:"name" db:"name"`
+	Region         string              `json:"region" db:"region"` // Diesel price index region, e.g. "US" or "CALIFORNIA"
+	EffectiveDate  time.Time           `json:"effective_date" db:"effective_date"`
+	ExpirationDate *time.Time          `json:"expiration_date,omitempty" db:"expiration_date"`
+	IsActive       bool                `json:"is_active" db:"is_active"`
+	Tiers          []FuelSurchargeTier `json:"tiers" db:"-"` // By MinPrice
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// IsEffective reports whether the schedule prices work done at the given time
+func (s *FuelSurchargeSchedule) IsEffective(at time.Time) bool {
+	return s.IsActive && !at.Before(s.EffectiveDate) && (s.ExpirationDate == nil || at.Before(*s.ExpirationDate))
+}
+
+// PercentAt returns the surcharge percent at a diesel price, or false when
+// no tier covers the price
+func (s *FuelSurchargeSchedule) PercentAt(price float64) (float64, bool) {
+	for _, tier := range s.Tiers {
+		if price >= tier.MinPrice && (tier.MaxPrice == nil || price < *tier.MaxPrice) {
+			return tier.Percent, true
+		}
+	}
+	return 0, false
+}
+
+// CheckTiers returns a problem with tiers sorted by MinPrice - a gap, an
+// overlap or an open tier below the top - or "" when they are sound
+func CheckTiers(tiers []FuelSurchargeTier) string {
+	if len(tiers) == 0 {
+		return "at least one tier is required"
+	}
+	for i, tier := range tiers {
+		if tier.MinPrice < 0 || tier.Percent < 0 {
+			return fmt.Sprintf("tier %d has a negative price or percent", i+1)
+		}
+		if tier.MaxPrice != nil && *tier.MaxPrice <= tier.MinPrice {
+			return fmt.Sprintf("tier %d max_price must be above its min_price", i+1)
+		}
+		if i == len(tiers)-1 {
+			break
+		}
+		if tier.MaxPrice == nil {
+			return fmt.Sprintf("only the top tier may leave max_price open; tier %d does", i+1)
+		}
+		if *tier.MaxPrice != tiers[i+1].MinPrice {
+			return fmt.Sprintf("tier %d ends at %.3f but tier %d starts at %.3f", i+1, *tier.MaxPrice, i+2, tiers[i+1].MinPrice)
+		}
+	}
+	return ""
+}
+
+// FuelPrice is a published diesel price for a region, e.g. the weekly DOE
+// on-highway average
+type FuelPrice struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	Region         string    `json:"region" db:"region"`
+	EffectiveDate  time.Time `json:"effective_date" db:"effective_date"`
+	PricePerGallon float64   `json:"price_per_gallon" db:"price_per_gallon"`
+	Source         string    `json:"source,omitempty" db:"source"`
+	CreatedBy      string    `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// FuelSurchargeBasis records how a schedule set a rate's fuel surcharge
+type FuelSurchargeBasis struct {
+	ScheduleID   uuid.UUID `json:"schedule_id"`
+	ScheduleName string    `json:"schedule_name"`
+	Region       string    `json:"region"`
+	DieselPrice  float64   `json:"diesel_price"`
+	PriceDate    time.Time `json:"price_date"`
+	Percent      float64   `json:"percent"`
+}
+
+// ApplyFuelSchedule prices the rate's fuel surcharge at the schedule percent
+func (r *Rate) ApplyFuelSchedule(basis *FuelSurchargeBasis) {
+	r.FuelSurcharge = basis.Percent
+	r.FuelSurchargeType = "percent"
+}
+
+// QuoteAccessorial is an accessorial expected on a move, priced into a quote
+type QuoteAccessorial struct {
+	ChargeType ChargeType `json:"charge_type"`
+	Quantity   float64    `json:"quantity"` // Hours, days or units as the rate charges; 1 when zero
+}
+
+// RateQuoteRequest describes a move to quote before the order is created
+type RateQuoteRequest struct {
+	ContractRateQuery
+	Accessorials []QuoteAccessorial
+}
+
+// RateQuoteLine is one priced charge of a quote
+type RateQuoteLine struct {
+	ChargeType  ChargeType `json:"charge_type"`
+	Description string     `json:"description"`
+	Quantity    float64    `json:"quantity"`
+	UnitRate    float64    `json:"unit_rate"`
+	Amount      float64    `json:"amount"`
+	RateKind    RateKind   `json:"rate_kind"`
+	RateID      uuid.UUID  `json:"rate_id"`
+}
+
+// RateQuote is the revenue a customer's rate tables put on a move
+type RateQuote struct {
+	CustomerID     uuid.UUID           `json:"customer_id"`
+	At             time.Time           `json:"at"`
+	ContractID     *uuid.UUID          `json:"contract_id,omitempty"`
+	ContractRateID uuid.UUID           `json:"contract_rate_id"`
+	RateName       string              `json:"rate_name"`
+	Lane           string              `json:"lane"`
+	Fuel           *FuelSurchargeBasis `json:"fuel,omitempty"` // Set when a schedule priced fuel
+	Lines          []RateQuoteLine     `json:"lines"`
+	LineHaul       float64             `json:"line_haul"`
+	FuelSurcharge  float64             `json:"fuel_surcharge"`
+	Accessorials   float64             `json:"accessorials"`
+	Total          float64             `json:"total"`
+	Currency       string              `json:"currency"`
+	// Requested accessorials the customer's price list has no rate for
+	Unpriced []ChargeType `json:"unpriced,omitempty"`
+}
+
+// QuoteRate prices a move at a contract rate, with fuel as PriceOrder takes
+// it, plus the requested accessorials at the customer's price list
+func QuoteRate(rate *Rate, accessorials map[ChargeType]AccessorialRate, requested []QuoteAccessorial) *RateQuote {
+	quote := &RateQuote{
+		CustomerID:     rate.CustomerID,
+		ContractID:     rate.ContractID,
+		ContractRateID: rate.ID,
+		RateName:       rate.Name,
+		Lane:           rate.LaneDescription(),
+		Lines:          []RateQuoteLine{},
+		Currency:       "USD",
+	}
+	add := func(line RateQuoteLine) {
+		line.Amount = roundCents(line.Amount)
+		quote.Lines = append(quote.Lines, line)
+		switch line.ChargeType {
+		case ChargeTypeLineHaul:
+			quote.LineHaul = roundCents(quote.LineHaul + line.Amount)
+		case ChargeTypeFuelSurcharge:
+			quote.FuelSurcharge = roundCents(quote.FuelSurcharge + line.Amount)
+		default:
+			quote.Accessorials = roundCents(quote.Accessorials + line.Amount)
+		}
+		quote.Total = roundCents(quote.Total + line.Amount)
+	}
+
+	// Line haul and fuel come from the same pricing invoices use
+	order := &BillableOrder{}
+	for _, c := range PriceOrder(order, rate, nil, nil) {
+		add(RateQuoteLine{
+			ChargeType:  c.ChargeType,
+			Description: c.Description,
+			Quantity:    c.Quantity,
+			UnitRate:    c.UnitRate,
+			Amount:      c.Amount,
+			RateKind:    RateKindContract,
+			RateID:      rate.ID,
+		})
+	}
+
+	for _, want := range requested {
+		if want.ChargeType == ChargeTypeLineHaul || want.ChargeType == ChargeTypeFuelSurcharge {
+			continue
+		}
+		accessorial, ok := accessorials[want.ChargeType]
+		if !ok {
+			quote.Unpriced = append(quote.Unpriced, want.ChargeType)
+			continue
+		}
+		quantity := want.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		description := accessorial.Description
+		if description == "" {
+			description = string(want.ChargeType)
+		}
+		c := accessorialCharge(accessorial, quantity, description, nil)
+		add(RateQuoteLine{
+			ChargeType:  c.ChargeType,
+			Description: c.Description,
+			Quantity:    c.Quantity,
+			UnitRate:    c.UnitRate,
+			Amount:      c.Amount,
+			RateKind:    RateKindAccessorial,
+			RateID:      accessorial.ID,
+		})
+	}
+	return quote
+}
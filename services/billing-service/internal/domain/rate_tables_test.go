@@ -0,0 +1,260 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func pricePtr(v float64) *float64 {
+	return &v
+}
+
+func TestCheckTiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers []FuelSurchargeTier
+		want  string
+	}{
+		{
+			name: "contiguous tiers with an open top",
+			tiers: []FuelSurchargeTier{
+				{MinPrice: 0, MaxPrice: pricePtr(3.50), Percent: 10},
+				{MinPrice: 3.50, MaxPrice: pricePtr(4.00), Percent: 15},
+				{MinPrice: 4.00, Percent: 20},
+			},
+		},
+		{
+			name:  "a single closed tier",
+			tiers: []FuelSurchargeTier{{MinPrice: 3, MaxPrice: pricePtr(5), Percent: 12}},
+		},
+		{
+			name: "no tiers",
+			want: "at least one tier is required",
+		},
+		{
+			name: "gap between tiers",
+			tiers: []FuelSurchargeTier{
+				{MinPrice: 0, MaxPrice: pricePtr(3.50), Percent: 10},
+				{MinPrice: 3.75, Percent: 15},
+			},
+			want: "tier 1 ends at 3.500 but tier 2 starts at 3.750",
+		},
+		{
+			name: "overlapping tiers",
+			tiers: []FuelSurchargeTier{
+				{MinPrice: 0, MaxPrice: pricePtr(4.00), Percent: 10},
+				{MinPrice: 3.50, Percent: 15},
+			},
+			want: "tier 1 ends at 4.000 but tier 2 starts at 3.500",
+		},
+		{
+			name: "open tier below the top",
+			tiers: []FuelSurchargeTier{
+				{MinPrice: 0, Percent: 10},
+				{MinPrice: 3.50, Percent: 15},
+			},
+			want: "only the top tier may leave max_price open; tier 1 does",
+		},
+		{
+			name: "max price not above min price",
+			tiers: []FuelSurchargeTier{
+				{MinPrice: 0, MaxPrice: pricePtr(3.50), Percent: 10},
+				{MinPrice: 3.50, MaxPrice: pricePtr(3.50), Percent: 15},
+			},
+			want: "tier 2 max_price must be above its min_price",
+		},
+		{
+			name:  "negative percent",
+			tiers: []FuelSurchargeTier{{MinPrice: 0, Percent: -5}},
+			want:  "tier 1 has a negative price or percent",
+		},
+		{
+			name:  "negative price",
+			tiers: []FuelSurchargeTier{{MinPrice: -1, Percent: 5}},
+			want:  "tier 1 has a negative price or percent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckTiers(tt.tiers); got != tt.want {
+				t.Errorf("CheckTiers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuelSurchargeSchedulePercentAt(t *testing.T) {
+	schedule := &FuelSurchargeSchedule{Tiers: []FuelSurchargeTier{
+		{MinPrice: 3.00, MaxPrice: pricePtr(3.50), Percent: 10},
+		{MinPrice: 3.50, MaxPrice: pricePtr(4.00), Percent: 15},
+		{MinPrice: 4.00, Percent: 20},
+	}}
+	tests := []struct {
+		price   float64
+		want    float64
+		covered bool
+	}{
+		{price: 2.99},
+		{price: 3.00, want: 10, covered: true}, // MinPrice is in the tier
+		{price: 3.499, want: 10, covered: true},
+		{price: 3.50, want: 15, covered: true}, // MaxPrice starts the next tier
+		{price: 4.00, want: 20, covered: true},
+		{price: 9.75, want: 20, covered: true}, // The top tier is open
+	}
+	for _, tt := range tests {
+		got, covered := schedule.PercentAt(tt.price)
+		if got != tt.want || covered != tt.covered {
+			t.Errorf("PercentAt(%.3f) = %g, %v, want %g, %v", tt.price, got, covered, tt.want, tt.covered)
+		}
+	}
+
+	closed := &FuelSurchargeSchedule{Tiers: []FuelSurchargeTier{{MinPrice: 3, MaxPrice: pricePtr(4), Percent: 12}}}
+	if _, covered := closed.PercentAt(4); covered {
+		t.Error("PercentAt() covered a price at the top tier's max price")
+	}
+}
+
+func TestIsEffective(t *testing.T) {
+	effective := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		at      time.Time
+		expires *time.Time
+		active  bool
+		want    bool
+	}{
+		{name: "before the effective date", at: effective.Add(-time.Second), expires: &expires, active: true},
+		{name: "on the effective date", at: effective, expires: &expires, active: true, want: true},
+		{name: "just before expiry", at: expires.Add(-time.Second), expires: &expires, active: true, want: true},
+		{name: "on the expiration date", at: expires, expires: &expires, active: true},
+		{name: "no expiration date", at: expires.AddDate(5, 0, 0), active: true, want: true},
+		{name: "inactive", at: effective.AddDate(0, 1, 0), expires: &expires},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &FuelSurchargeSchedule{EffectiveDate: effective, ExpirationDate: tt.expires, IsActive: tt.active}
+			rate := &Rate{EffectiveDate: effective, ExpirationDate: tt.expires, IsActive: tt.active}
+			accessorial := &AccessorialRate{EffectiveDate: effective, ExpirationDate: tt.expires, IsActive: tt.active}
+			if got := schedule.IsEffective(tt.at); got != tt.want {
+				t.Errorf("FuelSurchargeSchedule.IsEffective() = %v, want %v", got, tt.want)
+			}
+			if got := rate.IsEffective(tt.at); got != tt.want {
+				t.Errorf("Rate.IsEffective() = %v, want %v", got, tt.want)
+			}
+			if got := accessorial.IsEffective(tt.at); got != tt.want {
+				t.Errorf("AccessorialRate.IsEffective() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteRate(t *testing.T) {
+	accessorials := map[ChargeType]AccessorialRate{
+		ChargeTypeChassis: {ID: uuid.New(), ChargeType: ChargeTypeChassis, Description: "Chassis per day", Rate: 35},
+		ChargeTypeHazmat:  {ID: uuid.New(), ChargeType: ChargeTypeHazmat, Rate: 150},
+		ChargeTypeWaiting: {ID: uuid.New(), ChargeType: ChargeTypeWaiting, Description: "Waiting time", Rate: 60, MinCharge: 100, MaxCharge: 300},
+	}
+	tests := []struct {
+		name         string
+		fuel         float64
+		fuelType     string
+		requested    []QuoteAccessorial
+		wantFuel     float64
+		wantFuelDesc string
+		wantAccess   float64
+		wantTotal    float64
+		wantUnpriced []ChargeType
+	}{
+		{
+			name:         "percent fuel is a share of line haul",
+			fuel:         18.5,
+			fuelType:     "percent",
+			wantFuel:     83.25,
+			wantFuelDesc: "Fuel surcharge (18.5%)",
+			wantTotal:    533.25,
+		},
+		{
+			name:         "flat fuel is the contract amount",
+			fuel:         65,
+			fuelType:     "flat",
+			wantFuel:     65,
+			wantFuelDesc: "Fuel surcharge",
+			wantTotal:    515,
+		},
+		{
+			name:      "no fuel without a surcharge",
+			fuelType:  "percent",
+			wantTotal: 450,
+		},
+		{
+			name:     "accessorials are priced at the price list and held to its min and max",
+			fuelType: "flat",
+			requested: []QuoteAccessorial{
+				{ChargeType: ChargeTypeChassis, Quantity: 3},  // 105
+				{ChargeType: ChargeTypeHazmat},                // 150, quantity defaults to 1
+				{ChargeType: ChargeTypeWaiting, Quantity: 1},  // 60 raised to the 100 minimum
+				{ChargeType: ChargeTypeWaiting, Quantity: 10}, // 600 held to the 300 maximum
+				{ChargeType: ChargeTypeLineHaul, Quantity: 2}, // Priced by the contract, ignored
+				{ChargeType: ChargeTypeReefer, Quantity: 1},   // Not on the price list
+				{ChargeType: ChargeTypeFuelSurcharge},         // Priced by the contract, ignored
+			},
+			wantAccess:   655,
+			wantTotal:    1105,
+			wantUnpriced: []ChargeType{ChargeTypeReefer},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := &Rate{
+				ID: uuid.New(), CustomerID: uuid.New(), Name: "Port of LA to Inland Empire",
+				OriginType: RateLocationZone, OriginZone: "PORT-LA", DestinationType: RateLocationAny,
+				BaseRate: 450, FuelSurcharge: tt.fuel, FuelSurchargeType: tt.fuelType,
+			}
+			quote := QuoteRate(rate, accessorials, tt.requested)
+
+			if quote.LineHaul != 450 || quote.FuelSurcharge != tt.wantFuel || quote.Accessorials != tt.wantAccess || quote.Total != tt.wantTotal {
+				t.Errorf("totals = line haul %.2f, fuel %.2f, accessorials %.2f, total %.2f; want 450, %.2f, %.2f, %.2f",
+					quote.LineHaul, quote.FuelSurcharge, quote.Accessorials, quote.Total, tt.wantFuel, tt.wantAccess, tt.wantTotal)
+			}
+			if !reflect.DeepEqual(quote.Unpriced, tt.wantUnpriced) {
+				t.Errorf("unpriced = %v, want %v", quote.Unpriced, tt.wantUnpriced)
+			}
+			if quote.Lane != "zone PORT-LA to any" || quote.Currency != "USD" || quote.ContractRateID != rate.ID {
+				t.Errorf("quote = lane %q, currency %q, rate %s", quote.Lane, quote.Currency, quote.ContractRateID)
+			}
+
+			var fuelLines int
+			for _, line := range quote.Lines {
+				switch line.ChargeType {
+				case ChargeTypeLineHaul:
+					if line.RateKind != RateKindContract || line.RateID != rate.ID || line.Description != rate.Name {
+						t.Errorf("line haul line = %+v", line)
+					}
+				case ChargeTypeFuelSurcharge:
+					fuelLines++
+					if line.Description != tt.wantFuelDesc || line.RateKind != RateKindContract {
+						t.Errorf("fuel line = %+v, want description %q", line, tt.wantFuelDesc)
+					}
+				default:
+					want := accessorials[line.ChargeType]
+					if line.RateKind != RateKindAccessorial || line.RateID != want.ID {
+						t.Errorf("accessorial line = %+v", line)
+					}
+					if want.Description == "" && line.Description != string(line.ChargeType) {
+						t.Errorf("accessorial description = %q, want the charge type", line.Description)
+					}
+				}
+			}
+			if wantLines := map[bool]int{true: 1, false: 0}[tt.wantFuel > 0]; fuelLines != wantLines {
+				t.Errorf("fuel lines = %d, want %d", fuelLines, wantLines)
+			}
+		})
+	}
+}
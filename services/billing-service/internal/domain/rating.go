@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateKind identifies which rate table a rate lives in
+type RateKind string
+
+const (
+	RateKindContract    RateKind = "CONTRACT"    // Customer line haul rate, with its fuel surcharge
+	RateKindAccessorial RateKind = "ACCESSORIAL" // Customer or default accessorial rate
+)
+
+// IsValid checks if the rate kind is known
+func (k RateKind) IsValid() bool {
+	return k == RateKindContract || k == RateKindAccessorial
+}
+
+// IsEffective reports whether the rate prices work done at the given time
+func (r *Rate) IsEffective(at time.Time) bool {
+	return r.IsActive && !at.Before(r.EffectiveDate) && (r.ExpirationDate == nil || at.Before(*r.ExpirationDate))
+}
+
+// IsEffective reports whether the rate prices work done at the given time
+func (r *AccessorialRate) IsEffective(at time.Time) bool {
+	return r.IsActive && !at.Before(r.EffectiveDate) && (r.ExpirationDate == nil || at.Before(*r.ExpirationDate))
+}
+
+// ContractRateQuery describes a move to find the customer's contract rate
+// for. Nil locations and empty container attributes only match rates that
+// apply to any.
+type ContractRateQuery struct {
+	CustomerID    uuid.UUID
+	OriginID      *uuid.UUID
+	DestinationID *uuid.UUID
+	ContainerSize string // 20, 40 or 45
+	ContainerType string // As on the container, such as DRY or REEFER
+	At            time.Time
+}
+
+// RateExpiration is a current rate coming up on its expiration date
+type RateExpiration struct {
+	RateKind       RateKind   `json:"rate_kind"`
+	RateID         uuid.UUID  `json:"rate_id"`
+	CustomerID     *uuid.UUID `json:"customer_id,omitempty"` // Nil for a default accessorial rate
+	CustomerName   string     `json:"customer_name,omitempty"`
+	Name           string     `json:"name"` // Rate name, or the accessorial charge type
+	ExpirationDate time.Time  `json:"expiration_date"`
+	DaysLeft       int        `json:"days_left"`
+	AlertedAt      *time.Time `json:"alerted_at,omitempty"`
+}
+
+// RatedCharge is an order charge priced under a rate. InvoiceID and
+// LineItemID are set once the charge has been billed.
+type RatedCharge struct {
+	ID             uuid.UUID  `json:"id"`
+	OrderID        uuid.UUID  `json:"order_id"`
+	OrderNumber    string     `json:"order_number"`
+	OrderCreatedAt time.Time  `json:"order_created_at"`
+	ChargeType     ChargeType `json:"charge_type"`
+	Quantity       float64    `json:"quantity"`
+	UnitRate       float64    `json:"unit_rate"`
+	Amount         float64    `json:"amount"`
+	InvoiceID      *uuid.UUID `json:"invoice_id,omitempty"`
+	LineItemID     *uuid.UUID `json:"line_item_id,omitempty"`
+}
+
+// RerateStatus is what happened to a re-rated charge
+type RerateStatus string
+
+const (
+	// RerateStatusApplied means the unbilled charge was changed in place
+	RerateStatusApplied RerateStatus = "APPLIED"
+	// RerateStatusPendingInvoiceAdjustment means the charge was already
+	// invoiced; the invoice is left alone and billing issues a credit memo or
+	// supplemental invoice for the delta
+	RerateStatusPendingInvoiceAdjustment RerateStatus = "PENDING_INVOICE_ADJUSTMENT"
+)
+
+// RerateAdjustment is the audit record of one charge re-rated by an amendment
+type RerateAdjustment struct {
+	ID          uuid.UUID    `json:"id" db:"id"`
+	AmendmentID uuid.UUID    `json:"amendment_id" db:"amendment_id"`
+	OrderID     uuid.UUID    `json:"order_id" db:"order_id"`
+	OrderNumber string       `json:"order_number" db:"order_number"`
+	ChargeID    uuid.UUID    `json:"charge_id" db:"charge_id"`
+	ChargeType  ChargeType   `json:"charge_type" db:"charge_type"`
+	InvoiceID   *uuid.UUID   `json:"invoice_id,omitempty" db:"invoice_id"`
+	LineItemID  *uuid.UUID   `json:"line_item_id,omitempty" db:"line_item_id"`
+	Quantity    float64      `json:"quantity" db:"quantity"`
+	OldUnitRate float64      `json:"old_unit_rate" db:"old_unit_rate"`
+	NewUnitRate float64      `json:"new_unit_rate" db:"new_unit_rate"`
+	OldAmount   float64      `json:"old_amount" db:"old_amount"`
+	NewAmount   float64      `json:"new_amount" db:"new_amount"`
+	Delta       float64      `json:"delta" db:"delta"` // NewAmount - OldAmount
+	Status      RerateStatus `json:"status" db:"status"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+}
+
+// RateAmendment replaces a rate with a new version from EffectiveDate. When
+// EffectiveDate is in the past, charges on orders created since then under
+// the old version are re-rated at the new one.
+type RateAmendment struct {
+	ID                  uuid.UUID  `json:"id" db:"id"`
+	RateKind            RateKind   `json:"rate_kind" db:"rate_kind"`
+	PreviousRateID      uuid.UUID  `json:"previous_rate_id" db:"previous_rate_id"`
+	NewRateID           uuid.UUID  `json:"new_rate_id" db:"new_rate_id"`
+	CustomerID          *uuid.UUID `json:"customer_id,omitempty" db:"customer_id"`
+	EffectiveDate       time.Time  `json:"effective_date" db:"effective_date"`
+	Reason              string     `json:"reason" db:"reason"`
+	ChargesRerated      int        `json:"charges_rerated" db:"charges_rerated"`
+	OrdersRerated       int        `json:"orders_rerated" db:"orders_rerated"`
+	TotalDelta          float64    `json:"total_delta" db:"total_delta"`
+	PendingInvoiceDelta float64    `json:"pending_invoice_delta" db:"pending_invoice_delta"` // Part of TotalDelta on charges already invoiced
+	AmendedBy           string     `json:"amended_by" db:"amended_by"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+
+	// The new version; only the one matching RateKind is set
+	ContractRate    *Rate              `json:"contract_rate,omitempty" db:"-"`
+	AccessorialRate *AccessorialRate   `json:"accessorial_rate,omitempty" db:"-"`
+	Adjustments     []RerateAdjustment `json:"adjustments" db:"-"`
+}
+
+// RerateContractCharges prices charges at a contract rate. Line haul is the
+// base rate per unit; a percent fuel surcharge is taken on the order's re-rated
+// line haul, a flat one per unit. Other charge types are left as they are.
+func RerateContractCharges(rate *Rate, charges []RatedCharge) []RatedCharge {
+	lineHaul := make(map[uuid.UUID]float64)
+	out := make([]RatedCharge, 0, len(charges))
+	for _, c := range charges {
+		if c.ChargeType == ChargeTypeLineHaul {
+			c.UnitRate = rate.BaseRate
+			c.Amount = roundCents(c.Quantity * rate.BaseRate)
+			lineHaul[c.OrderID] += c.Amount
+		}
+		out = append(out, c)
+	}
+	for i := range out {
+		c := &out[i]
+		if c.ChargeType != ChargeTypeFuelSurcharge {
+			continue
+		}
+		if rate.FuelSurchargeType == "flat" {
+			c.UnitRate = rate.FuelSurcharge
+			c.Amount = roundCents(c.Quantity * rate.FuelSurcharge)
+			continue
+		}
+		c.Amount = roundCents(lineHaul[c.OrderID] * rate.FuelSurcharge / 100)
+		if c.Quantity > 0 {
+			c.UnitRate = roundCents(c.Amount / c.Quantity)
+		}
+	}
+	return out
+}
+
+// RerateAccessorialCharges prices charges at an accessorial rate, per unit
+// and held between the minimum and any maximum charge
+func RerateAccessorialCharges(rate *AccessorialRate, charges []RatedCharge) []RatedCharge {
+	out := make([]RatedCharge, 0, len(charges))
+	for _, c := range charges {
+		c.UnitRate = rate.Rate
+		amount := c.Quantity * rate.Rate
+		if amount < rate.MinCharge {
+			amount = rate.MinCharge
+		}
+		if rate.MaxCharge > 0 && amount > rate.MaxCharge {
+			amount = rate.MaxCharge
+		}
+		c.Amount = roundCents(amount)
+		out = append(out, c)
+	}
+	return out
+}
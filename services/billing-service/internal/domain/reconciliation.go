@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReconciliationIssueType classifies a gap between the work done and what was billed
+type ReconciliationIssueType string
+
+const (
+	ReconIssueMissingLineHaul    ReconciliationIssueType = "MISSING_LINE_HAUL"
+	ReconIssueMissingFuel        ReconciliationIssueType = "MISSING_FUEL_SURCHARGE"
+	ReconIssueMissingAccessorial ReconciliationIssueType = "MISSING_ACCESSORIAL" // Captured by dispatch but never charged
+	ReconIssueNotInvoiced        ReconciliationIssueType = "NOT_INVOICED"        // Charged but on no invoice after the grace period
+	ReconIssueDoubleBilled       ReconciliationIssueType = "DOUBLE_BILLED"
+)
+
+// ReconciliationIssueStatus is where a finance exception is in review
+type ReconciliationIssueStatus string
+
+const (
+	ReconIssueStatusOpen      ReconciliationIssueStatus = "OPEN"
+	ReconIssueStatusResolved  ReconciliationIssueStatus = "RESOLVED"  // Fixed by finance
+	ReconIssueStatusDismissed ReconciliationIssueStatus = "DISMISSED" // Not a problem, e.g. a no-charge move
+	ReconIssueStatusCleared   ReconciliationIssueStatus = "CLEARED"   // No longer found by a later run
+)
+
+// TripCharge is a customer charge on one of a trip's orders
+type TripCharge struct {
+	OrderID    uuid.UUID  `json:"order_id"`
+	ChargeType ChargeType `json:"charge_type"`
+	Amount     float64    `json:"amount"`
+}
+
+// TripInvoiceLine is a line billing a trip or one of its orders on an
+// invoice that was not voided
+type TripInvoiceLine struct {
+	LineItemID    uuid.UUID  `json:"line_item_id"`
+	InvoiceID     uuid.UUID  `json:"invoice_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	OrderID       *uuid.UUID `json:"order_id,omitempty"`
+	ChargeType    ChargeType `json:"charge_type"`
+	Amount        float64    `json:"amount"`
+}
+
+// CapturedAccessorial is a chargeable event dispatch recorded on a trip,
+// such as detention at a stop or a cancellation fee
+type CapturedAccessorial struct {
+	ChargeType ChargeType `json:"charge_type"`
+	Amount     float64    `json:"amount"`
+	Source     string     `json:"source"` // e.g. "detention at stop 2"
+}
+
+// TripBillingRecord is a finished trip with everything charged and billed for it
+type TripBillingRecord struct {
+	TripID       uuid.UUID
+	TripNumber   string
+	CustomerID   *uuid.UUID
+	OrderIDs     []uuid.UUID
+	Cancelled    bool // Cancelled with a fee; only the fee is owed
+	FinishedAt   time.Time
+	Charges      []TripCharge
+	InvoiceLines []TripInvoiceLine
+	Accessorials []CapturedAccessorial
+}
+
+// ReconciliationIssue is a finance exception raised by reconciliation. Only
+// one issue of a type and charge type is open for a trip at a time.
+type ReconciliationIssue struct {
+	ID              uuid.UUID                 `json:"id"`
+	TripID          uuid.UUID                 `json:"trip_id"`
+	TripNumber      string                    `json:"trip_number"`
+	CustomerID      *uuid.UUID                `json:"customer_id,omitempty"`
+	Type            ReconciliationIssueType   `json:"type"`
+	ChargeType      ChargeType                `json:"charge_type,omitempty"`
+	Amount          float64                   `json:"amount"` // Unbilled or double-billed amount, when known
+	Detail          string                    `json:"detail"`
+	InvoiceIDs      []uuid.UUID               `json:"invoice_ids,omitempty"` // Invoices carrying a double-billed charge
+	Status          ReconciliationIssueStatus `json:"status"`
+	FirstRunID      uuid.UUID                 `json:"first_run_id"`
+	LastRunID       uuid.UUID                 `json:"last_run_id"`
+	DetectedAt      time.Time                 `json:"detected_at"`
+	ResolvedBy      string                    `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time                `json:"resolved_at,omitempty"`
+	ResolutionNotes string                    `json:"resolution_notes,omitempty"`
+}
+
+// ReconciliationRun is one pass over the trips finished in a window
+type ReconciliationRun struct {
+	ID                 uuid.UUID  `json:"id"`
+	PeriodStart        time.Time  `json:"period_start"`
+	PeriodEnd          time.Time  `json:"period_end"`
+	TripsChecked       int        `json:"trips_checked"`
+	IssuesFound        int        `json:"issues_found"`
+	IssuesOpened       int        `json:"issues_opened"`  // Found for the first time
+	IssuesCleared      int        `json:"issues_cleared"` // Open before and not found again
+	UnbilledAmount     float64    `json:"unbilled_amount"`
+	DoubleBilledAmount float64    `json:"double_billed_amount"`
+	RunBy              string     `json:"run_by"`
+	StartedAt          time.Time  `json:"started_at"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReconciliationIssueFilter selects finance exceptions
+type ReconciliationIssueFilter struct {
+	Status     []ReconciliationIssueStatus
+	Type       *ReconciliationIssueType
+	TripID     *uuid.UUID
+	CustomerID *uuid.UUID
+	Limit      int
+}
+
+// ReconcileTrip checks a finished trip's billing. A completed trip must be
+// charged line haul and fuel surcharge; every accessorial dispatch captured
+// must be charged; the charges of a trip finished before invoiceCutoff must
+// be on an invoice; and no charge may be invoiced twice. A trip's charges are compared by
+// type, so a detention charged at a different amount than captured is not
+// flagged here.
+func ReconcileTrip(rec TripBillingRecord, invoiceCutoff time.Time) []ReconciliationIssue {
+	charged := make(map[ChargeType]bool)
+	for _, c := range rec.Charges {
+		charged[c.ChargeType] = true
+	}
+	for _, l := range rec.InvoiceLines {
+		charged[l.ChargeType] = true
+	}
+
+	var issues []ReconciliationIssue
+	add := func(issueType ReconciliationIssueType, chargeType ChargeType, amount float64, detail string) {
+		issues = append(issues, ReconciliationIssue{
+			TripID:     rec.TripID,
+			TripNumber: rec.TripNumber,
+			CustomerID: rec.CustomerID,
+			Type:       issueType,
+			ChargeType: chargeType,
+			Amount:     roundCents(amount),
+			Detail:     detail,
+		})
+	}
+
+	if !rec.Cancelled {
+		if !charged[ChargeTypeLineHaul] {
+			add(ReconIssueMissingLineHaul, ChargeTypeLineHaul, 0,
+				fmt.Sprintf("Trip %s was completed without a line haul charge", rec.TripNumber))
+		}
+		if !charged[ChargeTypeFuelSurcharge] {
+			add(ReconIssueMissingFuel, ChargeTypeFuelSurcharge, 0,
+				fmt.Sprintf("Trip %s was completed without a fuel surcharge", rec.TripNumber))
+		}
+	}
+
+	captured := make(map[ChargeType]float64)
+	var capturedOrder []ChargeType
+	for _, a := range rec.Accessorials {
+		if _, ok := captured[a.ChargeType]; !ok {
+			capturedOrder = append(capturedOrder, a.ChargeType)
+		}
+		captured[a.ChargeType] += a.Amount
+	}
+	for _, chargeType := range capturedOrder {
+		if !charged[chargeType] {
+			add(ReconIssueMissingAccessorial, chargeType, captured[chargeType],
+				fmt.Sprintf("Dispatch captured %s of $%.2f on trip %s but it was never charged",
+					chargeType, captured[chargeType], rec.TripNumber))
+		}
+	}
+
+	if len(rec.InvoiceLines) == 0 && len(rec.Charges) > 0 && rec.FinishedAt.Before(invoiceCutoff) {
+		var total float64
+		for _, c := range rec.Charges {
+			total += c.Amount
+		}
+		add(ReconIssueNotInvoiced, "", total,
+			fmt.Sprintf("Trip %s has $%.2f of charges that are on no invoice", rec.TripNumber, total))
+	}
+
+	for _, d := range doubleBilled(rec.InvoiceLines) {
+		add(ReconIssueDoubleBilled, d.chargeType, d.overbilled,
+			fmt.Sprintf("%s on trip %s is billed %d times on invoices %s",
+				d.chargeType, rec.TripNumber, d.lines, strings.Join(d.invoiceNumbers, ", ")))
+		issues[len(issues)-1].InvoiceIDs = d.invoiceIDs
+	}
+	return issues
+}
+
+// duplicateCharge is a charge found on more than one invoice line
+type duplicateCharge struct {
+	chargeType     ChargeType
+	lines          int
+	overbilled     float64 // Everything beyond the largest line
+	invoiceIDs     []uuid.UUID
+	invoiceNumbers []string
+}
+
+// doubleBilled finds charges invoiced more than once. Line haul and fuel
+// surcharge are billed once per order; an accessorial is double billed when
+// the same amount for the same order is on more than one invoice, since
+// separate accessorial lines on one invoice are usually separate events.
+func doubleBilled(invoiceLines []TripInvoiceLine) []duplicateCharge {
+	type key struct {
+		orderID    uuid.UUID
+		chargeType ChargeType
+		amount     float64
+	}
+	groups := make(map[key][]TripInvoiceLine)
+	var keys []key
+	for _, l := range invoiceLines {
+		k := key{chargeType: l.ChargeType}
+		if l.OrderID != nil {
+			k.orderID = *l.OrderID
+		}
+		if l.ChargeType != ChargeTypeLineHaul && l.ChargeType != ChargeTypeFuelSurcharge {
+			k.amount = roundCents(l.Amount)
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], l)
+	}
+
+	var duplicates []duplicateCharge
+	for _, k := range keys {
+		group := groups[k]
+		d := duplicateCharge{chargeType: k.chargeType, lines: len(group)}
+		seen := make(map[uuid.UUID]bool)
+		var total, largest float64
+		for _, l := range group {
+			total += l.Amount
+			largest = math.Max(largest, l.Amount)
+			if !seen[l.InvoiceID] {
+				seen[l.InvoiceID] = true
+				d.invoiceIDs = append(d.invoiceIDs, l.InvoiceID)
+				d.invoiceNumbers = append(d.invoiceNumbers, l.InvoiceNumber)
+			}
+		}
+		oncePerOrder := k.chargeType == ChargeTypeLineHaul || k.chargeType == ChargeTypeFuelSurcharge
+		if (oncePerOrder && len(group) < 2) || (!oncePerOrder && len(d.invoiceIDs) < 2) {
+			continue
+		}
+		d.overbilled = total - largest
+		duplicates = append(duplicates, d)
+	}
+	return duplicates
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// SplitBasis is what a split container's charges are shared in proportion to
+type SplitBasis string
+
+const (
+	SplitBasisWeight SplitBasis = "WEIGHT" // Every stop has a weight
+	SplitBasisPieces SplitBasis = "PIECES" // Every stop has a piece count
+	SplitBasisEven   SplitBasis = "EVEN"   // Neither recorded at every stop
+)
+
+// describe says how the shares were set, for invoice line descriptions
+func (b SplitBasis) describe() string {
+	switch b {
+	case SplitBasisWeight:
+		return "by weight"
+	case SplitBasisPieces:
+		return "by pieces"
+	default:
+		return "split evenly"
+	}
+}
+
+// SplitsByDelivery reports whether a charge is for the container as a whole
+// and so is shared across the stops it is delivered at. Charges earned at
+// one stop, such as detention or a redelivery, are billed as they are.
+func (c ChargeType) SplitsByDelivery() bool {
+	switch c {
+	case ChargeTypeLineHaul, ChargeTypeFuelSurcharge, ChargeTypeDemurrage, ChargeTypePerDiem,
+		ChargeTypeChassis, ChargeTypeStorage, ChargeTypePrepull, ChargeTypeOverweight,
+		ChargeTypeHazmat, ChargeTypeReefer:
+		return true
+	}
+	return false
+}
+
+// DeliveryStopShare is what one stop received of a container split across
+// delivery points, totalled over the delivery records dispatch keeps for it
+type DeliveryStopShare struct {
+	StopID        uuid.UUID `json:"stop_id"`
+	Sequence      int       `json:"sequence"`
+	ConsigneeName string    `json:"consignee_name,omitempty"`
+	Pieces        int       `json:"pieces"`
+	WeightLbs     int       `json:"weight_lbs"`
+	PODReceived   bool      `json:"pod_received"` // Every record at the stop signed for
+}
+
+// SplitDeliveryOrder is an order whose container a trip delivers across
+// stops, with the customer charges on the order
+type SplitDeliveryOrder struct {
+	OrderID         uuid.UUID
+	TripID          uuid.UUID
+	TripNumber      string
+	ContainerNumber string
+	Stops           []DeliveryStopShare // In stop order
+	Charges         []TripCharge
+}
+
+// StopBilling is one delivery stop's part of a split container's charges
+type StopBilling struct {
+	StopID        uuid.UUID         `json:"stop_id"`
+	Sequence      int               `json:"sequence"`
+	ConsigneeName string            `json:"consignee_name,omitempty"`
+	Share         float64           `json:"share"` // Fraction of each shared charge, to four places
+	PODReceived   bool              `json:"pod_received"`
+	Lines         []InvoiceLineItem `json:"lines"`
+	Total         float64           `json:"total"`
+}
+
+// SplitBillingPlan is how a split container's charges are billed per stop.
+// Lines are drafts for an invoice; they carry no IDs until invoiced.
+type SplitBillingPlan struct {
+	OrderID         uuid.UUID         `json:"order_id"`
+	TripID          uuid.UUID         `json:"trip_id"`
+	TripNumber      string            `json:"trip_number"`
+	ContainerNumber string            `json:"container_number,omitempty"`
+	Basis           SplitBasis        `json:"basis"`
+	PODComplete     bool              `json:"pod_complete"` // Every stop signed for; ready to invoice
+	Stops           []StopBilling     `json:"stops"`
+	Unshared        []InvoiceLineItem `json:"unshared,omitempty"` // Charges billed as they are
+	Total           float64           `json:"total"`
+}
+
+// PlanSplitBilling shares each container-wide charge on the order across its
+// delivery stops by weight when every stop has one, else by pieces when
+// every stop has a count, else evenly. Each share is rounded to the cent
+// and the last stop takes what rounding leaves, so the stops add up to the
+// charge.
+func PlanSplitBilling(order SplitDeliveryOrder) *SplitBillingPlan {
+	plan := &SplitBillingPlan{
+		OrderID:         order.OrderID,
+		TripID:          order.TripID,
+		TripNumber:      order.TripNumber,
+		ContainerNumber: order.ContainerNumber,
+		PODComplete:     len(order.Stops) > 0,
+	}
+
+	var totalPieces, totalWeight int
+	allWeighed, allCounted := true, true
+	for _, stop := range order.Stops {
+		totalPieces += stop.Pieces
+		totalWeight += stop.WeightLbs
+		allWeighed = allWeighed && stop.WeightLbs > 0
+		allCounted = allCounted && stop.Pieces > 0
+		if !stop.PODReceived {
+			plan.PODComplete = false
+		}
+	}
+	switch {
+	case allWeighed:
+		plan.Basis = SplitBasisWeight
+	case allCounted:
+		plan.Basis = SplitBasisPieces
+	default:
+		plan.Basis = SplitBasisEven
+	}
+	shares := make([]float64, len(order.Stops))
+	for i, stop := range order.Stops {
+		switch plan.Basis {
+		case SplitBasisWeight:
+			shares[i] = float64(stop.WeightLbs) / float64(totalWeight)
+		case SplitBasisPieces:
+			shares[i] = float64(stop.Pieces) / float64(totalPieces)
+		default:
+			shares[i] = 1 / float64(len(order.Stops))
+		}
+		plan.Stops = append(plan.Stops, StopBilling{
+			StopID:        stop.StopID,
+			Sequence:      stop.Sequence,
+			ConsigneeName: stop.ConsigneeName,
+			Share:         math.Round(shares[i]*10000) / 10000,
+			PODReceived:   stop.PODReceived,
+		})
+	}
+
+	orderID, tripID := order.OrderID, order.TripID
+	line := func(charge TripCharge, amount float64, description string, stopID *uuid.UUID) InvoiceLineItem {
+		return InvoiceLineItem{
+			TripID:          &tripID,
+			OrderID:         &orderID,
+			StopID:          stopID,
+			ChargeType:      charge.ChargeType,
+			Description:     description,
+			Quantity:        1,
+			UnitPrice:       amount,
+			Amount:          amount,
+			ContainerNumber: order.ContainerNumber,
+			TripNumber:      order.TripNumber,
+		}
+	}
+
+	for _, charge := range order.Charges {
+		if !charge.ChargeType.SplitsByDelivery() || len(plan.Stops) == 0 {
+			plan.Unshared = append(plan.Unshared, line(charge, roundCents(charge.Amount), string(charge.ChargeType), nil))
+			plan.Total += charge.Amount
+			continue
+		}
+		remaining := roundCents(charge.Amount)
+		for i := range plan.Stops {
+			stop := &plan.Stops[i]
+			amount := remaining
+			if i < len(plan.Stops)-1 {
+				amount = roundCents(charge.Amount * shares[i])
+				remaining = roundCents(remaining - amount)
+			}
+			at := fmt.Sprintf("stop %d", stop.Sequence)
+			if stop.ConsigneeName != "" {
+				at = fmt.Sprintf("stop %d (%s)", stop.Sequence, stop.ConsigneeName)
+			}
+			description := fmt.Sprintf("%s, %s: %.1f%% of $%.2f %s",
+				charge.ChargeType, at, shares[i]*100, charge.Amount, plan.Basis.describe())
+			stopID := stop.StopID
+			stop.Lines = append(stop.Lines, line(charge, amount, description, &stopID))
+			stop.Total = roundCents(stop.Total + amount)
+		}
+		plan.Total += charge.Amount
+	}
+	plan.Total = roundCents(plan.Total)
+	return plan
+}
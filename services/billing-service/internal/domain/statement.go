@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerCredit represents a credit memo issued to a customer, e.g. for a
+// billing correction or an overpayment, that can be applied to invoices
+type CustomerCredit struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	CustomerID    uuid.UUID  `json:"customer_id" db:"customer_id"`
+	CreditNumber  string     `json:"credit_number" db:"credit_number"`
+	CreditDate    time.Time  `json:"credit_date" db:"credit_date"`
+	Amount        float64    `json:"amount" db:"amount"`
+	AppliedAmount float64    `json:"applied_amount" db:"applied_amount"`
+	InvoiceID     *uuid.UUID `json:"invoice_id,omitempty" db:"invoice_id"` // Invoice the credit was issued against
+	Reason        string     `json:"reason,omitempty" db:"reason"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	CreatedBy     string     `json:"created_by" db:"created_by"`
+}
+
+// Remaining returns the credit not yet applied to an invoice
+func (c *CustomerCredit) Remaining() float64 {
+	return c.Amount - c.AppliedAmount
+}
+
+// CustomerContact is the billing contact a statement is addressed to
+type CustomerContact struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Address string    `json:"address,omitempty"`
+	City    string    `json:"city,omitempty"`
+	State   string    `json:"state,omitempty"`
+	Zip     string    `json:"zip,omitempty"`
+	Phone   string    `json:"phone,omitempty"`
+	Email   string    `json:"email,omitempty"`
+}
+
+// AgingBuckets holds an open balance split by days past due. Amounts in
+// dispute are not aged; they are reported separately until resolved.
+type AgingBuckets struct {
+	Current    float64 `json:"current"` // Not yet due
+	Days1To30  float64 `json:"days_1_to_30"`
+	Days31To60 float64 `json:"days_31_to_60"`
+	Days61To90 float64 `json:"days_61_to_90"`
+	Over90Days float64 `json:"over_90_days"`
+}
+
+// Add adds a balance to the bucket for daysPastDue
+func (a *AgingBuckets) Add(daysPastDue int, amount float64) {
+	switch {
+	case daysPastDue <= 0:
+		a.Current += amount
+	case daysPastDue <= 30:
+		a.Days1To30 += amount
+	case daysPastDue <= 60:
+		a.Days31To60 += amount
+	case daysPastDue <= 90:
+		a.Days61To90 += amount
+	default:
+		a.Over90Days += amount
+	}
+}
+
+// StatementInvoice is an invoice that was open at the end of a statement period
+type StatementInvoice struct {
+	InvoiceID      uuid.UUID `json:"invoice_id"`
+	InvoiceNumber  string    `json:"invoice_number"`
+	InvoiceDate    time.Time `json:"invoice_date"`
+	DueDate        time.Time `json:"due_date"`
+	PONumber       string    `json:"po_number,omitempty"`
+	BOLNumber      string    `json:"bol_number,omitempty"`
+	TotalAmount    float64   `json:"total_amount"`
+	PaidAmount     float64   `json:"paid_amount"` // Paid by the end of the period
+	BalanceDue     float64   `json:"balance_due"`
+	DisputedAmount float64   `json:"disputed_amount,omitempty"`
+	DaysPastDue    int       `json:"days_past_due"`
+}
+
+// StatementCredit is a credit that was unapplied at the end of a statement period
+type StatementCredit struct {
+	CreditID     uuid.UUID `json:"credit_id"`
+	CreditNumber string    `json:"credit_number"`
+	CreditDate   time.Time `json:"credit_date"`
+	Reason       string    `json:"reason,omitempty"`
+	Amount       float64   `json:"amount"`
+}
+
+// CustomerStatement is a monthly statement of a customer's account: every
+// invoice open at the end of the period, aged by days past due, with
+// unapplied credits and disputed amounts
+type CustomerStatement struct {
+	ID               uuid.UUID          `json:"id" db:"id"`
+	StatementNumber  string             `json:"statement_number" db:"statement_number"`
+	CustomerID       uuid.UUID          `json:"customer_id" db:"customer_id"`
+	CustomerName     string             `json:"customer_name" db:"customer_name"`
+	PeriodStart      time.Time          `json:"period_start" db:"period_start"`
+	PeriodEnd        time.Time          `json:"period_end" db:"period_end"` // Last day of the period
+	Currency         string             `json:"currency" db:"currency"`
+	Invoices         []StatementInvoice `json:"invoices"`
+	Credits          []StatementCredit  `json:"credits"`
+	Aging            AgingBuckets       `json:"aging"`
+	TotalOpen        float64            `json:"total_open" db:"total_open"`
+	TotalCredits     float64            `json:"total_credits" db:"total_credits"`
+	TotalDisputed    float64            `json:"total_disputed" db:"total_disputed"`
+	AmountDue        float64            `json:"amount_due" db:"amount_due"` // Undisputed open balance less credits
+	PaymentsInPeriod float64            `json:"payments_in_period" db:"payments_in_period"`
+	GeneratedBy      string             `json:"generated_by" db:"generated_by"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// StatementPeriod returns the first and last day of a monthly statement period
+func StatementPeriod(year int, month time.Month) (time.Time, time.Time) {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
+
+// StatementCutoff returns the instant after the last day of a period. Invoices,
+// payments and credits dated before it are on the statement.
+func StatementCutoff(periodEnd time.Time) time.Time {
+	return periodEnd.AddDate(0, 0, 1)
+}
+
+// StatementNumber returns the statement number for a customer and period,
+// e.g. "ST-202609-1A2B3C4D"
+func StatementNumber(customerID uuid.UUID, periodStart time.Time) string {
+	return fmt.Sprintf("ST-%s-%s", periodStart.Format("200601"), strings.ToUpper(customerID.String()[:8]))
+}
+
+// BuildCustomerStatement compiles a statement from the customer's invoices
+// and credits as of the end of the period. Invoices must carry the amount
+// paid by the period end in PaidAmount. Draft, void and fully paid invoices
+// are left off. A disputed invoice without a disputed amount is disputed in
+// full. Disputed amounts are left out of aging and of the amount due.
+func BuildCustomerStatement(customerID uuid.UUID, customerName string, periodStart, periodEnd time.Time, invoices []Invoice, credits []CustomerCredit, paymentsInPeriod float64) *CustomerStatement {
+	stmt := &CustomerStatement{
+		StatementNumber:  StatementNumber(customerID, periodStart),
+		CustomerID:       customerID,
+		CustomerName:     customerName,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		Currency:         "USD",
+		Invoices:         []StatementInvoice{},
+		Credits:          []StatementCredit{},
+		PaymentsInPeriod: roundCents(paymentsInPeriod),
+	}
+	cutoff := StatementCutoff(periodEnd)
+
+	for i := range invoices {
+		inv := &invoices[i]
+		if inv.Status == InvoiceStatusDraft || inv.Status == InvoiceStatusVoid || !inv.InvoiceDate.Before(cutoff) {
+			continue
+		}
+		balance := roundCents(inv.TotalAmount - inv.PaidAmount)
+		if balance <= 0 {
+			continue
+		}
+		if inv.Currency != "" {
+			stmt.Currency = inv.Currency
+		}
+
+		disputed := inv.DisputedAmount
+		if inv.Status == InvoiceStatusDisputed && disputed == 0 {
+			disputed = balance
+		}
+		if disputed > balance {
+			disputed = balance
+		}
+
+		daysPastDue := 0
+		if !inv.DueDate.IsZero() && inv.DueDate.Before(cutoff) {
+			daysPastDue = int(cutoff.Sub(inv.DueDate).Hours() / 24)
+		}
+
+		stmt.Invoices = append(stmt.Invoices, StatementInvoice{
+			InvoiceID:      inv.ID,
+			InvoiceNumber:  inv.InvoiceNumber,
+			InvoiceDate:    inv.InvoiceDate,
+			DueDate:        inv.DueDate,
+			PONumber:       inv.PONumber,
+			BOLNumber:      inv.BOLNumber,
+			TotalAmount:    inv.TotalAmount,
+			PaidAmount:     inv.PaidAmount,
+			BalanceDue:     balance,
+			DisputedAmount: roundCents(disputed),
+			DaysPastDue:    daysPastDue,
+		})
+		stmt.Aging.Add(daysPastDue, balance-disputed)
+		stmt.TotalOpen += balance
+		stmt.TotalDisputed += disputed
+	}
+
+	for i := range credits {
+		credit := &credits[i]
+		remaining := roundCents(credit.Remaining())
+		if remaining <= 0 || !credit.CreditDate.Before(cutoff) {
+			continue
+		}
+		stmt.Credits = append(stmt.Credits, StatementCredit{
+			CreditID:     credit.ID,
+			CreditNumber: credit.CreditNumber,
+			CreditDate:   credit.CreditDate,
+			Reason:       credit.Reason,
+			Amount:       remaining,
+		})
+		stmt.TotalCredits += remaining
+	}
+
+	sort.Slice(stmt.Invoices, func(i, j int) bool {
+		if !stmt.Invoices[i].DueDate.Equal(stmt.Invoices[j].DueDate) {
+			return stmt.Invoices[i].DueDate.Before(stmt.Invoices[j].DueDate)
+		}
+		return stmt.Invoices[i].InvoiceNumber < stmt.Invoices[j].InvoiceNumber
+	})
+	sort.Slice(stmt.Credits, func(i, j int) bool {
+		return stmt.Credits[i].CreditDate.Before(stmt.Credits[j].CreditDate)
+	})
+
+	stmt.Aging = AgingBuckets{
+		Current:    roundCents(stmt.Aging.Current),
+		Days1To30:  roundCents(stmt.Aging.Days1To30),
+		Days31To60: roundCents(stmt.Aging.Days31To60),
+		Days61To90: roundCents(stmt.Aging.Days61To90),
+		Over90Days: roundCents(stmt.Aging.Over90Days),
+	}
+	stmt.TotalOpen = roundCents(stmt.TotalOpen)
+	stmt.TotalCredits = roundCents(stmt.TotalCredits)
+	stmt.TotalDisputed = roundCents(stmt.TotalDisputed)
+	// Credits can exceed the open balance, which roundCents does not handle
+	stmt.AmountDue = math.Round((stmt.TotalOpen-stmt.TotalDisputed-stmt.TotalCredits)*100) / 100
+	return stmt
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkerClassification represents how a driver is paid for tax purposes
+type WorkerClassification string
+
+const (
+	WorkerClassificationEmployee      WorkerClassification = "EMPLOYEE"       // W-2, payroll taxes withheld
+	WorkerClassificationOwnerOperator WorkerClassification = "OWNER_OPERATOR" // 1099-NEC, no withholding
+)
+
+// TaxIDType represents the type of taxpayer identification number
+type TaxIDType string
+
+const (
+	TaxIDTypeSSN TaxIDType = "SSN"
+	TaxIDTypeEIN TaxIDType = "EIN"
+)
+
+// PayeeEntity represents the person or business an owner-operator is paid through
+type PayeeEntity struct {
+	ID                uuid.UUID            `json:"id" db:"id"`
+	DriverID          uuid.UUID            `json:"driver_id" db:"driver_id"`
+	Classification    WorkerClassification `json:"classification" db:"classification"`
+	LegalName         string               `json:"legal_name" db:"legal_name"`
+	BusinessName      string               `json:"business_name,omitempty" db:"business_name"` // DBA
+	TaxIDType         TaxIDType            `json:"tax_id_type" db:"tax_id_type"`
+	TaxID             string               `json:"-" db:"tax_id"`
+	AddressLine1      string               `json:"address_line1" db:"address_line1"`
+	AddressLine2      string               `json:"address_line2,omitempty" db:"address_line2"`
+	City              string               `json:"city" db:"city"`
+	State             string               `json:"state" db:"state"`
+	Zip               string               `json:"zip" db:"zip"`
+	BackupWithholding bool                 `json:"backup_withholding" db:"backup_withholding"` // Settlements withhold federal tax, recorded on each settlement
+	CreatedAt         time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// MaskedTaxID returns the tax ID with all but the last four digits hidden
+func (p *PayeeEntity) MaskedTaxID() string {
+	if len(p.TaxID) <= 4 {
+		return p.TaxID
+	}
+	return "*****" + p.TaxID[len(p.TaxID)-4:]
+}
+
+// EntitySettlementSummary accumulates a tax year of paid settlements for one entity
+type EntitySettlementSummary struct {
+	EntityID        uuid.UUID            `json:"entity_id"`
+	Classification  WorkerClassification `json:"classification"`
+	TaxYear         int                  `json:"tax_year"`
+	SettlementCount int                  `json:"settlement_count"`
+	TotalTrips      int                  `json:"total_trips"`
+	TotalMiles      float64              `json:"total_miles"`
+	GrossEarnings   float64              `json:"gross_earnings"`
+	TotalDeductions float64              `json:"total_deductions"`
+	TaxWithheld     float64              `json:"tax_withheld"`
+	NetPay          float64              `json:"net_pay"`
+	DriverIDs       []uuid.UUID          `json:"driver_ids"`
+}
+
+// Form1099NEC contains the export data for one 1099-NEC recipient
+type Form1099NEC struct {
+	TaxYear                 int       `json:"tax_year"`
+	PayeeEntityID           uuid.UUID `json:"payee_entity_id"`
+	RecipientName           string    `json:"recipient_name"`
+	RecipientBusinessName   string    `json:"recipient_business_name,omitempty"`
+	RecipientTaxIDType      TaxIDType `json:"recipient_tax_id_type"`
+	RecipientTaxID          string    `json:"recipient_tax_id"`
+	AddressLine1            string    `json:"address_line1"`
+	AddressLine2            string    `json:"address_line2,omitempty"`
+	City                    string    `json:"city"`
+	State                   string    `json:"state"`
+	Zip                     string    `json:"zip"`
+	NonemployeeCompensation float64   `json:"nonemployee_compensation"` // Box 1
+	FederalTaxWithheld      float64   `json:"federal_tax_withheld"`     // Box 4
+	SettlementCount         int       `json:"settlement_count"`
+}
+
+// Form1099NECThreshold returns the minimum annual compensation that must be reported
+func Form1099NECThreshold(taxYear int) float64 {
+	if taxYear >= 2026 {
+		return 2000
+	}
+	return 600
+}
+
+// SummarizeSettlementsByEntity accumulates paid settlements for a tax year by
+// payee entity. Payments are counted in the year they were paid. Settlements
+// without a payee entity are grouped under the driver ID.
+func SummarizeSettlementsByEntity(taxYear int, settlements []DriverSettlement) []EntitySettlementSummary {
+	byEntity := make(map[uuid.UUID]*EntitySettlementSummary)
+	var order []uuid.UUID
+
+	for i := range settlements {
+		st := &settlements[i]
+		if st.Status != "paid" || st.PaidDate == nil || st.PaidDate.Year() != taxYear {
+			continue
+		}
+
+		entityID := st.DriverID
+		if st.PayeeEntityID != nil {
+			entityID = *st.PayeeEntityID
+		}
+
+		summary, ok := byEntity[entityID]
+		if !ok {
+			summary = &EntitySettlementSummary{
+				EntityID:       entityID,
+				Classification: st.Classification,
+				TaxYear:        taxYear,
+			}
+			byEntity[entityID] = summary
+			order = append(order, entityID)
+		}
+
+		summary.SettlementCount++
+		summary.TotalTrips += st.TotalTrips
+		summary.TotalMiles += st.TotalMiles
+		summary.GrossEarnings += st.GrossEarnings
+		summary.TotalDeductions += st.TotalDeductions
+		summary.TaxWithheld += st.FederalTaxWithheld
+		summary.NetPay += st.NetPay
+		if !containsUUID(summary.DriverIDs, st.DriverID) {
+			summary.DriverIDs = append(summary.DriverIDs, st.DriverID)
+		}
+	}
+
+	summaries := make([]EntitySettlementSummary, 0, len(order))
+	for _, id := range order {
+		summaries = append(summaries, *byEntity[id])
+	}
+	return summaries
+}
+
+// Build1099NECExport produces 1099-NEC data for owner-operator entities whose
+// annual compensation meets the reporting threshold. Box 4 reports the backup
+// withholding actually taken from the year's settlements. Entities missing
+// from payees are skipped and returned so they can be followed up for a W-9.
+func Build1099NECExport(taxYear int, summaries []EntitySettlementSummary, payees map[uuid.UUID]*PayeeEntity) ([]Form1099NEC, []uuid.UUID) {
+	threshold := Form1099NECThreshold(taxYear)
+
+	var forms []Form1099NEC
+	var missing []uuid.UUID
+
+	for _, summary := range summaries {
+		if summary.Classification != WorkerClassificationOwnerOperator || summary.GrossEarnings < threshold {
+			continue
+		}
+
+		payee, ok := payees[summary.EntityID]
+		if !ok || payee.TaxID == "" {
+			missing = append(missing, summary.EntityID)
+			continue
+		}
+
+		form := Form1099NEC{
+			TaxYear:                 taxYear,
+			PayeeEntityID:           payee.ID,
+			RecipientName:           payee.LegalName,
+			RecipientBusinessName:   payee.BusinessName,
+			RecipientTaxIDType:      payee.TaxIDType,
+			RecipientTaxID:          payee.TaxID,
+			AddressLine1:            payee.AddressLine1,
+			AddressLine2:            payee.AddressLine2,
+			City:                    payee.City,
+			State:                   payee.State,
+			Zip:                     payee.Zip,
+			NonemployeeCompensation: roundCents(summary.GrossEarnings),
+			FederalTaxWithheld:      roundCents(summary.TaxWithheld),
+			SettlementCount:         summary.SettlementCount,
+		}
+		forms = append(forms, form)
+	}
+
+	sort.Slice(forms, func(i, j int) bool {
+		return forms[i].RecipientName < forms[j].RecipientName
+	})
+
+	return forms, missing
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func roundCents(amount float64) float64 {
+	return float64(int64(amount*100+0.5)) / 100
+}
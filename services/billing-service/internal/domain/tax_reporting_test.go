@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func paidSettlement(driverID uuid.UUID, entityID *uuid.UUID, paid time.Time, gross, withheld float64) DriverSettlement {
+	return DriverSettlement{
+		ID:                 uuid.New(),
+		DriverID:           driverID,
+		Classification:     WorkerClassificationOwnerOperator,
+		PayeeEntityID:      entityID,
+		Status:             "paid",
+		GrossEarnings:      gross,
+		TotalDeductions:    withheld,
+		FederalTaxWithheld: withheld,
+		NetPay:             gross - withheld,
+		PaidDate:           &paid,
+	}
+}
+
+func TestBuild1099NECExportReportsRecordedWithholding(t *testing.T) {
+	driverA, driverB := uuid.New(), uuid.New()
+	entity := uuid.New()
+	inYear := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	settlements := []DriverSettlement{
+		// Withholding started partway through the year
+		paidSettlement(driverA, &entity, inYear, 1500, 0),
+		paidSettlement(driverA, &entity, inYear.AddDate(0, 6, 0), 1000, 240),
+		paidSettlement(driverB, &entity, inYear.AddDate(0, 7, 0), 500, 120),
+		// Paid the next year and not yet paid
+		paidSettlement(driverA, &entity, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), 900, 216),
+		{DriverID: driverA, PayeeEntityID: &entity, Classification: WorkerClassificationOwnerOperator, Status: "approved", GrossEarnings: 700, FederalTaxWithheld: 168},
+	}
+
+	summaries := SummarizeSettlementsByEntity(2025, settlements)
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.SettlementCount != 3 || summary.GrossEarnings != 3000 || summary.TaxWithheld != 360 {
+		t.Errorf("summary = %d settlements, %.2f gross, %.2f withheld; want 3, 3000, 360",
+			summary.SettlementCount, summary.GrossEarnings, summary.TaxWithheld)
+	}
+	if len(summary.DriverIDs) != 2 {
+		t.Errorf("got %d drivers, want 2", len(summary.DriverIDs))
+	}
+
+	payees := map[uuid.UUID]*PayeeEntity{
+		entity: {ID: entity, LegalName: "Rivera Hauling LLC", TaxIDType: TaxIDTypeEIN, TaxID: "123456789", BackupWithholding: true},
+	}
+	forms, missing := Build1099NECExport(2025, summaries, payees)
+	if len(missing) != 0 {
+		t.Errorf("missing = %v, want none", missing)
+	}
+	if len(forms) != 1 {
+		t.Fatalf("got %d forms, want 1", len(forms))
+	}
+	// Box 4 is what was withheld, not 24% of the year's compensation
+	if forms[0].NonemployeeCompensation != 3000 || forms[0].FederalTaxWithheld != 360 {
+		t.Errorf("form = %.2f compensation, %.2f withheld; want 3000, 360",
+			forms[0].NonemployeeCompensation, forms[0].FederalTaxWithheld)
+	}
+}
+
+func TestBuild1099NECExportSkipsEmployeesAndThreshold(t *testing.T) {
+	entity, unknown := uuid.New(), uuid.New()
+	paid := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	employee := paidSettlement(uuid.New(), nil, paid, 50000, 0)
+	employee.Classification = WorkerClassificationEmployee
+
+	settlements := []DriverSettlement{
+		employee,
+		// Under the 2026 threshold of 2000
+		paidSettlement(uuid.New(), &entity, paid, 1999.99, 0),
+		// Over it, with no W-9 on file
+		paidSettlement(uuid.New(), &unknown, paid, 2000, 0),
+	}
+	payees := map[uuid.UUID]*PayeeEntity{
+		entity: {ID: entity, LegalName: "Small Payee", TaxIDType: TaxIDTypeSSN, TaxID: "987654321"},
+	}
+
+	forms, missing := Build1099NECExport(2026, SummarizeSettlementsByEntity(2026, settlements), payees)
+	if len(forms) != 0 {
+		t.Errorf("got %d forms, want none", len(forms))
+	}
+	if len(missing) != 1 || missing[0] != unknown {
+		t.Errorf("missing = %v, want [%s]", missing, unknown)
+	}
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TerminalFeeType is a fee a terminal charges the trucker for an activity
+// there
+type TerminalFeeType string
+
+const (
+	TerminalFeeGate        TerminalFeeType = "GATE"         // Per container moved through the gate
+	TerminalFeeExam        TerminalFeeType = "EXAM"         // Customs exam drayage to or at the exam site
+	TerminalFeeChassisFlip TerminalFeeType = "CHASSIS_FLIP" // Container lifted onto another chassis
+)
+
+// Valid reports whether t is a known terminal fee type
+func (t TerminalFeeType) Valid() bool {
+	switch t {
+	case TerminalFeeGate, TerminalFeeExam, TerminalFeeChassisFlip:
+		return true
+	}
+	return false
+}
+
+// ChargeType is the order charge a terminal fee is billed to the customer as
+func (t TerminalFeeType) ChargeType() ChargeType {
+	switch t {
+	case TerminalFeeGate:
+		return ChargeTypeGateFee
+	case TerminalFeeExam:
+		return ChargeTypeExamFee
+	case TerminalFeeChassisFlip:
+		return ChargeTypeChassisFlip
+	}
+	return ChargeTypeOther
+}
+
+// TerminalFee is one fee on a terminal's schedule. A fee with a container
+// size applies to that size only and wins over the terminal's fee for any
+// size. Fees are versioned by their effective dates.
+type TerminalFee struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	TerminalID    uuid.UUID       `json:"terminal_id" db:"terminal_id"`
+	TerminalName  string          `json:"terminal_name,omitempty" db:"-"`
+	FeeType       TerminalFeeType `json:"fee_type" db:"fee_type"`
+	Description   string          `json:"description" db:"description"`
+	ContainerSize string          `json:"container_size,omitempty" db:"container_size"` // 20, 40, 45 or empty for any
+	Amount        float64         `json:"amount" db:"amount"`
+	EffectiveDate time.Time       `json:"effective_date" db:"effective_date"`
+	EndDate       *time.Time      `json:"end_date,omitempty" db:"end_date"`
+	CreatedBy     string          `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// EffectiveAt reports whether the fee applies at the given time
+func (f *TerminalFee) EffectiveAt(at time.Time) bool {
+	return !at.Before(f.EffectiveDate) && (f.EndDate == nil || at.Before(*f.EndDate))
+}
+
+// TerminalFeeQuery finds the fee a terminal charges for an activity
+type TerminalFeeQuery struct {
+	TerminalID    uuid.UUID
+	FeeType       TerminalFeeType
+	ContainerSize string // 20, 40 or 45; empty when unknown
+	At            time.Time
+}
+
+// PassThroughMode is how a customer is billed for the terminal fees paid on
+// their moves
+type PassThroughMode string
+
+const (
+	PassThroughAtCost PassThroughMode = "AT_COST" // Billed what the terminal charged
+	PassThroughMarkup PassThroughMode = "MARKUP"  // Billed the fee plus a percentage
+	PassThroughAbsorb PassThroughMode = "ABSORB"  // Not billed; covered by the line haul
+)
+
+// Valid reports whether m is a known pass-through mode
+func (m PassThroughMode) Valid() bool {
+	switch m {
+	case PassThroughAtCost, PassThroughMarkup, PassThroughAbsorb:
+		return true
+	}
+	return false
+}
+
+// FeePassThrough is a customer's billing setting for one terminal fee type.
+// Customers without one are billed at cost.
+type FeePassThrough struct {
+	CustomerID uuid.UUID       `json:"customer_id" db:"customer_id"`
+	FeeType    TerminalFeeType `json:"fee_type" db:"fee_type"`
+	Mode       PassThroughMode `json:"mode" db:"mode"`
+	MarkupPct  float64         `json:"markup_pct,omitempty" db:"markup_pct"` // MARKUP only
+	UpdatedBy  string          `json:"updated_by" db:"updated_by"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// BilledAmount is what the customer is billed for a fee that cost amount.
+// A nil setting bills at cost.
+func (p *FeePassThrough) BilledAmount(amount float64) float64 {
+	if p == nil {
+		return amount
+	}
+	switch p.Mode {
+	case PassThroughAbsorb:
+		return 0
+	case PassThroughMarkup:
+		return math.Round(amount*(1+p.MarkupPct/100)*100) / 100
+	}
+	return amount
+}
+
+// TerminalFeeCharge is a terminal fee incurred on an order's trip, attached
+// when the activity that triggers it happened. Each stop is charged each fee
+// type once. OrderChargeID is set when the fee was billed to the customer.
+type TerminalFeeCharge struct {
+	ID              uuid.UUID       `json:"id" db:"id"`
+	FeeID           uuid.UUID       `json:"fee_id" db:"fee_id"`
+	FeeType         TerminalFeeType `json:"fee_type" db:"fee_type"`
+	TerminalID      uuid.UUID       `json:"terminal_id" db:"terminal_id"`
+	OrderID         uuid.UUID       `json:"order_id" db:"order_id"`
+	TripID          uuid.UUID       `json:"trip_id" db:"trip_id"`
+	StopID          uuid.UUID       `json:"stop_id" db:"stop_id"`
+	ContainerNumber string          `json:"container_number,omitempty" db:"container_number"`
+	CostAmount      float64         `json:"cost_amount" db:"cost_amount"`     // What the terminal charged
+	BilledAmount    float64         `json:"billed_amount" db:"billed_amount"` // What the customer is billed
+	Mode            PassThroughMode `json:"mode" db:"mode"`
+	OrderChargeID   *uuid.UUID      `json:"order_charge_id,omitempty" db:"order_charge_id"`
+	OccurredAt      time.Time       `json:"occurred_at" db:"occurred_at"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
+// TerminalFeeOrder is an order a terminal fee is being attached to, with
+// what the fee is priced and billed by
+type TerminalFeeOrder struct {
+	OrderID       uuid.UUID
+	CustomerID    *uuid.UUID // Nil when the shipment names no customer record
+	ContainerSize string     // As on the container, such as 40HC
+	Invoiced      bool       // Already on an invoice that is not void
+}
+
+// FeeContainerSize is the container size terminal fees are kept for
+func (o *TerminalFeeOrder) FeeContainerSize() string {
+	return rateContainerSize(o.ContainerSize)
+}
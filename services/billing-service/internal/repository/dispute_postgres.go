@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// INVOICE DISPUTES
+// ============================================================================
+
+const disputeColumns = `id, dispute_number, invoice_id, line_item_id, customer_id, amount::float8, reason_code,
+	reason, status, opened_via, opened_by, COALESCE(reviewed_by, ''), review_started_at,
+	COALESCE(resolution_notes, ''), COALESCE(credited_amount, 0)::float8, credit_id,
+	COALESCE(resolved_by, ''), resolved_at, created_at, updated_at`
+
+// refreshInvoiceDisputeSQL sets an invoice's disputed amount from its active
+// disputes. An invoice with active disputes is DISPUTED; once the last one
+// is resolved its status is worked out again from what has been paid.
+const refreshInvoiceDisputeSQL = `UPDATE invoices i SET
+	disputed_amount = d.active,
+	status = CASE
+		WHEN d.active > 0 THEN 'DISPUTED'
+		WHEN i.status <> 'DISPUTED' THEN i.status
+		WHEN COALESCE(i.paid_amount, 0) >= i.total_amount THEN 'PAID'
+		WHEN COALESCE(i.paid_amount, 0) > 0 THEN 'PARTIAL'
+		WHEN i.due_date < NOW() THEN 'OVERDUE'
+		ELSE 'SENT'
+	END,
+	updated_at = NOW()
+ FROM (SELECT COALESCE(SUM(amount), 0) AS active FROM invoice_disputes
+	WHERE invoice_id = $1 AND status IN ('OPEN', 'UNDER_REVIEW')) d
+ WHERE i.id = $1`
+
+// PostgresDisputeRepository implements DisputeRepository
+type PostgresDisputeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresDisputeRepository creates a new PostgreSQL dispute repository
+func NewPostgresDisputeRepository(pool *pgxpool.Pool) *PostgresDisputeRepository {
+	return &PostgresDisputeRepository{pool: pool}
+}
+
+func (r *PostgresDisputeRepository) GetInvoice(ctx context.Context, invoiceID uuid.UUID) (*domain.Invoice, error) {
+	var inv domain.Invoice
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, invoice_number, customer_id, COALESCE(customer_name, ''), status, invoice_date,
+			COALESCE(due_date, invoice_date), COALESCE(total_amount, 0)::float8, COALESCE(paid_amount, 0)::float8,
+			COALESCE(disputed_amount, 0)::float8, COALESCE(currency, 'USD')
+		 FROM invoices WHERE id = $1`, invoiceID,
+	).Scan(&inv.ID, &inv.InvoiceNumber, &inv.CustomerID, &inv.CustomerName, &inv.Status, &inv.InvoiceDate,
+		&inv.DueDate, &inv.TotalAmount, &inv.PaidAmount, &inv.DisputedAmount, &inv.Currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	inv.BalanceDue = inv.TotalAmount - inv.PaidAmount
+	return &inv, nil
+}
+
+func (r *PostgresDisputeRepository) GetLineItem(ctx context.Context, lineItemID uuid.UUID) (*domain.InvoiceLineItem, error) {
+	var item domain.InvoiceLineItem
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, invoice_id, trip_id, order_id, charge_type, COALESCE(description, ''),
+			COALESCE(quantity, 1)::float8, COALESCE(unit_price, 0)::float8, COALESCE(amount, 0)::float8,
+			COALESCE(container_number, ''), COALESCE(trip_number, ''), created_at
+		 FROM invoice_line_items WHERE id = $1`, lineItemID,
+	).Scan(&item.ID, &item.InvoiceID, &item.TripID, &item.OrderID, &item.ChargeType, &item.Description,
+		&item.Quantity, &item.UnitPrice, &item.Amount, &item.ContainerNumber, &item.TripNumber, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *PostgresDisputeRepository) ActiveAmountForLineItem(ctx context.Context, lineItemID uuid.UUID) (float64, error) {
+	var total float64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COALESCE(SUM(amount), 0)::float8 FROM invoice_disputes
+		 WHERE line_item_id = $1 AND status IN ('OPEN', 'UNDER_REVIEW')`,
+		lineItemID,
+	).Scan(&total)
+	return total, err
+}
+
+func (r *PostgresDisputeRepository) Create(ctx context.Context, d *domain.InvoiceDispute) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO invoice_disputes (id, dispute_number, invoice_id, line_item_id, customer_id, amount,
+			reason_code, reason, status, opened_via, opened_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		d.ID, d.DisputeNumber, d.InvoiceID, d.LineItemID, d.CustomerID, d.Amount,
+		d.ReasonCode, d.Reason, d.Status, d.OpenedVia, d.OpenedBy, d.CreatedAt, d.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("insert dispute: %w", err)
+	}
+	if _, err := tx.Exec(ctx, refreshInvoiceDisputeSQL, d.InvoiceID); err != nil {
+		return fmt.Errorf("update invoice: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresDisputeRepository) StartReview(ctx context.Context, d *domain.InvoiceDispute) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE invoice_disputes SET status = $2, reviewed_by = $3, review_started_at = $4, updated_at = $5
+		 WHERE id = $1 AND status = 'OPEN'`,
+		d.ID, d.Status, d.ReviewedBy, d.ReviewStartedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("dispute %s is no longer open", d.ID)
+	}
+	return nil
+}
+
+func (r *PostgresDisputeRepository) Resolve(ctx context.Context, d *domain.InvoiceDispute, credit *domain.CustomerCredit) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if credit != nil {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO customer_credits (id, customer_id, credit_number, credit_date, amount, applied_amount,
+				invoice_id, reason, created_at, created_by)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			credit.ID, credit.CustomerID, credit.CreditNumber, credit.CreditDate, credit.Amount, credit.AppliedAmount,
+			credit.InvoiceID, credit.Reason, credit.CreatedAt, credit.CreatedBy,
+		); err != nil {
+			return fmt.Errorf("insert credit memo: %w", err)
+		}
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE invoice_disputes SET status = $2, resolution_notes = $3, credited_amount = $4, credit_id = $5,
+			resolved_by = $6, resolved_at = $7, updated_at = $8
+		 WHERE id = $1 AND status IN ('OPEN', 'UNDER_REVIEW')`,
+		d.ID, d.Status, d.ResolutionNotes, d.CreditedAmount, d.CreditID, d.ResolvedBy, d.ResolvedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update dispute: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("dispute %s is already resolved", d.ID)
+	}
+	if _, err := tx.Exec(ctx, refreshInvoiceDisputeSQL, d.InvoiceID); err != nil {
+		return fmt.Errorf("update invoice: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresDisputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.InvoiceDispute, error) {
+	var d domain.InvoiceDispute
+	row := r.pool.QueryRow(ctx, `SELECT `+disputeColumns+` FROM invoice_disputes WHERE id = $1`, id)
+	if err := scanDispute(row, &d); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	attachments, err := r.listAttachments(ctx, []uuid.UUID{d.ID})
+	if err != nil {
+		return nil, err
+	}
+	d.Attachments = attachments[d.ID]
+	if d.Attachments == nil {
+		d.Attachments = []domain.DisputeAttachment{}
+	}
+	return &d, nil
+}
+
+func (r *PostgresDisputeRepository) List(ctx context.Context, filter domain.DisputeFilter) ([]domain.InvoiceDispute, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+	if filter.InvoiceID != nil {
+		args = append(args, *filter.InvoiceID)
+		conditions = append(conditions, fmt.Sprintf("invoice_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + disputeColumns + ` FROM invoice_disputes`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query disputes: %w", err)
+	}
+	defer rows.Close()
+
+	var disputes []domain.InvoiceDispute
+	var ids []uuid.UUID
+	for rows.Next() {
+		var d domain.InvoiceDispute
+		if err := scanDispute(rows, &d); err != nil {
+			return nil, fmt.Errorf("scan dispute: %w", err)
+		}
+		disputes = append(disputes, d)
+		ids = append(ids, d.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return disputes, nil
+	}
+
+	attachments, err := r.listAttachments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range disputes {
+		disputes[i].Attachments = attachments[disputes[i].ID]
+		if disputes[i].Attachments == nil {
+			disputes[i].Attachments = []domain.DisputeAttachment{}
+		}
+	}
+	return disputes, nil
+}
+
+func (r *PostgresDisputeRepository) AddAttachment(ctx context.Context, a *domain.DisputeAttachment) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO invoice_dispute_attachments (id, dispute_id, file_name, content_type, size_bytes,
+			storage_key, uploaded_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		a.ID, a.DisputeID, a.FileName, a.ContentType, a.SizeBytes, a.StorageKey, a.UploadedBy, a.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresDisputeRepository) GetAttachment(ctx context.Context, id uuid.UUID) (*domain.DisputeAttachment, error) {
+	var a domain.DisputeAttachment
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, dispute_id, file_name, COALESCE(content_type, ''), size_bytes, storage_key, uploaded_by, created_at
+		 FROM invoice_dispute_attachments WHERE id = $1`, id,
+	).Scan(&a.ID, &a.DisputeID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.StorageKey, &a.UploadedBy, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// listAttachments returns the attachments of the given disputes by dispute ID
+func (r *PostgresDisputeRepository) listAttachments(ctx context.Context, disputeIDs []uuid.UUID) (map[uuid.UUID][]domain.DisputeAttachment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, dispute_id, file_name, COALESCE(content_type, ''), size_bytes, storage_key, uploaded_by, created_at
+		 FROM invoice_dispute_attachments WHERE dispute_id = ANY($1) ORDER BY created_at`,
+		disputeIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	byDispute := make(map[uuid.UUID][]domain.DisputeAttachment)
+	for rows.Next() {
+		var a domain.DisputeAttachment
+		if err := rows.Scan(&a.ID, &a.DisputeID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.StorageKey,
+			&a.UploadedBy, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan attachment: %w", err)
+		}
+		byDispute[a.DisputeID] = append(byDispute[a.DisputeID], a)
+	}
+	return byDispute, rows.Err()
+}
+
+func scanDispute(row pgx.Row, d *domain.InvoiceDispute) error {
+	return row.Scan(&d.ID, &d.DisputeNumber, &d.InvoiceID, &d.LineItemID, &d.CustomerID, &d.Amount, &d.ReasonCode,
+		&d.Reason, &d.Status, &d.OpenedVia, &d.OpenedBy, &d.ReviewedBy, &d.ReviewStartedAt,
+		&d.ResolutionNotes, &d.CreditedAmount, &d.CreditID, &d.ResolvedBy, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt)
+}
@@ -0,0 +1,488 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// ============================================================================
+// INVOICE GENERATION
+// ============================================================================
+
+// ErrOrdersAlreadyBilled is returned by CreateInvoice when one of the
+// invoice's orders was billed by another run first
+var ErrOrdersAlreadyBilled = errors.New("orders already billed")
+
+const invoiceColumns = `id, invoice_number, customer_id, COALESCE(customer_name, ''), shipment_id, status,
+	invoice_date, COALESCE(due_date, invoice_date), sent_date, paid_date, COALESCE(subtotal, 0)::float8,
+	COALESCE(tax_rate, 0)::float8, COALESCE(tax_amount, 0)::float8, COALESCE(total_amount, 0)::float8,
+	COALESCE(paid_amount, 0)::float8, COALESCE(balance_due, 0)::float8, COALESCE(disputed_amount, 0)::float8,
+	COALESCE(payment_terms, ''), COALESCE(currency, 'USD'), COALESCE(po_number, ''), COALESCE(bol_number, ''),
+	COALESCE(notes, ''), COALESCE(qb_invoice_id, ''), synced_at, COALESCE(document_key, ''),
+	created_at, updated_at, COALESCE(created_by, '')`
+
+const invoiceLineColumns = `id, invoice_id, trip_id, order_id, charge_type, COALESCE(description, ''),
+	COALESCE(quantity, 1)::float8, COALESCE(unit_price, 0)::float8, COALESCE(amount, 0)::float8,
+	COALESCE(container_number, ''), COALESCE(trip_number, ''), stop_id, rate_id, created_at`
+
+// PostgresInvoiceRepository implements InvoiceRepository
+type PostgresInvoiceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresInvoiceRepository creates a new PostgreSQL invoice repository
+func NewPostgresInvoiceRepository(pool *pgxpool.Pool) *PostgresInvoiceRepository {
+	return &PostgresInvoiceRepository{pool: pool}
+}
+
+func (r *PostgresInvoiceRepository) ListBillableOrders(ctx context.Context, completedBefore time.Time, customerID *uuid.UUID) ([]domain.BillableOrder, error) {
+	// An order is complete when it last changed to COMPLETED; its customer is
+	// its shipment's
+	rows, err := r.pool.Query(ctx,
+		`SELECT o.id, o.order_number, s.customer_id, COALESCE(c.company_name, s.customer_name, ''),
+			COALESCE(c.payment_terms_days, 30), o.shipment_id, COALESCE(s.reference_number, ''),
+			COALESCE(s.bill_of_lading, ''), ct.id, ct.container_number, ct.size::text, ct.type::text,
+			o.pickup_location_id, o.delivery_location_id, o.updated_at
+		 FROM orders o
+		 JOIN shipments s ON s.id = o.shipment_id
+		 JOIN containers ct ON ct.id = o.container_id
+		 LEFT JOIN customers c ON c.id = s.customer_id
+		 WHERE o.deleted_at IS NULL AND o.status::text = 'COMPLETED' AND o.billing_status::text = 'UNBILLED'
+			AND o.updated_at < $1 AND ($2::uuid IS NULL OR s.customer_id = $2)
+			AND NOT EXISTS (SELECT 1 FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id
+				WHERE l.order_id = o.id AND i.status <> 'VOID')
+		 ORDER BY s.customer_id, o.shipment_id, o.order_number`,
+		completedBefore, customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query billable orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []domain.BillableOrder
+	for rows.Next() {
+		var o domain.BillableOrder
+		if err := rows.Scan(&o.OrderID, &o.OrderNumber, &o.CustomerID, &o.CustomerName, &o.PaymentTermsDays,
+			&o.ShipmentID, &o.ShipmentRef, &o.BillOfLading, &o.ContainerID, &o.ContainerNumber,
+			&o.ContainerSize, &o.ContainerType, &o.OriginID, &o.DestinationID, &o.CompletedAt); err != nil {
+			return nil, fmt.Errorf("scan billable order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	byOrder := make(map[uuid.UUID]int, len(orders))
+	byContainer := make(map[uuid.UUID]int, len(orders))
+	orderIDs := make([]uuid.UUID, 0, len(orders))
+	containerIDs := make([]uuid.UUID, 0, len(orders))
+	for i, o := range orders {
+		byOrder[o.OrderID] = i
+		orderIDs = append(orderIDs, o.OrderID)
+		// A container's charges go with the first of its orders billed
+		if _, ok := byContainer[o.ContainerID]; !ok {
+			byContainer[o.ContainerID] = i
+			containerIDs = append(containerIDs, o.ContainerID)
+		}
+	}
+
+	if err := r.loadBillableTrips(ctx, orders, byOrder, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadBillableCharges(ctx, orders, byOrder, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadStopDetention(ctx, orders, byOrder, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadDetentionProfiles(ctx, orders); err != nil {
+		return nil, err
+	}
+	if err := r.loadChassisUsage(ctx, orders); err != nil {
+		return nil, err
+	}
+	if err := r.loadContainerCharges(ctx, orders, byContainer, containerIDs); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *PostgresInvoiceRepository) loadBillableTrips(ctx context.Context, orders []domain.BillableOrder, byOrder map[uuid.UUID]int, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT x.order_id, t.id, t.trip_number
+		 FROM (SELECT trip_id, order_id FROM trip_stops WHERE order_id = ANY($1)
+			UNION SELECT trip_id, order_id FROM trip_orders WHERE order_id = ANY($1)) x
+		 JOIN trips t ON t.id = x.trip_id
+		 WHERE t.deleted_at IS NULL AND t.status::text = 'COMPLETED'
+		 ORDER BY COALESCE(t.actual_end_time, t.updated_at)`, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query trips: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orderID uuid.UUID
+		var trip domain.BillableTrip
+		if err := rows.Scan(&orderID, &trip.TripID, &trip.TripNumber); err != nil {
+			return fmt.Errorf("scan trip: %w", err)
+		}
+		o := &orders[byOrder[orderID]]
+		o.Trips = append(o.Trips, trip)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) loadBillableCharges(ctx context.Context, orders []domain.BillableOrder, byOrder map[uuid.UUID]int, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, order_id, charge_type, COALESCE(description, ''), COALESCE(quantity, 1)::float8,
+			COALESCE(unit_rate, 0)::float8, COALESCE(amount, 0)::float8, rate_kind, rate_id
+		 FROM order_charges
+		 WHERE order_id = ANY($1) AND COALESCE(billable_to, 'CUSTOMER') = 'CUSTOMER'
+		 ORDER BY created_at`, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query charges: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c domain.OrderCharge
+		if err := rows.Scan(&c.ID, &c.OrderID, &c.ChargeType, &c.Description, &c.Quantity,
+			&c.UnitRate, &c.Amount, &c.RateKind, &c.RateID); err != nil {
+			return fmt.Errorf("scan charge: %w", err)
+		}
+		o := &orders[byOrder[c.OrderID]]
+		o.Charges = append(o.Charges, c)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) loadStopDetention(ctx context.Context, orders []domain.BillableOrder, byOrder map[uuid.UUID]int, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT ts.order_id, ts.trip_id, t.trip_number, ts.id, ts.sequence, ts.detention_mins,
+			ts.actual_arrival, ts.actual_departure, COALESCE(ts.free_time_mins, 0)
+		 FROM trip_stops ts JOIN trips t ON t.id = ts.trip_id
+		 WHERE ts.order_id = ANY($1) AND COALESCE(ts.detention_mins, 0) > 0
+			AND t.deleted_at IS NULL AND t.status::text = 'COMPLETED'
+		 ORDER BY t.trip_number, ts.sequence`, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query stop detention: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orderID uuid.UUID
+		var d domain.StopDetention
+		if err := rows.Scan(&orderID, &d.TripID, &d.TripNumber, &d.StopID, &d.Sequence, &d.DetentionMins,
+			&d.ArrivedAt, &d.DepartedAt, &d.FreeTimeMins); err != nil {
+			return fmt.Errorf("scan stop detention: %w", err)
+		}
+		o := &orders[byOrder[orderID]]
+		o.Detention = append(o.Detention, d)
+	}
+	return rows.Err()
+}
+
+// loadDetentionProfiles attaches each customer's active contract profile to
+// the orders held at a stop, so detention is priced under its terms
+func (r *PostgresInvoiceRepository) loadDetentionProfiles(ctx context.Context, orders []domain.BillableOrder) error {
+	byCustomer := make(map[uuid.UUID][]int)
+	var customerIDs []uuid.UUID
+	for i, o := range orders {
+		if o.CustomerID == nil || len(o.Detention) == 0 {
+			continue
+		}
+		if len(byCustomer[*o.CustomerID]) == 0 {
+			customerIDs = append(customerIDs, *o.CustomerID)
+		}
+		byCustomer[*o.CustomerID] = append(byCustomer[*o.CustomerID], i)
+	}
+	if len(customerIDs) == 0 {
+		return nil
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT customer_id, name, free_time_mins, detention_tiers, grace_period_mins,
+			COALESCE(max_daily_charge, 0)::float8, count_weekends, count_holidays, holidays
+		 FROM customer_profiles
+		 WHERE customer_id = ANY($1) AND is_active`, customerIDs)
+	if err != nil {
+		return fmt.Errorf("query detention profiles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var customerID uuid.UUID
+		var freeTime, tiers []byte
+		var p config.CustomerProfile
+		if err := rows.Scan(&customerID, &p.Name, &freeTime, &tiers, &p.GracePeriodMins,
+			&p.MaxDailyCharge, &p.CountWeekends, &p.CountHolidays, &p.Holidays); err != nil {
+			return fmt.Errorf("scan detention profile: %w", err)
+		}
+		if err := json.Unmarshal(freeTime, &p.FreeTimeMins); err != nil {
+			return fmt.Errorf("decode profile free time: %w", err)
+		}
+		if err := json.Unmarshal(tiers, &p.DetentionTiers); err != nil {
+			return fmt.Errorf("decode profile detention tiers: %w", err)
+		}
+		p.CustomerID = customerID.String()
+		for _, i := range byCustomer[customerID] {
+			orders[i].DetentionProfile = &p
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) loadChassisUsage(ctx context.Context, orders []domain.BillableOrder) error {
+	// Chassis usage is kept per trip; on a trip moving several orders it goes
+	// to the order whose container it carried, or else the first of them
+	byTrip := make(map[uuid.UUID][]int)
+	var tripIDs []uuid.UUID
+	for i, o := range orders {
+		for _, trip := range o.Trips {
+			if len(byTrip[trip.TripID]) == 0 {
+				tripIDs = append(tripIDs, trip.TripID)
+			}
+			byTrip[trip.TripID] = append(byTrip[trip.TripID], i)
+		}
+	}
+	if len(tripIDs) == 0 {
+		return nil
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, trip_id, COALESCE(chassis_number, ''), COALESCE(container_number, ''),
+			COALESCE(NULLIF(usage_days, 0), days_out, 0), COALESCE(daily_rate, 0)::float8,
+			COALESCE(NULLIF(total_cost, 0), per_diem_amount, 0)::float8
+		 FROM chassis_usage
+		 WHERE trip_id = ANY($1) AND invoice_id IS NULL
+		 ORDER BY pickup_time`, tripIDs)
+	if err != nil {
+		return fmt.Errorf("query chassis usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u domain.ChassisUsage
+		var tripID uuid.UUID
+		var containerNumber string
+		if err := rows.Scan(&u.ID, &tripID, &u.ChassisNumber, &containerNumber, &u.Days, &u.DailyRate, &u.Amount); err != nil {
+			return fmt.Errorf("scan chassis usage: %w", err)
+		}
+		u.TripID = &tripID
+		candidates := byTrip[tripID]
+		target := candidates[0]
+		for _, idx := range candidates {
+			if containerNumber != "" && strings.EqualFold(orders[idx].ContainerNumber, containerNumber) {
+				target = idx
+				break
+			}
+		}
+		orders[target].Chassis = append(orders[target].Chassis, u)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) loadContainerCharges(ctx context.Context, orders []domain.BillableOrder, byContainer map[uuid.UUID]int, containerIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, container_id, charge_type, COALESCE(days_over, 0), COALESCE(daily_rate, 0)::float8,
+			COALESCE(total_charge, 0)::float8, COALESCE(status, 'ACCRUING')
+		 FROM container_charges
+		 WHERE container_id = ANY($1) AND charge_type IN ('PER_DIEM', 'DEMURRAGE') AND invoice_id IS NULL
+		 ORDER BY created_at`, containerIDs)
+	if err != nil {
+		return fmt.Errorf("query container charges: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c domain.ContainerCharge
+		var containerID uuid.UUID
+		if err := rows.Scan(&c.ID, &containerID, &c.ChargeType, &c.Days, &c.DailyRate, &c.Amount, &c.Status); err != nil {
+			return fmt.Errorf("scan container charge: %w", err)
+		}
+		o := &orders[byContainer[containerID]]
+		o.ContainerCharges = append(o.ContainerCharges, c)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) CreateInvoice(ctx context.Context, inv *domain.Invoice, charges []domain.OrderCharge, sources domain.InvoiceSources) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Orders are claimed first so a concurrent run cannot bill them too
+	tag, err := tx.Exec(ctx,
+		`UPDATE orders SET billing_status = 'BILLED', updated_at = NOW()
+		 WHERE id = ANY($1) AND billing_status::text = 'UNBILLED'`, sources.OrderIDs)
+	if err != nil {
+		return fmt.Errorf("mark orders billed: %w", err)
+	}
+	if tag.RowsAffected() != int64(len(sources.OrderIDs)) {
+		return ErrOrdersAlreadyBilled
+	}
+
+	var seq int64
+	if err := tx.QueryRow(ctx, `SELECT nextval('invoice_number_seq')`).Scan(&seq); err != nil {
+		return fmt.Errorf("next invoice number: %w", err)
+	}
+	inv.InvoiceNumber = domain.InvoiceNumber(seq, inv.InvoiceDate)
+
+	for i := range charges {
+		c := &charges[i]
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO order_charges (id, order_id, charge_type, description, quantity, unit_rate, amount,
+				billable_to, auto_calculated, rate_kind, rate_id, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, 'CUSTOMER', TRUE, $8, $9, $10)`,
+			c.ID, c.OrderID, c.ChargeType, c.Description, c.Quantity, c.UnitRate, c.Amount,
+			c.RateKind, c.RateID, inv.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("insert charge: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO invoices (id, invoice_number, customer_id, customer_name, shipment_id, status, invoice_date,
+			due_date, subtotal, tax_rate, tax_amount, total_amount, paid_amount, balance_due, payment_terms,
+			currency, po_number, bol_number, notes, created_at, updated_at, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`,
+		inv.ID, inv.InvoiceNumber, inv.CustomerID, inv.CustomerName, inv.ShipmentID, inv.Status, inv.InvoiceDate,
+		inv.DueDate, inv.Subtotal, inv.TaxRate, inv.TaxAmount, inv.TotalAmount, inv.PaidAmount, inv.BalanceDue,
+		inv.PaymentTerms, inv.Currency, inv.PONumber, inv.BOLNumber, inv.Notes, inv.CreatedAt, inv.UpdatedAt,
+		inv.CreatedBy,
+	); err != nil {
+		return fmt.Errorf("insert invoice: %w", err)
+	}
+
+	for i := range inv.LineItems {
+		l := &inv.LineItems[i]
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO invoice_line_items (id, invoice_id, trip_id, order_id, charge_type, description, quantity,
+				unit_price, amount, container_number, trip_number, stop_id, rate_id, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+			l.ID, l.InvoiceID, l.TripID, l.OrderID, l.ChargeType, l.Description, l.Quantity,
+			l.UnitPrice, l.Amount, l.ContainerNumber, l.TripNumber, l.StopID, l.RateID, l.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("insert invoice line: %w", err)
+		}
+	}
+
+	if len(sources.ChassisUsageIDs) > 0 {
+		if _, err := tx.Exec(ctx,
+			`UPDATE chassis_usage SET invoice_id = $1, billed_to_customer = TRUE WHERE id = ANY($2)`,
+			inv.ID, sources.ChassisUsageIDs); err != nil {
+			return fmt.Errorf("mark chassis usage billed: %w", err)
+		}
+	}
+	if len(sources.ContainerChargeIDs) > 0 {
+		if _, err := tx.Exec(ctx,
+			`UPDATE container_charges SET invoice_id = $1, updated_at = NOW() WHERE id = ANY($2)`,
+			inv.ID, sources.ContainerChargeIDs); err != nil {
+			return fmt.Errorf("mark container charges billed: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresInvoiceRepository) SetDocumentKey(ctx context.Context, invoiceID uuid.UUID, key string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE invoices SET document_key = $2, updated_at = NOW() WHERE id = $1`, invoiceID, key)
+	return err
+}
+
+func (r *PostgresInvoiceRepository) GetInvoice(ctx context.Context, id uuid.UUID) (*domain.Invoice, error) {
+	var inv domain.Invoice
+	if err := scanInvoice(r.pool.QueryRow(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id), &inv); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+invoiceLineColumns+` FROM invoice_line_items WHERE invoice_id = $1 ORDER BY created_at, trip_number`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query invoice lines: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l domain.InvoiceLineItem
+		if err := rows.Scan(&l.ID, &l.InvoiceID, &l.TripID, &l.OrderID, &l.ChargeType, &l.Description,
+			&l.Quantity, &l.UnitPrice, &l.Amount, &l.ContainerNumber, &l.TripNumber, &l.StopID, &l.RateID,
+			&l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan invoice line: %w", err)
+		}
+		inv.LineItems = append(inv.LineItems, l)
+	}
+	return &inv, rows.Err()
+}
+
+func (r *PostgresInvoiceRepository) ListInvoices(ctx context.Context, filter domain.InvoiceFilter) ([]domain.Invoice, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("invoice_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("invoice_date < $%d", len(args)))
+	}
+
+	query := `SELECT ` + invoiceColumns + ` FROM invoices`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY invoice_date DESC, invoice_number DESC LIMIT $%d", len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []domain.Invoice
+	for rows.Next() {
+		var inv domain.Invoice
+		if err := scanInvoice(rows, &inv); err != nil {
+			return nil, fmt.Errorf("scan invoice: %w", err)
+		}
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+func scanInvoice(row pgx.Row, inv *domain.Invoice) error {
+	return row.Scan(&inv.ID, &inv.InvoiceNumber, &inv.CustomerID, &inv.CustomerName, &inv.ShipmentID, &inv.Status,
+		&inv.InvoiceDate, &inv.DueDate, &inv.SentDate, &inv.PaidDate, &inv.Subtotal,
+		&inv.TaxRate, &inv.TaxAmount, &inv.TotalAmount,
+		&inv.PaidAmount, &inv.BalanceDue, &inv.DisputedAmount,
+		&inv.PaymentTerms, &inv.Currency, &inv.PONumber, &inv.BOLNumber,
+		&inv.Notes, &inv.QBInvoiceID, &inv.SyncedAt, &inv.DocumentKey,
+		&inv.CreatedAt, &inv.UpdatedAt, &inv.CreatedBy)
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// CUSTOMER STATEMENTS
+// ============================================================================
+
+const statementColumns = `id, statement_number, customer_id, customer_name, period_start, period_end, currency,
+	invoices, credits, aging, total_open::float8, total_credits::float8, total_disputed::float8,
+	amount_due::float8, payments_in_period::float8, generated_by, created_at, updated_at`
+
+// PostgresStatementRepository implements StatementRepository
+type PostgresStatementRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStatementRepository creates a new PostgreSQL statement repository
+func NewPostgresStatementRepository(pool *pgxpool.Pool) *PostgresStatementRepository {
+	return &PostgresStatementRepository{pool: pool}
+}
+
+func (r *PostgresStatementRepository) ListInvoicesAsOf(ctx context.Context, customerID uuid.UUID, cutoff time.Time) ([]domain.Invoice, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT i.id, i.invoice_number, i.customer_id, COALESCE(i.customer_name, ''), i.status,
+			i.invoice_date, COALESCE(i.due_date, i.invoice_date), i.total_amount::float8,
+			COALESCE(i.disputed_amount, 0)::float8, COALESCE(i.po_number, ''), COALESCE(i.bol_number, ''),
+			COALESCE(i.currency, 'USD'),
+			COALESCE((SELECT SUM(p.amount) FROM payments p
+				WHERE p.invoice_id = i.id AND p.payment_date < $2), 0)::float8
+		 FROM invoices i
+		 WHERE i.customer_id = $1 AND i.invoice_date < $2 AND i.status NOT IN ('DRAFT', 'VOID')
+		 ORDER BY i.due_date, i.invoice_number`,
+		customerID, cutoff,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []domain.Invoice
+	for rows.Next() {
+		var inv domain.Invoice
+		if err := rows.Scan(&inv.ID, &inv.InvoiceNumber, &inv.CustomerID, &inv.CustomerName, &inv.Status,
+			&inv.InvoiceDate, &inv.DueDate, &inv.TotalAmount, &inv.DisputedAmount, &inv.PONumber, &inv.BOLNumber,
+			&inv.Currency, &inv.PaidAmount); err != nil {
+			return nil, fmt.Errorf("scan invoice: %w", err)
+		}
+		inv.BalanceDue = inv.TotalAmount - inv.PaidAmount
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+func (r *PostgresStatementRepository) ListUnappliedCredits(ctx context.Context, customerID uuid.UUID, cutoff time.Time) ([]domain.CustomerCredit, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, customer_id, credit_number, credit_date, amount::float8, applied_amount::float8,
+			invoice_id, COALESCE(reason, ''), created_at, COALESCE(created_by, '')
+		 FROM customer_credits
+		 WHERE customer_id = $1 AND credit_date < $2 AND applied_amount < amount AND voided_at IS NULL
+		 ORDER BY credit_date`,
+		customerID, cutoff,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query credits: %w", err)
+	}
+	defer rows.Close()
+
+	var credits []domain.CustomerCredit
+	for rows.Next() {
+		var c domain.CustomerCredit
+		if err := rows.Scan(&c.ID, &c.CustomerID, &c.CreditNumber, &c.CreditDate, &c.Amount, &c.AppliedAmount,
+			&c.InvoiceID, &c.Reason, &c.CreatedAt, &c.CreatedBy); err != nil {
+			return nil, fmt.Errorf("scan credit: %w", err)
+		}
+		credits = append(credits, c)
+	}
+	return credits, rows.Err()
+}
+
+func (r *PostgresStatementRepository) SumPayments(ctx context.Context, customerID uuid.UUID, from, to time.Time) (float64, error) {
+	var total float64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COALESCE(SUM(p.amount), 0)::float8
+		 FROM payments p JOIN invoices i ON i.id = p.invoice_id
+		 WHERE i.customer_id = $1 AND p.payment_date >= $2 AND p.payment_date < $3`,
+		customerID, from, to,
+	).Scan(&total)
+	return total, err
+}
+
+func (r *PostgresStatementRepository) ListCustomersWithActivity(ctx context.Context, from, cutoff time.Time) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT i.customer_id FROM invoices i
+		 WHERE i.invoice_date < $2 AND i.status NOT IN ('DRAFT', 'VOID')
+		   AND i.total_amount > COALESCE((SELECT SUM(p.amount) FROM payments p
+				WHERE p.invoice_id = i.id AND p.payment_date < $2), 0)
+		 UNION
+		 SELECT i.customer_id FROM payments p JOIN invoices i ON i.id = p.invoice_id
+		 WHERE p.payment_date >= $1 AND p.payment_date < $2`,
+		from, cutoff,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query customers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan customer: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresStatementRepository) GetCustomerContact(ctx context.Context, customerID uuid.UUID) (*domain.CustomerContact, error) {
+	var c domain.CustomerContact
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, company_name, COALESCE(address, ''), COALESCE(city, ''), COALESCE(state, ''),
+			COALESCE(zip, ''), COALESCE(phone, ''), COALESCE(email, '')
+		 FROM customers WHERE id = $1`, customerID,
+	).Scan(&c.ID, &c.Name, &c.Address, &c.City, &c.State, &c.Zip, &c.Phone, &c.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *PostgresStatementRepository) Save(ctx context.Context, stmt *domain.CustomerStatement) error {
+	invoices, err := json.Marshal(stmt.Invoices)
+	if err != nil {
+		return fmt.Errorf("marshal invoices: %w", err)
+	}
+	credits, err := json.Marshal(stmt.Credits)
+	if err != nil {
+		return fmt.Errorf("marshal credits: %w", err)
+	}
+	aging, err := json.Marshal(stmt.Aging)
+	if err != nil {
+		return fmt.Errorf("marshal aging: %w", err)
+	}
+
+	// Regenerating a period keeps the original ID and creation time
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO customer_statements (id, statement_number, customer_id, customer_name, period_start, period_end,
+			currency, invoices, credits, aging, total_open, total_credits, total_disputed, amount_due,
+			payments_in_period, generated_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		 ON CONFLICT (customer_id, period_start) DO UPDATE SET
+			customer_name = EXCLUDED.customer_name, period_end = EXCLUDED.period_end, currency = EXCLUDED.currency,
+			invoices = EXCLUDED.invoices, credits = EXCLUDED.credits, aging = EXCLUDED.aging,
+			total_open = EXCLUDED.total_open, total_credits = EXCLUDED.total_credits,
+			total_disputed = EXCLUDED.total_disputed, amount_due = EXCLUDED.amount_due,
+			payments_in_period = EXCLUDED.payments_in_period, generated_by = EXCLUDED.generated_by,
+			updated_at = EXCLUDED.updated_at
+		 RETURNING id, created_at`,
+		stmt.ID, stmt.StatementNumber, stmt.CustomerID, stmt.CustomerName, stmt.PeriodStart, stmt.PeriodEnd,
+		stmt.Currency, invoices, credits, aging, stmt.TotalOpen, stmt.TotalCredits, stmt.TotalDisputed, stmt.AmountDue,
+		stmt.PaymentsInPeriod, stmt.GeneratedBy, stmt.CreatedAt, stmt.UpdatedAt,
+	).Scan(&stmt.ID, &stmt.CreatedAt)
+}
+
+func (r *PostgresStatementRepository) Get(ctx context.Context, customerID uuid.UUID, periodStart time.Time) (*domain.CustomerStatement, error) {
+	var stmt domain.CustomerStatement
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+statementColumns+` FROM customer_statements WHERE customer_id = $1 AND period_start = $2`,
+		customerID, periodStart)
+	if err := scanStatement(row, &stmt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stmt, nil
+}
+
+func (r *PostgresStatementRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]domain.CustomerStatement, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+statementColumns+` FROM customer_statements WHERE customer_id = $1 ORDER BY period_start DESC`,
+		customerID)
+	if err != nil {
+		return nil, fmt.Errorf("query statements: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []domain.CustomerStatement
+	for rows.Next() {
+		var stmt domain.CustomerStatement
+		if err := scanStatement(rows, &stmt); err != nil {
+			return nil, fmt.Errorf("scan statement: %w", err)
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, rows.Err()
+}
+
+func scanStatement(row pgx.Row, stmt *domain.CustomerStatement) error {
+	var invoices, credits, aging []byte
+	if err := row.Scan(&stmt.ID, &stmt.StatementNumber, &stmt.CustomerID, &stmt.CustomerName,
+		&stmt.PeriodStart, &stmt.PeriodEnd, &stmt.Currency, &invoices, &credits, &aging,
+		&stmt.TotalOpen, &stmt.TotalCredits, &stmt.TotalDisputed, &stmt.AmountDue, &stmt.PaymentsInPeriod,
+		&stmt.GeneratedBy, &stmt.CreatedAt, &stmt.UpdatedAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(invoices, &stmt.Invoices); err != nil {
+		return fmt.Errorf("decode invoices: %w", err)
+	}
+	if err := json.Unmarshal(credits, &stmt.Credits); err != nil {
+		return fmt.Errorf("decode credits: %w", err)
+	}
+	if err := json.Unmarshal(aging, &stmt.Aging); err != nil {
+		return fmt.Errorf("decode aging: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// RATES AND AMENDMENTS
+// ============================================================================
+
+const rateColumns = `id, customer_id, name, COALESCE(description, ''), rate_type, COALESCE(origin_type, 'any'),
+	origin_id, COALESCE(origin_zone, ''), COALESCE(destination_type, 'any'), destination_id,
+	COALESCE(destination_zone, ''), COALESCE(container_size, 'any'), COALESCE(container_type, 'any'),
+	base_rate::float8, COALESCE(fuel_surcharge, 0)::float8, COALESCE(fuel_surcharge_type, 'percent'),
+	effective_date, expiration_date, COALESCE(is_active, TRUE), superseded_by, expiry_alerted_at,
+	created_at, updated_at, contract_id,
+	(SELECT c.fuel_schedule_id FROM rate_contracts c WHERE c.id = rates.contract_id)`
+
+const accessorialRateColumns = `id, customer_id, charge_type, COALESCE(description, ''), rate_type, rate::float8,
+	COALESCE(min_charge, 0)::float8, COALESCE(max_charge, 0)::float8, COALESCE(free_time, 0),
+	effective_date, expiration_date, COALESCE(is_active, TRUE), superseded_by, expiry_alerted_at,
+	created_at, updated_at`
+
+const amendmentColumns = `id, rate_kind, previous_rate_id, new_rate_id, customer_id, effective_date, reason,
+	charges_rerated, orders_rerated, total_delta::float8, pending_invoice_delta::float8, amended_by, created_at`
+
+// PostgresRateRepository implements RateRepository
+type PostgresRateRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRateRepository creates a new PostgreSQL rate repository
+func NewPostgresRateRepository(pool *pgxpool.Pool) *PostgresRateRepository {
+	return &PostgresRateRepository{pool: pool}
+}
+
+func (r *PostgresRateRepository) GetRate(ctx context.Context, id uuid.UUID) (*domain.Rate, error) {
+	return scanRate(r.pool.QueryRow(ctx, `SELECT `+rateColumns+` FROM rates WHERE id = $1`, id))
+}
+
+func (r *PostgresRateRepository) GetAccessorialRate(ctx context.Context, id uuid.UUID) (*domain.AccessorialRate, error) {
+	var rate domain.AccessorialRate
+	err := r.pool.QueryRow(ctx, `SELECT `+accessorialRateColumns+` FROM accessorial_rates WHERE id = $1`, id).Scan(
+		&rate.ID, &rate.CustomerID, &rate.ChargeType, &rate.Description, &rate.RateType, &rate.Rate,
+		&rate.MinCharge, &rate.MaxCharge, &rate.FreeTime,
+		&rate.EffectiveDate, &rate.ExpirationDate, &rate.IsActive, &rate.SupersededBy, &rate.ExpiryAlertedAt,
+		&rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *PostgresRateRepository) FindContractRate(ctx context.Context, q domain.ContractRateQuery) (*domain.Rate, error) {
+	// A rate for the exact origin or destination beats one for a zone the
+	// location is in, which beats one for any; a rate for the container's
+	// size or type beats one for any. Among equals the latest version wins.
+	rate, err := scanRate(r.pool.QueryRow(ctx,
+		`WITH origin_zones AS (`+zonesContaining("$2")+`),
+			destination_zones AS (`+zonesContaining("$3")+`)
+		 SELECT `+rateColumns+` FROM rates
+		 WHERE customer_id = $1 AND COALESCE(is_active, TRUE) AND superseded_by IS NULL
+			AND effective_date <= $6 AND (expiration_date IS NULL OR expiration_date > $6)
+			AND (COALESCE(origin_type, 'any') = 'any' OR origin_id = $2
+				OR (origin_type = 'zone' AND origin_zone IN (SELECT code FROM origin_zones)))
+			AND (COALESCE(destination_type, 'any') = 'any' OR destination_id = $3
+				OR (destination_type = 'zone' AND destination_zone IN (SELECT code FROM destination_zones)))
+			AND (COALESCE(container_size, 'any') = 'any' OR container_size = $4)
+			AND (COALESCE(container_type, 'any') = 'any' OR LOWER(container_type) = LOWER($5))
+		 ORDER BY CASE WHEN origin_id = $2 THEN 2 WHEN origin_type = 'zone' THEN 1 ELSE 0 END
+			+ CASE WHEN destination_id = $3 THEN 2 WHEN destination_type = 'zone' THEN 1 ELSE 0 END
+			+ CASE WHEN container_size = $4 THEN 1 ELSE 0 END
+			+ CASE WHEN LOWER(container_type) = LOWER($5) THEN 1 ELSE 0 END DESC,
+			effective_date DESC
+		 LIMIT 1`,
+		q.CustomerID, q.OriginID, q.DestinationID, q.ContainerSize, q.ContainerType, q.At,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("find contract rate: %w", err)
+	}
+	return rate, nil
+}
+
+// zonesContaining selects the codes of the active zones a location is in,
+// by listed location or ZIP code prefix. param is the location ID parameter.
+func zonesContaining(param string) string {
+	return `SELECT z.code FROM rate_zones z
+		LEFT JOIN locations l ON l.id = ` + param + `
+		WHERE z.is_active AND (` + param + ` = ANY(z.location_ids)
+			OR EXISTS (SELECT 1 FROM unnest(z.postal_prefixes) p WHERE l.zip LIKE p || '%'))`
+}
+
+func (r *PostgresRateRepository) CurrentAccessorialRates(ctx context.Context, customerID uuid.UUID, at time.Time) ([]domain.AccessorialRate, error) {
+	// The customer's own rate beats the default; among equals the latest
+	// version wins
+	rows, err := r.pool.Query(ctx,
+		`SELECT DISTINCT ON (charge_type) `+accessorialRateColumns+` FROM accessorial_rates
+		 WHERE (customer_id = $1 OR customer_id IS NULL) AND COALESCE(is_active, TRUE) AND superseded_by IS NULL
+			AND effective_date <= $2 AND (expiration_date IS NULL OR expiration_date > $2)
+		 ORDER BY charge_type, customer_id NULLS LAST, effective_date DESC`,
+		customerID, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query accessorial rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []domain.AccessorialRate
+	for rows.Next() {
+		var rate domain.AccessorialRate
+		if err := rows.Scan(
+			&rate.ID, &rate.CustomerID, &rate.ChargeType, &rate.Description, &rate.RateType, &rate.Rate,
+			&rate.MinCharge, &rate.MaxCharge, &rate.FreeTime,
+			&rate.EffectiveDate, &rate.ExpirationDate, &rate.IsActive, &rate.SupersededBy, &rate.ExpiryAlertedAt,
+			&rate.CreatedAt, &rate.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan accessorial rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *PostgresRateRepository) ListExpiring(ctx context.Context, from, to time.Time) ([]domain.RateExpiration, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT 'CONTRACT', r.id, r.customer_id, COALESCE(c.company_name, ''), r.name, r.expiration_date, r.expiry_alerted_at
+		 FROM rates r LEFT JOIN customers c ON c.id = r.customer_id
+		 WHERE COALESCE(r.is_active, TRUE) AND r.superseded_by IS NULL
+			AND r.expiration_date >= $1 AND r.expiration_date < $2
+		 UNION ALL
+		 SELECT 'ACCESSORIAL', a.id, a.customer_id, COALESCE(c.company_name, ''), a.charge_type, a.expiration_date, a.expiry_alerted_at
+		 FROM accessorial_rates a LEFT JOIN customers c ON c.id = a.customer_id
+		 WHERE COALESCE(a.is_active, TRUE) AND a.superseded_by IS NULL
+			AND a.expiration_date >= $1 AND a.expiration_date < $2
+		 ORDER BY 6, 2`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query expiring rates: %w", err)
+	}
+	defer rows.Close()
+
+	var expiring []domain.RateExpiration
+	for rows.Next() {
+		var e domain.RateExpiration
+		if err := rows.Scan(&e.RateKind, &e.RateID, &e.CustomerID, &e.CustomerName, &e.Name,
+			&e.ExpirationDate, &e.AlertedAt); err != nil {
+			return nil, fmt.Errorf("scan expiring rate: %w", err)
+		}
+		expiring = append(expiring, e)
+	}
+	return expiring, rows.Err()
+}
+
+func (r *PostgresRateRepository) MarkExpiryAlerted(ctx context.Context, kind domain.RateKind, rateID uuid.UUID, at time.Time) error {
+	table := "rates"
+	if kind == domain.RateKindAccessorial {
+		table = "accessorial_rates"
+	}
+	_, err := r.pool.Exec(ctx, `UPDATE `+table+` SET expiry_alerted_at = $2 WHERE id = $1`, rateID, at)
+	return err
+}
+
+func (r *PostgresRateRepository) ListRatedCharges(ctx context.Context, kind domain.RateKind, rateID uuid.UUID, createdFrom time.Time) ([]domain.RatedCharge, error) {
+	// A charge counts as invoiced when its order has a line item of the same
+	// type on an invoice that was not voided
+	rows, err := r.pool.Query(ctx,
+		`SELECT oc.id, oc.order_id, o.order_number, o.created_at, oc.charge_type,
+			COALESCE(oc.quantity, 1)::float8, COALESCE(oc.unit_rate, 0)::float8, COALESCE(oc.amount, 0)::float8,
+			li.invoice_id, li.id
+		 FROM order_charges oc
+		 JOIN orders o ON o.id = oc.order_id
+		 LEFT JOIN LATERAL (
+			SELECT l.id, l.invoice_id FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id
+			WHERE l.order_id = oc.order_id AND l.charge_type = oc.charge_type AND i.status <> 'VOID'
+			ORDER BY l.created_at DESC LIMIT 1
+		 ) li ON TRUE
+		 WHERE oc.rate_kind = $1 AND oc.rate_id = $2 AND o.created_at >= $3 AND o.deleted_at IS NULL
+		 ORDER BY o.created_at, oc.order_id, oc.created_at`,
+		kind, rateID, createdFrom,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query rated charges: %w", err)
+	}
+	defer rows.Close()
+
+	var charges []domain.RatedCharge
+	for rows.Next() {
+		var c domain.RatedCharge
+		if err := rows.Scan(&c.ID, &c.OrderID, &c.OrderNumber, &c.OrderCreatedAt, &c.ChargeType,
+			&c.Quantity, &c.UnitRate, &c.Amount, &c.InvoiceID, &c.LineItemID); err != nil {
+			return nil, fmt.Errorf("scan rated charge: %w", err)
+		}
+		charges = append(charges, c)
+	}
+	return charges, rows.Err()
+}
+
+func (r *PostgresRateRepository) ApplyAmendment(ctx context.Context, a *domain.RateAmendment) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	table := "rates"
+	switch {
+	case a.ContractRate != nil:
+		v := a.ContractRate
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO rates (id, customer_id, name, description, rate_type, origin_type, origin_id, origin_zone,
+				destination_type, destination_id, destination_zone, container_size, container_type,
+				base_rate, fuel_surcharge, fuel_surcharge_type, effective_date, expiration_date, is_active,
+				created_at, updated_at, contract_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`,
+			v.ID, v.CustomerID, v.Name, v.Description, v.RateType, v.OriginType, v.OriginID, v.OriginZone,
+			v.DestinationType, v.DestinationID, v.DestinationZone, v.ContainerSize, v.ContainerType,
+			v.BaseRate, v.FuelSurcharge, v.FuelSurchargeType, v.EffectiveDate, v.ExpirationDate, v.IsActive,
+			v.CreatedAt, v.UpdatedAt, v.ContractID,
+		); err != nil {
+			return fmt.Errorf("insert rate version: %w", err)
+		}
+	case a.AccessorialRate != nil:
+		table = "accessorial_rates"
+		v := a.AccessorialRate
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO accessorial_rates (id, customer_id, charge_type, description, rate_type, rate,
+				min_charge, max_charge, free_time, effective_date, expiration_date, is_active, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+			v.ID, v.CustomerID, v.ChargeType, v.Description, v.RateType, v.Rate,
+			v.MinCharge, v.MaxCharge, v.FreeTime, v.EffectiveDate, v.ExpirationDate, v.IsActive, v.CreatedAt, v.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("insert accessorial rate version: %w", err)
+		}
+	default:
+		return fmt.Errorf("amendment %s has no new rate version", a.ID)
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE `+table+` SET expiration_date = $2, superseded_by = $3, updated_at = $4
+		 WHERE id = $1 AND superseded_by IS NULL`,
+		a.PreviousRateID, a.EffectiveDate, a.NewRateID, a.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("expire previous rate: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("rate %s has already been superseded", a.PreviousRateID)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE order_charges oc SET rate_id = $3
+		 FROM orders o
+		 WHERE o.id = oc.order_id AND oc.rate_kind = $1 AND oc.rate_id = $2 AND o.created_at >= $4`,
+		a.RateKind, a.PreviousRateID, a.NewRateID, a.EffectiveDate,
+	); err != nil {
+		return fmt.Errorf("move charges to new rate: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO rate_amendments (`+amendmentColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		a.ID, a.RateKind, a.PreviousRateID, a.NewRateID, a.CustomerID, a.EffectiveDate, a.Reason,
+		a.ChargesRerated, a.OrdersRerated, a.TotalDelta, a.PendingInvoiceDelta, a.AmendedBy, a.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert amendment: %w", err)
+	}
+
+	for _, adj := range a.Adjustments {
+		if adj.Status == domain.RerateStatusApplied {
+			if _, err := tx.Exec(ctx,
+				`UPDATE order_charges SET unit_rate = $2, amount = $3 WHERE id = $1`,
+				adj.ChargeID, adj.NewUnitRate, adj.NewAmount,
+			); err != nil {
+				return fmt.Errorf("update charge %s: %w", adj.ChargeID, err)
+			}
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO rerate_adjustments (id, amendment_id, order_id, order_number, charge_id, charge_type,
+				invoice_id, line_item_id, quantity, old_unit_rate, new_unit_rate, old_amount, new_amount, delta,
+				status, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+			adj.ID, adj.AmendmentID, adj.OrderID, adj.OrderNumber, adj.ChargeID, adj.ChargeType,
+			adj.InvoiceID, adj.LineItemID, adj.Quantity, adj.OldUnitRate, adj.NewUnitRate, adj.OldAmount,
+			adj.NewAmount, adj.Delta, adj.Status, adj.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("insert adjustment: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresRateRepository) GetAmendment(ctx context.Context, id uuid.UUID) (*domain.RateAmendment, error) {
+	var a domain.RateAmendment
+	row := r.pool.QueryRow(ctx, `SELECT `+amendmentColumns+` FROM rate_amendments WHERE id = $1`, id)
+	if err := scanAmendment(row, &a); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, amendment_id, order_id, order_number, charge_id, charge_type, invoice_id, line_item_id,
+			quantity::float8, old_unit_rate::float8, new_unit_rate::float8, old_amount::float8,
+			new_amount::float8, delta::float8, status, created_at
+		 FROM rerate_adjustments WHERE amendment_id = $1 ORDER BY order_number, charge_type`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	a.Adjustments = []domain.RerateAdjustment{}
+	for rows.Next() {
+		var adj domain.RerateAdjustment
+		if err := rows.Scan(&adj.ID, &adj.AmendmentID, &adj.OrderID, &adj.OrderNumber, &adj.ChargeID,
+			&adj.ChargeType, &adj.InvoiceID, &adj.LineItemID, &adj.Quantity, &adj.OldUnitRate, &adj.NewUnitRate,
+			&adj.OldAmount, &adj.NewAmount, &adj.Delta, &adj.Status, &adj.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan adjustment: %w", err)
+		}
+		a.Adjustments = append(a.Adjustments, adj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresRateRepository) ListAmendments(ctx context.Context, rateID uuid.UUID) ([]domain.RateAmendment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+amendmentColumns+` FROM rate_amendments
+		 WHERE previous_rate_id = $1 OR new_rate_id = $1 ORDER BY created_at`,
+		rateID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query amendments: %w", err)
+	}
+	defer rows.Close()
+
+	var amendments []domain.RateAmendment
+	for rows.Next() {
+		var a domain.RateAmendment
+		if err := scanAmendment(rows, &a); err != nil {
+			return nil, fmt.Errorf("scan amendment: %w", err)
+		}
+		amendments = append(amendments, a)
+	}
+	return amendments, rows.Err()
+}
+
+func scanAmendment(row pgx.Row, a *domain.RateAmendment) error {
+	return row.Scan(&a.ID, &a.RateKind, &a.PreviousRateID, &a.NewRateID, &a.CustomerID, &a.EffectiveDate,
+		&a.Reason, &a.ChargesRerated, &a.OrdersRerated, &a.TotalDelta, &a.PendingInvoiceDelta, &a.AmendedBy,
+		&a.CreatedAt)
+}
+
+func scanRate(row pgx.Row) (*domain.Rate, error) {
+	var rate domain.Rate
+	err := row.Scan(
+		&rate.ID, &rate.CustomerID, &rate.Name, &rate.Description, &rate.RateType, &rate.OriginType,
+		&rate.OriginID, &rate.OriginZone, &rate.DestinationType, &rate.DestinationID,
+		&rate.DestinationZone, &rate.ContainerSize, &rate.ContainerType,
+		&rate.BaseRate, &rate.FuelSurcharge, &rate.FuelSurchargeType,
+		&rate.EffectiveDate, &rate.ExpirationDate, &rate.IsActive, &rate.SupersededBy, &rate.ExpiryAlertedAt,
+		&rate.CreatedAt, &rate.UpdatedAt, &rate.ContractID, &rate.FuelScheduleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// RATE TABLES
+// ============================================================================
+
+const zoneColumns = `id, code, name, COALESCE(description, ''), postal_prefixes, location_ids, is_active,
+	created_at, updated_at`
+
+const contractColumns = `c.id, c.customer_id, COALESCE(cu.name, ''), c.contract_number, c.name, c.fuel_schedule_id,
+	c.effective_date, c.expiration_date, c.is_active, c.created_by, c.created_at, c.updated_at`
+
+const fuelScheduleColumns = `id, customer_id, name, region, effective_date, expiration_date, is_active,
+	created_at, updated_at`
+
+const fuelPriceColumns = `id, region, effective_date, price_per_gallon::float8, COALESCE(source, ''),
+	created_by, created_at`
+
+// PostgresRateTableRepository implements RateTableRepository
+type PostgresRateTableRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRateTableRepository creates a new PostgreSQL rate table repository
+func NewPostgresRateTableRepository(pool *pgxpool.Pool) *PostgresRateTableRepository {
+	return &PostgresRateTableRepository{pool: pool}
+}
+
+func (r *PostgresRateTableRepository) CreateZone(ctx context.Context, z *domain.RateZone) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO rate_zones (id, code, name, description, postal_prefixes, location_ids, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)`,
+		z.ID, z.Code, z.Name, z.Description, z.PostalPrefixes, z.LocationIDs, z.IsActive, z.CreatedAt, z.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert rate zone: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRateTableRepository) UpdateZone(ctx context.Context, z *domain.RateZone) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE rate_zones SET name = $2, description = NULLIF($3, ''), postal_prefixes = $4, location_ids = $5,
+			is_active = $6, updated_at = $7
+		 WHERE id = $1`,
+		z.ID, z.Name, z.Description, z.PostalPrefixes, z.LocationIDs, z.IsActive, z.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update rate zone: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRateTableRepository) GetZone(ctx context.Context, id uuid.UUID) (*domain.RateZone, error) {
+	zone, err := scanZone(r.pool.QueryRow(ctx, `SELECT `+zoneColumns+` FROM rate_zones WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("get rate zone: %w", err)
+	}
+	return zone, nil
+}
+
+func (r *PostgresRateTableRepository) ListZones(ctx context.Context) ([]domain.RateZone, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+zoneColumns+` FROM rate_zones ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("query rate zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []domain.RateZone{}
+	for rows.Next() {
+		zone, err := scanZone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rate zone: %w", err)
+		}
+		zones = append(zones, *zone)
+	}
+	return zones, rows.Err()
+}
+
+func (r *PostgresRateTableRepository) ZoneCodesExist(ctx context.Context, codes []string) (map[string]bool, error) {
+	rows, err := r.pool.Query(ctx, `SELECT code FROM rate_zones WHERE is_active AND code = ANY($1)`, codes)
+	if err != nil {
+		return nil, fmt.Errorf("query rate zones: %w", err)
+	}
+	defer rows.Close()
+
+	exist := make(map[string]bool, len(codes))
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("scan rate zone: %w", err)
+		}
+		exist[code] = true
+	}
+	return exist, rows.Err()
+}
+
+func (r *PostgresRateTableRepository) CreateContract(ctx context.Context, c *domain.RateContract) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO rate_contracts (id, customer_id, contract_number, name, fuel_schedule_id,
+			effective_date, expiration_date, is_active, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		c.ID, c.CustomerID, c.ContractNumber, c.Name, c.FuelScheduleID,
+		c.EffectiveDate, c.ExpirationDate, c.IsActive, c.CreatedBy, c.CreatedAt, c.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("insert rate contract: %w", err)
+	}
+	for _, v := range c.LaneRates {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO rates (id, customer_id, name, description, rate_type, origin_type, origin_id, origin_zone,
+				destination_type, destination_id, destination_zone, container_size, container_type,
+				base_rate, fuel_surcharge, fuel_surcharge_type, effective_date, expiration_date, is_active,
+				created_at, updated_at, contract_id)
+			 VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10, NULLIF($11, ''), $12, $13,
+				$14, $15, $16, $17, $18, $19, $20, $21, $22)`,
+			v.ID, v.CustomerID, v.Name, v.Description, v.RateType, v.OriginType, v.OriginID, v.OriginZone,
+			v.DestinationType, v.DestinationID, v.DestinationZone, v.ContainerSize, v.ContainerType,
+			v.BaseRate, v.FuelSurcharge, v.FuelSurchargeType, v.EffectiveDate, v.ExpirationDate, v.IsActive,
+			v.CreatedAt, v.UpdatedAt, v.ContractID,
+		); err != nil {
+			return fmt.Errorf("insert lane rate: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresRateTableRepository) GetContract(ctx context.Context, id uuid.UUID) (*domain.RateContract, error) {
+	contract, err := scanContract(r.pool.QueryRow(ctx,
+		`SELECT `+contractColumns+` FROM rate_contracts c
+		 LEFT JOIN customers cu ON cu.id = c.customer_id
+		 WHERE c.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("get rate contract: %w", err)
+	}
+	if contract == nil {
+		return nil, nil
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+rateColumns+` FROM rates
+		 WHERE contract_id = $1 AND superseded_by IS NULL
+		 ORDER BY origin_zone NULLS LAST, destination_zone NULLS LAST, container_size, name`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query lane rates: %w", err)
+	}
+	defer rows.Close()
+
+	contract.LaneRates = []domain.Rate{}
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan lane rate: %w", err)
+		}
+		contract.LaneRates = append(contract.LaneRates, *rate)
+	}
+	return contract, rows.Err()
+}
+
+func (r *PostgresRateTableRepository) ListContracts(ctx context.Context, customerID *uuid.UUID) ([]domain.RateContract, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+contractColumns+` FROM rate_contracts c
+		 LEFT JOIN customers cu ON cu.id = c.customer_id
+		 WHERE ($1::uuid IS NULL OR c.customer_id = $1)
+		 ORDER BY c.effective_date DESC, c.contract_number`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("query rate contracts: %w", err)
+	}
+	defer rows.Close()
+
+	contracts := []domain.RateContract{}
+	for rows.Next() {
+		contract, err := scanContract(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rate contract: %w", err)
+		}
+		contracts = append(contracts, *contract)
+	}
+	return contracts, rows.Err()
+}
+
+func (r *PostgresRateTableRepository) CreateFuelSchedule(ctx context.Context, s *domain.FuelSurchargeSchedule) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO fuel_surcharge_schedules (id, customer_id, name, region, effective_date, expiration_date,
+			is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		s.ID, s.CustomerID, s.Name, s.Region, s.EffectiveDate, s.ExpirationDate, s.IsActive, s.CreatedAt, s.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("insert fuel surcharge schedule: %w", err)
+	}
+	for _, tier := range s.Tiers {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO fuel_surcharge_tiers (schedule_id, min_price, max_price, surcharge_percent)
+			 VALUES ($1, $2, $3, $4)`,
+			s.ID, tier.MinPrice, tier.MaxPrice, tier.Percent,
+		); err != nil {
+			return fmt.Errorf("insert fuel surcharge tier: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresRateTableRepository) GetFuelSchedule(ctx context.Context, id uuid.UUID) (*domain.FuelSurchargeSchedule, error) {
+	schedules, err := r.listFuelSchedules(ctx, `WHERE id = $1`, id)
+	if err != nil || len(schedules) == 0 {
+		return nil, err
+	}
+	return &schedules[0], nil
+}
+
+func (r *PostgresRateTableRepository) ListFuelSchedules(ctx context.Context, customerID *uuid.UUID) ([]domain.FuelSurchargeSchedule, error) {
+	return r.listFuelSchedules(ctx, `WHERE ($1::uuid IS NULL OR customer_id = $1 OR customer_id IS NULL)`, customerID)
+}
+
+// listFuelSchedules loads the schedules matching where, with their tiers
+func (r *PostgresRateTableRepository) listFuelSchedules(ctx context.Context, where string, args ...interface{}) ([]domain.FuelSurchargeSchedule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+fuelScheduleColumns+` FROM fuel_surcharge_schedules `+where+`
+		 ORDER BY customer_id NULLS LAST, effective_date DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query fuel surcharge schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []domain.FuelSurchargeSchedule{}
+	index := make(map[uuid.UUID]int)
+	var ids []uuid.UUID
+	for rows.Next() {
+		var s domain.FuelSurchargeSchedule
+		if err := rows.Scan(&s.ID, &s.CustomerID, &s.Name, &s.Region, &s.EffectiveDate, &s.ExpirationDate,
+			&s.IsActive, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan fuel surcharge schedule: %w", err)
+		}
+		s.Tiers = []domain.FuelSurchargeTier{}
+		index[s.ID] = len(schedules)
+		ids = append(ids, s.ID)
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return schedules, nil
+	}
+
+	tierRows, err := r.pool.Query(ctx,
+		`SELECT schedule_id, min_price::float8, max_price::float8, surcharge_percent::float8
+		 FROM fuel_surcharge_tiers WHERE schedule_id = ANY($1)
+		 ORDER BY schedule_id, min_price`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("query fuel surcharge tiers: %w", err)
+	}
+	defer tierRows.Close()
+
+	for tierRows.Next() {
+		var scheduleID uuid.UUID
+		var tier domain.FuelSurchargeTier
+		if err := tierRows.Scan(&scheduleID, &tier.MinPrice, &tier.MaxPrice, &tier.Percent); err != nil {
+			return nil, fmt.Errorf("scan fuel surcharge tier: %w", err)
+		}
+		s := &schedules[index[scheduleID]]
+		s.Tiers = append(s.Tiers, tier)
+	}
+	return schedules, tierRows.Err()
+}
+
+func (r *PostgresRateTableRepository) SaveFuelPrice(ctx context.Context, p *domain.FuelPrice) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO fuel_prices (id, region, effective_date, price_per_gallon, source, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		 ON CONFLICT (region, effective_date) DO UPDATE SET
+			price_per_gallon = EXCLUDED.price_per_gallon, source = EXCLUDED.source,
+			created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at
+		 RETURNING id`,
+		p.ID, p.Region, p.EffectiveDate, p.PricePerGallon, p.Source, p.CreatedBy, p.CreatedAt,
+	).Scan(&p.ID)
+	if err != nil {
+		return fmt.Errorf("save fuel price: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRateTableRepository) CurrentFuelPrice(ctx context.Context, region string, at time.Time) (*domain.FuelPrice, error) {
+	price, err := scanFuelPrice(r.pool.QueryRow(ctx,
+		`SELECT `+fuelPriceColumns+` FROM fuel_prices
+		 WHERE region = $1 AND effective_date <= $2::date
+		 ORDER BY effective_date DESC LIMIT 1`, region, at))
+	if err != nil {
+		return nil, fmt.Errorf("get fuel price: %w", err)
+	}
+	return price, nil
+}
+
+func (r *PostgresRateTableRepository) ListFuelPrices(ctx context.Context, region string, limit int) ([]domain.FuelPrice, error) {
+	if limit <= 0 || limit > 520 {
+		limit = 52
+	}
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+fuelPriceColumns+` FROM fuel_prices
+		 WHERE ($1 = '' OR region = $1)
+		 ORDER BY effective_date DESC, region
+		 LIMIT $2`, region, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query fuel prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []domain.FuelPrice{}
+	for rows.Next() {
+		price, err := scanFuelPrice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan fuel price: %w", err)
+		}
+		prices = append(prices, *price)
+	}
+	return prices, rows.Err()
+}
+
+func (r *PostgresRateTableRepository) CreateAccessorialRate(ctx context.Context, v *domain.AccessorialRate) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO accessorial_rates (id, customer_id, charge_type, description, rate_type, rate,
+			min_charge, max_charge, free_time, effective_date, expiration_date, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), $9, $10, $11, $12, $13, $14)`,
+		v.ID, v.CustomerID, v.ChargeType, v.Description, v.RateType, v.Rate,
+		v.MinCharge, v.MaxCharge, v.FreeTime, v.EffectiveDate, v.ExpirationDate, v.IsActive, v.CreatedAt, v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert accessorial rate: %w", err)
+	}
+	return nil
+}
+
+func scanZone(row pgx.Row) (*domain.RateZone, error) {
+	var z domain.RateZone
+	err := row.Scan(&z.ID, &z.Code, &z.Name, &z.Description, &z.PostalPrefixes, &z.LocationIDs, &z.IsActive,
+		&z.CreatedAt, &z.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &z, nil
+}
+
+func scanContract(row pgx.Row) (*domain.RateContract, error) {
+	var c domain.RateContract
+	err := row.Scan(&c.ID, &c.CustomerID, &c.CustomerName, &c.ContractNumber, &c.Name, &c.FuelScheduleID,
+		&c.EffectiveDate, &c.ExpirationDate, &c.IsActive, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanFuelPrice(row pgx.Row) (*domain.FuelPrice, error) {
+	var p domain.FuelPrice
+	err := row.Scan(&p.ID, &p.Region, &p.EffectiveDate, &p.PricePerGallon, &p.Source, &p.CreatedBy, &p.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// TRIP BILLING RECONCILIATION
+// ============================================================================
+
+const reconIssueColumns = `id, trip_id, trip_number, customer_id, issue_type, charge_type, amount::float8,
+	detail, invoice_ids, status, first_run_id, last_run_id, detected_at, COALESCE(resolved_by, ''),
+	resolved_at, COALESCE(resolution_notes, '')`
+
+const reconRunColumns = `id, period_start, period_end, trips_checked, issues_found, issues_opened,
+	issues_cleared, unbilled_amount::float8, double_billed_amount::float8, run_by, started_at, completed_at`
+
+// PostgresReconciliationRepository implements ReconciliationRepository
+type PostgresReconciliationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresReconciliationRepository creates a new PostgreSQL reconciliation repository
+func NewPostgresReconciliationRepository(pool *pgxpool.Pool) *PostgresReconciliationRepository {
+	return &PostgresReconciliationRepository{pool: pool}
+}
+
+func (r *PostgresReconciliationRepository) ListFinishedTrips(ctx context.Context, from, to time.Time) ([]domain.TripBillingRecord, error) {
+	// A trip's orders are those on its stops or linked through trip_orders;
+	// its customer is the shipper of the first of them. An order split over
+	// several trips counts its charges toward each of them.
+	rows, err := r.pool.Query(ctx,
+		`WITH finished AS (
+			SELECT t.id, t.trip_number, t.status::text = 'CANCELLED' AS cancelled,
+				COALESCE(t.actual_end_time, t.updated_at) AS finished_at
+			FROM trips t
+			WHERE t.deleted_at IS NULL
+			  AND COALESCE(t.actual_end_time, t.updated_at) >= $1 AND COALESCE(t.actual_end_time, t.updated_at) < $2
+			  AND (t.status::text = 'COMPLETED'
+			   OR (t.status::text = 'CANCELLED' AND EXISTS (SELECT 1 FROM cancellation_charges cc WHERE cc.trip_id = t.id)))
+		 )
+		 SELECT f.id, f.trip_number, f.cancelled, f.finished_at,
+			ARRAY(SELECT ts.order_id FROM trip_stops ts WHERE ts.trip_id = f.id AND ts.order_id IS NOT NULL
+				UNION SELECT tr.order_id FROM trip_orders tr WHERE tr.trip_id = f.id) AS order_ids
+		 FROM finished f
+		 ORDER BY f.finished_at`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query finished trips: %w", err)
+	}
+	defer rows.Close()
+
+	var records []domain.TripBillingRecord
+	byTrip := make(map[uuid.UUID]int)
+	byOrder := make(map[uuid.UUID][]int)
+	var tripIDs, orderIDs []uuid.UUID
+	for rows.Next() {
+		var rec domain.TripBillingRecord
+		if err := rows.Scan(&rec.TripID, &rec.TripNumber, &rec.Cancelled, &rec.FinishedAt, &rec.OrderIDs); err != nil {
+			return nil, fmt.Errorf("scan trip: %w", err)
+		}
+		byTrip[rec.TripID] = len(records)
+		tripIDs = append(tripIDs, rec.TripID)
+		for _, orderID := range rec.OrderIDs {
+			if len(byOrder[orderID]) == 0 {
+				orderIDs = append(orderIDs, orderID)
+			}
+			byOrder[orderID] = append(byOrder[orderID], len(records))
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	if err := r.loadCustomers(ctx, records, byOrder, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadCharges(ctx, records, byOrder, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadInvoiceLines(ctx, records, byTrip, byOrder, tripIDs, orderIDs); err != nil {
+		return nil, err
+	}
+	if err := r.loadAccessorials(ctx, records, byTrip, tripIDs); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *PostgresReconciliationRepository) loadCustomers(ctx context.Context, records []domain.TripBillingRecord, byOrder map[uuid.UUID][]int, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT o.id, s.customer_id FROM orders o JOIN shipments s ON s.id = o.shipment_id
+		 WHERE o.id = ANY($1)`, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query customers: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orderID, customerID uuid.UUID
+		if err := rows.Scan(&orderID, &customerID); err != nil {
+			return fmt.Errorf("scan customer: %w", err)
+		}
+		for _, idx := range byOrder[orderID] {
+			if records[idx].CustomerID == nil {
+				records[idx].CustomerID = &customerID
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) loadCharges(ctx context.Context, records []domain.TripBillingRecord, byOrder map[uuid.UUID][]int, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT order_id, charge_type, COALESCE(amount, 0)::float8 FROM order_charges
+		 WHERE order_id = ANY($1) AND COALESCE(billable_to, 'CUSTOMER') = 'CUSTOMER'
+		 ORDER BY created_at`, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query charges: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c domain.TripCharge
+		if err := rows.Scan(&c.OrderID, &c.ChargeType, &c.Amount); err != nil {
+			return fmt.Errorf("scan charge: %w", err)
+		}
+		for _, idx := range byOrder[c.OrderID] {
+			records[idx].Charges = append(records[idx].Charges, c)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) loadInvoiceLines(ctx context.Context, records []domain.TripBillingRecord, byTrip map[uuid.UUID]int, byOrder map[uuid.UUID][]int, tripIDs, orderIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT l.id, l.invoice_id, i.invoice_number, l.trip_id, l.order_id, l.charge_type,
+			COALESCE(l.amount, 0)::float8
+		 FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id
+		 WHERE i.status <> 'VOID' AND (l.trip_id = ANY($1) OR l.order_id = ANY($2))
+		 ORDER BY i.invoice_date, l.created_at`, tripIDs, orderIDs)
+	if err != nil {
+		return fmt.Errorf("query invoice lines: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l domain.TripInvoiceLine
+		var tripID *uuid.UUID
+		if err := rows.Scan(&l.LineItemID, &l.InvoiceID, &l.InvoiceNumber, &tripID, &l.OrderID, &l.ChargeType, &l.Amount); err != nil {
+			return fmt.Errorf("scan invoice line: %w", err)
+		}
+		// A line naming its trip belongs to that trip alone
+		var targets []int
+		if idx, ok := tripIndex(byTrip, tripID); ok {
+			targets = []int{idx}
+		} else if l.OrderID != nil {
+			targets = byOrder[*l.OrderID]
+		}
+		for _, idx := range targets {
+			records[idx].InvoiceLines = append(records[idx].InvoiceLines, l)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) loadAccessorials(ctx context.Context, records []domain.TripBillingRecord, byTrip map[uuid.UUID]int, tripIDs []uuid.UUID) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT trip_id, 'DETENTION', detention_charge::float8, 'detention at stop ' || sequence
+		 FROM trip_stops WHERE trip_id = ANY($1) AND COALESCE(detention_charge, 0) > 0
+		 UNION ALL
+		 SELECT trip_id, charge_type, amount::float8, 'cancellation fee'
+		 FROM cancellation_charges WHERE trip_id = ANY($1)`, tripIDs)
+	if err != nil {
+		return fmt.Errorf("query accessorials: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tripID uuid.UUID
+		var a domain.CapturedAccessorial
+		if err := rows.Scan(&tripID, &a.ChargeType, &a.Amount, &a.Source); err != nil {
+			return fmt.Errorf("scan accessorial: %w", err)
+		}
+		rec := &records[byTrip[tripID]]
+		rec.Accessorials = append(rec.Accessorials, a)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) SaveRun(ctx context.Context, run *domain.ReconciliationRun, findings []domain.ReconciliationIssue, checkedTripIDs []uuid.UUID) ([]domain.ReconciliationIssue, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The run row goes in first so issues can reference it; its counts are
+	// filled in once the issues are stored
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO billing_reconciliation_runs (id, period_start, period_end, trips_checked, issues_found,
+			unbilled_amount, double_billed_amount, run_by, started_at, completed_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		run.ID, run.PeriodStart, run.PeriodEnd, run.TripsChecked, run.IssuesFound,
+		run.UnbilledAmount, run.DoubleBilledAmount, run.RunBy, run.StartedAt, run.CompletedAt,
+	); err != nil {
+		return nil, fmt.Errorf("insert run: %w", err)
+	}
+
+	var opened []domain.ReconciliationIssue
+	for _, f := range findings {
+		var inserted bool
+		err := tx.QueryRow(ctx,
+			`INSERT INTO billing_reconciliation_issues (id, trip_id, trip_number, customer_id, issue_type,
+				charge_type, amount, detail, invoice_ids, status, first_run_id, last_run_id, detected_at)
+			 SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, 'OPEN', $10, $10, $11
+			 WHERE NOT EXISTS (SELECT 1 FROM billing_reconciliation_issues d
+				WHERE d.trip_id = $2 AND d.issue_type = $5 AND d.charge_type = $6 AND d.status = 'DISMISSED')
+			 ON CONFLICT (trip_id, issue_type, charge_type) WHERE status = 'OPEN' DO UPDATE SET
+				amount = EXCLUDED.amount, detail = EXCLUDED.detail, invoice_ids = EXCLUDED.invoice_ids,
+				customer_id = EXCLUDED.customer_id, last_run_id = EXCLUDED.last_run_id
+			 RETURNING (xmax = 0)`,
+			f.ID, f.TripID, f.TripNumber, f.CustomerID, f.Type, string(f.ChargeType), f.Amount, f.Detail,
+			f.InvoiceIDs, run.ID, f.DetectedAt,
+		).Scan(&inserted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // Dismissed by finance
+		}
+		if err != nil {
+			return nil, fmt.Errorf("upsert issue: %w", err)
+		}
+		if inserted {
+			opened = append(opened, f)
+		}
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE billing_reconciliation_issues
+		 SET status = 'CLEARED', resolved_by = $3, resolved_at = $4,
+			resolution_notes = 'No longer found by reconciliation'
+		 WHERE trip_id = ANY($1) AND status = 'OPEN' AND last_run_id <> $2`,
+		checkedTripIDs, run.ID, run.RunBy, run.CompletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("clear issues: %w", err)
+	}
+
+	run.IssuesOpened = len(opened)
+	run.IssuesCleared = int(tag.RowsAffected())
+	if _, err := tx.Exec(ctx,
+		`UPDATE billing_reconciliation_runs SET issues_opened = $2, issues_cleared = $3 WHERE id = $1`,
+		run.ID, run.IssuesOpened, run.IssuesCleared,
+	); err != nil {
+		return nil, fmt.Errorf("update run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return opened, nil
+}
+
+func (r *PostgresReconciliationRepository) ListRuns(ctx context.Context, limit int) ([]domain.ReconciliationRun, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+reconRunColumns+` FROM billing_reconciliation_runs ORDER BY started_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.ReconciliationRun
+	for rows.Next() {
+		var run domain.ReconciliationRun
+		if err := rows.Scan(&run.ID, &run.PeriodStart, &run.PeriodEnd, &run.TripsChecked, &run.IssuesFound,
+			&run.IssuesOpened, &run.IssuesCleared, &run.UnbilledAmount, &run.DoubleBilledAmount, &run.RunBy,
+			&run.StartedAt, &run.CompletedAt); err != nil {
+			return nil, fmt.Errorf("scan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) GetIssue(ctx context.Context, id uuid.UUID) (*domain.ReconciliationIssue, error) {
+	var issue domain.ReconciliationIssue
+	err := scanReconIssue(r.pool.QueryRow(ctx,
+		`SELECT `+reconIssueColumns+` FROM billing_reconciliation_issues WHERE id = $1`, id), &issue)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (r *PostgresReconciliationRepository) ListIssues(ctx context.Context, filter domain.ReconciliationIssueFilter) ([]domain.ReconciliationIssue, error) {
+	var conditions []string
+	var args []interface{}
+	if len(filter.Status) > 0 {
+		statuses := make([]string, len(filter.Status))
+		for i, s := range filter.Status {
+			statuses[i] = string(s)
+		}
+		args = append(args, statuses)
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+	if filter.Type != nil {
+		args = append(args, string(*filter.Type))
+		conditions = append(conditions, fmt.Sprintf("issue_type = $%d", len(args)))
+	}
+	if filter.TripID != nil {
+		args = append(args, *filter.TripID)
+		conditions = append(conditions, fmt.Sprintf("trip_id = $%d", len(args)))
+	}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+
+	query := `SELECT ` + reconIssueColumns + ` FROM billing_reconciliation_issues`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY detected_at DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query issues: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []domain.ReconciliationIssue
+	for rows.Next() {
+		var issue domain.ReconciliationIssue
+		if err := scanReconIssue(rows, &issue); err != nil {
+			return nil, fmt.Errorf("scan issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+func (r *PostgresReconciliationRepository) CloseIssue(ctx context.Context, issue *domain.ReconciliationIssue) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE billing_reconciliation_issues
+		 SET status = $2, resolved_by = $3, resolved_at = $4, resolution_notes = $5
+		 WHERE id = $1 AND status = 'OPEN'`,
+		issue.ID, issue.Status, issue.ResolvedBy, issue.ResolvedAt, issue.ResolutionNotes,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func tripIndex(byTrip map[uuid.UUID]int, tripID *uuid.UUID) (int, bool) {
+	if tripID == nil {
+		return 0, false
+	}
+	idx, ok := byTrip[*tripID]
+	return idx, ok
+}
+
+func scanReconIssue(row pgx.Row, issue *domain.ReconciliationIssue) error {
+	var chargeType string
+	if err := row.Scan(&issue.ID, &issue.TripID, &issue.TripNumber, &issue.CustomerID, &issue.Type, &chargeType,
+		&issue.Amount, &issue.Detail, &issue.InvoiceIDs, &issue.Status, &issue.FirstRunID, &issue.LastRunID,
+		&issue.DetectedAt, &issue.ResolvedBy, &issue.ResolvedAt, &issue.ResolutionNotes); err != nil {
+		return err
+	}
+	issue.ChargeType = domain.ChargeType(chargeType)
+	return nil
+}
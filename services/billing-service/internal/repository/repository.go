@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// StatementRepository defines customer statement data access. Get and
+// GetCustomerContact return (nil, nil) when nothing matches.
+type StatementRepository interface {
+	// ListInvoicesAsOf returns the customer's invoices dated before cutoff,
+	// with PaidAmount and BalanceDue counting only payments made before cutoff
+	ListInvoicesAsOf(ctx context.Context, customerID uuid.UUID, cutoff time.Time) ([]domain.Invoice, error)
+	// ListUnappliedCredits returns the customer's credits dated before cutoff
+	// that still have an amount left to apply
+	ListUnappliedCredits(ctx context.Context, customerID uuid.UUID, cutoff time.Time) ([]domain.CustomerCredit, error)
+	// SumPayments totals the customer's payments dated in [from, to)
+	SumPayments(ctx context.Context, customerID uuid.UUID, from, to time.Time) (float64, error)
+	// ListCustomersWithActivity returns customers with an open invoice at
+	// cutoff or a payment in [from, cutoff)
+	ListCustomersWithActivity(ctx context.Context, from, cutoff time.Time) ([]uuid.UUID, error)
+	GetCustomerContact(ctx context.Context, customerID uuid.UUID) (*domain.CustomerContact, error)
+
+	// Save stores a statement, replacing one already generated for the period
+	Save(ctx context.Context, stmt *domain.CustomerStatement) error
+	Get(ctx context.Context, customerID uuid.UUID, periodStart time.Time) (*domain.CustomerStatement, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]domain.CustomerStatement, error)
+}
+
+// DisputeRepository defines invoice dispute data access. GetByID, GetInvoice,
+// GetLineItem and GetAttachment return (nil, nil) when nothing matches.
+type DisputeRepository interface {
+	GetInvoice(ctx context.Context, invoiceID uuid.UUID) (*domain.Invoice, error)
+	GetLineItem(ctx context.Context, lineItemID uuid.UUID) (*domain.InvoiceLineItem, error)
+	// ActiveAmountForLineItem totals the open and under review disputes
+	// against a line item
+	ActiveAmountForLineItem(ctx context.Context, lineItemID uuid.UUID) (float64, error)
+
+	// Create stores a new dispute and marks its invoice disputed
+	Create(ctx context.Context, dispute *domain.InvoiceDispute) error
+	// StartReview moves an open dispute to under review
+	StartReview(ctx context.Context, dispute *domain.InvoiceDispute) error
+	// Resolve stores a dispute's resolution, with the credit memo issued for
+	// it when credit is not nil, and releases its amount from the invoice
+	Resolve(ctx context.Context, dispute *domain.InvoiceDispute, credit *domain.CustomerCredit) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.InvoiceDispute, error)
+	List(ctx context.Context, filter domain.DisputeFilter) ([]domain.InvoiceDispute, error)
+
+	AddAttachment(ctx context.Context, attachment *domain.DisputeAttachment) error
+	GetAttachment(ctx context.Context, id uuid.UUID) (*domain.DisputeAttachment, error)
+}
+
+// RateRepository defines contract and accessorial rate data access for
+// expiration alerts and amendments. GetRate, GetAccessorialRate and
+// GetAmendment return (nil, nil) when nothing matches.
+type RateRepository interface {
+	GetRate(ctx context.Context, id uuid.UUID) (*domain.Rate, error)
+	GetAccessorialRate(ctx context.Context, id uuid.UUID) (*domain.AccessorialRate, error)
+	// FindContractRate returns the customer's current contract rate that
+	// matches the move most specifically, or nil when none applies
+	FindContractRate(ctx context.Context, query domain.ContractRateQuery) (*domain.Rate, error)
+	// CurrentAccessorialRates returns the accessorial rate effective at the
+	// given time for each charge type: the customer's own, or the default
+	// where it has none
+	CurrentAccessorialRates(ctx context.Context, customerID uuid.UUID, at time.Time) ([]domain.AccessorialRate, error)
+	// ListExpiring returns active rates not yet superseded that expire in
+	// [from, to), soonest first
+	ListExpiring(ctx context.Context, from, to time.Time) ([]domain.RateExpiration, error)
+	MarkExpiryAlerted(ctx context.Context, kind domain.RateKind, rateID uuid.UUID, at time.Time) error
+
+	// ListRatedCharges returns the charges priced under a rate on orders
+	// created at or after createdFrom
+	ListRatedCharges(ctx context.Context, kind domain.RateKind, rateID uuid.UUID, createdFrom time.Time) ([]domain.RatedCharge, error)
+	// ApplyAmendment stores the new rate version and expires the one it
+	// replaces, moves charges on orders created since the effective date to
+	// the new version, updates the APPLIED adjustments' charges and records
+	// the amendment with its adjustments, all in one transaction
+	ApplyAmendment(ctx context.Context, amendment *domain.RateAmendment) error
+	GetAmendment(ctx context.Context, id uuid.UUID) (*domain.RateAmendment, error)
+	// ListAmendments returns the amendments that replaced or created the rate
+	ListAmendments(ctx context.Context, rateID uuid.UUID) ([]domain.RateAmendment, error)
+}
+
+// ReconciliationRepository defines trip-to-billing reconciliation data
+// access. GetIssue returns (nil, nil) when nothing matches.
+type ReconciliationRepository interface {
+	// ListFinishedTrips returns the trips completed in [from, to), and those
+	// cancelled in it with a cancellation fee, with their customer charges,
+	// the non-void invoice lines billing them and the accessorials dispatch
+	// captured on them
+	ListFinishedTrips(ctx context.Context, from, to time.Time) ([]domain.TripBillingRecord, error)
+
+	// SaveRun stores a completed run and its findings in one transaction.
+	// A finding already open is refreshed, one finance dismissed is skipped,
+	// and the rest are opened and returned.
+	// Open issues on the checked trips that were not found again are
+	// cleared. The run's opened and cleared counts are set.
+	SaveRun(ctx context.Context, run *domain.ReconciliationRun, findings []domain.ReconciliationIssue, checkedTripIDs []uuid.UUID) ([]domain.ReconciliationIssue, error)
+	ListRuns(ctx context.Context, limit int) ([]domain.ReconciliationRun, error)
+
+	GetIssue(ctx context.Context, id uuid.UUID) (*domain.ReconciliationIssue, error)
+	ListIssues(ctx context.Context, filter domain.ReconciliationIssueFilter) ([]domain.ReconciliationIssue, error)
+	// CloseIssue stores an open issue's resolution; it returns false when
+	// the issue is no longer open
+	CloseIssue(ctx context.Context, issue *domain.ReconciliationIssue) (bool, error)
+}
+
+// SplitBillingRepository defines access to containers delivered across
+// several stops, from the delivery records dispatch keeps per stop
+type SplitBillingRepository interface {
+	// ListSplitDeliveryOrders returns the orders with delivery records on the
+	// trip, each with its stops in order and its customer charges. It returns
+	// nil when there is no such trip.
+	ListSplitDeliveryOrders(ctx context.Context, tripID uuid.UUID) ([]domain.SplitDeliveryOrder, error)
+}
+
+// InvoiceRepository defines invoice generation and invoice data access.
+// GetInvoice returns (nil, nil) when nothing matches.
+type InvoiceRepository interface {
+	// ListBillableOrders returns the completed, unbilled orders last updated
+	// before completedBefore that are on no invoice that was not voided, with
+	// their completed trips, customer charges, stop detention, unbilled
+	// chassis usage and container charges. A customer ID narrows it to that
+	// customer's orders.
+	ListBillableOrders(ctx context.Context, completedBefore time.Time, customerID *uuid.UUID) ([]domain.BillableOrder, error)
+	// CreateInvoice stores a generated invoice in one transaction: the
+	// charges priced for it, the invoice numbered from the invoice sequence
+	// and its lines. The orders are marked billed and the chassis usage and
+	// container charges it bills point at it. It returns
+	// ErrOrdersAlreadyBilled when another run billed one of the orders first.
+	CreateInvoice(ctx context.Context, invoice *domain.Invoice, charges []domain.OrderCharge, sources domain.InvoiceSources) error
+	SetDocumentKey(ctx context.Context, invoiceID uuid.UUID, key string) error
+
+	// GetInvoice returns an invoice with its line items
+	GetInvoice(ctx context.Context, id uuid.UUID) (*domain.Invoice, error)
+	// ListInvoices returns invoices newest first, without line items
+	ListInvoices(ctx context.Context, filter domain.InvoiceFilter) ([]domain.Invoice, error)
+}
+
+// RateTableRepository defines data access for the rate tables customers are
+// priced from: zones, contracts with their lane rates, fuel surcharge
+// schedules and diesel prices, and accessorial price lists. Getters return
+// (nil, nil) when nothing matches.
+type RateTableRepository interface {
+	CreateZone(ctx context.Context, zone *domain.RateZone) error
+	UpdateZone(ctx context.Context, zone *domain.RateZone) error
+	GetZone(ctx context.Context, id uuid.UUID) (*domain.RateZone, error)
+	ListZones(ctx context.Context) ([]domain.RateZone, error)
+	// ZoneCodesExist returns which of the codes are active zones
+	ZoneCodesExist(ctx context.Context, codes []string) (map[string]bool, error)
+
+	// CreateContract stores a contract and its lane rates in one transaction
+	CreateContract(ctx context.Context, contract *domain.RateContract) error
+	// GetContract returns a contract with the current versions of its lane rates
+	GetContract(ctx context.Context, id uuid.UUID) (*domain.RateContract, error)
+	// ListContracts returns contracts newest first, for one customer when set
+	ListContracts(ctx context.Context, customerID *uuid.UUID) ([]domain.RateContract, error)
+
+	// CreateFuelSchedule stores a schedule and its tiers in one transaction
+	CreateFuelSchedule(ctx context.Context, schedule *domain.FuelSurchargeSchedule) error
+	// GetFuelSchedule returns a schedule with its tiers
+	GetFuelSchedule(ctx context.Context, id uuid.UUID) (*domain.FuelSurchargeSchedule, error)
+	// ListFuelSchedules returns schedules with their tiers, for one customer
+	// and the defaults when set
+	ListFuelSchedules(ctx context.Context, customerID *uuid.UUID) ([]domain.FuelSurchargeSchedule, error)
+
+	// SaveFuelPrice stores a diesel price, replacing the region's price for
+	// the same date
+	SaveFuelPrice(ctx context.Context, price *domain.FuelPrice) error
+	// CurrentFuelPrice returns the region's latest price effective at the given time
+	CurrentFuelPrice(ctx context.Context, region string, at time.Time) (*domain.FuelPrice, error)
+	// ListFuelPrices returns a region's prices newest first
+	ListFuelPrices(ctx context.Context, region string, limit int) ([]domain.FuelPrice, error)
+
+	CreateAccessorialRate(ctx context.Context, rate *domain.AccessorialRate) error
+}
+
+// TerminalFeeRepository defines data access for terminal fee schedules,
+// customers' pass-through settings and the fees attached to orders. Getters
+// return (nil, nil) when nothing matches.
+type TerminalFeeRepository interface {
+	// CreateFee stores a fee, ending the version it replaces - the same
+	// terminal, type and size still open at the new fee's effective date
+	CreateFee(ctx context.Context, fee *domain.TerminalFee) error
+	UpdateFee(ctx context.Context, fee *domain.TerminalFee) error
+	GetFee(ctx context.Context, id uuid.UUID) (*domain.TerminalFee, error)
+	// ListFees returns fees by terminal, type and newest version first, for
+	// one terminal when set
+	ListFees(ctx context.Context, terminalID *uuid.UUID) ([]domain.TerminalFee, error)
+	// FindFee returns the fee in effect for the query, preferring one kept
+	// for the container size over the terminal's fee for any size
+	FindFee(ctx context.Context, query domain.TerminalFeeQuery) (*domain.TerminalFee, error)
+
+	// SavePassThrough stores a customer's setting for a fee type, replacing
+	// the one it had
+	SavePassThrough(ctx context.Context, setting *domain.FeePassThrough) error
+	GetPassThrough(ctx context.Context, customerID uuid.UUID, feeType domain.TerminalFeeType) (*domain.FeePassThrough, error)
+	ListPassThroughs(ctx context.Context, customerID uuid.UUID) ([]domain.FeePassThrough, error)
+
+	// GetFeeOrder returns an order with its customer and container size
+	GetFeeOrder(ctx context.Context, orderID uuid.UUID) (*domain.TerminalFeeOrder, error)
+	// AttachCharge stores a fee charge and, when one is given, the order
+	// charge that bills it, in one transaction. It returns false without
+	// storing anything when the stop already carries the fee type.
+	AttachCharge(ctx context.Context, charge *domain.TerminalFeeCharge, orderCharge *domain.OrderCharge) (bool, error)
+	// ListCharges returns an order's fee charges oldest first
+	ListCharges(ctx context.Context, orderID uuid.UUID) ([]domain.TerminalFeeCharge, error)
+}
+
+// TaxReportingRepository defines data access for owner-operator payee
+// entities and the paid settlements reported on their 1099-NECs. Tax IDs
+// are returned decrypted.
+type TaxReportingRepository interface {
+	// SavePayeeEntity stores a payee, replacing the one with its ID
+	SavePayeeEntity(ctx context.Context, payee *domain.PayeeEntity) error
+	// GetPayeeEntities returns the payees with the given IDs, keyed by ID
+	GetPayeeEntities(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.PayeeEntity, error)
+	// ListPaidSettlements returns the settlements paid in the tax year
+	ListPaidSettlements(ctx context.Context, taxYear int) ([]domain.DriverSettlement, error)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// SPLIT DELIVERY BILLING
+// ============================================================================
+
+// PostgresSplitBillingRepository implements SplitBillingRepository
+type PostgresSplitBillingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSplitBillingRepository creates a new PostgreSQL split billing repository
+func NewPostgresSplitBillingRepository(pool *pgxpool.Pool) *PostgresSplitBillingRepository {
+	return &PostgresSplitBillingRepository{pool: pool}
+}
+
+func (r *PostgresSplitBillingRepository) ListSplitDeliveryOrders(ctx context.Context, tripID uuid.UUID) ([]domain.SplitDeliveryOrder, error) {
+	var tripNumber string
+	err := r.pool.QueryRow(ctx, `SELECT trip_number FROM trips WHERE id = $1 AND deleted_at IS NULL`, tripID).Scan(&tripNumber)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query trip: %w", err)
+	}
+
+	// A record without an order belongs to the order on its stop
+	rows, err := r.pool.Query(ctx,
+		`SELECT COALESCE(sd.order_id, ts.order_id), sd.stop_id, ts.sequence,
+			COALESCE(sd.container_number, ''), COALESCE(sd.consignee_name, ''),
+			sd.pieces, sd.weight_lbs, sd.pod_received_at IS NOT NULL
+		 FROM stop_deliveries sd JOIN trip_stops ts ON ts.id = sd.stop_id
+		 WHERE sd.trip_id = $1 AND COALESCE(sd.order_id, ts.order_id) IS NOT NULL
+		 ORDER BY ts.sequence, sd.created_at`, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("query stop deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []domain.SplitDeliveryOrder{}
+	byOrder := make(map[uuid.UUID]int)
+	var orderIDs []uuid.UUID
+	for rows.Next() {
+		var orderID, stopID uuid.UUID
+		var sequence, pieces, weight int
+		var containerNumber, consignee string
+		var signed bool
+		if err := rows.Scan(&orderID, &stopID, &sequence, &containerNumber, &consignee, &pieces, &weight, &signed); err != nil {
+			return nil, fmt.Errorf("scan stop delivery: %w", err)
+		}
+		idx, ok := byOrder[orderID]
+		if !ok {
+			idx = len(orders)
+			byOrder[orderID] = idx
+			orderIDs = append(orderIDs, orderID)
+			orders = append(orders, domain.SplitDeliveryOrder{
+				OrderID:         orderID,
+				TripID:          tripID,
+				TripNumber:      tripNumber,
+				ContainerNumber: containerNumber,
+			})
+		}
+		order := &orders[idx]
+		n := len(order.Stops)
+		if n == 0 || order.Stops[n-1].StopID != stopID {
+			order.Stops = append(order.Stops, domain.DeliveryStopShare{StopID: stopID, Sequence: sequence, PODReceived: true})
+			n++
+		}
+		stop := &order.Stops[n-1]
+		stop.Pieces += pieces
+		stop.WeightLbs += weight
+		stop.PODReceived = stop.PODReceived && signed
+		if stop.ConsigneeName == "" {
+			stop.ConsigneeName = consignee
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	chargeRows, err := r.pool.Query(ctx,
+		`SELECT order_id, charge_type, COALESCE(amount, 0)::float8 FROM order_charges
+		 WHERE order_id = ANY($1) AND COALESCE(billable_to, 'CUSTOMER') = 'CUSTOMER'
+		 ORDER BY created_at`, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query charges: %w", err)
+	}
+	defer chargeRows.Close()
+	for chargeRows.Next() {
+		var c domain.TripCharge
+		if err := chargeRows.Scan(&c.OrderID, &c.ChargeType, &c.Amount); err != nil {
+			return nil, fmt.Errorf("scan charge: %w", err)
+		}
+		orders[byOrder[c.OrderID]].Charges = append(orders[byOrder[c.OrderID]].Charges, c)
+	}
+	return orders, chargeRows.Err()
+}
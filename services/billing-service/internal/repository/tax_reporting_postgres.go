@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/shared/pkg/crypto"
+)
+
+// ============================================================================
+// OWNER-OPERATOR TAX REPORTING
+// ============================================================================
+
+const payeeEntityColumns = `id, driver_id, classification, legal_name, COALESCE(business_name, ''), tax_id_type,
+	COALESCE(tax_id, ''), COALESCE(address_line1, ''), COALESCE(address_line2, ''), COALESCE(city, ''),
+	COALESCE(state, ''), COALESCE(zip, ''), backup_withholding, created_at, updated_at`
+
+// PostgresTaxReportingRepository implements TaxReportingRepository
+type PostgresTaxReportingRepository struct {
+	pool   *pgxpool.Pool
+	taxIDs *crypto.FieldEncryptor // nil stores tax IDs in plaintext
+}
+
+// NewPostgresTaxReportingRepository creates a new PostgreSQL tax reporting
+// repository. Payee tax IDs are envelope-encrypted with taxIDs when it is
+// set; values stored in plaintext stay readable either way.
+func NewPostgresTaxReportingRepository(pool *pgxpool.Pool, taxIDs *crypto.FieldEncryptor) *PostgresTaxReportingRepository {
+	return &PostgresTaxReportingRepository{pool: pool, taxIDs: taxIDs}
+}
+
+func (r *PostgresTaxReportingRepository) SavePayeeEntity(ctx context.Context, payee *domain.PayeeEntity) error {
+	taxID := payee.TaxID
+	if r.taxIDs != nil {
+		sealed, err := r.taxIDs.Encrypt(ctx, taxID)
+		if err != nil {
+			return fmt.Errorf("encrypt payee tax id: %w", err)
+		}
+		taxID = sealed
+	}
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO payee_entities (id, driver_id, classification, legal_name, business_name, tax_id_type, tax_id,
+			address_line1, address_line2, city, state, zip, backup_withholding, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''),
+			NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), $13, $14, $15)
+		 ON CONFLICT (id) DO UPDATE SET
+			driver_id = EXCLUDED.driver_id, classification = EXCLUDED.classification,
+			legal_name = EXCLUDED.legal_name, business_name = EXCLUDED.business_name,
+			tax_id_type = EXCLUDED.tax_id_type, tax_id = EXCLUDED.tax_id,
+			address_line1 = EXCLUDED.address_line1, address_line2 = EXCLUDED.address_line2,
+			city = EXCLUDED.city, state = EXCLUDED.state, zip = EXCLUDED.zip,
+			backup_withholding = EXCLUDED.backup_withholding, updated_at = EXCLUDED.updated_at`,
+		payee.ID, payee.DriverID, payee.Classification, payee.LegalName, payee.BusinessName, payee.TaxIDType, taxID,
+		payee.AddressLine1, payee.AddressLine2, payee.City, payee.State, payee.Zip, payee.BackupWithholding,
+		payee.CreatedAt, payee.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save payee entity: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTaxReportingRepository) GetPayeeEntities(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.PayeeEntity, error) {
+	payees := make(map[uuid.UUID]*domain.PayeeEntity)
+	if len(ids) == 0 {
+		return payees, nil
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+payeeEntityColumns+` FROM payee_entities WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("query payee entities: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p domain.PayeeEntity
+		if err := rows.Scan(&p.ID, &p.DriverID, &p.Classification, &p.LegalName, &p.BusinessName, &p.TaxIDType,
+			&p.TaxID, &p.AddressLine1, &p.AddressLine2, &p.City, &p.State, &p.Zip, &p.BackupWithholding,
+			&p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan payee entity: %w", err)
+		}
+		if r.taxIDs != nil {
+			taxID, err := r.taxIDs.Decrypt(ctx, p.TaxID)
+			if err != nil {
+				return nil, fmt.Errorf("decrypt payee tax id: %w", err)
+			}
+			p.TaxID = taxID
+		}
+		payees[p.ID] = &p
+	}
+	return payees, rows.Err()
+}
+
+func (r *PostgresTaxReportingRepository) ListPaidSettlements(ctx context.Context, taxYear int) ([]domain.DriverSettlement, error) {
+	from := time.Date(taxYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, driver_id, settlement_number, classification, payee_entity_id, period_start, period_end, status,
+			COALESCE(gross_earnings, 0)::float8, COALESCE(total_miles, 0)::float8, COALESCE(total_trips, 0),
+			COALESCE(total_deductions, 0)::float8, federal_tax_withheld::float8, COALESCE(net_pay, 0)::float8, paid_date
+		 FROM driver_settlements
+		 WHERE status = 'paid' AND paid_date >= $1 AND paid_date < $2
+		 ORDER BY paid_date`,
+		from, from.AddDate(1, 0, 0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query paid settlements: %w", err)
+	}
+	defer rows.Close()
+
+	var settlements []domain.DriverSettlement
+	for rows.Next() {
+		var st domain.DriverSettlement
+		if err := rows.Scan(&st.ID, &st.DriverID, &st.SettlementNumber, &st.Classification, &st.PayeeEntityID,
+			&st.PeriodStart, &st.PeriodEnd, &st.Status, &st.GrossEarnings, &st.TotalMiles, &st.TotalTrips,
+			&st.TotalDeductions, &st.FederalTaxWithheld, &st.NetPay, &st.PaidDate); err != nil {
+			return nil, fmt.Errorf("scan settlement: %w", err)
+		}
+		settlements = append(settlements, st)
+	}
+	return settlements, rows.Err()
+}
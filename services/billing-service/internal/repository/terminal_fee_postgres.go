@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+)
+
+// ============================================================================
+// TERMINAL FEES
+// ============================================================================
+
+const terminalFeeColumns = `f.id, f.terminal_id, COALESCE(l.name, ''), f.fee_type, f.description,
+	f.container_size, f.amount::float8, f.effective_date, f.end_date, f.created_by, f.created_at, f.updated_at`
+
+const feePassThroughColumns = `customer_id, fee_type, mode, markup_pct::float8, updated_by, updated_at`
+
+const terminalFeeChargeColumns = `id, fee_id, fee_type, terminal_id, order_id, trip_id, stop_id,
+	container_number, cost_amount::float8, billed_amount::float8, mode, order_charge_id, occurred_at, created_at`
+
+// PostgresTerminalFeeRepository implements TerminalFeeRepository
+type PostgresTerminalFeeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresTerminalFeeRepository creates a new PostgreSQL terminal fee repository
+func NewPostgresTerminalFeeRepository(pool *pgxpool.Pool) *PostgresTerminalFeeRepository {
+	return &PostgresTerminalFeeRepository{pool: pool}
+}
+
+func (r *PostgresTerminalFeeRepository) CreateFee(ctx context.Context, f *domain.TerminalFee) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE terminal_fees SET end_date = $4, updated_at = $5
+		 WHERE terminal_id = $1 AND fee_type = $2 AND container_size = $3
+			AND effective_date < $4 AND (end_date IS NULL OR end_date > $4)`,
+		f.TerminalID, f.FeeType, f.ContainerSize, f.EffectiveDate, f.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("end previous terminal fee: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO terminal_fees (id, terminal_id, fee_type, description, container_size, amount,
+			effective_date, end_date, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		f.ID, f.TerminalID, f.FeeType, f.Description, f.ContainerSize, f.Amount,
+		f.EffectiveDate, f.EndDate, f.CreatedBy, f.CreatedAt, f.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("insert terminal fee: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresTerminalFeeRepository) UpdateFee(ctx context.Context, f *domain.TerminalFee) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE terminal_fees SET description = $2, amount = $3, end_date = $4, updated_at = $5 WHERE id = $1`,
+		f.ID, f.Description, f.Amount, f.EndDate, f.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update terminal fee: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTerminalFeeRepository) GetFee(ctx context.Context, id uuid.UUID) (*domain.TerminalFee, error) {
+	fee, err := scanTerminalFee(r.pool.QueryRow(ctx,
+		`SELECT `+terminalFeeColumns+` FROM terminal_fees f LEFT JOIN locations l ON l.id = f.terminal_id
+		 WHERE f.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("get terminal fee: %w", err)
+	}
+	return fee, nil
+}
+
+func (r *PostgresTerminalFeeRepository) ListFees(ctx context.Context, terminalID *uuid.UUID) ([]domain.TerminalFee, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+terminalFeeColumns+` FROM terminal_fees f LEFT JOIN locations l ON l.id = f.terminal_id
+		 WHERE $1::uuid IS NULL OR f.terminal_id = $1
+		 ORDER BY l.name, f.terminal_id, f.fee_type, f.container_size, f.effective_date DESC`, terminalID)
+	if err != nil {
+		return nil, fmt.Errorf("query terminal fees: %w", err)
+	}
+	defer rows.Close()
+
+	fees := []domain.TerminalFee{}
+	for rows.Next() {
+		fee, err := scanTerminalFee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan terminal fee: %w", err)
+		}
+		fees = append(fees, *fee)
+	}
+	return fees, rows.Err()
+}
+
+func (r *PostgresTerminalFeeRepository) FindFee(ctx context.Context, q domain.TerminalFeeQuery) (*domain.TerminalFee, error) {
+	fee, err := scanTerminalFee(r.pool.QueryRow(ctx,
+		`SELECT `+terminalFeeColumns+` FROM terminal_fees f LEFT JOIN locations l ON l.id = f.terminal_id
+		 WHERE f.terminal_id = $1 AND f.fee_type = $2 AND f.container_size IN ($3, '')
+			AND f.effective_date <= $4 AND (f.end_date IS NULL OR f.end_date > $4)
+		 ORDER BY f.container_size = '', f.effective_date DESC LIMIT 1`,
+		q.TerminalID, q.FeeType, q.ContainerSize, q.At))
+	if err != nil {
+		return nil, fmt.Errorf("find terminal fee: %w", err)
+	}
+	return fee, nil
+}
+
+func (r *PostgresTerminalFeeRepository) SavePassThrough(ctx context.Context, p *domain.FeePassThrough) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO customer_fee_pass_through (customer_id, fee_type, mode, markup_pct, updated_by, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (customer_id, fee_type) DO UPDATE SET
+			mode = EXCLUDED.mode, markup_pct = EXCLUDED.markup_pct,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		p.CustomerID, p.FeeType, p.Mode, p.MarkupPct, p.UpdatedBy, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("save fee pass-through: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTerminalFeeRepository) GetPassThrough(ctx context.Context, customerID uuid.UUID, feeType domain.TerminalFeeType) (*domain.FeePassThrough, error) {
+	var p domain.FeePassThrough
+	err := r.pool.QueryRow(ctx,
+		`SELECT `+feePassThroughColumns+` FROM customer_fee_pass_through WHERE customer_id = $1 AND fee_type = $2`,
+		customerID, feeType,
+	).Scan(&p.CustomerID, &p.FeeType, &p.Mode, &p.MarkupPct, &p.UpdatedBy, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get fee pass-through: %w", err)
+	}
+	return &p, nil
+}
+
+func (r *PostgresTerminalFeeRepository) ListPassThroughs(ctx context.Context, customerID uuid.UUID) ([]domain.FeePassThrough, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+feePassThroughColumns+` FROM customer_fee_pass_through WHERE customer_id = $1 ORDER BY fee_type`,
+		customerID)
+	if err != nil {
+		return nil, fmt.Errorf("query fee pass-through: %w", err)
+	}
+	defer rows.Close()
+
+	settings := []domain.FeePassThrough{}
+	for rows.Next() {
+		var p domain.FeePassThrough
+		if err := rows.Scan(&p.CustomerID, &p.FeeType, &p.Mode, &p.MarkupPct, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan fee pass-through: %w", err)
+		}
+		settings = append(settings, p)
+	}
+	return settings, rows.Err()
+}
+
+func (r *PostgresTerminalFeeRepository) GetFeeOrder(ctx context.Context, orderID uuid.UUID) (*domain.TerminalFeeOrder, error) {
+	o := domain.TerminalFeeOrder{OrderID: orderID}
+	err := r.pool.QueryRow(ctx,
+		`SELECT s.customer_id, COALESCE(ct.size::text, ''),
+			EXISTS (SELECT 1 FROM invoice_line_items li JOIN invoices i ON i.id = li.invoice_id
+				WHERE li.order_id = o.id AND i.status <> 'VOID')
+		 FROM orders o
+		 JOIN shipments s ON s.id = o.shipment_id
+		 LEFT JOIN containers ct ON ct.id = o.container_id
+		 WHERE o.id = $1 AND o.deleted_at IS NULL`, orderID,
+	).Scan(&o.CustomerID, &o.ContainerSize, &o.Invoiced)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get fee order: %w", err)
+	}
+	return &o, nil
+}
+
+func (r *PostgresTerminalFeeRepository) AttachCharge(ctx context.Context, c *domain.TerminalFeeCharge, oc *domain.OrderCharge) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO terminal_fee_charges (id, fee_id, fee_type, terminal_id, order_id, trip_id, stop_id,
+			container_number, cost_amount, billed_amount, mode, occurred_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 ON CONFLICT (stop_id, fee_type) DO NOTHING`,
+		c.ID, c.FeeID, c.FeeType, c.TerminalID, c.OrderID, c.TripID, c.StopID,
+		c.ContainerNumber, c.CostAmount, c.BilledAmount, c.Mode, c.OccurredAt, c.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("insert terminal fee charge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if oc != nil {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO order_charges (id, order_id, charge_type, description, quantity, unit_rate, amount,
+				billable_to, auto_calculated, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, 'CUSTOMER', TRUE, $8)`,
+			oc.ID, oc.OrderID, oc.ChargeType, oc.Description, oc.Quantity, oc.UnitRate, oc.Amount, c.CreatedAt,
+		); err != nil {
+			return false, fmt.Errorf("insert order charge: %w", err)
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE terminal_fee_charges SET order_charge_id = $2 WHERE id = $1`, c.ID, oc.ID,
+		); err != nil {
+			return false, fmt.Errorf("link order charge: %w", err)
+		}
+		c.OrderChargeID = &oc.ID
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (r *PostgresTerminalFeeRepository) ListCharges(ctx context.Context, orderID uuid.UUID) ([]domain.TerminalFeeCharge, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+terminalFeeChargeColumns+` FROM terminal_fee_charges WHERE order_id = $1 ORDER BY occurred_at`,
+		orderID)
+	if err != nil {
+		return nil, fmt.Errorf("query terminal fee charges: %w", err)
+	}
+	defer rows.Close()
+
+	charges := []domain.TerminalFeeCharge{}
+	for rows.Next() {
+		var c domain.TerminalFeeCharge
+		if err := rows.Scan(&c.ID, &c.FeeID, &c.FeeType, &c.TerminalID, &c.OrderID, &c.TripID, &c.StopID,
+			&c.ContainerNumber, &c.CostAmount, &c.BilledAmount, &c.Mode, &c.OrderChargeID, &c.OccurredAt,
+			&c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan terminal fee charge: %w", err)
+		}
+		charges = append(charges, c)
+	}
+	return charges, rows.Err()
+}
+
+func scanTerminalFee(row pgx.Row) (*domain.TerminalFee, error) {
+	var f domain.TerminalFee
+	err := row.Scan(&f.ID, &f.TerminalID, &f.TerminalName, &f.FeeType, &f.Description,
+		&f.ContainerSize, &f.Amount, &f.EffectiveDate, &f.EndDate, &f.CreatedBy, &f.CreatedAt, &f.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
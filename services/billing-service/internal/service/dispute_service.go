@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+// DisputeService manages disputes customers raise against invoice charges.
+// Customers open them in the portal and billing staff on their behalf; staff
+// review them and resolve each by issuing a credit memo or upholding the
+// charge. Methods that take a portal customer ID limit a portal user to their
+// own invoices; staff pass nil.
+type DisputeService struct {
+	disputeRepo repository.DisputeRepository
+	store       storage.Store
+	logger      *logger.Logger
+}
+
+// NewDisputeService creates a new invoice dispute service
+func NewDisputeService(disputeRepo repository.DisputeRepository, store storage.Store, log *logger.Logger) *DisputeService {
+	return &DisputeService{
+		disputeRepo: disputeRepo,
+		store:       store,
+		logger:      log,
+	}
+}
+
+// OpenDisputeInput contains input for opening a dispute. Amount defaults to
+// the whole line item.
+type OpenDisputeInput struct {
+	InvoiceID        uuid.UUID                `json:"-"`
+	LineItemID       uuid.UUID                `json:"line_item_id"`
+	Amount           float64                  `json:"amount"`
+	ReasonCode       domain.DisputeReasonCode `json:"reason_code"`
+	Reason           string                   `json:"reason"`
+	PortalCustomerID *uuid.UUID               `json:"-"`
+	OpenedBy         string                   `json:"-"`
+}
+
+// ResolveDisputeInput contains input for resolving a dispute. CreditAmount
+// applies to a CREDITED outcome and defaults to the disputed amount.
+type ResolveDisputeInput struct {
+	Outcome      domain.DisputeStatus `json:"outcome"`
+	CreditAmount float64              `json:"credit_amount"`
+	Notes        string               `json:"notes"`
+	ResolvedBy   string               `json:"-"`
+}
+
+// OpenDispute opens a dispute against a line item. The disputed amount is
+// held out of the invoice's aging until the dispute is resolved.
+func (s *DisputeService) OpenDispute(ctx context.Context, input OpenDisputeInput) (*domain.InvoiceDispute, error) {
+	if !input.ReasonCode.IsValid() {
+		return nil, apperrors.ValidationError("invalid reason code", "reason_code", input.ReasonCode)
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, apperrors.ValidationError("reason is required", "reason", input.Reason)
+	}
+	if input.Amount < 0 {
+		return nil, apperrors.ValidationError("amount cannot be negative", "amount", input.Amount)
+	}
+
+	invoice, err := s.invoice(ctx, input.InvoiceID, input.PortalCustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == domain.InvoiceStatusDraft || invoice.Status == domain.InvoiceStatusVoid {
+		return nil, apperrors.InvalidStateError(string(invoice.Status), "issued invoice")
+	}
+
+	item, err := s.disputeRepo.GetLineItem(ctx, input.LineItemID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get line item", err)
+	}
+	if item == nil || item.InvoiceID != invoice.ID {
+		return nil, apperrors.NotFoundError("invoice line item", input.LineItemID.String())
+	}
+
+	active, err := s.disputeRepo.ActiveAmountForLineItem(ctx, item.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("sum line item disputes", err)
+	}
+	undisputed := roundCents(item.Amount - active)
+	amount := roundCents(input.Amount)
+	if amount == 0 {
+		amount = undisputed
+	}
+	if amount <= 0 {
+		return nil, apperrors.ConflictError("line item is already disputed in full")
+	}
+	if amount > undisputed {
+		return nil, apperrors.ValidationError("amount exceeds the undisputed line item amount", "amount", input.Amount).
+			WithDetail("undisputed", undisputed)
+	}
+
+	via := domain.DisputeChannelStaff
+	if input.PortalCustomerID != nil {
+		via = domain.DisputeChannelPortal
+	}
+	now := time.Now()
+	dispute := &domain.InvoiceDispute{
+		ID:          uuid.New(),
+		InvoiceID:   invoice.ID,
+		LineItemID:  item.ID,
+		CustomerID:  invoice.CustomerID,
+		Amount:      amount,
+		ReasonCode:  input.ReasonCode,
+		Reason:      reason,
+		Status:      domain.DisputeStatusOpen,
+		OpenedVia:   via,
+		OpenedBy:    input.OpenedBy,
+		Attachments: []domain.DisputeAttachment{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	dispute.DisputeNumber = domain.DisputeNumber(dispute.ID, now)
+
+	if err := s.disputeRepo.Create(ctx, dispute); err != nil {
+		return nil, apperrors.DatabaseError("create dispute", err)
+	}
+
+	s.logger.Infow("Invoice dispute opened",
+		"dispute_number", dispute.DisputeNumber,
+		"invoice_number", invoice.InvoiceNumber,
+		"line_item_id", item.ID,
+		"amount", amount,
+		"reason_code", dispute.ReasonCode,
+		"opened_via", via,
+	)
+	return dispute, nil
+}
+
+// StartReview moves an open dispute to under review by a staff member
+func (s *DisputeService) StartReview(ctx context.Context, disputeID uuid.UUID, reviewedBy string) (*domain.InvoiceDispute, error) {
+	dispute, err := s.GetDispute(ctx, disputeID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != domain.DisputeStatusOpen {
+		return nil, apperrors.InvalidStateError(string(dispute.Status), string(domain.DisputeStatusOpen))
+	}
+
+	now := time.Now()
+	dispute.Status = domain.DisputeStatusUnderReview
+	dispute.ReviewedBy = reviewedBy
+	dispute.ReviewStartedAt = &now
+	dispute.UpdatedAt = now
+	if err := s.disputeRepo.StartReview(ctx, dispute); err != nil {
+		return nil, apperrors.DatabaseError("start dispute review", err)
+	}
+
+	s.logger.Infow("Invoice dispute under review", "dispute_number", dispute.DisputeNumber, "reviewed_by", reviewedBy)
+	return dispute, nil
+}
+
+// ResolveDispute closes a dispute. CREDITED issues a credit memo against the
+// invoice for the credited amount; UPHELD leaves the charge standing. Either
+// way the disputed amount goes back into the invoice's aging, less any credit.
+func (s *DisputeService) ResolveDispute(ctx context.Context, disputeID uuid.UUID, input ResolveDisputeInput) (*domain.InvoiceDispute, error) {
+	if input.Outcome != domain.DisputeStatusCredited && input.Outcome != domain.DisputeStatusUpheld {
+		return nil, apperrors.ValidationError("outcome must be CREDITED or UPHELD", "outcome", input.Outcome)
+	}
+	notes := strings.TrimSpace(input.Notes)
+	if notes == "" {
+		return nil, apperrors.ValidationError("resolution notes are required", "notes", input.Notes)
+	}
+
+	dispute, err := s.GetDispute(ctx, disputeID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.Status.IsActive() {
+		return nil, apperrors.InvalidStateError(string(dispute.Status), "OPEN or UNDER_REVIEW")
+	}
+
+	now := time.Now()
+	var credit *domain.CustomerCredit
+	if input.Outcome == domain.DisputeStatusCredited {
+		amount := roundCents(input.CreditAmount)
+		if amount == 0 {
+			amount = dispute.Amount
+		}
+		if amount < 0 || amount > dispute.Amount {
+			return nil, apperrors.ValidationError("credit amount must be between 0 and the disputed amount", "credit_amount", input.CreditAmount).
+				WithDetail("disputed", dispute.Amount)
+		}
+		invoiceID := dispute.InvoiceID
+		credit = &domain.CustomerCredit{
+			ID:           uuid.New(),
+			CustomerID:   dispute.CustomerID,
+			CreditNumber: domain.CreditMemoNumber(dispute.DisputeNumber),
+			CreditDate:   now,
+			Amount:       amount,
+			InvoiceID:    &invoiceID,
+			Reason:       "Dispute " + dispute.DisputeNumber + ": " + notes,
+			CreatedAt:    now,
+			CreatedBy:    input.ResolvedBy,
+		}
+		dispute.CreditedAmount = amount
+		dispute.CreditID = &credit.ID
+	}
+
+	dispute.Status = input.Outcome
+	dispute.ResolutionNotes = notes
+	dispute.ResolvedBy = input.ResolvedBy
+	dispute.ResolvedAt = &now
+	dispute.UpdatedAt = now
+	if err := s.disputeRepo.Resolve(ctx, dispute, credit); err != nil {
+		return nil, apperrors.DatabaseError("resolve dispute", err)
+	}
+
+	s.logger.Infow("Invoice dispute resolved",
+		"dispute_number", dispute.DisputeNumber,
+		"outcome", dispute.Status,
+		"disputed", dispute.Amount,
+		"credited", dispute.CreditedAmount,
+		"resolved_by", dispute.ResolvedBy,
+	)
+	return dispute, nil
+}
+
+// GetDispute returns a dispute with its attachments
+func (s *DisputeService) GetDispute(ctx context.Context, disputeID uuid.UUID, portalCustomerID *uuid.UUID) (*domain.InvoiceDispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get dispute", err)
+	}
+	if dispute == nil || (portalCustomerID != nil && dispute.CustomerID != *portalCustomerID) {
+		return nil, apperrors.NotFoundError("dispute", disputeID.String())
+	}
+	return dispute, nil
+}
+
+// ListDisputes returns disputes matching the filter, newest first. A portal
+// customer only sees their own.
+func (s *DisputeService) ListDisputes(ctx context.Context, filter domain.DisputeFilter, portalCustomerID *uuid.UUID) ([]domain.InvoiceDispute, error) {
+	if portalCustomerID != nil {
+		filter.CustomerID = portalCustomerID
+	}
+	if filter.InvoiceID != nil {
+		if _, err := s.invoice(ctx, *filter.InvoiceID, portalCustomerID); err != nil {
+			return nil, err
+		}
+	}
+	disputes, err := s.disputeRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list disputes", err)
+	}
+	if disputes == nil {
+		disputes = []domain.InvoiceDispute{}
+	}
+	return disputes, nil
+}
+
+// AddAttachment stores a supporting document for a dispute that is not yet
+// resolved
+func (s *DisputeService) AddAttachment(ctx context.Context, disputeID uuid.UUID, portalCustomerID *uuid.UUID, fileName, contentType string, size int64, uploadedBy string, body io.Reader) (*domain.DisputeAttachment, error) {
+	name := path.Base(strings.ReplaceAll(strings.TrimSpace(fileName), "\\", "/"))
+	if name == "." || name == "/" {
+		return nil, apperrors.ValidationError("file name is required", "file_name", fileName)
+	}
+
+	dispute, err := s.GetDispute(ctx, disputeID, portalCustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.Status.IsActive() {
+		return nil, apperrors.InvalidStateError(string(dispute.Status), "OPEN or UNDER_REVIEW")
+	}
+
+	attachment := &domain.DisputeAttachment{
+		ID:          uuid.New(),
+		DisputeID:   dispute.ID,
+		FileName:    name,
+		ContentType: contentType,
+		UploadedBy:  uploadedBy,
+		CreatedAt:   time.Now(),
+	}
+	attachment.StorageKey = storage.Key("disputes", dispute.ID.String(), attachment.ID.String(), name)
+
+	obj, err := s.store.Put(ctx, attachment.StorageKey, body, storage.PutOptions{
+		ContentType: contentType,
+		Size:        size,
+		Metadata:    map[string]string{"dispute": dispute.DisputeNumber},
+	})
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("file storage", err)
+	}
+	attachment.SizeBytes = obj.Size
+
+	if err := s.disputeRepo.AddAttachment(ctx, attachment); err != nil {
+		// Don't leave an orphaned file behind
+		if delErr := s.store.Delete(ctx, attachment.StorageKey); delErr != nil {
+			s.logger.Warnw("Failed to remove orphaned dispute attachment", "key", attachment.StorageKey, "error", delErr)
+		}
+		return nil, apperrors.DatabaseError("add dispute attachment", err)
+	}
+
+	s.logger.Infow("Dispute attachment added",
+		"dispute_number", dispute.DisputeNumber,
+		"attachment_id", attachment.ID,
+		"size_bytes", attachment.SizeBytes,
+	)
+	return attachment, nil
+}
+
+// OpenAttachment returns a dispute attachment and a reader for its file. The
+// caller closes the reader.
+func (s *DisputeService) OpenAttachment(ctx context.Context, disputeID, attachmentID uuid.UUID, portalCustomerID *uuid.UUID) (*domain.DisputeAttachment, io.ReadCloser, error) {
+	if _, err := s.GetDispute(ctx, disputeID, portalCustomerID); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.disputeRepo.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, apperrors.DatabaseError("get dispute attachment", err)
+	}
+	if attachment == nil || attachment.DisputeID != disputeID {
+		return nil, nil, apperrors.NotFoundError("dispute attachment", attachmentID.String())
+	}
+
+	body, _, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, apperrors.NotFoundError("dispute attachment file", attachmentID.String())
+		}
+		return nil, nil, apperrors.ExternalServiceError("file storage", err)
+	}
+	return attachment, body, nil
+}
+
+// invoice loads an invoice, hiding other customers' invoices from a portal user
+func (s *DisputeService) invoice(ctx context.Context, invoiceID uuid.UUID, portalCustomerID *uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := s.disputeRepo.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get invoice", err)
+	}
+	if invoice == nil || (portalCustomerID != nil && invoice.CustomerID != *portalCustomerID) {
+		return nil, apperrors.NotFoundError("invoice", invoiceID.String())
+	}
+	return invoice, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
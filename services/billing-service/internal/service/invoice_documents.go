@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/shared/pkg/document"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// InvoiceDocumentService renders invoices as branded PDFs
+type InvoiceDocumentService struct {
+	documents *document.Generator
+	logger    *logger.Logger
+}
+
+// NewInvoiceDocumentService creates a new invoice document service
+func NewInvoiceDocumentService(documents *document.Generator, log *logger.Logger) *InvoiceDocumentService {
+	return &InvoiceDocumentService{
+		documents: documents,
+		logger:    log,
+	}
+}
+
+// RenderInvoice renders an invoice with its line items. billTo is the
+// customer's billing contact; its name defaults to the invoice customer name.
+func (s *InvoiceDocumentService) RenderInvoice(ctx context.Context, tenantID string, invoice *domain.Invoice, billTo document.Party) (*document.Document, error) {
+	if invoice.Status == domain.InvoiceStatusVoid {
+		return nil, apperrors.InvalidStateError(string(invoice.Status), "not VOID")
+	}
+	if billTo.Name == "" {
+		billTo.Name = invoice.CustomerName
+	}
+
+	lines := make([]document.InvoiceLine, 0, len(invoice.LineItems))
+	for _, item := range invoice.LineItems {
+		description := item.Description
+		if item.TripNumber != "" {
+			description = fmt.Sprintf("%s (trip %s)", description, item.TripNumber)
+		}
+		lines = append(lines, document.InvoiceLine{
+			Description:     description,
+			ContainerNumber: item.ContainerNumber,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Amount:          item.Amount,
+		})
+	}
+
+	title := "Invoice"
+	if invoice.Status == domain.InvoiceStatusDraft {
+		title = "Draft Invoice"
+	}
+
+	return s.documents.Render(ctx, document.Request{
+		TenantID: tenantID,
+		Kind:     document.KindInvoice,
+		Title:    title,
+		Filename: "invoice-" + invoice.InvoiceNumber,
+		Data: document.InvoiceData{
+			Number:       invoice.InvoiceNumber,
+			Date:         invoice.InvoiceDate,
+			DueDate:      invoice.DueDate,
+			PaymentTerms: invoice.PaymentTerms,
+			Currency:     invoice.Currency,
+			BillTo:       billTo,
+			PONumber:     invoice.PONumber,
+			BOLNumber:    invoice.BOLNumber,
+			Lines:        lines,
+			Subtotal:     invoice.Subtotal,
+			TaxRate:      invoice.TaxRate,
+			TaxAmount:    invoice.TaxAmount,
+			Total:        invoice.TotalAmount,
+			AmountPaid:   invoice.PaidAmount,
+			BalanceDue:   invoice.BalanceDue,
+			Notes:        invoice.Notes,
+		},
+	})
+}
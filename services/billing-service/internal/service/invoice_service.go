@@ -0,0 +1,489 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/document"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+const (
+	// invoiceGraceHours is how long after completion an order waits to be
+	// invoiced by the scheduled run, so late accessorials make the invoice
+	invoiceGraceHours = 24
+
+	// scheduledInvoiceUser is recorded as the creator of scheduled invoices
+	scheduledInvoiceUser = "invoice-generation"
+)
+
+// InvoiceService invoices completed orders. Each run prices what the orders
+// are missing from the customer's rate tables, bills each shipment's orders
+// on one invoice, keeps the rendered PDF and publishes the invoice for the
+// accounting integration. Methods that take a portal customer ID limit a
+// portal user to their own invoices; staff pass nil.
+type InvoiceService struct {
+	invoiceRepo   repository.InvoiceRepository
+	rateRepo      repository.RateRepository
+	tableRepo     repository.RateTableRepository
+	splitRepo     repository.SplitBillingRepository
+	statementRepo repository.StatementRepository
+	documents     *InvoiceDocumentService
+	store         storage.Store
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewInvoiceService creates a new invoice generation service
+func NewInvoiceService(
+	invoiceRepo repository.InvoiceRepository,
+	rateRepo repository.RateRepository,
+	tableRepo repository.RateTableRepository,
+	splitRepo repository.SplitBillingRepository,
+	statementRepo repository.StatementRepository,
+	documents *InvoiceDocumentService,
+	store storage.Store,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo:   invoiceRepo,
+		rateRepo:      rateRepo,
+		tableRepo:     tableRepo,
+		splitRepo:     splitRepo,
+		statementRepo: statementRepo,
+		documents:     documents,
+		store:         store,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// GenerateInvoicesInput contains input for an invoice run
+type GenerateInvoicesInput struct {
+	CustomerID      *uuid.UUID // Only this customer's orders when set
+	CompletedBefore time.Time  // Orders completed before this; default now
+	TenantID        string     // Whose templates and branding render the PDFs
+	GeneratedBy     string
+}
+
+// billableShipment is a shipment's orders ready to go on one invoice
+type billableShipment struct {
+	orders  []*domain.BillableOrder
+	charges []domain.OrderCharge // Priced by the run, to be stored
+	lines   []domain.InvoiceLineItem
+	sources domain.InvoiceSources
+}
+
+// GenerateInvoices invoices the completed, unbilled orders. An order that
+// cannot be billed yet is reported as skipped and left for a later run;
+// a failure on one shipment is logged and the run continues.
+func (s *InvoiceService) GenerateInvoices(ctx context.Context, input GenerateInvoicesInput) (*domain.InvoiceRun, error) {
+	if input.CompletedBefore.IsZero() {
+		input.CompletedBefore = time.Now()
+	}
+	orders, err := s.invoiceRepo.ListBillableOrders(ctx, input.CompletedBefore, input.CustomerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list billable orders", err)
+	}
+
+	run := &domain.InvoiceRun{
+		CompletedBefore: input.CompletedBefore,
+		OrdersChecked:   len(orders),
+		Invoices:        []domain.Invoice{},
+		Skipped:         []domain.SkippedOrder{},
+		GeneratedBy:     input.GeneratedBy,
+	}
+	skip := func(o *domain.BillableOrder, reason domain.InvoiceSkipReason, detail string) {
+		run.Skipped = append(run.Skipped, domain.SkippedOrder{OrderID: o.OrderID, OrderNumber: o.OrderNumber, Reason: reason, Detail: detail})
+	}
+
+	// Orders come sorted by customer and shipment
+	var shipments []*billableShipment
+	byShipment := make(map[uuid.UUID]*billableShipment)
+	accessorials := make(map[uuid.UUID]map[domain.ChargeType]domain.AccessorialRate)
+	splits := make(map[uuid.UUID][]domain.SplitDeliveryOrder)
+	for i := range orders {
+		if ctx.Err() != nil {
+			return run, ctx.Err()
+		}
+		order := &orders[i]
+		if order.CustomerID == nil {
+			skip(order, domain.InvoiceSkipNoCustomer, "shipment has no customer")
+			continue
+		}
+		if !order.ContainerChargesSettled() {
+			skip(order, domain.InvoiceSkipChargesAccruing, "per diem or demurrage on "+order.ContainerNumber+" is not final")
+			continue
+		}
+
+		rates, ok := accessorials[*order.CustomerID]
+		if !ok {
+			list, err := s.rateRepo.CurrentAccessorialRates(ctx, *order.CustomerID, order.CompletedAt)
+			if err != nil {
+				return run, apperrors.DatabaseError("list accessorial rates", err)
+			}
+			rates = make(map[domain.ChargeType]domain.AccessorialRate, len(list))
+			for _, rate := range list {
+				rates[rate.ChargeType] = rate
+			}
+			accessorials[*order.CustomerID] = rates
+		}
+		var contract *domain.Rate
+		if !order.HasCharge(domain.ChargeTypeLineHaul) || !order.HasCharge(domain.ChargeTypeFuelSurcharge) {
+			if contract, err = s.rateRepo.FindContractRate(ctx, order.ContractRateQuery()); err != nil {
+				return run, apperrors.DatabaseError("find contract rate", err)
+			}
+			if contract == nil && !order.HasCharge(domain.ChargeTypeLineHaul) {
+				skip(order, domain.InvoiceSkipNoContractRate, "no line haul charge and no contract rate covers the move")
+				continue
+			}
+			if contract != nil {
+				if _, err := applyFuelSchedule(ctx, s.tableRepo, contract, order.CompletedAt); err != nil {
+					return run, err
+				}
+			}
+		}
+
+		priced := domain.PriceOrder(order, contract, rates, &s.businessRules.Detention)
+		for j := range priced {
+			priced[j].ID = uuid.New()
+		}
+		charges := append(append([]domain.OrderCharge{}, order.Charges...), priced...)
+		if len(charges) == 0 {
+			skip(order, domain.InvoiceSkipNothingToBill, "")
+			continue
+		}
+
+		lines, podMissing, err := s.orderLines(ctx, order, charges, splits)
+		if err != nil {
+			return run, err
+		}
+		if podMissing {
+			skip(order, domain.InvoiceSkipPODMissing, "split delivery is not signed for at every stop")
+			continue
+		}
+
+		shipment, ok := byShipment[order.ShipmentID]
+		if !ok {
+			shipment = &billableShipment{}
+			byShipment[order.ShipmentID] = shipment
+			shipments = append(shipments, shipment)
+		}
+		shipment.orders = append(shipment.orders, order)
+		shipment.charges = append(shipment.charges, priced...)
+		shipment.lines = append(shipment.lines, lines...)
+		shipment.sources.OrderIDs = append(shipment.sources.OrderIDs, order.OrderID)
+		for _, usage := range order.Chassis {
+			shipment.sources.ChassisUsageIDs = append(shipment.sources.ChassisUsageIDs, usage.ID)
+		}
+		for _, cc := range order.ContainerCharges {
+			if cc.Status == domain.ContainerChargeClosed {
+				shipment.sources.ContainerChargeIDs = append(shipment.sources.ContainerChargeIDs, cc.ID)
+			}
+		}
+	}
+
+	for _, shipment := range shipments {
+		if ctx.Err() != nil {
+			return run, ctx.Err()
+		}
+		invoice, err := s.createInvoice(ctx, shipment, input)
+		if err != nil {
+			if errors.Is(err, repository.ErrOrdersAlreadyBilled) {
+				for _, order := range shipment.orders {
+					skip(order, domain.InvoiceSkipAlreadyBilled, "")
+				}
+				continue
+			}
+			s.logger.Errorw("Failed to generate invoice",
+				"shipment_id", shipment.orders[0].ShipmentID,
+				"customer_id", shipment.orders[0].CustomerID,
+				"error", err,
+			)
+			continue
+		}
+		invoice.LineItems = nil
+		run.Invoices = append(run.Invoices, *invoice)
+		run.TotalBilled = roundCents(run.TotalBilled + invoice.TotalAmount)
+	}
+
+	s.logger.Infow("Invoice run complete",
+		"orders_checked", run.OrdersChecked,
+		"invoices", len(run.Invoices),
+		"skipped", len(run.Skipped),
+		"total_billed", run.TotalBilled,
+		"generated_by", input.GeneratedBy,
+	)
+	return run, nil
+}
+
+// orderLines returns an order's invoice lines. A container the order's trip
+// delivered across several stops has its shared charges billed per stop;
+// podMissing reports that one of those stops has not signed for it yet.
+func (s *InvoiceService) orderLines(ctx context.Context, order *domain.BillableOrder, charges []domain.OrderCharge, splits map[uuid.UUID][]domain.SplitDeliveryOrder) ([]domain.InvoiceLineItem, bool, error) {
+	for _, trip := range order.Trips {
+		tripOrders, ok := splits[trip.TripID]
+		if !ok {
+			var err error
+			if tripOrders, err = s.splitRepo.ListSplitDeliveryOrders(ctx, trip.TripID); err != nil {
+				return nil, false, apperrors.DatabaseError("list split delivery orders", err)
+			}
+			splits[trip.TripID] = tripOrders
+		}
+		for _, split := range tripOrders {
+			if split.OrderID != order.OrderID || len(split.Stops) < 2 {
+				continue
+			}
+
+			var shared, unshared []domain.OrderCharge
+			split.Charges = nil
+			for _, c := range charges {
+				if c.ChargeType.SplitsByDelivery() {
+					shared = append(shared, c)
+					split.Charges = append(split.Charges, domain.TripCharge{OrderID: c.OrderID, ChargeType: c.ChargeType, Amount: c.Amount})
+				} else {
+					unshared = append(unshared, c)
+				}
+			}
+			if len(shared) == 0 {
+				break
+			}
+			plan := domain.PlanSplitBilling(split)
+			if !plan.PODComplete {
+				return nil, true, nil
+			}
+			lines := domain.InvoiceLines(order, unshared)
+			for _, stop := range plan.Stops {
+				lines = append(lines, stop.Lines...)
+			}
+			return lines, false, nil
+		}
+	}
+	return domain.InvoiceLines(order, charges), false, nil
+}
+
+// createInvoice stores a shipment's invoice, then renders and keeps its PDF
+// and publishes it. A PDF that fails to render is logged and rendered again
+// when the document is asked for.
+func (s *InvoiceService) createInvoice(ctx context.Context, shipment *billableShipment, input GenerateInvoicesInput) (*domain.Invoice, error) {
+	first := shipment.orders[0]
+	now := time.Now()
+	shipmentID := first.ShipmentID
+	invoice := &domain.Invoice{
+		ID:           uuid.New(),
+		CustomerID:   *first.CustomerID,
+		CustomerName: first.CustomerName,
+		ShipmentID:   &shipmentID,
+		Status:       domain.InvoiceStatusPending,
+		InvoiceDate:  now,
+		DueDate:      now.AddDate(0, 0, first.PaymentTermsDays),
+		PaymentTerms: domain.PaymentTerms(first.PaymentTermsDays),
+		Currency:     "USD",
+		PONumber:     first.ShipmentRef,
+		BOLNumber:    first.BillOfLading,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CreatedBy:    input.GeneratedBy,
+		LineItems:    shipment.lines,
+	}
+	for i := range invoice.LineItems {
+		line := &invoice.LineItems[i]
+		line.ID = uuid.New()
+		line.InvoiceID = invoice.ID
+		line.CreatedAt = now
+	}
+	invoice.ApplyTotals()
+
+	if err := s.invoiceRepo.CreateInvoice(ctx, invoice, shipment.charges, shipment.sources); err != nil {
+		if errors.Is(err, repository.ErrOrdersAlreadyBilled) {
+			return nil, err
+		}
+		return nil, apperrors.DatabaseError("create invoice", err)
+	}
+
+	if doc, err := s.render(ctx, input.TenantID, invoice); err != nil {
+		s.logger.Warnw("Failed to render invoice", "invoice_number", invoice.InvoiceNumber, "error", err)
+	} else if err := s.keepDocument(ctx, invoice, doc); err != nil {
+		s.logger.Warnw("Failed to store invoice document", "invoice_number", invoice.InvoiceNumber, "error", err)
+	}
+
+	s.publishCreated(ctx, invoice)
+
+	s.logger.Infow("Invoice generated",
+		"invoice_number", invoice.InvoiceNumber,
+		"customer_id", invoice.CustomerID,
+		"orders", len(shipment.orders),
+		"lines", len(invoice.LineItems),
+		"total", invoice.TotalAmount,
+	)
+	return invoice, nil
+}
+
+// publishCreated publishes a new invoice with its lines for the accounting
+// integration
+func (s *InvoiceService) publishCreated(ctx context.Context, invoice *domain.Invoice) {
+	lines := make([]map[string]interface{}, 0, len(invoice.LineItems))
+	for _, line := range invoice.LineItems {
+		lines = append(lines, map[string]interface{}{
+			"line_item_id":     line.ID.String(),
+			"order_id":         line.OrderID,
+			"trip_id":          line.TripID,
+			"charge_type":      line.ChargeType,
+			"description":      line.Description,
+			"quantity":         line.Quantity,
+			"unit_price":       line.UnitPrice,
+			"amount":           line.Amount,
+			"container_number": line.ContainerNumber,
+		})
+	}
+	event := kafka.NewEvent(kafka.Topics.InvoiceCreated, "billing-service", map[string]interface{}{
+		"invoice_id":     invoice.ID.String(),
+		"invoice_number": invoice.InvoiceNumber,
+		"customer_id":    invoice.CustomerID.String(),
+		"customer_name":  invoice.CustomerName,
+		"shipment_id":    invoice.ShipmentID,
+		"invoice_date":   invoice.InvoiceDate,
+		"due_date":       invoice.DueDate,
+		"payment_terms":  invoice.PaymentTerms,
+		"currency":       invoice.Currency,
+		"po_number":      invoice.PONumber,
+		"bol_number":     invoice.BOLNumber,
+		"subtotal":       invoice.Subtotal,
+		"tax_amount":     invoice.TaxAmount,
+		"total_amount":   invoice.TotalAmount,
+		"has_document":   invoice.DocumentKey != "",
+		"line_items":     lines,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.InvoiceCreated, event)
+}
+
+// GenerateScheduled invoices the orders completed more than the grace period
+// before now
+func (s *InvoiceService) GenerateScheduled(ctx context.Context, now time.Time) (*domain.InvoiceRun, error) {
+	return s.GenerateInvoices(ctx, GenerateInvoicesInput{
+		CompletedBefore: now.Add(-invoiceGraceHours * time.Hour),
+		GeneratedBy:     scheduledInvoiceUser,
+	})
+}
+
+// Start runs scheduled invoice generation every interval until ctx is cancelled
+func (s *InvoiceService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.GenerateScheduled(ctx, now); err != nil {
+				s.logger.Errorw("Invoice generation failed", "error", err)
+			}
+		}
+	}
+}
+
+// GetInvoice returns an invoice with its line items
+func (s *InvoiceService) GetInvoice(ctx context.Context, invoiceID uuid.UUID, portalCustomerID *uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get invoice", err)
+	}
+	if invoice == nil || (portalCustomerID != nil && invoice.CustomerID != *portalCustomerID) {
+		return nil, apperrors.NotFoundError("invoice", invoiceID.String())
+	}
+	return invoice, nil
+}
+
+// ListInvoices returns invoices newest first. A portal user only sees their
+// own customer's.
+func (s *InvoiceService) ListInvoices(ctx context.Context, filter domain.InvoiceFilter, portalCustomerID *uuid.UUID) ([]domain.Invoice, error) {
+	if portalCustomerID != nil {
+		filter.CustomerID = portalCustomerID
+	}
+	invoices, err := s.invoiceRepo.ListInvoices(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list invoices", err)
+	}
+	return invoices, nil
+}
+
+// OpenInvoiceDocument returns an invoice's PDF and a reader for it; the
+// caller closes the reader. An invoice whose PDF was not kept is rendered
+// again and kept now.
+func (s *InvoiceService) OpenInvoiceDocument(ctx context.Context, invoiceID uuid.UUID, portalCustomerID *uuid.UUID, tenantID string) (*domain.Invoice, io.ReadCloser, error) {
+	invoice, err := s.GetInvoice(ctx, invoiceID, portalCustomerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if invoice.DocumentKey != "" {
+		body, _, err := s.store.Get(ctx, invoice.DocumentKey)
+		if err == nil {
+			return invoice, body, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, apperrors.ExternalServiceError("file storage", err)
+		}
+		s.logger.Warnw("Invoice document missing from storage, rendering again", "invoice_number", invoice.InvoiceNumber)
+	}
+
+	doc, err := s.render(ctx, tenantID, invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.keepDocument(ctx, invoice, doc); err != nil {
+		s.logger.Warnw("Failed to store invoice document", "invoice_number", invoice.InvoiceNumber, "error", err)
+	}
+	return invoice, io.NopCloser(bytes.NewReader(doc.Data)), nil
+}
+
+// render renders an invoice addressed to the customer's billing contact
+func (s *InvoiceService) render(ctx context.Context, tenantID string, invoice *domain.Invoice) (*document.Document, error) {
+	billTo := document.Party{Name: invoice.CustomerName}
+	customer, err := s.statementRepo.GetCustomerContact(ctx, invoice.CustomerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get customer", err)
+	}
+	if customer != nil {
+		billTo = document.Party{
+			Name:         customer.Name,
+			AddressLine1: customer.Address,
+			AddressLine2: cityStateZip(customer),
+			Phone:        customer.Phone,
+			Email:        customer.Email,
+		}
+	}
+	return s.documents.RenderInvoice(ctx, tenantID, invoice, billTo)
+}
+
+// keepDocument stores a rendered invoice PDF and records where it is
+func (s *InvoiceService) keepDocument(ctx context.Context, invoice *domain.Invoice, doc *document.Document) error {
+	key := storage.Key("invoices", invoice.CustomerID.String(), invoice.InvoiceNumber+".pdf")
+	if _, err := s.store.Put(ctx, key, bytes.NewReader(doc.Data), storage.PutOptions{
+		ContentType: doc.ContentType,
+		Size:        int64(len(doc.Data)),
+		Metadata:    map[string]string{"invoice": invoice.InvoiceNumber},
+	}); err != nil {
+		return err
+	}
+	if err := s.invoiceRepo.SetDocumentKey(ctx, invoice.ID, key); err != nil {
+		return err
+	}
+	invoice.DocumentKey = key
+	return nil
+}
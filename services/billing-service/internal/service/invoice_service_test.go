@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// fakeInvoiceRepo serves billable orders and records the invoices created
+type fakeInvoiceRepo struct {
+	repository.InvoiceRepository
+
+	orders        []domain.BillableOrder
+	alreadyBilled map[uuid.UUID]bool // Shipments another run billed first
+	created       []*domain.Invoice
+	lines         [][]domain.InvoiceLineItem // As created; the run drops them from its summary
+	charges       [][]domain.OrderCharge
+	sources       []domain.InvoiceSources
+}
+
+func (r *fakeInvoiceRepo) ListBillableOrders(ctx context.Context, completedBefore time.Time, customerID *uuid.UUID) ([]domain.BillableOrder, error) {
+	return r.orders, nil
+}
+
+func (r *fakeInvoiceRepo) CreateInvoice(ctx context.Context, invoice *domain.Invoice, charges []domain.OrderCharge, sources domain.InvoiceSources) error {
+	if r.alreadyBilled[*invoice.ShipmentID] {
+		return repository.ErrOrdersAlreadyBilled
+	}
+	invoice.InvoiceNumber = domain.InvoiceNumber(int64(len(r.created)+1), invoice.InvoiceDate)
+	r.created = append(r.created, invoice)
+	r.lines = append(r.lines, append([]domain.InvoiceLineItem(nil), invoice.LineItems...))
+	r.charges = append(r.charges, charges)
+	r.sources = append(r.sources, sources)
+	return nil
+}
+
+// fakeRateRepo prices 40 foot moves at one contract rate
+type fakeRateRepo struct {
+	repository.RateRepository
+
+	contract     domain.Rate
+	accessorials []domain.AccessorialRate
+}
+
+func (r *fakeRateRepo) FindContractRate(ctx context.Context, query domain.ContractRateQuery) (*domain.Rate, error) {
+	if query.ContainerSize != "40" {
+		return nil, nil
+	}
+	rate := r.contract
+	return &rate, nil
+}
+
+func (r *fakeRateRepo) CurrentAccessorialRates(ctx context.Context, customerID uuid.UUID, at time.Time) ([]domain.AccessorialRate, error) {
+	return r.accessorials, nil
+}
+
+type fakeRateTableRepo struct {
+	repository.RateTableRepository
+
+	schedule *domain.FuelSurchargeSchedule
+	price    *domain.FuelPrice
+}
+
+func (r *fakeRateTableRepo) GetFuelSchedule(ctx context.Context, id uuid.UUID) (*domain.FuelSurchargeSchedule, error) {
+	return r.schedule, nil
+}
+
+func (r *fakeRateTableRepo) CurrentFuelPrice(ctx context.Context, region string, at time.Time) (*domain.FuelPrice, error) {
+	return r.price, nil
+}
+
+type fakeSplitRepo struct {
+	repository.SplitBillingRepository
+}
+
+func (r *fakeSplitRepo) ListSplitDeliveryOrders(ctx context.Context, tripID uuid.UUID) ([]domain.SplitDeliveryOrder, error) {
+	return nil, nil
+}
+
+// fakeStatementRepo fails customer lookups, so invoices go unrendered as
+// when the document service is down
+type fakeStatementRepo struct {
+	repository.StatementRepository
+}
+
+func (r *fakeStatementRepo) GetCustomerContact(ctx context.Context, customerID uuid.UUID) (*domain.CustomerContact, error) {
+	return nil, errors.New("connection refused")
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return log
+}
+
+func TestGenerateInvoices(t *testing.T) {
+	customerID := uuid.New()
+	scheduleID := uuid.New()
+	completed := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	order := func(number string, shipmentID uuid.UUID, size string) domain.BillableOrder {
+		return domain.BillableOrder{
+			OrderID:          uuid.New(),
+			OrderNumber:      number,
+			CustomerID:       &customerID,
+			CustomerName:     "Acme Imports",
+			PaymentTermsDays: 30,
+			ShipmentID:       shipmentID,
+			ShipmentRef:      "PO-7781",
+			ContainerNumber:  "MSCU" + number,
+			ContainerSize:    size,
+			ContainerType:    "DRY",
+			CompletedAt:      completed,
+			Trips:            []domain.BillableTrip{{TripID: uuid.New(), TripNumber: "TRP-" + number}},
+		}
+	}
+
+	shipment, billedElsewhere := uuid.New(), uuid.New()
+	priced := order("1001", shipment, "40HC")
+	ownLineHaul := order("1002", shipment, "40")
+	ownLineHaul.Charges = []domain.OrderCharge{{ID: uuid.New(), ChargeType: domain.ChargeTypeLineHaul, Description: "Line haul", Amount: 500}}
+	ownLineHaul.Chassis = []domain.ChassisUsage{{ID: uuid.New(), Days: 2, Amount: 70}}
+	ownLineHaul.ContainerCharges = []domain.ContainerCharge{
+		{ID: uuid.New(), ChargeType: domain.ChargeTypePerDiem, Days: 1, DailyRate: 125, Amount: 125, Status: domain.ContainerChargeClosed},
+	}
+	noCustomer := order("1003", uuid.New(), "40")
+	noCustomer.CustomerID = nil
+	accruing := order("1004", uuid.New(), "40")
+	accruing.ContainerCharges = []domain.ContainerCharge{{ChargeType: domain.ChargeTypeDemurrage, Status: domain.ContainerChargeAccruing}}
+	noRate := order("1005", uuid.New(), "20")
+	billed := order("1006", billedElsewhere, "40")
+
+	invoices := &fakeInvoiceRepo{
+		orders:        []domain.BillableOrder{priced, ownLineHaul, noCustomer, accruing, noRate, billed},
+		alreadyBilled: map[uuid.UUID]bool{billedElsewhere: true},
+	}
+	rates := &fakeRateRepo{contract: domain.Rate{
+		ID: uuid.New(), CustomerID: customerID, Name: "Port of LA to Inland Empire", BaseRate: 450,
+		FuelSurcharge: 25, FuelSurchargeType: "flat", FuelScheduleID: &scheduleID,
+	}}
+	tables := &fakeRateTableRepo{
+		schedule: &domain.FuelSurchargeSchedule{
+			ID: scheduleID, Name: "DOE West Coast", Region: "CALIFORNIA", IsActive: true,
+			EffectiveDate: completed.AddDate(0, -1, 0),
+			Tiers: []domain.FuelSurchargeTier{
+				{MinPrice: 0, MaxPrice: floatPtr(4), Percent: 12},
+				{MinPrice: 4, Percent: 15},
+			},
+		},
+		price: &domain.FuelPrice{Region: "CALIFORNIA", PricePerGallon: 4.85, EffectiveDate: completed.AddDate(0, 0, -2)},
+	}
+	// Async so publishing does not wait on a broker
+	producer := kafka.NewProducerWithConfig([]string{"127.0.0.1:1"}, kafka.ProducerConfig{Async: true}, testLogger(t))
+	svc := NewInvoiceService(invoices, rates, tables, &fakeSplitRepo{}, &fakeStatementRepo{}, nil, nil, producer, testLogger(t))
+
+	run, err := svc.GenerateInvoices(context.Background(), GenerateInvoicesInput{CompletedBefore: completed.Add(time.Hour), GeneratedBy: "tester"})
+	if err != nil {
+		t.Fatalf("GenerateInvoices() error = %v", err)
+	}
+
+	var skipped []domain.InvoiceSkipReason
+	for _, s := range run.Skipped {
+		skipped = append(skipped, s.Reason)
+	}
+	wantSkipped := []domain.InvoiceSkipReason{
+		domain.InvoiceSkipNoCustomer, domain.InvoiceSkipChargesAccruing, domain.InvoiceSkipNoContractRate, domain.InvoiceSkipAlreadyBilled,
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", skipped, wantSkipped)
+	}
+	if run.OrdersChecked != 6 || len(run.Invoices) != 1 || len(invoices.created) != 1 {
+		t.Fatalf("run = %d checked, %d invoices (%d stored), want 6 checked and 1 invoice", run.OrdersChecked, len(run.Invoices), len(invoices.created))
+	}
+
+	// Both orders of the shipment go on one invoice. The schedule's 15%
+	// replaces the contract's flat fuel: 450 + 67.50 on the priced order,
+	// and 500 + 75 + 70 chassis + 125 per diem on the other
+	invoice := invoices.created[0]
+	if invoice.TotalAmount != 1287.5 || run.TotalBilled != 1287.5 {
+		t.Errorf("invoice total = %.2f, run total = %.2f, want 1287.50", invoice.TotalAmount, run.TotalBilled)
+	}
+	if *invoice.ShipmentID != shipment || invoice.PaymentTerms != "NET30" || invoice.PONumber != "PO-7781" || invoice.CreatedBy != "tester" {
+		t.Errorf("invoice = %+v", invoice)
+	}
+	if !invoice.DueDate.Equal(invoice.InvoiceDate.AddDate(0, 0, 30)) {
+		t.Errorf("due %s, want 30 days after %s", invoice.DueDate, invoice.InvoiceDate)
+	}
+	if len(invoices.lines[0]) != 6 {
+		t.Errorf("invoice has %d lines, want 6", len(invoices.lines[0]))
+	}
+	for _, line := range invoices.lines[0] {
+		if line.InvoiceID != invoice.ID || line.ID == uuid.Nil || line.TripNumber == "" {
+			t.Errorf("line %+v is not on the invoice or a trip", line)
+		}
+	}
+
+	// Only what the run priced is stored as new charges; the order's own
+	// line haul is already on file
+	var fuel []float64
+	for _, c := range invoices.charges[0] {
+		if c.ID == uuid.Nil {
+			t.Errorf("priced charge %+v has no ID", c)
+		}
+		if c.ChargeType == domain.ChargeTypeLineHaul && c.OrderID == ownLineHaul.OrderID {
+			t.Error("the order's own line haul was priced again")
+		}
+		if c.ChargeType == domain.ChargeTypeFuelSurcharge {
+			fuel = append(fuel, c.Amount)
+		}
+	}
+	if len(invoices.charges[0]) != 5 || !reflect.DeepEqual(fuel, []float64{67.5, 75}) {
+		t.Errorf("stored charges = %+v, want 5 with fuel 67.50 and 75", invoices.charges[0])
+	}
+	sources := invoices.sources[0]
+	if !reflect.DeepEqual(sources.OrderIDs, []uuid.UUID{priced.OrderID, ownLineHaul.OrderID}) ||
+		len(sources.ChassisUsageIDs) != 1 || len(sources.ContainerChargeIDs) != 1 {
+		t.Errorf("sources = %+v", sources)
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// defaultRateExpiryAlertDays is how far ahead of expiration a rate is flagged
+// when no window is given
+const defaultRateExpiryAlertDays = 30
+
+// RateService handles contract rate expiration and amendments. An amendment
+// replaces a rate with a new version from its effective date; a retroactive
+// one re-rates the charges on orders created since then under the old
+// version and keeps an adjustment for each charge whose amount changed.
+type RateService struct {
+	rateRepo      repository.RateRepository
+	tableRepo     repository.RateTableRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewRateService creates a new rate service
+func NewRateService(rateRepo repository.RateRepository, tableRepo repository.RateTableRepository, eventProducer *kafka.Producer, log *logger.Logger) *RateService {
+	return &RateService{rateRepo: rateRepo, tableRepo: tableRepo, eventProducer: eventProducer, logger: log}
+}
+
+// AmendRateInput contains input for amending a rate. Fields left nil keep
+// the value of the version being replaced.
+type AmendRateInput struct {
+	RateKind       domain.RateKind
+	RateID         uuid.UUID
+	EffectiveDate  time.Time
+	ExpirationDate *time.Time
+
+	// Contract rate terms
+	BaseRate          *float64
+	FuelSurcharge     *float64
+	FuelSurchargeType *string // percent or flat
+
+	// Accessorial rate terms
+	Rate      *float64
+	MinCharge *float64
+	MaxCharge *float64
+
+	Reason    string
+	AmendedBy string
+	DryRun    bool // Work out the re-rating without saving anything
+}
+
+// FindContractRate returns the customer's contract rate for a move, with
+// fuel at its contract's surcharge schedule when it has one
+func (s *RateService) FindContractRate(ctx context.Context, query domain.ContractRateQuery) (*domain.Rate, error) {
+	if query.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer_id is required", "customer_id", nil)
+	}
+	if query.At.IsZero() {
+		query.At = time.Now()
+	}
+	rate, err := s.rateRepo.FindContractRate(ctx, query)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find contract rate", err)
+	}
+	if rate == nil {
+		return nil, apperrors.NotFoundError("contract rate", query.CustomerID.String())
+	}
+	if _, err := applyFuelSchedule(ctx, s.tableRepo, rate, query.At); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// ListExpiringRates returns current rates expiring within the given number
+// of days, soonest first
+func (s *RateService) ListExpiringRates(ctx context.Context, withinDays int) ([]domain.RateExpiration, error) {
+	if withinDays <= 0 {
+		withinDays = defaultRateExpiryAlertDays
+	}
+	now := time.Now()
+	expiring, err := s.rateRepo.ListExpiring(ctx, now, now.AddDate(0, 0, withinDays))
+	if err != nil {
+		return nil, apperrors.DatabaseError("list expiring rates", err)
+	}
+	for i := range expiring {
+		expiring[i].DaysLeft = int(math.Ceil(expiring[i].ExpirationDate.Sub(now).Hours() / 24))
+	}
+	return expiring, nil
+}
+
+// SendExpirationAlerts publishes an alert for each rate expiring within the
+// given number of days that has not been alerted yet, and returns them. It
+// is meant to run daily; each rate is alerted once.
+func (s *RateService) SendExpirationAlerts(ctx context.Context, withinDays int) ([]domain.RateExpiration, error) {
+	expiring, err := s.ListExpiringRates(ctx, withinDays)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	alerted := []domain.RateExpiration{}
+	for _, e := range expiring {
+		if e.AlertedAt != nil {
+			continue
+		}
+
+		event := kafka.NewEvent(kafka.Topics.RateExpiring, "billing-service", map[string]interface{}{
+			"rate_kind":       e.RateKind,
+			"rate_id":         e.RateID.String(),
+			"customer_id":     e.CustomerID,
+			"customer_name":   e.CustomerName,
+			"name":            e.Name,
+			"expiration_date": e.ExpirationDate,
+			"days_left":       e.DaysLeft,
+		})
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.RateExpiring, event)
+
+		if err := s.rateRepo.MarkExpiryAlerted(ctx, e.RateKind, e.RateID, now); err != nil {
+			return alerted, apperrors.DatabaseError("mark rate expiry alerted", err)
+		}
+		e.AlertedAt = &now
+		alerted = append(alerted, e)
+	}
+
+	if len(alerted) > 0 {
+		s.logger.Infow("Rate expiration alerts sent", "rates", len(alerted))
+	}
+	return alerted, nil
+}
+
+// AmendRate replaces a rate with a new version from the effective date and
+// re-rates the charges priced under the old version on orders created since
+// then. Unbilled charges are changed in place; invoiced ones are left alone
+// and recorded as pending an invoice adjustment. With DryRun the amendment
+// and its adjustments are returned without being saved.
+func (s *RateService) AmendRate(ctx context.Context, input AmendRateInput) (*domain.RateAmendment, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, apperrors.ValidationError("reason is required", "reason", nil)
+	}
+	if input.EffectiveDate.IsZero() {
+		return nil, apperrors.ValidationError("effective_date is required", "effective_date", nil)
+	}
+	if input.ExpirationDate != nil && !input.ExpirationDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("expiration_date must be after effective_date", "expiration_date", *input.ExpirationDate)
+	}
+	for field, v := range map[string]*float64{
+		"base_rate": input.BaseRate, "fuel_surcharge": input.FuelSurcharge,
+		"rate": input.Rate, "min_charge": input.MinCharge, "max_charge": input.MaxCharge,
+	} {
+		if v != nil && *v < 0 {
+			return nil, apperrors.ValidationError(field+" cannot be negative", field, *v)
+		}
+	}
+
+	now := time.Now()
+	amendment := &domain.RateAmendment{
+		ID:             uuid.New(),
+		RateKind:       input.RateKind,
+		PreviousRateID: input.RateID,
+		NewRateID:      uuid.New(),
+		EffectiveDate:  input.EffectiveDate,
+		Reason:         reason,
+		AmendedBy:      input.AmendedBy,
+		CreatedAt:      now,
+	}
+
+	switch input.RateKind {
+	case domain.RateKindContract:
+		rate, err := s.rateRepo.GetRate(ctx, input.RateID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get rate", err)
+		}
+		if rate == nil {
+			return nil, apperrors.NotFoundError("rate", input.RateID.String())
+		}
+		if rate.SupersededBy != nil {
+			return nil, supersededError(*rate.SupersededBy)
+		}
+		if err := checkAmendmentWindow(input, rate.EffectiveDate, rate.ExpirationDate); err != nil {
+			return nil, err
+		}
+		if input.BaseRate == nil && input.FuelSurcharge == nil && input.FuelSurchargeType == nil {
+			return nil, apperrors.ValidationError("amendment changes nothing; give base_rate or fuel surcharge terms", "base_rate", nil)
+		}
+
+		version := *rate
+		version.ID = amendment.NewRateID
+		version.EffectiveDate = input.EffectiveDate
+		if input.ExpirationDate != nil {
+			version.ExpirationDate = input.ExpirationDate
+		}
+		if input.BaseRate != nil {
+			version.BaseRate = *input.BaseRate
+		}
+		if input.FuelSurcharge != nil {
+			version.FuelSurcharge = *input.FuelSurcharge
+		}
+		if input.FuelSurchargeType != nil {
+			if *input.FuelSurchargeType != "percent" && *input.FuelSurchargeType != "flat" {
+				return nil, apperrors.ValidationError("fuel_surcharge_type must be percent or flat", "fuel_surcharge_type", *input.FuelSurchargeType)
+			}
+			version.FuelSurchargeType = *input.FuelSurchargeType
+		}
+		version.IsActive = true
+		version.SupersededBy = nil
+		version.ExpiryAlertedAt = nil
+		version.CreatedAt = now
+		version.UpdatedAt = now
+		amendment.ContractRate = &version
+		customerID := rate.CustomerID
+		amendment.CustomerID = &customerID
+
+		charges, err := s.rateRepo.ListRatedCharges(ctx, input.RateKind, input.RateID, input.EffectiveDate)
+		if err != nil {
+			return nil, apperrors.DatabaseError("list rated charges", err)
+		}
+		amendment.Adjustments = buildRerateAdjustments(amendment.ID, charges, domain.RerateContractCharges(&version, charges), now)
+
+	case domain.RateKindAccessorial:
+		rate, err := s.rateRepo.GetAccessorialRate(ctx, input.RateID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get accessorial rate", err)
+		}
+		if rate == nil {
+			return nil, apperrors.NotFoundError("accessorial rate", input.RateID.String())
+		}
+		if rate.SupersededBy != nil {
+			return nil, supersededError(*rate.SupersededBy)
+		}
+		if err := checkAmendmentWindow(input, rate.EffectiveDate, rate.ExpirationDate); err != nil {
+			return nil, err
+		}
+		if input.Rate == nil && input.MinCharge == nil && input.MaxCharge == nil {
+			return nil, apperrors.ValidationError("amendment changes nothing; give rate, min_charge or max_charge", "rate", nil)
+		}
+
+		version := *rate
+		version.ID = amendment.NewRateID
+		version.EffectiveDate = input.EffectiveDate
+		if input.ExpirationDate != nil {
+			version.ExpirationDate = input.ExpirationDate
+		}
+		if input.Rate != nil {
+			version.Rate = *input.Rate
+		}
+		if input.MinCharge != nil {
+			version.MinCharge = *input.MinCharge
+		}
+		if input.MaxCharge != nil {
+			version.MaxCharge = *input.MaxCharge
+		}
+		if version.MaxCharge > 0 && version.MaxCharge < version.MinCharge {
+			return nil, apperrors.ValidationError("max_charge cannot be below min_charge", "max_charge", version.MaxCharge)
+		}
+		version.IsActive = true
+		version.SupersededBy = nil
+		version.ExpiryAlertedAt = nil
+		version.CreatedAt = now
+		version.UpdatedAt = now
+		amendment.AccessorialRate = &version
+		amendment.CustomerID = rate.CustomerID
+
+		charges, err := s.rateRepo.ListRatedCharges(ctx, input.RateKind, input.RateID, input.EffectiveDate)
+		if err != nil {
+			return nil, apperrors.DatabaseError("list rated charges", err)
+		}
+		amendment.Adjustments = buildRerateAdjustments(amendment.ID, charges, domain.RerateAccessorialCharges(&version, charges), now)
+
+	default:
+		return nil, apperrors.ValidationError("rate_kind must be CONTRACT or ACCESSORIAL", "rate_kind", input.RateKind)
+	}
+
+	orders := make(map[uuid.UUID]bool)
+	for _, adj := range amendment.Adjustments {
+		orders[adj.OrderID] = true
+		amendment.TotalDelta += adj.Delta
+		if adj.Status == domain.RerateStatusPendingInvoiceAdjustment {
+			amendment.PendingInvoiceDelta += adj.Delta
+		}
+	}
+	amendment.ChargesRerated = len(amendment.Adjustments)
+	amendment.OrdersRerated = len(orders)
+	amendment.TotalDelta = roundCents(amendment.TotalDelta)
+	amendment.PendingInvoiceDelta = roundCents(amendment.PendingInvoiceDelta)
+
+	if input.DryRun {
+		return amendment, nil
+	}
+
+	if err := s.rateRepo.ApplyAmendment(ctx, amendment); err != nil {
+		return nil, apperrors.DatabaseError("apply rate amendment", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.RateAmended, "billing-service", map[string]interface{}{
+		"amendment_id":          amendment.ID.String(),
+		"rate_kind":             amendment.RateKind,
+		"previous_rate_id":      amendment.PreviousRateID.String(),
+		"new_rate_id":           amendment.NewRateID.String(),
+		"customer_id":           amendment.CustomerID,
+		"effective_date":        amendment.EffectiveDate,
+		"charges_rerated":       amendment.ChargesRerated,
+		"orders_rerated":        amendment.OrdersRerated,
+		"total_delta":           amendment.TotalDelta,
+		"pending_invoice_delta": amendment.PendingInvoiceDelta,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.RateAmended, event)
+
+	s.logger.Infow("Rate amended",
+		"amendment_id", amendment.ID,
+		"rate_kind", amendment.RateKind,
+		"previous_rate_id", amendment.PreviousRateID,
+		"new_rate_id", amendment.NewRateID,
+		"effective_date", amendment.EffectiveDate,
+		"charges_rerated", amendment.ChargesRerated,
+		"total_delta", amendment.TotalDelta,
+	)
+
+	return amendment, nil
+}
+
+// supersededError rejects amending a version an earlier amendment replaced
+func supersededError(by uuid.UUID) error {
+	return apperrors.InvalidStateError("SUPERSEDED", "CURRENT").WithDetail("superseded_by", by.String())
+}
+
+// checkAmendmentWindow requires the amendment to take effect inside the
+// window of the version it replaces
+func checkAmendmentWindow(input AmendRateInput, effective time.Time, expiration *time.Time) error {
+	if !input.EffectiveDate.After(effective) {
+		return apperrors.ValidationError("effective_date must be after the current rate's effective date", "effective_date", input.EffectiveDate).
+			WithDetail("rate_effective_date", effective)
+	}
+	if expiration != nil && !input.EffectiveDate.Before(*expiration) {
+		return apperrors.ValidationError("effective_date must be before the current rate expires; add a new rate instead", "effective_date", input.EffectiveDate).
+			WithDetail("rate_expiration_date", *expiration)
+	}
+	return nil
+}
+
+// buildRerateAdjustments pairs each charge with its re-rated price and keeps
+// the ones whose amount changed
+func buildRerateAdjustments(amendmentID uuid.UUID, before, after []domain.RatedCharge, now time.Time) []domain.RerateAdjustment {
+	adjustments := []domain.RerateAdjustment{}
+	for i, old := range before {
+		rerated := after[i]
+		delta := roundCents(rerated.Amount - old.Amount)
+		if delta == 0 {
+			continue
+		}
+		status := domain.RerateStatusApplied
+		if old.InvoiceID != nil {
+			status = domain.RerateStatusPendingInvoiceAdjustment
+		}
+		adjustments = append(adjustments, domain.RerateAdjustment{
+			ID:          uuid.New(),
+			AmendmentID: amendmentID,
+			OrderID:     old.OrderID,
+			OrderNumber: old.OrderNumber,
+			ChargeID:    old.ID,
+			ChargeType:  old.ChargeType,
+			InvoiceID:   old.InvoiceID,
+			LineItemID:  old.LineItemID,
+			Quantity:    old.Quantity,
+			OldUnitRate: old.UnitRate,
+			NewUnitRate: rerated.UnitRate,
+			OldAmount:   old.Amount,
+			NewAmount:   rerated.Amount,
+			Delta:       delta,
+			Status:      status,
+			CreatedAt:   now,
+		})
+	}
+	return adjustments
+}
+
+// GetAmendment returns an amendment with the adjustments it made
+func (s *RateService) GetAmendment(ctx context.Context, id uuid.UUID) (*domain.RateAmendment, error) {
+	amendment, err := s.rateRepo.GetAmendment(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate amendment", err)
+	}
+	if amendment == nil {
+		return nil, apperrors.NotFoundError("rate amendment", id.String())
+	}
+	return amendment, nil
+}
+
+// ListAmendments returns the amendments that replaced or created a rate
+func (s *RateService) ListAmendments(ctx context.Context, rateID uuid.UUID) ([]domain.RateAmendment, error) {
+	amendments, err := s.rateRepo.ListAmendments(ctx, rateID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate amendments", err)
+	}
+	if amendments == nil {
+		amendments = []domain.RateAmendment{}
+	}
+	return amendments, nil
+}
@@ -0,0 +1,657 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// defaultFuelRegion is the diesel price index schedules follow when none is given
+const defaultFuelRegion = "US"
+
+// RateTableService manages the tables customers are priced from - rate
+// zones, contracts with their lane rates, fuel surcharge schedules and
+// accessorial price lists - and quotes moves against them
+type RateTableService struct {
+	rateRepo  repository.RateRepository
+	tableRepo repository.RateTableRepository
+	logger    *logger.Logger
+}
+
+// NewRateTableService creates a new rate table service
+func NewRateTableService(rateRepo repository.RateRepository, tableRepo repository.RateTableRepository, log *logger.Logger) *RateTableService {
+	return &RateTableService{rateRepo: rateRepo, tableRepo: tableRepo, logger: log}
+}
+
+// ZoneInput contains input for creating or updating a rate zone
+type ZoneInput struct {
+	Code           string      `json:"code"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	PostalPrefixes []string    `json:"postal_prefixes"`
+	LocationIDs    []uuid.UUID `json:"location_ids"`
+	IsActive       *bool       `json:"is_active"` // Defaults to true
+}
+
+// CreateZone creates a rate zone
+func (s *RateTableService) CreateZone(ctx context.Context, input ZoneInput) (*domain.RateZone, error) {
+	now := time.Now()
+	zone := &domain.RateZone{ID: uuid.New(), IsActive: true, CreatedAt: now, UpdatedAt: now}
+	if err := applyZoneInput(zone, input); err != nil {
+		return nil, err
+	}
+	existing, err := s.tableRepo.ZoneCodesExist(ctx, []string{zone.Code})
+	if err != nil {
+		return nil, apperrors.DatabaseError("check zone codes", err)
+	}
+	if existing[zone.Code] {
+		return nil, apperrors.ConflictError("rate zone " + zone.Code + " already exists")
+	}
+	if err := s.tableRepo.CreateZone(ctx, zone); err != nil {
+		return nil, apperrors.DatabaseError("create rate zone", err)
+	}
+
+	s.logger.Infow("Rate zone created", "zone", zone.Code, "postal_prefixes", len(zone.PostalPrefixes), "locations", len(zone.LocationIDs))
+	return zone, nil
+}
+
+// UpdateZone replaces a rate zone's name and members. The code cannot
+// change since lane rates refer to it.
+func (s *RateTableService) UpdateZone(ctx context.Context, id uuid.UUID, input ZoneInput) (*domain.RateZone, error) {
+	zone, err := s.tableRepo.GetZone(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate zone", err)
+	}
+	if zone == nil {
+		return nil, apperrors.NotFoundError("rate zone", id.String())
+	}
+	if code := domain.NormalizeZoneCode(input.Code); code != "" && code != zone.Code {
+		return nil, apperrors.ValidationError("a zone's code cannot change; lane rates refer to it", "code", input.Code)
+	}
+	input.Code = zone.Code
+	if err := applyZoneInput(zone, input); err != nil {
+		return nil, err
+	}
+	zone.UpdatedAt = time.Now()
+	if err := s.tableRepo.UpdateZone(ctx, zone); err != nil {
+		return nil, apperrors.DatabaseError("update rate zone", err)
+	}
+	return zone, nil
+}
+
+// applyZoneInput validates the input and sets it on the zone
+func applyZoneInput(zone *domain.RateZone, input ZoneInput) error {
+	code := domain.NormalizeZoneCode(input.Code)
+	if code == "" {
+		return apperrors.ValidationError("code is required", "code", nil)
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return apperrors.ValidationError("name is required", "name", nil)
+	}
+	prefixes := make([]string, 0, len(input.PostalPrefixes))
+	for _, prefix := range input.PostalPrefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		for _, c := range prefix {
+			if c < '0' || c > '9' {
+				return apperrors.ValidationError("postal prefixes must be digits", "postal_prefixes", prefix)
+			}
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if len(prefixes) == 0 && len(input.LocationIDs) == 0 {
+		return apperrors.ValidationError("a zone needs postal prefixes or locations", "postal_prefixes", nil)
+	}
+
+	zone.Code = code
+	zone.Name = name
+	zone.Description = strings.TrimSpace(input.Description)
+	zone.PostalPrefixes = prefixes
+	zone.LocationIDs = input.LocationIDs
+	if zone.LocationIDs == nil {
+		zone.LocationIDs = []uuid.UUID{}
+	}
+	if input.IsActive != nil {
+		zone.IsActive = *input.IsActive
+	}
+	return nil
+}
+
+// ListZones returns the rate zones by code
+func (s *RateTableService) ListZones(ctx context.Context) ([]domain.RateZone, error) {
+	zones, err := s.tableRepo.ListZones(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate zones", err)
+	}
+	return zones, nil
+}
+
+// LaneRateInput is one lane of a new contract. Each end is a zone code, a
+// location ID or, with neither, any location.
+type LaneRateInput struct {
+	Name              string     `json:"name"`
+	OriginZone        string     `json:"origin_zone"`
+	OriginID          *uuid.UUID `json:"origin_id"`
+	DestinationZone   string     `json:"destination_zone"`
+	DestinationID     *uuid.UUID `json:"destination_id"`
+	ContainerSize     string     `json:"container_size"` // 20, 40, 45 or any
+	ContainerType     string     `json:"container_type"` // dry, reefer or any
+	BaseRate          float64    `json:"base_rate"`
+	FuelSurcharge     float64    `json:"fuel_surcharge"`      // Ignored when the contract has a fuel schedule
+	FuelSurchargeType string     `json:"fuel_surcharge_type"` // percent or flat
+}
+
+// CreateContractInput contains input for creating a customer contract
+type CreateContractInput struct {
+	CustomerID     uuid.UUID       `json:"customer_id"`
+	ContractNumber string          `json:"contract_number"`
+	Name           string          `json:"name"`
+	FuelScheduleID *uuid.UUID      `json:"fuel_schedule_id"`
+	EffectiveDate  time.Time       `json:"effective_date"`
+	ExpirationDate *time.Time      `json:"expiration_date"`
+	LaneRates      []LaneRateInput `json:"lane_rates"`
+	CreatedBy      string          `json:"-"`
+}
+
+// CreateContract creates a customer contract with its lane rates. The lane
+// rates take the contract's dates; change them later with rate amendments.
+func (s *RateTableService) CreateContract(ctx context.Context, input CreateContractInput) (*domain.RateContract, error) {
+	if input.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer_id is required", "customer_id", nil)
+	}
+	number := strings.TrimSpace(input.ContractNumber)
+	if number == "" {
+		return nil, apperrors.ValidationError("contract_number is required", "contract_number", nil)
+	}
+	if input.EffectiveDate.IsZero() {
+		return nil, apperrors.ValidationError("effective_date is required", "effective_date", nil)
+	}
+	if input.ExpirationDate != nil && !input.ExpirationDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("expiration_date must be after effective_date", "expiration_date", *input.ExpirationDate)
+	}
+	if len(input.LaneRates) == 0 {
+		return nil, apperrors.ValidationError("a contract needs at least one lane rate", "lane_rates", nil)
+	}
+	if input.FuelScheduleID != nil {
+		schedule, err := s.tableRepo.GetFuelSchedule(ctx, *input.FuelScheduleID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get fuel surcharge schedule", err)
+		}
+		if schedule == nil {
+			return nil, apperrors.NotFoundError("fuel surcharge schedule", input.FuelScheduleID.String())
+		}
+		if schedule.CustomerID != nil && *schedule.CustomerID != input.CustomerID {
+			return nil, apperrors.ValidationError("fuel surcharge schedule belongs to another customer", "fuel_schedule_id", *input.FuelScheduleID)
+		}
+	}
+
+	now := time.Now()
+	contract := &domain.RateContract{
+		ID:             uuid.New(),
+		CustomerID:     input.CustomerID,
+		ContractNumber: number,
+		Name:           strings.TrimSpace(input.Name),
+		FuelScheduleID: input.FuelScheduleID,
+		EffectiveDate:  input.EffectiveDate,
+		ExpirationDate: input.ExpirationDate,
+		IsActive:       true,
+		CreatedBy:      input.CreatedBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if contract.Name == "" {
+		contract.Name = number
+	}
+
+	var zoneCodes []string
+	for i, lane := range input.LaneRates {
+		rate, err := laneRate(contract, lane, i)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range []string{rate.OriginZone, rate.DestinationZone} {
+			if code != "" {
+				zoneCodes = append(zoneCodes, code)
+			}
+		}
+		contract.LaneRates = append(contract.LaneRates, *rate)
+	}
+	if len(zoneCodes) > 0 {
+		existing, err := s.tableRepo.ZoneCodesExist(ctx, zoneCodes)
+		if err != nil {
+			return nil, apperrors.DatabaseError("check zone codes", err)
+		}
+		for _, code := range zoneCodes {
+			if !existing[code] {
+				return nil, apperrors.ValidationError("unknown or inactive rate zone "+code, "lane_rates", code)
+			}
+		}
+	}
+
+	if err := s.tableRepo.CreateContract(ctx, contract); err != nil {
+		return nil, apperrors.DatabaseError("create rate contract", err)
+	}
+
+	s.logger.Infow("Rate contract created",
+		"contract_id", contract.ID,
+		"contract_number", contract.ContractNumber,
+		"customer_id", contract.CustomerID,
+		"lane_rates", len(contract.LaneRates),
+		"created_by", input.CreatedBy,
+	)
+	return contract, nil
+}
+
+// laneRate builds the contract rate for a lane
+func laneRate(contract *domain.RateContract, lane LaneRateInput, i int) (*domain.Rate, error) {
+	if lane.BaseRate <= 0 {
+		return nil, apperrors.ValidationError("lane rate base_rate must be positive", "lane_rates", i)
+	}
+	if lane.FuelSurcharge < 0 {
+		return nil, apperrors.ValidationError("lane rate fuel_surcharge cannot be negative", "lane_rates", i)
+	}
+	fuelType := lane.FuelSurchargeType
+	switch fuelType {
+	case "":
+		fuelType = "percent"
+	case "percent", "flat":
+	default:
+		return nil, apperrors.ValidationError("fuel_surcharge_type must be percent or flat", "lane_rates", lane.FuelSurchargeType)
+	}
+
+	end := func(zone string, id *uuid.UUID) (string, string, error) {
+		zone = domain.NormalizeZoneCode(zone)
+		switch {
+		case zone != "" && id != nil:
+			return "", "", apperrors.ValidationError("a lane end is a zone or a location, not both", "lane_rates", i)
+		case zone != "":
+			return domain.RateLocationZone, zone, nil
+		case id != nil:
+			return domain.RateLocationSpecific, "", nil
+		default:
+			return domain.RateLocationAny, "", nil
+		}
+	}
+	originType, originZone, err := end(lane.OriginZone, lane.OriginID)
+	if err != nil {
+		return nil, err
+	}
+	destType, destZone, err := end(lane.DestinationZone, lane.DestinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := &domain.Rate{
+		ID:                uuid.New(),
+		CustomerID:        contract.CustomerID,
+		Name:              strings.TrimSpace(lane.Name),
+		RateType:          "flat",
+		ContractID:        &contract.ID,
+		FuelScheduleID:    contract.FuelScheduleID,
+		OriginType:        originType,
+		OriginID:          lane.OriginID,
+		OriginZone:        originZone,
+		DestinationType:   destType,
+		DestinationID:     lane.DestinationID,
+		DestinationZone:   destZone,
+		ContainerSize:     orAny(lane.ContainerSize),
+		ContainerType:     orAny(strings.ToLower(lane.ContainerType)),
+		BaseRate:          lane.BaseRate,
+		FuelSurcharge:     lane.FuelSurcharge,
+		FuelSurchargeType: fuelType,
+		EffectiveDate:     contract.EffectiveDate,
+		ExpirationDate:    contract.ExpirationDate,
+		IsActive:          true,
+		CreatedAt:         contract.CreatedAt,
+		UpdatedAt:         contract.CreatedAt,
+	}
+	if rate.Name == "" {
+		rate.Name = contract.ContractNumber + " " + rate.LaneDescription()
+	}
+	return rate, nil
+}
+
+func orAny(s string) string {
+	if s = strings.TrimSpace(s); s == "" {
+		return "any"
+	}
+	return s
+}
+
+// GetContract returns a contract with its current lane rates
+func (s *RateTableService) GetContract(ctx context.Context, id uuid.UUID) (*domain.RateContract, error) {
+	contract, err := s.tableRepo.GetContract(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate contract", err)
+	}
+	if contract == nil {
+		return nil, apperrors.NotFoundError("rate contract", id.String())
+	}
+	return contract, nil
+}
+
+// ListContracts returns contracts newest first, for one customer when set
+func (s *RateTableService) ListContracts(ctx context.Context, customerID *uuid.UUID) ([]domain.RateContract, error) {
+	contracts, err := s.tableRepo.ListContracts(ctx, customerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate contracts", err)
+	}
+	return contracts, nil
+}
+
+// CreateFuelScheduleInput contains input for creating a fuel surcharge
+// schedule. Leave CustomerID nil for a default schedule.
+type CreateFuelScheduleInput struct {
+	CustomerID     *uuid.UUID                 `json:"customer_id"`
+	Name           string                     `json:"name"`
+	Region         string                     `json:"region"`
+	EffectiveDate  time.Time                  `json:"effective_date"`
+	ExpirationDate *time.Time                 `json:"expiration_date"`
+	Tiers          []domain.FuelSurchargeTier `json:"tiers"`
+}
+
+// CreateFuelSchedule creates a fuel surcharge schedule. Its tiers must
+// cover prices from the lowest tier up without gaps or overlaps.
+func (s *RateTableService) CreateFuelSchedule(ctx context.Context, input CreateFuelScheduleInput) (*domain.FuelSurchargeSchedule, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, apperrors.ValidationError("name is required", "name", nil)
+	}
+	if input.EffectiveDate.IsZero() {
+		return nil, apperrors.ValidationError("effective_date is required", "effective_date", nil)
+	}
+	if input.ExpirationDate != nil && !input.ExpirationDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("expiration_date must be after effective_date", "expiration_date", *input.ExpirationDate)
+	}
+	tiers := append([]domain.FuelSurchargeTier(nil), input.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinPrice < tiers[j].MinPrice })
+	if problem := domain.CheckTiers(tiers); problem != "" {
+		return nil, apperrors.ValidationError(problem, "tiers", nil)
+	}
+
+	now := time.Now()
+	schedule := &domain.FuelSurchargeSchedule{
+		ID:             uuid.New(),
+		CustomerID:     input.CustomerID,
+		Name:           name,
+		Region:         fuelRegion(input.Region),
+		EffectiveDate:  input.EffectiveDate,
+		ExpirationDate: input.ExpirationDate,
+		IsActive:       true,
+		Tiers:          tiers,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.tableRepo.CreateFuelSchedule(ctx, schedule); err != nil {
+		return nil, apperrors.DatabaseError("create fuel surcharge schedule", err)
+	}
+
+	s.logger.Infow("Fuel surcharge schedule created", "schedule_id", schedule.ID, "region", schedule.Region, "tiers", len(tiers))
+	return schedule, nil
+}
+
+func fuelRegion(region string) string {
+	if region = strings.ToUpper(strings.TrimSpace(region)); region == "" {
+		return defaultFuelRegion
+	}
+	return region
+}
+
+// GetFuelSchedule returns a fuel surcharge schedule with its tiers
+func (s *RateTableService) GetFuelSchedule(ctx context.Context, id uuid.UUID) (*domain.FuelSurchargeSchedule, error) {
+	schedule, err := s.tableRepo.GetFuelSchedule(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get fuel surcharge schedule", err)
+	}
+	if schedule == nil {
+		return nil, apperrors.NotFoundError("fuel surcharge schedule", id.String())
+	}
+	return schedule, nil
+}
+
+// ListFuelSchedules returns fuel surcharge schedules, for one customer and
+// the defaults when set
+func (s *RateTableService) ListFuelSchedules(ctx context.Context, customerID *uuid.UUID) ([]domain.FuelSurchargeSchedule, error) {
+	schedules, err := s.tableRepo.ListFuelSchedules(ctx, customerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list fuel surcharge schedules", err)
+	}
+	return schedules, nil
+}
+
+// RecordFuelPriceInput contains input for recording a published diesel price
+type RecordFuelPriceInput struct {
+	Region         string    `json:"region"`
+	EffectiveDate  time.Time `json:"effective_date"`
+	PricePerGallon float64   `json:"price_per_gallon"`
+	Source         string    `json:"source"`
+	CreatedBy      string    `json:"-"`
+}
+
+// RecordFuelPrice stores a diesel price, replacing any already recorded for
+// the region on that date
+func (s *RateTableService) RecordFuelPrice(ctx context.Context, input RecordFuelPriceInput) (*domain.FuelPrice, error) {
+	if input.EffectiveDate.IsZero() {
+		return nil, apperrors.ValidationError("effective_date is required", "effective_date", nil)
+	}
+	if input.PricePerGallon <= 0 {
+		return nil, apperrors.ValidationError("price_per_gallon must be positive", "price_per_gallon", input.PricePerGallon)
+	}
+	y, m, d := input.EffectiveDate.Date()
+	price := &domain.FuelPrice{
+		ID:             uuid.New(),
+		Region:         fuelRegion(input.Region),
+		EffectiveDate:  time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+		PricePerGallon: input.PricePerGallon,
+		Source:         strings.TrimSpace(input.Source),
+		CreatedBy:      input.CreatedBy,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.tableRepo.SaveFuelPrice(ctx, price); err != nil {
+		return nil, apperrors.DatabaseError("save fuel price", err)
+	}
+
+	s.logger.Infow("Fuel price recorded", "region", price.Region, "date", price.EffectiveDate.Format("2006-01-02"), "price", price.PricePerGallon)
+	return price, nil
+}
+
+// ListFuelPrices returns a region's diesel prices newest first
+func (s *RateTableService) ListFuelPrices(ctx context.Context, region string, limit int) ([]domain.FuelPrice, error) {
+	prices, err := s.tableRepo.ListFuelPrices(ctx, fuelRegion(region), limit)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list fuel prices", err)
+	}
+	return prices, nil
+}
+
+// CreateAccessorialRateInput contains input for adding an accessorial to a
+// price list. Leave CustomerID nil for the default price list.
+type CreateAccessorialRateInput struct {
+	CustomerID     *uuid.UUID        `json:"customer_id"`
+	ChargeType     domain.ChargeType `json:"charge_type"`
+	Description    string            `json:"description"`
+	RateType       string            `json:"rate_type"` // flat, per_hour, per_day or per_mile
+	Rate           float64           `json:"rate"`
+	MinCharge      float64           `json:"min_charge"`
+	MaxCharge      float64           `json:"max_charge"`
+	FreeTime       int               `json:"free_time"` // Minutes
+	EffectiveDate  time.Time         `json:"effective_date"`
+	ExpirationDate *time.Time        `json:"expiration_date"`
+}
+
+// CreateAccessorialRate adds an accessorial to a customer's price list or
+// the default one. A charge type already priced on the list is changed with
+// a rate amendment instead, which keeps its versions.
+func (s *RateTableService) CreateAccessorialRate(ctx context.Context, input CreateAccessorialRateInput) (*domain.AccessorialRate, error) {
+	if input.ChargeType == "" {
+		return nil, apperrors.ValidationError("charge_type is required", "charge_type", nil)
+	}
+	if input.ChargeType == domain.ChargeTypeLineHaul || input.ChargeType == domain.ChargeTypeFuelSurcharge {
+		return nil, apperrors.ValidationError("line haul and fuel are priced by contract lane rates", "charge_type", input.ChargeType)
+	}
+	switch input.RateType {
+	case "flat", "per_hour", "per_day", "per_mile":
+	default:
+		return nil, apperrors.ValidationError("rate_type must be flat, per_hour, per_day or per_mile", "rate_type", input.RateType)
+	}
+	for field, v := range map[string]float64{"rate": input.Rate, "min_charge": input.MinCharge, "max_charge": input.MaxCharge} {
+		if v < 0 {
+			return nil, apperrors.ValidationError(field+" cannot be negative", field, v)
+		}
+	}
+	if input.MaxCharge > 0 && input.MaxCharge < input.MinCharge {
+		return nil, apperrors.ValidationError("max_charge cannot be below min_charge", "max_charge", input.MaxCharge)
+	}
+	if input.EffectiveDate.IsZero() {
+		input.EffectiveDate = time.Now()
+	}
+	if input.ExpirationDate != nil && !input.ExpirationDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("expiration_date must be after effective_date", "expiration_date", *input.ExpirationDate)
+	}
+
+	customerID := uuid.Nil
+	if input.CustomerID != nil {
+		customerID = *input.CustomerID
+	}
+	current, err := s.rateRepo.CurrentAccessorialRates(ctx, customerID, input.EffectiveDate)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get accessorial rates", err)
+	}
+	for _, existing := range current {
+		if existing.ChargeType == input.ChargeType && sameCustomer(existing.CustomerID, input.CustomerID) {
+			return nil, apperrors.ConflictError("the price list already has a " + string(input.ChargeType) + " rate; amend rate " + existing.ID.String() + " instead")
+		}
+	}
+
+	now := time.Now()
+	rate := &domain.AccessorialRate{
+		ID:             uuid.New(),
+		CustomerID:     input.CustomerID,
+		ChargeType:     input.ChargeType,
+		Description:    strings.TrimSpace(input.Description),
+		RateType:       input.RateType,
+		Rate:           input.Rate,
+		MinCharge:      input.MinCharge,
+		MaxCharge:      input.MaxCharge,
+		FreeTime:       input.FreeTime,
+		EffectiveDate:  input.EffectiveDate,
+		ExpirationDate: input.ExpirationDate,
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.tableRepo.CreateAccessorialRate(ctx, rate); err != nil {
+		return nil, apperrors.DatabaseError("create accessorial rate", err)
+	}
+	return rate, nil
+}
+
+func sameCustomer(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// AccessorialPriceList returns the accessorial rates a customer is charged
+// at the given time: its own, or the default where it has none
+func (s *RateTableService) AccessorialPriceList(ctx context.Context, customerID uuid.UUID, at time.Time) ([]domain.AccessorialRate, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rates, err := s.rateRepo.CurrentAccessorialRates(ctx, customerID, at)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get accessorial rates", err)
+	}
+	return rates, nil
+}
+
+// QuoteRate prices a move from the customer's rate tables: the lane rate
+// that matches it, fuel at the contract's schedule when it has one, and the
+// expected accessorials at the customer's price list
+func (s *RateTableService) QuoteRate(ctx context.Context, request domain.RateQuoteRequest) (*domain.RateQuote, error) {
+	if request.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer_id is required", "customer_id", nil)
+	}
+	if request.At.IsZero() {
+		request.At = time.Now()
+	}
+
+	rate, err := s.rateRepo.FindContractRate(ctx, request.ContractRateQuery)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find contract rate", err)
+	}
+	if rate == nil {
+		return nil, apperrors.NotFoundError("contract rate", request.CustomerID.String())
+	}
+	fuel, err := applyFuelSchedule(ctx, s.tableRepo, rate, request.At)
+	if err != nil {
+		return nil, err
+	}
+
+	accessorials := make(map[domain.ChargeType]domain.AccessorialRate)
+	if len(request.Accessorials) > 0 {
+		current, err := s.rateRepo.CurrentAccessorialRates(ctx, request.CustomerID, request.At)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get accessorial rates", err)
+		}
+		for _, a := range current {
+			accessorials[a.ChargeType] = a
+		}
+	}
+
+	quote := domain.QuoteRate(rate, accessorials, request.Accessorials)
+	quote.At = request.At
+	quote.Fuel = fuel
+	return quote, nil
+}
+
+// applyFuelSchedule prices a rate's fuel surcharge off its contract's
+// schedule and the diesel price in effect at the given time. It returns nil
+// and leaves the rate's own surcharge when the rate has no schedule or the
+// schedule has no price or tier for the time.
+func applyFuelSchedule(ctx context.Context, tables repository.RateTableRepository, rate *domain.Rate, at time.Time) (*domain.FuelSurchargeBasis, error) {
+	if rate.FuelScheduleID == nil {
+		return nil, nil
+	}
+	schedule, err := tables.GetFuelSchedule(ctx, *rate.FuelScheduleID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get fuel surcharge schedule", err)
+	}
+	if schedule == nil || !schedule.IsEffective(at) {
+		return nil, nil
+	}
+	price, err := tables.CurrentFuelPrice(ctx, schedule.Region, at)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get fuel price", err)
+	}
+	if price == nil {
+		return nil, nil
+	}
+	percent, ok := schedule.PercentAt(price.PricePerGallon)
+	if !ok {
+		return nil, nil
+	}
+
+	basis := &domain.FuelSurchargeBasis{
+		ScheduleID:   schedule.ID,
+		ScheduleName: schedule.Name,
+		Region:       schedule.Region,
+		DieselPrice:  price.PricePerGallon,
+		PriceDate:    price.EffectiveDate,
+		Percent:      percent,
+	}
+	rate.ApplyFuelSchedule(basis)
+	return basis, nil
+}
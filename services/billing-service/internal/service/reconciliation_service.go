@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// reconciliationLookbackDays is how far back the nightly run re-checks
+	// finished trips, so late invoices and corrections clear their issues
+	reconciliationLookbackDays = 30
+
+	// reconciliationInvoiceGraceDays is how long a finished trip has to be
+	// invoiced before its charges are reported as not invoiced
+	reconciliationInvoiceGraceDays = 3
+
+	// nightlyReconciliationUser is recorded as the runner of scheduled runs
+	nightlyReconciliationUser = "nightly-reconciliation"
+)
+
+// ReconciliationService cross-checks finished trips against the charges and
+// invoices billing them, and keeps what does not match as finance exceptions
+type ReconciliationService struct {
+	reconRepo     repository.ReconciliationRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewReconciliationService creates a new reconciliation service
+func NewReconciliationService(reconRepo repository.ReconciliationRepository, eventProducer *kafka.Producer, log *logger.Logger) *ReconciliationService {
+	return &ReconciliationService{reconRepo: reconRepo, eventProducer: eventProducer, logger: log}
+}
+
+// Reconcile checks the trips finished in [from, to). Issues found for the
+// first time are opened and published for finance; open issues on those
+// trips that are no longer found are cleared.
+func (s *ReconciliationService) Reconcile(ctx context.Context, from, to time.Time, runBy string) (*domain.ReconciliationRun, error) {
+	if !to.After(from) {
+		return nil, apperrors.ValidationError("to must be after from", "to", to)
+	}
+
+	run := &domain.ReconciliationRun{
+		ID:          uuid.New(),
+		PeriodStart: from,
+		PeriodEnd:   to,
+		RunBy:       runBy,
+		StartedAt:   time.Now(),
+	}
+
+	trips, err := s.reconRepo.ListFinishedTrips(ctx, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list finished trips", err)
+	}
+
+	invoiceCutoff := run.StartedAt.AddDate(0, 0, -reconciliationInvoiceGraceDays)
+	var findings []domain.ReconciliationIssue
+	checked := make([]uuid.UUID, 0, len(trips))
+	for _, trip := range trips {
+		checked = append(checked, trip.TripID)
+		for _, issue := range domain.ReconcileTrip(trip, invoiceCutoff) {
+			issue.ID = uuid.New()
+			issue.Status = domain.ReconIssueStatusOpen
+			issue.DetectedAt = run.StartedAt
+			switch issue.Type {
+			case domain.ReconIssueDoubleBilled:
+				run.DoubleBilledAmount += issue.Amount
+			default:
+				run.UnbilledAmount += issue.Amount
+			}
+			findings = append(findings, issue)
+		}
+	}
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.TripsChecked = len(trips)
+	run.IssuesFound = len(findings)
+
+	opened, err := s.reconRepo.SaveRun(ctx, run, findings, checked)
+	if err != nil {
+		return nil, apperrors.DatabaseError("save reconciliation run", err)
+	}
+
+	for _, issue := range opened {
+		data := map[string]interface{}{
+			"issue_id":    issue.ID.String(),
+			"trip_id":     issue.TripID.String(),
+			"trip_number": issue.TripNumber,
+			"issue_type":  issue.Type,
+			"charge_type": issue.ChargeType,
+			"amount":      issue.Amount,
+			"detail":      issue.Detail,
+		}
+		if issue.CustomerID != nil {
+			data["customer_id"] = issue.CustomerID.String()
+		}
+		event := kafka.NewEvent(kafka.Topics.BillingReconciliationIssue, "billing-service", data)
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.BillingReconciliationIssue, event)
+	}
+
+	s.logger.Infow("Billing reconciliation run",
+		"run_id", run.ID,
+		"period_start", from,
+		"period_end", to,
+		"trips", run.TripsChecked,
+		"found", run.IssuesFound,
+		"opened", run.IssuesOpened,
+		"cleared", run.IssuesCleared,
+		"unbilled", run.UnbilledAmount,
+		"double_billed", run.DoubleBilledAmount,
+	)
+	return run, nil
+}
+
+// ReconcileNightly re-checks the trips finished over the lookback window
+// ending at now
+func (s *ReconciliationService) ReconcileNightly(ctx context.Context, now time.Time) (*domain.ReconciliationRun, error) {
+	return s.Reconcile(ctx, now.AddDate(0, 0, -reconciliationLookbackDays), now, nightlyReconciliationUser)
+}
+
+// Start runs the nightly reconciliation every interval until ctx is cancelled
+func (s *ReconciliationService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.ReconcileNightly(ctx, now); err != nil {
+				s.logger.Errorw("Billing reconciliation failed", "error", err)
+			}
+		}
+	}
+}
+
+// ListRuns returns the most recent reconciliation runs
+func (s *ReconciliationService) ListRuns(ctx context.Context, limit int) ([]domain.ReconciliationRun, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+	runs, err := s.reconRepo.ListRuns(ctx, limit)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list reconciliation runs", err)
+	}
+	return runs, nil
+}
+
+// ListIssues returns finance exceptions raised by reconciliation
+func (s *ReconciliationService) ListIssues(ctx context.Context, filter domain.ReconciliationIssueFilter) ([]domain.ReconciliationIssue, error) {
+	issues, err := s.reconRepo.ListIssues(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list reconciliation issues", err)
+	}
+	return issues, nil
+}
+
+// CloseIssueInput contains input for closing a finance exception
+type CloseIssueInput struct {
+	IssueID  uuid.UUID
+	Status   domain.ReconciliationIssueStatus // RESOLVED or DISMISSED
+	Notes    string
+	ClosedBy string
+}
+
+// CloseIssue records finance's resolution of an open issue. A resolved issue
+// that is still found is opened again by the next run; a dismissed one is
+// not raised again for the trip.
+func (s *ReconciliationService) CloseIssue(ctx context.Context, input CloseIssueInput) (*domain.ReconciliationIssue, error) {
+	if input.Status != domain.ReconIssueStatusResolved && input.Status != domain.ReconIssueStatusDismissed {
+		return nil, apperrors.ValidationError("status must be RESOLVED or DISMISSED", "status", input.Status)
+	}
+	notes := strings.TrimSpace(input.Notes)
+	if notes == "" {
+		return nil, apperrors.ValidationError("notes are required", "notes", nil)
+	}
+
+	issue, err := s.reconRepo.GetIssue(ctx, input.IssueID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get reconciliation issue", err)
+	}
+	if issue == nil {
+		return nil, apperrors.NotFoundError("reconciliation issue", input.IssueID.String())
+	}
+	if issue.Status != domain.ReconIssueStatusOpen {
+		return nil, apperrors.InvalidStateError(string(issue.Status), string(domain.ReconIssueStatusOpen))
+	}
+
+	now := time.Now()
+	issue.Status = input.Status
+	issue.ResolvedBy = input.ClosedBy
+	issue.ResolvedAt = &now
+	issue.ResolutionNotes = notes
+
+	closed, err := s.reconRepo.CloseIssue(ctx, issue)
+	if err != nil {
+		return nil, apperrors.DatabaseError("close reconciliation issue", err)
+	}
+	if !closed {
+		return nil, apperrors.ConflictError("reconciliation issue was closed by someone else")
+	}
+
+	s.logger.Infow("Reconciliation issue closed",
+		"issue_id", issue.ID,
+		"trip_number", issue.TripNumber,
+		"type", issue.Type,
+		"status", issue.Status,
+		"closed_by", input.ClosedBy,
+	)
+	return issue, nil
+}
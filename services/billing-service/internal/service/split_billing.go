@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// SplitBillingService bills a container split across delivery points (a
+// partial devan) per stop, sharing its container-wide charges in proportion
+// to what each stop received
+type SplitBillingService struct {
+	splitRepo repository.SplitBillingRepository
+	logger    *logger.Logger
+}
+
+// NewSplitBillingService creates a new split billing service
+func NewSplitBillingService(splitRepo repository.SplitBillingRepository, log *logger.Logger) *SplitBillingService {
+	return &SplitBillingService{splitRepo: splitRepo, logger: log}
+}
+
+// PlanTripBilling returns the per-stop invoice lines for each order the
+// trip delivers across stops. An order delivered at a single stop is
+// planned too, with the whole of each charge on that stop.
+func (s *SplitBillingService) PlanTripBilling(ctx context.Context, tripID uuid.UUID) ([]domain.SplitBillingPlan, error) {
+	orders, err := s.splitRepo.ListSplitDeliveryOrders(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list split delivery orders", err)
+	}
+	if orders == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+
+	plans := make([]domain.SplitBillingPlan, 0, len(orders))
+	for _, order := range orders {
+		plans = append(plans, *domain.PlanSplitBilling(order))
+	}
+	return plans, nil
+}
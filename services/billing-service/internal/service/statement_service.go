@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	"github.com/draymaster/shared/pkg/document"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// StatementService compiles monthly customer statements of open invoices,
+// aging, credits and disputes, and renders them for sending
+type StatementService struct {
+	statementRepo repository.StatementRepository
+	documents     *document.Generator
+	logger        *logger.Logger
+}
+
+// NewStatementService creates a new customer statement service
+func NewStatementService(statementRepo repository.StatementRepository, documents *document.Generator, log *logger.Logger) *StatementService {
+	return &StatementService{
+		statementRepo: statementRepo,
+		documents:     documents,
+		logger:        log,
+	}
+}
+
+// GenerateStatement compiles a customer's statement for a month, replacing
+// one already generated for it. The current month can be generated as an
+// interim statement; it is regenerated when the month closes.
+func (s *StatementService) GenerateStatement(ctx context.Context, customerID uuid.UUID, year int, month time.Month, generatedBy string) (*domain.CustomerStatement, error) {
+	periodStart, periodEnd, err := statementPeriod(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.statementRepo.GetCustomerContact(ctx, customerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get customer", err)
+	}
+	if customer == nil {
+		return nil, apperrors.NotFoundError("customer", customerID.String())
+	}
+
+	cutoff := domain.StatementCutoff(periodEnd)
+	invoices, err := s.statementRepo.ListInvoicesAsOf(ctx, customerID, cutoff)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list invoices", err)
+	}
+	credits, err := s.statementRepo.ListUnappliedCredits(ctx, customerID, cutoff)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list credits", err)
+	}
+	payments, err := s.statementRepo.SumPayments(ctx, customerID, periodStart, cutoff)
+	if err != nil {
+		return nil, apperrors.DatabaseError("sum payments", err)
+	}
+
+	now := time.Now()
+	stmt := domain.BuildCustomerStatement(customerID, customer.Name, periodStart, periodEnd, invoices, credits, payments)
+	stmt.ID = uuid.New()
+	stmt.GeneratedBy = generatedBy
+	stmt.CreatedAt = now
+	stmt.UpdatedAt = now
+
+	if err := s.statementRepo.Save(ctx, stmt); err != nil {
+		return nil, apperrors.DatabaseError("save statement", err)
+	}
+
+	s.logger.Infow("Customer statement generated",
+		"statement_number", stmt.StatementNumber,
+		"customer_id", customerID,
+		"open_invoices", len(stmt.Invoices),
+		"amount_due", stmt.AmountDue,
+		"disputed", stmt.TotalDisputed,
+	)
+	return stmt, nil
+}
+
+// GenerateMonthlyStatements generates the month's statement for every
+// customer with an open balance or a payment in the month. Failures are
+// logged and the run continues; the number generated is returned.
+func (s *StatementService) GenerateMonthlyStatements(ctx context.Context, year int, month time.Month, generatedBy string) (int, error) {
+	periodStart, periodEnd, err := statementPeriod(year, month)
+	if err != nil {
+		return 0, err
+	}
+
+	customerIDs, err := s.statementRepo.ListCustomersWithActivity(ctx, periodStart, domain.StatementCutoff(periodEnd))
+	if err != nil {
+		return 0, apperrors.DatabaseError("list customers", err)
+	}
+
+	generated := 0
+	for _, customerID := range customerIDs {
+		if ctx.Err() != nil {
+			return generated, ctx.Err()
+		}
+		if _, err := s.GenerateStatement(ctx, customerID, year, month, generatedBy); err != nil {
+			s.logger.Errorw("Failed to generate customer statement",
+				"customer_id", customerID,
+				"period", periodStart.Format("2006-01"),
+				"error", err,
+			)
+			continue
+		}
+		generated++
+	}
+
+	s.logger.Infow("Monthly statements generated",
+		"period", periodStart.Format("2006-01"),
+		"customers", len(customerIDs),
+		"generated", generated,
+	)
+	return generated, nil
+}
+
+// GetStatement returns a customer's statement for a month
+func (s *StatementService) GetStatement(ctx context.Context, customerID uuid.UUID, year int, month time.Month) (*domain.CustomerStatement, error) {
+	periodStart, _, err := statementPeriod(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.statementRepo.Get(ctx, customerID, periodStart)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get statement", err)
+	}
+	if stmt == nil {
+		return nil, apperrors.NotFoundError("statement", fmt.Sprintf("%s/%s", customerID, periodStart.Format("2006-01")))
+	}
+	return stmt, nil
+}
+
+// ListStatements returns a customer's statements, newest period first
+func (s *StatementService) ListStatements(ctx context.Context, customerID uuid.UUID) ([]domain.CustomerStatement, error) {
+	statements, err := s.statementRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list statements", err)
+	}
+	return statements, nil
+}
+
+// RenderStatement renders a statement as a PDF addressed to the customer's
+// billing contact
+func (s *StatementService) RenderStatement(ctx context.Context, tenantID string, stmt *domain.CustomerStatement) (*document.Document, error) {
+	billTo := document.Party{Name: stmt.CustomerName}
+	customer, err := s.statementRepo.GetCustomerContact(ctx, stmt.CustomerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get customer", err)
+	}
+	if customer != nil {
+		billTo = document.Party{
+			Name:         customer.Name,
+			AddressLine1: customer.Address,
+			AddressLine2: cityStateZip(customer),
+			Phone:        customer.Phone,
+			Email:        customer.Email,
+		}
+	}
+
+	invoices := make([]document.StatementInvoice, 0, len(stmt.Invoices))
+	for _, inv := range stmt.Invoices {
+		reference := inv.PONumber
+		if reference == "" {
+			reference = inv.BOLNumber
+		}
+		invoices = append(invoices, document.StatementInvoice{
+			Number:         inv.InvoiceNumber,
+			Date:           inv.InvoiceDate,
+			DueDate:        inv.DueDate,
+			Reference:      reference,
+			Total:          inv.TotalAmount,
+			Paid:           inv.PaidAmount,
+			BalanceDue:     inv.BalanceDue,
+			DisputedAmount: inv.DisputedAmount,
+			DaysPastDue:    inv.DaysPastDue,
+		})
+	}
+
+	credits := make([]document.StatementCredit, 0, len(stmt.Credits))
+	for _, credit := range stmt.Credits {
+		credits = append(credits, document.StatementCredit{
+			Number: credit.CreditNumber,
+			Date:   credit.CreditDate,
+			Reason: credit.Reason,
+			Amount: credit.Amount,
+		})
+	}
+
+	return s.documents.Render(ctx, document.Request{
+		TenantID: tenantID,
+		Kind:     document.KindStatement,
+		Title:    "Statement of Account",
+		Filename: "statement-" + stmt.StatementNumber,
+		Data: document.StatementData{
+			Number:      stmt.StatementNumber,
+			Date:        stmt.UpdatedAt,
+			PeriodStart: stmt.PeriodStart,
+			PeriodEnd:   stmt.PeriodEnd,
+			Currency:    stmt.Currency,
+			BillTo:      billTo,
+			Invoices:    invoices,
+			Credits:     credits,
+			Aging: document.StatementAging{
+				Current:    stmt.Aging.Current,
+				Days1To30:  stmt.Aging.Days1To30,
+				Days31To60: stmt.Aging.Days31To60,
+				Days61To90: stmt.Aging.Days61To90,
+				Over90:     stmt.Aging.Over90Days,
+			},
+			TotalOpen:        stmt.TotalOpen,
+			TotalCredits:     stmt.TotalCredits,
+			TotalDisputed:    stmt.TotalDisputed,
+			AmountDue:        stmt.AmountDue,
+			PaymentsInPeriod: stmt.PaymentsInPeriod,
+		},
+	})
+}
+
+// cityStateZip formats the second address line, e.g. "Long Beach, CA 90802"
+func cityStateZip(c *domain.CustomerContact) string {
+	stateZip := strings.TrimSpace(c.State + " " + c.Zip)
+	if c.City != "" && stateZip != "" {
+		return c.City + ", " + stateZip
+	}
+	return c.City + stateZip
+}
+
+// statementPeriod validates a statement month and returns its first and last day
+func statementPeriod(year int, month time.Month) (time.Time, time.Time, error) {
+	if month < time.January || month > time.December {
+		return time.Time{}, time.Time{}, apperrors.ValidationError("month must be between 1 and 12", "month", int(month))
+	}
+	start, end := domain.StatementPeriod(year, month)
+	if start.After(time.Now()) {
+		return time.Time{}, time.Time{}, apperrors.ValidationError("statement period has not started", "period", start.Format("2006-01"))
+	}
+	return start, end, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// TaxReportingService keeps the payee entities owner-operators are paid
+// through and exports their paid settlements for 1099-NEC filing
+type TaxReportingService struct {
+	taxRepo repository.TaxReportingRepository
+	logger  *logger.Logger
+}
+
+// NewTaxReportingService creates a new tax reporting service
+func NewTaxReportingService(taxRepo repository.TaxReportingRepository, log *logger.Logger) *TaxReportingService {
+	return &TaxReportingService{taxRepo: taxRepo, logger: log}
+}
+
+// SavePayeeEntityInput contains a payee's W-9 details
+type SavePayeeEntityInput struct {
+	ID                *uuid.UUID       `json:"id"` // Set to replace an existing payee
+	DriverID          uuid.UUID        `json:"driver_id"`
+	LegalName         string           `json:"legal_name"`
+	BusinessName      string           `json:"business_name"`
+	TaxIDType         domain.TaxIDType `json:"tax_id_type"`
+	TaxID             string           `json:"tax_id"`
+	AddressLine1      string           `json:"address_line1"`
+	AddressLine2      string           `json:"address_line2"`
+	City              string           `json:"city"`
+	State             string           `json:"state"`
+	Zip               string           `json:"zip"`
+	BackupWithholding bool             `json:"backup_withholding"`
+}
+
+// SavePayeeEntity stores an owner-operator's payee entity from their W-9.
+// The tax ID is kept as nine digits and never returned in full.
+func (s *TaxReportingService) SavePayeeEntity(ctx context.Context, input SavePayeeEntityInput) (*domain.PayeeEntity, error) {
+	if input.DriverID == uuid.Nil {
+		return nil, apperrors.ValidationError("driver_id is required", "driver_id", nil)
+	}
+	legalName := strings.TrimSpace(input.LegalName)
+	if legalName == "" {
+		return nil, apperrors.ValidationError("legal_name is required", "legal_name", nil)
+	}
+	if input.TaxIDType != domain.TaxIDTypeSSN && input.TaxIDType != domain.TaxIDTypeEIN {
+		return nil, apperrors.ValidationError("tax_id_type must be SSN or EIN", "tax_id_type", input.TaxIDType)
+	}
+	taxID := strings.NewReplacer("-", "", " ", "").Replace(input.TaxID)
+	if len(taxID) != 9 || strings.Trim(taxID, "0123456789") != "" {
+		// The value itself is not echoed back
+		return nil, apperrors.ValidationError("tax_id must be nine digits", "tax_id", nil)
+	}
+
+	now := time.Now()
+	payee := &domain.PayeeEntity{
+		ID:                uuid.New(),
+		DriverID:          input.DriverID,
+		Classification:    domain.WorkerClassificationOwnerOperator,
+		LegalName:         legalName,
+		BusinessName:      strings.TrimSpace(input.BusinessName),
+		TaxIDType:         input.TaxIDType,
+		TaxID:             taxID,
+		AddressLine1:      strings.TrimSpace(input.AddressLine1),
+		AddressLine2:      strings.TrimSpace(input.AddressLine2),
+		City:              strings.TrimSpace(input.City),
+		State:             strings.TrimSpace(input.State),
+		Zip:               strings.TrimSpace(input.Zip),
+		BackupWithholding: input.BackupWithholding,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if input.ID != nil {
+		existing, err := s.taxRepo.GetPayeeEntities(ctx, []uuid.UUID{*input.ID})
+		if err != nil {
+			return nil, apperrors.DatabaseError("get payee entity", err)
+		}
+		current, ok := existing[*input.ID]
+		if !ok {
+			return nil, apperrors.NotFoundError("payee entity", input.ID.String())
+		}
+		payee.ID = current.ID
+		payee.CreatedAt = current.CreatedAt
+	}
+
+	if err := s.taxRepo.SavePayeeEntity(ctx, payee); err != nil {
+		return nil, apperrors.DatabaseError("save payee entity", err)
+	}
+
+	s.logger.Infow("Payee entity saved",
+		"payee_entity_id", payee.ID,
+		"driver_id", payee.DriverID,
+		"tax_id_type", payee.TaxIDType,
+	)
+	return payee, nil
+}
+
+// Form1099NECExport is a tax year's 1099-NEC data
+type Form1099NECExport struct {
+	TaxYear int                  `json:"tax_year"`
+	Forms   []domain.Form1099NEC `json:"forms"`
+	// Owner-operator entities over the threshold with no W-9 on file
+	MissingW9 []uuid.UUID `json:"missing_w9"`
+}
+
+// Export1099NEC builds 1099-NEC data from the settlements paid in the tax
+// year, with the backup withholding recorded on them
+func (s *TaxReportingService) Export1099NEC(ctx context.Context, taxYear int) (*Form1099NECExport, error) {
+	settlements, err := s.taxRepo.ListPaidSettlements(ctx, taxYear)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list paid settlements", err)
+	}
+	summaries := domain.SummarizeSettlementsByEntity(taxYear, settlements)
+
+	ids := make([]uuid.UUID, 0, len(summaries))
+	for _, summary := range summaries {
+		ids = append(ids, summary.EntityID)
+	}
+	payees, err := s.taxRepo.GetPayeeEntities(ctx, ids)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get payee entities", err)
+	}
+
+	forms, missing := domain.Build1099NECExport(taxYear, summaries, payees)
+	s.logger.Infow("1099-NEC export built",
+		"tax_year", taxYear,
+		"forms", len(forms),
+		"missing_w9", len(missing),
+	)
+	return &Form1099NECExport{TaxYear: taxYear, Forms: forms, MissingW9: missing}, nil
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/billing-service/internal/domain"
+	"github.com/draymaster/services/billing-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// gateActivities are the stop activities that move a container through a
+// terminal's gate
+var gateActivities = map[string]bool{
+	"PICKUP_LOADED":  true,
+	"PICKUP_EMPTY":   true,
+	"DROP_LOADED":    true,
+	"DROP_EMPTY":     true,
+	"DELIVER_LOADED": true,
+}
+
+// TerminalFeeService keeps the gate, exam and chassis flip fees terminals
+// charge, and attaches the fee in effect to an order when dispatch reports
+// the stop that incurred it, billing the customer as their pass-through
+// setting says
+type TerminalFeeService struct {
+	feeRepo repository.TerminalFeeRepository
+	logger  *logger.Logger
+}
+
+// NewTerminalFeeService creates a new terminal fee service
+func NewTerminalFeeService(feeRepo repository.TerminalFeeRepository, log *logger.Logger) *TerminalFeeService {
+	return &TerminalFeeService{feeRepo: feeRepo, logger: log}
+}
+
+// CreateTerminalFeeInput contains input for adding a fee to a terminal's
+// schedule
+type CreateTerminalFeeInput struct {
+	TerminalID    uuid.UUID              `json:"terminal_id"`
+	FeeType       domain.TerminalFeeType `json:"fee_type"`
+	Description   string                 `json:"description"`
+	ContainerSize string                 `json:"container_size"` // 20, 40, 45 or empty for any
+	Amount        float64                `json:"amount"`
+	EffectiveDate time.Time              `json:"effective_date"`
+	EndDate       *time.Time             `json:"end_date"`
+	CreatedBy     string                 `json:"-"`
+}
+
+// CreateFee adds a fee to a terminal's schedule. A fee already in effect for
+// the same terminal, type and size ends when the new one takes effect, so a
+// price change is a new fee rather than an edit and charges already attached
+// keep the fee they were priced from.
+func (s *TerminalFeeService) CreateFee(ctx context.Context, input CreateTerminalFeeInput) (*domain.TerminalFee, error) {
+	if input.TerminalID == uuid.Nil {
+		return nil, apperrors.ValidationError("terminal_id is required", "terminal_id", nil)
+	}
+	if !input.FeeType.Valid() {
+		return nil, apperrors.ValidationError("fee_type must be GATE, EXAM or CHASSIS_FLIP", "fee_type", input.FeeType)
+	}
+	size := strings.TrimSpace(input.ContainerSize)
+	switch size {
+	case "", "20", "40", "45":
+	default:
+		return nil, apperrors.ValidationError("container_size must be 20, 40, 45 or empty for any size", "container_size", input.ContainerSize)
+	}
+	if input.Amount <= 0 {
+		return nil, apperrors.ValidationError("amount must be greater than zero", "amount", input.Amount)
+	}
+	if input.EffectiveDate.IsZero() {
+		return nil, apperrors.ValidationError("effective_date is required", "effective_date", nil)
+	}
+	if input.EndDate != nil && !input.EndDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("end_date must be after effective_date", "end_date", input.EndDate)
+	}
+
+	existing, err := s.feeRepo.ListFees(ctx, &input.TerminalID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list terminal fees", err)
+	}
+	for _, fee := range existing {
+		if fee.FeeType == input.FeeType && fee.ContainerSize == size && !fee.EffectiveDate.Before(input.EffectiveDate) {
+			return nil, apperrors.ConflictError(fmt.Sprintf("a %s fee already takes effect on %s; new fees must start after the latest one",
+				input.FeeType, fee.EffectiveDate.Format("2006-01-02")))
+		}
+	}
+
+	description := strings.TrimSpace(input.Description)
+	if description == "" {
+		description = defaultTerminalFeeDescription(input.FeeType)
+	}
+	now := time.Now()
+	fee := &domain.TerminalFee{
+		ID:            uuid.New(),
+		TerminalID:    input.TerminalID,
+		FeeType:       input.FeeType,
+		Description:   description,
+		ContainerSize: size,
+		Amount:        input.Amount,
+		EffectiveDate: input.EffectiveDate,
+		EndDate:       input.EndDate,
+		CreatedBy:     input.CreatedBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.feeRepo.CreateFee(ctx, fee); err != nil {
+		return nil, apperrors.DatabaseError("create terminal fee", err)
+	}
+
+	s.logger.Infow("Terminal fee created",
+		"fee_id", fee.ID,
+		"terminal_id", fee.TerminalID,
+		"fee_type", fee.FeeType,
+		"container_size", fee.ContainerSize,
+		"amount", fee.Amount,
+		"effective_date", fee.EffectiveDate,
+	)
+	return fee, nil
+}
+
+// UpdateTerminalFeeInput contains the parts of a fee that can change once it
+// is on the schedule
+type UpdateTerminalFeeInput struct {
+	Description string     `json:"description"`
+	EndDate     *time.Time `json:"end_date"` // Nil leaves the fee open-ended
+}
+
+// UpdateFee changes a fee's description or end date. The amount cannot
+// change; a new price is a new fee from the date it takes effect.
+func (s *TerminalFeeService) UpdateFee(ctx context.Context, id uuid.UUID, input UpdateTerminalFeeInput) (*domain.TerminalFee, error) {
+	fee, err := s.feeRepo.GetFee(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal fee", err)
+	}
+	if fee == nil {
+		return nil, apperrors.NotFoundError("terminal fee", id.String())
+	}
+	if input.EndDate != nil && !input.EndDate.After(fee.EffectiveDate) {
+		return nil, apperrors.ValidationError("end_date must be after effective_date", "end_date", input.EndDate)
+	}
+
+	if description := strings.TrimSpace(input.Description); description != "" {
+		fee.Description = description
+	}
+	fee.EndDate = input.EndDate
+	fee.UpdatedAt = time.Now()
+	if err := s.feeRepo.UpdateFee(ctx, fee); err != nil {
+		return nil, apperrors.DatabaseError("update terminal fee", err)
+	}
+	return fee, nil
+}
+
+// ListFees returns the fee schedules, every version, for one terminal or
+// all of them when terminalID is nil
+func (s *TerminalFeeService) ListFees(ctx context.Context, terminalID *uuid.UUID) ([]domain.TerminalFee, error) {
+	fees, err := s.feeRepo.ListFees(ctx, terminalID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list terminal fees", err)
+	}
+	return fees, nil
+}
+
+// SetPassThroughInput contains input for setting how a customer is billed
+// for one terminal fee type
+type SetPassThroughInput struct {
+	CustomerID uuid.UUID              `json:"customer_id"`
+	FeeType    domain.TerminalFeeType `json:"fee_type"`
+	Mode       domain.PassThroughMode `json:"mode"`
+	MarkupPct  float64                `json:"markup_pct"`
+	UpdatedBy  string                 `json:"-"`
+}
+
+// SetPassThrough sets how a customer is billed for a terminal fee type. It
+// applies to fees attached from then on.
+func (s *TerminalFeeService) SetPassThrough(ctx context.Context, input SetPassThroughInput) (*domain.FeePassThrough, error) {
+	if input.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer_id is required", "customer_id", nil)
+	}
+	if !input.FeeType.Valid() {
+		return nil, apperrors.ValidationError("fee_type must be GATE, EXAM or CHASSIS_FLIP", "fee_type", input.FeeType)
+	}
+	if !input.Mode.Valid() {
+		return nil, apperrors.ValidationError("mode must be AT_COST, MARKUP or ABSORB", "mode", input.Mode)
+	}
+	if input.Mode == domain.PassThroughMarkup {
+		if input.MarkupPct <= 0 || input.MarkupPct > 500 {
+			return nil, apperrors.ValidationError("markup_pct must be between 0 and 500", "markup_pct", input.MarkupPct)
+		}
+	} else {
+		input.MarkupPct = 0
+	}
+
+	setting := &domain.FeePassThrough{
+		CustomerID: input.CustomerID,
+		FeeType:    input.FeeType,
+		Mode:       input.Mode,
+		MarkupPct:  input.MarkupPct,
+		UpdatedBy:  input.UpdatedBy,
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.feeRepo.SavePassThrough(ctx, setting); err != nil {
+		return nil, apperrors.DatabaseError("save fee pass-through", err)
+	}
+
+	s.logger.Infow("Fee pass-through set",
+		"customer_id", setting.CustomerID,
+		"fee_type", setting.FeeType,
+		"mode", setting.Mode,
+		"markup_pct", setting.MarkupPct,
+		"updated_by", setting.UpdatedBy,
+	)
+	return setting, nil
+}
+
+// ListPassThroughs returns a customer's pass-through settings. Fee types
+// without one are billed at cost.
+func (s *TerminalFeeService) ListPassThroughs(ctx context.Context, customerID uuid.UUID) ([]domain.FeePassThrough, error) {
+	settings, err := s.feeRepo.ListPassThroughs(ctx, customerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list fee pass-through", err)
+	}
+	return settings, nil
+}
+
+// ListOrderCharges returns the terminal fees attached to an order
+func (s *TerminalFeeService) ListOrderCharges(ctx context.Context, orderID uuid.UUID) ([]domain.TerminalFeeCharge, error) {
+	charges, err := s.feeRepo.ListCharges(ctx, orderID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list terminal fee charges", err)
+	}
+	return charges, nil
+}
+
+// HandleStopDeparted is a kafka.Handler for dispatch.stop.departed. It
+// attaches the fees the stop incurred at its location: a gate fee for a
+// container moved through the gate, an exam fee for a customs exam stop and
+// a chassis flip fee when the container left on a different chassis than it
+// arrived on. Locations without a fee of that type on their schedule are
+// skipped, and redelivered events attach nothing twice.
+func (s *TerminalFeeService) HandleStopDeparted(ctx context.Context, event *kafka.Event) error {
+	var payload kafka.StopDepartedEvent
+	if err := event.DecodeData(&payload); err != nil {
+		return kafka.Permanent(fmt.Errorf("decode stop departed event: %w", err))
+	}
+	feeTypes := stopFeeTypes(&payload)
+	if len(feeTypes) == 0 {
+		return nil
+	}
+
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return nil
+	}
+	terminalID, err := uuid.Parse(payload.LocationID)
+	if err != nil {
+		return nil
+	}
+	tripID, err := uuid.Parse(payload.TripID)
+	if err != nil {
+		return kafka.Permanent(fmt.Errorf("invalid trip_id %q", payload.TripID))
+	}
+	stopID, err := uuid.Parse(payload.StopID)
+	if err != nil {
+		return kafka.Permanent(fmt.Errorf("invalid stop_id %q", payload.StopID))
+	}
+
+	order, err := s.feeRepo.GetFeeOrder(ctx, orderID)
+	if err != nil {
+		return apperrors.DatabaseError("get fee order", err)
+	}
+	if order == nil {
+		return nil
+	}
+
+	at := payload.DepartedAt
+	if at.IsZero() {
+		at = event.Time
+	}
+	for _, feeType := range feeTypes {
+		fee, err := s.feeRepo.FindFee(ctx, domain.TerminalFeeQuery{
+			TerminalID:    terminalID,
+			FeeType:       feeType,
+			ContainerSize: order.FeeContainerSize(),
+			At:            at,
+		})
+		if err != nil {
+			return apperrors.DatabaseError("find terminal fee", err)
+		}
+		if fee == nil {
+			continue
+		}
+		charge := &domain.TerminalFeeCharge{
+			ID:              uuid.New(),
+			FeeID:           fee.ID,
+			FeeType:         feeType,
+			TerminalID:      terminalID,
+			OrderID:         orderID,
+			TripID:          tripID,
+			StopID:          stopID,
+			ContainerNumber: payload.ContainerNumber,
+			OccurredAt:      at,
+			CreatedAt:       time.Now(),
+		}
+		if err := s.attachFee(ctx, order, fee, charge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachFee prices a fee for the order's customer and records it, with an
+// order charge when the customer is billed for it
+func (s *TerminalFeeService) attachFee(ctx context.Context, order *domain.TerminalFeeOrder, fee *domain.TerminalFee, charge *domain.TerminalFeeCharge) error {
+	var setting *domain.FeePassThrough
+	if order.CustomerID != nil {
+		var err error
+		setting, err = s.feeRepo.GetPassThrough(ctx, *order.CustomerID, fee.FeeType)
+		if err != nil {
+			return apperrors.DatabaseError("get fee pass-through", err)
+		}
+	}
+	charge.CostAmount = fee.Amount
+	charge.BilledAmount = setting.BilledAmount(fee.Amount)
+	charge.Mode = domain.PassThroughAtCost
+	if setting != nil {
+		charge.Mode = setting.Mode
+	}
+
+	var orderCharge *domain.OrderCharge
+	if charge.BilledAmount > 0 {
+		if order.Invoiced {
+			// The invoice is out; billing staff add the fee by hand or on a
+			// supplemental invoice from the recorded charge
+			s.logger.Warnw("Terminal fee incurred on an invoiced order; not billed",
+				"order_id", order.OrderID,
+				"stop_id", charge.StopID,
+				"fee_type", fee.FeeType,
+				"amount", charge.BilledAmount,
+			)
+		} else {
+			tripID := charge.TripID
+			description := fee.Description
+			if fee.TerminalName != "" {
+				description += " at " + fee.TerminalName
+			}
+			if charge.ContainerNumber != "" {
+				description += " - " + charge.ContainerNumber
+			}
+			orderCharge = &domain.OrderCharge{
+				ID:          uuid.New(),
+				OrderID:     order.OrderID,
+				ChargeType:  fee.FeeType.ChargeType(),
+				Description: description,
+				Quantity:    1,
+				UnitRate:    charge.BilledAmount,
+				Amount:      charge.BilledAmount,
+				TripID:      &tripID,
+			}
+		}
+	}
+
+	attached, err := s.feeRepo.AttachCharge(ctx, charge, orderCharge)
+	if err != nil {
+		return apperrors.DatabaseError("attach terminal fee", err)
+	}
+	if !attached {
+		return nil
+	}
+
+	s.logger.Infow("Terminal fee attached",
+		"order_id", order.OrderID,
+		"trip_id", charge.TripID,
+		"stop_id", charge.StopID,
+		"fee_type", fee.FeeType,
+		"cost", charge.CostAmount,
+		"billed", charge.BilledAmount,
+		"mode", charge.Mode,
+	)
+	return nil
+}
+
+// stopFeeTypes are the terminal fee types a departed stop may have incurred
+func stopFeeTypes(payload *kafka.StopDepartedEvent) []domain.TerminalFeeType {
+	var types []domain.TerminalFeeType
+	if payload.Activity == "CUSTOMS_EXAM" {
+		types = append(types, domain.TerminalFeeExam)
+	}
+	if gateActivities[payload.Activity] {
+		types = append(types, domain.TerminalFeeGate)
+	}
+	if payload.ChassisInID != "" && payload.ChassisID != "" && payload.ChassisInID != payload.ChassisID {
+		types = append(types, domain.TerminalFeeChassisFlip)
+	}
+	return types
+}
+
+// defaultTerminalFeeDescription is the invoice wording for a fee added
+// without one
+func defaultTerminalFeeDescription(t domain.TerminalFeeType) string {
+	switch t {
+	case domain.TerminalFeeGate:
+		return "Terminal gate fee"
+	case domain.TerminalFeeExam:
+		return "Customs exam fee"
+	case domain.TerminalFeeChassisFlip:
+		return "Chassis flip fee"
+	}
+	return "Terminal fee"
+}
@@ -0,0 +1,4 @@
+-- 000001_invoice_disputes.down.sql
+
+DROP TABLE IF EXISTS invoice_dispute_attachments;
+DROP TABLE IF EXISTS invoice_disputes;
@@ -0,0 +1,44 @@
+-- 000001_invoice_disputes.up.sql
+-- Disputes customers (through the portal) or billing staff open against
+-- invoice line items. While a dispute is open or under review its amount is
+-- carried in invoices.disputed_amount and kept out of aging; a dispute
+-- resolved in the customer's favour issues a credit memo.
+
+CREATE TABLE IF NOT EXISTS invoice_disputes (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_number  VARCHAR(30) UNIQUE NOT NULL,
+    invoice_id      UUID        NOT NULL REFERENCES invoices(id),
+    line_item_id    UUID        NOT NULL REFERENCES invoice_line_items(id),
+    customer_id     UUID        NOT NULL REFERENCES customers(id),
+    amount          DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    reason_code     VARCHAR(30) NOT NULL,
+    reason          TEXT        NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    opened_via      VARCHAR(10) NOT NULL,
+    opened_by       VARCHAR(100) NOT NULL,
+    reviewed_by     VARCHAR(100),
+    review_started_at TIMESTAMPTZ,
+    resolution_notes TEXT,
+    credited_amount DECIMAL(12,2),
+    credit_id       UUID        REFERENCES customer_credits(id),
+    resolved_by     VARCHAR(100),
+    resolved_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS invoice_dispute_attachments (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id      UUID        NOT NULL REFERENCES invoice_disputes(id) ON DELETE CASCADE,
+    file_name       VARCHAR(255) NOT NULL,
+    content_type    VARCHAR(100),
+    size_bytes      BIGINT      NOT NULL DEFAULT 0,
+    storage_key     VARCHAR(500) NOT NULL,
+    uploaded_by     VARCHAR(100) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_disputes_invoice    ON invoice_disputes(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_disputes_customer   ON invoice_disputes(customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoice_disputes_active     ON invoice_disputes(status) WHERE status IN ('OPEN', 'UNDER_REVIEW');
+CREATE INDEX IF NOT EXISTS idx_dispute_attachments_dispute ON invoice_dispute_attachments(dispute_id);
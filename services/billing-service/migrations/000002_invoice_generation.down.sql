@@ -0,0 +1,11 @@
+-- 000002_invoice_generation.down.sql
+
+DROP INDEX IF EXISTS idx_chassis_usage_unbilled;
+DROP INDEX IF EXISTS idx_invoice_line_items_order;
+DROP INDEX IF EXISTS idx_orders_billable;
+
+ALTER TABLE container_charges DROP COLUMN IF EXISTS invoice_id;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS document_key;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS payment_terms_days;
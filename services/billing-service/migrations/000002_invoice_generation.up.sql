@@ -0,0 +1,17 @@
+-- 000002_invoice_generation.up.sql
+-- Billing invoices completed orders: it prices what is missing from the
+-- customer's rate tables, bills each shipment's orders on one invoice with
+-- their detention, chassis, per diem and demurrage, and keeps the rendered
+-- PDF. Chassis usage and container charges point at the invoice that billed
+-- them so they are billed once.
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS payment_terms_days INTEGER NOT NULL DEFAULT 30;
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS document_key VARCHAR(500);
+
+ALTER TABLE container_charges ADD COLUMN IF NOT EXISTS invoice_id UUID REFERENCES invoices(id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_billable ON orders(updated_at)
+    WHERE status = 'COMPLETED' AND billing_status = 'UNBILLED' AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_invoice_line_items_order ON invoice_line_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_chassis_usage_unbilled ON chassis_usage(trip_id) WHERE invoice_id IS NULL;
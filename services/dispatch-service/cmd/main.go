@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
+	"github.com/draymaster/shared/pkg/logger"
+
+	"github.com/draymaster/services/dispatch-service/internal/api"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+// autoDispatchInterval is how often trips are proposed and expired proposals confirmed
+const autoDispatchInterval = time.Minute
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "dispatch-service"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting dispatch service",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Database
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+	log.Info("Database connected")
+
+	// Kafka
+	producer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
+	defer producer.Close()
+	log.Info("Kafka producer initialized")
+
+	// Services
+	autoDispatchService := service.NewAutoDispatchService(
+		repository.NewPostgresTripRepository(db.Pool),
+		repository.NewPostgresTripStopRepository(db.Pool),
+		repository.NewPostgresDriverRepository(db.Pool),
+		repository.NewPostgresLocationRepository(db.Pool),
+		repository.NewPostgresAutoDispatchRepository(db.Pool),
+		repository.NewPostgresHOSSnapshotRepository(db.Pool),
+		producer,
+		log,
+	)
+
+	// Proposals and confirmations run on one replica at a time; the others
+	// stand by to take over
+	elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+	go autoDispatchService.Run(ctx, elector, autoDispatchInterval)
+	log.Infow("Auto-dispatch started", "interval", autoDispatchInterval)
+
+	// HTTP API
+	handler := api.NewHandler(api.Services{
+		AutoAssign: autoDispatchService,
+	}, log)
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      handler.Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("Dispatch service stopped")
+}
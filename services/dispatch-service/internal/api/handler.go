@@ -0,0 +1,2250 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// userHeader carries the authenticated ops user ID, set by the API gateway
+const userHeader = "X-User-ID"
+
+// defaultScheduleDays is the schedule range returned when none is given
+const defaultScheduleDays = 7
+
+// maxAttachmentBytes caps a single POD or photo upload
+const maxAttachmentBytes = 25 << 20
+
+// maxAppointmentFileBytes caps a terminal appointment confirmation file
+const maxAppointmentFileBytes = 10 << 20
+
+// Handler serves the dispatch HTTP API used by other services and the
+// dispatch board
+type Handler struct {
+	dispatch    *service.DispatchService
+	schedules   *service.DriverScheduleService
+	syncs       *service.DriverSyncService
+	yards       *service.YardGateService
+	callOuts    *service.DriverCallOutService
+	nextTrips   *service.NextTripService
+	attachments *service.AttachmentService
+	cutover     *service.DispatchRouter
+	emergencies *service.EmergencyService
+	empties     *service.EmptyReturnService
+	tags        *service.TripTagService
+	gates       *service.GateAppointmentService
+	breaks      *service.BreakPlanService
+	axles       *service.AxleWeightService
+	deliveries  *service.SplitDeliveryService
+	owners      *service.OwnerOperatorService
+	geofences   *service.GeofenceLearningService
+	timeOff     *service.DriverTimeOffService
+	plans       *service.TripPlanService
+	imports     *service.AppointmentImportService
+	autoAssign  *service.AutoDispatchService
+	logger      *logger.Logger
+}
+
+// Services holds the services behind the API. Routes mounts only the
+// features whose services are set, so a deployment can serve a subset.
+type Services struct {
+	Dispatch    *service.DispatchService
+	Schedules   *service.DriverScheduleService
+	Syncs       *service.DriverSyncService
+	Yards       *service.YardGateService
+	CallOuts    *service.DriverCallOutService
+	NextTrips   *service.NextTripService
+	Attachments *service.AttachmentService
+	Cutover     *service.DispatchRouter
+	Emergencies *service.EmergencyService
+	Empties     *service.EmptyReturnService
+	Tags        *service.TripTagService
+	Gates       *service.GateAppointmentService
+	Breaks      *service.BreakPlanService
+	Axles       *service.AxleWeightService
+	Deliveries  *service.SplitDeliveryService
+	Owners      *service.OwnerOperatorService
+	Geofences   *service.GeofenceLearningService
+	TimeOff     *service.DriverTimeOffService
+	Plans       *service.TripPlanService
+	Imports     *service.AppointmentImportService
+	AutoAssign  *service.AutoDispatchService
+}
+
+// NewHandler creates a new dispatch HTTP handler
+func NewHandler(svc Services, log *logger.Logger) *Handler {
+	return &Handler{
+		dispatch:    svc.Dispatch,
+		schedules:   svc.Schedules,
+		syncs:       svc.Syncs,
+		yards:       svc.Yards,
+		callOuts:    svc.CallOuts,
+		nextTrips:   svc.NextTrips,
+		attachments: svc.Attachments,
+		cutover:     svc.Cutover,
+		emergencies: svc.Emergencies,
+		empties:     svc.Empties,
+		tags:        svc.Tags,
+		gates:       svc.Gates,
+		breaks:      svc.Breaks,
+		axles:       svc.Axles,
+		deliveries:  svc.Deliveries,
+		owners:      svc.Owners,
+		geofences:   svc.Geofences,
+		timeOff:     svc.TimeOff,
+		plans:       svc.Plans,
+		imports:     svc.Imports,
+		autoAssign:  svc.AutoAssign,
+		logger:      log,
+	}
+}
+
+// Routes returns the HTTP routes for the API. Routes of features without a
+// service answer 404.
+//
+//	GET                 /v1/trips/lookup              (?container_number=&trip_number=&order_id=)
+//
+// Driver schedules (X-User-ID):
+//
+//	POST                /v1/trips/{id}/allocations    (soft-allocate a driver to a future trip)
+//	POST                /v1/allocations/{id}/confirm
+//	POST                /v1/allocations/{id}/release
+//	GET                 /v1/drivers/{id}/schedule     (?from=&to= as YYYY-MM-DD or RFC 3339)
+//	GET                 /v1/drivers/{id}/next-trips   (nearest unassigned trips the driver could legally take)
+//
+// Driver time off and PTO (X-User-ID):
+//
+//	GET|POST            /v1/time-off                  (?driver_id=&status=&from=&to=&limit=; request days off)
+//	GET                 /v1/time-off/{id}
+//	POST                /v1/time-off/{id}/approve     (draws paid hours from the driver's PTO balance)
+//	POST                /v1/time-off/{id}/deny
+//	POST                /v1/time-off/{id}/cancel      (pending, or approved and not yet started; returns the hours)
+//	GET                 /v1/drivers/{id}/pto          (balance, hours held by pending requests and recent ledger)
+//	POST                /v1/drivers/{id}/pto/adjustments (add or take hours by hand, with a note)
+//
+// Company drivers accrue PTO weekly from hours on completed trips. Drivers
+// on approved time off cannot be assigned or allocated trips on those days,
+// and paid time off is added to their draft settlement for the week.
+//
+// TWIC escorts (X-User-ID):
+//
+//	GET                 /v1/escort-policies           (every terminal's escort policy)
+//	PUT                 /v1/terminals/{id}/escort-policy
+//	POST                /v1/trips/{id}/twic-escort    (let a driver without a TWIC take the trip under escort, for a fee)
+//
+// Driver call-outs (X-User-ID):
+//
+//	POST                /v1/drivers/{id}/call-outs    (mark out for a day and propose reassignments)
+//	GET                 /v1/call-outs/{id}
+//	POST                /v1/call-outs/{id}/apply      (apply accepted proposals, reject the rest)
+//
+// Driver app offline sync:
+//
+//	POST                /v1/mobile/sync/download      (trips changed since the app's known versions)
+//	POST                /v1/mobile/sync/upload        (queued arrivals, completions, seals and PODs)
+//	GET                 /v1/mobile/detention-clock    (?driver_id=; free time left at the driver's current stop)
+//	POST                /v1/mobile/attachments        (?kind=&trip_id=&stop_id=&driver_id=&file_name=; raw file body)
+//
+// Driver emergencies (panic button):
+//
+//	POST                /v1/mobile/emergency          (open an incident at the driver's location and alert staff)
+//	GET                 /v1/mobile/emergency          (?driver_id=; active incident or null, with the reporting interval)
+//	POST                /v1/mobile/emergency/{id}/locations (streamed position; 409 once closed)
+//	GET                 /v1/emergencies               (X-User-ID; incidents not yet closed)
+//	GET                 /v1/emergencies/{id}          (X-User-ID; with location trail and alerts sent)
+//	POST                /v1/emergencies/{id}/acknowledge (X-User-ID)
+//	POST                /v1/emergencies/{id}/close    (X-User-ID; resolution required)
+//	GET|POST            /v1/emergency-contacts        (X-User-ID; staff alerted by text and/or call)
+//	DELETE              /v1/emergency-contacts/{id}   (X-User-ID)
+//
+// Attachments (X-User-ID):
+//
+//	GET                 /v1/trips/{id}/attachments    (PODs and photos uploaded against the trip)
+//	GET                 /v1/attachments/{id}          (the file itself)
+//
+// Split deliveries (X-User-ID):
+//
+//	GET                 /v1/trips/{id}/deliveries     (each container's parts per delivery stop, shares and POD status)
+//	PUT                 /v1/trips/{id}/deliveries     (replace the commodity, pieces and weight delivered at one stop)
+//	POST                /v1/deliveries/{id}/pod       (the consignee signs for their part; adds to the stop's POD)
+//
+// A container split across delivery points is billed to each stop in
+// proportion to its weight, else its pieces, else evenly.
+//
+// Dispatch board (X-User-ID):
+//
+//	GET                 /v1/detention/clocks          (free time countdown at every stop a driver is on site at)
+//	GET                 /v1/trips/{id}/cost           (cost accrued so far and live margin; on site, the margin of waiting an hour or a dry run)
+//	GET                 /v1/trip-plans                (?date=YYYY-MM-DD, default today; proposed drivers for the day's unassigned trips)
+//	POST                /v1/trip-plans/apply          (assignments; assign each trip to its planned driver)
+//
+// Trip plans chain trips onto drivers to keep empty miles and missed
+// appointments down within each driver's drive and duty time. Applied
+// assignments go through the same checks as assigning by hand.
+//
+// Company yard gates (X-User-ID):
+//
+//	POST                /v1/yards/{id}/gate-events    (in-gate or out-gate of a container and/or chassis)
+//	GET                 /v1/yards/{id}/gate-events    (?from=&to= as YYYY-MM-DD or RFC 3339, default today)
+//	GET                 /v1/yards/{id}/inventory      (what is on the ground, with per-diem days)
+//
+// Trip tags and saved filters (X-User-ID):
+//
+//	PUT                 /v1/trips/{id}/tags           (replace with tags, or add/remove; version optional)
+//	GET|POST            /v1/trip-filters              (the user's saved filters, default first; save one)
+//	PUT|DELETE          /v1/trip-filters/{id}
+//	GET                 /v1/trip-filters/{id}/trips   (?page=&page_size=; run a saved filter)
+//
+// Terminal gate appointments (X-User-ID):
+//
+//	GET                 /v1/terminals/{id}/gate-slots (?move_type=&from=&to=, default today; places left in each)
+//	POST                /v1/terminals/{id}/gate-slots (release slots cut from start to end for one move type)
+//	GET                 /v1/terminals/{id}/gate-demand (?move_type=&from=&to=; which slots are likely to sell out and when to book by)
+//	GET                 /v1/trips/{id}/gate-appointments
+//	POST                /v1/gate-appointments         (book a terminal stop into a slot with the terminal's number)
+//	POST                /v1/gate-appointments/{id}/reschedule
+//	POST                /v1/gate-appointments/{id}/cancel
+//
+// Trips with a terminal stop that releases slots are not dispatched until
+// the stop is booked and its planned arrival falls within the window. A
+// driver cannot hold appointments at two terminals in the same hour.
+//
+// Auto-dispatch (X-User-ID):
+//
+//	GET|PUT             /v1/terminals/{id}/auto-dispatch (turn auto-dispatch on or off for trips picking up there, with its window and limits)
+//	GET                 /v1/auto-dispatch/decisions   (proposals still open to override, soonest deadline first)
+//	POST                /v1/auto-dispatch/decisions/{id}/override (cancel a proposal; optionally assign another driver)
+//
+// Proposals commit once their override period passes. Trips no driver
+// qualified for are recorded as skipped with the reason.
+//
+// Terminal appointment confirmations (X-User-ID):
+//
+//	POST                /v1/stop-appointments/import  (?file_name=&terminal_id=&dry_run=; raw CSV or .xlsx body)
+//
+// Each row sets the appointment time and number of the pending terminal
+// stop for its container on its date. Rows matching no stop or several,
+// or that cannot be read, are reported back for handling by hand.
+//
+// Driver breaks (X-User-ID):
+//
+//	GET                 /v1/trips/{id}/break-plan     (when the 30-minute break falls due, suggested stops, whether it was taken)
+//	POST                /v1/trips/{id}/break-plan     (replan from the driver's current HOS; null when no break falls due)
+//
+// Trips are planned when dispatched and checked against the driver's GPS
+// trail when completed.
+//
+// Trip timeline (X-User-ID):
+//
+//	GET                 /v1/trips/{id}/timeline       (creation, HOS snapshots, start, stop arrivals and departures, completion)
+//
+// The driver's drive, duty and cycle time left and break state are
+// snapshotted when a trip is assigned and when it is dispatched, and kept
+// as recorded for disputing HOS violations.
+//
+// Axle weights (X-User-ID):
+//
+//	GET                 /v1/trips/{id}/axle-weights   (estimated steer, drive and chassis loads per loaded container, against each state's limits)
+//	POST                /v1/axle-weights              (estimate for a container or a weight and size on a chassis and tractor)
+//
+// Overweight estimates list chassis that would make the load legal, or the
+// states needing an overweight permit when none would. New trips are
+// checked when created and a warning is published when over a limit.
+//
+// Owner-operator compliance (X-User-ID):
+//
+//	GET|POST            /v1/owner-operators           (every owner-operator, held first; register a driver as one)
+//	GET|PUT             /v1/owner-operators/{id}      (authority, certificates and standing; setting authority_status records a manual check)
+//	POST                /v1/owner-operators/{id}/verify (read authority and filed insurance from the verification provider)
+//	POST                /v1/owner-operators/{id}/certificates (file a certificate of insurance by hand)
+//	POST                /v1/owner-operators/{id}/certificates/{certificate_id}/cancel
+//
+// An owner-operator whose authority is not active or whose required
+// insurance is missing, below its minimum or lapsed cannot be dispatched.
+// Ops are alerted ahead of each expiration with no renewal on file.
+//
+// Learned location geofences (X-User-ID):
+//
+//	GET                 /v1/location-proposals        (?status=, default PENDING; largest shift first)
+//	POST                /v1/location-proposals/learn  (cluster stop positions now rather than at the next scheduled run)
+//	POST                /v1/location-proposals/accept (ids; move each location and apply its geofence)
+//	POST                /v1/location-proposals/reject (ids)
+//
+// Proposals are drawn where the positions drivers reported at a location's
+// stops cluster away from its coordinates or outside its geofence. Bulk
+// reviews report each proposal that could not be applied.
+//
+// Empty returns (X-User-ID):
+//
+//	POST                /v1/containers/{id}/empty-return (empty is available; price returning, street turning or staging it)
+//
+// Legacy/enhanced cutover:
+//
+//	GET                 /v1/cutover/metrics           (calls per path and shadow match counts per operation)
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	handle := func(enabled bool, pattern string, fn http.HandlerFunc) {
+		if enabled {
+			mux.HandleFunc(pattern, fn)
+		}
+	}
+
+	handle(h.dispatch != nil, "/v1/trips/lookup", h.lookupTrips)
+	handle(anyEnabled(h.tripFeatures()), "/v1/trips/", h.trip)
+	handle(h.schedules != nil, "/v1/allocations/", h.allocation)
+	handle(anyEnabled(h.driverFeatures()), "/v1/drivers/", h.driver)
+	handle(h.timeOff != nil, "/v1/time-off", h.timeOffRequests)
+	handle(h.timeOff != nil, "/v1/time-off/", h.timeOffRequests)
+	handle(h.dispatch != nil, "/v1/escort-policies", h.escortPolicies)
+	handle(anyEnabled(h.terminalFeatures()), "/v1/terminals/", h.terminal)
+	handle(h.callOuts != nil, "/v1/call-outs/", h.callOut)
+	handle(h.syncs != nil, "/v1/mobile/sync/download", h.syncDownload)
+	handle(h.syncs != nil, "/v1/mobile/sync/upload", h.syncUpload)
+	handle(h.dispatch != nil, "/v1/mobile/detention-clock", h.driverDetentionClock)
+	handle(h.attachments != nil, "/v1/mobile/attachments", h.uploadAttachment)
+	handle(h.emergencies != nil, "/v1/mobile/emergency", h.mobileEmergency)
+	handle(h.emergencies != nil, "/v1/mobile/emergency/", h.mobileEmergency)
+	handle(h.emergencies != nil, "/v1/emergencies", h.emergency)
+	handle(h.emergencies != nil, "/v1/emergencies/", h.emergency)
+	handle(h.emergencies != nil, "/v1/emergency-contacts", h.emergencyContacts)
+	handle(h.emergencies != nil, "/v1/emergency-contacts/", h.emergencyContacts)
+	handle(h.attachments != nil, "/v1/attachments/", h.attachment)
+	handle(h.deliveries != nil, "/v1/deliveries/", h.delivery)
+	handle(h.dispatch != nil, "/v1/detention/clocks", h.detentionClocks)
+	handle(h.plans != nil, "/v1/trip-plans", h.tripPlan)
+	handle(h.plans != nil, "/v1/trip-plans/apply", h.applyTripPlan)
+	handle(h.yards != nil, "/v1/yards/", h.yard)
+	handle(h.empties != nil, "/v1/containers/", h.emptyReturn)
+	handle(h.tags != nil, "/v1/trip-filters", h.tripFilters)
+	handle(h.tags != nil, "/v1/trip-filters/", h.tripFilters)
+	handle(h.gates != nil, "/v1/gate-appointments", h.gateAppointment)
+	handle(h.gates != nil, "/v1/gate-appointments/", h.gateAppointment)
+	handle(h.imports != nil, "/v1/stop-appointments/import", h.importAppointments)
+	handle(h.autoAssign != nil, "/v1/auto-dispatch/decisions", h.autoAssignDecisions)
+	handle(h.autoAssign != nil, "/v1/auto-dispatch/decisions/", h.autoAssignDecisions)
+	handle(h.axles != nil, "/v1/axle-weights", h.estimateAxleWeights)
+	handle(h.owners != nil, "/v1/owner-operators", h.ownerOperators)
+	handle(h.owners != nil, "/v1/owner-operators/", h.ownerOperators)
+	handle(h.geofences != nil, "/v1/location-proposals", h.locationProposals)
+	handle(h.geofences != nil, "/v1/location-proposals/", h.locationProposals)
+	handle(h.cutover != nil, "/v1/cutover/metrics", h.cutoverMetrics)
+
+	return mux
+}
+
+// tripFeatures reports which /v1/trips/{id}/ subresources are served
+func (h *Handler) tripFeatures() map[string]bool {
+	return map[string]bool{
+		"allocations":       h.schedules != nil,
+		"twic-escort":       h.dispatch != nil,
+		"attachments":       h.attachments != nil,
+		"gate-appointments": h.gates != nil,
+		"break-plan":        h.breaks != nil,
+		"timeline":          h.dispatch != nil,
+		"cost":              h.dispatch != nil,
+		"axle-weights":      h.axles != nil,
+		"deliveries":        h.deliveries != nil,
+		"tags":              h.tags != nil,
+	}
+}
+
+// driverFeatures reports which /v1/drivers/{id}/ subresources are served
+func (h *Handler) driverFeatures() map[string]bool {
+	return map[string]bool{
+		"schedule":   h.schedules != nil,
+		"next-trips": h.nextTrips != nil,
+		"call-outs":  h.callOuts != nil,
+		"pto":        h.timeOff != nil,
+	}
+}
+
+// terminalFeatures reports which /v1/terminals/{id}/ subresources are served
+func (h *Handler) terminalFeatures() map[string]bool {
+	return map[string]bool{
+		"escort-policy": h.dispatch != nil,
+		"gate-slots":    h.gates != nil,
+		"gate-demand":   h.gates != nil,
+		"auto-dispatch": h.autoAssign != nil,
+	}
+}
+
+func anyEnabled(features map[string]bool) bool {
+	for _, enabled := range features {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) lookupTrips(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	lookup := service.TripLookup{
+		ContainerNumber: query.Get("container_number"),
+		TripNumber:      query.Get("trip_number"),
+	}
+	if raw := query.Get("order_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid order_id", "order_id", raw))
+			return
+		}
+		lookup.OrderID = &id
+	}
+
+	trips, err := h.dispatch.LookupTrips(r.Context(), lookup)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, trips)
+}
+
+// ============================================================================
+// DRIVER SCHEDULES
+// ============================================================================
+
+func (h *Handler) trip(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/trips/")
+	if len(parts) != 2 || !h.tripFeatures()[parts[1]] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tripID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch parts[1] {
+	case "allocations":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.allocateDriver(w, r, tripID, user)
+	case "twic-escort":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input struct {
+			Enabled bool `json:"enabled"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		trip, err := h.dispatch.SetTWICEscort(r.Context(), tripID, input.Enabled, user)
+		h.respond(w, trip, err)
+	case "attachments":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		attachments, err := h.attachments.GetTripAttachments(r.Context(), tripID)
+		h.respond(w, attachments, err)
+	case "gate-appointments":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		appointments, err := h.gates.GetTripGateAppointments(r.Context(), tripID)
+		h.respond(w, appointments, err)
+	case "break-plan":
+		switch r.Method {
+		case http.MethodGet:
+			plan, err := h.breaks.GetBreakPlan(r.Context(), tripID)
+			h.respond(w, plan, err)
+		case http.MethodPost:
+			plan, err := h.breaks.PlanBreak(r.Context(), tripID)
+			h.respond(w, plan, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case "timeline":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		timeline, err := h.dispatch.GetTripTimeline(r.Context(), tripID)
+		h.respond(w, timeline, err)
+	case "cost":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cost, err := h.dispatch.GetTripCost(r.Context(), tripID)
+		h.respond(w, cost, err)
+	case "axle-weights":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		weights, err := h.axles.CheckTrip(r.Context(), tripID)
+		h.respond(w, weights, err)
+	case "deliveries":
+		switch r.Method {
+		case http.MethodGet:
+			deliveries, err := h.deliveries.GetTripDeliveries(r.Context(), tripID)
+			h.respond(w, deliveries, err)
+		case http.MethodPut:
+			h.setStopDeliveries(w, r, tripID, user)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case "tags":
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input struct {
+			Tags    []string `json:"tags"`
+			Add     []string `json:"add"`
+			Remove  []string `json:"remove"`
+			Version int      `json:"version"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		trip, err := h.tags.UpdateTripTags(r.Context(), tripID, service.UpdateTripTagsInput{
+			Tags:      input.Tags,
+			Add:       input.Add,
+			Remove:    input.Remove,
+			Version:   input.Version,
+			UpdatedBy: user,
+		})
+		h.respond(w, trip, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) allocateDriver(w http.ResponseWriter, r *http.Request, tripID uuid.UUID, user string) {
+	var input struct {
+		DriverID uuid.UUID `json:"driver_id"`
+		Notes    string    `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	allocation, err := h.schedules.AllocateDriver(r.Context(), service.AllocateDriverInput{
+		TripID:      tripID,
+		DriverID:    input.DriverID,
+		Notes:       input.Notes,
+		AllocatedBy: user,
+	})
+	h.respondCreated(w, allocation, err)
+}
+
+func (h *Handler) allocation(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/allocations/")
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch parts[1] {
+	case "confirm":
+		var input struct {
+			TractorID *uuid.UUID `json:"tractor_id"`
+		}
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		trip, err := h.schedules.ConfirmAllocation(r.Context(), id, input.TractorID)
+		h.respond(w, trip, err)
+	case "release":
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		allocation, err := h.schedules.ReleaseAllocation(r.Context(), id, input.Reason, user)
+		h.respond(w, allocation, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) driver(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/drivers/")
+	if len(parts) < 2 || !h.driverFeatures()[parts[1]] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(parts) == 3 && parts[1] == "pto" && parts[2] == "adjustments" {
+		driverID, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.adjustPTO(w, r, driverID, user)
+		return
+	}
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	driverID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch parts[1] {
+	case "schedule":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.driverSchedule(w, r, driverID)
+	case "next-trips":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		suggestions, err := h.nextTrips.GetNextTripSuggestions(r.Context(), driverID)
+		h.respond(w, suggestions, err)
+	case "call-outs":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.markDriverOut(w, r, driverID, user)
+	case "pto":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		balance, err := h.timeOff.GetBalance(r.Context(), driverID)
+		h.respond(w, balance, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) driverSchedule(w http.ResponseWriter, r *http.Request, driverID uuid.UUID) {
+	var ok bool
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if from, ok = h.parseTime(w, "from", raw); !ok {
+			return
+		}
+	}
+	to := from.AddDate(0, 0, defaultScheduleDays)
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, ok = h.parseTime(w, "to", raw); !ok {
+			return
+		}
+	}
+
+	schedule, err := h.schedules.GetDriverSchedule(r.Context(), driverID, from, to)
+	h.respond(w, schedule, err)
+}
+
+// ============================================================================
+// DRIVER TIME OFF
+// ============================================================================
+
+func (h *Handler) timeOffRequests(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/time-off")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		h.listTimeOff(w, r)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.requestTimeOff(w, r, user)
+	case len(parts) == 0:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		h.timeOffRequest(w, r, id, parts[1:], user)
+	}
+}
+
+func (h *Handler) timeOffRequest(w http.ResponseWriter, r *http.Request, id uuid.UUID, parts []string, user string) {
+	if len(parts) == 0 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		timeOff, err := h.timeOff.GetTimeOff(r.Context(), id)
+		h.respond(w, timeOff, err)
+		return
+	}
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if parts[0] != "approve" && parts[0] != "deny" && parts[0] != "cancel" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input struct {
+		PayRate float64 `json:"pay_rate"`
+		Notes   string  `json:"notes"`
+		Reason  string  `json:"reason"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	switch parts[0] {
+	case "approve":
+		timeOff, err := h.timeOff.ApproveTimeOff(r.Context(), service.ApproveTimeOffInput{
+			TimeOffID:  id,
+			PayRate:    input.PayRate,
+			Notes:      input.Notes,
+			ReviewedBy: user,
+		})
+		h.respond(w, timeOff, err)
+	case "deny":
+		timeOff, err := h.timeOff.DenyTimeOff(r.Context(), id, input.Notes, user)
+		h.respond(w, timeOff, err)
+	case "cancel":
+		timeOff, err := h.timeOff.CancelTimeOff(r.Context(), id, input.Reason, user)
+		h.respond(w, timeOff, err)
+	}
+}
+
+func (h *Handler) listTimeOff(w http.ResponseWriter, r *http.Request) {
+	var ok bool
+	query := r.URL.Query()
+	filter := repository.TimeOffFilter{Status: domain.TimeOffStatus(strings.ToUpper(query.Get("status")))}
+	if raw := query.Get("driver_id"); raw != "" {
+		driverID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.DriverID = &driverID
+	}
+	if raw := query.Get("from"); raw != "" {
+		if filter.From, ok = h.parseTime(w, "from", raw); !ok {
+			return
+		}
+	}
+	if raw := query.Get("to"); raw != "" {
+		if filter.To, ok = h.parseTime(w, "to", raw); !ok {
+			return
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			h.writeError(w, apperrors.ValidationError("invalid limit", "limit", raw))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	requests, err := h.timeOff.ListTimeOff(r.Context(), filter)
+	h.respond(w, requests, err)
+}
+
+func (h *Handler) requestTimeOff(w http.ResponseWriter, r *http.Request, user string) {
+	var input struct {
+		DriverID  string `json:"driver_id"`
+		Type      string `json:"type"`
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Unpaid    bool   `json:"unpaid"`
+		Notes     string `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	driverID, ok := h.parseID(w, input.DriverID)
+	if !ok {
+		return
+	}
+	start, ok := h.parseTime(w, "start_date", input.StartDate)
+	if !ok {
+		return
+	}
+	end, ok := h.parseTime(w, "end_date", input.EndDate)
+	if !ok {
+		return
+	}
+
+	timeOff, err := h.timeOff.RequestTimeOff(r.Context(), service.RequestTimeOffInput{
+		DriverID:    driverID,
+		Type:        domain.TimeOffType(strings.ToUpper(input.Type)),
+		StartDate:   start,
+		EndDate:     end,
+		Unpaid:      input.Unpaid,
+		Notes:       input.Notes,
+		RequestedBy: user,
+	})
+	h.respondCreated(w, timeOff, err)
+}
+
+func (h *Handler) adjustPTO(w http.ResponseWriter, r *http.Request, driverID uuid.UUID, user string) {
+	var input struct {
+		Hours float64 `json:"hours"`
+		Notes string  `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	balance, err := h.timeOff.AdjustBalance(r.Context(), service.AdjustBalanceInput{
+		DriverID:   driverID,
+		Hours:      input.Hours,
+		Notes:      input.Notes,
+		AdjustedBy: user,
+	})
+	h.respondCreated(w, balance, err)
+}
+
+// ============================================================================
+// TWIC ESCORTS
+// ============================================================================
+
+func (h *Handler) escortPolicies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	policies, err := h.dispatch.GetEscortPolicies(r.Context())
+	h.respond(w, policies, err)
+}
+
+func (h *Handler) terminal(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/terminals/")
+	if len(parts) != 2 || !h.terminalFeatures()[parts[1]] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	terminalID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch {
+	case parts[1] == "escort-policy" && r.Method == http.MethodPut:
+		h.terminalEscortPolicy(w, r, terminalID, user)
+	case parts[1] == "gate-slots" && r.Method == http.MethodGet:
+		h.listGateSlots(w, r, terminalID)
+	case parts[1] == "gate-slots" && r.Method == http.MethodPost:
+		h.releaseGateSlots(w, r, terminalID, user)
+	case parts[1] == "gate-demand" && r.Method == http.MethodGet:
+		h.gateDemand(w, r, terminalID)
+	case parts[1] == "auto-dispatch" && r.Method == http.MethodGet:
+		cfg, err := h.autoAssign.GetTerminalAutoDispatch(r.Context(), terminalID)
+		h.respond(w, cfg, err)
+	case parts[1] == "auto-dispatch" && r.Method == http.MethodPut:
+		h.setTerminalAutoDispatch(w, r, terminalID, user)
+	case parts[1] == "escort-policy" || parts[1] == "gate-slots" || parts[1] == "gate-demand" || parts[1] == "auto-dispatch":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) terminalEscortPolicy(w http.ResponseWriter, r *http.Request, terminalID uuid.UUID, user string) {
+	var input struct {
+		EscortsAllowed bool    `json:"escorts_allowed"`
+		Fee            float64 `json:"fee"`
+		Notes          string  `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	policy, err := h.dispatch.SaveEscortPolicy(r.Context(), service.SaveEscortPolicyInput{
+		TerminalID:     terminalID,
+		EscortsAllowed: input.EscortsAllowed,
+		Fee:            input.Fee,
+		Notes:          input.Notes,
+		UpdatedBy:      user,
+	})
+	h.respond(w, policy, err)
+}
+
+// ============================================================================
+// AUTO-DISPATCH
+// ============================================================================
+
+func (h *Handler) setTerminalAutoDispatch(w http.ResponseWriter, r *http.Request, terminalID uuid.UUID, user string) {
+	var input struct {
+		Enabled          bool    `json:"enabled"`
+		WindowStart      string  `json:"window_start"`
+		WindowEnd        string  `json:"window_end"`
+		LookaheadMins    int     `json:"lookahead_mins"`
+		MaxDistanceMiles float64 `json:"max_distance_miles"`
+		OverrideMins     int     `json:"override_mins"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	cfg, err := h.autoAssign.SetTerminalAutoDispatch(r.Context(), service.TerminalAutoDispatchInput{
+		TerminalID:       terminalID,
+		Enabled:          input.Enabled,
+		WindowStart:      input.WindowStart,
+		WindowEnd:        input.WindowEnd,
+		LookaheadMins:    input.LookaheadMins,
+		MaxDistanceMiles: input.MaxDistanceMiles,
+		OverrideMins:     input.OverrideMins,
+		UpdatedBy:        user,
+	})
+	h.respond(w, cfg, err)
+}
+
+func (h *Handler) autoAssignDecisions(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/auto-dispatch/decisions")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		decisions, err := h.autoAssign.GetPendingDecisions(r.Context())
+		h.respond(w, decisions, err)
+	case len(parts) == 2 && parts[1] == "override" && r.Method == http.MethodPost:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input struct {
+			Reason   string     `json:"reason"`
+			DriverID *uuid.UUID `json:"driver_id"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		decision, err := h.autoAssign.OverrideDecision(r.Context(), service.OverrideDecisionInput{
+			DecisionID:   id,
+			OverriddenBy: user,
+			Reason:       input.Reason,
+			DriverID:     input.DriverID,
+		})
+		h.respond(w, decision, err)
+	case len(parts) == 0 || (len(parts) == 2 && parts[1] == "override"):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// GATE APPOINTMENTS
+// ============================================================================
+
+func (h *Handler) listGateSlots(w http.ResponseWriter, r *http.Request, terminalID uuid.UUID) {
+	moveType, from, to, ok := h.gateSlotQuery(w, r)
+	if !ok {
+		return
+	}
+	slots, err := h.gates.ListGateSlots(r.Context(), terminalID, moveType, from, to)
+	h.respond(w, slots, err)
+}
+
+func (h *Handler) gateDemand(w http.ResponseWriter, r *http.Request, terminalID uuid.UUID) {
+	moveType, from, to, ok := h.gateSlotQuery(w, r)
+	if !ok {
+		return
+	}
+	forecast, err := h.gates.ForecastGateDemand(r.Context(), terminalID, moveType, from, to)
+	h.respond(w, forecast, err)
+}
+
+// gateSlotQuery parses ?move_type=&from=&to=, defaulting to every move today
+func (h *Handler) gateSlotQuery(w http.ResponseWriter, r *http.Request) (domain.GateMoveType, time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var ok bool
+	if raw := query.Get("from"); raw != "" {
+		if from, ok = h.parseTime(w, "from", raw); !ok {
+			return "", from, from, false
+		}
+	}
+	to := from.AddDate(0, 0, 1)
+	if raw := query.Get("to"); raw != "" {
+		if to, ok = h.parseTime(w, "to", raw); !ok {
+			return "", from, to, false
+		}
+	}
+	return domain.GateMoveType(strings.ToUpper(query.Get("move_type"))), from, to, true
+}
+
+func (h *Handler) releaseGateSlots(w http.ResponseWriter, r *http.Request, terminalID uuid.UUID, user string) {
+	var input struct {
+		MoveType domain.GateMoveType `json:"move_type"`
+		Start    time.Time           `json:"start"`
+		End      time.Time           `json:"end"`
+		SlotMins int                 `json:"slot_mins"`
+		Capacity int                 `json:"capacity"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	slots, err := h.gates.ReleaseGateSlots(r.Context(), service.ReleaseGateSlotsInput{
+		TerminalID: terminalID,
+		MoveType:   input.MoveType,
+		Start:      input.Start,
+		End:        input.End,
+		SlotMins:   input.SlotMins,
+		Capacity:   input.Capacity,
+		CreatedBy:  user,
+	})
+	h.respondCreated(w, slots, err)
+}
+
+func (h *Handler) gateAppointment(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/gate-appointments")
+
+	switch {
+	case len(parts) == 0:
+		var input struct {
+			StopID            uuid.UUID `json:"stop_id"`
+			SlotID            uuid.UUID `json:"slot_id"`
+			AppointmentNumber string    `json:"appointment_number"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		appointment, err := h.gates.BookGateAppointment(r.Context(), service.BookGateAppointmentInput{
+			StopID:            input.StopID,
+			SlotID:            input.SlotID,
+			AppointmentNumber: input.AppointmentNumber,
+			BookedBy:          user,
+		})
+		h.respondCreated(w, appointment, err)
+
+	case len(parts) == 2 && parts[1] == "reschedule":
+		appointmentID, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input struct {
+			SlotID            uuid.UUID `json:"slot_id"`
+			AppointmentNumber string    `json:"appointment_number"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		appointment, err := h.gates.RescheduleGateAppointment(r.Context(), service.RescheduleGateAppointmentInput{
+			AppointmentID:     appointmentID,
+			SlotID:            input.SlotID,
+			AppointmentNumber: input.AppointmentNumber,
+			RescheduledBy:     user,
+		})
+		h.respondCreated(w, appointment, err)
+
+	case len(parts) == 2 && parts[1] == "cancel":
+		appointmentID, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		appointment, err := h.gates.CancelGateAppointment(r.Context(), appointmentID, input.Reason, user)
+		h.respond(w, appointment, err)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// importAppointments takes a terminal's confirmation file as the raw request
+// body, like attachment uploads
+func (h *Handler) importAppointments(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	input := service.AppointmentImportInput{
+		FileName:   query.Get("file_name"),
+		ImportedBy: user,
+	}
+	if raw := query.Get("terminal_id"); raw != "" {
+		terminalID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		input.TerminalID = &terminalID
+	}
+	if raw := query.Get("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid dry_run", "dry_run", raw))
+			return
+		}
+		input.DryRun = dryRun
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxAppointmentFileBytes)
+	result, err := h.imports.ImportAppointments(r.Context(), input, body)
+	h.respond(w, result, err)
+}
+
+// ============================================================================
+// DRIVER CALL-OUTS
+// ============================================================================
+
+func (h *Handler) markDriverOut(w http.ResponseWriter, r *http.Request, driverID uuid.UUID, user string) {
+	var input struct {
+		Date   string `json:"date"` // YYYY-MM-DD or RFC 3339, default today
+		Reason string `json:"reason"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	var date time.Time
+	if input.Date != "" {
+		var ok bool
+		if date, ok = h.parseTime(w, "date", input.Date); !ok {
+			return
+		}
+	}
+	callOut, err := h.callOuts.MarkDriverOut(r.Context(), service.MarkDriverOutInput{
+		DriverID:   driverID,
+		Date:       date,
+		Reason:     input.Reason,
+		ReportedBy: user,
+	})
+	h.respondCreated(w, callOut, err)
+}
+
+func (h *Handler) callOut(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/call-outs/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		callOut, err := h.callOuts.GetCallOut(r.Context(), id)
+		h.respond(w, callOut, err)
+	case len(parts) == 2 && parts[1] == "apply" && r.Method == http.MethodPost:
+		var input struct {
+			AcceptedProposalIDs []uuid.UUID `json:"accepted_proposal_ids"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		callOut, err := h.callOuts.ApplyReassignments(r.Context(), service.ApplyReassignmentsInput{
+			CallOutID:           id,
+			AcceptedProposalIDs: input.AcceptedProposalIDs,
+			DecidedBy:           user,
+		})
+		h.respond(w, callOut, err)
+	case len(parts) == 1 || parts[1] == "apply":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// DRIVER APP SYNC
+// ============================================================================
+
+func (h *Handler) syncDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input struct {
+		DriverID      uuid.UUID           `json:"driver_id"`
+		KnownVersions map[uuid.UUID]int64 `json:"known_versions"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	if input.DriverID == uuid.Nil {
+		h.writeError(w, apperrors.ValidationError("driver_id is required", "driver_id", nil))
+		return
+	}
+
+	download, err := h.syncs.DownloadTrips(r.Context(), input.DriverID, input.KnownVersions)
+	h.respond(w, download, err)
+}
+
+func (h *Handler) syncUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input struct {
+		DriverID uuid.UUID                 `json:"driver_id"`
+		DeviceID string                    `json:"device_id"`
+		Actions  []domain.DriverSyncAction `json:"actions"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	if input.DriverID == uuid.Nil {
+		h.writeError(w, apperrors.ValidationError("driver_id is required", "driver_id", nil))
+		return
+	}
+
+	upload, err := h.syncs.UploadActions(r.Context(), input.DriverID, input.DeviceID, input.Actions)
+	h.respond(w, upload, err)
+}
+
+// ============================================================================
+// DRIVER EMERGENCIES
+// ============================================================================
+
+func (h *Handler) mobileEmergency(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/mobile/emergency")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var input struct {
+			DriverID  uuid.UUID  `json:"driver_id"`
+			TripID    *uuid.UUID `json:"trip_id"`
+			Latitude  float64    `json:"latitude"`
+			Longitude float64    `json:"longitude"`
+			AccuracyM float64    `json:"accuracy_m"`
+			Notes     string     `json:"notes"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		incident, err := h.emergencies.TriggerPanic(r.Context(), service.PanicInput{
+			DriverID:  input.DriverID,
+			TripID:    input.TripID,
+			Latitude:  input.Latitude,
+			Longitude: input.Longitude,
+			AccuracyM: input.AccuracyM,
+			Notes:     input.Notes,
+		})
+		h.respondCreated(w, incident, err)
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		raw := r.URL.Query().Get("driver_id")
+		driverID, err := uuid.Parse(raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid driver_id", "driver_id", raw))
+			return
+		}
+		incident, err := h.emergencies.GetActiveForDriver(r.Context(), driverID)
+		h.respond(w, incident, err)
+	case len(parts) == 2 && parts[1] == "locations" && r.Method == http.MethodPost:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		var input struct {
+			DriverID   uuid.UUID `json:"driver_id"`
+			Latitude   float64   `json:"latitude"`
+			Longitude  float64   `json:"longitude"`
+			AccuracyM  float64   `json:"accuracy_m"`
+			SpeedMPH   float64   `json:"speed_mph"`
+			Heading    float64   `json:"heading"`
+			RecordedAt time.Time `json:"recorded_at"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		incident, err := h.emergencies.RecordLocation(r.Context(), service.EmergencyLocationInput{
+			IncidentID: id,
+			DriverID:   input.DriverID,
+			Latitude:   input.Latitude,
+			Longitude:  input.Longitude,
+			AccuracyM:  input.AccuracyM,
+			SpeedMPH:   input.SpeedMPH,
+			Heading:    input.Heading,
+			RecordedAt: input.RecordedAt,
+		})
+		h.respond(w, incident, err)
+	case len(parts) == 0 || (len(parts) == 2 && parts[1] == "locations"):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) emergency(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/emergencies")
+	if len(parts) == 0 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		incidents, err := h.emergencies.ListActiveIncidents(r.Context())
+		h.respond(w, incidents, err)
+		return
+	}
+	if len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		incident, err := h.emergencies.GetIncident(r.Context(), id)
+		h.respond(w, incident, err)
+	case len(parts) == 2 && parts[1] == "acknowledge" && r.Method == http.MethodPost:
+		incident, err := h.emergencies.AcknowledgeIncident(r.Context(), id, user)
+		h.respond(w, incident, err)
+	case len(parts) == 2 && parts[1] == "close" && r.Method == http.MethodPost:
+		var input struct {
+			Resolution string `json:"resolution"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		incident, err := h.emergencies.CloseIncident(r.Context(), id, user, input.Resolution)
+		h.respond(w, incident, err)
+	case len(parts) == 1 || parts[1] == "acknowledge" || parts[1] == "close":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) emergencyContacts(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/emergency-contacts")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		contacts, err := h.emergencies.ListContacts(r.Context())
+		h.respond(w, contacts, err)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var input struct {
+			Name       string `json:"name"`
+			Phone      string `json:"phone"`
+			NotifySMS  bool   `json:"notify_sms"`
+			NotifyCall bool   `json:"notify_call"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		contact, err := h.emergencies.AddContact(r.Context(), service.AddContactInput{
+			Name:       input.Name,
+			Phone:      input.Phone,
+			NotifySMS:  input.NotifySMS,
+			NotifyCall: input.NotifyCall,
+			CreatedBy:  user,
+		})
+		h.respondCreated(w, contact, err)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		if err := h.emergencies.RemoveContact(r.Context(), id); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) <= 1:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// DETENTION CLOCKS
+// ============================================================================
+
+func (h *Handler) driverDetentionClock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	raw := r.URL.Query().Get("driver_id")
+	driverID, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid driver_id", "driver_id", raw))
+		return
+	}
+
+	clock, err := h.dispatch.GetDriverDetentionClock(r.Context(), driverID, time.Now())
+	h.respond(w, clock, err)
+}
+
+func (h *Handler) detentionClocks(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	clocks, err := h.dispatch.GetDetentionClocks(r.Context(), time.Now())
+	h.respond(w, clocks, err)
+}
+
+func (h *Handler) tripPlan(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	date := time.Now()
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", raw, time.Local); err != nil {
+			h.writeError(w, apperrors.ValidationError("date must be YYYY-MM-DD", "date", raw))
+			return
+		}
+	}
+
+	plan, err := h.plans.BuildPlan(r.Context(), date)
+	h.respond(w, plan, err)
+}
+
+func (h *Handler) applyTripPlan(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input struct {
+		Assignments []domain.TripPlanAssignment `json:"assignments"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+
+	results, err := h.plans.ApplyPlan(r.Context(), service.ApplyTripPlanInput{
+		Assignments: input.Assignments,
+		AppliedBy:   user,
+	})
+	h.respond(w, results, err)
+}
+
+// ============================================================================
+// YARD GATES
+// ============================================================================
+
+func (h *Handler) yard(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/yards/")
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	yardID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch {
+	case parts[1] == "gate-events" && r.Method == http.MethodPost:
+		h.recordGateEvent(w, r, yardID, user)
+	case parts[1] == "gate-events" && r.Method == http.MethodGet:
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if raw := r.URL.Query().Get("from"); raw != "" {
+			if from, ok = h.parseTime(w, "from", raw); !ok {
+				return
+			}
+		}
+		to := from.AddDate(0, 0, 1)
+		if raw := r.URL.Query().Get("to"); raw != "" {
+			if to, ok = h.parseTime(w, "to", raw); !ok {
+				return
+			}
+		}
+		events, err := h.yards.GetGateEvents(r.Context(), yardID, from, to)
+		h.respond(w, events, err)
+	case parts[1] == "inventory" && r.Method == http.MethodGet:
+		inventory, err := h.yards.GetYardInventory(r.Context(), yardID)
+		h.respond(w, inventory, err)
+	case parts[1] == "gate-events" || parts[1] == "inventory":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) recordGateEvent(w http.ResponseWriter, r *http.Request, yardID uuid.UUID, user string) {
+	var input struct {
+		Direction        domain.GateDirection      `json:"direction"`
+		ContainerNumber  string                    `json:"container_number"`
+		ContainerSize    string                    `json:"container_size"`
+		IsLoaded         bool                      `json:"is_loaded"`
+		SealNumber       string                    `json:"seal_number"`
+		ChassisID        *uuid.UUID                `json:"chassis_id"`
+		ChassisNumber    string                    `json:"chassis_number"`
+		ChassisPoolID    *uuid.UUID                `json:"chassis_pool_id"`
+		TripID           *uuid.UUID                `json:"trip_id"`
+		DriverID         *uuid.UUID                `json:"driver_id"`
+		TractorID        *uuid.UUID                `json:"tractor_id"`
+		Condition        domain.EquipmentCondition `json:"condition"`
+		DamageNotes      string                    `json:"damage_notes"`
+		PhotoDocumentIDs []string                  `json:"photo_document_ids"`
+		OccurredAt       time.Time                 `json:"occurred_at"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	event, err := h.yards.RecordGateEvent(r.Context(), service.RecordGateEventInput{
+		YardLocationID:   yardID,
+		Direction:        input.Direction,
+		ContainerNumber:  input.ContainerNumber,
+		ContainerSize:    input.ContainerSize,
+		IsLoaded:         input.IsLoaded,
+		SealNumber:       input.SealNumber,
+		ChassisID:        input.ChassisID,
+		ChassisNumber:    input.ChassisNumber,
+		ChassisPoolID:    input.ChassisPoolID,
+		TripID:           input.TripID,
+		DriverID:         input.DriverID,
+		TractorID:        input.TractorID,
+		Condition:        input.Condition,
+		DamageNotes:      input.DamageNotes,
+		PhotoDocumentIDs: input.PhotoDocumentIDs,
+		OccurredAt:       input.OccurredAt,
+		RecordedBy:       user,
+	})
+	h.respondCreated(w, event, err)
+}
+
+// ============================================================================
+// CUTOVER
+// ============================================================================
+
+func (h *Handler) cutoverMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.cutover.CutoverMetrics())
+}
+
+// ============================================================================
+// ATTACHMENTS
+// ============================================================================
+
+// uploadAttachment takes the raw file as the request body so it streams to
+// storage without being buffered
+func (h *Handler) uploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	input := service.UploadAttachmentInput{
+		Kind:        domain.AttachmentKind(strings.ToUpper(query.Get("kind"))),
+		FileName:    query.Get("file_name"),
+		ContentType: r.Header.Get("Content-Type"),
+		Size:        r.ContentLength,
+	}
+	for field, dst := range map[string]**uuid.UUID{
+		"trip_id":   &input.TripID,
+		"stop_id":   &input.StopID,
+		"driver_id": &input.DriverID,
+	} {
+		raw := query.Get(field)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid "+field, field, raw))
+			return
+		}
+		*dst = &id
+	}
+	if input.DriverID == nil {
+		h.writeError(w, apperrors.ValidationError("driver_id is required", "driver_id", nil))
+		return
+	}
+	input.UploadedBy = input.DriverID.String()
+
+	body := http.MaxBytesReader(w, r.Body, maxAttachmentBytes)
+	attachment, err := h.attachments.UploadAttachment(r.Context(), input, body)
+	h.respondCreated(w, attachment, err)
+}
+
+func (h *Handler) attachment(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/attachments/")
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	attachment, body, err := h.attachments.OpenAttachment(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer body.Close()
+
+	if attachment.ContentType != "" {
+		w.Header().Set("Content-Type", attachment.ContentType)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		h.logger.Warnw("Attachment download interrupted", "attachment_id", id, "error", err)
+	}
+}
+
+// ============================================================================
+// SPLIT DELIVERIES
+// ============================================================================
+
+func (h *Handler) setStopDeliveries(w http.ResponseWriter, r *http.Request, tripID uuid.UUID, user string) {
+	var input struct {
+		StopID     uuid.UUID `json:"stop_id"`
+		Deliveries []struct {
+			OrderID         *uuid.UUID `json:"order_id"`
+			Commodity       string     `json:"commodity"`
+			Pieces          int        `json:"pieces"`
+			PieceUnit       string     `json:"piece_unit"`
+			WeightLbs       int        `json:"weight_lbs"`
+			ConsigneeName   string     `json:"consignee_name"`
+			ReferenceNumber string     `json:"reference_number"`
+		} `json:"deliveries"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	deliveries := make([]service.StopDeliveryInput, len(input.Deliveries))
+	for i, d := range input.Deliveries {
+		deliveries[i] = service.StopDeliveryInput{
+			OrderID:         d.OrderID,
+			Commodity:       d.Commodity,
+			Pieces:          d.Pieces,
+			PieceUnit:       d.PieceUnit,
+			WeightLbs:       d.WeightLbs,
+			ConsigneeName:   d.ConsigneeName,
+			ReferenceNumber: d.ReferenceNumber,
+		}
+	}
+	splits, err := h.deliveries.SetStopDeliveries(r.Context(), service.SetStopDeliveriesInput{
+		TripID:     tripID,
+		StopID:     input.StopID,
+		Deliveries: deliveries,
+		UpdatedBy:  user,
+	})
+	h.respond(w, splits, err)
+}
+
+func (h *Handler) delivery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/deliveries/")
+	if len(parts) != 2 || parts[1] != "pod" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PiecesDelivered *int       `json:"pieces_delivered"`
+		DocumentIDs     []string   `json:"document_ids"`
+		SignedBy        string     `json:"signed_by"`
+		Notes           string     `json:"notes"`
+		ReceivedAt      *time.Time `json:"received_at"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	delivery, err := h.deliveries.RecordDeliveryPOD(r.Context(), service.RecordDeliveryPODInput{
+		DeliveryID:      id,
+		PiecesDelivered: input.PiecesDelivered,
+		DocumentIDs:     input.DocumentIDs,
+		SignedBy:        input.SignedBy,
+		Notes:           input.Notes,
+		ReceivedAt:      input.ReceivedAt,
+	})
+	h.respond(w, delivery, err)
+}
+
+// ============================================================================
+// EMPTY RETURNS
+// ============================================================================
+
+func (h *Handler) emptyReturn(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/containers/")
+	if len(parts) != 2 || parts[1] != "empty-return" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	containerID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	var input struct {
+		LocationID  *uuid.UUID `json:"location_id"`
+		AvailableAt time.Time  `json:"available_at"`
+	}
+	if r.ContentLength != 0 && !h.decode(w, r, &input) {
+		return
+	}
+	rec, err := h.empties.RecommendAndPublish(r.Context(), service.EmptyReturnInput{
+		ContainerID: containerID,
+		LocationID:  input.LocationID,
+		AvailableAt: input.AvailableAt,
+	})
+	h.respond(w, rec, err)
+}
+
+// ============================================================================
+// AXLE WEIGHTS
+// ============================================================================
+
+func (h *Handler) estimateAxleWeights(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input struct {
+		ContainerID    *uuid.UUID `json:"container_id"`
+		ContainerSize  string     `json:"container_size"`
+		GrossWeightLbs int        `json:"gross_weight_lbs"`
+		ChassisID      *uuid.UUID `json:"chassis_id"`
+		ChassisType    string     `json:"chassis_type"`
+		TractorID      *uuid.UUID `json:"tractor_id"`
+		TractorConfig  string     `json:"tractor_config"`
+		States         []string   `json:"states"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	check, err := h.axles.EstimateAxleWeights(r.Context(), service.EstimateAxleWeightsInput{
+		ContainerID:    input.ContainerID,
+		ContainerSize:  input.ContainerSize,
+		GrossWeightLbs: input.GrossWeightLbs,
+		ChassisID:      input.ChassisID,
+		ChassisType:    input.ChassisType,
+		TractorID:      input.TractorID,
+		TractorConfig:  input.TractorConfig,
+		States:         input.States,
+	})
+	h.respond(w, check, err)
+}
+
+// ============================================================================
+// OWNER-OPERATOR COMPLIANCE
+// ============================================================================
+
+func (h *Handler) ownerOperators(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/owner-operators")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		carriers, err := h.owners.ListCarriers(r.Context())
+		h.respond(w, carriers, err)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.registerOwnerOperator(w, r)
+	case len(parts) == 0:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		h.ownerOperator(w, r, id, parts[1:], user)
+	}
+}
+
+func (h *Handler) ownerOperator(w http.ResponseWriter, r *http.Request, id uuid.UUID, parts []string, user string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		compliance, err := h.owners.GetCompliance(r.Context(), id)
+		h.respond(w, compliance, err)
+	case len(parts) == 0 && r.Method == http.MethodPut:
+		h.updateOwnerOperator(w, r, id)
+	case len(parts) == 1 && parts[0] == "verify" && r.Method == http.MethodPost:
+		compliance, err := h.owners.VerifyCarrier(r.Context(), id)
+		h.respond(w, compliance, err)
+	case len(parts) == 1 && parts[0] == "certificates" && r.Method == http.MethodPost:
+		h.addInsuranceCertificate(w, r, id, user)
+	case len(parts) == 3 && parts[0] == "certificates" && parts[2] == "cancel" && r.Method == http.MethodPost:
+		certificateID, ok := h.parseID(w, parts[1])
+		if !ok {
+			return
+		}
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		compliance, err := h.owners.CancelCertificate(r.Context(), id, certificateID, input.Reason, user)
+		h.respond(w, compliance, err)
+	case len(parts) == 0 || (len(parts) == 1 && (parts[0] == "verify" || parts[0] == "certificates")) ||
+		(len(parts) == 3 && parts[0] == "certificates" && parts[2] == "cancel"):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) registerOwnerOperator(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DriverID      string `json:"driver_id"`
+		LegalName     string `json:"legal_name"`
+		AuthorityType string `json:"authority_type"`
+		DOTNumber     string `json:"dot_number"`
+		MCNumber      string `json:"mc_number"`
+		Notes         string `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	driverID, ok := h.parseID(w, input.DriverID)
+	if !ok {
+		return
+	}
+	compliance, err := h.owners.RegisterCarrier(r.Context(), service.RegisterCarrierInput{
+		DriverID:      driverID,
+		LegalName:     input.LegalName,
+		AuthorityType: domain.AuthorityType(input.AuthorityType),
+		DOTNumber:     input.DOTNumber,
+		MCNumber:      input.MCNumber,
+		Notes:         input.Notes,
+	})
+	h.respondCreated(w, compliance, err)
+}
+
+func (h *Handler) updateOwnerOperator(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	var input struct {
+		LegalName       *string                 `json:"legal_name"`
+		AuthorityType   *domain.AuthorityType   `json:"authority_type"`
+		DOTNumber       *string                 `json:"dot_number"`
+		MCNumber        *string                 `json:"mc_number"`
+		AuthorityStatus *domain.AuthorityStatus `json:"authority_status"`
+		Notes           *string                 `json:"notes"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	compliance, err := h.owners.UpdateCarrier(r.Context(), id, service.UpdateCarrierInput{
+		LegalName:       input.LegalName,
+		AuthorityType:   input.AuthorityType,
+		DOTNumber:       input.DOTNumber,
+		MCNumber:        input.MCNumber,
+		AuthorityStatus: input.AuthorityStatus,
+		Notes:           input.Notes,
+	})
+	h.respond(w, compliance, err)
+}
+
+func (h *Handler) addInsuranceCertificate(w http.ResponseWriter, r *http.Request, carrierID uuid.UUID, user string) {
+	var input struct {
+		Coverage       string  `json:"coverage"`
+		InsurerName    string  `json:"insurer_name"`
+		PolicyNumber   string  `json:"policy_number"`
+		CoverageAmount float64 `json:"coverage_amount"`
+		EffectiveDate  string  `json:"effective_date"`
+		ExpirationDate string  `json:"expiration_date"`
+		AttachmentID   string  `json:"attachment_id"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	effective, ok := h.parseTime(w, "effective_date", input.EffectiveDate)
+	if !ok {
+		return
+	}
+	expiration, ok := h.parseTime(w, "expiration_date", input.ExpirationDate)
+	if !ok {
+		return
+	}
+	var attachmentID *uuid.UUID
+	if input.AttachmentID != "" {
+		id, ok := h.parseID(w, input.AttachmentID)
+		if !ok {
+			return
+		}
+		attachmentID = &id
+	}
+
+	compliance, err := h.owners.AddCertificate(r.Context(), service.AddCertificateInput{
+		CarrierID:      carrierID,
+		Coverage:       input.Coverage,
+		InsurerName:    input.InsurerName,
+		PolicyNumber:   input.PolicyNumber,
+		CoverageAmount: input.CoverageAmount,
+		EffectiveDate:  effective,
+		ExpirationDate: expiration,
+		AttachmentID:   attachmentID,
+		AddedBy:        user,
+	})
+	h.respondCreated(w, compliance, err)
+}
+
+// ============================================================================
+// LEARNED LOCATION GEOFENCES
+// ============================================================================
+
+func (h *Handler) locationProposals(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/location-proposals")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		status := domain.LocationProposalStatus(strings.ToUpper(r.URL.Query().Get("status")))
+		proposals, err := h.geofences.ListProposals(r.Context(), status)
+		h.respond(w, proposals, err)
+	case len(parts) == 1 && parts[0] == "learn" && r.Method == http.MethodPost:
+		proposed, err := h.geofences.Learn(r.Context(), time.Now())
+		h.respond(w, map[string]int{"proposals": proposed}, err)
+	case len(parts) == 1 && (parts[0] == "accept" || parts[0] == "reject") && r.Method == http.MethodPost:
+		var input struct {
+			IDs []uuid.UUID `json:"ids"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		var review *domain.LocationProposalReview
+		var err error
+		if parts[0] == "accept" {
+			review, err = h.geofences.AcceptProposals(r.Context(), input.IDs, user)
+		} else {
+			review, err = h.geofences.RejectProposals(r.Context(), input.IDs, user)
+		}
+		h.respond(w, review, err)
+	case len(parts) == 0 || (len(parts) == 1 && (parts[0] == "learn" || parts[0] == "accept" || parts[0] == "reject")):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// SAVED TRIP FILTERS
+// ============================================================================
+
+func (h *Handler) tripFilters(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/trip-filters")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		filters, err := h.tags.ListTripFilters(r.Context(), user)
+		h.respond(w, filters, err)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.saveTripFilter(w, r, nil, user)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		h.saveTripFilter(w, r, &id, user)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		if err := h.tags.DeleteTripFilter(r.Context(), id, user); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "trips" && r.Method == http.MethodGet:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		page, ok := h.queryInt(w, r, "page")
+		if !ok {
+			return
+		}
+		pageSize, ok := h.queryInt(w, r, "page_size")
+		if !ok {
+			return
+		}
+		trips, err := h.tags.RunTripFilter(r.Context(), id, user, page, pageSize)
+		h.respond(w, trips, err)
+	case len(parts) <= 1 || (len(parts) == 2 && parts[1] == "trips"):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) saveTripFilter(w http.ResponseWriter, r *http.Request, id *uuid.UUID, user string) {
+	var input struct {
+		Name      string                    `json:"name"`
+		Criteria  domain.TripFilterCriteria `json:"criteria"`
+		IsDefault bool                      `json:"is_default"`
+	}
+	if !h.decode(w, r, &input) {
+		return
+	}
+	filter, err := h.tags.SaveTripFilter(r.Context(), service.SaveTripFilterInput{
+		ID:        id,
+		UserID:    user,
+		Name:      input.Name,
+		Criteria:  input.Criteria,
+		IsDefault: input.IsDefault,
+	})
+	if id == nil {
+		h.respondCreated(w, filter, err)
+		return
+	}
+	h.respond(w, filter, err)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func (h *Handler) user(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := strings.TrimSpace(r.Header.Get(userHeader))
+	if user == "" {
+		writeJSON(w, http.StatusUnauthorized, apperrors.New("UNAUTHORIZED", "missing user identity"))
+		return "", false
+	}
+	return user, true
+}
+
+// queryInt parses an optional integer query parameter, 0 when absent
+func (h *Handler) queryInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid "+name, name, raw))
+		return 0, false
+	}
+	return n, true
+}
+
+func pathParts(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid id", "id", raw))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseTime accepts a date, taken as local midnight, or an RFC 3339 time
+func (h *Handler) parseTime(w http.ResponseWriter, field, raw string) (time.Time, bool) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid "+field, field, raw))
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) respondCreated(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		status = http.StatusBadRequest
+	case "UNAUTHORIZED":
+		status = http.StatusUnauthorized
+	case "NOT_FOUND":
+		status = http.StatusNotFound
+	case "CONFLICT", "INVALID_STATE", "ESCORT_REQUIRED", "SLOT_FULL", "GATE_WINDOW_CONFLICT", "DRIVER_HOUR_CONFLICT":
+		status = http.StatusConflict
+	case "INSUFFICIENT_RESOURCE", "ESCORT_NOT_ALLOWED", "GATE_APPOINTMENT_REQUIRED", "CARRIER_NOT_COMPLIANT", "INSUFFICIENT_PTO":
+		status = http.StatusUnprocessableEntity
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Dispatch request failed", "error", err)
+	}
+
+	writeJSON(w, status, appErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
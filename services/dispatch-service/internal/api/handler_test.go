@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/draymaster/services/dispatch-service/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+func TestRoutesMountsOnlyConfiguredFeatures(t *testing.T) {
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(Services{AutoAssign: &service.AutoDispatchService{}}, log)
+	routes := h.Routes()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"health", http.MethodGet, "/health", http.StatusOK},
+		{"unconfigured feature", http.MethodGet, "/v1/emergencies", http.StatusNotFound},
+		{"unconfigured trip subresource", http.MethodGet, "/v1/trips/2b1c4d7e-0000-4000-8000-000000000001/timeline", http.StatusNotFound},
+		{"unconfigured terminal subresource", http.MethodGet, "/v1/terminals/2b1c4d7e-0000-4000-8000-000000000001/gate-slots", http.StatusNotFound},
+		{"unconfigured driver subresource", http.MethodGet, "/v1/drivers/2b1c4d7e-0000-4000-8000-000000000001/pto", http.StatusNotFound},
+		{"configured route, wrong method", http.MethodDelete, "/v1/terminals/2b1c4d7e-0000-4000-8000-000000000001/auto-dispatch", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(userHeader, "ops-1")
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
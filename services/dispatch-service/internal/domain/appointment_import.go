@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppointmentRowOutcome is what an import did with one row of a terminal's
+// appointment confirmation file
+type AppointmentRowOutcome string
+
+const (
+	AppointmentRowUpdated   AppointmentRowOutcome = "UPDATED"   // The stop's appointment was set from the row
+	AppointmentRowUnchanged AppointmentRowOutcome = "UNCHANGED" // The stop already had the row's appointment
+	AppointmentRowMatched   AppointmentRowOutcome = "MATCHED"   // Dry run: the row would update the stop
+	AppointmentRowUnmatched AppointmentRowOutcome = "UNMATCHED" // No open stop for the container on the date
+	AppointmentRowAmbiguous AppointmentRowOutcome = "AMBIGUOUS" // More than one open stop for the container on the date
+	AppointmentRowInvalid   AppointmentRowOutcome = "INVALID"   // Missing or unreadable container number or time
+	AppointmentRowFailed    AppointmentRowOutcome = "FAILED"    // Matched, but the stop could not be saved
+)
+
+// AppointmentImportRow is one row of a confirmation file and what was done
+// with it. Rows needing manual handling carry the reason.
+type AppointmentImportRow struct {
+	Row               int                   `json:"row"` // Line or sheet row, counting the header as 1
+	ContainerNumber   string                `json:"container_number"`
+	AppointmentTime   *time.Time            `json:"appointment_time,omitempty"`
+	AppointmentNumber string                `json:"appointment_number,omitempty"`
+	Outcome           AppointmentRowOutcome `json:"outcome"`
+	Reason            string                `json:"reason,omitempty"`
+	TripID            *uuid.UUID            `json:"trip_id,omitempty"`
+	TripNumber        string                `json:"trip_number,omitempty"`
+	StopID            *uuid.UUID            `json:"stop_id,omitempty"`
+}
+
+// AppointmentImport is the result of importing a terminal's appointment
+// confirmation file
+type AppointmentImport struct {
+	FileName   string                 `json:"file_name"`
+	TerminalID *uuid.UUID             `json:"terminal_id,omitempty"`
+	DryRun     bool                   `json:"dry_run"`
+	Rows       []AppointmentImportRow `json:"rows"`
+	Updated    int                    `json:"updated"`
+	Unchanged  int                    `json:"unchanged"`
+	Matched    int                    `json:"matched"`     // Dry run only
+	NeedReview int                    `json:"need_review"` // Unmatched, ambiguous, invalid or failed rows
+	ImportedBy string                 `json:"imported_by"`
+	ImportedAt time.Time              `json:"imported_at"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentKind is what a file uploaded against a trip shows
+type AttachmentKind string
+
+const (
+	AttachmentKindPOD         AttachmentKind = "POD"          // Signed delivery receipt or photo of it
+	AttachmentKindSealPhoto   AttachmentKind = "SEAL_PHOTO"   // Seal on the container doors at pickup
+	AttachmentKindDamagePhoto AttachmentKind = "DAMAGE_PHOTO" // Container or chassis damage, at a stop or a yard gate
+	AttachmentKindOther       AttachmentKind = "OTHER"
+)
+
+// IsValid checks if the kind is one the app may upload
+func (k AttachmentKind) IsValid() bool {
+	switch k {
+	case AttachmentKindPOD, AttachmentKindSealPhoto, AttachmentKindDamagePhoto, AttachmentKindOther:
+		return true
+	}
+	return false
+}
+
+// Attachment is a file a driver or dispatcher uploaded against a trip. The
+// file is kept in blob storage under StorageKey; the attachment ID is what
+// stop PODs, seal verifications and yard gate events reference.
+type Attachment struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	Kind        AttachmentKind `json:"kind" db:"kind"`
+	TripID      *uuid.UUID     `json:"trip_id,omitempty" db:"trip_id"`
+	StopID      *uuid.UUID     `json:"stop_id,omitempty" db:"stop_id"`
+	DriverID    *uuid.UUID     `json:"driver_id,omitempty" db:"driver_id"`
+	FileName    string         `json:"file_name" db:"file_name"`
+	ContentType string         `json:"content_type" db:"content_type"`
+	SizeBytes   int64          `json:"size_bytes" db:"size_bytes"`
+	StorageKey  string         `json:"-" db:"storage_key"`
+	UploadedBy  string         `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AutoDispatchConfig controls automatic assignment for trips picking up at a terminal
+type AutoDispatchConfig struct {
+	TerminalID       uuid.UUID `json:"terminal_id" db:"terminal_id"`
+	Enabled          bool      `json:"enabled" db:"enabled"`
+	WindowStart      string    `json:"window_start" db:"window_start"` // HH:MM local, inclusive
+	WindowEnd        string    `json:"window_end" db:"window_end"`     // HH:MM local, exclusive
+	LookaheadMins    int       `json:"lookahead_mins" db:"lookahead_mins"`
+	MaxDistanceMiles float64   `json:"max_distance_miles" db:"max_distance_miles"`
+	OverrideMins     int       `json:"override_mins" db:"override_mins"`
+	UpdatedBy        string    `json:"updated_by" db:"updated_by"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// InWindow checks if t falls inside the configured dispatch window. Windows
+// that end before they start wrap past midnight; an empty window is always open.
+func (c *AutoDispatchConfig) InWindow(t time.Time) bool {
+	if c.WindowStart == "" || c.WindowEnd == "" {
+		return true
+	}
+	start, err1 := time.Parse("15:04", c.WindowStart)
+	end, err2 := time.Parse("15:04", c.WindowEnd)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	mins := t.Hour()*60 + t.Minute()
+	startMins := start.Hour()*60 + start.Minute()
+	endMins := end.Hour()*60 + end.Minute()
+
+	if startMins <= endMins {
+		return mins >= startMins && mins < endMins
+	}
+	return mins >= startMins || mins < endMins
+}
+
+// AutoDispatchStatus represents the state of an auto-dispatch decision
+type AutoDispatchStatus string
+
+const (
+	AutoDispatchStatusPending    AutoDispatchStatus = "PENDING_OVERRIDE"
+	AutoDispatchStatusConfirmed  AutoDispatchStatus = "CONFIRMED"
+	AutoDispatchStatusOverridden AutoDispatchStatus = "OVERRIDDEN"
+	AutoDispatchStatusExpired    AutoDispatchStatus = "EXPIRED" // Trip or driver changed before confirmation
+	AutoDispatchStatusSkipped    AutoDispatchStatus = "SKIPPED" // No eligible driver
+)
+
+// AutoDispatchDecision records an automatic assignment and why it was made
+type AutoDispatchDecision struct {
+	ID               uuid.UUID          `json:"id" db:"id"`
+	TripID           uuid.UUID          `json:"trip_id" db:"trip_id"`
+	TripNumber       string             `json:"trip_number" db:"trip_number"`
+	TerminalID       uuid.UUID          `json:"terminal_id" db:"terminal_id"`
+	DriverID         *uuid.UUID         `json:"driver_id,omitempty" db:"driver_id"`
+	DriverName       string             `json:"driver_name,omitempty" db:"driver_name"`
+	Status           AutoDispatchStatus `json:"status" db:"status"`
+	Reason           string             `json:"reason" db:"reason"`
+	DistanceMiles    float64            `json:"distance_miles" db:"distance_miles"`
+	ETAToPickupMins  int                `json:"eta_to_pickup_mins" db:"eta_to_pickup_mins"`
+	SLADeadline      *time.Time         `json:"sla_deadline,omitempty" db:"sla_deadline"`
+	SlackMins        int                `json:"slack_mins" db:"slack_mins"`
+	OverrideDeadline *time.Time         `json:"override_deadline,omitempty" db:"override_deadline"`
+	OverriddenBy     string             `json:"overridden_by,omitempty" db:"overridden_by"`
+	OverrideReason   string             `json:"override_reason,omitempty" db:"override_reason"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+	ResolvedAt       *time.Time         `json:"resolved_at,omitempty" db:"resolved_at"`
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// AxleGroup is a group of axles whose combined load is limited by law
+type AxleGroup string
+
+const (
+	AxleGroupSteer   AxleGroup = "STEER"   // Tractor steer axle
+	AxleGroupDrive   AxleGroup = "DRIVE"   // Tractor drive axles
+	AxleGroupChassis AxleGroup = "CHASSIS" // Chassis rear axles
+	AxleGroupGross   AxleGroup = "GROSS"   // Whole combination
+)
+
+// AxleLoads are estimated loads on each axle group
+type AxleLoads struct {
+	SteerLbs   int `json:"steer_lbs"`
+	DriveLbs   int `json:"drive_lbs"`
+	ChassisLbs int `json:"chassis_lbs"`
+	GrossLbs   int `json:"gross_lbs"`
+}
+
+// AxleViolation is an axle group estimated over its legal limit in a state
+type AxleViolation struct {
+	State    string    `json:"state"`
+	Group    AxleGroup `json:"group"`
+	LoadLbs  int       `json:"load_lbs"`
+	LimitLbs int       `json:"limit_lbs"`
+}
+
+// ChassisAlternative is a chassis that keeps every axle group legal on the route
+type ChassisAlternative struct {
+	ChassisType string    `json:"chassis_type"`
+	Loads       AxleLoads `json:"loads"`
+}
+
+// AxleWeightCheck is the axle weight estimate for a loaded container on a
+// tractor and chassis, checked against the limits of the states it runs in
+type AxleWeightCheck struct {
+	ContainerID     *uuid.UUID      `json:"container_id,omitempty"`
+	ContainerNumber string          `json:"container_number,omitempty"`
+	ContainerSize   string          `json:"container_size"`
+	GrossWeightLbs  int             `json:"gross_weight_lbs"` // Container and cargo
+	ChassisType     string          `json:"chassis_type"`
+	ChassisAssumed  bool            `json:"chassis_assumed"` // No chassis assigned; the default for the size was used
+	TractorConfig   string          `json:"tractor_config"`
+	TractorAssumed  bool            `json:"tractor_assumed"` // No tractor assigned; the default config was used
+	States          []string        `json:"states"`
+	Loads           AxleLoads       `json:"loads"`
+	Violations      []AxleViolation `json:"violations"`
+
+	// Alternatives are chassis that would bring the load within every
+	// limit; when there are none an overweight permit is needed in each
+	// of PermitStates
+	Alternatives []ChassisAlternative `json:"alternatives,omitempty"`
+	PermitStates []string             `json:"permit_states,omitempty"`
+}
+
+// Overweight reports whether any axle group is over its limit
+func (c *AxleWeightCheck) Overweight() bool {
+	return len(c.Violations) > 0
+}
+
+// TripAxleWeights holds the axle weight checks for each loaded container on a trip
+type TripAxleWeights struct {
+	TripID     uuid.UUID         `json:"trip_id"`
+	TripNumber string            `json:"trip_number"`
+	Checks     []AxleWeightCheck `json:"checks"`
+}
+
+// Overweight reports whether any container on the trip is over an axle limit
+func (t *TripAxleWeights) Overweight() bool {
+	for i := range t.Checks {
+		if t.Checks[i].Overweight() {
+			return true
+		}
+	}
+	return false
+}
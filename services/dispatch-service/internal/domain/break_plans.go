@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Location types offered as places to take a required break
+const (
+	LocationTypeTruckStop = "TRUCK_STOP"
+	LocationTypeRestArea  = "REST_AREA"
+)
+
+// BreakStopActivity is the activity of a suggested break stop on the manifest
+const BreakStopActivity = "REST_BREAK"
+
+// BreakPlanStatus is whether a planned break was taken
+type BreakPlanStatus string
+
+const (
+	BreakPlanPlanned          BreakPlanStatus = "PLANNED"
+	BreakPlanTakenAtSuggested BreakPlanStatus = "TAKEN_AT_SUGGESTED" // Stopped long enough at one of the suggestions
+	BreakPlanTakenElsewhere   BreakPlanStatus = "TAKEN_ELSEWHERE"    // Stopped long enough, but not at a suggestion
+	BreakPlanNotTaken         BreakPlanStatus = "NOT_TAKEN"          // No stop long enough for the break on the GPS trail
+)
+
+// BreakSuggestion is a truck stop or rest area near the predicted break point
+type BreakSuggestion struct {
+	Location      Location `json:"location"`
+	DistanceMiles float64  `json:"distance_miles"` // From the predicted break point
+}
+
+// BreakPlan is where and when a driver's 30-minute break is predicted to
+// fall due on a trip, the places suggested for it and whether it was taken
+type BreakPlan struct {
+	ID                uuid.UUID         `json:"id" db:"id"`
+	TripID            uuid.UUID         `json:"trip_id" db:"trip_id"`
+	DriverID          uuid.UUID         `json:"driver_id" db:"driver_id"`
+	DueAt             time.Time         `json:"due_at" db:"due_at"` // When the driver reaches the driving limit
+	AfterStopSequence int               `json:"after_stop_sequence" db:"after_stop_sequence"`
+	Latitude          float64           `json:"latitude" db:"latitude"` // Point on the route the suggestions were searched around
+	Longitude         float64           `json:"longitude" db:"longitude"`
+	DrivenMins        int               `json:"driven_mins" db:"driven_mins"` // Driving estimated before the trip started
+	Suggestions       []BreakSuggestion `json:"suggestions" db:"suggestions"`
+	Status            BreakPlanStatus   `json:"status" db:"status"`
+
+	// Where the break was taken, from the driver's GPS trail
+	TakenLocationID *uuid.UUID `json:"taken_location_id,omitempty" db:"taken_location_id"`
+	TakenAt         *time.Time `json:"taken_at,omitempty" db:"taken_at"`
+	TakenMins       int        `json:"taken_mins,omitempty" db:"taken_mins"`
+	TakenLatitude   *float64   `json:"taken_latitude,omitempty" db:"taken_latitude"`
+	TakenLongitude  *float64   `json:"taken_longitude,omitempty" db:"taken_longitude"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DriverCallOut marks a driver out for a day, e.g. calling in sick. The
+// driver's assigned and dispatched trips that day are re-planned onto other
+// drivers through reassignment proposals.
+type DriverCallOut struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	DriverID   uuid.UUID `json:"driver_id" db:"driver_id"`
+	DriverName string    `json:"driver_name" db:"driver_name"`
+	Date       time.Time `json:"date" db:"out_date"` // Local midnight of the day out
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+	ReportedBy string    `json:"reported_by" db:"reported_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	Proposals []ReassignmentProposal `json:"proposals,omitempty" db:"-"`
+}
+
+// ReassignmentStatus represents the state of a reassignment proposal
+type ReassignmentStatus string
+
+const (
+	ReassignmentStatusProposed     ReassignmentStatus = "PROPOSED"     // Waiting for the dispatcher
+	ReassignmentStatusUnassignable ReassignmentStatus = "UNASSIGNABLE" // No driver had capacity; needs manual planning
+	ReassignmentStatusApplied      ReassignmentStatus = "APPLIED"      // Trip moved to the proposed driver
+	ReassignmentStatusRejected     ReassignmentStatus = "REJECTED"     // Not accepted when proposals were applied
+	ReassignmentStatusFailed       ReassignmentStatus = "FAILED"       // Accepted but the assignment no longer validated
+)
+
+// ReassignmentProposal moves one of an out driver's trips to another driver
+type ReassignmentProposal struct {
+	ID                 uuid.UUID          `json:"id" db:"id"`
+	CallOutID          uuid.UUID          `json:"call_out_id" db:"call_out_id"`
+	TripID             uuid.UUID          `json:"trip_id" db:"trip_id"`
+	TripNumber         string             `json:"trip_number" db:"trip_number"`
+	TripStatus         TripStatus         `json:"trip_status" db:"trip_status"` // Status when the driver called out
+	PlannedStartTime   *time.Time         `json:"planned_start_time,omitempty" db:"planned_start_time"`
+	FromDriverID       uuid.UUID          `json:"from_driver_id" db:"from_driver_id"`
+	ProposedDriverID   *uuid.UUID         `json:"proposed_driver_id,omitempty" db:"proposed_driver_id"`
+	ProposedDriverName string             `json:"proposed_driver_name,omitempty" db:"proposed_driver_name"`
+	DeadheadMiles      float64            `json:"deadhead_miles" db:"deadhead_miles"` // Proposed driver's position to the first stop
+	Reason             string             `json:"reason" db:"reason"`
+	Status             ReassignmentStatus `json:"status" db:"status"`
+	Error              string             `json:"error,omitempty" db:"error"`
+	DecidedBy          string             `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt          *time.Time         `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt          time.Time          `json:"created_at" db:"created_at"`
+}
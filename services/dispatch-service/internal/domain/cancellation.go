@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CancellationCharge is the billable fee a customer incurs by cancelling a
+// trip after dispatch, with the trip state it was assessed on
+type CancellationCharge struct {
+	ID          uuid.UUID            `json:"id" db:"id"`
+	TripID      uuid.UUID            `json:"trip_id" db:"trip_id"`
+	TripNumber  string               `json:"trip_number" db:"trip_number"`
+	OrderID     *uuid.UUID           `json:"order_id,omitempty" db:"order_id"`
+	ChargeType  string               `json:"charge_type" db:"charge_type"` // CANCELLATION or DRY_RUN
+	Amount      float64              `json:"amount" db:"amount"`
+	Basis       string               `json:"basis" db:"basis"`
+	Evidence    CancellationEvidence `json:"evidence" db:"evidence"`
+	Reason      string               `json:"reason" db:"reason"`
+	CancelledBy string               `json:"cancelled_by" db:"cancelled_by"`
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+}
+
+// CancellationEvidence is the trip state backing a cancellation charge
+type CancellationEvidence struct {
+	TripStatus      TripStatus `json:"trip_status"` // Status when the cancellation came in
+	DriverID        *uuid.UUID `json:"driver_id,omitempty"`
+	ActualStartTime *time.Time `json:"actual_start_time,omitempty"`
+	StopID          *uuid.UUID `json:"stop_id,omitempty"` // Next stop, whose appointment the fee is measured against
+	AppointmentTime *time.Time `json:"appointment_time,omitempty"`
+	HoursAhead      *float64   `json:"hours_ahead,omitempty"`
+	ArrivedAt       *time.Time `json:"arrived_at,omitempty"` // Driver arrival at the stop, for dry runs
+	Profile         string     `json:"profile"`              // Contract profile the rule came from
+	RuleType        string     `json:"rule_type"`
+	CancelledAt     time.Time  `json:"cancelled_at"`
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChassisOwnership is who provides a chassis and so whether it is rented by the day
+type ChassisOwnership string
+
+const (
+	ChassisOwnershipCompany ChassisOwnership = "COMPANY" // Owned; no per-diem
+	ChassisOwnershipLeased  ChassisOwnership = "LEASED"  // Long-term lease; no per-diem
+	ChassisOwnershipPool    ChassisOwnership = "POOL"    // Shared pool, rented by the day
+	ChassisOwnershipIEP     ChassisOwnership = "IEP"     // Intermodal equipment provider, rented by the day
+)
+
+// IsRental reports whether the chassis is charged per day of use
+func (o ChassisOwnership) IsRental() bool {
+	return o == ChassisOwnershipPool || o == ChassisOwnershipIEP
+}
+
+// ChassisStatus is whether a chassis can be put on a trip
+type ChassisStatus string
+
+const (
+	ChassisStatusAvailable    ChassisStatus = "AVAILABLE"
+	ChassisStatusInUse        ChassisStatus = "IN_USE"
+	ChassisStatusOutOfService ChassisStatus = "OUT_OF_SERVICE"
+	ChassisStatusOffHired     ChassisStatus = "OFF_HIRED" // Returned to its provider
+)
+
+// ChassisPool is a chassis pool or provider with its daily rental rates
+type ChassisPool struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Code         string    `json:"code" db:"code"` // DCLI, TRAC, FLEXI...
+	Name         string    `json:"name" db:"name"`
+	ProviderName string    `json:"provider_name,omitempty" db:"provider_name"`
+	DailyRate20  float64   `json:"daily_rate_20" db:"daily_rate_20"`
+	DailyRate40  float64   `json:"daily_rate_40" db:"daily_rate_40"`
+	DailyRate45  float64   `json:"daily_rate_45" db:"daily_rate_45"`
+	SplitFee     float64   `json:"split_fee" db:"split_fee"` // Charged when a chassis is picked up or returned away from its container
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DailyRate returns the pool's rate for a chassis size
+func (p *ChassisPool) DailyRate(size string) float64 {
+	switch size {
+	case "20":
+		return p.DailyRate20
+	case "45":
+		return p.DailyRate45
+	default:
+		return p.DailyRate40
+	}
+}
+
+// Chassis represents a chassis and where it is
+type Chassis struct {
+	ID                uuid.UUID        `json:"id" db:"id"`
+	ChassisNumber     string           `json:"chassis_number" db:"chassis_number"`
+	Size              string           `json:"size" db:"size"` // 20, 40, 45
+	Type              string           `json:"type" db:"type"` // standard, extendable, tri_axle, gooseneck
+	TareWeight        int              `json:"tare_weight" db:"tare_weight"`
+	NumAxles          int              `json:"num_axles" db:"num_axles"`
+	Ownership         ChassisOwnership `json:"ownership" db:"ownership"`
+	PoolID            *uuid.UUID       `json:"pool_id,omitempty" db:"pool_id"`
+	IEPCode           string           `json:"iep_code,omitempty" db:"iep_code"` // Provider SCAC for IEP chassis
+	Status            ChassisStatus    `json:"status" db:"status"`
+	CurrentLocationID *uuid.UUID       `json:"current_location_id,omitempty" db:"current_location_id"`
+	CurrentTripID     *uuid.UUID       `json:"current_trip_id,omitempty" db:"current_trip_id"`
+	LastMovedAt       *time.Time       `json:"last_moved_at,omitempty" db:"last_moved_at"`
+	Notes             string           `json:"notes,omitempty" db:"notes"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// ProfileKey returns the key of the chassis profile for this chassis
+func (c *Chassis) ProfileKey() string {
+	return c.Size + "_" + c.Type
+}
+
+// ChassisFilter narrows a chassis listing; zero fields match everything
+type ChassisFilter struct {
+	Status     ChassisStatus
+	Ownership  ChassisOwnership
+	PoolID     *uuid.UUID
+	LocationID *uuid.UUID
+}
+
+// ChassisSplitStatus is where a trip's chassis and container parted ways
+type ChassisSplitStatus string
+
+const (
+	ChassisSplitNone   ChassisSplitStatus = "NONE"   // Chassis picked up and returned with the container
+	ChassisSplitPickup ChassisSplitStatus = "PICKUP" // Chassis picked up away from the container
+	ChassisSplitReturn ChassisSplitStatus = "RETURN" // Chassis returned away from where the container was left
+	ChassisSplitBoth   ChassisSplitStatus = "BOTH"
+)
+
+// ChassisSplit records whether a trip split its chassis from the container.
+// A split costs an extra move and, on pool chassis, the pool's split fee.
+type ChassisSplit struct {
+	ID                        uuid.UUID          `json:"id" db:"id"`
+	TripID                    uuid.UUID          `json:"trip_id" db:"trip_id"`
+	ChassisID                 *uuid.UUID         `json:"chassis_id,omitempty" db:"chassis_id"`
+	Status                    ChassisSplitStatus `json:"status" db:"status"`
+	ChassisPickupLocationID   *uuid.UUID         `json:"chassis_pickup_location_id,omitempty" db:"chassis_pickup_location_id"`
+	ContainerPickupLocationID *uuid.UUID         `json:"container_pickup_location_id,omitempty" db:"container_pickup_location_id"`
+	ChassisReturnLocationID   *uuid.UUID         `json:"chassis_return_location_id,omitempty" db:"chassis_return_location_id"`
+	ContainerDropLocationID   *uuid.UUID         `json:"container_drop_location_id,omitempty" db:"container_drop_location_id"`
+	SplitFee                  float64            `json:"split_fee" db:"split_fee"`
+	FlaggedBy                 string             `json:"flagged_by" db:"flagged_by"`
+	CreatedAt                 time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt                 time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// IsSplit reports whether the chassis left or came back without its container
+func (s *ChassisSplit) IsSplit() bool {
+	return s.Status != ChassisSplitNone
+}
+
+// ChassisUsageCharge is the rental owed for one usage of a chassis
+type ChassisUsageCharge struct {
+	Usage     ChassisUsage `json:"usage"`
+	Days      int          `json:"days"` // Counted to now while the chassis is still out
+	DailyRate float64      `json:"daily_rate"`
+	Amount    float64      `json:"amount"`
+}
+
+// ChassisPerDiem is the rental owed on a chassis over a period
+type ChassisPerDiem struct {
+	ChassisID     uuid.UUID            `json:"chassis_id"`
+	ChassisNumber string               `json:"chassis_number"`
+	Ownership     ChassisOwnership     `json:"ownership"`
+	PoolID        *uuid.UUID           `json:"pool_id,omitempty"`
+	PeriodStart   time.Time            `json:"period_start"`
+	PeriodEnd     time.Time            `json:"period_end"`
+	Charges       []ChassisUsageCharge `json:"charges"`
+	TotalDays     int                  `json:"total_days"`
+	SplitCount    int                  `json:"split_count"`
+	SplitFees     float64              `json:"split_fees"`
+	TotalAmount   float64              `json:"total_amount"`
+}
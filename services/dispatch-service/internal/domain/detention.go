@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DetentionClockState is where a stop is on its free time countdown
+type DetentionClockState string
+
+const (
+	DetentionClockFreeTime   DetentionClockState = "FREE_TIME"   // Free time running
+	DetentionClockEndingSoon DetentionClockState = "ENDING_SOON" // Inside the warning lead before detention
+	DetentionClockDetention  DetentionClockState = "DETENTION"   // Free time used up; detention accruing
+)
+
+// DetentionClock is the live free time countdown for a stop the driver has
+// arrived at and not yet left, shown on the driver app and dispatch board
+type DetentionClock struct {
+	TripID             uuid.UUID           `json:"trip_id"`
+	TripNumber         string              `json:"trip_number,omitempty"`
+	StopID             uuid.UUID           `json:"stop_id"`
+	Sequence           int                 `json:"sequence"`
+	Activity           ActivityType        `json:"activity"`
+	LocationID         uuid.UUID           `json:"location_id"`
+	LocationName       string              `json:"location_name,omitempty"`
+	ContainerNumber    string              `json:"container_number,omitempty"`
+	DriverID           *uuid.UUID          `json:"driver_id,omitempty"`
+	DispatcherID       *uuid.UUID          `json:"dispatcher_id,omitempty"`
+	State              DetentionClockState `json:"state"`
+	ArrivedAt          time.Time           `json:"arrived_at"`
+	ArrivalSource      string              `json:"arrival_source,omitempty"` // DRIVER or GEOFENCE
+	FreeTimeMins       int                 `json:"free_time_mins"`
+	FreeTimeEndsAt     time.Time           `json:"free_time_ends_at"`
+	RemainingFreeMins  int                 `json:"remaining_free_mins"` // 0 once detention has started
+	DetentionStartTime *time.Time          `json:"detention_start_time,omitempty"`
+	DetentionMins      int                 `json:"detention_mins"` // Accrued so far
+	WarnedAt           *time.Time          `json:"warned_at,omitempty"`
+	AsOf               time.Time           `json:"as_of"`
+}
+
+// FreeTimeEndsAt returns when detention starts at the stop, or nil before arrival
+func (s *TripStop) FreeTimeEndsAt() *time.Time {
+	if s.ActualArrival == nil {
+		return nil
+	}
+	end := s.ActualArrival.Add(time.Duration(s.FreeTimeMins) * time.Minute)
+	return &end
+}
+
+// NewDetentionClock reads the countdown for an arrived stop as of now. trip
+// may be nil when it could not be loaded.
+func NewDetentionClock(trip *Trip, stop *TripStop, now time.Time, warningLeadMins int) *DetentionClock {
+	ends := stop.FreeTimeEndsAt()
+	if ends == nil {
+		return nil
+	}
+
+	clock := &DetentionClock{
+		TripID:             stop.TripID,
+		StopID:             stop.ID,
+		Sequence:           stop.Sequence,
+		Activity:           stop.Activity,
+		LocationID:         stop.LocationID,
+		ContainerNumber:    stop.ContainerNumber,
+		State:              DetentionClockFreeTime,
+		ArrivedAt:          *stop.ActualArrival,
+		ArrivalSource:      stop.ArrivalSource,
+		FreeTimeMins:       stop.FreeTimeMins,
+		FreeTimeEndsAt:     *ends,
+		DetentionStartTime: stop.DetentionStartTime,
+		WarnedAt:           stop.DetentionWarnedAt,
+		AsOf:               now,
+	}
+	if trip != nil {
+		clock.TripNumber = trip.TripNumber
+		clock.DriverID = trip.DriverID
+		clock.DispatcherID = trip.DispatcherID
+	}
+	if stop.Location != nil {
+		clock.LocationName = stop.Location.Name
+	}
+
+	remaining := ends.Sub(now)
+	switch {
+	case remaining <= 0:
+		clock.State = DetentionClockDetention
+		clock.DetentionMins = int(-remaining.Minutes())
+		if clock.DetentionStartTime == nil {
+			clock.DetentionStartTime = ends
+		}
+	case remaining <= time.Duration(warningLeadMins)*time.Minute:
+		clock.State = DetentionClockEndingSoon
+		clock.RemainingFreeMins = int(math.Ceil(remaining.Minutes()))
+	default:
+		clock.RemainingFreeMins = int(math.Ceil(remaining.Minutes()))
+	}
+	return clock
+}
+
+// How a stop's arrival was recorded
+const (
+	StopArrivalSourceDriver   = "DRIVER"   // Reported from the driver app
+	StopArrivalSourceGeofence = "GEOFENCE" // The truck entered the location's geofence
+)
+
+// FreeTimeUsedPct returns the share of the stop's free time used as of now,
+// 0-100 and beyond once detention has started
+func (s *TripStop) FreeTimeUsedPct(now time.Time) int {
+	if s.ActualArrival == nil {
+		return 0
+	}
+	if s.FreeTimeMins <= 0 {
+		return 100
+	}
+	elapsed := now.Sub(*s.ActualArrival).Minutes()
+	return int(elapsed * 100 / float64(s.FreeTimeMins))
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispatcher is a person on the dispatch desk who owns a share of the trips
+type Dispatcher struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	Name           string      `json:"name" db:"name"`
+	Email          string      `json:"email,omitempty" db:"email"`
+	Active         bool        `json:"active" db:"active"`                       // On shift and taking new trips
+	TerminalIDs    []uuid.UUID `json:"terminal_ids,omitempty" db:"terminal_ids"` // Terminals covered; empty covers all
+	MaxActiveTrips int         `json:"max_active_trips" db:"max_active_trips"`   // 0 uses the configured default
+	LastAssignedAt *time.Time  `json:"last_assigned_at,omitempty" db:"last_assigned_at"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// CoversTerminal reports whether the dispatcher handles trips picking up at the terminal
+func (d *Dispatcher) CoversTerminal(terminalID uuid.UUID) bool {
+	if len(d.TerminalIDs) == 0 {
+		return true
+	}
+	for _, id := range d.TerminalIDs {
+		if id == terminalID {
+			return true
+		}
+	}
+	return false
+}
+
+// DispatcherWorkload is what one dispatcher currently has on their board
+type DispatcherWorkload struct {
+	DispatcherID       uuid.UUID `json:"dispatcher_id" db:"dispatcher_id"`
+	Name               string    `json:"name" db:"name"`
+	Active             bool      `json:"active" db:"active"`
+	MaxActiveTrips     int       `json:"max_active_trips" db:"max_active_trips"`
+	ActiveTrips        int       `json:"active_trips" db:"active_trips"`               // Planned through in progress
+	OpenExceptions     int       `json:"open_exceptions" db:"open_exceptions"`         // Open exceptions on their trips
+	CriticalExceptions int       `json:"critical_exceptions" db:"critical_exceptions"` // Open exceptions needing immediate action
+	LoadPercent        float64   `json:"load_percent"`                                 // Active trips as a share of capacity
+}
+
+// DispatcherBalance is the workload balancing view across the dispatch desk
+type DispatcherBalance struct {
+	Workloads          []DispatcherWorkload `json:"workloads"`
+	UnassignedTrips    int64                `json:"unassigned_trips"` // Active trips with no dispatcher
+	AverageActiveTrips float64              `json:"average_active_trips"`
+	GeneratedAt        time.Time            `json:"generated_at"`
+}
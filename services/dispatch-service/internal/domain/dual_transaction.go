@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TerminalMove is a trip's pending stop at a terminal, such as an empty
+// being returned (DROP_EMPTY) or an import being picked up (PICKUP_LOADED)
+type TerminalMove struct {
+	TripID            uuid.UUID    `json:"trip_id" db:"trip_id"`
+	TripNumber        string       `json:"trip_number" db:"trip_number"`
+	TripStatus        TripStatus   `json:"trip_status" db:"trip_status"`
+	IsDualTransaction bool         `json:"is_dual_transaction" db:"is_dual_transaction"`
+	DriverID          *uuid.UUID   `json:"driver_id,omitempty" db:"driver_id"`
+	StopID            uuid.UUID    `json:"stop_id" db:"stop_id"`
+	TerminalID        uuid.UUID    `json:"terminal_id" db:"terminal_id"`
+	TerminalName      string       `json:"terminal_name" db:"terminal_name"`
+	Activity          ActivityType `json:"activity" db:"activity"`
+	OrderID           *uuid.UUID   `json:"order_id,omitempty" db:"order_id"`
+	ContainerNumber   string       `json:"container_number" db:"container_number"`
+	ContainerSize     string       `json:"container_size" db:"container_size"`
+	SteamshipLine     string       `json:"steamship_line,omitempty" db:"steamship_line"`
+	PlannedTime       time.Time    `json:"planned_time" db:"planned_time"` // Appointment, else planned arrival, else trip planned start
+}
+
+// DualTransactionOpportunity pairs an empty return with an import pickup at
+// the same terminal close enough in time to make in one gate visit: the
+// driver in-gates with the empty and out-gates with the load
+type DualTransactionOpportunity struct {
+	TerminalID       uuid.UUID    `json:"terminal_id"`
+	TerminalName     string       `json:"terminal_name"`
+	EmptyReturn      TerminalMove `json:"empty_return"`
+	ImportPickup     TerminalMove `json:"import_pickup"`
+	GapMins          int          `json:"gap_mins"`          // Between the two planned times
+	ChassisReuse     bool         `json:"chassis_reuse"`     // Same container size, so the empty's chassis carries the load out
+	DriverConflict   bool         `json:"driver_conflict"`   // Both trips already assigned, to different drivers
+	EstimatedSavings float64      `json:"estimated_savings"` // Gate visit and chassis flip saved
+	MatchScore       int          `json:"match_score"`       // 0-100
+}
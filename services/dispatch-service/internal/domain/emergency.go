@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmergencyStatus represents the state of a driver emergency incident
+type EmergencyStatus string
+
+const (
+	EmergencyStatusOpen         EmergencyStatus = "OPEN"         // Panic raised, no one has responded yet
+	EmergencyStatusAcknowledged EmergencyStatus = "ACKNOWLEDGED" // Staff are responding
+	EmergencyStatusClosed       EmergencyStatus = "CLOSED"
+)
+
+// IsActive reports whether the driver's location is still being streamed
+func (s EmergencyStatus) IsActive() bool {
+	return s == EmergencyStatusOpen || s == EmergencyStatusAcknowledged
+}
+
+// EmergencyIncident is opened when a driver presses the panic button in the
+// driver app. While it is active the app reports its location every
+// LocationIntervalSecs instead of at the normal tracking rate.
+type EmergencyIncident struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	DriverID       uuid.UUID       `json:"driver_id" db:"driver_id"`
+	DriverName     string          `json:"driver_name" db:"driver_name"`
+	DriverPhone    string          `json:"driver_phone,omitempty" db:"driver_phone"`
+	TripID         *uuid.UUID      `json:"trip_id,omitempty" db:"trip_id"`
+	TripNumber     string          `json:"trip_number,omitempty" db:"trip_number"`
+	Status         EmergencyStatus `json:"status" db:"status"`
+	Latitude       float64         `json:"latitude" db:"latitude"` // Where the panic was raised
+	Longitude      float64         `json:"longitude" db:"longitude"`
+	Notes          string          `json:"notes,omitempty" db:"notes"`
+	LastLatitude   float64         `json:"last_latitude" db:"last_latitude"`
+	LastLongitude  float64         `json:"last_longitude" db:"last_longitude"`
+	LastLocationAt time.Time       `json:"last_location_at" db:"last_location_at"`
+	AcknowledgedBy string          `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ClosedBy       string          `json:"closed_by,omitempty" db:"closed_by"`
+	ClosedAt       *time.Time      `json:"closed_at,omitempty" db:"closed_at"`
+	Resolution     string          `json:"resolution,omitempty" db:"resolution"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+
+	// LocationIntervalSecs tells the app how often to report; 0 once closed
+	LocationIntervalSecs int                     `json:"location_interval_secs" db:"-"`
+	Notifications        []EmergencyNotification `json:"notifications,omitempty" db:"-"`
+	Locations            []EmergencyLocation     `json:"locations,omitempty" db:"-"`
+}
+
+// EmergencyContact is a staff member alerted when a driver raises a panic
+type EmergencyContact struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Phone      string    `json:"phone" db:"phone"` // E.164
+	NotifySMS  bool      `json:"notify_sms" db:"notify_sms"`
+	NotifyCall bool      `json:"notify_call" db:"notify_call"`
+	Active     bool      `json:"active" db:"active"`
+	CreatedBy  string    `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// EmergencyChannel is how a contact was alerted
+type EmergencyChannel string
+
+const (
+	EmergencyChannelSMS   EmergencyChannel = "SMS"
+	EmergencyChannelVoice EmergencyChannel = "VOICE"
+)
+
+// EmergencyNotification records one alert sent to a contact for an incident
+type EmergencyNotification struct {
+	ID                uuid.UUID        `json:"id" db:"id"`
+	IncidentID        uuid.UUID        `json:"incident_id" db:"incident_id"`
+	ContactID         uuid.UUID        `json:"contact_id" db:"contact_id"`
+	ContactName       string           `json:"contact_name" db:"contact_name"`
+	Phone             string           `json:"phone" db:"phone"`
+	Channel           EmergencyChannel `json:"channel" db:"channel"`
+	Status            SMSStatus        `json:"status" db:"status"` // SENT or FAILED
+	Error             string           `json:"error,omitempty" db:"error"`
+	ProviderMessageID string           `json:"provider_message_id,omitempty" db:"provider_message_id"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+}
+
+// EmergencyLocation is one position streamed by the app during an incident
+type EmergencyLocation struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	IncidentID uuid.UUID `json:"incident_id" db:"incident_id"`
+	Latitude   float64   `json:"latitude" db:"latitude"`
+	Longitude  float64   `json:"longitude" db:"longitude"`
+	AccuracyM  float64   `json:"accuracy_m,omitempty" db:"accuracy_m"`
+	SpeedMPH   float64   `json:"speed_mph,omitempty" db:"speed_mph"`
+	Heading    float64   `json:"heading,omitempty" db:"heading"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
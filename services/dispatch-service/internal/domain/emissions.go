@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoadStatus represents what the tractor is pulling on a trip leg
+type LoadStatus string
+
+const (
+	LoadStatusLoaded  LoadStatus = "LOADED"
+	LoadStatusEmpty   LoadStatus = "EMPTY"
+	LoadStatusBobtail LoadStatus = "BOBTAIL"
+)
+
+// NextLoadStatus returns the load status after performing an activity
+func (a ActivityType) NextLoadStatus(current LoadStatus) LoadStatus {
+	switch a {
+	case ActivityTypePickupLoaded, ActivityTypeLiveLoad:
+		return LoadStatusLoaded
+	case ActivityTypePickupEmpty, ActivityTypeHookEmpty, ActivityTypeLiveUnload:
+		return LoadStatusEmpty
+	case ActivityTypeDeliverLoaded, ActivityTypeDropLoaded, ActivityTypeDropEmpty:
+		return LoadStatusBobtail
+	default:
+		return current
+	}
+}
+
+// EmissionsLeg represents estimated emissions between two consecutive stops
+type EmissionsLeg struct {
+	FromSequence int        `json:"from_sequence"`
+	ToSequence   int        `json:"to_sequence"`
+	LoadStatus   LoadStatus `json:"load_status"`
+	Miles        float64    `json:"miles"`
+	CO2eKg       float64    `json:"co2e_kg"`
+	OrderID      *uuid.UUID `json:"order_id,omitempty"`
+}
+
+// TripEmissions represents estimated CO2e for a trip
+type TripEmissions struct {
+	TripID         uuid.UUID      `json:"trip_id"`
+	TripNumber     string         `json:"trip_number"`
+	EquipmentClass string         `json:"equipment_class"`
+	TotalMiles     float64        `json:"total_miles"`
+	LoadedMiles    float64        `json:"loaded_miles"`
+	EmptyMiles     float64        `json:"empty_miles"`
+	BobtailMiles   float64        `json:"bobtail_miles"`
+	CO2eKg         float64        `json:"co2e_kg"`
+	Legs           []EmissionsLeg `json:"legs"`
+
+	// ByOrder allocates trip emissions to orders; legs without an order are
+	// shared evenly across the orders on the trip
+	ByOrder map[uuid.UUID]float64 `json:"by_order,omitempty"`
+}
+
+// OrderEmissions represents emissions attributed to one order
+type OrderEmissions struct {
+	OrderID uuid.UUID   `json:"order_id"`
+	TripIDs []uuid.UUID `json:"trip_ids"`
+	CO2eKg  float64     `json:"co2e_kg"`
+}
+
+// EmissionsReport summarizes emissions for a customer over a period, suitable
+// for ESG disclosure requests
+type EmissionsReport struct {
+	CustomerID   uuid.UUID        `json:"customer_id"`
+	PeriodStart  time.Time        `json:"period_start"`
+	PeriodEnd    time.Time        `json:"period_end"`
+	TripCount    int              `json:"trip_count"`
+	OrderCount   int              `json:"order_count"`
+	TotalMiles   float64          `json:"total_miles"`
+	LoadedMiles  float64          `json:"loaded_miles"`
+	EmptyMiles   float64          `json:"empty_miles"`
+	BobtailMiles float64          `json:"bobtail_miles"`
+	CO2eKg       float64          `json:"co2e_kg"`
+	CO2eTonnes   float64          `json:"co2e_tonnes"`
+	Orders       []OrderEmissions `json:"orders"`
+	Methodology  string           `json:"methodology"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+}
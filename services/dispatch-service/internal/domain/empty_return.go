@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmptyReturnAction is what to do with an empty import container
+type EmptyReturnAction string
+
+const (
+	EmptyReturnNow        EmptyReturnAction = "RETURN_NOW"    // Take it back to the return terminal at the next appointment
+	EmptyReturnStreetTurn EmptyReturnAction = "STREET_TURN"   // Hold it for a pending export booking
+	EmptyReturnStageYard  EmptyReturnAction = "STAGE_IN_YARD" // Ground it in a company yard and return it later
+)
+
+// EmptyContainer is an empty import container waiting on a decision, with
+// the facts the decision is priced on. Owned by order-service.
+type EmptyContainer struct {
+	ContainerID      uuid.UUID  `json:"container_id"`
+	ContainerNumber  string     `json:"container_number"`
+	ContainerSize    string     `json:"container_size"`
+	ContainerType    string     `json:"container_type"`
+	SteamshipLineID  *uuid.UUID `json:"steamship_line_id,omitempty"`
+	ImportOrderID    uuid.UUID  `json:"import_order_id"`
+	LocationID       uuid.UUID  `json:"location_id"`        // Where the empty sits, normally the consignee
+	ReturnTerminalID uuid.UUID  `json:"return_terminal_id"` // Empty return location on the shipment, else its terminal
+	OutGatedAt       time.Time  `json:"out_gated_at"`       // Start of the per-diem clock
+}
+
+// EmptyReturnCost is an option's cost broken down by what causes it
+type EmptyReturnCost struct {
+	Mileage float64 `json:"mileage"`
+	PerDiem float64 `json:"per_diem"`
+	Chassis float64 `json:"chassis"`
+	Yard    float64 `json:"yard"`    // Lifts and storage
+	Fees    float64 `json:"fees"`    // Street turn approval
+	Savings float64 `json:"savings"` // Empty pickup the export no longer needs
+	Total   float64 `json:"total"`
+}
+
+// EmptyReturnOption is one priced way of disposing of an empty. Options
+// that cannot be carried out are kept, unpriced against the others, with
+// the reason.
+type EmptyReturnOption struct {
+	Action            EmptyReturnAction `json:"action"`
+	Feasible          bool              `json:"feasible"`
+	Reason            string            `json:"reason,omitempty"` // Why it is not feasible
+	DestinationID     uuid.UUID         `json:"destination_id"`   // Return terminal, export shipper or yard
+	DestinationName   string            `json:"destination_name"`
+	ExportOrderID     *uuid.UUID        `json:"export_order_id,omitempty"`
+	ExportOrderNumber string            `json:"export_order_number,omitempty"`
+	AppointmentTime   *time.Time        `json:"appointment_time,omitempty"` // Terminal return appointment
+	ReleasedAt        time.Time         `json:"released_at"`                // When the per-diem clock stops
+	Miles             float64           `json:"miles"`
+	PerDiemDays       int               `json:"per_diem_days"`
+	ChassisDays       int               `json:"chassis_days"`
+	Cost              EmptyReturnCost   `json:"cost"`
+}
+
+// EmptyReturnRecommendation is every option for an empty, cheapest feasible
+// option first, with the one recommended
+type EmptyReturnRecommendation struct {
+	Container   EmptyContainer      `json:"container"`
+	Recommended *EmptyReturnOption  `json:"recommended,omitempty"` // Nil when nothing is feasible
+	Options     []EmptyReturnOption `json:"options"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// CalendarDays counts the calendar days touched from start to end, both
+// partial days included
+func CalendarDays(start, end time.Time) int {
+	return calendarDays(start, end)
+}
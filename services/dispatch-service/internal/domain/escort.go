@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TerminalEscortPolicy is whether a port terminal lets a driver without a
+// TWIC in under escort, and what it charges for the escort
+type TerminalEscortPolicy struct {
+	TerminalID     uuid.UUID `json:"terminal_id" db:"terminal_id"`
+	EscortsAllowed bool      `json:"escorts_allowed" db:"escorts_allowed"`
+	Fee            float64   `json:"fee" db:"fee"`     // Per visit; 0 uses the default escort charge
+	Notes          string    `json:"notes" db:"notes"` // How to book the escort, lead time, gate to use
+	UpdatedBy      string    `json:"updated_by" db:"updated_by"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EscortCharge is the accessorial for escorting a non-TWIC driver through a
+// terminal on a trip. It is voided if a TWIC driver takes the trip over.
+type EscortCharge struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TripID     uuid.UUID  `json:"trip_id" db:"trip_id"`
+	TripNumber string     `json:"trip_number" db:"trip_number"`
+	OrderID    *uuid.UUID `json:"order_id,omitempty" db:"order_id"`
+	TerminalID uuid.UUID  `json:"terminal_id" db:"terminal_id"`
+	DriverID   uuid.UUID  `json:"driver_id" db:"driver_id"`
+	Amount     float64    `json:"amount" db:"amount"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	VoidedAt   *time.Time `json:"voided_at,omitempty" db:"voided_at"`
+}
+
+// IsOpen checks if the charge still stands
+func (c *EscortCharge) IsOpen() bool {
+	return c.VoidedAt == nil
+}
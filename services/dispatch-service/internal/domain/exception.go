@@ -24,6 +24,11 @@ const (
 	ExceptionTypeAppointmentMissed   ExceptionType = "APPOINTMENT_MISSED"
 	ExceptionTypeWeightIssue         ExceptionType = "WEIGHT_ISSUE"
 	ExceptionTypeDamage              ExceptionType = "DAMAGE"
+	ExceptionTypeLocationMismatch    ExceptionType = "LOCATION_MISMATCH"
+	ExceptionTypeStaleTrip           ExceptionType = "STALE_TRIP"
+	ExceptionTypeSealMismatch        ExceptionType = "SEAL_MISMATCH"
+	ExceptionTypeSyncConflict        ExceptionType = "SYNC_CONFLICT"
+	ExceptionTypeContainerGrounded   ExceptionType = "CONTAINER_GROUNDED"
 	ExceptionTypeOther               ExceptionType = "OTHER"
 )
 
@@ -121,10 +126,11 @@ func GetSeverityForType(exceptionType ExceptionType) ExceptionSeverity {
 	case ExceptionTypeAccident, ExceptionTypeDamage:
 		return ExceptionSeverityCritical
 	case ExceptionTypeFailedPickup, ExceptionTypeFailedDelivery,
-		ExceptionTypeEquipmentFailure, ExceptionTypeCustomsHold:
+		ExceptionTypeEquipmentFailure, ExceptionTypeCustomsHold, ExceptionTypeSealMismatch:
 		return ExceptionSeverityHigh
 	case ExceptionTypeChassisUnavailable, ExceptionTypeAppointmentMissed,
-		ExceptionTypeWeightIssue:
+		ExceptionTypeWeightIssue, ExceptionTypeLocationMismatch, ExceptionTypeStaleTrip,
+		ExceptionTypeSyncConflict:
 		return ExceptionSeverityMedium
 	case ExceptionTypeWeatherDelay, ExceptionTypeRoadClosure:
 		return ExceptionSeverityMedium
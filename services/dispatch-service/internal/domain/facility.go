@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FacilityWindow is a weekly time range at a shipper or consignee facility,
+// in the facility's timezone
+type FacilityWindow struct {
+	DayOfWeek time.Weekday `json:"day_of_week"`
+	Open      string       `json:"open"`  // HH:MM
+	Close     string       `json:"close"` // HH:MM
+}
+
+// FacilityProfile is dispatch's copy of a shipper or consignee profile kept
+// in the order-service address book, printed on driver manifests
+type FacilityProfile struct {
+	LocationID       uuid.UUID        `json:"location_id" db:"location_id"`
+	Timezone         string           `json:"timezone" db:"timezone"`
+	Hours            []FacilityWindow `json:"hours" db:"hours"`
+	LunchClosures    []FacilityWindow `json:"lunch_closures" db:"lunch_closures"`
+	DockCount        int              `json:"dock_count" db:"dock_count"`
+	RequiredPPE      []string         `json:"required_ppe" db:"required_ppe"`
+	CheckInProcedure string           `json:"check_in_procedure,omitempty" db:"check_in_procedure"`
+	Notes            string           `json:"notes,omitempty" db:"notes"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// HoursOn describes receiving hours and lunch closures on a day, e.g.
+// "Mon 07:00-15:30, lunch 12:00-12:30", or "Closed Sun"
+func (p *FacilityProfile) HoursOn(day time.Weekday) string {
+	var hours, lunch []string
+	for _, w := range p.Hours {
+		if w.DayOfWeek == day {
+			hours = append(hours, w.Open+"-"+w.Close)
+		}
+	}
+	if len(hours) == 0 {
+		return "Closed " + day.String()[:3]
+	}
+	for _, w := range p.LunchClosures {
+		if w.DayOfWeek == day {
+			lunch = append(lunch, w.Open+"-"+w.Close)
+		}
+	}
+
+	summary := day.String()[:3] + " " + strings.Join(hours, ", ")
+	if len(lunch) > 0 {
+		summary += ", lunch " + strings.Join(lunch, ", ")
+	}
+	return summary
+}
+
+// WeeklyHours describes every day the facility receives, e.g.
+// "Mon 07:00-15:30; Tue 07:00-15:30"
+func (p *FacilityProfile) WeeklyHours() string {
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		for _, w := range p.Hours {
+			if w.DayOfWeek == day {
+				days = append(days, p.HoursOn(day))
+				break
+			}
+		}
+	}
+	return strings.Join(days, "; ")
+}
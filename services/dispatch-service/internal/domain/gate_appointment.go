@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GateMoveType is the kind of terminal transaction a gate slot is released for
+type GateMoveType string
+
+const (
+	GateMoveImportPickup  GateMoveType = "IMPORT_PICKUP"
+	GateMoveExportDropoff GateMoveType = "EXPORT_DROPOFF"
+	GateMoveEmptyReturn   GateMoveType = "EMPTY_RETURN"
+	GateMoveEmptyPickup   GateMoveType = "EMPTY_PICKUP"
+)
+
+// GateMoveForActivity returns the gate move a stop activity needs at a
+// terminal, or "" when the activity does not go through the gate
+func GateMoveForActivity(activity ActivityType) GateMoveType {
+	switch activity {
+	case ActivityTypePickupLoaded:
+		return GateMoveImportPickup
+	case ActivityTypeDropLoaded, ActivityTypeDeliverLoaded:
+		return GateMoveExportDropoff
+	case ActivityTypeDropEmpty:
+		return GateMoveEmptyReturn
+	case ActivityTypePickupEmpty, ActivityTypeHookEmpty:
+		return GateMoveEmptyPickup
+	}
+	return ""
+}
+
+// GateSlot is a block of appointments a terminal releases for one kind of
+// move. Booked counts the active appointments holding a place in it.
+type GateSlot struct {
+	ID         uuid.UUID    `json:"id" db:"id"`
+	TerminalID uuid.UUID    `json:"terminal_id" db:"terminal_id"`
+	MoveType   GateMoveType `json:"move_type" db:"move_type"`
+	StartTime  time.Time    `json:"start_time" db:"start_time"`
+	EndTime    time.Time    `json:"end_time" db:"end_time"`
+	Capacity   int          `json:"capacity" db:"capacity"`
+	Booked     int          `json:"booked" db:"booked"`
+	SoldOutAt  *time.Time   `json:"sold_out_at,omitempty" db:"sold_out_at"` // First time the slot filled
+	CreatedBy  string       `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// Available returns the places left in the slot
+func (s *GateSlot) Available() int {
+	if s.Booked >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.Booked
+}
+
+// GateAppointmentStatus represents the gate appointment status
+type GateAppointmentStatus string
+
+const (
+	GateAppointmentBooked      GateAppointmentStatus = "BOOKED"
+	GateAppointmentCancelled   GateAppointmentStatus = "CANCELLED"
+	GateAppointmentRescheduled GateAppointmentStatus = "RESCHEDULED" // Replaced by RescheduledToID
+)
+
+// GateAppointment is a place in a gate slot booked for a trip stop. Only one
+// appointment is booked for a stop at a time; its window and number are
+// copied onto the stop.
+type GateAppointment struct {
+	ID                uuid.UUID             `json:"id" db:"id"`
+	SlotID            uuid.UUID             `json:"slot_id" db:"slot_id"`
+	TerminalID        uuid.UUID             `json:"terminal_id" db:"terminal_id"`
+	MoveType          GateMoveType          `json:"move_type" db:"move_type"`
+	TripID            uuid.UUID             `json:"trip_id" db:"trip_id"`
+	StopID            uuid.UUID             `json:"stop_id" db:"stop_id"`
+	ContainerNumber   string                `json:"container_number,omitempty" db:"container_number"`
+	AppointmentNumber string                `json:"appointment_number" db:"appointment_number"` // Terminal confirmation number
+	WindowStart       time.Time             `json:"window_start" db:"window_start"`
+	WindowEnd         time.Time             `json:"window_end" db:"window_end"`
+	Status            GateAppointmentStatus `json:"status" db:"status"`
+	RescheduledToID   *uuid.UUID            `json:"rescheduled_to_id,omitempty" db:"rescheduled_to_id"`
+	CancelReason      string                `json:"cancel_reason,omitempty" db:"cancel_reason"`
+	BookedBy          string                `json:"booked_by" db:"booked_by"`
+	BookedAt          time.Time             `json:"booked_at" db:"booked_at"`
+	CancelledAt       *time.Time            `json:"cancelled_at,omitempty" db:"cancelled_at"`
+}
+
+// IsActive checks if the appointment still holds its slot
+func (a *GateAppointment) IsActive() bool {
+	return a.Status == GateAppointmentBooked
+}
+
+// Covers checks if t falls within the appointment window
+func (a *GateAppointment) Covers(t time.Time) bool {
+	return !t.Before(a.WindowStart) && !t.After(a.WindowEnd)
+}
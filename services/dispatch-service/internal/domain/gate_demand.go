@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GateSlotUsage is how a closed gate slot was used
+type GateSlotUsage struct {
+	SlotID        uuid.UUID    `json:"slot_id"`
+	MoveType      GateMoveType `json:"move_type"`
+	StartTime     time.Time    `json:"start_time"`
+	Capacity      int          `json:"capacity"`
+	Used          int          `json:"used"`          // Appointments still held when the slot opened
+	Cancellations int          `json:"cancellations"` // Cancelled or rescheduled away
+	SoldOutAt     *time.Time   `json:"sold_out_at,omitempty"`
+}
+
+// GateDemandWindow summarizes how one recurring weekday/hour gate window at
+// a terminal has filled
+type GateDemandWindow struct {
+	MoveType         GateMoveType `json:"move_type"`
+	DayOfWeek        time.Weekday `json:"day_of_week"`
+	Hour             int          `json:"hour"` // Slot start hour in the terminal's timezone
+	Samples          int          `json:"samples"`
+	AvgFillRate      float64      `json:"avg_fill_rate"`
+	SellOutRate      float64      `json:"sell_out_rate"`                 // Share of slots that filled before opening
+	CancelRate       float64      `json:"cancel_rate"`                   // Cancellations per place released
+	SellOutLeadHours float64      `json:"sell_out_lead_hours,omitempty"` // Median hours before the slot opened that it filled
+	HighDemand       bool         `json:"high_demand"`
+}
+
+// GateSlotForecast is the predicted demand for an upcoming gate slot
+type GateSlotForecast struct {
+	Slot              GateSlot          `json:"slot"`
+	Window            *GateDemandWindow `json:"window,omitempty"` // Nil without enough history
+	SellOutLikelihood float64           `json:"sell_out_likelihood"`
+	ExpectedSellOutAt *time.Time        `json:"expected_sell_out_at,omitempty"`
+	BookBy            *time.Time        `json:"book_by,omitempty"` // Book before this to be safe
+	HighDemand        bool              `json:"high_demand"`
+}
+
+// gateWindowKey identifies a recurring gate window
+type gateWindowKey struct {
+	moveType GateMoveType
+	day      time.Weekday
+	hour     int
+}
+
+// GateDemandModel holds the demand history of a terminal's gate windows
+type GateDemandModel struct {
+	loc     *time.Location
+	windows map[gateWindowKey]*GateDemandWindow
+}
+
+// NewGateDemandModel buckets closed slots by move type, weekday and start
+// hour in loc. Windows with fewer than minSamples slots are left out; those
+// where at least highDemandRate of slots sold out are high demand.
+func NewGateDemandModel(usage []GateSlotUsage, loc *time.Location, minSamples int, highDemandRate float64) *GateDemandModel {
+	type bucket struct {
+		samples, soldOut, cancellations int
+		fill                            float64
+		capacity                        int
+		leads                           []float64
+	}
+	buckets := make(map[gateWindowKey]*bucket)
+	for _, u := range usage {
+		if u.Capacity <= 0 {
+			continue
+		}
+		local := u.StartTime.In(loc)
+		k := gateWindowKey{moveType: u.MoveType, day: local.Weekday(), hour: local.Hour()}
+		b := buckets[k]
+		if b == nil {
+			b = &bucket{}
+			buckets[k] = b
+		}
+		b.samples++
+		b.capacity += u.Capacity
+		b.cancellations += u.Cancellations
+		b.fill += float64(u.Used) / float64(u.Capacity)
+		if u.SoldOutAt != nil && u.SoldOutAt.Before(u.StartTime) {
+			b.soldOut++
+			b.leads = append(b.leads, u.StartTime.Sub(*u.SoldOutAt).Hours())
+		}
+	}
+
+	model := &GateDemandModel{loc: loc, windows: make(map[gateWindowKey]*GateDemandWindow)}
+	for k, b := range buckets {
+		if b.samples < minSamples {
+			continue
+		}
+		w := &GateDemandWindow{
+			MoveType:    k.moveType,
+			DayOfWeek:   k.day,
+			Hour:        k.hour,
+			Samples:     b.samples,
+			AvgFillRate: b.fill / float64(b.samples),
+			SellOutRate: float64(b.soldOut) / float64(b.samples),
+			CancelRate:  float64(b.cancellations) / float64(b.capacity),
+		}
+		if len(b.leads) > 0 {
+			sort.Float64s(b.leads)
+			w.SellOutLeadHours = b.leads[len(b.leads)/2]
+		}
+		w.HighDemand = w.SellOutRate >= highDemandRate
+		model.windows[k] = w
+	}
+	return model
+}
+
+// Windows returns the windows with enough history, busiest first
+func (m *GateDemandModel) Windows() []GateDemandWindow {
+	windows := make([]GateDemandWindow, 0, len(m.windows))
+	for _, w := range m.windows {
+		windows = append(windows, *w)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if windows[i].SellOutRate != windows[j].SellOutRate {
+			return windows[i].SellOutRate > windows[j].SellOutRate
+		}
+		if windows[i].DayOfWeek != windows[j].DayOfWeek {
+			return windows[i].DayOfWeek < windows[j].DayOfWeek
+		}
+		return windows[i].Hour < windows[j].Hour
+	})
+	return windows
+}
+
+// Forecast predicts whether an upcoming slot will sell out from the history
+// of its window. A slot that is already full is certain; otherwise the
+// window's sell-out rate is the likelihood, and a high-demand slot is
+// expected to fill its median lead time before opening. BookBy leaves
+// bookEarly ahead of that.
+func (m *GateDemandModel) Forecast(slot GateSlot, bookEarly time.Duration) GateSlotForecast {
+	forecast := GateSlotForecast{Slot: slot}
+	if slot.Available() == 0 {
+		forecast.SellOutLikelihood = 1
+		return forecast
+	}
+
+	local := slot.StartTime.In(m.loc)
+	w := m.windows[gateWindowKey{moveType: slot.MoveType, day: local.Weekday(), hour: local.Hour()}]
+	if w == nil {
+		return forecast
+	}
+	forecast.Window = w
+	forecast.SellOutLikelihood = w.SellOutRate
+	forecast.HighDemand = w.HighDemand
+	if w.HighDemand {
+		soldOut := slot.StartTime.Add(-time.Duration(w.SellOutLeadHours * float64(time.Hour)))
+		bookBy := soldOut.Add(-bookEarly)
+		forecast.ExpectedSellOutAt = &soldOut
+		forecast.BookBy = &bookBy
+	}
+	return forecast
+}
+
+// DriverHourClaim is a clock hour of a driver's day held by a gate
+// appointment. A driver's hour can be held at one terminal only.
+type DriverHourClaim struct {
+	DriverID      uuid.UUID `json:"driver_id" db:"driver_id"`
+	HourStart     time.Time `json:"hour_start" db:"hour_start"`
+	TerminalID    uuid.UUID `json:"terminal_id" db:"terminal_id"`
+	AppointmentID uuid.UUID `json:"appointment_id" db:"appointment_id"`
+	TripID        uuid.UUID `json:"trip_id" db:"trip_id"`
+}
+
+// DriverHourClaims returns the claims a driver needs for an appointment,
+// one for every clock hour its window touches
+func DriverHourClaims(driverID uuid.UUID, appointment *GateAppointment) []DriverHourClaim {
+	var claims []DriverHourClaim
+	for hour := appointment.WindowStart.Truncate(time.Hour); hour.Before(appointment.WindowEnd); hour = hour.Add(time.Hour) {
+		claims = append(claims, DriverHourClaim{
+			DriverID:      driverID,
+			HourStart:     hour,
+			TerminalID:    appointment.TerminalID,
+			AppointmentID: appointment.ID,
+			TripID:        appointment.TripID,
+		})
+	}
+	return claims
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HOSSnapshotEvent is the trip change a driver's HOS clocks were captured at
+type HOSSnapshotEvent string
+
+const (
+	HOSSnapshotAssigned   HOSSnapshotEvent = "ASSIGNED"
+	HOSSnapshotDispatched HOSSnapshotEvent = "DISPATCHED"
+)
+
+// HOSSnapshot is what the system believed about a driver's hours of service
+// when a trip was assigned to them or dispatched. Available time is
+// recomputed as the driver works, so the snapshot is the record of what
+// dispatch relied on if a violation is disputed later. Snapshots are never
+// updated.
+type HOSSnapshot struct {
+	ID       uuid.UUID        `json:"id" db:"id"`
+	TripID   uuid.UUID        `json:"trip_id" db:"trip_id"`
+	DriverID uuid.UUID        `json:"driver_id" db:"driver_id"`
+	Event    HOSSnapshotEvent `json:"event" db:"event"`
+
+	// The driver's clocks, as driver-service last reported them
+	DriveRemainingMins int        `json:"drive_remaining_mins" db:"drive_remaining_mins"`
+	DutyRemainingMins  int        `json:"duty_remaining_mins" db:"duty_remaining_mins"`
+	CycleRemainingMins int        `json:"cycle_remaining_mins" db:"cycle_remaining_mins"`
+	ClocksAsOf         *time.Time `json:"clocks_as_of,omitempty" db:"clocks_as_of"` // Nil when driver-service never reported
+
+	// 30-minute break state, estimated from the drive time used in the
+	// shift as break planning does: assumes no break was taken yet
+	NeedsBreak     bool `json:"needs_break" db:"needs_break"`
+	MinsUntilBreak int  `json:"mins_until_break" db:"mins_until_break"`
+
+	// The trip as it was planned at the time
+	TripDurationMins int        `json:"trip_duration_mins" db:"trip_duration_mins"`
+	PlannedStartTime *time.Time `json:"planned_start_time,omitempty" db:"planned_start_time"`
+
+	TakenBy string    `json:"taken_by,omitempty" db:"taken_by"`
+	TakenAt time.Time `json:"taken_at" db:"taken_at"`
+}
+
+// TripTimelineEntryType is the kind of change a trip timeline entry records
+type TripTimelineEntryType string
+
+const (
+	TimelineTripCreated   TripTimelineEntryType = "TRIP_CREATED"
+	TimelineHOSSnapshot   TripTimelineEntryType = "HOS_SNAPSHOT"
+	TimelineTripStarted   TripTimelineEntryType = "TRIP_STARTED"
+	TimelineStopArrived   TripTimelineEntryType = "STOP_ARRIVED"
+	TimelineStopDeparted  TripTimelineEntryType = "STOP_DEPARTED"
+	TimelineTripCompleted TripTimelineEntryType = "TRIP_COMPLETED"
+)
+
+// TripTimelineEntry is one recorded change on a trip
+type TripTimelineEntry struct {
+	Time         time.Time             `json:"time"`
+	Type         TripTimelineEntryType `json:"type"`
+	Description  string                `json:"description"`
+	StopSequence int                   `json:"stop_sequence,omitempty"`
+	Source       string                `json:"source,omitempty"` // DRIVER or GEOFENCE for stop arrivals
+	HOSSnapshot  *HOSSnapshot          `json:"hos_snapshot,omitempty"`
+}
+
+// TripTimeline is a trip's recorded changes, oldest first
+type TripTimeline struct {
+	TripID     uuid.UUID           `json:"trip_id"`
+	TripNumber string              `json:"trip_number"`
+	Status     TripStatus          `json:"status"`
+	Entries    []TripTimelineEntry `json:"entries"`
+}
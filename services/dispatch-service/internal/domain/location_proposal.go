@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StopFix is a position a driver reported arriving at or completing a stop from
+type StopFix struct {
+	LocationID uuid.UUID `json:"location_id" db:"location_id"`
+	StopID     uuid.UUID `json:"stop_id" db:"stop_id"`
+	Latitude   float64   `json:"latitude" db:"latitude"`
+	Longitude  float64   `json:"longitude" db:"longitude"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// LocationProposalStatus is where a learned location correction is in review
+type LocationProposalStatus string
+
+const (
+	LocationProposalPending    LocationProposalStatus = "PENDING"
+	LocationProposalAccepted   LocationProposalStatus = "ACCEPTED"
+	LocationProposalRejected   LocationProposalStatus = "REJECTED"
+	LocationProposalSuperseded LocationProposalStatus = "SUPERSEDED" // Replaced by a later run before review
+)
+
+// LocationProposal is a correction to a location's coordinates and geofence
+// radius learned by clustering where drivers reported being at its stops.
+// ProposedRadiusMeters is 0 when the location's geofence is a hand-drawn
+// polygon, which is never replaced.
+type LocationProposal struct {
+	ID                   uuid.UUID              `json:"id" db:"id"`
+	LocationID           uuid.UUID              `json:"location_id" db:"location_id"`
+	LocationName         string                 `json:"location_name" db:"location_name"`
+	CurrentLatitude      float64                `json:"current_latitude" db:"current_latitude"`
+	CurrentLongitude     float64                `json:"current_longitude" db:"current_longitude"`
+	CurrentRadiusMeters  float64                `json:"current_radius_meters" db:"current_radius_meters"` // 0 when there is no circular geofence
+	GeofenceID           *uuid.UUID             `json:"geofence_id,omitempty" db:"geofence_id"`
+	ProposedLatitude     float64                `json:"proposed_latitude" db:"proposed_latitude"`
+	ProposedLongitude    float64                `json:"proposed_longitude" db:"proposed_longitude"`
+	ProposedRadiusMeters float64                `json:"proposed_radius_meters" db:"proposed_radius_meters"`
+	ShiftMeters          float64                `json:"shift_meters" db:"shift_meters"` // How far the coordinates move
+	FixCount             int                    `json:"fix_count" db:"fix_count"`
+	ClusterShare         float64                `json:"cluster_share" db:"cluster_share"` // Share of positions in the cluster the proposal is drawn from
+	Status               LocationProposalStatus `json:"status" db:"status"`
+	ReviewedBy           string                 `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt           *time.Time             `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt            time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// LocationProposalReview is the outcome of accepting or rejecting proposals
+// in bulk. Proposals that could not be applied are listed with the reason.
+type LocationProposalReview struct {
+	Applied []uuid.UUID          `json:"applied"`
+	Failed  map[uuid.UUID]string `json:"failed,omitempty"`
+}
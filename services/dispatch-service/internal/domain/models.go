@@ -47,6 +47,7 @@ const (
 	StopTypeDelivery StopType = "DELIVERY"
 	StopTypeReturn   StopType = "RETURN"
 	StopTypeYard     StopType = "YARD"
+	StopTypeWaypoint StopType = "WAYPOINT" // En-route stop such as a scale or fuel stop
 )
 
 // ActivityType represents the activity at a stop
@@ -89,6 +90,7 @@ type Trip struct {
 	Type                  TripType   `json:"type" db:"type"`
 	Status                TripStatus `json:"status" db:"status"`
 	DriverID              *uuid.UUID `json:"driver_id,omitempty" db:"driver_id"`
+	DispatcherID          *uuid.UUID `json:"dispatcher_id,omitempty" db:"dispatcher_id"`
 	TractorID             *uuid.UUID `json:"tractor_id,omitempty" db:"tractor_id"`
 	ChassisID             *uuid.UUID `json:"chassis_id,omitempty" db:"chassis_id"`
 	CurrentStopSequence   int        `json:"current_stop_sequence" db:"current_stop_sequence"`
@@ -103,6 +105,15 @@ type Trip struct {
 	IsStreetTurn          bool       `json:"is_street_turn" db:"is_street_turn"`
 	IsDualTransaction     bool       `json:"is_dual_transaction" db:"is_dual_transaction"`
 	LinkedTripID          *uuid.UUID `json:"linked_trip_id,omitempty" db:"linked_trip_id"`
+	RolledOverFromTripID  *uuid.UUID `json:"rolled_over_from_trip_id,omitempty" db:"rolled_over_from_trip_id"`
+	RolledOverToTripID    *uuid.UUID `json:"rolled_over_to_trip_id,omitempty" db:"rolled_over_to_trip_id"`
+	// Set by the trip watchdog when the trip goes quiet, cleared when activity resumes
+	StalePromptedAt       *time.Time `json:"stale_prompted_at,omitempty" db:"stale_prompted_at"`
+	StaleFlaggedAt        *time.Time `json:"stale_flagged_at,omitempty" db:"stale_flagged_at"`
+	// A driver without a TWIC may run the trip with terminal escorts; fees are charged when one is assigned
+	TWICEscort            bool       `json:"twic_escort" db:"twic_escort"`
+	Tags                  []string   `json:"tags,omitempty" db:"tags"` // Normalized labels such as "hot", see validation.TagValidator
+	Version               int        `json:"version" db:"version"` // Optimistic concurrency, bumped on every update
 	CreatedBy             string     `json:"created_by" db:"created_by"`
 	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
@@ -112,6 +123,12 @@ type Trip struct {
 	Driver   *Driver    `json:"driver,omitempty"`
 	Tractor  *Tractor   `json:"tractor,omitempty"`
 	OrderIDs []string   `json:"order_ids,omitempty"`
+
+	// The driver's HOS clocks as the system saw them at assignment and dispatch
+	HOSSnapshots []HOSSnapshot `json:"hos_snapshots,omitempty"`
+
+	// Live cost and margin, filled on the dispatch board for started trips
+	CostAccrual *TripCostAccrual `json:"cost_accrual,omitempty"`
 }
 
 // TripStop represents a stop within a trip
@@ -133,11 +150,16 @@ type TripStop struct {
 	EstimatedArrival      *time.Time   `json:"estimated_arrival,omitempty" db:"estimated_arrival"`
 	ActualArrival         *time.Time   `json:"actual_arrival,omitempty" db:"actual_arrival"`
 	ActualDeparture       *time.Time   `json:"actual_departure,omitempty" db:"actual_departure"`
+	ArrivalSource         string       `json:"arrival_source,omitempty" db:"arrival_source"` // DRIVER or GEOFENCE
+	GeofenceExitedAt      *time.Time   `json:"geofence_exited_at,omitempty" db:"geofence_exited_at"` // Truck left the location's geofence
 	EstimatedDurationMins int          `json:"estimated_duration_mins" db:"estimated_duration_mins"`
 	ActualDurationMins    int          `json:"actual_duration_mins" db:"actual_duration_mins"`
 	FreeTimeMins          int          `json:"free_time_mins" db:"free_time_mins"`
 	DetentionStartTime    *time.Time   `json:"detention_start_time,omitempty" db:"detention_start_time"`
+	DetentionWarnedAt     *time.Time   `json:"detention_warned_at,omitempty" db:"detention_warned_at"` // Free time ending alert sent
+	DetentionThresholdPct int          `json:"detention_threshold_pct,omitempty" db:"detention_threshold_pct"` // Highest free time threshold alerted
 	DetentionMins         int          `json:"detention_mins" db:"detention_mins"`
+	DetentionCharge       float64      `json:"detention_charge" db:"detention_charge"`
 	ChassisInID           *uuid.UUID   `json:"chassis_in_id,omitempty" db:"chassis_in_id"`
 	ChassisOutID          *uuid.UUID   `json:"chassis_out_id,omitempty" db:"chassis_out_id"`
 	ContainerInID         *uuid.UUID   `json:"container_in_id,omitempty" db:"container_in_id"`
@@ -146,6 +168,31 @@ type TripStop struct {
 	SealNumber            string       `json:"seal_number,omitempty" db:"seal_number"`
 	FailureReason         string       `json:"failure_reason,omitempty" db:"failure_reason"`
 	Notes                 string       `json:"notes,omitempty" db:"notes"`
+
+	// Position reported by the driver at arrival and completion, and its
+	// distance from the stop location
+	ArrivalLatitude        *float64 `json:"arrival_latitude,omitempty" db:"arrival_latitude"`
+	ArrivalLongitude       *float64 `json:"arrival_longitude,omitempty" db:"arrival_longitude"`
+	ArrivalOffsetMeters    *float64 `json:"arrival_offset_meters,omitempty" db:"arrival_offset_meters"`
+	CompletionLatitude     *float64 `json:"completion_latitude,omitempty" db:"completion_latitude"`
+	CompletionLongitude    *float64 `json:"completion_longitude,omitempty" db:"completion_longitude"`
+	CompletionOffsetMeters *float64 `json:"completion_offset_meters,omitempty" db:"completion_offset_meters"`
+	LocationMismatch       bool     `json:"location_mismatch" db:"location_mismatch"` // Arrival or completion reported outside tolerance
+
+	// Seal verification at loaded pickups. SealNumber holds the seal the
+	// driver observed; the expected seal comes from the shipment.
+	ExpectedSealNumber  string     `json:"expected_seal_number,omitempty" db:"expected_seal_number"`
+	SealStatus          SealStatus `json:"seal_status,omitempty" db:"seal_status"`
+	SealPhotoDocumentID string     `json:"seal_photo_document_id,omitempty" db:"seal_photo_document_id"`
+	SealVerifiedAt      *time.Time `json:"seal_verified_at,omitempty" db:"seal_verified_at"`
+	SealVerifiedBy      string     `json:"seal_verified_by,omitempty" db:"seal_verified_by"`
+
+	// Proof of delivery captured by the driver, possibly while offline
+	PODDocumentIDs []string   `json:"pod_document_ids,omitempty" db:"pod_document_ids"`
+	PODSignedBy    string     `json:"pod_signed_by,omitempty" db:"pod_signed_by"`
+	PODReceivedAt  *time.Time `json:"pod_received_at,omitempty" db:"pod_received_at"`
+
+	Version               int          `json:"version" db:"version"` // Optimistic concurrency, bumped on every update
 	CreatedAt             time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time    `json:"updated_at" db:"updated_at"`
 
@@ -181,20 +228,46 @@ type Location struct {
 	ContactName  string    `json:"contact_name,omitempty" db:"contact_name"`
 	ContactPhone string    `json:"contact_phone,omitempty" db:"contact_phone"`
 	GeofenceID   *uuid.UUID `json:"geofence_id,omitempty" db:"geofence_id"`
+	GeofenceRadiusMeters float64 `json:"geofence_radius_meters,omitempty" db:"geofence_radius_meters"` // Radius of a circular geofence, 0 if none
+}
+
+// LocationTypeScale is the location type of a certified truck scale
+const LocationTypeScale = "SCALE"
+
+// ContainerLoad is the weight and cargo class of a container, owned by order-service
+type ContainerLoad struct {
+	ContainerID      uuid.UUID `json:"container_id"`
+	ContainerNumber  string    `json:"container_number"`
+	ContainerSize    string    `json:"container_size"`
+	GrossWeightLbs   int       `json:"gross_weight_lbs"`
+	IsHazmat         bool      `json:"is_hazmat"`
+	IsHazardousWaste bool      `json:"is_hazardous_waste"`
 }
 
 // Driver represents a driver (lightweight for dispatch)
 type Driver struct {
-	ID                   uuid.UUID `json:"id" db:"id"`
-	Name                 string    `json:"name" db:"name"`
-	Phone                string    `json:"phone" db:"phone"`
-	Status               string    `json:"status" db:"status"`
-	CurrentLatitude      float64   `json:"current_latitude" db:"current_latitude"`
-	CurrentLongitude     float64   `json:"current_longitude" db:"current_longitude"`
-	AvailableDriveMins   int       `json:"available_drive_mins" db:"available_drive_mins"`
-	AvailableDutyMins    int       `json:"available_duty_mins" db:"available_duty_mins"`
-	HasTWIC              bool      `json:"has_twic" db:"has_twic"`
-	HasHazmatEndorsement bool      `json:"has_hazmat_endorsement" db:"has_hazmat_endorsement"`
+	ID                   uuid.UUID  `json:"id" db:"id"`
+	Name                 string     `json:"name" db:"name"`
+	Phone                string     `json:"phone" db:"phone"`
+	Status               string     `json:"status" db:"status"`
+	CurrentLatitude      float64    `json:"current_latitude" db:"current_latitude"`
+	CurrentLongitude     float64    `json:"current_longitude" db:"current_longitude"`
+	AvailableDriveMins   int        `json:"available_drive_mins" db:"available_drive_mins"`
+	AvailableDutyMins    int        `json:"available_duty_mins" db:"available_duty_mins"`
+	AvailableCycleMins   int        `json:"available_cycle_mins" db:"available_cycle_mins"`
+	LastHOSUpdate        *time.Time `json:"last_hos_update,omitempty" db:"last_hos_update"` // When driver-service last refreshed the clocks
+	HasTWIC              bool       `json:"has_twic" db:"has_twic"`
+	HasHazmatEndorsement bool       `json:"has_hazmat_endorsement" db:"has_hazmat_endorsement"`
+
+	// Document expirations, evaluated against the configured enforcement tiers
+	LicenseExpiration     *time.Time `json:"license_expiration,omitempty" db:"license_expiration"`
+	MedicalCardExpiration *time.Time `json:"medical_card_expiration,omitempty" db:"medical_card_expiration"`
+	TWICExpiration        *time.Time `json:"twic_expiration,omitempty" db:"twic_expiration"`
+	HazmatExpiration      *time.Time `json:"hazmat_expiration,omitempty" db:"hazmat_expiration"`
+
+	// Owner-operator standing, from the driver's carrier record; empty for company drivers
+	CarrierHold         string     `json:"carrier_hold,omitempty" db:"carrier_hold"`
+	CarrierCoveredUntil *time.Time `json:"carrier_covered_until,omitempty" db:"carrier_covered_until"`
 }
 
 // Tractor represents a tractor/truck
@@ -202,6 +275,8 @@ type Tractor struct {
 	ID         uuid.UUID `json:"id" db:"id"`
 	UnitNumber string    `json:"unit_number" db:"unit_number"`
 	Status     string    `json:"status" db:"status"`
+	FuelType   string    `json:"fuel_type" db:"fuel_type"`     // diesel, natural_gas, electric
+	AxleConfig string    `json:"axle_config" db:"axle_config"` // single, tandem
 }
 
 // StreetTurnOpportunity represents a potential street turn match
@@ -263,6 +338,8 @@ type DriverAvailability struct {
 	ETAToPickupMins       int       `json:"eta_to_pickup_mins"`
 	Endorsements          []string  `json:"endorsements"`
 	HasTWIC               bool      `json:"has_twic"`
+	NeedsEscort           bool      `json:"needs_escort"`         // No valid TWIC; the terminal admits the driver under escort
+	EscortFee             float64   `json:"escort_fee,omitempty"` // Charged if the driver is assigned
 }
 
 // TripTemplate defines common trip patterns
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TripSuggestion is an unassigned trip a driver could legally take next
+type TripSuggestion struct {
+	TripID                uuid.UUID  `json:"trip_id"`
+	TripNumber            string     `json:"trip_number"`
+	Type                  TripType   `json:"type"`
+	DispatcherID          *uuid.UUID `json:"dispatcher_id,omitempty"` // Owner of the trip, who gets the push
+	PlannedStartTime      *time.Time `json:"planned_start_time,omitempty"`
+	PickupLocationID      uuid.UUID  `json:"pickup_location_id"`
+	PickupName            string     `json:"pickup_name"`
+	DeadheadMiles         float64    `json:"deadhead_miles"` // Driver's position to the first stop
+	ETAToPickupMins       int        `json:"eta_to_pickup_mins"`
+	AppointmentTime       *time.Time `json:"appointment_time,omitempty"` // First stop appointment
+	SlackMins             *int       `json:"slack_mins,omitempty"`       // Time to spare at the appointment
+	EstimatedDurationMins int        `json:"estimated_duration_mins"`
+	DriveMinsAfter        int        `json:"drive_mins_after"` // Drive time the driver has left after the trip
+}
+
+// NextTripSuggestions are the nearest trips a driver could take next, with
+// counts of the trips ruled out
+type NextTripSuggestions struct {
+	DriverID           uuid.UUID        `json:"driver_id"`
+	DriverName         string           `json:"driver_name"`
+	Latitude           float64          `json:"latitude"`
+	Longitude          float64          `json:"longitude"`
+	AvailableDriveMins int              `json:"available_drive_mins"`
+	Suggestions        []TripSuggestion `json:"suggestions"`
+	Considered         int              `json:"considered"`       // Unassigned trips within the lookahead
+	TooFar             int              `json:"too_far"`          // Beyond the deadhead limit
+	LackHOS            int              `json:"lack_hos"`         // Not enough drive time left
+	Restricted         int              `json:"restricted"`       // Missing endorsements or expired documents
+	MissAppointment    int              `json:"miss_appointment"` // Could not make the first appointment window
+	Conflicting        int              `json:"conflicting"`      // Overlaps a trip already committed
+	GeneratedAt        time.Time        `json:"generated_at"`
+}
@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorityType is whose operating authority an owner-operator hauls under
+type AuthorityType string
+
+const (
+	AuthorityOwn      AuthorityType = "OWN_AUTHORITY" // Their own MC number; they carry auto liability and cargo
+	AuthorityLeasedOn AuthorityType = "LEASED_ON"     // Leased on to ours; they carry bobtail and occupational accident
+)
+
+// AuthorityStatus is the FMCSA operating status of a carrier's authority
+type AuthorityStatus string
+
+const (
+	AuthorityActive     AuthorityStatus = "ACTIVE"
+	AuthorityInactive   AuthorityStatus = "INACTIVE"
+	AuthorityRevoked    AuthorityStatus = "REVOKED"
+	AuthorityPending    AuthorityStatus = "PENDING"
+	AuthorityUnverified AuthorityStatus = "UNVERIFIED" // Never checked
+)
+
+// VerificationManual marks authority and certificates entered by ops rather
+// than read from a verification provider
+const VerificationManual = "MANUAL"
+
+// CertificateStatus is whether an insurance certificate is still in force
+type CertificateStatus string
+
+const (
+	CertificateActive    CertificateStatus = "ACTIVE"
+	CertificateCancelled CertificateStatus = "CANCELLED" // Cancelled by the insurer before it expired
+)
+
+// OwnerOperatorCarrier is an owner-operator's authority and insurance
+// standing. Hold and CoveredUntil are kept on it so every dispatch path can
+// refuse the driver without re-reading the certificates.
+type OwnerOperatorCarrier struct {
+	ID                   uuid.UUID       `json:"id" db:"id"`
+	DriverID             uuid.UUID       `json:"driver_id" db:"driver_id"`
+	LegalName            string          `json:"legal_name" db:"legal_name"`
+	AuthorityType        AuthorityType   `json:"authority_type" db:"authority_type"`
+	DOTNumber            string          `json:"dot_number,omitempty" db:"dot_number"`
+	MCNumber             string          `json:"mc_number,omitempty" db:"mc_number"`
+	AuthorityStatus      AuthorityStatus `json:"authority_status" db:"authority_status"`
+	VerificationProvider string          `json:"verification_provider" db:"verification_provider"` // MANUAL, or the provider that last verified it
+	ExternalID           string          `json:"external_id,omitempty" db:"external_id"`           // The provider's carrier ID
+	VerifiedAt           *time.Time      `json:"verified_at,omitempty" db:"verified_at"`
+	Hold                 string          `json:"hold,omitempty" db:"hold"`                   // Why dispatch is blocked; empty when compliant
+	CoveredUntil         *time.Time      `json:"covered_until,omitempty" db:"covered_until"` // Earliest expiration among the required coverage
+	Notes                string          `json:"notes,omitempty" db:"notes"`
+	CreatedAt            time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// InsuranceCertificate is a certificate of insurance on file for an
+// owner-operator, entered by ops or read from a verification provider
+type InsuranceCertificate struct {
+	ID             uuid.UUID         `json:"id" db:"id"`
+	CarrierID      uuid.UUID         `json:"carrier_id" db:"carrier_id"`
+	Coverage       string            `json:"coverage" db:"coverage"` // AUTO_LIABILITY, CARGO, NON_TRUCKING_LIABILITY...
+	InsurerName    string            `json:"insurer_name" db:"insurer_name"`
+	PolicyNumber   string            `json:"policy_number" db:"policy_number"`
+	CoverageAmount float64           `json:"coverage_amount" db:"coverage_amount"`
+	EffectiveDate  time.Time         `json:"effective_date" db:"effective_date"`
+	ExpirationDate time.Time         `json:"expiration_date" db:"expiration_date"`
+	Status         CertificateStatus `json:"status" db:"status"`
+	Source         string            `json:"source" db:"source"`                         // MANUAL or the provider
+	AttachmentID   *uuid.UUID        `json:"attachment_id,omitempty" db:"attachment_id"` // Scanned certificate, for manual entries
+	CancelledAt    *time.Time        `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CancelReason   string            `json:"cancel_reason,omitempty" db:"cancel_reason"`
+	AddedBy        string            `json:"added_by" db:"added_by"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// InForce reports whether the certificate covers t
+func (c *InsuranceCertificate) InForce(t time.Time) bool {
+	return c.Status == CertificateActive && !t.Before(c.EffectiveDate) && t.Before(c.ExpirationDate)
+}
+
+// CarrierVerification is what a verification provider reports for a carrier
+type CarrierVerification struct {
+	Provider        string                 `json:"provider"`
+	ExternalID      string                 `json:"external_id,omitempty"`
+	LegalName       string                 `json:"legal_name,omitempty"`
+	AuthorityStatus AuthorityStatus        `json:"authority_status"`
+	Certificates    []InsuranceCertificate `json:"certificates"`
+	CheckedAt       time.Time              `json:"checked_at"`
+}
+
+// ComplianceProblem is one reason an owner-operator cannot be dispatched
+type ComplianceProblem struct {
+	Code     string `json:"code"` // AUTHORITY_NOT_ACTIVE, INSURANCE_MISSING, INSURANCE_BELOW_MINIMUM
+	Coverage string `json:"coverage,omitempty"`
+	Message  string `json:"message"`
+}
+
+// CarrierStanding is the result of checking an owner-operator's authority
+// and certificates against the required coverage
+type CarrierStanding struct {
+	Compliant    bool                `json:"compliant"`
+	Problems     []ComplianceProblem `json:"problems,omitempty"`
+	CoveredUntil *time.Time          `json:"covered_until,omitempty"`
+}
+
+// Hold returns the reason to block dispatch, or empty when compliant
+func (s *CarrierStanding) Hold() string {
+	messages := make([]string, len(s.Problems))
+	for i, problem := range s.Problems {
+		messages[i] = problem.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// EvaluateStanding checks the carrier's authority and that each required
+// coverage has a certificate in force at now with at least the minimum
+// limit. CoveredUntil is when the first required coverage runs out, counting
+// renewals already on file that pick up where a certificate ends.
+func (c *OwnerOperatorCarrier) EvaluateStanding(certificates []InsuranceCertificate, minimums map[string]float64, now time.Time) CarrierStanding {
+	var standing CarrierStanding
+
+	if c.AuthorityType == AuthorityOwn && c.AuthorityStatus != AuthorityActive {
+		standing.Problems = append(standing.Problems, ComplianceProblem{
+			Code:    "AUTHORITY_NOT_ACTIVE",
+			Message: fmt.Sprintf("operating authority is %s", c.AuthorityStatus),
+		})
+	}
+
+	coverages := make([]string, 0, len(minimums))
+	for coverage := range minimums {
+		coverages = append(coverages, coverage)
+	}
+	sort.Strings(coverages)
+
+	for _, coverage := range coverages {
+		minimum := minimums[coverage]
+		var until *time.Time
+		var belowMinimum bool
+		for i := range certificates {
+			cert := &certificates[i]
+			if cert.Coverage != coverage || !cert.InForce(now) {
+				continue
+			}
+			if cert.CoverageAmount < minimum {
+				belowMinimum = true
+				continue
+			}
+			if until == nil || cert.ExpirationDate.After(*until) {
+				expiration := cert.ExpirationDate
+				until = &expiration
+			}
+		}
+
+		// A renewal taking effect by the time one runs out extends the cover
+		for extended := until != nil; extended; {
+			extended = false
+			for i := range certificates {
+				cert := &certificates[i]
+				if cert.Coverage == coverage && cert.Status == CertificateActive && cert.CoverageAmount >= minimum &&
+					!cert.EffectiveDate.After(*until) && cert.ExpirationDate.After(*until) {
+					expiration := cert.ExpirationDate
+					until, extended = &expiration, true
+				}
+			}
+		}
+
+		switch {
+		case until != nil:
+			if standing.CoveredUntil == nil || until.Before(*standing.CoveredUntil) {
+				standing.CoveredUntil = until
+			}
+		case belowMinimum:
+			standing.Problems = append(standing.Problems, ComplianceProblem{
+				Code:     "INSURANCE_BELOW_MINIMUM",
+				Coverage: coverage,
+				Message:  fmt.Sprintf("%s coverage is below the $%.0f minimum", coverage, minimum),
+			})
+		default:
+			standing.Problems = append(standing.Problems, ComplianceProblem{
+				Code:     "INSURANCE_MISSING",
+				Coverage: coverage,
+				Message:  fmt.Sprintf("no %s certificate in force", coverage),
+			})
+		}
+	}
+
+	standing.Compliant = len(standing.Problems) == 0
+	return standing
+}
+
+// OwnerOperatorCompliance is a carrier with its certificates and current standing
+type OwnerOperatorCompliance struct {
+	Carrier      OwnerOperatorCarrier   `json:"carrier"`
+	Certificates []InsuranceCertificate `json:"certificates"`
+	Standing     CarrierStanding        `json:"standing"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AllocationStatus represents the state of a soft driver allocation
+type AllocationStatus string
+
+const (
+	AllocationStatusHeld      AllocationStatus = "HELD"      // Driver penciled in; the trip is still unassigned
+	AllocationStatusConfirmed AllocationStatus = "CONFIRMED" // Turned into a driver assignment
+	AllocationStatusReleased  AllocationStatus = "RELEASED"  // Given up before the trip was assigned
+)
+
+// DriverAllocation holds a driver for a future trip without assigning them.
+// The window is copied from the trip when the allocation is made so the
+// driver's schedule can be built without loading every trip.
+type DriverAllocation struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	DriverID       uuid.UUID        `json:"driver_id" db:"driver_id"`
+	TripID         uuid.UUID        `json:"trip_id" db:"trip_id"`
+	TripNumber     string           `json:"trip_number" db:"trip_number"`
+	WindowStart    time.Time        `json:"window_start" db:"window_start"`
+	WindowEnd      time.Time        `json:"window_end" db:"window_end"`
+	Status         AllocationStatus `json:"status" db:"status"`
+	Notes          string           `json:"notes,omitempty" db:"notes"`
+	AllocatedBy    string           `json:"allocated_by" db:"allocated_by"`
+	ConfirmedAt    *time.Time       `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	ReleasedAt     *time.Time       `json:"released_at,omitempty" db:"released_at"`
+	ReleasedBy     string           `json:"released_by,omitempty" db:"released_by"`
+	ReleasedReason string           `json:"released_reason,omitempty" db:"released_reason"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// PlannedWindow returns when the trip is expected to occupy its driver. The
+// end falls back to the estimated duration and then to defaultMins. Trips
+// without a planned start have no window.
+func (t *Trip) PlannedWindow(defaultMins int) (start, end time.Time, ok bool) {
+	if t.PlannedStartTime == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start = *t.PlannedStartTime
+	switch {
+	case t.PlannedEndTime != nil && t.PlannedEndTime.After(start):
+		end = *t.PlannedEndTime
+	case t.EstimatedDurationMins > 0:
+		end = start.Add(time.Duration(t.EstimatedDurationMins) * time.Minute)
+	default:
+		end = start.Add(time.Duration(defaultMins) * time.Minute)
+	}
+	return start, end, true
+}
+
+// WindowsOverlap checks if two time windows come closer than buffer
+func WindowsOverlap(startA, endA, startB, endB time.Time, buffer time.Duration) bool {
+	return startA.Before(endB.Add(buffer)) && startB.Before(endA.Add(buffer))
+}
+
+// ScheduleBlockKind distinguishes assigned trips from soft allocations
+type ScheduleBlockKind string
+
+const (
+	ScheduleBlockTrip       ScheduleBlockKind = "TRIP"
+	ScheduleBlockAllocation ScheduleBlockKind = "ALLOCATION"
+	ScheduleBlockTimeOff    ScheduleBlockKind = "TIME_OFF"
+)
+
+// ScheduleBlock is one bar on a driver's schedule. Time off has no trip.
+type ScheduleBlock struct {
+	Kind          ScheduleBlockKind `json:"kind"`
+	TripID        uuid.UUID         `json:"trip_id"`
+	TripNumber    string            `json:"trip_number"`
+	AllocationID  *uuid.UUID        `json:"allocation_id,omitempty"`
+	TimeOffID     *uuid.UUID        `json:"time_off_id,omitempty"`
+	Status        string            `json:"status"` // Trip status, HELD for allocations, or the time off type
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	ConflictsWith []string          `json:"conflicts_with,omitempty"` // Trip numbers of overlapping blocks, or TIME_OFF
+}
+
+// DriverSchedule is a driver's assigned trips and soft allocations over a
+// date range, ordered by start time for a Gantt-style view
+type DriverSchedule struct {
+	DriverID   uuid.UUID       `json:"driver_id"`
+	DriverName string          `json:"driver_name"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Blocks     []ScheduleBlock `json:"blocks"`
+	Conflicts  int             `json:"conflicts"` // Blocks overlapping at least one other block
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "strings"
+
+// SealStatus represents the verification state of a container seal at pickup
+type SealStatus string
+
+const (
+	SealStatusPending    SealStatus = "PENDING"    // Expected seal known, driver has not confirmed it
+	SealStatusVerified   SealStatus = "VERIFIED"   // Observed seal matches the shipment
+	SealStatusMismatch   SealStatus = "MISMATCH"   // Observed seal differs; the container is held at the pickup
+	SealStatusOverridden SealStatus = "OVERRIDDEN" // Mismatch reviewed and released by dispatch
+)
+
+// NormalizeSealNumber uppercases a seal number and drops spaces and dashes,
+// which drivers and shippers write inconsistently
+func NormalizeSealNumber(seal string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(seal)))
+}
+
+// SealNumbersMatch checks if an observed seal matches the expected one
+func SealNumbersMatch(expected, observed string) bool {
+	return NormalizeSealNumber(expected) == NormalizeSealNumber(observed)
+}
+
+// SealCleared checks if a container may leave the pickup as far as its seal is concerned
+func (s *TripStop) SealCleared() bool {
+	return s.SealStatus == SealStatusVerified || s.SealStatus == SealStatusOverridden
+}
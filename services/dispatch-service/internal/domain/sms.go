@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMSDirection represents whether a text was sent to or received from a driver
+type SMSDirection string
+
+const (
+	SMSDirectionOutbound SMSDirection = "OUTBOUND"
+	SMSDirectionInbound  SMSDirection = "INBOUND"
+)
+
+// SMSStatus represents the processing result of a text message
+type SMSStatus string
+
+const (
+	SMSStatusSent      SMSStatus = "SENT"
+	SMSStatusFailed    SMSStatus = "FAILED"
+	SMSStatusProcessed SMSStatus = "PROCESSED"
+	SMSStatusRejected  SMSStatus = "REJECTED"
+)
+
+// SMSKeyword is a command a driver can text back
+type SMSKeyword string
+
+const (
+	SMSKeywordArrived  SMSKeyword = "ARR"
+	SMSKeywordDeparted SMSKeyword = "DEP"
+	SMSKeywordEmpty    SMSKeyword = "EMPTY"
+	SMSKeywordHelp     SMSKeyword = "HELP"
+)
+
+// smsKeywordAliases maps accepted reply words to keywords
+var smsKeywordAliases = map[string]SMSKeyword{
+	"ARR":      SMSKeywordArrived,
+	"ARRIVED":  SMSKeywordArrived,
+	"DEP":      SMSKeywordDeparted,
+	"DEPART":   SMSKeywordDeparted,
+	"DEPARTED": SMSKeywordDeparted,
+	"DONE":     SMSKeywordDeparted,
+	"EMPTY":    SMSKeywordEmpty,
+	"MT":       SMSKeywordEmpty,
+	"HELP":     SMSKeywordHelp,
+	"?":        SMSKeywordHelp,
+}
+
+// ParseSMSReply extracts the keyword and any trailing text (e.g. a gate
+// ticket or seal number) from a driver reply
+func ParseSMSReply(body string) (SMSKeyword, string, bool) {
+	fields := strings.Fields(strings.TrimSpace(body))
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	keyword, ok := smsKeywordAliases[strings.ToUpper(strings.Trim(fields[0], ".,!"))]
+	if !ok {
+		return "", "", false
+	}
+	return keyword, strings.Join(fields[1:], " "), true
+}
+
+// NormalizePhone converts a US phone number to E.164 form for matching
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	switch {
+	case len(d) == 10:
+		return "+1" + d
+	case len(d) == 11 && d[0] == '1':
+		return "+" + d
+	case d == "":
+		return ""
+	}
+	return "+" + d
+}
+
+// SMSMessage is the audit record of a text exchanged with a driver
+type SMSMessage struct {
+	ID                uuid.UUID    `json:"id" db:"id"`
+	Direction         SMSDirection `json:"direction" db:"direction"`
+	Phone             string       `json:"phone" db:"phone"`
+	DriverID          *uuid.UUID   `json:"driver_id,omitempty" db:"driver_id"`
+	TripID            *uuid.UUID   `json:"trip_id,omitempty" db:"trip_id"`
+	StopID            *uuid.UUID   `json:"stop_id,omitempty" db:"stop_id"`
+	Body              string       `json:"body" db:"body"`
+	Keyword           SMSKeyword   `json:"keyword,omitempty" db:"keyword"`
+	Status            SMSStatus    `json:"status" db:"status"`
+	Error             string       `json:"error,omitempty" db:"error"`
+	ProviderMessageID string       `json:"provider_message_id,omitempty" db:"provider_message_id"`
+	CreatedBy         string       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt         time.Time    `json:"created_at" db:"created_at"`
+}
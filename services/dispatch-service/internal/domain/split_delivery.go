@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplitShareBasis is what the delivery stops of a split container are
+// billed in proportion to
+type SplitShareBasis string
+
+const (
+	SplitShareByWeight SplitShareBasis = "WEIGHT" // Every stop has a weight
+	SplitShareByPieces SplitShareBasis = "PIECES" // Every stop has a piece count
+	SplitShareEven     SplitShareBasis = "EVEN"   // Neither recorded at every stop
+)
+
+// StopDelivery is part of a container's freight delivered at one stop. A
+// shipper that splits a container across delivery points (a partial devan)
+// has one or more records at each of them, each signed for separately.
+type StopDelivery struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	TripID          uuid.UUID  `json:"trip_id" db:"trip_id"`
+	StopID          uuid.UUID  `json:"stop_id" db:"stop_id"`
+	OrderID         *uuid.UUID `json:"order_id,omitempty" db:"order_id"`
+	ContainerID     *uuid.UUID `json:"container_id,omitempty" db:"container_id"`
+	ContainerNumber string     `json:"container_number,omitempty" db:"container_number"`
+	Commodity       string     `json:"commodity" db:"commodity"`
+	Pieces          int        `json:"pieces" db:"pieces"`
+	PieceUnit       string     `json:"piece_unit,omitempty" db:"piece_unit"` // cartons, pallets, drums...
+	WeightLbs       int        `json:"weight_lbs" db:"weight_lbs"`
+	ConsigneeName   string     `json:"consignee_name,omitempty" db:"consignee_name"`
+	ReferenceNumber string     `json:"reference_number,omitempty" db:"reference_number"` // Consignee PO or delivery order
+
+	// Proof of delivery for this part alone
+	PiecesDelivered *int       `json:"pieces_delivered,omitempty" db:"pieces_delivered"`
+	PODDocumentIDs  []string   `json:"pod_document_ids,omitempty" db:"pod_document_ids"`
+	PODSignedBy     string     `json:"pod_signed_by,omitempty" db:"pod_signed_by"`
+	PODReceivedAt   *time.Time `json:"pod_received_at,omitempty" db:"pod_received_at"`
+	PODNotes        string     `json:"pod_notes,omitempty" db:"pod_notes"` // Shortages, overages or damage noted on the receipt
+
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HasPOD reports whether the consignee has signed for this part
+func (d *StopDelivery) HasPOD() bool {
+	return d.PODReceivedAt != nil
+}
+
+// ShortPieces returns how many pieces fewer than planned were signed for
+func (d *StopDelivery) ShortPieces() int {
+	if d.PiecesDelivered == nil || *d.PiecesDelivered >= d.Pieces {
+		return 0
+	}
+	return d.Pieces - *d.PiecesDelivered
+}
+
+// SplitDeliveryStop is one delivery stop's part of a split container
+type SplitDeliveryStop struct {
+	StopID      uuid.UUID      `json:"stop_id"`
+	Sequence    int            `json:"sequence"`
+	LocationID  uuid.UUID      `json:"location_id"`
+	Status      StopStatus     `json:"status"`
+	Pieces      int            `json:"pieces"`
+	WeightLbs   int            `json:"weight_lbs"`
+	Share       float64        `json:"share"` // Fraction of the container billed to this stop
+	PODComplete bool           `json:"pod_complete"`
+	Deliveries  []StopDelivery `json:"deliveries"`
+}
+
+// SplitDelivery is a container's freight spread over the delivery stops of a trip
+type SplitDelivery struct {
+	TripID          uuid.UUID           `json:"trip_id"`
+	ContainerID     *uuid.UUID          `json:"container_id,omitempty"`
+	ContainerNumber string              `json:"container_number,omitempty"`
+	Basis           SplitShareBasis     `json:"basis"`
+	TotalPieces     int                 `json:"total_pieces"`
+	TotalWeightLbs  int                 `json:"total_weight_lbs"`
+	PODComplete     bool                `json:"pod_complete"` // Every part at every stop signed for
+	Stops           []SplitDeliveryStop `json:"stops"`
+}
+
+// IsSplit reports whether the container is delivered at more than one stop
+func (s *SplitDelivery) IsSplit() bool {
+	return len(s.Stops) > 1
+}
+
+// BuildSplitDeliveries groups a trip's delivery records by container and
+// stop, in stop order, and works out each stop's share of the container.
+// Shares are by weight when every stop has one, else by pieces when every
+// stop has a count, else even.
+func BuildSplitDeliveries(stops []TripStop, deliveries []StopDelivery) []SplitDelivery {
+	byStop := make(map[uuid.UUID][]StopDelivery)
+	for _, d := range deliveries {
+		byStop[d.StopID] = append(byStop[d.StopID], d)
+	}
+
+	ordered := append([]TripStop(nil), stops...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Sequence < ordered[j].Sequence })
+
+	var splits []SplitDelivery
+	index := make(map[string]int)
+	for _, stop := range ordered {
+		records := byStop[stop.ID]
+		if len(records) == 0 {
+			continue
+		}
+		key := records[0].ContainerNumber
+		if records[0].ContainerID != nil {
+			key = records[0].ContainerID.String()
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(splits)
+			index[key] = i
+			splits = append(splits, SplitDelivery{
+				TripID:          stop.TripID,
+				ContainerID:     records[0].ContainerID,
+				ContainerNumber: records[0].ContainerNumber,
+			})
+		}
+
+		part := SplitDeliveryStop{
+			StopID:      stop.ID,
+			Sequence:    stop.Sequence,
+			LocationID:  stop.LocationID,
+			Status:      stop.Status,
+			PODComplete: true,
+			Deliveries:  records,
+		}
+		for _, d := range records {
+			part.Pieces += d.Pieces
+			part.WeightLbs += d.WeightLbs
+			if !d.HasPOD() {
+				part.PODComplete = false
+			}
+		}
+		splits[i].Stops = append(splits[i].Stops, part)
+	}
+
+	for i := range splits {
+		splits[i].share()
+	}
+	return splits
+}
+
+// share totals the split and sets each stop's share and the split's basis
+func (s *SplitDelivery) share() {
+	allWeighed, allCounted := true, true
+	s.PODComplete = true
+	for _, stop := range s.Stops {
+		s.TotalPieces += stop.Pieces
+		s.TotalWeightLbs += stop.WeightLbs
+		allWeighed = allWeighed && stop.WeightLbs > 0
+		allCounted = allCounted && stop.Pieces > 0
+		if !stop.PODComplete {
+			s.PODComplete = false
+		}
+	}
+	switch {
+	case allWeighed:
+		s.Basis = SplitShareByWeight
+	case allCounted:
+		s.Basis = SplitShareByPieces
+	default:
+		s.Basis = SplitShareEven
+	}
+
+	for i := range s.Stops {
+		var share float64
+		switch s.Basis {
+		case SplitShareByWeight:
+			share = float64(s.Stops[i].WeightLbs) / float64(s.TotalWeightLbs)
+		case SplitShareByPieces:
+			share = float64(s.Stops[i].Pieces) / float64(s.TotalPieces)
+		default:
+			share = 1 / float64(len(s.Stops))
+		}
+		s.Stops[i].Share = math.Round(share*10000) / 10000
+	}
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyncActionType is a driver action recorded in the mobile app, possibly
+// while offline, and uploaded later
+type SyncActionType string
+
+const (
+	SyncActionArrive     SyncActionType = "ARRIVE"
+	SyncActionComplete   SyncActionType = "COMPLETE"
+	SyncActionVerifySeal SyncActionType = "VERIFY_SEAL"
+	SyncActionPOD        SyncActionType = "POD" // Proof of delivery documents and signer
+)
+
+// SyncOutcome is what the server did with an uploaded driver action
+type SyncOutcome string
+
+const (
+	SyncOutcomeApplied    SyncOutcome = "APPLIED"    // Recorded as the driver reported it
+	SyncOutcomeDuplicate  SyncOutcome = "DUPLICATE"  // Idempotency key seen before; the original result is returned
+	SyncOutcomeSuperseded SyncOutcome = "SUPERSEDED" // The stop already reflects the action; nothing changed
+	SyncOutcomeConflict   SyncOutcome = "CONFLICT"   // Dispatch changed the trip while the driver was offline; held for review
+	SyncOutcomeRejected   SyncOutcome = "REJECTED"   // Invalid or blocked; the app should show the message
+)
+
+// DriverSyncAction is one queued action uploaded by the driver app. The
+// client time is when the driver performed it; BaseVersion is the stop
+// version the app had when it was performed.
+type DriverSyncAction struct {
+	IdempotencyKey string         `json:"idempotency_key"`
+	Type           SyncActionType `json:"type"`
+	TripID         uuid.UUID      `json:"trip_id"`
+	StopID         uuid.UUID      `json:"stop_id"`
+	ClientTime     time.Time      `json:"client_time"`
+	BaseVersion    int            `json:"base_version"`
+	Latitude       float64        `json:"latitude,omitempty"`
+	Longitude      float64        `json:"longitude,omitempty"`
+
+	// Completion details
+	GateTicketNumber string     `json:"gate_ticket_number,omitempty"`
+	SealNumber       string     `json:"seal_number,omitempty"`
+	ContainerNumber  string     `json:"container_number,omitempty"`
+	ChassisID        *uuid.UUID `json:"chassis_id,omitempty"`
+	Notes            string     `json:"notes,omitempty"`
+
+	// Seal verification and proof of delivery
+	PhotoDocumentID string   `json:"photo_document_id,omitempty"`
+	DocumentIDs     []string `json:"document_ids,omitempty"`
+	SignedBy        string   `json:"signed_by,omitempty"`
+}
+
+// DriverSyncRecord is the stored result of an uploaded action, kept so a
+// retried upload with the same idempotency key gets the same answer
+type DriverSyncRecord struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	DriverID       uuid.UUID      `json:"driver_id" db:"driver_id"`
+	DeviceID       string         `json:"device_id,omitempty" db:"device_id"`
+	IdempotencyKey string         `json:"idempotency_key" db:"idempotency_key"`
+	Type           SyncActionType `json:"type" db:"action_type"`
+	TripID         uuid.UUID      `json:"trip_id" db:"trip_id"`
+	StopID         uuid.UUID      `json:"stop_id" db:"stop_id"`
+	ClientTime     time.Time      `json:"client_time" db:"client_time"`
+	AppliedTime    *time.Time     `json:"applied_time,omitempty" db:"applied_time"` // Client time after skew correction
+	Outcome        SyncOutcome    `json:"outcome" db:"outcome"`
+	Message        string         `json:"message,omitempty" db:"message"`
+	ExceptionID    *uuid.UUID     `json:"exception_id,omitempty" db:"exception_id"`
+	ReceivedAt     time.Time      `json:"received_at" db:"received_at"`
+}
+
+// SyncActionResult is returned to the app for each uploaded action
+type SyncActionResult struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	Outcome        SyncOutcome `json:"outcome"`
+	Message        string      `json:"message,omitempty"`
+	ExceptionID    *uuid.UUID  `json:"exception_id,omitempty"`
+	Stop           *TripStop   `json:"stop,omitempty"` // Server copy of the stop after the action
+}
+
+// TripSyncPayload is a trip as downloaded to the driver app. Version changes
+// whenever the trip or any of its stops changes, so the app only downloads
+// trips whose version differs from its copy.
+type TripSyncPayload struct {
+	Trip    Trip  `json:"trip"`
+	Version int64 `json:"version"`
+}
+
+// DriverSyncDownload is the set of trips the app should hold offline
+type DriverSyncDownload struct {
+	DriverID   uuid.UUID         `json:"driver_id"`
+	ServerTime time.Time         `json:"server_time"`
+	Trips      []TripSyncPayload `json:"trips"`     // New or changed since the app's copy
+	Unchanged  []uuid.UUID       `json:"unchanged"` // App copy is current
+	Removed    []uuid.UUID       `json:"removed"`   // No longer the driver's; drop from the device
+}
+
+// DriverSyncUpload is the answer to a batch of uploaded actions
+type DriverSyncUpload struct {
+	Results    []SyncActionResult `json:"results"`
+	ServerTime time.Time          `json:"server_time"`
+}
+
+// SyncVersion combines the trip and stop row versions. Every update bumps
+// one of them, so the sum only grows while the trip changes.
+func SyncVersion(trip *Trip, stops []TripStop) int64 {
+	version := int64(trip.Version)
+	for i := range stops {
+		version += int64(stops[i].Version)
+	}
+	return version
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedTripFilter is a trip search a user has named and kept, such as
+// "hot imports" for the trips tagged hot
+type SavedTripFilter struct {
+	ID        uuid.UUID          `json:"id" db:"id"`
+	UserID    string             `json:"user_id" db:"user_id"`
+	Name      string             `json:"name" db:"name"`
+	Criteria  TripFilterCriteria `json:"criteria" db:"criteria"`
+	IsDefault bool               `json:"is_default" db:"is_default"` // Opened first on the user's board
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// TripFilterCriteria is the stored part of a trip list filter. Paging is
+// chosen each time the filter is run.
+type TripFilterCriteria struct {
+	Status       []TripStatus `json:"status,omitempty"`
+	Type         []TripType   `json:"type,omitempty"`
+	DriverID     *uuid.UUID   `json:"driver_id,omitempty"`
+	Tags         []string     `json:"tags,omitempty"`
+	MatchAllTags bool         `json:"match_all_tags,omitempty"` // Trips with every tag rather than any
+	IsStreetTurn *bool        `json:"is_street_turn,omitempty"`
+	SortBy       string       `json:"sort_by,omitempty"`
+	SortOrder    string       `json:"sort_order,omitempty"`
+}
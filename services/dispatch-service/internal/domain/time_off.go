@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimeOffType is why a driver is off
+type TimeOffType string
+
+const (
+	TimeOffVacation TimeOffType = "VACATION"
+	TimeOffPersonal TimeOffType = "PERSONAL"
+	TimeOffSick     TimeOffType = "SICK" // Exempt from the notice period
+)
+
+// TimeOffStatus is where a time off request is in review
+type TimeOffStatus string
+
+const (
+	TimeOffPending   TimeOffStatus = "PENDING"
+	TimeOffApproved  TimeOffStatus = "APPROVED"
+	TimeOffDenied    TimeOffStatus = "DENIED"
+	TimeOffCancelled TimeOffStatus = "CANCELLED"
+)
+
+// DriverTimeOff is a driver's request for whole days off, StartDate to
+// EndDate inclusive. Paid time off draws PaidHours from the driver's PTO
+// balance when approved and is paid out at PayRate on the settlement for
+// the week it is taken.
+type DriverTimeOff struct {
+	ID           uuid.UUID     `json:"id" db:"id"`
+	DriverID     uuid.UUID     `json:"driver_id" db:"driver_id"`
+	DriverName   string        `json:"driver_name" db:"driver_name"`
+	Type         TimeOffType   `json:"type" db:"type"`
+	StartDate    time.Time     `json:"start_date" db:"start_date"`
+	EndDate      time.Time     `json:"end_date" db:"end_date"`
+	Paid         bool          `json:"paid" db:"paid"`
+	PaidHours    float64       `json:"paid_hours" db:"paid_hours"` // Weekdays off at the configured hours per day; 0 when unpaid
+	PayRate      float64       `json:"pay_rate" db:"pay_rate"`     // Per hour, set on approval
+	PayAmount    float64       `json:"pay_amount" db:"pay_amount"`
+	Status       TimeOffStatus `json:"status" db:"status"`
+	Notes        string        `json:"notes,omitempty" db:"notes"`
+	RequestedBy  string        `json:"requested_by" db:"requested_by"`
+	ReviewedBy   string        `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt   *time.Time    `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNotes  string        `json:"review_notes,omitempty" db:"review_notes"`
+	CancelledBy  string        `json:"cancelled_by,omitempty" db:"cancelled_by"`
+	CancelledAt  *time.Time    `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CancelReason string        `json:"cancel_reason,omitempty" db:"cancel_reason"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Window returns when the driver is off: from the start of the first day to
+// the end of the last
+func (t *DriverTimeOff) Window() (start, end time.Time) {
+	start = time.Date(t.StartDate.Year(), t.StartDate.Month(), t.StartDate.Day(), 0, 0, 0, 0, t.StartDate.Location())
+	end = time.Date(t.EndDate.Year(), t.EndDate.Month(), t.EndDate.Day()+1, 0, 0, 0, 0, t.EndDate.Location())
+	return start, end
+}
+
+// Weekdays counts the Monday to Friday days between start and end inclusive
+func Weekdays(start, end time.Time) int {
+	days := 0
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for !day.After(end) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days++
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+// PTOEntryType is the kind of movement on a driver's PTO balance
+type PTOEntryType string
+
+const (
+	PTOEntryAccrual    PTOEntryType = "ACCRUAL"    // Earned from a week's completed trips
+	PTOEntryUsage      PTOEntryType = "USAGE"      // Drawn by approved time off
+	PTOEntryReversal   PTOEntryType = "REVERSAL"   // Approved time off cancelled before it was taken
+	PTOEntryAdjustment PTOEntryType = "ADJUSTMENT" // Set by hand, e.g. an opening balance
+)
+
+// PTOLedgerEntry is one movement on a driver's PTO balance. Hours are
+// positive when added and negative when drawn.
+type PTOLedgerEntry struct {
+	ID          uuid.UUID    `json:"id" db:"id"`
+	DriverID    uuid.UUID    `json:"driver_id" db:"driver_id"`
+	Type        PTOEntryType `json:"type" db:"type"`
+	Hours       float64      `json:"hours" db:"hours"`
+	WeekStart   *time.Time   `json:"week_start,omitempty" db:"week_start"`     // Accruals: the Monday of the week worked
+	WorkedHours float64      `json:"worked_hours,omitempty" db:"worked_hours"` // Accruals: hours on completed trips that week
+	TimeOffID   *uuid.UUID   `json:"time_off_id,omitempty" db:"time_off_id"`
+	Notes       string       `json:"notes,omitempty" db:"notes"`
+	CreatedBy   string       `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+}
+
+// PTOBalance is a driver's PTO standing. Available is the balance less the
+// hours of requests still pending.
+type PTOBalance struct {
+	DriverID     uuid.UUID        `json:"driver_id"`
+	DriverName   string           `json:"driver_name"`
+	BalanceHours float64          `json:"balance_hours"`
+	PendingHours float64          `json:"pending_hours"`
+	Available    float64          `json:"available_hours"`
+	Entries      []PTOLedgerEntry `json:"entries"` // Newest first
+}
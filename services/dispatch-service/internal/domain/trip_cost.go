@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MarginLevel grades a trip's live margin for the dispatch board
+type MarginLevel string
+
+const (
+	MarginLevelHealthy  MarginLevel = "HEALTHY"
+	MarginLevelThin     MarginLevel = "THIN"     // Below the configured share of revenue
+	MarginLevelLoss     MarginLevel = "LOSS"     // Costs so far exceed revenue
+	MarginLevelUnpriced MarginLevel = "UNPRICED" // No revenue recorded on the trip
+)
+
+// TripCostAccrual is what a trip has cost so far against what it earns.
+// Driver time covers the whole trip including waiting; detention is what
+// the customer owes for waiting past free time and counts as revenue.
+type TripCostAccrual struct {
+	TripID     uuid.UUID `json:"trip_id"`
+	TripNumber string    `json:"trip_number"`
+	AsOf       time.Time `json:"as_of"`
+
+	DriverMins    int     `json:"driver_mins"`
+	DriverCost    float64 `json:"driver_cost"`
+	MilesDriven   float64 `json:"miles_driven"`
+	FuelCost      float64 `json:"fuel_cost"`
+	MileageCost   float64 `json:"mileage_cost"` // Other per-mile operating cost
+	TotalCost     float64 `json:"total_cost"`
+	DetentionMins int     `json:"detention_mins"`
+
+	Revenue          float64     `json:"revenue"`
+	DetentionRevenue float64     `json:"detention_revenue"`
+	Margin           float64     `json:"margin"`
+	MarginPct        float64     `json:"margin_pct"`
+	Level            MarginLevel `json:"level"`
+
+	// When the driver is on site at a stop: the margin if they wait
+	// another hour there, and if the stop is abandoned as a dry run now
+	WaitingStopID        *uuid.UUID `json:"waiting_stop_id,omitempty"`
+	MarginAfterExtraHour *float64   `json:"margin_after_extra_hour,omitempty"`
+	DryRunMargin         *float64   `json:"dry_run_margin,omitempty"`
+}
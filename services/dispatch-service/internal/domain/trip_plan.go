@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlannedTrip is one trip placed on a driver's route in a trip plan
+type PlannedTrip struct {
+	TripID            uuid.UUID  `json:"trip_id"`
+	TripNumber        string     `json:"trip_number"`
+	Type              TripType   `json:"type"`
+	PickupLocationID  uuid.UUID  `json:"pickup_location_id"`
+	PickupName        string     `json:"pickup_name"`
+	DeadheadMiles     float64    `json:"deadhead_miles"` // Empty from the driver's previous position
+	ArriveAt          time.Time  `json:"arrive_at"`      // At the first stop, after any wait for the appointment
+	FinishAt          time.Time  `json:"finish_at"`
+	AppointmentTime   *time.Time `json:"appointment_time,omitempty"` // First stop appointment
+	WaitMins          int        `json:"wait_mins"`                  // Early, waiting for the appointment to open
+	LateMins          int        `json:"late_mins"`                  // Past the end of the appointment window
+	MissedAppointment bool       `json:"missed_appointment"`
+}
+
+// RouteCost is what a driver's route costs, as scored by the planner
+type RouteCost struct {
+	EmptyMiles         float64 `json:"empty_miles"`
+	DriveMins          int     `json:"drive_mins"` // Deadhead plus the trips' estimated durations
+	WaitMins           int     `json:"wait_mins"`
+	LateMins           int     `json:"late_mins"`
+	MissedAppointments int     `json:"missed_appointments"`
+}
+
+// Add sums two route costs
+func (c RouteCost) Add(other RouteCost) RouteCost {
+	return RouteCost{
+		EmptyMiles:         c.EmptyMiles + other.EmptyMiles,
+		DriveMins:          c.DriveMins + other.DriveMins,
+		WaitMins:           c.WaitMins + other.WaitMins,
+		LateMins:           c.LateMins + other.LateMins,
+		MissedAppointments: c.MissedAppointments + other.MissedAppointments,
+	}
+}
+
+// DriverRoute is the trips a plan gives one driver, in the order they run
+type DriverRoute struct {
+	DriverID           uuid.UUID     `json:"driver_id"`
+	DriverName         string        `json:"driver_name"`
+	AvailableDriveMins int           `json:"available_drive_mins"`
+	Trips              []PlannedTrip `json:"trips"`
+	Cost               RouteCost     `json:"cost"`
+	Score              float64       `json:"score"`
+}
+
+// UnplannedTrip is an unassigned trip no driver could take, and why
+type UnplannedTrip struct {
+	TripID     uuid.UUID `json:"trip_id"`
+	TripNumber string    `json:"trip_number"`
+	Reason     string    `json:"reason"`
+}
+
+// TripPlan proposes drivers for a day's unassigned trips. Nothing is
+// assigned until a dispatcher applies it.
+type TripPlan struct {
+	Date             string          `json:"date"` // YYYY-MM-DD
+	Scorer           string          `json:"scorer"`
+	Routes           []DriverRoute   `json:"routes"`
+	Unplanned        []UnplannedTrip `json:"unplanned"`
+	TripsConsidered  int             `json:"trips_considered"`
+	TripsPlanned     int             `json:"trips_planned"`
+	DriversAvailable int             `json:"drivers_available"`
+	Cost             RouteCost       `json:"cost"`
+	Score            float64         `json:"score"`
+	GeneratedAt      time.Time       `json:"generated_at"`
+}
+
+// TripPlanAssignment is one trip-to-driver pairing a dispatcher applies from a plan
+type TripPlanAssignment struct {
+	TripID   uuid.UUID `json:"trip_id"`
+	DriverID uuid.UUID `json:"driver_id"`
+	Applied  bool      `json:"applied"`
+	Error    string    `json:"error,omitempty"`
+}
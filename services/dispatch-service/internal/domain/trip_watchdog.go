@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PositionFix is a GPS position reported by a driver's device
+type PositionFix struct {
+	Latitude   float64   `json:"latitude" db:"latitude"`
+	Longitude  float64   `json:"longitude" db:"longitude"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// StaleTripAction is what the trip watchdog did about a quiet trip
+type StaleTripAction string
+
+const (
+	StaleTripPrompted      StaleTripAction = "PROMPTED"       // Driver and dispatcher asked to update the trip
+	StaleTripAutoCompleted StaleTripAction = "AUTO_COMPLETED" // Arrived stops closed out and the trip completed
+	StaleTripFlagged       StaleTripAction = "FLAGGED"        // Exception raised for dispatch to review
+)
+
+// StaleTrip is an in-progress trip the watchdog acted on
+type StaleTrip struct {
+	TripID         uuid.UUID       `json:"trip_id"`
+	TripNumber     string          `json:"trip_number"`
+	DriverID       *uuid.UUID      `json:"driver_id,omitempty"`
+	LastActivityAt time.Time       `json:"last_activity_at"`
+	QuietMins      int             `json:"quiet_mins"`
+	OpenStops      int             `json:"open_stops"`
+	Action         StaleTripAction `json:"action"`
+}
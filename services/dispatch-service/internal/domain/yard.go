@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationTypeYard is the location type of a company yard
+const LocationTypeYard = "YARD"
+
+// IsYard checks if the location is a company yard
+func (l *Location) IsYard() bool {
+	return strings.EqualFold(l.Type, LocationTypeYard)
+}
+
+// GateDirection is whether equipment entered or left the yard
+type GateDirection string
+
+const (
+	GateDirectionIn  GateDirection = "IN"
+	GateDirectionOut GateDirection = "OUT"
+)
+
+// EquipmentCondition is the condition recorded at the gate
+type EquipmentCondition string
+
+const (
+	EquipmentConditionGood    EquipmentCondition = "GOOD"
+	EquipmentConditionDamaged EquipmentCondition = "DAMAGED"
+)
+
+// YardGateEvent is a container and/or chassis passing a company yard gate.
+// In-gates open a yard inventory entry and start the container's per-diem
+// clock; out-gates close them. A chassis leaving the yard starts a usage
+// record that the matching in-gate closes.
+type YardGateEvent struct {
+	ID               uuid.UUID          `json:"id" db:"id"`
+	YardLocationID   uuid.UUID          `json:"yard_location_id" db:"yard_location_id"`
+	Direction        GateDirection      `json:"direction" db:"direction"`
+	ContainerNumber  string             `json:"container_number,omitempty" db:"container_number"`
+	ContainerSize    string             `json:"container_size,omitempty" db:"container_size"`
+	IsLoaded         bool               `json:"is_loaded" db:"is_loaded"`
+	SealNumber       string             `json:"seal_number,omitempty" db:"seal_number"`
+	ChassisID        *uuid.UUID         `json:"chassis_id,omitempty" db:"chassis_id"`
+	ChassisNumber    string             `json:"chassis_number,omitempty" db:"chassis_number"`
+	ChassisPoolID    *uuid.UUID         `json:"chassis_pool_id,omitempty" db:"chassis_pool_id"`
+	TripID           *uuid.UUID         `json:"trip_id,omitempty" db:"trip_id"`
+	DriverID         *uuid.UUID         `json:"driver_id,omitempty" db:"driver_id"`
+	TractorID        *uuid.UUID         `json:"tractor_id,omitempty" db:"tractor_id"`
+	Condition        EquipmentCondition `json:"condition" db:"condition"`
+	DamageNotes      string             `json:"damage_notes,omitempty" db:"damage_notes"`
+	PhotoDocumentIDs []string           `json:"photo_document_ids,omitempty" db:"photo_document_ids"`
+	OccurredAt       time.Time          `json:"occurred_at" db:"occurred_at"`
+	RecordedBy       string             `json:"recorded_by" db:"recorded_by"`
+	InventoryID      *uuid.UUID         `json:"inventory_id,omitempty" db:"inventory_id"`
+	ChassisUsageID   *uuid.UUID         `json:"chassis_usage_id,omitempty" db:"chassis_usage_id"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+}
+
+// YardInventoryStatus is whether an inventory entry is still on the ground
+type YardInventoryStatus string
+
+const (
+	YardInventoryStatusInYard   YardInventoryStatus = "IN_YARD"
+	YardInventoryStatusOutGated YardInventoryStatus = "OUT_GATED"
+)
+
+// YardInventoryItem is a container, or a bare chassis, sitting in a yard.
+// InDate to OutDate is the per-diem clock for the container.
+type YardInventoryItem struct {
+	ID              uuid.UUID           `json:"id" db:"id"`
+	YardLocationID  uuid.UUID           `json:"yard_location_id" db:"yard_location_id"`
+	ContainerNumber string              `json:"container_number,omitempty" db:"container_number"`
+	ContainerSize   string              `json:"container_size,omitempty" db:"container_size"`
+	IsLoaded        bool                `json:"is_loaded" db:"is_loaded"`
+	OnChassis       bool                `json:"on_chassis" db:"on_chassis"`
+	ChassisID       *uuid.UUID          `json:"chassis_id,omitempty" db:"chassis_id"`
+	ChassisNumber   string              `json:"chassis_number,omitempty" db:"chassis_number"`
+	InDate          time.Time           `json:"in_date" db:"in_date"`
+	OutDate         *time.Time          `json:"out_date,omitempty" db:"out_date"`
+	InCondition     EquipmentCondition  `json:"in_condition" db:"in_condition"`
+	OutCondition    EquipmentCondition  `json:"out_condition,omitempty" db:"out_condition"`
+	InGateEventID   uuid.UUID           `json:"in_gate_event_id" db:"in_gate_event_id"`
+	OutGateEventID  *uuid.UUID          `json:"out_gate_event_id,omitempty" db:"out_gate_event_id"`
+	Status          YardInventoryStatus `json:"status" db:"status"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// StorageDays returns the calendar days the entry has been in the yard as of
+// asOf, or until it out-gated. A partial first or last day counts as a day.
+func (i *YardInventoryItem) StorageDays(asOf time.Time) int {
+	end := asOf
+	if i.OutDate != nil {
+		end = *i.OutDate
+	}
+	if end.Before(i.InDate) {
+		return 0
+	}
+	return calendarDays(i.InDate, end)
+}
+
+// calendarDays counts the calendar days touched from start to end, both
+// partial days included
+func calendarDays(start, end time.Time) int {
+	end = end.In(start.Location())
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, start.Location())
+	days := int(math.Round(last.Sub(first).Hours()/24)) + 1
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// ChassisUsage is a chassis out of a company yard, from its out-gate to
+// the in-gate that brings it back. Billing prices the usage days.
+type ChassisUsage struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	ChassisID      uuid.UUID  `json:"chassis_id" db:"chassis_id"`
+	TripID         *uuid.UUID `json:"trip_id,omitempty" db:"trip_id"`
+	PoolID         *uuid.UUID `json:"pool_id,omitempty" db:"pool_id"`
+	PickupTime     time.Time  `json:"pickup_time" db:"pickup_time"`
+	PickupLocation string     `json:"pickup_location" db:"pickup_location"`
+	ReturnTime     *time.Time `json:"return_time,omitempty" db:"return_time"`
+	ReturnLocation string     `json:"return_location,omitempty" db:"return_location"`
+	UsageDays      int        `json:"usage_days" db:"usage_days"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Close records the chassis coming back and counts the calendar days it was out
+func (u *ChassisUsage) Close(returnTime time.Time, location string) {
+	u.ReturnTime = &returnTime
+	u.ReturnLocation = location
+	u.UsageDays = calendarDays(u.PickupTime, returnTime)
+}
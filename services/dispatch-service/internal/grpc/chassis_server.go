@@ -0,0 +1,196 @@
+//go:build grpc
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/draymaster/shared/proto/dispatch/v1"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ChassisServer implements the ChassisService gRPC API.
+type ChassisServer struct {
+	pb.UnimplementedChassisServiceServer
+	chassis *service.ChassisService
+	log     *logger.Logger
+}
+
+// NewChassisServer creates a new ChassisService gRPC server.
+func NewChassisServer(chassis *service.ChassisService, log *logger.Logger) *ChassisServer {
+	return &ChassisServer{chassis: chassis, log: log}
+}
+
+func (s *ChassisServer) CreateChassis(ctx context.Context, req *pb.CreateChassisRequest) (*pb.Chassis, error) {
+	poolID, err := parseOptionalID("pool_id", req.GetPoolId())
+	if err != nil {
+		return nil, err
+	}
+	locationID, err := parseOptionalID("current_location_id", req.GetCurrentLocationId())
+	if err != nil {
+		return nil, err
+	}
+
+	chassis, err := s.chassis.CreateChassis(ctx, service.CreateChassisInput{
+		ChassisNumber:     req.GetChassisNumber(),
+		Size:              req.GetSize(),
+		Type:              req.GetType(),
+		TareWeight:        int(req.GetTareWeight()),
+		NumAxles:          int(req.GetNumAxles()),
+		Ownership:         domain.ChassisOwnership(req.GetOwnership()),
+		PoolID:            poolID,
+		IEPCode:           req.GetIepCode(),
+		CurrentLocationID: locationID,
+		Notes:             req.GetNotes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chassisToProto(chassis), nil
+}
+
+func (s *ChassisServer) GetChassis(ctx context.Context, req *pb.GetChassisRequest) (*pb.Chassis, error) {
+	id, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	chassis, err := s.chassis.GetChassis(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return chassisToProto(chassis), nil
+}
+
+func (s *ChassisServer) UpdateChassis(ctx context.Context, req *pb.UpdateChassisRequest) (*pb.Chassis, error) {
+	id, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	input := service.UpdateChassisInput{
+		Type:    req.Type,
+		IEPCode: req.IepCode,
+		Notes:   req.Notes,
+	}
+	if req.TareWeight != nil {
+		tare := int(req.GetTareWeight())
+		input.TareWeight = &tare
+	}
+	if req.NumAxles != nil {
+		axles := int(req.GetNumAxles())
+		input.NumAxles = &axles
+	}
+	if req.Ownership != nil {
+		ownership := domain.ChassisOwnership(req.GetOwnership())
+		input.Ownership = &ownership
+	}
+	if req.Status != nil {
+		status := domain.ChassisStatus(req.GetStatus())
+		input.Status = &status
+	}
+	if input.PoolID, err = parseOptionalID("pool_id", req.GetPoolId()); err != nil {
+		return nil, err
+	}
+	if input.CurrentLocationID, err = parseOptionalID("current_location_id", req.GetCurrentLocationId()); err != nil {
+		return nil, err
+	}
+
+	chassis, err := s.chassis.UpdateChassis(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	return chassisToProto(chassis), nil
+}
+
+func (s *ChassisServer) ListChassis(ctx context.Context, req *pb.ListChassisRequest) (*pb.ListChassisResponse, error) {
+	filter := domain.ChassisFilter{
+		Status:    domain.ChassisStatus(req.GetStatus()),
+		Ownership: domain.ChassisOwnership(req.GetOwnership()),
+	}
+	var err error
+	if filter.PoolID, err = parseOptionalID("pool_id", req.GetPoolId()); err != nil {
+		return nil, err
+	}
+	if filter.LocationID, err = parseOptionalID("location_id", req.GetLocationId()); err != nil {
+		return nil, err
+	}
+
+	chassis, err := s.chassis.ListChassis(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListChassisResponse{Chassis: make([]*pb.Chassis, len(chassis))}
+	for i := range chassis {
+		resp.Chassis[i] = chassisToProto(&chassis[i])
+	}
+	return resp, nil
+}
+
+func (s *ChassisServer) ListChassisPools(ctx context.Context, req *pb.ListChassisPoolsRequest) (*pb.ListChassisPoolsResponse, error) {
+	pools, err := s.chassis.ListChassisPools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListChassisPoolsResponse{Pools: make([]*pb.ChassisPool, len(pools))}
+	for i := range pools {
+		resp.Pools[i] = chassisPoolToProto(&pools[i])
+	}
+	return resp, nil
+}
+
+func (s *ChassisServer) GetChassisPerDiem(ctx context.Context, req *pb.GetChassisPerDiemRequest) (*pb.ChassisPerDiem, error) {
+	chassisID, err := parseID("chassis_id", req.GetChassisId())
+	if err != nil {
+		return nil, err
+	}
+	from := timeFrom(req.GetFrom())
+	if from == nil {
+		return nil, apperrors.ValidationError("from is required", "from", nil)
+	}
+	to := time.Now()
+	if t := timeFrom(req.GetTo()); t != nil {
+		to = *t
+	}
+
+	perDiem, err := s.chassis.GetChassisPerDiem(ctx, chassisID, *from, to)
+	if err != nil {
+		return nil, err
+	}
+	return perDiemToProto(perDiem), nil
+}
+
+func (s *ChassisServer) FlagTripChassisSplit(ctx context.Context, req *pb.FlagTripChassisSplitRequest) (*pb.ChassisSplit, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := s.chassis.FlagTripChassisSplit(ctx, tripID, by)
+	if err != nil {
+		return nil, err
+	}
+	return chassisSplitToProto(split), nil
+}
+
+func (s *ChassisServer) GetTripChassisSplit(ctx context.Context, req *pb.GetTripChassisSplitRequest) (*pb.ChassisSplit, error) {
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := s.chassis.GetTripChassisSplit(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	return chassisSplitToProto(split), nil
+}
@@ -0,0 +1,482 @@
+//go:build grpc
+
+package grpc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/draymaster/shared/proto/dispatch/v1"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+)
+
+// Proto enum values are the domain values with the enum's prefix, so
+// TRIP_TYPE_LIVE_LOAD is domain.TripTypeLiveLoad. Unknown values map to
+// UNSPECIFIED, and UNSPECIFIED maps to the empty string.
+
+func enumValue(values map[string]int32, prefix, value string) int32 {
+	if value == "" {
+		return 0
+	}
+	return values[prefix+value]
+}
+
+func enumName(names map[int32]string, prefix string, value int32) string {
+	if value == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(names[value], prefix)
+}
+
+func tripTypeToProto(t domain.TripType) pb.TripType {
+	return pb.TripType(enumValue(pb.TripType_value, "TRIP_TYPE_", string(t)))
+}
+
+func tripTypeFromProto(t pb.TripType) domain.TripType {
+	return domain.TripType(enumName(pb.TripType_name, "TRIP_TYPE_", int32(t)))
+}
+
+func tripStatusToProto(s domain.TripStatus) pb.TripStatus {
+	return pb.TripStatus(enumValue(pb.TripStatus_value, "TRIP_STATUS_", string(s)))
+}
+
+func tripStatusFromProto(s pb.TripStatus) domain.TripStatus {
+	return domain.TripStatus(enumName(pb.TripStatus_name, "TRIP_STATUS_", int32(s)))
+}
+
+func stopTypeToProto(t domain.StopType) pb.StopType {
+	return pb.StopType(enumValue(pb.StopType_value, "STOP_TYPE_", string(t)))
+}
+
+func stopTypeFromProto(t pb.StopType) domain.StopType {
+	return domain.StopType(enumName(pb.StopType_name, "STOP_TYPE_", int32(t)))
+}
+
+func activityToProto(a domain.ActivityType) pb.ActivityType {
+	return pb.ActivityType(enumValue(pb.ActivityType_value, "ACTIVITY_TYPE_", string(a)))
+}
+
+func activityFromProto(a pb.ActivityType) domain.ActivityType {
+	return domain.ActivityType(enumName(pb.ActivityType_name, "ACTIVITY_TYPE_", int32(a)))
+}
+
+func stopStatusToProto(s domain.StopStatus) pb.StopStatus {
+	return pb.StopStatus(enumValue(pb.StopStatus_value, "STOP_STATUS_", string(s)))
+}
+
+func stopStatusFromProto(s pb.StopStatus) domain.StopStatus {
+	return domain.StopStatus(enumName(pb.StopStatus_name, "STOP_STATUS_", int32(s)))
+}
+
+func tripToProto(t *domain.Trip) *pb.Trip {
+	if t == nil {
+		return nil
+	}
+	out := &pb.Trip{
+		Id:                       t.ID.String(),
+		TripNumber:               t.TripNumber,
+		Type:                     tripTypeToProto(t.Type),
+		Status:                   tripStatusToProto(t.Status),
+		DriverId:                 idString(t.DriverID),
+		TractorId:                idString(t.TractorID),
+		ChassisId:                idString(t.ChassisID),
+		CurrentStopSequence:      int32(t.CurrentStopSequence),
+		OrderIds:                 t.OrderIDs,
+		PlannedStartTime:         timestamp(t.PlannedStartTime),
+		ActualStartTime:          timestamp(t.ActualStartTime),
+		PlannedEndTime:           timestamp(t.PlannedEndTime),
+		ActualEndTime:            timestamp(t.ActualEndTime),
+		EstimatedDurationMinutes: int32(t.EstimatedDurationMins),
+		TotalMiles:               t.TotalMiles,
+		CompletedMiles:           t.CompletedMiles,
+		IsStreetTurn:             t.IsStreetTurn,
+		IsDualTransaction:        t.IsDualTransaction,
+		StreetTurnLinkedTripId:   idString(t.LinkedTripID),
+		CreatedAt:                timestamppb.New(t.CreatedAt),
+		UpdatedAt:                timestamppb.New(t.UpdatedAt),
+		CreatedBy:                t.CreatedBy,
+		Version:                  int32(t.Version),
+		Tags:                     t.Tags,
+		CostAccrual:              costAccrualToProto(t.CostAccrual),
+	}
+	if t.Driver != nil {
+		out.Driver = driverToProto(t.Driver)
+	}
+	if t.Tractor != nil {
+		out.Tractor = &pb.Tractor{
+			Id:         t.Tractor.ID.String(),
+			UnitNumber: t.Tractor.UnitNumber,
+			Status:     t.Tractor.Status,
+		}
+	}
+	out.Stops = make([]*pb.TripStop, len(t.Stops))
+	for i := range t.Stops {
+		out.Stops[i] = stopToProto(&t.Stops[i])
+	}
+	return out
+}
+
+func tripsToProto(trips []domain.Trip) []*pb.Trip {
+	out := make([]*pb.Trip, len(trips))
+	for i := range trips {
+		out[i] = tripToProto(&trips[i])
+	}
+	return out
+}
+
+func stopToProto(s *domain.TripStop) *pb.TripStop {
+	if s == nil {
+		return nil
+	}
+	return &pb.TripStop{
+		Id:                       s.ID.String(),
+		TripId:                   s.TripID.String(),
+		Sequence:                 int32(s.Sequence),
+		Type:                     stopTypeToProto(s.Type),
+		Activity:                 activityToProto(s.Activity),
+		Status:                   stopStatusToProto(s.Status),
+		LocationId:               s.LocationID.String(),
+		Location:                 locationToProto(s.Location),
+		ContainerId:              idString(s.ContainerID),
+		ContainerNumber:          s.ContainerNumber,
+		OrderId:                  idString(s.OrderID),
+		AppointmentTime:          timestamp(s.AppointmentTime),
+		AppointmentNumber:        s.AppointmentNumber,
+		AppointmentWindowMinutes: int32(s.AppointmentWindowMins),
+		PlannedArrival:           timestamp(s.PlannedArrival),
+		ActualArrival:            timestamp(s.ActualArrival),
+		ActualDeparture:          timestamp(s.ActualDeparture),
+		EstimatedDurationMinutes: int32(s.EstimatedDurationMins),
+		ActualDurationMinutes:    int32(s.ActualDurationMins),
+		FreeTimeMinutes:          int32(s.FreeTimeMins),
+		DetentionStartTime:       timestamp(s.DetentionStartTime),
+		DetentionMinutes:         int32(s.DetentionMins),
+		ChassisInId:              idString(s.ChassisInID),
+		ChassisOutId:             idString(s.ChassisOutID),
+		ContainerInId:            idString(s.ContainerInID),
+		ContainerOutId:           idString(s.ContainerOutID),
+		GateTicketNumber:         s.GateTicketNumber,
+		SealNumber:               s.SealNumber,
+		DocumentIds:              s.DocumentIDs,
+		FailureReason:            s.FailureReason,
+		Notes:                    s.Notes,
+		Version:                  int32(s.Version),
+	}
+}
+
+func driverToProto(d *domain.Driver) *pb.Driver {
+	return &pb.Driver{
+		Id:                    d.ID.String(),
+		Name:                  d.Name,
+		Phone:                 d.Phone,
+		Status:                d.Status,
+		CurrentLatitude:       d.CurrentLatitude,
+		CurrentLongitude:      d.CurrentLongitude,
+		AvailableDriveMinutes: int32(d.AvailableDriveMins),
+		AvailableDutyMinutes:  int32(d.AvailableDutyMins),
+	}
+}
+
+func locationToProto(l *domain.Location) *pb.Location {
+	if l == nil {
+		return nil
+	}
+	return &pb.Location{
+		Id:           l.ID.String(),
+		Name:         l.Name,
+		Type:         l.Type,
+		Address:      l.Address,
+		City:         l.City,
+		State:        l.State,
+		Zip:          l.Zip,
+		Latitude:     l.Latitude,
+		Longitude:    l.Longitude,
+		ContactName:  l.ContactName,
+		ContactPhone: l.ContactPhone,
+		GeofenceId:   idString(l.GeofenceID),
+	}
+}
+
+func streetTurnToProto(o *domain.StreetTurnOpportunity) *pb.StreetTurnOpportunity {
+	return &pb.StreetTurnOpportunity{
+		ImportOrderId:          o.ImportOrderID.String(),
+		ImportOrderNumber:      o.ImportOrderNumber,
+		ImportContainerNumber:  o.ImportContainerNumber,
+		ImportConsigneeName:    o.ImportConsigneeName,
+		ImportDeliveryLocation: locationToProto(&o.ImportDeliveryLocation),
+		ExportOrderId:          o.ExportOrderID.String(),
+		ExportOrderNumber:      o.ExportOrderNumber,
+		ExportShipperName:      o.ExportShipperName,
+		ExportPickupLocation:   locationToProto(&o.ExportPickupLocation),
+		SteamshipLine:          o.SteamshipLine,
+		ContainerSize:          o.ContainerSize,
+		ContainerType:          o.ContainerType,
+		DistanceMiles:          o.DistanceMiles,
+		EstimatedSavings:       o.EstimatedSavings,
+		MatchScore:             int32(o.MatchScore),
+		ImportDeliveryDate:     timestamppb.New(o.ImportDeliveryDate),
+		ExportPickupDate:       timestamppb.New(o.ExportPickupDate),
+	}
+}
+
+func dualTransactionToProto(o *domain.DualTransactionOpportunity) *pb.DualTransactionOpportunity {
+	return &pb.DualTransactionOpportunity{
+		TerminalId:       o.TerminalID.String(),
+		TerminalName:     o.TerminalName,
+		EmptyReturn:      terminalMoveToProto(&o.EmptyReturn),
+		ImportPickup:     terminalMoveToProto(&o.ImportPickup),
+		GapMins:          int32(o.GapMins),
+		ChassisReuse:     o.ChassisReuse,
+		DriverConflict:   o.DriverConflict,
+		EstimatedSavings: o.EstimatedSavings,
+		MatchScore:       int32(o.MatchScore),
+	}
+}
+
+func terminalMoveToProto(m *domain.TerminalMove) *pb.TerminalMove {
+	return &pb.TerminalMove{
+		TripId:          m.TripID.String(),
+		TripNumber:      m.TripNumber,
+		TripStatus:      string(m.TripStatus),
+		DriverId:        idString(m.DriverID),
+		StopId:          m.StopID.String(),
+		Activity:        string(m.Activity),
+		OrderId:         idString(m.OrderID),
+		ContainerNumber: m.ContainerNumber,
+		ContainerSize:   m.ContainerSize,
+		SteamshipLine:   m.SteamshipLine,
+		PlannedTime:     timestamppb.New(m.PlannedTime),
+	}
+}
+
+func boardToProto(b *domain.DispatchBoard) *pb.DispatchBoard {
+	return &pb.DispatchBoard{
+		Unassigned: tripsToProto(b.Unassigned),
+		Assigned:   tripsToProto(b.Assigned),
+		Dispatched: tripsToProto(b.Dispatched),
+		InProgress: tripsToProto(b.InProgress),
+		Completed:  tripsToProto(b.Completed),
+		Failed:     tripsToProto(b.Failed),
+		TotalTrips: int32(b.TotalTrips),
+		AsOf:       timestamppb.New(b.AsOf),
+	}
+}
+
+func availabilityToProto(a *domain.DriverAvailability) *pb.DriverAvailability {
+	return &pb.DriverAvailability{
+		DriverId:              a.DriverID.String(),
+		DriverName:            a.DriverName,
+		Status:                a.Status,
+		Latitude:              a.Latitude,
+		Longitude:             a.Longitude,
+		AvailableDriveMinutes: int32(a.AvailableDriveMins),
+		AvailableDutyMinutes:  int32(a.AvailableDutyMins),
+		CurrentTripId:         idString(a.CurrentTripID),
+		CurrentTripEta:        timestamp(a.CurrentTripETA),
+		DistanceToPickupMiles: a.DistanceToPickupMiles,
+		EtaToPickupMinutes:    int32(a.ETAToPickupMins),
+		Endorsements:          a.Endorsements,
+		HasTwic:               a.HasTWIC,
+		NeedsEscort:           a.NeedsEscort,
+		EscortFee:             a.EscortFee,
+	}
+}
+
+func chargeToProto(c *domain.CancellationCharge) *pb.CancellationCharge {
+	if c == nil {
+		return nil
+	}
+	return &pb.CancellationCharge{
+		Id:         c.ID.String(),
+		TripId:     c.TripID.String(),
+		TripNumber: c.TripNumber,
+		ChargeType: c.ChargeType,
+		Amount:     c.Amount,
+		Basis:      c.Basis,
+		Reason:     c.Reason,
+		CreatedAt:  timestamppb.New(c.CreatedAt),
+	}
+}
+
+func statisticsToProto(s *service.TripStatistics) *pb.TripStatistics {
+	out := &pb.TripStatistics{
+		Period:            s.Period,
+		StartDate:         timestamppb.New(s.StartDate),
+		EndDate:           timestamppb.New(s.EndDate),
+		TotalTrips:        int32(s.TotalTrips),
+		CompletedTrips:    int32(s.CompletedTrips),
+		TotalMiles:        s.TotalMiles,
+		TotalRevenue:      s.TotalRevenue,
+		AvgMilesPerTrip:   s.AvgMilesPerTrip,
+		AvgRevenuePerTrip: s.AvgRevenuePerTrip,
+		ByStatus:          make(map[string]int32, len(s.ByStatus)),
+		ByType:            make(map[string]int32, len(s.ByType)),
+	}
+	for k, v := range s.ByStatus {
+		out.ByStatus[k] = int32(v)
+	}
+	for k, v := range s.ByType {
+		out.ByType[k] = int32(v)
+	}
+	return out
+}
+
+func stopInputFromProto(in *pb.TripStopInput) (service.CreateStopInput, error) {
+	locationID, err := parseID("location_id", in.GetLocationId())
+	if err != nil {
+		return service.CreateStopInput{}, err
+	}
+	containerID, err := parseOptionalID("container_id", in.GetContainerId())
+	if err != nil {
+		return service.CreateStopInput{}, err
+	}
+	orderID, err := parseOptionalID("order_id", in.GetOrderId())
+	if err != nil {
+		return service.CreateStopInput{}, err
+	}
+	return service.CreateStopInput{
+		Sequence:              int(in.GetSequence()),
+		Type:                  stopTypeFromProto(in.GetType()),
+		Activity:              activityFromProto(in.GetActivity()),
+		LocationID:            locationID,
+		ContainerID:           containerID,
+		OrderID:               orderID,
+		AppointmentTime:       timeFrom(in.GetAppointmentTime()),
+		AppointmentNumber:     in.GetAppointmentNumber(),
+		EstimatedDurationMins: int(in.GetEstimatedDurationMinutes()),
+		FreeTimeMins:          int(in.GetFreeTimeMinutes()),
+	}, nil
+}
+
+func idString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeFrom(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func chassisToProto(c *domain.Chassis) *pb.Chassis {
+	return &pb.Chassis{
+		Id:                c.ID.String(),
+		ChassisNumber:     c.ChassisNumber,
+		Size:              c.Size,
+		Type:              c.Type,
+		TareWeight:        int32(c.TareWeight),
+		NumAxles:          int32(c.NumAxles),
+		Ownership:         string(c.Ownership),
+		PoolId:            idString(c.PoolID),
+		IepCode:           c.IEPCode,
+		Status:            string(c.Status),
+		CurrentLocationId: idString(c.CurrentLocationID),
+		CurrentTripId:     idString(c.CurrentTripID),
+		LastMovedAt:       timestamp(c.LastMovedAt),
+		Notes:             c.Notes,
+		CreatedAt:         timestamppb.New(c.CreatedAt),
+		UpdatedAt:         timestamppb.New(c.UpdatedAt),
+	}
+}
+
+func chassisPoolToProto(p *domain.ChassisPool) *pb.ChassisPool {
+	return &pb.ChassisPool{
+		Id:           p.ID.String(),
+		Code:         p.Code,
+		Name:         p.Name,
+		ProviderName: p.ProviderName,
+		DailyRate_20: p.DailyRate20,
+		DailyRate_40: p.DailyRate40,
+		DailyRate_45: p.DailyRate45,
+		SplitFee:     p.SplitFee,
+	}
+}
+
+func chassisSplitToProto(s *domain.ChassisSplit) *pb.ChassisSplit {
+	return &pb.ChassisSplit{
+		Id:                        s.ID.String(),
+		TripId:                    s.TripID.String(),
+		ChassisId:                 idString(s.ChassisID),
+		Status:                    string(s.Status),
+		ChassisPickupLocationId:   idString(s.ChassisPickupLocationID),
+		ContainerPickupLocationId: idString(s.ContainerPickupLocationID),
+		ChassisReturnLocationId:   idString(s.ChassisReturnLocationID),
+		ContainerDropLocationId:   idString(s.ContainerDropLocationID),
+		SplitFee:                  s.SplitFee,
+		FlaggedBy:                 s.FlaggedBy,
+		UpdatedAt:                 timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func perDiemToProto(d *domain.ChassisPerDiem) *pb.ChassisPerDiem {
+	out := &pb.ChassisPerDiem{
+		ChassisId:     d.ChassisID.String(),
+		ChassisNumber: d.ChassisNumber,
+		Ownership:     string(d.Ownership),
+		PoolId:        idString(d.PoolID),
+		PeriodStart:   timestamppb.New(d.PeriodStart),
+		PeriodEnd:     timestamppb.New(d.PeriodEnd),
+		Charges:       make([]*pb.ChassisUsageCharge, len(d.Charges)),
+		TotalDays:     int32(d.TotalDays),
+		SplitCount:    int32(d.SplitCount),
+		SplitFees:     d.SplitFees,
+		TotalAmount:   d.TotalAmount,
+	}
+	for i, c := range d.Charges {
+		out.Charges[i] = &pb.ChassisUsageCharge{
+			UsageId:        c.Usage.ID.String(),
+			TripId:         idString(c.Usage.TripID),
+			PickupTime:     timestamppb.New(c.Usage.PickupTime),
+			PickupLocation: c.Usage.PickupLocation,
+			ReturnTime:     timestamp(c.Usage.ReturnTime),
+			ReturnLocation: c.Usage.ReturnLocation,
+			Days:           int32(c.Days),
+			DailyRate:      c.DailyRate,
+			Amount:         c.Amount,
+		}
+	}
+	return out
+}
+
+func costAccrualToProto(a *domain.TripCostAccrual) *pb.TripCostAccrual {
+	if a == nil {
+		return nil
+	}
+	return &pb.TripCostAccrual{
+		TripId:               a.TripID.String(),
+		TripNumber:           a.TripNumber,
+		AsOf:                 timestamppb.New(a.AsOf),
+		DriverMinutes:        int32(a.DriverMins),
+		DriverCost:           a.DriverCost,
+		MilesDriven:          a.MilesDriven,
+		FuelCost:             a.FuelCost,
+		MileageCost:          a.MileageCost,
+		TotalCost:            a.TotalCost,
+		DetentionMinutes:     int32(a.DetentionMins),
+		Revenue:              a.Revenue,
+		DetentionRevenue:     a.DetentionRevenue,
+		Margin:               a.Margin,
+		MarginPct:            a.MarginPct,
+		Level:                string(a.Level),
+		WaitingStopId:        idString(a.WaitingStopID),
+		MarginAfterExtraHour: a.MarginAfterExtraHour,
+		DryRunMargin:         a.DryRunMargin,
+	}
+}
@@ -0,0 +1,384 @@
+//go:build grpc
+
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/draymaster/shared/proto/dispatch/v1"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxBulkTrips caps the trips in one bulk call, matching BulkAssignDriver
+const maxBulkTrips = 50
+
+// CRUDServer implements the DispatchCRUDService gRPC API.
+type CRUDServer struct {
+	pb.UnimplementedDispatchCRUDServiceServer
+	crud     *service.DispatchCRUDService
+	dispatch *service.DispatchService
+	log      *logger.Logger
+}
+
+// NewCRUDServer creates a new DispatchCRUDService gRPC server. Batch
+// lookups are served by the dispatch service.
+func NewCRUDServer(crud *service.DispatchCRUDService, dispatch *service.DispatchService, log *logger.Logger) *CRUDServer {
+	return &CRUDServer{crud: crud, dispatch: dispatch, log: log}
+}
+
+func (s *CRUDServer) CancelTrip(ctx context.Context, req *pb.CancelTripRequest) (*pb.CancelTripResponse, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	origin, err := parseOrigin(req.GetOrigin())
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := s.crud.CancelTrip(ctx, tripID, service.CancelTripInput{Reason: req.GetReason(), CancelledBy: by, Origin: origin})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CancelTripResponse{Charge: chargeToProto(charge)}, nil
+}
+
+func (s *CRUDServer) DeleteTrip(ctx context.Context, req *pb.DeleteTripRequest) (*pb.DeleteTripResponse, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.crud.DeleteTrip(ctx, tripID, by); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteTripResponse{}, nil
+}
+
+func (s *CRUDServer) GetTripWithDetails(ctx context.Context, req *pb.GetTripRequest) (*pb.Trip, error) {
+	tripID, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.crud.GetTripWithDetails(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *CRUDServer) UpdateStop(ctx context.Context, req *pb.UpdateStopRequest) (*pb.TripStop, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := parseID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+
+	input := service.UpdateStopInput{
+		AppointmentTime:   timeFrom(req.GetAppointmentTime()),
+		AppointmentNumber: req.AppointmentNumber,
+		Notes:             req.Notes,
+		Version:           int(req.GetVersion()),
+		UpdatedBy:         by,
+	}
+	if req.EstimatedDurationMinutes != nil {
+		mins := int(req.GetEstimatedDurationMinutes())
+		input.EstimatedDurationMins = &mins
+	}
+	if req.FreeTimeMinutes != nil {
+		mins := int(req.GetFreeTimeMinutes())
+		input.FreeTimeMins = &mins
+	}
+
+	stop, err := s.crud.UpdateStop(ctx, tripID, stopID, input)
+	if err != nil {
+		return nil, err
+	}
+	return stopToProto(stop), nil
+}
+
+func (s *CRUDServer) SkipStop(ctx context.Context, req *pb.SkipStopRequest) (*pb.TripStop, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := parseID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.GetReason()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	return skipStop(ctx, s.crud, tripID, stopID, req.GetReason(), by)
+}
+
+func (s *CRUDServer) GetTripsByDriver(ctx context.Context, req *pb.GetTripsByDriverRequest) (*pb.ListTripsResponse, error) {
+	driverID, err := parseID("driver_id", req.GetDriverId())
+	if err != nil {
+		return nil, err
+	}
+
+	trips, err := s.crud.GetTripsByDriver(ctx, driverID, timeFrom(req.GetDateFrom()), timeFrom(req.GetDateTo()))
+	if err != nil {
+		return nil, err
+	}
+	return tripList(trips), nil
+}
+
+func (s *CRUDServer) GetActiveTrips(ctx context.Context, req *pb.GetActiveTripsRequest) (*pb.ListTripsResponse, error) {
+	trips, err := s.crud.GetActiveTrips(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tripList(trips), nil
+}
+
+func (s *CRUDServer) GetUnassignedTrips(ctx context.Context, req *pb.GetUnassignedTripsRequest) (*pb.ListTripsResponse, error) {
+	trips, err := s.crud.GetUnassignedTrips(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tripList(trips), nil
+}
+
+func (s *CRUDServer) SearchTrips(ctx context.Context, req *pb.SearchTripsRequest) (*pb.ListTripsResponse, error) {
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+
+	trips, err := s.crud.SearchTrips(ctx, strings.TrimSpace(req.GetQuery()), int(req.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+	return tripList(trips), nil
+}
+
+func (s *CRUDServer) GetTripStatistics(ctx context.Context, req *pb.GetTripStatisticsRequest) (*pb.TripStatistics, error) {
+	if req.GetStartDate() == nil || req.GetEndDate() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start_date and end_date are required")
+	}
+	start, end := req.GetStartDate().AsTime(), req.GetEndDate().AsTime()
+	if end.Before(start) {
+		return nil, status.Error(codes.InvalidArgument, "end_date is before start_date")
+	}
+
+	stats, err := s.crud.GetTripStatistics(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return statisticsToProto(stats), nil
+}
+
+// BulkAssignDriver assigns a driver to every trip that is planned or
+// assigned, then reports per trip whether it now belongs to the driver.
+func (s *CRUDServer) BulkAssignDriver(ctx context.Context, req *pb.BulkAssignDriverRequest) (*pb.BulkAssignDriverResponse, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripIDs, err := parseTripIDs(req.GetTripIds())
+	if err != nil {
+		return nil, err
+	}
+	driverID, err := parseID("driver_id", req.GetDriverId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.crud.BulkAssignDriver(ctx, tripIDs, driverID, by); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.BulkAssignDriverResponse{}
+	for _, tripID := range tripIDs {
+		result := &pb.BulkTripResult{TripId: tripID.String()}
+		trip, err := s.crud.GetTripWithDetails(ctx, tripID)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case trip.DriverID == nil || *trip.DriverID != driverID || trip.Status != domain.TripStatusAssigned:
+			result.Error = "trip is " + string(trip.Status) + "; only planned or assigned trips can be assigned"
+		default:
+			result.Success = true
+		}
+		resp.Results = append(resp.Results, result)
+		if result.Success {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+// BulkCancelTrips cancels each trip on its own, so one trip that cannot be
+// cancelled does not hold up the rest.
+func (s *CRUDServer) BulkCancelTrips(ctx context.Context, req *pb.BulkCancelTripsRequest) (*pb.BulkCancelTripsResponse, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripIDs, err := parseTripIDs(req.GetTripIds())
+	if err != nil {
+		return nil, err
+	}
+	origin, err := parseOrigin(req.GetOrigin())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.BulkCancelTripsResponse{}
+	for _, tripID := range tripIDs {
+		result := &pb.BulkTripResult{TripId: tripID.String()}
+		charge, err := s.crud.CancelTrip(ctx, tripID, service.CancelTripInput{Reason: req.GetReason(), CancelledBy: by, Origin: origin})
+		if err != nil {
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			result.Success = true
+			result.Charge = chargeToProto(charge)
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	s.log.Infow("Bulk trip cancellation completed",
+		"trip_count", len(tripIDs),
+		"succeeded", resp.Succeeded,
+		"failed", resp.Failed,
+	)
+	return resp, nil
+}
+
+func (s *CRUDServer) GetTripStops(ctx context.Context, req *pb.GetTripStopsRequest) (*pb.GetTripStopsResponse, error) {
+	tripIDs, err := parseLookupIDs("trip_ids", req.GetTripIds())
+	if err != nil {
+		return nil, err
+	}
+
+	stops, err := s.dispatch.GetTripStops(ctx, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetTripStopsResponse{Stops: make([]*pb.TripStop, len(stops))}
+	for i := range stops {
+		resp.Stops[i] = stopToProto(&stops[i])
+	}
+	return resp, nil
+}
+
+func (s *CRUDServer) GetLocations(ctx context.Context, req *pb.GetLocationsRequest) (*pb.GetLocationsResponse, error) {
+	ids, err := parseLookupIDs("ids", req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.dispatch.GetLocations(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetLocationsResponse{Locations: make([]*pb.Location, len(locations))}
+	for i := range locations {
+		resp.Locations[i] = locationToProto(&locations[i])
+	}
+	return resp, nil
+}
+
+func (s *CRUDServer) GetDrivers(ctx context.Context, req *pb.GetDriversRequest) (*pb.GetDriversResponse, error) {
+	ids, err := parseLookupIDs("ids", req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+
+	drivers, err := s.dispatch.GetDrivers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetDriversResponse{Drivers: make([]*pb.Driver, len(drivers))}
+	for i := range drivers {
+		resp.Drivers[i] = driverToProto(&drivers[i])
+	}
+	return resp, nil
+}
+
+func tripList(trips []domain.Trip) *pb.ListTripsResponse {
+	return &pb.ListTripsResponse{
+		Trips:      tripsToProto(trips),
+		Total:      int32(len(trips)),
+		Page:       1,
+		PageSize:   int32(len(trips)),
+		TotalPages: 1,
+	}
+}
+
+func parseTripIDs(raw []string) ([]uuid.UUID, error) {
+	if len(raw) == 0 {
+		return nil, apperrors.ValidationError("trip_ids cannot be empty", "trip_ids", raw)
+	}
+	if len(raw) > maxBulkTrips {
+		return nil, apperrors.ValidationError("cannot change more than 50 trips at once", "trip_ids", len(raw))
+	}
+	ids := make([]uuid.UUID, len(raw))
+	for i, r := range raw {
+		id, err := parseID("trip_ids", r)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// parseLookupIDs parses the IDs of a batch lookup. The service caps how
+// many may be asked for at once.
+func parseLookupIDs(field string, raw []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(raw))
+	for i, r := range raw {
+		id, err := parseID(field, r)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func parseOrigin(raw string) (config.CancellationOrigin, error) {
+	switch origin := config.CancellationOrigin(strings.ToUpper(raw)); origin {
+	case config.CancellationByCustomer, config.CancellationByCarrier:
+		return origin, nil
+	default:
+		return "", apperrors.ValidationError("origin must be CUSTOMER or CARRIER", "origin", raw)
+	}
+}
@@ -0,0 +1,7 @@
+// Package grpc serves DispatchService, DispatchCRUDService and the chassis
+// RPCs over gRPC. It needs the Go code generated from shared/proto by
+// make proto, which is not checked in, so its files build only with the
+// grpc tag:
+//
+//	make proto && go build -tags grpc ./...
+package grpc
@@ -0,0 +1,146 @@
+//go:build grpc
+
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// UnaryInterceptors returns the interceptor chain for unary calls to both
+// dispatch services, outermost first. Calls are authenticated after they
+// are logged, so rejected calls show up too.
+func UnaryInterceptors(log *logger.Logger, authn *auth.Authenticator) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		LoggingInterceptor(log),
+		RecoveryInterceptor(log),
+		authn.UnaryInterceptor(),
+		ErrorInterceptor(log),
+	}
+}
+
+// StreamInterceptors returns the interceptor chain for streaming calls, in
+// the same order as UnaryInterceptors.
+func StreamInterceptors(log *logger.Logger, authn *auth.Authenticator) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		StreamLoggingInterceptor(log),
+		StreamRecoveryInterceptor(log),
+		authn.StreamInterceptor(),
+		StreamErrorInterceptor(log),
+	}
+}
+
+// LoggingInterceptor returns a gRPC unary interceptor that logs all requests.
+func LoggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor returns a gRPC stream interceptor that logs each
+// stream when it ends.
+func StreamLoggingInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(log *logger.Logger, method string, start time.Time, err error) {
+	duration := time.Since(start)
+	if err != nil && status.Code(err) == codes.Internal {
+		log.Errorw("gRPC request failed",
+			"method", method,
+			"duration_ms", duration.Milliseconds(),
+			"error", err,
+		)
+		return
+	}
+	if err != nil {
+		log.Infow("gRPC request rejected",
+			"method", method,
+			"duration_ms", duration.Milliseconds(),
+			"code", status.Code(err).String(),
+			"error", err,
+		)
+		return
+	}
+	log.Infow("gRPC request completed",
+		"method", method,
+		"duration_ms", duration.Milliseconds(),
+	)
+}
+
+// RecoveryInterceptor returns a gRPC unary interceptor that recovers from panics.
+func RecoveryInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(log, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor returns a gRPC stream interceptor that recovers
+// from panics.
+func StreamRecoveryInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(log, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func logPanic(log *logger.Logger, method string, r interface{}) {
+	log.Errorw("Panic recovered in gRPC handler",
+		"method", method,
+		"panic", r,
+		"stack", string(debug.Stack()),
+	)
+}
+
+// ErrorInterceptor returns a gRPC unary interceptor that turns service
+// errors into gRPC status errors, so handlers can return them unchanged.
+// Internal errors are logged with their cause, which the caller never sees.
+func ErrorInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, translateError(log, info.FullMethod, err)
+	}
+}
+
+// StreamErrorInterceptor is ErrorInterceptor for streaming calls.
+func StreamErrorInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return translateError(log, info.FullMethod, handler(srv, ss))
+	}
+}
+
+func translateError(log *logger.Logger, method string, err error) error {
+	st := grpcerrors.ToStatus(err)
+	if st != nil && st != err && status.Code(st) == codes.Internal {
+		log.Errorw("Dispatch request failed", "method", method, "error", err)
+	}
+	return st
+}
@@ -0,0 +1,624 @@
+//go:build grpc
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/draymaster/shared/proto/dispatch/v1"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/services/dispatch-service/internal/service"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// UserMetadataKey is the gRPC metadata key carrying the authenticated ops
+// user ID, the counterpart of the REST API's X-User-ID header.
+const UserMetadataKey = "x-user-id"
+
+// Dispatch board streams refresh every defaultBoardRefresh unless the caller
+// asks otherwise, and never more often than minBoardRefresh.
+const (
+	defaultBoardRefresh = 15 * time.Second
+	minBoardRefresh     = 5 * time.Second
+)
+
+// Server implements the DispatchService gRPC API.
+type Server struct {
+	pb.UnimplementedDispatchServiceServer
+	dispatch *service.DispatchService
+	crud     *service.DispatchCRUDService
+	duals    *service.DualTransactionMatcher
+	log      *logger.Logger
+}
+
+// NewServer creates a new DispatchService gRPC server. Listing and editing
+// trips is shared with the CRUD service.
+func NewServer(dispatch *service.DispatchService, crud *service.DispatchCRUDService, duals *service.DualTransactionMatcher, log *logger.Logger) *Server {
+	return &Server{dispatch: dispatch, crud: crud, duals: duals, log: log}
+}
+
+// Register registers the dispatch gRPC services on a server.
+func Register(s *grpc.Server, dispatch *Server, crud *CRUDServer, chassis *ChassisServer) {
+	pb.RegisterDispatchServiceServer(s, dispatch)
+	pb.RegisterDispatchCRUDServiceServer(s, crud)
+	pb.RegisterChassisServiceServer(s, chassis)
+}
+
+func (s *Server) CreateTrip(ctx context.Context, req *pb.CreateTripRequest) (*pb.Trip, error) {
+	createdBy, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetType() == pb.TripType_TRIP_TYPE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "type is required")
+	}
+	if len(req.GetStops()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one stop is required")
+	}
+
+	input := service.CreateTripInput{
+		Type:             tripTypeFromProto(req.GetType()),
+		PlannedStartTime: timeFrom(req.GetPlannedStartTime()),
+		Tags:             req.GetTags(),
+		CreatedBy:        createdBy,
+	}
+	for _, in := range req.GetStops() {
+		stop, err := stopInputFromProto(in)
+		if err != nil {
+			return nil, err
+		}
+		input.Stops = append(input.Stops, stop)
+	}
+	for _, raw := range req.GetOrderIds() {
+		orderID, err := parseID("order_ids", raw)
+		if err != nil {
+			return nil, err
+		}
+		input.OrderIDs = append(input.OrderIDs, orderID)
+	}
+	if input.DriverID, err = parseOptionalID("driver_id", req.GetDriverId()); err != nil {
+		return nil, err
+	}
+	if input.TractorID, err = parseOptionalID("tractor_id", req.GetTractorId()); err != nil {
+		return nil, err
+	}
+
+	trip, err := s.dispatch.CreateTrip(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *Server) GetTrip(ctx context.Context, req *pb.GetTripRequest) (*pb.Trip, error) {
+	if req.GetId() == "" && req.GetTripNumber() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id or trip_number is required")
+	}
+
+	if req.GetId() == "" {
+		trips, err := s.dispatch.LookupTrips(ctx, service.TripLookup{TripNumber: req.GetTripNumber()})
+		if err != nil {
+			return nil, err
+		}
+		if len(trips) == 0 {
+			return nil, apperrors.NotFoundError("trip", req.GetTripNumber())
+		}
+		return tripToProto(&trips[0]), nil
+	}
+
+	tripID, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	trip, err := s.crud.GetTripWithDetails(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *Server) ListTrips(ctx context.Context, req *pb.ListTripsRequest) (*pb.ListTripsResponse, error) {
+	if req.GetCustomerId() != "" {
+		return nil, status.Error(codes.InvalidArgument, "trips cannot be listed by customer_id; list the customer's orders instead")
+	}
+
+	filter := service.ListTripsFilter{
+		TripNumber:    req.GetTripNumber(),
+		PlannedAfter:  timeFrom(req.GetDateFrom()),
+		PlannedBefore: timeFrom(req.GetDateTo()),
+		Page:          int(req.GetPage()),
+		PageSize:      int(req.GetPageSize()),
+		SortBy:        req.GetSortBy(),
+		SortOrder:     req.GetSortOrder(),
+		Tags:          req.GetTags(),
+		MatchAllTags:  req.GetMatchAllTags(),
+	}
+	if req.GetStatus() != pb.TripStatus_TRIP_STATUS_UNSPECIFIED {
+		filter.Status = []domain.TripStatus{tripStatusFromProto(req.GetStatus())}
+	}
+	if req.GetType() != pb.TripType_TRIP_TYPE_UNSPECIFIED {
+		filter.Type = []domain.TripType{tripTypeFromProto(req.GetType())}
+	}
+	var err error
+	if filter.DriverID, err = parseOptionalID("driver_id", req.GetDriverId()); err != nil {
+		return nil, err
+	}
+
+	result, err := s.crud.ListTrips(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListTripsResponse{
+		Trips:      tripsToProto(result.Trips),
+		Total:      int32(result.Total),
+		Page:       int32(result.Page),
+		PageSize:   int32(result.PageSize),
+		TotalPages: int32(result.TotalPages),
+	}, nil
+}
+
+func (s *Server) UpdateTrip(ctx context.Context, req *pb.UpdateTripRequest) (*pb.Trip, error) {
+	updatedBy, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetStops()) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "stops cannot be replaced; use UpdateStop or SkipStop")
+	}
+
+	input := service.UpdateTripInput{
+		PlannedStartTime: timeFrom(req.GetPlannedStartTime()),
+		Version:          int(req.GetVersion()),
+		UpdatedBy:        updatedBy,
+	}
+	if input.DriverID, err = parseOptionalID("driver_id", req.GetDriverId()); err != nil {
+		return nil, err
+	}
+	if input.TractorID, err = parseOptionalID("tractor_id", req.GetTractorId()); err != nil {
+		return nil, err
+	}
+
+	trip, err := s.crud.UpdateTrip(ctx, tripID, input)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+// UpdateTripStatus moves a trip to a status a dispatcher can set directly:
+// DISPATCHED or CANCELLED. Every other status follows from the stops.
+func (s *Server) UpdateTripStatus(ctx context.Context, req *pb.UpdateTripStatusRequest) (*pb.Trip, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GetStatus() {
+	case pb.TripStatus_TRIP_STATUS_DISPATCHED:
+		trip, err := s.dispatch.DispatchTrip(ctx, tripID)
+		if err != nil {
+			return nil, err
+		}
+		return tripToProto(trip), nil
+	case pb.TripStatus_TRIP_STATUS_CANCELLED:
+		if _, err := s.crud.CancelTrip(ctx, tripID, service.CancelTripInput{
+			Reason:      req.GetReason(),
+			CancelledBy: by,
+			Origin:      config.CancellationByCarrier,
+		}); err != nil {
+			return nil, err
+		}
+		trip, err := s.crud.GetTripWithDetails(ctx, tripID)
+		if err != nil {
+			return nil, err
+		}
+		return tripToProto(trip), nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "status %s cannot be set directly", req.GetStatus())
+	}
+}
+
+// UpdateStopStatus moves a stop to ARRIVED, COMPLETED or SKIPPED, the
+// statuses a dispatcher can record on the driver's behalf.
+func (s *Server) UpdateStopStatus(ctx context.Context, req *pb.UpdateStopStatusRequest) (*pb.TripStop, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := parseID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GetStatus() {
+	case pb.StopStatus_STOP_STATUS_ARRIVED:
+		stop, err := s.dispatch.RecordStopArrival(ctx, tripID, stopID, time.Now(), 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return stopToProto(stop), nil
+	case pb.StopStatus_STOP_STATUS_COMPLETED:
+		stop, err := s.dispatch.CompleteStop(ctx, service.CompleteStopInput{
+			TripID:        tripID,
+			StopID:        stopID,
+			DepartureTime: time.Now(),
+			Notes:         req.GetNotes(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return stopToProto(stop), nil
+	case pb.StopStatus_STOP_STATUS_SKIPPED:
+		return skipStop(ctx, s.crud, tripID, stopID, req.GetNotes(), by)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "status %s cannot be set directly", req.GetStatus())
+	}
+}
+
+func (s *Server) RecordStopArrival(ctx context.Context, req *pb.RecordStopArrivalRequest) (*pb.TripStop, error) {
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := parseID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+	arrival := time.Now()
+	if req.GetArrivalTime() != nil {
+		arrival = req.GetArrivalTime().AsTime()
+	}
+
+	stop, err := s.dispatch.RecordStopArrival(ctx, tripID, stopID, arrival, req.GetLatitude(), req.GetLongitude())
+	if err != nil {
+		return nil, err
+	}
+	return stopToProto(stop), nil
+}
+
+func (s *Server) CompleteStop(ctx context.Context, req *pb.CompleteStopRequest) (*pb.TripStop, error) {
+	input := service.CompleteStopInput{
+		DepartureTime:    time.Now(),
+		GateTicketNumber: req.GetGateTicketNumber(),
+		SealNumber:       req.GetSealNumber(),
+		ContainerNumber:  req.GetContainerNumber(),
+		DocumentIDs:      req.GetDocumentIds(),
+		Notes:            req.GetNotes(),
+	}
+	var err error
+	if input.TripID, err = parseID("trip_id", req.GetTripId()); err != nil {
+		return nil, err
+	}
+	if input.StopID, err = parseID("stop_id", req.GetStopId()); err != nil {
+		return nil, err
+	}
+	if input.ChassisID, err = parseOptionalID("chassis_id", req.GetChassisId()); err != nil {
+		return nil, err
+	}
+	if req.GetDepartureTime() != nil {
+		input.DepartureTime = req.GetDepartureTime().AsTime()
+	}
+
+	stop, err := s.dispatch.CompleteStop(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return stopToProto(stop), nil
+}
+
+func (s *Server) AssignDriver(ctx context.Context, req *pb.AssignDriverRequest) (*pb.Trip, error) {
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	driverID, err := parseID("driver_id", req.GetDriverId())
+	if err != nil {
+		return nil, err
+	}
+	tractorID, err := parseOptionalID("tractor_id", req.GetTractorId())
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.dispatch.AssignDriver(ctx, tripID, driverID, tractorID)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *Server) UnassignDriver(ctx context.Context, req *pb.UnassignDriverRequest) (*pb.Trip, error) {
+	by, err := user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+
+	none := uuid.Nil
+	trip, err := s.crud.UpdateTrip(ctx, tripID, service.UpdateTripInput{DriverID: &none, UpdatedBy: by})
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("Driver unassigned", "trip_id", tripID, "reason", req.GetReason(), "unassigned_by", by)
+	return tripToProto(trip), nil
+}
+
+func (s *Server) DispatchTrip(ctx context.Context, req *pb.DispatchTripRequest) (*pb.Trip, error) {
+	tripID, err := parseID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.dispatch.DispatchTrip(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *Server) FindStreetTurnOpportunities(ctx context.Context, req *pb.FindStreetTurnRequest) (*pb.FindStreetTurnResponse, error) {
+	filter := repository.StreetTurnFilter{
+		ContainerSize:    req.GetContainerSize(),
+		MaxDistanceMiles: int(req.GetMaxDistanceMiles()),
+		MaxResults:       int(req.GetMaxResults()),
+	}
+	var err error
+	if filter.ImportOrderID, err = parseOptionalID("import_order_id", req.GetImportOrderId()); err != nil {
+		return nil, err
+	}
+	if filter.ExportOrderID, err = parseOptionalID("export_order_id", req.GetExportOrderId()); err != nil {
+		return nil, err
+	}
+	if filter.SteamshipLineID, err = parseOptionalID("steamship_line_id", req.GetSteamshipLineId()); err != nil {
+		return nil, err
+	}
+
+	opportunities, err := s.dispatch.FindStreetTurnOpportunities(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.FindStreetTurnResponse{Opportunities: make([]*pb.StreetTurnOpportunity, len(opportunities))}
+	for i := range opportunities {
+		resp.Opportunities[i] = streetTurnToProto(&opportunities[i])
+	}
+	return resp, nil
+}
+
+func (s *Server) FindDualTransactionOpportunities(ctx context.Context, req *pb.FindDualTransactionRequest) (*pb.FindDualTransactionResponse, error) {
+	filter := service.DualTransactionFilter{
+		WindowMins: int(req.GetWindowMins()),
+		MaxResults: int(req.GetMaxResults()),
+	}
+	var err error
+	if filter.TerminalID, err = parseOptionalID("terminal_id", req.GetTerminalId()); err != nil {
+		return nil, err
+	}
+	if from := timeFrom(req.GetFrom()); from != nil {
+		filter.From = *from
+	}
+	if to := timeFrom(req.GetTo()); to != nil {
+		filter.To = *to
+	}
+
+	opportunities, err := s.duals.FindOpportunities(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.FindDualTransactionResponse{Opportunities: make([]*pb.DualTransactionOpportunity, len(opportunities))}
+	for i := range opportunities {
+		resp.Opportunities[i] = dualTransactionToProto(&opportunities[i])
+	}
+	return resp, nil
+}
+
+func (s *Server) CreateStreetTurn(ctx context.Context, req *pb.CreateStreetTurnRequest) (*pb.Trip, error) {
+	importID, err := parseID("import_order_id", req.GetImportOrderId())
+	if err != nil {
+		return nil, err
+	}
+	exportID, err := parseID("export_order_id", req.GetExportOrderId())
+	if err != nil {
+		return nil, err
+	}
+	driverID, err := parseOptionalID("driver_id", req.GetDriverId())
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.dispatch.CreateStreetTurn(ctx, importID, exportID, driverID, timeFrom(req.GetPlannedStartTime()))
+	if err != nil {
+		return nil, err
+	}
+	return tripToProto(trip), nil
+}
+
+func (s *Server) GetDispatchBoard(ctx context.Context, req *pb.GetDispatchBoardRequest) (*pb.DispatchBoard, error) {
+	board, err := s.dispatch.GetDispatchBoard(ctx, boardDate(req.GetDate()))
+	if err != nil {
+		return nil, err
+	}
+	return boardToProto(board), nil
+}
+
+// StreamDispatchBoard sends the board for a day straight away and again
+// whenever a trip on it is added, removed or changed, checking every
+// refresh interval until the caller goes away.
+func (s *Server) StreamDispatchBoard(req *pb.StreamDispatchBoardRequest, stream pb.DispatchService_StreamDispatchBoardServer) error {
+	ctx := stream.Context()
+	date := boardDate(req.GetDate())
+	interval := defaultBoardRefresh
+	if req.GetRefreshIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetRefreshIntervalSeconds()) * time.Second
+	}
+	if interval < minBoardRefresh {
+		interval = minBoardRefresh
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		board, err := s.dispatch.GetDispatchBoard(ctx, date)
+		if err != nil {
+			return err
+		}
+		if key := boardKey(board); key != last {
+			if err := stream.Send(boardToProto(board)); err != nil {
+				return err
+			}
+			last = key
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) GetDriverAvailability(ctx context.Context, req *pb.GetDriverAvailabilityRequest) (*pb.GetDriverAvailabilityResponse, error) {
+	if req.GetPickupLatitude() == 0 && req.GetPickupLongitude() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "pickup_latitude and pickup_longitude are required")
+	}
+	terminalID, err := parseOptionalID("terminal_id", req.GetTerminalId())
+	if err != nil {
+		return nil, err
+	}
+
+	drivers, err := s.dispatch.GetDriverAvailability(ctx,
+		req.GetPickupLatitude(),
+		req.GetPickupLongitude(),
+		int(req.GetRequiredDriveMinutes()),
+		req.GetRequireTwic(),
+		terminalID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.GetDriverAvailabilityResponse{Drivers: make([]*pb.DriverAvailability, len(drivers))}
+	for i := range drivers {
+		resp.Drivers[i] = availabilityToProto(&drivers[i])
+	}
+	return resp, nil
+}
+
+func (s *Server) GetTripCost(ctx context.Context, req *pb.GetTripRequest) (*pb.TripCostAccrual, error) {
+	tripID, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	cost, err := s.dispatch.GetTripCost(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	return costAccrualToProto(cost), nil
+}
+
+// boardDate is the requested board day, today when none is given
+func boardDate(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Now()
+	}
+	return ts.AsTime()
+}
+
+// boardKey identifies a board's contents: every trip on it with its
+// version and margin level, so any edit, status change, reassignment or
+// margin moving between levels changes the key
+func boardKey(b *domain.DispatchBoard) string {
+	var keys []string
+	for _, column := range [][]domain.Trip{b.Unassigned, b.Assigned, b.Dispatched, b.InProgress, b.Completed, b.Failed} {
+		for _, trip := range column {
+			key := fmt.Sprintf("%s/%d/%s", trip.ID, trip.Version, trip.Status)
+			if trip.CostAccrual != nil {
+				key += "/" + string(trip.CostAccrual.Level)
+			}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// skipStop skips a stop and returns it as it now stands
+func skipStop(ctx context.Context, crud *service.DispatchCRUDService, tripID, stopID uuid.UUID, reason, by string) (*pb.TripStop, error) {
+	if err := crud.SkipStop(ctx, tripID, stopID, reason, by); err != nil {
+		return nil, err
+	}
+	trip, err := crud.GetTripWithDetails(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trip.Stops {
+		if trip.Stops[i].ID == stopID {
+			return stopToProto(&trip.Stops[i]), nil
+		}
+	}
+	return nil, apperrors.NotFoundError("stop", stopID.String())
+}
+
+// user is the ops user the call is made on behalf of
+func user(ctx context.Context) (string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(UserMetadataKey); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			return strings.TrimSpace(values[0]), nil
+		}
+	}
+	return "", apperrors.New("UNAUTHORIZED", "missing user identity")
+}
+
+func parseID(field, raw string) (uuid.UUID, error) {
+	if raw == "" {
+		return uuid.Nil, apperrors.ValidationError(field+" is required", field, nil)
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, apperrors.ValidationError("invalid "+field, field, raw)
+	}
+	return id, nil
+}
+
+// parseOptionalID parses an ID that may be left empty, which gives nil
+func parseOptionalID(field, raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := parseID(field, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+)
+
+// PostgresAutoDispatchRepository implements AutoDispatchRepository using PostgreSQL
+type PostgresAutoDispatchRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAutoDispatchRepository creates a new PostgreSQL auto-dispatch repository
+func NewPostgresAutoDispatchRepository(pool *pgxpool.Pool) *PostgresAutoDispatchRepository {
+	return &PostgresAutoDispatchRepository{pool: pool}
+}
+
+const autoDispatchConfigColumns = `terminal_id, enabled, COALESCE(window_start, ''), COALESCE(window_end, ''),
+	lookahead_mins, COALESCE(max_distance_miles, 0), override_mins, COALESCE(updated_by, ''), updated_at`
+
+const autoDispatchDecisionColumns = `id, trip_id, trip_number, terminal_id, driver_id, COALESCE(driver_name, ''),
+	status, reason, COALESCE(distance_miles, 0), COALESCE(eta_to_pickup_mins, 0), sla_deadline,
+	COALESCE(slack_mins, 0), override_deadline, COALESCE(overridden_by, ''), COALESCE(override_reason, ''),
+	created_at, resolved_at`
+
+// GetConfig retrieves a terminal's auto-dispatch configuration
+func (r *PostgresAutoDispatchRepository) GetConfig(ctx context.Context, terminalID uuid.UUID) (*domain.AutoDispatchConfig, error) {
+	cfg, err := scanAutoDispatchConfig(conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+autoDispatchConfigColumns+` FROM auto_dispatch_configs WHERE terminal_id = $1`, terminalID,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("auto-dispatch config not found: %s", terminalID)
+		}
+		return nil, fmt.Errorf("failed to get auto-dispatch config: %w", err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig creates or replaces a terminal's auto-dispatch configuration
+func (r *PostgresAutoDispatchRepository) SaveConfig(ctx context.Context, cfg *domain.AutoDispatchConfig) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO auto_dispatch_configs (terminal_id, enabled, window_start, window_end,
+		     lookahead_mins, max_distance_miles, override_mins, updated_by, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (terminal_id) DO UPDATE
+		 SET enabled = EXCLUDED.enabled, window_start = EXCLUDED.window_start, window_end = EXCLUDED.window_end,
+		     lookahead_mins = EXCLUDED.lookahead_mins, max_distance_miles = EXCLUDED.max_distance_miles,
+		     override_mins = EXCLUDED.override_mins, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		cfg.TerminalID, cfg.Enabled, cfg.WindowStart, cfg.WindowEnd,
+		cfg.LookaheadMins, cfg.MaxDistanceMiles, cfg.OverrideMins, cfg.UpdatedBy, cfg.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save auto-dispatch config: %w", err)
+	}
+	return nil
+}
+
+// ListEnabledConfigs retrieves the configuration of every terminal with auto-dispatch on
+func (r *PostgresAutoDispatchRepository) ListEnabledConfigs(ctx context.Context) ([]domain.AutoDispatchConfig, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+autoDispatchConfigColumns+` FROM auto_dispatch_configs WHERE enabled ORDER BY terminal_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auto-dispatch configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []domain.AutoDispatchConfig
+	for rows.Next() {
+		cfg, err := scanAutoDispatchConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan auto-dispatch config: %w", err)
+		}
+		configs = append(configs, *cfg)
+	}
+	return configs, rows.Err()
+}
+
+// CreateDecision records an auto-dispatch proposal or skip
+func (r *PostgresAutoDispatchRepository) CreateDecision(ctx context.Context, d *domain.AutoDispatchDecision) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO auto_dispatch_decisions (id, trip_id, trip_number, terminal_id, driver_id, driver_name,
+		     status, reason, distance_miles, eta_to_pickup_mins, sla_deadline, slack_mins,
+		     override_deadline, overridden_by, override_reason, created_at, resolved_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		d.ID, d.TripID, d.TripNumber, d.TerminalID, d.DriverID, d.DriverName,
+		string(d.Status), d.Reason, d.DistanceMiles, d.ETAToPickupMins, d.SLADeadline, d.SlackMins,
+		d.OverrideDeadline, d.OverriddenBy, d.OverrideReason, d.CreatedAt, d.ResolvedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create auto-dispatch decision: %w", err)
+	}
+	return nil
+}
+
+// UpdateDecision saves a decision's outcome
+func (r *PostgresAutoDispatchRepository) UpdateDecision(ctx context.Context, d *domain.AutoDispatchDecision) error {
+	result, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE auto_dispatch_decisions
+		 SET status = $2, reason = $3, overridden_by = $4, override_reason = $5, resolved_at = $6
+		 WHERE id = $1`,
+		d.ID, string(d.Status), d.Reason, d.OverriddenBy, d.OverrideReason, d.ResolvedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update auto-dispatch decision: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("auto-dispatch decision not found: %s", d.ID)
+	}
+	return nil
+}
+
+// GetDecision retrieves a decision by ID
+func (r *PostgresAutoDispatchRepository) GetDecision(ctx context.Context, id uuid.UUID) (*domain.AutoDispatchDecision, error) {
+	d, err := scanAutoDispatchDecision(conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+autoDispatchDecisionColumns+` FROM auto_dispatch_decisions WHERE id = $1`, id,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("auto-dispatch decision not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get auto-dispatch decision: %w", err)
+	}
+	return d, nil
+}
+
+// GetLatestDecision retrieves the trip's most recent decision, or nil if it has none
+func (r *PostgresAutoDispatchRepository) GetLatestDecision(ctx context.Context, tripID uuid.UUID) (*domain.AutoDispatchDecision, error) {
+	d, err := scanAutoDispatchDecision(conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+autoDispatchDecisionColumns+` FROM auto_dispatch_decisions
+		 WHERE trip_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT 1`,
+		tripID,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest auto-dispatch decision: %w", err)
+	}
+	return d, nil
+}
+
+// GetPendingDecisions retrieves the proposals still open to override
+func (r *PostgresAutoDispatchRepository) GetPendingDecisions(ctx context.Context) ([]domain.AutoDispatchDecision, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+autoDispatchDecisionColumns+` FROM auto_dispatch_decisions
+		 WHERE status = $1
+		 ORDER BY override_deadline`,
+		string(domain.AutoDispatchStatusPending),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending auto-dispatch decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var decisions []domain.AutoDispatchDecision
+	for rows.Next() {
+		d, err := scanAutoDispatchDecision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan auto-dispatch decision: %w", err)
+		}
+		decisions = append(decisions, *d)
+	}
+	return decisions, rows.Err()
+}
+
+func scanAutoDispatchConfig(row pgx.Row) (*domain.AutoDispatchConfig, error) {
+	var c domain.AutoDispatchConfig
+	if err := row.Scan(
+		&c.TerminalID, &c.Enabled, &c.WindowStart, &c.WindowEnd,
+		&c.LookaheadMins, &c.MaxDistanceMiles, &c.OverrideMins, &c.UpdatedBy, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanAutoDispatchDecision(row pgx.Row) (*domain.AutoDispatchDecision, error) {
+	var d domain.AutoDispatchDecision
+	var status string
+	if err := row.Scan(
+		&d.ID, &d.TripID, &d.TripNumber, &d.TerminalID, &d.DriverID, &d.DriverName,
+		&status, &d.Reason, &d.DistanceMiles, &d.ETAToPickupMins, &d.SLADeadline,
+		&d.SlackMins, &d.OverrideDeadline, &d.OverriddenBy, &d.OverrideReason,
+		&d.CreatedAt, &d.ResolvedAt,
+	); err != nil {
+		return nil, err
+	}
+	d.Status = domain.AutoDispatchStatus(status)
+	return &d, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+)
+
+// PostgresDriverRepository implements DriverAvailabilityRepository using
+// PostgreSQL, reading the drivers driver-service maintains. Phone numbers
+// are not loaded.
+type PostgresDriverRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresDriverRepository creates a new PostgreSQL driver repository
+func NewPostgresDriverRepository(pool *pgxpool.Pool) *PostgresDriverRepository {
+	return &PostgresDriverRepository{pool: pool}
+}
+
+const driverSelect = `SELECT d.id, d.first_name || ' ' || d.last_name, d.status,
+	COALESCE(d.current_latitude, 0), COALESCE(d.current_longitude, 0),
+	COALESCE(d.available_drive_mins, 0), COALESCE(d.available_duty_mins, 0), COALESCE(d.available_cycle_mins, 0),
+	d.last_hos_update, COALESCE(d.has_twic, FALSE), COALESCE(d.has_hazmat_endorsement, FALSE),
+	d.license_expiration, d.medical_card_expiration, d.twic_expiration, d.hazmat_expiration,
+	COALESCE(c.hold, ''), c.covered_until
+	FROM drivers d
+	LEFT JOIN owner_operator_carriers c ON c.driver_id = d.id`
+
+// GetByID retrieves a driver by ID
+func (r *PostgresDriverRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Driver, error) {
+	driver, err := scanDriver(conn(ctx, r.pool).QueryRow(ctx, driverSelect+` WHERE d.id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("driver not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get driver: %w", err)
+	}
+	return driver, nil
+}
+
+// GetAvailable retrieves active drivers who are available or on duty,
+// leaving out those on approved time off today
+func (r *PostgresDriverRepository) GetAvailable(ctx context.Context) ([]domain.Driver, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		driverSelect+`
+		 WHERE d.status IN ('AVAILABLE', 'ON_DUTY')
+		   AND d.termination_date IS NULL
+		   AND COALESCE(d.is_active, TRUE)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM driver_time_off t
+		       WHERE t.driver_id = d.id AND t.status = 'APPROVED'
+		         AND CURRENT_DATE BETWEEN t.start_date AND t.end_date
+		   )
+		 ORDER BY d.last_name, d.first_name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available drivers: %w", err)
+	}
+	defer rows.Close()
+
+	var drivers []domain.Driver
+	for rows.Next() {
+		driver, err := scanDriver(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan driver: %w", err)
+		}
+		drivers = append(drivers, *driver)
+	}
+	return drivers, rows.Err()
+}
+
+func scanDriver(row pgx.Row) (*domain.Driver, error) {
+	var d domain.Driver
+	if err := row.Scan(
+		&d.ID, &d.Name, &d.Status, &d.CurrentLatitude, &d.CurrentLongitude,
+		&d.AvailableDriveMins, &d.AvailableDutyMins, &d.AvailableCycleMins,
+		&d.LastHOSUpdate, &d.HasTWIC, &d.HasHazmatEndorsement,
+		&d.LicenseExpiration, &d.MedicalCardExpiration, &d.TWICExpiration, &d.HazmatExpiration,
+		&d.CarrierHold, &d.CarrierCoveredUntil,
+	); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+)
+
+// PostgresHOSSnapshotRepository implements HOSSnapshotRepository using PostgreSQL
+type PostgresHOSSnapshotRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresHOSSnapshotRepository creates a new PostgreSQL HOS snapshot repository
+func NewPostgresHOSSnapshotRepository(pool *pgxpool.Pool) *PostgresHOSSnapshotRepository {
+	return &PostgresHOSSnapshotRepository{pool: pool}
+}
+
+const hosSnapshotColumns = `id, trip_id, driver_id, event, drive_remaining_mins, duty_remaining_mins,
+	cycle_remaining_mins, clocks_as_of, needs_break, mins_until_break, trip_duration_mins,
+	planned_start_time, COALESCE(taken_by, ''), taken_at`
+
+// Create records a snapshot
+func (r *PostgresHOSSnapshotRepository) Create(ctx context.Context, s *domain.HOSSnapshot) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO trip_hos_snapshots (id, trip_id, driver_id, event, drive_remaining_mins, duty_remaining_mins,
+		     cycle_remaining_mins, clocks_as_of, needs_break, mins_until_break, trip_duration_mins,
+		     planned_start_time, taken_by, taken_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		s.ID, s.TripID, s.DriverID, string(s.Event), s.DriveRemainingMins, s.DutyRemainingMins,
+		s.CycleRemainingMins, s.ClocksAsOf, s.NeedsBreak, s.MinsUntilBreak, s.TripDurationMins,
+		s.PlannedStartTime, s.TakenBy, s.TakenAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create HOS snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetByTripID retrieves a trip's snapshots, oldest first
+func (r *PostgresHOSSnapshotRepository) GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.HOSSnapshot, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+hosSnapshotColumns+` FROM trip_hos_snapshots WHERE trip_id = $1 ORDER BY taken_at`, tripID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HOS snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []domain.HOSSnapshot
+	for rows.Next() {
+		s, err := scanHOSSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan HOS snapshot: %w", err)
+		}
+		snapshots = append(snapshots, *s)
+	}
+	return snapshots, rows.Err()
+}
+
+func scanHOSSnapshot(row pgx.Row) (*domain.HOSSnapshot, error) {
+	var s domain.HOSSnapshot
+	var event string
+	if err := row.Scan(
+		&s.ID, &s.TripID, &s.DriverID, &event, &s.DriveRemainingMins, &s.DutyRemainingMins,
+		&s.CycleRemainingMins, &s.ClocksAsOf, &s.NeedsBreak, &s.MinsUntilBreak, &s.TripDurationMins,
+		&s.PlannedStartTime, &s.TakenBy, &s.TakenAt,
+	); err != nil {
+		return nil, err
+	}
+	s.Event = domain.HOSSnapshotEvent(event)
+	return &s, nil
+}
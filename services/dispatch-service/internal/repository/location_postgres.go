@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+)
+
+// PostgresLocationRepository implements LocationLookupRepository using PostgreSQL
+type PostgresLocationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresLocationRepository creates a new PostgreSQL location repository
+func NewPostgresLocationRepository(pool *pgxpool.Pool) *PostgresLocationRepository {
+	return &PostgresLocationRepository{pool: pool}
+}
+
+// Polygon geofences have no radius, so only circles contribute one
+const locationSelect = `SELECT l.id, l.name, l.type, COALESCE(l.address, ''), COALESCE(l.city, ''),
+	COALESCE(l.state, ''), COALESCE(l.zip, ''), COALESCE(l.latitude, 0), COALESCE(l.longitude, 0),
+	COALESCE(l.contact_name, ''), COALESCE(l.contact_phone, ''), l.geofence_id, COALESCE(g.radius_meters, 0)
+	FROM locations l
+	LEFT JOIN geofences g ON g.id = l.geofence_id AND g.type = 'circle'`
+
+// GetByID retrieves a location by ID
+func (r *PostgresLocationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Location, error) {
+	location, err := scanLocation(conn(ctx, r.pool).QueryRow(ctx, locationSelect+` WHERE l.id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("location not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	return location, nil
+}
+
+// GetByIDs retrieves locations by ID, leaving out unknown IDs
+func (r *PostgresLocationRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Location, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := conn(ctx, r.pool).Query(ctx, locationSelect+` WHERE l.id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []domain.Location
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, *location)
+	}
+	return locations, rows.Err()
+}
+
+func scanLocation(row pgx.Row) (*domain.Location, error) {
+	var l domain.Location
+	if err := row.Scan(
+		&l.ID, &l.Name, &l.Type, &l.Address, &l.City, &l.State, &l.Zip, &l.Latitude, &l.Longitude,
+		&l.ContactName, &l.ContactPhone, &l.GeofenceID, &l.GeofenceRadiusMeters,
+	); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
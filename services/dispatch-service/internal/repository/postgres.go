@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// querier is the subset of pgxpool.Pool and pgx.Tx used by the repositories
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// conn returns the transaction carried by ctx, or the pool
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+	if tx, ok := database.TxFromContext(ctx); ok {
+		return tx
+	}
+	return pool
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/shared/pkg/config"
 )
 
 // TripFilter contains filter criteria for listing trips
@@ -14,13 +15,19 @@ type TripFilter struct {
 	Status            []domain.TripStatus
 	Type              []domain.TripType
 	DriverID          *uuid.UUID
+	DispatcherID      *uuid.UUID
+	NoDispatcher      bool // Only trips without a dispatcher
 	TripNumber        string
+	ContainerNumber   string     // Trips with a stop for this container
+	OrderID           *uuid.UUID // Trips with a stop for this order
 	PlannedAfter      *time.Time
 	PlannedBefore     *time.Time
 	CompletedAfter    *time.Time
 	CompletedBefore   *time.Time
 	IsStreetTurn      *bool
 	IsDualTransaction *bool
+	Tags              []string // Trips with any of these tags
+	MatchAllTags      bool     // Trips with every one of Tags instead
 	Page              int
 	PageSize          int
 	SortBy            string
@@ -37,38 +44,378 @@ type StreetTurnFilter struct {
 	MaxResults      int
 }
 
-// TripRepository defines the interface for trip data access
+// TripRepository defines the interface for trip data access.
+// Create starts a trip at version 1. Update only applies when trip.Version matches the stored row, bumps the
+// version, and returns database.ErrVersionConflict otherwise. Queries run on
+// the transaction carried by ctx (database.WithTx) when there is one.
 type TripRepository interface {
+	TripAssignmentRepository
 	Create(ctx context.Context, trip *domain.Trip) error
-	GetByID(ctx context.Context, id uuid.UUID) (*domain.Trip, error)
-	Update(ctx context.Context, trip *domain.Trip) error
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Trip, error) // SELECT ... FOR UPDATE, requires a transaction
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetNextTripNumber(ctx context.Context) (string, error)
 	FindStreetTurnMatches(ctx context.Context, filter StreetTurnFilter) ([]domain.StreetTurnOpportunity, error)
-	GetByDateRange(ctx context.Context, start, end time.Time) ([]domain.Trip, error)
 	List(ctx context.Context, filter TripFilter) ([]domain.Trip, int64, error)
 	Search(ctx context.Context, query string, limit int) ([]domain.Trip, error)
 }
 
-// TripStopRepository defines the interface for trip stop data access
+// TripAssignmentRepository is the part of TripRepository used to find
+// unassigned trips and assign drivers to them
+type TripAssignmentRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Trip, error)
+	GetByDateRange(ctx context.Context, start, end time.Time) ([]domain.Trip, error)
+	Update(ctx context.Context, trip *domain.Trip) error
+}
+
+// TripStopRepository defines the interface for trip stop data access.
+// Update follows the same version check as TripRepository.Update.
 type TripStopRepository interface {
+	TripStopLookupRepository
 	Create(ctx context.Context, stop *domain.TripStop) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.TripStop, error)
 	Update(ctx context.Context, stop *domain.TripStop) error
 	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.TripStop, error)
-	GetByTripIDs(ctx context.Context, tripIDs []uuid.UUID) ([]domain.TripStop, error)
+	GetOpenArrivals(ctx context.Context) ([]domain.TripStop, error) // Arrived and not yet departed
 	DeleteByTripID(ctx context.Context, tripID uuid.UUID) error
+	// GetFixesSince returns the arrival and completion positions reported at
+	// stops since the given time, leaving out devices without a fix (0,0)
+	GetFixesSince(ctx context.Context, since time.Time) ([]domain.StopFix, error)
+	// GetTerminalMoves returns pending stops at terminals on trips in the
+	// filter's statuses, oldest planned time first
+	GetTerminalMoves(ctx context.Context, filter TerminalMoveFilter) ([]domain.TerminalMove, error)
+}
+
+// TripStopLookupRepository is the part of TripStopRepository used to load
+// the stops of many trips at once
+type TripStopLookupRepository interface {
+	GetByTripIDs(ctx context.Context, tripIDs []uuid.UUID) ([]domain.TripStop, error)
+}
+
+// TerminalMoveFilter contains filter criteria for terminal moves. A stop's
+// time is its appointment, else its planned arrival, else its trip's
+// planned start. Container numbers match ignoring case, spaces and dashes.
+type TerminalMoveFilter struct {
+	TerminalID       *uuid.UUID
+	Activities       []domain.ActivityType // All activities when empty
+	ContainerNumbers []string
+	Statuses         []domain.TripStatus // Planned and assigned trips when empty
+	From             time.Time
+	To               time.Time
+}
+
+// SavedTripFilterRepository defines the interface for saved trip filter
+// data access. Names are unique per user.
+type SavedTripFilterRepository interface {
+	Create(ctx context.Context, filter *domain.SavedTripFilter) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SavedTripFilter, error)
+	ListByUser(ctx context.Context, userID string) ([]domain.SavedTripFilter, error) // Default first, then by name
+	Update(ctx context.Context, filter *domain.SavedTripFilter) error
+	ClearDefault(ctx context.Context, userID string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// DispatcherRepository defines the interface for dispatcher data access.
+// GetWorkloads counts active trips and the open exceptions on them for every
+// dispatcher, including inactive dispatchers who still hold trips.
+type DispatcherRepository interface {
+	Create(ctx context.Context, dispatcher *domain.Dispatcher) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispatcher, error)
+	Update(ctx context.Context, dispatcher *domain.Dispatcher) error
+	ListActive(ctx context.Context) ([]domain.Dispatcher, error)
+	GetWorkloads(ctx context.Context) ([]domain.DispatcherWorkload, error)
+}
+
+// DriverAllocationRepository defines the interface for soft driver allocation
+// data access. GetHeldByDriver returns held allocations whose window overlaps
+// start to end, earliest first.
+type DriverAllocationRepository interface {
+	Create(ctx context.Context, allocation *domain.DriverAllocation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.DriverAllocation, error)
+	Update(ctx context.Context, allocation *domain.DriverAllocation) error
+	GetHeldByDriver(ctx context.Context, driverID uuid.UUID, start, end time.Time) ([]domain.DriverAllocation, error)
+	GetHeldByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.DriverAllocation, error)
+}
+
+// DriverSyncRepository defines the interface for uploaded driver app actions.
+// GetByKey returns nil when the driver has not sent the idempotency key.
+type DriverSyncRepository interface {
+	Create(ctx context.Context, record *domain.DriverSyncRecord) error
+	GetByKey(ctx context.Context, driverID uuid.UUID, idempotencyKey string) (*domain.DriverSyncRecord, error)
+}
+
+// DriverCallOutRepository defines the interface for drivers marked out for a
+// day and the reassignment proposals for their trips. GetByDriverAndDate
+// returns nil when the driver is not out that day.
+type DriverCallOutRepository interface {
+	Create(ctx context.Context, callOut *domain.DriverCallOut) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.DriverCallOut, error)
+	GetByDriverAndDate(ctx context.Context, driverID uuid.UUID, date time.Time) (*domain.DriverCallOut, error)
+	GetDriverIDsOut(ctx context.Context, date time.Time) ([]uuid.UUID, error)
+	CreateProposal(ctx context.Context, proposal *domain.ReassignmentProposal) error
+	UpdateProposal(ctx context.Context, proposal *domain.ReassignmentProposal) error
+	GetProposals(ctx context.Context, callOutID uuid.UUID) ([]domain.ReassignmentProposal, error)
+}
+
+// YardRepository defines the interface for company yard gate events and
+// inventory. The open lookups return nil when nothing matching is in a yard.
+type YardRepository interface {
+	CreateGateEvent(ctx context.Context, event *domain.YardGateEvent) error
+	GetGateEvents(ctx context.Context, yardLocationID uuid.UUID, from, to time.Time) ([]domain.YardGateEvent, error)
+	CreateInventory(ctx context.Context, item *domain.YardInventoryItem) error
+	UpdateInventory(ctx context.Context, item *domain.YardInventoryItem) error
+	GetInventory(ctx context.Context, yardLocationID uuid.UUID) ([]domain.YardInventoryItem, error)
+	GetOpenByContainer(ctx context.Context, containerNumber string) (*domain.YardInventoryItem, error)
+	// GetOpenBareChassis returns the chassis when it is in a yard without a container
+	GetOpenBareChassis(ctx context.Context, chassisNumber string) (*domain.YardInventoryItem, error)
+}
+
+// ChassisUsageRepository defines the interface for chassis usage records.
+// GetOpenByChassis returns nil when the chassis has no usage without a return.
+type ChassisUsageRepository interface {
+	Create(ctx context.Context, usage *domain.ChassisUsage) error
+	Update(ctx context.Context, usage *domain.ChassisUsage) error
+	GetOpenByChassis(ctx context.Context, chassisID uuid.UUID) (*domain.ChassisUsage, error)
+	// GetByChassis returns usages of the chassis that overlap [from, to)
+	GetByChassis(ctx context.Context, chassisID uuid.UUID, from, to time.Time) ([]domain.ChassisUsage, error)
+}
+
+// CancellationChargeRepository defines the interface for fees charged on
+// customer cancellations
+type CancellationChargeRepository interface {
+	Create(ctx context.Context, charge *domain.CancellationCharge) error
+	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.CancellationCharge, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]domain.CancellationCharge, error)
+}
+
+// EscortRepository defines the interface for terminal escort policies and
+// the escort fees charged on trips. GetPolicy returns nil when the terminal
+// has no policy, which means it does not allow escorts.
+type EscortRepository interface {
+	GetPolicy(ctx context.Context, terminalID uuid.UUID) (*domain.TerminalEscortPolicy, error)
+	SavePolicy(ctx context.Context, policy *domain.TerminalEscortPolicy) error
+	ListPolicies(ctx context.Context) ([]domain.TerminalEscortPolicy, error)
+	CreateCharge(ctx context.Context, charge *domain.EscortCharge) error
+	GetOpenCharges(ctx context.Context, tripID uuid.UUID) ([]domain.EscortCharge, error)
+	VoidCharge(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// AttachmentRepository defines the interface for files uploaded against
+// trips. GetByID returns nil when there is no such attachment.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *domain.Attachment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error)
+	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.Attachment, error)
+}
+
+// StopDeliveryRepository defines the interface for the parts of a container
+// delivered at each stop. GetByID returns nil when there is no such record.
+type StopDeliveryRepository interface {
+	// ReplaceForStop replaces the stop's records with deliveries in one transaction
+	ReplaceForStop(ctx context.Context, stopID uuid.UUID, deliveries []domain.StopDelivery) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.StopDelivery, error)
+	Update(ctx context.Context, delivery *domain.StopDelivery) error
+	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.StopDelivery, error)
+}
+
+// FacilityProfileRepository defines the interface for the copies of
+// order-service facility profiles. GetByLocationID returns nil when the
+// location has no profile.
+type FacilityProfileRepository interface {
+	GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.FacilityProfile, error)
+	Upsert(ctx context.Context, profile *domain.FacilityProfile) error
 }
 
-// DriverRepository defines the interface for driver data access
+// GateAppointmentRepository defines the interface for terminal gate slots
+// and the appointments booked in them. Book takes a place in the slot and
+// inserts the appointment together, returning false without booking when the
+// slot is full, and stamps the slot's SoldOutAt the first time it fills;
+// Reschedule does the same for the new appointment and releases the old
+// one's place. The single lookups return nil when nothing matches.
+//
+// The driver-hour ledger keeps a driver from being booked at two terminals in
+// the same hour. ClaimDriverHours replaces an appointment's claims with the
+// given ones, or claims nothing and returns the conflicting claim when
+// another terminal already holds one of the hours.
+type GateAppointmentRepository interface {
+	CreateSlot(ctx context.Context, slot *domain.GateSlot) error
+	GetSlot(ctx context.Context, id uuid.UUID) (*domain.GateSlot, error)
+	ListSlots(ctx context.Context, terminalID uuid.UUID, moveType domain.GateMoveType, from, to time.Time) ([]domain.GateSlot, error) // moveType "" lists every move
+	HasSlots(ctx context.Context, terminalID uuid.UUID, from, to time.Time) (bool, error)
+	Book(ctx context.Context, appointment *domain.GateAppointment) (bool, error)
+	Reschedule(ctx context.Context, old, appointment *domain.GateAppointment) (bool, error)
+	Cancel(ctx context.Context, appointment *domain.GateAppointment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.GateAppointment, error)
+	GetActiveByStop(ctx context.Context, stopID uuid.UUID) (*domain.GateAppointment, error)
+	GetActiveByTrip(ctx context.Context, tripID uuid.UUID) ([]domain.GateAppointment, error)
+	GetSlotUsage(ctx context.Context, terminalID uuid.UUID, from, to time.Time) ([]domain.GateSlotUsage, error) // Slots starting in the period
+	ClaimDriverHours(ctx context.Context, appointmentID uuid.UUID, claims []domain.DriverHourClaim) (*domain.DriverHourClaim, error)
+	ReleaseDriverHours(ctx context.Context, appointmentID uuid.UUID) error
+}
+
+// DriverRepository defines the interface for driver data access. Drivers
+// carry the hold and covered-until of their owner-operator carrier record.
+// GetAvailable leaves out drivers on approved time off today.
 type DriverRepository interface {
+	DriverAvailabilityRepository
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Driver, error) // Unknown IDs are left out
+	GetByPhone(ctx context.Context, phone string) (*domain.Driver, error)
+}
+
+// DriverAvailabilityRepository is the part of DriverRepository used to
+// pick drivers for trips. It does not need driver phone numbers, which
+// driver-service may keep encrypted.
+type DriverAvailabilityRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Driver, error)
 	GetAvailable(ctx context.Context) ([]domain.Driver, error)
 }
 
 // LocationRepository defines the interface for location data access
 type LocationRepository interface {
+	LocationLookupRepository
+	// FindNearestScale returns the closest certified scale within radiusMiles, or nil if none
+	FindNearestScale(ctx context.Context, latitude, longitude, radiusMiles float64) (*domain.Location, error)
+	// FindRestStops returns up to limit truck stops and rest areas within radiusMiles, nearest first
+	FindRestStops(ctx context.Context, latitude, longitude, radiusMiles float64, limit int) ([]domain.Location, error)
+	ListYards(ctx context.Context) ([]domain.Location, error)
+	UpdateCoordinates(ctx context.Context, id uuid.UUID, latitude, longitude float64) error
+}
+
+// LocationLookupRepository is the part of LocationRepository used to load
+// locations by ID
+type LocationLookupRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Location, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Location, error) // Unknown IDs are left out
+}
+
+// LocationProposalRepository defines the interface for learned location
+// corrections. SavePending supersedes any proposal still pending for the
+// location; GetByID returns nil when there is no such proposal.
+type LocationProposalRepository interface {
+	SavePending(ctx context.Context, proposal *domain.LocationProposal) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.LocationProposal, error)
+	List(ctx context.Context, status domain.LocationProposalStatus) ([]domain.LocationProposal, error) // Largest shift first
+	Update(ctx context.Context, proposal *domain.LocationProposal) error
+}
+
+// OwnerOperatorRepository defines the interface for owner-operator carrier
+// records, their insurance certificates and the expiration alerts sent.
+// Single lookups return nil when there is no match.
+type OwnerOperatorRepository interface {
+	CreateCarrier(ctx context.Context, carrier *domain.OwnerOperatorCarrier) error
+	UpdateCarrier(ctx context.Context, carrier *domain.OwnerOperatorCarrier) error
+	GetCarrier(ctx context.Context, id uuid.UUID) (*domain.OwnerOperatorCarrier, error)
+	GetCarrierByDriver(ctx context.Context, driverID uuid.UUID) (*domain.OwnerOperatorCarrier, error)
+	ListCarriers(ctx context.Context) ([]domain.OwnerOperatorCarrier, error)
+	// SaveCertificate creates or replaces a certificate by ID
+	SaveCertificate(ctx context.Context, certificate *domain.InsuranceCertificate) error
+	GetCertificate(ctx context.Context, id uuid.UUID) (*domain.InsuranceCertificate, error)
+	GetCertificates(ctx context.Context, carrierID uuid.UUID) ([]domain.InsuranceCertificate, error)
+	// MarkExpirationAlerted records an alert for a certificate at so many days
+	// out, returning false when it was already sent
+	MarkExpirationAlerted(ctx context.Context, certificateID uuid.UUID, daysOut int) (bool, error)
+}
+
+// TractorRepository defines the interface for tractor data access
+type TractorRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Tractor, error)
+}
+
+// ChassisRepository defines the interface for chassis, chassis pools and
+// the chassis splits flagged on trips. Single lookups return nil when
+// there is no match.
+type ChassisRepository interface {
+	Create(ctx context.Context, chassis *domain.Chassis) error
+	Update(ctx context.Context, chassis *domain.Chassis) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Chassis, error)
+	GetByNumber(ctx context.Context, chassisNumber string) (*domain.Chassis, error)
+	List(ctx context.Context, filter domain.ChassisFilter) ([]domain.Chassis, error)
+	GetPool(ctx context.Context, id uuid.UUID) (*domain.ChassisPool, error)
+	ListPools(ctx context.Context) ([]domain.ChassisPool, error)
+	// SaveSplit creates or replaces the split flagged on the split's trip
+	SaveSplit(ctx context.Context, split *domain.ChassisSplit) error
+	GetSplitByTripID(ctx context.Context, tripID uuid.UUID) (*domain.ChassisSplit, error)
+	// GetSplitsByChassis returns the splits flagged on the chassis in [from, to)
+	GetSplitsByChassis(ctx context.Context, chassisID uuid.UUID, from, to time.Time) ([]domain.ChassisSplit, error)
+}
+
+// OrderRepository defines the interface for order lookups owned by order-service
+type OrderRepository interface {
+	GetCustomerIDs(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error)
+	// GetUnsignedRateConfirmations returns the order number of each brokered order
+	// whose broker requires a signed rate confirmation and that has none signed
+	GetUnsignedRateConfirmations(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID]string, error)
+}
+
+// ContainerRepository defines the interface for container compliance lookups owned by order-service
+type ContainerRepository interface {
+	GetVGMOnFile(ctx context.Context, containerIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	GetLoads(ctx context.Context, containerIDs []uuid.UUID) (map[uuid.UUID]domain.ContainerLoad, error)
+	// GetSealNumbers returns the seal recorded on the shipment for each container that has one
+	GetSealNumbers(ctx context.Context, containerIDs []uuid.UUID) (map[uuid.UUID]string, error)
+	// GetEmpty returns the container as an empty awaiting return, or nil when it
+	// is not an import that has been delivered and not yet returned
+	GetEmpty(ctx context.Context, containerID uuid.UUID) (*domain.EmptyContainer, error)
+}
+
+// BreakPlanRepository defines the interface for trips' planned driver breaks.
+// A trip has at most one plan; Upsert replaces it when the trip is replanned.
+// GetByTripID returns nil when the trip has no plan.
+type BreakPlanRepository interface {
+	Upsert(ctx context.Context, plan *domain.BreakPlan) error
+	Update(ctx context.Context, plan *domain.BreakPlan) error
+	GetByTripID(ctx context.Context, tripID uuid.UUID) (*domain.BreakPlan, error)
+	DeleteByTripID(ctx context.Context, tripID uuid.UUID) error
+}
+
+// HOSSnapshotRepository defines the interface for the HOS clocks captured
+// when trips are assigned and dispatched. Snapshots are only ever added.
+type HOSSnapshotRepository interface {
+	Create(ctx context.Context, snapshot *domain.HOSSnapshot) error
+	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.HOSSnapshot, error) // Oldest first
+}
+
+// PositionRepository defines the interface for GPS history owned by tracking-service
+type PositionRepository interface {
+	// GetByDriverID returns the driver's positions recorded since the given time, oldest first
+	GetByDriverID(ctx context.Context, driverID uuid.UUID, since time.Time) ([]domain.PositionFix, error)
+}
+
+// SMSRepository defines the interface for driver text message audit records
+type SMSRepository interface {
+	Create(ctx context.Context, message *domain.SMSMessage) error
+	GetByDriverID(ctx context.Context, driverID uuid.UUID, since time.Time) ([]domain.SMSMessage, error)
+	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.SMSMessage, error)
+}
+
+// EmergencyRepository defines the interface for driver panic incidents, the
+// staff alerted for them and the driver locations streamed while they are
+// open. GetActiveByDriver returns nil when the driver has no active incident.
+type EmergencyRepository interface {
+	Create(ctx context.Context, incident *domain.EmergencyIncident) error
+	Update(ctx context.Context, incident *domain.EmergencyIncident) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.EmergencyIncident, error)
+	GetActiveByDriver(ctx context.Context, driverID uuid.UUID) (*domain.EmergencyIncident, error)
+	ListActive(ctx context.Context) ([]domain.EmergencyIncident, error)
+	AddLocation(ctx context.Context, location *domain.EmergencyLocation) error
+	GetLocations(ctx context.Context, incidentID uuid.UUID) ([]domain.EmergencyLocation, error)
+	CreateNotification(ctx context.Context, notification *domain.EmergencyNotification) error
+	GetNotifications(ctx context.Context, incidentID uuid.UUID) ([]domain.EmergencyNotification, error)
+	ListContacts(ctx context.Context, activeOnly bool) ([]domain.EmergencyContact, error)
+	CreateContact(ctx context.Context, contact *domain.EmergencyContact) error
+	DeactivateContact(ctx context.Context, id uuid.UUID) error
+}
+
+// AutoDispatchRepository defines the interface for auto-dispatch configuration
+// and decisions. GetLatestDecision returns nil when the trip has none.
+type AutoDispatchRepository interface {
+	GetConfig(ctx context.Context, terminalID uuid.UUID) (*domain.AutoDispatchConfig, error)
+	SaveConfig(ctx context.Context, config *domain.AutoDispatchConfig) error
+	ListEnabledConfigs(ctx context.Context) ([]domain.AutoDispatchConfig, error)
+	CreateDecision(ctx context.Context, decision *domain.AutoDispatchDecision) error
+	UpdateDecision(ctx context.Context, decision *domain.AutoDispatchDecision) error
+	GetDecision(ctx context.Context, id uuid.UUID) (*domain.AutoDispatchDecision, error)
+	GetLatestDecision(ctx context.Context, tripID uuid.UUID) (*domain.AutoDispatchDecision, error)
+	GetPendingDecisions(ctx context.Context) ([]domain.AutoDispatchDecision, error)
 }
 
 // EquipmentRepository defines the interface for equipment data access
@@ -76,6 +423,13 @@ type EquipmentRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (interface{}, error)
 }
 
+// CustomerProfileRepository defines the interface for customer contract profile access.
+// Lookups return nil when the customer has no profile.
+type CustomerProfileRepository interface {
+	GetByCustomerID(ctx context.Context, customerID uuid.UUID) (*config.CustomerProfile, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*config.CustomerProfile, error)
+}
+
 // ExceptionRepository defines the interface for exception data access
 type ExceptionRepository interface {
 	Create(ctx context.Context, exception *domain.Exception) error
@@ -87,3 +441,30 @@ type ExceptionRepository interface {
 	GetByStatus(ctx context.Context, statuses []domain.ExceptionStatus) ([]domain.Exception, error)
 	GetBySeverity(ctx context.Context, severities []domain.ExceptionSeverity) ([]domain.Exception, error)
 }
+
+// DriverTimeOffRepository defines the interface for driver time off
+// requests and the PTO ledger behind each driver's balance. GetByID returns
+// nil when there is no such request.
+type DriverTimeOffRepository interface {
+	Create(ctx context.Context, timeOff *domain.DriverTimeOff) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.DriverTimeOff, error)
+	List(ctx context.Context, filter TimeOffFilter) ([]domain.DriverTimeOff, error) // By start date
+	// GetOverlapping returns the driver's requests in the given statuses whose days fall between from and to
+	GetOverlapping(ctx context.Context, driverID uuid.UUID, from, to time.Time, statuses []domain.TimeOffStatus) ([]domain.DriverTimeOff, error)
+	Update(ctx context.Context, timeOff *domain.DriverTimeOff) error
+
+	// AddLedgerEntry returns false when an accrual for the driver's week is already recorded
+	AddLedgerEntry(ctx context.Context, entry *domain.PTOLedgerEntry) (bool, error)
+	GetLedger(ctx context.Context, driverID uuid.UUID, limit int) ([]domain.PTOLedgerEntry, error) // Newest first
+	GetBalance(ctx context.Context, driverID uuid.UUID) (float64, error)                           // Sum of the ledger
+}
+
+// TimeOffFilter contains filter criteria for listing time off requests.
+// From and To select requests with days in the range.
+type TimeOffFilter struct {
+	DriverID *uuid.UUID
+	Status   domain.TimeOffStatus
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// PostgresTripRepository implements TripAssignmentRepository using PostgreSQL
+type PostgresTripRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresTripRepository creates a new PostgreSQL trip repository
+func NewPostgresTripRepository(pool *pgxpool.Pool) *PostgresTripRepository {
+	return &PostgresTripRepository{pool: pool}
+}
+
+const tripColumns = `id, trip_number, type::text, status::text, driver_id, dispatcher_id, tractor_id, chassis_id,
+	COALESCE(current_stop_sequence, 1), planned_start_time, actual_start_time, planned_end_time, actual_end_time,
+	COALESCE(estimated_duration_mins, 0), COALESCE(total_miles, 0), COALESCE(completed_miles, 0),
+	COALESCE(is_street_turn, FALSE), COALESCE(is_dual_transaction, FALSE), linked_trip_id,
+	rolled_over_from_trip_id, rolled_over_to_trip_id, stale_prompted_at, stale_flagged_at,
+	twic_escort, tags, version, COALESCE(created_by, ''), created_at, updated_at`
+
+// GetByID retrieves a trip by ID
+func (r *PostgresTripRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Trip, error) {
+	trip, err := scanTrip(conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+tripColumns+` FROM trips WHERE id = $1`, id,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("trip not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+	return trip, nil
+}
+
+// GetByDateRange retrieves trips planned to start within [start, end), earliest first
+func (r *PostgresTripRepository) GetByDateRange(ctx context.Context, start, end time.Time) ([]domain.Trip, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+tripColumns+` FROM trips
+		 WHERE planned_start_time >= $1 AND planned_start_time < $2
+		 ORDER BY planned_start_time`,
+		start, end,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trips by date range: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []domain.Trip
+	for rows.Next() {
+		trip, err := scanTrip(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		trips = append(trips, *trip)
+	}
+	return trips, rows.Err()
+}
+
+// Update saves a trip when its version matches the stored row and bumps
+// the version, returning database.ErrVersionConflict otherwise
+func (r *PostgresTripRepository) Update(ctx context.Context, trip *domain.Trip) error {
+	tags := trip.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		`UPDATE trips
+		 SET status = $2, driver_id = $3, dispatcher_id = $4, tractor_id = $5, chassis_id = $6,
+		     current_stop_sequence = $7, planned_start_time = $8, actual_start_time = $9,
+		     planned_end_time = $10, actual_end_time = $11, estimated_duration_mins = $12,
+		     total_miles = $13, completed_miles = $14, is_street_turn = $15, is_dual_transaction = $16,
+		     linked_trip_id = $17, rolled_over_from_trip_id = $18, rolled_over_to_trip_id = $19,
+		     stale_prompted_at = $20, stale_flagged_at = $21, twic_escort = $22, tags = $23,
+		     updated_at = $24, version = version + 1
+		 WHERE id = $1 AND version = $25
+		 RETURNING version`,
+		trip.ID, string(trip.Status), trip.DriverID, trip.DispatcherID, trip.TractorID, trip.ChassisID,
+		trip.CurrentStopSequence, trip.PlannedStartTime, trip.ActualStartTime,
+		trip.PlannedEndTime, trip.ActualEndTime, trip.EstimatedDurationMins,
+		trip.TotalMiles, trip.CompletedMiles, trip.IsStreetTurn, trip.IsDualTransaction,
+		trip.LinkedTripID, trip.RolledOverFromTripID, trip.RolledOverToTripID,
+		trip.StalePromptedAt, trip.StaleFlaggedAt, trip.TWICEscort, tags,
+		trip.UpdatedAt, trip.Version,
+	).Scan(&trip.Version)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return database.ErrVersionConflict
+		}
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return nil
+}
+
+func scanTrip(row pgx.Row) (*domain.Trip, error) {
+	var t domain.Trip
+	var tripType, status string
+	if err := row.Scan(
+		&t.ID, &t.TripNumber, &tripType, &status, &t.DriverID, &t.DispatcherID, &t.TractorID, &t.ChassisID,
+		&t.CurrentStopSequence, &t.PlannedStartTime, &t.ActualStartTime, &t.PlannedEndTime, &t.ActualEndTime,
+		&t.EstimatedDurationMins, &t.TotalMiles, &t.CompletedMiles,
+		&t.IsStreetTurn, &t.IsDualTransaction, &t.LinkedTripID,
+		&t.RolledOverFromTripID, &t.RolledOverToTripID, &t.StalePromptedAt, &t.StaleFlaggedAt,
+		&t.TWICEscort, &t.Tags, &t.Version, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	t.Type = domain.TripType(tripType)
+	t.Status = domain.TripStatus(status)
+	return &t, nil
+}
+
+// PostgresTripStopRepository implements TripStopLookupRepository using
+// PostgreSQL. Stops are loaded with their scheduling, detention and
+// equipment fields; seal, POD and reported position details are left unset.
+type PostgresTripStopRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresTripStopRepository creates a new PostgreSQL trip stop repository
+func NewPostgresTripStopRepository(pool *pgxpool.Pool) *PostgresTripStopRepository {
+	return &PostgresTripStopRepository{pool: pool}
+}
+
+const tripStopColumns = `id, trip_id, sequence, type::text, activity::text, status::text, location_id,
+	container_id, COALESCE(container_number, ''), order_id, appointment_time, COALESCE(appointment_number, ''),
+	COALESCE(appointment_window_mins, 60), planned_arrival, actual_arrival, actual_departure,
+	COALESCE(estimated_duration_mins, 30), COALESCE(actual_duration_mins, 0), COALESCE(free_time_mins, 120),
+	detention_start_time, COALESCE(detention_mins, 0), COALESCE(detention_charge, 0),
+	chassis_in_id, chassis_out_id, container_in_id, container_out_id,
+	COALESCE(gate_ticket_number, ''), COALESCE(seal_number, ''), COALESCE(failure_reason, ''), COALESCE(notes, ''),
+	version, created_at, updated_at`
+
+// GetByTripIDs retrieves the stops of many trips, by trip then sequence
+func (r *PostgresTripStopRepository) GetByTripIDs(ctx context.Context, tripIDs []uuid.UUID) ([]domain.TripStop, error) {
+	if len(tripIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+tripStopColumns+` FROM trip_stops
+		 WHERE trip_id = ANY($1)
+		 ORDER BY trip_id, sequence`,
+		tripIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip stops: %w", err)
+	}
+	defer rows.Close()
+
+	var stops []domain.TripStop
+	for rows.Next() {
+		stop, err := scanTripStop(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trip stop: %w", err)
+		}
+		stops = append(stops, *stop)
+	}
+	return stops, rows.Err()
+}
+
+func scanTripStop(row pgx.Row) (*domain.TripStop, error) {
+	var s domain.TripStop
+	var stopType, activity, status string
+	if err := row.Scan(
+		&s.ID, &s.TripID, &s.Sequence, &stopType, &activity, &status, &s.LocationID,
+		&s.ContainerID, &s.ContainerNumber, &s.OrderID, &s.AppointmentTime, &s.AppointmentNumber,
+		&s.AppointmentWindowMins, &s.PlannedArrival, &s.ActualArrival, &s.ActualDeparture,
+		&s.EstimatedDurationMins, &s.ActualDurationMins, &s.FreeTimeMins,
+		&s.DetentionStartTime, &s.DetentionMins, &s.DetentionCharge,
+		&s.ChassisInID, &s.ChassisOutID, &s.ContainerInID, &s.ContainerOutID,
+		&s.GateTicketNumber, &s.SealNumber, &s.FailureReason, &s.Notes,
+		&s.Version, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	s.Type = domain.StopType(stopType)
+	s.Activity = domain.ActivityType(activity)
+	s.Status = domain.StopStatus(status)
+	return &s, nil
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readSpreadsheet returns the rows of a CSV file or of the first worksheet of
+// an Excel (.xlsx) workbook. The format is sniffed from the content, so a
+// misnamed file still reads.
+func readSpreadsheet(data []byte) ([][]string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return readXLSX(data)
+	case bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}):
+		return nil, fmt.Errorf("legacy Excel (.xls) files are not supported; save the file as .xlsx or CSV")
+	default:
+		return readCSV(data)
+	}
+}
+
+// readCSV reads comma, semicolon or tab separated rows, whichever separator
+// the widest of the leading lines uses most
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	comma, most := ',', 0
+	for i, line := range bytes.SplitN(data, []byte("\n"), appointmentHeaderScanRows+1) {
+		if i == appointmentHeaderScanRows {
+			break
+		}
+		for _, sep := range []rune{',', ';', '\t'} {
+			if n := bytes.Count(line, []byte(string(sep))); n > most {
+				comma, most = sep, n
+			}
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV: %w", err)
+	}
+	return rows, nil
+}
+
+// xlsxWorkbook is the part of xl/workbook.xml naming the worksheets
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is xl/_rels/workbook.xml.rels, mapping worksheets to parts
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a shared or inline string: plain text or rich text runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// xlsxSharedStrings is xl/sharedStrings.xml
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxWorksheet is the cell data of a worksheet part
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the first worksheet of a workbook. Numbers, including dates,
+// come back as Excel stores them; parseAppointmentTime reads date serials.
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open workbook: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPart, err := firstSheetPart(parts)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := parts[sheetPart]
+	if !ok {
+		return nil, fmt.Errorf("workbook has no worksheet")
+	}
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, xr := range sheet.Rows {
+		var row []string
+		for i, cell := range xr.Cells {
+			col := i
+			if c, ok := columnIndex(cell.Ref); ok {
+				col = c
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch cell.Type {
+			case "s":
+				n, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				row[col] = shared.Items[n].String()
+			case "inlineStr":
+				row[col] = cell.Inline.String()
+			default:
+				row[col] = cell.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstSheetPart returns the part name of the workbook's first worksheet,
+// falling back to the name Excel gives it
+func firstSheetPart(parts map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, ok := parts["xl/workbook.xml"]
+	relsFile, relsOK := parts["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOK {
+		return fallback, nil
+	}
+	var wb xlsxWorkbook
+	if err := decodeXLSXPart(wbFile, &wb); err != nil {
+		return "", err
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return fallback, nil
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+func decodeXLSXPart(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the zero-based column of a cell reference like "AB12"
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+		n++
+	}
+	return col - 1, n > 0
+}
+
+// appointmentLayouts are the date and time formats terminals' confirmation
+// files use, US month-first
+var appointmentLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04 PM",
+	"2006-01-02 3:04PM",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/2006 3:04:05 PM",
+	"1/2/2006 3:04 PM",
+	"1/2/2006 3:04PM",
+	"1/2/2006",
+	"1/2/06 15:04",
+	"1/2/06 3:04 PM",
+	"1/2/06",
+	"02-Jan-2006 15:04",
+	"02-Jan-2006",
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006",
+}
+
+// clockLayouts are the time-of-day formats of a separate time column
+var clockLayouts = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM", "3:04PM", "1504"}
+
+// parseAppointmentTime reads an appointment from a date and a time cell in
+// loc. Either may be empty when the other holds both, and either may be an
+// Excel serial: days since 1899-12-30 with the time of day as the fraction.
+func parseAppointmentTime(dateCell, timeCell string, loc *time.Location) (time.Time, error) {
+	dateCell = strings.TrimSpace(dateCell)
+	timeCell = strings.TrimSpace(timeCell)
+	if dateCell == "" {
+		dateCell, timeCell = timeCell, ""
+	}
+	if dateCell == "" {
+		return time.Time{}, fmt.Errorf("no appointment time")
+	}
+
+	t, err := parseDateTime(dateCell, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if timeCell == "" {
+		return t, nil
+	}
+	clock, err := parseClock(timeCell)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(clock), nil
+}
+
+func parseDateTime(s string, loc *time.Location) (time.Time, error) {
+	if serial, err := strconv.ParseFloat(s, 64); err == nil {
+		return excelSerialTime(serial, loc)
+	}
+	for _, layout := range appointmentLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unreadable appointment time %q", s)
+}
+
+// parseClock returns a time of day as the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	if fraction, err := strconv.ParseFloat(s, 64); err == nil && fraction >= 0 && fraction < 1 {
+		return time.Duration(math.Round(fraction*24*60)) * time.Minute, nil
+	}
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(s)); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("unreadable appointment time %q", s)
+}
+
+// excelSerialTime converts an Excel date serial in the 1900 date system. The
+// epoch is 1899-12-30 to absorb Excel's phantom 1900-02-29.
+func excelSerialTime(serial float64, loc *time.Location) (time.Time, error) {
+	if serial < 1 || serial > 2958465 {
+		return time.Time{}, fmt.Errorf("unreadable appointment time %v", serial)
+	}
+	days := math.Floor(serial)
+	mins := math.Round((serial - days) * 24 * 60)
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, loc).AddDate(0, 0, int(days)).Add(time.Duration(mins) * time.Minute), nil
+}
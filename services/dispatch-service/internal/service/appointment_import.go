@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// maxAppointmentRows caps the rows one confirmation file can carry
+	maxAppointmentRows = 5000
+
+	// appointmentHeaderScanRows is how many leading rows are searched for the
+	// header, since terminal reports often open with a title block
+	appointmentHeaderScanRows = 10
+)
+
+// Header names terminals use for each column, compared after lowercasing and
+// dropping everything but letters and digits
+var (
+	containerHeaders = []string{
+		"container", "containernumber", "containerno", "containernbr", "containerid",
+		"cntr", "cntrno", "cntrnumber", "ctr", "ctrno", "unit", "unitnumber", "unitno", "equipment", "equipmentnumber",
+	}
+	appointmentNumberHeaders = []string{
+		"appointmentnumber", "appointmentno", "appointmentid", "appointment", "apptnumber", "apptno", "apptid", "appt",
+		"confirmation", "confirmationnumber", "confirmationno", "reference", "referencenumber", "refno",
+		"transaction", "transactionnumber", "transactionno", "ticket", "ticketnumber",
+	}
+	appointmentDateHeaders = []string{"appointmentdate", "apptdate", "date", "slotdate", "gatedate", "visitdate"}
+	appointmentTimeHeaders = []string{
+		"appointmenttime", "appointmentdatetime", "appttime", "apptdatetime", "time", "datetime",
+		"slot", "slottime", "slotstart", "windowstart", "starttime", "gatetime", "visittime",
+	}
+)
+
+// appointmentImportStatuses are the trips whose pending stops can take an
+// appointment from a confirmation file
+var appointmentImportStatuses = []domain.TripStatus{
+	domain.TripStatusPlanned,
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+	domain.TripStatusInProgress,
+}
+
+// AppointmentImportService applies the appointment confirmations terminals
+// email as CSV or Excel files to trip stops in bulk. Rows are matched to
+// pending terminal stops by container number and appointment date; rows that
+// match no stop, or more than one, are reported for dispatchers to handle.
+type AppointmentImportService struct {
+	stopRepo     repository.TripStopRepository
+	locationRepo repository.LocationRepository
+	logger       *logger.Logger
+}
+
+// NewAppointmentImportService creates a new appointment import service
+func NewAppointmentImportService(
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	log *logger.Logger,
+) *AppointmentImportService {
+	return &AppointmentImportService{
+		stopRepo:     stopRepo,
+		locationRepo: locationRepo,
+		logger:       log,
+	}
+}
+
+// AppointmentImportInput contains input for importing a confirmation file.
+// TerminalID limits matching to one terminal's stops; a dry run matches rows
+// without saving anything.
+type AppointmentImportInput struct {
+	FileName   string
+	TerminalID *uuid.UUID
+	DryRun     bool
+	ImportedBy string
+}
+
+// appointmentColumns are the column indexes of a confirmation file, -1 when
+// the file has no such column
+type appointmentColumns struct {
+	container, number, date, time int
+}
+
+// ImportAppointments reads a confirmation file and sets the appointment time
+// and number of each row's stop. Appointment times without a zone are read as
+// local time. A row that cannot be saved is reported as failed without
+// stopping the rest.
+func (s *AppointmentImportService) ImportAppointments(ctx context.Context, input AppointmentImportInput, file io.Reader) (*domain.AppointmentImport, error) {
+	if strings.TrimSpace(input.FileName) == "" {
+		return nil, apperrors.ValidationError("file name is required", "file_name", input.FileName)
+	}
+	if input.TerminalID != nil {
+		terminal, err := s.locationRepo.GetByID(ctx, *input.TerminalID)
+		if err != nil || terminal == nil {
+			return nil, apperrors.NotFoundError("terminal", input.TerminalID.String())
+		}
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, apperrors.ValidationError("could not read file", "file", err.Error())
+	}
+	records, err := readSpreadsheet(data)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "file", input.FileName)
+	}
+	header, cols, err := findAppointmentHeader(records)
+	if err != nil {
+		return nil, err
+	}
+	records = records[header+1:]
+	if len(records) > maxAppointmentRows {
+		return nil, apperrors.ValidationError(fmt.Sprintf("file has more than %d rows", maxAppointmentRows), "file", len(records))
+	}
+
+	result := &domain.AppointmentImport{
+		FileName:   input.FileName,
+		TerminalID: input.TerminalID,
+		DryRun:     input.DryRun,
+		Rows:       []domain.AppointmentImportRow{},
+		ImportedBy: input.ImportedBy,
+		ImportedAt: time.Now(),
+	}
+
+	// Read every row first so the stops can be fetched in one query
+	var containers []string
+	var from, to time.Time
+	for i, record := range records {
+		if blankRecord(record) {
+			continue
+		}
+		row := domain.AppointmentImportRow{
+			Row:               header + i + 2,
+			ContainerNumber:   strings.ToUpper(strings.TrimSpace(cell(record, cols.container))),
+			AppointmentNumber: strings.TrimSpace(cell(record, cols.number)),
+		}
+		if row.ContainerNumber == "" {
+			row.Outcome = domain.AppointmentRowInvalid
+			row.Reason = "no container number"
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		at, err := parseAppointmentTime(cell(record, cols.date), cell(record, cols.time), time.Local)
+		if err != nil {
+			row.Outcome = domain.AppointmentRowInvalid
+			row.Reason = err.Error()
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		row.AppointmentTime = &at
+		result.Rows = append(result.Rows, row)
+
+		containers = append(containers, row.ContainerNumber)
+		day := startOfDay(at)
+		if from.IsZero() || day.Before(from) {
+			from = day
+		}
+		if to.IsZero() || day.After(to) {
+			to = day
+		}
+	}
+
+	var moves []domain.TerminalMove
+	if len(containers) > 0 {
+		moves, err = s.stopRepo.GetTerminalMoves(ctx, repository.TerminalMoveFilter{
+			TerminalID:       input.TerminalID,
+			ContainerNumbers: containers,
+			Statuses:         appointmentImportStatuses,
+			From:             from,
+			To:               to.AddDate(0, 0, 1),
+		})
+		if err != nil {
+			return nil, apperrors.DatabaseError("get terminal moves", err)
+		}
+	}
+	byContainer := make(map[string][]domain.TerminalMove)
+	for _, move := range moves {
+		key := containerKey(move.ContainerNumber)
+		byContainer[key] = append(byContainer[key], move)
+	}
+
+	matchedBy := make(map[uuid.UUID]int) // Stop to the row that matched it
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if row.Outcome == "" {
+			s.applyAppointmentRow(ctx, row, byContainer[containerKey(row.ContainerNumber)], matchedBy, input.DryRun)
+		}
+		switch row.Outcome {
+		case domain.AppointmentRowUpdated:
+			result.Updated++
+		case domain.AppointmentRowUnchanged:
+			result.Unchanged++
+		case domain.AppointmentRowMatched:
+			result.Matched++
+		default:
+			result.NeedReview++
+		}
+	}
+
+	s.logger.Infow("Appointment file imported",
+		"file_name", input.FileName,
+		"terminal_id", input.TerminalID,
+		"dry_run", input.DryRun,
+		"rows", len(result.Rows),
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"matched", result.Matched,
+		"need_review", result.NeedReview,
+		"imported_by", input.ImportedBy,
+	)
+	return result, nil
+}
+
+// applyAppointmentRow matches a row to the container's stop on the
+// appointment date and, unless this is a dry run, saves the appointment
+func (s *AppointmentImportService) applyAppointmentRow(ctx context.Context, row *domain.AppointmentImportRow, moves []domain.TerminalMove, matchedBy map[uuid.UUID]int, dryRun bool) {
+	day := startOfDay(*row.AppointmentTime)
+	var candidates []domain.TerminalMove
+	for _, move := range moves {
+		if startOfDay(move.PlannedTime.In(day.Location())).Equal(day) {
+			candidates = append(candidates, move)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		row.Outcome = domain.AppointmentRowUnmatched
+		row.Reason = fmt.Sprintf("no open terminal stop for %s on %s", row.ContainerNumber, day.Format("2006-01-02"))
+		if len(moves) > 0 {
+			row.Reason += fmt.Sprintf("; it has %d on other dates", len(moves))
+		}
+		return
+	case 1:
+	default:
+		row.Outcome = domain.AppointmentRowAmbiguous
+		row.Reason = fmt.Sprintf("%d open terminal stops for %s on %s", len(candidates), row.ContainerNumber, day.Format("2006-01-02"))
+		return
+	}
+
+	move := candidates[0]
+	row.TripID = &move.TripID
+	row.TripNumber = move.TripNumber
+	row.StopID = &move.StopID
+	if earlier, ok := matchedBy[move.StopID]; ok {
+		row.Outcome = domain.AppointmentRowAmbiguous
+		row.Reason = fmt.Sprintf("stop already matched by row %d", earlier)
+		return
+	}
+	matchedBy[move.StopID] = row.Row
+
+	stop, err := s.stopRepo.GetByID(ctx, move.StopID)
+	if err != nil || stop == nil {
+		row.Outcome = domain.AppointmentRowFailed
+		row.Reason = "stop not found"
+		return
+	}
+	number := row.AppointmentNumber
+	if number == "" {
+		number = stop.AppointmentNumber
+	}
+	if stop.AppointmentTime != nil && stop.AppointmentTime.Equal(*row.AppointmentTime) && stop.AppointmentNumber == number {
+		row.Outcome = domain.AppointmentRowUnchanged
+		return
+	}
+	if dryRun {
+		row.Outcome = domain.AppointmentRowMatched
+		return
+	}
+
+	at := *row.AppointmentTime
+	stop.AppointmentTime = &at
+	stop.AppointmentNumber = number
+	stop.UpdatedAt = time.Now()
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		err = stopUpdateError(ctx, s.stopRepo, stop.ID, "update stop appointment", err)
+		row.Outcome = domain.AppointmentRowFailed
+		row.Reason = err.Error()
+		s.logger.Errorw("Failed to import stop appointment",
+			"stop_id", stop.ID,
+			"row", row.Row,
+			"error", err,
+		)
+		return
+	}
+	row.Outcome = domain.AppointmentRowUpdated
+}
+
+// findAppointmentHeader returns the index of the header row and the columns
+// it names. The header is the first row naming a container column.
+func findAppointmentHeader(records [][]string) (int, appointmentColumns, error) {
+	for i, record := range records {
+		if i == appointmentHeaderScanRows {
+			break
+		}
+		cols := appointmentColumns{container: -1, number: -1, date: -1, time: -1}
+		for j, name := range record {
+			name = headerKey(name)
+			switch {
+			case cols.container < 0 && containsString(containerHeaders, name):
+				cols.container = j
+			case cols.number < 0 && containsString(appointmentNumberHeaders, name):
+				cols.number = j
+			case cols.date < 0 && containsString(appointmentDateHeaders, name):
+				cols.date = j
+			case cols.time < 0 && containsString(appointmentTimeHeaders, name):
+				cols.time = j
+			}
+		}
+		if cols.container < 0 {
+			continue
+		}
+		if cols.date < 0 && cols.time < 0 {
+			return 0, cols, apperrors.ValidationError("file has no appointment date or time column", "file", record)
+		}
+		return i, cols, nil
+	}
+	return 0, appointmentColumns{}, apperrors.ValidationError("file has no container number column", "file", nil)
+}
+
+// headerKey lowercases a header and drops everything but letters and digits
+func headerKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// containerKey normalizes a container number for matching, ignoring case,
+// spaces and dashes
+func containerKey(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(strings.TrimSpace(number)))
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func cell(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	return record[col]
+}
+
+func blankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
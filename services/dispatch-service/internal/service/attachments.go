@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+// AttachmentService stores the PODs and photos drivers upload against trips.
+// The app uploads a file first and then sends the returned attachment ID with
+// the delivery, seal verification or gate event it belongs to.
+type AttachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	tripRepo       repository.TripRepository
+	stopRepo       repository.TripStopRepository
+	store          storage.Store
+	logger         *logger.Logger
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(
+	attachmentRepo repository.AttachmentRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	store storage.Store,
+	log *logger.Logger,
+) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		tripRepo:       tripRepo,
+		stopRepo:       stopRepo,
+		store:          store,
+		logger:         log,
+	}
+}
+
+// UploadAttachmentInput contains input for uploading an attachment. A stop
+// must belong to the trip; a POD needs the stop it was signed at.
+type UploadAttachmentInput struct {
+	Kind        domain.AttachmentKind
+	TripID      *uuid.UUID
+	StopID      *uuid.UUID
+	DriverID    *uuid.UUID
+	FileName    string
+	ContentType string
+	Size        int64 // Body length when known
+	UploadedBy  string
+}
+
+// UploadAttachment streams the file to storage and records the attachment
+func (s *AttachmentService) UploadAttachment(ctx context.Context, input UploadAttachmentInput, body io.Reader) (*domain.Attachment, error) {
+	if !input.Kind.IsValid() {
+		return nil, apperrors.ValidationError("invalid attachment kind", "kind", input.Kind)
+	}
+	fileName := path.Base(strings.ReplaceAll(strings.TrimSpace(input.FileName), "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return nil, apperrors.ValidationError("file name is required", "file_name", input.FileName)
+	}
+	if input.Kind == domain.AttachmentKindPOD && input.StopID == nil {
+		return nil, apperrors.ValidationError("a POD must name the stop it was signed at", "stop_id", nil)
+	}
+	if input.StopID != nil && input.TripID == nil {
+		return nil, apperrors.ValidationError("a stop attachment must name its trip", "trip_id", nil)
+	}
+
+	if input.TripID != nil {
+		trip, err := s.tripRepo.GetByID(ctx, *input.TripID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get trip", err)
+		}
+		if trip == nil {
+			return nil, apperrors.NotFoundError("trip", input.TripID.String())
+		}
+	}
+	if input.StopID != nil {
+		stop, err := s.stopRepo.GetByID(ctx, *input.StopID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get trip stop", err)
+		}
+		if stop == nil || stop.TripID != *input.TripID {
+			return nil, apperrors.NotFoundError("trip stop", input.StopID.String())
+		}
+	}
+
+	attachment := &domain.Attachment{
+		ID:          uuid.New(),
+		Kind:        input.Kind,
+		TripID:      input.TripID,
+		StopID:      input.StopID,
+		DriverID:    input.DriverID,
+		FileName:    fileName,
+		ContentType: input.ContentType,
+		UploadedBy:  input.UploadedBy,
+		CreatedAt:   time.Now(),
+	}
+	owner := "unassigned"
+	if input.TripID != nil {
+		owner = input.TripID.String()
+	}
+	attachment.StorageKey = storage.Key("trips", owner, attachment.ID.String(), fileName)
+
+	obj, err := s.store.Put(ctx, attachment.StorageKey, body, storage.PutOptions{
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		Metadata:    map[string]string{"kind": string(input.Kind)},
+	})
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("file storage", err)
+	}
+	attachment.SizeBytes = obj.Size
+
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		// Don't leave an orphaned file behind
+		if delErr := s.store.Delete(ctx, attachment.StorageKey); delErr != nil {
+			s.logger.Warnw("Failed to remove orphaned attachment file", "key", attachment.StorageKey, "error", delErr)
+		}
+		return nil, apperrors.DatabaseError("create attachment", err)
+	}
+
+	s.logger.Infow("Attachment uploaded",
+		"attachment_id", attachment.ID,
+		"kind", attachment.Kind,
+		"trip_id", attachment.TripID,
+		"size_bytes", attachment.SizeBytes,
+	)
+	return attachment, nil
+}
+
+// GetTripAttachments returns the attachments uploaded against a trip
+func (s *AttachmentService) GetTripAttachments(ctx context.Context, tripID uuid.UUID) ([]domain.Attachment, error) {
+	attachments, err := s.attachmentRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip attachments", err)
+	}
+	return attachments, nil
+}
+
+// OpenAttachment returns the attachment and a reader for its file. The
+// caller closes the reader.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, id uuid.UUID) (*domain.Attachment, io.ReadCloser, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, apperrors.DatabaseError("get attachment", err)
+	}
+	if attachment == nil {
+		return nil, nil, apperrors.NotFoundError("attachment", id.String())
+	}
+	body, _, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, apperrors.NotFoundError("attachment file", id.String())
+		}
+		return nil, nil, apperrors.ExternalServiceError("file storage", err)
+	}
+	return attachment, body, nil
+}
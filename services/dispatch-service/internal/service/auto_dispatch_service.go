@@ -0,0 +1,580 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	defaultAutoDispatchLookaheadMins = 240
+	defaultAutoDispatchOverrideMins  = 5
+	autoDispatchHOSBufferMins        = 30
+)
+
+// AutoDispatchService assigns top-priority trips to the nearest eligible
+// drivers, giving dispatchers an override period before assignments commit
+type AutoDispatchService struct {
+	tripRepo      repository.TripAssignmentRepository
+	stopRepo      repository.TripStopLookupRepository
+	driverRepo    repository.DriverAvailabilityRepository
+	locationRepo  repository.LocationLookupRepository
+	autoRepo      repository.AutoDispatchRepository
+	snapshotRepo  repository.HOSSnapshotRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewAutoDispatchService creates a new auto-dispatch service
+func NewAutoDispatchService(
+	tripRepo repository.TripAssignmentRepository,
+	stopRepo repository.TripStopLookupRepository,
+	driverRepo repository.DriverAvailabilityRepository,
+	locationRepo repository.LocationLookupRepository,
+	autoRepo repository.AutoDispatchRepository,
+	snapshotRepo repository.HOSSnapshotRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *AutoDispatchService {
+	return &AutoDispatchService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		autoRepo:      autoRepo,
+		snapshotRepo:  snapshotRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// TerminalAutoDispatchInput contains input for configuring auto-dispatch at a terminal
+type TerminalAutoDispatchInput struct {
+	TerminalID       uuid.UUID
+	Enabled          bool
+	WindowStart      string // HH:MM
+	WindowEnd        string // HH:MM
+	LookaheadMins    int
+	MaxDistanceMiles float64
+	OverrideMins     int
+	UpdatedBy        string
+}
+
+// SetTerminalAutoDispatch enables, disables, or reconfigures auto-dispatch for a terminal
+func (s *AutoDispatchService) SetTerminalAutoDispatch(ctx context.Context, input TerminalAutoDispatchInput) (*domain.AutoDispatchConfig, error) {
+	if _, err := s.locationRepo.GetByID(ctx, input.TerminalID); err != nil {
+		return nil, apperrors.NotFoundError("terminal", input.TerminalID.String())
+	}
+	for field, value := range map[string]string{"window_start": input.WindowStart, "window_end": input.WindowEnd} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			return nil, apperrors.ValidationError("window must be HH:MM", field, value)
+		}
+	}
+
+	cfg := &domain.AutoDispatchConfig{
+		TerminalID:       input.TerminalID,
+		Enabled:          input.Enabled,
+		WindowStart:      input.WindowStart,
+		WindowEnd:        input.WindowEnd,
+		LookaheadMins:    input.LookaheadMins,
+		MaxDistanceMiles: input.MaxDistanceMiles,
+		OverrideMins:     input.OverrideMins,
+		UpdatedBy:        input.UpdatedBy,
+		UpdatedAt:        time.Now(),
+	}
+	if cfg.LookaheadMins <= 0 {
+		cfg.LookaheadMins = defaultAutoDispatchLookaheadMins
+	}
+	if cfg.OverrideMins <= 0 {
+		cfg.OverrideMins = defaultAutoDispatchOverrideMins
+	}
+
+	if err := s.autoRepo.SaveConfig(ctx, cfg); err != nil {
+		return nil, apperrors.DatabaseError("save auto-dispatch config", err)
+	}
+
+	s.logger.Infow("Auto-dispatch configured",
+		"terminal_id", cfg.TerminalID,
+		"enabled", cfg.Enabled,
+		"window", cfg.WindowStart+"-"+cfg.WindowEnd,
+		"updated_by", cfg.UpdatedBy,
+	)
+
+	return cfg, nil
+}
+
+// GetTerminalAutoDispatch returns a terminal's auto-dispatch configuration
+func (s *AutoDispatchService) GetTerminalAutoDispatch(ctx context.Context, terminalID uuid.UUID) (*domain.AutoDispatchConfig, error) {
+	cfg, err := s.autoRepo.GetConfig(ctx, terminalID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("auto-dispatch config", terminalID.String())
+	}
+	return cfg, nil
+}
+
+// GetPendingDecisions returns the proposals still open to dispatcher
+// override, the soonest to commit first
+func (s *AutoDispatchService) GetPendingDecisions(ctx context.Context) ([]domain.AutoDispatchDecision, error) {
+	decisions, err := s.autoRepo.GetPendingDecisions(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get pending decisions", err)
+	}
+	sort.SliceStable(decisions, func(i, j int) bool {
+		a, b := decisions[i].OverrideDeadline, decisions[j].OverrideDeadline
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	return decisions, nil
+}
+
+// Run runs Start on whichever replica holds the auto-dispatch lock, so trips
+// are proposed and confirmed once however many replicas are up
+func (s *AutoDispatchService) Run(ctx context.Context, elector *leader.Elector, interval time.Duration) {
+	elector.Run(ctx, "dispatch.auto-dispatch", func(ctx context.Context) {
+		s.Start(ctx, interval)
+	})
+}
+
+// Start runs auto-dispatch cycles and confirms expired proposals until ctx is cancelled
+func (s *AutoDispatchService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ConfirmExpiredDecisions(ctx); err != nil {
+			s.logger.Errorw("Failed to confirm auto-dispatch decisions", "error", err)
+		}
+		if _, err := s.RunCycle(ctx); err != nil {
+			s.logger.Errorw("Auto-dispatch cycle failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// autoDispatchCandidate is an unassigned trip considered for auto-dispatch
+type autoDispatchCandidate struct {
+	trip        domain.Trip
+	pickup      *domain.Location
+	slaDeadline *time.Time
+}
+
+// RunCycle proposes driver assignments for unassigned trips at every enabled
+// terminal whose dispatch window is open
+func (s *AutoDispatchService) RunCycle(ctx context.Context) ([]domain.AutoDispatchDecision, error) {
+	now := time.Now()
+
+	configs, err := s.autoRepo.ListEnabledConfigs(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list auto-dispatch configs", err)
+	}
+
+	pending, err := s.autoRepo.GetPendingDecisions(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get pending decisions", err)
+	}
+	reservedTrips := make(map[uuid.UUID]bool)
+	reservedDrivers := make(map[uuid.UUID]bool)
+	for _, d := range pending {
+		reservedTrips[d.TripID] = true
+		if d.DriverID != nil {
+			reservedDrivers[*d.DriverID] = true
+		}
+	}
+
+	drivers, err := s.driverRepo.GetAvailable(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get available drivers", err)
+	}
+
+	var decisions []domain.AutoDispatchDecision
+	for i := range configs {
+		cfg := &configs[i]
+		if !cfg.InWindow(now) {
+			continue
+		}
+
+		candidates, err := s.collectCandidates(ctx, cfg, now, reservedTrips)
+		if err != nil {
+			return decisions, err
+		}
+
+		for _, candidate := range candidates {
+			decision, ok := s.selectDriver(cfg, candidate, drivers, reservedDrivers, now)
+			if !ok {
+				if err := s.recordSkip(ctx, decision); err != nil {
+					return decisions, err
+				}
+				continue
+			}
+
+			if err := s.autoRepo.CreateDecision(ctx, decision); err != nil {
+				return decisions, apperrors.DatabaseError("create auto-dispatch decision", err)
+			}
+			reservedDrivers[*decision.DriverID] = true
+			reservedTrips[decision.TripID] = true
+
+			// Notify dispatchers so they can override before the assignment commits
+			event := kafka.NewEvent(kafka.Topics.AutoDispatchProposed, "dispatch-service", map[string]interface{}{
+				"decision_id":       decision.ID.String(),
+				"trip_id":           decision.TripID.String(),
+				"trip_number":       decision.TripNumber,
+				"terminal_id":       decision.TerminalID.String(),
+				"driver_id":         decision.DriverID.String(),
+				"driver_name":       decision.DriverName,
+				"reason":            decision.Reason,
+				"override_deadline": decision.OverrideDeadline,
+			})
+			_ = s.eventProducer.Publish(ctx, kafka.Topics.AutoDispatchProposed, event)
+
+			s.logger.Infow("Auto-dispatch proposed",
+				"decision_id", decision.ID,
+				"trip_number", decision.TripNumber,
+				"driver_id", decision.DriverID,
+				"reason", decision.Reason,
+			)
+			decisions = append(decisions, *decision)
+		}
+	}
+
+	return decisions, nil
+}
+
+// recordSkip records why a trip could not be auto-dispatched. A trip stays a
+// candidate every cycle until it is assigned, so a skip is only recorded
+// when the trip's latest decision is not already a skip. The reason counts
+// drivers, which shift from cycle to cycle, so it is not compared.
+func (s *AutoDispatchService) recordSkip(ctx context.Context, decision *domain.AutoDispatchDecision) error {
+	last, err := s.autoRepo.GetLatestDecision(ctx, decision.TripID)
+	if err != nil {
+		return apperrors.DatabaseError("get latest auto-dispatch decision", err)
+	}
+	if last != nil && last.Status == domain.AutoDispatchStatusSkipped {
+		return nil
+	}
+
+	if err := s.autoRepo.CreateDecision(ctx, decision); err != nil {
+		return apperrors.DatabaseError("create auto-dispatch decision", err)
+	}
+
+	s.logger.Infow("Auto-dispatch skipped trip",
+		"decision_id", decision.ID,
+		"trip_number", decision.TripNumber,
+		"reason", decision.Reason,
+	)
+	return nil
+}
+
+// collectCandidates returns unassigned trips picking up at the terminal within
+// the lookahead, most urgent SLA first
+func (s *AutoDispatchService) collectCandidates(ctx context.Context, cfg *domain.AutoDispatchConfig, now time.Time, reserved map[uuid.UUID]bool) ([]autoDispatchCandidate, error) {
+	trips, err := s.tripRepo.GetByDateRange(ctx, now, now.Add(time.Duration(cfg.LookaheadMins)*time.Minute))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trips", err)
+	}
+
+	var tripIDs []uuid.UUID
+	for _, trip := range trips {
+		if trip.Status == domain.TripStatusPlanned && trip.DriverID == nil && !reserved[trip.ID] {
+			tripIDs = append(tripIDs, trip.ID)
+		}
+	}
+	if len(tripIDs) == 0 {
+		return nil, nil
+	}
+
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	stopsByTrip := make(map[uuid.UUID][]domain.TripStop)
+	for _, stop := range stops {
+		stopsByTrip[stop.TripID] = append(stopsByTrip[stop.TripID], stop)
+	}
+
+	pickup, err := s.locationRepo.GetByID(ctx, cfg.TerminalID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("terminal", cfg.TerminalID.String())
+	}
+
+	var candidates []autoDispatchCandidate
+	for _, trip := range trips {
+		tripStops := stopsByTrip[trip.ID]
+		if len(tripStops) == 0 {
+			continue
+		}
+		sort.Slice(tripStops, func(i, j int) bool {
+			return tripStops[i].Sequence < tripStops[j].Sequence
+		})
+		if tripStops[0].LocationID != cfg.TerminalID {
+			continue
+		}
+
+		candidates = append(candidates, autoDispatchCandidate{
+			trip:        trip,
+			pickup:      pickup,
+			slaDeadline: slaDeadline(&trip, tripStops),
+		})
+	}
+
+	// Earliest SLA first; trips without a deadline go last
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].slaDeadline, candidates[j].slaDeadline
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+
+	return candidates, nil
+}
+
+// selectDriver picks the nearest eligible driver who can reach the pickup
+// before the SLA deadline. When no driver qualifies the returned decision
+// carries the reason and ok is false.
+func (s *AutoDispatchService) selectDriver(cfg *domain.AutoDispatchConfig, candidate autoDispatchCandidate, drivers []domain.Driver, reserved map[uuid.UUID]bool, now time.Time) (*domain.AutoDispatchDecision, bool) {
+	trip := candidate.trip
+	requiredMins := trip.EstimatedDurationMins + autoDispatchHOSBufferMins
+	requireTWIC := strings.EqualFold(candidate.pickup.Type, "terminal")
+
+	decision := &domain.AutoDispatchDecision{
+		ID:          uuid.New(),
+		TripID:      trip.ID,
+		TripNumber:  trip.TripNumber,
+		TerminalID:  cfg.TerminalID,
+		SLADeadline: candidate.slaDeadline,
+		CreatedAt:   now,
+	}
+
+	var best *domain.Driver
+	var bestMiles float64
+	var bestETA int
+	var lackHOS, lackTWIC, restricted, tooFar, missSLA int
+
+	for i := range drivers {
+		driver := &drivers[i]
+		if reserved[driver.ID] {
+			continue
+		}
+		if driver.Status != "AVAILABLE" && driver.Status != "ON_DUTY" {
+			continue
+		}
+		if driver.AvailableDriveMins < requiredMins {
+			lackHOS++
+			continue
+		}
+		if requireTWIC && !driver.HasTWIC {
+			lackTWIC++
+			continue
+		}
+		if checkDriverDocuments(&s.businessRules.Documents, driver, requireTWIC, false) != nil {
+			restricted++
+			continue
+		}
+
+		miles := haversineMiles(driver.CurrentLatitude, driver.CurrentLongitude, candidate.pickup.Latitude, candidate.pickup.Longitude)
+		if cfg.MaxDistanceMiles > 0 && miles > cfg.MaxDistanceMiles {
+			tooFar++
+			continue
+		}
+		etaMins := int(math.Ceil(miles / s.businessRules.Distance.DrayageAverageSpeedMPH * 60))
+		if candidate.slaDeadline != nil && now.Add(time.Duration(etaMins)*time.Minute).After(*candidate.slaDeadline) {
+			missSLA++
+			continue
+		}
+
+		if best == nil || miles < bestMiles {
+			best, bestMiles, bestETA = driver, miles, etaMins
+		}
+	}
+
+	if best == nil {
+		decision.Status = domain.AutoDispatchStatusSkipped
+		decision.Reason = fmt.Sprintf("no eligible driver: %d lacked HOS, %d lacked TWIC, %d restricted by expired documents, %d beyond %.0f mi, %d would miss SLA",
+			lackHOS, lackTWIC, restricted, tooFar, cfg.MaxDistanceMiles, missSLA)
+		return decision, false
+	}
+
+	overrideDeadline := now.Add(time.Duration(cfg.OverrideMins) * time.Minute)
+	decision.DriverID = &best.ID
+	decision.DriverName = best.Name
+	decision.Status = domain.AutoDispatchStatusPending
+	decision.DistanceMiles = math.Round(bestMiles*10) / 10
+	decision.ETAToPickupMins = bestETA
+	decision.OverrideDeadline = &overrideDeadline
+	decision.Reason = fmt.Sprintf("nearest eligible driver: %.1f mi, ETA %d min, %d min drive time available",
+		bestMiles, bestETA, best.AvailableDriveMins)
+	if candidate.slaDeadline != nil {
+		decision.SlackMins = int(candidate.slaDeadline.Sub(now).Minutes()) - bestETA
+		decision.Reason += fmt.Sprintf(", %d min slack to appointment", decision.SlackMins)
+	}
+
+	return decision, true
+}
+
+// ConfirmExpiredDecisions commits proposals whose override period has passed
+func (s *AutoDispatchService) ConfirmExpiredDecisions(ctx context.Context) error {
+	pending, err := s.autoRepo.GetPendingDecisions(ctx)
+	if err != nil {
+		return apperrors.DatabaseError("get pending decisions", err)
+	}
+
+	now := time.Now()
+	for i := range pending {
+		decision := &pending[i]
+		if decision.OverrideDeadline == nil || now.Before(*decision.OverrideDeadline) {
+			continue
+		}
+
+		if err := s.assignDriver(ctx, decision.TripID, *decision.DriverID, true); err != nil {
+			decision.Status = domain.AutoDispatchStatusExpired
+			decision.Reason += "; not applied: " + err.Error()
+			s.logger.Warnw("Auto-dispatch decision expired",
+				"decision_id", decision.ID,
+				"trip_id", decision.TripID,
+				"error", err,
+			)
+		} else {
+			decision.Status = domain.AutoDispatchStatusConfirmed
+		}
+		decision.ResolvedAt = &now
+
+		if err := s.autoRepo.UpdateDecision(ctx, decision); err != nil {
+			return apperrors.DatabaseError("update auto-dispatch decision", err)
+		}
+	}
+
+	return nil
+}
+
+// OverrideDecisionInput contains input for a dispatcher overriding a proposal
+type OverrideDecisionInput struct {
+	DecisionID   uuid.UUID
+	OverriddenBy string
+	Reason       string
+	DriverID     *uuid.UUID // Optional replacement driver, assigned immediately
+}
+
+// OverrideDecision cancels a pending proposal during its override period and
+// optionally assigns a dispatcher-chosen driver instead
+func (s *AutoDispatchService) OverrideDecision(ctx context.Context, input OverrideDecisionInput) (*domain.AutoDispatchDecision, error) {
+	decision, err := s.autoRepo.GetDecision(ctx, input.DecisionID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("auto-dispatch decision", input.DecisionID.String())
+	}
+	if decision.Status != domain.AutoDispatchStatusPending {
+		return nil, apperrors.InvalidStateError(string(decision.Status), string(domain.AutoDispatchStatusPending))
+	}
+
+	now := time.Now()
+	if decision.OverrideDeadline != nil && now.After(*decision.OverrideDeadline) {
+		return nil, apperrors.New("OVERRIDE_WINDOW_CLOSED", "override period for this decision has ended")
+	}
+
+	if input.DriverID != nil {
+		if err := s.assignDriver(ctx, decision.TripID, *input.DriverID, false); err != nil {
+			return nil, err
+		}
+	}
+
+	decision.Status = domain.AutoDispatchStatusOverridden
+	decision.OverriddenBy = input.OverriddenBy
+	decision.OverrideReason = input.Reason
+	decision.ResolvedAt = &now
+
+	if err := s.autoRepo.UpdateDecision(ctx, decision); err != nil {
+		return nil, apperrors.DatabaseError("update auto-dispatch decision", err)
+	}
+
+	s.logger.Infow("Auto-dispatch overridden",
+		"decision_id", decision.ID,
+		"trip_id", decision.TripID,
+		"overridden_by", input.OverriddenBy,
+		"reason", input.Reason,
+	)
+
+	return decision, nil
+}
+
+// assignDriver assigns a driver to a still-unassigned trip
+func (s *AutoDispatchService) assignDriver(ctx context.Context, tripID, driverID uuid.UUID, auto bool) error {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return apperrors.NotFoundError("trip", tripID.String())
+	}
+	if trip.Status != domain.TripStatusPlanned || trip.DriverID != nil {
+		return apperrors.InvalidStateError(string(trip.Status), string(domain.TripStatusPlanned))
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return apperrors.NotFoundError("driver", driverID.String())
+	}
+	if driver.Status != "AVAILABLE" && driver.Status != "ON_DUTY" {
+		return apperrors.InvalidStateError(driver.Status, "AVAILABLE or ON_DUTY")
+	}
+
+	trip.DriverID = &driverID
+	trip.Status = domain.TripStatusAssigned
+	trip.UpdatedAt = time.Now()
+
+	if err := s.tripRepo.Update(ctx, trip); err != nil {
+		return tripUpdateError(ctx, s.tripRepo, tripID, "assign driver", err)
+	}
+	by := ""
+	if auto {
+		by = "auto-dispatch"
+	}
+	recordHOSSnapshot(ctx, s.snapshotRepo, s.logger, &s.businessRules.BreakPlanning, trip, driver, domain.HOSSnapshotAssigned, by, time.Now())
+
+	event := kafka.NewEvent(kafka.Topics.TripAssigned, "dispatch-service", map[string]interface{}{
+		"trip_id":         tripID.String(),
+		"driver_id":       driverID.String(),
+		"driver_name":     driver.Name,
+		"trip_number":     trip.TripNumber,
+		"auto_dispatched": auto,
+	}).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAssigned, event)
+
+	return nil
+}
+
+// slaDeadline returns the earliest appointment on the trip, falling back to
+// the planned start time
+func slaDeadline(trip *domain.Trip, stops []domain.TripStop) *time.Time {
+	var deadline *time.Time
+	for i := range stops {
+		appt := stops[i].AppointmentTime
+		if appt != nil && (deadline == nil || appt.Before(*deadline)) {
+			deadline = appt
+		}
+	}
+	if deadline == nil {
+		return trip.PlannedStartTime
+	}
+	return deadline
+}
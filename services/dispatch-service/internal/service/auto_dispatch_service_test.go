@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// fakeAutoDispatchRepo records created decisions and serves the trip's latest one
+type fakeAutoDispatchRepo struct {
+	repository.AutoDispatchRepository
+	latest  *domain.AutoDispatchDecision
+	created []domain.AutoDispatchDecision
+}
+
+func (f *fakeAutoDispatchRepo) GetLatestDecision(ctx context.Context, tripID uuid.UUID) (*domain.AutoDispatchDecision, error) {
+	return f.latest, nil
+}
+
+func (f *fakeAutoDispatchRepo) CreateDecision(ctx context.Context, decision *domain.AutoDispatchDecision) error {
+	f.created = append(f.created, *decision)
+	return nil
+}
+
+func newTestAutoDispatchService(t *testing.T, autoRepo repository.AutoDispatchRepository) *AutoDispatchService {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AutoDispatchService{autoRepo: autoRepo, logger: log, businessRules: config.DefaultBusinessRules()}
+}
+
+// Pickup at the port; 0.1 degrees of latitude is about 6.9 miles
+const (
+	testPickupLat = 33.75
+	testPickupLon = -118.22
+)
+
+func testDriver(name string, milesNorth float64, modify func(*domain.Driver)) domain.Driver {
+	d := domain.Driver{
+		ID:                 uuid.New(),
+		Name:               name,
+		Status:             "AVAILABLE",
+		CurrentLatitude:    testPickupLat + milesNorth/69.0,
+		CurrentLongitude:   testPickupLon,
+		AvailableDriveMins: 600,
+		HasTWIC:            true,
+	}
+	if modify != nil {
+		modify(&d)
+	}
+	return d
+}
+
+func TestSelectDriver(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	soon := now.Add(10 * time.Minute)
+	later := now.Add(3 * time.Hour)
+
+	tests := []struct {
+		name        string
+		pickupType  string
+		maxMiles    float64
+		sla         *time.Time
+		tripMins    int
+		drivers     []domain.Driver
+		reserved    []int // Indexes into drivers
+		wantDriver  string
+		wantReasons []string // Substrings of the skip reason when no driver qualifies
+	}{
+		{
+			name:       "nearest eligible driver wins",
+			pickupType: "terminal",
+			sla:        &later,
+			drivers: []domain.Driver{
+				testDriver("far", 20, nil),
+				testDriver("near", 5, nil),
+				testDriver("middle", 10, nil),
+			},
+			wantDriver: "near",
+		},
+		{
+			name:       "driver without drive time for the trip and buffer is passed over",
+			pickupType: "terminal",
+			tripMins:   120,
+			drivers: []domain.Driver{
+				testDriver("near", 5, func(d *domain.Driver) { d.AvailableDriveMins = 149 }),
+				testDriver("far", 20, nil),
+			},
+			wantDriver: "far",
+		},
+		{
+			name:       "terminal pickup needs a TWIC",
+			pickupType: "TERMINAL",
+			drivers: []domain.Driver{
+				testDriver("near", 5, func(d *domain.Driver) { d.HasTWIC = false }),
+				testDriver("far", 20, nil),
+			},
+			wantDriver: "far",
+		},
+		{
+			name:       "warehouse pickup does not need a TWIC",
+			pickupType: "warehouse",
+			drivers: []domain.Driver{
+				testDriver("near", 5, func(d *domain.Driver) { d.HasTWIC = false }),
+				testDriver("far", 20, nil),
+			},
+			wantDriver: "near",
+		},
+		{
+			name:       "reserved driver is passed over",
+			pickupType: "terminal",
+			drivers: []domain.Driver{
+				testDriver("near", 5, nil),
+				testDriver("far", 20, nil),
+			},
+			reserved:   []int{0},
+			wantDriver: "far",
+		},
+		{
+			name:       "off duty driver is passed over",
+			pickupType: "terminal",
+			drivers: []domain.Driver{
+				testDriver("near", 5, func(d *domain.Driver) { d.Status = "OFF_DUTY" }),
+				testDriver("far", 20, nil),
+			},
+			wantDriver: "far",
+		},
+		{
+			name:       "owner-operator on hold is passed over",
+			pickupType: "terminal",
+			drivers: []domain.Driver{
+				testDriver("near", 5, func(d *domain.Driver) { d.CarrierHold = "authority inactive" }),
+				testDriver("far", 20, nil),
+			},
+			wantDriver: "far",
+		},
+		{
+			name:       "drivers beyond the terminal's distance limit are passed over",
+			pickupType: "terminal",
+			maxMiles:   15,
+			drivers: []domain.Driver{
+				testDriver("far", 20, nil),
+				testDriver("middle", 10, nil),
+			},
+			wantDriver: "middle",
+		},
+		{
+			name:       "no driver in range",
+			pickupType: "terminal",
+			maxMiles:   15,
+			drivers: []domain.Driver{
+				testDriver("far", 20, nil),
+			},
+			wantReasons: []string{"1 beyond 15 mi"},
+		},
+		{
+			name:       "no driver can make the appointment",
+			pickupType: "terminal",
+			sla:        &soon,
+			drivers: []domain.Driver{
+				testDriver("near", 10, nil),
+			},
+			wantReasons: []string{"1 would miss SLA"},
+		},
+		{
+			name:       "skip reason counts each cause",
+			pickupType: "terminal",
+			drivers: []domain.Driver{
+				testDriver("tired", 5, func(d *domain.Driver) { d.AvailableDriveMins = 10 }),
+				testDriver("no twic", 5, func(d *domain.Driver) { d.HasTWIC = false }),
+				testDriver("held", 5, func(d *domain.Driver) { d.CarrierHold = "insurance lapsed" }),
+			},
+			wantReasons: []string{"1 lacked HOS", "1 lacked TWIC", "1 restricted by expired documents"},
+		},
+		{
+			name:        "no drivers",
+			pickupType:  "terminal",
+			wantReasons: []string{"no eligible driver"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAutoDispatchService(t, nil)
+			cfg := &domain.AutoDispatchConfig{
+				TerminalID:       uuid.New(),
+				MaxDistanceMiles: tt.maxMiles,
+				OverrideMins:     5,
+			}
+			candidate := autoDispatchCandidate{
+				trip:        domain.Trip{ID: uuid.New(), TripNumber: "TRP-1", EstimatedDurationMins: tt.tripMins},
+				pickup:      &domain.Location{Type: tt.pickupType, Latitude: testPickupLat, Longitude: testPickupLon},
+				slaDeadline: tt.sla,
+			}
+			reserved := make(map[uuid.UUID]bool)
+			for _, i := range tt.reserved {
+				reserved[tt.drivers[i].ID] = true
+			}
+
+			decision, ok := s.selectDriver(cfg, candidate, tt.drivers, reserved, now)
+
+			if tt.wantDriver == "" {
+				if ok {
+					t.Fatalf("selected %s, want none", decision.DriverName)
+				}
+				if decision.Status != domain.AutoDispatchStatusSkipped || decision.DriverID != nil {
+					t.Errorf("status = %s, driver = %v; want skipped with no driver", decision.Status, decision.DriverID)
+				}
+				for _, want := range tt.wantReasons {
+					if !strings.Contains(decision.Reason, want) {
+						t.Errorf("reason %q does not contain %q", decision.Reason, want)
+					}
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("no driver selected: %s", decision.Reason)
+			}
+			if decision.DriverName != tt.wantDriver {
+				t.Errorf("selected %s, want %s", decision.DriverName, tt.wantDriver)
+			}
+			if decision.Status != domain.AutoDispatchStatusPending {
+				t.Errorf("status = %s, want %s", decision.Status, domain.AutoDispatchStatusPending)
+			}
+			if want := now.Add(5 * time.Minute); decision.OverrideDeadline == nil || !decision.OverrideDeadline.Equal(want) {
+				t.Errorf("override deadline = %v, want %v", decision.OverrideDeadline, want)
+			}
+		})
+	}
+}
+
+func TestSelectDriverDistanceAndSlack(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	sla := now.Add(time.Hour)
+	s := newTestAutoDispatchService(t, nil)
+
+	decision, ok := s.selectDriver(
+		&domain.AutoDispatchConfig{TerminalID: uuid.New(), OverrideMins: 5},
+		autoDispatchCandidate{
+			trip:        domain.Trip{ID: uuid.New()},
+			pickup:      &domain.Location{Type: "terminal", Latitude: testPickupLat, Longitude: testPickupLon},
+			slaDeadline: &sla,
+		},
+		[]domain.Driver{testDriver("driver", 6.9, nil)},
+		nil,
+		now,
+	)
+	if !ok {
+		t.Fatalf("no driver selected: %s", decision.Reason)
+	}
+	// 6.9 miles at the 35 mph drayage average is 12 minutes, rounded up
+	if decision.DistanceMiles != 6.9 {
+		t.Errorf("distance = %v, want 6.9", decision.DistanceMiles)
+	}
+	if decision.ETAToPickupMins != 12 {
+		t.Errorf("ETA = %d, want 12", decision.ETAToPickupMins)
+	}
+	if decision.SlackMins != 48 {
+		t.Errorf("slack = %d, want 48", decision.SlackMins)
+	}
+}
+
+func TestRecordSkip(t *testing.T) {
+	tripID := uuid.New()
+	skip := func(reason string) *domain.AutoDispatchDecision {
+		return &domain.AutoDispatchDecision{
+			ID:     uuid.New(),
+			TripID: tripID,
+			Status: domain.AutoDispatchStatusSkipped,
+			Reason: reason,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		latest     *domain.AutoDispatchDecision
+		wantRecord bool
+	}{
+		{"first skip is recorded", nil, true},
+		{"skip with the same reason is not recorded again", skip("no eligible driver: 2 lacked HOS"), false},
+		{"skip with different driver counts is not recorded again", skip("no eligible driver: 3 lacked HOS"), false},
+		{"skip after an overridden proposal is recorded", &domain.AutoDispatchDecision{
+			ID: uuid.New(), TripID: tripID, Status: domain.AutoDispatchStatusOverridden,
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAutoDispatchRepo{latest: tt.latest}
+			s := newTestAutoDispatchService(t, repo)
+
+			if err := s.recordSkip(context.Background(), skip("no eligible driver: 2 lacked HOS")); err != nil {
+				t.Fatal(err)
+			}
+			if recorded := len(repo.created) == 1; recorded != tt.wantRecord {
+				t.Errorf("recorded = %v, want %v", recorded, tt.wantRecord)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// AxleWeightService estimates how a loaded container's weight is spread
+// over the steer, drive and chassis axles from the container, chassis and
+// tractor, and warns when a group is over the legal limit in a state on
+// the route. Gross weight alone can be legal with an axle group overloaded.
+type AxleWeightService struct {
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	containerRepo repository.ContainerRepository
+	tractorRepo   repository.TractorRepository
+	chassisRepo   repository.ChassisRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewAxleWeightService creates a new axle weight service
+func NewAxleWeightService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	tractorRepo repository.TractorRepository,
+	chassisRepo repository.ChassisRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *AxleWeightService {
+	return &AxleWeightService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		containerRepo: containerRepo,
+		tractorRepo:   tractorRepo,
+		chassisRepo:   chassisRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// EstimateAxleWeightsInput contains input for estimating axle weights
+// before a container is put on a trip
+type EstimateAxleWeightsInput struct {
+	ContainerID    *uuid.UUID // Size and weight are read from the container when given
+	ContainerSize  string
+	GrossWeightLbs int        // Container and cargo
+	ChassisID      *uuid.UUID // Otherwise ChassisType, otherwise the default for the size
+	ChassisType    string     // Chassis profile such as "40_tri_axle"
+	TractorID      *uuid.UUID // Otherwise TractorConfig, otherwise the default
+	TractorConfig  string     // single or tandem
+	States         []string   // States the move runs through
+}
+
+// EstimateAxleWeights estimates axle loads for a container and checks them
+// against the limits of the given states
+func (s *AxleWeightService) EstimateAxleWeights(ctx context.Context, input EstimateAxleWeightsInput) (*domain.AxleWeightCheck, error) {
+	rules := &s.businessRules.AxleWeight
+
+	load := domain.ContainerLoad{
+		ContainerSize:  input.ContainerSize,
+		GrossWeightLbs: input.GrossWeightLbs,
+	}
+	if input.ContainerID != nil {
+		loads, err := s.containerRepo.GetLoads(ctx, []uuid.UUID{*input.ContainerID})
+		if err != nil {
+			return nil, apperrors.DatabaseError("get container load", err)
+		}
+		found, ok := loads[*input.ContainerID]
+		if !ok {
+			return nil, apperrors.NotFoundError("container", input.ContainerID.String())
+		}
+		load = found
+	}
+	if load.GrossWeightLbs <= 0 {
+		return nil, apperrors.ValidationError("gross weight is required", "gross_weight_lbs", load.GrossWeightLbs)
+	}
+	if _, ok := rules.DefaultChassis[load.ContainerSize]; !ok {
+		return nil, apperrors.ValidationError("unsupported container size", "container_size", load.ContainerSize)
+	}
+
+	chassisType := input.ChassisType
+	if chassisType != "" {
+		if _, ok := rules.Chassis[chassisType]; !ok {
+			return nil, apperrors.ValidationError("unknown chassis type", "chassis_type", chassisType)
+		}
+	}
+	if input.ChassisID != nil {
+		chassis, err := s.chassisRepo.GetByID(ctx, *input.ChassisID)
+		if err != nil || chassis == nil {
+			return nil, apperrors.NotFoundError("chassis", input.ChassisID.String())
+		}
+		chassisType = chassis.ProfileKey()
+	}
+
+	tractorConfig := input.TractorConfig
+	if tractorConfig != "" {
+		if _, ok := rules.Tractors[tractorConfig]; !ok {
+			return nil, apperrors.ValidationError("unknown tractor config", "tractor_config", tractorConfig)
+		}
+	}
+	if input.TractorID != nil {
+		tractor, err := s.tractorRepo.GetByID(ctx, *input.TractorID)
+		if err != nil || tractor == nil {
+			return nil, apperrors.NotFoundError("tractor", input.TractorID.String())
+		}
+		tractorConfig = tractor.AxleConfig
+	}
+
+	states := make([]string, len(input.States))
+	for i, state := range input.States {
+		states[i] = strings.ToUpper(strings.TrimSpace(state))
+	}
+	return s.check(load, chassisType, tractorConfig, distinctStates(states)), nil
+}
+
+// CheckTrip estimates axle loads for each container picked up loaded on the
+// trip, on the chassis it leaves on and the trip's tractor, against the
+// states of the stops from the pickup on. It publishes a warning when any
+// container is over a limit.
+func (s *AxleWeightService) CheckTrip(ctx context.Context, tripID uuid.UUID) (*domain.TripAxleWeights, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Sequence < stops[j].Sequence })
+
+	result := &domain.TripAxleWeights{TripID: trip.ID, TripNumber: trip.TripNumber, Checks: []domain.AxleWeightCheck{}}
+
+	var containerIDs []uuid.UUID
+	for _, stop := range stops {
+		if stop.ContainerID != nil && isLoadedPickup(stop.Activity) {
+			containerIDs = append(containerIDs, *stop.ContainerID)
+		}
+	}
+	if len(containerIDs) == 0 {
+		return result, nil
+	}
+	loads, err := s.containerRepo.GetLoads(ctx, containerIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get container loads", err)
+	}
+
+	states := make([]string, len(stops))
+	for i, stop := range stops {
+		location, err := s.locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return nil, apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+		states[i] = location.State
+	}
+
+	tractorConfig := ""
+	if trip.TractorID != nil {
+		if tractor, err := s.tractorRepo.GetByID(ctx, *trip.TractorID); err == nil && tractor != nil {
+			tractorConfig = tractor.AxleConfig
+		}
+	}
+
+	for i, stop := range stops {
+		if stop.ContainerID == nil || !isLoadedPickup(stop.Activity) {
+			continue
+		}
+		load, ok := loads[*stop.ContainerID]
+		if !ok || load.GrossWeightLbs <= 0 {
+			continue
+		}
+
+		chassisType := ""
+		chassisID := stop.ChassisOutID
+		if chassisID == nil {
+			chassisID = trip.ChassisID
+		}
+		if chassisID != nil {
+			if chassis, err := s.chassisRepo.GetByID(ctx, *chassisID); err == nil && chassis != nil {
+				chassisType = chassis.ProfileKey()
+			}
+		}
+
+		check := s.check(load, chassisType, tractorConfig, distinctStates(states[i:]))
+		check.ContainerID = stop.ContainerID
+		result.Checks = append(result.Checks, *check)
+	}
+
+	if result.Overweight() {
+		s.publishWarning(ctx, result)
+	}
+	return result, nil
+}
+
+// check estimates the loads for a container and compares them with each
+// state's limits. An empty or unconfigured chassis type or tractor config
+// is replaced by the default and flagged as assumed.
+func (s *AxleWeightService) check(load domain.ContainerLoad, chassisType, tractorConfig string, states []string) *domain.AxleWeightCheck {
+	rules := &s.businessRules.AxleWeight
+
+	check := &domain.AxleWeightCheck{
+		ContainerNumber: load.ContainerNumber,
+		ContainerSize:   load.ContainerSize,
+		GrossWeightLbs:  load.GrossWeightLbs,
+		ChassisType:     chassisType,
+		TractorConfig:   tractorConfig,
+		States:          states,
+		Violations:      []domain.AxleViolation{},
+	}
+	if check.States == nil {
+		check.States = []string{}
+	}
+
+	chassis, ok := rules.Chassis[chassisType]
+	if _, fits := chassis.LoadCenterFt[load.ContainerSize]; !ok || !fits {
+		check.ChassisType = rules.DefaultChassis[load.ContainerSize]
+		check.ChassisAssumed = true
+		chassis = rules.Chassis[check.ChassisType]
+	}
+	tractor, ok := rules.Tractors[tractorConfig]
+	if !ok {
+		check.TractorConfig = rules.DefaultTractor
+		check.TractorAssumed = true
+		tractor = rules.Tractors[check.TractorConfig]
+	}
+
+	check.Loads = estimateAxleLoads(load.GrossWeightLbs, chassis.LoadCenterFt[load.ContainerSize], chassis, tractor)
+	check.Violations = s.axleViolations(check.Loads, chassis, tractor, states)
+	if !check.Overweight() {
+		return check
+	}
+
+	// Other chassis for the size that keep every group legal
+	keys := make([]string, 0, len(rules.Chassis))
+	for key := range rules.Chassis {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		alt := rules.Chassis[key]
+		center, fits := alt.LoadCenterFt[load.ContainerSize]
+		if key == check.ChassisType || !fits {
+			continue
+		}
+		loads := estimateAxleLoads(load.GrossWeightLbs, center, alt, tractor)
+		if len(s.axleViolations(loads, alt, tractor, states)) == 0 {
+			check.Alternatives = append(check.Alternatives, domain.ChassisAlternative{ChassisType: key, Loads: loads})
+		}
+	}
+
+	if len(check.Alternatives) == 0 {
+		seen := make(map[string]bool)
+		for _, v := range check.Violations {
+			if v.State != "" && !seen[v.State] {
+				seen[v.State] = true
+				check.PermitStates = append(check.PermitStates, v.State)
+			}
+		}
+	}
+	return check
+}
+
+// axleViolations compares loads with the limits of each state, or the
+// default limits when the route has no states
+func (s *AxleWeightService) axleViolations(loads domain.AxleLoads, chassis config.ChassisProfile, tractor config.TractorProfile, states []string) []domain.AxleViolation {
+	rules := &s.businessRules.AxleWeight
+	if len(states) == 0 {
+		states = []string{""}
+	}
+
+	violations := []domain.AxleViolation{}
+	for _, state := range states {
+		limits := rules.DefaultLimits
+		if state != "" {
+			limits = rules.Limits(state)
+		}
+		groups := []struct {
+			group domain.AxleGroup
+			load  int
+			limit int
+		}{
+			{domain.AxleGroupSteer, loads.SteerLbs, limits.SteerLbs},
+			{domain.AxleGroupDrive, loads.DriveLbs, limits.GroupLbs(tractor.DriveAxles)},
+			{domain.AxleGroupChassis, loads.ChassisLbs, limits.GroupLbs(chassis.Axles)},
+			{domain.AxleGroupGross, loads.GrossLbs, limits.GrossLbs},
+		}
+		for _, g := range groups {
+			if g.limit > 0 && g.load > g.limit {
+				violations = append(violations, domain.AxleViolation{
+					State:    state,
+					Group:    g.group,
+					LoadLbs:  g.load,
+					LimitLbs: g.limit,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// estimateAxleLoads splits the container and chassis between the kingpin
+// and the chassis axles by the container's position, assuming the cargo is
+// centered in the box, then splits the kingpin load between the tractor's
+// steer and drive axles by the fifth wheel position
+func estimateAxleLoads(grossLbs int, loadCenterFt float64, chassis config.ChassisProfile, tractor config.TractorProfile) domain.AxleLoads {
+	kingpin := 0.0
+	if chassis.AxleCenterFt > 0 {
+		kingpin = math.Max(float64(grossLbs)*(1-loadCenterFt/chassis.AxleCenterFt), 0)
+	}
+	kingpin += float64(chassis.KingpinTareLbs)
+
+	steerShare := 0.0
+	if tractor.WheelbaseFt > 0 {
+		steerShare = tractor.FifthWheelFt / tractor.WheelbaseFt
+	}
+	onSteer := int(math.Round(kingpin * steerShare))
+	onKingpin := int(math.Round(kingpin))
+
+	loads := domain.AxleLoads{
+		SteerLbs:   tractor.SteerTareLbs + onSteer,
+		DriveLbs:   tractor.DriveTareLbs + onKingpin - onSteer,
+		ChassisLbs: grossLbs + chassis.TareLbs - onKingpin,
+	}
+	loads.GrossLbs = loads.SteerLbs + loads.DriveLbs + loads.ChassisLbs
+	return loads
+}
+
+// distinctStates returns the non-empty states in order of first appearance
+func distinctStates(states []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, state := range states {
+		if state == "" || seen[state] {
+			continue
+		}
+		seen[state] = true
+		result = append(result, state)
+	}
+	return result
+}
+
+func (s *AxleWeightService) publishWarning(ctx context.Context, result *domain.TripAxleWeights) {
+	var containers []map[string]interface{}
+	for _, check := range result.Checks {
+		if !check.Overweight() {
+			continue
+		}
+		alternatives := make([]string, len(check.Alternatives))
+		for i, alt := range check.Alternatives {
+			alternatives[i] = alt.ChassisType
+		}
+		containers = append(containers, map[string]interface{}{
+			"container_number": check.ContainerNumber,
+			"chassis_type":     check.ChassisType,
+			"violations":       check.Violations,
+			"alternatives":     alternatives,
+			"permit_states":    check.PermitStates,
+		})
+
+		s.logger.Warnw("Estimated axle loads over legal limit",
+			"trip_number", result.TripNumber,
+			"container", check.ContainerNumber,
+			"chassis_type", check.ChassisType,
+			"violations", len(check.Violations),
+			"alternatives", alternatives,
+		)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.TripAxleWeightWarning, "dispatch-service", map[string]interface{}{
+		"trip_id":     result.TripID.String(),
+		"trip_number": result.TripNumber,
+		"containers":  containers,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAxleWeightWarning, event)
+}
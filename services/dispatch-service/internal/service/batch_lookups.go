@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// maxBatchLookup caps the IDs in one batch lookup
+const maxBatchLookup = 500
+
+// GetTripStops returns the stops of several trips at once, ordered by trip
+// and then sequence. The API gateway uses it to resolve nested queries
+// without a call per trip.
+func (s *DispatchService) GetTripStops(ctx context.Context, tripIDs []uuid.UUID) ([]domain.TripStop, error) {
+	if err := checkBatchLookup("trip_ids", tripIDs); err != nil || len(tripIDs) == 0 {
+		return nil, err
+	}
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	order := make(map[uuid.UUID]int, len(tripIDs))
+	for i, id := range tripIDs {
+		if _, ok := order[id]; !ok {
+			order[id] = i
+		}
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		if stops[i].TripID != stops[j].TripID {
+			return order[stops[i].TripID] < order[stops[j].TripID]
+		}
+		return stops[i].Sequence < stops[j].Sequence
+	})
+	return stops, nil
+}
+
+// GetLocations returns several locations at once, leaving out unknown IDs
+func (s *DispatchService) GetLocations(ctx context.Context, ids []uuid.UUID) ([]domain.Location, error) {
+	if err := checkBatchLookup("ids", ids); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	locations, err := s.locationRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get locations", err)
+	}
+	return locations, nil
+}
+
+// GetDrivers returns several drivers at once, with their HOS clocks as last
+// synced, leaving out unknown IDs
+func (s *DispatchService) GetDrivers(ctx context.Context, ids []uuid.UUID) ([]domain.Driver, error) {
+	if err := checkBatchLookup("ids", ids); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	drivers, err := s.driverRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get drivers", err)
+	}
+	return drivers, nil
+}
+
+func checkBatchLookup(field string, ids []uuid.UUID) error {
+	if len(ids) > maxBatchLookup {
+		return apperrors.ValidationError(fmt.Sprintf("cannot look up more than %d at once", maxBatchLookup), field, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// BreakPlanService predicts when a driver's 30-minute break falls due on a
+// trip, suggests truck stops and rest areas near that point on the route,
+// and checks the driver's GPS trail afterwards for where it was taken
+type BreakPlanService struct {
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	driverRepo    repository.DriverRepository
+	locationRepo  repository.LocationRepository
+	breakRepo     repository.BreakPlanRepository
+	positionRepo  repository.PositionRepository
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewBreakPlanService creates a new break plan service
+func NewBreakPlanService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	locationRepo repository.LocationRepository,
+	breakRepo repository.BreakPlanRepository,
+	positionRepo repository.PositionRepository,
+	log *logger.Logger,
+) *BreakPlanService {
+	return &BreakPlanService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		breakRepo:     breakRepo,
+		positionRepo:  positionRepo,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// breakPoint is where on the route a break falls due
+type breakPoint struct {
+	dueAt         time.Time
+	afterSequence int
+	latitude      float64
+	longitude     float64
+}
+
+// PlanBreak predicts whether the trip's driver reaches the driving limit
+// before the trip ends and, if so, saves a plan with the truck stops and rest
+// areas nearest the point on the route where they should stop. It returns
+// nil, and removes any earlier plan, when no break falls due on the trip.
+func (s *BreakPlanService) PlanBreak(ctx context.Context, tripID uuid.UUID) (*domain.BreakPlan, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	if trip.DriverID == nil {
+		return nil, apperrors.InvalidStateError("unassigned", "assigned to a driver")
+	}
+	driver, err := s.driverRepo.GetByID(ctx, *trip.DriverID)
+	if err != nil || driver == nil {
+		return nil, apperrors.NotFoundError("driver", trip.DriverID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Sequence < stops[j].Sequence })
+
+	locations := make(map[uuid.UUID]*domain.Location, len(stops))
+	for _, stop := range stops {
+		if _, ok := locations[stop.LocationID]; ok {
+			continue
+		}
+		location, err := s.locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return nil, apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+		locations[stop.LocationID] = location
+	}
+
+	rules := &s.businessRules.BreakPlanning
+	start := time.Now()
+	if trip.ActualStartTime != nil {
+		start = *trip.ActualStartTime
+	} else if trip.PlannedStartTime != nil {
+		start = *trip.PlannedStartTime
+	}
+
+	// HOS only reports driving left in the shift, so assume no break has
+	// been taken yet; the suggestion may come early but never late
+	driven := rules.DailyDriveLimitMins - driver.AvailableDriveMins
+	if driven < 0 {
+		driven = 0
+	}
+
+	point := s.predictBreak(stops, locations, start, driven)
+	if point == nil {
+		if err := s.breakRepo.DeleteByTripID(ctx, tripID); err != nil {
+			return nil, apperrors.DatabaseError("delete break plan", err)
+		}
+		return nil, nil
+	}
+
+	found, err := s.locationRepo.FindRestStops(ctx, point.latitude, point.longitude, rules.SearchRadiusMiles, rules.MaxSuggestions)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find rest stops", err)
+	}
+	suggestions := make([]domain.BreakSuggestion, len(found))
+	for i, location := range found {
+		suggestions[i] = domain.BreakSuggestion{
+			Location:      location,
+			DistanceMiles: math.Round(haversineMiles(point.latitude, point.longitude, location.Latitude, location.Longitude)*10) / 10,
+		}
+	}
+
+	now := time.Now()
+	plan := &domain.BreakPlan{
+		ID:                uuid.New(),
+		TripID:            tripID,
+		DriverID:          driver.ID,
+		DueAt:             point.dueAt,
+		AfterStopSequence: point.afterSequence,
+		Latitude:          point.latitude,
+		Longitude:         point.longitude,
+		DrivenMins:        driven,
+		Suggestions:       suggestions,
+		Status:            domain.BreakPlanPlanned,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.breakRepo.Upsert(ctx, plan); err != nil {
+		return nil, apperrors.DatabaseError("save break plan", err)
+	}
+
+	s.logger.Infow("Driver break planned",
+		"trip_id", tripID,
+		"driver_id", driver.ID,
+		"due_at", plan.DueAt,
+		"after_stop", plan.AfterStopSequence,
+		"suggestions", len(suggestions),
+	)
+	return plan, nil
+}
+
+// GetBreakPlan returns the trip's break plan, or nil when no break falls due on it
+func (s *BreakPlanService) GetBreakPlan(ctx context.Context, tripID uuid.UUID) (*domain.BreakPlan, error) {
+	plan, err := s.breakRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get break plan", err)
+	}
+	return plan, nil
+}
+
+// predictBreak walks the stops in order from start, counting driving time at
+// the drayage average speed, and returns the point where the driver has
+// LeadMins of driving left before the limit. A stop long enough to count as
+// the break restarts the count. It returns nil when the trip ends first.
+func (s *BreakPlanService) predictBreak(stops []domain.TripStop, locations map[uuid.UUID]*domain.Location, start time.Time, driven int) *breakPoint {
+	rules := &s.businessRules.BreakPlanning
+	limit := float64(rules.BreakAfterDriveMins)
+	target := limit - float64(rules.LeadMins)
+	speed := s.businessRules.Distance.DrayageAverageSpeedMPH
+	if len(stops) < 2 || speed <= 0 {
+		return nil
+	}
+
+	at := start
+	used := float64(driven)
+	for i := 0; i < len(stops)-1; i++ {
+		if stops[i].EstimatedDurationMins >= rules.BreakDurationMins {
+			used = 0
+		}
+		at = at.Add(time.Duration(stops[i].EstimatedDurationMins) * time.Minute)
+
+		from := locations[stops[i].LocationID]
+		to := locations[stops[i+1].LocationID]
+		if used >= target {
+			return &breakPoint{
+				dueAt:         at.Add(time.Duration(math.Max(limit-used, 0) * float64(time.Minute))),
+				afterSequence: stops[i].Sequence,
+				latitude:      from.Latitude,
+				longitude:     from.Longitude,
+			}
+		}
+
+		legMins := haversineMiles(from.Latitude, from.Longitude, to.Latitude, to.Longitude) / speed * 60
+		if used+legMins >= target {
+			// Straight-line interpolation is close enough over a drayage leg
+			frac := (target - used) / legMins
+			return &breakPoint{
+				dueAt:         at.Add(time.Duration((limit - used) * float64(time.Minute))),
+				afterSequence: stops[i].Sequence,
+				latitude:      from.Latitude + (to.Latitude-from.Latitude)*frac,
+				longitude:     from.Longitude + (to.Longitude-from.Longitude)*frac,
+			}
+		}
+		used += legMins
+		at = at.Add(time.Duration(legMins * float64(time.Minute)))
+	}
+	return nil
+}
+
+// EvaluateBreakPlan checks the driver's GPS trail over the trip for a stop
+// long enough to count as the break, and records whether it was at one of
+// the suggested locations. It returns nil when the trip has no plan.
+func (s *BreakPlanService) EvaluateBreakPlan(ctx context.Context, tripID uuid.UUID) (*domain.BreakPlan, error) {
+	plan, err := s.breakRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get break plan", err)
+	}
+	if plan == nil {
+		return nil, nil
+	}
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+
+	since := plan.CreatedAt
+	if trip.ActualStartTime != nil {
+		since = *trip.ActualStartTime
+	}
+	trail, err := s.positionRepo.GetByDriverID(ctx, plan.DriverID, since)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver positions", err)
+	}
+	if trip.ActualEndTime != nil {
+		end := len(trail)
+		for end > 0 && trail[end-1].RecordedAt.After(*trip.ActualEndTime) {
+			end--
+		}
+		trail = trail[:end]
+	}
+
+	s.applyBreakTaken(plan, s.findBreaks(trail))
+	plan.UpdatedAt = time.Now()
+	if err := s.breakRepo.Update(ctx, plan); err != nil {
+		return nil, apperrors.DatabaseError("update break plan", err)
+	}
+
+	s.logger.Infow("Driver break evaluated",
+		"trip_id", tripID,
+		"driver_id", plan.DriverID,
+		"status", plan.Status,
+		"taken_mins", plan.TakenMins,
+	)
+	return plan, nil
+}
+
+// breakStop is a spell where the driver stayed in one place
+type breakStop struct {
+	at        time.Time
+	mins      int
+	latitude  float64
+	longitude float64
+}
+
+// findBreaks returns the spells on the trail where the driver stayed within
+// TakenRadiusMiles of one spot for at least BreakDurationMins
+func (s *BreakPlanService) findBreaks(trail []domain.PositionFix) []breakStop {
+	rules := &s.businessRules.BreakPlanning
+	minDuration := time.Duration(rules.BreakDurationMins) * time.Minute
+
+	var breaks []breakStop
+	for i := 0; i < len(trail); {
+		anchor := trail[i]
+		j := i + 1
+		for j < len(trail) && haversineMiles(anchor.Latitude, anchor.Longitude, trail[j].Latitude, trail[j].Longitude) <= rules.TakenRadiusMiles {
+			j++
+		}
+		if spell := trail[j-1].RecordedAt.Sub(anchor.RecordedAt); spell >= minDuration {
+			breaks = append(breaks, breakStop{
+				at:        anchor.RecordedAt,
+				mins:      int(spell.Minutes()),
+				latitude:  anchor.Latitude,
+				longitude: anchor.Longitude,
+			})
+		}
+		i = j
+	}
+	return breaks
+}
+
+// applyBreakTaken sets the plan's outcome from the breaks found on the trail,
+// preferring one taken at a suggestion over the first taken anywhere
+func (s *BreakPlanService) applyBreakTaken(plan *domain.BreakPlan, breaks []breakStop) {
+	plan.Status = domain.BreakPlanNotTaken
+	plan.TakenLocationID = nil
+	plan.TakenAt = nil
+	plan.TakenMins = 0
+	plan.TakenLatitude = nil
+	plan.TakenLongitude = nil
+	if len(breaks) == 0 {
+		return
+	}
+
+	taken := breaks[0]
+	plan.Status = domain.BreakPlanTakenElsewhere
+	for _, b := range breaks {
+		if location := s.suggestionAt(plan, b.latitude, b.longitude); location != nil {
+			taken = b
+			plan.Status = domain.BreakPlanTakenAtSuggested
+			plan.TakenLocationID = &location.ID
+			break
+		}
+	}
+	plan.TakenAt = &taken.at
+	plan.TakenMins = taken.mins
+	plan.TakenLatitude = &taken.latitude
+	plan.TakenLongitude = &taken.longitude
+}
+
+// suggestionAt returns the suggested location within TakenRadiusMiles of a
+// position, or nil when the position is not at a suggestion
+func (s *BreakPlanService) suggestionAt(plan *domain.BreakPlan, latitude, longitude float64) *domain.Location {
+	radius := s.businessRules.BreakPlanning.TakenRadiusMiles
+	for i := range plan.Suggestions {
+		location := &plan.Suggestions[i].Location
+		if haversineMiles(latitude, longitude, location.Latitude, location.Longitude) <= radius {
+			return location
+		}
+	}
+	return nil
+}
+
+// HandleTripDispatched is a kafka.Handler that plans the driver's break when
+// a trip is dispatched, from the driver's HOS at that point
+func (s *BreakPlanService) HandleTripDispatched(ctx context.Context, event *kafka.Event) error {
+	tripID, ok := eventTripID(event)
+	if !ok {
+		return nil
+	}
+	_, err := s.PlanBreak(ctx, tripID)
+	return err
+}
+
+// HandleTripCompleted is a kafka.Handler that records whether the planned
+// break was taken once the trip is completed
+func (s *BreakPlanService) HandleTripCompleted(ctx context.Context, event *kafka.Event) error {
+	tripID, ok := eventTripID(event)
+	if !ok {
+		return nil
+	}
+	_, err := s.EvaluateBreakPlan(ctx, tripID)
+	return err
+}
+
+// eventTripID reads the trip_id of a dispatch trip event
+func eventTripID(event *kafka.Event) (uuid.UUID, bool) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	var payload struct {
+		TripID string `json:"trip_id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return uuid.Nil, false
+	}
+	tripID, err := uuid.Parse(payload.TripID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return tripID, true
+}
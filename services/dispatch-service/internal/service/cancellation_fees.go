@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// cancellableTripStatuses are the trip statuses an order cancellation calls off
+var cancellableTripStatuses = []domain.TripStatus{
+	domain.TripStatusDraft,
+	domain.TripStatusPlanned,
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+	domain.TripStatusInProgress,
+}
+
+// assessCancellationFee prices a customer cancellation under the contract of
+// the trip's next stop. It returns nil when the rule charges nothing.
+func (s *DispatchCRUDService) assessCancellationFee(ctx context.Context, trip *domain.Trip, stops []domain.TripStop, input CancelTripInput, at time.Time) (*domain.CancellationCharge, error) {
+	next := nextOpenStop(stops)
+
+	var orderID *uuid.UUID
+	if next != nil && next.OrderID != nil {
+		orderID = next.OrderID
+	} else {
+		for i := range stops {
+			if stops[i].OrderID != nil {
+				orderID = stops[i].OrderID
+				break
+			}
+		}
+	}
+
+	profile, err := newProfileCache(s.profileRepo).resolve(ctx, nil, orderID)
+	if err != nil {
+		return nil, err
+	}
+	rule := profile.CancellationRule(&s.businessRules.Cancellation)
+
+	evidence := domain.CancellationEvidence{
+		TripStatus:      trip.Status,
+		DriverID:        trip.DriverID,
+		ActualStartTime: trip.ActualStartTime,
+		Profile:         "default",
+		RuleType:        string(rule.Type),
+		CancelledAt:     at,
+	}
+	if profile != nil {
+		evidence.Profile = profile.Name
+	}
+	if next != nil {
+		evidence.StopID = &next.ID
+		evidence.AppointmentTime = next.AppointmentTime
+		evidence.ArrivedAt = next.ActualArrival
+	}
+
+	fee := rule.Calculate(config.CancellationContext{
+		Dispatched: trip.Status == domain.TripStatusDispatched ||
+			trip.Status == domain.TripStatusEnRoute ||
+			trip.Status == domain.TripStatusInProgress,
+		DriverOnSite:    evidence.ArrivedAt != nil,
+		AppointmentTime: evidence.AppointmentTime,
+		CancelledAt:     at,
+	})
+	evidence.HoursAhead = fee.HoursAhead
+
+	if fee.Amount <= 0 {
+		s.logger.Infow("No cancellation fee",
+			"trip_id", trip.ID,
+			"profile", evidence.Profile,
+			"basis", fee.Basis,
+		)
+		return nil, nil
+	}
+
+	return &domain.CancellationCharge{
+		ID:          uuid.New(),
+		TripID:      trip.ID,
+		TripNumber:  trip.TripNumber,
+		OrderID:     orderID,
+		ChargeType:  fee.ChargeType,
+		Amount:      fee.Amount,
+		Basis:       fee.Basis,
+		Evidence:    evidence,
+		Reason:      input.Reason,
+		CancelledBy: input.CancelledBy,
+		CreatedAt:   at,
+	}, nil
+}
+
+// nextOpenStop returns the lowest sequence stop the driver has not finished
+func nextOpenStop(stops []domain.TripStop) *domain.TripStop {
+	var next *domain.TripStop
+	for i := range stops {
+		switch stops[i].Status {
+		case domain.StopStatusCompleted, domain.StopStatusSkipped, domain.StopStatusCancelled, domain.StopStatusFailed:
+			continue
+		}
+		if next == nil || stops[i].Sequence < next.Sequence {
+			next = &stops[i]
+		}
+	}
+	return next
+}
+
+// publishCancellationFee tells billing about a charge to invoice
+func (s *DispatchCRUDService) publishCancellationFee(ctx context.Context, charge *domain.CancellationCharge) {
+	data := map[string]interface{}{
+		"charge_id":    charge.ID.String(),
+		"trip_id":      charge.TripID.String(),
+		"trip_number":  charge.TripNumber,
+		"charge_type":  charge.ChargeType,
+		"amount":       charge.Amount,
+		"basis":        charge.Basis,
+		"evidence":     charge.Evidence,
+		"cancelled_by": charge.CancelledBy,
+	}
+	if charge.OrderID != nil {
+		data["order_id"] = charge.OrderID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.CancellationFeeAssessed, "dispatch-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.CancellationFeeAssessed, event)
+
+	s.logger.Infow("Cancellation fee assessed",
+		"trip_id", charge.TripID,
+		"charge_type", charge.ChargeType,
+		"amount", charge.Amount,
+		"basis", charge.Basis,
+		"profile", charge.Evidence.Profile,
+	)
+}
+
+// HandleOrderCancelled is a kafka.Handler that cancels the open trips of a
+// cancelled order, carrying the cancellation's origin so customer
+// cancellations are charged per trip
+func (s *DispatchCRUDService) HandleOrderCancelled(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload struct {
+		OrderID     string `json:"order_id"`
+		Reason      string `json:"reason"`
+		CancelledBy string `json:"cancelled_by"`
+		Origin      string `json:"origin"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal order cancelled: %w", err)
+	}
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return nil
+	}
+
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		Status:   cancellableTripStatuses,
+		OrderID:  &orderID,
+		PageSize: 100,
+	})
+	if err != nil {
+		return apperrors.DatabaseError("list order trips", err)
+	}
+
+	input := CancelTripInput{
+		Reason:      payload.Reason,
+		CancelledBy: payload.CancelledBy,
+		Origin:      config.CancellationOrigin(payload.Origin),
+	}
+	for _, trip := range trips {
+		if _, err := s.CancelTrip(ctx, trip.ID, input); err != nil {
+			s.logger.Warnw("Failed to cancel trip for cancelled order",
+				"order_id", orderID,
+				"trip_id", trip.ID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
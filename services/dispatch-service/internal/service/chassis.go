@@ -0,0 +1,605 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ChassisService keeps the chassis register: where each chassis is, who
+// provides it, what its rental costs and which trips split it from its
+// container. Stop departures move chassis; trip completion flags splits.
+type ChassisService struct {
+	chassisRepo   repository.ChassisRepository
+	usageRepo     repository.ChassisUsageRepository
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewChassisService creates a new chassis service
+func NewChassisService(
+	chassisRepo repository.ChassisRepository,
+	usageRepo repository.ChassisUsageRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *ChassisService {
+	return &ChassisService{
+		chassisRepo:   chassisRepo,
+		usageRepo:     usageRepo,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// CreateChassisInput contains input for registering a chassis
+type CreateChassisInput struct {
+	ChassisNumber     string
+	Size              string
+	Type              string // standard when empty
+	TareWeight        int
+	NumAxles          int // 2, or 3 for tri_axle, when zero
+	Ownership         domain.ChassisOwnership
+	PoolID            *uuid.UUID
+	IEPCode           string
+	CurrentLocationID *uuid.UUID
+	Notes             string
+}
+
+// CreateChassis registers a chassis as available
+func (s *ChassisService) CreateChassis(ctx context.Context, input CreateChassisInput) (*domain.Chassis, error) {
+	number := strings.ToUpper(strings.TrimSpace(input.ChassisNumber))
+	if number == "" {
+		return nil, apperrors.ValidationError("chassis number is required", "chassis_number", input.ChassisNumber)
+	}
+	existing, err := s.chassisRepo.GetByNumber(ctx, number)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis", err)
+	}
+	if existing != nil {
+		return nil, apperrors.ConflictError("chassis number already registered").
+			WithDetail("chassis_id", existing.ID.String())
+	}
+
+	now := time.Now()
+	chassis := &domain.Chassis{
+		ID:                uuid.New(),
+		ChassisNumber:     number,
+		Size:              input.Size,
+		Type:              strings.ToLower(strings.TrimSpace(input.Type)),
+		TareWeight:        input.TareWeight,
+		NumAxles:          input.NumAxles,
+		Ownership:         input.Ownership,
+		PoolID:            input.PoolID,
+		IEPCode:           strings.ToUpper(strings.TrimSpace(input.IEPCode)),
+		Status:            domain.ChassisStatusAvailable,
+		CurrentLocationID: input.CurrentLocationID,
+		Notes:             input.Notes,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if chassis.Type == "" {
+		chassis.Type = "standard"
+	}
+	if chassis.NumAxles == 0 {
+		chassis.NumAxles = 2
+		if chassis.Type == "tri_axle" {
+			chassis.NumAxles = 3
+		}
+	}
+	if err := s.validateChassis(ctx, chassis); err != nil {
+		return nil, err
+	}
+
+	if err := s.chassisRepo.Create(ctx, chassis); err != nil {
+		return nil, apperrors.DatabaseError("create chassis", err)
+	}
+	return chassis, nil
+}
+
+// UpdateChassisInput contains the chassis fields to change; nil fields are kept
+type UpdateChassisInput struct {
+	Type              *string
+	TareWeight        *int
+	NumAxles          *int
+	Ownership         *domain.ChassisOwnership
+	PoolID            *uuid.UUID
+	IEPCode           *string
+	Status            *domain.ChassisStatus
+	CurrentLocationID *uuid.UUID
+	Notes             *string
+}
+
+// UpdateChassis changes a chassis's details. A chassis on a trip cannot be
+// taken out of service or off-hired until the trip releases it.
+func (s *ChassisService) UpdateChassis(ctx context.Context, id uuid.UUID, input UpdateChassisInput) (*domain.Chassis, error) {
+	chassis, err := s.GetChassis(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Type != nil {
+		chassis.Type = strings.ToLower(strings.TrimSpace(*input.Type))
+	}
+	if input.TareWeight != nil {
+		chassis.TareWeight = *input.TareWeight
+	}
+	if input.NumAxles != nil {
+		chassis.NumAxles = *input.NumAxles
+	}
+	if input.Ownership != nil {
+		chassis.Ownership = *input.Ownership
+		if !chassis.Ownership.IsRental() {
+			chassis.PoolID = nil
+		}
+	}
+	if input.PoolID != nil {
+		chassis.PoolID = input.PoolID
+	}
+	if input.IEPCode != nil {
+		chassis.IEPCode = strings.ToUpper(strings.TrimSpace(*input.IEPCode))
+	}
+	if input.Status != nil && *input.Status != chassis.Status {
+		switch *input.Status {
+		case domain.ChassisStatusAvailable, domain.ChassisStatusOutOfService, domain.ChassisStatusOffHired:
+		default:
+			return nil, apperrors.ValidationError("chassis status can only be set to AVAILABLE, OUT_OF_SERVICE or OFF_HIRED", "status", string(*input.Status))
+		}
+		if chassis.CurrentTripID != nil {
+			return nil, apperrors.InvalidStateError(string(chassis.Status), "AVAILABLE").
+				WithDetail("current_trip_id", chassis.CurrentTripID.String())
+		}
+		chassis.Status = *input.Status
+	}
+	if input.CurrentLocationID != nil {
+		chassis.CurrentLocationID = input.CurrentLocationID
+	}
+	if input.Notes != nil {
+		chassis.Notes = *input.Notes
+	}
+	if err := s.validateChassis(ctx, chassis); err != nil {
+		return nil, err
+	}
+
+	chassis.UpdatedAt = time.Now()
+	if err := s.chassisRepo.Update(ctx, chassis); err != nil {
+		return nil, apperrors.DatabaseError("update chassis", err)
+	}
+	return chassis, nil
+}
+
+// validateChassis checks the size, axles and that rental chassis say who rents them
+func (s *ChassisService) validateChassis(ctx context.Context, chassis *domain.Chassis) error {
+	switch chassis.Size {
+	case "20", "40", "45":
+	default:
+		return apperrors.ValidationError("chassis size must be 20, 40 or 45", "size", chassis.Size)
+	}
+	if chassis.NumAxles < 2 || chassis.NumAxles > 4 {
+		return apperrors.ValidationError("chassis must have 2 to 4 axles", "num_axles", fmt.Sprintf("%d", chassis.NumAxles))
+	}
+
+	switch chassis.Ownership {
+	case domain.ChassisOwnershipCompany, domain.ChassisOwnershipLeased:
+	case domain.ChassisOwnershipPool:
+		if chassis.PoolID == nil {
+			return apperrors.ValidationError("pool chassis need a pool", "pool_id", "")
+		}
+	case domain.ChassisOwnershipIEP:
+		if chassis.IEPCode == "" && chassis.PoolID == nil {
+			return apperrors.ValidationError("IEP chassis need a provider code or pool", "iep_code", "")
+		}
+	default:
+		return apperrors.ValidationError("ownership must be COMPANY, LEASED, POOL or IEP", "ownership", string(chassis.Ownership))
+	}
+	if chassis.PoolID != nil {
+		pool, err := s.chassisRepo.GetPool(ctx, *chassis.PoolID)
+		if err != nil {
+			return apperrors.DatabaseError("get chassis pool", err)
+		}
+		if pool == nil {
+			return apperrors.NotFoundError("chassis pool", chassis.PoolID.String())
+		}
+	}
+	return nil
+}
+
+// GetChassis returns a chassis by ID
+func (s *ChassisService) GetChassis(ctx context.Context, id uuid.UUID) (*domain.Chassis, error) {
+	chassis, err := s.chassisRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis", err)
+	}
+	if chassis == nil {
+		return nil, apperrors.NotFoundError("chassis", id.String())
+	}
+	return chassis, nil
+}
+
+// ListChassis returns the chassis matching a filter
+func (s *ChassisService) ListChassis(ctx context.Context, filter domain.ChassisFilter) ([]domain.Chassis, error) {
+	chassis, err := s.chassisRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list chassis", err)
+	}
+	return chassis, nil
+}
+
+// ListChassisPools returns the chassis pools and providers with their rates
+func (s *ChassisService) ListChassisPools(ctx context.Context) ([]domain.ChassisPool, error) {
+	pools, err := s.chassisRepo.ListPools(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list chassis pools", err)
+	}
+	return pools, nil
+}
+
+// GetChassisPerDiem prices the rental owed on a chassis for usage within
+// [from, to). Usages are clipped to the period and priced per calendar day
+// at the pool's rate for the chassis size; a chassis still out is counted
+// to now. Split fees flagged in the period are added on top. Company and
+// leased chassis come back with their days but no charge.
+func (s *ChassisService) GetChassisPerDiem(ctx context.Context, chassisID uuid.UUID, from, to time.Time) (*domain.ChassisPerDiem, error) {
+	if !to.After(from) {
+		return nil, apperrors.ValidationError("period end must be after its start", "to", to.Format(time.RFC3339))
+	}
+	chassis, err := s.GetChassis(ctx, chassisID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rate float64
+	if chassis.Ownership.IsRental() && chassis.PoolID != nil {
+		pool, err := s.chassisRepo.GetPool(ctx, *chassis.PoolID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get chassis pool", err)
+		}
+		if pool != nil {
+			rate = pool.DailyRate(chassis.Size)
+		}
+	}
+
+	usages, err := s.usageRepo.GetByChassis(ctx, chassisID, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis usage", err)
+	}
+	splits, err := s.chassisRepo.GetSplitsByChassis(ctx, chassisID, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis splits", err)
+	}
+
+	perDiem := &domain.ChassisPerDiem{
+		ChassisID:     chassis.ID,
+		ChassisNumber: chassis.ChassisNumber,
+		Ownership:     chassis.Ownership,
+		PoolID:        chassis.PoolID,
+		PeriodStart:   from,
+		PeriodEnd:     to,
+		Charges:       make([]domain.ChassisUsageCharge, 0, len(usages)),
+	}
+	now := time.Now()
+	for _, usage := range usages {
+		start := usage.PickupTime
+		if start.Before(from) {
+			start = from
+		}
+		end := now
+		if usage.ReturnTime != nil {
+			end = *usage.ReturnTime
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		days := domain.CalendarDays(start, end)
+		charge := domain.ChassisUsageCharge{
+			Usage:     usage,
+			Days:      days,
+			DailyRate: rate,
+			Amount:    round2(float64(days) * rate),
+		}
+		perDiem.Charges = append(perDiem.Charges, charge)
+		perDiem.TotalDays += days
+		perDiem.TotalAmount += charge.Amount
+	}
+	for _, split := range splits {
+		if !split.IsSplit() {
+			continue
+		}
+		perDiem.SplitCount++
+		perDiem.SplitFees += split.SplitFee
+	}
+	perDiem.SplitFees = round2(perDiem.SplitFees)
+	perDiem.TotalAmount = round2(perDiem.TotalAmount + perDiem.SplitFees)
+	return perDiem, nil
+}
+
+// GetTripChassisSplit returns the chassis split flagged on a trip
+func (s *ChassisService) GetTripChassisSplit(ctx context.Context, tripID uuid.UUID) (*domain.ChassisSplit, error) {
+	split, err := s.chassisRepo.GetSplitByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis split", err)
+	}
+	if split == nil {
+		return nil, apperrors.NotFoundError("chassis split", tripID.String())
+	}
+	return split, nil
+}
+
+// FlagTripChassisSplit works out from a trip's stops whether its chassis
+// was picked up or returned away from the container and records the
+// result, replacing any earlier flag. A newly found split is published so
+// billing can pass the split fee on.
+func (s *ChassisService) FlagTripChassisSplit(ctx context.Context, tripID uuid.UUID, flaggedBy string) (*domain.ChassisSplit, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip", err)
+	}
+	if trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	split := detectChassisSplit(trip, stops)
+	split.FlaggedBy = flaggedBy
+
+	var pool *domain.ChassisPool
+	if split.ChassisID != nil {
+		chassis, err := s.chassisRepo.GetByID(ctx, *split.ChassisID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get chassis", err)
+		}
+		if chassis != nil && chassis.Ownership.IsRental() && chassis.PoolID != nil {
+			pool, err = s.chassisRepo.GetPool(ctx, *chassis.PoolID)
+			if err != nil {
+				return nil, apperrors.DatabaseError("get chassis pool", err)
+			}
+		}
+	}
+	if pool != nil {
+		switch split.Status {
+		case domain.ChassisSplitPickup, domain.ChassisSplitReturn:
+			split.SplitFee = pool.SplitFee
+		case domain.ChassisSplitBoth:
+			split.SplitFee = round2(2 * pool.SplitFee)
+		}
+	}
+
+	previous, err := s.chassisRepo.GetSplitByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis split", err)
+	}
+	if previous != nil {
+		split.ID = previous.ID
+		split.CreatedAt = previous.CreatedAt
+	}
+	if err := s.chassisRepo.SaveSplit(ctx, split); err != nil {
+		return nil, apperrors.DatabaseError("save chassis split", err)
+	}
+
+	if split.IsSplit() && (previous == nil || previous.Status != split.Status) {
+		s.publishSplitFlagged(ctx, trip, split)
+	}
+	return split, nil
+}
+
+// detectChassisSplit compares where the chassis was picked up and returned
+// with where the container was picked up and left. A chassis picked up at
+// one place before hooking a container at another is a pickup split; a
+// chassis returned somewhere other than where its last container was
+// dropped is a return split.
+func detectChassisSplit(trip *domain.Trip, stops []domain.TripStop) *domain.ChassisSplit {
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Sequence < stops[j].Sequence })
+
+	var chassisPickup, containerPickup, chassisReturn, containerDrop *domain.TripStop
+	for i := range stops {
+		stop := &stops[i]
+		switch stop.Activity {
+		case domain.ActivityTypeChassisPickup:
+			if chassisPickup == nil {
+				chassisPickup = stop
+			}
+		case domain.ActivityTypePickupLoaded, domain.ActivityTypePickupEmpty, domain.ActivityTypeHookEmpty:
+			if containerPickup == nil {
+				containerPickup = stop
+			}
+		case domain.ActivityTypeChassisDrop:
+			chassisReturn = stop
+		case domain.ActivityTypeDropEmpty, domain.ActivityTypeDropLoaded:
+			if chassisReturn == nil || stop.Sequence < chassisReturn.Sequence {
+				containerDrop = stop
+			}
+		}
+	}
+
+	now := time.Now()
+	split := &domain.ChassisSplit{
+		ID:        uuid.New(),
+		TripID:    trip.ID,
+		ChassisID: trip.ChassisID,
+		Status:    domain.ChassisSplitNone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	pickupSplit := false
+	if chassisPickup != nil {
+		split.ChassisPickupLocationID = &chassisPickup.LocationID
+		if chassisPickup.ChassisOutID != nil {
+			split.ChassisID = chassisPickup.ChassisOutID
+		}
+		if containerPickup != nil && containerPickup.Sequence > chassisPickup.Sequence {
+			split.ContainerPickupLocationID = &containerPickup.LocationID
+			pickupSplit = containerPickup.LocationID != chassisPickup.LocationID
+		}
+	}
+	returnSplit := false
+	if chassisReturn != nil {
+		split.ChassisReturnLocationID = &chassisReturn.LocationID
+		if split.ChassisID == nil {
+			split.ChassisID = chassisReturn.ChassisInID
+		}
+		if containerDrop != nil && containerDrop.Sequence < chassisReturn.Sequence {
+			split.ContainerDropLocationID = &containerDrop.LocationID
+			returnSplit = containerDrop.LocationID != chassisReturn.LocationID
+		}
+	}
+
+	switch {
+	case pickupSplit && returnSplit:
+		split.Status = domain.ChassisSplitBoth
+	case pickupSplit:
+		split.Status = domain.ChassisSplitPickup
+	case returnSplit:
+		split.Status = domain.ChassisSplitReturn
+	}
+	return split
+}
+
+func (s *ChassisService) publishSplitFlagged(ctx context.Context, trip *domain.Trip, split *domain.ChassisSplit) {
+	data := map[string]interface{}{
+		"trip_id":     trip.ID.String(),
+		"trip_number": trip.TripNumber,
+		"status":      split.Status,
+		"split_fee":   split.SplitFee,
+		"flagged_by":  split.FlaggedBy,
+	}
+	if split.ChassisID != nil {
+		data["chassis_id"] = split.ChassisID.String()
+	}
+	kafkaEvent := kafka.NewEvent(kafka.Topics.ChassisSplitFlagged, "dispatch-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.ChassisSplitFlagged, kafkaEvent)
+
+	s.logger.Infow("Chassis split flagged",
+		"trip_number", trip.TripNumber,
+		"status", split.Status,
+		"split_fee", split.SplitFee,
+	)
+}
+
+// HandleStopDeparted is a kafka.Handler that follows a chassis from stop to
+// stop. A chassis leaving a stop is in use on the trip; leaving a rental
+// chassis at a CHASSIS_DROP frees it and closes its usage for billing.
+func (s *ChassisService) HandleStopDeparted(ctx context.Context, event *kafka.Event) error {
+	var payload kafka.StopDepartedEvent
+	if err := event.DecodeData(&payload); err != nil {
+		return fmt.Errorf("unmarshal stop departed: %w", err)
+	}
+	stopID, err := uuid.Parse(payload.StopID)
+	if err != nil {
+		return nil
+	}
+	stop, err := s.stopRepo.GetByID(ctx, stopID)
+	if err != nil || stop == nil {
+		return nil
+	}
+
+	chassisID := stop.ChassisOutID
+	if stop.Activity == domain.ActivityTypeChassisDrop {
+		chassisID = stop.ChassisInID
+	}
+	if chassisID == nil {
+		return nil
+	}
+	chassis, err := s.chassisRepo.GetByID(ctx, *chassisID)
+	if err != nil || chassis == nil {
+		return nil
+	}
+
+	departedAt := payload.DepartedAt
+	if departedAt.IsZero() {
+		departedAt = time.Now()
+	}
+	locationName := ""
+	if location, err := s.locationRepo.GetByID(ctx, stop.LocationID); err == nil && location != nil {
+		locationName = location.Name
+	}
+
+	chassis.CurrentLocationID = &stop.LocationID
+	chassis.LastMovedAt = &departedAt
+	chassis.UpdatedAt = time.Now()
+	if stop.Activity == domain.ActivityTypeChassisDrop {
+		chassis.Status = domain.ChassisStatusAvailable
+		chassis.CurrentTripID = nil
+	} else {
+		chassis.Status = domain.ChassisStatusInUse
+		chassis.CurrentTripID = &stop.TripID
+	}
+	if err := s.chassisRepo.Update(ctx, chassis); err != nil {
+		return apperrors.DatabaseError("update chassis", err)
+	}
+
+	if !chassis.Ownership.IsRental() {
+		return nil
+	}
+	usage, err := s.usageRepo.GetOpenByChassis(ctx, chassis.ID)
+	if err != nil {
+		return apperrors.DatabaseError("get chassis usage", err)
+	}
+	switch {
+	case stop.Activity == domain.ActivityTypeChassisDrop && usage != nil:
+		usage.Close(departedAt, locationName)
+		if err := s.usageRepo.Update(ctx, usage); err != nil {
+			return apperrors.DatabaseError("close chassis usage", err)
+		}
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.ChassisUsageClosed, chassisUsageClosedEvent(usage))
+	case stop.Activity != domain.ActivityTypeChassisDrop && usage == nil:
+		// A rental's clock starts when it leaves the pool on a trip
+		usage = &domain.ChassisUsage{
+			ID:             uuid.New(),
+			ChassisID:      chassis.ID,
+			TripID:         &stop.TripID,
+			PoolID:         chassis.PoolID,
+			PickupTime:     departedAt,
+			PickupLocation: locationName,
+			CreatedAt:      time.Now(),
+		}
+		if err := s.usageRepo.Create(ctx, usage); err != nil {
+			return apperrors.DatabaseError("create chassis usage", err)
+		}
+	}
+	return nil
+}
+
+// HandleTripCompleted is a kafka.Handler that flags chassis splits once a
+// trip's last stop is done
+func (s *ChassisService) HandleTripCompleted(ctx context.Context, event *kafka.Event) error {
+	tripID, ok := eventTripID(event)
+	if !ok {
+		return nil
+	}
+	if _, err := s.FlagTripChassisSplit(ctx, tripID, "system"); err != nil {
+		s.logger.Warnw("Could not flag chassis split",
+			"trip_id", tripID,
+			"error", err,
+		)
+	}
+	return nil
+}
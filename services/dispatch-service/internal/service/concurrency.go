@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// checkTripVersion rejects an edit made against a stale copy of the trip.
+// An expected version of zero skips the check for callers that don't track it.
+func checkTripVersion(trip *domain.Trip, expected int) error {
+	if expected == 0 || expected == trip.Version {
+		return nil
+	}
+	return apperrors.VersionConflictError("trip", trip.ID.String(), trip)
+}
+
+// checkStopVersion rejects an edit made against a stale copy of the stop
+func checkStopVersion(stop *domain.TripStop, expected int) error {
+	if expected == 0 || expected == stop.Version {
+		return nil
+	}
+	return apperrors.VersionConflictError("stop", stop.ID.String(), stop)
+}
+
+// tripUpdateError converts a failed trip update into an app error, returning
+// the latest trip state when another dispatcher saved first
+func tripUpdateError(ctx context.Context, tripRepo repository.TripAssignmentRepository, tripID uuid.UUID, operation string, err error) error {
+	if !errors.Is(err, database.ErrVersionConflict) {
+		return apperrors.DatabaseError(operation, err)
+	}
+	latest, getErr := tripRepo.GetByID(ctx, tripID)
+	if getErr != nil {
+		latest = nil
+	}
+	return apperrors.VersionConflictError("trip", tripID.String(), latest)
+}
+
+// stopUpdateError converts a failed stop update into an app error, returning
+// the latest stop state when another dispatcher saved first
+func stopUpdateError(ctx context.Context, stopRepo repository.TripStopRepository, stopID uuid.UUID, operation string, err error) error {
+	if !errors.Is(err, database.ErrVersionConflict) {
+		return apperrors.DatabaseError(operation, err)
+	}
+	latest, getErr := stopRepo.GetByID(ctx, stopID)
+	if getErr != nil {
+		latest = nil
+	}
+	return apperrors.VersionConflictError("stop", stopID.String(), latest)
+}
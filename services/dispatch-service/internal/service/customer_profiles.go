@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// profileCache resolves customer contract profiles, memoizing lookups for
+// the duration of a single operation
+type profileCache struct {
+	repo       repository.CustomerProfileRepository
+	byCustomer map[uuid.UUID]*config.CustomerProfile
+	byOrder    map[uuid.UUID]*config.CustomerProfile
+}
+
+func newProfileCache(repo repository.CustomerProfileRepository) *profileCache {
+	return &profileCache{
+		repo:       repo,
+		byCustomer: make(map[uuid.UUID]*config.CustomerProfile),
+		byOrder:    make(map[uuid.UUID]*config.CustomerProfile),
+	}
+}
+
+// resolve returns the profile for an explicit customer, or for the customer
+// on the stop's order. A nil profile means global business rules apply.
+func (c *profileCache) resolve(ctx context.Context, customerID, orderID *uuid.UUID) (*config.CustomerProfile, error) {
+	if c.repo == nil {
+		return nil, nil
+	}
+
+	if customerID != nil {
+		if profile, ok := c.byCustomer[*customerID]; ok {
+			return profile, nil
+		}
+		profile, err := c.repo.GetByCustomerID(ctx, *customerID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get customer profile", err)
+		}
+		c.byCustomer[*customerID] = profile
+		return profile, nil
+	}
+
+	if orderID != nil {
+		if profile, ok := c.byOrder[*orderID]; ok {
+			return profile, nil
+		}
+		profile, err := c.repo.GetByOrderID(ctx, *orderID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get customer profile", err)
+		}
+		c.byOrder[*orderID] = profile
+		return profile, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// GetDetentionClocks returns the live free time countdown at every stop a
+// driver is on site at, soonest into detention first, for the dispatch board
+func (s *DispatchService) GetDetentionClocks(ctx context.Context, now time.Time) ([]domain.DetentionClock, error) {
+	return s.detentionClocks(ctx, nil, now)
+}
+
+// GetDriverDetentionClock returns the countdown at the stop the driver is on
+// site at, for the driver app, or nil when they are not at one
+func (s *DispatchService) GetDriverDetentionClock(ctx context.Context, driverID uuid.UUID, now time.Time) (*domain.DetentionClock, error) {
+	clocks, err := s.detentionClocks(ctx, &driverID, now)
+	if err != nil || len(clocks) == 0 {
+		return nil, err
+	}
+	return &clocks[0], nil
+}
+
+// detentionClocks reads the clocks of open arrivals, limited to one driver's
+// trips when driverID is set
+func (s *DispatchService) detentionClocks(ctx context.Context, driverID *uuid.UUID, now time.Time) ([]domain.DetentionClock, error) {
+	stops, err := s.stopRepo.GetOpenArrivals(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get open arrivals", err)
+	}
+
+	trips := make(map[uuid.UUID]*domain.Trip)
+	locations := make(map[uuid.UUID]*domain.Location)
+	clocks := make([]domain.DetentionClock, 0, len(stops))
+	for i := range stops {
+		stop := &stops[i]
+		if stop.ActualArrival == nil || stop.GeofenceExitedAt != nil || stop.Type == domain.StopTypeWaypoint {
+			continue
+		}
+
+		trip, ok := trips[stop.TripID]
+		if !ok {
+			trip, _ = s.tripRepo.GetByID(ctx, stop.TripID)
+			trips[stop.TripID] = trip
+		}
+		if driverID != nil && (trip == nil || trip.DriverID == nil || *trip.DriverID != *driverID) {
+			continue
+		}
+
+		if stop.Location == nil {
+			location, ok := locations[stop.LocationID]
+			if !ok {
+				location, _ = s.locationRepo.GetByID(ctx, stop.LocationID)
+				locations[stop.LocationID] = location
+			}
+			stop.Location = location
+		}
+
+		clocks = append(clocks, *domain.NewDetentionClock(trip, stop, now, s.businessRules.Detention.WarningLeadMins))
+	}
+
+	sort.Slice(clocks, func(i, j int) bool {
+		return clocks[i].FreeTimeEndsAt.Before(clocks[j].FreeTimeEndsAt)
+	})
+	return clocks, nil
+}
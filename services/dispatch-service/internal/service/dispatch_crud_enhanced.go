@@ -10,6 +10,7 @@ import (
 
 	"github.com/draymaster/services/dispatch-service/internal/domain"
 	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
 	apperrors "github.com/draymaster/shared/pkg/errors"
 	"github.com/draymaster/shared/pkg/database"
 	"github.com/draymaster/shared/pkg/kafka"
@@ -18,12 +19,17 @@ import (
 
 // DispatchCRUDService provides comprehensive CRUD operations for trips
 type DispatchCRUDService struct {
-	db            *database.DB
-	tripRepo      repository.TripRepository
-	stopRepo      repository.TripStopRepository
-	driverRepo    repository.DriverRepository
-	eventProducer *kafka.Producer
-	logger        *logger.Logger
+	db              *database.DB
+	tripRepo        repository.TripRepository
+	stopRepo        repository.TripStopRepository
+	driverRepo      repository.DriverRepository
+	profileRepo     repository.CustomerProfileRepository
+	chargeRepo      repository.CancellationChargeRepository
+	appointmentRepo repository.GateAppointmentRepository
+	snapshotRepo    repository.HOSSnapshotRepository
+	eventProducer   *kafka.Producer
+	logger          *logger.Logger
+	businessRules   *config.BusinessRules
 }
 
 // NewDispatchCRUDService creates a new dispatch CRUD service
@@ -32,16 +38,25 @@ func NewDispatchCRUDService(
 	tripRepo repository.TripRepository,
 	stopRepo repository.TripStopRepository,
 	driverRepo repository.DriverRepository,
+	profileRepo repository.CustomerProfileRepository,
+	chargeRepo repository.CancellationChargeRepository,
+	appointmentRepo repository.GateAppointmentRepository,
+	snapshotRepo repository.HOSSnapshotRepository,
 	eventProducer *kafka.Producer,
 	log *logger.Logger,
 ) *DispatchCRUDService {
 	return &DispatchCRUDService{
-		db:            db,
-		tripRepo:      tripRepo,
-		stopRepo:      stopRepo,
-		driverRepo:    driverRepo,
-		eventProducer: eventProducer,
-		logger:        log,
+		db:              db,
+		tripRepo:        tripRepo,
+		stopRepo:        stopRepo,
+		driverRepo:      driverRepo,
+		profileRepo:     profileRepo,
+		chargeRepo:      chargeRepo,
+		appointmentRepo: appointmentRepo,
+		snapshotRepo:    snapshotRepo,
+		eventProducer:   eventProducer,
+		logger:          log,
+		businessRules:   config.DefaultBusinessRules(),
 	}
 }
 
@@ -50,6 +65,7 @@ type UpdateTripInput struct {
 	PlannedStartTime *time.Time
 	DriverID         *uuid.UUID
 	TractorID        *uuid.UUID
+	Version          int // Trip version the edit was made against; 0 skips the check
 	UpdatedBy        string
 }
 
@@ -72,6 +88,10 @@ func (s *DispatchCRUDService) UpdateTrip(ctx context.Context, tripID uuid.UUID,
 		)
 	}
 
+	if err := checkTripVersion(trip, input.Version); err != nil {
+		return nil, err
+	}
+
 	// Apply updates
 	updated := false
 
@@ -114,7 +134,7 @@ func (s *DispatchCRUDService) UpdateTrip(ctx context.Context, tripID uuid.UUID,
 	trip.UpdatedAt = time.Now()
 
 	if err := s.tripRepo.Update(ctx, trip); err != nil {
-		return nil, apperrors.DatabaseError("update trip", err)
+		return nil, tripUpdateError(ctx, s.tripRepo, tripID, "update trip", err)
 	}
 
 	// Load trip details
@@ -129,33 +149,66 @@ func (s *DispatchCRUDService) UpdateTrip(ctx context.Context, tripID uuid.UUID,
 	return trip, nil
 }
 
-// CancelTrip cancels a trip
-func (s *DispatchCRUDService) CancelTrip(ctx context.Context, tripID uuid.UUID, reason, cancelledBy string) error {
-	s.logger.Infow("Cancelling trip", "trip_id", tripID)
+// CancelTripInput contains input for cancelling a trip
+type CancelTripInput struct {
+	Reason      string
+	CancelledBy string
+	Origin      config.CancellationOrigin // Customer cancellations after dispatch are charged
+}
+
+// CancelTrip cancels a trip. When the customer cancels after dispatch the
+// contract cancellation fee is recorded with the trip and returned; the
+// charge is nil when nothing is owed.
+func (s *DispatchCRUDService) CancelTrip(ctx context.Context, tripID uuid.UUID, input CancelTripInput) (*domain.CancellationCharge, error) {
+	s.logger.Infow("Cancelling trip", "trip_id", tripID, "origin", input.Origin)
 
 	trip, err := s.tripRepo.GetByID(ctx, tripID)
 	if err != nil {
-		return apperrors.NotFoundError("trip", tripID.String())
+		return nil, apperrors.NotFoundError("trip", tripID.String())
 	}
 
 	// Validate trip can be cancelled
 	if trip.Status == domain.TripStatusCompleted || trip.Status == domain.TripStatusCancelled {
-		return apperrors.InvalidStateError(
+		return nil, apperrors.InvalidStateError(
 			string(trip.Status),
 			"planned, assigned, dispatched, or in_progress",
 		)
 	}
 
+	stops, _ := s.stopRepo.GetByTripID(ctx, tripID)
+	now := time.Now()
+
+	// Assess the fee on the trip as the customer left it
+	var charge *domain.CancellationCharge
+	if input.Origin == config.CancellationByCustomer {
+		charge, err = s.assessCancellationFee(ctx, trip, stops, input, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Update status
 	trip.Status = domain.TripStatusCancelled
-	trip.UpdatedAt = time.Now()
+	trip.UpdatedAt = now
 
-	if err := s.tripRepo.Update(ctx, trip); err != nil {
-		return apperrors.DatabaseError("cancel trip", err)
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		if err := s.tripRepo.Update(txCtx, trip); err != nil {
+			return tripUpdateError(ctx, s.tripRepo, tripID, "cancel trip", err)
+		}
+		if charge != nil {
+			if err := s.chargeRepo.Create(txCtx, charge); err != nil {
+				return apperrors.DatabaseError("create cancellation charge", err)
+			}
+		}
+		return releaseGateAppointments(txCtx, s.appointmentRepo, s.eventProducer, trip, "trip cancelled", now)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Cancel all pending stops
-	stops, _ := s.stopRepo.GetByTripID(ctx, tripID)
 	for _, stop := range stops {
 		if stop.Status == domain.StopStatusPending || stop.Status == domain.StopStatusEnRoute {
 			stop.Status = domain.StopStatusCancelled
@@ -167,17 +220,22 @@ func (s *DispatchCRUDService) CancelTrip(ctx context.Context, tripID uuid.UUID,
 	event := kafka.NewEvent("dispatch.trip.cancelled", "dispatch-service", map[string]interface{}{
 		"trip_id":      tripID.String(),
 		"trip_number":  trip.TripNumber,
-		"reason":       reason,
-		"cancelled_by": cancelledBy,
+		"reason":       input.Reason,
+		"cancelled_by": input.CancelledBy,
+		"origin":       string(input.Origin),
 	})
 	_ = s.eventProducer.Publish(ctx, "dispatch.trip.cancelled", event)
 
+	if charge != nil {
+		s.publishCancellationFee(ctx, charge)
+	}
+
 	s.logger.Infow("Trip cancelled",
 		"trip_id", tripID,
-		"reason", reason,
+		"reason", input.Reason,
 	)
 
-	return nil
+	return charge, nil
 }
 
 // DeleteTrip soft deletes a trip
@@ -196,13 +254,15 @@ func (s *DispatchCRUDService) DeleteTrip(ctx context.Context, tripID uuid.UUID,
 
 	// Execute in transaction
 	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
 		// Delete stops
-		if err := s.stopRepo.DeleteByTripID(ctx, tripID); err != nil {
+		if err := s.stopRepo.DeleteByTripID(txCtx, tripID); err != nil {
 			return apperrors.DatabaseError("delete stops", err)
 		}
 
 		// Delete trip
-		if err := s.tripRepo.Delete(ctx, tripID); err != nil {
+		if err := s.tripRepo.Delete(txCtx, tripID); err != nil {
 			return apperrors.DatabaseError("delete trip", err)
 		}
 
@@ -233,6 +293,8 @@ type ListTripsFilter struct {
 	CompletedBefore  *time.Time
 	IsStreetTurn     *bool
 	IsDualTransaction *bool
+	Tags             []string // Trips with any of these tags, or all of them with MatchAllTags
+	MatchAllTags     bool
 	Page             int
 	PageSize         int
 	SortBy           string // "created_at", "trip_number", "planned_start_time"
@@ -272,6 +334,8 @@ func (s *DispatchCRUDService) ListTrips(ctx context.Context, filter ListTripsFil
 		CompletedBefore:   filter.CompletedBefore,
 		IsStreetTurn:      filter.IsStreetTurn,
 		IsDualTransaction: filter.IsDualTransaction,
+		Tags:              filter.Tags,
+		MatchAllTags:      filter.MatchAllTags,
 		Page:              filter.Page,
 		PageSize:          filter.PageSize,
 		SortBy:            filter.SortBy,
@@ -333,6 +397,7 @@ type UpdateStopInput struct {
 	EstimatedDurationMins *int
 	FreeTimeMins          *int
 	Notes                 *string
+	Version               int // Stop version the edit was made against; 0 skips the check
 	UpdatedBy             string
 }
 
@@ -357,6 +422,10 @@ func (s *DispatchCRUDService) UpdateStop(ctx context.Context, tripID, stopID uui
 		)
 	}
 
+	if err := checkStopVersion(stop, input.Version); err != nil {
+		return nil, err
+	}
+
 	// Apply updates
 	updated := false
 
@@ -388,7 +457,7 @@ func (s *DispatchCRUDService) UpdateStop(ctx context.Context, tripID, stopID uui
 	stop.UpdatedAt = time.Now()
 
 	if err := s.stopRepo.Update(ctx, stop); err != nil {
-		return nil, apperrors.DatabaseError("update stop", err)
+		return nil, stopUpdateError(ctx, s.stopRepo, stopID, "update stop", err)
 	}
 
 	s.logger.Infow("Stop updated",
@@ -426,7 +495,7 @@ func (s *DispatchCRUDService) SkipStop(ctx context.Context, tripID, stopID uuid.
 	stop.UpdatedAt = time.Now()
 
 	if err := s.stopRepo.Update(ctx, stop); err != nil {
-		return apperrors.DatabaseError("skip stop", err)
+		return stopUpdateError(ctx, s.stopRepo, stopID, "skip stop", err)
 	}
 
 	// Update trip current stop sequence
@@ -465,6 +534,11 @@ func (s *DispatchCRUDService) GetTripWithDetails(ctx context.Context, tripID uui
 		}
 	}
 
+	// Load the HOS clocks captured at assignment and dispatch
+	if snapshots, err := s.snapshotRepo.GetByTripID(ctx, tripID); err == nil {
+		trip.HOSSnapshots = snapshots
+	}
+
 	return trip, nil
 }
 
@@ -578,9 +652,15 @@ func (s *DispatchCRUDService) BulkAssignDriver(ctx context.Context, tripIDs []uu
 	}
 
 	// Execute in transaction
+	var assigned []*domain.Trip
 	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		assigned = assigned[:0]
 		for _, tripID := range tripIDs {
-			trip, err := s.tripRepo.GetByID(ctx, tripID)
+			// Lock the row so a concurrent edit can't slip in between the
+			// status check and the update
+			trip, err := s.tripRepo.GetByIDForUpdate(txCtx, tripID)
 			if err != nil {
 				s.logger.Warnw("Trip not found in bulk assign", "trip_id", tripID)
 				continue
@@ -600,9 +680,10 @@ func (s *DispatchCRUDService) BulkAssignDriver(ctx context.Context, tripIDs []uu
 			trip.Status = domain.TripStatusAssigned
 			trip.UpdatedAt = time.Now()
 
-			if err := s.tripRepo.Update(ctx, trip); err != nil {
-				return apperrors.DatabaseError("update trip", err)
+			if err := s.tripRepo.Update(txCtx, trip); err != nil {
+				return tripUpdateError(ctx, s.tripRepo, tripID, "update trip", err)
 			}
+			assigned = append(assigned, trip)
 
 			// Publish event
 			event := kafka.NewEvent(kafka.Topics.TripAssigned, "dispatch-service", map[string]interface{}{
@@ -610,7 +691,7 @@ func (s *DispatchCRUDService) BulkAssignDriver(ctx context.Context, tripIDs []uu
 				"driver_id":    driverID.String(),
 				"driver_name":  driver.Name,
 				"assigned_by":  assignedBy,
-			})
+			}).WithTags(trip.Tags)
 			_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAssigned, event)
 		}
 		return nil
@@ -619,6 +700,9 @@ func (s *DispatchCRUDService) BulkAssignDriver(ctx context.Context, tripIDs []uu
 	if err != nil {
 		return err
 	}
+	for _, trip := range assigned {
+		recordHOSSnapshot(ctx, s.snapshotRepo, s.logger, &s.businessRules.BreakPlanning, trip, driver, domain.HOSSnapshotAssigned, assignedBy, time.Now())
+	}
 
 	s.logger.Infow("Bulk driver assignment completed",
 		"trip_count", len(tripIDs),
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/cutover"
+)
+
+// Cutover operation names, used to set per-operation modes and in metrics
+const (
+	OpCreateTrip      = "dispatch.create_trip"
+	OpAssignDriver    = "dispatch.assign_driver"
+	OpFindStreetTurns = "dispatch.find_street_turns"
+)
+
+// Shadow comparison tolerances for trip plans. The legacy path estimates
+// miles, so only differences beyond these are reported.
+const (
+	shadowMilesTolerancePct = 0.10
+	shadowMinsTolerance     = 15
+)
+
+// DispatchRouter serves the operations implemented by both DispatchService
+// and EnhancedDispatchService, routing each call by its cutover mode. Shadowed
+// writes compare the legacy result with the enhanced plan or checks, never a
+// second write.
+type DispatchRouter struct {
+	legacy   *DispatchService
+	enhanced *EnhancedDispatchService
+	router   *cutover.Router
+}
+
+// NewDispatchRouter creates a new dispatch cutover facade
+func NewDispatchRouter(legacy *DispatchService, enhanced *EnhancedDispatchService, router *cutover.Router) *DispatchRouter {
+	return &DispatchRouter{legacy: legacy, enhanced: enhanced, router: router}
+}
+
+// CreateTrip creates a trip. The shadow plans the trip the enhanced way and
+// compares its status, miles and duration with the created trip.
+func (r *DispatchRouter) CreateTrip(ctx context.Context, input CreateTripInput) (*domain.Trip, error) {
+	return cutover.Run(ctx, r.router, OpCreateTrip, "", cutover.Paths[*domain.Trip]{
+		Legacy: func(ctx context.Context) (*domain.Trip, error) {
+			return r.legacy.CreateTrip(ctx, input)
+		},
+		Enhanced: func(ctx context.Context) (*domain.Trip, error) {
+			return r.enhanced.CreateTripEnhanced(ctx, input)
+		},
+		Shadow: func(ctx context.Context) (*domain.Trip, error) {
+			trip, _, _, err := r.enhanced.planTrip(ctx, input)
+			return trip, err
+		},
+		Diff: diffTripPlans,
+	})
+}
+
+// AssignDriver assigns a driver to a trip. The shadow runs the enhanced
+// assignment checks, so the comparison is whether both paths accept it.
+func (r *DispatchRouter) AssignDriver(ctx context.Context, tripID, driverID uuid.UUID, tractorID *uuid.UUID) (*domain.Trip, error) {
+	return cutover.Run(ctx, r.router, OpAssignDriver, tripID.String(), cutover.Paths[*domain.Trip]{
+		Legacy: func(ctx context.Context) (*domain.Trip, error) {
+			return r.legacy.AssignDriver(ctx, tripID, driverID, tractorID)
+		},
+		Enhanced: func(ctx context.Context) (*domain.Trip, error) {
+			return r.enhanced.AssignDriverEnhanced(ctx, tripID, driverID, tractorID)
+		},
+		Shadow: func(ctx context.Context) (*domain.Trip, error) {
+			trip, _, _, err := r.enhanced.checkAssignment(ctx, tripID, driverID)
+			return trip, err
+		},
+	})
+}
+
+// FindStreetTurnOpportunities finds street turn matches. Both paths only
+// read, so the shadow runs the full enhanced search and compares the matches.
+func (r *DispatchRouter) FindStreetTurnOpportunities(ctx context.Context, filter repository.StreetTurnFilter) ([]domain.StreetTurnOpportunity, error) {
+	return cutover.Run(ctx, r.router, OpFindStreetTurns, "", cutover.Paths[[]domain.StreetTurnOpportunity]{
+		Legacy: func(ctx context.Context) ([]domain.StreetTurnOpportunity, error) {
+			return r.legacy.FindStreetTurnOpportunities(ctx, filter)
+		},
+		Enhanced: func(ctx context.Context) ([]domain.StreetTurnOpportunity, error) {
+			return r.enhanced.FindStreetTurnOpportunitiesEnhanced(ctx, filter)
+		},
+		Diff: func(legacy, enhanced []domain.StreetTurnOpportunity) []string {
+			// The enhanced search applies MaxResults; the legacy one does not
+			if filter.MaxResults > 0 && len(legacy) > filter.MaxResults {
+				legacy = legacy[:filter.MaxResults]
+			}
+			return diffStreetTurns(legacy, enhanced)
+		},
+	})
+}
+
+// CutoverMetrics returns the routing and shadow comparison counters
+func (r *DispatchRouter) CutoverMetrics() []cutover.OperationMetrics {
+	return r.router.Metrics()
+}
+
+// diffTripPlans compares a created legacy trip with the enhanced plan
+func diffTripPlans(legacy, enhanced *domain.Trip) []string {
+	var diffs []string
+	if legacy.Status != enhanced.Status {
+		diffs = append(diffs, fmt.Sprintf("status %s vs %s", legacy.Status, enhanced.Status))
+	}
+	if math.Abs(legacy.TotalMiles-enhanced.TotalMiles) > shadowMilesTolerancePct*math.Max(legacy.TotalMiles, enhanced.TotalMiles) {
+		diffs = append(diffs, fmt.Sprintf("total miles %.1f vs %.1f", legacy.TotalMiles, enhanced.TotalMiles))
+	}
+	if delta := legacy.EstimatedDurationMins - enhanced.EstimatedDurationMins; delta > shadowMinsTolerance || delta < -shadowMinsTolerance {
+		diffs = append(diffs, fmt.Sprintf("duration %d vs %d min", legacy.EstimatedDurationMins, enhanced.EstimatedDurationMins))
+	}
+	return diffs
+}
+
+// diffStreetTurns compares the matched import/export pairs and the best match
+func diffStreetTurns(legacy, enhanced []domain.StreetTurnOpportunity) []string {
+	type pair struct{ importID, exportID uuid.UUID }
+	key := func(o *domain.StreetTurnOpportunity) pair { return pair{o.ImportOrderID, o.ExportOrderID} }
+
+	var diffs []string
+	if len(legacy) != len(enhanced) {
+		diffs = append(diffs, fmt.Sprintf("%d matches vs %d", len(legacy), len(enhanced)))
+	}
+	if len(legacy) > 0 && len(enhanced) > 0 && key(&legacy[0]) != key(&enhanced[0]) {
+		diffs = append(diffs, fmt.Sprintf("best match %s/%s vs %s/%s",
+			legacy[0].ImportOrderNumber, legacy[0].ExportOrderNumber,
+			enhanced[0].ImportOrderNumber, enhanced[0].ExportOrderNumber))
+	}
+
+	inEnhanced := make(map[pair]bool, len(enhanced))
+	for i := range enhanced {
+		inEnhanced[key(&enhanced[i])] = true
+	}
+	missing := 0
+	for i := range legacy {
+		if !inEnhanced[key(&legacy[i])] {
+			missing++
+		}
+	}
+	if missing > 0 {
+		diffs = append(diffs, fmt.Sprintf("%d legacy matches not found by enhanced", missing))
+	}
+	return diffs
+}
@@ -8,39 +8,76 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/draymaster/services/dispatch-service/internal/domain"
 	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
 	"github.com/draymaster/shared/pkg/kafka"
 	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/validation"
 )
 
 // DispatchService handles trip dispatch business logic
 type DispatchService struct {
-	tripRepo      repository.TripRepository
-	stopRepo      repository.TripStopRepository
-	driverRepo    repository.DriverRepository
-	locationRepo  repository.LocationRepository
-	eventProducer *kafka.Producer
-	logger        *logger.Logger
+	db              *database.DB
+	tripRepo        repository.TripRepository
+	stopRepo        repository.TripStopRepository
+	driverRepo      repository.DriverRepository
+	locationRepo    repository.LocationRepository
+	containerRepo   repository.ContainerRepository
+	orderRepo       repository.OrderRepository
+	escortRepo      repository.EscortRepository
+	appointmentRepo repository.GateAppointmentRepository
+	timeOffRepo     repository.DriverTimeOffRepository
+	hosSnapshotRepo repository.HOSSnapshotRepository
+	exceptions      *ExceptionService
+	dispatchers     *DispatcherService // Optional; assigns new trips to dispatchers
+	eventProducer   *kafka.Producer
+	outbox          *kafka.Outbox
+	logger          *logger.Logger
+	businessRules   *config.BusinessRules
 }
 
 // NewDispatchService creates a new dispatch service
 func NewDispatchService(
+	db *database.DB,
 	tripRepo repository.TripRepository,
 	stopRepo repository.TripStopRepository,
 	driverRepo repository.DriverRepository,
 	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	orderRepo repository.OrderRepository,
+	escortRepo repository.EscortRepository,
+	appointmentRepo repository.GateAppointmentRepository,
+	timeOffRepo repository.DriverTimeOffRepository,
+	hosSnapshotRepo repository.HOSSnapshotRepository,
+	exceptions *ExceptionService,
+	dispatchers *DispatcherService,
 	eventProducer *kafka.Producer,
+	outbox *kafka.Outbox,
 	log *logger.Logger,
 ) *DispatchService {
 	return &DispatchService{
-		tripRepo:      tripRepo,
-		stopRepo:      stopRepo,
-		driverRepo:    driverRepo,
-		locationRepo:  locationRepo,
-		eventProducer: eventProducer,
-		logger:        log,
+		db:              db,
+		tripRepo:        tripRepo,
+		stopRepo:        stopRepo,
+		driverRepo:      driverRepo,
+		locationRepo:    locationRepo,
+		containerRepo:   containerRepo,
+		orderRepo:       orderRepo,
+		escortRepo:      escortRepo,
+		appointmentRepo: appointmentRepo,
+		timeOffRepo:     timeOffRepo,
+		hosSnapshotRepo: hosSnapshotRepo,
+		exceptions:      exceptions,
+		dispatchers:     dispatchers,
+		eventProducer:   eventProducer,
+		outbox:          outbox,
+		logger:          log,
+		businessRules:   config.DefaultBusinessRules(),
 	}
 }
 
@@ -49,9 +86,13 @@ type CreateTripInput struct {
 	Type             domain.TripType
 	Stops            []CreateStopInput
 	OrderIDs         []uuid.UUID
+	CustomerID       *uuid.UUID // Resolves the contract profile; defaults to each stop's order customer
 	PlannedStartTime *time.Time
 	DriverID         *uuid.UUID
 	TractorID        *uuid.UUID
+	DispatcherID     *uuid.UUID // Optional; otherwise assigned by the dispatcher rotation
+	Draft            bool       // Created as DRAFT for a planner to review; ignored when a driver is given
+	Tags             []string   // Labels such as "hot", normalized on create
 	CreatedBy        string
 }
 
@@ -67,6 +108,7 @@ type CreateStopInput struct {
 	AppointmentNumber     string
 	EstimatedDurationMins int
 	FreeTimeMins          int
+	Notes                 string
 }
 
 // CreateTrip creates a new trip with stops
@@ -81,6 +123,11 @@ func (s *DispatchService) CreateTrip(ctx context.Context, input CreateTripInput)
 		return nil, fmt.Errorf("trip must have at least 2 stops")
 	}
 
+	tags, err := validation.NewTagValidator().Normalize(input.Tags)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "tags", input.Tags)
+	}
+
 	// Generate trip number
 	tripNumber, err := s.tripRepo.GetNextTripNumber(ctx)
 	if err != nil {
@@ -97,6 +144,7 @@ func (s *DispatchService) CreateTrip(ctx context.Context, input CreateTripInput)
 		Type:                  input.Type,
 		Status:                domain.TripStatusPlanned,
 		DriverID:              input.DriverID,
+		DispatcherID:          input.DispatcherID,
 		TractorID:             input.TractorID,
 		CurrentStopSequence:   1,
 		PlannedStartTime:      input.PlannedStartTime,
@@ -104,6 +152,7 @@ func (s *DispatchService) CreateTrip(ctx context.Context, input CreateTripInput)
 		TotalMiles:            totalMiles,
 		IsStreetTurn:          input.Type == domain.TripTypeStreetTurn,
 		IsDualTransaction:     input.Type == domain.TripTypeDualTransaction,
+		Tags:                  tags,
 		CreatedBy:             input.CreatedBy,
 	}
 
@@ -113,49 +162,87 @@ func (s *DispatchService) CreateTrip(ctx context.Context, input CreateTripInput)
 		trip.PlannedEndTime = &endTime
 	}
 
+	if input.Draft {
+		trip.Status = domain.TripStatusDraft
+	}
+
 	// Set status to assigned if driver is provided
 	if input.DriverID != nil {
+		if err := checkScheduleConflicts(ctx, s.tripRepo, s.timeOffRepo, &s.businessRules.Schedule, *input.DriverID, trip); err != nil {
+			return nil, err
+		}
 		trip.Status = domain.TripStatusAssigned
 	}
 
-	if err := s.tripRepo.Create(ctx, trip); err != nil {
-		return nil, fmt.Errorf("failed to create trip: %w", err)
+	if s.dispatchers != nil {
+		first := input.Stops[0]
+		for _, stop := range input.Stops[1:] {
+			if stop.Sequence < first.Sequence {
+				first = stop
+			}
+		}
+		if err := s.dispatchers.AssignNewTrip(ctx, trip, first.LocationID); err != nil {
+			s.logger.Warnw("Failed to assign dispatcher to new trip", "trip_number", trip.TripNumber, "error", err)
+		}
 	}
 
-	// Create stops
+	// The trip, its stops and its TripCreated event commit together
 	stops := make([]domain.TripStop, len(input.Stops))
-	for i, stopInput := range input.Stops {
-		stop := domain.TripStop{
-			ID:                    uuid.New(),
-			TripID:                trip.ID,
-			Sequence:              stopInput.Sequence,
-			Type:                  stopInput.Type,
-			Activity:              stopInput.Activity,
-			Status:                domain.StopStatusPending,
-			LocationID:            stopInput.LocationID,
-			ContainerID:           stopInput.ContainerID,
-			OrderID:               stopInput.OrderID,
-			AppointmentTime:       stopInput.AppointmentTime,
-			AppointmentNumber:     stopInput.AppointmentNumber,
-			EstimatedDurationMins: stopInput.EstimatedDurationMins,
-			FreeTimeMins:          stopInput.FreeTimeMins,
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+		if err := s.tripRepo.Create(txCtx, trip); err != nil {
+			return fmt.Errorf("failed to create trip: %w", err)
+		}
+
+		for i, stopInput := range input.Stops {
+			stop := domain.TripStop{
+				ID:                    uuid.New(),
+				TripID:                trip.ID,
+				Sequence:              stopInput.Sequence,
+				Type:                  stopInput.Type,
+				Activity:              stopInput.Activity,
+				Status:                domain.StopStatusPending,
+				LocationID:            stopInput.LocationID,
+				ContainerID:           stopInput.ContainerID,
+				OrderID:               stopInput.OrderID,
+				AppointmentTime:       stopInput.AppointmentTime,
+				AppointmentNumber:     stopInput.AppointmentNumber,
+				EstimatedDurationMins: stopInput.EstimatedDurationMins,
+				FreeTimeMins:          stopInput.FreeTimeMins,
+				Notes:                 stopInput.Notes,
+			}
+
+			if err := s.stopRepo.Create(txCtx, &stop); err != nil {
+				return fmt.Errorf("failed to create stop: %w", err)
+			}
+			stops[i] = stop
 		}
 
-		if err := s.stopRepo.Create(ctx, &stop); err != nil {
-			return nil, fmt.Errorf("failed to create stop: %w", err)
+		data := map[string]interface{}{
+			"trip_id":     trip.ID.String(),
+			"trip_number": trip.TripNumber,
+			"type":        trip.Type,
+			"stop_count":  len(stops),
 		}
-		stops[i] = stop
+		if trip.DispatcherID != nil {
+			data["dispatcher_id"] = trip.DispatcherID.String()
+		}
+		event := kafka.NewEvent(kafka.Topics.TripCreated, "dispatch-service", data).WithTags(trip.Tags)
+		if err := s.outbox.Publish(txCtx, kafka.Topics.TripCreated, event); err != nil {
+			return fmt.Errorf("failed to queue trip created event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	trip.Stops = stops
 
-	// Publish event
-	event := kafka.NewEvent(kafka.Topics.TripCreated, "dispatch-service", map[string]interface{}{
-		"trip_id":     trip.ID.String(),
-		"trip_number": trip.TripNumber,
-		"type":        trip.Type,
-		"stop_count":  len(stops),
-	})
-	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripCreated, event)
+	if trip.DriverID != nil {
+		if driver, err := s.driverRepo.GetByID(ctx, *trip.DriverID); err == nil {
+			s.snapshotHOS(ctx, trip, driver, domain.HOSSnapshotAssigned, input.CreatedBy)
+		}
+	}
 
 	s.logger.Infow("Trip created",
 		"trip_id", trip.ID,
@@ -218,6 +305,40 @@ func (s *DispatchService) AssignDriver(ctx context.Context, tripID, driverID uui
 			driver.AvailableDriveMins, trip.EstimatedDurationMins)
 	}
 
+	// Check endorsements and expired-document restrictions. A driver without
+	// a valid TWIC may take an escorted trip if every terminal allows escorts.
+	needsTWIC, needsHazmat, err := tripRequirements(ctx, s.stopRepo, s.locationRepo, s.containerRepo, tripID)
+	if err != nil {
+		return nil, err
+	}
+	var escorts *escortPlan
+	if needsTWIC && trip.TWICEscort && !driverHasValidTWIC(&s.businessRules.Documents, driver, time.Now()) {
+		if escorts, err = planTripEscorts(ctx, s.escortRepo, s.stopRepo, s.locationRepo, tripID); err != nil {
+			return nil, err
+		}
+		needsTWIC = false
+	}
+	if err := checkDriverDocuments(&s.businessRules.Documents, driver, needsTWIC, needsHazmat); err != nil {
+		return nil, err
+	}
+
+	// Check the driver is not already committed during the trip
+	if err := checkScheduleConflicts(ctx, s.tripRepo, s.timeOffRepo, &s.businessRules.Schedule, driverID, trip); err != nil {
+		return nil, err
+	}
+
+	// Move the trip's gate appointment hours to the driver, refusing a driver
+	// already booked at another terminal in one of them
+	appointments, err := s.appointmentRepo.GetActiveByTrip(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate appointments", err)
+	}
+	for i := range appointments {
+		if err := claimDriverHours(ctx, s.appointmentRepo, driverID, &appointments[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update trip
 	trip.DriverID = &driverID
 	trip.TractorID = tractorID
@@ -226,13 +347,17 @@ func (s *DispatchService) AssignDriver(ctx context.Context, tripID, driverID uui
 	if err := s.tripRepo.Update(ctx, trip); err != nil {
 		return nil, fmt.Errorf("failed to assign driver: %w", err)
 	}
+	if err := settleEscortFees(ctx, s.escortRepo, s.eventProducer, s.logger, &s.businessRules.Rates, trip, driverID, escorts, time.Now()); err != nil {
+		return nil, err
+	}
+	s.snapshotHOS(ctx, trip, driver, domain.HOSSnapshotAssigned, "")
 
 	// Publish event
 	event := kafka.NewEvent(kafka.Topics.TripAssigned, "dispatch-service", map[string]interface{}{
 		"trip_id":     tripID.String(),
 		"driver_id":   driverID.String(),
 		"driver_name": driver.Name,
-	})
+	}).WithTags(trip.Tags)
 	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAssigned, event)
 
 	trip.Driver = driver
@@ -255,6 +380,16 @@ func (s *DispatchService) DispatchTrip(ctx context.Context, tripID uuid.UUID) (*
 		return nil, fmt.Errorf("trip has no driver assigned")
 	}
 
+	if err := s.checkExportIngateVGM(ctx, trip); err != nil {
+		return nil, err
+	}
+	if err := s.checkRateConfirmations(ctx, trip); err != nil {
+		return nil, err
+	}
+	if err := s.checkGateAppointments(ctx, trip); err != nil {
+		return nil, err
+	}
+
 	// Update status
 	trip.Status = domain.TripStatusDispatched
 	now := time.Now()
@@ -265,6 +400,9 @@ func (s *DispatchService) DispatchTrip(ctx context.Context, tripID uuid.UUID) (*
 	if err := s.tripRepo.Update(ctx, trip); err != nil {
 		return nil, fmt.Errorf("failed to dispatch trip: %w", err)
 	}
+	if trip.Driver != nil {
+		s.snapshotHOS(ctx, trip, trip.Driver, domain.HOSSnapshotDispatched, "")
+	}
 
 	// Publish event for driver mobile app
 	event := kafka.NewEvent(kafka.Topics.TripDispatched, "dispatch-service", map[string]interface{}{
@@ -272,7 +410,7 @@ func (s *DispatchService) DispatchTrip(ctx context.Context, tripID uuid.UUID) (*
 		"trip_number": trip.TripNumber,
 		"driver_id":   trip.DriverID.String(),
 		"stops":       len(trip.Stops),
-	})
+	}).WithTags(trip.Tags)
 	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripDispatched, event)
 
 	s.logger.Infow("Trip dispatched",
@@ -285,6 +423,13 @@ func (s *DispatchService) DispatchTrip(ctx context.Context, tripID uuid.UUID) (*
 
 // RecordStopArrival records driver arrival at a stop
 func (s *DispatchService) RecordStopArrival(ctx context.Context, tripID, stopID uuid.UUID, arrivalTime time.Time, lat, lon float64) (*domain.TripStop, error) {
+	return s.recordStopArrival(ctx, tripID, stopID, arrivalTime, lat, lon, domain.StopArrivalSourceDriver)
+}
+
+// recordStopArrival records arrival at a stop from the driver or a geofence
+// entry. An arrival the geofence already recorded is kept, so the driver
+// reporting in late does not restart the free time clock.
+func (s *DispatchService) recordStopArrival(ctx context.Context, tripID, stopID uuid.UUID, arrivalTime time.Time, lat, lon float64, source string) (*domain.TripStop, error) {
 	stop, err := s.stopRepo.GetByID(ctx, stopID)
 	if err != nil {
 		return nil, err
@@ -293,9 +438,22 @@ func (s *DispatchService) RecordStopArrival(ctx context.Context, tripID, stopID
 	if stop.TripID != tripID {
 		return nil, fmt.Errorf("stop does not belong to trip")
 	}
+	if stop.ActualArrival != nil && stop.ArrivalSource == domain.StopArrivalSourceGeofence {
+		return stop, nil
+	}
 
 	stop.Status = domain.StopStatusArrived
 	stop.ActualArrival = &arrivalTime
+	stop.ArrivalSource = source
+
+	var check *positionCheck
+	if hasPosition(lat, lon) {
+		stop.ArrivalLatitude, stop.ArrivalLongitude = &lat, &lon
+		if check = s.checkStopPosition(ctx, stop, lat, lon); check != nil {
+			stop.ArrivalOffsetMeters = &check.offsetMeters
+			stop.LocationMismatch = stop.LocationMismatch || check.mismatch()
+		}
+	}
 
 	// Update trip status if this is first stop
 	trip, _ := s.tripRepo.GetByID(ctx, tripID)
@@ -309,6 +467,12 @@ func (s *DispatchService) RecordStopArrival(ctx context.Context, tripID, stopID
 		return nil, fmt.Errorf("failed to record arrival: %w", err)
 	}
 
+	if check != nil && check.mismatch() {
+		s.raiseLocationMismatch(ctx, trip, stop, "arrival", lat, lon, check, arrivalTime)
+	}
+
+	s.publishStopArrived(ctx, trip, stop)
+
 	return stop, nil
 }
 
@@ -322,20 +486,49 @@ func (s *DispatchService) CompleteStop(ctx context.Context, input CompleteStopIn
 	if stop.TripID != input.TripID {
 		return nil, fmt.Errorf("stop does not belong to trip")
 	}
+	if err := s.checkSealCleared(stop); err != nil {
+		return nil, err
+	}
 
 	// Update stop
 	stop.Status = domain.StopStatusCompleted
 	stop.ActualDeparture = &input.DepartureTime
 	stop.GateTicketNumber = input.GateTicketNumber
-	stop.SealNumber = input.SealNumber
+	if stop.SealStatus == "" {
+		// Seals verified at pickup keep the number the driver observed
+		stop.SealNumber = input.SealNumber
+	}
 	stop.Notes = input.Notes
 
+	var check *positionCheck
+	if hasPosition(input.Latitude, input.Longitude) {
+		lat, lon := input.Latitude, input.Longitude
+		stop.CompletionLatitude, stop.CompletionLongitude = &lat, &lon
+		if check = s.checkStopPosition(ctx, stop, lat, lon); check != nil {
+			stop.CompletionOffsetMeters = &check.offsetMeters
+			stop.LocationMismatch = stop.LocationMismatch || check.mismatch()
+		}
+	}
+
+	// Drivers often complete the stop after pulling out; the geofence exit
+	// is when they actually left
+	if stop.GeofenceExitedAt != nil && stop.GeofenceExitedAt.Before(input.DepartureTime) {
+		stop.ActualDeparture = stop.GeofenceExitedAt
+	}
+
 	// Calculate actual duration
 	if stop.ActualArrival != nil {
-		stop.ActualDurationMins = int(input.DepartureTime.Sub(*stop.ActualArrival).Minutes())
+		stop.ActualDurationMins = int(stop.ActualDeparture.Sub(*stop.ActualArrival).Minutes())
 		stop.DetentionMins = stop.CalculateDetention()
 	}
 
+	// Detention the monitor has not yet picked up started during this stop
+	detentionMissed := stop.DetentionMins > 0 && stop.DetentionStartTime == nil
+	if detentionMissed {
+		start := stop.ActualArrival.Add(time.Duration(stop.FreeTimeMins) * time.Minute)
+		stop.DetentionStartTime = &start
+	}
+
 	// Handle equipment changes
 	if input.ChassisID != nil {
 		stop.ChassisOutID = input.ChassisID
@@ -350,6 +543,13 @@ func (s *DispatchService) CompleteStop(ctx context.Context, input CompleteStopIn
 
 	// Check if trip is complete
 	trip, _ := s.tripRepo.GetByID(ctx, input.TripID)
+	if detentionMissed {
+		s.publishDetentionStarted(ctx, trip, stop)
+	}
+	s.publishStopDeparted(ctx, trip, stop)
+	if check != nil && check.mismatch() {
+		s.raiseLocationMismatch(ctx, trip, stop, "completion", input.Latitude, input.Longitude, check, input.DepartureTime)
+	}
 	if trip != nil {
 		allComplete := s.checkAllStopsComplete(ctx, input.TripID)
 		if allComplete {
@@ -360,7 +560,7 @@ func (s *DispatchService) CompleteStop(ctx context.Context, input CompleteStopIn
 			event := kafka.NewEvent(kafka.Topics.TripCompleted, "dispatch-service", map[string]interface{}{
 				"trip_id":     trip.ID.String(),
 				"trip_number": trip.TripNumber,
-			})
+			}).WithTags(trip.Tags)
 			_ = s.eventProducer.Publish(ctx, kafka.Topics.TripCompleted, event)
 		} else {
 			// Update current stop sequence
@@ -371,10 +571,11 @@ func (s *DispatchService) CompleteStop(ctx context.Context, input CompleteStopIn
 
 	// Publish stop completed event
 	event := kafka.NewEvent(kafka.Topics.StopCompleted, "dispatch-service", map[string]interface{}{
-		"trip_id":    input.TripID.String(),
-		"stop_id":    input.StopID.String(),
-		"sequence":   stop.Sequence,
-		"detention":  stop.DetentionMins,
+		"trip_id":           input.TripID.String(),
+		"stop_id":           input.StopID.String(),
+		"sequence":          stop.Sequence,
+		"detention":         stop.DetentionMins,
+		"location_mismatch": stop.LocationMismatch,
 	})
 	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopCompleted, event)
 
@@ -392,6 +593,99 @@ type CompleteStopInput struct {
 	ContainerNumber  string
 	DocumentIDs      []string
 	Notes            string
+	Latitude         float64 // Driver position at completion; zero when not reported
+	Longitude        float64
+}
+
+// metersPerMile converts haversineMiles results to meters
+const metersPerMile = 1609.344
+
+// positionCheck is a reported stop position measured against the stop location
+type positionCheck struct {
+	location        *domain.Location
+	offsetMeters    float64
+	toleranceMeters float64
+}
+
+// mismatch reports whether the position is outside the location's tolerance
+func (c *positionCheck) mismatch() bool {
+	return c.offsetMeters > c.toleranceMeters
+}
+
+// hasPosition reports whether a driver position was supplied. Devices
+// without a fix report 0,0, which is never a real stop location.
+func hasPosition(lat, lon float64) bool {
+	return lat != 0 || lon != 0
+}
+
+// checkStopPosition measures how far a reported position is from the stop
+// location. The tolerance is the location's geofence radius plus a GPS
+// buffer, or the default tolerance when the location has no geofence. It
+// returns nil when the location or its coordinates are unknown.
+func (s *DispatchService) checkStopPosition(ctx context.Context, stop *domain.TripStop, lat, lon float64) *positionCheck {
+	location := stop.Location
+	if location == nil {
+		location, _ = s.locationRepo.GetByID(ctx, stop.LocationID)
+	}
+	if location == nil || !hasPosition(location.Latitude, location.Longitude) {
+		return nil
+	}
+
+	offset := haversineMiles(lat, lon, location.Latitude, location.Longitude) * metersPerMile
+	return &positionCheck{
+		location:        location,
+		offsetMeters:    math.Round(offset*10) / 10,
+		toleranceMeters: s.businessRules.StopLocation.ToleranceMeters(location.GeofenceRadiusMeters),
+	}
+}
+
+// raiseLocationMismatch puts a stop event recorded away from the stop
+// location into the exception queue for review. Failures are logged rather
+// than returned so the driver's arrival or completion still stands.
+func (s *DispatchService) raiseLocationMismatch(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, event string, lat, lon float64, check *positionCheck, occurredAt time.Time) {
+	s.logger.Warnw("Stop event reported away from stop location",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"event", event,
+		"offset_meters", check.offsetMeters,
+		"tolerance_meters", check.toleranceMeters,
+	)
+	if s.exceptions == nil {
+		return
+	}
+
+	var driverID *uuid.UUID
+	if trip != nil {
+		driverID = trip.DriverID
+	}
+	_, err := s.exceptions.CreateException(ctx, CreateExceptionInput{
+		TripID:   stop.TripID,
+		StopID:   &stop.ID,
+		OrderID:  stop.OrderID,
+		DriverID: driverID,
+		Type:     domain.ExceptionTypeLocationMismatch,
+		Title: fmt.Sprintf("Stop %d %s recorded %.1f mi from %s",
+			stop.Sequence, event, check.offsetMeters/metersPerMile, check.location.Name),
+		Description: fmt.Sprintf("Driver reported %s at %.6f,%.6f, %.0f m from the stop location (tolerance %.0f m). Verify the stop was actually serviced.",
+			event, lat, lon, check.offsetMeters, check.toleranceMeters),
+		LocationID: &stop.LocationID,
+		Latitude:   lat,
+		Longitude:  lon,
+		ReportedBy: "dispatch-service",
+		Metadata: map[string]string{
+			"event":            event,
+			"offset_meters":    fmt.Sprintf("%.1f", check.offsetMeters),
+			"tolerance_meters": fmt.Sprintf("%.1f", check.toleranceMeters),
+		},
+		OccurredAt: &occurredAt,
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to raise location mismatch exception",
+			"trip_id", stop.TripID,
+			"stop_id", stop.ID,
+			"error", err,
+		)
+	}
 }
 
 // FindStreetTurnOpportunities finds potential street turn matches
@@ -486,7 +780,14 @@ func (s *DispatchService) GetDispatchBoard(ctx context.Context, date time.Time)
 		AsOf:        time.Now(),
 	}
 
+	accruals, err := s.accrueTripCosts(ctx, trips)
+	if err != nil {
+		// The board is still useful without margins
+		s.logger.Warnw("Could not accrue trip costs for the dispatch board", "error", err)
+	}
+
 	for _, trip := range trips {
+		trip.CostAccrual = accruals[trip.ID]
 		switch trip.Status {
 		case domain.TripStatusPlanned:
 			board.Unassigned = append(board.Unassigned, trip)
@@ -506,17 +807,31 @@ func (s *DispatchService) GetDispatchBoard(ctx context.Context, date time.Time)
 	return board, nil
 }
 
-// GetDriverAvailability returns available drivers sorted by proximity
-func (s *DispatchService) GetDriverAvailability(ctx context.Context, pickupLat, pickupLon float64, requiredDriveMins int, requireTWIC bool) ([]domain.DriverAvailability, error) {
+// GetDriverAvailability returns available drivers sorted by proximity. When
+// the pickup terminal admits escorted drivers, drivers without a valid TWIC
+// are included and marked as needing an escort rather than left out.
+func (s *DispatchService) GetDriverAvailability(ctx context.Context, pickupLat, pickupLon float64, requiredDriveMins int, requireTWIC bool, terminalID *uuid.UUID) ([]domain.DriverAvailability, error) {
 	drivers, err := s.driverRepo.GetAvailable(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get drivers: %w", err)
 	}
 
+	var escortPolicy *domain.TerminalEscortPolicy
+	if requireTWIC && terminalID != nil {
+		if escortPolicy, err = s.escortRepo.GetPolicy(ctx, *terminalID); err != nil {
+			return nil, fmt.Errorf("failed to get terminal escort policy: %w", err)
+		}
+		if escortPolicy != nil && !escortPolicy.EscortsAllowed {
+			escortPolicy = nil
+		}
+	}
+
+	now := time.Now()
 	var availability []domain.DriverAvailability
 	for _, driver := range drivers {
-		// Filter by TWIC if required
-		if requireTWIC && !driver.HasTWIC {
+		// Filter by TWIC and expired-document restrictions
+		needsEscort := requireTWIC && escortPolicy != nil && !driverHasValidTWIC(&s.businessRules.Documents, &driver, now)
+		if checkDriverDocuments(&s.businessRules.Documents, &driver, requireTWIC && !needsEscort, false) != nil {
 			continue
 		}
 
@@ -526,7 +841,7 @@ func (s *DispatchService) GetDriverAvailability(ctx context.Context, pickupLat,
 		}
 
 		// Calculate distance to pickup
-		distance := s.haversineDistance(driver.CurrentLatitude, driver.CurrentLongitude, pickupLat, pickupLon)
+		distance := haversineMiles(driver.CurrentLatitude, driver.CurrentLongitude, pickupLat, pickupLon)
 		etaMins := int(distance / 0.75) // Assume 45 mph average
 
 		availability = append(availability, domain.DriverAvailability{
@@ -540,7 +855,15 @@ func (s *DispatchService) GetDriverAvailability(ctx context.Context, pickupLat,
 			DistanceToPickupMiles: distance,
 			ETAToPickupMins:       etaMins,
 			HasTWIC:               driver.HasTWIC,
+			NeedsEscort:           needsEscort,
 		})
+		if needsEscort {
+			fee := escortPolicy.Fee
+			if fee <= 0 {
+				fee = s.businessRules.Rates.TWICEscortCharge
+			}
+			availability[len(availability)-1].EscortFee = fee
+		}
 	}
 
 	// Sort by distance
@@ -618,7 +941,8 @@ func (s *DispatchService) calculateStreetTurnSavings(opp *domain.StreetTurnOppor
 	return savedMiles * ratePerMile
 }
 
-func (s *DispatchService) haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+// haversineMiles returns the great-circle distance between two coordinates in miles
+func haversineMiles(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadiusMiles = 3959
 
 	lat1Rad := lat1 * math.Pi / 180
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"math"
 	"sort"
 	"time"
 
@@ -17,6 +16,7 @@ import (
 	"github.com/draymaster/shared/pkg/database"
 	"github.com/draymaster/shared/pkg/kafka"
 	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/validation"
 )
 
 // EnhancedDispatchService handles trip dispatch with optimizations
@@ -26,8 +26,16 @@ type EnhancedDispatchService struct {
 	stopRepo      repository.TripStopRepository
 	driverRepo    repository.DriverRepository
 	locationRepo  repository.LocationRepository
+	containerRepo repository.ContainerRepository
 	equipmentRepo repository.EquipmentRepository
+	profileRepo   repository.CustomerProfileRepository
+	escortRepo    repository.EscortRepository
+	timeOffRepo   repository.DriverTimeOffRepository
+	snapshotRepo  repository.HOSSnapshotRepository
+	dispatchers   *DispatcherService // Optional; assigns new trips to dispatchers
+	axleWeights   *AxleWeightService // Optional; warns when a new trip's containers are over axle limits
 	eventProducer *kafka.Producer
+	outbox        *kafka.Outbox
 	logger        *logger.Logger
 	businessRules *config.BusinessRules
 }
@@ -39,8 +47,16 @@ func NewEnhancedDispatchService(
 	stopRepo repository.TripStopRepository,
 	driverRepo repository.DriverRepository,
 	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
 	equipmentRepo repository.EquipmentRepository,
+	profileRepo repository.CustomerProfileRepository,
+	escortRepo repository.EscortRepository,
+	timeOffRepo repository.DriverTimeOffRepository,
+	snapshotRepo repository.HOSSnapshotRepository,
+	dispatchers *DispatcherService,
+	axleWeights *AxleWeightService,
 	eventProducer *kafka.Producer,
+	outbox *kafka.Outbox,
 	log *logger.Logger,
 ) *EnhancedDispatchService {
 	return &EnhancedDispatchService{
@@ -49,8 +65,16 @@ func NewEnhancedDispatchService(
 		stopRepo:      stopRepo,
 		driverRepo:    driverRepo,
 		locationRepo:  locationRepo,
+		containerRepo: containerRepo,
 		equipmentRepo: equipmentRepo,
+		profileRepo:   profileRepo,
+		escortRepo:    escortRepo,
+		timeOffRepo:   timeOffRepo,
+		snapshotRepo:  snapshotRepo,
+		dispatchers:   dispatchers,
+		axleWeights:   axleWeights,
 		eventProducer: eventProducer,
+		outbox:        outbox,
 		logger:        log,
 		businessRules: config.DefaultBusinessRules(),
 	}
@@ -63,81 +87,52 @@ func (s *EnhancedDispatchService) CreateTripEnhanced(ctx context.Context, input
 		"stops", len(input.Stops),
 	)
 
-	// Validate minimum stops
-	if len(input.Stops) < 2 {
-		return nil, apperrors.ValidationError("trip must have at least 2 stops", "stops", len(input.Stops))
-	}
-
-	// Validate driver availability if assigned
-	if input.DriverID != nil {
-		if err := s.validateDriverAvailability(ctx, *input.DriverID, input.PlannedStartTime); err != nil {
-			return nil, err
-		}
+	trip, stopInputs, locations, err := s.planTrip(ctx, input)
+	if err != nil {
+		return nil, err
 	}
 
-	var trip *domain.Trip
-
 	// Execute in transaction
-	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
-		// Load location details for all stops
-		locations, err := s.loadStopLocations(ctx, input.Stops)
-		if err != nil {
-			return err
-		}
-
-		// Calculate actual trip metrics
-		totalMiles, totalDuration, err := s.calculateRealTripMetrics(ctx, locations, input.Stops)
-		if err != nil {
-			return err
-		}
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
 
 		// Generate trip number
-		tripNumber, err := s.tripRepo.GetNextTripNumber(ctx)
+		tripNumber, err := s.tripRepo.GetNextTripNumber(txCtx)
 		if err != nil {
 			return apperrors.DatabaseError("generate trip number", err)
 		}
+		trip.TripNumber = tripNumber
 
-		// Create trip
-		trip = &domain.Trip{
-			ID:                    uuid.New(),
-			TripNumber:            tripNumber,
-			Type:                  input.Type,
-			Status:                domain.TripStatusPlanned,
-			DriverID:              input.DriverID,
-			TractorID:             input.TractorID,
-			CurrentStopSequence:   1,
-			PlannedStartTime:      input.PlannedStartTime,
-			EstimatedDurationMins: totalDuration,
-			TotalMiles:            totalMiles,
-			IsStreetTurn:          input.Type == domain.TripTypeStreetTurn,
-			IsDualTransaction:     input.Type == domain.TripTypeDualTransaction,
-			CreatedBy:             input.CreatedBy,
-			CreatedAt:             time.Now(),
-			UpdatedAt:             time.Now(),
-		}
-
-		// Calculate planned end time
-		if input.PlannedStartTime != nil {
-			endTime := input.PlannedStartTime.Add(time.Duration(totalDuration) * time.Minute)
-			trip.PlannedEndTime = &endTime
-		}
-
-		// Set status to assigned if driver is provided
-		if input.DriverID != nil {
-			trip.Status = domain.TripStatusAssigned
+		if s.dispatchers != nil {
+			if err := s.dispatchers.AssignNewTrip(ctx, trip, stopInputs[0].LocationID); err != nil {
+				s.logger.Warnw("Failed to assign dispatcher to new trip", "trip_number", trip.TripNumber, "error", err)
+			}
 		}
 
-		if err := s.tripRepo.Create(ctx, trip); err != nil {
+		if err := s.tripRepo.Create(txCtx, trip); err != nil {
 			return apperrors.DatabaseError("create trip", err)
 		}
 
 		// Create stops with calculated ETAs
-		stops, err := s.createTripStops(ctx, trip, input.Stops, locations)
+		stops, err := s.createTripStops(txCtx, trip, input.CustomerID, stopInputs, locations)
 		if err != nil {
 			return err
 		}
 		trip.Stops = stops
 
+		// Queued with the trip so the event is not lost if Kafka is down
+		event := kafka.NewEvent(kafka.Topics.TripCreated, "dispatch-service", map[string]interface{}{
+			"trip_id":        trip.ID.String(),
+			"trip_number":    trip.TripNumber,
+			"type":           trip.Type,
+			"stop_count":     len(trip.Stops),
+			"total_miles":    trip.TotalMiles,
+			"total_duration": trip.EstimatedDurationMins,
+		}).WithTags(trip.Tags)
+		if err := s.outbox.Publish(txCtx, kafka.Topics.TripCreated, event); err != nil {
+			return apperrors.DatabaseError("queue trip created event", err)
+		}
+
 		return nil
 	})
 
@@ -146,16 +141,19 @@ func (s *EnhancedDispatchService) CreateTripEnhanced(ctx context.Context, input
 		return nil, err
 	}
 
-	// Publish event (outside transaction)
-	event := kafka.NewEvent(kafka.Topics.TripCreated, "dispatch-service", map[string]interface{}{
-		"trip_id":       trip.ID.String(),
-		"trip_number":   trip.TripNumber,
-		"type":          trip.Type,
-		"stop_count":    len(trip.Stops),
-		"total_miles":   trip.TotalMiles,
-		"total_duration": trip.EstimatedDurationMins,
-	})
-	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripCreated, event)
+	if trip.DriverID != nil {
+		if driver, err := s.driverRepo.GetByID(ctx, *trip.DriverID); err == nil {
+			if snapshot := recordHOSSnapshot(ctx, s.snapshotRepo, s.logger, &s.businessRules.BreakPlanning, trip, driver, domain.HOSSnapshotAssigned, input.CreatedBy, time.Now()); snapshot != nil {
+				trip.HOSSnapshots = append(trip.HOSSnapshots, *snapshot)
+			}
+		}
+	}
+
+	if s.axleWeights != nil {
+		if _, err := s.axleWeights.CheckTrip(ctx, trip.ID); err != nil {
+			s.logger.Warnw("Failed to check axle weights", "trip_number", trip.TripNumber, "error", err)
+		}
+	}
 
 	s.logger.Infow("Trip created successfully",
 		"trip_id", trip.ID,
@@ -167,6 +165,80 @@ func (s *EnhancedDispatchService) CreateTripEnhanced(ctx context.Context, input
 	return trip, nil
 }
 
+// planTrip validates the input and builds the trip the enhanced path would
+// create, with its stops after scale stops are inserted. Nothing is written,
+// so the plan can also be compared against the legacy path in shadow mode.
+func (s *EnhancedDispatchService) planTrip(ctx context.Context, input CreateTripInput) (*domain.Trip, []CreateStopInput, map[uuid.UUID]*domain.Location, error) {
+	// Validate minimum stops
+	if len(input.Stops) < 2 {
+		return nil, nil, nil, apperrors.ValidationError("trip must have at least 2 stops", "stops", len(input.Stops))
+	}
+	tags, err := validation.NewTagValidator().Normalize(input.Tags)
+	if err != nil {
+		return nil, nil, nil, apperrors.ValidationError(err.Error(), "tags", input.Tags)
+	}
+
+	// Validate driver availability if assigned
+	if input.DriverID != nil {
+		if err := s.validateDriverAvailability(ctx, *input.DriverID, input.PlannedStartTime); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// Load location details for all stops
+	locations, err := s.loadStopLocations(ctx, input.Stops)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Add any legally required scale stops before routing
+	stopInputs, err := s.insertScaleStops(ctx, input.Stops, locations)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Calculate actual trip metrics
+	totalMiles, totalDuration, err := s.calculateRealTripMetrics(ctx, locations, stopInputs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	trip := &domain.Trip{
+		ID:                    uuid.New(),
+		Type:                  input.Type,
+		Status:                domain.TripStatusPlanned,
+		DriverID:              input.DriverID,
+		DispatcherID:          input.DispatcherID,
+		TractorID:             input.TractorID,
+		CurrentStopSequence:   1,
+		PlannedStartTime:      input.PlannedStartTime,
+		EstimatedDurationMins: totalDuration,
+		TotalMiles:            totalMiles,
+		IsStreetTurn:          input.Type == domain.TripTypeStreetTurn,
+		IsDualTransaction:     input.Type == domain.TripTypeDualTransaction,
+		Tags:                  tags,
+		CreatedBy:             input.CreatedBy,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	// Calculate planned end time
+	if input.PlannedStartTime != nil {
+		endTime := input.PlannedStartTime.Add(time.Duration(totalDuration) * time.Minute)
+		trip.PlannedEndTime = &endTime
+	}
+
+	// Set status to assigned if driver is provided
+	if input.DriverID != nil {
+		if err := checkScheduleConflicts(ctx, s.tripRepo, s.timeOffRepo, &s.businessRules.Schedule, *input.DriverID, trip); err != nil {
+			return nil, nil, nil, err
+		}
+		trip.Status = domain.TripStatusAssigned
+	}
+
+	return trip, stopInputs, locations, nil
+}
+
 // validateDriverAvailability checks if driver can accept the trip
 func (s *EnhancedDispatchService) validateDriverAvailability(ctx context.Context, driverID uuid.UUID, _ *time.Time) error {
 	driver, err := s.driverRepo.GetByID(ctx, driverID)
@@ -214,7 +286,7 @@ func (s *EnhancedDispatchService) calculateRealTripMetrics(_ context.Context, lo
 		toLoc := locations[stops[i+1].LocationID]
 
 		// Calculate actual distance using Haversine formula
-		miles := s.haversineDistance(
+		miles := haversineMiles(
 			fromLoc.Latitude, fromLoc.Longitude,
 			toLoc.Latitude, toLoc.Longitude,
 		)
@@ -239,8 +311,9 @@ func (s *EnhancedDispatchService) calculateRealTripMetrics(_ context.Context, lo
 }
 
 // createTripStops creates stops with calculated ETAs
-func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *domain.Trip, stopInputs []CreateStopInput, locations map[uuid.UUID]*domain.Location) ([]domain.TripStop, error) {
+func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *domain.Trip, customerID *uuid.UUID, stopInputs []CreateStopInput, locations map[uuid.UUID]*domain.Location) ([]domain.TripStop, error) {
 	stops := make([]domain.TripStop, len(stopInputs))
+	profiles := newProfileCache(s.profileRepo)
 
 	currentTime := trip.PlannedStartTime
 	if currentTime == nil {
@@ -256,7 +329,7 @@ func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *dom
 			prevLoc := locations[stopInputs[i-1].LocationID]
 			currLoc := locations[stopInput.LocationID]
 
-			miles := s.haversineDistance(
+			miles := haversineMiles(
 				prevLoc.Latitude, prevLoc.Longitude,
 				currLoc.Latitude, currLoc.Longitude,
 			)
@@ -272,10 +345,14 @@ func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *dom
 			estimatedArrival = currentTime
 		}
 
-		// Determine free time based on activity type
+		// Determine free time from the customer contract, then activity type
 		freeTime := stopInput.FreeTimeMins
 		if freeTime == 0 {
-			freeTime = s.businessRules.Time.GetFreeTime(string(stopInput.Activity))
+			profile, err := profiles.resolve(ctx, customerID, stopInput.OrderID)
+			if err != nil {
+				return nil, err
+			}
+			freeTime = profile.GetFreeTime(string(stopInput.Activity), &s.businessRules.Time)
 		}
 
 		stop := domain.TripStop{
@@ -293,6 +370,7 @@ func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *dom
 			EstimatedArrival:      estimatedArrival,
 			EstimatedDurationMins: stopInput.EstimatedDurationMins,
 			FreeTimeMins:          freeTime,
+			Notes:                 stopInput.Notes,
 			CreatedAt:             time.Now(),
 			UpdatedAt:             time.Now(),
 		}
@@ -315,14 +393,57 @@ func (s *EnhancedDispatchService) createTripStops(ctx context.Context, trip *dom
 
 // AssignDriverEnhanced assigns driver with comprehensive validation
 func (s *EnhancedDispatchService) AssignDriverEnhanced(ctx context.Context, tripID, driverID uuid.UUID, tractorID *uuid.UUID) (*domain.Trip, error) {
+	trip, driver, escorts, err := s.checkAssignment(ctx, tripID, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update trip
+	trip.DriverID = &driverID
+	trip.TractorID = tractorID
+	trip.Status = domain.TripStatusAssigned
+	trip.UpdatedAt = time.Now()
+
+	if err := s.tripRepo.Update(ctx, trip); err != nil {
+		return nil, tripUpdateError(ctx, s.tripRepo, tripID, "assign driver", err)
+	}
+	if err := settleEscortFees(ctx, s.escortRepo, s.eventProducer, s.logger, &s.businessRules.Rates, trip, driverID, escorts, time.Now()); err != nil {
+		return nil, err
+	}
+	if snapshot := recordHOSSnapshot(ctx, s.snapshotRepo, s.logger, &s.businessRules.BreakPlanning, trip, driver, domain.HOSSnapshotAssigned, "", time.Now()); snapshot != nil {
+		trip.HOSSnapshots = append(trip.HOSSnapshots, *snapshot)
+	}
+
+	// Publish event
+	event := kafka.NewEvent(kafka.Topics.TripAssigned, "dispatch-service", map[string]interface{}{
+		"trip_id":       tripID.String(),
+		"driver_id":     driverID.String(),
+		"driver_name":   driver.Name,
+		"trip_number":   trip.TripNumber,
+	}).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAssigned, event)
+
+	trip.Driver = driver
+	s.logger.Infow("Driver assigned to trip",
+		"trip_id", tripID,
+		"driver_id", driverID,
+		"driver_name", driver.Name,
+	)
+
+	return trip, nil
+}
+
+// checkAssignment runs the enhanced assignment checks without assigning. It
+// returns the escorts the driver needs, or nil when none are needed.
+func (s *EnhancedDispatchService) checkAssignment(ctx context.Context, tripID, driverID uuid.UUID) (*domain.Trip, *domain.Driver, *escortPlan, error) {
 	trip, err := s.tripRepo.GetByID(ctx, tripID)
 	if err != nil {
-		return nil, apperrors.NotFoundError("trip", tripID.String())
+		return nil, nil, nil, apperrors.NotFoundError("trip", tripID.String())
 	}
 
 	// Validate trip status
 	if trip.Status != domain.TripStatusPlanned && trip.Status != domain.TripStatusAssigned {
-		return nil, apperrors.InvalidStateError(
+		return nil, nil, nil, apperrors.InvalidStateError(
 			string(trip.Status),
 			string(domain.TripStatusPlanned)+" or "+string(domain.TripStatusAssigned),
 		)
@@ -331,53 +452,56 @@ func (s *EnhancedDispatchService) AssignDriverEnhanced(ctx context.Context, trip
 	// Validate driver
 	driver, err := s.driverRepo.GetByID(ctx, driverID)
 	if err != nil {
-		return nil, apperrors.NotFoundError("driver", driverID.String())
+		return nil, nil, nil, apperrors.NotFoundError("driver", driverID.String())
 	}
 
 	if driver.Status != "AVAILABLE" && driver.Status != "ON_DUTY" {
-		return nil, apperrors.InvalidStateError(driver.Status, "AVAILABLE or ON_DUTY")
+		return nil, nil, nil, apperrors.InvalidStateError(driver.Status, "AVAILABLE or ON_DUTY")
 	}
 
 	// Check HOS compliance with buffer
 	requiredTime := trip.EstimatedDurationMins + 30 // 30-minute buffer
 	if driver.AvailableDriveMins < requiredTime {
-		return nil, apperrors.InsufficientResourceError(
+		return nil, nil, nil, apperrors.InsufficientResourceError(
 			"driver HOS time",
 			fmt.Sprintf("%d mins", requiredTime),
 			fmt.Sprintf("%d mins", driver.AvailableDriveMins),
 		)
 	}
 
-	// Check if driver requires TWIC and has it
-	// (would check trip requirements)
-
-	// Update trip
-	trip.DriverID = &driverID
-	trip.TractorID = tractorID
-	trip.Status = domain.TripStatusAssigned
-	trip.UpdatedAt = time.Now()
-
-	if err := s.tripRepo.Update(ctx, trip); err != nil {
-		return nil, apperrors.DatabaseError("assign driver", err)
+	// Check endorsements and expired-document restrictions. A driver without
+	// a valid TWIC may take an escorted trip if every terminal allows escorts.
+	needsTWIC, needsHazmat, err := tripRequirements(ctx, s.stopRepo, s.locationRepo, s.containerRepo, tripID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var escorts *escortPlan
+	if needsTWIC && trip.TWICEscort && !driverHasValidTWIC(&s.businessRules.Documents, driver, time.Now()) {
+		if escorts, err = planTripEscorts(ctx, s.escortRepo, s.stopRepo, s.locationRepo, tripID); err != nil {
+			return nil, nil, nil, err
+		}
+		needsTWIC = false
+	}
+	if err := checkDriverDocuments(&s.businessRules.Documents, driver, needsTWIC, needsHazmat); err != nil {
+		s.logger.Warnw("Driver assignment blocked by document restrictions",
+			"trip_id", tripID,
+			"driver_id", driverID,
+			"error", err,
+		)
+		return nil, nil, nil, err
 	}
 
-	// Publish event
-	event := kafka.NewEvent(kafka.Topics.TripAssigned, "dispatch-service", map[string]interface{}{
-		"trip_id":       tripID.String(),
-		"driver_id":     driverID.String(),
-		"driver_name":   driver.Name,
-		"trip_number":   trip.TripNumber,
-	})
-	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripAssigned, event)
-
-	trip.Driver = driver
-	s.logger.Infow("Driver assigned to trip",
-		"trip_id", tripID,
-		"driver_id", driverID,
-		"driver_name", driver.Name,
-	)
+	// Check the driver is not already committed during the trip
+	if err := checkScheduleConflicts(ctx, s.tripRepo, s.timeOffRepo, &s.businessRules.Schedule, driverID, trip); err != nil {
+		s.logger.Warnw("Driver assignment blocked by schedule conflict",
+			"trip_id", tripID,
+			"driver_id", driverID,
+			"error", err,
+		)
+		return nil, nil, nil, err
+	}
 
-	return trip, nil
+	return trip, driver, escorts, nil
 }
 
 // FindStreetTurnOpportunitiesEnhanced finds street turn matches with improved scoring
@@ -483,19 +607,3 @@ func (s *EnhancedDispatchService) calculateRealStreetTurnSavings(opp *domain.Str
 	return totalSavings
 }
 
-// haversineDistance calculates distance between two coordinates
-func (s *EnhancedDispatchService) haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadiusMiles = 3959
-
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadiusMiles * c
-}
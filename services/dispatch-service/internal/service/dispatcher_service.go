@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// activeTripStatuses are the trips that sit on a dispatcher's board
+var activeTripStatuses = []domain.TripStatus{
+	domain.TripStatusPlanned,
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+	domain.TripStatusInProgress,
+}
+
+// DispatcherService spreads trips across the dispatch desk and reports how
+// the work is balanced. New trips go to active dispatchers in turn, either
+// across the whole desk or among those covering the pickup terminal.
+type DispatcherService struct {
+	dispatcherRepo repository.DispatcherRepository
+	tripRepo       repository.TripRepository
+	stopRepo       repository.TripStopRepository
+	eventProducer  *kafka.Producer
+	logger         *logger.Logger
+	businessRules  *config.BusinessRules
+}
+
+// NewDispatcherService creates a new dispatcher service
+func NewDispatcherService(
+	dispatcherRepo repository.DispatcherRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *DispatcherService {
+	return &DispatcherService{
+		dispatcherRepo: dispatcherRepo,
+		tripRepo:       tripRepo,
+		stopRepo:       stopRepo,
+		eventProducer:  eventProducer,
+		logger:         log,
+		businessRules:  config.DefaultBusinessRules(),
+	}
+}
+
+// SaveDispatcherInput contains input for adding or updating a dispatcher
+type SaveDispatcherInput struct {
+	ID             *uuid.UUID // Nil creates a new dispatcher
+	Name           string
+	Email          string
+	Active         bool
+	TerminalIDs    []uuid.UUID
+	MaxActiveTrips int
+}
+
+// SaveDispatcher adds a dispatcher or updates their shift status, terminals
+// and capacity
+func (s *DispatcherService) SaveDispatcher(ctx context.Context, input SaveDispatcherInput) (*domain.Dispatcher, error) {
+	if input.Name == "" {
+		return nil, apperrors.ValidationError("dispatcher name is required", "name", input.Name)
+	}
+	if input.MaxActiveTrips < 0 {
+		return nil, apperrors.ValidationError("max active trips cannot be negative", "max_active_trips", input.MaxActiveTrips)
+	}
+
+	now := time.Now()
+	if input.ID == nil {
+		dispatcher := &domain.Dispatcher{
+			ID:             uuid.New(),
+			Name:           input.Name,
+			Email:          input.Email,
+			Active:         input.Active,
+			TerminalIDs:    input.TerminalIDs,
+			MaxActiveTrips: input.MaxActiveTrips,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.dispatcherRepo.Create(ctx, dispatcher); err != nil {
+			return nil, apperrors.DatabaseError("create dispatcher", err)
+		}
+		return dispatcher, nil
+	}
+
+	dispatcher, err := s.getDispatcher(ctx, *input.ID)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher.Name = input.Name
+	dispatcher.Email = input.Email
+	dispatcher.Active = input.Active
+	dispatcher.TerminalIDs = input.TerminalIDs
+	dispatcher.MaxActiveTrips = input.MaxActiveTrips
+	dispatcher.UpdatedAt = now
+	if err := s.dispatcherRepo.Update(ctx, dispatcher); err != nil {
+		return nil, apperrors.DatabaseError("update dispatcher", err)
+	}
+	return dispatcher, nil
+}
+
+// AssignNewTrip picks a dispatcher for a trip that is about to be created
+// and sets trip.DispatcherID. terminalID is the trip's first pickup location.
+// Trips that already name a dispatcher are left alone, and a trip is left
+// unassigned when nobody is on shift.
+func (s *DispatcherService) AssignNewTrip(ctx context.Context, trip *domain.Trip, terminalID uuid.UUID) error {
+	if trip.DispatcherID != nil {
+		return nil
+	}
+
+	dispatcher, err := s.pickDispatcher(ctx, terminalID, nil)
+	if err != nil {
+		return err
+	}
+	if dispatcher == nil {
+		s.logger.Warnw("No active dispatcher for new trip", "trip_number", trip.TripNumber, "terminal_id", terminalID)
+		return nil
+	}
+
+	trip.DispatcherID = &dispatcher.ID
+	return s.markAssigned(ctx, dispatcher)
+}
+
+// ReassignTrip moves a trip to another dispatcher
+func (s *DispatcherService) ReassignTrip(ctx context.Context, tripID, dispatcherID uuid.UUID, reassignedBy string) (*domain.Trip, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	dispatcher, err := s.getDispatcher(ctx, dispatcherID)
+	if err != nil {
+		return nil, err
+	}
+	if !dispatcher.Active {
+		return nil, apperrors.InvalidStateError("inactive", "active")
+	}
+
+	if err := s.reassign(ctx, trip, dispatcher, reassignedBy); err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
+
+// ReassignDispatcherTrips moves every active trip off a dispatcher, for
+// example at the end of their shift. With toDispatcherID set they all go to
+// that dispatcher; otherwise each trip is assigned as if it were new,
+// excluding the dispatcher being cleared. It returns how many trips moved.
+func (s *DispatcherService) ReassignDispatcherTrips(ctx context.Context, fromDispatcherID uuid.UUID, toDispatcherID *uuid.UUID, reassignedBy string) (int, error) {
+	if toDispatcherID != nil && *toDispatcherID == fromDispatcherID {
+		return 0, apperrors.ValidationError("cannot reassign trips to the same dispatcher", "to_dispatcher_id", toDispatcherID.String())
+	}
+
+	var target *domain.Dispatcher
+	if toDispatcherID != nil {
+		var err error
+		if target, err = s.getDispatcher(ctx, *toDispatcherID); err != nil {
+			return 0, err
+		}
+		if !target.Active {
+			return 0, apperrors.InvalidStateError("inactive", "active")
+		}
+	}
+
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		DispatcherID: &fromDispatcherID,
+		Status:       activeTripStatuses,
+		PageSize:     1000,
+		SortBy:       "planned_start_time",
+	})
+	if err != nil {
+		return 0, apperrors.DatabaseError("list dispatcher trips", err)
+	}
+
+	terminals := make(map[uuid.UUID]uuid.UUID, len(trips))
+	if target == nil && len(trips) > 0 {
+		tripIDs := make([]uuid.UUID, len(trips))
+		for i := range trips {
+			tripIDs[i] = trips[i].ID
+		}
+		stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+		if err != nil {
+			return 0, apperrors.DatabaseError("get stops", err)
+		}
+		first := make(map[uuid.UUID]int)
+		for _, stop := range stops {
+			if seq, ok := first[stop.TripID]; !ok || stop.Sequence < seq {
+				first[stop.TripID] = stop.Sequence
+				terminals[stop.TripID] = stop.LocationID
+			}
+		}
+	}
+
+	moved := 0
+	for i := range trips {
+		trip := &trips[i]
+		dispatcher := target
+		if dispatcher == nil {
+			if dispatcher, err = s.pickDispatcher(ctx, terminals[trip.ID], &fromDispatcherID); err != nil {
+				return moved, err
+			}
+			if dispatcher == nil {
+				return moved, apperrors.New("NO_DISPATCHER_AVAILABLE", "no other active dispatcher to take the trips")
+			}
+		}
+
+		if err := s.reassign(ctx, trip, dispatcher, reassignedBy); err != nil {
+			return moved, err
+		}
+		if target == nil {
+			if err := s.markAssigned(ctx, dispatcher); err != nil {
+				return moved, err
+			}
+		}
+		moved++
+	}
+
+	s.logger.Infow("Dispatcher trips reassigned",
+		"from_dispatcher_id", fromDispatcherID,
+		"to_dispatcher_id", toDispatcherID,
+		"trips", moved,
+	)
+	return moved, nil
+}
+
+// GetWorkloadBalance returns trips and open exceptions per dispatcher,
+// heaviest load first, with the active trips nobody owns
+func (s *DispatcherService) GetWorkloadBalance(ctx context.Context) (*domain.DispatcherBalance, error) {
+	workloads, err := s.workloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, unassigned, err := s.tripRepo.List(ctx, repository.TripFilter{
+		NoDispatcher: true,
+		Status:       activeTripStatuses,
+		PageSize:     1,
+	})
+	if err != nil {
+		return nil, apperrors.DatabaseError("count unassigned trips", err)
+	}
+
+	balance := &domain.DispatcherBalance{
+		UnassignedTrips: unassigned,
+		GeneratedAt:     time.Now(),
+	}
+	active, activeTrips := 0, 0
+	for _, w := range workloads {
+		if w.Active {
+			active++
+			activeTrips += w.ActiveTrips
+		}
+		balance.Workloads = append(balance.Workloads, w)
+	}
+	if active > 0 {
+		balance.AverageActiveTrips = float64(activeTrips) / float64(active)
+	}
+	sort.Slice(balance.Workloads, func(i, j int) bool {
+		return balance.Workloads[i].LoadPercent > balance.Workloads[j].LoadPercent
+	})
+	return balance, nil
+}
+
+// pickDispatcher chooses the next dispatcher in turn: the active dispatcher
+// assigned least recently, among those covering the terminal in TERMINAL
+// mode. Dispatchers at capacity are passed over while anyone else has room.
+// It returns nil when no dispatcher is active.
+func (s *DispatcherService) pickDispatcher(ctx context.Context, terminalID uuid.UUID, exclude *uuid.UUID) (*domain.Dispatcher, error) {
+	dispatchers, err := s.dispatcherRepo.ListActive(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list active dispatchers", err)
+	}
+	workloads, err := s.workloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	load := make(map[uuid.UUID]domain.DispatcherWorkload, len(workloads))
+	for _, w := range workloads {
+		load[w.DispatcherID] = w
+	}
+
+	var candidates []domain.Dispatcher
+	for _, d := range dispatchers {
+		if exclude != nil && d.ID == *exclude {
+			continue
+		}
+		candidates = append(candidates, d)
+	}
+	if s.businessRules.Dispatchers.AssignmentMode == config.DispatcherAssignmentTerminal && terminalID != uuid.Nil {
+		var covering []domain.Dispatcher
+		for _, d := range candidates {
+			if d.CoversTerminal(terminalID) {
+				covering = append(covering, d)
+			}
+		}
+		// Nobody on shift covers the terminal; fall back to the whole desk
+		if len(covering) > 0 {
+			candidates = covering
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var withRoom []domain.Dispatcher
+	for _, d := range candidates {
+		if load[d.ID].ActiveTrips < s.capacity(d.MaxActiveTrips) {
+			withRoom = append(withRoom, d)
+		}
+	}
+	if len(withRoom) > 0 {
+		candidates = withRoom
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].LastAssignedAt, candidates[j].LastAssignedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return &candidates[0], nil
+}
+
+// workloads loads per-dispatcher counts and fills in load against capacity
+func (s *DispatcherService) workloads(ctx context.Context) ([]domain.DispatcherWorkload, error) {
+	workloads, err := s.dispatcherRepo.GetWorkloads(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get dispatcher workloads", err)
+	}
+	for i := range workloads {
+		capacity := s.capacity(workloads[i].MaxActiveTrips)
+		if capacity > 0 {
+			workloads[i].LoadPercent = float64(workloads[i].ActiveTrips) / float64(capacity) * 100
+		}
+	}
+	return workloads, nil
+}
+
+// capacity returns a dispatcher's trip limit, or the default when unset
+func (s *DispatcherService) capacity(maxActiveTrips int) int {
+	if maxActiveTrips > 0 {
+		return maxActiveTrips
+	}
+	return s.businessRules.Dispatchers.DefaultMaxActiveTrips
+}
+
+// markAssigned moves the dispatcher to the back of the round-robin queue
+func (s *DispatcherService) markAssigned(ctx context.Context, dispatcher *domain.Dispatcher) error {
+	now := time.Now()
+	dispatcher.LastAssignedAt = &now
+	dispatcher.UpdatedAt = now
+	if err := s.dispatcherRepo.Update(ctx, dispatcher); err != nil {
+		return apperrors.DatabaseError("update dispatcher", err)
+	}
+	return nil
+}
+
+// reassign saves the trip under a new dispatcher and announces the change
+func (s *DispatcherService) reassign(ctx context.Context, trip *domain.Trip, dispatcher *domain.Dispatcher, reassignedBy string) error {
+	previous := trip.DispatcherID
+	if previous != nil && *previous == dispatcher.ID {
+		return nil
+	}
+
+	trip.DispatcherID = &dispatcher.ID
+	trip.UpdatedAt = time.Now()
+	if err := s.tripRepo.Update(ctx, trip); err != nil {
+		return tripUpdateError(ctx, s.tripRepo, trip.ID, "reassign trip dispatcher", err)
+	}
+
+	data := map[string]interface{}{
+		"trip_id":         trip.ID.String(),
+		"trip_number":     trip.TripNumber,
+		"dispatcher_id":   dispatcher.ID.String(),
+		"dispatcher_name": dispatcher.Name,
+		"reassigned_by":   reassignedBy,
+	}
+	if previous != nil {
+		data["previous_dispatcher_id"] = previous.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.TripDispatcherAssigned, "dispatch-service", data).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripDispatcherAssigned, event)
+	return nil
+}
+
+func (s *DispatcherService) getDispatcher(ctx context.Context, id uuid.UUID) (*domain.Dispatcher, error) {
+	dispatcher, err := s.dispatcherRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get dispatcher", err)
+	}
+	if dispatcher == nil {
+		return nil, apperrors.NotFoundError("dispatcher", id.String())
+	}
+	return dispatcher, nil
+}
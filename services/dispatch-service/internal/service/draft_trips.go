@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// draftTripPayload is the data of an orders.prearrival.draft_trip_requested event
+type draftTripPayload struct {
+	OrderID               string     `json:"order_id"`
+	OrderNumber           string     `json:"order_number"`
+	ContainerID           string     `json:"container_id"`
+	ContainerNumber       string     `json:"container_number"`
+	CustomerID            string     `json:"customer_id"`
+	PickupLocationID      string     `json:"pickup_location_id"`
+	DeliveryLocationID    string     `json:"delivery_location_id"`
+	ReturnLocationID      string     `json:"return_location_id"`
+	PickupAppointmentTime *time.Time `json:"pickup_appointment_time"`
+	RequestedBy           string     `json:"requested_by"`
+}
+
+// HandleDraftTripRequested is a kafka.Handler that creates a draft import
+// trip for a container staged in the order-service pre-arrival workspace:
+// terminal pickup, live unload at the delivery location, and the empty
+// return when the order has one. Orders that already have an open trip are
+// left alone, so redelivered events do not duplicate trips.
+func (s *DispatchService) HandleDraftTripRequested(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload draftTripPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal draft trip request: %w", err)
+	}
+
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return nil
+	}
+	containerID, err := uuid.Parse(payload.ContainerID)
+	if err != nil {
+		return nil
+	}
+	pickupID, err := uuid.Parse(payload.PickupLocationID)
+	if err != nil {
+		return nil
+	}
+	deliveryID, err := uuid.Parse(payload.DeliveryLocationID)
+	if err != nil {
+		return nil
+	}
+
+	open, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		Status:   cancellableTripStatuses,
+		OrderID:  &orderID,
+		PageSize: 1,
+	})
+	if err != nil {
+		return apperrors.DatabaseError("list order trips", err)
+	}
+	if len(open) > 0 {
+		s.logger.Infow("Order already has an open trip, skipping draft",
+			"order_id", orderID,
+			"trip_number", open[0].TripNumber,
+		)
+		return nil
+	}
+
+	stops := []CreateStopInput{
+		{
+			Sequence:        1,
+			Type:            domain.StopTypePickup,
+			Activity:        domain.ActivityTypePickupLoaded,
+			LocationID:      pickupID,
+			ContainerID:     &containerID,
+			OrderID:         &orderID,
+			AppointmentTime: payload.PickupAppointmentTime,
+		},
+		{
+			Sequence:     2,
+			Type:         domain.StopTypeDelivery,
+			Activity:     domain.ActivityTypeLiveUnload,
+			LocationID:   deliveryID,
+			ContainerID:  &containerID,
+			OrderID:      &orderID,
+			FreeTimeMins: s.businessRules.Time.LiveUnloadFreeTimeMins,
+		},
+	}
+	if returnID, err := uuid.Parse(payload.ReturnLocationID); err == nil {
+		stops = append(stops, CreateStopInput{
+			Sequence:    3,
+			Type:        domain.StopTypeReturn,
+			Activity:    domain.ActivityTypeDropEmpty,
+			LocationID:  returnID,
+			ContainerID: &containerID,
+			OrderID:     &orderID,
+		})
+	}
+
+	input := CreateTripInput{
+		Type:      domain.TripTypeLiveUnload,
+		Stops:     stops,
+		OrderIDs:  []uuid.UUID{orderID},
+		Draft:     true,
+		CreatedBy: payload.RequestedBy,
+	}
+	if customerID, err := uuid.Parse(payload.CustomerID); err == nil {
+		input.CustomerID = &customerID
+	}
+	if input.CreatedBy == "" {
+		input.CreatedBy = "prearrival"
+	}
+
+	trip, err := s.CreateTrip(ctx, input)
+	if err != nil {
+		return fmt.Errorf("create draft trip for order %s: %w", payload.OrderNumber, err)
+	}
+
+	s.logger.Infow("Draft trip created from pre-arrival workspace",
+		"trip_number", trip.TripNumber,
+		"order_id", orderID,
+		"container", payload.ContainerNumber,
+	)
+	return nil
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// callOutTripStatuses are the trips taken off a driver who calls out. Trips
+// already under way stay with the driver for dispatch to handle directly.
+var callOutTripStatuses = []domain.TripStatus{
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+}
+
+// DriverCallOutService handles drivers calling out for a day. Marking a
+// driver out collects their trips for the day and proposes reassignments to
+// drivers with capacity; dispatch then applies the accepted proposals.
+type DriverCallOutService struct {
+	callOutRepo   repository.DriverCallOutRepository
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	driverRepo    repository.DriverRepository
+	locationRepo  repository.LocationRepository
+	containerRepo repository.ContainerRepository
+	dispatch      *DispatchService
+	exceptions    *ExceptionService
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewDriverCallOutService creates a new driver call-out service
+func NewDriverCallOutService(
+	callOutRepo repository.DriverCallOutRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	dispatch *DispatchService,
+	exceptions *ExceptionService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *DriverCallOutService {
+	return &DriverCallOutService{
+		callOutRepo:   callOutRepo,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		containerRepo: containerRepo,
+		dispatch:      dispatch,
+		exceptions:    exceptions,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// MarkDriverOutInput contains input for marking a driver out for a day
+type MarkDriverOutInput struct {
+	DriverID   uuid.UUID
+	Date       time.Time // Any time on the day out
+	Reason     string
+	ReportedBy string
+}
+
+// reassignmentCandidate is a driver who could take over trips, with the
+// capacity left after the proposals made so far
+type reassignmentCandidate struct {
+	driver        domain.Driver
+	remainingMins int
+	windows       [][2]time.Time
+	loaded        bool
+}
+
+// MarkDriverOut records the driver as out for the day and proposes a new
+// driver for each of their assigned and dispatched trips that day. Trips no
+// one can take raise a DRIVER_UNAVAILABLE exception for manual planning.
+func (s *DriverCallOutService) MarkDriverOut(ctx context.Context, input MarkDriverOutInput) (*domain.DriverCallOut, error) {
+	driver, err := s.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", input.DriverID.String())
+	}
+
+	day := input.Date
+	if day.IsZero() {
+		day = time.Now()
+	}
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	existing, err := s.callOutRepo.GetByDriverAndDate(ctx, input.DriverID, dayStart)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver call-out", err)
+	}
+	if existing != nil {
+		return nil, apperrors.ConflictError("driver is already marked out for the day").
+			WithDetail("call_out_id", existing.ID.String())
+	}
+
+	now := time.Now()
+	callOut := &domain.DriverCallOut{
+		ID:         uuid.New(),
+		DriverID:   driver.ID,
+		DriverName: driver.Name,
+		Date:       dayStart,
+		Reason:     input.Reason,
+		ReportedBy: input.ReportedBy,
+		CreatedAt:  now,
+	}
+	if err := s.callOutRepo.Create(ctx, callOut); err != nil {
+		return nil, apperrors.DatabaseError("create driver call-out", err)
+	}
+
+	trips, err := driverTrips(ctx, s.tripRepo, driver.ID, callOutTripStatuses, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	var dayTrips []domain.Trip
+	for _, trip := range trips {
+		if trip.PlannedStartTime != nil && !trip.PlannedStartTime.Before(dayStart) {
+			dayTrips = append(dayTrips, trip)
+		}
+	}
+
+	candidates, err := s.reassignmentCandidates(ctx, driver.ID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	unassignable := 0
+	for i := range dayTrips {
+		proposal, err := s.proposeReassignment(ctx, callOut, &dayTrips[i], candidates, dayStart, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.callOutRepo.CreateProposal(ctx, proposal); err != nil {
+			return nil, apperrors.DatabaseError("create reassignment proposal", err)
+		}
+		if proposal.Status == domain.ReassignmentStatusUnassignable {
+			unassignable++
+			s.raiseUnassignable(ctx, callOut, &dayTrips[i], proposal)
+		}
+		callOut.Proposals = append(callOut.Proposals, *proposal)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.DriverUnavailable, "dispatch-service", map[string]interface{}{
+		"call_out_id":  callOut.ID.String(),
+		"driver_id":    driver.ID.String(),
+		"driver_name":  driver.Name,
+		"date":         dayStart.Format("2006-01-02"),
+		"reason":       input.Reason,
+		"trips":        len(dayTrips),
+		"unassignable": unassignable,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.DriverUnavailable, event)
+
+	s.logger.Infow("Driver marked out",
+		"call_out_id", callOut.ID,
+		"driver_id", driver.ID,
+		"date", dayStart.Format("2006-01-02"),
+		"trips", len(dayTrips),
+		"unassignable", unassignable,
+	)
+
+	return callOut, nil
+}
+
+// reassignmentCandidates returns the available drivers other than the one
+// calling out and anyone else already out that day
+func (s *DriverCallOutService) reassignmentCandidates(ctx context.Context, outDriverID uuid.UUID, day time.Time) ([]*reassignmentCandidate, error) {
+	drivers, err := s.driverRepo.GetAvailable(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get available drivers", err)
+	}
+	outIDs, err := s.callOutRepo.GetDriverIDsOut(ctx, day)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get drivers out", err)
+	}
+	out := map[uuid.UUID]bool{outDriverID: true}
+	for _, id := range outIDs {
+		out[id] = true
+	}
+
+	var candidates []*reassignmentCandidate
+	for _, driver := range drivers {
+		if out[driver.ID] {
+			continue
+		}
+		if driver.Status != "AVAILABLE" && driver.Status != "ON_DUTY" {
+			continue
+		}
+		candidates = append(candidates, &reassignmentCandidate{
+			driver:        driver,
+			remainingMins: driver.AvailableDriveMins,
+		})
+	}
+	return candidates, nil
+}
+
+// proposeReassignment picks the driver closest to the trip's first stop who
+// has the drive time, endorsements and a free window for it. Capacity used
+// by earlier proposals in the same call-out is taken into account.
+func (s *DriverCallOutService) proposeReassignment(ctx context.Context, callOut *domain.DriverCallOut, trip *domain.Trip, candidates []*reassignmentCandidate, dayStart, dayEnd time.Time) (*domain.ReassignmentProposal, error) {
+	rules := &s.businessRules.Schedule
+	proposal := &domain.ReassignmentProposal{
+		ID:               uuid.New(),
+		CallOutID:        callOut.ID,
+		TripID:           trip.ID,
+		TripNumber:       trip.TripNumber,
+		TripStatus:       trip.Status,
+		PlannedStartTime: trip.PlannedStartTime,
+		FromDriverID:     callOut.DriverID,
+		Status:           domain.ReassignmentStatusUnassignable,
+		CreatedAt:        callOut.CreatedAt,
+	}
+
+	stops, err := s.stopRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	if len(stops) == 0 {
+		proposal.Reason = "trip has no stops"
+		return proposal, nil
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+	first, err := s.locationRepo.GetByID(ctx, stops[0].LocationID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("location", stops[0].LocationID.String())
+	}
+	needsTWIC, needsHazmat, err := tripRequirements(ctx, s.stopRepo, s.locationRepo, s.containerRepo, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, _ := trip.PlannedWindow(rules.DefaultTripMins)
+	buffer := time.Duration(rules.ConflictBufferMins) * time.Minute
+	requiredMins := trip.EstimatedDurationMins + autoDispatchHOSBufferMins
+
+	var best *reassignmentCandidate
+	var bestMiles float64
+	var lackHOS, restricted, busy int
+
+	for _, candidate := range candidates {
+		if candidate.remainingMins < requiredMins {
+			lackHOS++
+			continue
+		}
+		if checkDriverDocuments(&s.businessRules.Documents, &candidate.driver, needsTWIC, needsHazmat) != nil {
+			restricted++
+			continue
+		}
+		if err := s.loadWindows(ctx, candidate, dayStart, dayEnd); err != nil {
+			return nil, err
+		}
+		overlaps := false
+		for _, window := range candidate.windows {
+			if domain.WindowsOverlap(start, end, window[0], window[1], buffer) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			busy++
+			continue
+		}
+
+		miles := haversineMiles(candidate.driver.CurrentLatitude, candidate.driver.CurrentLongitude, first.Latitude, first.Longitude)
+		if best == nil || miles < bestMiles || (miles == bestMiles && candidate.remainingMins > best.remainingMins) {
+			best, bestMiles = candidate, miles
+		}
+	}
+
+	if best == nil {
+		proposal.Reason = fmt.Sprintf("no driver with capacity: %d lacked drive time, %d lacked endorsements or documents, %d already committed at %s",
+			lackHOS, restricted, busy, start.Format("15:04"))
+		return proposal, nil
+	}
+
+	best.remainingMins -= trip.EstimatedDurationMins
+	best.windows = append(best.windows, [2]time.Time{start, end})
+
+	proposal.ProposedDriverID = &best.driver.ID
+	proposal.ProposedDriverName = best.driver.Name
+	proposal.DeadheadMiles = math.Round(bestMiles*10) / 10
+	proposal.Status = domain.ReassignmentStatusProposed
+	proposal.Reason = fmt.Sprintf("nearest driver with capacity: %.1f mi from %s, %d min drive time left after this trip",
+		bestMiles, first.Name, best.remainingMins)
+	return proposal, nil
+}
+
+// loadWindows fills in the candidate's committed trip windows for the day
+// the first time the candidate is considered
+func (s *DriverCallOutService) loadWindows(ctx context.Context, candidate *reassignmentCandidate, dayStart, dayEnd time.Time) error {
+	if candidate.loaded {
+		return nil
+	}
+	trips, err := driverTrips(ctx, s.tripRepo, candidate.driver.ID, committedTripStatuses, dayStart, dayEnd)
+	if err != nil {
+		return err
+	}
+	for i := range trips {
+		if start, end, ok := trips[i].PlannedWindow(s.businessRules.Schedule.DefaultTripMins); ok {
+			candidate.windows = append(candidate.windows, [2]time.Time{start, end})
+		}
+	}
+	candidate.loaded = true
+	return nil
+}
+
+// raiseUnassignable opens an exception for a trip no driver could take
+func (s *DriverCallOutService) raiseUnassignable(ctx context.Context, callOut *domain.DriverCallOut, trip *domain.Trip, proposal *domain.ReassignmentProposal) {
+	_, err := s.exceptions.CreateException(ctx, CreateExceptionInput{
+		TripID:               trip.ID,
+		DriverID:             &callOut.DriverID,
+		Type:                 domain.ExceptionTypeDriverUnavailable,
+		Title:                fmt.Sprintf("%s needs a driver: %s is out", trip.TripNumber, callOut.DriverName),
+		Description:          proposal.Reason,
+		ReportedBy:           callOut.ReportedBy,
+		RequiresReassignment: true,
+		Metadata: map[string]string{
+			"call_out_id": callOut.ID.String(),
+			"proposal_id": proposal.ID.String(),
+		},
+	})
+	if err != nil {
+		s.logger.Warnw("Failed to raise driver unavailable exception", "trip_id", trip.ID, "error", err)
+	}
+}
+
+// ApplyReassignmentsInput contains the proposals a dispatcher accepted.
+// Proposals of the call-out that are not accepted are rejected.
+type ApplyReassignmentsInput struct {
+	CallOutID           uuid.UUID
+	AcceptedProposalIDs []uuid.UUID
+	DecidedBy           string
+}
+
+// ApplyReassignments moves the accepted trips to their proposed drivers.
+// Each assignment runs the usual availability, HOS and conflict checks;
+// one failing is recorded on its proposal and does not stop the rest.
+// Dispatched trips are dispatched again to their new driver.
+func (s *DriverCallOutService) ApplyReassignments(ctx context.Context, input ApplyReassignmentsInput) (*domain.DriverCallOut, error) {
+	callOut, err := s.GetCallOut(ctx, input.CallOutID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[uuid.UUID]domain.ReassignmentStatus, len(callOut.Proposals))
+	for _, proposal := range callOut.Proposals {
+		statuses[proposal.ID] = proposal.Status
+	}
+	accepted := make(map[uuid.UUID]bool, len(input.AcceptedProposalIDs))
+	for _, id := range input.AcceptedProposalIDs {
+		status, ok := statuses[id]
+		if !ok {
+			return nil, apperrors.NotFoundError("reassignment proposal", id.String())
+		}
+		if status != domain.ReassignmentStatusProposed {
+			return nil, apperrors.InvalidStateError(string(status), string(domain.ReassignmentStatusProposed)).
+				WithDetail("proposal_id", id.String())
+		}
+		accepted[id] = true
+	}
+
+	applied, failed := 0, 0
+	for i := range callOut.Proposals {
+		proposal := &callOut.Proposals[i]
+		if proposal.Status != domain.ReassignmentStatusProposed {
+			continue
+		}
+
+		now := time.Now()
+		proposal.DecidedBy = input.DecidedBy
+		proposal.DecidedAt = &now
+		proposal.Status = domain.ReassignmentStatusRejected
+		if accepted[proposal.ID] {
+			if err := s.reassignTrip(ctx, proposal); err != nil {
+				proposal.Status = domain.ReassignmentStatusFailed
+				proposal.Error = err.Error()
+				failed++
+			} else {
+				proposal.Status = domain.ReassignmentStatusApplied
+				applied++
+			}
+		}
+		if err := s.callOutRepo.UpdateProposal(ctx, proposal); err != nil {
+			s.logger.Warnw("Failed to save reassignment proposal", "proposal_id", proposal.ID, "error", err)
+		}
+	}
+
+	s.logger.Infow("Driver call-out reassignments applied",
+		"call_out_id", callOut.ID,
+		"applied", applied,
+		"failed", failed,
+		"decided_by", input.DecidedBy,
+	)
+
+	return callOut, nil
+}
+
+// reassignTrip moves a trip from the driver who called out to the proposed
+// driver, keeping the tractor, and re-dispatches it if it was dispatched
+func (s *DriverCallOutService) reassignTrip(ctx context.Context, proposal *domain.ReassignmentProposal) error {
+	trip, err := s.tripRepo.GetByID(ctx, proposal.TripID)
+	if err != nil {
+		return apperrors.NotFoundError("trip", proposal.TripID.String())
+	}
+	if trip.DriverID == nil || *trip.DriverID != proposal.FromDriverID ||
+		(trip.Status != domain.TripStatusAssigned && trip.Status != domain.TripStatusDispatched) {
+		return apperrors.ConflictError("trip changed after the driver called out")
+	}
+
+	wasDispatched := trip.Status == domain.TripStatusDispatched
+	if wasDispatched {
+		trip.Status = domain.TripStatusAssigned
+		trip.UpdatedAt = time.Now()
+		if err := s.tripRepo.Update(ctx, trip); err != nil {
+			return tripUpdateError(ctx, s.tripRepo, trip.ID, "recall dispatched trip", err)
+		}
+	}
+
+	if _, err := s.dispatch.AssignDriver(ctx, trip.ID, *proposal.ProposedDriverID, trip.TractorID); err != nil {
+		return err
+	}
+	if wasDispatched {
+		if _, err := s.dispatch.DispatchTrip(ctx, trip.ID); err != nil {
+			return fmt.Errorf("reassigned but not dispatched: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCallOut returns a call-out with its reassignment proposals
+func (s *DriverCallOutService) GetCallOut(ctx context.Context, id uuid.UUID) (*domain.DriverCallOut, error) {
+	callOut, err := s.callOutRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver call-out", id.String())
+	}
+	proposals, err := s.callOutRepo.GetProposals(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get reassignment proposals", err)
+	}
+	callOut.Proposals = proposals
+	return callOut, nil
+}
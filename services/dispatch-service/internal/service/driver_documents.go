@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// driverDocumentExpirations returns the expiration of each document the driver holds
+func driverDocumentExpirations(driver *domain.Driver) map[string]*time.Time {
+	expirations := map[string]*time.Time{
+		config.DocumentTypeCDL:         driver.LicenseExpiration,
+		config.DocumentTypeMedicalCard: driver.MedicalCardExpiration,
+	}
+	if driver.HasTWIC {
+		expirations[config.DocumentTypeTWIC] = driver.TWICExpiration
+	}
+	if driver.HasHazmatEndorsement {
+		expirations[config.DocumentTypeHazmat] = driver.HazmatExpiration
+	}
+	return expirations
+}
+
+// tripRequirements reports whether a trip enters a port terminal and whether
+// it hauls a hazmat container
+func tripRequirements(
+	ctx context.Context,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	tripID uuid.UUID,
+) (needsTWIC, needsHazmat bool, err error) {
+	stops, err := stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return false, false, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	var containerIDs []uuid.UUID
+	for _, stop := range stops {
+		if stop.ContainerID != nil {
+			containerIDs = append(containerIDs, *stop.ContainerID)
+		}
+		if needsTWIC {
+			continue
+		}
+		location, err := locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return false, false, apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+		needsTWIC = location != nil && strings.EqualFold(location.Type, "terminal")
+	}
+
+	if len(containerIDs) > 0 {
+		loads, err := containerRepo.GetLoads(ctx, containerIDs)
+		if err != nil {
+			return false, false, apperrors.DatabaseError("get container loads", err)
+		}
+		for _, load := range loads {
+			if load.IsHazmat {
+				needsHazmat = true
+				break
+			}
+		}
+	}
+
+	return needsTWIC, needsHazmat, nil
+}
+
+// checkDriverDocuments rejects an assignment that the driver's endorsements or
+// expired-document restrictions do not allow, or that an owner-operator's
+// lapsed authority or insurance does not
+func checkDriverDocuments(rules *config.DocumentRules, driver *domain.Driver, needsTWIC, needsHazmat bool) error {
+	if err := checkCarrierStanding(driver, time.Now()); err != nil {
+		return err
+	}
+	if needsTWIC && !driver.HasTWIC {
+		return apperrors.ValidationError("trip enters a port terminal and driver has no TWIC", "driver_id", driver.ID)
+	}
+	if needsHazmat && !driver.HasHazmatEndorsement {
+		return apperrors.ValidationError("trip hauls hazmat and driver has no hazmat endorsement", "driver_id", driver.ID)
+	}
+
+	restrictions := rules.Restrictions(driverDocumentExpirations(driver), time.Now())
+
+	restricted := func(restriction config.DutyRestriction) error {
+		return apperrors.New("DRIVER_RESTRICTED", "driver is restricted by expired documents").
+			WithDetail("driver_id", driver.ID.String()).
+			WithDetail("restriction", string(restriction)).
+			WithDetail("documents", restrictions[restriction])
+	}
+	if len(restrictions[config.DutyRestrictionOutOfService]) > 0 {
+		return restricted(config.DutyRestrictionOutOfService)
+	}
+	if needsTWIC && len(restrictions[config.DutyRestrictionNonPort]) > 0 {
+		return restricted(config.DutyRestrictionNonPort)
+	}
+	if needsHazmat && len(restrictions[config.DutyRestrictionNoHazmat]) > 0 {
+		return restricted(config.DutyRestrictionNoHazmat)
+	}
+	return nil
+}
+
+// checkCarrierStanding rejects an owner-operator whose carrier record is on
+// hold or whose required insurance has run out
+func checkCarrierStanding(driver *domain.Driver, now time.Time) error {
+	switch {
+	case driver.CarrierHold != "":
+		return apperrors.New("CARRIER_NOT_COMPLIANT", "owner-operator authority or insurance is not in order").
+			WithDetail("driver_id", driver.ID.String()).
+			WithDetail("hold", driver.CarrierHold)
+	case driver.CarrierCoveredUntil != nil && !now.Before(*driver.CarrierCoveredUntil):
+		return apperrors.New("CARRIER_NOT_COMPLIANT", "owner-operator insurance has lapsed").
+			WithDetail("driver_id", driver.ID.String()).
+			WithDetail("covered_until", driver.CarrierCoveredUntil.Format(time.RFC3339))
+	}
+	return nil
+}
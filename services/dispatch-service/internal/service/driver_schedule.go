@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// committedTripStatuses are the trips that hold their driver's time
+var committedTripStatuses = []domain.TripStatus{
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+	domain.TripStatusInProgress,
+}
+
+// maxScheduleDays caps the range of a schedule query
+const maxScheduleDays = 31
+
+// checkScheduleConflicts rejects giving trip to driverID when the driver
+// already holds a committed trip whose planned window overlaps it, or is on
+// approved time off then. Trips without a planned start cannot be checked
+// and pass.
+func checkScheduleConflicts(ctx context.Context, tripRepo repository.TripRepository, timeOffRepo repository.DriverTimeOffRepository, rules *config.ScheduleRules, driverID uuid.UUID, trip *domain.Trip) error {
+	start, end, ok := trip.PlannedWindow(rules.DefaultTripMins)
+	if !ok {
+		return nil
+	}
+	if err := checkTimeOff(ctx, timeOffRepo, driverID, start, end); err != nil {
+		return err
+	}
+	conflicts, err := overlappingTrips(ctx, tripRepo, rules, driverID, trip.ID, start, end)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return apperrors.ConflictError("driver is already assigned to an overlapping trip: "+strings.Join(conflicts, ", ")).
+		WithDetail("driver_id", driverID.String()).
+		WithDetail("conflicting_trips", conflicts)
+}
+
+// overlappingTrips returns the numbers of the driver's committed trips,
+// other than excludeTripID, whose planned window overlaps start to end
+func overlappingTrips(ctx context.Context, tripRepo repository.TripRepository, rules *config.ScheduleRules, driverID, excludeTripID uuid.UUID, start, end time.Time) ([]string, error) {
+	buffer := time.Duration(rules.ConflictBufferMins) * time.Minute
+	trips, err := driverTrips(ctx, tripRepo, driverID, committedTripStatuses, start, end.Add(buffer))
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for i := range trips {
+		if trips[i].ID == excludeTripID {
+			continue
+		}
+		otherStart, otherEnd, ok := trips[i].PlannedWindow(rules.DefaultTripMins)
+		if ok && domain.WindowsOverlap(start, end, otherStart, otherEnd, buffer) {
+			conflicts = append(conflicts, trips[i].TripNumber)
+		}
+	}
+	return conflicts, nil
+}
+
+// driverTrips lists the driver's trips in the given statuses planned to
+// start before the range ends. The lookback covers trips that started the
+// day before and may still be running.
+func driverTrips(ctx context.Context, tripRepo repository.TripRepository, driverID uuid.UUID, statuses []domain.TripStatus, from, to time.Time) ([]domain.Trip, error) {
+	after := from.Add(-24 * time.Hour)
+	trips, _, err := tripRepo.List(ctx, repository.TripFilter{
+		Status:        statuses,
+		DriverID:      &driverID,
+		PlannedAfter:  &after,
+		PlannedBefore: &to,
+		PageSize:      500,
+		SortBy:        "planned_start_time",
+		SortOrder:     "asc",
+	})
+	if err != nil {
+		return nil, apperrors.DatabaseError("list driver trips", err)
+	}
+	return trips, nil
+}
+
+// DriverScheduleService holds drivers for future trips with soft
+// allocations and builds each driver's schedule. Allocations do not touch
+// the trip; confirming one assigns the driver through the dispatch service.
+type DriverScheduleService struct {
+	allocationRepo repository.DriverAllocationRepository
+	tripRepo       repository.TripRepository
+	driverRepo     repository.DriverRepository
+	timeOffRepo    repository.DriverTimeOffRepository
+	dispatch       *DispatchService
+	eventProducer  *kafka.Producer
+	logger         *logger.Logger
+	businessRules  *config.BusinessRules
+}
+
+// NewDriverScheduleService creates a new driver schedule service
+func NewDriverScheduleService(
+	allocationRepo repository.DriverAllocationRepository,
+	tripRepo repository.TripRepository,
+	driverRepo repository.DriverRepository,
+	timeOffRepo repository.DriverTimeOffRepository,
+	dispatch *DispatchService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *DriverScheduleService {
+	return &DriverScheduleService{
+		allocationRepo: allocationRepo,
+		tripRepo:       tripRepo,
+		driverRepo:     driverRepo,
+		timeOffRepo:    timeOffRepo,
+		dispatch:       dispatch,
+		eventProducer:  eventProducer,
+		logger:         log,
+		businessRules:  config.DefaultBusinessRules(),
+	}
+}
+
+// AllocateDriverInput contains input for holding a driver for a future trip
+type AllocateDriverInput struct {
+	TripID      uuid.UUID
+	DriverID    uuid.UUID
+	Notes       string
+	AllocatedBy string
+}
+
+// AllocateDriver pencils a driver in for a planned trip on a future day.
+// The trip stays unassigned, but the hold is rejected when it overlaps the
+// driver's committed trips, other held allocations or approved time off,
+// or when the trip is already held for someone else.
+func (s *DriverScheduleService) AllocateDriver(ctx context.Context, input AllocateDriverInput) (*domain.DriverAllocation, error) {
+	rules := &s.businessRules.Schedule
+
+	trip, err := s.tripRepo.GetByID(ctx, input.TripID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("trip", input.TripID.String())
+	}
+	if trip.Status != domain.TripStatusPlanned || trip.DriverID != nil {
+		return nil, apperrors.InvalidStateError(string(trip.Status), "PLANNED without a driver")
+	}
+	start, end, ok := trip.PlannedWindow(rules.DefaultTripMins)
+	if !ok {
+		return nil, apperrors.ValidationError("trip has no planned start time", "trip_id", input.TripID.String())
+	}
+
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	if start.Before(tomorrow) {
+		return nil, apperrors.ValidationError("soft allocations are for future days; assign the driver instead", "trip_id", input.TripID.String())
+	}
+	if horizon := tomorrow.AddDate(0, 0, rules.AllocationHorizonDays); !start.Before(horizon) {
+		return nil, apperrors.ValidationError("trip is beyond the allocation horizon", "trip_id", input.TripID.String())
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", input.DriverID.String())
+	}
+
+	held, err := s.allocationRepo.GetHeldByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip allocations", err)
+	}
+	if len(held) > 0 {
+		if held[0].DriverID == input.DriverID {
+			return &held[0], nil
+		}
+		return nil, apperrors.ConflictError("trip is already held for another driver").
+			WithDetail("allocation_id", held[0].ID.String())
+	}
+
+	if err := checkTimeOff(ctx, s.timeOffRepo, input.DriverID, start, end); err != nil {
+		return nil, err
+	}
+	conflicts, err := s.conflicts(ctx, input.DriverID, trip.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, apperrors.ConflictError("driver is already committed during this trip: "+strings.Join(conflicts, ", ")).
+			WithDetail("driver_id", input.DriverID.String()).
+			WithDetail("conflicting_trips", conflicts)
+	}
+
+	allocation := &domain.DriverAllocation{
+		ID:          uuid.New(),
+		DriverID:    input.DriverID,
+		TripID:      trip.ID,
+		TripNumber:  trip.TripNumber,
+		WindowStart: start,
+		WindowEnd:   end,
+		Status:      domain.AllocationStatusHeld,
+		Notes:       input.Notes,
+		AllocatedBy: input.AllocatedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.allocationRepo.Create(ctx, allocation); err != nil {
+		return nil, apperrors.DatabaseError("create allocation", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.DriverAllocated, "dispatch-service", map[string]interface{}{
+		"allocation_id": allocation.ID.String(),
+		"trip_id":       trip.ID.String(),
+		"trip_number":   trip.TripNumber,
+		"driver_id":     input.DriverID.String(),
+		"driver_name":   driver.Name,
+		"window_start":  start,
+		"window_end":    end,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.DriverAllocated, event)
+
+	s.logger.Infow("Driver allocated to future trip",
+		"allocation_id", allocation.ID,
+		"trip_number", trip.TripNumber,
+		"driver_id", input.DriverID,
+		"window_start", start,
+	)
+	return allocation, nil
+}
+
+// ConfirmAllocation turns a held allocation into a driver assignment. The
+// assignment runs the usual availability, HOS and conflict checks.
+func (s *DriverScheduleService) ConfirmAllocation(ctx context.Context, allocationID uuid.UUID, tractorID *uuid.UUID) (*domain.Trip, error) {
+	allocation, err := s.heldAllocation(ctx, allocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.dispatch.AssignDriver(ctx, allocation.TripID, allocation.DriverID, tractorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	allocation.Status = domain.AllocationStatusConfirmed
+	allocation.ConfirmedAt = &now
+	allocation.UpdatedAt = now
+	if err := s.allocationRepo.Update(ctx, allocation); err != nil {
+		s.logger.Warnw("Failed to mark allocation confirmed", "allocation_id", allocationID, "error", err)
+	}
+	return trip, nil
+}
+
+// ReleaseAllocation gives up a held allocation, freeing the driver's time
+func (s *DriverScheduleService) ReleaseAllocation(ctx context.Context, allocationID uuid.UUID, reason, releasedBy string) (*domain.DriverAllocation, error) {
+	allocation, err := s.heldAllocation(ctx, allocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	allocation.Status = domain.AllocationStatusReleased
+	allocation.ReleasedAt = &now
+	allocation.ReleasedBy = releasedBy
+	allocation.ReleasedReason = reason
+	allocation.UpdatedAt = now
+	if err := s.allocationRepo.Update(ctx, allocation); err != nil {
+		return nil, apperrors.DatabaseError("release allocation", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.DriverAllocationReleased, "dispatch-service", map[string]interface{}{
+		"allocation_id": allocation.ID.String(),
+		"trip_id":       allocation.TripID.String(),
+		"trip_number":   allocation.TripNumber,
+		"driver_id":     allocation.DriverID.String(),
+		"reason":        reason,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.DriverAllocationReleased, event)
+
+	return allocation, nil
+}
+
+// GetDriverSchedule returns the driver's committed trips, held allocations
+// and approved time off between from and to, flagging blocks that overlap.
+// Holds on trips that have since been assigned or cancelled are left out.
+func (s *DriverScheduleService) GetDriverSchedule(ctx context.Context, driverID uuid.UUID, from, to time.Time) (*domain.DriverSchedule, error) {
+	if !to.After(from) {
+		return nil, apperrors.ValidationError("schedule end must be after its start", "to", to)
+	}
+	if to.Sub(from) > maxScheduleDays*24*time.Hour {
+		return nil, apperrors.ValidationError("schedule range is limited to 31 days", "to", to)
+	}
+
+	rules := &s.businessRules.Schedule
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", driverID.String())
+	}
+
+	statuses := append([]domain.TripStatus{domain.TripStatusCompleted}, committedTripStatuses...)
+	trips, err := driverTrips(ctx, s.tripRepo, driverID, statuses, from, to)
+	if err != nil {
+		return nil, err
+	}
+	allocations, err := s.allocationRepo.GetHeldByDriver(ctx, driverID, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver allocations", err)
+	}
+	timeOff, err := s.timeOffRepo.GetOverlapping(ctx, driverID, from, to, []domain.TimeOffStatus{domain.TimeOffApproved})
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver time off", err)
+	}
+
+	var blocks []domain.ScheduleBlock
+	for i := range trips {
+		start, end, ok := trips[i].PlannedWindow(rules.DefaultTripMins)
+		if !ok || !domain.WindowsOverlap(from, to, start, end, 0) {
+			continue
+		}
+		blocks = append(blocks, domain.ScheduleBlock{
+			Kind:       domain.ScheduleBlockTrip,
+			TripID:     trips[i].ID,
+			TripNumber: trips[i].TripNumber,
+			Status:     string(trips[i].Status),
+			Start:      start,
+			End:        end,
+		})
+	}
+	for i := range allocations {
+		a := allocations[i]
+		if trip, err := s.tripRepo.GetByID(ctx, a.TripID); err != nil || trip.Status != domain.TripStatusPlanned || trip.DriverID != nil {
+			continue
+		}
+		blocks = append(blocks, domain.ScheduleBlock{
+			Kind:         domain.ScheduleBlockAllocation,
+			TripID:       a.TripID,
+			TripNumber:   a.TripNumber,
+			AllocationID: &a.ID,
+			Status:       string(a.Status),
+			Start:        a.WindowStart,
+			End:          a.WindowEnd,
+		})
+	}
+	for i := range timeOff {
+		t := timeOff[i]
+		start, end := t.Window()
+		blocks = append(blocks, domain.ScheduleBlock{
+			Kind:      domain.ScheduleBlockTimeOff,
+			TimeOffID: &t.ID,
+			Status:    string(t.Type),
+			Start:     start,
+			End:       end,
+		})
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start.Before(blocks[j].Start) })
+
+	schedule := &domain.DriverSchedule{
+		DriverID:   driverID,
+		DriverName: driver.Name,
+		From:       from,
+		To:         to,
+		Blocks:     []domain.ScheduleBlock{},
+	}
+	buffer := time.Duration(rules.ConflictBufferMins) * time.Minute
+	for i := range blocks {
+		for j := range blocks {
+			if i == j || !domain.WindowsOverlap(blocks[i].Start, blocks[i].End, blocks[j].Start, blocks[j].End, buffer) {
+				continue
+			}
+			if blocks[j].Kind == domain.ScheduleBlockTimeOff {
+				blocks[i].ConflictsWith = append(blocks[i].ConflictsWith, string(domain.ScheduleBlockTimeOff))
+			} else {
+				blocks[i].ConflictsWith = append(blocks[i].ConflictsWith, blocks[j].TripNumber)
+			}
+		}
+		if len(blocks[i].ConflictsWith) > 0 {
+			schedule.Conflicts++
+		}
+	}
+	if blocks != nil {
+		schedule.Blocks = blocks
+	}
+	return schedule, nil
+}
+
+// conflicts returns the trip numbers of committed trips and held
+// allocations for other trips that overlap the window
+func (s *DriverScheduleService) conflicts(ctx context.Context, driverID, tripID uuid.UUID, start, end time.Time) ([]string, error) {
+	rules := &s.businessRules.Schedule
+	conflicts, err := overlappingTrips(ctx, s.tripRepo, rules, driverID, tripID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := time.Duration(rules.ConflictBufferMins) * time.Minute
+	held, err := s.allocationRepo.GetHeldByDriver(ctx, driverID, start.Add(-buffer), end.Add(buffer))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver allocations", err)
+	}
+	for _, a := range held {
+		if a.TripID != tripID {
+			conflicts = append(conflicts, a.TripNumber)
+		}
+	}
+	return conflicts, nil
+}
+
+func (s *DriverScheduleService) heldAllocation(ctx context.Context, id uuid.UUID) (*domain.DriverAllocation, error) {
+	allocation, err := s.allocationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundError("allocation", id.String())
+	}
+	if allocation.Status != domain.AllocationStatusHeld {
+		return nil, apperrors.InvalidStateError(string(allocation.Status), string(domain.AllocationStatusHeld))
+	}
+	return allocation, nil
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// syncTripStatuses are the trips the driver app keeps offline
+var syncTripStatuses = []domain.TripStatus{
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+	domain.TripStatusInProgress,
+}
+
+// DriverSyncService is the offline sync protocol for the driver app. The app
+// downloads versioned copies of its trips, records arrivals, completions,
+// seals and PODs locally while out of coverage, and uploads them later with
+// the time they happened and an idempotency key.
+//
+// What the driver physically did is kept even when dispatch edited the stop
+// in the meantime. Actions on stops dispatch cancelled, or on trips handed
+// to another driver, are not applied; they are returned as conflicts and
+// raised as exceptions so dispatch can reconcile the work.
+type DriverSyncService struct {
+	syncRepo      repository.DriverSyncRepository
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	dispatch      *DispatchService
+	exceptions    *ExceptionService // Optional; raises sync conflicts for dispatch
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewDriverSyncService creates a new driver app sync service
+func NewDriverSyncService(
+	syncRepo repository.DriverSyncRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	dispatch *DispatchService,
+	exceptions *ExceptionService,
+	log *logger.Logger,
+) *DriverSyncService {
+	return &DriverSyncService{
+		syncRepo:      syncRepo,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		dispatch:      dispatch,
+		exceptions:    exceptions,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// DownloadTrips returns the driver's dispatched trips with stops and
+// locations. known maps trip IDs to the version the app holds; trips at that
+// version are listed as unchanged instead of being sent again, and known
+// trips that are no longer the driver's are listed for removal.
+func (s *DriverSyncService) DownloadTrips(ctx context.Context, driverID uuid.UUID, known map[uuid.UUID]int64) (*domain.DriverSyncDownload, error) {
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		Status:    syncTripStatuses,
+		DriverID:  &driverID,
+		PageSize:  50,
+		SortBy:    "planned_start_time",
+		SortOrder: "asc",
+	})
+	if err != nil {
+		return nil, apperrors.DatabaseError("list driver trips", err)
+	}
+
+	download := &domain.DriverSyncDownload{
+		DriverID:   driverID,
+		ServerTime: time.Now(),
+		Trips:      []domain.TripSyncPayload{},
+		Unchanged:  []uuid.UUID{},
+		Removed:    []uuid.UUID{},
+	}
+
+	current := make(map[uuid.UUID]bool, len(trips))
+	if len(trips) > 0 {
+		tripIDs := make([]uuid.UUID, len(trips))
+		for i := range trips {
+			tripIDs[i] = trips[i].ID
+			current[trips[i].ID] = true
+		}
+		stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get stops", err)
+		}
+		byTrip := make(map[uuid.UUID][]domain.TripStop, len(trips))
+		for _, stop := range stops {
+			byTrip[stop.TripID] = append(byTrip[stop.TripID], stop)
+		}
+
+		locations := make(map[uuid.UUID]*domain.Location)
+		for i := range trips {
+			tripStops := byTrip[trips[i].ID]
+			version := domain.SyncVersion(&trips[i], tripStops)
+			if v, ok := known[trips[i].ID]; ok && v == version {
+				download.Unchanged = append(download.Unchanged, trips[i].ID)
+				continue
+			}
+
+			sort.Slice(tripStops, func(a, b int) bool { return tripStops[a].Sequence < tripStops[b].Sequence })
+			for j := range tripStops {
+				tripStops[j].Location = s.location(ctx, locations, tripStops[j].LocationID)
+			}
+			trips[i].Stops = tripStops
+			download.Trips = append(download.Trips, domain.TripSyncPayload{Trip: trips[i], Version: version})
+		}
+	}
+
+	for tripID := range known {
+		if !current[tripID] {
+			download.Removed = append(download.Removed, tripID)
+		}
+	}
+	return download, nil
+}
+
+// UploadActions applies actions the driver app queued, oldest first, and
+// returns one result per action. A rejected or conflicting action does not
+// stop the rest of the batch.
+func (s *DriverSyncService) UploadActions(ctx context.Context, driverID uuid.UUID, deviceID string, actions []domain.DriverSyncAction) (*domain.DriverSyncUpload, error) {
+	rules := &s.businessRules.DriverSync
+	if len(actions) > rules.MaxBatchActions {
+		return nil, apperrors.ValidationError(fmt.Sprintf("at most %d actions per upload", rules.MaxBatchActions), "actions", len(actions))
+	}
+
+	ordered := make([]domain.DriverSyncAction, len(actions))
+	copy(ordered, actions)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].ClientTime.Before(ordered[j].ClientTime) })
+
+	upload := &domain.DriverSyncUpload{Results: make([]domain.SyncActionResult, 0, len(ordered))}
+	for i := range ordered {
+		upload.Results = append(upload.Results, s.applyAction(ctx, driverID, deviceID, &ordered[i]))
+	}
+	upload.ServerTime = time.Now()
+	return upload, nil
+}
+
+// applyAction resolves one uploaded action against the server copy of its
+// stop and records the outcome under its idempotency key
+func (s *DriverSyncService) applyAction(ctx context.Context, driverID uuid.UUID, deviceID string, action *domain.DriverSyncAction) domain.SyncActionResult {
+	result := domain.SyncActionResult{IdempotencyKey: action.IdempotencyKey}
+	if strings.TrimSpace(action.IdempotencyKey) == "" {
+		result.Outcome = domain.SyncOutcomeRejected
+		result.Message = "idempotency key is required"
+		return result
+	}
+
+	if previous, err := s.syncRepo.GetByKey(ctx, driverID, action.IdempotencyKey); err != nil {
+		result.Outcome = domain.SyncOutcomeRejected
+		result.Message = "sync is temporarily unavailable; retry later"
+		s.logger.Errorw("Failed to look up sync action", "driver_id", driverID, "key", action.IdempotencyKey, "error", err)
+		return result
+	} else if previous != nil {
+		result.Outcome = domain.SyncOutcomeDuplicate
+		result.Message = fmt.Sprintf("already processed as %s", previous.Outcome)
+		if previous.Message != "" {
+			result.Message += ": " + previous.Message
+		}
+		result.ExceptionID = previous.ExceptionID
+		result.Stop, _ = s.stopRepo.GetByID(ctx, action.StopID)
+		return result
+	}
+
+	receivedAt := time.Now()
+	record := &domain.DriverSyncRecord{
+		ID:             uuid.New(),
+		DriverID:       driverID,
+		DeviceID:       deviceID,
+		IdempotencyKey: action.IdempotencyKey,
+		Type:           action.Type,
+		TripID:         action.TripID,
+		StopID:         action.StopID,
+		ClientTime:     action.ClientTime,
+		ReceivedAt:     receivedAt,
+	}
+
+	s.resolve(ctx, driverID, action, receivedAt, record, &result)
+
+	record.Outcome = result.Outcome
+	record.Message = result.Message
+	record.ExceptionID = result.ExceptionID
+	if err := s.syncRepo.Create(ctx, record); err != nil {
+		s.logger.Errorw("Failed to record sync action", "driver_id", driverID, "key", action.IdempotencyKey, "error", err)
+	}
+
+	s.logger.Infow("Driver sync action processed",
+		"driver_id", driverID,
+		"key", action.IdempotencyKey,
+		"type", action.Type,
+		"stop_id", action.StopID,
+		"outcome", result.Outcome,
+	)
+	return result
+}
+
+func (s *DriverSyncService) resolve(ctx context.Context, driverID uuid.UUID, action *domain.DriverSyncAction, receivedAt time.Time, record *domain.DriverSyncRecord, result *domain.SyncActionResult) {
+	rules := &s.businessRules.DriverSync
+	reject := func(msg string) {
+		result.Outcome = domain.SyncOutcomeRejected
+		result.Message = msg
+	}
+
+	switch action.Type {
+	case domain.SyncActionArrive, domain.SyncActionComplete, domain.SyncActionVerifySeal, domain.SyncActionPOD:
+	default:
+		reject(fmt.Sprintf("unknown action type %q", action.Type))
+		return
+	}
+	if action.ClientTime.IsZero() {
+		reject("client time is required")
+		return
+	}
+
+	// Trust the device clock unless it is ahead of ours; reject actions from
+	// so long ago that they would rewrite settled trips
+	appliedTime := action.ClientTime
+	if appliedTime.After(receivedAt.Add(time.Duration(rules.MaxClockSkewMins) * time.Minute)) {
+		appliedTime = receivedAt
+	}
+	if appliedTime.Before(receivedAt.Add(-time.Duration(rules.MaxOfflineHours) * time.Hour)) {
+		reject(fmt.Sprintf("action is older than %d hours; report it to dispatch", rules.MaxOfflineHours))
+		return
+	}
+	record.AppliedTime = &appliedTime
+
+	stop, err := s.stopRepo.GetByID(ctx, action.StopID)
+	if err != nil || stop.TripID != action.TripID {
+		reject("stop not found on trip")
+		return
+	}
+	trip, err := s.tripRepo.GetByID(ctx, action.TripID)
+	if err != nil {
+		reject("trip not found")
+		return
+	}
+	result.Stop = stop
+
+	// Dispatch changes that outrank what the driver did offline
+	switch {
+	case trip.DriverID == nil || *trip.DriverID != driverID:
+		s.conflict(ctx, trip, stop, driverID, action, appliedTime, "trip was reassigned while the driver was offline", result)
+		return
+	case trip.Status == domain.TripStatusCancelled:
+		s.conflict(ctx, trip, stop, driverID, action, appliedTime, "trip was cancelled while the driver was offline", result)
+		return
+	case stop.Status == domain.StopStatusCancelled || stop.Status == domain.StopStatusSkipped:
+		s.conflict(ctx, trip, stop, driverID, action, appliedTime, fmt.Sprintf("stop was %s while the driver was offline", strings.ToLower(string(stop.Status))), result)
+		return
+	}
+
+	var updated *domain.TripStop
+	switch action.Type {
+	case domain.SyncActionArrive:
+		if stop.ActualArrival != nil {
+			s.superseded(stop, "arrival already recorded", result)
+			return
+		}
+		updated, err = s.dispatch.RecordStopArrival(ctx, trip.ID, stop.ID, appliedTime, action.Latitude, action.Longitude)
+
+	case domain.SyncActionComplete:
+		if stop.Status == domain.StopStatusCompleted {
+			s.superseded(stop, "stop already completed", result)
+			return
+		}
+		if stop.Status == domain.StopStatusFailed {
+			s.conflict(ctx, trip, stop, driverID, action, appliedTime, "stop was marked failed while the driver was offline", result)
+			return
+		}
+		updated, err = s.dispatch.CompleteStop(ctx, CompleteStopInput{
+			TripID:           trip.ID,
+			StopID:           stop.ID,
+			DepartureTime:    appliedTime,
+			GateTicketNumber: action.GateTicketNumber,
+			SealNumber:       action.SealNumber,
+			ChassisID:        action.ChassisID,
+			ContainerNumber:  action.ContainerNumber,
+			DocumentIDs:      action.DocumentIDs,
+			Notes:            action.Notes,
+			Latitude:         action.Latitude,
+			Longitude:        action.Longitude,
+		})
+
+	case domain.SyncActionVerifySeal:
+		if stop.SealStatus != "" && stop.SealStatus != domain.SealStatusPending {
+			s.superseded(stop, "seal already verified", result)
+			return
+		}
+		updated, err = s.dispatch.VerifySeal(ctx, VerifySealInput{
+			TripID:          trip.ID,
+			StopID:          stop.ID,
+			ObservedSeal:    action.SealNumber,
+			PhotoDocumentID: action.PhotoDocumentID,
+			VerifiedBy:      driverID.String(),
+		})
+
+	case domain.SyncActionPOD:
+		updated, err = s.recordPOD(ctx, stop, action, appliedTime)
+		if err == nil && updated == nil {
+			s.superseded(stop, "proof of delivery already received", result)
+			return
+		}
+	}
+
+	if err != nil {
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) {
+			reject(appErr.Message)
+		} else {
+			reject(err.Error())
+		}
+		return
+	}
+
+	result.Outcome = domain.SyncOutcomeApplied
+	result.Stop = updated
+	if action.BaseVersion > 0 && action.BaseVersion < stop.Version {
+		result.Message = "applied over dispatch changes made while offline"
+	}
+}
+
+// recordPOD adds proof of delivery documents to a stop. It returns nil
+// without error when every document was already attached.
+func (s *DriverSyncService) recordPOD(ctx context.Context, stop *domain.TripStop, action *domain.DriverSyncAction, at time.Time) (*domain.TripStop, error) {
+	if len(action.DocumentIDs) == 0 {
+		return nil, apperrors.ValidationError("at least one POD document is required", "document_ids", nil)
+	}
+
+	attached := make(map[string]bool, len(stop.PODDocumentIDs))
+	for _, id := range stop.PODDocumentIDs {
+		attached[id] = true
+	}
+	added := false
+	for _, id := range action.DocumentIDs {
+		if id != "" && !attached[id] {
+			stop.PODDocumentIDs = append(stop.PODDocumentIDs, id)
+			attached[id] = true
+			added = true
+		}
+	}
+	if !added {
+		return nil, nil
+	}
+
+	if action.SignedBy != "" {
+		stop.PODSignedBy = action.SignedBy
+	}
+	if stop.PODReceivedAt == nil {
+		stop.PODReceivedAt = &at
+	}
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		return nil, stopUpdateError(ctx, s.stopRepo, stop.ID, "record proof of delivery", err)
+	}
+	return stop, nil
+}
+
+func (s *DriverSyncService) superseded(stop *domain.TripStop, msg string, result *domain.SyncActionResult) {
+	result.Outcome = domain.SyncOutcomeSuperseded
+	result.Message = msg
+	result.Stop = stop
+}
+
+// conflict leaves the server copy untouched and raises an exception so
+// dispatch can decide what to do with work done on a changed trip
+func (s *DriverSyncService) conflict(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, driverID uuid.UUID, action *domain.DriverSyncAction, at time.Time, reason string, result *domain.SyncActionResult) {
+	result.Outcome = domain.SyncOutcomeConflict
+	result.Message = reason + "; dispatch has been notified"
+	result.Stop = stop
+
+	s.logger.Warnw("Driver sync conflict",
+		"driver_id", driverID,
+		"trip_id", trip.ID,
+		"stop_id", stop.ID,
+		"action", action.Type,
+		"reason", reason,
+	)
+	if s.exceptions == nil {
+		return
+	}
+
+	exception, err := s.exceptions.CreateException(ctx, CreateExceptionInput{
+		TripID:      trip.ID,
+		StopID:      &stop.ID,
+		OrderID:     stop.OrderID,
+		ContainerID: stop.ContainerID,
+		DriverID:    &driverID,
+		Type:        domain.ExceptionTypeSyncConflict,
+		Title:       fmt.Sprintf("Offline %s on %s stop %d not applied", strings.ToLower(string(action.Type)), trip.TripNumber, stop.Sequence),
+		Description: fmt.Sprintf("The driver app uploaded a %s recorded at %s, but the %s. Confirm what happened with the driver.",
+			strings.ToLower(string(action.Type)), at.Format(time.RFC3339), strings.TrimSuffix(reason, " while the driver was offline")),
+		LocationID: &stop.LocationID,
+		Latitude:   action.Latitude,
+		Longitude:  action.Longitude,
+		ReportedBy: driverID.String(),
+		Metadata: map[string]string{
+			"idempotency_key": action.IdempotencyKey,
+			"action":          string(action.Type),
+			"stop_status":     string(stop.Status),
+			"trip_status":     string(trip.Status),
+		},
+		OccurredAt: &at,
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to raise sync conflict exception", "trip_id", trip.ID, "stop_id", stop.ID, "error", err)
+		return
+	}
+	result.ExceptionID = &exception.ID
+}
+
+func (s *DriverSyncService) location(ctx context.Context, cache map[uuid.UUID]*domain.Location, id uuid.UUID) *domain.Location {
+	if location, ok := cache[id]; ok {
+		return location
+	}
+	location, err := s.locationRepo.GetByID(ctx, id)
+	if err != nil {
+		location = nil
+	}
+	cache[id] = location
+	return location
+}
@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ptoLedgerLimit is how many ledger entries a balance lists
+const ptoLedgerLimit = 50
+
+// ptoAccrualPageSize is the completed trip page size accrual scans with
+const ptoAccrualPageSize = 200
+
+// heldTimeOffStatuses are the requests that keep a driver's days
+var heldTimeOffStatuses = []domain.TimeOffStatus{domain.TimeOffPending, domain.TimeOffApproved}
+
+// checkTimeOff rejects giving the driver work between start and end when it
+// falls on approved time off
+func checkTimeOff(ctx context.Context, timeOffRepo repository.DriverTimeOffRepository, driverID uuid.UUID, start, end time.Time) error {
+	off, err := timeOffRepo.GetOverlapping(ctx, driverID, start, end, []domain.TimeOffStatus{domain.TimeOffApproved})
+	if err != nil {
+		return apperrors.DatabaseError("get driver time off", err)
+	}
+	if len(off) == 0 {
+		return nil
+	}
+	return apperrors.ConflictError("driver has approved time off during this trip").
+		WithDetail("driver_id", driverID.String()).
+		WithDetail("time_off_id", off[0].ID.String())
+}
+
+// DriverTimeOffService handles company drivers' vacation and other time off
+// requests and the PTO balance they draw on. PTO accrues weekly from hours
+// on completed trips. Approved days block the driver on the schedule, and
+// paid time off is posted to the driver's settlement for the week it is
+// taken.
+type DriverTimeOffService struct {
+	timeOffRepo   repository.DriverTimeOffRepository
+	tripRepo      repository.TripRepository
+	driverRepo    repository.DriverRepository
+	ownerRepo     repository.OwnerOperatorRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewDriverTimeOffService creates a new driver time off service
+func NewDriverTimeOffService(
+	timeOffRepo repository.DriverTimeOffRepository,
+	tripRepo repository.TripRepository,
+	driverRepo repository.DriverRepository,
+	ownerRepo repository.OwnerOperatorRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *DriverTimeOffService {
+	return &DriverTimeOffService{
+		timeOffRepo:   timeOffRepo,
+		tripRepo:      tripRepo,
+		driverRepo:    driverRepo,
+		ownerRepo:     ownerRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// ============================================================================
+// REQUESTS AND APPROVAL
+// ============================================================================
+
+// RequestTimeOffInput contains input for requesting days off
+type RequestTimeOffInput struct {
+	DriverID    uuid.UUID          `json:"driver_id"`
+	Type        domain.TimeOffType `json:"type"`
+	StartDate   time.Time          `json:"start_date"`
+	EndDate     time.Time          `json:"end_date"`
+	Unpaid      bool               `json:"unpaid"` // Take the days without drawing PTO
+	Notes       string             `json:"notes"`
+	RequestedBy string             `json:"-"`
+}
+
+// RequestTimeOff records a pending request. Paid requests need the hours in
+// the driver's balance after other pending requests; owner-operators can
+// only take unpaid time off.
+func (s *DriverTimeOffService) RequestTimeOff(ctx context.Context, input RequestTimeOffInput) (*domain.DriverTimeOff, error) {
+	rules := &s.businessRules.PTO
+
+	switch input.Type {
+	case domain.TimeOffVacation, domain.TimeOffPersonal, domain.TimeOffSick:
+	default:
+		return nil, apperrors.ValidationError("type must be VACATION, PERSONAL or SICK", "type", input.Type)
+	}
+	if input.StartDate.IsZero() || input.EndDate.IsZero() {
+		return nil, apperrors.ValidationError("start and end dates are required", "start_date", input.StartDate)
+	}
+	if input.EndDate.Before(input.StartDate) {
+		return nil, apperrors.ValidationError("end date must not be before the start date", "end_date", input.EndDate)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if input.Type != domain.TimeOffSick {
+		if earliest := today.AddDate(0, 0, rules.MinNoticeDays); input.StartDate.Before(earliest) {
+			return nil, apperrors.ValidationError(
+				fmt.Sprintf("vacation and personal time need %d days' notice", rules.MinNoticeDays), "start_date", input.StartDate)
+		}
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", input.DriverID.String())
+	}
+
+	timeOff := &domain.DriverTimeOff{
+		ID:          uuid.New(),
+		DriverID:    driver.ID,
+		DriverName:  driver.Name,
+		Type:        input.Type,
+		StartDate:   input.StartDate,
+		EndDate:     input.EndDate,
+		Paid:        !input.Unpaid,
+		Status:      domain.TimeOffPending,
+		Notes:       input.Notes,
+		RequestedBy: input.RequestedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	start, end := timeOff.Window()
+
+	held, err := s.timeOffRepo.GetOverlapping(ctx, driver.ID, start, end, heldTimeOffStatuses)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get driver time off", err)
+	}
+	if len(held) > 0 {
+		return nil, apperrors.ConflictError("driver already has time off requested for these days").
+			WithDetail("time_off_id", held[0].ID.String())
+	}
+
+	if timeOff.Paid {
+		carrier, err := s.ownerRepo.GetCarrierByDriver(ctx, driver.ID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get owner-operator carrier", err)
+		}
+		if carrier != nil {
+			return nil, apperrors.ValidationError("owner-operators do not accrue PTO; request unpaid time off", "unpaid", false)
+		}
+
+		timeOff.PaidHours = float64(domain.Weekdays(input.StartDate, input.EndDate)) * rules.HoursPerDay
+		balance, pending, err := s.balance(ctx, driver.ID)
+		if err != nil {
+			return nil, err
+		}
+		if available := balance - pending; timeOff.PaidHours > available {
+			return nil, insufficientPTO(timeOff.PaidHours, available)
+		}
+	}
+
+	if err := s.timeOffRepo.Create(ctx, timeOff); err != nil {
+		return nil, apperrors.DatabaseError("create time off request", err)
+	}
+	s.publish(ctx, timeOff)
+
+	s.logger.Infow("Time off requested",
+		"time_off_id", timeOff.ID,
+		"driver_id", driver.ID,
+		"type", timeOff.Type,
+		"start_date", timeOff.StartDate,
+		"end_date", timeOff.EndDate,
+		"paid_hours", timeOff.PaidHours,
+	)
+	return timeOff, nil
+}
+
+// ApproveTimeOffInput contains input for approving a time off request
+type ApproveTimeOffInput struct {
+	TimeOffID  uuid.UUID `json:"-"`
+	PayRate    float64   `json:"pay_rate"` // Per hour; the configured rate when 0
+	Notes      string    `json:"notes"`
+	ReviewedBy string    `json:"-"`
+}
+
+// ApproveTimeOff approves a pending request and draws its hours from the
+// driver's PTO balance. Trips already committed to the driver on those days
+// have to be reassigned first.
+func (s *DriverTimeOffService) ApproveTimeOff(ctx context.Context, input ApproveTimeOffInput) (*domain.DriverTimeOff, error) {
+	timeOff, err := s.pendingTimeOff(ctx, input.TimeOffID)
+	if err != nil {
+		return nil, err
+	}
+	if input.PayRate < 0 {
+		return nil, apperrors.ValidationError("pay rate must not be negative", "pay_rate", input.PayRate)
+	}
+
+	start, end := timeOff.Window()
+	conflicts, err := s.committedTrips(ctx, timeOff.DriverID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, apperrors.ConflictError("driver has trips during this time off; reassign them first: "+strings.Join(conflicts, ", ")).
+			WithDetail("driver_id", timeOff.DriverID.String()).
+			WithDetail("conflicting_trips", conflicts)
+	}
+
+	if timeOff.Paid && timeOff.PaidHours > 0 {
+		balance, _, err := s.balance(ctx, timeOff.DriverID)
+		if err != nil {
+			return nil, err
+		}
+		if timeOff.PaidHours > balance {
+			return nil, insufficientPTO(timeOff.PaidHours, balance)
+		}
+		timeOff.PayRate = input.PayRate
+		if timeOff.PayRate == 0 {
+			timeOff.PayRate = s.businessRules.PTO.PayRatePerHour
+		}
+		timeOff.PayAmount = roundTo(timeOff.PaidHours*timeOff.PayRate, 2)
+	}
+
+	now := time.Now()
+	timeOff.Status = domain.TimeOffApproved
+	timeOff.ReviewedBy = input.ReviewedBy
+	timeOff.ReviewedAt = &now
+	timeOff.ReviewNotes = input.Notes
+	timeOff.UpdatedAt = now
+	if err := s.timeOffRepo.Update(ctx, timeOff); err != nil {
+		return nil, apperrors.DatabaseError("approve time off", err)
+	}
+
+	if timeOff.Paid && timeOff.PaidHours > 0 {
+		s.addEntry(ctx, &domain.PTOLedgerEntry{
+			ID:        uuid.New(),
+			DriverID:  timeOff.DriverID,
+			Type:      domain.PTOEntryUsage,
+			Hours:     -timeOff.PaidHours,
+			TimeOffID: &timeOff.ID,
+			CreatedBy: input.ReviewedBy,
+			CreatedAt: now,
+		})
+	}
+	s.publish(ctx, timeOff)
+
+	s.logger.Infow("Time off approved",
+		"time_off_id", timeOff.ID,
+		"driver_id", timeOff.DriverID,
+		"paid_hours", timeOff.PaidHours,
+		"pay_amount", timeOff.PayAmount,
+		"approved_by", input.ReviewedBy,
+	)
+	return timeOff, nil
+}
+
+// DenyTimeOff turns down a pending request
+func (s *DriverTimeOffService) DenyTimeOff(ctx context.Context, id uuid.UUID, notes, reviewedBy string) (*domain.DriverTimeOff, error) {
+	timeOff, err := s.pendingTimeOff(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	timeOff.Status = domain.TimeOffDenied
+	timeOff.ReviewedBy = reviewedBy
+	timeOff.ReviewedAt = &now
+	timeOff.ReviewNotes = notes
+	timeOff.UpdatedAt = now
+	if err := s.timeOffRepo.Update(ctx, timeOff); err != nil {
+		return nil, apperrors.DatabaseError("deny time off", err)
+	}
+	s.publish(ctx, timeOff)
+	return timeOff, nil
+}
+
+// CancelTimeOff withdraws a pending request, or approved time off that has
+// not started yet, returning its hours to the driver's balance. The payout
+// comes off the driver's draft settlement with it.
+func (s *DriverTimeOffService) CancelTimeOff(ctx context.Context, id uuid.UUID, reason, cancelledBy string) (*domain.DriverTimeOff, error) {
+	timeOff, err := s.timeOffRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get time off", err)
+	}
+	if timeOff == nil {
+		return nil, apperrors.NotFoundError("time off", id.String())
+	}
+
+	now := time.Now()
+	wasApproved := timeOff.Status == domain.TimeOffApproved
+	switch timeOff.Status {
+	case domain.TimeOffPending:
+	case domain.TimeOffApproved:
+		if start, _ := timeOff.Window(); !now.Before(start) {
+			return nil, apperrors.ValidationError("time off that has started cannot be cancelled", "start_date", timeOff.StartDate)
+		}
+	default:
+		return nil, apperrors.InvalidStateError(string(timeOff.Status), "PENDING or APPROVED")
+	}
+
+	timeOff.Status = domain.TimeOffCancelled
+	timeOff.CancelledBy = cancelledBy
+	timeOff.CancelledAt = &now
+	timeOff.CancelReason = reason
+	timeOff.UpdatedAt = now
+	if err := s.timeOffRepo.Update(ctx, timeOff); err != nil {
+		return nil, apperrors.DatabaseError("cancel time off", err)
+	}
+
+	if wasApproved && timeOff.Paid && timeOff.PaidHours > 0 {
+		s.addEntry(ctx, &domain.PTOLedgerEntry{
+			ID:        uuid.New(),
+			DriverID:  timeOff.DriverID,
+			Type:      domain.PTOEntryReversal,
+			Hours:     timeOff.PaidHours,
+			TimeOffID: &timeOff.ID,
+			Notes:     reason,
+			CreatedBy: cancelledBy,
+			CreatedAt: now,
+		})
+	}
+	s.publish(ctx, timeOff)
+	return timeOff, nil
+}
+
+// GetTimeOff returns a time off request
+func (s *DriverTimeOffService) GetTimeOff(ctx context.Context, id uuid.UUID) (*domain.DriverTimeOff, error) {
+	timeOff, err := s.timeOffRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get time off", err)
+	}
+	if timeOff == nil {
+		return nil, apperrors.NotFoundError("time off", id.String())
+	}
+	return timeOff, nil
+}
+
+// ListTimeOff returns time off requests by start date
+func (s *DriverTimeOffService) ListTimeOff(ctx context.Context, filter repository.TimeOffFilter) ([]domain.DriverTimeOff, error) {
+	requests, err := s.timeOffRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list time off", err)
+	}
+	return requests, nil
+}
+
+// ============================================================================
+// PTO BALANCE
+// ============================================================================
+
+// GetBalance returns the driver's PTO balance with its recent ledger entries
+func (s *DriverTimeOffService) GetBalance(ctx context.Context, driverID uuid.UUID) (*domain.PTOBalance, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", driverID.String())
+	}
+	balance, pending, err := s.balance(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.timeOffRepo.GetLedger(ctx, driverID, ptoLedgerLimit)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get PTO ledger", err)
+	}
+	if entries == nil {
+		entries = []domain.PTOLedgerEntry{}
+	}
+
+	return &domain.PTOBalance{
+		DriverID:     driverID,
+		DriverName:   driver.Name,
+		BalanceHours: balance,
+		PendingHours: pending,
+		Available:    balance - pending,
+		Entries:      entries,
+	}, nil
+}
+
+// AdjustBalanceInput contains input for adjusting a PTO balance by hand
+type AdjustBalanceInput struct {
+	DriverID   uuid.UUID `json:"-"`
+	Hours      float64   `json:"hours"` // Negative to take hours off
+	Notes      string    `json:"notes"`
+	AdjustedBy string    `json:"-"`
+}
+
+// AdjustBalance records a manual change to a driver's PTO balance, such as
+// an opening balance carried over from payroll
+func (s *DriverTimeOffService) AdjustBalance(ctx context.Context, input AdjustBalanceInput) (*domain.PTOBalance, error) {
+	if input.Hours == 0 {
+		return nil, apperrors.ValidationError("hours must not be zero", "hours", input.Hours)
+	}
+	if strings.TrimSpace(input.Notes) == "" {
+		return nil, apperrors.ValidationError("a note explaining the adjustment is required", "notes", input.Notes)
+	}
+	if _, err := s.driverRepo.GetByID(ctx, input.DriverID); err != nil {
+		return nil, apperrors.NotFoundError("driver", input.DriverID.String())
+	}
+
+	entry := &domain.PTOLedgerEntry{
+		ID:        uuid.New(),
+		DriverID:  input.DriverID,
+		Type:      domain.PTOEntryAdjustment,
+		Hours:     input.Hours,
+		Notes:     input.Notes,
+		CreatedBy: input.AdjustedBy,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.timeOffRepo.AddLedgerEntry(ctx, entry); err != nil {
+		return nil, apperrors.DatabaseError("adjust PTO balance", err)
+	}
+	return s.GetBalance(ctx, input.DriverID)
+}
+
+// AccrueWeek credits company drivers with PTO for the hours on trips they
+// completed in the week starting weekStart, up to the balance cap. A week
+// already accrued for a driver is skipped. It returns how many drivers
+// were credited.
+func (s *DriverTimeOffService) AccrueWeek(ctx context.Context, weekStart time.Time) (int, error) {
+	rules := &s.businessRules.PTO
+	weekStart = startOfWeek(weekStart)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	worked := make(map[uuid.UUID]float64)
+	filter := repository.TripFilter{
+		Status:          []domain.TripStatus{domain.TripStatusCompleted},
+		CompletedAfter:  &weekStart,
+		CompletedBefore: &weekEnd,
+		PageSize:        ptoAccrualPageSize,
+	}
+	for filter.Page = 1; ; filter.Page++ {
+		trips, total, err := s.tripRepo.List(ctx, filter)
+		if err != nil {
+			return 0, apperrors.DatabaseError("list completed trips for PTO accrual", err)
+		}
+		for i := range trips {
+			if trips[i].DriverID != nil {
+				worked[*trips[i].DriverID] += s.workedHours(&trips[i])
+			}
+		}
+		if len(trips) < ptoAccrualPageSize || int64(filter.Page*ptoAccrualPageSize) >= total {
+			break
+		}
+	}
+
+	credited := 0
+	for driverID, hours := range worked {
+		carrier, err := s.ownerRepo.GetCarrierByDriver(ctx, driverID)
+		if err != nil {
+			s.logger.Warnw("Failed to check owner-operator status for PTO accrual", "driver_id", driverID, "error", err)
+			continue
+		}
+		if carrier != nil {
+			continue
+		}
+		balance, err := s.timeOffRepo.GetBalance(ctx, driverID)
+		if err != nil {
+			s.logger.Warnw("Failed to get PTO balance for accrual", "driver_id", driverID, "error", err)
+			continue
+		}
+
+		accrued := roundTo(hours*rules.AccrualPerHourWorked, 2)
+		if room := rules.MaxBalanceHours - balance; accrued > room {
+			accrued = roundTo(room, 2)
+		}
+		if accrued < 0 {
+			accrued = 0
+		}
+
+		fresh, err := s.timeOffRepo.AddLedgerEntry(ctx, &domain.PTOLedgerEntry{
+			ID:          uuid.New(),
+			DriverID:    driverID,
+			Type:        domain.PTOEntryAccrual,
+			Hours:       accrued,
+			WeekStart:   &weekStart,
+			WorkedHours: roundTo(hours, 2),
+			CreatedBy:   "system",
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			s.logger.Warnw("Failed to record PTO accrual", "driver_id", driverID, "error", err)
+			continue
+		}
+		if fresh && accrued > 0 {
+			credited++
+		}
+	}
+
+	s.logger.Infow("PTO accrued", "week_start", weekStart, "drivers", len(worked), "credited", credited)
+	return credited, nil
+}
+
+// Start accrues the previous week's PTO every interval until ctx is
+// cancelled. Weeks already accrued are skipped, so the first tick of a week
+// does the work and later ones find nothing to do.
+func (s *DriverTimeOffService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.AccrueWeek(ctx, startOfWeek(now).AddDate(0, 0, -7)); err != nil {
+				s.logger.Errorw("PTO accrual failed", "error", err)
+			}
+		}
+	}
+}
+
+// workedHours is the time a completed trip took, or its planned window
+// when the actual times were not recorded
+func (s *DriverTimeOffService) workedHours(trip *domain.Trip) float64 {
+	if trip.ActualStartTime != nil && trip.ActualEndTime != nil && trip.ActualEndTime.After(*trip.ActualStartTime) {
+		return trip.ActualEndTime.Sub(*trip.ActualStartTime).Hours()
+	}
+	if start, end, ok := trip.PlannedWindow(s.businessRules.Schedule.DefaultTripMins); ok {
+		return end.Sub(start).Hours()
+	}
+	return 0
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// balance returns the driver's PTO balance and the paid hours of their
+// pending requests
+func (s *DriverTimeOffService) balance(ctx context.Context, driverID uuid.UUID) (balance, pending float64, err error) {
+	balance, err = s.timeOffRepo.GetBalance(ctx, driverID)
+	if err != nil {
+		return 0, 0, apperrors.DatabaseError("get PTO balance", err)
+	}
+	requests, err := s.timeOffRepo.List(ctx, repository.TimeOffFilter{DriverID: &driverID, Status: domain.TimeOffPending})
+	if err != nil {
+		return 0, 0, apperrors.DatabaseError("list pending time off", err)
+	}
+	for _, r := range requests {
+		pending += r.PaidHours
+	}
+	return balance, pending, nil
+}
+
+// committedTrips returns the numbers of the driver's committed trips whose
+// planned window falls between start and end
+func (s *DriverTimeOffService) committedTrips(ctx context.Context, driverID uuid.UUID, start, end time.Time) ([]string, error) {
+	trips, err := driverTrips(ctx, s.tripRepo, driverID, committedTripStatuses, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var numbers []string
+	for i := range trips {
+		tripStart, tripEnd, ok := trips[i].PlannedWindow(s.businessRules.Schedule.DefaultTripMins)
+		if ok && domain.WindowsOverlap(start, end, tripStart, tripEnd, 0) {
+			numbers = append(numbers, trips[i].TripNumber)
+		}
+	}
+	return numbers, nil
+}
+
+func (s *DriverTimeOffService) pendingTimeOff(ctx context.Context, id uuid.UUID) (*domain.DriverTimeOff, error) {
+	timeOff, err := s.timeOffRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get time off", err)
+	}
+	if timeOff == nil {
+		return nil, apperrors.NotFoundError("time off", id.String())
+	}
+	if timeOff.Status != domain.TimeOffPending {
+		return nil, apperrors.InvalidStateError(string(timeOff.Status), string(domain.TimeOffPending))
+	}
+	return timeOff, nil
+}
+
+func (s *DriverTimeOffService) addEntry(ctx context.Context, entry *domain.PTOLedgerEntry) {
+	if _, err := s.timeOffRepo.AddLedgerEntry(ctx, entry); err != nil {
+		s.logger.Errorw("Failed to record PTO ledger entry",
+			"driver_id", entry.DriverID,
+			"type", entry.Type,
+			"hours", entry.Hours,
+			"error", err,
+		)
+	}
+}
+
+func (s *DriverTimeOffService) publish(ctx context.Context, timeOff *domain.DriverTimeOff) {
+	event := kafka.NewEvent(kafka.Topics.DriverTimeOffChanged, "dispatch-service", map[string]interface{}{
+		"time_off_id": timeOff.ID.String(),
+		"driver_id":   timeOff.DriverID.String(),
+		"driver_name": timeOff.DriverName,
+		"type":        string(timeOff.Type),
+		"status":      string(timeOff.Status),
+		"start_date":  timeOff.StartDate,
+		"end_date":    timeOff.EndDate,
+		"paid_hours":  timeOff.PaidHours,
+		"pay_amount":  timeOff.PayAmount,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.DriverTimeOffChanged, event)
+}
+
+func insufficientPTO(requested, available float64) error {
+	return apperrors.New("INSUFFICIENT_PTO", fmt.Sprintf("%.1f PTO hours requested but only %.1f available", requested, available)).
+		WithDetail("requested_hours", requested).
+		WithDetail("available_hours", available)
+}
+
+// startOfWeek returns midnight on the Monday of t's week, matching the
+// weekly settlement periods
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
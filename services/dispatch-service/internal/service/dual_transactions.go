@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// DualTransactionMatcher is the dual transaction counterpart of street turn
+// matching. It pairs empty returns with import pickups planned at the same
+// terminal close together in time, so one driver can drop the empty and
+// take the load out on one gate visit instead of two.
+type DualTransactionMatcher struct {
+	stopRepo      repository.TripStopRepository
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewDualTransactionMatcher creates a new dual transaction matcher
+func NewDualTransactionMatcher(stopRepo repository.TripStopRepository, log *logger.Logger) *DualTransactionMatcher {
+	return &DualTransactionMatcher{
+		stopRepo:      stopRepo,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// DualTransactionFilter contains filter criteria for dual transaction
+// matching. Zero values take the configured defaults; From defaults to now.
+type DualTransactionFilter struct {
+	TerminalID *uuid.UUID
+	From       time.Time
+	To         time.Time
+	WindowMins int
+	MaxResults int
+}
+
+// FindOpportunities returns empty return and import pickup pairs, best
+// first. Each move appears in at most one pair: the best-scoring pairs are
+// taken first.
+func (m *DualTransactionMatcher) FindOpportunities(ctx context.Context, filter DualTransactionFilter) ([]domain.DualTransactionOpportunity, error) {
+	rules := &m.businessRules.DualTransaction
+	if filter.WindowMins < 0 {
+		return nil, apperrors.ValidationError("window_mins cannot be negative", "window_mins", filter.WindowMins)
+	}
+	if filter.WindowMins == 0 {
+		filter.WindowMins = rules.WindowMins
+	}
+	if filter.MaxResults <= 0 || filter.MaxResults > rules.MaxResults {
+		filter.MaxResults = rules.MaxResults
+	}
+	if filter.From.IsZero() {
+		filter.From = time.Now()
+	}
+	if filter.To.IsZero() {
+		filter.To = filter.From.Add(time.Duration(rules.LookaheadHours) * time.Hour)
+	}
+	if !filter.To.After(filter.From) {
+		return nil, apperrors.ValidationError("to must be after from", "to", filter.To)
+	}
+
+	// Moves just outside the range can still pair with ones inside it
+	window := time.Duration(filter.WindowMins) * time.Minute
+	moves, err := m.stopRepo.GetTerminalMoves(ctx, repository.TerminalMoveFilter{
+		TerminalID: filter.TerminalID,
+		Activities: []domain.ActivityType{domain.ActivityTypeDropEmpty, domain.ActivityTypePickupLoaded},
+		From:       filter.From.Add(-window),
+		To:         filter.To.Add(window),
+	})
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal moves", err)
+	}
+
+	empties := make(map[uuid.UUID][]domain.TerminalMove)
+	pickups := make(map[uuid.UUID][]domain.TerminalMove)
+	for _, move := range moves {
+		if move.IsDualTransaction {
+			continue
+		}
+		switch move.Activity {
+		case domain.ActivityTypeDropEmpty:
+			empties[move.TerminalID] = append(empties[move.TerminalID], move)
+		case domain.ActivityTypePickupLoaded:
+			pickups[move.TerminalID] = append(pickups[move.TerminalID], move)
+		}
+	}
+
+	var candidates []domain.DualTransactionOpportunity
+	for terminalID, terminalEmpties := range empties {
+		for _, empty := range terminalEmpties {
+			for _, pickup := range pickups[terminalID] {
+				if empty.TripID == pickup.TripID {
+					continue
+				}
+				gap := pickup.PlannedTime.Sub(empty.PlannedTime)
+				if gap < 0 {
+					gap = -gap
+				}
+				if gap > window || !inRange(empty, pickup, filter.From, filter.To) {
+					continue
+				}
+				candidates = append(candidates, m.opportunity(empty, pickup, gap, window))
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].MatchScore != candidates[j].MatchScore {
+			return candidates[i].MatchScore > candidates[j].MatchScore
+		}
+		if candidates[i].GapMins != candidates[j].GapMins {
+			return candidates[i].GapMins < candidates[j].GapMins
+		}
+		return candidates[i].EmptyReturn.PlannedTime.Before(candidates[j].EmptyReturn.PlannedTime)
+	})
+
+	used := make(map[uuid.UUID]bool)
+	opportunities := []domain.DualTransactionOpportunity{}
+	for _, candidate := range candidates {
+		if used[candidate.EmptyReturn.StopID] || used[candidate.ImportPickup.StopID] {
+			continue
+		}
+		used[candidate.EmptyReturn.StopID] = true
+		used[candidate.ImportPickup.StopID] = true
+		opportunities = append(opportunities, candidate)
+		if len(opportunities) == filter.MaxResults {
+			break
+		}
+	}
+
+	m.logger.Infow("Dual transactions matched",
+		"terminal_id", filter.TerminalID,
+		"moves", len(moves),
+		"opportunities", len(opportunities),
+	)
+	return opportunities, nil
+}
+
+// inRange reports whether either move of a pair is planned within the range
+func inRange(empty, pickup domain.TerminalMove, from, to time.Time) bool {
+	for _, t := range []time.Time{empty.PlannedTime, pickup.PlannedTime} {
+		if !t.Before(from) && !t.After(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// opportunity scores a pair. A pair planned at the same time scores 100;
+// the score falls with the gap, and more when the empty's chassis cannot
+// carry the load or the trips are already with different drivers.
+func (m *DualTransactionMatcher) opportunity(empty, pickup domain.TerminalMove, gap, window time.Duration) domain.DualTransactionOpportunity {
+	rules := &m.businessRules.DualTransaction
+	opp := domain.DualTransactionOpportunity{
+		TerminalID:       empty.TerminalID,
+		TerminalName:     empty.TerminalName,
+		EmptyReturn:      empty,
+		ImportPickup:     pickup,
+		GapMins:          int(gap.Minutes()),
+		ChassisReuse:     empty.ContainerSize != "" && empty.ContainerSize == pickup.ContainerSize,
+		DriverConflict:   empty.DriverID != nil && pickup.DriverID != nil && *empty.DriverID != *pickup.DriverID,
+		EstimatedSavings: rules.GateVisitSavings,
+	}
+
+	score := 100
+	if window > 0 {
+		score -= int(40 * gap / window)
+	}
+	if opp.ChassisReuse {
+		opp.EstimatedSavings += rules.ChassisFlipSavings
+	} else {
+		score -= 20
+	}
+	if opp.DriverConflict {
+		score -= 15
+	}
+	if score < 0 {
+		score = 0
+	}
+	opp.MatchScore = score
+	return opp
+}
@@ -0,0 +1,513 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// emergencyLocationIntervalSecs is how often the driver app reports its
+// position while an incident is active
+const emergencyLocationIntervalSecs = 5
+
+// VoiceGateway places automated voice calls that read a message aloud
+type VoiceGateway interface {
+	Call(ctx context.Context, to, message string) (providerCallID string, err error)
+}
+
+// EmergencyService runs the driver panic workflow. A panic opens an incident
+// at the driver's location, alerts the designated staff by text and phone,
+// and has the app stream its location at an elevated rate until staff close
+// the incident.
+type EmergencyService struct {
+	emergencyRepo repository.EmergencyRepository
+	driverRepo    repository.DriverRepository
+	tripRepo      repository.TripRepository
+	sms           SMSGateway
+	voice         VoiceGateway
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewEmergencyService creates a new emergency service. Either gateway may be
+// nil, in which case that channel is skipped.
+func NewEmergencyService(
+	emergencyRepo repository.EmergencyRepository,
+	driverRepo repository.DriverRepository,
+	tripRepo repository.TripRepository,
+	sms SMSGateway,
+	voice VoiceGateway,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *EmergencyService {
+	return &EmergencyService{
+		emergencyRepo: emergencyRepo,
+		driverRepo:    driverRepo,
+		tripRepo:      tripRepo,
+		sms:           sms,
+		voice:         voice,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// PanicInput is a panic raised from the driver app
+type PanicInput struct {
+	DriverID  uuid.UUID
+	TripID    *uuid.UUID
+	Latitude  float64
+	Longitude float64
+	AccuracyM float64
+	Notes     string
+}
+
+// TriggerPanic opens an emergency incident for the driver and alerts staff.
+// Pressing panic again while an incident is active records the location on
+// that incident instead of opening another. Lookups of the driver and trip
+// only enrich the alert, so their failures never stop the incident opening.
+func (s *EmergencyService) TriggerPanic(ctx context.Context, input PanicInput) (*domain.EmergencyIncident, error) {
+	if input.DriverID == uuid.Nil {
+		return nil, apperrors.ValidationError("driver_id is required", "driver_id", nil)
+	}
+	if err := validateCoordinates(input.Latitude, input.Longitude); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	existing, err := s.emergencyRepo.GetActiveByDriver(ctx, input.DriverID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get active emergency", err)
+	}
+	if existing != nil {
+		if err := s.addLocation(ctx, existing, domain.EmergencyLocation{
+			Latitude:   input.Latitude,
+			Longitude:  input.Longitude,
+			AccuracyM:  input.AccuracyM,
+			RecordedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+		existing.LocationIntervalSecs = emergencyLocationIntervalSecs
+		return existing, nil
+	}
+
+	incident := &domain.EmergencyIncident{
+		ID:             uuid.New(),
+		DriverID:       input.DriverID,
+		DriverName:     input.DriverID.String(),
+		TripID:         input.TripID,
+		Status:         domain.EmergencyStatusOpen,
+		Latitude:       input.Latitude,
+		Longitude:      input.Longitude,
+		Notes:          strings.TrimSpace(input.Notes),
+		LastLatitude:   input.Latitude,
+		LastLongitude:  input.Longitude,
+		LastLocationAt: now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if driver, err := s.driverRepo.GetByID(ctx, input.DriverID); err == nil {
+		incident.DriverName = driver.Name
+		incident.DriverPhone = domain.NormalizePhone(driver.Phone)
+	} else {
+		s.logger.Warnw("Emergency driver lookup failed", "driver_id", input.DriverID, "error", err)
+	}
+	if input.TripID != nil {
+		if trip, err := s.tripRepo.GetByID(ctx, *input.TripID); err == nil {
+			incident.TripNumber = trip.TripNumber
+		} else {
+			s.logger.Warnw("Emergency trip lookup failed", "trip_id", *input.TripID, "error", err)
+		}
+	}
+
+	if err := s.emergencyRepo.Create(ctx, incident); err != nil {
+		return nil, apperrors.DatabaseError("create emergency incident", err)
+	}
+	location := &domain.EmergencyLocation{
+		ID:         uuid.New(),
+		IncidentID: incident.ID,
+		Latitude:   input.Latitude,
+		Longitude:  input.Longitude,
+		AccuracyM:  input.AccuracyM,
+		RecordedAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.emergencyRepo.AddLocation(ctx, location); err != nil {
+		s.logger.Warnw("Failed to record emergency location", "incident_id", incident.ID, "error", err)
+	}
+
+	incident.Notifications = s.notifyContacts(ctx, incident)
+	incident.LocationIntervalSecs = emergencyLocationIntervalSecs
+
+	event := kafka.NewEvent(kafka.Topics.EmergencyRaised, "dispatch-service", map[string]interface{}{
+		"incident_id": incident.ID.String(),
+		"driver_id":   incident.DriverID.String(),
+		"driver_name": incident.DriverName,
+		"trip_id":     incident.TripID,
+		"trip_number": incident.TripNumber,
+		"latitude":    incident.Latitude,
+		"longitude":   incident.Longitude,
+		"notes":       incident.Notes,
+		"notified":    len(incident.Notifications),
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.EmergencyRaised, event)
+
+	s.logger.Errorw("Driver emergency raised",
+		"incident_id", incident.ID,
+		"driver_id", incident.DriverID,
+		"trip_id", incident.TripID,
+		"latitude", incident.Latitude,
+		"longitude", incident.Longitude,
+		"notified", len(incident.Notifications),
+	)
+
+	return incident, nil
+}
+
+// notifyContacts texts and calls every active emergency contact, recording
+// each attempt. A failed alert is recorded and does not stop the others.
+func (s *EmergencyService) notifyContacts(ctx context.Context, incident *domain.EmergencyIncident) []domain.EmergencyNotification {
+	contacts, err := s.emergencyRepo.ListContacts(ctx, true)
+	if err != nil {
+		s.logger.Errorw("Failed to load emergency contacts", "incident_id", incident.ID, "error", err)
+		return nil
+	}
+	if len(contacts) == 0 {
+		s.logger.Errorw("No emergency contacts configured", "incident_id", incident.ID)
+		return nil
+	}
+
+	text := emergencySMSBody(incident)
+	speech := emergencyCallMessage(incident)
+
+	var notifications []domain.EmergencyNotification
+	for _, contact := range contacts {
+		if contact.NotifySMS && s.sms != nil {
+			id, err := s.sms.Send(ctx, contact.Phone, text)
+			notifications = append(notifications, s.recordNotification(ctx, incident, contact, domain.EmergencyChannelSMS, id, err))
+		}
+		if contact.NotifyCall && s.voice != nil {
+			id, err := s.voice.Call(ctx, contact.Phone, speech)
+			notifications = append(notifications, s.recordNotification(ctx, incident, contact, domain.EmergencyChannelVoice, id, err))
+		}
+	}
+	return notifications
+}
+
+func (s *EmergencyService) recordNotification(ctx context.Context, incident *domain.EmergencyIncident, contact domain.EmergencyContact, channel domain.EmergencyChannel, providerID string, sendErr error) domain.EmergencyNotification {
+	notification := domain.EmergencyNotification{
+		ID:                uuid.New(),
+		IncidentID:        incident.ID,
+		ContactID:         contact.ID,
+		ContactName:       contact.Name,
+		Phone:             contact.Phone,
+		Channel:           channel,
+		Status:            domain.SMSStatusSent,
+		ProviderMessageID: providerID,
+		CreatedAt:         time.Now(),
+	}
+	if sendErr != nil {
+		notification.Status = domain.SMSStatusFailed
+		notification.Error = sendErr.Error()
+		s.logger.Errorw("Emergency alert failed",
+			"incident_id", incident.ID,
+			"contact", contact.Name,
+			"channel", channel,
+			"error", sendErr,
+		)
+	}
+	if err := s.emergencyRepo.CreateNotification(ctx, &notification); err != nil {
+		s.logger.Warnw("Failed to record emergency alert", "incident_id", incident.ID, "error", err)
+	}
+	return notification
+}
+
+func emergencySMSBody(incident *domain.EmergencyIncident) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "EMERGENCY: driver %s", incident.DriverName)
+	if incident.DriverPhone != "" {
+		fmt.Fprintf(&b, " (%s)", incident.DriverPhone)
+	}
+	b.WriteString(" pressed panic")
+	if incident.TripNumber != "" {
+		fmt.Fprintf(&b, " on trip %s", incident.TripNumber)
+	}
+	fmt.Fprintf(&b, " at %s. Location: https://maps.google.com/?q=%.6f,%.6f",
+		incident.CreatedAt.Format("15:04"), incident.Latitude, incident.Longitude)
+	if incident.Notes != "" {
+		fmt.Fprintf(&b, " Note: %s", incident.Notes)
+	}
+	b.WriteString(" Live location is on the dispatch board.")
+	return b.String()
+}
+
+func emergencyCallMessage(incident *domain.EmergencyIncident) string {
+	msg := fmt.Sprintf("This is an emergency alert from dispatch. Driver %s has pressed the panic button", incident.DriverName)
+	if incident.TripNumber != "" {
+		msg += " on trip " + incident.TripNumber
+	}
+	return msg + ". A text with their location has been sent. Check the dispatch board for their live location."
+}
+
+// EmergencyLocationInput is a position streamed by the app during an incident
+type EmergencyLocationInput struct {
+	IncidentID uuid.UUID
+	DriverID   uuid.UUID
+	Latitude   float64
+	Longitude  float64
+	AccuracyM  float64
+	SpeedMPH   float64
+	Heading    float64
+	RecordedAt time.Time
+}
+
+// RecordLocation adds a streamed position to an active incident. Once the
+// incident is closed it fails with INVALID_STATE so the app returns to its
+// normal tracking rate.
+func (s *EmergencyService) RecordLocation(ctx context.Context, input EmergencyLocationInput) (*domain.EmergencyIncident, error) {
+	if err := validateCoordinates(input.Latitude, input.Longitude); err != nil {
+		return nil, err
+	}
+	incident, err := s.emergencyRepo.GetByID(ctx, input.IncidentID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("emergency incident", input.IncidentID.String())
+	}
+	if incident.DriverID != input.DriverID {
+		return nil, apperrors.ValidationError("incident belongs to another driver", "driver_id", input.DriverID)
+	}
+	if !incident.Status.IsActive() {
+		return nil, apperrors.InvalidStateError(string(incident.Status), "OPEN or ACKNOWLEDGED")
+	}
+
+	if input.RecordedAt.IsZero() {
+		input.RecordedAt = time.Now()
+	}
+	if err := s.addLocation(ctx, incident, domain.EmergencyLocation{
+		Latitude:   input.Latitude,
+		Longitude:  input.Longitude,
+		AccuracyM:  input.AccuracyM,
+		SpeedMPH:   input.SpeedMPH,
+		Heading:    input.Heading,
+		RecordedAt: input.RecordedAt,
+	}); err != nil {
+		return nil, err
+	}
+	incident.LocationIntervalSecs = emergencyLocationIntervalSecs
+	return incident, nil
+}
+
+// addLocation stores the position, moves the incident's last known location
+// if it is newer and publishes it for the live map
+func (s *EmergencyService) addLocation(ctx context.Context, incident *domain.EmergencyIncident, location domain.EmergencyLocation) error {
+	location.ID = uuid.New()
+	location.IncidentID = incident.ID
+	location.CreatedAt = time.Now()
+	if err := s.emergencyRepo.AddLocation(ctx, &location); err != nil {
+		return apperrors.DatabaseError("record emergency location", err)
+	}
+
+	if location.RecordedAt.After(incident.LastLocationAt) {
+		incident.LastLatitude = location.Latitude
+		incident.LastLongitude = location.Longitude
+		incident.LastLocationAt = location.RecordedAt
+		incident.UpdatedAt = location.CreatedAt
+		if err := s.emergencyRepo.Update(ctx, incident); err != nil {
+			return apperrors.DatabaseError("update emergency incident", err)
+		}
+	}
+
+	event := kafka.NewEvent(kafka.Topics.EmergencyLocation, "dispatch-service", map[string]interface{}{
+		"incident_id": incident.ID.String(),
+		"driver_id":   incident.DriverID.String(),
+		"latitude":    location.Latitude,
+		"longitude":   location.Longitude,
+		"speed_mph":   location.SpeedMPH,
+		"heading":     location.Heading,
+		"recorded_at": location.RecordedAt,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.EmergencyLocation, event)
+	return nil
+}
+
+// GetActiveForDriver returns the driver's active incident, or nil when there
+// is none. The app polls this to know whether to keep streaming.
+func (s *EmergencyService) GetActiveForDriver(ctx context.Context, driverID uuid.UUID) (*domain.EmergencyIncident, error) {
+	incident, err := s.emergencyRepo.GetActiveByDriver(ctx, driverID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get active emergency", err)
+	}
+	if incident != nil {
+		incident.LocationIntervalSecs = emergencyLocationIntervalSecs
+	}
+	return incident, nil
+}
+
+// GetIncident returns an incident with its location trail and the alerts sent
+func (s *EmergencyService) GetIncident(ctx context.Context, id uuid.UUID) (*domain.EmergencyIncident, error) {
+	incident, err := s.emergencyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundError("emergency incident", id.String())
+	}
+	if incident.Locations, err = s.emergencyRepo.GetLocations(ctx, id); err != nil {
+		return nil, apperrors.DatabaseError("get emergency locations", err)
+	}
+	if incident.Notifications, err = s.emergencyRepo.GetNotifications(ctx, id); err != nil {
+		return nil, apperrors.DatabaseError("get emergency alerts", err)
+	}
+	if incident.Status.IsActive() {
+		incident.LocationIntervalSecs = emergencyLocationIntervalSecs
+	}
+	return incident, nil
+}
+
+// ListActiveIncidents returns the incidents not yet closed
+func (s *EmergencyService) ListActiveIncidents(ctx context.Context) ([]domain.EmergencyIncident, error) {
+	incidents, err := s.emergencyRepo.ListActive(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list active emergencies", err)
+	}
+	for i := range incidents {
+		incidents[i].LocationIntervalSecs = emergencyLocationIntervalSecs
+	}
+	return incidents, nil
+}
+
+// AcknowledgeIncident records that staff are responding to an open incident
+func (s *EmergencyService) AcknowledgeIncident(ctx context.Context, id uuid.UUID, acknowledgedBy string) (*domain.EmergencyIncident, error) {
+	incident, err := s.emergencyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundError("emergency incident", id.String())
+	}
+	if incident.Status != domain.EmergencyStatusOpen {
+		return nil, apperrors.InvalidStateError(string(incident.Status), string(domain.EmergencyStatusOpen))
+	}
+
+	now := time.Now()
+	incident.Status = domain.EmergencyStatusAcknowledged
+	incident.AcknowledgedBy = acknowledgedBy
+	incident.AcknowledgedAt = &now
+	incident.UpdatedAt = now
+	if err := s.emergencyRepo.Update(ctx, incident); err != nil {
+		return nil, apperrors.DatabaseError("update emergency incident", err)
+	}
+	incident.LocationIntervalSecs = emergencyLocationIntervalSecs
+
+	s.logger.Infow("Driver emergency acknowledged", "incident_id", id, "acknowledged_by", acknowledgedBy)
+	return incident, nil
+}
+
+// CloseIncident closes an incident, which stops the elevated location stream
+func (s *EmergencyService) CloseIncident(ctx context.Context, id uuid.UUID, closedBy, resolution string) (*domain.EmergencyIncident, error) {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return nil, apperrors.ValidationError("resolution is required", "resolution", nil)
+	}
+	incident, err := s.emergencyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundError("emergency incident", id.String())
+	}
+	if !incident.Status.IsActive() {
+		return nil, apperrors.InvalidStateError(string(incident.Status), "OPEN or ACKNOWLEDGED")
+	}
+
+	now := time.Now()
+	if incident.AcknowledgedAt == nil {
+		incident.AcknowledgedBy = closedBy
+		incident.AcknowledgedAt = &now
+	}
+	incident.Status = domain.EmergencyStatusClosed
+	incident.ClosedBy = closedBy
+	incident.ClosedAt = &now
+	incident.Resolution = resolution
+	incident.UpdatedAt = now
+	if err := s.emergencyRepo.Update(ctx, incident); err != nil {
+		return nil, apperrors.DatabaseError("update emergency incident", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.EmergencyClosed, "dispatch-service", map[string]interface{}{
+		"incident_id":   incident.ID.String(),
+		"driver_id":     incident.DriverID.String(),
+		"closed_by":     closedBy,
+		"resolution":    resolution,
+		"duration_mins": int(now.Sub(incident.CreatedAt).Minutes()),
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.EmergencyClosed, event)
+
+	s.logger.Infow("Driver emergency closed", "incident_id", id, "closed_by", closedBy)
+	return incident, nil
+}
+
+// ListContacts returns the staff alerted for driver emergencies
+func (s *EmergencyService) ListContacts(ctx context.Context) ([]domain.EmergencyContact, error) {
+	contacts, err := s.emergencyRepo.ListContacts(ctx, true)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list emergency contacts", err)
+	}
+	return contacts, nil
+}
+
+// AddContactInput contains input for designating an emergency contact
+type AddContactInput struct {
+	Name       string
+	Phone      string
+	NotifySMS  bool
+	NotifyCall bool
+	CreatedBy  string
+}
+
+// AddContact designates a staff member to be alerted for driver emergencies
+func (s *EmergencyService) AddContact(ctx context.Context, input AddContactInput) (*domain.EmergencyContact, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, apperrors.ValidationError("name is required", "name", nil)
+	}
+	phone := domain.NormalizePhone(input.Phone)
+	if phone == "" {
+		return nil, apperrors.ValidationError("phone is required", "phone", input.Phone)
+	}
+	if !input.NotifySMS && !input.NotifyCall {
+		return nil, apperrors.ValidationError("contact must be notified by text, call or both", "notify_sms", nil)
+	}
+
+	contact := &domain.EmergencyContact{
+		ID:         uuid.New(),
+		Name:       name,
+		Phone:      phone,
+		NotifySMS:  input.NotifySMS,
+		NotifyCall: input.NotifyCall,
+		Active:     true,
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.emergencyRepo.CreateContact(ctx, contact); err != nil {
+		return nil, apperrors.DatabaseError("create emergency contact", err)
+	}
+	return contact, nil
+}
+
+// RemoveContact stops alerting a contact. Past alerts keep referring to it.
+func (s *EmergencyService) RemoveContact(ctx context.Context, id uuid.UUID) error {
+	if err := s.emergencyRepo.DeactivateContact(ctx, id); err != nil {
+		return apperrors.DatabaseError("deactivate emergency contact", err)
+	}
+	return nil
+}
+
+func validateCoordinates(lat, lon float64) error {
+	if lat < -90 || lat > 90 {
+		return apperrors.ValidationError("latitude must be between -90 and 90", "latitude", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return apperrors.ValidationError("longitude must be between -180 and 180", "longitude", lon)
+	}
+	return nil
+}
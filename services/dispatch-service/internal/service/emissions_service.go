@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// EmissionsService estimates CO2e emissions for trips and customers
+type EmissionsService struct {
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	tractorRepo   repository.TractorRepository
+	orderRepo     repository.OrderRepository
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewEmissionsService creates a new emissions service
+func NewEmissionsService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	tractorRepo repository.TractorRepository,
+	orderRepo repository.OrderRepository,
+	log *logger.Logger,
+) *EmissionsService {
+	return &EmissionsService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		tractorRepo:   tractorRepo,
+		orderRepo:     orderRepo,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// GetTripEmissions estimates emissions for a single trip
+func (s *EmissionsService) GetTripEmissions(ctx context.Context, tripID uuid.UUID) (*domain.TripEmissions, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	return s.estimateTrip(ctx, trip, stops)
+}
+
+// GetCustomerEmissionsReport aggregates emissions of completed trips in a
+// period for every order belonging to the customer
+func (s *EmissionsService) GetCustomerEmissionsReport(ctx context.Context, customerID uuid.UUID, start, end time.Time) (*domain.EmissionsReport, error) {
+	trips, err := s.tripRepo.GetByDateRange(ctx, start, end)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trips", err)
+	}
+
+	var tripIDs []uuid.UUID
+	for _, trip := range trips {
+		if trip.Status == domain.TripStatusCompleted {
+			tripIDs = append(tripIDs, trip.ID)
+		}
+	}
+
+	report := &domain.EmissionsReport{
+		CustomerID:  customerID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Methodology: s.businessRules.Emissions.Methodology,
+		GeneratedAt: time.Now(),
+	}
+	if len(tripIDs) == 0 {
+		return report, nil
+	}
+
+	allStops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	stopsByTrip := make(map[uuid.UUID][]domain.TripStop)
+	orderSet := make(map[uuid.UUID]bool)
+	for _, stop := range allStops {
+		stopsByTrip[stop.TripID] = append(stopsByTrip[stop.TripID], stop)
+		if stop.OrderID != nil {
+			orderSet[*stop.OrderID] = true
+		}
+	}
+
+	orderIDs := make([]uuid.UUID, 0, len(orderSet))
+	for id := range orderSet {
+		orderIDs = append(orderIDs, id)
+	}
+	customers, err := s.orderRepo.GetCustomerIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get order customers", err)
+	}
+
+	byOrder := make(map[uuid.UUID]*domain.OrderEmissions)
+	for i := range trips {
+		trip := &trips[i]
+		if trip.Status != domain.TripStatusCompleted {
+			continue
+		}
+
+		estimate, err := s.estimateTrip(ctx, trip, stopsByTrip[trip.ID])
+		if err != nil {
+			return nil, err
+		}
+
+		// Share of the trip that belongs to this customer
+		var customerKg float64
+		for orderID, kg := range estimate.ByOrder {
+			if customers[orderID] != customerID {
+				continue
+			}
+			customerKg += kg
+			oe, ok := byOrder[orderID]
+			if !ok {
+				oe = &domain.OrderEmissions{OrderID: orderID}
+				byOrder[orderID] = oe
+			}
+			oe.TripIDs = append(oe.TripIDs, trip.ID)
+			oe.CO2eKg += kg
+		}
+		if customerKg == 0 || estimate.CO2eKg == 0 {
+			continue
+		}
+
+		// Scale trip miles by the customer's share of trip emissions
+		share := customerKg / estimate.CO2eKg
+		report.TripCount++
+		report.TotalMiles += estimate.TotalMiles * share
+		report.LoadedMiles += estimate.LoadedMiles * share
+		report.EmptyMiles += estimate.EmptyMiles * share
+		report.BobtailMiles += estimate.BobtailMiles * share
+		report.CO2eKg += customerKg
+	}
+
+	for _, oe := range byOrder {
+		oe.CO2eKg = roundTo(oe.CO2eKg, 2)
+		report.Orders = append(report.Orders, *oe)
+	}
+	sort.Slice(report.Orders, func(i, j int) bool {
+		return report.Orders[i].CO2eKg > report.Orders[j].CO2eKg
+	})
+
+	report.OrderCount = len(report.Orders)
+	report.TotalMiles = roundTo(report.TotalMiles, 1)
+	report.LoadedMiles = roundTo(report.LoadedMiles, 1)
+	report.EmptyMiles = roundTo(report.EmptyMiles, 1)
+	report.BobtailMiles = roundTo(report.BobtailMiles, 1)
+	report.CO2eKg = roundTo(report.CO2eKg, 2)
+	report.CO2eTonnes = roundTo(report.CO2eKg/1000, 3)
+
+	s.logger.Infow("Emissions report generated",
+		"customer_id", customerID,
+		"trips", report.TripCount,
+		"co2e_kg", report.CO2eKg,
+	)
+
+	return report, nil
+}
+
+// estimateTrip splits a trip into legs, classifies each leg's load status and
+// applies the tractor's emission factor to actual miles
+func (s *EmissionsService) estimateTrip(ctx context.Context, trip *domain.Trip, stops []domain.TripStop) (*domain.TripEmissions, error) {
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+
+	equipmentClass := s.businessRules.Emissions.DefaultEquipmentClass
+	if trip.TractorID != nil {
+		if tractor, err := s.tractorRepo.GetByID(ctx, *trip.TractorID); err == nil && tractor.FuelType != "" {
+			equipmentClass = tractor.FuelType
+		}
+	}
+	factor := s.businessRules.Emissions.GetFactor(equipmentClass)
+
+	result := &domain.TripEmissions{
+		TripID:         trip.ID,
+		TripNumber:     trip.TripNumber,
+		EquipmentClass: equipmentClass,
+		ByOrder:        make(map[uuid.UUID]float64),
+	}
+
+	// Straight-line leg distances are only used to apportion actual miles
+	status := domain.LoadStatusBobtail
+	var plannedMiles float64
+	for i := 0; i+1 < len(stops); i++ {
+		status = stops[i].Activity.NextLoadStatus(status)
+
+		miles, err := s.legMiles(ctx, &stops[i], &stops[i+1])
+		if err != nil {
+			return nil, err
+		}
+		plannedMiles += miles
+
+		orderID := stops[i].OrderID
+		if orderID == nil {
+			orderID = stops[i+1].OrderID
+		}
+
+		result.Legs = append(result.Legs, domain.EmissionsLeg{
+			FromSequence: stops[i].Sequence,
+			ToSequence:   stops[i+1].Sequence,
+			LoadStatus:   status,
+			Miles:        miles,
+			OrderID:      orderID,
+		})
+	}
+
+	actualMiles := trip.CompletedMiles
+	if actualMiles == 0 {
+		actualMiles = trip.TotalMiles
+	}
+	scale := 1.0
+	if plannedMiles > 0 && actualMiles > 0 {
+		scale = actualMiles / plannedMiles
+	}
+
+	var unallocatedKg float64
+	orderIDs := make(map[uuid.UUID]bool)
+	for i := range result.Legs {
+		leg := &result.Legs[i]
+		leg.Miles = roundTo(leg.Miles*scale, 1)
+
+		var kgPerMile float64
+		switch leg.LoadStatus {
+		case domain.LoadStatusLoaded:
+			kgPerMile = factor.LoadedKgPerMile
+			result.LoadedMiles += leg.Miles
+		case domain.LoadStatusEmpty:
+			kgPerMile = factor.EmptyKgPerMile
+			result.EmptyMiles += leg.Miles
+		default:
+			kgPerMile = factor.BobtailKgPerMile
+			result.BobtailMiles += leg.Miles
+		}
+		leg.CO2eKg = roundTo(leg.Miles*kgPerMile, 2)
+
+		result.TotalMiles += leg.Miles
+		result.CO2eKg += leg.CO2eKg
+		if leg.OrderID != nil {
+			orderIDs[*leg.OrderID] = true
+			result.ByOrder[*leg.OrderID] += leg.CO2eKg
+		} else {
+			unallocatedKg += leg.CO2eKg
+		}
+	}
+
+	if unallocatedKg > 0 && len(orderIDs) > 0 {
+		share := unallocatedKg / float64(len(orderIDs))
+		for id := range orderIDs {
+			result.ByOrder[id] += share
+		}
+	}
+
+	result.CO2eKg = roundTo(result.CO2eKg, 2)
+	return result, nil
+}
+
+// legMiles returns the straight-line distance between two stops
+func (s *EmissionsService) legMiles(ctx context.Context, from, to *domain.TripStop) (float64, error) {
+	fromLoc, err := s.locationRepo.GetByID(ctx, from.LocationID)
+	if err != nil {
+		return 0, apperrors.NotFoundError("location", from.LocationID.String())
+	}
+	toLoc, err := s.locationRepo.GetByID(ctx, to.LocationID)
+	if err != nil {
+		return 0, apperrors.NotFoundError("location", to.LocationID.String())
+	}
+	return haversineMiles(fromLoc.Latitude, fromLoc.Longitude, toLoc.Latitude, toLoc.Longitude), nil
+}
+
+// roundTo rounds a value to the given number of decimal places
+func roundTo(value float64, places int) float64 {
+	pow := math.Pow(10, float64(places))
+	return math.Round(value*pow) / pow
+}
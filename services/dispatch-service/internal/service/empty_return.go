@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ReturnSlotFinder looks up terminal appointment availability for empty
+// returns, backed by eModal PreGate (move type MD). NextEmptyReturnSlot
+// returns nil when the terminal has no slot between after and before.
+type ReturnSlotFinder interface {
+	NextEmptyReturnSlot(ctx context.Context, terminalID uuid.UUID, containerSize string, after, before time.Time) (*time.Time, error)
+}
+
+// EmptyReturnService decides what to do with an empty import once it is
+// available: return it at the next terminal appointment, hold it for a
+// street turn onto a pending export booking, or ground it in a company yard
+// and return it later. Each option is priced on miles, steamship line
+// per-diem, chassis rent, yard lifts and storage, and the cheapest feasible
+// one is recommended.
+type EmptyReturnService struct {
+	containerRepo repository.ContainerRepository
+	locationRepo  repository.LocationRepository
+	stopRepo      repository.TripStopRepository
+	dispatch      *DispatchService
+	slots         ReturnSlotFinder
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewEmptyReturnService creates a new empty return decision service
+func NewEmptyReturnService(
+	containerRepo repository.ContainerRepository,
+	locationRepo repository.LocationRepository,
+	stopRepo repository.TripStopRepository,
+	dispatch *DispatchService,
+	slots ReturnSlotFinder,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *EmptyReturnService {
+	return &EmptyReturnService{
+		containerRepo: containerRepo,
+		locationRepo:  locationRepo,
+		stopRepo:      stopRepo,
+		dispatch:      dispatch,
+		slots:         slots,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// EmptyReturnInput contains input for deciding on an empty. LocationID
+// overrides where the empty sits; a zero AvailableAt means now.
+type EmptyReturnInput struct {
+	ContainerID uuid.UUID
+	LocationID  *uuid.UUID
+	AvailableAt time.Time
+}
+
+// Recommend prices every option for an empty and recommends the cheapest
+// one that can be carried out
+func (s *EmptyReturnService) Recommend(ctx context.Context, input EmptyReturnInput) (*domain.EmptyReturnRecommendation, error) {
+	empty, err := s.containerRepo.GetEmpty(ctx, input.ContainerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get empty container", err)
+	}
+	if empty == nil {
+		return nil, apperrors.NotFoundError("empty import container", input.ContainerID.String())
+	}
+	if input.LocationID != nil {
+		empty.LocationID = *input.LocationID
+	}
+	now := input.AvailableAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if empty.OutGatedAt.IsZero() {
+		empty.OutGatedAt = now
+	}
+
+	origin, err := s.locationRepo.GetByID(ctx, empty.LocationID)
+	if err != nil || origin == nil {
+		return nil, apperrors.NotFoundError("location", empty.LocationID.String())
+	}
+	terminal, err := s.locationRepo.GetByID(ctx, empty.ReturnTerminalID)
+	if err != nil || terminal == nil {
+		return nil, apperrors.NotFoundError("location", empty.ReturnTerminalID.String())
+	}
+
+	rec := &domain.EmptyReturnRecommendation{
+		Container:   *empty,
+		Options:     []domain.EmptyReturnOption{},
+		GeneratedAt: now,
+	}
+
+	slot, slotErr := s.nextReturnSlot(ctx, empty, origin, terminal, now)
+	rec.Options = append(rec.Options, s.returnNow(empty, origin, terminal, slot, slotErr, now))
+
+	yardOption, err := s.stageInYard(ctx, empty, origin, terminal, slot, now)
+	if err != nil {
+		return nil, err
+	}
+	rec.Options = append(rec.Options, yardOption)
+
+	streetTurns, err := s.streetTurns(ctx, empty, origin, now)
+	if err != nil {
+		return nil, err
+	}
+	rec.Options = append(rec.Options, streetTurns...)
+
+	sort.SliceStable(rec.Options, func(i, j int) bool {
+		a, b := rec.Options[i], rec.Options[j]
+		if a.Feasible != b.Feasible {
+			return a.Feasible
+		}
+		return a.Cost.Total < b.Cost.Total
+	})
+	if rec.Options[0].Feasible {
+		best := rec.Options[0]
+		rec.Recommended = &best
+	}
+	return rec, nil
+}
+
+// nextReturnSlot finds the first return appointment the empty can make
+// after driving from where it sits to the terminal
+func (s *EmptyReturnService) nextReturnSlot(ctx context.Context, empty *domain.EmptyContainer, origin, terminal *domain.Location, now time.Time) (*time.Time, error) {
+	if s.slots == nil {
+		return nil, fmt.Errorf("appointment availability is not configured")
+	}
+	after := now.Add(s.driveTime(origin, terminal))
+	before := now.AddDate(0, 0, s.businessRules.EmptyReturn.AppointmentHorizonDays)
+	return s.slots.NextEmptyReturnSlot(ctx, terminal.ID, empty.ContainerSize, after, before)
+}
+
+func (s *EmptyReturnService) returnNow(empty *domain.EmptyContainer, origin, terminal *domain.Location, slot *time.Time, slotErr error, now time.Time) domain.EmptyReturnOption {
+	option := domain.EmptyReturnOption{
+		Action:          domain.EmptyReturnNow,
+		DestinationID:   terminal.ID,
+		DestinationName: terminal.Name,
+		Miles:           s.miles(origin, terminal),
+	}
+	switch {
+	case slotErr != nil:
+		s.logger.Warnw("Could not check empty return appointments",
+			"container_number", empty.ContainerNumber,
+			"terminal", terminal.Name,
+			"error", slotErr,
+		)
+		option.Reason = "return appointment availability could not be checked"
+		return option
+	case slot == nil:
+		option.Reason = fmt.Sprintf("no return appointment at %s in the next %d days",
+			terminal.Name, s.businessRules.EmptyReturn.AppointmentHorizonDays)
+		return option
+	}
+
+	option.Feasible = true
+	option.AppointmentTime = slot
+	option.ReleasedAt = *slot
+	option.ChassisDays = domain.CalendarDays(now, *slot)
+	s.price(&option, empty)
+	return option
+}
+
+// stageInYard grounds the empty in the nearest company yard today, which
+// frees the chassis, and returns it at the next appointment. With no
+// appointment in sight it is priced as returned at the end of the horizon.
+func (s *EmptyReturnService) stageInYard(ctx context.Context, empty *domain.EmptyContainer, origin, terminal *domain.Location, slot *time.Time, now time.Time) (domain.EmptyReturnOption, error) {
+	rules := &s.businessRules.EmptyReturn
+	option := domain.EmptyReturnOption{Action: domain.EmptyReturnStageYard}
+
+	yards, err := s.locationRepo.ListYards(ctx)
+	if err != nil {
+		return option, apperrors.DatabaseError("list yards", err)
+	}
+	var yard *domain.Location
+	nearest := math.MaxFloat64
+	for i := range yards {
+		if miles := s.miles(origin, &yards[i]); miles < nearest {
+			yard, nearest = &yards[i], miles
+		}
+	}
+	if yard == nil {
+		option.Reason = "no company yard to stage in"
+		return option, nil
+	}
+
+	option.DestinationID = yard.ID
+	option.DestinationName = yard.Name
+	option.Miles = nearest + s.miles(yard, terminal)
+	option.Feasible = true
+	option.AppointmentTime = slot
+	option.ReleasedAt = now.AddDate(0, 0, rules.AppointmentHorizonDays)
+	if slot != nil {
+		option.ReleasedAt = *slot
+	}
+	option.ChassisDays = 2 // The move in today, and remounted for the return
+	s.price(&option, empty)
+
+	inYard := now.Add(s.driveTime(origin, yard))
+	option.Cost.Yard = round2(2*rules.YardLiftFee + float64(domain.CalendarDays(inYard, option.ReleasedAt))*rules.YardStorageDailyRate)
+	option.Cost.Total = round2(option.Cost.Total + option.Cost.Yard)
+	return option, nil
+}
+
+// streetTurns prices holding the empty for each matching export booking.
+// The export's empty pickup at the terminal is saved; bookings too far out
+// to hold for are kept as infeasible.
+func (s *EmptyReturnService) streetTurns(ctx context.Context, empty *domain.EmptyContainer, origin *domain.Location, now time.Time) ([]domain.EmptyReturnOption, error) {
+	rules := &s.businessRules.EmptyReturn
+	opportunities, err := s.dispatch.FindStreetTurnOpportunities(ctx, repository.StreetTurnFilter{
+		ImportOrderID:   &empty.ImportOrderID,
+		SteamshipLineID: empty.SteamshipLineID,
+		ContainerSize:   empty.ContainerSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := now.AddDate(0, 0, rules.MaxStreetTurnHoldDays)
+	var options []domain.EmptyReturnOption
+	for i := range opportunities {
+		opp := &opportunities[i]
+		exportOrderID := opp.ExportOrderID
+		option := domain.EmptyReturnOption{
+			Action:            domain.EmptyReturnStreetTurn,
+			DestinationID:     opp.ExportPickupLocation.ID,
+			DestinationName:   opp.ExportPickupLocation.Name,
+			ExportOrderID:     &exportOrderID,
+			ExportOrderNumber: opp.ExportOrderNumber,
+			Miles:             opp.DistanceMiles,
+		}
+		if option.Miles == 0 {
+			option.Miles = s.miles(origin, &opp.ExportPickupLocation)
+		}
+
+		switch {
+		case empty.SteamshipLineID != nil && opp.ExportSSLID != uuid.Nil && opp.ExportSSLID != *empty.SteamshipLineID:
+			option.Reason = "export booking is with a different steamship line"
+		case opp.ExportPickupDate.After(latest):
+			option.Reason = fmt.Sprintf("export loads %s, more than %d days out",
+				opp.ExportPickupDate.Format("2006-01-02"), rules.MaxStreetTurnHoldDays)
+		default:
+			option.Feasible = true
+			option.ReleasedAt = opp.ExportPickupDate
+			if option.ReleasedAt.Before(now) {
+				option.ReleasedAt = now
+			}
+			option.ChassisDays = domain.CalendarDays(now, option.ReleasedAt)
+			s.price(&option, empty)
+			option.Cost.Fees = rules.StreetTurnFee
+			if opp.ExportTerminalID != uuid.Nil {
+				if exportTerminal, err := s.locationRepo.GetByID(ctx, opp.ExportTerminalID); err == nil && exportTerminal != nil {
+					option.Cost.Savings = round2(s.miles(exportTerminal, &opp.ExportPickupLocation) * rules.CostPerMile)
+				}
+			}
+			option.Cost.Total = round2(option.Cost.Total + option.Cost.Fees - option.Cost.Savings)
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// price fills in the mileage, per-diem and chassis cost of an option from
+// its miles, release time and chassis days
+func (s *EmptyReturnService) price(option *domain.EmptyReturnOption, empty *domain.EmptyContainer) {
+	rules := &s.businessRules.EmptyReturn
+	option.Miles = math.Round(option.Miles*10) / 10
+	option.PerDiemDays = domain.CalendarDays(empty.OutGatedAt, option.ReleasedAt)
+	option.Cost.Mileage = round2(option.Miles * rules.CostPerMile)
+	option.Cost.PerDiem = round2(rules.PerDiem(empty.ContainerSize, option.PerDiemDays))
+	option.Cost.Chassis = round2(float64(option.ChassisDays) * rules.ChassisDailyRate)
+	option.Cost.Total = round2(option.Cost.Mileage + option.Cost.PerDiem + option.Cost.Chassis)
+}
+
+func (s *EmptyReturnService) miles(from, to *domain.Location) float64 {
+	return haversineMiles(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+}
+
+func (s *EmptyReturnService) driveTime(from, to *domain.Location) time.Duration {
+	mins := math.Ceil(s.miles(from, to) / s.businessRules.Distance.DrayageAverageSpeedMPH * 60)
+	return time.Duration(mins) * time.Minute
+}
+
+// RecommendAndPublish recommends an option for an empty that has just
+// become available and publishes it for the dispatch board
+func (s *EmptyReturnService) RecommendAndPublish(ctx context.Context, input EmptyReturnInput) (*domain.EmptyReturnRecommendation, error) {
+	rec, err := s.Recommend(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"container_id":     rec.Container.ContainerID.String(),
+		"container_number": rec.Container.ContainerNumber,
+		"import_order_id":  rec.Container.ImportOrderID.String(),
+		"location_id":      rec.Container.LocationID.String(),
+		"options":          len(rec.Options),
+	}
+	if best := rec.Recommended; best != nil {
+		data["action"] = best.Action
+		data["destination_id"] = best.DestinationID.String()
+		data["destination_name"] = best.DestinationName
+		data["total_cost"] = best.Cost.Total
+		if best.ExportOrderID != nil {
+			data["export_order_id"] = best.ExportOrderID.String()
+		}
+	}
+	event := kafka.NewEvent(kafka.Topics.EmptyReturnRecommended, "dispatch-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.EmptyReturnRecommended, event)
+
+	s.logger.Infow("Empty return recommended",
+		"container_number", rec.Container.ContainerNumber,
+		"action", data["action"],
+		"total_cost", data["total_cost"],
+	)
+	return rec, nil
+}
+
+// HandleStopCompleted is a kafka.Handler that decides on the empty once a
+// live unload is finished. Dropped loads come through the API when the
+// customer reports the container empty.
+func (s *EmptyReturnService) HandleStopCompleted(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload struct {
+		StopID string `json:"stop_id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal stop completed: %w", err)
+	}
+	stopID, err := uuid.Parse(payload.StopID)
+	if err != nil {
+		return nil
+	}
+
+	stop, err := s.stopRepo.GetByID(ctx, stopID)
+	if err != nil || stop == nil || stop.Activity != domain.ActivityTypeLiveUnload || stop.ContainerID == nil {
+		return nil
+	}
+
+	input := EmptyReturnInput{ContainerID: *stop.ContainerID, LocationID: &stop.LocationID}
+	if stop.ActualDeparture != nil {
+		input.AvailableAt = *stop.ActualDeparture
+	}
+	if _, err := s.RecommendAndPublish(ctx, input); err != nil {
+		s.logger.Warnw("Could not recommend an empty return",
+			"stop_id", stop.ID,
+			"container_number", stop.ContainerNumber,
+			"error", err,
+		)
+	}
+	return nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// escortPlan is what escorting a non-TWIC driver through a trip's port
+// terminals takes: each terminal's policy, and the order the fees bill to
+type escortPlan struct {
+	policies []domain.TerminalEscortPolicy
+	orderID  *uuid.UUID
+}
+
+// driverHasValidTWIC checks if a driver may enter a port terminal unescorted
+func driverHasValidTWIC(rules *config.DocumentRules, driver *domain.Driver, now time.Time) bool {
+	if !driver.HasTWIC {
+		return false
+	}
+	restrictions := rules.Restrictions(driverDocumentExpirations(driver), now)
+	return len(restrictions[config.DutyRestrictionNonPort]) == 0
+}
+
+// planTripEscorts returns the escort policies of every port terminal a trip
+// enters. It fails naming the terminals that do not allow escorts, since a
+// non-TWIC driver cannot run the trip unless all of them do.
+func planTripEscorts(
+	ctx context.Context,
+	escortRepo repository.EscortRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	tripID uuid.UUID,
+) (*escortPlan, error) {
+	stops, err := stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	plan := &escortPlan{}
+	seen := make(map[uuid.UUID]bool)
+	var refused []string
+	for _, stop := range stops {
+		if plan.orderID == nil && stop.OrderID != nil {
+			plan.orderID = stop.OrderID
+		}
+		if seen[stop.LocationID] {
+			continue
+		}
+		seen[stop.LocationID] = true
+
+		location, err := locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return nil, apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+		if location == nil || !strings.EqualFold(location.Type, "terminal") {
+			continue
+		}
+		policy, err := escortRepo.GetPolicy(ctx, location.ID)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get terminal escort policy", err)
+		}
+		if policy == nil || !policy.EscortsAllowed {
+			refused = append(refused, location.Name)
+			continue
+		}
+		plan.policies = append(plan.policies, *policy)
+	}
+
+	if len(refused) > 0 {
+		return nil, apperrors.New("ESCORT_NOT_ALLOWED", "terminal does not allow escorted drivers without a TWIC").
+			WithDetail("trip_id", tripID.String()).
+			WithDetail("terminals", refused)
+	}
+	return plan, nil
+}
+
+// settleEscortFees brings a trip's escort charges in line with its driver.
+// A non-TWIC driver running with escorts is charged once per terminal;
+// charges for anyone else, or on a trip no longer escorted (plan nil), are
+// voided.
+func settleEscortFees(
+	ctx context.Context,
+	escortRepo repository.EscortRepository,
+	producer *kafka.Producer,
+	log *logger.Logger,
+	rates *config.RateRules,
+	trip *domain.Trip,
+	driverID uuid.UUID,
+	plan *escortPlan,
+	now time.Time,
+) error {
+	open, err := escortRepo.GetOpenCharges(ctx, trip.ID)
+	if err != nil {
+		return apperrors.DatabaseError("get escort charges", err)
+	}
+
+	charged := make(map[uuid.UUID]bool)
+	for i := range open {
+		charge := &open[i]
+		if plan != nil && charge.DriverID == driverID {
+			charged[charge.TerminalID] = true
+			continue
+		}
+		if err := escortRepo.VoidCharge(ctx, charge.ID, now); err != nil {
+			return apperrors.DatabaseError("void escort charge", err)
+		}
+		event := kafka.NewEvent(kafka.Topics.EscortFeeVoided, "dispatch-service", map[string]interface{}{
+			"charge_id":   charge.ID.String(),
+			"trip_id":     trip.ID.String(),
+			"trip_number": trip.TripNumber,
+			"amount":      charge.Amount,
+		})
+		_ = producer.Publish(ctx, kafka.Topics.EscortFeeVoided, event)
+	}
+	if plan == nil {
+		return nil
+	}
+
+	for _, policy := range plan.policies {
+		if charged[policy.TerminalID] {
+			continue
+		}
+		charge := &domain.EscortCharge{
+			ID:         uuid.New(),
+			TripID:     trip.ID,
+			TripNumber: trip.TripNumber,
+			OrderID:    plan.orderID,
+			TerminalID: policy.TerminalID,
+			DriverID:   driverID,
+			Amount:     policy.Fee,
+			CreatedAt:  now,
+		}
+		if charge.Amount <= 0 {
+			charge.Amount = rates.TWICEscortCharge
+		}
+		if err := escortRepo.CreateCharge(ctx, charge); err != nil {
+			return apperrors.DatabaseError("create escort charge", err)
+		}
+
+		data := map[string]interface{}{
+			"charge_id":   charge.ID.String(),
+			"trip_id":     trip.ID.String(),
+			"trip_number": trip.TripNumber,
+			"terminal_id": charge.TerminalID.String(),
+			"driver_id":   driverID.String(),
+			"charge_type": "TWIC_ESCORT",
+			"amount":      charge.Amount,
+		}
+		if charge.OrderID != nil {
+			data["order_id"] = charge.OrderID.String()
+		}
+		event := kafka.NewEvent(kafka.Topics.EscortFeeAssessed, "dispatch-service", data)
+		_ = producer.Publish(ctx, kafka.Topics.EscortFeeAssessed, event)
+
+		log.Infow("Escort fee assessed",
+			"trip_id", trip.ID,
+			"terminal_id", charge.TerminalID,
+			"driver_id", driverID,
+			"amount", charge.Amount,
+		)
+	}
+	return nil
+}
+
+// =============================================================================
+// TERMINAL POLICIES AND THE TRIP ESCORT FLAG
+// =============================================================================
+
+// SaveEscortPolicyInput contains input for setting a terminal's escort policy
+type SaveEscortPolicyInput struct {
+	TerminalID     uuid.UUID
+	EscortsAllowed bool
+	Fee            float64
+	Notes          string
+	UpdatedBy      string
+}
+
+// SaveEscortPolicy sets whether a port terminal admits escorted drivers without a TWIC
+func (s *DispatchService) SaveEscortPolicy(ctx context.Context, input SaveEscortPolicyInput) (*domain.TerminalEscortPolicy, error) {
+	if input.Fee < 0 {
+		return nil, apperrors.ValidationError("escort fee cannot be negative", "fee", input.Fee)
+	}
+	location, err := s.locationRepo.GetByID(ctx, input.TerminalID)
+	if err != nil || location == nil {
+		return nil, apperrors.NotFoundError("terminal", input.TerminalID.String())
+	}
+	if !strings.EqualFold(location.Type, "terminal") {
+		return nil, apperrors.ValidationError("location is not a port terminal", "terminal_id", input.TerminalID)
+	}
+
+	policy := &domain.TerminalEscortPolicy{
+		TerminalID:     input.TerminalID,
+		EscortsAllowed: input.EscortsAllowed,
+		Fee:            input.Fee,
+		Notes:          strings.TrimSpace(input.Notes),
+		UpdatedBy:      input.UpdatedBy,
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.escortRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, apperrors.DatabaseError("save terminal escort policy", err)
+	}
+
+	s.logger.Infow("Terminal escort policy saved",
+		"terminal_id", policy.TerminalID,
+		"escorts_allowed", policy.EscortsAllowed,
+		"fee", policy.Fee,
+		"updated_by", policy.UpdatedBy,
+	)
+	return policy, nil
+}
+
+// GetEscortPolicies returns every terminal's escort policy
+func (s *DispatchService) GetEscortPolicies(ctx context.Context) ([]domain.TerminalEscortPolicy, error) {
+	policies, err := s.escortRepo.ListPolicies(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list terminal escort policies", err)
+	}
+	return policies, nil
+}
+
+// SetTWICEscort turns terminal escorts on or off for a trip. With escorts on,
+// a driver without a valid TWIC may be assigned and the trip is charged an
+// escort fee per terminal. Turning them off is refused while such a driver
+// is assigned.
+func (s *DispatchService) SetTWICEscort(ctx context.Context, tripID uuid.UUID, enabled bool, user string) (*domain.Trip, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	if trip.TWICEscort == enabled {
+		return trip, nil
+	}
+	switch trip.Status {
+	case domain.TripStatusDraft, domain.TripStatusPlanned, domain.TripStatusAssigned:
+	default:
+		return nil, apperrors.InvalidStateError(
+			string(trip.Status),
+			string(domain.TripStatusPlanned)+" or "+string(domain.TripStatusAssigned),
+		)
+	}
+
+	var plan *escortPlan
+	if enabled {
+		if plan, err = planTripEscorts(ctx, s.escortRepo, s.stopRepo, s.locationRepo, tripID); err != nil {
+			return nil, err
+		}
+		if len(plan.policies) == 0 {
+			return nil, apperrors.ValidationError("trip does not enter a port terminal", "trip_id", tripID)
+		}
+	}
+
+	var driver *domain.Driver
+	if trip.DriverID != nil {
+		if driver, err = s.driverRepo.GetByID(ctx, *trip.DriverID); err != nil {
+			return nil, apperrors.NotFoundError("driver", trip.DriverID.String())
+		}
+	}
+	escorted := driver != nil && !driverHasValidTWIC(&s.businessRules.Documents, driver, time.Now())
+	if !enabled && escorted {
+		return nil, apperrors.New("ESCORT_REQUIRED", "assigned driver has no valid TWIC; reassign the trip before turning escorts off").
+			WithDetail("driver_id", driver.ID.String())
+	}
+
+	trip.TWICEscort = enabled
+	trip.UpdatedAt = time.Now()
+	if err := s.tripRepo.Update(ctx, trip); err != nil {
+		return nil, tripUpdateError(ctx, s.tripRepo, tripID, "set trip escort", err)
+	}
+
+	if escorted {
+		if err := settleEscortFees(ctx, s.escortRepo, s.eventProducer, s.logger, &s.businessRules.Rates, trip, driver.ID, plan, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Infow("Trip escort flag changed",
+		"trip_id", tripID,
+		"twic_escort", enabled,
+		"changed_by", user,
+	)
+	return trip, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// isExportIngate checks if a stop drops a loaded container at a terminal,
+// which only happens when delivering an export for vessel loading
+func isExportIngate(stop *domain.TripStop, location *domain.Location) bool {
+	if stop.Activity != domain.ActivityTypeDropLoaded && stop.Activity != domain.ActivityTypeDeliverLoaded {
+		return false
+	}
+	return location != nil && strings.EqualFold(location.Type, "terminal")
+}
+
+// checkExportIngateVGM blocks trips that would ingate an export container
+// without a verified gross mass on file
+func (s *DispatchService) checkExportIngateVGM(ctx context.Context, trip *domain.Trip) error {
+	var containerIDs []uuid.UUID
+	containerNumbers := make(map[uuid.UUID]string)
+	for i := range trip.Stops {
+		stop := &trip.Stops[i]
+		if stop.ContainerID == nil {
+			continue
+		}
+		location, err := s.locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+		if isExportIngate(stop, location) {
+			containerIDs = append(containerIDs, *stop.ContainerID)
+			containerNumbers[*stop.ContainerID] = stop.ContainerNumber
+		}
+	}
+	if len(containerIDs) == 0 {
+		return nil
+	}
+
+	onFile, err := s.containerRepo.GetVGMOnFile(ctx, containerIDs)
+	if err != nil {
+		return apperrors.DatabaseError("get vgm status", err)
+	}
+
+	var missing []string
+	for _, id := range containerIDs {
+		if !onFile[id] {
+			missing = append(missing, containerNumbers[id])
+		}
+	}
+	if len(missing) > 0 {
+		s.logger.Warnw("Export ingate blocked without VGM",
+			"trip_id", trip.ID,
+			"containers", missing,
+		)
+		return apperrors.New("VGM_REQUIRED", "export container has no verified gross mass on file").
+			WithDetail("containers", missing)
+	}
+
+	return nil
+}
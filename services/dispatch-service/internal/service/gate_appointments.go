@@ -0,0 +1,670 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// gateSlotHorizonDays is how far ahead a terminal must have released gate
+	// slots for its stops to need an appointment before dispatch
+	gateSlotHorizonDays = 14
+
+	// maxGateSlotsPerRelease caps the slots one release request can create
+	maxGateSlotsPerRelease = 500
+)
+
+// GateAppointmentService manages terminal gate slot inventory and the
+// appointments booked in it for trip stops
+type GateAppointmentService struct {
+	db              *database.DB
+	appointmentRepo repository.GateAppointmentRepository
+	tripRepo        repository.TripRepository
+	stopRepo        repository.TripStopRepository
+	locationRepo    repository.LocationRepository
+	eventProducer   *kafka.Producer
+	logger          *logger.Logger
+	businessRules   *config.BusinessRules
+
+	warnedMu sync.Mutex
+	warned   map[uuid.UUID]uuid.UUID // Stop to the slot planners were last warned about
+}
+
+// NewGateAppointmentService creates a new gate appointment service
+func NewGateAppointmentService(
+	db *database.DB,
+	appointmentRepo repository.GateAppointmentRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *GateAppointmentService {
+	return &GateAppointmentService{
+		db:              db,
+		appointmentRepo: appointmentRepo,
+		tripRepo:        tripRepo,
+		stopRepo:        stopRepo,
+		locationRepo:    locationRepo,
+		eventProducer:   eventProducer,
+		logger:          log,
+		businessRules:   config.DefaultBusinessRules(),
+		warned:          make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+// =============================================================================
+// SLOT INVENTORY
+// =============================================================================
+
+// ReleaseGateSlotsInput contains input for loading a terminal's gate slots.
+// The period from Start to End is cut into slots of SlotMins.
+type ReleaseGateSlotsInput struct {
+	TerminalID uuid.UUID
+	MoveType   domain.GateMoveType
+	Start      time.Time
+	End        time.Time
+	SlotMins   int
+	Capacity   int // Appointments per slot
+	CreatedBy  string
+}
+
+// ReleaseGateSlots records the gate slots a terminal has released for a move type
+func (s *GateAppointmentService) ReleaseGateSlots(ctx context.Context, input ReleaseGateSlotsInput) ([]domain.GateSlot, error) {
+	switch input.MoveType {
+	case domain.GateMoveImportPickup, domain.GateMoveExportDropoff, domain.GateMoveEmptyReturn, domain.GateMoveEmptyPickup:
+	default:
+		return nil, apperrors.ValidationError("unknown move type", "move_type", input.MoveType)
+	}
+	if input.SlotMins <= 0 {
+		return nil, apperrors.ValidationError("slot length must be positive", "slot_mins", input.SlotMins)
+	}
+	if input.Capacity <= 0 {
+		return nil, apperrors.ValidationError("capacity must be positive", "capacity", input.Capacity)
+	}
+	if !input.End.After(input.Start) {
+		return nil, apperrors.ValidationError("end must be after start", "end", input.End)
+	}
+	length := time.Duration(input.SlotMins) * time.Minute
+	if count := int(input.End.Sub(input.Start) / length); count > maxGateSlotsPerRelease {
+		return nil, apperrors.ValidationError(
+			fmt.Sprintf("release would create %d slots, at most %d are allowed", count, maxGateSlotsPerRelease),
+			"end", input.End)
+	}
+	if _, err := s.terminal(ctx, input.TerminalID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.appointmentRepo.ListSlots(ctx, input.TerminalID, input.MoveType, input.Start, input.End)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list gate slots", err)
+	}
+	if len(existing) > 0 {
+		return nil, apperrors.ConflictError(
+			fmt.Sprintf("terminal already has %d %s slots in this period", len(existing), input.MoveType))
+	}
+
+	now := time.Now()
+	var slots []domain.GateSlot
+	for start := input.Start; !start.Add(length).After(input.End); start = start.Add(length) {
+		slot := domain.GateSlot{
+			ID:         uuid.New(),
+			TerminalID: input.TerminalID,
+			MoveType:   input.MoveType,
+			StartTime:  start,
+			EndTime:    start.Add(length),
+			Capacity:   input.Capacity,
+			CreatedBy:  input.CreatedBy,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := s.appointmentRepo.CreateSlot(ctx, &slot); err != nil {
+			return nil, apperrors.DatabaseError("create gate slot", err)
+		}
+		slots = append(slots, slot)
+	}
+
+	s.logger.Infow("Gate slots released",
+		"terminal_id", input.TerminalID,
+		"move_type", input.MoveType,
+		"slots", len(slots),
+		"capacity", input.Capacity,
+		"created_by", input.CreatedBy,
+	)
+	return slots, nil
+}
+
+// ListGateSlots returns a terminal's gate slots starting between from and
+// to, with what is still available in each
+func (s *GateAppointmentService) ListGateSlots(ctx context.Context, terminalID uuid.UUID, moveType domain.GateMoveType, from, to time.Time) ([]domain.GateSlot, error) {
+	slots, err := s.appointmentRepo.ListSlots(ctx, terminalID, moveType, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list gate slots", err)
+	}
+	return slots, nil
+}
+
+// =============================================================================
+// APPOINTMENTS
+// =============================================================================
+
+// BookGateAppointmentInput contains input for booking a stop into a gate slot
+type BookGateAppointmentInput struct {
+	StopID            uuid.UUID
+	SlotID            uuid.UUID
+	AppointmentNumber string // Confirmation number issued by the terminal
+	BookedBy          string
+}
+
+// BookGateAppointment books a place in a gate slot for a terminal stop and
+// copies the window onto the stop. A stop holds one appointment at a time;
+// moving it to another slot is a reschedule.
+func (s *GateAppointmentService) BookGateAppointment(ctx context.Context, input BookGateAppointmentInput) (*domain.GateAppointment, error) {
+	stop, trip, slot, err := s.loadBooking(ctx, input.StopID, input.SlotID, input.AppointmentNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.appointmentRepo.GetActiveByStop(ctx, stop.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate appointment", err)
+	}
+	if current != nil {
+		return nil, apperrors.ConflictError(
+			fmt.Sprintf("stop already has gate appointment %s; reschedule it instead", current.AppointmentNumber))
+	}
+
+	appointment := newGateAppointment(trip, stop, slot, input.AppointmentNumber, input.BookedBy)
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		booked, err := s.appointmentRepo.Book(txCtx, appointment)
+		if err != nil {
+			return apperrors.DatabaseError("book gate appointment", err)
+		}
+		if !booked {
+			return slotFullError(slot)
+		}
+		if trip.DriverID != nil {
+			if err := claimDriverHours(txCtx, s.appointmentRepo, *trip.DriverID, appointment); err != nil {
+				return err
+			}
+		}
+		applyGateAppointment(stop, appointment)
+		if err := s.stopRepo.Update(txCtx, stop); err != nil {
+			return stopUpdateError(ctx, s.stopRepo, stop.ID, "set stop appointment", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishGateAppointment(ctx, kafka.Topics.GateAppointmentBooked, trip, appointment, nil)
+	s.logger.Infow("Gate appointment booked",
+		"appointment_id", appointment.ID,
+		"trip_id", trip.ID,
+		"stop_id", stop.ID,
+		"terminal_id", slot.TerminalID,
+		"window_start", appointment.WindowStart,
+		"booked_by", input.BookedBy,
+	)
+	return appointment, nil
+}
+
+// RescheduleGateAppointmentInput contains input for moving an appointment to another slot
+type RescheduleGateAppointmentInput struct {
+	AppointmentID     uuid.UUID
+	SlotID            uuid.UUID
+	AppointmentNumber string // New confirmation number; defaults to the old one
+	RescheduledBy     string
+}
+
+// RescheduleGateAppointment moves a stop's appointment to another slot. The
+// new place is taken before the old one is given up, so a full slot leaves
+// the original booking in place.
+func (s *GateAppointmentService) RescheduleGateAppointment(ctx context.Context, input RescheduleGateAppointmentInput) (*domain.GateAppointment, error) {
+	old, err := s.activeAppointment(ctx, input.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if old.SlotID == input.SlotID {
+		return nil, apperrors.ValidationError("appointment is already in this slot", "slot_id", input.SlotID)
+	}
+	number := strings.TrimSpace(input.AppointmentNumber)
+	if number == "" {
+		number = old.AppointmentNumber
+	}
+
+	stop, trip, slot, err := s.loadBooking(ctx, old.StopID, input.SlotID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment := newGateAppointment(trip, stop, slot, number, input.RescheduledBy)
+	now := time.Now()
+	old.Status = domain.GateAppointmentRescheduled
+	old.RescheduledToID = &appointment.ID
+	old.CancelledAt = &now
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		booked, err := s.appointmentRepo.Reschedule(txCtx, old, appointment)
+		if err != nil {
+			return apperrors.DatabaseError("reschedule gate appointment", err)
+		}
+		if !booked {
+			return slotFullError(slot)
+		}
+		if err := s.appointmentRepo.ReleaseDriverHours(txCtx, old.ID); err != nil {
+			return apperrors.DatabaseError("release driver hours", err)
+		}
+		if trip.DriverID != nil {
+			if err := claimDriverHours(txCtx, s.appointmentRepo, *trip.DriverID, appointment); err != nil {
+				return err
+			}
+		}
+		applyGateAppointment(stop, appointment)
+		if err := s.stopRepo.Update(txCtx, stop); err != nil {
+			return stopUpdateError(ctx, s.stopRepo, stop.ID, "set stop appointment", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishGateAppointment(ctx, kafka.Topics.GateAppointmentRescheduled, trip, appointment, map[string]interface{}{
+		"previous_appointment_id": old.ID.String(),
+		"previous_window_start":   old.WindowStart,
+	})
+	s.logger.Infow("Gate appointment rescheduled",
+		"appointment_id", appointment.ID,
+		"previous_id", old.ID,
+		"trip_id", trip.ID,
+		"window_start", appointment.WindowStart,
+		"rescheduled_by", input.RescheduledBy,
+	)
+	return appointment, nil
+}
+
+// CancelGateAppointment gives up a stop's appointment and clears the window
+// from the stop
+func (s *GateAppointmentService) CancelGateAppointment(ctx context.Context, appointmentID uuid.UUID, reason, cancelledBy string) (*domain.GateAppointment, error) {
+	appointment, err := s.activeAppointment(ctx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := s.stopRepo.GetByID(ctx, appointment.StopID)
+	if err != nil || stop == nil {
+		return nil, apperrors.NotFoundError("stop", appointment.StopID.String())
+	}
+	trip, err := s.tripRepo.GetByID(ctx, appointment.TripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", appointment.TripID.String())
+	}
+
+	now := time.Now()
+	appointment.Status = domain.GateAppointmentCancelled
+	appointment.CancelReason = strings.TrimSpace(reason)
+	appointment.CancelledAt = &now
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		if err := s.appointmentRepo.Cancel(txCtx, appointment); err != nil {
+			return apperrors.DatabaseError("cancel gate appointment", err)
+		}
+		if err := s.appointmentRepo.ReleaseDriverHours(txCtx, appointment.ID); err != nil {
+			return apperrors.DatabaseError("release driver hours", err)
+		}
+		if stop.AppointmentNumber == appointment.AppointmentNumber {
+			stop.AppointmentTime = nil
+			stop.AppointmentNumber = ""
+			stop.AppointmentWindowMins = 0
+			if err := s.stopRepo.Update(txCtx, stop); err != nil {
+				return stopUpdateError(ctx, s.stopRepo, stop.ID, "clear stop appointment", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishGateAppointment(ctx, kafka.Topics.GateAppointmentCancelled, trip, appointment, map[string]interface{}{
+		"reason": appointment.CancelReason,
+	})
+	s.logger.Infow("Gate appointment cancelled",
+		"appointment_id", appointment.ID,
+		"trip_id", trip.ID,
+		"reason", appointment.CancelReason,
+		"cancelled_by", cancelledBy,
+	)
+	return appointment, nil
+}
+
+// GetTripGateAppointments returns the appointments booked for a trip's stops
+func (s *GateAppointmentService) GetTripGateAppointments(ctx context.Context, tripID uuid.UUID) ([]domain.GateAppointment, error) {
+	appointments, err := s.appointmentRepo.GetActiveByTrip(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate appointments", err)
+	}
+	return appointments, nil
+}
+
+// loadBooking checks that a stop can be booked into a slot: the stop is
+// still ahead of the driver at the slot's terminal and needs the slot's
+// move, the slot has not closed and has room, and its window keeps the
+// trip's other appointments in stop order
+func (s *GateAppointmentService) loadBooking(ctx context.Context, stopID, slotID uuid.UUID, appointmentNumber string) (*domain.TripStop, *domain.Trip, *domain.GateSlot, error) {
+	if strings.TrimSpace(appointmentNumber) == "" {
+		return nil, nil, nil, apperrors.ValidationError("terminal appointment number is required", "appointment_number", appointmentNumber)
+	}
+
+	stop, err := s.stopRepo.GetByID(ctx, stopID)
+	if err != nil || stop == nil {
+		return nil, nil, nil, apperrors.NotFoundError("stop", stopID.String())
+	}
+	if stop.Status != domain.StopStatusPending && stop.Status != domain.StopStatusEnRoute {
+		return nil, nil, nil, apperrors.InvalidStateError(string(stop.Status), string(domain.StopStatusPending))
+	}
+	trip, err := s.tripRepo.GetByID(ctx, stop.TripID)
+	if err != nil || trip == nil {
+		return nil, nil, nil, apperrors.NotFoundError("trip", stop.TripID.String())
+	}
+	switch trip.Status {
+	case domain.TripStatusCompleted, domain.TripStatusCancelled, domain.TripStatusFailed:
+		return nil, nil, nil, apperrors.InvalidStateError(string(trip.Status), "open trip")
+	}
+
+	slot, err := s.appointmentRepo.GetSlot(ctx, slotID)
+	if err != nil {
+		return nil, nil, nil, apperrors.DatabaseError("get gate slot", err)
+	}
+	if slot == nil {
+		return nil, nil, nil, apperrors.NotFoundError("gate slot", slotID.String())
+	}
+	if slot.TerminalID != stop.LocationID {
+		return nil, nil, nil, apperrors.ValidationError("slot is at a different terminal than the stop", "slot_id", slotID)
+	}
+	move := domain.GateMoveForActivity(stop.Activity)
+	if move == "" {
+		return nil, nil, nil, apperrors.ValidationError("stop activity does not need a gate appointment", "stop_id", stopID)
+	}
+	if slot.MoveType != move {
+		return nil, nil, nil, apperrors.ValidationError(
+			fmt.Sprintf("slot is for %s but the stop needs %s", slot.MoveType, move), "slot_id", slotID)
+	}
+	if !slot.EndTime.After(time.Now()) {
+		return nil, nil, nil, apperrors.ValidationError("slot has already closed", "slot_id", slotID)
+	}
+	if slot.Available() == 0 {
+		return nil, nil, nil, slotFullError(slot)
+	}
+
+	if err := s.checkStopOrder(ctx, stop, slot); err != nil {
+		return nil, nil, nil, err
+	}
+	return stop, trip, slot, nil
+}
+
+// checkStopOrder refuses a window that would have the driver at this stop
+// before an earlier stop's appointment or after a later one's
+func (s *GateAppointmentService) checkStopOrder(ctx context.Context, stop *domain.TripStop, slot *domain.GateSlot) error {
+	others, err := s.appointmentRepo.GetActiveByTrip(ctx, stop.TripID)
+	if err != nil {
+		return apperrors.DatabaseError("get gate appointments", err)
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, stop.TripID)
+	if err != nil {
+		return apperrors.DatabaseError("get trip stops", err)
+	}
+	sequence := make(map[uuid.UUID]int, len(stops))
+	for _, st := range stops {
+		sequence[st.ID] = st.Sequence
+	}
+
+	for _, other := range others {
+		if other.StopID == stop.ID {
+			continue
+		}
+		seq := sequence[other.StopID]
+		if (seq < stop.Sequence && !other.WindowStart.Before(slot.EndTime)) ||
+			(seq > stop.Sequence && !other.WindowEnd.After(slot.StartTime)) {
+			return apperrors.New("GATE_WINDOW_CONFLICT", "slot is out of order with another appointment on the trip").
+				WithDetail("appointment_number", other.AppointmentNumber).
+				WithDetail("stop_sequence", seq).
+				WithDetail("window_start", other.WindowStart)
+		}
+	}
+	return nil
+}
+
+// activeAppointment loads an appointment that still holds its slot
+func (s *GateAppointmentService) activeAppointment(ctx context.Context, id uuid.UUID) (*domain.GateAppointment, error) {
+	appointment, err := s.appointmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate appointment", err)
+	}
+	if appointment == nil {
+		return nil, apperrors.NotFoundError("gate appointment", id.String())
+	}
+	if !appointment.IsActive() {
+		return nil, apperrors.InvalidStateError(string(appointment.Status), string(domain.GateAppointmentBooked))
+	}
+	return appointment, nil
+}
+
+func (s *GateAppointmentService) terminal(ctx context.Context, terminalID uuid.UUID) (*domain.Location, error) {
+	location, err := s.locationRepo.GetByID(ctx, terminalID)
+	if err != nil || location == nil {
+		return nil, apperrors.NotFoundError("terminal", terminalID.String())
+	}
+	if !strings.EqualFold(location.Type, "terminal") {
+		return nil, apperrors.ValidationError("location is not a port terminal", "terminal_id", terminalID)
+	}
+	return location, nil
+}
+
+func (s *GateAppointmentService) publishGateAppointment(ctx context.Context, topic string, trip *domain.Trip, appointment *domain.GateAppointment, extra map[string]interface{}) {
+	data := map[string]interface{}{
+		"appointment_id":     appointment.ID.String(),
+		"appointment_number": appointment.AppointmentNumber,
+		"trip_id":            trip.ID.String(),
+		"trip_number":        trip.TripNumber,
+		"stop_id":            appointment.StopID.String(),
+		"terminal_id":        appointment.TerminalID.String(),
+		"move_type":          appointment.MoveType,
+		"container_number":   appointment.ContainerNumber,
+		"window_start":       appointment.WindowStart,
+		"window_end":         appointment.WindowEnd,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	event := kafka.NewEvent(topic, "dispatch-service", data).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, topic, event)
+}
+
+func newGateAppointment(trip *domain.Trip, stop *domain.TripStop, slot *domain.GateSlot, number, bookedBy string) *domain.GateAppointment {
+	return &domain.GateAppointment{
+		ID:                uuid.New(),
+		SlotID:            slot.ID,
+		TerminalID:        slot.TerminalID,
+		MoveType:          slot.MoveType,
+		TripID:            trip.ID,
+		StopID:            stop.ID,
+		ContainerNumber:   stop.ContainerNumber,
+		AppointmentNumber: strings.TrimSpace(number),
+		WindowStart:       slot.StartTime,
+		WindowEnd:         slot.EndTime,
+		Status:            domain.GateAppointmentBooked,
+		BookedBy:          bookedBy,
+		BookedAt:          time.Now(),
+	}
+}
+
+// applyGateAppointment copies an appointment onto its stop, where the
+// driver app, SMS dispatch and detention read it
+func applyGateAppointment(stop *domain.TripStop, appointment *domain.GateAppointment) {
+	start := appointment.WindowStart
+	stop.AppointmentTime = &start
+	stop.AppointmentNumber = appointment.AppointmentNumber
+	stop.AppointmentWindowMins = int(appointment.WindowEnd.Sub(appointment.WindowStart).Minutes())
+}
+
+// claimDriverHours holds the appointment's hours for the driver, failing when
+// the driver is already booked at another terminal in one of them
+func claimDriverHours(ctx context.Context, appointmentRepo repository.GateAppointmentRepository, driverID uuid.UUID, appointment *domain.GateAppointment) error {
+	conflict, err := appointmentRepo.ClaimDriverHours(ctx, appointment.ID, domain.DriverHourClaims(driverID, appointment))
+	if err != nil {
+		return apperrors.DatabaseError("claim driver hours", err)
+	}
+	if conflict != nil {
+		return apperrors.New("DRIVER_HOUR_CONFLICT", "driver is already booked at another terminal in this hour").
+			WithDetail("driver_id", driverID.String()).
+			WithDetail("hour", conflict.HourStart).
+			WithDetail("terminal_id", conflict.TerminalID.String()).
+			WithDetail("trip_id", conflict.TripID.String())
+	}
+	return nil
+}
+
+func slotFullError(slot *domain.GateSlot) error {
+	return apperrors.New("SLOT_FULL", "gate slot is fully booked").
+		WithDetail("slot_id", slot.ID.String()).
+		WithDetail("start_time", slot.StartTime)
+}
+
+// releaseGateAppointments cancels every appointment still held by a trip's
+// stops, giving the places back to their slots
+func releaseGateAppointments(ctx context.Context, appointmentRepo repository.GateAppointmentRepository, producer *kafka.Producer, trip *domain.Trip, reason string, now time.Time) error {
+	appointments, err := appointmentRepo.GetActiveByTrip(ctx, trip.ID)
+	if err != nil {
+		return apperrors.DatabaseError("get gate appointments", err)
+	}
+	for i := range appointments {
+		appointment := &appointments[i]
+		appointment.Status = domain.GateAppointmentCancelled
+		appointment.CancelReason = reason
+		appointment.CancelledAt = &now
+		if err := appointmentRepo.Cancel(ctx, appointment); err != nil {
+			return apperrors.DatabaseError("cancel gate appointment", err)
+		}
+		if err := appointmentRepo.ReleaseDriverHours(ctx, appointment.ID); err != nil {
+			return apperrors.DatabaseError("release driver hours", err)
+		}
+		event := kafka.NewEvent(kafka.Topics.GateAppointmentCancelled, "dispatch-service", map[string]interface{}{
+			"appointment_id":     appointment.ID.String(),
+			"appointment_number": appointment.AppointmentNumber,
+			"trip_id":            trip.ID.String(),
+			"trip_number":        trip.TripNumber,
+			"stop_id":            appointment.StopID.String(),
+			"terminal_id":        appointment.TerminalID.String(),
+			"reason":             reason,
+		})
+		_ = producer.Publish(ctx, kafka.Topics.GateAppointmentCancelled, event)
+	}
+	return nil
+}
+
+// =============================================================================
+// DISPATCH CHECK
+// =============================================================================
+
+// checkGateAppointments blocks trips whose terminal stops are not booked into
+// a gate slot, or whose booked windows the driver can no longer make. Only
+// terminals that release slots through DrayMaster require appointments.
+func (s *DispatchService) checkGateAppointments(ctx context.Context, trip *domain.Trip) error {
+	now := time.Now()
+	horizon := now.AddDate(0, 0, gateSlotHorizonDays)
+	requires := make(map[uuid.UUID]bool)
+
+	var missing, outside []string
+	for i := range trip.Stops {
+		stop := &trip.Stops[i]
+		if stop.Status != domain.StopStatusPending && stop.Status != domain.StopStatusEnRoute {
+			continue
+		}
+		move := domain.GateMoveForActivity(stop.Activity)
+		if move == "" {
+			continue
+		}
+
+		required, seen := requires[stop.LocationID]
+		if !seen {
+			location, err := s.locationRepo.GetByID(ctx, stop.LocationID)
+			if err != nil {
+				return apperrors.NotFoundError("location", stop.LocationID.String())
+			}
+			if location != nil && strings.EqualFold(location.Type, "terminal") {
+				if required, err = s.appointmentRepo.HasSlots(ctx, stop.LocationID, now, horizon); err != nil {
+					return apperrors.DatabaseError("get gate slots", err)
+				}
+			}
+			requires[stop.LocationID] = required
+		}
+		if !required {
+			continue
+		}
+
+		label := fmt.Sprintf("stop %d (%s)", stop.Sequence, move)
+		appointment, err := s.appointmentRepo.GetActiveByStop(ctx, stop.ID)
+		if err != nil {
+			return apperrors.DatabaseError("get gate appointment", err)
+		}
+		if appointment == nil {
+			missing = append(missing, label)
+			continue
+		}
+
+		arrival := stop.EstimatedArrival
+		if arrival == nil {
+			arrival = stop.PlannedArrival
+		}
+		switch {
+		case appointment.WindowEnd.Before(now):
+			outside = append(outside, fmt.Sprintf("%s: window %s closed", label, appointment.WindowStart.Format("01/02 15:04")))
+		case arrival != nil && !appointment.Covers(*arrival):
+			outside = append(outside, fmt.Sprintf("%s: arrival %s is outside window %s-%s", label,
+				arrival.Format("01/02 15:04"), appointment.WindowStart.Format("01/02 15:04"), appointment.WindowEnd.Format("15:04")))
+		}
+	}
+
+	if len(missing) > 0 {
+		s.logger.Warnw("Dispatch blocked without gate appointments",
+			"trip_id", trip.ID,
+			"stops", missing,
+		)
+		return apperrors.New("GATE_APPOINTMENT_REQUIRED", "terminal stops need a gate appointment before dispatch").
+			WithDetail("stops", missing)
+	}
+	if len(outside) > 0 {
+		s.logger.Warnw("Dispatch blocked outside gate appointment windows",
+			"trip_id", trip.ID,
+			"stops", outside,
+		)
+		return apperrors.New("GATE_WINDOW_CONFLICT", "trip cannot make its gate appointment windows; reschedule them").
+			WithDetail("stops", outside)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// gateDemandWarnStatuses are the trips planners can still book appointments for
+var gateDemandWarnStatuses = []domain.TripStatus{
+	domain.TripStatusDraft,
+	domain.TripStatusPlanned,
+	domain.TripStatusAssigned,
+}
+
+// GateDemandForecast is the predicted demand for a terminal's upcoming gate
+// slots and the recurring windows it is based on
+type GateDemandForecast struct {
+	TerminalID uuid.UUID                 `json:"terminal_id"`
+	Timezone   string                    `json:"timezone"`
+	Windows    []domain.GateDemandWindow `json:"windows"`
+	Slots      []domain.GateSlotForecast `json:"slots"`
+}
+
+// ForecastGateDemand predicts which of a terminal's slots starting between
+// from and to will sell out, from how the same weekday and hour filled over
+// the lookback period
+func (s *GateAppointmentService) ForecastGateDemand(ctx context.Context, terminalID uuid.UUID, moveType domain.GateMoveType, from, to time.Time) (*GateDemandForecast, error) {
+	model, err := s.demandModel(ctx, terminalID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.appointmentRepo.ListSlots(ctx, terminalID, moveType, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list gate slots", err)
+	}
+
+	rules := &s.businessRules.GateDemand
+	forecast := &GateDemandForecast{
+		TerminalID: terminalID,
+		Timezone:   rules.Timezone,
+		Windows:    model.Windows(),
+		Slots:      make([]domain.GateSlotForecast, 0, len(slots)),
+	}
+	bookEarly := time.Duration(rules.BookEarlyHours) * time.Hour
+	for _, slot := range slots {
+		forecast.Slots = append(forecast.Slots, model.Forecast(slot, bookEarly))
+	}
+	return forecast, nil
+}
+
+// WarnHighDemandWindows warns planners about terminal stops planned into
+// high-demand windows that are not booked yet, once the slot's book-by time
+// has come. Each stop is warned once per slot.
+func (s *GateAppointmentService) WarnHighDemandWindows(ctx context.Context, now time.Time) (int, error) {
+	rules := &s.businessRules.GateDemand
+	horizon := now.Add(time.Duration(rules.WarnHorizonHours) * time.Hour)
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		Status:        gateDemandWarnStatuses,
+		PlannedBefore: &horizon,
+		PageSize:      500,
+	})
+	if err != nil {
+		return 0, apperrors.DatabaseError("list trips", err)
+	}
+	if len(trips) == 0 {
+		return 0, nil
+	}
+
+	tripIDs := make([]uuid.UUID, len(trips))
+	byID := make(map[uuid.UUID]*domain.Trip, len(trips))
+	for i := range trips {
+		tripIDs[i] = trips[i].ID
+		byID[trips[i].ID] = &trips[i]
+	}
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return 0, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	models := make(map[uuid.UUID]*domain.GateDemandModel)
+	bookEarly := time.Duration(rules.BookEarlyHours) * time.Hour
+	warned := 0
+	for i := range stops {
+		stop := &stops[i]
+		trip := byID[stop.TripID]
+		move := domain.GateMoveForActivity(stop.Activity)
+		if trip == nil || move == "" || stop.Status != domain.StopStatusPending {
+			continue
+		}
+		planned := stop.PlannedArrival
+		if planned == nil {
+			planned = trip.PlannedStartTime
+		}
+		if planned == nil || planned.Before(now) || planned.After(horizon) {
+			continue
+		}
+
+		slot, err := s.slotAt(ctx, stop.LocationID, move, *planned)
+		if err != nil || slot == nil {
+			continue
+		}
+		model := models[stop.LocationID]
+		if model == nil {
+			if model, err = s.demandModel(ctx, stop.LocationID, now); err != nil {
+				return warned, err
+			}
+			models[stop.LocationID] = model
+		}
+		forecast := model.Forecast(*slot, bookEarly)
+		if !forecast.HighDemand || forecast.BookBy == nil || now.Before(*forecast.BookBy) {
+			continue
+		}
+		current, err := s.appointmentRepo.GetActiveByStop(ctx, stop.ID)
+		if err != nil || current != nil {
+			continue
+		}
+		if s.alreadyWarned(stop.ID, slot.ID) {
+			continue
+		}
+
+		event := kafka.NewEvent(kafka.Topics.GateSlotHighDemand, "dispatch-service", map[string]interface{}{
+			"trip_id":              trip.ID.String(),
+			"trip_number":          trip.TripNumber,
+			"stop_id":              stop.ID.String(),
+			"terminal_id":          stop.LocationID.String(),
+			"move_type":            move,
+			"container_number":     stop.ContainerNumber,
+			"slot_id":              slot.ID.String(),
+			"slot_start":           slot.StartTime,
+			"available":            slot.Available(),
+			"sell_out_likelihood":  forecast.SellOutLikelihood,
+			"expected_sell_out_at": forecast.ExpectedSellOutAt,
+			"book_by":              forecast.BookBy,
+		}).WithTags(trip.Tags)
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.GateSlotHighDemand, event)
+		warned++
+
+		s.logger.Infow("Planners warned to book high-demand gate window",
+			"trip_id", trip.ID,
+			"stop_id", stop.ID,
+			"slot_id", slot.ID,
+			"book_by", forecast.BookBy,
+		)
+	}
+	return warned, nil
+}
+
+// Start checks for unbooked stops in high-demand windows every interval
+// until ctx is cancelled
+func (s *GateAppointmentService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.WarnHighDemandWindows(ctx, now); err != nil {
+				s.logger.Errorw("Gate demand warnings failed", "error", err)
+			}
+		}
+	}
+}
+
+// demandModel builds a terminal's demand history over the lookback period
+func (s *GateAppointmentService) demandModel(ctx context.Context, terminalID uuid.UUID, now time.Time) (*domain.GateDemandModel, error) {
+	rules := &s.businessRules.GateDemand
+	usage, err := s.appointmentRepo.GetSlotUsage(ctx, terminalID, now.AddDate(0, 0, -rules.LookbackDays), now)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate slot usage", err)
+	}
+	loc, err := time.LoadLocation(rules.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return domain.NewGateDemandModel(usage, loc, rules.MinSamples, rules.HighDemandRate), nil
+}
+
+// slotAt returns the terminal's slot for the move covering t, or nil
+func (s *GateAppointmentService) slotAt(ctx context.Context, terminalID uuid.UUID, move domain.GateMoveType, t time.Time) (*domain.GateSlot, error) {
+	slots, err := s.appointmentRepo.ListSlots(ctx, terminalID, move, t.Add(-24*time.Hour), t.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		if !t.Before(slots[i].StartTime) && t.Before(slots[i].EndTime) {
+			return &slots[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// alreadyWarned records a warning for the stop and slot, reporting whether
+// one was already sent
+func (s *GateAppointmentService) alreadyWarned(stopID, slotID uuid.UUID) bool {
+	s.warnedMu.Lock()
+	defer s.warnedMu.Unlock()
+	if s.warned[stopID] == slotID {
+		return true
+	}
+	s.warned[stopID] = slotID
+	return false
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// geofencePayload is the data of a tracking.geofence.entered or
+// tracking.geofence.exited event
+type geofencePayload struct {
+	GeofenceID string  `json:"geofence_id"`
+	LocationID string  `json:"location_id"`
+	DriverID   string  `json:"driver_id"`
+	TripID     string  `json:"trip_id"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+}
+
+// HandleGeofenceEntered is a kafka.Handler for trucks entering a location's
+// geofence. When the location is the next stop on the driver's trip, the
+// arrival is recorded at the time of entry, starting the free time clock
+// without waiting for the driver to report in.
+func (s *DispatchService) HandleGeofenceEntered(ctx context.Context, event *kafka.Event) error {
+	payload, err := decodeGeofencePayload(event)
+	if err != nil {
+		return err
+	}
+	trip, stop, err := s.geofenceStop(ctx, payload, true)
+	if err != nil || stop == nil {
+		return err
+	}
+
+	at := geofenceEventTime(event)
+	if _, err := s.recordStopArrival(ctx, trip.ID, stop.ID, at, payload.Latitude, payload.Longitude, domain.StopArrivalSourceGeofence); err != nil {
+		return fmt.Errorf("record geofence arrival: %w", err)
+	}
+
+	s.logger.Infow("Stop arrival recorded from geofence",
+		"trip_id", trip.ID,
+		"stop_id", stop.ID,
+		"location_id", stop.LocationID,
+		"arrived_at", at,
+	)
+	return nil
+}
+
+// HandleGeofenceExited is a kafka.Handler for trucks leaving a location's
+// geofence. The exit stops the free time clock at the stop the driver was
+// on site at; the stop stays open for the driver to complete, and its
+// departure is taken from the exit when that is earlier.
+func (s *DispatchService) HandleGeofenceExited(ctx context.Context, event *kafka.Event) error {
+	payload, err := decodeGeofencePayload(event)
+	if err != nil {
+		return err
+	}
+	trip, stop, err := s.geofenceStop(ctx, payload, false)
+	if err != nil || stop == nil {
+		return err
+	}
+
+	at := geofenceEventTime(event)
+	if at.Before(*stop.ActualArrival) {
+		return nil
+	}
+	stop.GeofenceExitedAt = &at
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		return apperrors.DatabaseError("record geofence exit", err)
+	}
+
+	s.logger.Infow("Stop geofence exit recorded",
+		"trip_id", trip.ID,
+		"stop_id", stop.ID,
+		"location_id", stop.LocationID,
+		"exited_at", at,
+		"dwell_mins", int(at.Sub(*stop.ActualArrival).Minutes()),
+	)
+	return nil
+}
+
+func decodeGeofencePayload(event *kafka.Event) (*geofencePayload, error) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload geofencePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("unmarshal geofence event: %w", err)
+	}
+	return &payload, nil
+}
+
+// geofenceEventTime is when the truck crossed the geofence
+func geofenceEventTime(event *kafka.Event) time.Time {
+	if event.Time.IsZero() {
+		return time.Now()
+	}
+	return event.Time
+}
+
+// geofenceStop finds the stop a geofence crossing belongs to, on the trip in
+// the event or else the driver's active trips. On entry it is the trip's
+// next open stop when that is at the location, so driving past a later
+// stop's location does not start its clock. On exit it is the stop at the
+// location the driver is still on site at. It returns nil when the crossing
+// matches no stop.
+func (s *DispatchService) geofenceStop(ctx context.Context, payload *geofencePayload, entering bool) (*domain.Trip, *domain.TripStop, error) {
+	locationID, err := uuid.Parse(payload.LocationID)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	var trips []domain.Trip
+	if tripID, err := uuid.Parse(payload.TripID); err == nil {
+		trip, err := s.tripRepo.GetByID(ctx, tripID)
+		if err != nil || trip == nil {
+			return nil, nil, nil
+		}
+		trips = append(trips, *trip)
+	} else {
+		driverID, err := uuid.Parse(payload.DriverID)
+		if err != nil {
+			return nil, nil, nil
+		}
+		trips, _, err = s.tripRepo.List(ctx, repository.TripFilter{
+			Status:   syncTripStatuses,
+			DriverID: &driverID,
+			PageSize: 20,
+		})
+		if err != nil {
+			return nil, nil, apperrors.DatabaseError("list driver trips", err)
+		}
+	}
+
+	for i := range trips {
+		trip := &trips[i]
+		stops, err := s.stopRepo.GetByTripID(ctx, trip.ID)
+		if err != nil {
+			return nil, nil, apperrors.DatabaseError("get trip stops", err)
+		}
+		if stop := geofenceTripStop(stops, locationID, entering); stop != nil {
+			return trip, stop, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// geofenceTripStop picks the stop of one trip a crossing at the location
+// belongs to, or nil
+func geofenceTripStop(stops []domain.TripStop, locationID uuid.UUID, entering bool) *domain.TripStop {
+	var match *domain.TripStop
+	for i := range stops {
+		stop := &stops[i]
+		if stop.Type == domain.StopTypeWaypoint {
+			continue
+		}
+		if entering {
+			if stop.Status != domain.StopStatusPending && stop.Status != domain.StopStatusEnRoute {
+				continue
+			}
+			if match == nil || stop.Sequence < match.Sequence {
+				match = stop
+			}
+			continue
+		}
+		if stop.LocationID != locationID || stop.ActualArrival == nil || stop.GeofenceExitedAt != nil {
+			continue
+		}
+		if stop.Status == domain.StopStatusArrived || stop.Status == domain.StopStatusInProgress {
+			return stop
+		}
+	}
+	if match == nil || match.LocationID != locationID {
+		return nil
+	}
+	return match
+}
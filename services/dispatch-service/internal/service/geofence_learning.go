@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxFixesPerLocation bounds the positions clustered for one location to the most recent
+const maxFixesPerLocation = 1000
+
+// clusterCoreNeighbors is how many other positions must lie within the
+// cluster radius of a position for the cluster to grow through it
+const clusterCoreNeighbors = 2
+
+// GeofenceLearningService learns where customer locations really are from
+// the positions drivers reported arriving at and completing their stops.
+// Each run clusters every location's positions, and where the main cluster
+// sits away from the location's coordinates or spreads beyond its geofence,
+// proposes corrected coordinates and a circular geofence radius for an admin
+// to accept or reject in bulk. Accepted geofences are applied by the
+// tracking service.
+type GeofenceLearningService struct {
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	proposalRepo  repository.LocationProposalRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewGeofenceLearningService creates a new geofence learning service
+func NewGeofenceLearningService(
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	proposalRepo repository.LocationProposalRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *GeofenceLearningService {
+	return &GeofenceLearningService{
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		proposalRepo:  proposalRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// Learn clusters the stop positions of the lookback period and saves a
+// proposal for each location whose coordinates or geofence they disagree
+// with. It returns how many proposals were saved.
+func (s *GeofenceLearningService) Learn(ctx context.Context, now time.Time) (int, error) {
+	rules := &s.businessRules.GeofenceLearning
+	fixes, err := s.stopRepo.GetFixesSince(ctx, now.AddDate(0, 0, -rules.LookbackDays))
+	if err != nil {
+		return 0, apperrors.DatabaseError("get stop positions", err)
+	}
+
+	byLocation := make(map[uuid.UUID][]domain.StopFix)
+	for _, fix := range fixes {
+		byLocation[fix.LocationID] = append(byLocation[fix.LocationID], fix)
+	}
+
+	proposed := 0
+	for locationID, locationFixes := range byLocation {
+		if len(locationFixes) < rules.MinFixes {
+			continue
+		}
+		location, err := s.locationRepo.GetByID(ctx, locationID)
+		if err != nil || location == nil {
+			continue
+		}
+
+		proposal := s.propose(location, locationFixes, now)
+		if proposal == nil {
+			continue
+		}
+		if err := s.proposalRepo.SavePending(ctx, proposal); err != nil {
+			return proposed, apperrors.DatabaseError("save location proposal", err)
+		}
+		proposed++
+	}
+
+	s.logger.Infow("Location geofences learned",
+		"locations", len(byLocation),
+		"fixes", len(fixes),
+		"proposals", proposed,
+	)
+	return proposed, nil
+}
+
+// Start learns from stop positions every interval until ctx is cancelled
+func (s *GeofenceLearningService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.Learn(ctx, now); err != nil {
+				s.logger.Errorw("Geofence learning failed", "error", err)
+			}
+		}
+	}
+}
+
+// ListProposals returns the proposals with a status, PENDING when none is given
+func (s *GeofenceLearningService) ListProposals(ctx context.Context, status domain.LocationProposalStatus) ([]domain.LocationProposal, error) {
+	if status == "" {
+		status = domain.LocationProposalPending
+	}
+	proposals, err := s.proposalRepo.List(ctx, status)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list location proposals", err)
+	}
+	return proposals, nil
+}
+
+// AcceptProposals moves each location to its proposed coordinates and
+// publishes its proposed geofence for the tracking service to apply.
+// Proposals for locations edited since they were drawn are refused.
+func (s *GeofenceLearningService) AcceptProposals(ctx context.Context, ids []uuid.UUID, user string) (*domain.LocationProposalReview, error) {
+	return s.review(ctx, ids, user, func(proposal *domain.LocationProposal) error {
+		location, err := s.locationRepo.GetByID(ctx, proposal.LocationID)
+		if err != nil || location == nil {
+			return fmt.Errorf("location not found")
+		}
+		if location.Latitude != proposal.CurrentLatitude || location.Longitude != proposal.CurrentLongitude {
+			return fmt.Errorf("location was moved after the proposal was drawn")
+		}
+
+		if proposal.ProposedLatitude != proposal.CurrentLatitude || proposal.ProposedLongitude != proposal.CurrentLongitude {
+			if err := s.locationRepo.UpdateCoordinates(ctx, location.ID, proposal.ProposedLatitude, proposal.ProposedLongitude); err != nil {
+				return fmt.Errorf("update location coordinates: %w", err)
+			}
+		}
+		if proposal.ProposedRadiusMeters > 0 {
+			event := kafka.NewEvent(kafka.Topics.LocationGeofenceLearned, "dispatch-service", map[string]interface{}{
+				"proposal_id":   proposal.ID.String(),
+				"location_id":   location.ID.String(),
+				"location_name": location.Name,
+				"latitude":      proposal.ProposedLatitude,
+				"longitude":     proposal.ProposedLongitude,
+				"radius_meters": proposal.ProposedRadiusMeters,
+				"accepted_by":   user,
+			})
+			if err := s.eventProducer.Publish(ctx, kafka.Topics.LocationGeofenceLearned, event); err != nil {
+				return fmt.Errorf("publish learned geofence: %w", err)
+			}
+		}
+		proposal.Status = domain.LocationProposalAccepted
+		return nil
+	})
+}
+
+// RejectProposals closes proposals without applying them. A later run may
+// propose the location again if its stops keep disagreeing with it.
+func (s *GeofenceLearningService) RejectProposals(ctx context.Context, ids []uuid.UUID, user string) (*domain.LocationProposalReview, error) {
+	return s.review(ctx, ids, user, func(proposal *domain.LocationProposal) error {
+		proposal.Status = domain.LocationProposalRejected
+		return nil
+	})
+}
+
+// review applies a decision to each pending proposal, collecting failures
+// rather than stopping at the first
+func (s *GeofenceLearningService) review(ctx context.Context, ids []uuid.UUID, user string, decide func(*domain.LocationProposal) error) (*domain.LocationProposalReview, error) {
+	if len(ids) == 0 {
+		return nil, apperrors.ValidationError("at least one proposal is required", "ids", nil)
+	}
+
+	result := &domain.LocationProposalReview{Applied: []uuid.UUID{}, Failed: make(map[uuid.UUID]string)}
+	for _, id := range ids {
+		proposal, err := s.proposalRepo.GetByID(ctx, id)
+		if err != nil {
+			return result, apperrors.DatabaseError("get location proposal", err)
+		}
+		if proposal == nil {
+			result.Failed[id] = "not found"
+			continue
+		}
+		if proposal.Status != domain.LocationProposalPending {
+			result.Failed[id] = "already " + string(proposal.Status)
+			continue
+		}
+		if err := decide(proposal); err != nil {
+			result.Failed[id] = err.Error()
+			continue
+		}
+
+		now := time.Now()
+		proposal.ReviewedBy = user
+		proposal.ReviewedAt = &now
+		proposal.UpdatedAt = now
+		if err := s.proposalRepo.Update(ctx, proposal); err != nil {
+			return result, apperrors.DatabaseError("update location proposal", err)
+		}
+		result.Applied = append(result.Applied, id)
+	}
+
+	s.logger.Infow("Location proposals reviewed",
+		"reviewed_by", user,
+		"applied", len(result.Applied),
+		"failed", len(result.Failed),
+	)
+	return result, nil
+}
+
+// propose draws a proposal for a location from its stop positions, or
+// returns nil when the positions are too scattered to trust or agree with
+// the location as it is
+func (s *GeofenceLearningService) propose(location *domain.Location, fixes []domain.StopFix, now time.Time) *domain.LocationProposal {
+	rules := &s.businessRules.GeofenceLearning
+	if len(fixes) > maxFixesPerLocation {
+		sort.Slice(fixes, func(i, j int) bool { return fixes[i].RecordedAt.After(fixes[j].RecordedAt) })
+		fixes = fixes[:maxFixesPerLocation]
+	}
+
+	cluster := s.mainCluster(fixes, rules.ClusterRadiusMeters)
+	share := float64(len(cluster)) / float64(len(fixes))
+	if len(cluster) < rules.MinFixes || share < rules.MinClusterShare {
+		return nil
+	}
+
+	lat, lon := medianCenter(cluster)
+	distances := make([]float64, len(cluster))
+	for i, fix := range cluster {
+		distances[i] = haversineMiles(lat, lon, fix.Latitude, fix.Longitude) * metersPerMile
+	}
+	sort.Float64s(distances)
+	radius := distances[int(math.Ceil(rules.RadiusPercentile*float64(len(distances))))-1] + rules.RadiusBufferMeters
+	radius = math.Round(math.Min(math.Max(radius, rules.MinRadiusMeters), rules.MaxRadiusMeters))
+
+	proposal := &domain.LocationProposal{
+		ID:                  uuid.New(),
+		LocationID:          location.ID,
+		LocationName:        location.Name,
+		CurrentLatitude:     location.Latitude,
+		CurrentLongitude:    location.Longitude,
+		CurrentRadiusMeters: location.GeofenceRadiusMeters,
+		GeofenceID:          location.GeofenceID,
+		ProposedLatitude:    location.Latitude,
+		ProposedLongitude:   location.Longitude,
+		FixCount:            len(fixes),
+		ClusterShare:        math.Round(share*100) / 100,
+		Status:              domain.LocationProposalPending,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	// Coordinates move only when they are missing or the cluster sits clearly elsewhere
+	moved := false
+	shift := 0.0
+	if hasPosition(location.Latitude, location.Longitude) {
+		shift = haversineMiles(location.Latitude, location.Longitude, lat, lon) * metersPerMile
+	}
+	if !hasPosition(location.Latitude, location.Longitude) || shift >= rules.MinShiftMeters {
+		proposal.ProposedLatitude, proposal.ProposedLongitude = lat, lon
+		proposal.ShiftMeters = math.Round(shift)
+		moved = true
+	}
+
+	// A hand-drawn polygon geofence is left alone; a circle is resized when it
+	// is missing or clearly the wrong size, and re-centred when the coordinates move
+	polygon := location.GeofenceID != nil && location.GeofenceRadiusMeters <= 0
+	resized := false
+	if !polygon {
+		current := location.GeofenceRadiusMeters
+		resized = current <= 0 || math.Abs(radius-current)/current >= rules.MinRadiusChange
+		switch {
+		case resized:
+			proposal.ProposedRadiusMeters = radius
+		case moved:
+			proposal.ProposedRadiusMeters = current
+		}
+	}
+
+	if !moved && !resized {
+		return nil
+	}
+	return proposal
+}
+
+// mainCluster returns the largest dense group of positions: starting from
+// the position with the most neighbours within radiusMeters, it grows
+// through every position that itself has enough neighbours
+func (s *GeofenceLearningService) mainCluster(fixes []domain.StopFix, radiusMeters float64) []domain.StopFix {
+	neighbours := make([][]int, len(fixes))
+	for i := range fixes {
+		for j := i + 1; j < len(fixes); j++ {
+			if haversineMiles(fixes[i].Latitude, fixes[i].Longitude, fixes[j].Latitude, fixes[j].Longitude)*metersPerMile <= radiusMeters {
+				neighbours[i] = append(neighbours[i], j)
+				neighbours[j] = append(neighbours[j], i)
+			}
+		}
+	}
+
+	seed := 0
+	for i := range neighbours {
+		if len(neighbours[i]) > len(neighbours[seed]) {
+			seed = i
+		}
+	}
+	if len(fixes) == 0 || len(neighbours[seed]) < clusterCoreNeighbors {
+		return nil
+	}
+
+	inCluster := map[int]bool{seed: true}
+	queue := []int{seed}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if len(neighbours[i]) < clusterCoreNeighbors {
+			continue
+		}
+		for _, j := range neighbours[i] {
+			if !inCluster[j] {
+				inCluster[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	cluster := make([]domain.StopFix, 0, len(inCluster))
+	for i := range fixes {
+		if inCluster[i] {
+			cluster = append(cluster, fixes[i])
+		}
+	}
+	return cluster
+}
+
+// medianCenter returns the median latitude and longitude, which unlike the
+// mean is not pulled toward the cluster's stragglers
+func medianCenter(fixes []domain.StopFix) (float64, float64) {
+	lats := make([]float64, len(fixes))
+	lons := make([]float64, len(fixes))
+	for i, fix := range fixes {
+		lats[i], lons[i] = fix.Latitude, fix.Longitude
+	}
+	return median(lats), median(lons)
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
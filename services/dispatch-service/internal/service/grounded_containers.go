@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// groundedWarningTripStatuses are the trips that have not yet reached the
+// terminal pickup and can still be re-planned around a slow dig-out
+var groundedWarningTripStatuses = []domain.TripStatus{
+	domain.TripStatusPlanned,
+	domain.TripStatusAssigned,
+	domain.TripStatusDispatched,
+	domain.TripStatusEnRoute,
+}
+
+// HandleContainerGrounded is a kafka.Handler for containers the terminal has
+// grounded. A grounded box has to be dug out by a yard machine, so each open
+// trip still headed to pick it up gets an exception warning dispatch to
+// expect a longer terminal turn.
+func (s *DispatchService) HandleContainerGrounded(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload struct {
+		ContainerNumber string `json:"container_number"`
+		TerminalCode    string `json:"terminal_code"`
+		YardBlock       string `json:"yard_block"`
+		YardRow         string `json:"yard_row"`
+		YardBay         string `json:"yard_bay"`
+		YardTier        string `json:"yard_tier"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal container grounded: %w", err)
+	}
+	if payload.ContainerNumber == "" || s.exceptions == nil {
+		return nil
+	}
+
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		Status:          groundedWarningTripStatuses,
+		ContainerNumber: payload.ContainerNumber,
+		PageSize:        100,
+	})
+	if err != nil {
+		return apperrors.DatabaseError("list container trips", err)
+	}
+
+	extraMins := s.businessRules.Time.GroundedPickupExtraMins
+	position := yardPositionLabel(payload.YardBlock, payload.YardRow, payload.YardBay, payload.YardTier)
+	for i := range trips {
+		trip := &trips[i]
+		stop, err := s.groundedPickupStop(ctx, trip.ID, payload.ContainerNumber)
+		if err != nil || stop == nil {
+			continue
+		}
+		if s.hasOpenGroundedException(ctx, trip.ID, stop.ID) {
+			continue
+		}
+
+		description := fmt.Sprintf("%s is grounded at %s", payload.ContainerNumber, payload.TerminalCode)
+		if position != "" {
+			description += " (" + position + ")"
+		}
+		description += fmt.Sprintf(". Expect about %d extra minutes at the terminal while the yard digs it out.", extraMins)
+
+		_, err = s.exceptions.CreateException(ctx, CreateExceptionInput{
+			TripID:             trip.ID,
+			StopID:             &stop.ID,
+			OrderID:            stop.OrderID,
+			ContainerID:        stop.ContainerID,
+			DriverID:           trip.DriverID,
+			Type:               domain.ExceptionTypeContainerGrounded,
+			Title:              fmt.Sprintf("%s grounded for pickup on %s", payload.ContainerNumber, trip.TripNumber),
+			Description:        description,
+			LocationID:         &stop.LocationID,
+			ReportedBy:         "dispatch-service",
+			EstimatedDelayMins: &extraMins,
+			Metadata: map[string]string{
+				"terminal_code": payload.TerminalCode,
+				"yard_block":    payload.YardBlock,
+				"yard_row":      payload.YardRow,
+				"yard_bay":      payload.YardBay,
+				"yard_tier":     payload.YardTier,
+			},
+		})
+		if err != nil {
+			s.logger.Errorw("Failed to raise grounded container exception",
+				"trip_id", trip.ID,
+				"container", payload.ContainerNumber,
+				"error", err,
+			)
+			continue
+		}
+		s.logger.Infow("Warned dispatch about grounded container",
+			"trip_id", trip.ID,
+			"container", payload.ContainerNumber,
+			"terminal", payload.TerminalCode,
+		)
+	}
+	return nil
+}
+
+// groundedPickupStop returns the trip's pending pickup of the container, or
+// nil once the driver has already reached it
+func (s *DispatchService) groundedPickupStop(ctx context.Context, tripID uuid.UUID, containerNumber string) (*domain.TripStop, error) {
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stops {
+		stop := &stops[i]
+		if stop.Type != domain.StopTypePickup || !strings.EqualFold(stop.ContainerNumber, containerNumber) {
+			continue
+		}
+		if stop.Status == domain.StopStatusPending || stop.Status == domain.StopStatusEnRoute {
+			return stop, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasOpenGroundedException reports whether dispatch has already been warned
+// about this pickup, so a container that flips between wheeled and grounded
+// does not pile up duplicates
+func (s *DispatchService) hasOpenGroundedException(ctx context.Context, tripID, stopID uuid.UUID) bool {
+	exceptions, err := s.exceptions.GetExceptionsByTrip(ctx, tripID)
+	if err != nil {
+		return false
+	}
+	for _, e := range exceptions {
+		if e.Type != domain.ExceptionTypeContainerGrounded || e.StopID == nil || *e.StopID != stopID {
+			continue
+		}
+		switch e.Status {
+		case domain.ExceptionStatusResolved, domain.ExceptionStatusClosed, domain.ExceptionStatusCancelled:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// yardPositionLabel renders a yard position such as "block B14, row 07, bay 22, tier 3"
+func yardPositionLabel(block, row, bay, tier string) string {
+	var parts []string
+	for _, p := range []struct{ name, value string }{
+		{"block", block}, {"row", row}, {"bay", bay}, {"tier", tier},
+	} {
+		if p.value != "" {
+			parts = append(parts, p.name+" "+p.value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
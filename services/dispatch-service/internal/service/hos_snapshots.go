@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// recordHOSSnapshot saves the driver's HOS clocks as they stand when the trip
+// is assigned to them or dispatched. by names the acting user when the call
+// carries no authenticated identity, as for auto-dispatch. The trip change
+// has already been saved, so a failure is logged rather than undoing it.
+func recordHOSSnapshot(
+	ctx context.Context,
+	snapshotRepo repository.HOSSnapshotRepository,
+	log *logger.Logger,
+	rules *config.BreakPlanningRules,
+	trip *domain.Trip,
+	driver *domain.Driver,
+	event domain.HOSSnapshotEvent,
+	by string,
+	now time.Time,
+) *domain.HOSSnapshot {
+	if snapshotRepo == nil || driver == nil {
+		return nil
+	}
+	if id, ok := auth.FromContext(ctx); ok {
+		by = id.Subject
+	}
+
+	snapshot := newHOSSnapshot(rules, trip, driver, event, now)
+	snapshot.TakenBy = by
+	if err := snapshotRepo.Create(ctx, snapshot); err != nil {
+		log.Errorw("Failed to record HOS snapshot",
+			"trip_id", trip.ID,
+			"driver_id", driver.ID,
+			"event", event,
+			"error", err,
+		)
+		return nil
+	}
+	return snapshot
+}
+
+// newHOSSnapshot captures the driver's clocks. HOS only reports driving
+// left in the shift, so break state assumes no break has been taken yet,
+// as break planning does.
+func newHOSSnapshot(rules *config.BreakPlanningRules, trip *domain.Trip, driver *domain.Driver, event domain.HOSSnapshotEvent, now time.Time) *domain.HOSSnapshot {
+	driven := rules.DailyDriveLimitMins - driver.AvailableDriveMins
+	if driven < 0 {
+		driven = 0
+	}
+	untilBreak := rules.BreakAfterDriveMins - driven
+	if untilBreak < 0 {
+		untilBreak = 0
+	}
+
+	return &domain.HOSSnapshot{
+		ID:                 uuid.New(),
+		TripID:             trip.ID,
+		DriverID:           driver.ID,
+		Event:              event,
+		DriveRemainingMins: driver.AvailableDriveMins,
+		DutyRemainingMins:  driver.AvailableDutyMins,
+		CycleRemainingMins: driver.AvailableCycleMins,
+		ClocksAsOf:         driver.LastHOSUpdate,
+		NeedsBreak:         untilBreak == 0,
+		MinsUntilBreak:     untilBreak,
+		TripDurationMins:   trip.EstimatedDurationMins,
+		PlannedStartTime:   trip.PlannedStartTime,
+		TakenAt:            now,
+	}
+}
+
+// snapshotHOS records the driver's clocks and attaches the snapshot to trip
+func (s *DispatchService) snapshotHOS(ctx context.Context, trip *domain.Trip, driver *domain.Driver, event domain.HOSSnapshotEvent, by string) {
+	if snapshot := recordHOSSnapshot(ctx, s.hosSnapshotRepo, s.logger, &s.businessRules.BreakPlanning, trip, driver, event, by, time.Now()); snapshot != nil {
+		trip.HOSSnapshots = append(trip.HOSSnapshots, *snapshot)
+	}
+}
+
+// GetTripTimeline returns what happened to a trip in order: its creation,
+// the HOS snapshots taken at assignment and dispatch, and its start, stop
+// arrivals and departures and completion
+func (s *DispatchService) GetTripTimeline(ctx context.Context, tripID uuid.UUID) (*domain.TripTimeline, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	snapshots, err := s.hosSnapshotRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get HOS snapshots", err)
+	}
+
+	entries := []domain.TripTimelineEntry{{
+		Time:        trip.CreatedAt,
+		Type:        domain.TimelineTripCreated,
+		Description: fmt.Sprintf("Trip %s created", trip.TripNumber),
+	}}
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		description := fmt.Sprintf("Driver %s with %d min drive, %d min duty and %d min cycle time left",
+			strings.ToLower(string(snapshot.Event)), snapshot.DriveRemainingMins, snapshot.DutyRemainingMins, snapshot.CycleRemainingMins)
+		if snapshot.NeedsBreak {
+			description += "; 30-minute break due"
+		}
+		entries = append(entries, domain.TripTimelineEntry{
+			Time:        snapshot.TakenAt,
+			Type:        domain.TimelineHOSSnapshot,
+			Description: description,
+			HOSSnapshot: snapshot,
+		})
+	}
+	if trip.ActualStartTime != nil {
+		entries = append(entries, domain.TripTimelineEntry{
+			Time:        *trip.ActualStartTime,
+			Type:        domain.TimelineTripStarted,
+			Description: "Trip started",
+		})
+	}
+	for _, stop := range stops {
+		if stop.ActualArrival != nil {
+			entries = append(entries, domain.TripTimelineEntry{
+				Time:         *stop.ActualArrival,
+				Type:         domain.TimelineStopArrived,
+				Description:  fmt.Sprintf("Arrived at stop %d", stop.Sequence),
+				StopSequence: stop.Sequence,
+				Source:       stop.ArrivalSource,
+			})
+		}
+		if stop.ActualDeparture != nil {
+			entries = append(entries, domain.TripTimelineEntry{
+				Time:         *stop.ActualDeparture,
+				Type:         domain.TimelineStopDeparted,
+				Description:  fmt.Sprintf("Departed stop %d", stop.Sequence),
+				StopSequence: stop.Sequence,
+			})
+		}
+	}
+	if trip.ActualEndTime != nil {
+		entries = append(entries, domain.TripTimelineEntry{
+			Time:        *trip.ActualEndTime,
+			Type:        domain.TimelineTripCompleted,
+			Description: "Trip completed",
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	return &domain.TripTimeline{
+		TripID:     trip.ID,
+		TripNumber: trip.TripNumber,
+		Status:     trip.Status,
+		Entries:    entries,
+	}, nil
+}
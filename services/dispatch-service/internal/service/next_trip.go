@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// NextTripService suggests the nearest unassigned trips a driver could
+// legally take next, and pushes them to the owning dispatchers when the
+// driver finishes a trip
+type NextTripService struct {
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	driverRepo    repository.DriverRepository
+	locationRepo  repository.LocationRepository
+	containerRepo repository.ContainerRepository
+	dispatch      *DispatchService
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewNextTripService creates a new next-trip suggestion service
+func NewNextTripService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	dispatch *DispatchService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *NextTripService {
+	return &NextTripService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		containerRepo: containerRepo,
+		dispatch:      dispatch,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// GetNextTripSuggestions returns the unassigned trips nearest to the
+// driver's current position that the driver has the drive time,
+// endorsements and schedule room for, and whose first appointment they can
+// still make
+func (s *NextTripService) GetNextTripSuggestions(ctx context.Context, driverID uuid.UUID) (*domain.NextTripSuggestions, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil || driver == nil {
+		return nil, apperrors.NotFoundError("driver", driverID.String())
+	}
+
+	rules := &s.businessRules.NextTrip
+	now := time.Now()
+	result := &domain.NextTripSuggestions{
+		DriverID:           driver.ID,
+		DriverName:         driver.Name,
+		Latitude:           driver.CurrentLatitude,
+		Longitude:          driver.CurrentLongitude,
+		AvailableDriveMins: driver.AvailableDriveMins,
+		Suggestions:        []domain.TripSuggestion{},
+		GeneratedAt:        now,
+	}
+
+	trips, err := s.tripRepo.GetByDateRange(ctx, now, now.Add(time.Duration(rules.LookaheadHours)*time.Hour))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trips", err)
+	}
+	var open []domain.Trip
+	var tripIDs []uuid.UUID
+	for _, trip := range trips {
+		if trip.Status == domain.TripStatusPlanned && trip.DriverID == nil {
+			open = append(open, trip)
+			tripIDs = append(tripIDs, trip.ID)
+		}
+	}
+	result.Considered = len(open)
+	if len(open) == 0 {
+		return result, nil
+	}
+
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	firstStops := make(map[uuid.UUID]domain.TripStop, len(open))
+	for _, stop := range stops {
+		if first, ok := firstStops[stop.TripID]; !ok || stop.Sequence < first.Sequence {
+			firstStops[stop.TripID] = stop
+		}
+	}
+
+	windows, err := s.committedWindows(ctx, driver.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[uuid.UUID]*domain.Location)
+	for i := range open {
+		trip := &open[i]
+		first, ok := firstStops[trip.ID]
+		if !ok {
+			continue
+		}
+		pickup, ok := locations[first.LocationID]
+		if !ok {
+			if pickup, err = s.locationRepo.GetByID(ctx, first.LocationID); err != nil || pickup == nil {
+				return nil, apperrors.NotFoundError("location", first.LocationID.String())
+			}
+			locations[first.LocationID] = pickup
+		}
+
+		suggestion, reason, err := s.evaluate(ctx, driver, trip, &first, pickup, windows, now)
+		if err != nil {
+			return nil, err
+		}
+		switch reason {
+		case suggestionTooFar:
+			result.TooFar++
+		case suggestionLackHOS:
+			result.LackHOS++
+		case suggestionRestricted:
+			result.Restricted++
+		case suggestionMissAppointment:
+			result.MissAppointment++
+		case suggestionConflicting:
+			result.Conflicting++
+		default:
+			result.Suggestions = append(result.Suggestions, *suggestion)
+		}
+	}
+
+	sort.SliceStable(result.Suggestions, func(i, j int) bool {
+		return result.Suggestions[i].DeadheadMiles < result.Suggestions[j].DeadheadMiles
+	})
+	if rules.MaxSuggestions > 0 && len(result.Suggestions) > rules.MaxSuggestions {
+		result.Suggestions = result.Suggestions[:rules.MaxSuggestions]
+	}
+	return result, nil
+}
+
+// suggestionReason is why a trip was or was not suggested
+type suggestionReason int
+
+const (
+	suggestionOK suggestionReason = iota
+	suggestionTooFar
+	suggestionLackHOS
+	suggestionRestricted
+	suggestionMissAppointment
+	suggestionConflicting
+)
+
+// evaluate checks one unassigned trip against the driver. The cheap
+// distance and drive time checks run before the trip's requirements are
+// loaded.
+func (s *NextTripService) evaluate(ctx context.Context, driver *domain.Driver, trip *domain.Trip, first *domain.TripStop, pickup *domain.Location, windows [][2]time.Time, now time.Time) (*domain.TripSuggestion, suggestionReason, error) {
+	rules := &s.businessRules.NextTrip
+	schedule := &s.businessRules.Schedule
+
+	miles := haversineMiles(driver.CurrentLatitude, driver.CurrentLongitude, pickup.Latitude, pickup.Longitude)
+	if rules.MaxDeadheadMiles > 0 && miles > rules.MaxDeadheadMiles {
+		return nil, suggestionTooFar, nil
+	}
+	etaMins := int(math.Ceil(miles / s.businessRules.Distance.DrayageAverageSpeedMPH * 60))
+	if driver.AvailableDriveMins < etaMins+trip.EstimatedDurationMins+autoDispatchHOSBufferMins {
+		return nil, suggestionLackHOS, nil
+	}
+
+	needsTWIC, needsHazmat, err := tripRequirements(ctx, s.stopRepo, s.locationRepo, s.containerRepo, trip.ID)
+	if err != nil {
+		return nil, suggestionOK, err
+	}
+	if checkDriverDocuments(&s.businessRules.Documents, driver, needsTWIC, needsHazmat) != nil {
+		return nil, suggestionRestricted, nil
+	}
+
+	arrival := now.Add(time.Duration(etaMins) * time.Minute)
+	var slack *int
+	if first.AppointmentTime != nil {
+		latest := first.AppointmentTime.Add(time.Duration(first.AppointmentWindowMins) * time.Minute)
+		if arrival.After(latest) {
+			return nil, suggestionMissAppointment, nil
+		}
+		mins := int(latest.Sub(arrival).Minutes())
+		slack = &mins
+	}
+
+	if start, end, ok := trip.PlannedWindow(schedule.DefaultTripMins); ok {
+		buffer := time.Duration(schedule.ConflictBufferMins) * time.Minute
+		for _, window := range windows {
+			if domain.WindowsOverlap(start, end, window[0], window[1], buffer) {
+				return nil, suggestionConflicting, nil
+			}
+		}
+	}
+
+	return &domain.TripSuggestion{
+		TripID:                trip.ID,
+		TripNumber:            trip.TripNumber,
+		Type:                  trip.Type,
+		DispatcherID:          trip.DispatcherID,
+		PlannedStartTime:      trip.PlannedStartTime,
+		PickupLocationID:      pickup.ID,
+		PickupName:            pickup.Name,
+		DeadheadMiles:         math.Round(miles*10) / 10,
+		ETAToPickupMins:       etaMins,
+		AppointmentTime:       first.AppointmentTime,
+		SlackMins:             slack,
+		EstimatedDurationMins: trip.EstimatedDurationMins,
+		DriveMinsAfter:        driver.AvailableDriveMins - etaMins - trip.EstimatedDurationMins,
+	}, suggestionOK, nil
+}
+
+// committedWindows returns the planned windows of the trips the driver is
+// already committed to within the lookahead
+func (s *NextTripService) committedWindows(ctx context.Context, driverID uuid.UUID, now time.Time) ([][2]time.Time, error) {
+	until := now.Add(time.Duration(s.businessRules.NextTrip.LookaheadHours) * time.Hour)
+	trips, err := driverTrips(ctx, s.tripRepo, driverID, committedTripStatuses, now, until)
+	if err != nil {
+		return nil, err
+	}
+	var windows [][2]time.Time
+	for i := range trips {
+		if start, end, ok := trips[i].PlannedWindow(s.businessRules.Schedule.DefaultTripMins); ok {
+			windows = append(windows, [2]time.Time{start, end})
+		}
+	}
+	return windows, nil
+}
+
+// HandleTripCompleted is a kafka.Handler that suggests the next trip for a
+// driver who has just finished their last stop. Drivers with another trip
+// already lined up are left alone. Suggestions go to the dispatcher owning
+// each suggested trip, and unowned trips to the dispatcher of the finished one.
+func (s *NextTripService) HandleTripCompleted(ctx context.Context, event *kafka.Event) error {
+	if !s.businessRules.NextTrip.PushOnCompletion {
+		return nil
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload struct {
+		TripID string `json:"trip_id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal trip completed: %w", err)
+	}
+	tripID, err := uuid.Parse(payload.TripID)
+	if err != nil {
+		return nil
+	}
+
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil || trip.DriverID == nil {
+		return nil
+	}
+
+	now := time.Now()
+	lined, err := driverTrips(ctx, s.tripRepo, *trip.DriverID, committedTripStatuses, now, now.Add(time.Duration(s.businessRules.NextTrip.LookaheadHours)*time.Hour))
+	if err != nil {
+		return err
+	}
+	if len(lined) > 0 {
+		return nil
+	}
+
+	suggestions, err := s.GetNextTripSuggestions(ctx, *trip.DriverID)
+	if err != nil {
+		return err
+	}
+	if len(suggestions.Suggestions) == 0 {
+		s.logger.Infow("No next trip to suggest",
+			"driver_id", suggestions.DriverID,
+			"completed_trip", trip.TripNumber,
+			"considered", suggestions.Considered,
+		)
+		return nil
+	}
+
+	s.pushSuggestions(ctx, trip, suggestions)
+	return nil
+}
+
+// pushSuggestions publishes one event per dispatcher with the suggestions
+// for the trips they own
+func (s *NextTripService) pushSuggestions(ctx context.Context, completed *domain.Trip, suggestions *domain.NextTripSuggestions) {
+	byDispatcher := make(map[uuid.UUID][]domain.TripSuggestion)
+	var order []uuid.UUID
+	for _, suggestion := range suggestions.Suggestions {
+		owner := uuid.Nil
+		if suggestion.DispatcherID != nil {
+			owner = *suggestion.DispatcherID
+		} else if completed.DispatcherID != nil {
+			owner = *completed.DispatcherID
+		}
+		if _, ok := byDispatcher[owner]; !ok {
+			order = append(order, owner)
+		}
+		byDispatcher[owner] = append(byDispatcher[owner], suggestion)
+	}
+
+	for _, dispatcherID := range order {
+		data := map[string]interface{}{
+			"driver_id":      suggestions.DriverID.String(),
+			"driver_name":    suggestions.DriverName,
+			"completed_trip": completed.TripNumber,
+			"suggestions":    byDispatcher[dispatcherID],
+		}
+		// Trips nobody owns go to the whole desk
+		if dispatcherID != uuid.Nil {
+			data["dispatcher_id"] = dispatcherID.String()
+		}
+		event := kafka.NewEvent(kafka.Topics.NextTripSuggested, "dispatch-service", data)
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.NextTripSuggested, event)
+	}
+
+	s.logger.Infow("Next trips suggested",
+		"driver_id", suggestions.DriverID,
+		"completed_trip", completed.TripNumber,
+		"suggestions", len(suggestions.Suggestions),
+		"dispatchers", len(order),
+	)
+}
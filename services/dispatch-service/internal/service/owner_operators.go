@@ -0,0 +1,646 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// CarrierVerifier looks up a carrier's operating authority and the
+// certificates its insurers have filed, through a carrier monitoring
+// provider such as RMIS or MyCarrierPackets
+type CarrierVerifier interface {
+	Name() string
+	Verify(ctx context.Context, dotNumber, mcNumber string) (*domain.CarrierVerification, error)
+}
+
+// OwnerOperatorService keeps owner-operators' operating authority and
+// insurance certificates. Each change re-evaluates the carrier's standing
+// against the required coverage and stores a hold on it, which every
+// dispatch path checks; the monitor re-verifies authority with the
+// provider, lets lapsed certificates block dispatch and alerts ops ahead of
+// expirations.
+type OwnerOperatorService struct {
+	ownerRepo     repository.OwnerOperatorRepository
+	driverRepo    repository.DriverRepository
+	verifier      CarrierVerifier // Nil when only manual certificates are kept
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewOwnerOperatorService creates a new owner-operator compliance service
+func NewOwnerOperatorService(
+	ownerRepo repository.OwnerOperatorRepository,
+	driverRepo repository.DriverRepository,
+	verifier CarrierVerifier,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *OwnerOperatorService {
+	return &OwnerOperatorService{
+		ownerRepo:     ownerRepo,
+		driverRepo:    driverRepo,
+		verifier:      verifier,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// RegisterCarrierInput contains input for registering an owner-operator
+type RegisterCarrierInput struct {
+	DriverID      uuid.UUID
+	LegalName     string
+	AuthorityType domain.AuthorityType
+	DOTNumber     string
+	MCNumber      string
+	Notes         string
+}
+
+// RegisterCarrier records a driver as an owner-operator. Their authority is
+// verified at once when a provider is configured; until then, and until
+// their certificates are on file, they are held from dispatch.
+func (s *OwnerOperatorService) RegisterCarrier(ctx context.Context, input RegisterCarrierInput) (*domain.OwnerOperatorCompliance, error) {
+	driver, err := s.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil || driver == nil {
+		return nil, apperrors.NotFoundError("driver", input.DriverID.String())
+	}
+	existing, err := s.ownerRepo.GetCarrierByDriver(ctx, input.DriverID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get owner-operator", err)
+	}
+	if existing != nil {
+		return nil, apperrors.ConflictError("driver is already registered as an owner-operator").
+			WithDetail("carrier_id", existing.ID.String())
+	}
+
+	now := time.Now()
+	carrier := &domain.OwnerOperatorCarrier{
+		ID:                   uuid.New(),
+		DriverID:             input.DriverID,
+		LegalName:            strings.TrimSpace(input.LegalName),
+		AuthorityType:        input.AuthorityType,
+		DOTNumber:            strings.TrimSpace(input.DOTNumber),
+		MCNumber:             normalizeMCNumber(input.MCNumber),
+		AuthorityStatus:      domain.AuthorityUnverified,
+		VerificationProvider: domain.VerificationManual,
+		Notes:                input.Notes,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	if carrier.LegalName == "" {
+		carrier.LegalName = driver.Name
+	}
+	if err := validateCarrier(carrier); err != nil {
+		return nil, err
+	}
+	if err := s.ownerRepo.CreateCarrier(ctx, carrier); err != nil {
+		return nil, apperrors.DatabaseError("create owner-operator", err)
+	}
+
+	if s.verifier != nil && carrier.AuthorityType == domain.AuthorityOwn {
+		if _, err := s.VerifyCarrier(ctx, carrier.ID); err != nil {
+			s.logger.Warnw("Owner-operator verification failed; left unverified",
+				"carrier_id", carrier.ID,
+				"dot_number", carrier.DOTNumber,
+				"error", err,
+			)
+		}
+	}
+	return s.GetCompliance(ctx, carrier.ID)
+}
+
+// UpdateCarrierInput contains the owner-operator fields to change; nil
+// fields are kept. Setting AuthorityStatus records a manual verification.
+type UpdateCarrierInput struct {
+	LegalName       *string
+	AuthorityType   *domain.AuthorityType
+	DOTNumber       *string
+	MCNumber        *string
+	AuthorityStatus *domain.AuthorityStatus
+	Notes           *string
+}
+
+// UpdateCarrier changes an owner-operator's details and re-evaluates their standing
+func (s *OwnerOperatorService) UpdateCarrier(ctx context.Context, id uuid.UUID, input UpdateCarrierInput) (*domain.OwnerOperatorCompliance, error) {
+	carrier, err := s.getCarrier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if input.LegalName != nil {
+		carrier.LegalName = strings.TrimSpace(*input.LegalName)
+	}
+	if input.AuthorityType != nil {
+		carrier.AuthorityType = *input.AuthorityType
+	}
+	if input.DOTNumber != nil {
+		carrier.DOTNumber = strings.TrimSpace(*input.DOTNumber)
+	}
+	if input.MCNumber != nil {
+		carrier.MCNumber = normalizeMCNumber(*input.MCNumber)
+	}
+	if input.AuthorityStatus != nil {
+		switch *input.AuthorityStatus {
+		case domain.AuthorityActive, domain.AuthorityInactive, domain.AuthorityRevoked, domain.AuthorityPending:
+		default:
+			return nil, apperrors.ValidationError("authority status must be ACTIVE, INACTIVE, REVOKED or PENDING", "authority_status", string(*input.AuthorityStatus))
+		}
+		carrier.AuthorityStatus = *input.AuthorityStatus
+		carrier.VerificationProvider = domain.VerificationManual
+		carrier.VerifiedAt = &now
+	}
+	if input.Notes != nil {
+		carrier.Notes = *input.Notes
+	}
+	if carrier.LegalName == "" {
+		return nil, apperrors.ValidationError("legal name is required", "legal_name", "")
+	}
+	if err := validateCarrier(carrier); err != nil {
+		return nil, err
+	}
+
+	carrier.UpdatedAt = now
+	if err := s.ownerRepo.UpdateCarrier(ctx, carrier); err != nil {
+		return nil, apperrors.DatabaseError("update owner-operator", err)
+	}
+	return s.GetCompliance(ctx, id)
+}
+
+// AddCertificateInput contains input for filing a certificate of insurance by hand
+type AddCertificateInput struct {
+	CarrierID      uuid.UUID
+	Coverage       string
+	InsurerName    string
+	PolicyNumber   string
+	CoverageAmount float64
+	EffectiveDate  time.Time
+	ExpirationDate time.Time
+	AttachmentID   *uuid.UUID // Scanned certificate uploaded as an attachment
+	AddedBy        string
+}
+
+// AddCertificate files a certificate of insurance for an owner-operator and
+// re-evaluates their standing
+func (s *OwnerOperatorService) AddCertificate(ctx context.Context, input AddCertificateInput) (*domain.OwnerOperatorCompliance, error) {
+	carrier, err := s.getCarrier(ctx, input.CarrierID)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := strings.ToUpper(strings.TrimSpace(input.Coverage))
+	if !isKnownCoverage(coverage) {
+		return nil, apperrors.ValidationError("unknown coverage type", "coverage", input.Coverage)
+	}
+	policyNumber := strings.TrimSpace(input.PolicyNumber)
+	if policyNumber == "" {
+		return nil, apperrors.ValidationError("policy number is required", "policy_number", "")
+	}
+	if input.EffectiveDate.IsZero() || !input.ExpirationDate.After(input.EffectiveDate) {
+		return nil, apperrors.ValidationError("expiration date must be after the effective date", "expiration_date", input.ExpirationDate.Format(time.RFC3339))
+	}
+	if input.CoverageAmount < 0 {
+		return nil, apperrors.ValidationError("coverage amount cannot be negative", "coverage_amount", input.CoverageAmount)
+	}
+
+	now := time.Now()
+	certificate := &domain.InsuranceCertificate{
+		ID:             uuid.New(),
+		CarrierID:      carrier.ID,
+		Coverage:       coverage,
+		InsurerName:    strings.TrimSpace(input.InsurerName),
+		PolicyNumber:   policyNumber,
+		CoverageAmount: input.CoverageAmount,
+		EffectiveDate:  input.EffectiveDate,
+		ExpirationDate: input.ExpirationDate,
+		Status:         domain.CertificateActive,
+		Source:         domain.VerificationManual,
+		AttachmentID:   input.AttachmentID,
+		AddedBy:        input.AddedBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.ownerRepo.SaveCertificate(ctx, certificate); err != nil {
+		return nil, apperrors.DatabaseError("save insurance certificate", err)
+	}
+
+	if _, err := s.refreshStanding(ctx, carrier, now); err != nil {
+		return nil, err
+	}
+	return s.GetCompliance(ctx, carrier.ID)
+}
+
+// CancelCertificate records that an insurer cancelled a certificate before
+// it expired. Dispatch is blocked at once if it was the only cover of its kind.
+func (s *OwnerOperatorService) CancelCertificate(ctx context.Context, carrierID, certificateID uuid.UUID, reason, user string) (*domain.OwnerOperatorCompliance, error) {
+	carrier, err := s.getCarrier(ctx, carrierID)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := s.ownerRepo.GetCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get insurance certificate", err)
+	}
+	if certificate == nil || certificate.CarrierID != carrierID {
+		return nil, apperrors.NotFoundError("insurance certificate", certificateID.String())
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, apperrors.ValidationError("cancellation reason is required", "reason", "")
+	}
+
+	now := time.Now()
+	if certificate.Status != domain.CertificateCancelled {
+		certificate.Status = domain.CertificateCancelled
+		certificate.CancelledAt = &now
+		certificate.CancelReason = strings.TrimSpace(reason)
+		certificate.UpdatedAt = now
+		if err := s.ownerRepo.SaveCertificate(ctx, certificate); err != nil {
+			return nil, apperrors.DatabaseError("save insurance certificate", err)
+		}
+		s.logger.Infow("Insurance certificate cancelled",
+			"carrier_id", carrierID,
+			"certificate_id", certificateID,
+			"coverage", certificate.Coverage,
+			"cancelled_by", user,
+		)
+	}
+
+	if _, err := s.refreshStanding(ctx, carrier, now); err != nil {
+		return nil, err
+	}
+	return s.GetCompliance(ctx, carrierID)
+}
+
+// VerifyCarrier reads the owner-operator's authority and filed insurance
+// from the verification provider. Certificates the provider reports are
+// added or updated; ones it reported before and no longer does are taken as
+// cancelled. Certificates filed by hand are left alone.
+func (s *OwnerOperatorService) VerifyCarrier(ctx context.Context, id uuid.UUID) (*domain.OwnerOperatorCompliance, error) {
+	if s.verifier == nil {
+		return nil, apperrors.ValidationError("no carrier verification provider is configured", "carrier_id", id.String())
+	}
+	carrier, err := s.getCarrier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if carrier.DOTNumber == "" && carrier.MCNumber == "" {
+		return nil, apperrors.ValidationError("a DOT or MC number is needed to verify a carrier", "dot_number", "")
+	}
+
+	verification, err := s.verifier.Verify(ctx, carrier.DOTNumber, carrier.MCNumber)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "VERIFICATION_FAILED", "carrier verification provider request failed").
+			WithDetail("provider", s.verifier.Name())
+	}
+
+	now := time.Now()
+	existing, err := s.ownerRepo.GetCertificates(ctx, carrier.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get insurance certificates", err)
+	}
+	for _, certificate := range mergeVerifiedCertificates(carrier.ID, verification, existing, now) {
+		certificate := certificate
+		if err := s.ownerRepo.SaveCertificate(ctx, &certificate); err != nil {
+			return nil, apperrors.DatabaseError("save insurance certificate", err)
+		}
+	}
+
+	carrier.AuthorityStatus = verification.AuthorityStatus
+	carrier.VerificationProvider = verification.Provider
+	if verification.ExternalID != "" {
+		carrier.ExternalID = verification.ExternalID
+	}
+	if verification.LegalName != "" {
+		carrier.LegalName = verification.LegalName
+	}
+	checkedAt := verification.CheckedAt
+	if checkedAt.IsZero() {
+		checkedAt = now
+	}
+	carrier.VerifiedAt = &checkedAt
+	carrier.UpdatedAt = now
+	if err := s.ownerRepo.UpdateCarrier(ctx, carrier); err != nil {
+		return nil, apperrors.DatabaseError("update owner-operator", err)
+	}
+
+	if _, err := s.refreshStanding(ctx, carrier, now); err != nil {
+		return nil, err
+	}
+	return s.GetCompliance(ctx, id)
+}
+
+// GetCompliance returns an owner-operator with their certificates and current standing
+func (s *OwnerOperatorService) GetCompliance(ctx context.Context, id uuid.UUID) (*domain.OwnerOperatorCompliance, error) {
+	carrier, err := s.getCarrier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := s.ownerRepo.GetCertificates(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get insurance certificates", err)
+	}
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].ExpirationDate.Before(certificates[j].ExpirationDate)
+	})
+	return &domain.OwnerOperatorCompliance{
+		Carrier:      *carrier,
+		Certificates: certificates,
+		Standing:     carrier.EvaluateStanding(certificates, s.requiredCoverage(carrier), time.Now()),
+	}, nil
+}
+
+// ListCarriers returns every owner-operator, those held from dispatch first
+func (s *OwnerOperatorService) ListCarriers(ctx context.Context) ([]domain.OwnerOperatorCarrier, error) {
+	carriers, err := s.ownerRepo.ListCarriers(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list owner-operators", err)
+	}
+	sort.SliceStable(carriers, func(i, j int) bool {
+		return carriers[i].Hold != "" && carriers[j].Hold == ""
+	})
+	return carriers, nil
+}
+
+// CheckCompliance re-verifies owner-operators whose provider check is stale,
+// re-evaluates every standing so lapsed insurance blocks dispatch, and alerts
+// ops to certificates nearing expiration with no renewal on file. It returns
+// how many alerts were sent.
+func (s *OwnerOperatorService) CheckCompliance(ctx context.Context, now time.Time) (int, error) {
+	carriers, err := s.ownerRepo.ListCarriers(ctx)
+	if err != nil {
+		return 0, apperrors.DatabaseError("list owner-operators", err)
+	}
+
+	rules := &s.businessRules.OwnerOperators
+	reverifyAfter := time.Duration(rules.ReverifyAfterHours) * time.Hour
+	alerted := 0
+	for i := range carriers {
+		carrier := &carriers[i]
+		if s.verifier != nil && carrier.AuthorityType == domain.AuthorityOwn &&
+			(carrier.VerifiedAt == nil || now.Sub(*carrier.VerifiedAt) >= reverifyAfter) {
+			if _, err := s.VerifyCarrier(ctx, carrier.ID); err != nil {
+				s.logger.Warnw("Owner-operator re-verification failed", "carrier_id", carrier.ID, "error", err)
+			} else if carrier, err = s.getCarrier(ctx, carrier.ID); err != nil {
+				return alerted, err
+			}
+		}
+
+		certificates, err := s.refreshStanding(ctx, carrier, now)
+		if err != nil {
+			return alerted, err
+		}
+		alerted += s.alertExpirations(ctx, carrier, certificates, now)
+	}
+	return alerted, nil
+}
+
+// Start checks owner-operator compliance every interval until ctx is cancelled
+func (s *OwnerOperatorService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.CheckCompliance(ctx, now); err != nil {
+				s.logger.Errorw("Owner-operator compliance check failed", "error", err)
+			}
+		}
+	}
+}
+
+// refreshStanding re-evaluates a carrier and stores its hold and cover end
+// when they change, announcing when dispatch is blocked or cleared. It
+// returns the certificates it read.
+func (s *OwnerOperatorService) refreshStanding(ctx context.Context, carrier *domain.OwnerOperatorCarrier, now time.Time) ([]domain.InsuranceCertificate, error) {
+	certificates, err := s.ownerRepo.GetCertificates(ctx, carrier.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get insurance certificates", err)
+	}
+
+	standing := carrier.EvaluateStanding(certificates, s.requiredCoverage(carrier), now)
+	hold := standing.Hold()
+	if hold == carrier.Hold && sameTime(standing.CoveredUntil, carrier.CoveredUntil) {
+		return certificates, nil
+	}
+
+	wasHeld := carrier.Hold != ""
+	carrier.Hold = hold
+	carrier.CoveredUntil = standing.CoveredUntil
+	carrier.UpdatedAt = now
+	if err := s.ownerRepo.UpdateCarrier(ctx, carrier); err != nil {
+		return nil, apperrors.DatabaseError("update owner-operator", err)
+	}
+
+	if wasHeld != (hold != "") {
+		event := kafka.NewEvent(kafka.Topics.OwnerOperatorComplianceChanged, "dispatch-service", map[string]interface{}{
+			"carrier_id":    carrier.ID,
+			"driver_id":     carrier.DriverID,
+			"legal_name":    carrier.LegalName,
+			"compliant":     standing.Compliant,
+			"problems":      standing.Problems,
+			"covered_until": standing.CoveredUntil,
+		})
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.OwnerOperatorComplianceChanged, event)
+
+		if hold != "" {
+			s.logger.Warnw("Owner-operator held from dispatch", "carrier_id", carrier.ID, "driver_id", carrier.DriverID, "hold", hold)
+		} else {
+			s.logger.Infow("Owner-operator cleared for dispatch", "carrier_id", carrier.ID, "driver_id", carrier.DriverID)
+		}
+	}
+	return certificates, nil
+}
+
+// alertExpirations alerts once per configured lead time for each required
+// certificate running out with no renewal on file, returning how many went out
+func (s *OwnerOperatorService) alertExpirations(ctx context.Context, carrier *domain.OwnerOperatorCarrier, certificates []domain.InsuranceCertificate, now time.Time) int {
+	required := s.requiredCoverage(carrier)
+	alertDays := append([]int(nil), s.businessRules.OwnerOperators.ExpirationAlertDays...)
+	sort.Ints(alertDays)
+
+	sent := 0
+	for i := range certificates {
+		certificate := &certificates[i]
+		if _, ok := required[certificate.Coverage]; !ok || !certificate.InForce(now) {
+			continue
+		}
+		if renewalOnFile(certificates, certificate) {
+			continue
+		}
+
+		daysLeft := int(math.Ceil(certificate.ExpirationDate.Sub(now).Hours() / 24))
+		threshold := -1
+		for _, days := range alertDays {
+			if daysLeft <= days {
+				threshold = days
+				break
+			}
+		}
+		if threshold < 0 {
+			continue
+		}
+
+		fresh, err := s.ownerRepo.MarkExpirationAlerted(ctx, certificate.ID, threshold)
+		if err != nil {
+			s.logger.Warnw("Failed to record insurance expiration alert", "certificate_id", certificate.ID, "error", err)
+			continue
+		}
+		if !fresh {
+			continue
+		}
+
+		event := kafka.NewEvent(kafka.Topics.OwnerOperatorInsuranceExpiring, "dispatch-service", map[string]interface{}{
+			"carrier_id":      carrier.ID,
+			"driver_id":       carrier.DriverID,
+			"legal_name":      carrier.LegalName,
+			"certificate_id":  certificate.ID,
+			"coverage":        certificate.Coverage,
+			"insurer_name":    certificate.InsurerName,
+			"policy_number":   certificate.PolicyNumber,
+			"expiration_date": certificate.ExpirationDate,
+			"days_left":       daysLeft,
+		})
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.OwnerOperatorInsuranceExpiring, event)
+		sent++
+	}
+	return sent
+}
+
+// requiredCoverage returns the minimum limits the carrier must carry for its authority type
+func (s *OwnerOperatorService) requiredCoverage(carrier *domain.OwnerOperatorCarrier) map[string]float64 {
+	if carrier.AuthorityType == domain.AuthorityLeasedOn {
+		return s.businessRules.OwnerOperators.LeasedOnMinimums
+	}
+	return s.businessRules.OwnerOperators.OwnAuthorityMinimums
+}
+
+func (s *OwnerOperatorService) getCarrier(ctx context.Context, id uuid.UUID) (*domain.OwnerOperatorCarrier, error) {
+	carrier, err := s.ownerRepo.GetCarrier(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get owner-operator", err)
+	}
+	if carrier == nil {
+		return nil, apperrors.NotFoundError("owner-operator", id.String())
+	}
+	return carrier, nil
+}
+
+// validateCarrier checks the authority type and that carriers on their own
+// authority say which it is
+func validateCarrier(carrier *domain.OwnerOperatorCarrier) error {
+	switch carrier.AuthorityType {
+	case domain.AuthorityOwn:
+		if carrier.DOTNumber == "" && carrier.MCNumber == "" {
+			return apperrors.ValidationError("carriers on their own authority need a DOT or MC number", "dot_number", "")
+		}
+	case domain.AuthorityLeasedOn:
+	default:
+		return apperrors.ValidationError("authority type must be OWN_AUTHORITY or LEASED_ON", "authority_type", string(carrier.AuthorityType))
+	}
+	return nil
+}
+
+// mergeVerifiedCertificates returns the certificates to save after a
+// provider check: reported ones matched to those on file by coverage and
+// policy number, and provider certificates on file it no longer reports,
+// cancelled
+func mergeVerifiedCertificates(carrierID uuid.UUID, verification *domain.CarrierVerification, existing []domain.InsuranceCertificate, now time.Time) []domain.InsuranceCertificate {
+	key := func(c *domain.InsuranceCertificate) string {
+		return c.Coverage + "|" + strings.ToUpper(c.PolicyNumber)
+	}
+	onFile := make(map[string]*domain.InsuranceCertificate)
+	for i := range existing {
+		if existing[i].Source == verification.Provider {
+			onFile[key(&existing[i])] = &existing[i]
+		}
+	}
+
+	var changed []domain.InsuranceCertificate
+	reported := make(map[string]bool)
+	for _, certificate := range verification.Certificates {
+		certificate.CarrierID = carrierID
+		certificate.Source = verification.Provider
+		certificate.AddedBy = verification.Provider
+		certificate.UpdatedAt = now
+		if certificate.Status == "" {
+			certificate.Status = domain.CertificateActive
+		}
+		reported[key(&certificate)] = true
+		if current, ok := onFile[key(&certificate)]; ok {
+			certificate.ID = current.ID
+			certificate.AttachmentID = current.AttachmentID
+			certificate.CreatedAt = current.CreatedAt
+		} else {
+			certificate.ID = uuid.New()
+			certificate.CreatedAt = now
+		}
+		if certificate.Status == domain.CertificateCancelled && certificate.CancelledAt == nil {
+			certificate.CancelledAt = &now
+		}
+		changed = append(changed, certificate)
+	}
+
+	for k, certificate := range onFile {
+		if reported[k] || certificate.Status == domain.CertificateCancelled {
+			continue
+		}
+		cancelled := *certificate
+		cancelled.Status = domain.CertificateCancelled
+		cancelled.CancelledAt = &now
+		cancelled.CancelReason = "no longer reported by " + verification.Provider
+		cancelled.UpdatedAt = now
+		changed = append(changed, cancelled)
+	}
+	return changed
+}
+
+// renewalOnFile reports whether another active certificate of the same
+// coverage takes over by the time this one expires
+func renewalOnFile(certificates []domain.InsuranceCertificate, certificate *domain.InsuranceCertificate) bool {
+	for i := range certificates {
+		other := &certificates[i]
+		if other.ID != certificate.ID && other.Coverage == certificate.Coverage && other.Status == domain.CertificateActive &&
+			!other.EffectiveDate.After(certificate.ExpirationDate) && other.ExpirationDate.After(certificate.ExpirationDate) {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownCoverage(coverage string) bool {
+	switch coverage {
+	case config.CoverageAutoLiability, config.CoverageCargo, config.CoveragePhysicalDamage,
+		config.CoverageNonTruckingLiability, config.CoverageOccupationalAccident:
+		return true
+	}
+	return false
+}
+
+// normalizeMCNumber strips the MC prefix carriers often write their docket number with
+func normalizeMCNumber(mc string) string {
+	mc = strings.ToUpper(strings.TrimSpace(mc))
+	mc = strings.TrimPrefix(mc, "MC")
+	return strings.TrimLeft(mc, "-# ")
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// checkRateConfirmations blocks trips carrying brokered orders whose broker
+// requires a signed rate confirmation before the load moves
+func (s *DispatchService) checkRateConfirmations(ctx context.Context, trip *domain.Trip) error {
+	var orderIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for i := range trip.Stops {
+		orderID := trip.Stops[i].OrderID
+		if orderID == nil || seen[*orderID] {
+			continue
+		}
+		seen[*orderID] = true
+		orderIDs = append(orderIDs, *orderID)
+	}
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	unsigned, err := s.orderRepo.GetUnsignedRateConfirmations(ctx, orderIDs)
+	if err != nil {
+		return apperrors.DatabaseError("get rate confirmation status", err)
+	}
+
+	var orders []string
+	for _, id := range orderIDs {
+		if number, ok := unsigned[id]; ok {
+			orders = append(orders, number)
+		}
+	}
+	if len(orders) > 0 {
+		s.logger.Warnw("Dispatch blocked without signed rate confirmation",
+			"trip_id", trip.ID,
+			"orders", orders,
+		)
+		return apperrors.New("RATE_CONFIRMATION_UNSIGNED", "broker has not signed the rate confirmation").
+			WithDetail("orders", orders)
+	}
+
+	return nil
+}
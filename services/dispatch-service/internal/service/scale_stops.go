@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// isLoadedPickup checks if the container leaves this stop loaded
+func isLoadedPickup(activity domain.ActivityType) bool {
+	return activity == domain.ActivityTypePickupLoaded || activity == domain.ActivityTypeLiveLoad
+}
+
+// routeStates returns the distinct states the trip's stops are in
+func routeStates(stops []CreateStopInput, locations map[uuid.UUID]*domain.Location) []string {
+	seen := make(map[string]bool)
+	var states []string
+	for _, stop := range stops {
+		loc := locations[stop.LocationID]
+		if loc == nil || loc.State == "" || seen[loc.State] {
+			continue
+		}
+		seen[loc.State] = true
+		states = append(states, loc.State)
+	}
+	return states
+}
+
+// insertScaleStops adds a SCALE stop at the nearest certified scale right after
+// each loaded pickup whose container must be weighed on this route, then
+// renumbers the stops. Containers that already have a scale stop are left alone.
+func (s *EnhancedDispatchService) insertScaleStops(ctx context.Context, stops []CreateStopInput, locations map[uuid.UUID]*domain.Location) ([]CreateStopInput, error) {
+	rules := &s.businessRules.Scale
+
+	sorted := make([]CreateStopInput, len(stops))
+	copy(sorted, stops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sequence < sorted[j].Sequence
+	})
+
+	scaled := make(map[uuid.UUID]bool)
+	var containerIDs []uuid.UUID
+	for _, stop := range sorted {
+		if stop.ContainerID == nil {
+			continue
+		}
+		if stop.Activity == domain.ActivityTypeScale {
+			scaled[*stop.ContainerID] = true
+		} else if isLoadedPickup(stop.Activity) {
+			containerIDs = append(containerIDs, *stop.ContainerID)
+		}
+	}
+	if len(containerIDs) == 0 {
+		return stops, nil
+	}
+
+	loads, err := s.containerRepo.GetLoads(ctx, containerIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get container loads", err)
+	}
+
+	threshold := rules.ThresholdLbs(routeStates(sorted, locations))
+	result := make([]CreateStopInput, 0, len(sorted)+len(containerIDs))
+	inserted := 0
+
+	for _, stop := range sorted {
+		result = append(result, stop)
+		if stop.ContainerID == nil || scaled[*stop.ContainerID] || !isLoadedPickup(stop.Activity) {
+			continue
+		}
+		load, ok := loads[*stop.ContainerID]
+		if !ok {
+			continue
+		}
+
+		var reason string
+		switch {
+		case rules.HazardousWasteRequiresScale && load.IsHazardousWaste:
+			reason = "hazardous waste requires a certified weight"
+		case load.GrossWeightLbs > threshold:
+			reason = fmt.Sprintf("gross weight %d lbs exceeds %d lbs route threshold", load.GrossWeightLbs, threshold)
+		default:
+			continue
+		}
+
+		origin := locations[stop.LocationID]
+		scale, err := s.locationRepo.FindNearestScale(ctx, origin.Latitude, origin.Longitude, rules.MaxScaleDistanceMiles)
+		if err != nil {
+			return nil, apperrors.DatabaseError("find certified scale", err)
+		}
+		if scale == nil {
+			return nil, apperrors.New("SCALE_REQUIRED", "no certified scale within range of the pickup").
+				WithDetail("container_number", load.ContainerNumber).
+				WithDetail("reason", reason).
+				WithDetail("radius_miles", rules.MaxScaleDistanceMiles)
+		}
+		locations[scale.ID] = scale
+
+		result = append(result, CreateStopInput{
+			Type:                  domain.StopTypeWaypoint,
+			Activity:              domain.ActivityTypeScale,
+			LocationID:            scale.ID,
+			ContainerID:           stop.ContainerID,
+			OrderID:               stop.OrderID,
+			EstimatedDurationMins: rules.ScaleStopDurationMins,
+			Notes:                 "Auto-inserted: " + reason,
+		})
+		scaled[*stop.ContainerID] = true
+		inserted++
+
+		s.logger.Infow("Scale stop inserted",
+			"container", load.ContainerNumber,
+			"scale", scale.Name,
+			"reason", reason,
+		)
+	}
+
+	if inserted == 0 {
+		return stops, nil
+	}
+	for i := range result {
+		result[i].Sequence = i + 1
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// VerifySealInput contains the seal a driver observed on a container at pickup
+type VerifySealInput struct {
+	TripID          uuid.UUID
+	StopID          uuid.UUID
+	ObservedSeal    string
+	PhotoDocumentID string // Photo of the seal on the container doors
+	VerifiedBy      string
+}
+
+// VerifySeal compares the seal a driver observed at a loaded pickup with the
+// seal on the shipment. A mismatch raises an exception, notifies the customer
+// and holds the container at the pickup until dispatch releases it.
+func (s *DispatchService) VerifySeal(ctx context.Context, input VerifySealInput) (*domain.TripStop, error) {
+	rules := &s.businessRules.Seal
+
+	if domain.NormalizeSealNumber(input.ObservedSeal) == "" {
+		return nil, apperrors.ValidationError("observed seal number is required", "observed_seal", input.ObservedSeal)
+	}
+	if rules.RequirePhoto && input.PhotoDocumentID == "" {
+		return nil, apperrors.ValidationError("a photo of the seal is required", "photo_document_id", input.PhotoDocumentID)
+	}
+
+	stop, err := s.stopRepo.GetByID(ctx, input.StopID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("stop", input.StopID.String())
+	}
+	if stop.TripID != input.TripID {
+		return nil, fmt.Errorf("stop does not belong to trip")
+	}
+	if !isLoadedPickup(stop.Activity) || stop.ContainerID == nil {
+		return nil, apperrors.ValidationError("seals are only verified when picking up a loaded container", "stop_id", stop.ID.String())
+	}
+	if stop.Status == domain.StopStatusCompleted {
+		return nil, apperrors.InvalidStateError(string(stop.Status), "not completed")
+	}
+
+	if stop.ExpectedSealNumber == "" {
+		seals, err := s.containerRepo.GetSealNumbers(ctx, []uuid.UUID{*stop.ContainerID})
+		if err != nil {
+			return nil, apperrors.DatabaseError("get shipment seal", err)
+		}
+		stop.ExpectedSealNumber = seals[*stop.ContainerID]
+	}
+
+	now := time.Now()
+	stop.SealNumber = input.ObservedSeal
+	stop.SealPhotoDocumentID = input.PhotoDocumentID
+	stop.SealVerifiedAt = &now
+	stop.SealVerifiedBy = input.VerifiedBy
+
+	// Without a seal on the shipment the driver's reading becomes the record
+	mismatch := stop.ExpectedSealNumber != "" && !domain.SealNumbersMatch(stop.ExpectedSealNumber, input.ObservedSeal)
+	if mismatch {
+		stop.SealStatus = domain.SealStatusMismatch
+	} else {
+		stop.SealStatus = domain.SealStatusVerified
+	}
+
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		return nil, stopUpdateError(ctx, s.stopRepo, stop.ID, "verify seal", err)
+	}
+
+	if mismatch {
+		s.raiseSealMismatch(ctx, stop)
+	}
+
+	s.logger.Infow("Seal verified",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"container", stop.ContainerNumber,
+		"status", stop.SealStatus,
+	)
+	return stop, nil
+}
+
+// ReleaseSealMismatch lets a container with a mismatched seal leave the
+// pickup once dispatch has confirmed the seal with the customer or shipper
+func (s *DispatchService) ReleaseSealMismatch(ctx context.Context, stopID uuid.UUID, releasedBy, reason string) (*domain.TripStop, error) {
+	if reason == "" {
+		return nil, apperrors.ValidationError("a reason is required to release a seal mismatch", "reason", reason)
+	}
+
+	stop, err := s.stopRepo.GetByID(ctx, stopID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("stop", stopID.String())
+	}
+	if stop.SealStatus != domain.SealStatusMismatch {
+		return nil, apperrors.InvalidStateError(string(stop.SealStatus), string(domain.SealStatusMismatch))
+	}
+
+	stop.SealStatus = domain.SealStatusOverridden
+	stop.Notes = appendNote(stop.Notes, fmt.Sprintf("Seal mismatch released by %s: %s", releasedBy, reason))
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		return nil, stopUpdateError(ctx, s.stopRepo, stop.ID, "release seal mismatch", err)
+	}
+
+	s.logger.Infow("Seal mismatch released",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"released_by", releasedBy,
+	)
+	return stop, nil
+}
+
+// checkSealCleared blocks completing a loaded pickup until the container's
+// seal has been verified or a mismatch released
+func (s *DispatchService) checkSealCleared(stop *domain.TripStop) error {
+	if !s.businessRules.Seal.RequireVerification || !isLoadedPickup(stop.Activity) || stop.ContainerID == nil {
+		return nil
+	}
+	if stop.SealCleared() {
+		return nil
+	}
+	if stop.SealStatus == domain.SealStatusMismatch {
+		return apperrors.New("SEAL_MISMATCH", "container seal does not match the shipment; dispatch must release it before departure").
+			WithDetail("container_number", stop.ContainerNumber).
+			WithDetail("expected_seal", stop.ExpectedSealNumber).
+			WithDetail("observed_seal", stop.SealNumber)
+	}
+	return apperrors.New("SEAL_NOT_VERIFIED", "container seal must be verified before departing the pickup").
+		WithDetail("container_number", stop.ContainerNumber)
+}
+
+// raiseSealMismatch opens an exception for a mismatched seal and publishes
+// the mismatch so the customer is notified while the container is still held
+func (s *DispatchService) raiseSealMismatch(ctx context.Context, stop *domain.TripStop) {
+	s.logger.Warnw("Container seal mismatch",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"container", stop.ContainerNumber,
+		"expected_seal", stop.ExpectedSealNumber,
+		"observed_seal", stop.SealNumber,
+	)
+
+	var exceptionID string
+	if s.exceptions != nil {
+		var driverID *uuid.UUID
+		if trip, _ := s.tripRepo.GetByID(ctx, stop.TripID); trip != nil {
+			driverID = trip.DriverID
+		}
+		exception, err := s.exceptions.CreateException(ctx, CreateExceptionInput{
+			TripID:      stop.TripID,
+			StopID:      &stop.ID,
+			OrderID:     stop.OrderID,
+			ContainerID: stop.ContainerID,
+			DriverID:    driverID,
+			Type:        domain.ExceptionTypeSealMismatch,
+			Title:       fmt.Sprintf("Seal mismatch on %s at stop %d", stop.ContainerNumber, stop.Sequence),
+			Description: fmt.Sprintf("Shipment seal is %s but the driver observed %s. The container is held at the pickup until dispatch releases it.",
+				stop.ExpectedSealNumber, stop.SealNumber),
+			LocationID: &stop.LocationID,
+			ReportedBy: stop.SealVerifiedBy,
+			Metadata: map[string]string{
+				"expected_seal":     stop.ExpectedSealNumber,
+				"observed_seal":     stop.SealNumber,
+				"photo_document_id": stop.SealPhotoDocumentID,
+			},
+			OccurredAt: stop.SealVerifiedAt,
+		})
+		if err != nil {
+			s.logger.Errorw("Failed to raise seal mismatch exception",
+				"trip_id", stop.TripID,
+				"stop_id", stop.ID,
+				"error", err,
+			)
+		} else {
+			exceptionID = exception.ID.String()
+		}
+	}
+
+	data := map[string]interface{}{
+		"trip_id":           stop.TripID.String(),
+		"stop_id":           stop.ID.String(),
+		"location_id":       stop.LocationID.String(),
+		"container_number":  stop.ContainerNumber,
+		"expected_seal":     stop.ExpectedSealNumber,
+		"observed_seal":     stop.SealNumber,
+		"photo_document_id": stop.SealPhotoDocumentID,
+		"exception_id":      exceptionID,
+		"observed_at":       stop.SealVerifiedAt,
+	}
+	if stop.OrderID != nil {
+		data["order_id"] = stop.OrderID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.SealMismatch, "dispatch-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.SealMismatch, event)
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const smsHelpText = "Reply ARR when you arrive, DEP when you leave (add gate ticket # if any), EMPTY when the container is unloaded."
+
+// SMSGateway sends text messages through an SMS provider
+type SMSGateway interface {
+	Send(ctx context.Context, to, body string) (providerMessageID string, err error)
+}
+
+// SMSService runs the text message workflow for drivers without the mobile app
+type SMSService struct {
+	dispatch      *DispatchService
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	driverRepo    repository.DriverRepository
+	locationRepo  repository.LocationRepository
+	smsRepo       repository.SMSRepository
+	gateway       SMSGateway
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewSMSService creates a new SMS service
+func NewSMSService(
+	dispatch *DispatchService,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	locationRepo repository.LocationRepository,
+	smsRepo repository.SMSRepository,
+	gateway SMSGateway,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *SMSService {
+	return &SMSService{
+		dispatch:      dispatch,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		smsRepo:       smsRepo,
+		gateway:       gateway,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// SendTripDetails texts the stop list of a trip to its assigned driver
+func (s *SMSService) SendTripDetails(ctx context.Context, tripID uuid.UUID, sentBy string) (*domain.SMSMessage, error) {
+	trip, err := s.dispatch.GetTrip(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	if trip.DriverID == nil {
+		return nil, apperrors.ValidationError("trip has no driver assigned", "driver_id", nil)
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, *trip.DriverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", trip.DriverID.String())
+	}
+	phone := domain.NormalizePhone(driver.Phone)
+	if phone == "" {
+		return nil, apperrors.ValidationError("driver has no phone number", "phone", driver.Phone)
+	}
+
+	body, err := s.formatTripDetails(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.send(ctx, phone, body, &driver.ID, &trip.ID, nil, sentBy)
+}
+
+// SendStopReminder texts the trip's driver that a stop is still open, asking
+// them to reply with the update they missed
+func (s *SMSService) SendStopReminder(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, sentBy string) (*domain.SMSMessage, error) {
+	if trip.DriverID == nil {
+		return nil, apperrors.ValidationError("trip has no driver assigned", "driver_id", nil)
+	}
+	driver, err := s.driverRepo.GetByID(ctx, *trip.DriverID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("driver", trip.DriverID.String())
+	}
+	phone := domain.NormalizePhone(driver.Phone)
+	if phone == "" {
+		return nil, apperrors.ValidationError("driver has no phone number", "phone", driver.Phone)
+	}
+
+	name := stop.LocationID.String()
+	if location, err := s.locationRepo.GetByID(ctx, stop.LocationID); err == nil {
+		name = location.Name
+	}
+	reply := "ARR when you get there, DEP when you leave"
+	if stop.Status == domain.StopStatusArrived || stop.Status == domain.StopStatusInProgress {
+		reply = "DEP if you have left"
+	}
+	body := fmt.Sprintf("Trip %s: stop %d %s is still open. Reply %s, or call dispatch.",
+		trip.TripNumber, stop.Sequence, name, reply)
+
+	return s.send(ctx, phone, body, &driver.ID, &trip.ID, &stop.ID, sentBy)
+}
+
+// formatTripDetails builds a compact, text-friendly stop list
+func (s *SMSService) formatTripDetails(ctx context.Context, trip *domain.Trip) (string, error) {
+	stops := trip.Stops
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Trip %s", trip.TripNumber)
+	if trip.PlannedStartTime != nil {
+		fmt.Fprintf(&b, " start %s", trip.PlannedStartTime.Format("01/02 15:04"))
+	}
+	b.WriteString("\n")
+
+	for i := range stops {
+		stop := &stops[i]
+		location, err := s.locationRepo.GetByID(ctx, stop.LocationID)
+		if err != nil {
+			return "", apperrors.NotFoundError("location", stop.LocationID.String())
+		}
+
+		fmt.Fprintf(&b, "%d) %s - %s", stop.Sequence, strings.ReplaceAll(string(stop.Activity), "_", " "), location.Name)
+		if location.Address != "" {
+			fmt.Fprintf(&b, ", %s, %s", location.Address, location.City)
+		}
+		if stop.ContainerNumber != "" {
+			fmt.Fprintf(&b, " CTR %s", stop.ContainerNumber)
+		}
+		if stop.AppointmentTime != nil {
+			fmt.Fprintf(&b, " APPT %s", stop.AppointmentTime.Format("01/02 15:04"))
+			if stop.AppointmentNumber != "" {
+				fmt.Fprintf(&b, " #%s", stop.AppointmentNumber)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(smsHelpText)
+	return b.String(), nil
+}
+
+// InboundSMS is a text received from the SMS provider webhook
+type InboundSMS struct {
+	From              string
+	Body              string
+	ProviderMessageID string
+	ReceivedAt        time.Time
+}
+
+// HandleInbound parses a driver reply into a stop update and texts back a
+// confirmation. Every inbound and outbound message is audited.
+func (s *SMSService) HandleInbound(ctx context.Context, msg InboundSMS) (*domain.SMSMessage, error) {
+	if msg.ReceivedAt.IsZero() {
+		msg.ReceivedAt = time.Now()
+	}
+
+	record := &domain.SMSMessage{
+		ID:                uuid.New(),
+		Direction:         domain.SMSDirectionInbound,
+		Phone:             domain.NormalizePhone(msg.From),
+		Body:              msg.Body,
+		Status:            domain.SMSStatusProcessed,
+		ProviderMessageID: msg.ProviderMessageID,
+		CreatedAt:         msg.ReceivedAt,
+	}
+
+	reply, procErr := s.processInbound(ctx, record, msg)
+	if procErr != nil {
+		record.Status = domain.SMSStatusRejected
+		record.Error = procErr.Error()
+	}
+
+	if err := s.smsRepo.Create(ctx, record); err != nil {
+		return nil, apperrors.DatabaseError("create sms audit", err)
+	}
+
+	s.logger.Infow("Driver SMS received",
+		"phone", record.Phone,
+		"driver_id", record.DriverID,
+		"keyword", record.Keyword,
+		"status", record.Status,
+	)
+
+	if reply != "" && record.Phone != "" {
+		if _, err := s.send(ctx, record.Phone, reply, record.DriverID, record.TripID, record.StopID, "system"); err != nil {
+			s.logger.Warnw("Failed to send SMS reply", "phone", record.Phone, "error", err)
+		}
+	}
+
+	return record, nil
+}
+
+// processInbound applies a reply and returns the confirmation text
+func (s *SMSService) processInbound(ctx context.Context, record *domain.SMSMessage, msg InboundSMS) (string, error) {
+	driver, err := s.driverRepo.GetByPhone(ctx, record.Phone)
+	if err != nil || driver == nil {
+		return "", fmt.Errorf("phone number not mapped to a driver")
+	}
+	record.DriverID = &driver.ID
+
+	keyword, extra, ok := domain.ParseSMSReply(msg.Body)
+	if !ok {
+		return "Sorry, we didn't understand that. " + smsHelpText, fmt.Errorf("unrecognized reply")
+	}
+	record.Keyword = keyword
+	if keyword == domain.SMSKeywordHelp {
+		return smsHelpText, nil
+	}
+
+	trip, stop, err := s.currentStop(ctx, driver.ID)
+	if err != nil {
+		return "No active trip found. Call dispatch.", err
+	}
+	record.TripID = &trip.ID
+	record.StopID = &stop.ID
+
+	switch keyword {
+	case domain.SMSKeywordArrived:
+		if stop.Status == domain.StopStatusArrived || stop.Status == domain.StopStatusInProgress {
+			return fmt.Sprintf("Arrival at stop %d already recorded.", stop.Sequence), nil
+		}
+		if _, err := s.dispatch.RecordStopArrival(ctx, trip.ID, stop.ID, msg.ReceivedAt,
+			driver.CurrentLatitude, driver.CurrentLongitude); err != nil {
+			return "Could not record arrival. Call dispatch.", err
+		}
+		return fmt.Sprintf("Arrival at stop %d recorded %s.", stop.Sequence, msg.ReceivedAt.Format("15:04")), nil
+
+	case domain.SMSKeywordDeparted:
+		if stop.Status == domain.StopStatusPending || stop.Status == domain.StopStatusEnRoute {
+			return fmt.Sprintf("Reply ARR for stop %d before DEP.", stop.Sequence), fmt.Errorf("departure before arrival")
+		}
+		notes := stop.Notes
+		if extra != "" {
+			notes = strings.TrimSpace(notes + "\nSMS: " + extra)
+		}
+		if _, err := s.dispatch.CompleteStop(ctx, CompleteStopInput{
+			TripID:           trip.ID,
+			StopID:           stop.ID,
+			DepartureTime:    msg.ReceivedAt,
+			GateTicketNumber: extra,
+			Notes:            notes,
+			Latitude:         driver.CurrentLatitude,
+			Longitude:        driver.CurrentLongitude,
+		}); err != nil {
+			return "Could not record departure. Call dispatch.", err
+		}
+		return s.nextStopText(ctx, trip.ID, stop.Sequence), nil
+
+	case domain.SMSKeywordEmpty:
+		if stop.ContainerID == nil && stop.ContainerNumber == "" {
+			return "No container on this stop. Call dispatch.", fmt.Errorf("stop has no container")
+		}
+		s.publishEmpty(ctx, trip, stop, msg.ReceivedAt)
+		return fmt.Sprintf("Container %s marked empty.", stop.ContainerNumber), nil
+	}
+
+	return "", nil
+}
+
+// currentStop finds the driver's active trip and the first stop not yet done
+func (s *SMSService) currentStop(ctx context.Context, driverID uuid.UUID) (*domain.Trip, *domain.TripStop, error) {
+	trips, _, err := s.tripRepo.List(ctx, repository.TripFilter{
+		DriverID: &driverID,
+		Status:   []domain.TripStatus{domain.TripStatusDispatched, domain.TripStatusEnRoute, domain.TripStatusInProgress},
+		PageSize: 1,
+		SortBy:   "planned_start_time",
+	})
+	if err != nil {
+		return nil, nil, apperrors.DatabaseError("list driver trips", err)
+	}
+	if len(trips) == 0 {
+		return nil, nil, fmt.Errorf("driver has no active trip")
+	}
+	trip := &trips[0]
+
+	stops, err := s.stopRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, nil, apperrors.DatabaseError("get stops", err)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+	for i := range stops {
+		switch stops[i].Status {
+		case domain.StopStatusCompleted, domain.StopStatusSkipped, domain.StopStatusCancelled, domain.StopStatusFailed:
+			continue
+		}
+		return trip, &stops[i], nil
+	}
+	return nil, nil, fmt.Errorf("trip has no open stops")
+}
+
+// nextStopText describes the stop after the one just completed
+func (s *SMSService) nextStopText(ctx context.Context, tripID uuid.UUID, completedSeq int) string {
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return fmt.Sprintf("Departure from stop %d recorded.", completedSeq)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+	for i := range stops {
+		if stops[i].Sequence <= completedSeq || stops[i].Status != domain.StopStatusPending {
+			continue
+		}
+		name := stops[i].LocationID.String()
+		if location, err := s.locationRepo.GetByID(ctx, stops[i].LocationID); err == nil {
+			name = location.Name
+		}
+		return fmt.Sprintf("Departure recorded. Next: stop %d %s - %s.", stops[i].Sequence,
+			strings.ReplaceAll(string(stops[i].Activity), "_", " "), name)
+	}
+	return "Departure recorded. Trip complete, thank you."
+}
+
+// publishEmpty records that the driver reported the container unloaded
+func (s *SMSService) publishEmpty(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, at time.Time) {
+	data := map[string]interface{}{
+		"trip_id":          trip.ID.String(),
+		"stop_id":          stop.ID.String(),
+		"type":             "UNLOADED",
+		"occurred_at":      at,
+		"container_number": stop.ContainerNumber,
+		"reported_via":     "sms",
+	}
+	if stop.ContainerID != nil {
+		data["container_id"] = stop.ContainerID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.MilestoneRecorded, "dispatch-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.MilestoneRecorded, event)
+}
+
+// send texts a driver and audits the outbound message
+func (s *SMSService) send(ctx context.Context, phone, body string, driverID, tripID, stopID *uuid.UUID, sentBy string) (*domain.SMSMessage, error) {
+	record := &domain.SMSMessage{
+		ID:        uuid.New(),
+		Direction: domain.SMSDirectionOutbound,
+		Phone:     phone,
+		DriverID:  driverID,
+		TripID:    tripID,
+		StopID:    stopID,
+		Body:      body,
+		Status:    domain.SMSStatusSent,
+		CreatedBy: sentBy,
+		CreatedAt: time.Now(),
+	}
+
+	providerID, sendErr := s.gateway.Send(ctx, phone, body)
+	if sendErr != nil {
+		record.Status = domain.SMSStatusFailed
+		record.Error = sendErr.Error()
+	}
+	record.ProviderMessageID = providerID
+
+	if err := s.smsRepo.Create(ctx, record); err != nil {
+		return nil, apperrors.DatabaseError("create sms audit", err)
+	}
+	if sendErr != nil {
+		return record, apperrors.ExternalServiceError("sms gateway", sendErr)
+	}
+	return record, nil
+}
+
+// GetTripMessages returns the SMS audit trail for a trip
+func (s *SMSService) GetTripMessages(ctx context.Context, tripID uuid.UUID) ([]domain.SMSMessage, error) {
+	messages, err := s.smsRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get sms messages", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// SplitDeliveryService records what part of a container is delivered at
+// each stop when a shipper splits it across delivery points, and the proof
+// of delivery each consignee signs for their part. Billing shares the
+// container's charges across the stops in proportion to what each received.
+type SplitDeliveryService struct {
+	deliveryRepo  repository.StopDeliveryRepository
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewSplitDeliveryService creates a new split delivery service
+func NewSplitDeliveryService(
+	deliveryRepo repository.StopDeliveryRepository,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *SplitDeliveryService {
+	return &SplitDeliveryService{
+		deliveryRepo:  deliveryRepo,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// StopDeliveryInput is one part of a container delivered at a stop
+type StopDeliveryInput struct {
+	OrderID         *uuid.UUID
+	Commodity       string
+	Pieces          int
+	PieceUnit       string
+	WeightLbs       int
+	ConsigneeName   string
+	ReferenceNumber string
+}
+
+// SetStopDeliveriesInput contains input for recording the parts of a
+// container delivered at a stop. An empty list clears the stop.
+type SetStopDeliveriesInput struct {
+	TripID     uuid.UUID
+	StopID     uuid.UUID
+	Deliveries []StopDeliveryInput
+	UpdatedBy  string
+}
+
+// SetStopDeliveries replaces what is to be delivered at a delivery stop.
+// Records can no longer change once any of them has been signed for.
+func (s *SplitDeliveryService) SetStopDeliveries(ctx context.Context, input SetStopDeliveriesInput) ([]domain.SplitDelivery, error) {
+	stops, err := s.tripStops(ctx, input.TripID)
+	if err != nil {
+		return nil, err
+	}
+	var stop *domain.TripStop
+	for i := range stops {
+		if stops[i].ID == input.StopID {
+			stop = &stops[i]
+		}
+	}
+	if stop == nil {
+		return nil, apperrors.NotFoundError("stop", input.StopID.String())
+	}
+	if stop.Type != domain.StopTypeDelivery {
+		return nil, apperrors.ValidationError("deliveries can only be recorded at a delivery stop", "stop_id", stop.Type)
+	}
+
+	existing, err := s.deliveryRepo.GetByTripID(ctx, input.TripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stop deliveries", err)
+	}
+	for _, d := range existing {
+		if d.StopID == stop.ID && d.HasPOD() {
+			return nil, apperrors.ConflictError("deliveries at this stop have already been signed for").
+				WithDetail("delivery_id", d.ID.String())
+		}
+	}
+
+	now := time.Now()
+	deliveries := make([]domain.StopDelivery, 0, len(input.Deliveries))
+	for i, in := range input.Deliveries {
+		commodity := strings.TrimSpace(in.Commodity)
+		if commodity == "" {
+			return nil, apperrors.ValidationError("commodity is required", "deliveries.commodity", i)
+		}
+		if in.Pieces < 0 || in.WeightLbs < 0 {
+			return nil, apperrors.ValidationError("pieces and weight cannot be negative", "deliveries.pieces", i)
+		}
+		if in.Pieces == 0 && in.WeightLbs == 0 {
+			return nil, apperrors.ValidationError("a delivery needs a piece count or a weight", "deliveries.pieces", i)
+		}
+		orderID := in.OrderID
+		if orderID == nil {
+			orderID = stop.OrderID
+		}
+		deliveries = append(deliveries, domain.StopDelivery{
+			ID:              uuid.New(),
+			TripID:          input.TripID,
+			StopID:          stop.ID,
+			OrderID:         orderID,
+			ContainerID:     stop.ContainerID,
+			ContainerNumber: stop.ContainerNumber,
+			Commodity:       commodity,
+			Pieces:          in.Pieces,
+			PieceUnit:       strings.ToLower(strings.TrimSpace(in.PieceUnit)),
+			WeightLbs:       in.WeightLbs,
+			ConsigneeName:   strings.TrimSpace(in.ConsigneeName),
+			ReferenceNumber: strings.TrimSpace(in.ReferenceNumber),
+			CreatedBy:       input.UpdatedBy,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+	}
+
+	if err := s.deliveryRepo.ReplaceForStop(ctx, stop.ID, deliveries); err != nil {
+		return nil, apperrors.DatabaseError("save stop deliveries", err)
+	}
+
+	kept := deliveries
+	for _, d := range existing {
+		if d.StopID != stop.ID {
+			kept = append(kept, d)
+		}
+	}
+	return domain.BuildSplitDeliveries(stops, kept), nil
+}
+
+// GetTripDeliveries returns the trip's containers with what is delivered at
+// each stop, each stop's share and whether every part has been signed for
+func (s *SplitDeliveryService) GetTripDeliveries(ctx context.Context, tripID uuid.UUID) ([]domain.SplitDelivery, error) {
+	stops, err := s.tripStops(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.deliveryRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stop deliveries", err)
+	}
+	return domain.BuildSplitDeliveries(stops, deliveries), nil
+}
+
+// RecordDeliveryPODInput contains the proof of delivery for one part
+type RecordDeliveryPODInput struct {
+	DeliveryID      uuid.UUID
+	PiecesDelivered *int // As planned when nil
+	DocumentIDs     []string
+	SignedBy        string
+	Notes           string
+	ReceivedAt      *time.Time // Now when nil
+}
+
+// RecordDeliveryPOD records a consignee signing for their part of a split
+// container. The documents are also added to the stop's own POD, so the
+// stop reads as delivered once any part of it is signed for.
+func (s *SplitDeliveryService) RecordDeliveryPOD(ctx context.Context, input RecordDeliveryPODInput) (*domain.StopDelivery, error) {
+	if len(input.DocumentIDs) == 0 {
+		return nil, apperrors.ValidationError("at least one POD document is required", "document_ids", nil)
+	}
+	signedBy := strings.TrimSpace(input.SignedBy)
+	if signedBy == "" {
+		return nil, apperrors.ValidationError("the name of who signed is required", "signed_by", input.SignedBy)
+	}
+	if input.PiecesDelivered != nil && *input.PiecesDelivered < 0 {
+		return nil, apperrors.ValidationError("pieces delivered cannot be negative", "pieces_delivered", *input.PiecesDelivered)
+	}
+
+	delivery, err := s.deliveryRepo.GetByID(ctx, input.DeliveryID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stop delivery", err)
+	}
+	if delivery == nil {
+		return nil, apperrors.NotFoundError("delivery", input.DeliveryID.String())
+	}
+	stop, err := s.stopRepo.GetByID(ctx, delivery.StopID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stop", err)
+	}
+	if stop == nil {
+		return nil, apperrors.NotFoundError("stop", delivery.StopID.String())
+	}
+
+	receivedAt := time.Now()
+	if input.ReceivedAt != nil {
+		receivedAt = *input.ReceivedAt
+	}
+	pieces := delivery.Pieces
+	if input.PiecesDelivered != nil {
+		pieces = *input.PiecesDelivered
+	}
+	delivery.PiecesDelivered = &pieces
+	delivery.PODDocumentIDs = mergeDocumentIDs(delivery.PODDocumentIDs, input.DocumentIDs)
+	delivery.PODSignedBy = signedBy
+	delivery.PODReceivedAt = &receivedAt
+	delivery.PODNotes = strings.TrimSpace(input.Notes)
+	delivery.UpdatedAt = time.Now()
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return nil, apperrors.DatabaseError("record delivery POD", err)
+	}
+
+	stop.PODDocumentIDs = mergeDocumentIDs(stop.PODDocumentIDs, input.DocumentIDs)
+	if stop.PODSignedBy == "" {
+		stop.PODSignedBy = signedBy
+	}
+	if stop.PODReceivedAt == nil {
+		stop.PODReceivedAt = &receivedAt
+	}
+	if err := s.stopRepo.Update(ctx, stop); err != nil {
+		return nil, stopUpdateError(ctx, s.stopRepo, stop.ID, "record proof of delivery", err)
+	}
+
+	s.publishPODRecorded(ctx, delivery)
+	return delivery, nil
+}
+
+// publishPODRecorded tells billing a part was signed for and whether the
+// whole container now has been, so a split can be invoiced per stop
+func (s *SplitDeliveryService) publishPODRecorded(ctx context.Context, delivery *domain.StopDelivery) {
+	containerComplete := false
+	if splits, err := s.GetTripDeliveries(ctx, delivery.TripID); err == nil {
+		for _, split := range splits {
+			for _, stop := range split.Stops {
+				if stop.StopID == delivery.StopID {
+					containerComplete = split.PODComplete
+				}
+			}
+		}
+	}
+
+	event := kafka.NewEvent(kafka.Topics.DeliveryPODRecorded, "dispatch-service", map[string]interface{}{
+		"delivery_id":        delivery.ID.String(),
+		"trip_id":            delivery.TripID.String(),
+		"stop_id":            delivery.StopID.String(),
+		"container_number":   delivery.ContainerNumber,
+		"pieces":             delivery.Pieces,
+		"pieces_delivered":   *delivery.PiecesDelivered,
+		"short_pieces":       delivery.ShortPieces(),
+		"signed_by":          delivery.PODSignedBy,
+		"received_at":        delivery.PODReceivedAt,
+		"container_complete": containerComplete,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.DeliveryPODRecorded, event)
+}
+
+// tripStops returns the stops of an existing trip
+func (s *SplitDeliveryService) tripStops(ctx context.Context, tripID uuid.UUID) ([]domain.TripStop, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip", err)
+	}
+	if trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	return stops, nil
+}
+
+// mergeDocumentIDs appends the document IDs not already in ids
+func mergeDocumentIDs(ids, add []string) []string {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range add {
+		if id != "" && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+	return ids
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/shipmentstatus"
+)
+
+// CheckDetention runs the detention clock on every stop where the driver is
+// still on site. Stops inside the warning lead get one StopDetentionWarning so
+// the driver and dispatcher can act before free time runs out, and each free
+// time threshold passed gets one StopDetentionThreshold for the dispatcher.
+// Stops past free time start detention at the moment it ran out, publishing
+// StopDetentionStarted once per stop. Stops whose truck has left the geofence
+// are no longer timed. It returns how many stops entered detention.
+func (s *DispatchService) CheckDetention(ctx context.Context, now time.Time) (int, error) {
+	stops, err := s.stopRepo.GetOpenArrivals(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	lead := time.Duration(s.businessRules.Detention.WarningLeadMins) * time.Minute
+	trips := make(map[uuid.UUID]*domain.Trip)
+	tripFor := func(id uuid.UUID) *domain.Trip {
+		trip, ok := trips[id]
+		if !ok {
+			trip, _ = s.tripRepo.GetByID(ctx, id)
+			trips[id] = trip
+		}
+		return trip
+	}
+
+	started := 0
+	for i := range stops {
+		stop := &stops[i]
+		if stop.ActualArrival == nil || stop.GeofenceExitedAt != nil || stop.Type == domain.StopTypeWaypoint {
+			continue
+		}
+		start := *stop.FreeTimeEndsAt()
+		threshold := s.detentionThresholdPassed(stop, now)
+		running := stop.DetentionStartTime == nil
+		warn := running && stop.DetentionWarnedAt == nil && now.Before(start) && !now.Before(start.Add(-lead))
+		begin := running && !now.Before(start)
+		if threshold == 0 && !warn && !begin {
+			continue
+		}
+
+		if threshold > 0 {
+			stop.DetentionThresholdPct = threshold
+		}
+		if warn {
+			stop.DetentionWarnedAt = &now
+		}
+		if begin {
+			stop.DetentionStartTime = &start
+		}
+		if err := s.stopRepo.Update(ctx, stop); err != nil {
+			s.logger.Errorw("Failed to update detention clock",
+				"stop_id", stop.ID,
+				"error", err,
+			)
+			continue
+		}
+
+		trip := tripFor(stop.TripID)
+		if warn {
+			s.publishDetentionWarning(ctx, trip, stop, start, now)
+		}
+		if threshold > 0 {
+			s.publishDetentionThreshold(ctx, trip, stop, threshold, now)
+		}
+		if begin {
+			s.publishDetentionStarted(ctx, trip, stop)
+			started++
+		}
+	}
+
+	return started, nil
+}
+
+// detentionThresholdPassed returns the highest free time threshold the stop
+// has passed and not yet been alerted to, or 0. A stop that skipped several
+// thresholds between checks is alerted to the highest only.
+func (s *DispatchService) detentionThresholdPassed(stop *domain.TripStop, now time.Time) int {
+	used := stop.FreeTimeUsedPct(now)
+	passed := 0
+	for _, pct := range s.businessRules.Detention.ThresholdPcts {
+		if pct <= used && pct > stop.DetentionThresholdPct && pct > passed {
+			passed = pct
+		}
+	}
+	return passed
+}
+
+// publishDetentionWarning alerts the driver and dispatcher that free time
+// ends at start
+func (s *DispatchService) publishDetentionWarning(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, start, now time.Time) {
+	payload := kafka.StopDetentionWarningEvent{
+		StopRef:        stopRef(trip, stop),
+		ArrivedAt:      *stop.ActualArrival,
+		FreeTimeMins:   stop.FreeTimeMins,
+		FreeTimeEndsAt: start,
+		RemainingMins:  int(math.Ceil(start.Sub(now).Minutes())),
+	}
+	if trip != nil && trip.DispatcherID != nil {
+		payload.DispatcherID = trip.DispatcherID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.StopDetentionWarning, "dispatch-service", payload)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopDetentionWarning, event)
+
+	s.logger.Infow("Detention warning sent",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"free_time_ends_at", start,
+		"remaining_mins", payload.RemainingMins,
+	)
+}
+
+// publishDetentionThreshold alerts the dispatcher that the stop has used
+// pct of its free time
+func (s *DispatchService) publishDetentionThreshold(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, pct int, now time.Time) {
+	ends := *stop.FreeTimeEndsAt()
+	payload := kafka.StopDetentionThresholdEvent{
+		StopRef:        stopRef(trip, stop),
+		ThresholdPct:   pct,
+		ArrivedAt:      *stop.ActualArrival,
+		ArrivalSource:  stop.ArrivalSource,
+		FreeTimeMins:   stop.FreeTimeMins,
+		FreeTimeEndsAt: ends,
+		ElapsedMins:    int(now.Sub(*stop.ActualArrival).Minutes()),
+	}
+	if remaining := ends.Sub(now); remaining > 0 {
+		payload.RemainingMins = int(math.Ceil(remaining.Minutes()))
+	}
+	if trip != nil && trip.DispatcherID != nil {
+		payload.DispatcherID = trip.DispatcherID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.StopDetentionThreshold, "dispatch-service", payload)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopDetentionThreshold, event)
+
+	s.logger.Infow("Detention threshold passed",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"threshold_pct", pct,
+		"remaining_mins", payload.RemainingMins,
+	)
+}
+
+// StartDetentionMonitor checks open stops for detention until ctx is cancelled
+func (s *DispatchService) StartDetentionMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.CheckDetention(ctx, time.Now()); err != nil {
+			s.logger.Errorw("Detention check failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DispatchService) publishStopArrived(ctx context.Context, trip *domain.Trip, stop *domain.TripStop) {
+	payload := kafka.StopArrivedEvent{
+		StopRef:          stopRef(trip, stop),
+		ArrivedAt:        *stop.ActualArrival,
+		AppointmentTime:  stop.AppointmentTime,
+		FreeTimeMins:     stop.FreeTimeMins,
+		Latitude:         stop.ArrivalLatitude,
+		Longitude:        stop.ArrivalLongitude,
+		LocationMismatch: stop.LocationMismatch,
+	}
+	if stop.AppointmentTime != nil {
+		windowEnd := stop.AppointmentTime.Add(time.Duration(stop.AppointmentWindowMins) * time.Minute)
+		if late := int(stop.ActualArrival.Sub(windowEnd).Minutes()); late > 0 {
+			payload.MinutesLate = late
+		}
+	}
+
+	event := kafka.NewEvent(kafka.Topics.StopArrived, "dispatch-service", payload)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopArrived, event)
+
+	s.publishShipmentStatus(ctx, trip, stop, arrivalMilestones(stop), false, *stop.ActualArrival)
+}
+
+func (s *DispatchService) publishStopDeparted(ctx context.Context, trip *domain.Trip, stop *domain.TripStop) {
+	payload := kafka.StopDepartedEvent{
+		StopRef:          stopRef(trip, stop),
+		ArrivedAt:        stop.ActualArrival,
+		DepartedAt:       *stop.ActualDeparture,
+		DwellMins:        stop.ActualDurationMins,
+		FreeTimeMins:     stop.FreeTimeMins,
+		DetentionMins:    stop.DetentionMins,
+		GateTicketNumber: stop.GateTicketNumber,
+		SealNumber:       stop.SealNumber,
+		ContainerState:   containerStateAfter(stop),
+		LocationMismatch: stop.LocationMismatch,
+	}
+	if stop.ChassisInID != nil {
+		payload.ChassisInID = stop.ChassisInID.String()
+	}
+	if stop.ChassisOutID != nil {
+		payload.ChassisID = stop.ChassisOutID.String()
+	}
+	if stop.DetentionMins > 0 {
+		payload.DetentionStartedAt = stop.DetentionStartTime
+	}
+
+	event := kafka.NewEvent(kafka.Topics.StopDeparted, "dispatch-service", payload)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopDeparted, event)
+
+	location, _ := s.locationRepo.GetByID(ctx, stop.LocationID)
+	atTerminal := location != nil && strings.EqualFold(location.Type, "terminal")
+	empty := false
+	switch payload.ContainerState {
+	case kafka.ContainerStateEmptyOnChassis, kafka.ContainerStateDroppedEmpty, kafka.ContainerStateReturned:
+		empty = true
+	}
+	s.publishShipmentStatus(ctx, trip, stop, departureMilestones(stop, atTerminal), empty, *stop.ActualDeparture)
+}
+
+// publishShipmentStatus publishes the industry status codes for a stop's
+// milestones, in order. Empty says whether the container left the stop empty.
+func (s *DispatchService) publishShipmentStatus(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, milestones []shipmentstatus.Milestone, empty bool, at time.Time) {
+	for _, milestone := range milestones {
+		status, ok := shipmentstatus.Translate(milestone, empty)
+		if !ok {
+			continue
+		}
+		payload := kafka.ShipmentStatusEvent{
+			StopRef:        stopRef(trip, stop),
+			Milestone:      string(status.Milestone),
+			X12Code:        status.X12Code,
+			X12Reason:      status.X12Reason,
+			X12Description: status.X12Description,
+			OccurredAt:     at,
+		}
+		if status.DCSA != nil {
+			payload.DCSAEventType = status.DCSA.EventType
+			payload.DCSAEventTypeCode = status.DCSA.EventTypeCode
+			payload.DCSAClassifierCode = status.DCSA.EventClassifierCode
+			payload.DCSAEmptyIndicator = status.DCSA.EmptyIndicatorCode
+		}
+		event := kafka.NewEvent(kafka.Topics.ShipmentStatus, "dispatch-service", payload)
+		_ = s.eventProducer.Publish(ctx, kafka.Topics.ShipmentStatus, event)
+	}
+}
+
+// arrivalMilestones are the shipment milestones reported when a driver
+// arrives at a stop
+func arrivalMilestones(stop *domain.TripStop) []shipmentstatus.Milestone {
+	switch stop.Type {
+	case domain.StopTypePickup:
+		return []shipmentstatus.Milestone{shipmentstatus.MilestoneArrivedPickup}
+	case domain.StopTypeDelivery, domain.StopTypeReturn:
+		return []shipmentstatus.Milestone{shipmentstatus.MilestoneArrivedDelivery}
+	}
+	return nil
+}
+
+// departureMilestones are the shipment milestones reported when a driver
+// leaves a stop: the work done there, then the departure itself. At a port
+// terminal the work is reported as the gate move.
+func departureMilestones(stop *domain.TripStop, atTerminal bool) []shipmentstatus.Milestone {
+	var milestones []shipmentstatus.Milestone
+	switch stop.Activity {
+	case domain.ActivityTypePickupLoaded, domain.ActivityTypePickupEmpty:
+		if atTerminal {
+			milestones = append(milestones, shipmentstatus.MilestoneGateOut)
+		}
+	case domain.ActivityTypeDropLoaded, domain.ActivityTypeDropEmpty:
+		if atTerminal {
+			milestones = append(milestones, shipmentstatus.MilestoneGateIn)
+		} else if stop.Activity == domain.ActivityTypeDropLoaded {
+			milestones = append(milestones, shipmentstatus.MilestoneSpotted)
+		}
+	case domain.ActivityTypeDeliverLoaded:
+		if atTerminal {
+			milestones = append(milestones, shipmentstatus.MilestoneGateIn)
+		} else {
+			milestones = append(milestones, shipmentstatus.MilestoneUnloaded)
+		}
+	case domain.ActivityTypeLiveLoad:
+		milestones = append(milestones, shipmentstatus.MilestoneLoaded)
+	case domain.ActivityTypeLiveUnload:
+		milestones = append(milestones, shipmentstatus.MilestoneUnloaded)
+	}
+
+	switch stop.Type {
+	case domain.StopTypePickup:
+		milestones = append(milestones, shipmentstatus.MilestoneDepartedPickup)
+	case domain.StopTypeDelivery, domain.StopTypeReturn:
+		milestones = append(milestones, shipmentstatus.MilestoneDepartedDelivery)
+	}
+	return milestones
+}
+
+func (s *DispatchService) publishDetentionStarted(ctx context.Context, trip *domain.Trip, stop *domain.TripStop) {
+	event := kafka.NewEvent(kafka.Topics.StopDetentionStarted, "dispatch-service", kafka.StopDetentionStartedEvent{
+		StopRef:            stopRef(trip, stop),
+		ArrivedAt:          *stop.ActualArrival,
+		FreeTimeMins:       stop.FreeTimeMins,
+		DetentionStartedAt: *stop.DetentionStartTime,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.StopDetentionStarted, event)
+
+	s.logger.Infow("Detention started",
+		"trip_id", stop.TripID,
+		"stop_id", stop.ID,
+		"started_at", *stop.DetentionStartTime,
+	)
+}
+
+// stopRef builds the identifying part of a stop event. trip may be nil when
+// it could not be loaded; the trip number and driver are then left empty.
+func stopRef(trip *domain.Trip, stop *domain.TripStop) kafka.StopRef {
+	ref := kafka.StopRef{
+		TripID:          stop.TripID.String(),
+		StopID:          stop.ID.String(),
+		Sequence:        stop.Sequence,
+		StopType:        string(stop.Type),
+		Activity:        string(stop.Activity),
+		LocationID:      stop.LocationID.String(),
+		ContainerNumber: stop.ContainerNumber,
+	}
+	if trip != nil {
+		ref.TripNumber = trip.TripNumber
+		if trip.DriverID != nil {
+			ref.DriverID = trip.DriverID.String()
+		}
+	}
+	if stop.OrderID != nil {
+		ref.OrderID = stop.OrderID.String()
+	}
+	if stop.ContainerID != nil {
+		ref.ContainerID = stop.ContainerID.String()
+	}
+	return ref
+}
+
+// containerStateAfter is the container's state once the stop's activity is
+// done, or empty for stops that do not move a container
+func containerStateAfter(stop *domain.TripStop) string {
+	switch stop.Activity {
+	case domain.ActivityTypePickupLoaded, domain.ActivityTypeLiveLoad:
+		return kafka.ContainerStateLoadedOnChassis
+	case domain.ActivityTypePickupEmpty, domain.ActivityTypeHookEmpty, domain.ActivityTypeLiveUnload:
+		return kafka.ContainerStateEmptyOnChassis
+	case domain.ActivityTypeDeliverLoaded:
+		return kafka.ContainerStateDelivered
+	case domain.ActivityTypeDropLoaded:
+		return kafka.ContainerStateDroppedLoaded
+	case domain.ActivityTypeDropEmpty:
+		if stop.Type == domain.StopTypeReturn {
+			return kafka.ContainerStateReturned
+		}
+		return kafka.ContainerStateDroppedEmpty
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// GetTripCost returns what a trip has cost so far and its live margin
+func (s *DispatchService) GetTripCost(ctx context.Context, tripID uuid.UUID) (*domain.TripCostAccrual, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip", err)
+	}
+	if trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	stops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	return s.accrueTripCost(trip, stops, time.Now()), nil
+}
+
+// accrueTripCosts accrues the cost of every started trip in trips, keyed
+// by trip. Trips not yet under way have nothing to accrue and are left out.
+func (s *DispatchService) accrueTripCosts(ctx context.Context, trips []domain.Trip) (map[uuid.UUID]*domain.TripCostAccrual, error) {
+	var tripIDs []uuid.UUID
+	for i := range trips {
+		if trips[i].ActualStartTime != nil {
+			tripIDs = append(tripIDs, trips[i].ID)
+		}
+	}
+	accruals := make(map[uuid.UUID]*domain.TripCostAccrual, len(tripIDs))
+	if len(tripIDs) == 0 {
+		return accruals, nil
+	}
+
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	stopsByTrip := make(map[uuid.UUID][]domain.TripStop)
+	for _, stop := range stops {
+		stopsByTrip[stop.TripID] = append(stopsByTrip[stop.TripID], stop)
+	}
+
+	now := time.Now()
+	for i := range trips {
+		if trips[i].ActualStartTime != nil {
+			accruals[trips[i].ID] = s.accrueTripCost(&trips[i], stopsByTrip[trips[i].ID], now)
+		}
+	}
+	return accruals, nil
+}
+
+// accrueTripCost prices a trip as it stands at now: driver time from the
+// trip's start, miles completed at the fuel estimate and per-mile cost,
+// and detention earned at each stop. A driver still on site at a stop
+// also gets the margin of waiting another hour and of a dry run.
+func (s *DispatchService) accrueTripCost(trip *domain.Trip, stops []domain.TripStop, now time.Time) *domain.TripCostAccrual {
+	rules := s.businessRules.TripCost
+	detention := s.businessRules.Detention
+
+	end := now
+	if trip.ActualEndTime != nil {
+		end = *trip.ActualEndTime
+	}
+	accrual := &domain.TripCostAccrual{
+		TripID:     trip.ID,
+		TripNumber: trip.TripNumber,
+		AsOf:       now,
+		Revenue:    trip.Revenue,
+	}
+	if trip.ActualStartTime != nil && end.After(*trip.ActualStartTime) {
+		accrual.DriverMins = int(end.Sub(*trip.ActualStartTime).Minutes())
+	}
+	accrual.MilesDriven = trip.CompletedMiles
+	if trip.Status == domain.TripStatusCompleted && accrual.MilesDriven == 0 {
+		accrual.MilesDriven = trip.TotalMiles
+	}
+
+	var waiting *domain.TripStop
+	var waitingMins int
+	var waitingCharge float64
+	for i := range stops {
+		stop := &stops[i]
+		if stop.ActualArrival == nil {
+			continue
+		}
+		if stop.ActualDeparture != nil && stop.DetentionMins > 0 {
+			accrual.DetentionMins += stop.DetentionMins
+			accrual.DetentionRevenue += stop.DetentionCharge
+			continue
+		}
+
+		left := stop.ActualDeparture
+		if left == nil {
+			if trip.ActualEndTime != nil {
+				continue
+			}
+			left = &now
+			waiting = stop
+		}
+		onSite := int(left.Sub(*stop.ActualArrival).Minutes())
+		charge, mins := detention.Calculate(onSite, stop.FreeTimeMins)
+		accrual.DetentionMins += mins
+		accrual.DetentionRevenue += charge
+		if waiting == stop {
+			waitingMins, waitingCharge = onSite, charge
+		}
+	}
+
+	accrual.DriverCost = round2(float64(accrual.DriverMins) / 60 * rules.DriverRatePerHour)
+	accrual.FuelCost = round2(accrual.MilesDriven * rules.FuelCostPerMile())
+	accrual.MileageCost = round2(accrual.MilesDriven * rules.OtherCostPerMile)
+	accrual.TotalCost = round2(accrual.DriverCost + accrual.FuelCost + accrual.MileageCost)
+	accrual.DetentionRevenue = round2(accrual.DetentionRevenue)
+
+	earned := accrual.Revenue + accrual.DetentionRevenue
+	accrual.Margin = round2(earned - accrual.TotalCost)
+	switch {
+	case accrual.Revenue <= 0:
+		accrual.Level = domain.MarginLevelUnpriced
+	case accrual.Margin < 0:
+		accrual.Level = domain.MarginLevelLoss
+	default:
+		accrual.MarginPct = round2(accrual.Margin / earned * 100)
+		accrual.Level = domain.MarginLevelHealthy
+		if accrual.MarginPct < rules.ThinMarginPct {
+			accrual.Level = domain.MarginLevelThin
+		}
+	}
+	if accrual.Level == domain.MarginLevelLoss && earned > 0 {
+		accrual.MarginPct = round2(accrual.Margin / earned * 100)
+	}
+
+	if waiting != nil {
+		accrual.WaitingStopID = &waiting.ID
+		laterCharge, _ := detention.Calculate(waitingMins+60, waiting.FreeTimeMins)
+		afterHour := round2(accrual.Margin - rules.DriverRatePerHour + (laterCharge - waitingCharge))
+		accrual.MarginAfterExtraHour = &afterHour
+
+		// A dry run is billed in place of the move; detention so far still stands
+		dryRun := round2(s.businessRules.Cancellation.DryRunAmount + accrual.DetentionRevenue - accrual.TotalCost)
+		accrual.DryRunMargin = &dryRun
+	}
+	return accrual
+}
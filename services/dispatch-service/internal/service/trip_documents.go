@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/document"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// TripDocumentService renders driver manifests and rate confirmations for trips
+type TripDocumentService struct {
+	tripRepo     repository.TripRepository
+	stopRepo     repository.TripStopRepository
+	driverRepo   repository.DriverRepository
+	tractorRepo  repository.TractorRepository
+	locationRepo repository.LocationRepository
+	facilityRepo repository.FacilityProfileRepository
+	breakRepo    repository.BreakPlanRepository
+	documents    *document.Generator
+	logger       *logger.Logger
+}
+
+// NewTripDocumentService creates a new trip document service
+func NewTripDocumentService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	tractorRepo repository.TractorRepository,
+	locationRepo repository.LocationRepository,
+	facilityRepo repository.FacilityProfileRepository,
+	breakRepo repository.BreakPlanRepository,
+	documents *document.Generator,
+	log *logger.Logger,
+) *TripDocumentService {
+	return &TripDocumentService{
+		tripRepo:     tripRepo,
+		stopRepo:     stopRepo,
+		driverRepo:   driverRepo,
+		tractorRepo:  tractorRepo,
+		locationRepo: locationRepo,
+		facilityRepo: facilityRepo,
+		breakRepo:    breakRepo,
+		documents:    documents,
+		logger:       log,
+	}
+}
+
+// DriverManifest renders the printable run sheet a driver carries for a trip
+func (s *TripDocumentService) DriverManifest(ctx context.Context, tenantID string, tripID uuid.UUID) (*document.Document, error) {
+	trip, stops, err := s.loadTrip(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := document.ManifestData{
+		TripNumber: trip.TripNumber,
+		Date:       tripDate(trip),
+		Stops:      stops,
+	}
+	if trip.DriverID != nil {
+		if driver, _ := s.driverRepo.GetByID(ctx, *trip.DriverID); driver != nil {
+			data.DriverName = driver.Name
+			data.DriverPhone = driver.Phone
+		}
+	}
+	if trip.TractorID != nil {
+		if tractor, _ := s.tractorRepo.GetByID(ctx, *trip.TractorID); tractor != nil {
+			data.TractorNumber = tractor.UnitNumber
+		}
+	}
+	if plan, _ := s.breakRepo.GetByTripID(ctx, tripID); plan != nil && plan.Status == domain.BreakPlanPlanned {
+		data.OptionalStops = breakStops(plan)
+	}
+
+	return s.documents.Render(ctx, document.Request{
+		TenantID: tenantID,
+		Kind:     document.KindDriverManifest,
+		Title:    "Driver Manifest",
+		Filename: "manifest-" + trip.TripNumber,
+		Data:     data,
+	})
+}
+
+// RateConfirmationInput contains input for a carrier rate confirmation
+type RateConfirmationInput struct {
+	TenantID     string
+	TripID       uuid.UUID
+	Carrier      document.Party // Defaults to the trip's driver for owner-operators
+	MCNumber     string
+	DOTNumber    string
+	Equipment    string
+	Charges      []document.RateCharge
+	PaymentTerms string
+	Terms        string
+}
+
+// RateConfirmation renders the rate agreed with the carrier hauling a trip
+func (s *TripDocumentService) RateConfirmation(ctx context.Context, input RateConfirmationInput) (*document.Document, error) {
+	if len(input.Charges) == 0 {
+		return nil, apperrors.ValidationError("at least one charge is required", "charges", nil)
+	}
+
+	trip, stops, err := s.loadTrip(ctx, input.TripID)
+	if err != nil {
+		return nil, err
+	}
+
+	carrier := input.Carrier
+	if carrier.Name == "" && trip.DriverID != nil {
+		if driver, _ := s.driverRepo.GetByID(ctx, *trip.DriverID); driver != nil {
+			carrier.Name = driver.Name
+			carrier.Phone = driver.Phone
+		}
+	}
+	if carrier.Name == "" {
+		return nil, apperrors.ValidationError("carrier is required when the trip has no driver", "carrier", nil)
+	}
+
+	var total float64
+	for _, c := range input.Charges {
+		total += c.Amount
+	}
+
+	return s.documents.Render(ctx, document.Request{
+		TenantID: input.TenantID,
+		Kind:     document.KindRateConfirmation,
+		Title:    "Rate Confirmation",
+		Filename: "rate-confirmation-" + trip.TripNumber,
+		Data: document.RateConfirmationData{
+			Number:       "RC-" + trip.TripNumber,
+			Date:         time.Now(),
+			Reference:    trip.TripNumber,
+			Carrier:      carrier,
+			MCNumber:     input.MCNumber,
+			DOTNumber:    input.DOTNumber,
+			Equipment:    input.Equipment,
+			Stops:        stops,
+			Charges:      input.Charges,
+			Total:        total,
+			PaymentTerms: input.PaymentTerms,
+			Terms:        input.Terms,
+		},
+	})
+}
+
+// loadTrip loads a trip and its stops in sequence order as document stops
+func (s *TripDocumentService) loadTrip(ctx context.Context, tripID uuid.UUID) (*domain.Trip, []document.Stop, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+
+	tripStops, err := s.stopRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, nil, apperrors.DatabaseError("get trip stops", err)
+	}
+
+	stops := make([]document.Stop, 0, len(tripStops))
+	for _, ts := range tripStops {
+		stop := document.Stop{
+			Sequence:          ts.Sequence,
+			Type:              string(ts.Type),
+			Activity:          string(ts.Activity),
+			AppointmentTime:   ts.AppointmentTime,
+			AppointmentNumber: ts.AppointmentNumber,
+			ContainerNumber:   ts.ContainerNumber,
+			Instructions:      ts.Notes,
+		}
+		if location, _ := s.locationRepo.GetByID(ctx, ts.LocationID); location != nil {
+			stop.LocationName = location.Name
+			stop.Address = formatAddress(location)
+		}
+		if profile, _ := s.facilityRepo.GetByLocationID(ctx, ts.LocationID); profile != nil {
+			applyFacilityProfile(&stop, profile)
+		}
+		stops = append(stops, stop)
+	}
+	return trip, stops, nil
+}
+
+// HandleFacilityProfileUpdated is a kafka.Handler that keeps the copy of a
+// facility profile saved in the order-service address book. Profiles older
+// than the stored copy are ignored, so redelivered events do not roll it back.
+func (s *TripDocumentService) HandleFacilityProfileUpdated(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var profile domain.FacilityProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return fmt.Errorf("unmarshal facility profile: %w", err)
+	}
+	if profile.LocationID == uuid.Nil {
+		return nil
+	}
+
+	current, err := s.facilityRepo.GetByLocationID(ctx, profile.LocationID)
+	if err != nil {
+		return apperrors.DatabaseError("get facility profile", err)
+	}
+	if current != nil && current.UpdatedAt.After(profile.UpdatedAt) {
+		return nil
+	}
+	if err := s.facilityRepo.Upsert(ctx, &profile); err != nil {
+		return apperrors.DatabaseError("save facility profile", err)
+	}
+
+	s.logger.Infow("Facility profile updated", "location_id", profile.LocationID)
+	return nil
+}
+
+// applyFacilityProfile adds a facility's hours, docks, PPE and check-in steps
+// to a document stop. Hours are for the appointment day when there is one.
+func applyFacilityProfile(stop *document.Stop, profile *domain.FacilityProfile) {
+	if stop.AppointmentTime != nil {
+		loc, err := time.LoadLocation(profile.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		stop.ReceivingHours = profile.HoursOn(stop.AppointmentTime.In(loc).Weekday())
+	} else {
+		stop.ReceivingHours = profile.WeeklyHours()
+	}
+	stop.DockCount = profile.DockCount
+	stop.RequiredPPE = profile.RequiredPPE
+	stop.CheckInProcedure = profile.CheckInProcedure
+}
+
+// breakStops lists the places suggested for a planned break as optional
+// manifest stops, or a reminder to find one when none were found nearby
+func breakStops(plan *domain.BreakPlan) []document.Stop {
+	if len(plan.Suggestions) == 0 {
+		return []document.Stop{{
+			Sequence:        plan.AfterStopSequence,
+			Activity:        domain.BreakStopActivity,
+			LocationName:    "No truck stop or rest area found nearby",
+			AppointmentTime: &plan.DueAt,
+			Instructions:    "Take a 30-minute break off the wheel before this time",
+		}}
+	}
+
+	stops := make([]document.Stop, len(plan.Suggestions))
+	for i := range plan.Suggestions {
+		suggestion := &plan.Suggestions[i]
+		stops[i] = document.Stop{
+			Sequence:        plan.AfterStopSequence,
+			Type:            suggestion.Location.Type,
+			Activity:        domain.BreakStopActivity,
+			LocationName:    suggestion.Location.Name,
+			Address:         formatAddress(&suggestion.Location),
+			AppointmentTime: &plan.DueAt,
+			Instructions:    fmt.Sprintf("Optional 30-minute break stop, %.1f mi from the planned route", suggestion.DistanceMiles),
+		}
+	}
+	return stops
+}
+
+// tripDate is the day a trip runs, its planned start when scheduled
+func tripDate(trip *domain.Trip) time.Time {
+	if trip.PlannedStartTime != nil {
+		return *trip.PlannedStartTime
+	}
+	return trip.CreatedAt
+}
+
+func formatAddress(location *domain.Location) string {
+	cityLine := strings.TrimSpace(fmt.Sprintf("%s, %s %s", location.City, location.State, location.Zip))
+	cityLine = strings.Trim(cityLine, ", ")
+	return strings.Trim(location.Address+", "+cityLine, ", ")
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// maxLookupTrips caps the trips returned for one identifier; a container
+// that has moved more often than this returns its most recent moves
+const maxLookupTrips = 25
+
+// TripLookup identifies the trips to find. At least one field is required.
+type TripLookup struct {
+	ContainerNumber string
+	TripNumber      string
+	OrderID         *uuid.UUID
+}
+
+// LookupTrips finds the trips that moved a container or order, or a trip by
+// its number, newest first with stops and driver loaded. It backs identifier
+// resolution for support, which fans out to this service.
+func (s *DispatchService) LookupTrips(ctx context.Context, lookup TripLookup) ([]domain.Trip, error) {
+	filter := repository.TripFilter{
+		ContainerNumber: strings.ToUpper(strings.TrimSpace(lookup.ContainerNumber)),
+		TripNumber:      strings.TrimSpace(lookup.TripNumber),
+		OrderID:         lookup.OrderID,
+		PageSize:        maxLookupTrips,
+		SortBy:          "planned_start_time",
+		SortOrder:       "desc",
+	}
+	if filter.ContainerNumber == "" && filter.TripNumber == "" && filter.OrderID == nil {
+		return nil, apperrors.ValidationError("container number, trip number or order ID is required", "lookup", nil)
+	}
+
+	trips, _, err := s.tripRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("lookup trips", err)
+	}
+	if len(trips) == 0 {
+		return []domain.Trip{}, nil
+	}
+
+	tripIDs := make([]uuid.UUID, len(trips))
+	for i := range trips {
+		tripIDs[i] = trips[i].ID
+	}
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stops", err)
+	}
+	byTrip := make(map[uuid.UUID][]domain.TripStop, len(trips))
+	for _, stop := range stops {
+		byTrip[stop.TripID] = append(byTrip[stop.TripID], stop)
+	}
+
+	for i := range trips {
+		trips[i].Stops = byTrip[trips[i].ID]
+		if trips[i].DriverID != nil {
+			if driver, err := s.driverRepo.GetByID(ctx, *trips[i].DriverID); err == nil {
+				trips[i].Driver = driver
+			}
+		}
+	}
+	return trips, nil
+}
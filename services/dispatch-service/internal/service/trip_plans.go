@@ -0,0 +1,555 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxTripPlanAssignments caps the assignments applied from a plan at once
+const maxTripPlanAssignments = 500
+
+// TripPlanScorer scores a driver's route for the trip planner; lower is
+// better. The planner places each trip where it adds the least score.
+type TripPlanScorer interface {
+	Name() string
+	Score(cost domain.RouteCost) float64
+}
+
+// WeightedPlanScorer scores routes on empty miles, waiting, late minutes and
+// missed appointments, weighted by the trip planning rules
+type WeightedPlanScorer struct {
+	rules config.TripPlanningRules
+}
+
+// NewWeightedPlanScorer creates a scorer with the given weights
+func NewWeightedPlanScorer(rules config.TripPlanningRules) *WeightedPlanScorer {
+	return &WeightedPlanScorer{rules: rules}
+}
+
+// Name identifies the scorer on the plans it produces
+func (s *WeightedPlanScorer) Name() string {
+	return "weighted"
+}
+
+// Score weighs the route's costs
+func (s *WeightedPlanScorer) Score(cost domain.RouteCost) float64 {
+	return cost.EmptyMiles*s.rules.EmptyMileCost +
+		float64(cost.WaitMins)*s.rules.WaitMinuteCost +
+		float64(cost.LateMins)*s.rules.LateMinuteCost +
+		float64(cost.MissedAppointments)*s.rules.MissedAppointmentCost
+}
+
+// TripPlanService plans the day's unassigned trips across the available
+// drivers with a cheapest-insertion heuristic: each round it places the one
+// trip, on the one driver and at the one point in that driver's route, that
+// adds the least score, until no remaining trip fits anywhere. Routes respect
+// the drivers' drive and duty time, endorsements, committed trips and time
+// off. Plans are proposals; dispatchers apply the assignments they accept.
+type TripPlanService struct {
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	driverRepo    repository.DriverRepository
+	locationRepo  repository.LocationRepository
+	containerRepo repository.ContainerRepository
+	timeOffRepo   repository.DriverTimeOffRepository
+	dispatch      *DispatchService
+	scorer        TripPlanScorer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewTripPlanService creates a new trip planning service. A nil scorer
+// uses the weighted scorer from the business rules.
+func NewTripPlanService(
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	driverRepo repository.DriverRepository,
+	locationRepo repository.LocationRepository,
+	containerRepo repository.ContainerRepository,
+	timeOffRepo repository.DriverTimeOffRepository,
+	dispatch *DispatchService,
+	scorer TripPlanScorer,
+	log *logger.Logger,
+) *TripPlanService {
+	rules := config.DefaultBusinessRules()
+	if scorer == nil {
+		scorer = NewWeightedPlanScorer(rules.TripPlanning)
+	}
+	return &TripPlanService{
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		driverRepo:    driverRepo,
+		locationRepo:  locationRepo,
+		containerRepo: containerRepo,
+		timeOffRepo:   timeOffRepo,
+		dispatch:      dispatch,
+		scorer:        scorer,
+		logger:        log,
+		businessRules: rules,
+	}
+}
+
+// planTrip is an unassigned trip with what the planner needs to place it
+type planTrip struct {
+	trip         domain.Trip
+	pickup       *domain.Location
+	dropoff      *domain.Location
+	appointment  *time.Time
+	windowMins   int
+	durationMins int
+	needsTWIC    bool
+	needsHazmat  bool
+}
+
+// planDriver is an available driver with the time they cannot be given
+type planDriver struct {
+	driver  *domain.Driver
+	blocked [][2]time.Time // Committed trips and approved time off
+	route   []*planTrip
+	cost    domain.RouteCost
+	score   float64
+}
+
+// planReason is why a trip could not go on a driver's route
+type planReason int
+
+const (
+	planOK planReason = iota
+	planTooFar
+	planLackHOS
+	planConflicting
+	planRouteFull
+)
+
+// BuildPlan plans the unassigned trips planned to start on date across the
+// drivers available now. Routes start from each driver's current position,
+// and from now when planning today.
+func (s *TripPlanService) BuildPlan(ctx context.Context, date time.Time) (*domain.TripPlan, error) {
+	now := time.Now()
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	if !dayEnd.After(now) {
+		return nil, apperrors.ValidationError("cannot plan a day that has passed", "date", dayStart.Format("2006-01-02"))
+	}
+	start := dayStart
+	if now.After(start) {
+		start = now
+	}
+
+	plan := &domain.TripPlan{
+		Date:        dayStart.Format("2006-01-02"),
+		Scorer:      s.scorer.Name(),
+		Routes:      []domain.DriverRoute{},
+		Unplanned:   []domain.UnplannedTrip{},
+		GeneratedAt: now,
+	}
+
+	trips, err := s.loadTrips(ctx, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	plan.TripsConsidered = len(trips)
+
+	drivers, err := s.loadDrivers(ctx, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	plan.DriversAvailable = len(drivers)
+
+	remaining := trips
+	for len(remaining) > 0 {
+		var best *planDriver
+		var bestTrip, bestPos int
+		var bestRoute []*planTrip
+		var bestCost domain.RouteCost
+		bestDelta := math.Inf(1)
+
+		for i, trip := range remaining {
+			for _, driver := range drivers {
+				if s.eligible(driver.driver, trip) != nil {
+					continue
+				}
+				for pos := 0; pos <= len(driver.route); pos++ {
+					route := insertPlanTrip(driver.route, trip, pos)
+					cost, _, reason := s.simulate(driver, route, start)
+					if reason != planOK {
+						continue
+					}
+					if delta := s.scorer.Score(cost) - driver.score; delta < bestDelta {
+						best, bestTrip, bestPos, bestRoute, bestCost, bestDelta = driver, i, pos, route, cost, delta
+					}
+				}
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		best.route = bestRoute
+		best.cost = bestCost
+		best.score = s.scorer.Score(bestCost)
+		s.logger.Debugw("Trip placed",
+			"trip_number", remaining[bestTrip].trip.TripNumber,
+			"driver_id", best.driver.ID,
+			"position", bestPos,
+			"added_score", bestDelta,
+		)
+		remaining = append(remaining[:bestTrip], remaining[bestTrip+1:]...)
+	}
+
+	for _, driver := range drivers {
+		if len(driver.route) == 0 {
+			continue
+		}
+		_, planned, _ := s.simulate(driver, driver.route, start)
+		cost := driver.cost
+		cost.EmptyMiles = math.Round(cost.EmptyMiles*10) / 10
+		plan.Routes = append(plan.Routes, domain.DriverRoute{
+			DriverID:           driver.driver.ID,
+			DriverName:         driver.driver.Name,
+			AvailableDriveMins: driver.driver.AvailableDriveMins,
+			Trips:              planned,
+			Cost:               cost,
+			Score:              math.Round(driver.score*100) / 100,
+		})
+		plan.TripsPlanned += len(planned)
+		plan.Cost = plan.Cost.Add(driver.cost)
+	}
+	plan.Cost.EmptyMiles = math.Round(plan.Cost.EmptyMiles*10) / 10
+	plan.Score = math.Round(s.scorer.Score(plan.Cost)*100) / 100
+	sort.SliceStable(plan.Routes, func(i, j int) bool {
+		return plan.Routes[i].DriverName < plan.Routes[j].DriverName
+	})
+
+	for _, trip := range remaining {
+		plan.Unplanned = append(plan.Unplanned, domain.UnplannedTrip{
+			TripID:     trip.trip.ID,
+			TripNumber: trip.trip.TripNumber,
+			Reason:     s.unplannedReason(drivers, trip, start),
+		})
+	}
+
+	s.logger.Infow("Trip plan built",
+		"date", plan.Date,
+		"scorer", plan.Scorer,
+		"trips", plan.TripsConsidered,
+		"planned", plan.TripsPlanned,
+		"drivers", plan.DriversAvailable,
+		"empty_miles", plan.Cost.EmptyMiles,
+		"missed_appointments", plan.Cost.MissedAppointments,
+	)
+
+	return plan, nil
+}
+
+// loadTrips returns the day's unassigned trips with their first and last
+// stops and requirements, earliest appointment first so ties go to the
+// most urgent trip
+func (s *TripPlanService) loadTrips(ctx context.Context, dayStart, dayEnd time.Time) ([]*planTrip, error) {
+	trips, err := s.tripRepo.GetByDateRange(ctx, dayStart, dayEnd)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trips", err)
+	}
+	var open []domain.Trip
+	var tripIDs []uuid.UUID
+	for _, trip := range trips {
+		if trip.Status == domain.TripStatusPlanned && trip.DriverID == nil {
+			open = append(open, trip)
+			tripIDs = append(tripIDs, trip.ID)
+		}
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	stops, err := s.stopRepo.GetByTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get trip stops", err)
+	}
+	stopsByTrip := make(map[uuid.UUID][]domain.TripStop, len(open))
+	for _, stop := range stops {
+		stopsByTrip[stop.TripID] = append(stopsByTrip[stop.TripID], stop)
+	}
+
+	locations := make(map[uuid.UUID]*domain.Location)
+	location := func(id uuid.UUID) (*domain.Location, error) {
+		if loc, ok := locations[id]; ok {
+			return loc, nil
+		}
+		loc, err := s.locationRepo.GetByID(ctx, id)
+		if err != nil || loc == nil {
+			return nil, apperrors.NotFoundError("location", id.String())
+		}
+		locations[id] = loc
+		return loc, nil
+	}
+
+	var planned []*planTrip
+	for _, trip := range open {
+		tripStops := stopsByTrip[trip.ID]
+		if len(tripStops) == 0 {
+			continue
+		}
+		sort.Slice(tripStops, func(i, j int) bool {
+			return tripStops[i].Sequence < tripStops[j].Sequence
+		})
+		first, last := tripStops[0], tripStops[len(tripStops)-1]
+
+		pickup, err := location(first.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		dropoff, err := location(last.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		needsTWIC, needsHazmat, err := tripRequirements(ctx, s.stopRepo, s.locationRepo, s.containerRepo, trip.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		pt := &planTrip{
+			trip:         trip,
+			pickup:       pickup,
+			dropoff:      dropoff,
+			appointment:  first.AppointmentTime,
+			windowMins:   first.AppointmentWindowMins,
+			durationMins: trip.EstimatedDurationMins,
+			// Escorted trips are planned for drivers without a TWIC and
+			// escorts are arranged when the trip is assigned
+			needsTWIC:   needsTWIC && !trip.TWICEscort,
+			needsHazmat: needsHazmat,
+		}
+		if pt.durationMins <= 0 {
+			pt.durationMins = s.businessRules.Schedule.DefaultTripMins
+		}
+		planned = append(planned, pt)
+	}
+
+	due := func(trip *planTrip) *time.Time {
+		if trip.appointment != nil {
+			return trip.appointment
+		}
+		return trip.trip.PlannedStartTime
+	}
+	sort.SliceStable(planned, func(i, j int) bool {
+		a, b := due(planned[i]), due(planned[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+
+	return planned, nil
+}
+
+// loadDrivers returns the drivers available now with the time their
+// committed trips and approved time off take out of the day
+func (s *TripPlanService) loadDrivers(ctx context.Context, dayStart, dayEnd time.Time) ([]*planDriver, error) {
+	available, err := s.driverRepo.GetAvailable(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get available drivers", err)
+	}
+
+	var drivers []*planDriver
+	for i := range available {
+		driver := &available[i]
+		if driver.Status != "AVAILABLE" && driver.Status != "ON_DUTY" {
+			continue
+		}
+		pd := &planDriver{driver: driver}
+
+		committed, err := driverTrips(ctx, s.tripRepo, driver.ID, committedTripStatuses, dayStart, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+		for j := range committed {
+			if start, end, ok := committed[j].PlannedWindow(s.businessRules.Schedule.DefaultTripMins); ok {
+				pd.blocked = append(pd.blocked, [2]time.Time{start, end})
+			}
+		}
+
+		off, err := s.timeOffRepo.GetOverlapping(ctx, driver.ID, dayStart, dayEnd, []domain.TimeOffStatus{domain.TimeOffApproved})
+		if err != nil {
+			return nil, apperrors.DatabaseError("get driver time off", err)
+		}
+		for j := range off {
+			start, end := off[j].Window()
+			pd.blocked = append(pd.blocked, [2]time.Time{start, end})
+		}
+
+		drivers = append(drivers, pd)
+	}
+	return drivers, nil
+}
+
+// eligible checks the driver's endorsements and documents allow the trip
+func (s *TripPlanService) eligible(driver *domain.Driver, trip *planTrip) error {
+	return checkDriverDocuments(&s.businessRules.Documents, driver, trip.needsTWIC, trip.needsHazmat)
+}
+
+// simulate runs the driver through the route from their current position,
+// returning its cost and the trips as they would run, or why the route
+// cannot be driven
+func (s *TripPlanService) simulate(driver *planDriver, route []*planTrip, start time.Time) (domain.RouteCost, []domain.PlannedTrip, planReason) {
+	rules := &s.businessRules.TripPlanning
+	buffer := time.Duration(s.businessRules.Schedule.ConflictBufferMins) * time.Minute
+
+	var cost domain.RouteCost
+	if rules.MaxTripsPerDriver > 0 && len(route) > rules.MaxTripsPerDriver {
+		return cost, nil, planRouteFull
+	}
+
+	planned := make([]domain.PlannedTrip, 0, len(route))
+	lat, lon := driver.driver.CurrentLatitude, driver.driver.CurrentLongitude
+	clock := start
+	for _, trip := range route {
+		miles := haversineMiles(lat, lon, trip.pickup.Latitude, trip.pickup.Longitude)
+		if rules.MaxDeadheadMiles > 0 && miles > rules.MaxDeadheadMiles {
+			return cost, nil, planTooFar
+		}
+		etaMins := int(math.Ceil(miles / s.businessRules.Distance.DrayageAverageSpeedMPH * 60))
+
+		arrive := clock.Add(time.Duration(etaMins) * time.Minute)
+		step := domain.PlannedTrip{
+			TripID:           trip.trip.ID,
+			TripNumber:       trip.trip.TripNumber,
+			Type:             trip.trip.Type,
+			PickupLocationID: trip.pickup.ID,
+			PickupName:       trip.pickup.Name,
+			DeadheadMiles:    math.Round(miles*10) / 10,
+			AppointmentTime:  trip.appointment,
+		}
+
+		// Wait for the appointment, or the planned start when there is none
+		opens := trip.appointment
+		if opens == nil {
+			opens = trip.trip.PlannedStartTime
+		}
+		if opens != nil && arrive.Before(*opens) {
+			step.WaitMins = int(opens.Sub(arrive).Minutes())
+			arrive = *opens
+		}
+		if trip.appointment != nil {
+			closes := trip.appointment.Add(time.Duration(trip.windowMins) * time.Minute)
+			if arrive.After(closes) {
+				step.LateMins = int(math.Ceil(arrive.Sub(closes).Minutes()))
+				step.MissedAppointment = true
+			}
+		}
+		step.ArriveAt = arrive
+		step.FinishAt = arrive.Add(time.Duration(trip.durationMins) * time.Minute)
+
+		for _, window := range driver.blocked {
+			if domain.WindowsOverlap(arrive, step.FinishAt, window[0], window[1], buffer) {
+				return cost, nil, planConflicting
+			}
+		}
+
+		cost.EmptyMiles += miles
+		cost.DriveMins += etaMins + trip.durationMins
+		cost.WaitMins += step.WaitMins
+		cost.LateMins += step.LateMins
+		if step.MissedAppointment {
+			cost.MissedAppointments++
+		}
+
+		planned = append(planned, step)
+		lat, lon = trip.dropoff.Latitude, trip.dropoff.Longitude
+		clock = step.FinishAt
+	}
+
+	if cost.DriveMins+autoDispatchHOSBufferMins > driver.driver.AvailableDriveMins {
+		return cost, nil, planLackHOS
+	}
+	if duty := driver.driver.AvailableDutyMins; duty > 0 && int(clock.Sub(start).Minutes()) > duty {
+		return cost, nil, planLackHOS
+	}
+
+	return cost, planned, planOK
+}
+
+// unplannedReason counts why each available driver could not take the trip
+func (s *TripPlanService) unplannedReason(drivers []*planDriver, trip *planTrip, start time.Time) string {
+	if len(drivers) == 0 {
+		return "no drivers available"
+	}
+	var restricted, tooFar, lackHOS, conflicting, full int
+	for _, driver := range drivers {
+		if s.eligible(driver.driver, trip) != nil {
+			restricted++
+			continue
+		}
+		_, _, reason := s.simulate(driver, insertPlanTrip(driver.route, trip, len(driver.route)), start)
+		switch reason {
+		case planTooFar:
+			tooFar++
+		case planLackHOS:
+			lackHOS++
+		case planConflicting:
+			conflicting++
+		case planRouteFull:
+			full++
+		}
+	}
+	return fmt.Sprintf("no driver could take it: %d restricted by endorsements or documents, %d beyond %.0f mi empty, %d lacked drive or duty time, %d already committed, %d with a full route",
+		restricted, tooFar, s.businessRules.TripPlanning.MaxDeadheadMiles, lackHOS, conflicting, full)
+}
+
+// insertPlanTrip returns a copy of route with trip inserted at pos
+func insertPlanTrip(route []*planTrip, trip *planTrip, pos int) []*planTrip {
+	out := make([]*planTrip, 0, len(route)+1)
+	out = append(out, route[:pos]...)
+	out = append(out, trip)
+	return append(out, route[pos:]...)
+}
+
+// ApplyTripPlanInput contains the assignments a dispatcher accepted from a plan
+type ApplyTripPlanInput struct {
+	Assignments []domain.TripPlanAssignment
+	AppliedBy   string
+}
+
+// ApplyPlan assigns each accepted trip to its planned driver through the
+// dispatch service, so every assignment is checked again. Assignments that
+// fail are reported and the rest still apply.
+func (s *TripPlanService) ApplyPlan(ctx context.Context, input ApplyTripPlanInput) ([]domain.TripPlanAssignment, error) {
+	if len(input.Assignments) == 0 {
+		return nil, apperrors.ValidationError("at least one assignment is required", "assignments", nil)
+	}
+	if len(input.Assignments) > maxTripPlanAssignments {
+		return nil, apperrors.ValidationError(fmt.Sprintf("at most %d assignments can be applied at once", maxTripPlanAssignments), "assignments", len(input.Assignments))
+	}
+
+	results := make([]domain.TripPlanAssignment, len(input.Assignments))
+	applied, failed := 0, 0
+	for i, assignment := range input.Assignments {
+		results[i] = domain.TripPlanAssignment{TripID: assignment.TripID, DriverID: assignment.DriverID}
+		if _, err := s.dispatch.AssignDriver(ctx, assignment.TripID, assignment.DriverID, nil); err != nil {
+			results[i].Error = err.Error()
+			failed++
+			continue
+		}
+		results[i].Applied = true
+		applied++
+	}
+
+	s.logger.Infow("Trip plan applied",
+		"applied", applied,
+		"failed", failed,
+		"applied_by", input.AppliedBy,
+	)
+
+	return results, nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// RolloverTripInput contains input for rolling a failed trip's remaining stops into a new trip
+type RolloverTripInput struct {
+	TripID           uuid.UUID
+	Reason           string
+	PlannedStartTime *time.Time
+	DriverID         *uuid.UUID // Optional driver for the new trip
+	RolledOverBy     string
+}
+
+// RolloverResult contains the closed trip and the trip created from its remaining stops
+type RolloverResult struct {
+	FailedTrip *domain.Trip `json:"failed_trip"`
+	NewTrip    *domain.Trip `json:"new_trip"`
+}
+
+// RolloverTrip closes a trip that failed mid-way and clones its incomplete
+// stops into a new trip, carrying over the chassis and container in hand.
+// The two trips are linked so billing can follow the move across both.
+func (s *DispatchCRUDService) RolloverTrip(ctx context.Context, input RolloverTripInput) (*RolloverResult, error) {
+	s.logger.Infow("Rolling over trip", "trip_id", input.TripID, "reason", input.Reason)
+
+	trip, err := s.tripRepo.GetByID(ctx, input.TripID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("trip", input.TripID.String())
+	}
+
+	switch trip.Status {
+	case domain.TripStatusDispatched, domain.TripStatusEnRoute, domain.TripStatusInProgress, domain.TripStatusFailed:
+	default:
+		return nil, apperrors.InvalidStateError(string(trip.Status), "dispatched, en_route, in_progress, or failed")
+	}
+	if trip.RolledOverToTripID != nil {
+		return nil, apperrors.ConflictError("trip has already been rolled over")
+	}
+
+	stops, err := s.stopRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stops", err)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+
+	var remaining []domain.TripStop
+	chassisID := trip.ChassisID
+	var containerID *uuid.UUID
+	var containerNumber string
+	for _, stop := range stops {
+		if stop.Status == domain.StopStatusCompleted {
+			// Track equipment in hand after each completed stop
+			if stop.ChassisOutID != nil {
+				chassisID = stop.ChassisOutID
+			}
+			if stop.ContainerOutID != nil {
+				containerID = stop.ContainerOutID
+			}
+			if stop.ContainerNumber != "" {
+				containerNumber = stop.ContainerNumber
+			}
+			continue
+		}
+		if stop.Status == domain.StopStatusSkipped || stop.Status == domain.StopStatusCancelled {
+			continue
+		}
+		remaining = append(remaining, stop)
+	}
+
+	if len(remaining) == 0 {
+		return nil, apperrors.New("NOTHING_TO_ROLL_OVER", "trip has no incomplete stops")
+	}
+
+	tripNumber, err := s.tripRepo.GetNextTripNumber(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("generate trip number", err)
+	}
+
+	now := time.Now()
+	newTrip := &domain.Trip{
+		ID:                   uuid.New(),
+		TripNumber:           tripNumber,
+		Type:                 trip.Type,
+		Status:               domain.TripStatusPlanned,
+		DriverID:             input.DriverID,
+		ChassisID:            chassisID,
+		CurrentStopSequence:  1,
+		PlannedStartTime:     input.PlannedStartTime,
+		IsStreetTurn:         trip.IsStreetTurn,
+		IsDualTransaction:    trip.IsDualTransaction,
+		RolledOverFromTripID: &trip.ID,
+		OrderIDs:             trip.OrderIDs,
+		Tags:                 trip.Tags, // The work is the same, so are its labels
+		CreatedBy:            input.RolledOverBy,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	if input.DriverID != nil {
+		newTrip.Status = domain.TripStatusAssigned
+		newTrip.TractorID = trip.TractorID
+	}
+
+	newStops := make([]domain.TripStop, len(remaining))
+	for i, stop := range remaining {
+		newStops[i] = domain.TripStop{
+			ID:                    uuid.New(),
+			TripID:                newTrip.ID,
+			Sequence:              i + 1,
+			Type:                  stop.Type,
+			Activity:              stop.Activity,
+			Status:                domain.StopStatusPending,
+			LocationID:            stop.LocationID,
+			ContainerID:           stop.ContainerID,
+			ContainerNumber:       stop.ContainerNumber,
+			OrderID:               stop.OrderID,
+			AppointmentWindowMins: stop.AppointmentWindowMins,
+			EstimatedDurationMins: stop.EstimatedDurationMins,
+			FreeTimeMins:          stop.FreeTimeMins,
+			Notes:                 stop.Notes,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		}
+		newTrip.EstimatedDurationMins += stop.EstimatedDurationMins
+	}
+
+	// The first rolled stop starts with whatever the driver had in hand
+	newStops[0].ChassisInID = chassisID
+	newStops[0].ContainerInID = containerID
+	if newStops[0].ContainerID == nil {
+		newStops[0].ContainerID = containerID
+	}
+	if newStops[0].ContainerNumber == "" {
+		newStops[0].ContainerNumber = containerNumber
+	}
+
+	if trip.TotalMiles > 0 {
+		newTrip.TotalMiles = trip.TotalMiles - trip.CompletedMiles
+		if newTrip.TotalMiles < 0 {
+			newTrip.TotalMiles = 0
+		}
+	}
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		if err := s.tripRepo.Create(txCtx, newTrip); err != nil {
+			return apperrors.DatabaseError("create rollover trip", err)
+		}
+		for i := range newStops {
+			if err := s.stopRepo.Create(txCtx, &newStops[i]); err != nil {
+				return apperrors.DatabaseError("create rollover stop", err)
+			}
+		}
+
+		// Close out the remaining stops on the failed trip
+		failureReason := fmt.Sprintf("rolled over to trip %s: %s", newTrip.TripNumber, input.Reason)
+		for i := range remaining {
+			stop := &remaining[i]
+			if stop.Status == domain.StopStatusArrived || stop.Status == domain.StopStatusInProgress || stop.Status == domain.StopStatusFailed {
+				stop.Status = domain.StopStatusFailed
+			} else {
+				stop.Status = domain.StopStatusCancelled
+			}
+			stop.FailureReason = failureReason
+			stop.UpdatedAt = now
+			if err := s.stopRepo.Update(txCtx, stop); err != nil {
+				return stopUpdateError(ctx, s.stopRepo, stop.ID, "close failed stop", err)
+			}
+		}
+
+		trip.Status = domain.TripStatusFailed
+		trip.ActualEndTime = &now
+		trip.RolledOverToTripID = &newTrip.ID
+		trip.UpdatedAt = now
+		if err := s.tripRepo.Update(txCtx, trip); err != nil {
+			return tripUpdateError(ctx, s.tripRepo, trip.ID, "close failed trip", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newTrip.Stops = newStops
+
+	event := kafka.NewEvent(kafka.Topics.TripRolledOver, "dispatch-service", map[string]interface{}{
+		"failed_trip_id":     trip.ID.String(),
+		"failed_trip_number": trip.TripNumber,
+		"new_trip_id":        newTrip.ID.String(),
+		"new_trip_number":    newTrip.TripNumber,
+		"rolled_stops":       len(newStops),
+		"reason":             input.Reason,
+		"rolled_over_by":     input.RolledOverBy,
+	}).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripRolledOver, event)
+
+	s.logger.Infow("Trip rolled over",
+		"failed_trip_id", trip.ID,
+		"new_trip_id", newTrip.ID,
+		"new_trip_number", newTrip.TripNumber,
+		"stops", len(newStops),
+	)
+
+	return &RolloverResult{FailedTrip: trip, NewTrip: newTrip}, nil
+}
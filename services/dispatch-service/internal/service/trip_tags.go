@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/validation"
+)
+
+// maxSavedTripFilters caps the filters one user can keep
+const maxSavedTripFilters = 50
+
+// TripTagService labels trips with free-form tags and keeps each user's
+// saved trip filters
+type TripTagService struct {
+	tripRepo      repository.TripRepository
+	filterRepo    repository.SavedTripFilterRepository
+	crud          *DispatchCRUDService
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	validator     *validation.TagValidator
+}
+
+// NewTripTagService creates a new trip tag service
+func NewTripTagService(
+	tripRepo repository.TripRepository,
+	filterRepo repository.SavedTripFilterRepository,
+	crud *DispatchCRUDService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *TripTagService {
+	return &TripTagService{
+		tripRepo:      tripRepo,
+		filterRepo:    filterRepo,
+		crud:          crud,
+		eventProducer: eventProducer,
+		logger:        log,
+		validator:     validation.NewTagValidator(),
+	}
+}
+
+// UpdateTripTagsInput changes a trip's tags. Tags replaces the whole set
+// when given; otherwise Add and Remove are applied to the current tags.
+type UpdateTripTagsInput struct {
+	Tags      []string
+	Add       []string
+	Remove    []string
+	Version   int // Trip version the edit was made against; 0 skips the check
+	UpdatedBy string
+}
+
+// UpdateTripTags changes a trip's tags and publishes the change with the
+// added and removed tags
+func (s *TripTagService) UpdateTripTags(ctx context.Context, tripID uuid.UUID, input UpdateTripTagsInput) (*domain.Trip, error) {
+	trip, err := s.tripRepo.GetByID(ctx, tripID)
+	if err != nil || trip == nil {
+		return nil, apperrors.NotFoundError("trip", tripID.String())
+	}
+	if err := checkTripVersion(trip, input.Version); err != nil {
+		return nil, err
+	}
+
+	next := input.Tags
+	if next == nil {
+		next = append(append([]string{}, trip.Tags...), input.Add...)
+	}
+	tags, err := s.validator.Normalize(next)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "tags", next)
+	}
+	if input.Tags == nil && len(input.Remove) > 0 {
+		remove, err := s.validator.Normalize(input.Remove)
+		if err != nil {
+			return nil, apperrors.ValidationError(err.Error(), "remove", input.Remove)
+		}
+		tags = tagDifference(tags, remove)
+	}
+
+	added, removed := tagDifference(tags, trip.Tags), tagDifference(trip.Tags, tags)
+	if len(added) == 0 && len(removed) == 0 {
+		return trip, nil
+	}
+
+	trip.Tags = tags
+	trip.UpdatedAt = time.Now()
+	if err := s.tripRepo.Update(ctx, trip); err != nil {
+		return nil, tripUpdateError(ctx, s.tripRepo, tripID, "update trip tags", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.TripTagsChanged, "dispatch-service", map[string]interface{}{
+		"trip_id":     trip.ID.String(),
+		"trip_number": trip.TripNumber,
+		"status":      trip.Status,
+		"tags":        trip.Tags,
+		"added":       added,
+		"removed":     removed,
+		"changed_by":  input.UpdatedBy,
+	}).WithTags(trip.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TripTagsChanged, event)
+
+	s.logger.Infow("Trip tags changed",
+		"trip_id", tripID,
+		"added", added,
+		"removed", removed,
+		"changed_by", input.UpdatedBy,
+	)
+	return trip, nil
+}
+
+// SaveTripFilterInput creates or replaces a saved filter
+type SaveTripFilterInput struct {
+	ID        *uuid.UUID // Existing filter to replace; nil creates one
+	UserID    string
+	Name      string
+	Criteria  domain.TripFilterCriteria
+	IsDefault bool
+}
+
+// SaveTripFilter creates or replaces one of a user's saved filters. Making a
+// filter the default clears the user's previous default.
+func (s *TripTagService) SaveTripFilter(ctx context.Context, input SaveTripFilterInput) (*domain.SavedTripFilter, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, apperrors.ValidationError("name is required", "name", input.Name)
+	}
+	tags, err := s.validator.Normalize(input.Criteria.Tags)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "criteria.tags", input.Criteria.Tags)
+	}
+	input.Criteria.Tags = tags
+
+	existing, err := s.filterRepo.ListByUser(ctx, input.UserID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list saved filters", err)
+	}
+	var filter *domain.SavedTripFilter
+	for i := range existing {
+		if input.ID != nil && existing[i].ID == *input.ID {
+			filter = &existing[i]
+			continue
+		}
+		if strings.EqualFold(existing[i].Name, name) {
+			return nil, apperrors.ConflictError("a saved filter named " + name + " already exists")
+		}
+	}
+	if input.ID != nil && filter == nil {
+		return nil, apperrors.NotFoundError("saved filter", input.ID.String())
+	}
+	if filter == nil && len(existing) >= maxSavedTripFilters {
+		return nil, apperrors.ValidationError("too many saved filters", "name", name)
+	}
+
+	if input.IsDefault {
+		if err := s.filterRepo.ClearDefault(ctx, input.UserID); err != nil {
+			return nil, apperrors.DatabaseError("clear default filter", err)
+		}
+	}
+
+	now := time.Now()
+	if filter == nil {
+		filter = &domain.SavedTripFilter{
+			ID:        uuid.New(),
+			UserID:    input.UserID,
+			Name:      name,
+			Criteria:  input.Criteria,
+			IsDefault: input.IsDefault,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.filterRepo.Create(ctx, filter); err != nil {
+			return nil, apperrors.DatabaseError("create saved filter", err)
+		}
+		return filter, nil
+	}
+
+	filter.Name = name
+	filter.Criteria = input.Criteria
+	filter.IsDefault = input.IsDefault
+	filter.UpdatedAt = now
+	if err := s.filterRepo.Update(ctx, filter); err != nil {
+		return nil, apperrors.DatabaseError("update saved filter", err)
+	}
+	return filter, nil
+}
+
+// ListTripFilters returns a user's saved filters, default first
+func (s *TripTagService) ListTripFilters(ctx context.Context, userID string) ([]domain.SavedTripFilter, error) {
+	filters, err := s.filterRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list saved filters", err)
+	}
+	return filters, nil
+}
+
+// DeleteTripFilter deletes one of a user's saved filters
+func (s *TripTagService) DeleteTripFilter(ctx context.Context, id uuid.UUID, userID string) error {
+	if _, err := s.ownFilter(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := s.filterRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete saved filter", err)
+	}
+	return nil
+}
+
+// RunTripFilter lists the trips matching one of a user's saved filters
+func (s *TripTagService) RunTripFilter(ctx context.Context, id uuid.UUID, userID string, page, pageSize int) (*TripListResult, error) {
+	filter, err := s.ownFilter(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	c := filter.Criteria
+	return s.crud.ListTrips(ctx, ListTripsFilter{
+		Status:       c.Status,
+		Type:         c.Type,
+		DriverID:     c.DriverID,
+		Tags:         c.Tags,
+		MatchAllTags: c.MatchAllTags,
+		IsStreetTurn: c.IsStreetTurn,
+		Page:         page,
+		PageSize:     pageSize,
+		SortBy:       c.SortBy,
+		SortOrder:    c.SortOrder,
+	})
+}
+
+// ownFilter loads a saved filter, hiding other users' filters as not found
+func (s *TripTagService) ownFilter(ctx context.Context, id uuid.UUID, userID string) (*domain.SavedTripFilter, error) {
+	filter, err := s.filterRepo.GetByID(ctx, id)
+	if err != nil || filter == nil || filter.UserID != userID {
+		return nil, apperrors.NotFoundError("saved filter", id.String())
+	}
+	return filter, nil
+}
+
+// tagDifference returns the tags in a that are not in b
+func tagDifference(a, b []string) []string {
+	drop := make(map[string]bool, len(b))
+	for _, tag := range b {
+		drop[tag] = true
+	}
+	var out []string
+	for _, tag := range a {
+		if !drop[tag] {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// watchdogTrailHours bounds how much GPS history is read per trip
+const watchdogTrailHours = 24
+
+// TripWatchdog finds in-progress trips that have gone quiet, usually because
+// the driver never completed a stop. A trip is quiet when no stop has been
+// updated and the driver has not been seen near a remaining stop for
+// TripWatchdog.StaleAfterMins. The driver and dispatcher are prompted first;
+// if nothing changes within ReviewAfterMins the trip is auto-completed when
+// every remaining stop was arrived at, and flagged for review otherwise.
+type TripWatchdog struct {
+	dispatch      *DispatchService
+	tripRepo      repository.TripRepository
+	stopRepo      repository.TripStopRepository
+	locationRepo  repository.LocationRepository
+	positionRepo  repository.PositionRepository
+	exceptions    *ExceptionService
+	sms           *SMSService // Optional; without it only dispatch is prompted
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewTripWatchdog creates a new trip watchdog
+func NewTripWatchdog(
+	dispatch *DispatchService,
+	tripRepo repository.TripRepository,
+	stopRepo repository.TripStopRepository,
+	locationRepo repository.LocationRepository,
+	positionRepo repository.PositionRepository,
+	exceptions *ExceptionService,
+	sms *SMSService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *TripWatchdog {
+	return &TripWatchdog{
+		dispatch:      dispatch,
+		tripRepo:      tripRepo,
+		stopRepo:      stopRepo,
+		locationRepo:  locationRepo,
+		positionRepo:  positionRepo,
+		exceptions:    exceptions,
+		sms:           sms,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// CheckTrips runs the watchdog over every in-progress trip and returns the
+// trips it acted on. A failure on one trip is logged and does not stop the
+// rest.
+func (w *TripWatchdog) CheckTrips(ctx context.Context, now time.Time) ([]domain.StaleTrip, error) {
+	trips, _, err := w.tripRepo.List(ctx, repository.TripFilter{
+		Status:   []domain.TripStatus{domain.TripStatusInProgress},
+		PageSize: 1000,
+	})
+	if err != nil {
+		return nil, apperrors.DatabaseError("list in-progress trips", err)
+	}
+
+	var acted []domain.StaleTrip
+	for i := range trips {
+		stale, err := w.checkTrip(ctx, &trips[i], now)
+		if err != nil {
+			w.logger.Errorw("Trip watchdog check failed",
+				"trip_id", trips[i].ID,
+				"error", err,
+			)
+			continue
+		}
+		if stale != nil {
+			acted = append(acted, *stale)
+		}
+	}
+	return acted, nil
+}
+
+// Start runs the watchdog on the given interval until ctx is cancelled
+func (w *TripWatchdog) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if acted, err := w.CheckTrips(ctx, time.Now()); err != nil {
+			w.logger.Errorw("Trip watchdog run failed", "error", err)
+		} else if len(acted) > 0 {
+			w.logger.Infow("Trip watchdog acted on quiet trips", "trips", len(acted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkTrip applies the next watchdog step to one trip, returning nil when
+// the trip is active or already waiting on the previous step
+func (w *TripWatchdog) checkTrip(ctx context.Context, trip *domain.Trip, now time.Time) (*domain.StaleTrip, error) {
+	stops, err := w.stopRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get stops", err)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Sequence < stops[j].Sequence
+	})
+
+	var open []*domain.TripStop
+	for i := range stops {
+		switch stops[i].Status {
+		case domain.StopStatusCompleted, domain.StopStatusSkipped, domain.StopStatusCancelled, domain.StopStatusFailed:
+			continue
+		}
+		open = append(open, &stops[i])
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	trail := w.driverTrail(ctx, trip, now)
+	lastActivity := w.lastActivity(ctx, trip, stops, open, trail)
+	rules := w.businessRules.TripWatchdog
+
+	if now.Sub(lastActivity) < time.Duration(rules.StaleAfterMins)*time.Minute {
+		if trip.StalePromptedAt != nil || trip.StaleFlaggedAt != nil {
+			// Activity resumed, so a later stall starts over with a fresh prompt
+			trip.StalePromptedAt = nil
+			trip.StaleFlaggedAt = nil
+			trip.UpdatedAt = now
+			if err := w.tripRepo.Update(ctx, trip); err != nil {
+				return nil, tripUpdateError(ctx, w.tripRepo, trip.ID, "clear stale trip", err)
+			}
+		}
+		return nil, nil
+	}
+
+	stale := &domain.StaleTrip{
+		TripID:         trip.ID,
+		TripNumber:     trip.TripNumber,
+		DriverID:       trip.DriverID,
+		LastActivityAt: lastActivity,
+		QuietMins:      int(now.Sub(lastActivity).Minutes()),
+		OpenStops:      len(open),
+	}
+
+	switch {
+	case trip.StaleFlaggedAt != nil:
+		return nil, nil
+
+	case trip.StalePromptedAt == nil:
+		stale.Action = domain.StaleTripPrompted
+		w.promptDriver(ctx, trip, open[0])
+		trip.StalePromptedAt = &now
+
+	case now.Sub(*trip.StalePromptedAt) < time.Duration(rules.ReviewAfterMins)*time.Minute:
+		return nil, nil
+
+	case rules.AutoCompleteArrived && allArrived(open):
+		stale.Action = domain.StaleTripAutoCompleted
+		if err := w.autoComplete(ctx, trip, open, trail, now); err != nil {
+			return nil, err
+		}
+		w.publishStale(ctx, stale, open[0])
+		return stale, nil
+
+	default:
+		stale.Action = domain.StaleTripFlagged
+		w.flagForReview(ctx, trip, open[0], stale, now)
+		trip.StaleFlaggedAt = &now
+	}
+
+	trip.UpdatedAt = now
+	if err := w.tripRepo.Update(ctx, trip); err != nil {
+		return nil, tripUpdateError(ctx, w.tripRepo, trip.ID, "mark stale trip", err)
+	}
+	w.publishStale(ctx, stale, open[0])
+
+	w.logger.Warnw("Trip has gone quiet",
+		"trip_id", trip.ID,
+		"trip_number", trip.TripNumber,
+		"quiet_mins", stale.QuietMins,
+		"action", stale.Action,
+	)
+	return stale, nil
+}
+
+// driverTrail returns the driver's recent GPS positions, or nil when the trip
+// has no driver or tracking history is unavailable
+func (w *TripWatchdog) driverTrail(ctx context.Context, trip *domain.Trip, now time.Time) []domain.PositionFix {
+	if trip.DriverID == nil || w.positionRepo == nil {
+		return nil
+	}
+	since := now.Add(-watchdogTrailHours * time.Hour)
+	if trip.ActualStartTime != nil && trip.ActualStartTime.After(since) {
+		since = *trip.ActualStartTime
+	}
+	trail, err := w.positionRepo.GetByDriverID(ctx, *trip.DriverID, since)
+	if err != nil {
+		w.logger.Warnw("Failed to load driver positions for trip watchdog",
+			"trip_id", trip.ID,
+			"error", err,
+		)
+		return nil
+	}
+	return trail
+}
+
+// lastActivity is the latest of the trip start, any stop update, and any GPS
+// position within tolerance of a remaining stop. Driving between stops does
+// not count: a driver who forgot to depart a stop keeps moving all day.
+func (w *TripWatchdog) lastActivity(ctx context.Context, trip *domain.Trip, stops []domain.TripStop, open []*domain.TripStop, trail []domain.PositionFix) time.Time {
+	last := trip.CreatedAt
+	if trip.ActualStartTime != nil {
+		last = *trip.ActualStartTime
+	}
+	for i := range stops {
+		if stops[i].UpdatedAt.After(last) {
+			last = stops[i].UpdatedAt
+		}
+	}
+
+	for _, stop := range open {
+		if seen := w.lastSeenAt(ctx, stop, trail); seen != nil && seen.After(last) {
+			last = *seen
+		}
+	}
+	return last
+}
+
+// lastSeenAt returns when the driver was last within tolerance of the stop
+// location, or nil when they were not seen there or the location is unknown
+func (w *TripWatchdog) lastSeenAt(ctx context.Context, stop *domain.TripStop, trail []domain.PositionFix) *time.Time {
+	if len(trail) == 0 {
+		return nil
+	}
+	if stop.Location == nil {
+		stop.Location, _ = w.locationRepo.GetByID(ctx, stop.LocationID)
+	}
+	for i := len(trail) - 1; i >= 0; i-- {
+		fix := trail[i]
+		if !hasPosition(fix.Latitude, fix.Longitude) {
+			continue
+		}
+		check := w.dispatch.checkStopPosition(ctx, stop, fix.Latitude, fix.Longitude)
+		if check == nil {
+			return nil
+		}
+		if !check.mismatch() {
+			return &fix.RecordedAt
+		}
+	}
+	return nil
+}
+
+// allArrived reports whether every remaining stop that needs servicing was
+// arrived at. Waypoints are ignored; they are skipped on auto-complete.
+func allArrived(open []*domain.TripStop) bool {
+	arrived := false
+	for _, stop := range open {
+		if stop.Type == domain.StopTypeWaypoint {
+			continue
+		}
+		if stop.Status != domain.StopStatusArrived && stop.Status != domain.StopStatusInProgress {
+			return false
+		}
+		arrived = true
+	}
+	return arrived
+}
+
+// promptDriver texts the driver about the first open stop. A failed text is
+// logged; dispatch is still prompted through the stale trip event.
+func (w *TripWatchdog) promptDriver(ctx context.Context, trip *domain.Trip, stop *domain.TripStop) {
+	if w.sms == nil || trip.DriverID == nil {
+		return
+	}
+	if _, err := w.sms.SendStopReminder(ctx, trip, stop, "trip-watchdog"); err != nil {
+		w.logger.Warnw("Failed to text driver about quiet trip",
+			"trip_id", trip.ID,
+			"driver_id", trip.DriverID,
+			"error", err,
+		)
+	}
+}
+
+// autoComplete departs every remaining arrived stop and skips remaining
+// waypoints, which completes the trip. Each departure is the last time the
+// driver was seen at the stop, falling back to the planned dwell.
+func (w *TripWatchdog) autoComplete(ctx context.Context, trip *domain.Trip, open []*domain.TripStop, trail []domain.PositionFix, now time.Time) error {
+	for _, stop := range open {
+		if stop.Type != domain.StopTypeWaypoint {
+			continue
+		}
+		stop.Status = domain.StopStatusSkipped
+		stop.Notes = appendNote(stop.Notes, "Skipped by trip watchdog")
+		stop.UpdatedAt = now
+		if err := w.stopRepo.Update(ctx, stop); err != nil {
+			return stopUpdateError(ctx, w.stopRepo, stop.ID, "skip waypoint", err)
+		}
+	}
+
+	for _, stop := range open {
+		if stop.Type == domain.StopTypeWaypoint {
+			continue
+		}
+		arrival := stop.UpdatedAt
+		if stop.ActualArrival != nil {
+			arrival = *stop.ActualArrival
+		}
+		departure := arrival.Add(time.Duration(stop.EstimatedDurationMins) * time.Minute)
+		if seen := w.lastSeenAt(ctx, stop, trail); seen != nil && seen.After(arrival) {
+			departure = *seen
+		}
+		if departure.After(now) {
+			departure = now
+		}
+
+		if _, err := w.dispatch.CompleteStop(ctx, CompleteStopInput{
+			TripID:           trip.ID,
+			StopID:           stop.ID,
+			DepartureTime:    departure,
+			GateTicketNumber: stop.GateTicketNumber,
+			SealNumber:       stop.SealNumber,
+			Notes: appendNote(stop.Notes, fmt.Sprintf("Departure %s set by trip watchdog; driver did not complete the stop",
+				departure.Format(time.RFC3339))),
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.logger.Infow("Trip auto-completed by watchdog",
+		"trip_id", trip.ID,
+		"trip_number", trip.TripNumber,
+	)
+	return nil
+}
+
+// flagForReview raises an exception so dispatch confirms what happened to
+// the open stops. Failures are logged; the trip is still marked flagged so
+// the event reaches dispatch.
+func (w *TripWatchdog) flagForReview(ctx context.Context, trip *domain.Trip, stop *domain.TripStop, stale *domain.StaleTrip, now time.Time) {
+	if w.exceptions == nil {
+		return
+	}
+	_, err := w.exceptions.CreateException(ctx, CreateExceptionInput{
+		TripID:   trip.ID,
+		StopID:   &stop.ID,
+		OrderID:  stop.OrderID,
+		DriverID: trip.DriverID,
+		Type:     domain.ExceptionTypeStaleTrip,
+		Title:    fmt.Sprintf("Trip %s has had no activity for %d min", trip.TripNumber, stale.QuietMins),
+		Description: fmt.Sprintf("No stop updates and no GPS near a remaining stop since %s. %d stop(s) still open, starting with stop %d (%s). Confirm whether they were serviced, then complete, roll over, or fail the trip.",
+			stale.LastActivityAt.Format(time.RFC3339), stale.OpenStops, stop.Sequence, stop.Status),
+		LocationID: &stop.LocationID,
+		ReportedBy: "trip-watchdog",
+		Metadata: map[string]string{
+			"last_activity_at": stale.LastActivityAt.Format(time.RFC3339),
+			"quiet_mins":       fmt.Sprintf("%d", stale.QuietMins),
+			"open_stops":       fmt.Sprintf("%d", stale.OpenStops),
+		},
+		OccurredAt: &now,
+	})
+	if err != nil {
+		w.logger.Errorw("Failed to raise stale trip exception",
+			"trip_id", trip.ID,
+			"error", err,
+		)
+	}
+}
+
+func (w *TripWatchdog) publishStale(ctx context.Context, stale *domain.StaleTrip, stop *domain.TripStop) {
+	topic := kafka.Topics.TripStale
+	if stale.Action == domain.StaleTripAutoCompleted {
+		topic = kafka.Topics.TripAutoClosed
+	}
+	data := map[string]interface{}{
+		"trip_id":          stale.TripID.String(),
+		"trip_number":      stale.TripNumber,
+		"action":           stale.Action,
+		"last_activity_at": stale.LastActivityAt,
+		"quiet_mins":       stale.QuietMins,
+		"open_stops":       stale.OpenStops,
+		"stop_id":          stop.ID.String(),
+		"stop_sequence":    stop.Sequence,
+	}
+	if stale.DriverID != nil {
+		data["driver_id"] = stale.DriverID.String()
+	}
+	event := kafka.NewEvent(topic, "dispatch-service", data)
+	_ = w.eventProducer.Publish(ctx, topic, event)
+}
+
+// appendNote adds a line to free-text stop notes
+func appendNote(notes, line string) string {
+	return strings.TrimSpace(notes + "\n" + line)
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/dispatch-service/internal/domain"
+	"github.com/draymaster/services/dispatch-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxGateClockAheadMins is how far ahead of the server a gate time may be
+// before it is treated as a data entry mistake
+const maxGateClockAheadMins = 5
+
+// YardGateService records containers and chassis passing company yard
+// gates. Each event keeps the yard inventory current, starts or stops the
+// container's per-diem clock and opens or closes chassis usage for billing.
+type YardGateService struct {
+	yardRepo      repository.YardRepository
+	chassisRepo   repository.ChassisUsageRepository
+	locationRepo  repository.LocationRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewYardGateService creates a new yard gate service
+func NewYardGateService(
+	yardRepo repository.YardRepository,
+	chassisRepo repository.ChassisUsageRepository,
+	locationRepo repository.LocationRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *YardGateService {
+	return &YardGateService{
+		yardRepo:      yardRepo,
+		chassisRepo:   chassisRepo,
+		locationRepo:  locationRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// RecordGateEventInput contains input for recording a yard gate event.
+// A zero OccurredAt means now.
+type RecordGateEventInput struct {
+	YardLocationID   uuid.UUID
+	Direction        domain.GateDirection
+	ContainerNumber  string
+	ContainerSize    string
+	IsLoaded         bool
+	SealNumber       string
+	ChassisID        *uuid.UUID
+	ChassisNumber    string
+	ChassisPoolID    *uuid.UUID
+	TripID           *uuid.UUID
+	DriverID         *uuid.UUID
+	TractorID        *uuid.UUID
+	Condition        domain.EquipmentCondition
+	DamageNotes      string
+	PhotoDocumentIDs []string
+	OccurredAt       time.Time
+	RecordedBy       string
+}
+
+// YardInventoryEntry is an inventory entry with its per-diem clock
+type YardInventoryEntry struct {
+	domain.YardInventoryItem
+	StorageDays    int `json:"storage_days"`
+	ChargeableDays int `json:"chargeable_days"` // Storage days beyond the per-diem free days
+}
+
+// RecordGateEvent records equipment entering or leaving a company yard
+func (s *YardGateService) RecordGateEvent(ctx context.Context, input RecordGateEventInput) (*domain.YardGateEvent, error) {
+	yard, err := s.locationRepo.GetByID(ctx, input.YardLocationID)
+	if err != nil {
+		return nil, apperrors.NotFoundError("location", input.YardLocationID.String())
+	}
+	if !yard.IsYard() {
+		return nil, apperrors.ValidationError("gate events are only recorded at company yards", "yard_location_id", input.YardLocationID.String())
+	}
+
+	event, err := s.newGateEvent(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event.Direction {
+	case domain.GateDirectionIn:
+		err = s.gateIn(ctx, yard, event)
+	case domain.GateDirectionOut:
+		err = s.gateOut(ctx, yard, event)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Yard gate event recorded",
+		"event_id", event.ID,
+		"yard", yard.Name,
+		"direction", event.Direction,
+		"container_number", event.ContainerNumber,
+		"chassis_number", event.ChassisNumber,
+		"condition", event.Condition,
+	)
+	return event, nil
+}
+
+// newGateEvent validates the input and builds the event to record
+func (s *YardGateService) newGateEvent(input RecordGateEventInput) (*domain.YardGateEvent, error) {
+	rules := &s.businessRules.YardGate
+
+	if input.Direction != domain.GateDirectionIn && input.Direction != domain.GateDirectionOut {
+		return nil, apperrors.ValidationError("direction must be IN or OUT", "direction", string(input.Direction))
+	}
+	containerNumber := strings.ToUpper(strings.TrimSpace(input.ContainerNumber))
+	chassisNumber := strings.ToUpper(strings.TrimSpace(input.ChassisNumber))
+	if containerNumber == "" && chassisNumber == "" {
+		return nil, apperrors.ValidationError("container number or chassis number is required", "container_number", "")
+	}
+	if input.ChassisID != nil && chassisNumber == "" {
+		return nil, apperrors.ValidationError("chassis number is required with a chassis", "chassis_number", "")
+	}
+
+	condition := input.Condition
+	if condition == "" {
+		condition = domain.EquipmentConditionGood
+	}
+	if condition != domain.EquipmentConditionGood && condition != domain.EquipmentConditionDamaged {
+		return nil, apperrors.ValidationError("condition must be GOOD or DAMAGED", "condition", string(condition))
+	}
+	if condition == domain.EquipmentConditionDamaged {
+		if strings.TrimSpace(input.DamageNotes) == "" {
+			return nil, apperrors.ValidationError("damage notes are required for damaged equipment", "damage_notes", "")
+		}
+		if rules.RequireDamagePhotos && len(input.PhotoDocumentIDs) == 0 {
+			return nil, apperrors.ValidationError("photos are required for damaged equipment", "photo_document_ids", "")
+		}
+	}
+
+	now := time.Now()
+	occurredAt := input.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+	if occurredAt.After(now.Add(maxGateClockAheadMins * time.Minute)) {
+		return nil, apperrors.ValidationError("gate time is in the future", "occurred_at", occurredAt.Format(time.RFC3339))
+	}
+	if occurredAt.Before(now.Add(-time.Duration(rules.MaxBackdateHours) * time.Hour)) {
+		return nil, apperrors.ValidationError("gate time is too far in the past to record", "occurred_at", occurredAt.Format(time.RFC3339))
+	}
+
+	return &domain.YardGateEvent{
+		ID:               uuid.New(),
+		YardLocationID:   input.YardLocationID,
+		Direction:        input.Direction,
+		ContainerNumber:  containerNumber,
+		ContainerSize:    input.ContainerSize,
+		IsLoaded:         input.IsLoaded,
+		SealNumber:       strings.TrimSpace(input.SealNumber),
+		ChassisID:        input.ChassisID,
+		ChassisNumber:    chassisNumber,
+		ChassisPoolID:    input.ChassisPoolID,
+		TripID:           input.TripID,
+		DriverID:         input.DriverID,
+		TractorID:        input.TractorID,
+		Condition:        condition,
+		DamageNotes:      strings.TrimSpace(input.DamageNotes),
+		PhotoDocumentIDs: input.PhotoDocumentIDs,
+		OccurredAt:       occurredAt,
+		RecordedBy:       input.RecordedBy,
+		CreatedAt:        now,
+	}, nil
+}
+
+// openInventory returns the entry the event's equipment occupies in any
+// yard, or nil if it is not on the ground
+func (s *YardGateService) openInventory(ctx context.Context, event *domain.YardGateEvent) (*domain.YardInventoryItem, error) {
+	var item *domain.YardInventoryItem
+	var err error
+	if event.ContainerNumber != "" {
+		item, err = s.yardRepo.GetOpenByContainer(ctx, event.ContainerNumber)
+	} else {
+		item, err = s.yardRepo.GetOpenBareChassis(ctx, event.ChassisNumber)
+	}
+	if err != nil {
+		return nil, apperrors.DatabaseError("get yard inventory", err)
+	}
+	return item, nil
+}
+
+// gateIn puts the equipment in the yard inventory, starting the per-diem
+// clock, and closes the chassis usage it was out on
+func (s *YardGateService) gateIn(ctx context.Context, yard *domain.Location, event *domain.YardGateEvent) error {
+	existing, err := s.openInventory(ctx, event)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return apperrors.ConflictError("equipment is already in a yard; record its out-gate first").
+			WithDetail("inventory_id", existing.ID.String()).
+			WithDetail("yard_location_id", existing.YardLocationID.String())
+	}
+
+	item := &domain.YardInventoryItem{
+		ID:              uuid.New(),
+		YardLocationID:  yard.ID,
+		ContainerNumber: event.ContainerNumber,
+		ContainerSize:   event.ContainerSize,
+		IsLoaded:        event.IsLoaded,
+		OnChassis:       event.ContainerNumber != "" && event.ChassisNumber != "",
+		ChassisID:       event.ChassisID,
+		ChassisNumber:   event.ChassisNumber,
+		InDate:          event.OccurredAt,
+		InCondition:     event.Condition,
+		InGateEventID:   event.ID,
+		Status:          domain.YardInventoryStatusInYard,
+		CreatedAt:       event.CreatedAt,
+		UpdatedAt:       event.CreatedAt,
+	}
+	event.InventoryID = &item.ID
+
+	usage, err := s.returnChassis(ctx, yard, event)
+	if err != nil {
+		return err
+	}
+
+	if err := s.yardRepo.CreateGateEvent(ctx, event); err != nil {
+		return apperrors.DatabaseError("create gate event", err)
+	}
+	if err := s.yardRepo.CreateInventory(ctx, item); err != nil {
+		return apperrors.DatabaseError("create yard inventory", err)
+	}
+	if usage != nil {
+		if err := s.chassisRepo.Update(ctx, usage); err != nil {
+			return apperrors.DatabaseError("close chassis usage", err)
+		}
+		s.publishChassisUsageClosed(ctx, usage)
+	}
+
+	kafkaEvent := kafka.NewEvent(kafka.Topics.YardGateIn, "dispatch-service", map[string]interface{}{
+		"event_id":            event.ID.String(),
+		"inventory_id":        item.ID.String(),
+		"yard_location_id":    yard.ID.String(),
+		"container_number":    event.ContainerNumber,
+		"chassis_number":      event.ChassisNumber,
+		"is_loaded":           event.IsLoaded,
+		"condition":           string(event.Condition),
+		"per_diem_started_at": item.InDate,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.YardGateIn, kafkaEvent)
+	return nil
+}
+
+// returnChassis closes the open usage of a chassis coming back into a yard.
+// It returns nil when the chassis is unknown or was not out.
+func (s *YardGateService) returnChassis(ctx context.Context, yard *domain.Location, event *domain.YardGateEvent) (*domain.ChassisUsage, error) {
+	if event.ChassisID == nil {
+		return nil, nil
+	}
+	usage, err := s.chassisRepo.GetOpenByChassis(ctx, *event.ChassisID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get chassis usage", err)
+	}
+	if usage == nil {
+		return nil, nil
+	}
+	if event.OccurredAt.Before(usage.PickupTime) {
+		return nil, apperrors.ValidationError("chassis in-gate is before it left the yard", "occurred_at", event.OccurredAt.Format(time.RFC3339))
+	}
+	usage.Close(event.OccurredAt, yard.Name)
+	event.ChassisUsageID = &usage.ID
+	return usage, nil
+}
+
+// gateOut takes the equipment out of the yard inventory, stopping the
+// per-diem clock, and opens chassis usage for a chassis leaving the yard
+func (s *YardGateService) gateOut(ctx context.Context, yard *domain.Location, event *domain.YardGateEvent) error {
+	item, err := s.openInventory(ctx, event)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return apperrors.NotFoundError("yard inventory", event.ContainerNumber+event.ChassisNumber)
+	}
+	if item.YardLocationID != yard.ID {
+		return apperrors.ValidationError("equipment is in a different yard", "yard_location_id", item.YardLocationID.String())
+	}
+	if event.OccurredAt.Before(item.InDate) {
+		return apperrors.ValidationError("out-gate is before the in-gate", "occurred_at", event.OccurredAt.Format(time.RFC3339))
+	}
+
+	item.OutDate = &event.OccurredAt
+	item.OutCondition = event.Condition
+	item.OutGateEventID = &event.ID
+	item.Status = domain.YardInventoryStatusOutGated
+	item.UpdatedAt = event.CreatedAt
+	event.InventoryID = &item.ID
+
+	var usage *domain.ChassisUsage
+	if event.ChassisID != nil {
+		open, err := s.chassisRepo.GetOpenByChassis(ctx, *event.ChassisID)
+		if err != nil {
+			return apperrors.DatabaseError("get chassis usage", err)
+		}
+		if open != nil {
+			return apperrors.ConflictError("chassis already has open usage; record its in-gate first").
+				WithDetail("chassis_usage_id", open.ID.String())
+		}
+		usage = &domain.ChassisUsage{
+			ID:             uuid.New(),
+			ChassisID:      *event.ChassisID,
+			TripID:         event.TripID,
+			PoolID:         event.ChassisPoolID,
+			PickupTime:     event.OccurredAt,
+			PickupLocation: yard.Name,
+			CreatedAt:      event.CreatedAt,
+		}
+		event.ChassisUsageID = &usage.ID
+	}
+
+	if err := s.yardRepo.CreateGateEvent(ctx, event); err != nil {
+		return apperrors.DatabaseError("create gate event", err)
+	}
+	if err := s.yardRepo.UpdateInventory(ctx, item); err != nil {
+		return apperrors.DatabaseError("update yard inventory", err)
+	}
+	if usage != nil {
+		if err := s.chassisRepo.Create(ctx, usage); err != nil {
+			return apperrors.DatabaseError("create chassis usage", err)
+		}
+	}
+
+	entry := s.inventoryEntry(*item, event.OccurredAt)
+	kafkaEvent := kafka.NewEvent(kafka.Topics.YardGateOut, "dispatch-service", map[string]interface{}{
+		"event_id":            event.ID.String(),
+		"inventory_id":        item.ID.String(),
+		"yard_location_id":    yard.ID.String(),
+		"container_number":    event.ContainerNumber,
+		"container_size":      item.ContainerSize,
+		"chassis_number":      event.ChassisNumber,
+		"condition":           string(event.Condition),
+		"per_diem_started_at": item.InDate,
+		"per_diem_stopped_at": event.OccurredAt,
+		"storage_days":        entry.StorageDays,
+		"chargeable_days":     entry.ChargeableDays,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.YardGateOut, kafkaEvent)
+	return nil
+}
+
+// publishChassisUsageClosed hands a completed chassis usage to billing
+func (s *YardGateService) publishChassisUsageClosed(ctx context.Context, usage *domain.ChassisUsage) {
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.ChassisUsageClosed, chassisUsageClosedEvent(usage))
+}
+
+// chassisUsageClosedEvent builds the ChassisUsageClosed event billing prices usage from
+func chassisUsageClosedEvent(usage *domain.ChassisUsage) *kafka.Event {
+	data := map[string]interface{}{
+		"chassis_usage_id": usage.ID.String(),
+		"chassis_id":       usage.ChassisID.String(),
+		"pickup_time":      usage.PickupTime,
+		"pickup_location":  usage.PickupLocation,
+		"return_time":      usage.ReturnTime,
+		"return_location":  usage.ReturnLocation,
+		"usage_days":       usage.UsageDays,
+	}
+	if usage.TripID != nil {
+		data["trip_id"] = usage.TripID.String()
+	}
+	if usage.PoolID != nil {
+		data["pool_id"] = usage.PoolID.String()
+	}
+	return kafka.NewEvent(kafka.Topics.ChassisUsageClosed, "dispatch-service", data)
+}
+
+// GetYardInventory returns what is on the ground in a yard with each
+// container's per-diem clock as of now
+func (s *YardGateService) GetYardInventory(ctx context.Context, yardLocationID uuid.UUID) ([]YardInventoryEntry, error) {
+	items, err := s.yardRepo.GetInventory(ctx, yardLocationID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get yard inventory", err)
+	}
+	now := time.Now()
+	entries := make([]YardInventoryEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, s.inventoryEntry(item, now))
+	}
+	return entries, nil
+}
+
+// GetGateEvents returns a yard's gate events in a time range
+func (s *YardGateService) GetGateEvents(ctx context.Context, yardLocationID uuid.UUID, from, to time.Time) ([]domain.YardGateEvent, error) {
+	if !to.After(from) {
+		return nil, apperrors.ValidationError("to must be after from", "to", to.Format(time.RFC3339))
+	}
+	events, err := s.yardRepo.GetGateEvents(ctx, yardLocationID, from, to)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get gate events", err)
+	}
+	return events, nil
+}
+
+// inventoryEntry applies the per-diem free days to an entry's storage days.
+// Bare chassis are not on a per-diem clock.
+func (s *YardGateService) inventoryEntry(item domain.YardInventoryItem, asOf time.Time) YardInventoryEntry {
+	entry := YardInventoryEntry{YardInventoryItem: item}
+	if item.ContainerNumber == "" {
+		return entry
+	}
+	entry.StorageDays = item.StorageDays(asOf)
+	if chargeable := entry.StorageDays - s.businessRules.PerDiem.FreeDays; chargeable > 0 {
+		entry.ChargeableDays = chargeable
+	}
+	return entry
+}
@@ -0,0 +1,21 @@
+-- 000001_init_schema.down.sql
+
+DROP VIEW IF EXISTS street_turn_candidates;
+DROP VIEW IF EXISTS dispatch_board_view;
+
+DROP TABLE IF EXISTS stop_documents;
+DROP TABLE IF EXISTS trip_orders;
+DROP TABLE IF EXISTS trip_stops;
+DROP TABLE IF EXISTS trips;
+
+DROP FUNCTION IF EXISTS generate_trip_number();
+-- update_updated_at_column() is shared by the order and dispatch schemas and
+-- is left in place.
+
+DROP TYPE IF EXISTS stop_status;
+DROP TYPE IF EXISTS activity_type;
+DROP TYPE IF EXISTS stop_type;
+DROP TYPE IF EXISTS trip_status;
+DROP TYPE IF EXISTS trip_type;
+
+DROP SEQUENCE IF EXISTS trip_number_seq;
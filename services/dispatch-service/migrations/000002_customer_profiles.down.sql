@@ -0,0 +1,5 @@
+-- 000002_customer_profiles.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS detention_charge;
+
+DROP TABLE IF EXISTS customer_profiles;
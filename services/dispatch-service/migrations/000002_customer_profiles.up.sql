@@ -0,0 +1,25 @@
+-- 000002_customer_profiles.up.sql
+-- Customer contract free time and detention profiles
+
+CREATE TABLE customer_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    -- Free time overrides keyed by activity type, e.g. {"LIVE_UNLOAD": 180}
+    free_time_mins JSONB NOT NULL DEFAULT '{}',
+    -- Hourly tiers: [{"from_hour": 1, "to_hour": 2, "rate_per_hour": 65}]
+    detention_tiers JSONB NOT NULL DEFAULT '[]',
+    grace_period_mins INTEGER,
+    max_daily_charge DECIMAL(10,2) DEFAULT 0,
+    count_weekends BOOLEAN NOT NULL DEFAULT true,
+    count_holidays BOOLEAN NOT NULL DEFAULT true,
+    holidays DATE[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_customer_profiles_customer ON customer_profiles(customer_id) WHERE is_active;
+
+-- Detention charge computed from the resolved profile when a stop completes
+ALTER TABLE trip_stops ADD COLUMN detention_charge DECIMAL(10,2) DEFAULT 0;
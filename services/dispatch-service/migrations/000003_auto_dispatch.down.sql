@@ -0,0 +1,4 @@
+-- 000003_auto_dispatch.down.sql
+
+DROP TABLE IF EXISTS auto_dispatch_decisions;
+DROP TABLE IF EXISTS auto_dispatch_configs;
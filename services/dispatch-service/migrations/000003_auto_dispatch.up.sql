@@ -0,0 +1,37 @@
+-- 000003_auto_dispatch.up.sql
+-- Per-terminal auto-dispatch configuration and decision log
+
+CREATE TABLE auto_dispatch_configs (
+    terminal_id UUID PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    window_start VARCHAR(5),
+    window_end VARCHAR(5),
+    lookahead_mins INTEGER NOT NULL DEFAULT 240,
+    max_distance_miles DECIMAL(8,2) DEFAULT 0,
+    override_mins INTEGER NOT NULL DEFAULT 5,
+    updated_by VARCHAR(100),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE auto_dispatch_decisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    trip_number VARCHAR(20) NOT NULL,
+    terminal_id UUID NOT NULL,
+    driver_id UUID,
+    driver_name VARCHAR(200),
+    status VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    distance_miles DECIMAL(8,2) DEFAULT 0,
+    eta_to_pickup_mins INTEGER DEFAULT 0,
+    sla_deadline TIMESTAMP WITH TIME ZONE,
+    slack_mins INTEGER DEFAULT 0,
+    override_deadline TIMESTAMP WITH TIME ZONE,
+    overridden_by VARCHAR(100),
+    override_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_auto_dispatch_decisions_pending ON auto_dispatch_decisions(override_deadline) WHERE status = 'PENDING_OVERRIDE';
+CREATE INDEX idx_auto_dispatch_decisions_trip ON auto_dispatch_decisions(trip_id);
@@ -0,0 +1,7 @@
+-- 000004_trip_rollover.down.sql
+
+DROP INDEX IF EXISTS idx_trips_rolled_over_from;
+
+ALTER TABLE trips
+    DROP COLUMN IF EXISTS rolled_over_to_trip_id,
+    DROP COLUMN IF EXISTS rolled_over_from_trip_id;
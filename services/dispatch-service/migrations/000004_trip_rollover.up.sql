@@ -0,0 +1,8 @@
+-- 000004_trip_rollover.up.sql
+-- Link trips whose remaining stops were rolled into a new trip
+
+ALTER TABLE trips
+    ADD COLUMN rolled_over_from_trip_id UUID REFERENCES trips(id),
+    ADD COLUMN rolled_over_to_trip_id UUID REFERENCES trips(id);
+
+CREATE INDEX idx_trips_rolled_over_from ON trips(rolled_over_from_trip_id) WHERE rolled_over_from_trip_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- 000005_sms_messages.down.sql
+
+DROP TABLE IF EXISTS sms_messages;
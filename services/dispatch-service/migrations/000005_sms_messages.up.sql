@@ -0,0 +1,22 @@
+-- 000005_sms_messages.up.sql
+-- Audit trail for the two-way SMS workflow with drivers without the mobile app
+
+CREATE TABLE sms_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    direction VARCHAR(10) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    driver_id UUID,
+    trip_id UUID REFERENCES trips(id),
+    stop_id UUID REFERENCES trip_stops(id),
+    body TEXT NOT NULL,
+    keyword VARCHAR(10),
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    provider_message_id VARCHAR(100),
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sms_messages_driver ON sms_messages(driver_id, created_at);
+CREATE INDEX idx_sms_messages_trip ON sms_messages(trip_id) WHERE trip_id IS NOT NULL;
+CREATE INDEX idx_sms_messages_phone ON sms_messages(phone);
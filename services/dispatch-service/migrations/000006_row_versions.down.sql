@@ -0,0 +1,4 @@
+-- 000006_row_versions.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS version;
+ALTER TABLE trips DROP COLUMN IF EXISTS version;
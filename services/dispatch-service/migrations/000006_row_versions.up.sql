@@ -0,0 +1,5 @@
+-- 000006_row_versions.up.sql
+-- Version columns for optimistic concurrency on trip and stop edits
+
+ALTER TABLE trips ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE trip_stops ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- 000007_waypoint_stops.down.sql
+-- PostgreSQL cannot drop a value from an enum, so WAYPOINT stays on stop_type.
@@ -0,0 +1,4 @@
+-- 000007_waypoint_stops.up.sql
+-- En-route stop type for automatically inserted scale and fuel stops
+
+ALTER TYPE stop_type ADD VALUE IF NOT EXISTS 'WAYPOINT';
@@ -0,0 +1,11 @@
+-- 000008_stop_location_offsets.down.sql
+
+DROP INDEX IF EXISTS idx_trip_stops_location_mismatch;
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS location_mismatch;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS completion_offset_meters;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS completion_longitude;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS completion_latitude;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS arrival_offset_meters;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS arrival_longitude;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS arrival_latitude;
@@ -0,0 +1,12 @@
+-- 000008_stop_location_offsets.up.sql
+-- Reported arrival and completion positions and their offset from the stop location
+
+ALTER TABLE trip_stops ADD COLUMN arrival_latitude DECIMAL(10,8);
+ALTER TABLE trip_stops ADD COLUMN arrival_longitude DECIMAL(11,8);
+ALTER TABLE trip_stops ADD COLUMN arrival_offset_meters DECIMAL(10,1);
+ALTER TABLE trip_stops ADD COLUMN completion_latitude DECIMAL(10,8);
+ALTER TABLE trip_stops ADD COLUMN completion_longitude DECIMAL(11,8);
+ALTER TABLE trip_stops ADD COLUMN completion_offset_meters DECIMAL(10,1);
+ALTER TABLE trip_stops ADD COLUMN location_mismatch BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_trip_stops_location_mismatch ON trip_stops(trip_id) WHERE location_mismatch;
@@ -0,0 +1,4 @@
+-- 000009_trip_watchdog.down.sql
+
+ALTER TABLE trips DROP COLUMN IF EXISTS stale_flagged_at;
+ALTER TABLE trips DROP COLUMN IF EXISTS stale_prompted_at;
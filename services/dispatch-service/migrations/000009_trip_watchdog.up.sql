@@ -0,0 +1,5 @@
+-- 000009_trip_watchdog.up.sql
+-- When the trip watchdog prompted the driver about, or flagged, a quiet in-progress trip
+
+ALTER TABLE trips ADD COLUMN stale_prompted_at TIMESTAMPTZ;
+ALTER TABLE trips ADD COLUMN stale_flagged_at TIMESTAMPTZ;
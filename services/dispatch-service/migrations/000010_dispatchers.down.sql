@@ -0,0 +1,7 @@
+-- 000010_dispatchers.down.sql
+
+DROP INDEX IF EXISTS idx_trips_dispatcher;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS dispatcher_id;
+
+DROP TABLE IF EXISTS dispatchers;
@@ -0,0 +1,20 @@
+-- 000010_dispatchers.up.sql
+-- Dispatchers, the terminals they cover, and the dispatcher owning each trip
+
+CREATE TABLE dispatchers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    terminal_ids UUID[] NOT NULL DEFAULT '{}',
+    max_active_trips INTEGER NOT NULL DEFAULT 0,
+    last_assigned_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_dispatchers_active ON dispatchers(active) WHERE active;
+
+ALTER TABLE trips ADD COLUMN dispatcher_id UUID REFERENCES dispatchers(id);
+
+CREATE INDEX idx_trips_dispatcher ON trips(dispatcher_id);
@@ -0,0 +1,7 @@
+-- 000011_seal_verification.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS seal_verified_by;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS seal_verified_at;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS seal_photo_document_id;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS seal_status;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS expected_seal_number;
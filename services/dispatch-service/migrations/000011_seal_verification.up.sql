@@ -0,0 +1,8 @@
+-- 000011_seal_verification.up.sql
+-- Seal expected from the shipment and the driver's verification at loaded pickups
+
+ALTER TABLE trip_stops ADD COLUMN expected_seal_number VARCHAR(50);
+ALTER TABLE trip_stops ADD COLUMN seal_status VARCHAR(20);
+ALTER TABLE trip_stops ADD COLUMN seal_photo_document_id VARCHAR(100);
+ALTER TABLE trip_stops ADD COLUMN seal_verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE trip_stops ADD COLUMN seal_verified_by VARCHAR(100);
@@ -0,0 +1,3 @@
+-- 000012_driver_allocations.down.sql
+
+DROP TABLE IF EXISTS driver_allocations;
@@ -0,0 +1,25 @@
+-- 000012_driver_allocations.up.sql
+-- Soft allocations holding drivers for future trips before they are assigned
+
+CREATE TABLE driver_allocations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL,
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    trip_number VARCHAR(20) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'HELD',
+    notes TEXT,
+    allocated_by VARCHAR(100) NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    released_at TIMESTAMP WITH TIME ZONE,
+    released_by VARCHAR(100),
+    released_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (window_end > window_start)
+);
+
+CREATE INDEX idx_driver_allocations_driver_window ON driver_allocations(driver_id, window_start, window_end) WHERE status = 'HELD';
+CREATE INDEX idx_driver_allocations_trip ON driver_allocations(trip_id) WHERE status = 'HELD';
+
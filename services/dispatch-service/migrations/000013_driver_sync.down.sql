@@ -0,0 +1,7 @@
+-- 000013_driver_sync.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS pod_received_at;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS pod_signed_by;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS pod_document_ids;
+
+DROP TABLE IF EXISTS driver_sync_actions;
@@ -0,0 +1,26 @@
+-- 000013_driver_sync.up.sql
+-- Driver app actions uploaded after being recorded offline, and proof of
+-- delivery captured at stops
+
+CREATE TABLE driver_sync_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL,
+    device_id VARCHAR(100),
+    idempotency_key VARCHAR(100) NOT NULL,
+    action_type VARCHAR(20) NOT NULL,
+    trip_id UUID NOT NULL,
+    stop_id UUID NOT NULL,
+    client_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    applied_time TIMESTAMP WITH TIME ZONE,
+    outcome VARCHAR(20) NOT NULL,
+    message TEXT,
+    exception_id UUID,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (driver_id, idempotency_key)
+);
+
+CREATE INDEX idx_driver_sync_actions_trip ON driver_sync_actions(trip_id);
+
+ALTER TABLE trip_stops ADD COLUMN pod_document_ids TEXT[];
+ALTER TABLE trip_stops ADD COLUMN pod_signed_by VARCHAR(100);
+ALTER TABLE trip_stops ADD COLUMN pod_received_at TIMESTAMP WITH TIME ZONE;
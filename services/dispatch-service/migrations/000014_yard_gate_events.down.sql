@@ -0,0 +1,5 @@
+-- 000014_yard_gate_events.down.sql
+
+DROP TABLE IF EXISTS chassis_usage;
+DROP TABLE IF EXISTS yard_inventory;
+DROP TABLE IF EXISTS yard_gate_events;
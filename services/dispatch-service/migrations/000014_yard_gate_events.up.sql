@@ -0,0 +1,71 @@
+-- 000014_yard_gate_events.up.sql
+-- Containers and chassis passing company yard gates, what is on the ground
+-- in each yard, and chassis usage from yard out-gate to in-gate
+
+CREATE TABLE yard_gate_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    yard_location_id UUID NOT NULL,
+    direction VARCHAR(3) NOT NULL,
+    container_number VARCHAR(15),
+    container_size VARCHAR(10),
+    is_loaded BOOLEAN NOT NULL DEFAULT FALSE,
+    seal_number VARCHAR(50),
+    chassis_id UUID,
+    chassis_number VARCHAR(20),
+    chassis_pool_id UUID,
+    trip_id UUID REFERENCES trips(id),
+    driver_id UUID,
+    tractor_id UUID,
+    condition VARCHAR(10) NOT NULL DEFAULT 'GOOD',
+    damage_notes TEXT,
+    photo_document_ids TEXT[],
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_by VARCHAR(100) NOT NULL,
+    inventory_id UUID,
+    chassis_usage_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_yard_gate_events_yard ON yard_gate_events(yard_location_id, occurred_at);
+
+CREATE TABLE yard_inventory (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    yard_location_id UUID NOT NULL,
+    container_number VARCHAR(15),
+    container_size VARCHAR(10),
+    is_loaded BOOLEAN NOT NULL DEFAULT FALSE,
+    on_chassis BOOLEAN NOT NULL DEFAULT FALSE,
+    chassis_id UUID,
+    chassis_number VARCHAR(20),
+    in_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    out_date TIMESTAMP WITH TIME ZONE,
+    in_condition VARCHAR(10) NOT NULL,
+    out_condition VARCHAR(10),
+    in_gate_event_id UUID NOT NULL REFERENCES yard_gate_events(id),
+    out_gate_event_id UUID REFERENCES yard_gate_events(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'IN_YARD',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_yard_inventory_yard ON yard_inventory(yard_location_id) WHERE status = 'IN_YARD';
+-- A container can only be on the ground in one yard at a time
+CREATE UNIQUE INDEX idx_yard_inventory_open_container ON yard_inventory(container_number)
+    WHERE status = 'IN_YARD' AND container_number IS NOT NULL;
+CREATE UNIQUE INDEX idx_yard_inventory_open_bare_chassis ON yard_inventory(chassis_number)
+    WHERE status = 'IN_YARD' AND container_number IS NULL;
+
+CREATE TABLE chassis_usage (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    chassis_id UUID NOT NULL,
+    trip_id UUID REFERENCES trips(id),
+    pool_id UUID,
+    pickup_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    pickup_location VARCHAR(200),
+    return_time TIMESTAMP WITH TIME ZONE,
+    return_location VARCHAR(200),
+    usage_days INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_chassis_usage_open ON chassis_usage(chassis_id) WHERE return_time IS NULL;
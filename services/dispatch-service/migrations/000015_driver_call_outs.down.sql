@@ -0,0 +1,4 @@
+-- 000015_driver_call_outs.down.sql
+
+DROP TABLE IF EXISTS reassignment_proposals;
+DROP TABLE IF EXISTS driver_call_outs;
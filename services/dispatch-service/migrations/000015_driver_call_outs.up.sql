@@ -0,0 +1,36 @@
+-- 000015_driver_call_outs.up.sql
+-- Drivers marked out for a day and the proposed reassignments of their trips
+
+CREATE TABLE driver_call_outs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL,
+    driver_name VARCHAR(200) NOT NULL,
+    out_date DATE NOT NULL,
+    reason TEXT,
+    reported_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (driver_id, out_date)
+);
+
+CREATE INDEX idx_driver_call_outs_date ON driver_call_outs(out_date);
+
+CREATE TABLE reassignment_proposals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    call_out_id UUID NOT NULL REFERENCES driver_call_outs(id) ON DELETE CASCADE,
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    trip_number VARCHAR(20) NOT NULL,
+    trip_status trip_status NOT NULL,
+    planned_start_time TIMESTAMP WITH TIME ZONE,
+    from_driver_id UUID NOT NULL,
+    proposed_driver_id UUID,
+    proposed_driver_name VARCHAR(200),
+    deadhead_miles DECIMAL(8,1) NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    decided_by VARCHAR(100),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reassignment_proposals_call_out ON reassignment_proposals(call_out_id);
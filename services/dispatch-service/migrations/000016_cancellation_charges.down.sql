@@ -0,0 +1,5 @@
+-- 000016_cancellation_charges.down.sql
+
+ALTER TABLE customer_profiles DROP COLUMN IF EXISTS cancellation_fee;
+
+DROP TABLE IF EXISTS cancellation_charges;
@@ -0,0 +1,24 @@
+-- 000016_cancellation_charges.up.sql
+-- Fees charged when a customer cancels a trip after dispatch
+
+-- Contract override: {"type": "TIERED", "flat_amount": 100,
+-- "tiers": [{"within_hours": 2, "amount": 175}], "dry_run_amount": 250}
+ALTER TABLE customer_profiles ADD COLUMN cancellation_fee JSONB;
+
+CREATE TABLE cancellation_charges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    trip_number VARCHAR(20) NOT NULL,
+    order_id UUID,
+    charge_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    basis TEXT NOT NULL,
+    -- Trip state the fee was assessed on
+    evidence JSONB NOT NULL,
+    reason TEXT,
+    cancelled_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_cancellation_charges_trip ON cancellation_charges(trip_id);
+CREATE INDEX idx_cancellation_charges_order ON cancellation_charges(order_id) WHERE order_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- 000017_detention_warnings.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS detention_warned_at;
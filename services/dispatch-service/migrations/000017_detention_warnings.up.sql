@@ -0,0 +1,4 @@
+-- 000017_detention_warnings.up.sql
+-- Free time ending alert sent to the driver and dispatcher once per stop
+
+ALTER TABLE trip_stops ADD COLUMN IF NOT EXISTS detention_warned_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- 000018_attachments.down.sql
+
+DROP TABLE IF EXISTS attachments;
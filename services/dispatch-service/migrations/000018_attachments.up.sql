@@ -0,0 +1,20 @@
+-- 000018_attachments.up.sql
+-- Files uploaded against trips: PODs, seal photos and damage photos. The
+-- file itself is in blob storage; storage_key locates it.
+
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL,
+    trip_id UUID REFERENCES trips(id),
+    stop_id UUID REFERENCES trip_stops(id),
+    driver_id UUID,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key VARCHAR(500) NOT NULL UNIQUE,
+    uploaded_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_trip ON attachments(trip_id, created_at);
+CREATE INDEX idx_attachments_stop ON attachments(stop_id) WHERE stop_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- 000019_twic_escorts.down.sql
+
+ALTER TABLE trips DROP COLUMN IF EXISTS twic_escort;
+
+DROP TABLE IF EXISTS escort_charges;
+DROP TABLE IF EXISTS terminal_escort_policies;
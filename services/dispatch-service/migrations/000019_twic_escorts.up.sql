@@ -0,0 +1,28 @@
+-- 000019_twic_escorts.up.sql
+-- Terminal escort policies for drivers without a TWIC, the escort flag on
+-- trips and the escort fees charged for them
+
+CREATE TABLE terminal_escort_policies (
+    terminal_id UUID PRIMARY KEY,
+    escorts_allowed BOOLEAN NOT NULL DEFAULT FALSE,
+    fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    notes TEXT,
+    updated_by VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS twic_escort BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE escort_charges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    trip_number VARCHAR(20) NOT NULL,
+    order_id UUID,
+    terminal_id UUID NOT NULL,
+    driver_id UUID NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    voided_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_escort_charges_trip ON escort_charges(trip_id) WHERE voided_at IS NULL;
@@ -0,0 +1,3 @@
+-- 000020_facility_profiles.down.sql
+
+DROP TABLE IF EXISTS facility_profiles;
@@ -0,0 +1,14 @@
+-- 000020_facility_profiles.up.sql
+-- Copies of order-service shipper and consignee facility profiles for driver manifests
+
+CREATE TABLE facility_profiles (
+    location_id UUID PRIMARY KEY,
+    timezone VARCHAR(50) NOT NULL,
+    hours JSONB NOT NULL DEFAULT '[]',
+    lunch_closures JSONB NOT NULL DEFAULT '[]',
+    dock_count INTEGER NOT NULL DEFAULT 0,
+    required_ppe TEXT[] NOT NULL DEFAULT '{}',
+    check_in_procedure TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,6 @@
+-- 000021_emergency_incidents.down.sql
+
+DROP TABLE IF EXISTS emergency_locations;
+DROP TABLE IF EXISTS emergency_notifications;
+DROP TABLE IF EXISTS emergency_incidents;
+DROP TABLE IF EXISTS emergency_contacts;
@@ -0,0 +1,70 @@
+-- 000021_emergency_incidents.up.sql
+-- Driver panic incidents, the staff alerted for them and the locations
+-- streamed by the driver app until each incident is closed
+
+CREATE TABLE emergency_contacts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(200) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    notify_sms BOOLEAN NOT NULL DEFAULT TRUE,
+    notify_call BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE emergency_incidents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL,
+    driver_name VARCHAR(200) NOT NULL,
+    driver_phone VARCHAR(20),
+    trip_id UUID REFERENCES trips(id),
+    trip_number VARCHAR(20),
+    status VARCHAR(20) NOT NULL,
+    latitude DECIMAL(10,8) NOT NULL,
+    longitude DECIMAL(11,8) NOT NULL,
+    notes TEXT,
+    last_latitude DECIMAL(10,8) NOT NULL,
+    last_longitude DECIMAL(11,8) NOT NULL,
+    last_location_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    acknowledged_by VARCHAR(100),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    closed_by VARCHAR(100),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    resolution TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One active incident per driver; repeated presses reuse it
+CREATE UNIQUE INDEX idx_emergency_incidents_active_driver
+    ON emergency_incidents(driver_id) WHERE status IN ('OPEN', 'ACKNOWLEDGED');
+
+CREATE TABLE emergency_notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    incident_id UUID NOT NULL REFERENCES emergency_incidents(id) ON DELETE CASCADE,
+    contact_id UUID NOT NULL REFERENCES emergency_contacts(id),
+    contact_name VARCHAR(200) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    provider_message_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_emergency_notifications_incident ON emergency_notifications(incident_id);
+
+CREATE TABLE emergency_locations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    incident_id UUID NOT NULL REFERENCES emergency_incidents(id) ON DELETE CASCADE,
+    latitude DECIMAL(10,8) NOT NULL,
+    longitude DECIMAL(11,8) NOT NULL,
+    accuracy_m DECIMAL(8,1),
+    speed_mph DECIMAL(5,1),
+    heading DECIMAL(5,1),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_emergency_locations_incident ON emergency_locations(incident_id, recorded_at);
@@ -0,0 +1,7 @@
+-- 000022_trip_tags.down.sql
+
+DROP INDEX IF EXISTS idx_trips_tags;
+
+ALTER TABLE trips DROP COLUMN IF EXISTS tags;
+
+DROP TABLE IF EXISTS saved_trip_filters;
@@ -0,0 +1,4 @@
+-- 000023_gate_appointments.down.sql
+
+DROP TABLE IF EXISTS gate_appointments;
+DROP TABLE IF EXISTS gate_slots;
@@ -0,0 +1,43 @@
+-- 000023_gate_appointments.up.sql
+-- Terminal gate slot inventory and the appointments trip stops book in it
+
+CREATE TABLE gate_slots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    terminal_id UUID NOT NULL,
+    move_type VARCHAR(20) NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    booked INTEGER NOT NULL DEFAULT 0 CHECK (booked >= 0 AND booked <= capacity),
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE UNIQUE INDEX idx_gate_slots_terminal ON gate_slots(terminal_id, move_type, start_time);
+CREATE INDEX idx_gate_slots_terminal_end ON gate_slots(terminal_id, end_time);
+
+CREATE TABLE gate_appointments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slot_id UUID NOT NULL REFERENCES gate_slots(id),
+    terminal_id UUID NOT NULL,
+    move_type VARCHAR(20) NOT NULL,
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    stop_id UUID NOT NULL REFERENCES trip_stops(id),
+    container_number VARCHAR(15),
+    appointment_number VARCHAR(50) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'BOOKED',
+    rescheduled_to_id UUID REFERENCES gate_appointments(id),
+    cancel_reason TEXT,
+    booked_by VARCHAR(100) NOT NULL,
+    booked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- One booked appointment per stop
+CREATE UNIQUE INDEX idx_gate_appointments_stop ON gate_appointments(stop_id) WHERE status = 'BOOKED';
+CREATE INDEX idx_gate_appointments_trip ON gate_appointments(trip_id) WHERE status = 'BOOKED';
+CREATE INDEX idx_gate_appointments_slot ON gate_appointments(slot_id);
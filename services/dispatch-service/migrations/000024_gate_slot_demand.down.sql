@@ -0,0 +1,7 @@
+-- 000024_gate_slot_demand.down.sql
+
+DROP INDEX IF EXISTS idx_gate_slots_terminal_start;
+
+ALTER TABLE gate_slots DROP COLUMN IF EXISTS sold_out_at;
+
+DROP TABLE IF EXISTS driver_hour_ledger;
@@ -0,0 +1,23 @@
+-- 000024_gate_slot_demand.up.sql
+-- When each gate slot first sold out, for demand forecasting, and the
+-- driver-hour ledger that keeps a driver booked at one terminal per hour
+
+ALTER TABLE gate_slots ADD COLUMN sold_out_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_gate_slots_terminal_start ON gate_slots(terminal_id, start_time);
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE driver_hour_ledger (
+    driver_id UUID NOT NULL,
+    hour_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    terminal_id UUID NOT NULL,
+    appointment_id UUID NOT NULL REFERENCES gate_appointments(id),
+    trip_id UUID NOT NULL REFERENCES trips(id),
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (driver_id, hour_start, appointment_id),
+    -- A driver's hour can only be held at one terminal
+    EXCLUDE USING gist (driver_id WITH =, hour_start WITH =, terminal_id WITH <>)
+);
+
+CREATE INDEX idx_driver_hour_ledger_appointment ON driver_hour_ledger(appointment_id);
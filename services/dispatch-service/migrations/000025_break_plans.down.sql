@@ -0,0 +1,5 @@
+-- 000025_break_plans.down.sql
+
+DROP INDEX IF EXISTS idx_locations_rest_stops;
+
+DROP TABLE IF EXISTS trip_break_plans;
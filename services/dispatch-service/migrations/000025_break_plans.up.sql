@@ -0,0 +1,29 @@
+-- 000025_break_plans.up.sql
+-- Where a driver's 30-minute break is predicted to fall due on a trip, the
+-- truck stops and rest areas suggested for it, and where it was taken
+
+CREATE TABLE trip_break_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL UNIQUE REFERENCES trips(id) ON DELETE CASCADE,
+    driver_id UUID NOT NULL,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    after_stop_sequence INTEGER NOT NULL,
+    latitude DECIMAL(10, 7) NOT NULL,
+    longitude DECIMAL(10, 7) NOT NULL,
+    driven_mins INTEGER NOT NULL DEFAULT 0,
+    suggestions JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'PLANNED',
+    taken_location_id UUID,
+    taken_at TIMESTAMP WITH TIME ZONE,
+    taken_mins INTEGER NOT NULL DEFAULT 0,
+    taken_latitude DECIMAL(10, 7),
+    taken_longitude DECIMAL(10, 7),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trip_break_plans_driver ON trip_break_plans(driver_id, due_at);
+
+-- Truck stops and rest areas are searched by position
+CREATE INDEX IF NOT EXISTS idx_locations_rest_stops ON locations(type, latitude, longitude)
+    WHERE type IN ('TRUCK_STOP', 'REST_AREA');
@@ -0,0 +1,7 @@
+-- 000026_chassis.down.sql
+
+DROP INDEX IF EXISTS idx_chassis_usage_period;
+
+DROP TABLE IF EXISTS trip_chassis_splits;
+DROP TABLE IF EXISTS chassis;
+DROP TABLE IF EXISTS chassis_pools;
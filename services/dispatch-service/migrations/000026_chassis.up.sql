@@ -0,0 +1,61 @@
+-- 000026_chassis.up.sql
+-- Chassis register with pool and IEP providers, and the chassis splits
+-- flagged on trips where the chassis and container parted ways
+
+CREATE TABLE chassis_pools (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(200) NOT NULL,
+    provider_name VARCHAR(200),
+    daily_rate_20 DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    daily_rate_40 DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    daily_rate_45 DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    split_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE chassis (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    chassis_number VARCHAR(50) NOT NULL UNIQUE,
+    size VARCHAR(5) NOT NULL,
+    type VARCHAR(20) NOT NULL DEFAULT 'standard',
+    tare_weight INTEGER NOT NULL DEFAULT 0,
+    num_axles INTEGER NOT NULL DEFAULT 2,
+    ownership VARCHAR(20) NOT NULL,
+    pool_id UUID REFERENCES chassis_pools(id),
+    iep_code VARCHAR(10),
+    status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE',
+    current_location_id UUID,
+    current_trip_id UUID REFERENCES trips(id) ON DELETE SET NULL,
+    last_moved_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_chassis_status ON chassis(status);
+CREATE INDEX idx_chassis_pool ON chassis(pool_id) WHERE pool_id IS NOT NULL;
+CREATE INDEX idx_chassis_location ON chassis(current_location_id) WHERE current_location_id IS NOT NULL;
+
+CREATE TABLE trip_chassis_splits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL UNIQUE REFERENCES trips(id) ON DELETE CASCADE,
+    chassis_id UUID REFERENCES chassis(id),
+    status VARCHAR(10) NOT NULL DEFAULT 'NONE',
+    chassis_pickup_location_id UUID,
+    container_pickup_location_id UUID,
+    chassis_return_location_id UUID,
+    container_drop_location_id UUID,
+    split_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    flagged_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Per-diem adds up the splits flagged on a chassis over a period
+CREATE INDEX idx_trip_chassis_splits_chassis ON trip_chassis_splits(chassis_id, created_at)
+    WHERE status <> 'NONE';
+
+-- Per-diem reads a chassis's usage over a period
+CREATE INDEX idx_chassis_usage_period ON chassis_usage(chassis_id, pickup_time);
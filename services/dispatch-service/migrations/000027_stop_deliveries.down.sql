@@ -0,0 +1,3 @@
+-- 000027_stop_deliveries.down.sql
+
+DROP TABLE IF EXISTS stop_deliveries;
@@ -0,0 +1,31 @@
+-- 000027_stop_deliveries.up.sql
+-- Parts of a container delivered at each stop when a shipper splits it
+-- across delivery points, each with its own proof of delivery
+
+CREATE TABLE stop_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    stop_id UUID NOT NULL REFERENCES trip_stops(id) ON DELETE CASCADE,
+    order_id UUID,
+    container_id UUID,
+    container_number VARCHAR(20),
+    commodity VARCHAR(200) NOT NULL,
+    pieces INTEGER NOT NULL DEFAULT 0,
+    piece_unit VARCHAR(30),
+    weight_lbs INTEGER NOT NULL DEFAULT 0,
+    consignee_name VARCHAR(200),
+    reference_number VARCHAR(100),
+    pieces_delivered INTEGER,
+    pod_document_ids TEXT[] NOT NULL DEFAULT '{}',
+    pod_signed_by VARCHAR(200),
+    pod_received_at TIMESTAMP WITH TIME ZONE,
+    pod_notes TEXT,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_stop_deliveries_quantity CHECK (pieces >= 0 AND weight_lbs >= 0)
+);
+
+CREATE INDEX idx_stop_deliveries_trip ON stop_deliveries(trip_id);
+CREATE INDEX idx_stop_deliveries_stop ON stop_deliveries(stop_id);
+CREATE INDEX idx_stop_deliveries_container ON stop_deliveries(container_id) WHERE container_id IS NOT NULL;
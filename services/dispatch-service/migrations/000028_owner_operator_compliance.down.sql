@@ -0,0 +1,5 @@
+-- 000028_owner_operator_compliance.down.sql
+
+DROP TABLE IF EXISTS owner_operator_insurance_alerts;
+DROP TABLE IF EXISTS owner_operator_insurance;
+DROP TABLE IF EXISTS owner_operator_carriers;
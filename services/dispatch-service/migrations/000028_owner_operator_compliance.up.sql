@@ -0,0 +1,52 @@
+-- 000028_owner_operator_compliance.up.sql
+-- Owner-operators' operating authority and certificates of insurance, with
+-- the hold that keeps them off dispatch while either is out of order
+
+CREATE TABLE owner_operator_carriers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    driver_id UUID NOT NULL UNIQUE,
+    legal_name VARCHAR(200) NOT NULL,
+    authority_type VARCHAR(20) NOT NULL,
+    dot_number VARCHAR(20),
+    mc_number VARCHAR(20),
+    authority_status VARCHAR(20) NOT NULL DEFAULT 'UNVERIFIED',
+    verification_provider VARCHAR(50) NOT NULL DEFAULT 'MANUAL',
+    external_id VARCHAR(100),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    hold TEXT,
+    covered_until TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_owner_operator_carriers_dot ON owner_operator_carriers(dot_number) WHERE dot_number IS NOT NULL;
+
+CREATE TABLE owner_operator_insurance (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    carrier_id UUID NOT NULL REFERENCES owner_operator_carriers(id) ON DELETE CASCADE,
+    coverage VARCHAR(30) NOT NULL,
+    insurer_name VARCHAR(200),
+    policy_number VARCHAR(100) NOT NULL,
+    coverage_amount DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    effective_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    expiration_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    source VARCHAR(50) NOT NULL DEFAULT 'MANUAL',
+    attachment_id UUID REFERENCES attachments(id),
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancel_reason TEXT,
+    added_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_owner_operator_insurance_carrier ON owner_operator_insurance(carrier_id, coverage);
+
+-- One alert per certificate at each lead time
+CREATE TABLE owner_operator_insurance_alerts (
+    certificate_id UUID NOT NULL REFERENCES owner_operator_insurance(id) ON DELETE CASCADE,
+    days_out INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (certificate_id, days_out)
+);
@@ -0,0 +1,3 @@
+-- 000029_location_proposals.down.sql
+
+DROP TABLE IF EXISTS location_proposals;
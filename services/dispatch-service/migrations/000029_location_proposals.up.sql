@@ -0,0 +1,28 @@
+-- 000029_location_proposals.up.sql
+-- Location coordinate and geofence corrections learned from where drivers
+-- reported arriving at and completing stops, held for admin review
+
+CREATE TABLE location_proposals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    location_id UUID NOT NULL,
+    location_name VARCHAR(200) NOT NULL,
+    current_latitude DECIMAL(10, 8) NOT NULL,
+    current_longitude DECIMAL(11, 8) NOT NULL,
+    current_radius_meters DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    geofence_id UUID,
+    proposed_latitude DECIMAL(10, 8) NOT NULL,
+    proposed_longitude DECIMAL(11, 8) NOT NULL,
+    proposed_radius_meters DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    shift_meters DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    fix_count INTEGER NOT NULL,
+    cluster_share DECIMAL(4, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    reviewed_by VARCHAR(100),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- At most one proposal per location awaits review; a new run supersedes it
+CREATE UNIQUE INDEX idx_location_proposals_pending ON location_proposals(location_id) WHERE status = 'PENDING';
+CREATE INDEX idx_location_proposals_status ON location_proposals(status, shift_meters DESC);
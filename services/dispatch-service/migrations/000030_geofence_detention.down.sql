@@ -0,0 +1,5 @@
+-- 000030_geofence_detention.down.sql
+
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS detention_threshold_pct;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS geofence_exited_at;
+ALTER TABLE trip_stops DROP COLUMN IF EXISTS arrival_source;
@@ -0,0 +1,7 @@
+-- 000030_geofence_detention.up.sql
+-- Stop arrivals and departures from tracking's geofence events, and the free
+-- time thresholds dispatch has been alerted to
+
+ALTER TABLE trip_stops ADD COLUMN IF NOT EXISTS arrival_source VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE trip_stops ADD COLUMN IF NOT EXISTS geofence_exited_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE trip_stops ADD COLUMN IF NOT EXISTS detention_threshold_pct INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- 000031_hos_snapshots.down.sql
+
+DROP TABLE IF EXISTS trip_hos_snapshots;
@@ -0,0 +1,23 @@
+-- 000031_hos_snapshots.up.sql
+-- The driver's HOS clocks as dispatch saw them when a trip was assigned or
+-- dispatched, kept unchanged as evidence if a violation is disputed
+
+CREATE TABLE trip_hos_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    driver_id UUID NOT NULL,
+    event VARCHAR(20) NOT NULL,
+    drive_remaining_mins INTEGER NOT NULL,
+    duty_remaining_mins INTEGER NOT NULL,
+    cycle_remaining_mins INTEGER NOT NULL,
+    clocks_as_of TIMESTAMP WITH TIME ZONE,
+    needs_break BOOLEAN NOT NULL DEFAULT FALSE,
+    mins_until_break INTEGER NOT NULL DEFAULT 0,
+    trip_duration_mins INTEGER NOT NULL DEFAULT 0,
+    planned_start_time TIMESTAMP WITH TIME ZONE,
+    taken_by VARCHAR(100),
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trip_hos_snapshots_trip ON trip_hos_snapshots(trip_id, taken_at);
+CREATE INDEX idx_trip_hos_snapshots_driver ON trip_hos_snapshots(driver_id, taken_at);
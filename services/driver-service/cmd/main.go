@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
@@ -17,11 +21,18 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/draymaster/services/driver-service/internal/domain"
 	"github.com/draymaster/services/driver-service/internal/repository"
 	"github.com/draymaster/services/driver-service/internal/service"
+	"github.com/draymaster/shared/pkg/auth"
 	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/crypto"
+	"github.com/draymaster/shared/pkg/document"
+	"github.com/draymaster/shared/pkg/email"
 	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
 	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
 )
 
 func main() {
@@ -60,8 +71,22 @@ func main() {
 
 	log.Info("Connected to Kafka")
 
-	// Initialize repositories
-	driverRepo := repository.NewPostgresDriverRepository(db)
+	// Initialize repositories. Driver PII is envelope-encrypted when master
+	// keys are configured: a Vault transit key (DRIVER_PII_KMS=vault) or a
+	// local key ring ("id:base64key,..." with the current key first).
+	// DRIVER_PII_INDEX_KEY keys the blind index that keeps encrypted phone
+	// numbers searchable.
+	var driverRepo *repository.PostgresDriverRepository
+	if keys := driverPIIKeys(log); keys != nil {
+		phoneIndex, err := crypto.NewBlindIndexFromBase64(os.Getenv("DRIVER_PII_INDEX_KEY"))
+		if err != nil {
+			log.Fatalw("Invalid DRIVER_PII_INDEX_KEY", "error", err)
+		}
+		driverRepo = repository.NewEncryptedDriverRepository(db, crypto.NewFieldEncryptor(keys), phoneIndex)
+	} else {
+		log.Warn("DRIVER_PII_KEYS not set, driver PII will be stored unencrypted")
+		driverRepo = repository.NewPostgresDriverRepository(db)
+	}
 	hosLogRepo := repository.NewPostgresHOSLogRepository(db)
 	violationRepo := repository.NewPostgresViolationRepository(db)
 	alertRepo := repository.NewPostgresAlertRepository(db)
@@ -74,13 +99,83 @@ func main() {
 		violationRepo,
 		alertRepo,
 		documentRepo,
+		repository.NewPostgresPriorDutyRepository(db),
+		eventProducer,
+		log,
+	)
+
+	// Daily logs use the built-in HOS template and default branding until
+	// tenant templates are published
+	documents := document.NewGenerator(nil, nil, document.NewChromiumRenderer(cfg.Documents), log)
+	hosDocuments := service.NewHOSDocumentService(driverService, documents, log)
+
+	// Countdown warnings before the driving limit or duty window closes. They
+	// are logged and published even when no push gateway is configured.
+	var push service.PushSender
+	if url := os.Getenv("PUSH_GATEWAY_URL"); url != "" {
+		push = service.NewHTTPPushSender(url, os.Getenv("PUSH_GATEWAY_API_KEY"))
+	} else {
+		log.Warn("PUSH_GATEWAY_URL not set, HOS warnings will not reach driver devices")
+	}
+	hosWarnings := service.NewHOSWarningService(driverService, repository.NewPostgresHOSWarningRepository(db), push, eventProducer, log)
+
+	// Team and slip-seat tractors: ELD driving goes to the driver logged on
+	// at the wheel, and driving no session accounts for is held for review
+	tractorSessions := service.NewTractorSessionService(
+		driverService,
+		repository.NewPostgresTractorSessionRepository(db),
+		repository.NewPostgresDrivingSegmentRepository(db),
 		eventProducer,
 		log,
 	)
 
+	// Daily log certifications and the safety department's HOS audit, sent
+	// weekly to HOS_AUDIT_RECIPIENTS (comma-separated)
+	var auditRecipients []string
+	for _, addr := range strings.Split(os.Getenv("HOS_AUDIT_RECIPIENTS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			auditRecipients = append(auditRecipients, addr)
+		}
+	}
+	if len(auditRecipients) == 0 {
+		log.Warn("HOS_AUDIT_RECIPIENTS not set, the weekly HOS audit report will only be logged")
+	}
+	hosAudit := service.NewHOSAuditService(
+		driverService,
+		repository.NewPostgresHOSCertificationRepository(db),
+		repository.NewPostgresDrivingSegmentRepository(db),
+		repository.NewPostgresLocationHistoryRepository(db),
+		email.NewSMTPSender(cfg.SMTP),
+		auditRecipients,
+		log,
+	)
+
+	// Duty status changes from the ELD providers, matched to drivers by the
+	// eld-integration service
+	eldConsumer := kafka.NewConsumer(cfg.KafkaBrokers, "driver-service-eld", kafka.Topics.ELDDutyStatusRecorded, log)
+	defer eldConsumer.Close()
+	eldCtx, stopELD := context.WithCancel(context.Background())
+	defer stopELD()
+	go func() {
+		if err := eldConsumer.Consume(eldCtx, driverService.HandleELDDutyStatus); err != nil && eldCtx.Err() == nil {
+			log.Errorw("ELD duty status consumer stopped", "error", err)
+		}
+	}()
+
+	// Driver documents are kept in the configured blob store
+	fileStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalw("Failed to initialize file storage", "error", err, "driver", cfg.Storage.Driver)
+	}
+	driverDocuments := service.NewDocumentService(driverService, documentRepo, fileStore, log)
+
 	// Create gRPC server
+	authn, err := auth.NewAuthenticator(cfg.Auth, auth.DefaultPolicy(), log)
+	if err != nil {
+		log.Fatalw("Invalid auth configuration", "error", err)
+	}
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(loggingInterceptor(log)),
+		grpc.ChainUnaryInterceptor(loggingInterceptor(log), authn.UnaryInterceptor()),
 	)
 
 	// Register gRPC services
@@ -110,7 +205,7 @@ func main() {
 	// Start HTTP health/metrics server
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
-		Handler:      httpHandler(driverService, log),
+		Handler:      httpHandler(driverService, hosDocuments, hosAudit, tractorSessions, driverDocuments, log),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
@@ -122,8 +217,22 @@ func main() {
 		}
 	}()
 
-	// Start background compliance checker
-	go startComplianceChecker(driverService, log)
+	// Start background jobs; each runs on one replica at a time and the others
+	// stand by to take over
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	elector := leader.NewElector(leader.NewSQLLocker(db.DB), leader.Config{}, log)
+	go elector.Run(jobCtx, "driver.compliance", func(ctx context.Context) {
+		startComplianceChecker(ctx, driverService, log)
+	})
+	go elector.Run(jobCtx, "driver.hos-warnings", func(ctx context.Context) {
+		hosWarnings.Start(ctx, time.Minute)
+	})
+	go elector.Run(jobCtx, "driver.hos-audit", hosAudit.Start)
+	// Re-encrypts PII left under a retired key; a no-op without a key ring
+	go elector.Run(jobCtx, "driver.pii-rotation", func(ctx context.Context) {
+		rotateDriverPII(ctx, driverRepo, log)
+	})
 
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
@@ -144,7 +253,13 @@ func main() {
 	log.Info("Driver-service stopped")
 }
 
-func httpHandler(svc *service.DriverService, log *logger.Logger) http.Handler {
+// maxDocumentUploadBytes caps a single driver document upload
+const maxDocumentUploadBytes = 25 << 20
+
+// maxELDFileBytes caps a prior-carrier ELD output file upload
+const maxELDFileBytes = 5 << 20
+
+func httpHandler(svc *service.DriverService, hosDocuments *service.HOSDocumentService, hosAudit *service.HOSAuditService, sessions *service.TractorSessionService, documents *service.DocumentService, log *logger.Logger) http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check endpoint
@@ -164,9 +279,402 @@ func httpHandler(svc *service.DriverService, log *logger.Logger) http.Handler {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// Mobile app: driver-initiated personal conveyance / yard move toggle
+	mux.HandleFunc("/v1/mobile/hos/special-status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			DriverID    uuid.UUID  `json:"driver_id"`
+			Status      string     `json:"status"`
+			Enable      bool       `json:"enable"`
+			Latitude    float64    `json:"latitude"`
+			Longitude   float64    `json:"longitude"`
+			Location    string     `json:"location"`
+			Odometer    int        `json:"odometer"`
+			EngineHours float64    `json:"engine_hours"`
+			TractorID   *uuid.UUID `json:"tractor_id"`
+			Notes       string     `json:"notes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid request body"}`))
+			return
+		}
+
+		hosLog, err := svc.SetSpecialDrivingStatus(r.Context(), service.SpecialStatusInput{
+			DriverID:    req.DriverID,
+			Status:      domain.HOSStatus(req.Status),
+			Enable:      req.Enable,
+			Latitude:    req.Latitude,
+			Longitude:   req.Longitude,
+			Location:    req.Location,
+			Odometer:    req.Odometer,
+			EngineHours: req.EngineHours,
+			TractorID:   req.TractorID,
+			Notes:       req.Notes,
+		})
+		if err != nil {
+			log.Warnw("Special status toggle rejected", "driver_id", req.DriverID, "error", err)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(hosLog)
+	})
+
+	// Printable record of duty status: GET /v1/hos/daily-log?driver_id=&date=2006-01-02
+	mux.HandleFunc("/v1/hos/daily-log", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+		date, err := time.Parse("2006-01-02", r.URL.Query().Get("date"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"date must be YYYY-MM-DD"}`))
+			return
+		}
+
+		doc, err := hosDocuments.DailyLog(r.Context(), r.Header.Get("X-Tenant-ID"), driverID, date)
+		if err != nil {
+			log.Warnw("Daily log rendering failed", "driver_id", driverID, "error", err)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", doc.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.Filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(doc.Data)
+	})
+
+	// Driver certifies a day's record of duty status:
+	// POST /v1/hos/certify?driver_id=&date=2006-01-02
+	mux.HandleFunc("/v1/hos/certify", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+		date, err := time.Parse("2006-01-02", r.URL.Query().Get("date"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"date must be YYYY-MM-DD"}`))
+			return
+		}
+		cert, err := hosAudit.CertifyDailyLog(r.Context(), driverID, date, r.URL.Query().Get("source"))
+		writeResult(w, log, "Daily log certification rejected", cert, err)
+	})
+
+	// Safety department HOS audit, both dates inclusive:
+	// GET /v1/hos/audit-report?start=2006-01-02&end=2006-01-02&driver_id=&format=csv
+	mux.HandleFunc("/v1/hos/audit-report", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		start, startErr := time.Parse("2006-01-02", query.Get("start"))
+		end, endErr := time.Parse("2006-01-02", query.Get("end"))
+		if startErr != nil || endErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"start and end must be YYYY-MM-DD"}`))
+			return
+		}
+		var driverID *uuid.UUID
+		if raw := query.Get("driver_id"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid driver_id"}`))
+				return
+			}
+			driverID = &id
+		}
+
+		report, err := hosAudit.BuildAuditReport(r.Context(), start, end.AddDate(0, 0, 1), driverID)
+		if err != nil || query.Get("format") != "csv" {
+			writeResult(w, log, "HOS audit report failed", report, err)
+			return
+		}
+		data, err := service.HOSAuditCSV(report)
+		if err != nil {
+			writeResult(w, log, "HOS audit export failed", nil, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "hos-audit-"+query.Get("start")+".csv"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	})
+
+	// Mobile app and ELD: log a driver on to or off a shared tractor
+	mux.HandleFunc("/v1/tractors/sessions/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input service.TractorLoginInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid request body"}`))
+			return
+		}
+		session, err := sessions.Login(r.Context(), input)
+		writeResult(w, log, "Tractor login rejected", session, err)
+	})
+
+	mux.HandleFunc("/v1/tractors/sessions/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input service.TractorLogoutInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid request body"}`))
+			return
+		}
+		err := sessions.Logout(r.Context(), input)
+		writeResult(w, log, "Tractor logout rejected", map[string]string{"status": "logged_out"}, err)
+	})
+
+	// Drivers logged on to a tractor: GET /v1/tractors/sessions?tractor_id=
+	mux.HandleFunc("/v1/tractors/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		tractorID, err := uuid.Parse(r.URL.Query().Get("tractor_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid tractor_id"}`))
+			return
+		}
+		active, err := sessions.GetTractorSessions(r.Context(), tractorID)
+		writeResult(w, log, "Tractor session lookup failed", active, err)
+	})
+
+	// ELD feed: driving segments reported per tractor
+	mux.HandleFunc("/v1/eld/driving-segments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input service.DrivingSegmentInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid request body"}`))
+			return
+		}
+		segment, err := sessions.RecordDrivingSegment(r.Context(), input)
+		writeResult(w, log, "ELD driving segment rejected", segment, err)
+	})
+
+	// Manual HOS entries checked against the ELD:
+	// GET /v1/hos/eld-reconciliation?driver_id=&start=&end= (RFC 3339)
+	mux.HandleFunc("/v1/hos/eld-reconciliation", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+		start, startErr := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		end, endErr := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		if startErr != nil || endErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"start and end must be RFC 3339 times"}`))
+			return
+		}
+		reconciliation, err := svc.ReconcileELDLogs(r.Context(), driverID, start, end)
+		writeResult(w, log, "ELD reconciliation failed", reconciliation, err)
+	})
+
+	// Dispatcher review of driving held for review:
+	// GET /v1/hos/segments/review, POST /v1/hos/segments/review?segment_id=
+	mux.HandleFunc("/v1/hos/segments/review", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			segments, err := sessions.GetSegmentsForReview(r.Context())
+			writeResult(w, log, "Driving segment review lookup failed", segments, err)
+		case http.MethodPost:
+			segmentID, err := uuid.Parse(r.URL.Query().Get("segment_id"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid segment_id"}`))
+				return
+			}
+			var input service.ResolveSegmentInput
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid request body"}`))
+				return
+			}
+			input.ResolvedBy = r.Header.Get("X-User-ID")
+			segment, err := sessions.ResolveSegment(r.Context(), segmentID, input)
+			writeResult(w, log, "Driving segment resolution rejected", segment, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// New hires' duty hours from their previous carrier, counted toward the
+	// 70-hour cycle for their first 8 days: GET /v1/drivers/hos/prior-duty?driver_id=,
+	// POST /v1/drivers/hos/prior-duty with the days keyed by hand
+	mux.HandleFunc("/v1/drivers/hos/prior-duty", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid driver_id"}`))
+				return
+			}
+			days, err := svc.GetPriorDuty(r.Context(), driverID)
+			writeResult(w, log, "Prior duty lookup failed", days, err)
+		case http.MethodPost:
+			var input service.PriorDutyInput
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid request body"}`))
+				return
+			}
+			input.ImportedBy = r.Header.Get("X-User-ID")
+			days, err := svc.RecordPriorDuty(r.Context(), input)
+			writeResult(w, log, "Prior duty entry rejected", days, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Prior-carrier ELD output file as the raw body:
+	// POST /v1/drivers/hos/prior-duty/eld-file?driver_id=
+	mux.HandleFunc("/v1/drivers/hos/prior-duty/eld-file", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+		body := http.MaxBytesReader(w, r.Body, maxELDFileBytes)
+		days, err := svc.ImportELDTransferFile(r.Context(), driverID, r.Header.Get("X-User-ID"), body)
+		writeResult(w, log, "ELD file import rejected", days, err)
+	})
+
+	// Driver documents: GET lists a driver's documents; POST streams the raw
+	// file body, e.g. POST /v1/drivers/documents?driver_id=&type=license&file_name=cdl.jpg
+	mux.HandleFunc("/v1/drivers/documents", func(w http.ResponseWriter, r *http.Request) {
+		driverID, err := uuid.Parse(r.URL.Query().Get("driver_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			docs, err := documents.ListDocuments(r.Context(), driverID)
+			writeResult(w, log, "Driver document lookup failed", docs, err)
+		case http.MethodPost:
+			input := service.UploadDocumentInput{
+				DriverID:   driverID,
+				Type:       r.URL.Query().Get("type"),
+				FileName:   r.URL.Query().Get("file_name"),
+				MimeType:   r.Header.Get("Content-Type"),
+				Size:       r.ContentLength,
+				UploadedBy: r.Header.Get("X-User-ID"),
+			}
+			if v := r.URL.Query().Get("expires_at"); v != "" {
+				expires, err := time.Parse("2006-01-02", v)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"expires_at must be YYYY-MM-DD"}`))
+					return
+				}
+				input.ExpiresAt = &expires
+			}
+			body := http.MaxBytesReader(w, r.Body, maxDocumentUploadBytes)
+			doc, err := documents.UploadDocument(r.Context(), input, body)
+			writeResult(w, log, "Driver document upload rejected", doc, err)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Driver document download: GET /v1/drivers/documents/file?id=
+	mux.HandleFunc("/v1/drivers/documents/file", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid id"}`))
+			return
+		}
+		doc, body, err := documents.OpenDocument(r.Context(), id)
+		if err != nil {
+			writeResult(w, log, "Driver document download failed", nil, err)
+			return
+		}
+		defer body.Close()
+
+		if doc.MimeType != "" {
+			w.Header().Set("Content-Type", doc.MimeType)
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.FileName))
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, body); err != nil {
+			log.Warnw("Driver document download interrupted", "document_id", id, "error", err)
+		}
+	})
+
 	return mux
 }
 
+// writeResult writes v as JSON, or err as 422 Unprocessable Entity
+func writeResult(w http.ResponseWriter, log *logger.Logger, failure string, v interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		log.Warnw(failure, "error", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
 func loggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
@@ -185,15 +693,66 @@ func loggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 }
 
 // startComplianceChecker runs periodic compliance checks
-func startComplianceChecker(svc *service.DriverService, log *logger.Logger) {
+func startComplianceChecker(ctx context.Context, svc *service.DriverService, log *logger.Logger) {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
 	log.Info("Started compliance checker")
 
-	for range ticker.C {
-		log.Info("Running scheduled compliance check")
-		// Would iterate through all active drivers and check compliance
-		// This would create alerts for expiring documents
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Info("Running scheduled compliance check")
+			// Would iterate through all active drivers and check compliance
+			// This would create alerts for expiring documents
+		}
+	}
+}
+
+// driverPIIKeys returns the master key provider for driver PII, or nil when
+// none is configured
+func driverPIIKeys(log *logger.Logger) crypto.KeyProvider {
+	if os.Getenv("DRIVER_PII_KMS") == "vault" {
+		keyID := os.Getenv("DRIVER_PII_KMS_KEY")
+		if keyID == "" || os.Getenv("VAULT_ADDR") == "" {
+			log.Fatal("DRIVER_PII_KMS=vault requires VAULT_ADDR and DRIVER_PII_KMS_KEY")
+		}
+		vault := crypto.NewVaultTransit(crypto.VaultConfig{
+			Address: os.Getenv("VAULT_ADDR"),
+			Token:   os.Getenv("VAULT_TOKEN"),
+			Mount:   os.Getenv("VAULT_TRANSIT_MOUNT"),
+		})
+		return crypto.NewKMSKeyProvider(vault, keyID)
+	}
+	if keyRing := os.Getenv("DRIVER_PII_KEYS"); keyRing != "" {
+		keys, err := crypto.ParseKeyRing(keyRing)
+		if err != nil {
+			log.Fatalw("Invalid DRIVER_PII_KEYS", "error", err)
+		}
+		return keys
+	}
+	return nil
+}
+
+// rotateDriverPII re-encrypts driver PII that is plaintext or sealed under a
+// retired key, a batch at a time, until every row uses the current key
+func rotateDriverPII(ctx context.Context, repo *repository.PostgresDriverRepository, log *logger.Logger) {
+	const batchSize = 200
+	total := 0
+	for {
+		n, err := repo.RotatePII(ctx, batchSize)
+		total += n
+		if err != nil {
+			log.Errorw("Driver PII rotation stopped", "rotated", total, "error", err)
+			return
+		}
+		if n < batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Infow("Driver PII rotated to current key", "drivers", total)
 	}
 }
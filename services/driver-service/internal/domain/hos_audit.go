@@ -0,0 +1,415 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HOS audit thresholds for falsification risk indicators
+const (
+	// HOSAuditMovingSpeedMph is the GPS speed above which the truck is taken
+	// to be moving rather than drifting in a parking lot
+	HOSAuditMovingSpeedMph = 10
+	// HOSAuditMovementMins is how long GPS must show movement during an off
+	// duty or sleeper berth entry before it is flagged
+	HOSAuditMovementMins = 5
+	// HOSAuditMovementGapMins is the longest gap between moving GPS points
+	// that still counts as one stretch of movement
+	HOSAuditMovementGapMins = 10
+	// HOSAuditOdometerMiles is how far the odometer may advance across an off
+	// duty or sleeper berth entry before it is flagged
+	HOSAuditOdometerMiles = 2
+)
+
+// HOSLogCertification is a driver's certification that their record of
+// duty status for one day is true and correct, 49 CFR 395.30
+type HOSLogCertification struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	DriverID    uuid.UUID `json:"driver_id" db:"driver_id"`
+	LogDate     time.Time `json:"log_date" db:"log_date"`
+	CertifiedAt time.Time `json:"certified_at" db:"certified_at"`
+	Source      string    `json:"source" db:"source"` // eld or manual
+}
+
+// LocationPoint is a GPS position recorded for a driver
+type LocationPoint struct {
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+	Latitude   float64   `json:"latitude" db:"latitude"`
+	Longitude  float64   `json:"longitude" db:"longitude"`
+	SpeedMph   float64   `json:"speed_mph" db:"speed_mph"`
+}
+
+// HOSCertificationGap is a logged day the driver has not certified
+type HOSCertificationGap struct {
+	Date        string     `json:"date"` // YYYY-MM-DD
+	CertifiedAt *time.Time `json:"certified_at,omitempty"`
+	// EditedAfter is set when the day was certified but entries were added
+	// or edited since, so it must be certified again
+	EditedAfter bool `json:"edited_after"`
+}
+
+// HOSLogEdit is an entry on a driver's log that was keyed or edited by hand
+type HOSLogEdit struct {
+	LogID         uuid.UUID  `json:"log_id"`
+	OriginalLogID *uuid.UUID `json:"original_log_id,omitempty"`
+	Status        HOSStatus  `json:"status"`
+	StartTime     time.Time  `json:"start_time"`
+	DurationMins  int        `json:"duration_mins"`
+	Source        string     `json:"source"`
+	EditReason    string     `json:"edit_reason,omitempty"`
+	RecordedAt    time.Time  `json:"recorded_at"`
+}
+
+// HOSRiskIndicator is a pattern on a driver's log that suggests it may not
+// match what the truck did
+type HOSRiskIndicator string
+
+const (
+	// GPS shows the truck moving while the log shows off duty or sleeper berth
+	HOSRiskMovementWhileOff HOSRiskIndicator = "MOVEMENT_WHILE_OFF_DUTY"
+	// The odometer advanced across an off duty or sleeper berth entry
+	HOSRiskOdometerWhileOff HOSRiskIndicator = "ODOMETER_WHILE_OFF_DUTY"
+	// The ELD recorded driving that manual entries show as something else
+	HOSRiskDrivingEditedAway HOSRiskIndicator = "DRIVING_EDITED_AWAY"
+)
+
+// HOSRiskFinding is one occurrence of a falsification risk indicator
+type HOSRiskFinding struct {
+	Indicator HOSRiskIndicator `json:"indicator"`
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+	Mins      int              `json:"mins"`
+	Status    HOSStatus        `json:"status"` // What the log shows
+	LogID     uuid.UUID        `json:"log_id"`
+	Detail    string           `json:"detail"`
+}
+
+// HOS audit risk levels
+const (
+	HOSRiskLow    = "LOW"
+	HOSRiskMedium = "MEDIUM"
+	HOSRiskHigh   = "HIGH"
+)
+
+// DriverHOSAudit is one driver's HOS record over an audit period
+type DriverHOSAudit struct {
+	DriverID       uuid.UUID `json:"driver_id"`
+	DriverName     string    `json:"driver_name"`
+	EmployeeNumber string    `json:"employee_number"`
+	LoggedDays     int       `json:"logged_days"`
+
+	Violations       []HOSViolation `json:"violations"`
+	ViolationsByType map[string]int `json:"violations_by_type"`
+
+	Edits         []HOSLogEdit `json:"edits"`
+	ManualEntries int          `json:"manual_entries"`
+
+	MissingCertifications []HOSCertificationGap `json:"missing_certifications"`
+
+	// Driving the ELD could not attribute that a dispatcher later assigned
+	// to this driver
+	UnidentifiedDrivingAssigned     int `json:"unidentified_driving_assigned"`
+	UnidentifiedDrivingAssignedMins int `json:"unidentified_driving_assigned_mins"`
+
+	RiskFindings []HOSRiskFinding `json:"risk_findings"`
+	RiskLevel    string           `json:"risk_level"`
+}
+
+// HasFindings reports whether anything in the period needs the safety
+// department's attention
+func (a *DriverHOSAudit) HasFindings() bool {
+	return len(a.Violations) > 0 || len(a.Edits) > 0 || len(a.MissingCertifications) > 0 ||
+		a.UnidentifiedDrivingAssigned > 0 || len(a.RiskFindings) > 0
+}
+
+// AssessRisk sets the driver's risk level: high for signs the log does not
+// match the truck's movement, medium for an odometer finding or for
+// violations alongside edits or uncertified days
+func (a *DriverHOSAudit) AssessRisk() {
+	a.RiskLevel = HOSRiskLow
+	for _, f := range a.RiskFindings {
+		if f.Indicator == HOSRiskMovementWhileOff || f.Indicator == HOSRiskDrivingEditedAway {
+			a.RiskLevel = HOSRiskHigh
+			return
+		}
+	}
+	if len(a.RiskFindings) > 0 || (len(a.Violations) > 0 && (len(a.Edits) > 0 || len(a.MissingCertifications) > 0)) {
+		a.RiskLevel = HOSRiskMedium
+	}
+}
+
+// UnidentifiedDriving is ELD driving still held for review at the end of an
+// audit period, attributed to no driver
+type UnidentifiedDriving struct {
+	SegmentID   uuid.UUID          `json:"segment_id"`
+	TractorID   uuid.UUID          `json:"tractor_id"`
+	Attribution SegmentAttribution `json:"attribution"`
+	StartTime   time.Time          `json:"start_time"`
+	Mins        int                `json:"mins"`
+	Location    string             `json:"location,omitempty"`
+	Note        string             `json:"note,omitempty"`
+}
+
+// HOSAuditTotals sums an audit report across drivers
+type HOSAuditTotals struct {
+	Drivers                 int `json:"drivers"`
+	DriversWithFindings     int `json:"drivers_with_findings"`
+	HighRiskDrivers         int `json:"high_risk_drivers"`
+	Violations              int `json:"violations"`
+	Edits                   int `json:"edits"`
+	MissingCertifications   int `json:"missing_certifications"`
+	RiskFindings            int `json:"risk_findings"`
+	UnidentifiedDriving     int `json:"unidentified_driving"`
+	UnidentifiedDrivingMins int `json:"unidentified_driving_mins"`
+}
+
+// HOSAuditReport is the safety department's HOS audit for a period, for
+// insurance reviews and DOT audit preparation
+type HOSAuditReport struct {
+	Start               time.Time             `json:"start"`
+	End                 time.Time             `json:"end"`
+	GeneratedAt         time.Time             `json:"generated_at"`
+	Totals              HOSAuditTotals        `json:"totals"`
+	Drivers             []DriverHOSAudit      `json:"drivers"`
+	UnidentifiedDriving []UnidentifiedDriving `json:"unidentified_driving"`
+}
+
+// Summarize sorts the drivers, highest risk first, and fills in the totals
+func (r *HOSAuditReport) Summarize() {
+	rank := map[string]int{HOSRiskHigh: 0, HOSRiskMedium: 1, HOSRiskLow: 2}
+	sort.SliceStable(r.Drivers, func(i, j int) bool {
+		a, b := r.Drivers[i], r.Drivers[j]
+		if rank[a.RiskLevel] != rank[b.RiskLevel] {
+			return rank[a.RiskLevel] < rank[b.RiskLevel]
+		}
+		return a.DriverName < b.DriverName
+	})
+
+	r.Totals = HOSAuditTotals{Drivers: len(r.Drivers), UnidentifiedDriving: len(r.UnidentifiedDriving)}
+	for _, d := range r.Drivers {
+		if d.HasFindings() {
+			r.Totals.DriversWithFindings++
+		}
+		if d.RiskLevel == HOSRiskHigh {
+			r.Totals.HighRiskDrivers++
+		}
+		r.Totals.Violations += len(d.Violations)
+		r.Totals.Edits += len(d.Edits)
+		r.Totals.MissingCertifications += len(d.MissingCertifications)
+		r.Totals.RiskFindings += len(d.RiskFindings)
+	}
+	for _, u := range r.UnidentifiedDriving {
+		r.Totals.UnidentifiedDrivingMins += u.Mins
+	}
+}
+
+// HOSLogEdits returns the manual entries and edits among logs that start
+// between start and end, oldest first
+func HOSLogEdits(logs []HOSLog, start, end time.Time) []HOSLogEdit {
+	edits := []HOSLogEdit{}
+	for _, l := range logs {
+		if l.StartTime.Before(start) || !l.StartTime.Before(end) {
+			continue
+		}
+		if l.Source != HOSSourceManual && l.OriginalLogID == nil && l.EditReason == "" {
+			continue
+		}
+		edits = append(edits, HOSLogEdit{
+			LogID:         l.ID,
+			OriginalLogID: l.OriginalLogID,
+			Status:        l.Status,
+			StartTime:     l.StartTime,
+			DurationMins:  l.DurationMins,
+			Source:        l.Source,
+			EditReason:    l.EditReason,
+			RecordedAt:    l.CreatedAt,
+		})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].StartTime.Before(edits[j].StartTime) })
+	return edits
+}
+
+// HOSLogDays returns the UTC days between start and end that logs cover,
+// in order
+func HOSLogDays(logs []HOSLog, start, end time.Time) []time.Time {
+	seen := map[time.Time]bool{}
+	for _, seg := range hosSegments(logs, end) {
+		from, to := laterTime(seg.start, start), earlierTime(seg.end, end)
+		for day := utcDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+			seen[day] = true
+		}
+	}
+	days := make([]time.Time, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// MissingCertifications returns the logged days that have not been
+// certified, or were edited after they were. Days ending after now are not
+// due yet.
+func MissingCertifications(logs []HOSLog, certs []HOSLogCertification, start, end, now time.Time) []HOSCertificationGap {
+	certified := map[time.Time]time.Time{}
+	for _, c := range certs {
+		certified[utcDay(c.LogDate)] = c.CertifiedAt
+	}
+	// The latest time an entry touching each day was recorded
+	recorded := map[time.Time]time.Time{}
+	for _, l := range logs {
+		for day := utcDay(l.StartTime); day.Before(hosLogEnd(l, end)); day = day.AddDate(0, 0, 1) {
+			if l.CreatedAt.After(recorded[day]) {
+				recorded[day] = l.CreatedAt
+			}
+		}
+	}
+
+	gaps := []HOSCertificationGap{}
+	for _, day := range HOSLogDays(logs, start, end) {
+		if day.AddDate(0, 0, 1).After(now) {
+			continue
+		}
+		at, ok := certified[day]
+		if !ok {
+			gaps = append(gaps, HOSCertificationGap{Date: day.Format("2006-01-02")})
+			continue
+		}
+		if recorded[day].After(at) {
+			certifiedAt := at
+			gaps = append(gaps, HOSCertificationGap{Date: day.Format("2006-01-02"), CertifiedAt: &certifiedAt, EditedAfter: true})
+		}
+	}
+	return gaps
+}
+
+// FindMovementWhileOff flags stretches where GPS shows the truck moving
+// while the driver's log shows off duty or sleeper berth. Personal
+// conveyance is off-duty driving by design and is not flagged.
+func FindMovementWhileOff(logs []HOSLog, points []LocationPoint, now time.Time) []HOSRiskFinding {
+	sorted := append([]LocationPoint(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RecordedAt.Before(sorted[j].RecordedAt) })
+
+	var findings []HOSRiskFinding
+	for _, l := range logs {
+		if l.Status != HOSStatusOffDuty && l.Status != HOSStatusSleeperBerth {
+			continue
+		}
+		start, end := l.StartTime, hosLogEnd(l, now)
+
+		var run []LocationPoint
+		flush := func() {
+			if len(run) == 0 {
+				return
+			}
+			first, last := run[0].RecordedAt, run[len(run)-1].RecordedAt
+			if mins := int(last.Sub(first).Minutes()); mins >= HOSAuditMovementMins {
+				top := 0.0
+				for _, p := range run {
+					top = max(top, p.SpeedMph)
+				}
+				findings = append(findings, HOSRiskFinding{
+					Indicator: HOSRiskMovementWhileOff,
+					Start:     first,
+					End:       last,
+					Mins:      mins,
+					Status:    l.Status,
+					LogID:     l.ID,
+					Detail:    fmt.Sprintf("GPS shows movement up to %.0f mph for %d min while %s", top, mins, l.Status),
+				})
+			}
+			run = nil
+		}
+
+		for _, p := range sorted {
+			if p.RecordedAt.Before(start) {
+				continue
+			}
+			if !p.RecordedAt.Before(end) {
+				break
+			}
+			if p.SpeedMph < HOSAuditMovingSpeedMph {
+				flush()
+				continue
+			}
+			if len(run) > 0 && p.RecordedAt.Sub(run[len(run)-1].RecordedAt) > HOSAuditMovementGapMins*time.Minute {
+				flush()
+			}
+			run = append(run, p)
+		}
+		flush()
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start.Before(findings[j].Start) })
+	return findings
+}
+
+// FindOdometerWhileOff flags off duty and sleeper berth entries the truck's
+// odometer advanced across, read from the odometer recorded with the entry
+// and with the entry that followed it
+func FindOdometerWhileOff(logs []HOSLog) []HOSRiskFinding {
+	sorted := append([]HOSLog(nil), logs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartTime.Before(sorted[j].StartTime) })
+
+	var findings []HOSRiskFinding
+	for i := 0; i+1 < len(sorted); i++ {
+		l, next := sorted[i], sorted[i+1]
+		if l.Status != HOSStatusOffDuty && l.Status != HOSStatusSleeperBerth {
+			continue
+		}
+		if l.Odometer <= 0 || next.Odometer <= 0 {
+			continue
+		}
+		if miles := next.Odometer - l.Odometer; miles > HOSAuditOdometerMiles {
+			findings = append(findings, HOSRiskFinding{
+				Indicator: HOSRiskOdometerWhileOff,
+				Start:     l.StartTime,
+				End:       next.StartTime,
+				Mins:      int(next.StartTime.Sub(l.StartTime).Minutes()),
+				Status:    l.Status,
+				LogID:     l.ID,
+				Detail:    fmt.Sprintf("Odometer advanced %d miles while %s", miles, l.Status),
+			})
+		}
+	}
+	return findings
+}
+
+// DrivingEditedAway turns the unlogged driving found by reconciling a
+// driver's manual entries with their ELD into risk findings
+func DrivingEditedAway(r *HOSReconciliation) []HOSRiskFinding {
+	var findings []HOSRiskFinding
+	for _, c := range r.Conflicts {
+		if c.Type != HOSConflictUnloggedDriving {
+			continue
+		}
+		findings = append(findings, HOSRiskFinding{
+			Indicator: HOSRiskDrivingEditedAway,
+			Start:     c.Start,
+			End:       c.End,
+			Mins:      c.Mins,
+			Status:    c.ManualStatus,
+			LogID:     c.ManualLogID,
+			Detail:    fmt.Sprintf("ELD recorded %d min of driving the log shows as %s", c.Mins, c.ManualStatus),
+		})
+	}
+	return findings
+}
+
+// hosLogEnd is when a log ended, or now when it is still open
+func hosLogEnd(l HOSLog, now time.Time) time.Time {
+	switch {
+	case l.EndTime != nil:
+		return *l.EndTime
+	case l.DurationMins > 0:
+		return l.StartTime.Add(time.Duration(l.DurationMins) * time.Minute)
+	}
+	return now
+}
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
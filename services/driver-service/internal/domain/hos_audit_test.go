@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFindMovementWhileOff(t *testing.T) {
+	day := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	end := at(8, 0)
+	offEnd := at(6, 0)
+	logs := []HOSLog{
+		{ID: uuid.New(), Status: HOSStatusSleeperBerth, StartTime: at(0, 0), EndTime: &offEnd},
+		{ID: uuid.New(), Status: HOSStatusDriving, StartTime: at(6, 0)},
+	}
+	moving := func(h, m int, mph float64) LocationPoint {
+		return LocationPoint{RecordedAt: at(h, m), SpeedMph: mph}
+	}
+
+	tests := []struct {
+		name     string
+		points   []LocationPoint
+		wantMins []int
+	}{
+		{
+			name:   "parked all night",
+			points: []LocationPoint{moving(1, 0, 0), moving(2, 0, 3), moving(3, 0, 0)},
+		},
+		{
+			name:     "sustained movement in the sleeper",
+			points:   []LocationPoint{moving(2, 0, 45), moving(2, 5, 55), moving(2, 12, 60), moving(2, 20, 50), moving(2, 25, 0)},
+			wantMins: []int{20},
+		},
+		{
+			name:   "a few minutes of repositioning",
+			points: []LocationPoint{moving(2, 0, 12), moving(2, 3, 15), moving(2, 4, 0)},
+		},
+		{
+			name:     "two separate stretches",
+			points:   []LocationPoint{moving(1, 0, 40), moving(1, 10, 40), moving(3, 0, 40), moving(3, 10, 40), moving(3, 20, 40), moving(3, 30, 40)},
+			wantMins: []int{10, 30},
+		},
+		{
+			name:   "movement after the rest ended",
+			points: []LocationPoint{moving(6, 10, 60), moving(7, 0, 60)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := FindMovementWhileOff(logs, tt.points, end)
+			if len(findings) != len(tt.wantMins) {
+				t.Fatalf("expected %d findings, got %+v", len(tt.wantMins), findings)
+			}
+			for i, f := range findings {
+				if f.Indicator != HOSRiskMovementWhileOff || f.Status != HOSStatusSleeperBerth {
+					t.Errorf("finding %d: unexpected %s while %s", i, f.Indicator, f.Status)
+				}
+				if f.Mins != tt.wantMins[i] {
+					t.Errorf("finding %d: expected %d mins, got %d", i, tt.wantMins[i], f.Mins)
+				}
+			}
+		})
+	}
+}
+
+func TestFindMovementWhileOff_PersonalConveyance(t *testing.T) {
+	start := time.Date(2024, 6, 12, 20, 0, 0, 0, time.UTC)
+	logs := []HOSLog{{ID: uuid.New(), Status: HOSStatusPersonalConveyance, StartTime: start, DurationMins: 60}}
+	points := []LocationPoint{
+		{RecordedAt: start.Add(5 * time.Minute), SpeedMph: 35},
+		{RecordedAt: start.Add(30 * time.Minute), SpeedMph: 35},
+	}
+	if findings := FindMovementWhileOff(logs, points, start.Add(2*time.Hour)); len(findings) != 0 {
+		t.Errorf("expected personal conveyance not to be flagged, got %+v", findings)
+	}
+}
+
+func TestFindOdometerWhileOff(t *testing.T) {
+	start := time.Date(2024, 6, 12, 6, 0, 0, 0, time.UTC)
+	logs := []HOSLog{
+		{ID: uuid.New(), Status: HOSStatusDriving, StartTime: start, Odometer: 1000},
+		{ID: uuid.New(), Status: HOSStatusOffDuty, StartTime: start.Add(2 * time.Hour), Odometer: 1100},
+		{ID: uuid.New(), Status: HOSStatusOnDutyNotDriv, StartTime: start.Add(4 * time.Hour), Odometer: 1101},
+		{ID: uuid.New(), Status: HOSStatusSleeperBerth, StartTime: start.Add(5 * time.Hour), Odometer: 1101},
+		{ID: uuid.New(), Status: HOSStatusDriving, StartTime: start.Add(9 * time.Hour), Odometer: 1160},
+		{ID: uuid.New(), Status: HOSStatusOffDuty, StartTime: start.Add(11 * time.Hour)},
+		{ID: uuid.New(), Status: HOSStatusDriving, StartTime: start.Add(12 * time.Hour), Odometer: 1300},
+	}
+
+	findings := FindOdometerWhileOff(logs)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	if findings[0].LogID != logs[3].ID || findings[0].Mins != 240 {
+		t.Errorf("expected the sleeper berth entry over 240 mins, got %+v", findings[0])
+	}
+}
+
+func TestMissingCertifications(t *testing.T) {
+	day1 := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	day2, day3, day4 := day1.AddDate(0, 0, 1), day1.AddDate(0, 0, 2), day1.AddDate(0, 0, 3)
+	end := day1.AddDate(0, 0, 7)
+	now := day4.Add(12 * time.Hour)
+	hour := func(d time.Time, h int) time.Time { return d.Add(time.Duration(h) * time.Hour) }
+	ended := func(t time.Time) *time.Time { return &t }
+
+	logs := []HOSLog{
+		{Status: HOSStatusDriving, StartTime: hour(day1, 6), EndTime: ended(hour(day1, 16)), CreatedAt: hour(day1, 6)},
+		{Status: HOSStatusDriving, StartTime: hour(day2, 6), EndTime: ended(hour(day2, 16)), CreatedAt: hour(day2, 6)},
+		{Status: HOSStatusDriving, StartTime: hour(day3, 6), EndTime: ended(hour(day3, 16)), CreatedAt: hour(day3, 6)},
+		// Keyed by hand after day 2 was certified
+		{Status: HOSStatusOnDutyNotDriv, StartTime: hour(day2, 17), EndTime: ended(hour(day2, 18)), CreatedAt: hour(day3, 9), Source: HOSSourceManual},
+		// Today is not due yet
+		{Status: HOSStatusDriving, StartTime: hour(day4, 6), CreatedAt: hour(day4, 6)},
+	}
+	certs := []HOSLogCertification{
+		{LogDate: day1, CertifiedAt: hour(day1, 20)},
+		{LogDate: day2, CertifiedAt: hour(day2, 20)},
+	}
+
+	gaps := MissingCertifications(logs, certs, day1, end, now)
+	if len(gaps) != 2 {
+		t.Fatalf("expected 2 gaps, got %+v", gaps)
+	}
+	if gaps[0].Date != "2024-06-11" || !gaps[0].EditedAfter {
+		t.Errorf("expected day 2 to need recertifying, got %+v", gaps[0])
+	}
+	if gaps[1].Date != "2024-06-12" || gaps[1].EditedAfter {
+		t.Errorf("expected day 3 to be uncertified, got %+v", gaps[1])
+	}
+}
+
+func TestDriverHOSAudit_AssessRisk(t *testing.T) {
+	tests := []struct {
+		name  string
+		audit DriverHOSAudit
+		want  string
+	}{
+		{name: "clean", want: HOSRiskLow},
+		{
+			name:  "violations alone",
+			audit: DriverHOSAudit{Violations: []HOSViolation{{Type: "11_hour"}}},
+			want:  HOSRiskLow,
+		},
+		{
+			name: "violations with uncertified days",
+			audit: DriverHOSAudit{
+				Violations:            []HOSViolation{{Type: "14_hour"}},
+				MissingCertifications: []HOSCertificationGap{{Date: "2024-06-10"}},
+			},
+			want: HOSRiskMedium,
+		},
+		{
+			name:  "odometer advanced while off duty",
+			audit: DriverHOSAudit{RiskFindings: []HOSRiskFinding{{Indicator: HOSRiskOdometerWhileOff}}},
+			want:  HOSRiskMedium,
+		},
+		{
+			name:  "movement while off duty",
+			audit: DriverHOSAudit{RiskFindings: []HOSRiskFinding{{Indicator: HOSRiskMovementWhileOff}}},
+			want:  HOSRiskHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.audit.AssessRisk()
+			if tt.audit.RiskLevel != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, tt.audit.RiskLevel)
+			}
+		})
+	}
+}
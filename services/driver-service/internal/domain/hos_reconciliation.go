@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HOS log sources
+const (
+	HOSSourceELD    = "eld"
+	HOSSourceManual = "manual"
+	HOSSourceAuto   = "auto"
+)
+
+// HOSReconcileToleranceMins is how long the ELD and manual records may
+// disagree before it is flagged, to allow for a driver keying a change a few
+// minutes late
+const HOSReconcileToleranceMins = 5
+
+// HOSConflictType is how a manual entry disagrees with the ELD
+type HOSConflictType string
+
+const (
+	// The manual entry shows a different duty status than the ELD recorded
+	HOSConflictStatusMismatch HOSConflictType = "STATUS_MISMATCH"
+	// The ELD recorded driving the manual entries show as something else;
+	// automatically recorded driving time cannot be edited away
+	HOSConflictUnloggedDriving HOSConflictType = "UNLOGGED_DRIVING"
+	// The manual entry covers time the ELD has no record for
+	HOSConflictNoELDRecord HOSConflictType = "NO_ELD_RECORD"
+)
+
+// HOSLogConflict is a stretch of time the manual and ELD records disagree on
+type HOSLogConflict struct {
+	Type         HOSConflictType `json:"type"`
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	Mins         int             `json:"mins"`
+	ManualLogID  uuid.UUID       `json:"manual_log_id"`
+	ManualStatus HOSStatus       `json:"manual_status"`
+	ELDLogID     *uuid.UUID      `json:"eld_log_id,omitempty"`
+	ELDStatus    HOSStatus       `json:"eld_status,omitempty"`
+}
+
+// HOSReconciliation compares a driver's manual entries with what their ELD
+// recorded over a period
+type HOSReconciliation struct {
+	DriverID   uuid.UUID        `json:"driver_id"`
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	ELDMins    int              `json:"eld_mins"`    // Time the ELD has a record for
+	ManualMins int              `json:"manual_mins"` // Time covered by manual entries
+	Conflicts  []HOSLogConflict `json:"conflicts"`
+}
+
+// Reconciled reports whether the manual entries agree with the ELD
+func (r *HOSReconciliation) Reconciled() bool {
+	return len(r.Conflicts) == 0
+}
+
+// hosSpan is one log's stretch of time within the reconciled period
+type hosSpan struct {
+	log   HOSLog
+	start time.Time
+	end   time.Time
+}
+
+// ReconcileHOSLogs compares the manual entries in logs with the ELD-sourced
+// ones between start and end. Each source is read as its own timeline, a
+// status running until that source's next change, because entries from one
+// source close the open entry of the other as they are recorded. Auto
+// entries are ignored. Disagreements shorter than HOSReconcileToleranceMins
+// are not reported.
+func ReconcileHOSLogs(driverID uuid.UUID, logs []HOSLog, start, end time.Time) *HOSReconciliation {
+	result := &HOSReconciliation{DriverID: driverID, Start: start, End: end, Conflicts: []HOSLogConflict{}}
+
+	eld := hosTimelineSpans(logs, HOSSourceELD, start, end)
+	manual := hosTimelineSpans(logs, HOSSourceManual, start, end)
+	for _, s := range eld {
+		result.ELDMins += int(s.end.Sub(s.start).Minutes())
+	}
+
+	for _, m := range manual {
+		result.ManualMins += int(m.end.Sub(m.start).Minutes())
+
+		covered := time.Duration(0)
+		for _, e := range eld {
+			from, to := laterTime(m.start, e.start), earlierTime(m.end, e.end)
+			if !to.After(from) {
+				continue
+			}
+			covered += to.Sub(from)
+			if e.log.Status == m.log.Status {
+				continue
+			}
+			conflict := HOSLogConflict{
+				Type:         HOSConflictStatusMismatch,
+				Start:        from,
+				End:          to,
+				Mins:         int(to.Sub(from).Minutes()),
+				ManualLogID:  m.log.ID,
+				ManualStatus: m.log.Status,
+				ELDStatus:    e.log.Status,
+			}
+			if e.log.Status.CountsAsDriving() {
+				conflict.Type = HOSConflictUnloggedDriving
+			}
+			eldID := e.log.ID
+			conflict.ELDLogID = &eldID
+			result.addConflict(conflict)
+		}
+
+		if uncovered := m.end.Sub(m.start) - covered; uncovered > 0 {
+			result.addConflict(HOSLogConflict{
+				Type:         HOSConflictNoELDRecord,
+				Start:        m.start,
+				End:          m.end,
+				Mins:         int(uncovered.Minutes()),
+				ManualLogID:  m.log.ID,
+				ManualStatus: m.log.Status,
+			})
+		}
+	}
+
+	sort.SliceStable(result.Conflicts, func(i, j int) bool {
+		return result.Conflicts[i].Start.Before(result.Conflicts[j].Start)
+	})
+	return result
+}
+
+// addConflict records a conflict that lasts longer than the tolerance
+func (r *HOSReconciliation) addConflict(c HOSLogConflict) {
+	if c.Mins > HOSReconcileToleranceMins {
+		r.Conflicts = append(r.Conflicts, c)
+	}
+}
+
+// hosTimelineSpans returns the logs from one source as back-to-back spans
+// clipped to start and end, each running until the next log from the source
+func hosTimelineSpans(logs []HOSLog, source string, start, end time.Time) []hosSpan {
+	var own []HOSLog
+	for _, l := range logs {
+		if l.Source == source {
+			own = append(own, l)
+		}
+	}
+	sort.Slice(own, func(i, j int) bool { return own[i].StartTime.Before(own[j].StartTime) })
+
+	var spans []hosSpan
+	for i, l := range own {
+		to := end
+		if i < len(own)-1 {
+			to = earlierTime(own[i+1].StartTime, end)
+		}
+		from := laterTime(l.StartTime, start)
+		if to.After(from) {
+			spans = append(spans, hosSpan{log: l, start: from, end: to})
+		}
+	}
+	return spans
+}
+
+func laterTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlierTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func sourcedLog(source string, status HOSStatus, start time.Time) HOSLog {
+	return HOSLog{ID: uuid.New(), Status: status, StartTime: start, Source: source}
+}
+
+func TestReconcileHOSLogs(t *testing.T) {
+	day := time.Date(2024, 6, 12, 6, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	end := at(10, 0)
+
+	tests := []struct {
+		name       string
+		logs       []HOSLog
+		wantTypes  []HOSConflictType
+		wantMins   []int
+		wantManual int
+	}{
+		{
+			name: "manual entries matching the ELD",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceELD, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceELD, HOSStatusDriving, at(1, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusDriving, at(1, 0)),
+			},
+			wantManual: 600,
+		},
+		{
+			name: "keyed a few minutes late",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceELD, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceELD, HOSStatusDriving, at(1, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusDriving, at(1, 4)),
+			},
+			wantManual: 600,
+		},
+		{
+			name: "driving logged as on duty",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceELD, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceELD, HOSStatusDriving, at(1, 0)),
+				sourcedLog(HOSSourceELD, HOSStatusOnDutyNotDriv, at(3, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusOnDutyNotDriv, at(0, 0)),
+			},
+			wantTypes:  []HOSConflictType{HOSConflictUnloggedDriving},
+			wantMins:   []int{120},
+			wantManual: 600,
+		},
+		{
+			name: "off duty logged as sleeper",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceELD, HOSStatusOffDuty, at(0, 0)),
+				sourcedLog(HOSSourceManual, HOSStatusSleeperBerth, at(2, 0)),
+			},
+			wantTypes:  []HOSConflictType{HOSConflictStatusMismatch},
+			wantMins:   []int{480},
+			wantManual: 480,
+		},
+		{
+			name: "manual time before the ELD came on",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceManual, HOSStatusOnDutyNotDriv, at(0, 0)),
+				sourcedLog(HOSSourceELD, HOSStatusOnDutyNotDriv, at(1, 30)),
+			},
+			wantTypes:  []HOSConflictType{HOSConflictNoELDRecord},
+			wantMins:   []int{90},
+			wantManual: 600,
+		},
+		{
+			name: "auto entries are not reconciled",
+			logs: []HOSLog{
+				sourcedLog(HOSSourceELD, HOSStatusOffDuty, at(0, 0)),
+				sourcedLog(HOSSourceAuto, HOSStatusDriving, at(1, 0)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverID := uuid.New()
+			got := ReconcileHOSLogs(driverID, tt.logs, day, end)
+			if got.DriverID != driverID {
+				t.Errorf("DriverID = %v, want %v", got.DriverID, driverID)
+			}
+			if got.ManualMins != tt.wantManual {
+				t.Errorf("ManualMins = %d, want %d", got.ManualMins, tt.wantManual)
+			}
+			if len(got.Conflicts) != len(tt.wantTypes) {
+				t.Fatalf("got %d conflicts %+v, want %d", len(got.Conflicts), got.Conflicts, len(tt.wantTypes))
+			}
+			for i, c := range got.Conflicts {
+				if c.Type != tt.wantTypes[i] {
+					t.Errorf("conflict %d type = %s, want %s", i, c.Type, tt.wantTypes[i])
+				}
+				if c.Mins != tt.wantMins[i] {
+					t.Errorf("conflict %d mins = %d, want %d", i, c.Mins, tt.wantMins[i])
+				}
+			}
+			if got.Reconciled() != (len(tt.wantTypes) == 0) {
+				t.Errorf("Reconciled() = %v", got.Reconciled())
+			}
+		})
+	}
+}
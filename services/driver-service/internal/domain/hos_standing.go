@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Property-carrying HOS limits, 49 CFR 395.3, in minutes
+const (
+	HOSDrivingLimitMins = 660  // 11 hours driving per duty period
+	HOSWindowLimitMins  = 840  // No driving after the 14th hour of the duty period
+	HOSCycleLimitMins   = 4200 // 70 hours on duty in 8 days
+	HOSCycleDays        = 8
+
+	HOSResetMins   = 600  // 10 consecutive hours off starts a new duty period
+	HOSRestartMins = 2040 // 34 consecutive hours off restarts the 8-day cycle
+
+	// Sleeper berth split, 395.1(g)(1)(ii): at least 7 consecutive hours in
+	// the sleeper paired with at least 2 hours off or in the sleeper, 10
+	// hours together. Either split may come first.
+	SleeperSplitLongMins  = 420
+	SleeperSplitShortMins = 120
+)
+
+// RestPeriod is an unbroken stretch of off-duty, sleeper berth or personal
+// conveyance time
+type RestPeriod struct {
+	Start       time.Time
+	End         time.Time
+	SleeperMins int  // Longest consecutive stretch in the sleeper berth
+	Ongoing     bool // The driver is still resting
+}
+
+// Mins returns the length of the period
+func (p RestPeriod) Mins() int {
+	return int(p.End.Sub(p.Start).Minutes())
+}
+
+// PairsWith reports whether two rest periods qualify as a sleeper berth split
+func (p RestPeriod) PairsWith(other RestPeriod) bool {
+	pairs := func(long, short RestPeriod) bool {
+		return long.SleeperMins >= SleeperSplitLongMins &&
+			short.Mins() >= SleeperSplitShortMins &&
+			long.SleeperMins+short.Mins() >= HOSResetMins
+	}
+	return pairs(p, other) || pairs(other, p)
+}
+
+// HOSStanding is where a driver stands against the 11-hour, 14-hour and
+// 70-hour limits at a point in time, worked out from their duty status logs
+type HOSStanding struct {
+	// The duty period is counted from the end of the last 10 hours off or,
+	// once a sleeper berth split is completed, from the end of its first
+	// rest period; the second rest period does not use up the 14 hours
+	PeriodStart            *time.Time `json:"period_start,omitempty"`
+	SleeperSplit           bool       `json:"sleeper_split"`
+	DrivingMins            int        `json:"driving_mins"`
+	OnDutyMins             int        `json:"on_duty_mins"` // Driving included
+	WindowMins             int        `json:"window_mins"`  // Elapsed against the 14 hours
+	DrivingAfterWindowMins int        `json:"driving_after_window_mins"`
+
+	// CycleStart is where the 8-day cycle is counted from: 8 days back, or
+	// the end of a 34-hour restart within them
+	CycleStart    time.Time  `json:"cycle_start"`
+	LastRestartAt *time.Time `json:"last_restart_at,omitempty"`
+	CycleDutyMins int        `json:"cycle_duty_mins"`
+}
+
+// AvailableDrivingMins returns the driving time left in the duty period
+func (s *HOSStanding) AvailableDrivingMins() int {
+	return max(0, HOSDrivingLimitMins-s.DrivingMins)
+}
+
+// AvailableWindowMins returns the time left before the 14th hour
+func (s *HOSStanding) AvailableWindowMins() int {
+	return max(0, HOSWindowLimitMins-s.WindowMins)
+}
+
+// AvailableCycleMins returns the on-duty time left in the 8-day cycle
+func (s *HOSStanding) AvailableCycleMins() int {
+	return max(0, HOSCycleLimitMins-s.CycleDutyMins)
+}
+
+// hosSegment is the span of one duty status log, clipped to now
+type hosSegment struct {
+	status HOSStatus
+	start  time.Time
+	end    time.Time
+}
+
+// hosSegments orders logs and works out when each ended: at its end time,
+// after its recorded duration, or now when it is still open
+func hosSegments(logs []HOSLog, now time.Time) []hosSegment {
+	segments := make([]hosSegment, 0, len(logs))
+	for _, log := range logs {
+		end := now
+		switch {
+		case log.EndTime != nil:
+			end = *log.EndTime
+		case log.DurationMins > 0:
+			end = log.StartTime.Add(time.Duration(log.DurationMins) * time.Minute)
+		}
+		if end.After(now) {
+			end = now
+		}
+		if end.After(log.StartTime) {
+			segments = append(segments, hosSegment{status: log.Status, start: log.StartTime, end: end})
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].start.Before(segments[j].start) })
+	return segments
+}
+
+// RestPeriods joins consecutive rest statuses in logs into rest periods
+func RestPeriods(logs []HOSLog, now time.Time) []RestPeriod {
+	return restPeriods(hosSegments(logs, now), now)
+}
+
+func restPeriods(segments []hosSegment, now time.Time) []RestPeriod {
+	var periods []RestPeriod
+	var current *RestPeriod
+	var sleeperStart *time.Time
+	for i := range segments {
+		seg := segments[i]
+		if !seg.status.CountsAsRest() {
+			current, sleeperStart = nil, nil
+			continue
+		}
+		if current == nil || seg.start.After(current.End) {
+			periods = append(periods, RestPeriod{Start: seg.start, End: seg.start})
+			current, sleeperStart = &periods[len(periods)-1], nil
+		}
+		if seg.end.After(current.End) {
+			current.End = seg.end
+		}
+
+		if seg.status != HOSStatusSleeperBerth {
+			sleeperStart = nil
+			continue
+		}
+		if sleeperStart == nil {
+			sleeperStart = &segments[i].start
+		}
+		if mins := int(seg.end.Sub(*sleeperStart).Minutes()); mins > current.SleeperMins {
+			current.SleeperMins = mins
+		}
+	}
+	for i := range periods {
+		periods[i].Ongoing = !periods[i].End.Before(now)
+	}
+	return periods
+}
+
+// ComputeHOSStanding works out where a driver stands at now from their logs,
+// which should reach back at least HOSCycleDays. A driver resting for 10
+// hours or more is at the start of a fresh duty period.
+func ComputeHOSStanding(logs []HOSLog, now time.Time) HOSStanding {
+	segments := hosSegments(logs, now)
+	periods := restPeriods(segments, now)
+
+	standing := HOSStanding{CycleStart: now.AddDate(0, 0, -HOSCycleDays)}
+	for i := range periods {
+		if periods[i].Mins() >= HOSRestartMins && periods[i].End.After(standing.CycleStart) {
+			end := periods[i].End
+			standing.LastRestartAt = &end
+			standing.CycleStart = end
+		}
+	}
+
+	// The duty period starts after the last full reset, or at the first log
+	var periodStart time.Time
+	if len(segments) > 0 {
+		periodStart = segments[0].start
+	}
+	first := 0
+	for i := range periods {
+		if periods[i].Mins() >= HOSResetMins {
+			periodStart = periods[i].End
+			first = i + 1
+		}
+	}
+
+	// Each completed split moves the start to the end of its first period
+	// and leaves the second out of the 14 hours
+	var excluded *RestPeriod
+	var previous *RestPeriod
+	for i := first; i < len(periods); i++ {
+		period := &periods[i]
+		if period.Mins() < SleeperSplitShortMins {
+			continue
+		}
+		if previous != nil && previous.PairsWith(*period) {
+			periodStart = previous.End
+			excluded = period
+			standing.SleeperSplit = true
+		}
+		previous = period
+	}
+	if !periodStart.IsZero() {
+		standing.PeriodStart = &periodStart
+	}
+
+	excludedBefore := func(t time.Time) int {
+		if excluded == nil || !t.After(excluded.Start) {
+			return 0
+		}
+		end := excluded.End
+		if t.Before(end) {
+			end = t
+		}
+		return int(end.Sub(excluded.Start).Minutes())
+	}
+	windowAt := func(t time.Time) int {
+		return int(t.Sub(periodStart).Minutes()) - excludedBefore(t)
+	}
+
+	for _, seg := range segments {
+		if seg.status.CountsAsOnDuty() {
+			if start := maxTime(seg.start, standing.CycleStart); seg.end.After(start) {
+				standing.CycleDutyMins += int(seg.end.Sub(start).Minutes())
+			}
+		}
+		if periodStart.IsZero() || !seg.end.After(periodStart) {
+			continue
+		}
+		start := maxTime(seg.start, periodStart)
+		mins := int(seg.end.Sub(start).Minutes())
+		if seg.status.CountsAsOnDuty() {
+			standing.OnDutyMins += mins
+		}
+		if seg.status.CountsAsDriving() {
+			standing.DrivingMins += mins
+			if over := windowAt(seg.end) - max(windowAt(start), HOSWindowLimitMins); over > 0 {
+				standing.DrivingAfterWindowMins += over
+			}
+		}
+	}
+	if !periodStart.IsZero() {
+		standing.WindowMins = max(0, windowAt(now))
+	}
+	return standing
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+type hosEntry struct {
+	status HOSStatus
+	hours  float64
+}
+
+// hosTimeline lays entries end to end so the last, still open, ends at now
+func hosTimeline(now time.Time, entries ...hosEntry) []HOSLog {
+	var total time.Duration
+	for _, e := range entries {
+		total += time.Duration(e.hours * float64(time.Hour))
+	}
+	start := now.Add(-total)
+	logs := make([]HOSLog, len(entries))
+	for i, e := range entries {
+		d := time.Duration(e.hours * float64(time.Hour))
+		logs[i] = HOSLog{Status: e.status, StartTime: start}
+		if i < len(entries)-1 {
+			end := start.Add(d)
+			logs[i].EndTime = &end
+			logs[i].DurationMins = int(d.Minutes())
+		}
+		start = start.Add(d)
+	}
+	return logs
+}
+
+func TestComputeHOSStanding_DutyPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		entries          []hosEntry
+		wantDriving      int
+		wantWindow       int
+		wantAfterWindow  int
+		wantSleeperSplit bool
+	}{
+		{
+			name: "after 10 hours off",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 3}, {HOSStatusOnDutyNotDriv, 1},
+			},
+			wantDriving: 180,
+			wantWindow:  240,
+		},
+		{
+			name: "short breaks use up the window",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 4}, {HOSStatusOffDuty, 1}, {HOSStatusDriving, 2},
+			},
+			wantDriving: 360,
+			wantWindow:  420,
+		},
+		{
+			name: "8/2 split counts from the end of the sleeper period",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 6}, {HOSStatusSleeperBerth, 8},
+				{HOSStatusDriving, 3}, {HOSStatusOffDuty, 2}, {HOSStatusDriving, 1},
+			},
+			wantDriving:      240,
+			wantWindow:       240,
+			wantSleeperSplit: true,
+		},
+		{
+			name: "7/3 split with the short period first",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 5}, {HOSStatusOffDuty, 3},
+				{HOSStatusDriving, 4}, {HOSStatusSleeperBerth, 7}, {HOSStatusDriving, 2},
+			},
+			wantDriving:      360,
+			wantWindow:       360,
+			wantSleeperSplit: true,
+		},
+		{
+			name: "6 hours in the sleeper does not qualify",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 3}, {HOSStatusSleeperBerth, 6},
+				{HOSStatusDriving, 2}, {HOSStatusOffDuty, 3}, {HOSStatusDriving, 1},
+			},
+			wantDriving:     360,
+			wantWindow:      900,
+			wantAfterWindow: 60,
+		},
+		{
+			name: "driving after the 14th hour",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusOnDutyNotDriv, 13}, {HOSStatusDriving, 2},
+			},
+			wantDriving:     120,
+			wantWindow:      900,
+			wantAfterWindow: 60,
+		},
+		{
+			name: "resting 10 hours starts a fresh period",
+			entries: []hosEntry{
+				{HOSStatusOffDuty, 10}, {HOSStatusDriving, 10}, {HOSStatusOffDuty, 4}, {HOSStatusSleeperBerth, 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ComputeHOSStanding(hosTimeline(now, tt.entries...), now)
+			if s.DrivingMins != tt.wantDriving {
+				t.Errorf("DrivingMins = %d, want %d", s.DrivingMins, tt.wantDriving)
+			}
+			if s.WindowMins != tt.wantWindow {
+				t.Errorf("WindowMins = %d, want %d", s.WindowMins, tt.wantWindow)
+			}
+			if s.DrivingAfterWindowMins != tt.wantAfterWindow {
+				t.Errorf("DrivingAfterWindowMins = %d, want %d", s.DrivingAfterWindowMins, tt.wantAfterWindow)
+			}
+			if s.SleeperSplit != tt.wantSleeperSplit {
+				t.Errorf("SleeperSplit = %v, want %v", s.SleeperSplit, tt.wantSleeperSplit)
+			}
+		})
+	}
+}
+
+func TestComputeHOSStanding_Restart(t *testing.T) {
+	now := time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC)
+
+	week := func(restHours float64) []hosEntry {
+		var entries []hosEntry
+		for i := 0; i < 4; i++ {
+			entries = append(entries, hosEntry{HOSStatusOffDuty, 12}, hosEntry{HOSStatusOnDutyNotDriv, 12})
+		}
+		return append(entries, hosEntry{HOSStatusOffDuty, restHours}, hosEntry{HOSStatusDriving, 2})
+	}
+
+	s := ComputeHOSStanding(hosTimeline(now, week(34)...), now)
+	if s.LastRestartAt == nil {
+		t.Fatal("expected a 34-hour restart")
+	}
+	if want := now.Add(-2 * time.Hour); !s.LastRestartAt.Equal(want) {
+		t.Errorf("LastRestartAt = %v, want %v", s.LastRestartAt, want)
+	}
+	if s.CycleDutyMins != 120 {
+		t.Errorf("CycleDutyMins after restart = %d, want 120", s.CycleDutyMins)
+	}
+	if s.AvailableCycleMins() != HOSCycleLimitMins-120 {
+		t.Errorf("AvailableCycleMins = %d, want %d", s.AvailableCycleMins(), HOSCycleLimitMins-120)
+	}
+
+	s = ComputeHOSStanding(hosTimeline(now, week(33)...), now)
+	if s.LastRestartAt != nil {
+		t.Errorf("33 hours off counted as a restart ending %v", s.LastRestartAt)
+	}
+	if s.CycleDutyMins != 4*12*60+120 {
+		t.Errorf("CycleDutyMins without restart = %d, want %d", s.CycleDutyMins, 4*12*60+120)
+	}
+}
+
+func TestRestPeriod_PairsWith(t *testing.T) {
+	at := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	period := func(mins, sleeperMins int) RestPeriod {
+		return RestPeriod{Start: at, End: at.Add(time.Duration(mins) * time.Minute), SleeperMins: sleeperMins}
+	}
+
+	tests := []struct {
+		name  string
+		a, b  RestPeriod
+		pairs bool
+	}{
+		{"8 sleeper and 2 off", period(480, 480), period(120, 0), true},
+		{"3 off and 7 sleeper", period(180, 0), period(420, 420), true},
+		{"7 sleeper and 2 off is short of 10", period(420, 420), period(120, 0), false},
+		{"7 off is not sleeper", period(420, 0), period(180, 0), false},
+		{"under 2 hours", period(540, 540), period(90, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.PairsWith(tt.b); got != tt.pairs {
+				t.Errorf("PairsWith() = %v, want %v", got, tt.pairs)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HOSClock identifies which hours-of-service limit a warning counts down to
+type HOSClock string
+
+const (
+	HOSClockDrive HOSClock = "DRIVE" // 11-hour driving limit
+	HOSClockDuty  HOSClock = "DUTY"  // 14-hour on-duty window
+)
+
+// HOSWarning is a countdown notification pushed to a driver before a limit is
+// reached. Each threshold is sent at most once per clock and window.
+type HOSWarning struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	DriverID      uuid.UUID `json:"driver_id" db:"driver_id"`
+	Clock         HOSClock  `json:"clock" db:"clock"`
+	ThresholdMins int       `json:"threshold_mins" db:"threshold_mins"`
+	RemainingMins int       `json:"remaining_mins" db:"remaining_mins"`
+	LimitAt       time.Time `json:"limit_at" db:"limit_at"`         // When the clock runs out if the driver keeps going
+	WindowStart   time.Time `json:"window_start" db:"window_start"` // Start of the window the clock belongs to
+	Delivered     bool      `json:"delivered" db:"delivered"`
+	DeliveryError string    `json:"delivery_error,omitempty" db:"delivery_error"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
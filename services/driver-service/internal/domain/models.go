@@ -26,8 +26,40 @@ const (
 	HOSStatusSleeperBerth  HOSStatus = "SLEEPER_BERTH"
 	HOSStatusDriving       HOSStatus = "DRIVING"
 	HOSStatusOnDutyNotDriv HOSStatus = "ON_DUTY_NOT_DRIVING"
+
+	// ELD special driving categories
+	HOSStatusPersonalConveyance HOSStatus = "PERSONAL_CONVEYANCE" // off-duty movement of the CMV for personal use
+	HOSStatusYardMove           HOSStatus = "YARD_MOVE"           // on-duty movement within a yard or terminal
 )
 
+// CountsAsDriving reports whether time in this status counts toward the 11-hour driving limit
+func (s HOSStatus) CountsAsDriving() bool {
+	return s == HOSStatusDriving
+}
+
+// CountsAsOnDuty reports whether time in this status counts toward the 14-hour and 70-hour limits
+func (s HOSStatus) CountsAsOnDuty() bool {
+	switch s {
+	case HOSStatusDriving, HOSStatusOnDutyNotDriv, HOSStatusYardMove:
+		return true
+	}
+	return false
+}
+
+// CountsAsRest reports whether time in this status can satisfy a required break
+func (s HOSStatus) CountsAsRest() bool {
+	switch s {
+	case HOSStatusOffDuty, HOSStatusSleeperBerth, HOSStatusPersonalConveyance:
+		return true
+	}
+	return false
+}
+
+// IsSpecialDrivingCategory reports whether the status is an ELD special driving category
+func (s HOSStatus) IsSpecialDrivingCategory() bool {
+	return s == HOSStatusPersonalConveyance || s == HOSStatusYardMove
+}
+
 // Driver represents a truck driver
 type Driver struct {
 	ID                    uuid.UUID    `json:"id" db:"id"`
@@ -36,6 +68,7 @@ type Driver struct {
 	LastName              string       `json:"last_name" db:"last_name"`
 	Email                 string       `json:"email" db:"email"`
 	Phone                 string       `json:"phone" db:"phone"`
+	PhoneIndex            string       `json:"-" db:"phone_index"`
 	Status                DriverStatus `json:"status" db:"status"`
 	
 	// License Information
@@ -54,6 +87,8 @@ type Driver struct {
 	
 	// Medical
 	MedicalCardExpiration *time.Time `json:"medical_card_expiration,omitempty" db:"medical_card_expiration"`
+	MedicalCardNumber     string     `json:"medical_card_number,omitempty" db:"medical_card_number"`   // Encrypted at rest
+	MedicalRestrictions   string     `json:"medical_restrictions,omitempty" db:"medical_restrictions"` // e.g. corrective lenses; encrypted at rest
 	
 	// Current State
 	CurrentLatitude       float64    `json:"current_latitude" db:"current_latitude"`
@@ -146,6 +181,8 @@ type HOSSummary struct {
 	OnDutyMins       int       `json:"on_duty_mins"`
 	OffDutyMins      int       `json:"off_duty_mins"`
 	SleeperMins      int       `json:"sleeper_mins"`
+	PersonalConveyanceMins int `json:"personal_conveyance_mins"` // included in OffDutyMins
+	YardMoveMins     int       `json:"yard_move_mins"`           // included in OnDutyMins
 	AvailableDrive   int       `json:"available_drive"`
 	AvailableDuty    int       `json:"available_duty"`
 	AvailableCycle   int       `json:"available_cycle"`
@@ -255,6 +255,8 @@ func TestHOSStatus_Values(t *testing.T) {
 		{HOSStatusSleeperBerth, "SLEEPER_BERTH"},
 		{HOSStatusDriving, "DRIVING"},
 		{HOSStatusOnDutyNotDriv, "ON_DUTY_NOT_DRIVING"},
+		{HOSStatusPersonalConveyance, "PERSONAL_CONVEYANCE"},
+		{HOSStatusYardMove, "YARD_MOVE"},
 	}
 
 	for _, tt := range tests {
@@ -266,6 +268,40 @@ func TestHOSStatus_Values(t *testing.T) {
 	}
 }
 
+func TestHOSStatus_LimitTreatment(t *testing.T) {
+	tests := []struct {
+		status      HOSStatus
+		wantDriving bool
+		wantOnDuty  bool
+		wantRest    bool
+		wantSpecial bool
+	}{
+		{HOSStatusOffDuty, false, false, true, false},
+		{HOSStatusSleeperBerth, false, false, true, false},
+		{HOSStatusDriving, true, true, false, false},
+		{HOSStatusOnDutyNotDriv, false, true, false, false},
+		{HOSStatusPersonalConveyance, false, false, true, true},
+		{HOSStatusYardMove, false, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.CountsAsDriving(); got != tt.wantDriving {
+				t.Errorf("CountsAsDriving() = %v, want %v", got, tt.wantDriving)
+			}
+			if got := tt.status.CountsAsOnDuty(); got != tt.wantOnDuty {
+				t.Errorf("CountsAsOnDuty() = %v, want %v", got, tt.wantOnDuty)
+			}
+			if got := tt.status.CountsAsRest(); got != tt.wantRest {
+				t.Errorf("CountsAsRest() = %v, want %v", got, tt.wantRest)
+			}
+			if got := tt.status.IsSpecialDrivingCategory(); got != tt.wantSpecial {
+				t.Errorf("IsSpecialDrivingCategory() = %v, want %v", got, tt.wantSpecial)
+			}
+		})
+	}
+}
+
 func TestHOSLog_Structure(t *testing.T) {
 	now := time.Now()
 	driverID := uuid.New()
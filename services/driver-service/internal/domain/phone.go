@@ -0,0 +1,26 @@
+package domain
+
+import "strings"
+
+// NormalizePhone reduces a phone number to E.164, assuming a North American
+// number when there is no country code. It must agree with dispatch-service's
+// NormalizePhone: inbound SMS lookups hash the number that service normalizes
+// against the phone_index written here.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	switch {
+	case len(d) == 10:
+		return "+1" + d
+	case len(d) == 11 && d[0] == '1':
+		return "+" + d
+	case d == "":
+		return ""
+	}
+	return "+" + d
+}
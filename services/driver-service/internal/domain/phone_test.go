@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"(555) 123-4567", "+15551234567"},
+		{"555.123.4567", "+15551234567"},
+		{"1-555-123-4567", "+15551234567"},
+		{"+1 555 123 4567", "+15551234567"},
+		{"+44 20 7946 0958", "+442079460958"},
+		{"", ""},
+		{"n/a", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := NormalizePhone(tt.phone); got != tt.want {
+				t.Errorf("NormalizePhone(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
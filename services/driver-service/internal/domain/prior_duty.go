@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriorDutySource records how a new hire's prior-carrier hours were entered
+type PriorDutySource string
+
+const (
+	PriorDutySourceManual      PriorDutySource = "MANUAL"       // Keyed from the driver's signed statement or prior logs
+	PriorDutySourceELDTransfer PriorDutySource = "ELD_TRANSFER" // Parsed from the previous carrier's ELD output file
+)
+
+// PriorDutyLookbackDays is how many days before the hire date prior-carrier
+// hours are kept for: the 70-hour/8-day cycle on the first day of employment
+// reaches back 7 days
+const PriorDutyLookbackDays = 7
+
+// PriorDutyDay is a day of on-duty time a new hire worked for a previous
+// carrier before joining. It counts toward the 70-hour/8-day cycle for the
+// first 8 days of employment.
+type PriorDutyDay struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	DriverID    uuid.UUID       `json:"driver_id" db:"driver_id"`
+	DutyDate    time.Time       `json:"duty_date" db:"duty_date"`
+	OnDutyMins  int             `json:"on_duty_mins" db:"on_duty_mins"` // Driving included
+	DrivingMins int             `json:"driving_mins" db:"driving_mins"`
+	CarrierName string          `json:"carrier_name" db:"carrier_name"`
+	CarrierDOT  string          `json:"carrier_dot,omitempty" db:"carrier_dot"`
+	Source      PriorDutySource `json:"source" db:"source"`
+	ImportedBy  string          `json:"imported_by" db:"imported_by"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// PriorDutyApplies reports whether prior-carrier hours still count toward the
+// driver's cycle at now, which is during the first 8 days of employment
+func (d *Driver) PriorDutyApplies(now time.Time) bool {
+	if d.HireDate == nil {
+		return false
+	}
+	hired := time.Date(d.HireDate.Year(), d.HireDate.Month(), d.HireDate.Day(), 0, 0, 0, 0, now.Location())
+	return now.Before(hired.AddDate(0, 0, 8))
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionSeat is where a logged-on driver sits on a shared tractor
+type SessionSeat string
+
+const (
+	SeatDriver   SessionSeat = "DRIVER"    // At the wheel; ELD driving is attributed to this seat
+	SeatCoDriver SessionSeat = "CO_DRIVER" // Team partner logged on but not driving
+)
+
+// Tractor session end reasons
+const (
+	SessionEndLogout     = "LOGOUT"     // Driver logged out
+	SessionEndSuperseded = "SUPERSEDED" // Another driver took the seat on a slip-seat tractor
+	SessionEndSeatSwap   = "SEAT_SWAP"  // Team drivers swapped seats
+	SessionEndMoved      = "MOVED"      // Driver logged on to another tractor
+	SessionEndResolved   = "RESOLVED"   // Cut short when an overlap was resolved against it
+)
+
+// TractorSession is a driver's time logged on to a tractor. A seat change
+// ends the session and starts a new one, so each session is one seat for
+// one window and driving can be attributed by time alone.
+type TractorSession struct {
+	ID        uuid.UUID   `json:"id" db:"id"`
+	TractorID uuid.UUID   `json:"tractor_id" db:"tractor_id"`
+	DriverID  uuid.UUID   `json:"driver_id" db:"driver_id"`
+	Seat      SessionSeat `json:"seat" db:"seat"`
+	Team      bool        `json:"team" db:"team"`     // Logged on as a team; the partner's session stays open
+	Source    string      `json:"source" db:"source"` // app, eld, dispatch
+	StartedAt time.Time   `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time  `json:"ended_at,omitempty" db:"ended_at"`
+	EndReason string      `json:"end_reason,omitempty" db:"end_reason"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+}
+
+// IsActive checks if the driver is still logged on
+func (s *TractorSession) IsActive() bool {
+	return s.EndedAt == nil
+}
+
+// Covers checks if the driver was logged on at t
+func (s *TractorSession) Covers(t time.Time) bool {
+	return !t.Before(s.StartedAt) && (s.EndedAt == nil || t.Before(*s.EndedAt))
+}
+
+// SegmentAttribution is how an ELD driving segment was assigned to a driver
+type SegmentAttribution string
+
+const (
+	AttributionSession      SegmentAttribution = "SESSION"      // The one driver-seat session on the tractor
+	AttributionReattributed SegmentAttribution = "REATTRIBUTED" // ELD named another driver; the session overrode it
+	AttributionELD          SegmentAttribution = "ELD"          // No session; the driver logged in on the ELD was used
+	AttributionUnassigned   SegmentAttribution = "UNASSIGNED"   // No session and no ELD driver; held for review
+	AttributionConflict     SegmentAttribution = "CONFLICT"     // Overlapping driver-seat sessions; held for review
+	AttributionResolved     SegmentAttribution = "RESOLVED"     // Assigned by a dispatcher after review
+)
+
+// DrivingSegment is a stretch of driving reported by a tractor's ELD and the
+// driver it was attributed to. Segments held for review are kept off every
+// driver's log, so they raise no HOS violations until they are resolved.
+type DrivingSegment struct {
+	ID               uuid.UUID          `json:"id" db:"id"`
+	TractorID        uuid.UUID          `json:"tractor_id" db:"tractor_id"`
+	ExternalID       string             `json:"external_id,omitempty" db:"external_id"`               // ELD provider's event ID
+	ReportedDriverID *uuid.UUID         `json:"reported_driver_id,omitempty" db:"reported_driver_id"` // Driver logged in on the ELD
+	DriverID         *uuid.UUID         `json:"driver_id,omitempty" db:"driver_id"`
+	SessionID        *uuid.UUID         `json:"session_id,omitempty" db:"session_id"`
+	Attribution      SegmentAttribution `json:"attribution" db:"attribution"`
+	Note             string             `json:"note,omitempty" db:"note"`
+	StartTime        time.Time          `json:"start_time" db:"start_time"`
+	EndTime          *time.Time         `json:"end_time,omitempty" db:"end_time"`
+	Location         string             `json:"location,omitempty" db:"location"`
+	Latitude         float64            `json:"latitude" db:"latitude"`
+	Longitude        float64            `json:"longitude" db:"longitude"`
+	Odometer         int                `json:"odometer" db:"odometer"`
+	EngineHours      float64            `json:"engine_hours" db:"engine_hours"`
+	HOSLogID         *uuid.UUID         `json:"hos_log_id,omitempty" db:"hos_log_id"` // Driving log recorded for the attributed driver
+	ResolvedBy       string             `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt       *time.Time         `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt        time.Time          `json:"created_at" db:"created_at"`
+}
+
+// NeedsReview checks if the segment is waiting for a dispatcher to assign it
+func (s *DrivingSegment) NeedsReview() bool {
+	return s.Attribution == AttributionUnassigned || s.Attribution == AttributionConflict
+}
@@ -3,17 +3,22 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/crypto"
 )
 
 // PostgresDriverRepository implements DriverRepository
 type PostgresDriverRepository struct {
-	db *sqlx.DB
+	db         *sqlx.DB
+	pii        *crypto.FieldEncryptor // nil stores PII columns in plaintext
+	phoneIndex *crypto.BlindIndex     // set with pii; keeps encrypted phones searchable
 }
 
 // NewPostgresDriverRepository creates a new PostgreSQL driver repository
@@ -21,7 +26,20 @@ func NewPostgresDriverRepository(db *sqlx.DB) *PostgresDriverRepository {
 	return &PostgresDriverRepository{db: db}
 }
 
+// NewEncryptedDriverRepository creates a PostgreSQL driver repository that
+// encrypts PII columns on write and decrypts them on read. phoneIndex keys
+// the phone_index column that GetByPhone searches in place of the sealed
+// phone number.
+func NewEncryptedDriverRepository(db *sqlx.DB, pii *crypto.FieldEncryptor, phoneIndex *crypto.BlindIndex) *PostgresDriverRepository {
+	return &PostgresDriverRepository{db: db, pii: pii, phoneIndex: phoneIndex}
+}
+
 func (r *PostgresDriverRepository) Create(ctx context.Context, driver *domain.Driver) error {
+	sealed, err := r.seal(ctx, driver)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO drivers (
 			id, employee_number, first_name, last_name, email, phone, status,
@@ -30,22 +48,24 @@ func (r *PostgresDriverRepository) Create(ctx context.Context, driver *domain.Dr
 			has_tanker_endorsement, has_doubles_endorsement, medical_card_expiration,
 			current_latitude, current_longitude, current_tractor_id, current_trip_id,
 			available_drive_mins, available_duty_mins, available_cycle_mins, last_hos_update,
-			home_terminal_id, hire_date, app_user_id, device_token, created_at, updated_at
+			home_terminal_id, hire_date, app_user_id, device_token, created_at, updated_at,
+			medical_card_number, medical_restrictions, phone_index
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
-			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
+			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35
 		)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		driver.ID, driver.EmployeeNumber, driver.FirstName, driver.LastName,
-		driver.Email, driver.Phone, driver.Status,
-		driver.LicenseNumber, driver.LicenseState, driver.LicenseClass, driver.LicenseExpiration,
+		sealed.email, sealed.phone, driver.Status,
+		sealed.licenseNumber, driver.LicenseState, driver.LicenseClass, driver.LicenseExpiration,
 		driver.HasTWIC, driver.TWICExpiration, driver.HasHazmatEndorsement, driver.HazmatExpiration,
 		driver.HasTankerEndorsement, driver.HasDoublesEndorsement, driver.MedicalCardExpiration,
 		driver.CurrentLatitude, driver.CurrentLongitude, driver.CurrentTractorID, driver.CurrentTripID,
 		driver.AvailableDriveMins, driver.AvailableDutyMins, driver.AvailableCycleMins, driver.LastHOSUpdate,
 		driver.HomeTerminalID, driver.HireDate, driver.AppUserID, driver.DeviceToken,
 		driver.CreatedAt, driver.UpdatedAt,
+		sealed.medicalCardNumber, sealed.medicalRestrictions, sealed.phoneIndex,
 	)
 	return err
 }
@@ -57,7 +77,10 @@ func (r *PostgresDriverRepository) GetByID(ctx context.Context, id uuid.UUID) (*
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return &driver, err
+	if err != nil {
+		return nil, err
+	}
+	return &driver, r.open(ctx, &driver)
 }
 
 func (r *PostgresDriverRepository) GetByEmployeeNumber(ctx context.Context, employeeNumber string) (*domain.Driver, error) {
@@ -67,21 +90,63 @@ func (r *PostgresDriverRepository) GetByEmployeeNumber(ctx context.Context, empl
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return &driver, err
+	if err != nil {
+		return nil, err
+	}
+	return &driver, r.open(ctx, &driver)
+}
+
+// GetByPhone retrieves the active driver with a phone number, in any
+// format NormalizePhone accepts. Encrypted phones are matched through the
+// blind index; plaintext ones by their digits.
+func (r *PostgresDriverRepository) GetByPhone(ctx context.Context, phone string) (*domain.Driver, error) {
+	normalized := domain.NormalizePhone(phone)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	var driver domain.Driver
+	var err error
+	if r.phoneIndex != nil {
+		query := `SELECT * FROM drivers WHERE phone_index = $1 AND termination_date IS NULL LIMIT 1`
+		err = r.db.GetContext(ctx, &driver, query, r.phoneIndex.Sum(normalized))
+	} else {
+		// Stored numbers are as entered, so compare digits with and
+		// without the North American country code
+		digits := strings.TrimPrefix(normalized, "+")
+		query := `
+			SELECT * FROM drivers
+			WHERE regexp_replace(phone, '[^0-9]', '', 'g') IN ($1, $2) AND termination_date IS NULL
+			LIMIT 1`
+		err = r.db.GetContext(ctx, &driver, query, digits, strings.TrimPrefix(digits, "1"))
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &driver, r.open(ctx, &driver)
 }
 
 func (r *PostgresDriverRepository) GetAll(ctx context.Context) ([]domain.Driver, error) {
 	var drivers []domain.Driver
 	query := `SELECT * FROM drivers WHERE termination_date IS NULL ORDER BY last_name, first_name`
 	err := r.db.SelectContext(ctx, &drivers, query)
-	return drivers, err
+	if err != nil {
+		return nil, err
+	}
+	return drivers, r.openAll(ctx, drivers)
 }
 
 func (r *PostgresDriverRepository) GetByStatus(ctx context.Context, status domain.DriverStatus) ([]domain.Driver, error) {
 	var drivers []domain.Driver
 	query := `SELECT * FROM drivers WHERE status = $1 AND termination_date IS NULL ORDER BY last_name, first_name`
 	err := r.db.SelectContext(ctx, &drivers, query, status)
-	return drivers, err
+	if err != nil {
+		return nil, err
+	}
+	return drivers, r.openAll(ctx, drivers)
 }
 
 func (r *PostgresDriverRepository) GetAvailable(ctx context.Context) ([]domain.Driver, error) {
@@ -95,33 +160,46 @@ func (r *PostgresDriverRepository) GetAvailable(ctx context.Context) ([]domain.D
 		  AND available_cycle_mins > 0
 		ORDER BY last_name, first_name`
 	err := r.db.SelectContext(ctx, &drivers, query)
-	return drivers, err
+	if err != nil {
+		return nil, err
+	}
+	return drivers, r.openAll(ctx, drivers)
 }
 
 func (r *PostgresDriverRepository) GetByTerminalID(ctx context.Context, terminalID uuid.UUID) ([]domain.Driver, error) {
 	var drivers []domain.Driver
 	query := `SELECT * FROM drivers WHERE home_terminal_id = $1 AND termination_date IS NULL ORDER BY last_name, first_name`
 	err := r.db.SelectContext(ctx, &drivers, query, terminalID)
-	return drivers, err
+	if err != nil {
+		return nil, err
+	}
+	return drivers, r.openAll(ctx, drivers)
 }
 
 func (r *PostgresDriverRepository) Update(ctx context.Context, driver *domain.Driver) error {
+	sealed, err := r.seal(ctx, driver)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE drivers SET
 			employee_number = $2, first_name = $3, last_name = $4, email = $5, phone = $6, status = $7,
 			license_number = $8, license_state = $9, license_class = $10, license_expiration = $11,
 			has_twic = $12, twic_expiration = $13, has_hazmat_endorsement = $14, hazmat_expiration = $15,
 			has_tanker_endorsement = $16, has_doubles_endorsement = $17, medical_card_expiration = $18,
-			home_terminal_id = $19, device_token = $20, updated_at = $21
+			home_terminal_id = $19, device_token = $20, updated_at = $21,
+			medical_card_number = $22, medical_restrictions = $23, phone_index = $24
 		WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		driver.ID, driver.EmployeeNumber, driver.FirstName, driver.LastName,
-		driver.Email, driver.Phone, driver.Status,
-		driver.LicenseNumber, driver.LicenseState, driver.LicenseClass, driver.LicenseExpiration,
+		sealed.email, sealed.phone, driver.Status,
+		sealed.licenseNumber, driver.LicenseState, driver.LicenseClass, driver.LicenseExpiration,
 		driver.HasTWIC, driver.TWICExpiration, driver.HasHazmatEndorsement, driver.HazmatExpiration,
 		driver.HasTankerEndorsement, driver.HasDoublesEndorsement, driver.MedicalCardExpiration,
 		driver.HomeTerminalID, driver.DeviceToken, time.Now(),
+		sealed.medicalCardNumber, sealed.medicalRestrictions, sealed.phoneIndex,
 	)
 	return err
 }
@@ -180,7 +258,162 @@ func (r *PostgresDriverRepository) GetExpiringDocuments(ctx context.Context, day
 			)`
 
 	err := r.db.SelectContext(ctx, &drivers, query, threshold)
-	return drivers, err
+	if err != nil {
+		return nil, err
+	}
+	return drivers, r.openAll(ctx, drivers)
+}
+
+// piiColumns holds a driver's PII column values as stored
+type piiColumns struct {
+	email               string
+	phone               string
+	licenseNumber       string
+	medicalCardNumber   string
+	medicalRestrictions string
+	phoneIndex          string
+}
+
+// seal returns the driver's PII as it should be written, encrypted when
+// the repository has an encryptor
+func (r *PostgresDriverRepository) seal(ctx context.Context, driver *domain.Driver) (piiColumns, error) {
+	cols := piiColumns{
+		email:               driver.Email,
+		phone:               driver.Phone,
+		licenseNumber:       driver.LicenseNumber,
+		medicalCardNumber:   driver.MedicalCardNumber,
+		medicalRestrictions: driver.MedicalRestrictions,
+	}
+	if r.pii == nil {
+		return cols, nil
+	}
+	cols.phoneIndex = r.indexPhone(driver.Phone)
+	for _, v := range cols.values() {
+		sealed, err := r.pii.Encrypt(ctx, *v)
+		if err != nil {
+			return piiColumns{}, fmt.Errorf("encrypt driver PII: %w", err)
+		}
+		*v = sealed
+	}
+	return cols, nil
+}
+
+// open decrypts a driver's PII in place. Plaintext left over from before
+// encryption was enabled is returned unchanged.
+func (r *PostgresDriverRepository) open(ctx context.Context, driver *domain.Driver) error {
+	if r.pii == nil {
+		return nil
+	}
+	for _, v := range []*string{
+		&driver.Email, &driver.Phone, &driver.LicenseNumber,
+		&driver.MedicalCardNumber, &driver.MedicalRestrictions,
+	} {
+		plaintext, err := r.pii.Decrypt(ctx, *v)
+		if err != nil {
+			return fmt.Errorf("decrypt PII for driver %s: %w", driver.ID, err)
+		}
+		*v = plaintext
+	}
+	return nil
+}
+
+func (r *PostgresDriverRepository) openAll(ctx context.Context, drivers []domain.Driver) error {
+	for i := range drivers {
+		if err := r.open(ctx, &drivers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexPhone returns the blind index value stored in phone_index
+func (r *PostgresDriverRepository) indexPhone(phone string) string {
+	if r.phoneIndex == nil {
+		return ""
+	}
+	return r.phoneIndex.Sum(domain.NormalizePhone(phone))
+}
+
+func (c *piiColumns) values() []*string {
+	return []*string{&c.email, &c.phone, &c.licenseNumber, &c.medicalCardNumber, &c.medicalRestrictions}
+}
+
+// RotatePII re-encrypts up to batchSize drivers whose PII is plaintext or
+// sealed under a retired master key, and indexes phones written before the
+// blind index existed, returning how many were rewritten.
+// Call it until it returns fewer than batchSize to finish a rotation. The
+// batch stays row-locked until it is rewritten, so an edit made meanwhile
+// waits instead of being overwritten; rows another writer holds are skipped
+// and picked up by the next rotation.
+func (r *PostgresDriverRepository) RotatePII(ctx context.Context, batchSize int) (int, error) {
+	if r.pii == nil {
+		return 0, nil
+	}
+
+	// Values sealed under the current key start with this prefix
+	current := "enc:v1:" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(r.pii.CurrentKeyID()) + ":%"
+	stale := func(col string) string {
+		return fmt.Sprintf("(COALESCE(%s, '') <> '' AND %s NOT LIKE $1)", col, col)
+	}
+	conds := []string{
+		stale("email"), stale("phone"), stale("license_number"),
+		stale("medical_card_number"), stale("medical_restrictions"),
+	}
+	if r.phoneIndex != nil {
+		conds = append(conds, "(COALESCE(phone, '') <> '' AND phone_index = '')")
+	}
+	query := `
+		SELECT id, COALESCE(email, '') AS email, COALESCE(phone, '') AS phone,
+			COALESCE(license_number, '') AS license_number, medical_card_number, medical_restrictions
+		FROM drivers
+		WHERE ` + strings.Join(conds, " OR ") + `
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	var drivers []domain.Driver
+	if err := tx.SelectContext(ctx, &drivers, query, current, batchSize); err != nil {
+		return 0, fmt.Errorf("find drivers to rotate: %w", err)
+	}
+
+	for i := range drivers {
+		d := &drivers[i]
+		phone, err := r.pii.Decrypt(ctx, d.Phone)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt phone for driver %s: %w", d.ID, err)
+		}
+		for _, v := range []*string{
+			&d.Email, &d.Phone, &d.LicenseNumber, &d.MedicalCardNumber, &d.MedicalRestrictions,
+		} {
+			rotated, err := r.pii.Rotate(ctx, *v)
+			if err != nil {
+				return 0, fmt.Errorf("rotate PII for driver %s: %w", d.ID, err)
+			}
+			*v = rotated
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE drivers SET
+				email = $2, phone = $3, license_number = $4,
+				medical_card_number = $5, medical_restrictions = $6, phone_index = $7
+			WHERE id = $1`,
+			d.ID, d.Email, d.Phone, d.LicenseNumber, d.MedicalCardNumber, d.MedicalRestrictions,
+			r.indexPhone(phone),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("update driver %s: %w", d.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit rotation: %w", err)
+	}
+	return len(drivers), nil
 }
 
 // PostgresHOSLogRepository implements HOSLogRepository
@@ -458,3 +691,264 @@ func (r *PostgresDocumentRepository) Delete(ctx context.Context, id uuid.UUID) e
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// PostgresHOSWarningRepository implements HOSWarningRepository
+type PostgresHOSWarningRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresHOSWarningRepository creates a new PostgreSQL HOS warning repository
+func NewPostgresHOSWarningRepository(db *sqlx.DB) *PostgresHOSWarningRepository {
+	return &PostgresHOSWarningRepository{db: db}
+}
+
+func (r *PostgresHOSWarningRepository) Create(ctx context.Context, warning *domain.HOSWarning) error {
+	query := `
+		INSERT INTO hos_warnings (
+			id, driver_id, clock, threshold_mins, remaining_mins, limit_at, window_start,
+			delivered, delivery_error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.db.ExecContext(ctx, query,
+		warning.ID, warning.DriverID, warning.Clock, warning.ThresholdMins, warning.RemainingMins,
+		warning.LimitAt, warning.WindowStart, warning.Delivered, warning.DeliveryError, warning.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresHOSWarningRepository) GetByDriverSince(ctx context.Context, driverID uuid.UUID, since time.Time) ([]domain.HOSWarning, error) {
+	var warnings []domain.HOSWarning
+	query := `SELECT * FROM hos_warnings WHERE driver_id = $1 AND window_start >= $2 ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &warnings, query, driverID, since)
+	return warnings, err
+}
+
+// PostgresTractorSessionRepository implements TractorSessionRepository
+type PostgresTractorSessionRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresTractorSessionRepository creates a new PostgreSQL tractor session repository
+func NewPostgresTractorSessionRepository(db *sqlx.DB) *PostgresTractorSessionRepository {
+	return &PostgresTractorSessionRepository{db: db}
+}
+
+func (r *PostgresTractorSessionRepository) Create(ctx context.Context, session *domain.TractorSession) error {
+	query := `
+		INSERT INTO tractor_sessions (
+			id, tractor_id, driver_id, seat, team, source, started_at, ended_at, end_reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.TractorID, session.DriverID, session.Seat, session.Team, session.Source,
+		session.StartedAt, session.EndedAt, session.EndReason, session.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresTractorSessionRepository) End(ctx context.Context, id uuid.UUID, endedAt time.Time, reason string) error {
+	query := `UPDATE tractor_sessions SET ended_at = $2, end_reason = $3 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, endedAt, reason)
+	return err
+}
+
+func (r *PostgresTractorSessionRepository) GetActiveByTractor(ctx context.Context, tractorID uuid.UUID) ([]domain.TractorSession, error) {
+	var sessions []domain.TractorSession
+	query := `SELECT * FROM tractor_sessions WHERE tractor_id = $1 AND ended_at IS NULL ORDER BY started_at`
+	err := r.db.SelectContext(ctx, &sessions, query, tractorID)
+	return sessions, err
+}
+
+func (r *PostgresTractorSessionRepository) GetActiveByDriver(ctx context.Context, driverID uuid.UUID) ([]domain.TractorSession, error) {
+	var sessions []domain.TractorSession
+	query := `SELECT * FROM tractor_sessions WHERE driver_id = $1 AND ended_at IS NULL ORDER BY started_at`
+	err := r.db.SelectContext(ctx, &sessions, query, driverID)
+	return sessions, err
+}
+
+func (r *PostgresTractorSessionRepository) GetByTractorAt(ctx context.Context, tractorID uuid.UUID, at time.Time) ([]domain.TractorSession, error) {
+	var sessions []domain.TractorSession
+	query := `
+		SELECT * FROM tractor_sessions
+		WHERE tractor_id = $1
+		  AND started_at <= $2
+		  AND (ended_at IS NULL OR ended_at > $2)
+		ORDER BY started_at`
+	err := r.db.SelectContext(ctx, &sessions, query, tractorID, at)
+	return sessions, err
+}
+
+// PostgresDrivingSegmentRepository implements DrivingSegmentRepository
+type PostgresDrivingSegmentRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresDrivingSegmentRepository creates a new PostgreSQL driving segment repository
+func NewPostgresDrivingSegmentRepository(db *sqlx.DB) *PostgresDrivingSegmentRepository {
+	return &PostgresDrivingSegmentRepository{db: db}
+}
+
+func (r *PostgresDrivingSegmentRepository) Create(ctx context.Context, segment *domain.DrivingSegment) error {
+	query := `
+		INSERT INTO eld_driving_segments (
+			id, tractor_id, external_id, reported_driver_id, driver_id, session_id, attribution, note,
+			start_time, end_time, location, latitude, longitude, odometer, engine_hours,
+			hos_log_id, resolved_by, resolved_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+	_, err := r.db.ExecContext(ctx, query,
+		segment.ID, segment.TractorID, segment.ExternalID, segment.ReportedDriverID, segment.DriverID,
+		segment.SessionID, segment.Attribution, segment.Note,
+		segment.StartTime, segment.EndTime, segment.Location, segment.Latitude, segment.Longitude,
+		segment.Odometer, segment.EngineHours,
+		segment.HOSLogID, segment.ResolvedBy, segment.ResolvedAt, segment.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresDrivingSegmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.DrivingSegment, error) {
+	var segment domain.DrivingSegment
+	query := `SELECT * FROM eld_driving_segments WHERE id = $1`
+	err := r.db.GetContext(ctx, &segment, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &segment, err
+}
+
+func (r *PostgresDrivingSegmentRepository) GetByExternalID(ctx context.Context, tractorID uuid.UUID, externalID string) (*domain.DrivingSegment, error) {
+	var segment domain.DrivingSegment
+	query := `SELECT * FROM eld_driving_segments WHERE tractor_id = $1 AND external_id = $2`
+	err := r.db.GetContext(ctx, &segment, query, tractorID, externalID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &segment, err
+}
+
+func (r *PostgresDrivingSegmentRepository) GetPendingReview(ctx context.Context) ([]domain.DrivingSegment, error) {
+	var segments []domain.DrivingSegment
+	query := `
+		SELECT * FROM eld_driving_segments
+		WHERE attribution IN ('UNASSIGNED', 'CONFLICT')
+		ORDER BY start_time`
+	err := r.db.SelectContext(ctx, &segments, query)
+	return segments, err
+}
+
+func (r *PostgresDrivingSegmentRepository) GetByTimeRange(ctx context.Context, start, end time.Time) ([]domain.DrivingSegment, error) {
+	var segments []domain.DrivingSegment
+	query := `
+		SELECT * FROM eld_driving_segments
+		WHERE start_time >= $1
+		  AND start_time < $2
+		ORDER BY start_time`
+	err := r.db.SelectContext(ctx, &segments, query, start, end)
+	return segments, err
+}
+
+func (r *PostgresDrivingSegmentRepository) Update(ctx context.Context, segment *domain.DrivingSegment) error {
+	query := `
+		UPDATE eld_driving_segments SET
+			driver_id = $2, session_id = $3, attribution = $4, note = $5, end_time = $6,
+			hos_log_id = $7, resolved_by = $8, resolved_at = $9
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query,
+		segment.ID, segment.DriverID, segment.SessionID, segment.Attribution, segment.Note, segment.EndTime,
+		segment.HOSLogID, segment.ResolvedBy, segment.ResolvedAt,
+	)
+	return err
+}
+
+// PostgresPriorDutyRepository implements PriorDutyRepository
+type PostgresPriorDutyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresPriorDutyRepository creates a new PostgreSQL prior-carrier duty repository
+func NewPostgresPriorDutyRepository(db *sqlx.DB) *PostgresPriorDutyRepository {
+	return &PostgresPriorDutyRepository{db: db}
+}
+
+func (r *PostgresPriorDutyRepository) Upsert(ctx context.Context, day *domain.PriorDutyDay) error {
+	query := `
+		INSERT INTO driver_prior_duty (
+			id, driver_id, duty_date, on_duty_mins, driving_mins, carrier_name, carrier_dot,
+			source, imported_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (driver_id, duty_date) DO UPDATE SET
+			on_duty_mins = EXCLUDED.on_duty_mins, driving_mins = EXCLUDED.driving_mins,
+			carrier_name = EXCLUDED.carrier_name, carrier_dot = EXCLUDED.carrier_dot,
+			source = EXCLUDED.source, imported_by = EXCLUDED.imported_by, created_at = EXCLUDED.created_at`
+	_, err := r.db.ExecContext(ctx, query,
+		day.ID, day.DriverID, day.DutyDate, day.OnDutyMins, day.DrivingMins, day.CarrierName, day.CarrierDOT,
+		day.Source, day.ImportedBy, day.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresPriorDutyRepository) GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.PriorDutyDay, error) {
+	var days []domain.PriorDutyDay
+	query := `
+		SELECT * FROM driver_prior_duty
+		WHERE driver_id = $1
+		  AND duty_date >= $2
+		  AND duty_date < $3
+		ORDER BY duty_date`
+	err := r.db.SelectContext(ctx, &days, query, driverID, from, to)
+	return days, err
+}
+
+// PostgresHOSCertificationRepository implements HOSCertificationRepository
+type PostgresHOSCertificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresHOSCertificationRepository creates a new PostgreSQL daily log certification repository
+func NewPostgresHOSCertificationRepository(db *sqlx.DB) *PostgresHOSCertificationRepository {
+	return &PostgresHOSCertificationRepository{db: db}
+}
+
+func (r *PostgresHOSCertificationRepository) Certify(ctx context.Context, cert *domain.HOSLogCertification) error {
+	query := `
+		INSERT INTO hos_log_certifications (id, driver_id, log_date, certified_at, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (driver_id, log_date) DO UPDATE SET
+			certified_at = EXCLUDED.certified_at, source = EXCLUDED.source`
+	_, err := r.db.ExecContext(ctx, query, cert.ID, cert.DriverID, cert.LogDate, cert.CertifiedAt, cert.Source)
+	return err
+}
+
+func (r *PostgresHOSCertificationRepository) GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.HOSLogCertification, error) {
+	var certs []domain.HOSLogCertification
+	query := `
+		SELECT * FROM hos_log_certifications
+		WHERE driver_id = $1
+		  AND log_date >= $2
+		  AND log_date < $3
+		ORDER BY log_date`
+	err := r.db.SelectContext(ctx, &certs, query, driverID, from, to)
+	return certs, err
+}
+
+// PostgresLocationHistoryRepository implements LocationHistoryRepository
+// over tracking-service's location_records table
+type PostgresLocationHistoryRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresLocationHistoryRepository creates a new PostgreSQL location history repository
+func NewPostgresLocationHistoryRepository(db *sqlx.DB) *PostgresLocationHistoryRepository {
+	return &PostgresLocationHistoryRepository{db: db}
+}
+
+func (r *PostgresLocationHistoryRepository) GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.LocationPoint, error) {
+	var points []domain.LocationPoint
+	query := `
+		SELECT recorded_at, latitude, longitude, COALESCE(speed_mph, 0) AS speed_mph
+		FROM location_records
+		WHERE driver_id = $1
+		  AND recorded_at >= $2
+		  AND recorded_at < $3
+		  AND fusion_status = 'PRIMARY'
+		ORDER BY recorded_at`
+	err := r.db.SelectContext(ctx, &points, query, driverID, from, to)
+	return points, err
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/crypto"
 )
 
 // Helper to create a mock DB
@@ -58,6 +61,7 @@ func TestPostgresDriverRepository_Create(t *testing.T) {
 			driver.AvailableDriveMins, driver.AvailableDutyMins, driver.AvailableCycleMins, driver.LastHOSUpdate,
 			driver.HomeTerminalID, driver.HireDate, driver.AppUserID, driver.DeviceToken,
 			driver.CreatedAt, driver.UpdatedAt,
+			driver.MedicalCardNumber, driver.MedicalRestrictions, "",
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -321,6 +325,267 @@ func TestPostgresDriverRepository_GetExpiringDocuments(t *testing.T) {
 	}
 }
 
+// sealedArg matches a PII column argument that was encrypted from want
+type sealedArg struct {
+	enc  *crypto.FieldEncryptor
+	want string
+}
+
+func (a sealedArg) Match(v sqldriver.Value) bool {
+	s, ok := v.(string)
+	if !ok || !crypto.IsEncrypted(s) {
+		return false
+	}
+	plaintext, err := a.enc.Decrypt(context.Background(), s)
+	return err == nil && plaintext == a.want
+}
+
+func newTestEncryptor(t *testing.T, spec string) *crypto.FieldEncryptor {
+	t.Helper()
+	keys, err := crypto.ParseKeyRing(spec)
+	if err != nil {
+		t.Fatalf("failed to parse key ring: %v", err)
+	}
+	return crypto.NewFieldEncryptor(keys)
+}
+
+func newTestPhoneIndex(t *testing.T) *crypto.BlindIndex {
+	t.Helper()
+	idx, err := crypto.NewBlindIndexFromBase64(testIndexKey)
+	if err != nil {
+		t.Fatalf("failed to create blind index: %v", err)
+	}
+	return idx
+}
+
+const (
+	testKeyV1    = "v1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	testKeyV2    = "v2:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+	testIndexKey = "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+)
+
+func TestPostgresDriverRepository_Create_EncryptsPII(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	enc := newTestEncryptor(t, testKeyV1)
+	repo := NewEncryptedDriverRepository(db, enc, newTestPhoneIndex(t))
+
+	driver := &domain.Driver{
+		ID:                  uuid.New(),
+		EmployeeNumber:      "EMP001",
+		FirstName:           "John",
+		LastName:            "Doe",
+		Email:               "john.doe@example.com",
+		Phone:               "555-123-4567",
+		Status:              domain.DriverStatusAvailable,
+		LicenseNumber:       "DL12345",
+		LicenseState:        "CA",
+		LicenseClass:        "A",
+		MedicalCardNumber:   "MED-998877",
+		MedicalRestrictions: "Corrective lenses",
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	args := make([]sqlmock.Argument, 35)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	args[4] = sealedArg{enc, driver.Email}
+	args[5] = sealedArg{enc, driver.Phone}
+	args[7] = sealedArg{enc, driver.LicenseNumber}
+	args[32] = sealedArg{enc, driver.MedicalCardNumber}
+	args[33] = sealedArg{enc, driver.MedicalRestrictions}
+	driverArgs := make([]sqldriver.Value, len(args))
+	for i, a := range args {
+		driverArgs[i] = a
+	}
+	driverArgs[34] = newTestPhoneIndex(t).Sum("+15551234567")
+
+	mock.ExpectExec("INSERT INTO drivers").
+		WithArgs(driverArgs...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := repo.Create(context.Background(), driver); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if driver.LicenseNumber != "DL12345" {
+		t.Errorf("Create must not modify the caller's driver, license is %q", driver.LicenseNumber)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDriverRepository_GetByID_DecryptsPII(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	enc := newTestEncryptor(t, testKeyV1)
+	repo := NewEncryptedDriverRepository(db, enc, newTestPhoneIndex(t))
+	driverID := uuid.New()
+
+	sealedLicense, _ := enc.Encrypt(context.Background(), "DL12345")
+	sealedMedical, _ := enc.Encrypt(context.Background(), "MED-998877")
+
+	rows := sqlmock.NewRows([]string{
+		"id", "first_name", "email", "phone", "license_number", "medical_card_number",
+	}).AddRow(
+		// Phone predates encryption and is still plaintext
+		driverID, "John", "", "555-123-4567", sealedLicense, sealedMedical,
+	)
+
+	mock.ExpectQuery("SELECT \\* FROM drivers WHERE id = \\$1").
+		WithArgs(driverID).
+		WillReturnRows(rows)
+
+	driver, err := repo.GetByID(context.Background(), driverID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if driver.LicenseNumber != "DL12345" {
+		t.Errorf("expected decrypted license 'DL12345', got %q", driver.LicenseNumber)
+	}
+	if driver.MedicalCardNumber != "MED-998877" {
+		t.Errorf("expected decrypted medical card 'MED-998877', got %q", driver.MedicalCardNumber)
+	}
+	if driver.Phone != "555-123-4567" {
+		t.Errorf("expected legacy plaintext phone to pass through, got %q", driver.Phone)
+	}
+}
+
+func TestPostgresDriverRepository_RotatePII(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	oldEnc := newTestEncryptor(t, testKeyV1)
+	enc := newTestEncryptor(t, testKeyV2+","+testKeyV1)
+	repo := NewEncryptedDriverRepository(db, enc, newTestPhoneIndex(t))
+	driverID := uuid.New()
+
+	sealedLicense, _ := oldEnc.Encrypt(context.Background(), "DL12345")
+
+	rows := sqlmock.NewRows([]string{
+		"id", "email", "phone", "license_number", "medical_card_number", "medical_restrictions",
+	}).AddRow(driverID, "", "555-123-4567", sealedLicense, "", "")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, COALESCE\\(email(.|\\n)*phone_index = ''(.|\\n)*FOR UPDATE SKIP LOCKED").
+		WithArgs("enc:v1:v2:%", 100).
+		WillReturnRows(rows)
+	mock.ExpectExec("UPDATE drivers SET").
+		WithArgs(driverID, "", sealedArg{enc, "555-123-4567"}, sealedArg{enc, "DL12345"}, "", "",
+			newTestPhoneIndex(t).Sum("+15551234567")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rotated, err := repo.RotatePII(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rotated != 1 {
+		t.Errorf("expected 1 driver rotated, got %d", rotated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDriverRepository_GetByPhone(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	enc := newTestEncryptor(t, testKeyV1)
+	idx := newTestPhoneIndex(t)
+	repo := NewEncryptedDriverRepository(db, enc, idx)
+	driverID := uuid.New()
+
+	sealedPhone, _ := enc.Encrypt(context.Background(), "555-123-4567")
+	rows := sqlmock.NewRows([]string{"id", "phone", "phone_index"}).
+		AddRow(driverID, sealedPhone, idx.Sum("+15551234567"))
+
+	// An inbound SMS arrives in E.164 while the driver was saved as entered
+	mock.ExpectQuery("SELECT \\* FROM drivers WHERE phone_index = \\$1").
+		WithArgs(idx.Sum("+15551234567")).
+		WillReturnRows(rows)
+
+	driver, err := repo.GetByPhone(context.Background(), "+15551234567")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if driver == nil || driver.ID != driverID {
+		t.Fatalf("expected driver %s, got %+v", driverID, driver)
+	}
+	if driver.Phone != "555-123-4567" {
+		t.Errorf("expected decrypted phone, got %q", driver.Phone)
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM drivers WHERE phone_index").WillReturnError(sql.ErrNoRows)
+	if driver, err := repo.GetByPhone(context.Background(), "(555) 000-0000"); err != nil || driver != nil {
+		t.Errorf("expected no driver for an unknown phone, got %+v, %v", driver, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDriverRepository_GetByPhone_Plaintext(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresDriverRepository(db)
+	rows := sqlmock.NewRows([]string{"id", "phone"}).AddRow(uuid.New(), "555-123-4567")
+
+	mock.ExpectQuery("regexp_replace\\(phone").
+		WithArgs("15551234567", "5551234567").
+		WillReturnRows(rows)
+
+	driver, err := repo.GetByPhone(context.Background(), "+1 (555) 123-4567")
+	if err != nil || driver == nil {
+		t.Fatalf("expected a driver, got %+v, %v", driver, err)
+	}
+	if driver, err := repo.GetByPhone(context.Background(), ""); err != nil || driver != nil {
+		t.Errorf("expected no lookup for an empty phone, got %+v, %v", driver, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresDriverRepository_RotatePII_RollsBackOnError(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	oldEnc := newTestEncryptor(t, testKeyV1)
+	enc := newTestEncryptor(t, testKeyV2+","+testKeyV1)
+	repo := NewEncryptedDriverRepository(db, enc, newTestPhoneIndex(t))
+
+	sealedLicense, _ := oldEnc.Encrypt(context.Background(), "DL12345")
+	rows := sqlmock.NewRows([]string{
+		"id", "email", "phone", "license_number", "medical_card_number", "medical_restrictions",
+	}).
+		AddRow(uuid.New(), "", "", sealedLicense, "", "").
+		AddRow(uuid.New(), "", "", sealedLicense, "", "")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, COALESCE\\(email").WillReturnRows(rows)
+	mock.ExpectExec("UPDATE drivers SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE drivers SET").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	rotated, err := repo.RotatePII(context.Background(), 100)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if rotated != 0 {
+		t.Errorf("expected the batch to be rolled back with 0 rotated, got %d", rotated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
 // ============================================================================
 // PostgresHOSLogRepository Tests
 // ============================================================================
@@ -650,7 +915,7 @@ func TestPostgresDocumentRepository_Create(t *testing.T) {
 		MimeType:   "application/pdf",
 		ExpiresAt:  timePtr(time.Now().Add(365 * 24 * time.Hour)),
 		UploadedAt: time.Now(),
-		UploadedBy: uuid.New(),
+		UploadedBy: "admin@draymaster.com",
 	}
 
 	mock.ExpectExec("INSERT INTO driver_documents").
@@ -737,6 +1002,209 @@ func TestPostgresDocumentRepository_Delete(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// PostgresHOSWarningRepository Tests
+// ============================================================================
+
+func TestPostgresHOSWarningRepository_Create(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresHOSWarningRepository(db)
+
+	now := time.Now()
+	warning := &domain.HOSWarning{
+		ID:            uuid.New(),
+		DriverID:      uuid.New(),
+		Clock:         domain.HOSClockDrive,
+		ThresholdMins: 30,
+		RemainingMins: 28,
+		LimitAt:       now.Add(28 * time.Minute),
+		WindowStart:   now.Truncate(24 * time.Hour),
+		Delivered:     true,
+		CreatedAt:     now,
+	}
+
+	mock.ExpectExec("INSERT INTO hos_warnings").
+		WithArgs(
+			warning.ID, warning.DriverID, warning.Clock, warning.ThresholdMins, warning.RemainingMins,
+			warning.LimitAt, warning.WindowStart, warning.Delivered, warning.DeliveryError, warning.CreatedAt,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), warning)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPostgresHOSWarningRepository_GetByDriverSince(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresHOSWarningRepository(db)
+	driverID := uuid.New()
+	since := time.Now().Truncate(24 * time.Hour)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "driver_id", "clock", "threshold_mins", "remaining_mins", "delivered",
+	}).
+		AddRow(uuid.New(), driverID, "DRIVE", 120, 118, true).
+		AddRow(uuid.New(), driverID, "DUTY", 60, 55, false)
+
+	mock.ExpectQuery("SELECT \\* FROM hos_warnings").
+		WithArgs(driverID, since).
+		WillReturnRows(rows)
+
+	warnings, err := repo.GetByDriverSince(context.Background(), driverID, since)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(warnings))
+	}
+	if warnings[1].Clock != domain.HOSClockDuty {
+		t.Errorf("expected second warning clock DUTY, got %s", warnings[1].Clock)
+	}
+}
+
+// ============================================================================
+// PostgresTractorSessionRepository Tests
+// ============================================================================
+
+func TestPostgresTractorSessionRepository_Create(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTractorSessionRepository(db)
+
+	now := time.Now()
+	session := &domain.TractorSession{
+		ID:        uuid.New(),
+		TractorID: uuid.New(),
+		DriverID:  uuid.New(),
+		Seat:      domain.SeatDriver,
+		Team:      true,
+		Source:    "app",
+		StartedAt: now,
+		CreatedAt: now,
+	}
+
+	mock.ExpectExec("INSERT INTO tractor_sessions").
+		WithArgs(
+			session.ID, session.TractorID, session.DriverID, session.Seat, session.Team, session.Source,
+			session.StartedAt, session.EndedAt, session.EndReason, session.CreatedAt,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), session)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPostgresTractorSessionRepository_End(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTractorSessionRepository(db)
+	id := uuid.New()
+	endedAt := time.Now()
+
+	mock.ExpectExec("UPDATE tractor_sessions SET ended_at").
+		WithArgs(id, endedAt, domain.SessionEndLogout).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.End(context.Background(), id, endedAt, domain.SessionEndLogout)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPostgresTractorSessionRepository_GetByTractorAt(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTractorSessionRepository(db)
+	tractorID := uuid.New()
+	at := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "tractor_id", "driver_id", "seat", "team"}).
+		AddRow(uuid.New(), tractorID, uuid.New(), "DRIVER", true).
+		AddRow(uuid.New(), tractorID, uuid.New(), "CO_DRIVER", true)
+
+	mock.ExpectQuery("SELECT \\* FROM tractor_sessions").
+		WithArgs(tractorID, at).
+		WillReturnRows(rows)
+
+	sessions, err := repo.GetByTractorAt(context.Background(), tractorID, at)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	if sessions[1].Seat != domain.SeatCoDriver {
+		t.Errorf("expected second session seat CO_DRIVER, got %s", sessions[1].Seat)
+	}
+}
+
+// ============================================================================
+// PostgresDrivingSegmentRepository Tests
+// ============================================================================
+
+func TestPostgresDrivingSegmentRepository_GetByExternalID_NotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresDrivingSegmentRepository(db)
+	tractorID := uuid.New()
+
+	mock.ExpectQuery("SELECT \\* FROM eld_driving_segments").
+		WithArgs(tractorID, "evt-1").
+		WillReturnError(sql.ErrNoRows)
+
+	segment, err := repo.GetByExternalID(context.Background(), tractorID, "evt-1")
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if segment != nil {
+		t.Error("expected nil segment")
+	}
+}
+
+func TestPostgresDrivingSegmentRepository_GetPendingReview(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresDrivingSegmentRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "tractor_id", "attribution", "note"}).
+		AddRow(uuid.New(), uuid.New(), "CONFLICT", "overlapping driver sessions").
+		AddRow(uuid.New(), uuid.New(), "UNASSIGNED", "no driver logged on")
+
+	mock.ExpectQuery("SELECT \\* FROM eld_driving_segments").
+		WillReturnRows(rows)
+
+	segments, err := repo.GetPendingReview(context.Background())
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(segments))
+	}
+	if !segments[0].NeedsReview() || !segments[1].NeedsReview() {
+		t.Error("expected both segments to need review")
+	}
+}
+
 // ============================================================================
 // Repository Constructor Tests
 // ============================================================================
@@ -14,6 +14,7 @@ type DriverRepository interface {
 	Create(ctx context.Context, driver *domain.Driver) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Driver, error)
 	GetByEmployeeNumber(ctx context.Context, employeeNumber string) (*domain.Driver, error)
+	GetByPhone(ctx context.Context, phone string) (*domain.Driver, error)
 	GetAll(ctx context.Context) ([]domain.Driver, error)
 	GetByStatus(ctx context.Context, status domain.DriverStatus) ([]domain.Driver, error)
 	GetAvailable(ctx context.Context) ([]domain.Driver, error)
@@ -66,3 +67,51 @@ type DocumentRepository interface {
 	Update(ctx context.Context, doc *domain.DriverDocument) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
+
+// HOSWarningRepository defines HOS countdown warning data access methods
+type HOSWarningRepository interface {
+	Create(ctx context.Context, warning *domain.HOSWarning) error
+	GetByDriverSince(ctx context.Context, driverID uuid.UUID, since time.Time) ([]domain.HOSWarning, error)
+}
+
+// TractorSessionRepository defines tractor login session data access methods
+type TractorSessionRepository interface {
+	Create(ctx context.Context, session *domain.TractorSession) error
+	End(ctx context.Context, id uuid.UUID, endedAt time.Time, reason string) error
+	GetActiveByTractor(ctx context.Context, tractorID uuid.UUID) ([]domain.TractorSession, error)
+	GetActiveByDriver(ctx context.Context, driverID uuid.UUID) ([]domain.TractorSession, error)
+	// GetByTractorAt returns the sessions logged on to the tractor at the given time
+	GetByTractorAt(ctx context.Context, tractorID uuid.UUID, at time.Time) ([]domain.TractorSession, error)
+}
+
+// DrivingSegmentRepository defines ELD driving segment attribution data access methods
+type DrivingSegmentRepository interface {
+	Create(ctx context.Context, segment *domain.DrivingSegment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.DrivingSegment, error)
+	GetByExternalID(ctx context.Context, tractorID uuid.UUID, externalID string) (*domain.DrivingSegment, error)
+	// GetPendingReview returns unassigned and conflicting segments, oldest first
+	GetPendingReview(ctx context.Context) ([]domain.DrivingSegment, error)
+	// GetByTimeRange returns the segments that started between start and end
+	GetByTimeRange(ctx context.Context, start, end time.Time) ([]domain.DrivingSegment, error)
+	Update(ctx context.Context, segment *domain.DrivingSegment) error
+}
+
+// PriorDutyRepository defines data access for a new hire's prior-carrier duty
+// days. Upsert replaces the driver's existing entry for the same date.
+type PriorDutyRepository interface {
+	Upsert(ctx context.Context, day *domain.PriorDutyDay) error
+	GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.PriorDutyDay, error)
+}
+
+// HOSCertificationRepository defines data access for drivers' daily log
+// certifications. Certify replaces the driver's certification of the same day.
+type HOSCertificationRepository interface {
+	Certify(ctx context.Context, cert *domain.HOSLogCertification) error
+	GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.HOSLogCertification, error)
+}
+
+// LocationHistoryRepository reads the GPS positions tracking-service records
+// for drivers
+type LocationHistoryRepository interface {
+	GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.LocationPoint, error)
+}
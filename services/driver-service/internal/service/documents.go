@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/services/driver-service/internal/repository"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+// DocumentService keeps driver documents - licenses, medical cards, TWIC and
+// hazmat certificates - in blob storage, with their record in the database
+type DocumentService struct {
+	drivers      *DriverService
+	documentRepo repository.DocumentRepository
+	store        storage.Store
+	logger       *logger.Logger
+}
+
+// NewDocumentService creates a new driver document service
+func NewDocumentService(
+	drivers *DriverService,
+	documentRepo repository.DocumentRepository,
+	store storage.Store,
+	log *logger.Logger,
+) *DocumentService {
+	return &DocumentService{
+		drivers:      drivers,
+		documentRepo: documentRepo,
+		store:        store,
+		logger:       log,
+	}
+}
+
+// UploadDocumentInput contains input for uploading a driver document
+type UploadDocumentInput struct {
+	DriverID   uuid.UUID
+	Type       string // license, medical_card, twic, hazmat_cert, etc.
+	FileName   string
+	MimeType   string
+	Size       int64 // Body length when known
+	ExpiresAt  *time.Time
+	UploadedBy string
+}
+
+// UploadDocument streams the file to storage and records it against the driver
+func (s *DocumentService) UploadDocument(ctx context.Context, input UploadDocumentInput, body io.Reader) (*domain.DriverDocument, error) {
+	docType := strings.TrimSpace(input.Type)
+	if docType == "" {
+		return nil, fmt.Errorf("document type is required")
+	}
+	fileName := path.Base(strings.ReplaceAll(strings.TrimSpace(input.FileName), "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return nil, fmt.Errorf("file name is required")
+	}
+	driver, err := s.drivers.GetDriver(ctx, input.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, fmt.Errorf("driver not found: %s", input.DriverID)
+	}
+
+	doc := &domain.DriverDocument{
+		ID:         uuid.New(),
+		DriverID:   input.DriverID,
+		Type:       docType,
+		FileName:   fileName,
+		MimeType:   input.MimeType,
+		ExpiresAt:  input.ExpiresAt,
+		UploadedAt: time.Now(),
+		UploadedBy: input.UploadedBy,
+	}
+	doc.FilePath = storage.Key("drivers", doc.DriverID.String(), doc.ID.String(), fileName)
+
+	obj, err := s.store.Put(ctx, doc.FilePath, body, storage.PutOptions{
+		ContentType: input.MimeType,
+		Size:        input.Size,
+		Metadata: map[string]string{
+			"driver-id": doc.DriverID.String(),
+			"type":      docType,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+	doc.FileSize = int(obj.Size)
+
+	if err := s.documentRepo.Create(ctx, doc); err != nil {
+		// Don't leave an orphaned file behind
+		if delErr := s.store.Delete(ctx, doc.FilePath); delErr != nil && s.logger != nil {
+			s.logger.Warnw("Failed to remove orphaned document file", "key", doc.FilePath, "error", delErr)
+		}
+		return nil, fmt.Errorf("failed to record document: %w", err)
+	}
+
+	if s.logger != nil {
+		s.logger.Infow("Driver document uploaded",
+			"driver_id", doc.DriverID,
+			"document_id", doc.ID,
+			"type", doc.Type,
+			"size", doc.FileSize,
+		)
+	}
+	return doc, nil
+}
+
+// ListDocuments returns the driver's documents
+func (s *DocumentService) ListDocuments(ctx context.Context, driverID uuid.UUID) ([]domain.DriverDocument, error) {
+	return s.documentRepo.GetByDriverID(ctx, driverID)
+}
+
+// OpenDocument returns the document record and a reader for its file. The
+// caller closes the reader.
+func (s *DocumentService) OpenDocument(ctx context.Context, id uuid.UUID) (*domain.DriverDocument, io.ReadCloser, error) {
+	doc, err := s.documentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("document not found: %s", id)
+	}
+	body, _, err := s.store.Get(ctx, doc.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open document %s: %w", id, err)
+	}
+	return doc, body, nil
+}
+
+// DeleteDocument removes the document's file and its record
+func (s *DocumentService) DeleteDocument(ctx context.Context, id uuid.UUID) error {
+	doc, err := s.documentRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return fmt.Errorf("document not found: %s", id)
+	}
+	if err := s.store.Delete(ctx, doc.FilePath); err != nil {
+		return fmt.Errorf("failed to delete document file: %w", err)
+	}
+	return s.documentRepo.Delete(ctx, id)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+func createTestDocumentService(t *testing.T) (*DocumentService, *mockDriverRepo, *mockDocumentRepo, storage.Store) {
+	t.Helper()
+	store, err := storage.NewLocalStore(config.StorageConfig{LocalRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	drivers, driverRepo, _, _, _ := createTestService()
+	documentRepo := newMockDocumentRepo()
+	return NewDocumentService(drivers, documentRepo, store, nil), driverRepo, documentRepo, store
+}
+
+func TestDocumentService_UploadAndOpen(t *testing.T) {
+	svc, driverRepo, documentRepo, store := createTestDocumentService(t)
+	ctx := context.Background()
+	driver := &domain.Driver{ID: uuid.New(), FirstName: "Test", LastName: "Driver"}
+	driverRepo.drivers[driver.ID] = driver
+
+	doc, err := svc.UploadDocument(ctx, UploadDocumentInput{
+		DriverID:   driver.ID,
+		Type:       "medical_card",
+		FileName:   `C:\scans\medcard.pdf`,
+		MimeType:   "application/pdf",
+		UploadedBy: "dispatcher-1",
+	}, strings.NewReader("%PDF-1.4 card"))
+	if err != nil {
+		t.Fatalf("UploadDocument() error = %v", err)
+	}
+
+	if doc.FileName != "medcard.pdf" {
+		t.Errorf("FileName = %q, want medcard.pdf", doc.FileName)
+	}
+	wantKey := "drivers/" + driver.ID.String() + "/" + doc.ID.String() + "/medcard.pdf"
+	if doc.FilePath != wantKey {
+		t.Errorf("FilePath = %q, want %q", doc.FilePath, wantKey)
+	}
+	if doc.FileSize != len("%PDF-1.4 card") {
+		t.Errorf("FileSize = %d, want %d", doc.FileSize, len("%PDF-1.4 card"))
+	}
+	if _, ok := documentRepo.documents[doc.ID]; !ok {
+		t.Error("Document was not recorded")
+	}
+
+	opened, body, err := svc.OpenDocument(ctx, doc.ID)
+	if err != nil {
+		t.Fatalf("OpenDocument() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "%PDF-1.4 card" || opened.ID != doc.ID {
+		t.Errorf("OpenDocument() returned %q for %s", data, opened.ID)
+	}
+
+	if err := svc.DeleteDocument(ctx, doc.ID); err != nil {
+		t.Fatalf("DeleteDocument() error = %v", err)
+	}
+	if _, err := store.Stat(ctx, doc.FilePath); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Stat() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDocumentService_UploadRejected(t *testing.T) {
+	svc, driverRepo, _, store := createTestDocumentService(t)
+	ctx := context.Background()
+	driver := &domain.Driver{ID: uuid.New()}
+	driverRepo.drivers[driver.ID] = driver
+
+	tests := []struct {
+		name  string
+		input UploadDocumentInput
+	}{
+		{
+			name:  "Missing type",
+			input: UploadDocumentInput{DriverID: driver.ID, FileName: "license.jpg"},
+		},
+		{
+			name:  "Missing file name",
+			input: UploadDocumentInput{DriverID: driver.ID, Type: "license"},
+		},
+		{
+			name:  "Unknown driver",
+			input: UploadDocumentInput{DriverID: uuid.New(), Type: "license", FileName: "license.jpg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UploadDocument(ctx, tt.input, strings.NewReader("image")); err == nil {
+				t.Error("UploadDocument() expected error")
+			}
+		})
+	}
+
+	objects, err := store.List(ctx, "drivers/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("Rejected uploads stored %d files", len(objects))
+	}
+}
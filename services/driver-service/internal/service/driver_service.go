@@ -9,6 +9,7 @@ import (
 
 	"github.com/draymaster/services/driver-service/internal/domain"
 	"github.com/draymaster/services/driver-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
 	"github.com/draymaster/shared/pkg/kafka"
 	"github.com/draymaster/shared/pkg/logger"
 )
@@ -20,8 +21,11 @@ type DriverService struct {
 	violationRepo  repository.ViolationRepository
 	alertRepo      repository.ComplianceAlertRepository
 	documentRepo   repository.DocumentRepository
+	priorDutyRepo  repository.PriorDutyRepository
 	eventProducer  *kafka.Producer
 	logger         *logger.Logger
+	documentRules  *config.DocumentRules
+	pcRules        *config.PersonalConveyanceRules
 }
 
 // NewDriverService creates a new driver service
@@ -31,6 +35,7 @@ func NewDriverService(
 	violationRepo repository.ViolationRepository,
 	alertRepo repository.ComplianceAlertRepository,
 	documentRepo repository.DocumentRepository,
+	priorDutyRepo repository.PriorDutyRepository,
 	eventProducer *kafka.Producer,
 	log *logger.Logger,
 ) *DriverService {
@@ -40,8 +45,11 @@ func NewDriverService(
 		violationRepo: violationRepo,
 		alertRepo:     alertRepo,
 		documentRepo:  documentRepo,
+		priorDutyRepo: priorDutyRepo,
 		eventProducer: eventProducer,
 		logger:        log,
+		documentRules: &config.DefaultBusinessRules().Documents,
+		pcRules:       &config.DefaultBusinessRules().PersonalConveyance,
 	}
 }
 
@@ -68,6 +76,8 @@ func (s *DriverService) CreateDriver(ctx context.Context, input CreateDriverInpu
 		HasHazmatEndorsement: input.HasHazmatEndorsement,
 		HazmatExpiration:     input.HazmatExpiration,
 		MedicalCardExpiration: input.MedicalCardExpiration,
+		MedicalCardNumber:    input.MedicalCardNumber,
+		MedicalRestrictions:  input.MedicalRestrictions,
 		HomeTerminalID:       input.HomeTerminalID,
 		HireDate:             input.HireDate,
 		// Initialize HOS with max available time
@@ -118,6 +128,8 @@ type CreateDriverInput struct {
 	HasHazmatEndorsement  bool
 	HazmatExpiration      *time.Time
 	MedicalCardExpiration *time.Time
+	MedicalCardNumber     string
+	MedicalRestrictions   string
 	HomeTerminalID        *uuid.UUID
 	HireDate              *time.Time
 }
@@ -127,13 +139,16 @@ func (s *DriverService) GetDriver(ctx context.Context, id uuid.UUID) (*domain.Dr
 	return s.driverRepo.GetByID(ctx, id)
 }
 
-// GetAvailableDrivers retrieves drivers who are available for dispatch
-func (s *DriverService) GetAvailableDrivers(ctx context.Context, requiredMins int, needsHazmat, needsTWIC bool) ([]domain.Driver, error) {
+// GetAvailableDrivers retrieves drivers who are available for dispatch. When
+// the terminal admits escorted drivers, those without a valid TWIC are kept;
+// dispatch attaches the escort fee when one is assigned.
+func (s *DriverService) GetAvailableDrivers(ctx context.Context, requiredMins int, needsHazmat, needsTWIC, escortAllowed bool) ([]domain.Driver, error) {
 	drivers, err := s.driverRepo.GetAvailable(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	var available []domain.Driver
 	for _, driver := range drivers {
 		// Check HOS availability
@@ -141,8 +156,15 @@ func (s *DriverService) GetAvailableDrivers(ctx context.Context, requiredMins in
 			continue
 		}
 
-		// Check compliance
-		if !driver.IsCompliant() {
+		// Check expired documents against their enforcement tier
+		restrictions := s.documentRules.Restrictions(documentExpirations(&driver), now)
+		if len(restrictions[config.DutyRestrictionOutOfService]) > 0 {
+			continue
+		}
+		if needsTWIC && !escortAllowed && len(restrictions[config.DutyRestrictionNonPort]) > 0 {
+			continue
+		}
+		if needsHazmat && len(restrictions[config.DutyRestrictionNoHazmat]) > 0 {
 			continue
 		}
 
@@ -150,7 +172,7 @@ func (s *DriverService) GetAvailableDrivers(ctx context.Context, requiredMins in
 		if needsHazmat && !driver.HasHazmatEndorsement {
 			continue
 		}
-		if needsTWIC && !driver.HasTWIC {
+		if needsTWIC && !escortAllowed && !driver.HasTWIC {
 			continue
 		}
 
@@ -160,6 +182,30 @@ func (s *DriverService) GetAvailableDrivers(ctx context.Context, requiredMins in
 	return available, nil
 }
 
+// GetDutyRestrictions returns the restrictions in effect from a driver's expired documents
+func (s *DriverService) GetDutyRestrictions(ctx context.Context, driverID uuid.UUID) (map[config.DutyRestriction][]string, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	return s.documentRules.Restrictions(documentExpirations(driver), time.Now()), nil
+}
+
+// documentExpirations returns the expiration of each document the driver holds
+func documentExpirations(driver *domain.Driver) map[string]*time.Time {
+	expirations := map[string]*time.Time{
+		config.DocumentTypeCDL:         driver.LicenseExpiration,
+		config.DocumentTypeMedicalCard: driver.MedicalCardExpiration,
+	}
+	if driver.HasTWIC {
+		expirations[config.DocumentTypeTWIC] = driver.TWICExpiration
+	}
+	if driver.HasHazmatEndorsement {
+		expirations[config.DocumentTypeHazmat] = driver.HazmatExpiration
+	}
+	return expirations
+}
+
 // UpdateDriverStatus updates driver status
 func (s *DriverService) UpdateDriverStatus(ctx context.Context, driverID uuid.UUID, status domain.DriverStatus) error {
 	return s.driverRepo.UpdateStatus(ctx, driverID, status)
@@ -262,8 +308,16 @@ func (s *DriverService) GetHOSSummary(ctx context.Context, driverID uuid.UUID, d
 			summary.DrivingMins += duration
 		case domain.HOSStatusOnDutyNotDriv:
 			summary.OnDutyMins += duration
+		case domain.HOSStatusYardMove:
+			// Yard moves are on-duty time but not driving time
+			summary.OnDutyMins += duration
+			summary.YardMoveMins += duration
 		case domain.HOSStatusOffDuty:
 			summary.OffDutyMins += duration
+		case domain.HOSStatusPersonalConveyance:
+			// Personal conveyance is recorded as off-duty time
+			summary.OffDutyMins += duration
+			summary.PersonalConveyanceMins += duration
 		case domain.HOSStatusSleeperBerth:
 			summary.SleeperMins += duration
 		}
@@ -289,25 +343,31 @@ func (s *DriverService) GetDriverLogs(ctx context.Context, driverID uuid.UUID, s
 	return s.hosLogRepo.GetByDriverID(ctx, driverID, startTime, endTime)
 }
 
-// CalculateAvailableTime calculates remaining available drive/duty time
+// CalculateAvailableTime calculates remaining available drive/duty time.
+// The 11- and 14-hour clocks run from the start of the duty period, which
+// a completed 7/3 or 8/2 sleeper berth split moves forward, and the 70-hour
+// cycle is counted from the end of any 34-hour restart in the last 8 days.
 func (s *DriverService) CalculateAvailableTime(ctx context.Context, driverID uuid.UUID) (*AvailableTime, error) {
 	driver, err := s.driverRepo.GetByID(ctx, driverID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get current day's logs
 	now := time.Now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	logs, err := s.hosLogRepo.GetByDriverID(ctx, driverID, startOfDay, now)
+	logs, err := s.hosLogRepo.GetByDriverID(ctx, driverID, now.AddDate(0, 0, -domain.HOSCycleDays), now)
 	if err != nil {
 		return nil, err
 	}
+	standing := domain.ComputeHOSStanding(logs, now)
 
 	// Calculate today's usage
-	var drivingMins, onDutyMins int
+	var drivingMins, onDutyMins, personalConveyanceMins, yardMoveMins int
 	for _, log := range logs {
+		if log.StartTime.Before(startOfDay) {
+			continue
+		}
 		duration := log.DurationMins
 		if duration == 0 && log.EndTime == nil {
 			duration = int(time.Since(log.StartTime).Minutes())
@@ -317,11 +377,17 @@ func (s *DriverService) CalculateAvailableTime(ctx context.Context, driverID uui
 			drivingMins += duration
 		case domain.HOSStatusOnDutyNotDriv:
 			onDutyMins += duration
+		case domain.HOSStatusYardMove:
+			onDutyMins += duration
+			yardMoveMins += duration
+		case domain.HOSStatusPersonalConveyance:
+			personalConveyanceMins += duration
 		}
 	}
 
-	// Calculate 8-day cycle
-	cycleMins, _ := s.getCycleDutyMins(ctx, driverID)
+	// Prior-carrier hours count toward the cycle unless a restart came after them
+	priorMins, _ := s.getPriorDutyMins(ctx, driverID, standing.CycleStart, now)
+	cycleMins := standing.CycleDutyMins + priorMins
 
 	// Check 30-minute break requirement
 	needsBreak := s.needsBreak(logs)
@@ -334,12 +400,20 @@ func (s *DriverService) CalculateAvailableTime(ctx context.Context, driverID uui
 
 	available := &AvailableTime{
 		DriverID:             driverID,
-		AvailableDriveMins:   max(0, 660-drivingMins),
-		AvailableDutyMins:    max(0, 840-(drivingMins+onDutyMins)),
-		AvailableCycleMins:   max(0, 4200-cycleMins),
+		AvailableDriveMins:   standing.AvailableDrivingMins(),
+		AvailableDutyMins:    standing.AvailableWindowMins(),
+		AvailableCycleMins:   max(0, domain.HOSCycleLimitMins-cycleMins),
 		TodayDrivingMins:     drivingMins,
 		TodayOnDutyMins:      onDutyMins,
+		TodayPersonalConveyanceMins: personalConveyanceMins,
+		TodayYardMoveMins:    yardMoveMins,
+		PeriodStart:          standing.PeriodStart,
+		PeriodDrivingMins:    standing.DrivingMins,
+		PeriodWindowMins:     standing.WindowMins,
+		DrivingAfterWindowMins: standing.DrivingAfterWindowMins,
+		SleeperSplit:         standing.SleeperSplit,
 		CycleDutyMins:        cycleMins,
+		LastRestartAt:        standing.LastRestartAt,
 		NeedsBreak:           needsBreak,
 		MinsUntilBreak:       minsUntilBreak,
 		LastResetTime:        driver.LastHOSUpdate,
@@ -358,7 +432,15 @@ type AvailableTime struct {
 	AvailableCycleMins   int        `json:"available_cycle_mins"`
 	TodayDrivingMins     int        `json:"today_driving_mins"`
 	TodayOnDutyMins      int        `json:"today_on_duty_mins"`
+	TodayPersonalConveyanceMins int `json:"today_personal_conveyance_mins"`
+	TodayYardMoveMins    int        `json:"today_yard_move_mins"`
+	PeriodStart          *time.Time `json:"period_start,omitempty"` // Start of the duty period the 11 and 14 hours count from
+	PeriodDrivingMins    int        `json:"period_driving_mins"`
+	PeriodWindowMins     int        `json:"period_window_mins"` // Used of the 14 hours; a paired sleeper split rest is left out
+	DrivingAfterWindowMins int      `json:"driving_after_window_mins"`
+	SleeperSplit         bool       `json:"sleeper_split"` // The period starts from a 7/3 or 8/2 sleeper berth split
 	CycleDutyMins        int        `json:"cycle_duty_mins"`
+	LastRestartAt        *time.Time `json:"last_restart_at,omitempty"` // End of a 34-hour restart within the last 8 days
 	NeedsBreak           bool       `json:"needs_break"`
 	MinsUntilBreak       int        `json:"mins_until_break"`
 	LastResetTime        *time.Time `json:"last_reset_time"`
@@ -366,6 +448,117 @@ type AvailableTime struct {
 	CalculatedAt         time.Time  `json:"calculated_at"`
 }
 
+// =============================================================================
+// SPECIAL DRIVING CATEGORIES
+// =============================================================================
+
+// SpecialStatusInput contains input for a driver-initiated special driving category toggle
+type SpecialStatusInput struct {
+	DriverID    uuid.UUID
+	Status      domain.HOSStatus // PERSONAL_CONVEYANCE or YARD_MOVE
+	Enable      bool
+	Latitude    float64
+	Longitude   float64
+	Location    string
+	Odometer    int
+	EngineHours float64
+	TractorID   *uuid.UUID
+	Notes       string
+}
+
+// SetSpecialDrivingStatus starts or ends personal conveyance or a yard move
+// on behalf of the driver from the mobile app
+func (s *DriverService) SetSpecialDrivingStatus(ctx context.Context, input SpecialStatusInput) (*domain.HOSLog, error) {
+	if !input.Status.IsSpecialDrivingCategory() {
+		return nil, fmt.Errorf("invalid special driving category: %s", input.Status)
+	}
+
+	driver, err := s.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.hosLogRepo.GetCurrentStatus(ctx, input.DriverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current HOS status: %w", err)
+	}
+
+	var status domain.HOSStatus
+	if input.Enable {
+		if err := validateSpecialStatusStart(driver, current, input); err != nil {
+			return nil, err
+		}
+		status = input.Status
+	} else {
+		if current == nil || current.Status != input.Status {
+			return nil, fmt.Errorf("driver is not in %s", input.Status)
+		}
+		status = specialStatusExit(input.Status)
+	}
+
+	tractorID := input.TractorID
+	if tractorID == nil {
+		tractorID = driver.CurrentTractorID
+	}
+
+	log, err := s.RecordHOSStatus(ctx, RecordHOSInput{
+		DriverID:    input.DriverID,
+		Status:      status,
+		StartTime:   time.Now(),
+		Location:    input.Location,
+		Latitude:    input.Latitude,
+		Longitude:   input.Longitude,
+		Odometer:    input.Odometer,
+		EngineHours: input.EngineHours,
+		TractorID:   tractorID,
+		Notes:       input.Notes,
+		Source:      "driver_app",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Special driving category toggled",
+		"driver_id", input.DriverID,
+		"category", input.Status,
+		"enabled", input.Enable,
+	)
+
+	return log, nil
+}
+
+// validateSpecialStatusStart checks whether a driver may enter a special driving category
+func validateSpecialStatusStart(driver *domain.Driver, current *domain.HOSLog, input SpecialStatusInput) error {
+	if current != nil && current.Status.IsSpecialDrivingCategory() {
+		return fmt.Errorf("driver is already in %s", current.Status)
+	}
+
+	switch input.Status {
+	case domain.HOSStatusPersonalConveyance:
+		// Personal conveyance cannot be used to advance a load
+		if driver.CurrentTripID != nil {
+			return fmt.Errorf("personal conveyance not allowed while assigned to a trip")
+		}
+		if current != nil && current.Status.CountsAsOnDuty() {
+			return fmt.Errorf("personal conveyance must start from off-duty status")
+		}
+	case domain.HOSStatusYardMove:
+		if input.TractorID == nil && driver.CurrentTractorID == nil {
+			return fmt.Errorf("yard move requires a tractor")
+		}
+	}
+
+	return nil
+}
+
+// specialStatusExit returns the duty status a driver returns to after a special category
+func specialStatusExit(status domain.HOSStatus) domain.HOSStatus {
+	if status == domain.HOSStatusYardMove {
+		return domain.HOSStatusOnDutyNotDriv
+	}
+	return domain.HOSStatusOffDuty
+}
+
 // =============================================================================
 // HOS VIOLATION CHECKING
 // =============================================================================
@@ -380,14 +573,14 @@ func (s *DriverService) checkHOSViolations(ctx context.Context, driverID uuid.UU
 	now := time.Now()
 
 	// Check 11-hour driving limit
-	if available.TodayDrivingMins > 660 {
+	if available.PeriodDrivingMins > domain.HOSDrivingLimitMins {
 		violation := &domain.HOSViolation{
 			ID:           uuid.New(),
 			DriverID:     driverID,
 			Type:         "11_HOUR",
 			OccurredAt:   now,
-			DurationMins: available.TodayDrivingMins - 660,
-			Description:  fmt.Sprintf("Exceeded 11-hour driving limit by %d minutes", available.TodayDrivingMins-660),
+			DurationMins: available.PeriodDrivingMins - domain.HOSDrivingLimitMins,
+			Description:  fmt.Sprintf("Exceeded 11-hour driving limit by %d minutes", available.PeriodDrivingMins-domain.HOSDrivingLimitMins),
 			CreatedAt:    now,
 		}
 		if err := s.violationRepo.Create(ctx, violation); err != nil {
@@ -396,16 +589,15 @@ func (s *DriverService) checkHOSViolations(ctx context.Context, driverID uuid.UU
 		s.publishViolationEvent(ctx, violation)
 	}
 
-	// Check 14-hour duty limit
-	totalDuty := available.TodayDrivingMins + available.TodayOnDutyMins
-	if totalDuty > 840 {
+	// Check 14-hour duty limit: no driving after the 14th hour of the period
+	if available.DrivingAfterWindowMins > 0 {
 		violation := &domain.HOSViolation{
 			ID:           uuid.New(),
 			DriverID:     driverID,
 			Type:         "14_HOUR",
 			OccurredAt:   now,
-			DurationMins: totalDuty - 840,
-			Description:  fmt.Sprintf("Exceeded 14-hour duty limit by %d minutes", totalDuty-840),
+			DurationMins: available.DrivingAfterWindowMins,
+			Description:  fmt.Sprintf("Drove %d minutes past the 14-hour duty window", available.DrivingAfterWindowMins),
 			CreatedAt:    now,
 		}
 		if err := s.violationRepo.Create(ctx, violation); err != nil {
@@ -431,6 +623,16 @@ func (s *DriverService) checkHOSViolations(ctx context.Context, driverID uuid.UU
 		s.publishViolationEvent(ctx, violation)
 	}
 
+	// The company personal conveyance allowance is policy, not HOS
+	if alert, err := s.checkPersonalConveyance(ctx, driverID, available.TodayPersonalConveyanceMins, now); err != nil {
+		s.logger.Errorw("Failed to create personal conveyance alert", "error", err)
+	} else if alert != nil {
+		s.logger.Warnw("Personal conveyance allowance exceeded",
+			"driver_id", driverID,
+			"personal_conveyance_mins", available.TodayPersonalConveyanceMins,
+		)
+	}
+
 	// Check 30-minute break requirement
 	if available.NeedsBreak {
 		violation := &domain.HOSViolation{
@@ -449,6 +651,45 @@ func (s *DriverService) checkHOSViolations(ctx context.Context, driverID uuid.UU
 	}
 }
 
+// personalConveyanceAlertType is the compliance alert raised when a driver
+// goes over the company's daily personal conveyance allowance
+const personalConveyanceAlertType = "personal_conveyance_limit"
+
+// checkPersonalConveyance raises a warning alert, once a day, when today's
+// personal conveyance is over the company allowance. It returns the alert
+// it created, if any.
+func (s *DriverService) checkPersonalConveyance(ctx context.Context, driverID uuid.UUID, todayMins int, now time.Time) (*domain.ComplianceAlert, error) {
+	limit := s.pcRules.MaxMinsPerDay
+	if limit <= 0 || todayMins <= limit {
+		return nil, nil
+	}
+
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	existing, err := s.alertRepo.GetByDriverID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range existing {
+		if a.Type == personalConveyanceAlertType && a.ExpiresAt.Equal(endOfDay) {
+			return nil, nil
+		}
+	}
+
+	alert := &domain.ComplianceAlert{
+		ID:        uuid.New(),
+		DriverID:  driverID,
+		Type:      personalConveyanceAlertType,
+		Severity:  "warning",
+		Message:   fmt.Sprintf("Personal conveyance today is %d minutes, over the %d-minute company allowance", todayMins, limit),
+		ExpiresAt: endOfDay,
+		CreatedAt: now,
+	}
+	if err := s.alertRepo.Create(ctx, alert); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
 func (s *DriverService) publishViolationEvent(ctx context.Context, violation *domain.HOSViolation) {
 	event := kafka.NewEvent(kafka.Topics.HOSViolation, "driver-service", map[string]interface{}{
 		"driver_id":      violation.DriverID.String(),
@@ -554,28 +795,22 @@ func (s *DriverService) recalculateHOS(ctx context.Context, driverID uuid.UUID)
 	)
 }
 
+// getCycleDutyMins sums on-duty time in the 8-day cycle, counted from the
+// end of a 34-hour restart when the driver took one
 func (s *DriverService) getCycleDutyMins(ctx context.Context, driverID uuid.UUID) (int, error) {
-	// Get last 8 days of logs
 	now := time.Now()
-	startTime := now.AddDate(0, 0, -8)
-
-	logs, err := s.hosLogRepo.GetByDriverID(ctx, driverID, startTime, now)
+	logs, err := s.hosLogRepo.GetByDriverID(ctx, driverID, now.AddDate(0, 0, -domain.HOSCycleDays), now)
 	if err != nil {
 		return 0, err
 	}
+	standing := domain.ComputeHOSStanding(logs, now)
 
-	var totalDutyMins int
-	for _, log := range logs {
-		if log.Status == domain.HOSStatusDriving || log.Status == domain.HOSStatusOnDutyNotDriv {
-			duration := log.DurationMins
-			if duration == 0 && log.EndTime == nil {
-				duration = int(time.Since(log.StartTime).Minutes())
-			}
-			totalDutyMins += duration
-		}
+	priorMins, err := s.getPriorDutyMins(ctx, driverID, standing.CycleStart, now)
+	if err != nil {
+		return 0, err
 	}
 
-	return totalDutyMins, nil
+	return standing.CycleDutyMins + priorMins, nil
 }
 
 func (s *DriverService) needsBreak(logs []domain.HOSLog) bool {
@@ -591,7 +826,7 @@ func (s *DriverService) needsBreak(logs []domain.HOSLog) bool {
 			}
 			consecutiveDrivingMins += duration
 			hadBreak = false
-		} else if log.Status == domain.HOSStatusOffDuty || log.Status == domain.HOSStatusSleeperBerth {
+		} else if log.Status.CountsAsRest() {
 			duration := log.DurationMins
 			if duration == 0 && log.EndTime == nil {
 				duration = int(time.Since(log.StartTime).Minutes())
@@ -617,7 +852,7 @@ func (s *DriverService) getMinsUntilBreak(logs []domain.HOSLog) int {
 				duration = int(time.Since(log.StartTime).Minutes())
 			}
 			consecutiveDrivingMins += duration
-		} else if log.Status == domain.HOSStatusOffDuty || log.Status == domain.HOSStatusSleeperBerth {
+		} else if log.Status.CountsAsRest() {
 			duration := log.DurationMins
 			if duration == 0 && log.EndTime == nil {
 				duration = int(time.Since(log.StartTime).Minutes())
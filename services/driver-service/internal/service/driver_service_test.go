@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/config"
 )
 
 // =============================================================================
@@ -57,6 +58,15 @@ func (m *mockDriverRepo) GetByEmployeeNumber(ctx context.Context, employeeNumber
 	return nil, errors.New("driver not found")
 }
 
+func (m *mockDriverRepo) GetByPhone(ctx context.Context, phone string) (*domain.Driver, error) {
+	for _, d := range m.drivers {
+		if domain.NormalizePhone(d.Phone) == domain.NormalizePhone(phone) {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockDriverRepo) GetAll(ctx context.Context) ([]domain.Driver, error) {
 	var drivers []domain.Driver
 	for _, d := range m.drivers {
@@ -409,6 +419,8 @@ func createTestService() (*DriverService, *mockDriverRepo, *mockHOSLogRepo, *moc
 		documentRepo:  documentRepo,
 		eventProducer: nil, // Not testing events
 		logger:        nil, // Not testing logging
+		documentRules: &config.DefaultBusinessRules().Documents,
+		pcRules:       &config.DefaultBusinessRules().PersonalConveyance,
 	}
 
 	return svc, driverRepo, hosLogRepo, violationRepo, alertRepo
@@ -569,7 +581,7 @@ func TestDriverService_GetAvailableDrivers(t *testing.T) {
 	driverRepo.drivers[tiredDriver.ID] = tiredDriver
 
 	// Test without special requirements
-	drivers, err := svc.GetAvailableDrivers(ctx, 60, false, false)
+	drivers, err := svc.GetAvailableDrivers(ctx, 60, false, false, false)
 	if err != nil {
 		t.Fatalf("GetAvailableDrivers() error = %v", err)
 	}
@@ -579,7 +591,7 @@ func TestDriverService_GetAvailableDrivers(t *testing.T) {
 	}
 
 	// Test with hazmat requirement
-	drivers, err = svc.GetAvailableDrivers(ctx, 60, true, false)
+	drivers, err = svc.GetAvailableDrivers(ctx, 60, true, false, false)
 	if err != nil {
 		t.Fatalf("GetAvailableDrivers() error = %v", err)
 	}
@@ -589,7 +601,7 @@ func TestDriverService_GetAvailableDrivers(t *testing.T) {
 	}
 
 	// Test with TWIC requirement
-	drivers, err = svc.GetAvailableDrivers(ctx, 60, false, true)
+	drivers, err = svc.GetAvailableDrivers(ctx, 60, false, true, false)
 	if err != nil {
 		t.Fatalf("GetAvailableDrivers() error = %v", err)
 	}
@@ -599,6 +611,78 @@ func TestDriverService_GetAvailableDrivers(t *testing.T) {
 	}
 }
 
+func TestDriverService_GetAvailableDrivers_DocumentRestrictions(t *testing.T) {
+	svc, driverRepo, _, _, _ := createTestService()
+	ctx := context.Background()
+
+	futureDate := time.Now().Add(365 * 24 * time.Hour)
+	pastDate := time.Now().Add(-3 * 24 * time.Hour)
+
+	newDriver := func(name string) *domain.Driver {
+		d := &domain.Driver{
+			ID:                    uuid.New(),
+			FirstName:             name,
+			Status:                domain.DriverStatusAvailable,
+			LicenseExpiration:     &futureDate,
+			MedicalCardExpiration: &futureDate,
+			AvailableDriveMins:    660,
+			AvailableDutyMins:     840,
+			AvailableCycleMins:    4200,
+			HasTWIC:               true,
+			TWICExpiration:        &futureDate,
+		}
+		driverRepo.drivers[d.ID] = d
+		return d
+	}
+
+	newDriver("Valid")
+	newDriver("ExpiredTWIC").TWICExpiration = &pastDate
+	newDriver("ExpiredMedical").MedicalCardExpiration = &pastDate
+
+	drivers, err := svc.GetAvailableDrivers(ctx, 60, false, false, false)
+	if err != nil {
+		t.Fatalf("GetAvailableDrivers() error = %v", err)
+	}
+	if len(drivers) != 2 {
+		t.Errorf("GetAvailableDrivers() returned %d drivers, want 2 (expired TWIC stays available for non-port trips)", len(drivers))
+	}
+
+	drivers, err = svc.GetAvailableDrivers(ctx, 60, false, true, false)
+	if err != nil {
+		t.Fatalf("GetAvailableDrivers() error = %v", err)
+	}
+	if len(drivers) != 1 || drivers[0].FirstName != "Valid" {
+		t.Errorf("GetAvailableDrivers() with TWIC returned %d drivers, want only the valid driver", len(drivers))
+	}
+
+	// A terminal that admits escorted drivers keeps the expired TWIC driver
+	noTWIC := newDriver("NoTWIC")
+	noTWIC.HasTWIC = false
+	drivers, err = svc.GetAvailableDrivers(ctx, 60, false, true, true)
+	if err != nil {
+		t.Fatalf("GetAvailableDrivers() error = %v", err)
+	}
+	if len(drivers) != 3 {
+		t.Errorf("GetAvailableDrivers() with escorts returned %d drivers, want 3 (all but the expired medical card)", len(drivers))
+	}
+	delete(driverRepo.drivers, noTWIC.ID)
+
+	// A grace period downgrades the restriction to a warning
+	svc.documentRules = &config.DocumentRules{
+		Enforcement: map[string]config.DocumentEnforcement{
+			config.DocumentTypeMedicalCard: {GraceDays: 7, Restriction: config.DutyRestrictionOutOfService},
+			config.DocumentTypeTWIC:        {GraceDays: 0, Restriction: config.DutyRestrictionNonPort},
+		},
+	}
+	drivers, err = svc.GetAvailableDrivers(ctx, 60, false, false, false)
+	if err != nil {
+		t.Fatalf("GetAvailableDrivers() error = %v", err)
+	}
+	if len(drivers) != 3 {
+		t.Errorf("GetAvailableDrivers() within grace period returned %d drivers, want 3", len(drivers))
+	}
+}
+
 func TestDriverService_UpdateDriverStatus(t *testing.T) {
 	svc, driverRepo, _, _, _ := createTestService()
 	ctx := context.Background()
@@ -669,6 +753,53 @@ func TestDriverService_AcknowledgeViolation(t *testing.T) {
 	}
 }
 
+func TestDriverService_CalculateAvailableTime_SleeperSplit(t *testing.T) {
+	svc, driverRepo, hosLogRepo, _, _ := createTestService()
+	ctx := context.Background()
+
+	driverID := uuid.New()
+	driverRepo.drivers[driverID] = &domain.Driver{ID: driverID}
+
+	// 8/2 split: the 8 hours in the sleeper pair with the later 2 hours off
+	entries := []struct {
+		status domain.HOSStatus
+		hours  int
+	}{
+		{domain.HOSStatusOffDuty, 10},
+		{domain.HOSStatusDriving, 6},
+		{domain.HOSStatusSleeperBerth, 8},
+		{domain.HOSStatusDriving, 3},
+		{domain.HOSStatusOffDuty, 2},
+		{domain.HOSStatusDriving, 1}, // Still driving
+	}
+	start := time.Now().Add(-30 * time.Hour)
+	for i, entry := range entries {
+		end := start.Add(time.Duration(entry.hours) * time.Hour)
+		log := &domain.HOSLog{ID: uuid.New(), DriverID: driverID, Status: entry.status, StartTime: start}
+		if i < len(entries)-1 {
+			log.EndTime = &end
+			log.DurationMins = entry.hours * 60
+		}
+		hosLogRepo.logs[log.ID] = log
+		start = end
+	}
+
+	available, err := svc.CalculateAvailableTime(ctx, driverID)
+	if err != nil {
+		t.Fatalf("CalculateAvailableTime: %v", err)
+	}
+	if !available.SleeperSplit {
+		t.Error("expected the duty period to start from the sleeper berth split")
+	}
+	// 4 hours driven since the sleeper period; without the split it would be 10
+	if available.AvailableDriveMins < 419 || available.AvailableDriveMins > 420 {
+		t.Errorf("AvailableDriveMins = %d, want 420", available.AvailableDriveMins)
+	}
+	if available.AvailableDutyMins < 599 || available.AvailableDutyMins > 600 {
+		t.Errorf("AvailableDutyMins = %d, want 600", available.AvailableDutyMins)
+	}
+}
+
 func TestNeedsBreak(t *testing.T) {
 	svc, _, _, _, _ := createTestService()
 	now := time.Now()
@@ -720,6 +851,191 @@ func TestNeedsBreak(t *testing.T) {
 	}
 }
 
+func TestNeedsBreak_SpecialCategories(t *testing.T) {
+	svc, _, _, _, _ := createTestService()
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		logs     []domain.HOSLog
+		expected bool
+	}{
+		{
+			name: "personal conveyance counts as a break",
+			logs: []domain.HOSLog{
+				{Status: domain.HOSStatusDriving, StartTime: now.Add(-9 * time.Hour), DurationMins: 300},
+				{Status: domain.HOSStatusPersonalConveyance, StartTime: now.Add(-4 * time.Hour), DurationMins: 40},
+				{Status: domain.HOSStatusDriving, StartTime: now.Add(-3 * time.Hour), DurationMins: 180},
+			},
+			expected: false,
+		},
+		{
+			name: "yard move does not count as a break",
+			logs: []domain.HOSLog{
+				{Status: domain.HOSStatusDriving, StartTime: now.Add(-9 * time.Hour), DurationMins: 300},
+				{Status: domain.HOSStatusYardMove, StartTime: now.Add(-4 * time.Hour), DurationMins: 40},
+				{Status: domain.HOSStatusDriving, StartTime: now.Add(-3 * time.Hour), DurationMins: 180},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := svc.needsBreak(tt.logs)
+			if result != tt.expected {
+				t.Errorf("needsBreak() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateSpecialStatusStart(t *testing.T) {
+	tripID := uuid.New()
+	tractorID := uuid.New()
+
+	tests := []struct {
+		name    string
+		driver  *domain.Driver
+		current *domain.HOSLog
+		status  domain.HOSStatus
+		wantErr bool
+	}{
+		{
+			name:    "personal conveyance from off duty",
+			driver:  &domain.Driver{},
+			current: &domain.HOSLog{Status: domain.HOSStatusOffDuty},
+			status:  domain.HOSStatusPersonalConveyance,
+			wantErr: false,
+		},
+		{
+			name:    "personal conveyance while on a trip",
+			driver:  &domain.Driver{CurrentTripID: &tripID},
+			current: &domain.HOSLog{Status: domain.HOSStatusOffDuty},
+			status:  domain.HOSStatusPersonalConveyance,
+			wantErr: true,
+		},
+		{
+			name:    "personal conveyance from on duty",
+			driver:  &domain.Driver{},
+			current: &domain.HOSLog{Status: domain.HOSStatusOnDutyNotDriv},
+			status:  domain.HOSStatusPersonalConveyance,
+			wantErr: true,
+		},
+		{
+			name:    "yard move with tractor",
+			driver:  &domain.Driver{CurrentTractorID: &tractorID},
+			current: &domain.HOSLog{Status: domain.HOSStatusOnDutyNotDriv},
+			status:  domain.HOSStatusYardMove,
+			wantErr: false,
+		},
+		{
+			name:    "yard move without tractor",
+			driver:  &domain.Driver{},
+			current: &domain.HOSLog{Status: domain.HOSStatusOnDutyNotDriv},
+			status:  domain.HOSStatusYardMove,
+			wantErr: true,
+		},
+		{
+			name:    "already in special category",
+			driver:  &domain.Driver{CurrentTractorID: &tractorID},
+			current: &domain.HOSLog{Status: domain.HOSStatusYardMove},
+			status:  domain.HOSStatusYardMove,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSpecialStatusStart(tt.driver, tt.current, SpecialStatusInput{Status: tt.status})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpecialStatusStart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpecialStatusExit(t *testing.T) {
+	if got := specialStatusExit(domain.HOSStatusYardMove); got != domain.HOSStatusOnDutyNotDriv {
+		t.Errorf("specialStatusExit(YARD_MOVE) = %v, want %v", got, domain.HOSStatusOnDutyNotDriv)
+	}
+	if got := specialStatusExit(domain.HOSStatusPersonalConveyance); got != domain.HOSStatusOffDuty {
+		t.Errorf("specialStatusExit(PERSONAL_CONVEYANCE) = %v, want %v", got, domain.HOSStatusOffDuty)
+	}
+}
+
+func TestCheckPersonalConveyance(t *testing.T) {
+	now := time.Date(2026, 10, 14, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		limit     int
+		todayMins int
+		wantAlert bool
+	}{
+		{name: "within the default allowance", limit: 60, todayMins: 60, wantAlert: false},
+		{name: "over the default allowance", limit: 60, todayMins: 61, wantAlert: true},
+		{name: "within a configured allowance", limit: 90, todayMins: 75, wantAlert: false},
+		{name: "over a configured allowance", limit: 90, todayMins: 120, wantAlert: true},
+		{name: "no allowance turns the check off", limit: 0, todayMins: 600, wantAlert: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, violationRepo, alertRepo := createTestService()
+			svc.pcRules = &config.PersonalConveyanceRules{MaxMinsPerDay: tt.limit}
+			driverID := uuid.New()
+
+			alert, err := svc.checkPersonalConveyance(context.Background(), driverID, tt.todayMins, now)
+			if err != nil {
+				t.Fatalf("checkPersonalConveyance() error = %v", err)
+			}
+			if (alert != nil) != tt.wantAlert {
+				t.Fatalf("checkPersonalConveyance() alert = %v, want alert %v", alert, tt.wantAlert)
+			}
+			if len(violationRepo.violations) != 0 {
+				t.Errorf("recorded %d HOS violations, want none", len(violationRepo.violations))
+			}
+			if !tt.wantAlert {
+				if len(alertRepo.alerts) != 0 {
+					t.Errorf("stored %d alerts, want none", len(alertRepo.alerts))
+				}
+				return
+			}
+
+			if alert.Severity != "warning" || alert.Type != personalConveyanceAlertType {
+				t.Errorf("alert = %s %s, want warning %s", alert.Severity, alert.Type, personalConveyanceAlertType)
+			}
+			if want := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC); !alert.ExpiresAt.Equal(want) {
+				t.Errorf("alert expires %v, want %v", alert.ExpiresAt, want)
+			}
+			if _, ok := alertRepo.alerts[alert.ID]; !ok {
+				t.Error("alert was not stored")
+			}
+		})
+	}
+}
+
+func TestCheckPersonalConveyance_OncePerDay(t *testing.T) {
+	svc, _, _, _, alertRepo := createTestService()
+	ctx := context.Background()
+	driverID := uuid.New()
+	now := time.Date(2026, 10, 14, 18, 30, 0, 0, time.UTC)
+
+	if alert, _ := svc.checkPersonalConveyance(ctx, driverID, 75, now); alert == nil {
+		t.Fatal("expected an alert on first going over the allowance")
+	}
+	if alert, _ := svc.checkPersonalConveyance(ctx, driverID, 95, now.Add(time.Hour)); alert != nil {
+		t.Error("expected no second alert the same day")
+	}
+	if alert, _ := svc.checkPersonalConveyance(ctx, driverID, 75, now.Add(24*time.Hour)); alert == nil {
+		t.Error("expected a new alert the next day")
+	}
+	if len(alertRepo.alerts) != 2 {
+		t.Errorf("stored %d alerts, want 2", len(alertRepo.alerts))
+	}
+}
+
 func TestGetMinsUntilBreak(t *testing.T) {
 	svc, _, _, _, _ := createTestService()
 	now := time.Now()
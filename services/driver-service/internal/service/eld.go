@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// eldDuplicateWindow is how close in time two ELD entries of the same status
+// must start to be taken as one change delivered twice
+const eldDuplicateWindow = time.Minute
+
+// eldDutyStatusEvent is a duty status change the eld-integration service
+// received from a provider and matched to a driver
+type eldDutyStatusEvent struct {
+	DriverID    string    `json:"driver_id"`
+	TractorID   string    `json:"tractor_id"`
+	Status      string    `json:"status"`
+	StartTime   time.Time `json:"start_time"`
+	Location    string    `json:"location"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Odometer    int       `json:"odometer"`
+	EngineHours float64   `json:"engine_hours"`
+	Notes       string    `json:"notes"`
+	Provider    string    `json:"provider"`
+	ExternalID  string    `json:"external_id"`
+}
+
+// HandleELDDutyStatus records a duty status change reported by a driver's
+// ELD. Changes already on the log are skipped, so a provider resending its
+// history does not duplicate entries.
+func (s *DriverService) HandleELDDutyStatus(ctx context.Context, event *kafka.Event) error {
+	input, err := eldDutyStatusInput(event)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	logs, err := s.hosLogRepo.GetByDriverID(ctx, input.DriverID, input.StartTime.Add(-eldDuplicateWindow), time.Now().Add(eldDuplicateWindow))
+	if err != nil {
+		return fmt.Errorf("get HOS logs: %w", err)
+	}
+	if isRecordedELDStatus(logs, input) {
+		return nil
+	}
+
+	if _, err := s.RecordHOSStatus(ctx, input); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReconcileELDLogs compares a driver's manual HOS entries with what their ELD
+// recorded between start and end
+func (s *DriverService) ReconcileELDLogs(ctx context.Context, driverID uuid.UUID, start, end time.Time) (*domain.HOSReconciliation, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	// Reach back a day for the statuses already running at start
+	logs, err := s.hosLogRepo.GetByDriverID(ctx, driverID, start.Add(-24*time.Hour), end)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ReconcileHOSLogs(driverID, logs, start, end), nil
+}
+
+// eldDutyStatusInput decodes an ELD duty status event into an HOS entry
+func eldDutyStatusInput(event *kafka.Event) (RecordHOSInput, error) {
+	var payload eldDutyStatusEvent
+	if err := event.DecodeData(&payload); err != nil {
+		return RecordHOSInput{}, fmt.Errorf("decode ELD duty status: %w", err)
+	}
+	driverID, err := uuid.Parse(payload.DriverID)
+	if err != nil {
+		return RecordHOSInput{}, fmt.Errorf("invalid driver_id %q", payload.DriverID)
+	}
+	status := domain.HOSStatus(payload.Status)
+	switch status {
+	case domain.HOSStatusOffDuty, domain.HOSStatusSleeperBerth, domain.HOSStatusDriving,
+		domain.HOSStatusOnDutyNotDriv, domain.HOSStatusPersonalConveyance, domain.HOSStatusYardMove:
+	default:
+		return RecordHOSInput{}, fmt.Errorf("unknown duty status %q", payload.Status)
+	}
+	if payload.StartTime.IsZero() {
+		return RecordHOSInput{}, fmt.Errorf("start_time is required")
+	}
+
+	input := RecordHOSInput{
+		DriverID:    driverID,
+		Status:      status,
+		StartTime:   payload.StartTime,
+		Location:    payload.Location,
+		Latitude:    payload.Latitude,
+		Longitude:   payload.Longitude,
+		Odometer:    payload.Odometer,
+		EngineHours: payload.EngineHours,
+		Notes:       payload.Notes,
+		Source:      domain.HOSSourceELD,
+	}
+	if tractorID, err := uuid.Parse(payload.TractorID); err == nil {
+		input.TractorID = &tractorID
+	}
+	return input, nil
+}
+
+// isRecordedELDStatus reports whether the driver's log already has the change
+func isRecordedELDStatus(logs []domain.HOSLog, input RecordHOSInput) bool {
+	for _, l := range logs {
+		if l.Source != domain.HOSSourceELD || l.Status != input.Status {
+			continue
+		}
+		gap := l.StartTime.Sub(input.StartTime)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap <= eldDuplicateWindow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+func TestELDDutyStatusInput(t *testing.T) {
+	driverID, tractorID := uuid.New(), uuid.New()
+	start := time.Date(2024, 6, 12, 8, 30, 0, 0, time.UTC)
+
+	event := kafka.NewEvent(kafka.Topics.ELDDutyStatusRecorded, "eld-integration", map[string]interface{}{
+		"driver_id":   driverID.String(),
+		"tractor_id":  tractorID.String(),
+		"status":      "DRIVING",
+		"start_time":  start,
+		"location":    "Carson, CA",
+		"odometer":    182004,
+		"provider":    "SAMSARA",
+		"external_id": "hos-1",
+	})
+	input, err := eldDutyStatusInput(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.DriverID != driverID || input.TractorID == nil || *input.TractorID != tractorID {
+		t.Errorf("driver/tractor = %v/%v", input.DriverID, input.TractorID)
+	}
+	if input.Status != domain.HOSStatusDriving || !input.StartTime.Equal(start) {
+		t.Errorf("status/start = %s/%v", input.Status, input.StartTime)
+	}
+	if input.Source != domain.HOSSourceELD || input.Odometer != 182004 {
+		t.Errorf("source/odometer = %s/%d", input.Source, input.Odometer)
+	}
+
+	for name, data := range map[string]map[string]interface{}{
+		"bad driver":     {"driver_id": "x", "status": "DRIVING", "start_time": start},
+		"unknown status": {"driver_id": driverID.String(), "status": "LUNCH", "start_time": start},
+		"no start":       {"driver_id": driverID.String(), "status": "DRIVING"},
+	} {
+		if _, err := eldDutyStatusInput(kafka.NewEvent(kafka.Topics.ELDDutyStatusRecorded, "eld-integration", data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestIsRecordedELDStatus(t *testing.T) {
+	start := time.Date(2024, 6, 12, 8, 30, 0, 0, time.UTC)
+	input := RecordHOSInput{Status: domain.HOSStatusDriving, StartTime: start, Source: domain.HOSSourceELD}
+
+	tests := []struct {
+		name string
+		log  domain.HOSLog
+		want bool
+	}{
+		{"same change", domain.HOSLog{Status: domain.HOSStatusDriving, StartTime: start.Add(20 * time.Second), Source: "eld"}, true},
+		{"other status", domain.HOSLog{Status: domain.HOSStatusOnDutyNotDriv, StartTime: start, Source: "eld"}, false},
+		{"manual entry", domain.HOSLog{Status: domain.HOSStatusDriving, StartTime: start, Source: "manual"}, false},
+		{"later change", domain.HOSLog{Status: domain.HOSStatusDriving, StartTime: start.Add(time.Hour), Source: "eld"}, false},
+	}
+	for _, tt := range tests {
+		if got := isRecordedELDStatus([]domain.HOSLog{tt.log}, input); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
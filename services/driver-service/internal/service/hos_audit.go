@@ -0,0 +1,373 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/services/driver-service/internal/repository"
+	"github.com/draymaster/shared/pkg/email"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// hosAuditMaxDays caps the period one audit report may cover
+	hosAuditMaxDays = 92
+	// hosAuditWeekday and hosAuditHourUTC are when the weekly report for the
+	// previous Monday to Sunday is sent
+	hosAuditWeekday = time.Monday
+	hosAuditHourUTC = 6
+)
+
+// HOSAuditService keeps drivers' daily log certifications and builds the
+// safety department's HOS audit report: violations, edits, uncertified days,
+// unidentified driving and signs a log may not match the truck's movement
+type HOSAuditService struct {
+	drivers      *DriverService
+	certRepo     repository.HOSCertificationRepository
+	segmentRepo  repository.DrivingSegmentRepository
+	locationRepo repository.LocationHistoryRepository
+	mailer       email.Sender
+	recipients   []string
+	logger       *logger.Logger
+}
+
+// NewHOSAuditService creates a new HOS audit service. The weekly report is
+// emailed to recipients; with none it is only logged.
+func NewHOSAuditService(
+	drivers *DriverService,
+	certRepo repository.HOSCertificationRepository,
+	segmentRepo repository.DrivingSegmentRepository,
+	locationRepo repository.LocationHistoryRepository,
+	mailer email.Sender,
+	recipients []string,
+	log *logger.Logger,
+) *HOSAuditService {
+	return &HOSAuditService{
+		drivers:      drivers,
+		certRepo:     certRepo,
+		segmentRepo:  segmentRepo,
+		locationRepo: locationRepo,
+		mailer:       mailer,
+		recipients:   recipients,
+		logger:       log,
+	}
+}
+
+// CertifyDailyLog records a driver's certification of one day's record of
+// duty status. Certifying again after edits replaces the earlier one.
+func (s *HOSAuditService) CertifyDailyLog(ctx context.Context, driverID uuid.UUID, date time.Time, source string) (*domain.HOSLogCertification, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.After(time.Now()) {
+		return nil, fmt.Errorf("cannot certify a day that has not started")
+	}
+	driver, err := s.drivers.GetDriver(ctx, driverID)
+	if err != nil || driver == nil {
+		return nil, fmt.Errorf("driver not found: %s", driverID)
+	}
+	if source == "" {
+		source = domain.HOSSourceManual
+	}
+
+	cert := &domain.HOSLogCertification{
+		ID:          uuid.New(),
+		DriverID:    driverID,
+		LogDate:     day,
+		CertifiedAt: time.Now(),
+		Source:      source,
+	}
+	if err := s.certRepo.Certify(ctx, cert); err != nil {
+		return nil, fmt.Errorf("failed to certify daily log: %w", err)
+	}
+
+	s.logger.Infow("Daily log certified", "driver_id", driverID, "log_date", day.Format("2006-01-02"), "source", source)
+	return cert, nil
+}
+
+// BuildAuditReport audits drivers' HOS records between start and end: every
+// driver with logs or violations in the period, or just driverID when set
+func (s *HOSAuditService) BuildAuditReport(ctx context.Context, start, end time.Time, driverID *uuid.UUID) (*domain.HOSAuditReport, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	if end.Sub(start) > hosAuditMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("an audit report covers at most %d days", hosAuditMaxDays)
+	}
+
+	var drivers []domain.Driver
+	if driverID != nil {
+		driver, err := s.drivers.GetDriver(ctx, *driverID)
+		if err != nil || driver == nil {
+			return nil, fmt.Errorf("driver not found: %s", driverID)
+		}
+		drivers = append(drivers, *driver)
+	} else {
+		var err error
+		drivers, err = s.drivers.driverRepo.GetAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list drivers: %w", err)
+		}
+	}
+
+	segments, err := s.segmentRepo.GetByTimeRange(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driving segments: %w", err)
+	}
+
+	now := time.Now()
+	report := &domain.HOSAuditReport{
+		Start:               start,
+		End:                 end,
+		GeneratedAt:         now,
+		Drivers:             []domain.DriverHOSAudit{},
+		UnidentifiedDriving: []domain.UnidentifiedDriving{},
+	}
+	for i := range drivers {
+		audit, err := s.auditDriver(ctx, &drivers[i], start, end, segments, now)
+		if err != nil {
+			return nil, err
+		}
+		if audit != nil {
+			report.Drivers = append(report.Drivers, *audit)
+		}
+	}
+	if driverID == nil {
+		for _, seg := range segments {
+			if !seg.NeedsReview() {
+				continue
+			}
+			report.UnidentifiedDriving = append(report.UnidentifiedDriving, domain.UnidentifiedDriving{
+				SegmentID:   seg.ID,
+				TractorID:   seg.TractorID,
+				Attribution: seg.Attribution,
+				StartTime:   seg.StartTime,
+				Mins:        segmentMins(seg, end),
+				Location:    seg.Location,
+				Note:        seg.Note,
+			})
+		}
+	}
+	report.Summarize()
+	return report, nil
+}
+
+// auditDriver audits one driver's records for the period, or returns nil
+// when they have neither logs nor violations in it
+func (s *HOSAuditService) auditDriver(ctx context.Context, driver *domain.Driver, start, end time.Time,
+	segments []domain.DrivingSegment, now time.Time) (*domain.DriverHOSAudit, error) {
+	// Reach back a day for the statuses already running at start
+	logs, err := s.drivers.hosLogRepo.GetByDriverID(ctx, driver.ID, start.Add(-24*time.Hour), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HOS logs for %s: %w", driver.ID, err)
+	}
+	violations, err := s.drivers.violationRepo.GetByDriverID(ctx, driver.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get violations for %s: %w", driver.ID, err)
+	}
+	days := domain.HOSLogDays(logs, start, end)
+	if len(days) == 0 && len(violations) == 0 {
+		return nil, nil
+	}
+
+	certs, err := s.certRepo.GetByDriverID(ctx, driver.ID, start.Add(-24*time.Hour), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certifications for %s: %w", driver.ID, err)
+	}
+	points, err := s.locationRepo.GetByDriverID(ctx, driver.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations for %s: %w", driver.ID, err)
+	}
+
+	audit := &domain.DriverHOSAudit{
+		DriverID:              driver.ID,
+		DriverName:            driver.FullName(),
+		EmployeeNumber:        driver.EmployeeNumber,
+		LoggedDays:            len(days),
+		Violations:            violations,
+		ViolationsByType:      map[string]int{},
+		Edits:                 domain.HOSLogEdits(logs, start, end),
+		MissingCertifications: domain.MissingCertifications(logs, certs, start, end, now),
+		RiskFindings:          []domain.HOSRiskFinding{},
+	}
+	if audit.Violations == nil {
+		audit.Violations = []domain.HOSViolation{}
+	}
+	for _, v := range violations {
+		audit.ViolationsByType[v.Type]++
+	}
+	for _, e := range audit.Edits {
+		if e.Source == domain.HOSSourceManual {
+			audit.ManualEntries++
+		}
+	}
+	for _, seg := range segments {
+		if seg.Attribution == domain.AttributionResolved && seg.DriverID != nil && *seg.DriverID == driver.ID {
+			audit.UnidentifiedDrivingAssigned++
+			audit.UnidentifiedDrivingAssignedMins += segmentMins(seg, end)
+		}
+	}
+
+	audit.RiskFindings = append(audit.RiskFindings, domain.FindMovementWhileOff(logs, points, now)...)
+	for _, f := range domain.FindOdometerWhileOff(logs) {
+		if f.End.After(start) && f.Start.Before(end) {
+			audit.RiskFindings = append(audit.RiskFindings, f)
+		}
+	}
+	audit.RiskFindings = append(audit.RiskFindings, domain.DrivingEditedAway(domain.ReconcileHOSLogs(driver.ID, logs, start, end))...)
+	audit.AssessRisk()
+	return audit, nil
+}
+
+// Start sends the weekly audit report for the previous Monday to Sunday
+// every Monday morning until ctx is cancelled
+func (s *HOSAuditService) Start(ctx context.Context) {
+	s.logger.Infow("HOS audit report scheduled", "weekday", hosAuditWeekday, "hour_utc", hosAuditHourUTC, "recipients", len(s.recipients))
+	for {
+		next := nextHOSAuditRun(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		end := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, time.UTC)
+		if err := s.SendAuditReport(ctx, end.AddDate(0, 0, -7), end); err != nil {
+			s.logger.Errorw("Weekly HOS audit report failed", "error", err)
+		}
+	}
+}
+
+// SendAuditReport builds the audit report for the period and emails it to
+// the safety department as a CSV attachment
+func (s *HOSAuditService) SendAuditReport(ctx context.Context, start, end time.Time) error {
+	report, err := s.BuildAuditReport(ctx, start, end, nil)
+	if err != nil {
+		return err
+	}
+	s.logger.Infow("HOS audit report built",
+		"start", start,
+		"end", end,
+		"drivers", report.Totals.Drivers,
+		"drivers_with_findings", report.Totals.DriversWithFindings,
+		"high_risk_drivers", report.Totals.HighRiskDrivers,
+	)
+	if s.mailer == nil || len(s.recipients) == 0 {
+		return nil
+	}
+
+	data, err := HOSAuditCSV(report)
+	if err != nil {
+		return err
+	}
+	period := start.Format("2006-01-02") + " to " + end.AddDate(0, 0, -1).Format("2006-01-02")
+	return s.mailer.Send(ctx, email.Message{
+		To:      s.recipients,
+		Subject: "HOS audit report " + period,
+		Body:    hosAuditSummary(report, period),
+		Attachments: []email.Attachment{{
+			Filename:    "hos-audit-" + start.Format("2006-01-02") + ".csv",
+			ContentType: "text/csv",
+			Data:        data,
+		}},
+	})
+}
+
+// HOSAuditCSV exports an audit report with one row per finding, and a
+// summary row for each driver, for insurers and DOT audit preparation
+func HOSAuditCSV(report *domain.HOSAuditReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"driver", "employee_number", "risk_level", "category", "type", "start", "end", "mins", "detail"})
+
+	ts := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+	for _, d := range report.Drivers {
+		row := func(category, kind, start, end string, mins int, detail string) {
+			w.Write([]string{d.DriverName, d.EmployeeNumber, d.RiskLevel, category, kind, start, end, strconv.Itoa(mins), detail})
+		}
+		row("SUMMARY", "", "", "", 0, fmt.Sprintf("%d logged days, %d violations, %d edits, %d uncertified days, %d risk findings",
+			d.LoggedDays, len(d.Violations), len(d.Edits), len(d.MissingCertifications), len(d.RiskFindings)))
+		for _, v := range d.Violations {
+			row("VIOLATION", v.Type, ts(v.OccurredAt), "", v.DurationMins, v.Description)
+		}
+		for _, e := range d.Edits {
+			detail := string(e.Status)
+			if e.EditReason != "" {
+				detail += ": " + e.EditReason
+			}
+			row("EDIT", e.Source, ts(e.StartTime), "", e.DurationMins, detail)
+		}
+		for _, c := range d.MissingCertifications {
+			kind := "NOT_CERTIFIED"
+			if c.EditedAfter {
+				kind = "EDITED_AFTER_CERTIFICATION"
+			}
+			row("CERTIFICATION", kind, c.Date, "", 0, "")
+		}
+		if d.UnidentifiedDrivingAssigned > 0 {
+			row("UNIDENTIFIED_DRIVING", "ASSIGNED", "", "", d.UnidentifiedDrivingAssignedMins,
+				fmt.Sprintf("%d unidentified driving segments assigned after review", d.UnidentifiedDrivingAssigned))
+		}
+		for _, f := range d.RiskFindings {
+			row("FALSIFICATION_RISK", string(f.Indicator), ts(f.Start), ts(f.End), f.Mins, f.Detail)
+		}
+	}
+	for _, u := range report.UnidentifiedDriving {
+		w.Write([]string{"", "", "", "UNIDENTIFIED_DRIVING", string(u.Attribution), ts(u.StartTime), "", strconv.Itoa(u.Mins),
+			"Tractor " + u.TractorID.String() + " " + u.Note})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write audit CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// hosAuditSummary is the email body for a report
+func hosAuditSummary(report *domain.HOSAuditReport, period string) string {
+	t := report.Totals
+	return fmt.Sprintf("HOS audit for %s\n\n"+
+		"Drivers audited: %d\n"+
+		"Drivers with findings: %d\n"+
+		"High risk drivers: %d\n"+
+		"Violations: %d\n"+
+		"Log edits: %d\n"+
+		"Uncertified days: %d\n"+
+		"Falsification risk findings: %d\n"+
+		"Unidentified driving still unassigned: %d segments, %s\n\n"+
+		"Details are in the attached CSV.\n",
+		period, t.Drivers, t.DriversWithFindings, t.HighRiskDrivers, t.Violations, t.Edits,
+		t.MissingCertifications, t.RiskFindings, t.UnidentifiedDriving, formatMins(t.UnidentifiedDrivingMins))
+}
+
+// segmentMins is how long an ELD driving segment ran, up to end when it is
+// still open
+func segmentMins(seg domain.DrivingSegment, end time.Time) int {
+	to := end
+	if seg.EndTime != nil {
+		to = *seg.EndTime
+	}
+	if !to.After(seg.StartTime) {
+		return 0
+	}
+	return int(to.Sub(seg.StartTime).Minutes())
+}
+
+// nextHOSAuditRun is the next weekly report time after now
+func nextHOSAuditRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hosAuditHourUTC, 0, 0, 0, time.UTC)
+	next = next.AddDate(0, 0, (int(hosAuditWeekday)-int(next.Weekday())+7)%7)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
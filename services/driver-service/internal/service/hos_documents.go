@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/document"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// HOSDocumentService renders driver daily logs for roadside inspections and
+// compliance audits
+type HOSDocumentService struct {
+	drivers   *DriverService
+	documents *document.Generator
+	logger    *logger.Logger
+}
+
+// NewHOSDocumentService creates a new HOS document service
+func NewHOSDocumentService(drivers *DriverService, documents *document.Generator, log *logger.Logger) *HOSDocumentService {
+	return &HOSDocumentService{
+		drivers:   drivers,
+		documents: documents,
+		logger:    log,
+	}
+}
+
+// DailyLog renders a driver's record of duty status for one day
+func (s *HOSDocumentService) DailyLog(ctx context.Context, tenantID string, driverID uuid.UUID, date time.Time) (*document.Document, error) {
+	driver, err := s.drivers.GetDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.drivers.GetHOSSummary(ctx, driverID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	logs, err := s.drivers.GetDriverLogs(ctx, driverID, startOfDay, startOfDay.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].StartTime.Before(logs[j].StartTime) })
+
+	data := document.HOSLogData{
+		DriverName:    driver.FullName(),
+		LicenseNumber: driver.LicenseNumber,
+		LicenseState:  driver.LicenseState,
+		Date:          startOfDay,
+		OffDutyMins:   summary.OffDutyMins,
+		SleeperMins:   summary.SleeperMins,
+		DrivingMins:   summary.DrivingMins,
+		OnDutyMins:    summary.OnDutyMins,
+	}
+	for _, log := range logs {
+		duration := log.DurationMins
+		if duration == 0 && log.EndTime == nil {
+			duration = int(time.Since(log.StartTime).Minutes())
+		}
+		data.Entries = append(data.Entries, document.HOSLogEntry{
+			Status:       string(log.Status),
+			Start:        log.StartTime,
+			End:          log.EndTime,
+			DurationMins: duration,
+			Location:     log.Location,
+			Odometer:     log.Odometer,
+			Source:       log.Source,
+			Notes:        log.Notes,
+		})
+	}
+	for _, v := range summary.Violations {
+		data.Violations = append(data.Violations, v.OccurredAt.Format("15:04")+" "+v.Description)
+	}
+
+	return s.documents.Render(ctx, document.Request{
+		TenantID: tenantID,
+		Kind:     document.KindHOSLog,
+		Title:    "Driver's Daily Log",
+		Filename: "hos-log-" + driver.EmployeeNumber + "-" + startOfDay.Format("2006-01-02"),
+		Data:     data,
+	})
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/shared/pkg/document"
+)
+
+type captureRenderer struct {
+	html string
+	page document.PageOptions
+}
+
+func (r *captureRenderer) RenderPDF(ctx context.Context, html []byte, page document.PageOptions) ([]byte, error) {
+	r.html = string(html)
+	r.page = page
+	return []byte("%PDF-1.7"), nil
+}
+
+func TestHOSDocumentService_DailyLog(t *testing.T) {
+	drivers, driverRepo, hosLogRepo, _, _ := createTestService()
+	renderer := &captureRenderer{}
+	svc := NewHOSDocumentService(drivers, document.NewGenerator(nil, nil, renderer, nil), nil)
+	ctx := context.Background()
+
+	driverID := uuid.New()
+	driverRepo.drivers[driverID] = &domain.Driver{
+		ID:             driverID,
+		EmployeeNumber: "EMP-7",
+		FirstName:      "Jane",
+		LastName:       "Smith",
+		LicenseNumber:  "D1234567",
+		LicenseState:   "CA",
+	}
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	driveEnd := day.Add(9*time.Hour + 30*time.Minute)
+	offEnd := day.Add(7 * time.Hour)
+	for _, l := range []*domain.HOSLog{
+		{Status: domain.HOSStatusDriving, StartTime: day.Add(7 * time.Hour), EndTime: &driveEnd, DurationMins: 150, Location: "Port of Long Beach"},
+		{Status: domain.HOSStatusOffDuty, StartTime: day.Add(time.Minute), EndTime: &offEnd, DurationMins: 419},
+	} {
+		l.ID = uuid.New()
+		l.DriverID = driverID
+		hosLogRepo.logs[l.ID] = l
+	}
+
+	doc, err := svc.DailyLog(ctx, "", driverID, day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("DailyLog() error = %v", err)
+	}
+
+	if doc.Kind != document.KindHOSLog || doc.ContentType != "application/pdf" {
+		t.Errorf("DailyLog() kind = %v, content type = %v", doc.Kind, doc.ContentType)
+	}
+	if doc.Filename != "hos-log-EMP-7-2024-03-04.pdf" {
+		t.Errorf("DailyLog() filename = %v", doc.Filename)
+	}
+	if !renderer.page.Landscape {
+		t.Error("DailyLog() should render landscape")
+	}
+	for _, want := range []string{"Jane Smith", "D1234567", "Port of Long Beach", "2h 30m"} {
+		if !strings.Contains(renderer.html, want) {
+			t.Errorf("DailyLog() html missing %q", want)
+		}
+	}
+	if strings.Index(renderer.html, "OFF_DUTY") > strings.Index(renderer.html, ">DRIVING<") {
+		t.Error("DailyLog() entries should be in time order")
+	}
+}
+
+func TestHOSDocumentService_DailyLog_DriverNotFound(t *testing.T) {
+	drivers, _, _, _, _ := createTestService()
+	svc := NewHOSDocumentService(drivers, document.NewGenerator(nil, nil, &captureRenderer{}, nil), nil)
+
+	if _, err := svc.DailyLog(context.Background(), "", uuid.New(), time.Now()); err == nil {
+		t.Error("DailyLog() expected error for unknown driver")
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+)
+
+// =============================================================================
+// PRIOR-CARRIER HOS HISTORY
+// =============================================================================
+
+// PriorDutyDayInput is one day of prior-carrier duty time
+type PriorDutyDayInput struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	OnDutyMins  int    `json:"on_duty_mins"`
+	DrivingMins int    `json:"driving_mins"`
+}
+
+// PriorDutyInput contains a new hire's duty hours from their previous carrier
+type PriorDutyInput struct {
+	DriverID    uuid.UUID           `json:"driver_id"`
+	CarrierName string              `json:"carrier_name"`
+	CarrierDOT  string              `json:"carrier_dot"`
+	Days        []PriorDutyDayInput `json:"days"`
+	ImportedBy  string              `json:"-"`
+}
+
+// RecordPriorDuty saves prior-carrier duty hours keyed by hand. Every day
+// must fall in the 7 days before the driver's hire date.
+func (s *DriverService) RecordPriorDuty(ctx context.Context, input PriorDutyInput) ([]domain.PriorDutyDay, error) {
+	if input.CarrierName == "" {
+		return nil, fmt.Errorf("carrier_name is required")
+	}
+	if len(input.Days) == 0 {
+		return nil, fmt.Errorf("at least one day is required")
+	}
+
+	driver, err := s.priorDutyDriver(ctx, input.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	first, end := priorDutyRange(driver)
+
+	days := make([]domain.PriorDutyDay, 0, len(input.Days))
+	for _, d := range input.Days {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("date %q must be YYYY-MM-DD", d.Date)
+		}
+		if date.Before(first) || !date.Before(end) {
+			return nil, fmt.Errorf("%s is outside the %d days before the hire date", d.Date, domain.PriorDutyLookbackDays)
+		}
+		if d.OnDutyMins < 0 || d.OnDutyMins > 24*60 {
+			return nil, fmt.Errorf("on_duty_mins for %s must be between 0 and 1440", d.Date)
+		}
+		if d.DrivingMins < 0 || d.DrivingMins > d.OnDutyMins {
+			return nil, fmt.Errorf("driving_mins for %s cannot exceed on_duty_mins", d.Date)
+		}
+		days = append(days, domain.PriorDutyDay{
+			DutyDate:    date,
+			OnDutyMins:  d.OnDutyMins,
+			DrivingMins: d.DrivingMins,
+		})
+	}
+
+	return s.savePriorDuty(ctx, driver, days, input.CarrierName, input.CarrierDOT, domain.PriorDutySourceManual, input.ImportedBy)
+}
+
+// ImportELDTransferFile saves prior-carrier duty hours from the previous
+// carrier's ELD output file (49 CFR 395 subpart B, appendix 4.8.2.1). Days
+// in the file outside the 7 days before the hire date are skipped.
+func (s *DriverService) ImportELDTransferFile(ctx context.Context, driverID uuid.UUID, importedBy string, file io.Reader) ([]domain.PriorDutyDay, error) {
+	driver, err := s.priorDutyDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := parseELDOutputFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	first, end := priorDutyRange(driver)
+	var days []domain.PriorDutyDay
+	for _, d := range transfer.days {
+		if d.DutyDate.Before(first) || !d.DutyDate.Before(end) {
+			continue
+		}
+		days = append(days, d)
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("ELD file has no duty time in the %d days before the hire date", domain.PriorDutyLookbackDays)
+	}
+
+	return s.savePriorDuty(ctx, driver, days, transfer.carrierName, transfer.carrierDOT, domain.PriorDutySourceELDTransfer, importedBy)
+}
+
+// GetPriorDuty returns the prior-carrier duty days on file for a driver
+func (s *DriverService) GetPriorDuty(ctx context.Context, driverID uuid.UUID) ([]domain.PriorDutyDay, error) {
+	driver, err := s.priorDutyDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	first, end := priorDutyRange(driver)
+	return s.priorDutyRepo.GetByDriverID(ctx, driverID, first, end)
+}
+
+// priorDutyDriver loads a driver that prior-carrier hours can be recorded for
+func (s *DriverService) priorDutyDriver(ctx context.Context, driverID uuid.UUID) (*domain.Driver, error) {
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil || driver == nil {
+		return nil, fmt.Errorf("driver not found: %s", driverID)
+	}
+	if driver.HireDate == nil {
+		return nil, fmt.Errorf("driver %s has no hire date", driverID)
+	}
+	return driver, nil
+}
+
+func (s *DriverService) savePriorDuty(ctx context.Context, driver *domain.Driver, days []domain.PriorDutyDay,
+	carrierName, carrierDOT string, source domain.PriorDutySource, importedBy string) ([]domain.PriorDutyDay, error) {
+	now := time.Now()
+	for i := range days {
+		days[i].ID = uuid.New()
+		days[i].DriverID = driver.ID
+		days[i].CarrierName = carrierName
+		days[i].CarrierDOT = carrierDOT
+		days[i].Source = source
+		days[i].ImportedBy = importedBy
+		days[i].CreatedAt = now
+		if err := s.priorDutyRepo.Upsert(ctx, &days[i]); err != nil {
+			return nil, fmt.Errorf("failed to save prior duty for %s: %w", days[i].DutyDate.Format("2006-01-02"), err)
+		}
+	}
+
+	if err := s.recalculateHOS(ctx, driver.ID); err != nil {
+		s.logger.Warnw("Failed to recalculate HOS after prior duty import", "driver_id", driver.ID, "error", err)
+	}
+	s.logger.Infow("Prior-carrier duty recorded",
+		"driver_id", driver.ID,
+		"carrier", carrierName,
+		"source", source,
+		"days", len(days),
+	)
+	return days, nil
+}
+
+// getPriorDutyMins sums prior-carrier on-duty time dated from the start of
+// the cycle window, while the driver is in their first 8 days of employment
+func (s *DriverService) getPriorDutyMins(ctx context.Context, driverID uuid.UUID, from, now time.Time) (int, error) {
+	if s.priorDutyRepo == nil {
+		return 0, nil
+	}
+	driver, err := s.driverRepo.GetByID(ctx, driverID)
+	if err != nil || driver == nil || !driver.PriorDutyApplies(now) {
+		return 0, nil
+	}
+
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	days, err := s.priorDutyRepo.GetByDriverID(ctx, driverID, start, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	for _, d := range days {
+		total += d.OnDutyMins
+	}
+	return total, nil
+}
+
+// priorDutyRange returns the first date prior-carrier hours may be recorded
+// for and the hire date, which ends the range
+func priorDutyRange(driver *domain.Driver) (first, end time.Time) {
+	hired := driver.HireDate
+	end = time.Date(hired.Year(), hired.Month(), hired.Day(), 0, 0, 0, 0, time.UTC)
+	return end.AddDate(0, 0, -domain.PriorDutyLookbackDays), end
+}
+
+// =============================================================================
+// ELD OUTPUT FILE
+// =============================================================================
+
+// eldTransfer is the duty time read from an ELD output file
+type eldTransfer struct {
+	carrierName string
+	carrierDOT  string
+	days        []domain.PriorDutyDay // By date, carrier fields unset
+}
+
+// ELD duty status event codes (event type 1)
+const (
+	eldEventTypeDutyStatus = "1"
+	eldRecordActive        = "1"
+	eldDutyDriving         = "3"
+	eldDutyOnDuty          = "4"
+)
+
+type eldDutyEvent struct {
+	at   time.Time
+	code string
+}
+
+// parseELDOutputFile reads active duty status changes from the ELD Event
+// List and totals on-duty and driving time per day. Event times are in the
+// carrier's home terminal time, which is also how its days are counted. The
+// last status runs to the file's creation time from the header, or to the
+// end of its day when that is missing.
+func parseELDOutputFile(r io.Reader) (*eldTransfer, error) {
+	transfer := &eldTransfer{}
+	var events []eldDutyEvent
+	var createdAt time.Time
+
+	scanner := bufio.NewScanner(r)
+	section := ""
+	headerLine := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasSuffix(line, ":") && !strings.Contains(line, ",") {
+			section = strings.TrimSuffix(line, ":")
+			continue
+		}
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		switch section {
+		case "ELD File Header Segment":
+			headerLine++
+			switch headerLine {
+			case 4: // Carrier's USDOT number, carrier name, ...
+				if len(fields) >= 2 {
+					transfer.carrierDOT = fields[0]
+					transfer.carrierName = fields[1]
+				}
+			case 6: // Current date, current time, ...
+				if len(fields) >= 2 {
+					if t, err := parseELDTime(fields[0], fields[1]); err == nil {
+						createdAt = t
+					}
+				}
+			}
+		case "ELD Event List":
+			// Sequence, record status, record origin, event type, event code, date, time, ...
+			if len(fields) < 7 || fields[1] != eldRecordActive || fields[3] != eldEventTypeDutyStatus {
+				continue
+			}
+			at, err := parseELDTime(fields[5], fields[6])
+			if err != nil {
+				return nil, fmt.Errorf("invalid ELD event time %q %q", fields[5], fields[6])
+			}
+			events = append(events, eldDutyEvent{at: at, code: fields[4]})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ELD file: %w", err)
+	}
+	if section == "" {
+		return nil, fmt.Errorf("not an ELD output file")
+	}
+	if transfer.carrierName == "" {
+		return nil, fmt.Errorf("ELD file header has no carrier")
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("ELD file has no duty status events")
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	totals := make(map[time.Time]*domain.PriorDutyDay)
+	day := func(t time.Time) *domain.PriorDutyDay {
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if totals[date] == nil {
+			totals[date] = &domain.PriorDutyDay{DutyDate: date}
+		}
+		return totals[date]
+	}
+
+	for i, e := range events {
+		end := endOfELDDay(e.at)
+		if i+1 < len(events) {
+			end = events[i+1].at
+		} else if createdAt.After(e.at) {
+			end = createdAt
+		}
+
+		// Split the status at midnight so each day gets its own minutes
+		for start := e.at; start.Before(end); {
+			stop := endOfELDDay(start)
+			if stop.After(end) {
+				stop = end
+			}
+			d := day(start)
+			mins := int(stop.Sub(start).Minutes())
+			// Off duty and sleeper berth time only marks the day as covered
+			switch e.code {
+			case eldDutyDriving:
+				d.DrivingMins += mins
+				d.OnDutyMins += mins
+			case eldDutyOnDuty:
+				d.OnDutyMins += mins
+			}
+			start = stop
+		}
+	}
+
+	for _, d := range totals {
+		transfer.days = append(transfer.days, *d)
+	}
+	sort.Slice(transfer.days, func(i, j int) bool { return transfer.days[i].DutyDate.Before(transfer.days[j].DutyDate) })
+	return transfer, nil
+}
+
+// parseELDTime reads an ELD MMDDYY date and HHMMSS time
+func parseELDTime(date, clock string) (time.Time, error) {
+	return time.Parse("010206150405", date+clock)
+}
+
+func endOfELDDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+)
+
+type mockPriorDutyRepo struct {
+	days []domain.PriorDutyDay
+}
+
+func (m *mockPriorDutyRepo) Upsert(ctx context.Context, day *domain.PriorDutyDay) error {
+	m.days = append(m.days, *day)
+	return nil
+}
+
+func (m *mockPriorDutyRepo) GetByDriverID(ctx context.Context, driverID uuid.UUID, from, to time.Time) ([]domain.PriorDutyDay, error) {
+	var days []domain.PriorDutyDay
+	for _, d := range m.days {
+		if d.DriverID == driverID && !d.DutyDate.Before(from) && d.DutyDate.Before(to) {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+const sampleELDFile = `ELD File Header Segment:
+DOE,JOHN,jdoe,CA,D1234567,1A
+,,,,,00
+TRK42,1FUJGLDR5CLBP8834,TRL7,2B
+1234567,ACME DRAYAGE,7,000000,08,3C
+BOL9981,0,4D
+060524,140000,33.7701,-118.1937,120345,5120.5,5E
+ELD Event List:
+1,1,2,1,4,060324,060000,120000,5100.0,33.77,-118.19,0,1,1,0,0,AA,01
+2,1,2,1,3,060324,063000,120010,5100.5,33.77,-118.19,0,1,1,0,0,AA,02
+3,1,2,1,1,060324,120000,120200,5106.0,33.80,-118.20,0,1,1,0,0,AA,03
+4,2,2,1,3,060324,130000,120250,5107.0,33.80,-118.20,0,1,1,0,0,AA,04
+5,1,2,1,4,060324,220000,120300,5107.0,33.80,-118.20,0,1,1,0,0,AA,05
+6,1,2,1,2,060424,020000,120300,5111.0,33.80,-118.20,0,1,1,0,0,AA,06
+7,1,2,1,3,060524,080000,120300,5117.0,33.80,-118.20,0,1,1,0,0,AA,07
+End of File:
+`
+
+func TestParseELDOutputFile(t *testing.T) {
+	transfer, err := parseELDOutputFile(strings.NewReader(sampleELDFile))
+	if err != nil {
+		t.Fatalf("parseELDOutputFile: %v", err)
+	}
+
+	if transfer.carrierDOT != "1234567" || transfer.carrierName != "ACME DRAYAGE" {
+		t.Errorf("carrier = %q %q, want 1234567 ACME DRAYAGE", transfer.carrierDOT, transfer.carrierName)
+	}
+
+	// Jun 3: on duty 06:00-06:30, driving 06:30-12:00, off, on duty 22:00-24:00
+	// (the inactive edited record at 13:00 is ignored). Jun 4: on duty until
+	// 02:00, then sleeper. Jun 5: sleeper until 08:00, driving until the file
+	// was created at 14:00.
+	want := []struct {
+		date    string
+		onDuty  int
+		driving int
+	}{
+		{"2024-06-03", 30 + 330 + 120, 330},
+		{"2024-06-04", 120, 0},
+		{"2024-06-05", 360, 360},
+	}
+	if len(transfer.days) != len(want) {
+		t.Fatalf("got %d days, want %d", len(transfer.days), len(want))
+	}
+	for i, w := range want {
+		d := transfer.days[i]
+		if got := d.DutyDate.Format("2006-01-02"); got != w.date {
+			t.Errorf("days[%d].DutyDate = %s, want %s", i, got, w.date)
+		}
+		if d.OnDutyMins != w.onDuty {
+			t.Errorf("%s OnDutyMins = %d, want %d", w.date, d.OnDutyMins, w.onDuty)
+		}
+		if d.DrivingMins != w.driving {
+			t.Errorf("%s DrivingMins = %d, want %d", w.date, d.DrivingMins, w.driving)
+		}
+	}
+}
+
+func TestParseELDOutputFile_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"not an ELD file", "driver,date,hours\nJohn,2024-06-03,8\n"},
+		{"no carrier", "ELD File Header Segment:\nDOE,JOHN\nELD Event List:\n1,1,2,1,4,060324,060000\n"},
+		{"no duty events", strings.Split(sampleELDFile, "ELD Event List:")[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseELDOutputFile(strings.NewReader(tt.file)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetCycleDutyMins_IncludesPriorDutyDuringFirstEightDays(t *testing.T) {
+	svc, driverRepo, _, _, _ := createTestService()
+	priorRepo := &mockPriorDutyRepo{}
+	svc.priorDutyRepo = priorRepo
+	ctx := context.Background()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	driverID := uuid.New()
+	hired := today.AddDate(0, 0, -2)
+	driverRepo.drivers[driverID] = &domain.Driver{ID: driverID, HireDate: &hired}
+
+	priorRepo.days = []domain.PriorDutyDay{
+		{DriverID: driverID, DutyDate: hired.AddDate(0, 0, -1), OnDutyMins: 600},
+		{DriverID: driverID, DutyDate: hired.AddDate(0, 0, -3), OnDutyMins: 540},
+		// Before the 8-day window
+		{DriverID: driverID, DutyDate: today.AddDate(0, 0, -10), OnDutyMins: 700},
+	}
+
+	mins, err := svc.getCycleDutyMins(ctx, driverID)
+	if err != nil {
+		t.Fatalf("getCycleDutyMins: %v", err)
+	}
+	if mins != 1140 {
+		t.Errorf("cycle mins = %d, want 1140", mins)
+	}
+
+	// Past the first 8 days of employment prior hours no longer count
+	hired = today.AddDate(0, 0, -8)
+	driverRepo.drivers[driverID].HireDate = &hired
+	mins, err = svc.getCycleDutyMins(ctx, driverID)
+	if err != nil {
+		t.Fatalf("getCycleDutyMins: %v", err)
+	}
+	if mins != 0 {
+		t.Errorf("cycle mins after 8 days = %d, want 0", mins)
+	}
+}
+
+func TestPriorDutyRange(t *testing.T) {
+	hired := time.Date(2024, 6, 10, 15, 30, 0, 0, time.UTC)
+	first, end := priorDutyRange(&domain.Driver{HireDate: &hired})
+
+	if want := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC); !first.Equal(want) {
+		t.Errorf("first = %v, want %v", first, want)
+	}
+	if want := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("end = %v, want %v", end, want)
+	}
+}
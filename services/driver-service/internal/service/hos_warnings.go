@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/services/driver-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// PushMessage is a notification shown on the driver's mobile app
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string // Passed to the app with the notification
+}
+
+// PushSender delivers notifications to a driver's device through the push
+// provider the mobile app is registered with
+type PushSender interface {
+	Send(ctx context.Context, deviceToken string, message PushMessage) error
+}
+
+// HOSWarningService pushes countdown warnings to drivers before their
+// driving limit or duty window runs out, so violations are not a surprise
+type HOSWarningService struct {
+	drivers     *DriverService
+	warningRepo repository.HOSWarningRepository
+	push        PushSender
+	producer    *kafka.Producer
+	logger      *logger.Logger
+	rules       config.HOSWarningRules
+}
+
+// NewHOSWarningService creates a new HOS warning service
+func NewHOSWarningService(
+	drivers *DriverService,
+	warningRepo repository.HOSWarningRepository,
+	push PushSender,
+	producer *kafka.Producer,
+	log *logger.Logger,
+) *HOSWarningService {
+	return &HOSWarningService{
+		drivers:     drivers,
+		warningRepo: warningRepo,
+		push:        push,
+		producer:    producer,
+		logger:      log,
+		rules:       config.DefaultBusinessRules().HOSWarnings,
+	}
+}
+
+// Start checks on-duty drivers every interval until ctx is cancelled
+func (s *HOSWarningService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.logger.Infow("HOS warning monitor started", "interval", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CheckDrivers(ctx); err != nil {
+				s.logger.Errorw("HOS warning check failed", "error", err)
+			}
+		}
+	}
+}
+
+// CheckDrivers sends any warnings that are due to drivers whose clocks are running
+func (s *HOSWarningService) CheckDrivers(ctx context.Context) error {
+	drivers, err := s.drivers.driverRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list drivers: %w", err)
+	}
+
+	for i := range drivers {
+		driver := &drivers[i]
+		if driver.Status == domain.DriverStatusInactive || driver.Status == domain.DriverStatusOffDuty {
+			continue
+		}
+		if _, err := s.CheckDriver(ctx, driver); err != nil {
+			s.logger.Warnw("Failed to check driver HOS warnings", "driver_id", driver.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// CheckDriver sends the warnings due to one driver and returns them
+func (s *HOSWarningService) CheckDriver(ctx context.Context, driver *domain.Driver) ([]domain.HOSWarning, error) {
+	current, err := s.drivers.hosLogRepo.GetCurrentStatus(ctx, driver.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current HOS status: %w", err)
+	}
+	if current == nil || !current.Status.CountsAsOnDuty() {
+		return nil, nil
+	}
+
+	available, err := s.drivers.CalculateAvailableTime(ctx, driver.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate available time: %w", err)
+	}
+
+	at := available.CalculatedAt
+	windowStart := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	sent, err := s.warningRepo.GetByDriverSince(ctx, driver.ID, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent warnings: %w", err)
+	}
+
+	due := dueHOSWarnings(available, current.Status, s.rules.ThresholdsMins, sent)
+	for i := range due {
+		warning := &due[i]
+		warning.ID = uuid.New()
+		warning.DriverID = driver.ID
+		warning.WindowStart = windowStart
+		warning.CreatedAt = time.Now()
+		s.deliver(ctx, driver, warning)
+	}
+	return due, nil
+}
+
+// deliver pushes a warning to the driver and logs the attempt. Failed
+// deliveries are logged too so the threshold is not retried every check.
+func (s *HOSWarningService) deliver(ctx context.Context, driver *domain.Driver, warning *domain.HOSWarning) {
+	switch {
+	case driver.DeviceToken == "":
+		warning.DeliveryError = "driver has no registered device"
+	case s.push == nil:
+		warning.DeliveryError = "push notifications are not configured"
+	default:
+		if err := s.push.Send(ctx, driver.DeviceToken, hosWarningMessage(warning)); err != nil {
+			warning.DeliveryError = err.Error()
+		} else {
+			warning.Delivered = true
+		}
+	}
+
+	if err := s.warningRepo.Create(ctx, warning); err != nil {
+		s.logger.Errorw("Failed to log HOS warning", "driver_id", driver.ID, "clock", warning.Clock, "error", err)
+	}
+
+	s.logger.Infow("HOS warning sent",
+		"driver_id", driver.ID,
+		"clock", warning.Clock,
+		"remaining_mins", warning.RemainingMins,
+		"delivered", warning.Delivered,
+		"delivery_error", warning.DeliveryError,
+	)
+
+	if s.producer != nil {
+		event := kafka.NewEvent(kafka.Topics.HOSWarning, "driver-service", map[string]interface{}{
+			"driver_id":      driver.ID.String(),
+			"clock":          string(warning.Clock),
+			"threshold_mins": warning.ThresholdMins,
+			"remaining_mins": warning.RemainingMins,
+			"limit_at":       warning.LimitAt,
+			"delivered":      warning.Delivered,
+		})
+		_ = s.producer.Publish(ctx, kafka.Topics.HOSWarning, event)
+	}
+}
+
+// dueHOSWarnings returns the warnings to send for clocks that are running in
+// the driver's current status. Only the tightest threshold crossed is sent,
+// so a driver who jumps from 2h to 25m left gets one 30-minute warning, and
+// nothing is sent for a threshold at or above one already sent this window.
+func dueHOSWarnings(available *AvailableTime, status domain.HOSStatus, thresholds []int, sent []domain.HOSWarning) []domain.HOSWarning {
+	remaining := map[domain.HOSClock]int{}
+	if status.CountsAsDriving() {
+		remaining[domain.HOSClockDrive] = available.AvailableDriveMins
+	}
+	if status.CountsAsOnDuty() {
+		remaining[domain.HOSClockDuty] = available.AvailableDutyMins
+	}
+
+	sorted := append([]int(nil), thresholds...)
+	sort.Ints(sorted)
+
+	var due []domain.HOSWarning
+	for _, clock := range []domain.HOSClock{domain.HOSClockDrive, domain.HOSClockDuty} {
+		mins, running := remaining[clock]
+		if !running || mins <= 0 {
+			continue
+		}
+
+		crossed := 0
+		for _, t := range sorted {
+			if mins <= t {
+				crossed = t
+				break
+			}
+		}
+		if crossed == 0 || alreadyWarned(sent, clock, crossed) {
+			continue
+		}
+
+		due = append(due, domain.HOSWarning{
+			Clock:         clock,
+			ThresholdMins: crossed,
+			RemainingMins: mins,
+			LimitAt:       available.CalculatedAt.Add(time.Duration(mins) * time.Minute),
+		})
+	}
+	return due
+}
+
+// alreadyWarned checks if a warning at or below threshold was sent for clock
+func alreadyWarned(sent []domain.HOSWarning, clock domain.HOSClock, threshold int) bool {
+	for _, w := range sent {
+		if w.Clock == clock && w.ThresholdMins <= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// hosWarningMessage builds the push notification for a warning
+func hosWarningMessage(warning *domain.HOSWarning) PushMessage {
+	limit := "11-hour driving limit"
+	if warning.Clock == domain.HOSClockDuty {
+		limit = "14-hour duty window"
+	}
+	return PushMessage{
+		Title: fmt.Sprintf("%s left on your %s", formatMins(warning.RemainingMins), limit),
+		Body: fmt.Sprintf("Your %s ends at %s. Plan your stop now to stay legal.",
+			limit, warning.LimitAt.Format("3:04 PM")),
+		Data: map[string]string{
+			"type":           "HOS_WARNING",
+			"clock":          string(warning.Clock),
+			"remaining_mins": fmt.Sprintf("%d", warning.RemainingMins),
+			"limit_at":       warning.LimitAt.Format(time.RFC3339),
+		},
+	}
+}
+
+// formatMins renders minutes as "1h 45m", "2h" or "30m"
+func formatMins(mins int) string {
+	h, m := mins/60, mins%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+}
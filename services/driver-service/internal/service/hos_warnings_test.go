@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+)
+
+func TestDueHOSWarnings(t *testing.T) {
+	now := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	thresholds := []int{120, 60, 30}
+
+	tests := []struct {
+		name      string
+		driveMins int
+		dutyMins  int
+		status    domain.HOSStatus
+		sent      []domain.HOSWarning
+		want      map[domain.HOSClock]int // clock -> threshold
+	}{
+		{
+			name:      "Plenty of time left",
+			driveMins: 300, dutyMins: 400,
+			status: domain.HOSStatusDriving,
+			want:   map[domain.HOSClock]int{},
+		},
+		{
+			name:      "Drive clock crosses 2 hours",
+			driveMins: 115, dutyMins: 300,
+			status: domain.HOSStatusDriving,
+			want:   map[domain.HOSClock]int{domain.HOSClockDrive: 120},
+		},
+		{
+			name:      "Only the tightest threshold is sent",
+			driveMins: 25, dutyMins: 300,
+			status: domain.HOSStatusDriving,
+			want:   map[domain.HOSClock]int{domain.HOSClockDrive: 30},
+		},
+		{
+			name:      "Threshold already sent this window",
+			driveMins: 100, dutyMins: 300,
+			status: domain.HOSStatusDriving,
+			sent:   []domain.HOSWarning{{Clock: domain.HOSClockDrive, ThresholdMins: 120}},
+			want:   map[domain.HOSClock]int{},
+		},
+		{
+			name:      "Next threshold after an earlier one",
+			driveMins: 55, dutyMins: 300,
+			status: domain.HOSStatusDriving,
+			sent:   []domain.HOSWarning{{Clock: domain.HOSClockDrive, ThresholdMins: 120}},
+			want:   map[domain.HOSClock]int{domain.HOSClockDrive: 60},
+		},
+		{
+			name:      "Drive clock is paused while on duty not driving",
+			driveMins: 50, dutyMins: 90,
+			status: domain.HOSStatusOnDutyNotDriv,
+			want:   map[domain.HOSClock]int{domain.HOSClockDuty: 120},
+		},
+		{
+			name:      "No warnings off duty",
+			driveMins: 20, dutyMins: 20,
+			status: domain.HOSStatusOffDuty,
+			want:   map[domain.HOSClock]int{},
+		},
+		{
+			name:      "Clock already run out",
+			driveMins: 0, dutyMins: 200,
+			status: domain.HOSStatusDriving,
+			want:   map[domain.HOSClock]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available := &AvailableTime{
+				AvailableDriveMins: tt.driveMins,
+				AvailableDutyMins:  tt.dutyMins,
+				CalculatedAt:       now,
+			}
+
+			due := dueHOSWarnings(available, tt.status, thresholds, tt.sent)
+
+			if len(due) != len(tt.want) {
+				t.Fatalf("dueHOSWarnings() returned %d warnings, want %d: %+v", len(due), len(tt.want), due)
+			}
+			for _, w := range due {
+				if tt.want[w.Clock] != w.ThresholdMins {
+					t.Errorf("dueHOSWarnings() %s threshold = %d, want %d", w.Clock, w.ThresholdMins, tt.want[w.Clock])
+				}
+				if !w.LimitAt.Equal(now.Add(time.Duration(w.RemainingMins) * time.Minute)) {
+					t.Errorf("dueHOSWarnings() %s limit at = %v", w.Clock, w.LimitAt)
+				}
+			}
+		})
+	}
+}
+
+func TestHOSWarningMessage(t *testing.T) {
+	msg := hosWarningMessage(&domain.HOSWarning{
+		Clock:         domain.HOSClockDuty,
+		RemainingMins: 105,
+		LimitAt:       time.Date(2024, 3, 4, 15, 45, 0, 0, time.UTC),
+	})
+
+	if msg.Title != "1h 45m left on your 14-hour duty window" {
+		t.Errorf("hosWarningMessage() title = %q", msg.Title)
+	}
+	if !strings.Contains(msg.Body, "3:45 PM") {
+		t.Errorf("hosWarningMessage() body = %q, want limit time", msg.Body)
+	}
+	if msg.Data["clock"] != "DUTY" || msg.Data["remaining_mins"] != "105" {
+		t.Errorf("hosWarningMessage() data = %v", msg.Data)
+	}
+}
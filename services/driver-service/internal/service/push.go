@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPPushSender delivers push notifications through a notification gateway
+// that fans out to FCM and APNs by device token
+type HTTPPushSender struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPPushSender creates a push sender for the gateway at url
+func NewHTTPPushSender(url, apiKey string) *HTTPPushSender {
+	return &HTTPPushSender{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts one notification to the gateway
+func (p *HTTPPushSender) Send(ctx context.Context, deviceToken string, message PushMessage) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"to":    deviceToken,
+		"title": message.Title,
+		"body":  message.Body,
+		"data":  message.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("push gateway returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+	"github.com/draymaster/services/driver-service/internal/repository"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// TractorSessionService tracks which drivers are logged on to each tractor
+// and attributes ELD driving to the driver at the wheel, so a team partner or
+// the next slip-seat driver is not charged with someone else's hours
+type TractorSessionService struct {
+	drivers     *DriverService
+	sessionRepo repository.TractorSessionRepository
+	segmentRepo repository.DrivingSegmentRepository
+	producer    *kafka.Producer
+	logger      *logger.Logger
+}
+
+// NewTractorSessionService creates a new tractor session service
+func NewTractorSessionService(
+	drivers *DriverService,
+	sessionRepo repository.TractorSessionRepository,
+	segmentRepo repository.DrivingSegmentRepository,
+	producer *kafka.Producer,
+	log *logger.Logger,
+) *TractorSessionService {
+	return &TractorSessionService{
+		drivers:     drivers,
+		sessionRepo: sessionRepo,
+		segmentRepo: segmentRepo,
+		producer:    producer,
+		logger:      log,
+	}
+}
+
+// =============================================================================
+// SESSIONS
+// =============================================================================
+
+// TractorLoginInput contains input for logging a driver on to a tractor
+type TractorLoginInput struct {
+	TractorID uuid.UUID          `json:"tractor_id"`
+	DriverID  uuid.UUID          `json:"driver_id"`
+	Seat      domain.SessionSeat `json:"seat"` // Defaults to DRIVER
+	Team      bool               `json:"team"`
+	Source    string             `json:"source"` // app, eld, dispatch
+	At        time.Time          `json:"at"`     // Defaults to now; offline app logins arrive backdated
+}
+
+// TractorLogoutInput contains input for logging a driver off a tractor
+type TractorLogoutInput struct {
+	TractorID uuid.UUID `json:"tractor_id"`
+	DriverID  uuid.UUID `json:"driver_id"`
+	At        time.Time `json:"at"`
+}
+
+// sessionChange is an open session a login ends, optionally reopening the
+// driver in another seat from the same time
+type sessionChange struct {
+	session *domain.TractorSession
+	reason  string
+	reseat  domain.SessionSeat // Seat to reopen in; empty leaves the driver logged off
+}
+
+// Login logs a driver on to a tractor. The driver's other sessions end. On a
+// slip-seat tractor the previous driver is logged off; on a team tractor a
+// driver taking the wheel moves the partner to the co-driver seat.
+func (s *TractorSessionService) Login(ctx context.Context, input TractorLoginInput) (*domain.TractorSession, error) {
+	if input.TractorID == uuid.Nil || input.DriverID == uuid.Nil {
+		return nil, fmt.Errorf("tractor_id and driver_id are required")
+	}
+	if input.Seat == "" {
+		input.Seat = domain.SeatDriver
+	}
+	if input.Seat != domain.SeatDriver && input.Seat != domain.SeatCoDriver {
+		return nil, fmt.Errorf("invalid seat %q", input.Seat)
+	}
+	if input.At.IsZero() {
+		input.At = time.Now()
+	}
+
+	driver, err := s.drivers.driverRepo.GetByID(ctx, input.DriverID)
+	if err != nil || driver == nil {
+		return nil, fmt.Errorf("driver not found: %s", input.DriverID)
+	}
+
+	own, err := s.sessionRepo.GetActiveByDriver(ctx, input.DriverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver sessions: %w", err)
+	}
+	onTractor, err := s.sessionRepo.GetActiveByTractor(ctx, input.TractorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tractor sessions: %w", err)
+	}
+
+	for i := range own {
+		if own[i].TractorID == input.TractorID && own[i].Seat == input.Seat {
+			return &own[i], nil // Already logged on
+		}
+	}
+
+	changes, err := loginChanges(own, onTractor, input)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if err := s.endSession(ctx, change.session, input.At, change.reason); err != nil {
+			return nil, err
+		}
+		if change.reseat != "" {
+			if _, err := s.startSession(ctx, change.session.TractorID, change.session.DriverID, change.reseat,
+				change.session.Team, change.session.Source, input.At); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.startSession(ctx, input.TractorID, input.DriverID, input.Seat, input.Team, input.Source, input.At)
+}
+
+// loginChanges works out which open sessions a login ends. A session that
+// started after the login time is left open: the login is backdated past a
+// newer one, and driving in the overlap is held for review instead.
+func loginChanges(own, onTractor []domain.TractorSession, input TractorLoginInput) ([]sessionChange, error) {
+	var changes []sessionChange
+
+	for i := range own {
+		session := &own[i]
+		if session.StartedAt.After(input.At) {
+			continue
+		}
+		reason := domain.SessionEndMoved
+		if session.TractorID == input.TractorID {
+			reason = domain.SessionEndSeatSwap
+		}
+		changes = append(changes, sessionChange{session: session, reason: reason})
+	}
+
+	partners := 0
+	for i := range onTractor {
+		session := &onTractor[i]
+		if session.DriverID == input.DriverID || session.StartedAt.After(input.At) {
+			continue
+		}
+		switch {
+		case !input.Team || !session.Team:
+			// Slip seat: the tractor changes hands
+			changes = append(changes, sessionChange{session: session, reason: domain.SessionEndSuperseded})
+		case input.Seat == domain.SeatDriver && session.Seat == domain.SeatDriver:
+			partners++
+			changes = append(changes, sessionChange{session: session, reason: domain.SessionEndSeatSwap, reseat: domain.SeatCoDriver})
+		default:
+			partners++
+		}
+	}
+	if partners > 1 {
+		return nil, fmt.Errorf("tractor already has a team of two logged on")
+	}
+
+	return changes, nil
+}
+
+// Logout logs a driver off a tractor
+func (s *TractorSessionService) Logout(ctx context.Context, input TractorLogoutInput) error {
+	if input.At.IsZero() {
+		input.At = time.Now()
+	}
+
+	own, err := s.sessionRepo.GetActiveByDriver(ctx, input.DriverID)
+	if err != nil {
+		return fmt.Errorf("failed to get driver sessions: %w", err)
+	}
+
+	found := false
+	for i := range own {
+		if own[i].TractorID != input.TractorID {
+			continue
+		}
+		found = true
+		if err := s.endSession(ctx, &own[i], input.At, domain.SessionEndLogout); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("driver %s is not logged on to tractor %s", input.DriverID, input.TractorID)
+	}
+	return nil
+}
+
+// GetTractorSessions returns the drivers logged on to a tractor
+func (s *TractorSessionService) GetTractorSessions(ctx context.Context, tractorID uuid.UUID) ([]domain.TractorSession, error) {
+	return s.sessionRepo.GetActiveByTractor(ctx, tractorID)
+}
+
+func (s *TractorSessionService) startSession(ctx context.Context, tractorID, driverID uuid.UUID, seat domain.SessionSeat, team bool, source string, at time.Time) (*domain.TractorSession, error) {
+	session := &domain.TractorSession{
+		ID:        uuid.New(),
+		TractorID: tractorID,
+		DriverID:  driverID,
+		Seat:      seat,
+		Team:      team,
+		Source:    source,
+		StartedAt: at,
+		CreatedAt: time.Now(),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to start tractor session: %w", err)
+	}
+
+	s.logger.Infow("Driver logged on to tractor",
+		"driver_id", driverID,
+		"tractor_id", tractorID,
+		"seat", seat,
+		"team", team,
+	)
+	s.publishSession(ctx, session)
+	return session, nil
+}
+
+func (s *TractorSessionService) endSession(ctx context.Context, session *domain.TractorSession, at time.Time, reason string) error {
+	if err := s.sessionRepo.End(ctx, session.ID, at, reason); err != nil {
+		return fmt.Errorf("failed to end tractor session: %w", err)
+	}
+	session.EndedAt = &at
+	session.EndReason = reason
+
+	s.logger.Infow("Driver logged off tractor",
+		"driver_id", session.DriverID,
+		"tractor_id", session.TractorID,
+		"seat", session.Seat,
+		"reason", reason,
+	)
+	s.publishSession(ctx, session)
+	return nil
+}
+
+func (s *TractorSessionService) publishSession(ctx context.Context, session *domain.TractorSession) {
+	if s.producer == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"session_id": session.ID.String(),
+		"tractor_id": session.TractorID.String(),
+		"driver_id":  session.DriverID.String(),
+		"seat":       string(session.Seat),
+		"team":       session.Team,
+		"started_at": session.StartedAt,
+	}
+	if session.EndedAt != nil {
+		data["ended_at"] = *session.EndedAt
+		data["end_reason"] = session.EndReason
+	}
+	event := kafka.NewEvent(kafka.Topics.TractorSessionChanged, "driver-service", data)
+	_ = s.producer.Publish(ctx, kafka.Topics.TractorSessionChanged, event)
+}
+
+// =============================================================================
+// ELD DRIVING ATTRIBUTION
+// =============================================================================
+
+// DrivingSegmentInput is a stretch of driving reported by a tractor's ELD
+type DrivingSegmentInput struct {
+	TractorID        uuid.UUID  `json:"tractor_id"`
+	ExternalID       string     `json:"external_id"`
+	ReportedDriverID *uuid.UUID `json:"driver_id"` // Driver logged in on the ELD, if any
+	StartTime        time.Time  `json:"start_time"`
+	EndTime          *time.Time `json:"end_time"`
+	Location         string     `json:"location"`
+	Latitude         float64    `json:"latitude"`
+	Longitude        float64    `json:"longitude"`
+	Odometer         int        `json:"odometer"`
+	EngineHours      float64    `json:"engine_hours"`
+}
+
+// ResolveSegmentInput contains a dispatcher's assignment of a held segment
+type ResolveSegmentInput struct {
+	DriverID   uuid.UUID `json:"driver_id"`
+	ResolvedBy string    `json:"-"`
+}
+
+// segmentAttribution is the driver a segment belongs to and why
+type segmentAttribution struct {
+	attribution domain.SegmentAttribution
+	driverID    *uuid.UUID
+	session     *domain.TractorSession
+	note        string
+}
+
+// RecordDrivingSegment attributes ELD driving to the driver at the wheel and
+// records it on their log. Segments no session accounts for are held for
+// review and kept off every driver's log. A segment sent again with the same
+// external ID only closes the driving when it has ended.
+func (s *TractorSessionService) RecordDrivingSegment(ctx context.Context, input DrivingSegmentInput) (*domain.DrivingSegment, error) {
+	if input.TractorID == uuid.Nil || input.StartTime.IsZero() {
+		return nil, fmt.Errorf("tractor_id and start_time are required")
+	}
+
+	if input.ExternalID != "" {
+		existing, err := s.segmentRepo.GetByExternalID(ctx, input.TractorID, input.ExternalID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get driving segment: %w", err)
+		}
+		if existing != nil {
+			return s.closeSegment(ctx, existing, input.EndTime)
+		}
+	}
+
+	sessions, err := s.sessionRepo.GetByTractorAt(ctx, input.TractorID, input.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tractor sessions: %w", err)
+	}
+	attributed := attributeSegment(sessions, input.ReportedDriverID)
+
+	segment := &domain.DrivingSegment{
+		ID:               uuid.New(),
+		TractorID:        input.TractorID,
+		ExternalID:       input.ExternalID,
+		ReportedDriverID: input.ReportedDriverID,
+		DriverID:         attributed.driverID,
+		Attribution:      attributed.attribution,
+		Note:             attributed.note,
+		StartTime:        input.StartTime,
+		EndTime:          input.EndTime,
+		Location:         input.Location,
+		Latitude:         input.Latitude,
+		Longitude:        input.Longitude,
+		Odometer:         input.Odometer,
+		EngineHours:      input.EngineHours,
+		CreatedAt:        time.Now(),
+	}
+	if attributed.session != nil {
+		segment.SessionID = &attributed.session.ID
+	}
+
+	if segment.DriverID != nil {
+		if err := s.recordDriving(ctx, segment); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.segmentRepo.Create(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to save driving segment: %w", err)
+	}
+
+	switch {
+	case segment.NeedsReview():
+		s.logger.Warnw("ELD driving held for review",
+			"segment_id", segment.ID,
+			"tractor_id", segment.TractorID,
+			"attribution", segment.Attribution,
+			"note", segment.Note,
+		)
+		s.publishReview(ctx, segment)
+	case segment.Attribution == domain.AttributionReattributed:
+		s.logger.Warnw("ELD driving reattributed to session driver",
+			"segment_id", segment.ID,
+			"tractor_id", segment.TractorID,
+			"reported_driver_id", segment.ReportedDriverID,
+			"driver_id", segment.DriverID,
+		)
+	}
+
+	return segment, nil
+}
+
+// attributeSegment picks the driver for driving that started while the given
+// sessions were logged on. The driver seat wins; a lone co-driver is at the
+// wheel. Overlapping driver-seat sessions are settled by the ELD login when it
+// names one of them and are otherwise a conflict.
+func attributeSegment(sessions []domain.TractorSession, reported *uuid.UUID) segmentAttribution {
+	var atWheel []*domain.TractorSession
+	for i := range sessions {
+		if sessions[i].Seat == domain.SeatDriver {
+			atWheel = append(atWheel, &sessions[i])
+		}
+	}
+	if len(atWheel) == 0 && len(sessions) == 1 {
+		atWheel = append(atWheel, &sessions[0])
+	}
+
+	switch len(atWheel) {
+	case 0:
+		if reported != nil {
+			return segmentAttribution{
+				attribution: domain.AttributionELD,
+				driverID:    reported,
+				note:        "no driver at the wheel; used the ELD login",
+			}
+		}
+		return segmentAttribution{
+			attribution: domain.AttributionUnassigned,
+			note:        "no driver logged on to the tractor or the ELD",
+		}
+
+	case 1:
+		session := atWheel[0]
+		result := segmentAttribution{
+			attribution: domain.AttributionSession,
+			driverID:    &session.DriverID,
+			session:     session,
+		}
+		if reported != nil && *reported != session.DriverID {
+			result.attribution = domain.AttributionReattributed
+			result.note = fmt.Sprintf("ELD login was %s; %s was at the wheel", *reported, session.DriverID)
+		}
+		return result
+
+	default:
+		ids := make([]string, len(atWheel))
+		for i, session := range atWheel {
+			if reported != nil && *reported == session.DriverID {
+				return segmentAttribution{
+					attribution: domain.AttributionSession,
+					driverID:    &session.DriverID,
+					session:     session,
+					note:        "overlapping sessions settled by the ELD login",
+				}
+			}
+			ids[i] = session.DriverID.String()
+		}
+		return segmentAttribution{
+			attribution: domain.AttributionConflict,
+			note:        "overlapping driver sessions: " + strings.Join(ids, ", "),
+		}
+	}
+}
+
+// GetSegmentsForReview returns driving held until a dispatcher assigns it
+func (s *TractorSessionService) GetSegmentsForReview(ctx context.Context) ([]domain.DrivingSegment, error) {
+	return s.segmentRepo.GetPendingReview(ctx)
+}
+
+// ResolveSegment assigns held driving to a driver and records it on their
+// log. For overlapping sessions the other drivers' sessions are cut off at
+// the start of the segment, so later driving is attributed without review.
+func (s *TractorSessionService) ResolveSegment(ctx context.Context, segmentID uuid.UUID, input ResolveSegmentInput) (*domain.DrivingSegment, error) {
+	segment, err := s.segmentRepo.GetByID(ctx, segmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driving segment: %w", err)
+	}
+	if segment == nil {
+		return nil, fmt.Errorf("driving segment not found: %s", segmentID)
+	}
+	if !segment.NeedsReview() {
+		return nil, fmt.Errorf("driving segment is already attributed (%s)", segment.Attribution)
+	}
+	if driver, err := s.drivers.driverRepo.GetByID(ctx, input.DriverID); err != nil || driver == nil {
+		return nil, fmt.Errorf("driver not found: %s", input.DriverID)
+	}
+
+	sessions, err := s.sessionRepo.GetByTractorAt(ctx, segment.TractorID, segment.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tractor sessions: %w", err)
+	}
+	for i := range sessions {
+		session := &sessions[i]
+		if session.DriverID == input.DriverID {
+			segment.SessionID = &session.ID
+			continue
+		}
+		if segment.Attribution != domain.AttributionConflict || session.Seat != domain.SeatDriver {
+			continue
+		}
+		if err := s.endSession(ctx, session, segment.StartTime, domain.SessionEndResolved); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	segment.DriverID = &input.DriverID
+	segment.Attribution = domain.AttributionResolved
+	segment.ResolvedBy = input.ResolvedBy
+	segment.ResolvedAt = &now
+	if err := s.recordDriving(ctx, segment); err != nil {
+		return nil, err
+	}
+	if err := s.segmentRepo.Update(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to update driving segment: %w", err)
+	}
+
+	s.logger.Infow("ELD driving assigned after review",
+		"segment_id", segment.ID,
+		"tractor_id", segment.TractorID,
+		"driver_id", input.DriverID,
+		"resolved_by", input.ResolvedBy,
+	)
+	return segment, nil
+}
+
+// closeSegment records the end of driving already attributed from an
+// earlier report of the same segment
+func (s *TractorSessionService) closeSegment(ctx context.Context, segment *domain.DrivingSegment, endTime *time.Time) (*domain.DrivingSegment, error) {
+	if endTime == nil || segment.EndTime != nil {
+		return segment, nil
+	}
+	segment.EndTime = endTime
+
+	if segment.DriverID != nil {
+		if _, err := s.drivers.RecordHOSStatus(ctx, RecordHOSInput{
+			DriverID:  *segment.DriverID,
+			Status:    domain.HOSStatusOnDutyNotDriv,
+			StartTime: *endTime,
+			TractorID: &segment.TractorID,
+			Source:    "eld",
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.segmentRepo.Update(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to update driving segment: %w", err)
+	}
+	return segment, nil
+}
+
+// recordDriving puts an attributed segment on the driver's log, followed by
+// on-duty time once the driving has ended. Violations are checked against
+// that driver only.
+func (s *TractorSessionService) recordDriving(ctx context.Context, segment *domain.DrivingSegment) error {
+	hosLog, err := s.drivers.RecordHOSStatus(ctx, RecordHOSInput{
+		DriverID:    *segment.DriverID,
+		Status:      domain.HOSStatusDriving,
+		StartTime:   segment.StartTime,
+		Location:    segment.Location,
+		Latitude:    segment.Latitude,
+		Longitude:   segment.Longitude,
+		Odometer:    segment.Odometer,
+		EngineHours: segment.EngineHours,
+		TractorID:   &segment.TractorID,
+		Notes:       fmt.Sprintf("ELD driving attributed by %s", strings.ToLower(string(segment.Attribution))),
+		Source:      "eld",
+	})
+	if err != nil {
+		return err
+	}
+	segment.HOSLogID = &hosLog.ID
+
+	if segment.EndTime != nil {
+		if _, err := s.drivers.RecordHOSStatus(ctx, RecordHOSInput{
+			DriverID:  *segment.DriverID,
+			Status:    domain.HOSStatusOnDutyNotDriv,
+			StartTime: *segment.EndTime,
+			TractorID: &segment.TractorID,
+			Source:    "eld",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TractorSessionService) publishReview(ctx context.Context, segment *domain.DrivingSegment) {
+	if s.producer == nil {
+		return
+	}
+	event := kafka.NewEvent(kafka.Topics.DrivingSegmentReview, "driver-service", map[string]interface{}{
+		"segment_id":  segment.ID.String(),
+		"tractor_id":  segment.TractorID.String(),
+		"attribution": string(segment.Attribution),
+		"note":        segment.Note,
+		"start_time":  segment.StartTime,
+	})
+	_ = s.producer.Publish(ctx, kafka.Topics.DrivingSegmentReview, event)
+}
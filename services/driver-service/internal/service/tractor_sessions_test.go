@@ -0,0 +1,189 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/driver-service/internal/domain"
+)
+
+func TestAttributeSegment(t *testing.T) {
+	tractorID := uuid.New()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+
+	session := func(driverID uuid.UUID, seat domain.SessionSeat) domain.TractorSession {
+		return domain.TractorSession{ID: uuid.New(), TractorID: tractorID, DriverID: driverID, Seat: seat}
+	}
+
+	tests := []struct {
+		name     string
+		sessions []domain.TractorSession
+		reported *uuid.UUID
+		want     domain.SegmentAttribution
+		driver   *uuid.UUID
+	}{
+		{
+			name:     "Single driver at the wheel",
+			sessions: []domain.TractorSession{session(alice, domain.SeatDriver)},
+			want:     domain.AttributionSession,
+			driver:   &alice,
+		},
+		{
+			name:     "ELD login agrees with the session",
+			sessions: []domain.TractorSession{session(alice, domain.SeatDriver)},
+			reported: &alice,
+			want:     domain.AttributionSession,
+			driver:   &alice,
+		},
+		{
+			name: "Team driving goes to the driver seat, not the ELD login",
+			sessions: []domain.TractorSession{
+				session(alice, domain.SeatCoDriver),
+				session(bob, domain.SeatDriver),
+			},
+			reported: &alice,
+			want:     domain.AttributionReattributed,
+			driver:   &bob,
+		},
+		{
+			name:     "Lone co-driver is at the wheel",
+			sessions: []domain.TractorSession{session(alice, domain.SeatCoDriver)},
+			want:     domain.AttributionSession,
+			driver:   &alice,
+		},
+		{
+			name:     "No session falls back to the ELD login",
+			reported: &carol,
+			want:     domain.AttributionELD,
+			driver:   &carol,
+		},
+		{
+			name: "No session and no ELD login",
+			want: domain.AttributionUnassigned,
+		},
+		{
+			name: "Overlapping sessions settled by the ELD login",
+			sessions: []domain.TractorSession{
+				session(alice, domain.SeatDriver),
+				session(bob, domain.SeatDriver),
+			},
+			reported: &bob,
+			want:     domain.AttributionSession,
+			driver:   &bob,
+		},
+		{
+			name: "Overlapping sessions without an ELD login",
+			sessions: []domain.TractorSession{
+				session(alice, domain.SeatDriver),
+				session(bob, domain.SeatDriver),
+			},
+			want: domain.AttributionConflict,
+		},
+		{
+			name: "Overlapping sessions with an unrelated ELD login",
+			sessions: []domain.TractorSession{
+				session(alice, domain.SeatDriver),
+				session(bob, domain.SeatDriver),
+			},
+			reported: &carol,
+			want:     domain.AttributionConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := attributeSegment(tt.sessions, tt.reported)
+			if got.attribution != tt.want {
+				t.Errorf("expected attribution %s, got %s", tt.want, got.attribution)
+			}
+			switch {
+			case tt.driver == nil && got.driverID != nil:
+				t.Errorf("expected no driver, got %s", *got.driverID)
+			case tt.driver != nil && (got.driverID == nil || *got.driverID != *tt.driver):
+				t.Errorf("expected driver %s, got %v", *tt.driver, got.driverID)
+			}
+			segment := domain.DrivingSegment{Attribution: got.attribution}
+			if segment.NeedsReview() != (tt.driver == nil) {
+				t.Errorf("expected needs review %v", tt.driver == nil)
+			}
+		})
+	}
+}
+
+func TestLoginChanges(t *testing.T) {
+	tractorID, otherTractorID := uuid.New(), uuid.New()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	now := time.Date(2024, 3, 4, 6, 0, 0, 0, time.UTC)
+
+	session := func(tractor, driverID uuid.UUID, seat domain.SessionSeat, team bool, started time.Time) domain.TractorSession {
+		return domain.TractorSession{ID: uuid.New(), TractorID: tractor, DriverID: driverID, Seat: seat, Team: team, StartedAt: started}
+	}
+
+	t.Run("Slip seat supersedes the previous driver", func(t *testing.T) {
+		onTractor := []domain.TractorSession{session(tractorID, alice, domain.SeatDriver, false, now.Add(-8*time.Hour))}
+		changes, err := loginChanges(nil, onTractor, TractorLoginInput{TractorID: tractorID, DriverID: bob, Seat: domain.SeatDriver, At: now})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(changes) != 1 || changes[0].reason != domain.SessionEndSuperseded || changes[0].reseat != "" {
+			t.Fatalf("expected previous driver superseded, got %+v", changes)
+		}
+	})
+
+	t.Run("Team partner moves to the co-driver seat", func(t *testing.T) {
+		onTractor := []domain.TractorSession{session(tractorID, alice, domain.SeatDriver, true, now.Add(-4*time.Hour))}
+		changes, err := loginChanges(nil, onTractor, TractorLoginInput{TractorID: tractorID, DriverID: bob, Seat: domain.SeatDriver, Team: true, At: now})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(changes) != 1 || changes[0].reason != domain.SessionEndSeatSwap || changes[0].reseat != domain.SeatCoDriver {
+			t.Fatalf("expected partner reseated as co-driver, got %+v", changes)
+		}
+	})
+
+	t.Run("Team co-driver login leaves the driver at the wheel", func(t *testing.T) {
+		onTractor := []domain.TractorSession{session(tractorID, alice, domain.SeatDriver, true, now.Add(-4*time.Hour))}
+		changes, err := loginChanges(nil, onTractor, TractorLoginInput{TractorID: tractorID, DriverID: bob, Seat: domain.SeatCoDriver, Team: true, At: now})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(changes) != 0 {
+			t.Fatalf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("Third team driver is rejected", func(t *testing.T) {
+		onTractor := []domain.TractorSession{
+			session(tractorID, alice, domain.SeatDriver, true, now.Add(-4*time.Hour)),
+			session(tractorID, bob, domain.SeatCoDriver, true, now.Add(-4*time.Hour)),
+		}
+		_, err := loginChanges(nil, onTractor, TractorLoginInput{TractorID: tractorID, DriverID: carol, Seat: domain.SeatCoDriver, Team: true, At: now})
+		if err == nil {
+			t.Fatal("expected error for a third team driver")
+		}
+	})
+
+	t.Run("Driver moving tractors ends the old session", func(t *testing.T) {
+		own := []domain.TractorSession{session(otherTractorID, alice, domain.SeatDriver, false, now.Add(-2*time.Hour))}
+		changes, err := loginChanges(own, nil, TractorLoginInput{TractorID: tractorID, DriverID: alice, Seat: domain.SeatDriver, At: now})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(changes) != 1 || changes[0].reason != domain.SessionEndMoved {
+			t.Fatalf("expected old tractor session moved, got %+v", changes)
+		}
+	})
+
+	t.Run("Backdated login leaves a newer session open", func(t *testing.T) {
+		onTractor := []domain.TractorSession{session(tractorID, alice, domain.SeatDriver, false, now.Add(-time.Hour))}
+		changes, err := loginChanges(nil, onTractor, TractorLoginInput{TractorID: tractorID, DriverID: bob, Seat: domain.SeatDriver, At: now.Add(-2 * time.Hour)})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(changes) != 0 {
+			t.Fatalf("expected the newer session left open, got %+v", changes)
+		}
+	})
+}
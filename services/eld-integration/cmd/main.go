@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
+	"github.com/draymaster/shared/pkg/logger"
+
+	"github.com/draymaster/services/eld-integration/internal/api"
+	"github.com/draymaster/services/eld-integration/internal/client"
+	"github.com/draymaster/services/eld-integration/internal/repository"
+	"github.com/draymaster/services/eld-integration/internal/service"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "eld-integration"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting ELD integration service",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Database
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+	log.Info("Database connected")
+
+	repo := repository.NewRepository(db.Pool)
+
+	// Kafka producer — duty status changes for driver-service, positions for tracking-service
+	kafkaProducer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
+	defer kafkaProducer.Close()
+	log.Info("Kafka producer initialized")
+
+	// ELD providers — each is enabled by its API credentials
+	var providers []client.ELDProvider
+	if token := getEnv("SAMSARA_API_TOKEN", ""); token != "" {
+		samsara, err := client.NewSamsaraClient(client.SamsaraConfig{
+			BaseURL:       getEnv("SAMSARA_BASE_URL", "https://api.samsara.com"),
+			APIToken:      token,
+			WebhookSecret: getEnv("SAMSARA_WEBHOOK_SECRET", ""),
+			Timeout:       getDuration("SAMSARA_TIMEOUT", 30*time.Second),
+		}, log)
+		if err != nil {
+			log.Fatalw("Invalid Samsara configuration", "error", err)
+		}
+		providers = append(providers, samsara)
+		log.Info("Samsara client initialized")
+	}
+	if key := getEnv("MOTIVE_API_KEY", ""); key != "" {
+		providers = append(providers, client.NewMotiveClient(client.MotiveConfig{
+			BaseURL:       getEnv("MOTIVE_BASE_URL", "https://api.gomotive.com"),
+			APIKey:        key,
+			WebhookSecret: getEnv("MOTIVE_WEBHOOK_SECRET", ""),
+			Timeout:       getDuration("MOTIVE_TIMEOUT", 30*time.Second),
+		}, log))
+		log.Info("Motive client initialized")
+	}
+	if len(providers) == 0 {
+		log.Warn("Neither SAMSARA_API_TOKEN nor MOTIVE_API_KEY set — no ELD data will be read")
+	}
+
+	eldService := service.NewELDService(repo, kafkaProducer, log)
+
+	// Sync poller — reads each provider on a timer, and at once when it sends a webhook
+	poller := service.NewSyncPoller(providers, eldService, repo, service.SyncConfig{
+		Interval: getDuration("ELD_SYNC_INTERVAL", time.Minute),
+		Overlap:  getDuration("ELD_SYNC_OVERLAP", 15*time.Minute),
+		Lookback: getDuration("ELD_SYNC_LOOKBACK", 24*time.Hour),
+	}, log)
+
+	// One replica syncs at a time. Webhook nudges received by the others are
+	// left for the holder's next sweep.
+	elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+	go elector.Run(ctx, "eld.sync", func(ctx context.Context) {
+		if err := poller.Start(ctx); err != nil && ctx.Err() == nil {
+			log.Fatalw("ELD sync poller failed", "error", err)
+		}
+	})
+	log.Info("ELD sync poller started")
+
+	// HTTP server — provider webhooks and link management
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      api.NewHandler(eldService, poller, providers, log).Routes(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("ELD integration service stopped")
+}
+
+func getEnv(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+func getDuration(key string, defaultVal time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return defaultVal
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/draymaster/services/eld-integration/internal/client"
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/services/eld-integration/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxWebhookBytes caps a webhook request body
+const maxWebhookBytes = 1 << 20
+
+// Handler serves provider webhooks and the link management API
+type Handler struct {
+	eld       *service.ELDService
+	poller    *service.SyncPoller
+	providers map[domain.Provider]client.ELDProvider
+	logger    *logger.Logger
+}
+
+// NewHandler creates a new ELD integration HTTP handler
+func NewHandler(eld *service.ELDService, poller *service.SyncPoller, providers []client.ELDProvider, log *logger.Logger) *Handler {
+	byName := make(map[domain.Provider]client.ELDProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Provider()] = p
+	}
+	return &Handler{eld: eld, poller: poller, providers: byName, logger: log}
+}
+
+// Routes returns the HTTP routes for the API
+//
+// Provider webhooks (signed by the provider):
+//
+//	POST  /webhooks/samsara       (X-Samsara-Signature; nudges a Samsara sync)
+//	POST  /webhooks/motive        (X-KT-Webhook-Signature; nudges a Motive sync)
+//
+// Links between provider accounts and ours:
+//
+//	GET|PUT  /v1/eld/driver-links   (PUT {provider, external_id, driver_id})
+//	GET|PUT  /v1/eld/vehicle-links  (PUT {provider, external_id, tractor_id})
+//	GET      /v1/eld/unmatched      (provider drivers and vehicles data arrived for with no link)
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	})
+	mux.HandleFunc("/webhooks/samsara", h.webhook(domain.ProviderSamsara))
+	mux.HandleFunc("/webhooks/motive", h.webhook(domain.ProviderMotive))
+	mux.HandleFunc("/v1/eld/driver-links", h.driverLinks)
+	mux.HandleFunc("/v1/eld/vehicle-links", h.vehicleLinks)
+	mux.HandleFunc("/v1/eld/unmatched", h.unmatched)
+	return mux
+}
+
+// webhook accepts a provider's signed notification and syncs that provider.
+// The notification itself is not parsed: the sync reads what changed, so
+// every webhook event type the provider sends is handled the same way.
+func (h *Handler) webhook(name domain.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		provider, ok := h.providers[name]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "provider not configured"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+			return
+		}
+		if err := provider.VerifyWebhook(r.Header, body); err != nil {
+			h.logger.Warnw("Rejected ELD webhook", "provider", name, "error", err)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+			return
+		}
+		h.poller.Nudge(name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) driverLinks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		links, err := h.eld.DriverLinks(r.Context())
+		h.respond(w, links, err)
+	case http.MethodPut:
+		var link domain.DriverLink
+		if !h.decode(w, r, &link) {
+			return
+		}
+		h.respond(w, link, h.eld.LinkDriver(r.Context(), link))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) vehicleLinks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		links, err := h.eld.VehicleLinks(r.Context())
+		h.respond(w, links, err)
+	case http.MethodPut:
+		var link domain.VehicleLink
+		if !h.decode(w, r, &link) {
+			return
+		}
+		h.respond(w, link, h.eld.LinkVehicle(r.Context(), link))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) unmatched(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	records, err := h.eld.Unmatched(r.Context())
+	h.respond(w, records, err)
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case err != nil:
+		h.logger.Errorw("ELD integration request failed", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	default:
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,226 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// motivePageSize is the page size requested from list endpoints.
+const motivePageSize = 100
+
+// MotiveConfig holds configuration for the Motive (formerly KeepTruckin) API.
+type MotiveConfig struct {
+	BaseURL       string        // e.g. https://api.gomotive.com
+	APIKey        string        // X-Api-Key for the fleet's account
+	WebhookSecret string        // Secret Motive signs webhooks with
+	Timeout       time.Duration // HTTP client timeout
+}
+
+// MotiveClient reads HOS logs and vehicle locations from Motive.
+type MotiveClient struct {
+	baseURL       string
+	apiKey        string
+	webhookSecret []byte
+	httpClient    *http.Client
+	log           *logger.Logger
+}
+
+// NewMotiveClient creates a new Motive API client.
+func NewMotiveClient(cfg MotiveConfig, log *logger.Logger) *MotiveClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return &MotiveClient{
+		baseURL:       strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:        cfg.APIKey,
+		webhookSecret: []byte(cfg.WebhookSecret),
+		httpClient:    &http.Client{Timeout: timeout},
+		log:           log,
+	}
+}
+
+// Provider names the vendor.
+func (c *MotiveClient) Provider() domain.Provider {
+	return domain.ProviderMotive
+}
+
+// --- Response types matching the Motive JSON format ---
+
+type motivePagination struct {
+	PerPage int `json:"per_page"`
+	PageNo  int `json:"page_no"`
+	Total   int `json:"total"`
+}
+
+// more reports whether pages follow this one.
+func (p motivePagination) more() bool {
+	return p.PerPage > 0 && p.PageNo*p.PerPage < p.Total
+}
+
+type motiveRef struct {
+	ID json.Number `json:"id"`
+}
+
+type motiveHOSLogsResponse struct {
+	HOSLogs []struct {
+		HOSLog struct {
+			ID          json.Number `json:"id"`
+			Status      string      `json:"status"`
+			StartTime   time.Time   `json:"start_time"`
+			Location    string      `json:"location"`
+			Lat         float64     `json:"lat"`
+			Lon         float64     `json:"lon"`
+			Odometer    float64     `json:"odometer"`
+			EngineHours float64     `json:"engine_hours"`
+			Notes       string      `json:"notes"`
+			Driver      *motiveRef  `json:"driver"`
+			Vehicle     *motiveRef  `json:"vehicle"`
+		} `json:"hos_log"`
+	} `json:"hos_logs"`
+	Pagination motivePagination `json:"pagination"`
+}
+
+type motiveVehicleLocationsResponse struct {
+	Vehicles []struct {
+		Vehicle struct {
+			ID              json.Number `json:"id"`
+			CurrentDriver   *motiveRef  `json:"current_driver"`
+			CurrentLocation *struct {
+				Lat         float64   `json:"lat"`
+				Lon         float64   `json:"lon"`
+				Bearing     float64   `json:"bearing"`
+				Speed       float64   `json:"speed"` // Miles per hour
+				LocatedAt   time.Time `json:"located_at"`
+				Description string    `json:"description"`
+			} `json:"current_location"`
+		} `json:"vehicle"`
+	} `json:"vehicles"`
+	Pagination motivePagination `json:"pagination"`
+}
+
+// DutyStatusChanges returns the HOS log entries that started in [since, until).
+// Motive filters by date, so the days spanned are read and trimmed.
+func (c *MotiveClient) DutyStatusChanges(ctx context.Context, since, until time.Time) ([]domain.DutyStatusChange, error) {
+	var changes []domain.DutyStatusChange
+	for page := 1; page <= maxPages; page++ {
+		q := url.Values{}
+		q.Set("start_date", since.UTC().Format("2006-01-02"))
+		q.Set("end_date", until.UTC().Format("2006-01-02"))
+		q.Set("per_page", strconv.Itoa(motivePageSize))
+		q.Set("page_no", strconv.Itoa(page))
+
+		var result motiveHOSLogsResponse
+		if err := getJSON(ctx, c.httpClient, c.baseURL+"/v1/hos_logs?"+q.Encode(), c.authorize, &result); err != nil {
+			return nil, fmt.Errorf("motive hos logs: %w", err)
+		}
+
+		for _, item := range result.HOSLogs {
+			entry := item.HOSLog
+			status, ok := domain.MapMotiveStatus(entry.Status)
+			if !ok || entry.Driver == nil || entry.StartTime.Before(since) || !entry.StartTime.Before(until) {
+				continue
+			}
+			change := domain.DutyStatusChange{
+				Provider:         domain.ProviderMotive,
+				ExternalID:       entry.ID.String(),
+				ExternalDriverID: entry.Driver.ID.String(),
+				Status:           status,
+				StartTime:        entry.StartTime,
+				Location:         entry.Location,
+				Latitude:         entry.Lat,
+				Longitude:        entry.Lon,
+				Odometer:         int(entry.Odometer),
+				EngineHours:      entry.EngineHours,
+				Notes:            entry.Notes,
+			}
+			if entry.Vehicle != nil {
+				change.ExternalVehicleID = entry.Vehicle.ID.String()
+			}
+			changes = append(changes, change)
+		}
+
+		if !result.Pagination.more() {
+			break
+		}
+	}
+	return changes, nil
+}
+
+// VehiclePositions returns every vehicle's current location and driver.
+func (c *MotiveClient) VehiclePositions(ctx context.Context) ([]domain.VehiclePosition, error) {
+	var positions []domain.VehiclePosition
+	for page := 1; page <= maxPages; page++ {
+		q := url.Values{}
+		q.Set("per_page", strconv.Itoa(motivePageSize))
+		q.Set("page_no", strconv.Itoa(page))
+
+		var result motiveVehicleLocationsResponse
+		if err := getJSON(ctx, c.httpClient, c.baseURL+"/v1/vehicle_locations?"+q.Encode(), c.authorize, &result); err != nil {
+			return nil, fmt.Errorf("motive vehicle locations: %w", err)
+		}
+
+		for _, item := range result.Vehicles {
+			v := item.Vehicle
+			if v.CurrentLocation == nil || v.CurrentLocation.LocatedAt.IsZero() {
+				continue
+			}
+			position := domain.VehiclePosition{
+				Provider:          domain.ProviderMotive,
+				ExternalVehicleID: v.ID.String(),
+				Latitude:          v.CurrentLocation.Lat,
+				Longitude:         v.CurrentLocation.Lon,
+				SpeedMPH:          v.CurrentLocation.Speed,
+				Heading:           v.CurrentLocation.Bearing,
+				Location:          v.CurrentLocation.Description,
+				RecordedAt:        v.CurrentLocation.LocatedAt,
+			}
+			if v.CurrentDriver != nil {
+				position.ExternalDriverID = v.CurrentDriver.ID.String()
+			}
+			positions = append(positions, position)
+		}
+
+		if !result.Pagination.more() {
+			break
+		}
+	}
+	return positions, nil
+}
+
+// VerifyWebhook checks the X-KT-Webhook-Signature header, a hex HMAC-SHA1 of
+// the body keyed with the webhook secret.
+func (c *MotiveClient) VerifyWebhook(header http.Header, body []byte) error {
+	if len(c.webhookSecret) == 0 {
+		return fmt.Errorf("motive webhook secret not configured: %w", ErrInvalidSignature)
+	}
+	signature := header.Get("X-KT-Webhook-Signature")
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha1.New, c.webhookSecret)
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// authorize adds the API key to a request.
+func (c *MotiveClient) authorize(req *http.Request) {
+	req.Header.Set("X-Api-Key", c.apiKey)
+}
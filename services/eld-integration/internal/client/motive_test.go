@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+)
+
+func TestMotiveClient_VehiclePositions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Api-Key"); got != "key" {
+			t.Errorf("X-Api-Key = %q", got)
+		}
+		switch r.URL.Query().Get("page_no") {
+		case "1":
+			w.Write([]byte(`{
+				"vehicles": [
+					{"vehicle": {"id": 101, "current_driver": {"id": 55},
+					 "current_location": {"lat": 33.77, "lon": -118.19, "bearing": 90, "speed": 42.5,
+					  "located_at": "2024-06-12T08:00:00Z", "description": "Long Beach, CA"}}},
+					{"vehicle": {"id": 102, "current_driver": null, "current_location": null}}
+				],
+				"pagination": {"per_page": 2, "page_no": 1, "total": 3}
+			}`))
+		case "2":
+			w.Write([]byte(`{
+				"vehicles": [
+					{"vehicle": {"id": 103, "current_driver": null,
+					 "current_location": {"lat": 34.01, "lon": -118.3, "located_at": "2024-06-12T08:01:00Z"}}}
+				],
+				"pagination": {"per_page": 2, "page_no": 2, "total": 3}
+			}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page_no"))
+		}
+	}))
+	defer server.Close()
+
+	c := NewMotiveClient(MotiveConfig{BaseURL: server.URL, APIKey: "key"}, newTestLogger(t))
+	positions, err := c.VehiclePositions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The vehicle with no location is skipped
+	if len(positions) != 2 {
+		t.Fatalf("got %d positions, want 2", len(positions))
+	}
+	p := positions[0]
+	if p.Provider != domain.ProviderMotive || p.ExternalVehicleID != "101" || p.ExternalDriverID != "55" {
+		t.Errorf("first position = %+v", p)
+	}
+	if p.SpeedMPH != 42.5 || p.Heading != 90 || p.Location != "Long Beach, CA" {
+		t.Errorf("first position = %+v", p)
+	}
+	if positions[1].ExternalDriverID != "" {
+		t.Errorf("second position driver = %q, want none", positions[1].ExternalDriverID)
+	}
+}
+
+func TestMotiveClient_VerifyWebhook(t *testing.T) {
+	c := NewMotiveClient(MotiveConfig{WebhookSecret: "motive-secret"}, newTestLogger(t))
+	body := []byte(`{"action":"hos_violation_upserted","id":9}`)
+
+	mac := hmac.New(sha1.New, []byte("motive-secret"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set("X-KT-Webhook-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	if err := c.VerifyWebhook(header, body); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := c.VerifyWebhook(header, []byte(`{"id":10}`)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered body: got %v", err)
+	}
+	unconfigured := NewMotiveClient(MotiveConfig{}, newTestLogger(t))
+	if err := unconfigured.VerifyWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("no secret configured: got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+)
+
+// ErrInvalidSignature is returned when a webhook request is not signed with
+// the provider's webhook secret.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// maxPages bounds how many pages a single feed read follows, so a provider
+// that keeps returning a next page cannot hold a sweep forever.
+const maxPages = 50
+
+// ELDProvider is the API of one ELD vendor, normalised to our duty statuses.
+type ELDProvider interface {
+	// Provider names the vendor.
+	Provider() domain.Provider
+	// DutyStatusChanges returns the duty status changes that started in [since, until).
+	DutyStatusChanges(ctx context.Context, since, until time.Time) ([]domain.DutyStatusChange, error)
+	// VehiclePositions returns every vehicle's last reported position.
+	VehiclePositions(ctx context.Context) ([]domain.VehiclePosition, error)
+	// VerifyWebhook checks a webhook request was signed by the vendor.
+	VerifyWebhook(header http.Header, body []byte) error
+}
+
+// getJSON sends an authenticated GET and decodes the JSON response into v.
+func getJSON(ctx context.Context, httpClient *http.Client, url string, authorize func(*http.Request), v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	authorize(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// samsaraWebhookTolerance is how old a signed Samsara webhook may be before
+// it is refused as a possible replay.
+const samsaraWebhookTolerance = 5 * time.Minute
+
+// SamsaraConfig holds configuration for the Samsara API.
+type SamsaraConfig struct {
+	BaseURL       string        // e.g. https://api.samsara.com
+	APIToken      string        // Bearer token with read access to HOS and vehicles
+	WebhookSecret string        // Base64 secret Samsara signs webhooks with
+	Timeout       time.Duration // HTTP client timeout
+}
+
+// SamsaraClient reads HOS logs and vehicle locations from Samsara.
+type SamsaraClient struct {
+	baseURL       string
+	token         string
+	webhookSecret []byte
+	httpClient    *http.Client
+	log           *logger.Logger
+}
+
+// NewSamsaraClient creates a new Samsara API client.
+func NewSamsaraClient(cfg SamsaraConfig, log *logger.Logger) (*SamsaraClient, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	var secret []byte
+	if cfg.WebhookSecret != "" {
+		var err error
+		if secret, err = base64.StdEncoding.DecodeString(cfg.WebhookSecret); err != nil {
+			return nil, fmt.Errorf("samsara webhook secret must be base64: %w", err)
+		}
+	}
+	return &SamsaraClient{
+		baseURL:       strings.TrimRight(cfg.BaseURL, "/"),
+		token:         cfg.APIToken,
+		webhookSecret: secret,
+		httpClient:    &http.Client{Timeout: timeout},
+		log:           log,
+	}, nil
+}
+
+// Provider names the vendor.
+func (c *SamsaraClient) Provider() domain.Provider {
+	return domain.ProviderSamsara
+}
+
+// --- Response types matching the Samsara JSON format ---
+
+type samsaraPagination struct {
+	EndCursor   string `json:"endCursor"`
+	HasNextPage bool   `json:"hasNextPage"`
+}
+
+type samsaraHOSLogsResponse struct {
+	Data []struct {
+		Driver struct {
+			ID string `json:"id"`
+		} `json:"driver"`
+		HOSLogs []struct {
+			HOSStatusType string    `json:"hosStatusType"`
+			LogStartTime  time.Time `json:"logStartTime"`
+			Remark        string    `json:"remark"`
+			Vehicle       *struct {
+				ID string `json:"id"`
+			} `json:"vehicle"`
+			LogRecordedLocation *struct {
+				Latitude  float64 `json:"latitude"`
+				Longitude float64 `json:"longitude"`
+			} `json:"logRecordedLocation"`
+		} `json:"hosLogs"`
+	} `json:"data"`
+	Pagination samsaraPagination `json:"pagination"`
+}
+
+type samsaraVehicleLocationsResponse struct {
+	Data []struct {
+		ID       string `json:"id"`
+		Location struct {
+			Time       time.Time `json:"time"`
+			Latitude   float64   `json:"latitude"`
+			Longitude  float64   `json:"longitude"`
+			Heading    float64   `json:"heading"`
+			Speed      float64   `json:"speed"` // Miles per hour
+			ReverseGeo struct {
+				FormattedLocation string `json:"formattedLocation"`
+			} `json:"reverseGeo"`
+		} `json:"location"`
+	} `json:"data"`
+	Pagination samsaraPagination `json:"pagination"`
+}
+
+// DutyStatusChanges returns the HOS log entries that started in [since, until).
+// Samsara log entries carry no ID of their own, so the driver and start time
+// identify them.
+func (c *SamsaraClient) DutyStatusChanges(ctx context.Context, since, until time.Time) ([]domain.DutyStatusChange, error) {
+	var changes []domain.DutyStatusChange
+	cursor := ""
+	for page := 0; page < maxPages; page++ {
+		q := url.Values{}
+		q.Set("startTime", since.UTC().Format(time.RFC3339))
+		q.Set("endTime", until.UTC().Format(time.RFC3339))
+		if cursor != "" {
+			q.Set("after", cursor)
+		}
+
+		var result samsaraHOSLogsResponse
+		if err := getJSON(ctx, c.httpClient, c.baseURL+"/fleet/hos/logs?"+q.Encode(), c.authorize, &result); err != nil {
+			return nil, fmt.Errorf("samsara hos logs: %w", err)
+		}
+
+		for _, driver := range result.Data {
+			for _, entry := range driver.HOSLogs {
+				status, ok := domain.MapSamsaraStatus(entry.HOSStatusType)
+				if !ok || entry.LogStartTime.Before(since) || !entry.LogStartTime.Before(until) {
+					continue
+				}
+				change := domain.DutyStatusChange{
+					Provider:         domain.ProviderSamsara,
+					ExternalID:       driver.Driver.ID + ":" + entry.LogStartTime.UTC().Format(time.RFC3339),
+					ExternalDriverID: driver.Driver.ID,
+					Status:           status,
+					StartTime:        entry.LogStartTime,
+					Notes:            entry.Remark,
+				}
+				if entry.Vehicle != nil {
+					change.ExternalVehicleID = entry.Vehicle.ID
+				}
+				if entry.LogRecordedLocation != nil {
+					change.Latitude = entry.LogRecordedLocation.Latitude
+					change.Longitude = entry.LogRecordedLocation.Longitude
+				}
+				changes = append(changes, change)
+			}
+		}
+
+		if !result.Pagination.HasNextPage || result.Pagination.EndCursor == "" {
+			break
+		}
+		cursor = result.Pagination.EndCursor
+	}
+	return changes, nil
+}
+
+// VehiclePositions returns every vehicle's last reported location.
+func (c *SamsaraClient) VehiclePositions(ctx context.Context) ([]domain.VehiclePosition, error) {
+	var positions []domain.VehiclePosition
+	cursor := ""
+	for page := 0; page < maxPages; page++ {
+		path := c.baseURL + "/fleet/vehicles/locations"
+		if cursor != "" {
+			path += "?after=" + url.QueryEscape(cursor)
+		}
+
+		var result samsaraVehicleLocationsResponse
+		if err := getJSON(ctx, c.httpClient, path, c.authorize, &result); err != nil {
+			return nil, fmt.Errorf("samsara vehicle locations: %w", err)
+		}
+
+		for _, v := range result.Data {
+			if v.Location.Time.IsZero() {
+				continue
+			}
+			positions = append(positions, domain.VehiclePosition{
+				Provider:          domain.ProviderSamsara,
+				ExternalVehicleID: v.ID,
+				Latitude:          v.Location.Latitude,
+				Longitude:         v.Location.Longitude,
+				SpeedMPH:          v.Location.Speed,
+				Heading:           v.Location.Heading,
+				Location:          v.Location.ReverseGeo.FormattedLocation,
+				RecordedAt:        v.Location.Time,
+			})
+		}
+
+		if !result.Pagination.HasNextPage || result.Pagination.EndCursor == "" {
+			break
+		}
+		cursor = result.Pagination.EndCursor
+	}
+	return positions, nil
+}
+
+// VerifyWebhook checks the X-Samsara-Signature header, an HMAC-SHA256 of
+// "v1:<timestamp>:<body>" keyed with the webhook secret, and refuses
+// requests whose X-Samsara-Timestamp is too old.
+func (c *SamsaraClient) VerifyWebhook(header http.Header, body []byte) error {
+	if len(c.webhookSecret) == 0 {
+		return fmt.Errorf("samsara webhook secret not configured: %w", ErrInvalidSignature)
+	}
+	timestamp := header.Get("X-Samsara-Timestamp")
+	signature := strings.TrimPrefix(header.Get("X-Samsara-Signature"), "v1=")
+	if timestamp == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(sent, 0)); age > samsaraWebhookTolerance || age < -samsaraWebhookTolerance {
+		return fmt.Errorf("samsara webhook timestamp out of range: %w", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, c.webhookSecret)
+	mac.Write([]byte("v1:" + timestamp + ":"))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// authorize adds the API token to a request.
+func (c *SamsaraClient) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	return log
+}
+
+func TestSamsaraClient_DutyStatusChanges(t *testing.T) {
+	var pages int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fleet/hos/logs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		pages++
+		if r.URL.Query().Get("after") == "" {
+			w.Write([]byte(`{
+				"data": [{
+					"driver": {"id": "d-1"},
+					"hosLogs": [
+						{"hosStatusType": "onDuty", "logStartTime": "2024-06-12T06:00:00Z", "vehicle": {"id": "v-7"},
+						 "logRecordedLocation": {"latitude": 33.75, "longitude": -118.21}},
+						{"hosStatusType": "driving", "logStartTime": "2024-06-12T06:20:00Z", "vehicle": {"id": "v-7"}},
+						{"hosStatusType": "offDuty", "logStartTime": "2024-06-11T20:00:00Z"}
+					]
+				}],
+				"pagination": {"endCursor": "next", "hasNextPage": true}
+			}`))
+			return
+		}
+		w.Write([]byte(`{
+			"data": [{"driver": {"id": "d-2"}, "hosLogs": [{"hosStatusType": "sleeperBed", "logStartTime": "2024-06-12T07:00:00Z"}]}],
+			"pagination": {"endCursor": "", "hasNextPage": false}
+		}`))
+	}))
+	defer server.Close()
+
+	c, err := NewSamsaraClient(SamsaraConfig{BaseURL: server.URL, APIToken: "token"}, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	since := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	changes, err := c.DutyStatusChanges(context.Background(), since, since.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pages != 2 {
+		t.Errorf("read %d pages, want 2", pages)
+	}
+	// The off-duty entry from the day before is outside the window
+	if len(changes) != 3 {
+		t.Fatalf("got %d changes, want 3", len(changes))
+	}
+	first := changes[0]
+	if first.Status != domain.DutyOnDutyNotDriving || first.ExternalDriverID != "d-1" || first.ExternalVehicleID != "v-7" {
+		t.Errorf("first change = %+v", first)
+	}
+	if first.ExternalID != "d-1:2024-06-12T06:00:00Z" {
+		t.Errorf("ExternalID = %q", first.ExternalID)
+	}
+	if first.Latitude != 33.75 || first.Longitude != -118.21 {
+		t.Errorf("location = %v,%v", first.Latitude, first.Longitude)
+	}
+	if changes[2].Status != domain.DutySleeperBerth || changes[2].ExternalDriverID != "d-2" {
+		t.Errorf("last change = %+v", changes[2])
+	}
+}
+
+func TestSamsaraClient_VerifyWebhook(t *testing.T) {
+	secret := []byte("samsara-secret")
+	c, err := NewSamsaraClient(SamsaraConfig{WebhookSecret: base64.StdEncoding.EncodeToString(secret)}, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := []byte(`{"eventId":"e-1","eventType":"HosLogsUpdated"}`)
+
+	sign := func(ts string, body []byte) http.Header {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte("v1:" + ts + ":"))
+		mac.Write(body)
+		h := http.Header{}
+		h.Set("X-Samsara-Timestamp", ts)
+		h.Set("X-Samsara-Signature", "v1="+hex.EncodeToString(mac.Sum(nil)))
+		return h
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if err := c.VerifyWebhook(sign(now, body), body); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := c.VerifyWebhook(sign(now, body), []byte(`{"eventId":"e-2"}`)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered body: got %v", err)
+	}
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	if err := c.VerifyWebhook(sign(stale, body), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("stale timestamp: got %v", err)
+	}
+	if err := c.VerifyWebhook(http.Header{}, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("unsigned: got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Provider identifies an ELD vendor whose devices are installed in the fleet.
+type Provider string
+
+const (
+	ProviderSamsara Provider = "SAMSARA"
+	ProviderMotive  Provider = "MOTIVE"
+)
+
+// DutyStatus is a record of duty status, named as the driver service records it.
+type DutyStatus string
+
+const (
+	DutyOffDuty            DutyStatus = "OFF_DUTY"
+	DutySleeperBerth       DutyStatus = "SLEEPER_BERTH"
+	DutyDriving            DutyStatus = "DRIVING"
+	DutyOnDutyNotDriving   DutyStatus = "ON_DUTY_NOT_DRIVING"
+	DutyPersonalConveyance DutyStatus = "PERSONAL_CONVEYANCE" // Off-duty movement of the CMV for personal use
+	DutyYardMove           DutyStatus = "YARD_MOVE"           // On-duty movement within a yard or terminal
+)
+
+// samsaraDutyStatuses maps Samsara hosStatusType values to DutyStatus.
+// Reference: Samsara API — GET /fleet/hos/logs, hosLogs[].hosStatusType.
+var samsaraDutyStatuses = map[string]DutyStatus{
+	"offDuty":            DutyOffDuty,
+	"sleeperBed":         DutySleeperBerth,
+	"driving":            DutyDriving,
+	"onDuty":             DutyOnDutyNotDriving,
+	"yardMove":           DutyYardMove,
+	"personalConveyance": DutyPersonalConveyance,
+}
+
+// motiveDutyStatuses maps Motive hos_log status values to DutyStatus.
+// Reference: Motive API — GET /v1/hos_logs, hos_logs[].hos_log.status.
+var motiveDutyStatuses = map[string]DutyStatus{
+	"off_duty":            DutyOffDuty,
+	"sleeper":             DutySleeperBerth,
+	"driving":             DutyDriving,
+	"on_duty":             DutyOnDutyNotDriving,
+	"yard_move":           DutyYardMove,
+	"personal_conveyance": DutyPersonalConveyance,
+	"waiting":             DutyOnDutyNotDriving, // Oilfield waiting time counts as on duty
+}
+
+// MapSamsaraStatus converts a Samsara hosStatusType to a DutyStatus.
+// Returns false for statuses that are not duty status changes.
+func MapSamsaraStatus(status string) (DutyStatus, bool) {
+	s, ok := samsaraDutyStatuses[status]
+	return s, ok
+}
+
+// MapMotiveStatus converts a Motive hos_log status to a DutyStatus.
+// Returns false for statuses that are not duty status changes.
+func MapMotiveStatus(status string) (DutyStatus, bool) {
+	s, ok := motiveDutyStatuses[strings.ToLower(status)]
+	return s, ok
+}
+
+// DutyStatusChange is a duty status change as an ELD provider reports it,
+// before the driver and vehicle are matched to ours.
+type DutyStatusChange struct {
+	Provider          Provider
+	ExternalID        string // Provider's ID for the log entry
+	ExternalDriverID  string
+	ExternalVehicleID string
+	Status            DutyStatus
+	StartTime         time.Time
+	Location          string
+	Latitude          float64
+	Longitude         float64
+	Odometer          int
+	EngineHours       float64
+	Notes             string
+}
+
+// VehiclePosition is a vehicle's last reported position and the driver the
+// provider has at its wheel, if any.
+type VehiclePosition struct {
+	Provider          Provider
+	ExternalVehicleID string
+	ExternalDriverID  string
+	Latitude          float64
+	Longitude         float64
+	SpeedMPH          float64
+	Heading           float64
+	Location          string
+	RecordedAt        time.Time
+}
+
+// DriverLink matches a driver's account with an ELD provider to our driver.
+type DriverLink struct {
+	Provider   Provider  `json:"provider"`
+	ExternalID string    `json:"external_id"`
+	DriverID   uuid.UUID `json:"driver_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VehicleLink matches a vehicle registered with an ELD provider to our tractor.
+type VehicleLink struct {
+	Provider   Provider  `json:"provider"`
+	ExternalID string    `json:"external_id"`
+	TractorID  uuid.UUID `json:"tractor_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Feed is one kind of data pulled from a provider.
+type Feed string
+
+const (
+	FeedDutyStatus Feed = "DUTY_STATUS"
+	FeedLocations  Feed = "LOCATIONS"
+)
+
+// SyncCursor records how far a provider's feed has been read.
+type SyncCursor struct {
+	Provider    Provider
+	Feed        Feed
+	SyncedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+// UnmatchedRecord counts data from a provider that could not be matched to a
+// driver or tractor, so ops can link the missing accounts.
+type UnmatchedRecord struct {
+	Provider   Provider  `json:"provider"`
+	Kind       string    `json:"kind"` // driver or vehicle
+	ExternalID string    `json:"external_id"`
+	Count      int       `json:"count"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "testing"
+
+func TestMapSamsaraStatus(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected DutyStatus
+		ok       bool
+	}{
+		{"offDuty", DutyOffDuty, true},
+		{"sleeperBed", DutySleeperBerth, true},
+		{"driving", DutyDriving, true},
+		{"onDuty", DutyOnDutyNotDriving, true},
+		{"yardMove", DutyYardMove, true},
+		{"personalConveyance", DutyPersonalConveyance, true},
+		{"certify", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got, ok := MapSamsaraStatus(tt.status)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("MapSamsaraStatus(%q) = %q, %v, want %q, %v", tt.status, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMapMotiveStatus(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected DutyStatus
+		ok       bool
+	}{
+		{"off_duty", DutyOffDuty, true},
+		{"sleeper", DutySleeperBerth, true},
+		{"driving", DutyDriving, true},
+		{"on_duty", DutyOnDutyNotDriving, true},
+		{"yard_move", DutyYardMove, true},
+		{"personal_conveyance", DutyPersonalConveyance, true},
+		{"waiting", DutyOnDutyNotDriving, true},
+		{"DRIVING", DutyDriving, true},
+		{"login", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got, ok := MapMotiveStatus(tt.status)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("MapMotiveStatus(%q) = %q, %v, want %q, %v", tt.status, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
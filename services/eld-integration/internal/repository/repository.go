@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+)
+
+// Repository provides read/write access to the ELD integration tables.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new Repository backed by the given connection pool.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// GetDriverLink returns the driver linked to a provider's driver account,
+// or nil when the account is not linked.
+func (r *Repository) GetDriverLink(ctx context.Context, provider domain.Provider, externalID string) (*domain.DriverLink, error) {
+	var link domain.DriverLink
+	err := r.pool.QueryRow(ctx,
+		`SELECT provider, external_id, driver_id, created_at
+		 FROM eld_driver_links
+		 WHERE provider = $1 AND external_id = $2`,
+		string(provider), externalID,
+	).Scan(&link.Provider, &link.ExternalID, &link.DriverID, &link.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get driver link: %w", err)
+	}
+	return &link, nil
+}
+
+// GetVehicleLink returns the tractor linked to a provider's vehicle, or nil
+// when the vehicle is not linked.
+func (r *Repository) GetVehicleLink(ctx context.Context, provider domain.Provider, externalID string) (*domain.VehicleLink, error) {
+	var link domain.VehicleLink
+	err := r.pool.QueryRow(ctx,
+		`SELECT provider, external_id, tractor_id, created_at
+		 FROM eld_vehicle_links
+		 WHERE provider = $1 AND external_id = $2`,
+		string(provider), externalID,
+	).Scan(&link.Provider, &link.ExternalID, &link.TractorID, &link.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get vehicle link: %w", err)
+	}
+	return &link, nil
+}
+
+// UpsertDriverLink links a provider's driver account to a driver, replacing
+// any earlier link for the account.
+func (r *Repository) UpsertDriverLink(ctx context.Context, link domain.DriverLink) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_driver_links (provider, external_id, driver_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (provider, external_id) DO UPDATE SET driver_id = EXCLUDED.driver_id`,
+		string(link.Provider), link.ExternalID, link.DriverID,
+	)
+	if err == nil {
+		err = r.clearUnmatched(ctx, link.Provider, "driver", link.ExternalID)
+	}
+	return err
+}
+
+// UpsertVehicleLink links a provider's vehicle to a tractor, replacing any
+// earlier link for the vehicle.
+func (r *Repository) UpsertVehicleLink(ctx context.Context, link domain.VehicleLink) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_vehicle_links (provider, external_id, tractor_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (provider, external_id) DO UPDATE SET tractor_id = EXCLUDED.tractor_id`,
+		string(link.Provider), link.ExternalID, link.TractorID,
+	)
+	if err == nil {
+		err = r.clearUnmatched(ctx, link.Provider, "vehicle", link.ExternalID)
+	}
+	return err
+}
+
+// ListDriverLinks returns every linked driver account.
+func (r *Repository) ListDriverLinks(ctx context.Context) ([]domain.DriverLink, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT provider, external_id, driver_id, created_at
+		 FROM eld_driver_links
+		 ORDER BY provider, external_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list driver links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []domain.DriverLink
+	for rows.Next() {
+		var link domain.DriverLink
+		if err := rows.Scan(&link.Provider, &link.ExternalID, &link.DriverID, &link.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan driver link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// ListVehicleLinks returns every linked vehicle.
+func (r *Repository) ListVehicleLinks(ctx context.Context) ([]domain.VehicleLink, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT provider, external_id, tractor_id, created_at
+		 FROM eld_vehicle_links
+		 ORDER BY provider, external_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list vehicle links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []domain.VehicleLink
+	for rows.Next() {
+		var link domain.VehicleLink
+		if err := rows.Scan(&link.Provider, &link.ExternalID, &link.TractorID, &link.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan vehicle link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// MarkDutyStatusReceived records a provider's duty status change as received.
+// Returns false when it had already been, so resent history is skipped.
+func (r *Repository) MarkDutyStatusReceived(ctx context.Context, provider domain.Provider, externalID string, driverID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_received_duty_statuses (provider, external_id, driver_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (provider, external_id) DO NOTHING`,
+		string(provider), externalID, driverID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("mark duty status received: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UnmarkDutyStatusReceived forgets a received duty status change, so it is
+// picked up again by the next sweep after it failed to publish.
+func (r *Repository) UnmarkDutyStatusReceived(ctx context.Context, provider domain.Provider, externalID string) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM eld_received_duty_statuses WHERE provider = $1 AND external_id = $2`,
+		string(provider), externalID,
+	)
+	return err
+}
+
+// SetVehicleDriver records the driver last seen on a provider's vehicle, for
+// providers whose location feed does not say who is driving.
+func (r *Repository) SetVehicleDriver(ctx context.Context, provider domain.Provider, externalVehicleID, externalDriverID string, at time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_vehicle_drivers (provider, external_vehicle_id, external_driver_id, seen_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (provider, external_vehicle_id) DO UPDATE SET
+			 external_driver_id = EXCLUDED.external_driver_id,
+			 seen_at            = EXCLUDED.seen_at
+		 WHERE eld_vehicle_drivers.seen_at <= EXCLUDED.seen_at`,
+		string(provider), externalVehicleID, externalDriverID, at,
+	)
+	return err
+}
+
+// GetVehicleDriver returns the driver last seen on a provider's vehicle since
+// a time, or "" when there is none.
+func (r *Repository) GetVehicleDriver(ctx context.Context, provider domain.Provider, externalVehicleID string, since time.Time) (string, error) {
+	var driverID string
+	err := r.pool.QueryRow(ctx,
+		`SELECT external_driver_id FROM eld_vehicle_drivers
+		 WHERE provider = $1 AND external_vehicle_id = $2 AND seen_at >= $3`,
+		string(provider), externalVehicleID, since,
+	).Scan(&driverID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get vehicle driver: %w", err)
+	}
+	return driverID, nil
+}
+
+// GetSyncCursor returns how far a provider's feed has been read, or nil when
+// it has never been read.
+func (r *Repository) GetSyncCursor(ctx context.Context, provider domain.Provider, feed domain.Feed) (*domain.SyncCursor, error) {
+	cursor := domain.SyncCursor{Provider: provider, Feed: feed}
+	err := r.pool.QueryRow(ctx,
+		`SELECT synced_until, updated_at FROM eld_sync_cursors
+		 WHERE provider = $1 AND feed = $2`,
+		string(provider), string(feed),
+	).Scan(&cursor.SyncedUntil, &cursor.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get sync cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+// SaveSyncCursor records how far a provider's feed has been read.
+func (r *Repository) SaveSyncCursor(ctx context.Context, provider domain.Provider, feed domain.Feed, syncedUntil time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_sync_cursors (provider, feed, synced_until, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (provider, feed) DO UPDATE SET
+			 synced_until = EXCLUDED.synced_until,
+			 updated_at   = NOW()`,
+		string(provider), string(feed), syncedUntil,
+	)
+	return err
+}
+
+// RecordUnmatched counts data from a provider that could not be matched to
+// a driver or tractor.
+func (r *Repository) RecordUnmatched(ctx context.Context, provider domain.Provider, kind, externalID string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO eld_unmatched (provider, kind, external_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (provider, kind, external_id) DO UPDATE SET
+			 count     = eld_unmatched.count + 1,
+			 last_seen = NOW()`,
+		string(provider), kind, externalID,
+	)
+	return err
+}
+
+// ListUnmatched returns the provider accounts and vehicles still to be linked.
+func (r *Repository) ListUnmatched(ctx context.Context) ([]domain.UnmatchedRecord, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT provider, kind, external_id, count, first_seen, last_seen
+		 FROM eld_unmatched
+		 ORDER BY last_seen DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list unmatched: %w", err)
+	}
+	defer rows.Close()
+
+	var records []domain.UnmatchedRecord
+	for rows.Next() {
+		var rec domain.UnmatchedRecord
+		if err := rows.Scan(&rec.Provider, &rec.Kind, &rec.ExternalID, &rec.Count, &rec.FirstSeen, &rec.LastSeen); err != nil {
+			return nil, fmt.Errorf("scan unmatched: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// clearUnmatched removes an account or vehicle from the unmatched list once linked.
+func (r *Repository) clearUnmatched(ctx context.Context, provider domain.Provider, kind, externalID string) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM eld_unmatched WHERE provider = $1 AND kind = $2 AND external_id = $3`,
+		string(provider), kind, externalID,
+	)
+	return err
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/services/eld-integration/internal/repository"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// vehicleDriverMaxAge is how long after a duty status change on a vehicle
+// its driver is taken to still be at the wheel, when the provider's location
+// feed does not say.
+const vehicleDriverMaxAge = 14 * time.Hour
+
+// ValidationError is returned for a request that is missing or has invalid fields.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ELDService passes ELD data on to the rest of the system:
+//   - Duty status changes become HOS log entries in the driver service
+//   - Vehicle positions become ELD-sourced locations in the tracking service
+//
+// Provider drivers and vehicles are matched to ours through the link tables;
+// data for anything not linked is counted for ops and dropped.
+type ELDService struct {
+	repo          *repository.Repository
+	kafkaProducer *kafka.Producer
+	log           *logger.Logger
+}
+
+// NewELDService creates a new ELDService.
+func NewELDService(repo *repository.Repository, kafkaProducer *kafka.Producer, log *logger.Logger) *ELDService {
+	return &ELDService{
+		repo:          repo,
+		kafkaProducer: kafkaProducer,
+		log:           log,
+	}
+}
+
+// ProcessDutyStatus passes a provider's duty status change to the driver
+// service. Changes already passed on are skipped.
+func (s *ELDService) ProcessDutyStatus(ctx context.Context, change domain.DutyStatusChange) error {
+	driver, err := s.repo.GetDriverLink(ctx, change.Provider, change.ExternalDriverID)
+	if err != nil {
+		return err
+	}
+	if driver == nil {
+		s.recordUnmatched(ctx, change.Provider, "driver", change.ExternalDriverID)
+		return nil
+	}
+
+	var tractorID *uuid.UUID
+	if change.ExternalVehicleID != "" {
+		if err := s.repo.SetVehicleDriver(ctx, change.Provider, change.ExternalVehicleID, change.ExternalDriverID, change.StartTime); err != nil {
+			s.log.Warnw("Failed to record vehicle driver", "error", err, "provider", change.Provider, "vehicle", change.ExternalVehicleID)
+		}
+		vehicle, err := s.repo.GetVehicleLink(ctx, change.Provider, change.ExternalVehicleID)
+		if err != nil {
+			return err
+		}
+		if vehicle != nil {
+			tractorID = &vehicle.TractorID
+		} else {
+			s.recordUnmatched(ctx, change.Provider, "vehicle", change.ExternalVehicleID)
+		}
+	}
+
+	fresh, err := s.repo.MarkDutyStatusReceived(ctx, change.Provider, change.ExternalID, driver.DriverID)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return nil
+	}
+
+	// Duty status feeds HOS, so wait for the ack even when the producer
+	// batches location pings
+	event := kafka.NewEvent(kafka.Topics.ELDDutyStatusRecorded, "eld-integration", dutyStatusPayload(change, driver.DriverID, tractorID))
+	if err := s.kafkaProducer.PublishSync(ctx, kafka.Topics.ELDDutyStatusRecorded, event); err != nil {
+		// Forget the change so the next sweep passes it on
+		if unmarkErr := s.repo.UnmarkDutyStatusReceived(ctx, change.Provider, change.ExternalID); unmarkErr != nil {
+			s.log.Errorw("Failed to forget unpublished duty status", "error", unmarkErr, "externalId", change.ExternalID)
+		}
+		return fmt.Errorf("publish duty status: %w", err)
+	}
+
+	s.log.Infow("ELD duty status recorded",
+		"provider", change.Provider,
+		"driverId", driver.DriverID,
+		"status", change.Status,
+		"startTime", change.StartTime,
+	)
+	return nil
+}
+
+// ProcessPosition passes a vehicle's position to the tracking service under
+// the driver at its wheel. Positions with no known driver are dropped.
+func (s *ELDService) ProcessPosition(ctx context.Context, position domain.VehiclePosition) error {
+	externalDriverID := position.ExternalDriverID
+	if externalDriverID == "" {
+		var err error
+		externalDriverID, err = s.repo.GetVehicleDriver(ctx, position.Provider, position.ExternalVehicleID, position.RecordedAt.Add(-vehicleDriverMaxAge))
+		if err != nil {
+			return err
+		}
+		if externalDriverID == "" {
+			return nil
+		}
+	}
+
+	driver, err := s.repo.GetDriverLink(ctx, position.Provider, externalDriverID)
+	if err != nil {
+		return err
+	}
+	if driver == nil {
+		s.recordUnmatched(ctx, position.Provider, "driver", externalDriverID)
+		return nil
+	}
+	var tractorID *uuid.UUID
+	vehicle, err := s.repo.GetVehicleLink(ctx, position.Provider, position.ExternalVehicleID)
+	if err != nil {
+		return err
+	}
+	if vehicle != nil {
+		tractorID = &vehicle.TractorID
+	} else {
+		s.recordUnmatched(ctx, position.Provider, "vehicle", position.ExternalVehicleID)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.ELDLocationRecorded, "eld-integration", locationPayload(position, driver.DriverID, tractorID))
+	if err := s.kafkaProducer.Publish(ctx, kafka.Topics.ELDLocationRecorded, event); err != nil {
+		return fmt.Errorf("publish location: %w", err)
+	}
+	return nil
+}
+
+// LinkDriver matches a provider's driver account to a driver.
+func (s *ELDService) LinkDriver(ctx context.Context, link domain.DriverLink) error {
+	if err := validateLink(link.Provider, link.ExternalID, link.DriverID); err != nil {
+		return err
+	}
+	link.ExternalID = strings.TrimSpace(link.ExternalID)
+	return s.repo.UpsertDriverLink(ctx, link)
+}
+
+// LinkVehicle matches a provider's vehicle to a tractor.
+func (s *ELDService) LinkVehicle(ctx context.Context, link domain.VehicleLink) error {
+	if err := validateLink(link.Provider, link.ExternalID, link.TractorID); err != nil {
+		return err
+	}
+	link.ExternalID = strings.TrimSpace(link.ExternalID)
+	return s.repo.UpsertVehicleLink(ctx, link)
+}
+
+// DriverLinks returns every linked driver account.
+func (s *ELDService) DriverLinks(ctx context.Context) ([]domain.DriverLink, error) {
+	return s.repo.ListDriverLinks(ctx)
+}
+
+// VehicleLinks returns every linked vehicle.
+func (s *ELDService) VehicleLinks(ctx context.Context) ([]domain.VehicleLink, error) {
+	return s.repo.ListVehicleLinks(ctx)
+}
+
+// Unmatched returns the provider accounts and vehicles data arrived for that
+// are not linked yet.
+func (s *ELDService) Unmatched(ctx context.Context) ([]domain.UnmatchedRecord, error) {
+	return s.repo.ListUnmatched(ctx)
+}
+
+// recordUnmatched counts data that could not be matched, for ops to link.
+func (s *ELDService) recordUnmatched(ctx context.Context, provider domain.Provider, kind, externalID string) {
+	if externalID == "" {
+		return
+	}
+	if err := s.repo.RecordUnmatched(ctx, provider, kind, externalID); err != nil {
+		s.log.Warnw("Failed to record unmatched ELD "+kind, "error", err, "provider", provider, "externalId", externalID)
+	}
+}
+
+// validateLink checks a link names a known provider, an account and one of ours.
+func validateLink(provider domain.Provider, externalID string, id uuid.UUID) error {
+	switch provider {
+	case domain.ProviderSamsara, domain.ProviderMotive:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("unknown provider %q", provider)}
+	}
+	if strings.TrimSpace(externalID) == "" {
+		return &ValidationError{Message: "external_id is required"}
+	}
+	if id == uuid.Nil {
+		return &ValidationError{Message: "a driver or tractor ID is required"}
+	}
+	return nil
+}
+
+// dutyStatusPayload is the event the driver service records as an HOS log entry.
+func dutyStatusPayload(change domain.DutyStatusChange, driverID uuid.UUID, tractorID *uuid.UUID) map[string]interface{} {
+	payload := map[string]interface{}{
+		"driver_id":    driverID.String(),
+		"status":       string(change.Status),
+		"start_time":   change.StartTime.UTC(),
+		"location":     change.Location,
+		"latitude":     change.Latitude,
+		"longitude":    change.Longitude,
+		"odometer":     change.Odometer,
+		"engine_hours": change.EngineHours,
+		"notes":        change.Notes,
+		"provider":     string(change.Provider),
+		"external_id":  change.ExternalID,
+	}
+	if tractorID != nil {
+		payload["tractor_id"] = tractorID.String()
+	}
+	return payload
+}
+
+// locationPayload is the event the tracking service records as a location.
+func locationPayload(position domain.VehiclePosition, driverID uuid.UUID, tractorID *uuid.UUID) map[string]interface{} {
+	payload := map[string]interface{}{
+		"driver_id":   driverID.String(),
+		"latitude":    position.Latitude,
+		"longitude":   position.Longitude,
+		"speed_mph":   position.SpeedMPH,
+		"heading":     position.Heading,
+		"location":    position.Location,
+		"recorded_at": position.RecordedAt.UTC(),
+		"provider":    string(position.Provider),
+	}
+	if tractorID != nil {
+		payload["tractor_id"] = tractorID.String()
+	}
+	return payload
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/draymaster/services/eld-integration/internal/client"
+	"github.com/draymaster/services/eld-integration/internal/domain"
+	"github.com/draymaster/services/eld-integration/internal/repository"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// SyncConfig holds configuration for reading the provider feeds.
+type SyncConfig struct {
+	Interval time.Duration // time between sweeps when no webhook arrives
+	Overlap  time.Duration // how far back each sweep re-reads, for late edits and uploads
+	Lookback time.Duration // how far back the first sweep of a feed reads
+}
+
+// SyncPoller reads duty status changes and vehicle positions from each ELD
+// provider on a timer. A webhook from a provider nudges an immediate sweep of
+// that provider, so changes arrive within seconds where webhooks are set up
+// and within an interval where they are not. Feeds are re-read with an
+// overlap; changes already passed on are skipped by the ELD service.
+type SyncPoller struct {
+	providers []client.ELDProvider
+	eld       *ELDService
+	repo      *repository.Repository
+	interval  time.Duration
+	overlap   time.Duration
+	lookback  time.Duration
+	nudges    chan domain.Provider
+	log       *logger.Logger
+
+	lastPositions map[string]time.Time // by provider and vehicle
+}
+
+// NewSyncPoller creates a new SyncPoller.
+func NewSyncPoller(providers []client.ELDProvider, eld *ELDService, repo *repository.Repository, cfg SyncConfig, log *logger.Logger) *SyncPoller {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	overlap := cfg.Overlap
+	if overlap <= 0 {
+		overlap = 15 * time.Minute
+	}
+	lookback := cfg.Lookback
+	if lookback <= 0 {
+		lookback = 24 * time.Hour
+	}
+	return &SyncPoller{
+		providers:     providers,
+		eld:           eld,
+		repo:          repo,
+		interval:      interval,
+		overlap:       overlap,
+		lookback:      lookback,
+		nudges:        make(chan domain.Provider, len(providers)+1),
+		log:           log,
+		lastPositions: make(map[string]time.Time),
+	}
+}
+
+// Nudge asks for a sweep of a provider as soon as possible, e.g. because it
+// sent a webhook. Nudges beyond those already queued are dropped.
+func (p *SyncPoller) Nudge(provider domain.Provider) {
+	select {
+	case p.nudges <- provider:
+	default:
+	}
+}
+
+// Start begins polling. Blocks until ctx is cancelled.
+func (p *SyncPoller) Start(ctx context.Context) error {
+	p.log.Infow("ELD sync started",
+		"providers", len(p.providers),
+		"interval", p.interval,
+		"overlap", p.overlap,
+	)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for _, provider := range p.providers {
+		p.sync(ctx, provider)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			p.log.Info("ELD sync shutting down")
+			return ctx.Err()
+		case <-ticker.C:
+			for _, provider := range p.providers {
+				p.sync(ctx, provider)
+			}
+		case name := <-p.nudges:
+			for _, provider := range p.providers {
+				if provider.Provider() == name {
+					p.sync(ctx, provider)
+				}
+			}
+		}
+	}
+}
+
+// sync reads both feeds of one provider.
+func (p *SyncPoller) sync(ctx context.Context, provider client.ELDProvider) {
+	if err := p.syncDutyStatus(ctx, provider); err != nil && ctx.Err() == nil {
+		p.log.Warnw("ELD duty status sync failed", "provider", provider.Provider(), "error", err)
+	}
+	if err := p.syncPositions(ctx, provider); err != nil && ctx.Err() == nil {
+		p.log.Warnw("ELD location sync failed", "provider", provider.Provider(), "error", err)
+	}
+}
+
+// syncDutyStatus passes on the duty status changes since the feed was last
+// read. The cursor stops at the first change that could not be passed on,
+// so it is retried next sweep.
+func (p *SyncPoller) syncDutyStatus(ctx context.Context, provider client.ELDProvider) error {
+	cursor, err := p.repo.GetSyncCursor(ctx, provider.Provider(), domain.FeedDutyStatus)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	since := syncWindowStart(cursor, now, p.overlap, p.lookback)
+
+	changes, err := provider.DutyStatusChanges(ctx, since, now)
+	if err != nil {
+		return err
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].StartTime.Before(changes[j].StartTime) })
+
+	syncedUntil := now
+	for _, change := range changes {
+		if err := p.eld.ProcessDutyStatus(ctx, change); err != nil {
+			p.log.Errorw("Failed to process ELD duty status",
+				"error", err,
+				"provider", change.Provider,
+				"externalId", change.ExternalID,
+			)
+			syncedUntil = change.StartTime
+			break
+		}
+	}
+	return p.repo.SaveSyncCursor(ctx, provider.Provider(), domain.FeedDutyStatus, syncedUntil)
+}
+
+// syncPositions passes on the vehicle positions reported since the last sweep.
+func (p *SyncPoller) syncPositions(ctx context.Context, provider client.ELDProvider) error {
+	positions, err := provider.VehiclePositions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, position := range freshPositions(p.lastPositions, positions) {
+		if err := p.eld.ProcessPosition(ctx, position); err != nil {
+			p.log.Warnw("Failed to process ELD position",
+				"error", err,
+				"provider", position.Provider,
+				"vehicle", position.ExternalVehicleID,
+			)
+			continue
+		}
+		p.lastPositions[positionKey(position)] = position.RecordedAt
+	}
+	return p.repo.SaveSyncCursor(ctx, provider.Provider(), domain.FeedLocations, time.Now().UTC())
+}
+
+// syncWindowStart returns where a feed read starts: the overlap before the
+// cursor, or the lookback before now for a feed never read.
+func syncWindowStart(cursor *domain.SyncCursor, now time.Time, overlap, lookback time.Duration) time.Time {
+	if cursor == nil || cursor.SyncedUntil.IsZero() {
+		return now.Add(-lookback)
+	}
+	since := cursor.SyncedUntil.Add(-overlap)
+	if since.Before(now.Add(-lookback)) {
+		// Down longer than the lookback: catch up on the lookback only
+		return now.Add(-lookback)
+	}
+	return since
+}
+
+// freshPositions returns the positions reported after the last one passed on
+// for each vehicle.
+func freshPositions(last map[string]time.Time, positions []domain.VehiclePosition) []domain.VehiclePosition {
+	var fresh []domain.VehiclePosition
+	for _, position := range positions {
+		if seen, ok := last[positionKey(position)]; ok && !position.RecordedAt.After(seen) {
+			continue
+		}
+		fresh = append(fresh, position)
+	}
+	return fresh
+}
+
+func positionKey(position domain.VehiclePosition) string {
+	return string(position.Provider) + ":" + position.ExternalVehicleID
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/eld-integration/internal/domain"
+)
+
+func TestSyncWindowStart(t *testing.T) {
+	now := time.Date(2024, 6, 12, 12, 0, 0, 0, time.UTC)
+	overlap, lookback := 15*time.Minute, 24*time.Hour
+
+	tests := []struct {
+		name   string
+		cursor *domain.SyncCursor
+		want   time.Time
+	}{
+		{"never read", nil, now.Add(-lookback)},
+		{"read a minute ago", &domain.SyncCursor{SyncedUntil: now.Add(-time.Minute)}, now.Add(-16 * time.Minute)},
+		{"down for two days", &domain.SyncCursor{SyncedUntil: now.Add(-48 * time.Hour)}, now.Add(-lookback)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncWindowStart(tt.cursor, now, overlap, lookback); !got.Equal(tt.want) {
+				t.Errorf("syncWindowStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreshPositions(t *testing.T) {
+	at := time.Date(2024, 6, 12, 8, 0, 0, 0, time.UTC)
+	last := map[string]time.Time{
+		"SAMSARA:v-1": at,
+		"SAMSARA:v-2": at,
+	}
+	positions := []domain.VehiclePosition{
+		{Provider: domain.ProviderSamsara, ExternalVehicleID: "v-1", RecordedAt: at},                  // Unchanged
+		{Provider: domain.ProviderSamsara, ExternalVehicleID: "v-2", RecordedAt: at.Add(time.Minute)}, // Moved on
+		{Provider: domain.ProviderMotive, ExternalVehicleID: "v-1", RecordedAt: at},                   // Another provider's vehicle
+	}
+
+	fresh := freshPositions(last, positions)
+	if len(fresh) != 2 {
+		t.Fatalf("got %d fresh positions, want 2", len(fresh))
+	}
+	if fresh[0].ExternalVehicleID != "v-2" || fresh[1].Provider != domain.ProviderMotive {
+		t.Errorf("fresh = %+v", fresh)
+	}
+}
+
+func TestDutyStatusPayload(t *testing.T) {
+	driverID, tractorID := uuid.New(), uuid.New()
+	change := domain.DutyStatusChange{
+		Provider:   domain.ProviderMotive,
+		ExternalID: "881",
+		Status:     domain.DutyDriving,
+		StartTime:  time.Date(2024, 6, 12, 8, 0, 0, 0, time.UTC),
+		Odometer:   120400,
+	}
+
+	payload := dutyStatusPayload(change, driverID, &tractorID)
+	if payload["driver_id"] != driverID.String() || payload["tractor_id"] != tractorID.String() {
+		t.Errorf("driver/tractor = %v/%v", payload["driver_id"], payload["tractor_id"])
+	}
+	if payload["status"] != "DRIVING" || payload["external_id"] != "881" || payload["odometer"] != 120400 {
+		t.Errorf("payload = %v", payload)
+	}
+	if _, ok := dutyStatusPayload(change, driverID, nil)["tractor_id"]; ok {
+		t.Error("tractor_id set for an unlinked vehicle")
+	}
+}
+
+func TestValidateLink(t *testing.T) {
+	id := uuid.New()
+	if err := validateLink(domain.ProviderSamsara, "281474", id); err != nil {
+		t.Errorf("valid link rejected: %v", err)
+	}
+	for name, err := range map[string]error{
+		"unknown provider": validateLink("GEOTAB", "281474", id),
+		"no external id":   validateLink(domain.ProviderMotive, " ", id),
+		"no internal id":   validateLink(domain.ProviderMotive, "55", uuid.Nil),
+	} {
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("%s: got %v, want a ValidationError", name, err)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+-- 000001_init_schema.down.sql
+
+DROP TABLE IF EXISTS eld_unmatched;
+DROP TABLE IF EXISTS eld_sync_cursors;
+DROP TABLE IF EXISTS eld_received_duty_statuses;
+DROP TABLE IF EXISTS eld_vehicle_drivers;
+DROP TABLE IF EXISTS eld_vehicle_links;
+DROP TABLE IF EXISTS eld_driver_links;
@@ -0,0 +1,88 @@
+-- ==============================================================================
+-- ELD Integration Service — Initial Schema
+-- ==============================================================================
+-- Tables:
+--   eld_driver_links            Provider driver accounts matched to our drivers
+--   eld_vehicle_links           Provider vehicles matched to our tractors
+--   eld_vehicle_drivers         Driver last seen on each provider vehicle
+--   eld_received_duty_statuses  Duty status changes already passed on
+--   eld_sync_cursors            How far each provider feed has been read
+--   eld_unmatched               Provider accounts and vehicles still to link
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- Links
+-- ---------------------------------------------------------------------------
+-- Samsara and Motive identify drivers and vehicles by their own IDs. Data for
+-- an account or vehicle without a link is counted in eld_unmatched and dropped.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS eld_driver_links (
+    provider    VARCHAR(20)  NOT NULL CHECK (provider IN ('SAMSARA', 'MOTIVE')),
+    external_id VARCHAR(100) NOT NULL,
+    driver_id   UUID         NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_eld_driver_links_driver ON eld_driver_links(driver_id);
+
+CREATE TABLE IF NOT EXISTS eld_vehicle_links (
+    provider    VARCHAR(20)  NOT NULL CHECK (provider IN ('SAMSARA', 'MOTIVE')),
+    external_id VARCHAR(100) NOT NULL,
+    tractor_id  UUID         NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_eld_vehicle_links_tractor ON eld_vehicle_links(tractor_id);
+
+-- ---------------------------------------------------------------------------
+-- eld_vehicle_drivers
+-- ---------------------------------------------------------------------------
+-- Samsara's location feed does not say who is driving; the driver is taken
+-- from the latest duty status change logged on the vehicle.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS eld_vehicle_drivers (
+    provider            VARCHAR(20)  NOT NULL,
+    external_vehicle_id VARCHAR(100) NOT NULL,
+    external_driver_id  VARCHAR(100) NOT NULL,
+    seen_at             TIMESTAMPTZ  NOT NULL,
+    PRIMARY KEY (provider, external_vehicle_id)
+);
+
+-- ---------------------------------------------------------------------------
+-- eld_received_duty_statuses
+-- ---------------------------------------------------------------------------
+-- Feeds are re-read with an overlap to pick up late edits; a change already
+-- passed on to the driver service is skipped.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS eld_received_duty_statuses (
+    provider    VARCHAR(20)  NOT NULL,
+    external_id VARCHAR(150) NOT NULL,
+    driver_id   UUID         NOT NULL,
+    received_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_eld_received_duty_statuses_at ON eld_received_duty_statuses(received_at);
+
+CREATE TABLE IF NOT EXISTS eld_sync_cursors (
+    provider     VARCHAR(20) NOT NULL,
+    feed         VARCHAR(20) NOT NULL CHECK (feed IN ('DUTY_STATUS', 'LOCATIONS')),
+    synced_until TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, feed)
+);
+
+CREATE TABLE IF NOT EXISTS eld_unmatched (
+    provider    VARCHAR(20)  NOT NULL,
+    kind        VARCHAR(10)  NOT NULL CHECK (kind IN ('driver', 'vehicle')),
+    external_id VARCHAR(100) NOT NULL,
+    count       INTEGER      NOT NULL DEFAULT 1,
+    first_seen  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_seen   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, kind, external_id)
+);
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,9 +15,12 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/draymaster/shared/pkg/auth"
 	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/crypto"
 	"github.com/draymaster/shared/pkg/database"
 	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
 	"github.com/draymaster/shared/pkg/logger"
 	pb "github.com/draymaster/shared/proto/emodal/v1"
 
@@ -64,15 +68,31 @@ func main() {
 	repo := repository.NewRepository(db.Pool)
 
 	// Kafka producer — publishes internal events for other services
-	kafkaProducer := kafka.NewProducer(cfg.Kafka.Brokers, log)
+	kafkaProducer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
 	defer kafkaProducer.Close()
 	log.Info("Kafka producer initialized")
 
+	// eModal accounts — per-SCAC credentials, encrypted at rest
+	var accountStore client.AccountStore
+	if key := getEnv("EMODAL_CREDENTIAL_KEY", ""); key != "" {
+		cipher, err := crypto.NewCipherFromBase64(key)
+		if err != nil {
+			log.Fatalw("Invalid EMODAL_CREDENTIAL_KEY", "error", err)
+		}
+		accountStore = repository.NewAccountRepository(db.Pool, cipher)
+		log.Info("eModal account store enabled")
+	} else {
+		log.Warn("EMODAL_CREDENTIAL_KEY not set — multi-account support disabled, using EMODAL_API_KEY only")
+	}
+
 	// eModal EDS REST client
 	eModalClient := client.NewEModalClient(client.EModalConfig{
-		BaseURL: getEnv("EMODAL_BASE_URL", "https://apigateway.emodal.com"),
-		APIKey:  getEnv("EMODAL_API_KEY", ""),
-		Timeout: getDuration("EMODAL_TIMEOUT", 30*time.Second),
+		BaseURL:           getEnv("EMODAL_BASE_URL", "https://apigateway.emodal.com"),
+		TokenURL:          getEnv("EMODAL_TOKEN_URL", ""),
+		APIKey:            getEnv("EMODAL_API_KEY", ""),
+		Timeout:           getDuration("EMODAL_TIMEOUT", 30*time.Second),
+		RequestsPerMinute: getInt("EMODAL_REQUESTS_PER_MINUTE", 60),
+		Accounts:          accountStore,
 	}, log)
 	log.Info("eModal EDS client initialized")
 
@@ -81,7 +101,7 @@ func main() {
 
 	// Container publisher — auto-publishes new containers to eModal when order-service fires container.added
 	containerPublisher := service.NewContainerPublisher(eModalClient, log)
-	containerConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, "emodal-integration", kafka.Topics.ContainerAdded, log)
+	containerConsumer := kafka.NewConsumerWithConfig(cfg.Kafka.Brokers, "emodal-integration", kafka.Topics.ContainerAdded, kafka.ConsumerConfigFrom(cfg.Kafka), log)
 	defer containerConsumer.Close()
 
 	go func() {
@@ -93,6 +113,23 @@ func main() {
 	}()
 	log.Info("Container publisher consumer started")
 
+	// Outage tracker — tells ops when eModal calls keep failing, and when they recover
+	outageTracker := service.NewOutageTracker(kafkaProducer, getInt("EMODAL_OUTAGE_THRESHOLD", 3), log)
+
+	// Container verifier — answers order-service requests to check keyed containers against the terminal
+	containerVerifier := service.NewContainerVerifier(eModalClient, kafkaProducer, outageTracker, getDuration("EMODAL_VERIFY_TIMEOUT", 10*time.Second), log)
+	verificationConsumer := kafka.NewConsumerWithConfig(cfg.Kafka.Brokers, "emodal-integration-verification", kafka.Topics.ContainerVerificationRequested, kafka.ConsumerConfigFrom(cfg.Kafka), log)
+	defer verificationConsumer.Close()
+
+	go func() {
+		if err := verificationConsumer.Consume(ctx, containerVerifier.HandleEvent); err != nil {
+			if ctx.Err() == nil {
+				log.Fatalw("Container verification consumer failed", "error", err)
+			}
+		}
+	}()
+	log.Info("Container verification consumer started")
+
 	// Service Bus consumer — receives live container status events from eModal
 	sbNamespace := getEnv("SERVICEBUS_NAMESPACE", "")
 	sbSASToken := getEnv("SERVICEBUS_SAS_TOKEN", "")
@@ -116,14 +153,39 @@ func main() {
 		}()
 		log.Info("Service Bus consumer started")
 	} else {
-		log.Warn("SERVICEBUS_NAMESPACE or SERVICEBUS_SAS_TOKEN not set — Service Bus consumer disabled")
+		log.Warn("SERVICEBUS_NAMESPACE or SERVICEBUS_SAS_TOKEN not set — Service Bus consumer disabled, falling back to EDS polling")
+
+		poller := service.NewContainerPoller(eModalClient, repo, outageTracker, service.PollerConfig{
+			Interval:          getDuration("EMODAL_POLL_INTERVAL", 5*time.Minute),
+			BatchSize:         getInt("EMODAL_POLL_BATCH_SIZE", 50),
+			RequestsPerMinute: getInt("EMODAL_POLL_REQUESTS_PER_MINUTE", 30),
+		}, log)
+
+		// One replica polls at a time, keeping within eModal's rate limits
+		elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+		go elector.Run(ctx, "emodal.container-poller", func(ctx context.Context) {
+			if err := poller.Start(ctx, func(event domain.ContainerStatusEvent) error {
+				return eModalService.ProcessContainerEvent(ctx, event)
+			}); err != nil {
+				if ctx.Err() == nil {
+					log.Fatalw("Container status poller failed", "error", err)
+				}
+			}
+		})
+		log.Info("Container status poller started")
 	}
 
 	// gRPC server
+	authn, err := auth.NewAuthenticator(cfg.Auth, auth.DefaultPolicy(), log)
+	if err != nil {
+		log.Fatalw("Invalid auth configuration", "error", err)
+	}
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			grpcHandler.LoggingInterceptor(log),
 			grpcHandler.RecoveryInterceptor(log),
+			authn.UnaryInterceptor(),
+			grpcHandler.AccountInterceptor(),
 		),
 	)
 
@@ -176,3 +238,12 @@ func getDuration(key string, defaultVal time.Duration) time.Duration {
 	}
 	return defaultVal
 }
+
+func getInt(key string, defaultVal int) int {
+	if val := os.Getenv(key); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+	}
+	return defaultVal
+}
@@ -0,0 +1,345 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+)
+
+const (
+	// accountRefreshInterval bounds how long a rotated credential can go unnoticed.
+	accountRefreshInterval = 5 * time.Minute
+	// tokenExpirySkew refreshes OAuth tokens before eModal starts rejecting them.
+	tokenExpirySkew = time.Minute
+	// defaultRetryAfter is the pause applied when a 429 carries no Retry-After header.
+	defaultRetryAfter = 10 * time.Second
+)
+
+// AccountStore loads eModal accounts with their credentials decrypted.
+// Both methods return nil, nil when no matching account exists.
+type AccountStore interface {
+	GetAccountBySCAC(ctx context.Context, scac string) (*domain.EModalAccount, error)
+	GetDefaultAccount(ctx context.Context) (*domain.EModalAccount, error)
+}
+
+type scacKey struct{}
+
+// WithSCAC selects the eModal account used for requests made with ctx.
+func WithSCAC(ctx context.Context, scac string) context.Context {
+	if scac == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, scacKey{}, strings.ToUpper(scac))
+}
+
+// SCACFromContext returns the SCAC selected with WithSCAC, or "" for the default account.
+func SCACFromContext(ctx context.Context) string {
+	scac, _ := ctx.Value(scacKey{}).(string)
+	return scac
+}
+
+// accountState is the cached credential, token and rate limiter for one account.
+type accountState struct {
+	key      string
+	account  *domain.EModalAccount
+	limiter  *rateLimiter
+	loadedAt time.Time
+
+	tokenMu sync.Mutex
+	token   *domain.AccessToken
+}
+
+// resolve returns the account state for the SCAC on ctx, loading it from the
+// store when it is missing or due for a refresh.
+func (c *EModalClient) resolve(ctx context.Context) (*accountState, error) {
+	key := SCACFromContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := c.accounts[key]
+	if st != nil && time.Since(st.loadedAt) < accountRefreshInterval {
+		return st, nil
+	}
+
+	account, err := c.loadAccount(ctx, key)
+	if err != nil {
+		if st != nil {
+			// Keep serving the cached credential if the store is briefly unavailable
+			c.log.Warnw("Failed to refresh eModal account, using cached credentials", "scac", key, "error", err)
+			return st, nil
+		}
+		return nil, err
+	}
+
+	rpm := account.RequestsPerMinute
+	if rpm <= 0 {
+		rpm = c.defaultRPM
+	}
+
+	if st == nil {
+		st = &accountState{key: key, limiter: newRateLimiter(rpm)}
+		c.accounts[key] = st
+	} else {
+		st.limiter.setRate(rpm)
+		if credentialsChanged(st.account, account) {
+			c.log.Infow("eModal credentials rotated", "scac", account.SCAC)
+			st.tokenMu.Lock()
+			st.token = nil
+			st.tokenMu.Unlock()
+		}
+	}
+	st.account = account
+	st.loadedAt = time.Now()
+	return st, nil
+}
+
+// loadAccount fetches an account from the store, falling back to the static
+// API key from configuration for the default account.
+func (c *EModalClient) loadAccount(ctx context.Context, scac string) (*domain.EModalAccount, error) {
+	var account *domain.EModalAccount
+	if c.store != nil {
+		var err error
+		if scac != "" {
+			account, err = c.store.GetAccountBySCAC(ctx, scac)
+		} else {
+			account, err = c.store.GetDefaultAccount(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load eModal account: %w", err)
+		}
+	}
+	if account != nil {
+		if !account.Active {
+			return nil, fmt.Errorf("eModal account for SCAC %s is inactive", account.SCAC)
+		}
+		return account, nil
+	}
+	if scac != "" {
+		return nil, fmt.Errorf("no eModal account configured for SCAC %s", scac)
+	}
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("no default eModal account configured")
+	}
+	return &domain.EModalAccount{
+		Name:      "default",
+		AuthType:  domain.AuthTypeAPIKey,
+		APIKey:    c.apiKey,
+		IsDefault: true,
+		Active:    true,
+	}, nil
+}
+
+// InvalidateAccount drops the cached credentials for a SCAC so the next
+// request reloads them. Pass "" for the default account.
+func (c *EModalClient) InvalidateAccount(scac string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st := c.accounts[strings.ToUpper(scac)]; st != nil {
+		st.loadedAt = time.Time{}
+	}
+}
+
+// RateLimitStats returns request pacing for every account used since startup.
+func (c *EModalClient) RateLimitStats() []domain.RateLimitStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]domain.RateLimitStats, 0, len(c.accounts))
+	for key, st := range c.accounts {
+		s := st.limiter.stats()
+		s.SCAC = key
+		if st.account != nil && st.account.SCAC != "" {
+			s.SCAC = st.account.SCAC
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].SCAC < stats[j].SCAC })
+	return stats
+}
+
+func credentialsChanged(old, updated *domain.EModalAccount) bool {
+	return old == nil ||
+		old.AuthType != updated.AuthType ||
+		old.APIKey != updated.APIKey ||
+		old.ClientID != updated.ClientID ||
+		old.ClientSecret != updated.ClientSecret
+}
+
+// authorize sets the authentication header for the account on req.
+func (c *EModalClient) authorize(ctx context.Context, st *accountState, req *http.Request) error {
+	if st.account.AuthType != domain.AuthTypeOAuth {
+		req.Header.Set("X-API-KEY", st.account.APIKey)
+		return nil
+	}
+	token, err := c.accessToken(ctx, st)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// accessToken returns a valid bearer token, requesting a new one when the
+// cached token is missing or about to expire.
+func (c *EModalClient) accessToken(ctx context.Context, st *accountState) (string, error) {
+	st.tokenMu.Lock()
+	defer st.tokenMu.Unlock()
+
+	if st.token.Valid(time.Now(), tokenExpirySkew) {
+		return st.token.Token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {st.account.ClientID},
+		"client_secret": {st.account.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("request token: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("request token: decode: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("request token: empty access token")
+	}
+
+	st.token = &domain.AccessToken{
+		Token:     result.AccessToken,
+		ExpiresAt: time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}
+	c.log.Debugw("eModal access token refreshed", "scac", st.account.SCAC, "expires_at", st.token.ExpiresAt)
+	return st.token.Token, nil
+}
+
+// rateLimiter paces requests for one account. Callers reserve the next free
+// slot in arrival order and wait for it, so bursts queue instead of failing.
+type rateLimiter struct {
+	mu          sync.Mutex
+	rpm         int
+	gap         time.Duration
+	next        time.Time
+	pausedUntil time.Time
+	queued      int
+	requests    int64
+	throttled   int64
+}
+
+func newRateLimiter(rpm int) *rateLimiter {
+	l := &rateLimiter{}
+	l.setRate(rpm)
+	return l
+}
+
+func (l *rateLimiter) setRate(rpm int) {
+	if rpm <= 0 {
+		rpm = 60
+	}
+	l.mu.Lock()
+	l.rpm = rpm
+	l.gap = time.Minute / time.Duration(rpm)
+	l.mu.Unlock()
+}
+
+// Wait blocks until the caller's reserved slot arrives or ctx is cancelled.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	if slot.Before(l.pausedUntil) {
+		slot = l.pausedUntil
+	}
+	l.next = slot.Add(l.gap)
+	l.queued++
+	l.requests++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Pause holds all requests for the account after eModal throttles it.
+func (l *rateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	if l.next.Before(until) {
+		l.next = until
+	}
+	l.throttled++
+}
+
+func (l *rateLimiter) stats() domain.RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := domain.RateLimitStats{
+		RequestsPerMinute: l.rpm,
+		Requests:          l.requests,
+		Throttled:         l.throttled,
+		Queued:            l.queued,
+	}
+	if l.pausedUntil.After(time.Now()) {
+		until := l.pausedUntil
+		s.PausedUntil = &until
+	}
+	return s
+}
+
+// retryAfter reads the Retry-After header in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return defaultRetryAfter
+}
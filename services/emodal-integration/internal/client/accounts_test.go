@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+)
+
+type memoryAccountStore struct {
+	mu       sync.Mutex
+	accounts map[string]*domain.EModalAccount
+}
+
+func (s *memoryAccountStore) GetAccountBySCAC(_ context.Context, scac string) (*domain.EModalAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[scac]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryAccountStore) GetDefaultAccount(_ context.Context) (*domain.EModalAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.accounts {
+		if a.IsDefault {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryAccountStore) setAPIKey(scac, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[scac].APIKey = key
+}
+
+func newAccountTestClient(t *testing.T, serverURL string, store AccountStore) *EModalClient {
+	t.Helper()
+	return NewEModalClient(EModalConfig{
+		BaseURL:           serverURL,
+		RequestsPerMinute: 6000,
+		Accounts:          store,
+	}, newTestLogger(t))
+}
+
+func TestDoRequest_RoutesBySCAC(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-API-KEY"))
+		mu.Unlock()
+		w.Write([]byte(`{"Terminals":[]}`))
+	}))
+	defer server.Close()
+
+	store := &memoryAccountStore{accounts: map[string]*domain.EModalAccount{
+		"ABCD": {SCAC: "ABCD", AuthType: domain.AuthTypeAPIKey, APIKey: "key-abcd", Active: true, IsDefault: true},
+		"WXYZ": {SCAC: "WXYZ", AuthType: domain.AuthTypeAPIKey, APIKey: "key-wxyz", Active: true},
+	}}
+	c := newAccountTestClient(t, server.URL, store)
+
+	ctx := context.Background()
+	if _, err := c.GetTerminals(WithSCAC(ctx, "wxyz"), "USLAX"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.GetTerminals(ctx, "USLAX"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.GetTerminals(WithSCAC(ctx, "NONE"), "USLAX"); err == nil {
+		t.Error("expected error for unknown SCAC")
+	}
+
+	if len(keys) != 2 || keys[0] != "key-wxyz" || keys[1] != "key-abcd" {
+		t.Errorf("keys sent = %v, want [key-wxyz key-abcd]", keys)
+	}
+}
+
+func TestDoRequest_ReloadsRotatedKeyOnUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-KEY") != "new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"Terminals":[]}`))
+	}))
+	defer server.Close()
+
+	store := &memoryAccountStore{accounts: map[string]*domain.EModalAccount{
+		"ABCD": {SCAC: "ABCD", AuthType: domain.AuthTypeAPIKey, APIKey: "new-key", Active: true},
+	}}
+	c := newAccountTestClient(t, server.URL, store)
+	ctx := WithSCAC(context.Background(), "ABCD")
+
+	// Prime the cache with the key that is about to be rotated out
+	store.setAPIKey("ABCD", "old-key")
+	if _, err := c.resolve(ctx); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	store.setAPIKey("ABCD", "new-key")
+
+	if _, err := c.GetTerminals(ctx, "USLAX"); err != nil {
+		t.Fatalf("expected retry with rotated key to succeed, got %v", err)
+	}
+}
+
+func TestDoRequest_OAuthTokenCachedAndBearerSent(t *testing.T) {
+	var tokenRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if r.FormValue("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token":"tok-1","expires_in":3600}`))
+	})
+	mux.HandleFunc("/eds/terminals/USLAX", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"Terminals":[]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := &memoryAccountStore{accounts: map[string]*domain.EModalAccount{
+		"ABCD": {SCAC: "ABCD", AuthType: domain.AuthTypeOAuth, ClientID: "id", ClientSecret: "s3cret", Active: true},
+	}}
+	c := newAccountTestClient(t, server.URL, store)
+	ctx := WithSCAC(context.Background(), "ABCD")
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetTerminals(ctx, "USLAX"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requested %d times, want 1", tokenRequests)
+	}
+}
+
+func TestDoRequest_PausesAccountOnTooManyRequests(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"Terminals":[]}`))
+	}))
+	defer server.Close()
+
+	c := NewEModalClient(EModalConfig{BaseURL: server.URL, APIKey: "static", RequestsPerMinute: 6000}, newTestLogger(t))
+
+	start := time.Now()
+	if _, err := c.GetTerminals(context.Background(), "USLAX"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retry after 429 came after %v, want at least 1s", elapsed)
+	}
+
+	stats := c.RateLimitStats()
+	if len(stats) != 1 || stats[0].Throttled != 1 || stats[0].Requests != 2 {
+		t.Errorf("stats = %+v, want one account with 2 requests and 1 throttle", stats)
+	}
+}
+
+func TestRateLimiter_QueuesRequests(t *testing.T) {
+	l := newRateLimiter(600) // 100ms apart
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Errorf("wait: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3 requests at 600 rpm finished in %v, want at least 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Pause(time.Hour)
+	cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("expected cancelled wait to return an error")
+	}
+}
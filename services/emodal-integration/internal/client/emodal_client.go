@@ -4,29 +4,50 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/draymaster/services/emodal-integration/internal/domain"
 	"github.com/draymaster/shared/pkg/logger"
 )
 
+// ErrSlotUnavailable is returned when the terminal has no room left in the
+// requested appointment slot.
+var ErrSlotUnavailable = errors.New("appointment slot unavailable")
+
+// ErrAppointmentNotFound is returned when eModal does not know an appointment,
+// e.g. because the terminal already cancelled it.
+var ErrAppointmentNotFound = errors.New("appointment not found")
+
 // EModalConfig holds configuration for the eModal EDS REST API.
 type EModalConfig struct {
-	BaseURL string        // e.g. https://apigateway.emodal.com
-	APIKey  string        // X-API-KEY header value
-	Timeout time.Duration // HTTP client timeout
+	BaseURL           string        // e.g. https://apigateway.emodal.com
+	TokenURL          string        // OAuth token endpoint for client-credential accounts
+	APIKey            string        // X-API-KEY for the default account when none is stored
+	Timeout           time.Duration // HTTP client timeout
+	RequestsPerMinute int           // Pacing for accounts without their own limit
+	Accounts          AccountStore  // Per-SCAC accounts; nil uses APIKey only
 }
 
 // EModalClient is the REST API client for eModal Data Services (EDS).
-// All requests authenticate via the X-API-KEY header.
+// Requests run under the account selected with WithSCAC, authenticating via
+// the X-API-KEY header or an OAuth bearer token, and are paced per account.
 type EModalClient struct {
 	baseURL    string
+	tokenURL   string
 	apiKey     string
+	defaultRPM int
+	store      AccountStore
 	httpClient *http.Client
 	log        *logger.Logger
+
+	mu       sync.Mutex
+	accounts map[string]*accountState
 }
 
 // NewEModalClient creates a new eModal EDS API client.
@@ -35,11 +56,23 @@ func NewEModalClient(cfg EModalConfig, log *logger.Logger) *EModalClient {
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
+	rpm := cfg.RequestsPerMinute
+	if rpm <= 0 {
+		rpm = 60
+	}
+	tokenURL := cfg.TokenURL
+	if tokenURL == "" {
+		tokenURL = cfg.BaseURL + "/oauth/token"
+	}
 	return &EModalClient{
 		baseURL:    cfg.BaseURL,
+		tokenURL:   tokenURL,
 		apiKey:     cfg.APIKey,
+		defaultRPM: rpm,
+		store:      cfg.Accounts,
 		httpClient: &http.Client{Timeout: timeout},
 		log:        log,
+		accounts:   make(map[string]*accountState),
 	}
 }
 
@@ -72,6 +105,27 @@ type slotItem struct {
 	MoveType  string    `json:"MoveType"`
 }
 
+type appointmentRequestBody struct {
+	TerminalCode      string    `json:"TerminalCode"`
+	MoveType          string    `json:"MoveType"`
+	SlotTime          time.Time `json:"SlotTime"`
+	ContainerNumber   string    `json:"ContainerNumber,omitempty"`
+	ContainerSize     string    `json:"ContainerSize,omitempty"`
+	BookingNumber     string    `json:"BookingNumber,omitempty"`
+	ChassisNumber     string    `json:"ChassisNumber,omitempty"`
+	TruckLicensePlate string    `json:"TruckLicensePlate,omitempty"`
+	DriverName        string    `json:"DriverName,omitempty"`
+}
+
+type appointmentResponse struct {
+	Success            bool       `json:"Success"`
+	Message            string     `json:"Message"`
+	AppointmentID      string     `json:"AppointmentId"`
+	ConfirmationNumber string     `json:"ConfirmationNumber"`
+	SlotTime           time.Time  `json:"SlotTime"`
+	WindowEnd          *time.Time `json:"WindowEnd"`
+}
+
 type dwellStatsResponse struct {
 	Stats             []dwellStatItem `json:"Stats"`
 	AverageDwellHours float64        `json:"AverageDwellHours"`
@@ -97,6 +151,20 @@ type terminalItem struct {
 	AppointmentType string   `json:"AppointmentType"`
 }
 
+type containerStatusResponse struct {
+	Containers []eModalEvent `json:"Containers"`
+}
+
+type containerInfoResponse struct {
+	ContainerNumber string `json:"ContainerNumber"`
+	TerminalCode    string `json:"TerminalCode"`
+	FacilityName    string `json:"FacilityName"`
+	VesselName      string `json:"VesselName"`
+	VoyageNumber    string `json:"VoyageNumber"`
+	SizeTypeCode    string `json:"SizeTypeCode"`
+	LineOperator    string `json:"LineOperator"`
+}
+
 // --- API Methods ---
 
 // PublishContainers registers containers with eModal for real-time status tracking.
@@ -168,6 +236,135 @@ func (c *EModalClient) GetAppointmentAvailability(ctx context.Context, terminalI
 	return slots, nil
 }
 
+// BookAppointment books a PreGate appointment in a terminal slot. The
+// returned appointment carries eModal's ID and the terminal's confirmation
+// number; a full slot returns ErrSlotUnavailable.
+func (c *EModalClient) BookAppointment(ctx context.Context, req domain.AppointmentRequest) (*domain.Appointment, error) {
+	result, err := c.sendAppointment(ctx, "book appointment", http.MethodPost, "/eds/PreGate/Appointments", req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Infow("Booked eModal appointment",
+		"terminal", req.TerminalCode,
+		"container", req.ContainerNumber,
+		"confirmation", result.ConfirmationNumber,
+	)
+	return confirmedAppointment(result, req), nil
+}
+
+// ModifyAppointment moves a PreGate appointment to another slot or changes
+// the truck, chassis or container on it. req must carry every field of the
+// appointment, changed or not. Terminals may issue a new confirmation number.
+func (c *EModalClient) ModifyAppointment(ctx context.Context, appointmentID string, req domain.AppointmentRequest) (*domain.Appointment, error) {
+	result, err := c.sendAppointment(ctx, "modify appointment", http.MethodPut, "/eds/PreGate/Appointments/"+url.PathEscape(appointmentID), req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Infow("Modified eModal appointment", "appointment", appointmentID, "confirmation", result.ConfirmationNumber)
+	if result.AppointmentID == "" {
+		result.AppointmentID = appointmentID
+	}
+	return confirmedAppointment(result, req), nil
+}
+
+// CancelAppointment cancels a PreGate appointment, releasing its slot. An
+// appointment eModal no longer knows returns ErrAppointmentNotFound.
+func (c *EModalClient) CancelAppointment(ctx context.Context, appointmentID, reason string) error {
+	path := "/eds/PreGate/Appointments/" + url.PathEscape(appointmentID)
+	if reason != "" {
+		path += "?reason=" + url.QueryEscape(reason)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return fmt.Errorf("cancel appointment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound:
+		return fmt.Errorf("cancel appointment %s: %w", appointmentID, ErrAppointmentNotFound)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cancel appointment: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	c.log.Infow("Cancelled eModal appointment", "appointment", appointmentID)
+	return nil
+}
+
+// sendAppointment books or modifies an appointment and decodes the confirmation.
+func (c *EModalClient) sendAppointment(ctx context.Context, op, method, path string, req domain.AppointmentRequest) (*appointmentResponse, error) {
+	body := appointmentRequestBody{
+		TerminalCode:      req.TerminalCode,
+		MoveType:          string(req.MoveType),
+		SlotTime:          req.SlotTime,
+		ContainerNumber:   req.ContainerNumber,
+		ContainerSize:     req.ContainerSize,
+		BookingNumber:     req.BookingNumber,
+		ChassisNumber:     req.ChassisNumber,
+		TruckLicensePlate: req.TruckPlate,
+		DriverName:        req.DriverName,
+	}
+
+	resp, err := c.doRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusConflict:
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrSlotUnavailable, string(msg))
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", op, ErrAppointmentNotFound)
+	default:
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: HTTP %d: %s", op, resp.StatusCode, string(msg))
+	}
+
+	var result appointmentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%s: decode: %w", op, err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("%s: %s", op, result.Message)
+	}
+	if result.ConfirmationNumber == "" {
+		return nil, fmt.Errorf("%s: no confirmation number returned", op)
+	}
+	return &result, nil
+}
+
+// confirmedAppointment is the appointment as requested, in the slot the
+// terminal confirmed when it names one.
+func confirmedAppointment(result *appointmentResponse, req domain.AppointmentRequest) *domain.Appointment {
+	slot := result.SlotTime
+	if slot.IsZero() {
+		slot = req.SlotTime
+	}
+	return &domain.Appointment{
+		EModalAppointmentID: result.AppointmentID,
+		ConfirmationNumber:  result.ConfirmationNumber,
+		TerminalCode:        req.TerminalCode,
+		MoveType:            req.MoveType,
+		SlotTime:            slot,
+		WindowEnd:           result.WindowEnd,
+		ContainerNumber:     req.ContainerNumber,
+		ContainerSize:       req.ContainerSize,
+		BookingNumber:       req.BookingNumber,
+		ChassisNumber:       req.ChassisNumber,
+		TruckPlate:          req.TruckPlate,
+		DriverName:          req.DriverName,
+		Status:              domain.AppointmentBooked,
+	}
+}
+
 // GetDwellStats retrieves container dwell time statistics from eModal.
 func (c *EModalClient) GetDwellStats(ctx context.Context, terminalID string, startDate, endDate time.Time, containerNumbers []string) ([]domain.DwellStats, float64, error) {
 	path := fmt.Sprintf("/eds/terminals/%s/dwell?startDate=%s&endDate=%s",
@@ -241,27 +438,140 @@ func (c *EModalClient) GetTerminals(ctx context.Context, portCode string) ([]dom
 	return terminals, nil
 }
 
+// GetContainerStatuses queries the current status of published containers.
+// Used as a polling fallback when Service Bus push events are unavailable.
+func (c *EModalClient) GetContainerStatuses(ctx context.Context, containerNumbers []string) ([]domain.ContainerStatusEvent, error) {
+	path := "/eds/ServiceOrder/ContainerStatus?"
+	for i, cn := range containerNumbers {
+		if i > 0 {
+			path += "&"
+		}
+		path += "container=" + url.QueryEscape(cn)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get container statuses: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get container statuses: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result containerStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("get container statuses: decode: %w", err)
+	}
+
+	events := make([]domain.ContainerStatusEvent, 0, len(result.Containers))
+	for _, raw := range result.Containers {
+		if raw.ContainerNumber == "" {
+			continue
+		}
+		events = append(events, raw.toDomain())
+	}
+	return events, nil
+}
+
+// GetContainerDetails looks up a container's terminal record, including the
+// vessel it arrived on and its ISO size/type. A container no terminal knows
+// is returned with Found false rather than as an error.
+func (c *EModalClient) GetContainerDetails(ctx context.Context, containerNumber string) (*domain.ContainerDetails, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/eds/ContainerInfo?container="+url.QueryEscape(containerNumber), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get container details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &domain.ContainerDetails{ContainerNumber: containerNumber}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get container details: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result containerInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("get container details: decode: %w", err)
+	}
+
+	return &domain.ContainerDetails{
+		ContainerNumber: containerNumber,
+		Found:           result.ContainerNumber != "",
+		TerminalCode:    result.TerminalCode,
+		TerminalName:    result.FacilityName,
+		VesselName:      result.VesselName,
+		VoyageNumber:    result.VoyageNumber,
+		ISOSizeType:     result.SizeTypeCode,
+		LineOperator:    result.LineOperator,
+	}, nil
+}
+
 // doRequest executes an authenticated HTTP request against the eModal EDS API.
+// A 401 reloads the account's credentials and a 429 pauses the account for
+// Retry-After; either way the request is retried once.
 func (c *EModalClient) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshal body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	st, err := c.resolve(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("X-API-KEY", c.apiKey)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	for attempt := 0; ; attempt++ {
+		if err := st.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		if err := c.authorize(ctx, st, req); err != nil {
+			return nil, fmt.Errorf("authorize: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		c.log.Debugw("eModal API request", "method", method, "path", path, "scac", st.key)
+		resp, err := c.httpClient.Do(req)
+		if err != nil || attempt > 0 {
+			return resp, err
+		}
 
-	c.log.Debugw("eModal API request", "method", method, "path", path)
-	return c.httpClient.Do(req)
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			resp.Body.Close()
+			c.log.Warnw("eModal rejected credentials, reloading account", "scac", st.key)
+			c.InvalidateAccount(st.key)
+			st.tokenMu.Lock()
+			st.token = nil
+			st.tokenMu.Unlock()
+			if st, err = c.resolve(ctx); err != nil {
+				return nil, err
+			}
+		case http.StatusTooManyRequests:
+			wait := retryAfter(resp)
+			resp.Body.Close()
+			c.log.Warnw("eModal rate limit hit, pausing account", "scac", st.key, "retry_after", wait)
+			st.limiter.Pause(wait)
+		default:
+			return resp, nil
+		}
+	}
 }
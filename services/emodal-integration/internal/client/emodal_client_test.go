@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+)
+
+func TestGetContainerDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eds/ContainerInfo" {
+			t.Errorf("path = %s, want /eds/ContainerInfo", r.URL.Path)
+		}
+		if r.URL.Query().Get("container") == "MSCU0000000" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"ContainerNumber":"MSCU1234567","TerminalCode":"POLA","FacilityName":"APM Terminals",
+			"VesselName":"MSC AURORA","VoyageNumber":"123E","SizeTypeCode":"45G1","LineOperator":"MSC"}`))
+	}))
+	defer server.Close()
+
+	c := NewEModalClient(EModalConfig{BaseURL: server.URL, APIKey: "key", RequestsPerMinute: 6000}, newTestLogger(t))
+
+	details, err := c.GetContainerDetails(context.Background(), "MSCU1234567")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !details.Found || details.TerminalCode != "POLA" || details.VesselName != "MSC AURORA" || details.ISOSizeType != "45G1" {
+		t.Errorf("details = %+v", details)
+	}
+
+	missing, err := c.GetContainerDetails(context.Background(), "MSCU0000000")
+	if err != nil {
+		t.Fatalf("unexpected error for unknown container: %v", err)
+	}
+	if missing.Found || missing.ContainerNumber != "MSCU0000000" {
+		t.Errorf("unknown container details = %+v, want not found", missing)
+	}
+}
+
+func TestBookAppointment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/eds/PreGate/Appointments" {
+			t.Errorf("request = %s %s, want POST /eds/PreGate/Appointments", r.Method, r.URL.Path)
+		}
+		var body appointmentRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if body.ContainerNumber == "MSCU0000000" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`slot full`))
+			return
+		}
+		if body.TerminalCode != "POLA" || body.MoveType != "IP" || body.TruckLicensePlate != "8ABC123" {
+			t.Errorf("body = %+v", body)
+		}
+		w.Write([]byte(`{"Success":true,"AppointmentId":"A-1","ConfirmationNumber":"PG123456",
+			"SlotTime":"2026-10-20T08:00:00Z","WindowEnd":"2026-10-20T09:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := NewEModalClient(EModalConfig{BaseURL: server.URL, APIKey: "key", RequestsPerMinute: 6000}, newTestLogger(t))
+	req := domain.AppointmentRequest{
+		TerminalCode:    "POLA",
+		MoveType:        domain.MoveTypeImportPickup,
+		SlotTime:        time.Date(2026, 10, 20, 7, 30, 0, 0, time.UTC),
+		ContainerNumber: "MSCU1234567",
+		TruckPlate:      "8ABC123",
+	}
+
+	appt, err := c.BookAppointment(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appt.EModalAppointmentID != "A-1" || appt.ConfirmationNumber != "PG123456" || appt.Status != domain.AppointmentBooked {
+		t.Errorf("appointment = %+v", appt)
+	}
+	if want := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC); !appt.SlotTime.Equal(want) {
+		t.Errorf("slot time = %v, want the confirmed %v", appt.SlotTime, want)
+	}
+	if appt.WindowEnd == nil || appt.ContainerNumber != "MSCU1234567" {
+		t.Errorf("appointment = %+v, want window end and container", appt)
+	}
+
+	req.ContainerNumber = "MSCU0000000"
+	if _, err := c.BookAppointment(context.Background(), req); !errors.Is(err, ErrSlotUnavailable) {
+		t.Errorf("full slot error = %v, want ErrSlotUnavailable", err)
+	}
+}
+
+func TestModifyAndCancelAppointment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/eds/PreGate/Appointments/A-1":
+			w.Write([]byte(`{"Success":true,"ConfirmationNumber":"PG654321"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/eds/PreGate/Appointments/A-1":
+			if r.URL.Query().Get("reason") != "driver out" {
+				t.Errorf("reason = %q, want driver out", r.URL.Query().Get("reason"))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && r.URL.Path == "/eds/PreGate/Appointments/A-2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	c := NewEModalClient(EModalConfig{BaseURL: server.URL, APIKey: "key", RequestsPerMinute: 6000}, newTestLogger(t))
+	ctx := context.Background()
+	slot := time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)
+
+	appt, err := c.ModifyAppointment(ctx, "A-1", domain.AppointmentRequest{TerminalCode: "POLA", MoveType: domain.MoveTypeImportPickup, SlotTime: slot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appt.EModalAppointmentID != "A-1" || appt.ConfirmationNumber != "PG654321" || !appt.SlotTime.Equal(slot) {
+		t.Errorf("modified appointment = %+v, want same ID, new confirmation and the requested slot", appt)
+	}
+
+	if err := c.CancelAppointment(ctx, "A-1", "driver out"); err != nil {
+		t.Errorf("unexpected cancel error: %v", err)
+	}
+	if err := c.CancelAppointment(ctx, "A-2", ""); !errors.Is(err, ErrAppointmentNotFound) {
+		t.Errorf("cancel unknown error = %v, want ErrAppointmentNotFound", err)
+	}
+}
@@ -144,6 +144,11 @@ type locationInfo struct {
 	TerminalCode string `json:"TerminalCode"`
 	City         string `json:"City"`
 	State        string `json:"State"`
+	GroundedInd  string `json:"GroundedWheeledInd"` // "G" grounded, "W" wheeled
+	YardBlock    string `json:"YardBlock"`
+	YardRow      string `json:"YardRow"`
+	YardBay      string `json:"YardBay"`
+	YardTier     string `json:"YardTier"`
 }
 
 // parseMessage converts raw JSON from eModal into a ContainerStatusEvent.
@@ -156,6 +161,13 @@ func (s *ServiceBusConsumer) parseMessage(data []byte) (*domain.ContainerStatusE
 		return nil, fmt.Errorf("missing ContainerNumber")
 	}
 
+	event := raw.toDomain()
+	return &event, nil
+}
+
+// toDomain converts an eModal container event payload into a ContainerStatusEvent.
+// The same payload shape is returned by the EDS status query endpoint.
+func (raw eModalEvent) toDomain() domain.ContainerStatusEvent {
 	location := raw.CurrentLocation.FacilityName
 	if raw.CurrentLocation.City != "" {
 		location += ", " + raw.CurrentLocation.City
@@ -164,12 +176,19 @@ func (s *ServiceBusConsumer) parseMessage(data []byte) (*domain.ContainerStatusE
 		}
 	}
 
-	return &domain.ContainerStatusEvent{
+	return domain.ContainerStatusEvent{
 		ContainerNumber:     raw.ContainerNumber,
 		Status:              domain.MapStatusCode(raw.UnitStatusInfo.StatusCode),
 		TerminalCode:        raw.CurrentLocation.TerminalCode,
 		TerminalName:        raw.CurrentLocation.FacilityName,
 		LocationDescription: location,
-		OccurredAt:          raw.EventTimestamp,
-	}, nil
+		Yard: domain.YardPosition{
+			MountStatus: domain.MapMountCode(raw.CurrentLocation.GroundedInd),
+			Block:       raw.CurrentLocation.YardBlock,
+			Row:         raw.CurrentLocation.YardRow,
+			Bay:         raw.CurrentLocation.YardBay,
+			Tier:        raw.CurrentLocation.YardTier,
+		},
+		OccurredAt: raw.EventTimestamp,
+	}
 }
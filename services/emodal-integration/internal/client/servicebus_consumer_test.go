@@ -127,3 +127,50 @@ func TestParseMessage_LocationCityOnly(t *testing.T) {
 		t.Errorf("LocationDescription = %q, want %q", event.LocationDescription, "Terminal X, Oakland")
 	}
 }
+
+func TestParseMessage_YardPosition(t *testing.T) {
+	consumer := &ServiceBusConsumer{log: newTestLogger(t)}
+
+	payload := []byte(`{
+		"ContainerNumber": "MSCU1234567",
+		"EventTimestamp": "2024-06-15T10:30:00Z",
+		"UnitStatusInfo": {"StatusCode": "A"},
+		"CurrentLocationInfo": {
+			"TerminalCode": "POLA",
+			"GroundedWheeledInd": "g",
+			"YardBlock": "B14",
+			"YardRow": "07",
+			"YardBay": "22",
+			"YardTier": "3"
+		}
+	}`)
+
+	event, err := consumer.parseMessage(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := domain.YardPosition{MountStatus: domain.MountGrounded, Block: "B14", Row: "07", Bay: "22", Tier: "3"}
+	if event.Yard != want {
+		t.Errorf("Yard = %+v, want %+v", event.Yard, want)
+	}
+}
+
+func TestParseMessage_NoYardPosition(t *testing.T) {
+	consumer := &ServiceBusConsumer{log: newTestLogger(t)}
+
+	payload := []byte(`{
+		"ContainerNumber": "MSCU1234567",
+		"EventTimestamp": "2024-06-15T10:30:00Z",
+		"UnitStatusInfo": {"StatusCode": "O"},
+		"CurrentLocationInfo": {"TerminalCode": "POLA"}
+	}`)
+
+	event, err := consumer.parseMessage(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !event.Yard.IsZero() {
+		t.Errorf("Yard = %+v, want zero position", event.Yard)
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthType is how an eModal account authenticates with EDS.
+type AuthType string
+
+const (
+	AuthTypeAPIKey AuthType = "API_KEY" // Static X-API-KEY header
+	AuthTypeOAuth  AuthType = "OAUTH"   // Client credentials exchanged for a bearer token
+)
+
+// EModalAccount is one eModal subscription, keyed by the SCAC it dispatches under.
+// Secrets are held decrypted in memory only; the repository encrypts them at rest.
+type EModalAccount struct {
+	ID                uuid.UUID
+	SCAC              string
+	Name              string
+	AuthType          AuthType
+	APIKey            string
+	ClientID          string
+	ClientSecret      string
+	RequestsPerMinute int
+	IsDefault         bool
+	Active            bool
+	RotatedAt         *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// AccessToken is a bearer token issued to an OAuth eModal account.
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Valid reports whether the token can still be used, leaving skew for
+// requests in flight when it expires.
+func (t *AccessToken) Valid(now time.Time, skew time.Duration) bool {
+	return t != nil && t.Token != "" && now.Add(skew).Before(t.ExpiresAt)
+}
+
+// RateLimitStats reports request pacing for one eModal account.
+type RateLimitStats struct {
+	SCAC              string
+	RequestsPerMinute int
+	Requests          int64
+	Throttled         int64 // HTTP 429 responses from eModal
+	Queued            int   // Requests currently waiting for a slot
+	PausedUntil       *time.Time
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppointmentStatus is the state of a PreGate appointment.
+type AppointmentStatus string
+
+const (
+	AppointmentBooked    AppointmentStatus = "BOOKED"
+	AppointmentCancelled AppointmentStatus = "CANCELLED"
+)
+
+// AppointmentRequest describes a PreGate appointment to book, or the changes
+// to make to one. When modifying, empty fields keep their booked values.
+type AppointmentRequest struct {
+	TerminalCode    string
+	MoveType        MoveType
+	SlotTime        time.Time
+	ContainerNumber string // Empty for an empty pickup against a booking
+	ContainerSize   string // 20, 40, 45
+	BookingNumber   string // Export booking or EDO, for moves without a container number yet
+	ChassisNumber   string
+	TruckPlate      string
+	DriverName      string
+}
+
+// Appointment is a PreGate appointment confirmation, persisted so dispatch
+// can attach it to the trip stop it was booked for.
+type Appointment struct {
+	ID                  uuid.UUID
+	EModalAppointmentID string // eModal's ID, used to modify or cancel
+	ConfirmationNumber  string // Number the terminal checks at the gate
+	TerminalCode        string
+	MoveType            MoveType
+	SlotTime            time.Time
+	WindowEnd           *time.Time // End of the terminal's grace window, when it sends one
+	ContainerNumber     string
+	ContainerSize       string
+	BookingNumber       string
+	ChassisNumber       string
+	TruckPlate          string
+	DriverName          string
+	Status              AppointmentStatus
+	SCAC                string // eModal account the appointment was booked under; "" for default
+	TripID              *uuid.UUID
+	StopID              *uuid.UUID
+	CancelReason        string
+	BookedAt            time.Time
+	ModifiedAt          *time.Time
+	CancelledAt         *time.Time
+}
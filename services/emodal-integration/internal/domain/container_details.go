@@ -0,0 +1,66 @@
+package domain
+
+import "strings"
+
+// ContainerDetails is a container's record at the terminal as reported by eModal EDS.
+type ContainerDetails struct {
+	ContainerNumber string
+	Found           bool // False when no terminal has a record of the container
+	TerminalCode    string
+	TerminalName    string
+	VesselName      string
+	VoyageNumber    string
+	ISOSizeType     string // ISO 6346 size/type code, e.g. 45G1
+	LineOperator    string
+}
+
+// TMS container sizes and types, matching order-service
+const (
+	ContainerSize20 = "20"
+	ContainerSize40 = "40"
+	ContainerSize45 = "45"
+
+	ContainerTypeDry      = "DRY"
+	ContainerTypeHighCube = "HIGH_CUBE"
+	ContainerTypeReefer   = "REEFER"
+	ContainerTypeTank     = "TANK"
+	ContainerTypeFlatRack = "FLAT_RACK"
+	ContainerTypeOpenTop  = "OPEN_TOP"
+)
+
+// SizeAndType decodes the ISO 6346 size/type code into TMS container size and
+// type. The first character is the length, the second the height (5 and
+// above are high cube), and the third the type group. Either result is
+// empty when the code does not identify it.
+func (d ContainerDetails) SizeAndType() (size, containerType string) {
+	code := strings.ToUpper(strings.TrimSpace(d.ISOSizeType))
+	if len(code) < 3 {
+		return "", ""
+	}
+
+	switch code[0] {
+	case '2':
+		size = ContainerSize20
+	case '4':
+		size = ContainerSize40
+	case 'L':
+		size = ContainerSize45
+	}
+
+	switch code[2] {
+	case 'R':
+		containerType = ContainerTypeReefer
+	case 'T':
+		containerType = ContainerTypeTank
+	case 'P':
+		containerType = ContainerTypeFlatRack
+	case 'U':
+		containerType = ContainerTypeOpenTop
+	case 'G':
+		containerType = ContainerTypeDry
+		if code[1] >= '5' && code[1] <= '9' {
+			containerType = ContainerTypeHighCube
+		}
+	}
+	return size, containerType
+}
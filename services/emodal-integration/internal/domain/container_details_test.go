@@ -0,0 +1,33 @@
+package domain
+
+import "testing"
+
+func TestContainerDetails_SizeAndType(t *testing.T) {
+	tests := []struct {
+		code     string
+		wantSize string
+		wantType string
+	}{
+		{"22G1", ContainerSize20, ContainerTypeDry},
+		{"42G1", ContainerSize40, ContainerTypeDry},
+		{"45G1", ContainerSize40, ContainerTypeHighCube},
+		{"L5G1", ContainerSize45, ContainerTypeHighCube},
+		{"45R1", ContainerSize40, ContainerTypeReefer},
+		{"22T6", ContainerSize20, ContainerTypeTank},
+		{"42P1", ContainerSize40, ContainerTypeFlatRack},
+		{"22U1", ContainerSize20, ContainerTypeOpenTop},
+		{" 45g1 ", ContainerSize40, ContainerTypeHighCube}, // normalized
+		{"95G1", "", ContainerTypeHighCube},                // unknown length
+		{"4", "", ""},                                       // too short
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			size, containerType := ContainerDetails{ISOSizeType: tt.code}.SizeAndType()
+			if size != tt.wantSize || containerType != tt.wantType {
+				t.Errorf("SizeAndType(%q) = (%q, %q), want (%q, %q)", tt.code, size, containerType, tt.wantSize, tt.wantType)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -62,6 +63,41 @@ func MapStatusCode(code string) ContainerStatus {
 	return StatusInYard
 }
 
+// MountStatus records whether a container sits on the ground or on a chassis
+// in the terminal yard.
+type MountStatus string
+
+const (
+	MountGrounded MountStatus = "GROUNDED" // Stacked; a yard machine must dig it out
+	MountWheeled  MountStatus = "WHEELED"  // On a chassis, ready to hook
+)
+
+// eModalMountCodes maps eModal grounded/wheeled indicators to MountStatus.
+var eModalMountCodes = map[string]MountStatus{
+	"G": MountGrounded,
+	"W": MountWheeled,
+}
+
+// MapMountCode converts an eModal grounded/wheeled indicator to a MountStatus.
+// Returns "" when the terminal did not report one.
+func MapMountCode(code string) MountStatus {
+	return eModalMountCodes[strings.ToUpper(strings.TrimSpace(code))]
+}
+
+// YardPosition is where a container sits in the terminal yard.
+type YardPosition struct {
+	MountStatus MountStatus
+	Block       string
+	Row         string
+	Bay         string
+	Tier        string
+}
+
+// IsZero reports whether the terminal sent no yard detail.
+func (p YardPosition) IsZero() bool {
+	return p == YardPosition{}
+}
+
 // GateFeeType represents the category of a terminal fee.
 type GateFeeType string
 
@@ -101,6 +137,7 @@ type ContainerStatusEvent struct {
 	TerminalCode        string
 	TerminalName        string
 	LocationDescription string
+	Yard                YardPosition // Zero when the terminal did not report a yard position
 	OccurredAt          time.Time
 }
 
@@ -130,6 +167,9 @@ type PublishedContainer struct {
 	PublishedAt     time.Time
 	LastStatusAt    *time.Time
 	CurrentStatus   ContainerStatus
+	SCAC            string // eModal account the container was published under; "" for default
+	Yard            YardPosition
+	YardUpdatedAt   *time.Time
 }
 
 // DwellStats holds dwell time data for a container at a terminal.
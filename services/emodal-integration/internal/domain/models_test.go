@@ -31,3 +31,26 @@ func TestMapStatusCode(t *testing.T) {
 		})
 	}
 }
+
+func TestMapMountCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected MountStatus
+	}{
+		{"G", MountGrounded},
+		{"W", MountWheeled},
+		{"w", MountWheeled},
+		{" G ", MountGrounded},
+		{"X", ""}, // unknown is unreported, not guessed
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got := MapMountCode(tt.code)
+			if got != tt.expected {
+				t.Errorf("MapMountCode(%q) = %q, want %q", tt.code, got, tt.expected)
+			}
+		})
+	}
+}
@@ -5,12 +5,17 @@ import (
 	"runtime/debug"
 	"time"
 
+	"github.com/draymaster/services/emodal-integration/internal/client"
 	"github.com/draymaster/shared/pkg/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// SCACMetadataKey is the gRPC metadata key that selects the eModal account.
+const SCACMetadataKey = "x-emodal-scac"
+
 // LoggingInterceptor returns a gRPC unary interceptor that logs all requests.
 func LoggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -50,3 +55,16 @@ func RecoveryInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 		return handler(ctx, req)
 	}
 }
+
+// AccountInterceptor returns a gRPC unary interceptor that routes eModal calls
+// to the account named by the x-emodal-scac metadata, if present.
+func AccountInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(SCACMetadataKey); len(values) > 0 {
+				ctx = client.WithSCAC(ctx, values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
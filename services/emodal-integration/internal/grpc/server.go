@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +12,7 @@ import (
 
 	pb "github.com/draymaster/shared/proto/emodal/v1"
 
+	"github.com/draymaster/services/emodal-integration/internal/client"
 	"github.com/draymaster/services/emodal-integration/internal/domain"
 	"github.com/draymaster/services/emodal-integration/internal/repository"
 	"github.com/draymaster/services/emodal-integration/internal/service"
@@ -188,6 +190,11 @@ func (s *Server) GetContainerStatus(ctx context.Context, req *pb.ContainerStatus
 			ContainerNumber: c.ContainerNumber,
 			Status:          string(c.CurrentStatus),
 			TerminalCode:    c.TerminalCode,
+			MountStatus:     string(c.Yard.MountStatus),
+			YardBlock:       c.Yard.Block,
+			YardRow:         c.Yard.Row,
+			YardBay:         c.Yard.Bay,
+			YardTier:        c.Yard.Tier,
 		}
 		if c.LastStatusAt != nil {
 			info.LastUpdated = timestamppb.New(*c.LastStatusAt)
@@ -197,3 +204,172 @@ func (s *Server) GetContainerStatus(ctx context.Context, req *pb.ContainerStatus
 
 	return &pb.ContainerStatusResponse{Statuses: pbStatuses}, nil
 }
+
+func (s *Server) BookAppointment(ctx context.Context, req *pb.BookAppointmentRequest) (*pb.Appointment, error) {
+	if req.GetTerminalCode() == "" || req.GetMoveType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "terminal_code and move_type are required")
+	}
+	if req.GetSlotTime() == nil {
+		return nil, status.Error(codes.InvalidArgument, "slot_time is required")
+	}
+	if req.GetContainerNumber() == "" && req.GetBookingNumber() == "" {
+		return nil, status.Error(codes.InvalidArgument, "container_number or booking_number is required")
+	}
+	tripID, err := optionalUUID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := optionalUUID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+
+	appt, err := s.svc.BookAppointment(ctx, service.BookAppointmentInput{
+		Request: domain.AppointmentRequest{
+			TerminalCode:    req.GetTerminalCode(),
+			MoveType:        domain.MoveType(req.GetMoveType()),
+			SlotTime:        req.GetSlotTime().AsTime(),
+			ContainerNumber: req.GetContainerNumber(),
+			ContainerSize:   req.GetContainerSize(),
+			BookingNumber:   req.GetBookingNumber(),
+			ChassisNumber:   req.GetChassisNumber(),
+			TruckPlate:      req.GetTruckPlate(),
+			DriverName:      req.GetDriverName(),
+		},
+		TripID: tripID,
+		StopID: stopID,
+	})
+	if err != nil {
+		return nil, appointmentError("book appointment", err)
+	}
+	return appointmentToProto(appt), nil
+}
+
+func (s *Server) ModifyAppointment(ctx context.Context, req *pb.ModifyAppointmentRequest) (*pb.Appointment, error) {
+	id, err := uuid.Parse(req.GetAppointmentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "appointment_id is not a valid UUID")
+	}
+
+	changes := domain.AppointmentRequest{
+		ContainerNumber: req.GetContainerNumber(),
+		ContainerSize:   req.GetContainerSize(),
+		BookingNumber:   req.GetBookingNumber(),
+		ChassisNumber:   req.GetChassisNumber(),
+		TruckPlate:      req.GetTruckPlate(),
+		DriverName:      req.GetDriverName(),
+	}
+	if req.GetSlotTime() != nil {
+		changes.SlotTime = req.GetSlotTime().AsTime()
+	}
+
+	appt, err := s.svc.ModifyAppointment(ctx, id, changes)
+	if err != nil {
+		return nil, appointmentError("modify appointment", err)
+	}
+	return appointmentToProto(appt), nil
+}
+
+func (s *Server) CancelAppointment(ctx context.Context, req *pb.CancelAppointmentRequest) (*pb.Appointment, error) {
+	id, err := uuid.Parse(req.GetAppointmentId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "appointment_id is not a valid UUID")
+	}
+
+	appt, err := s.svc.CancelAppointment(ctx, id, req.GetReason())
+	if err != nil {
+		return nil, appointmentError("cancel appointment", err)
+	}
+	return appointmentToProto(appt), nil
+}
+
+func (s *Server) GetAppointments(ctx context.Context, req *pb.GetAppointmentsRequest) (*pb.GetAppointmentsResponse, error) {
+	if req.GetTripId() == "" && req.GetStopId() == "" && req.GetContainerNumber() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip_id, stop_id or container_number is required")
+	}
+	tripID, err := optionalUUID("trip_id", req.GetTripId())
+	if err != nil {
+		return nil, err
+	}
+	stopID, err := optionalUUID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+
+	appts, err := s.svc.GetAppointments(ctx, repository.AppointmentFilter{
+		TripID:           tripID,
+		StopID:           stopID,
+		ContainerNumber:  req.GetContainerNumber(),
+		IncludeCancelled: req.GetIncludeCancelled(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get appointments: %v", err)
+	}
+
+	pbAppts := make([]*pb.Appointment, len(appts))
+	for i := range appts {
+		pbAppts[i] = appointmentToProto(&appts[i])
+	}
+	return &pb.GetAppointmentsResponse{Appointments: pbAppts}, nil
+}
+
+// appointmentError maps appointment failures to gRPC status codes.
+func appointmentError(op string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAppointmentNotFound), errors.Is(err, client.ErrAppointmentNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", op, err)
+	case errors.Is(err, service.ErrAppointmentCancelled), errors.Is(err, client.ErrSlotUnavailable):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", op, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", op, err)
+	}
+}
+
+// optionalUUID parses an optional UUID field, returning nil when empty.
+func optionalUUID(field, raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a valid UUID", field)
+	}
+	return &id, nil
+}
+
+func appointmentToProto(a *domain.Appointment) *pb.Appointment {
+	pa := &pb.Appointment{
+		Id:                  a.ID.String(),
+		EmodalAppointmentId: a.EModalAppointmentID,
+		ConfirmationNumber:  a.ConfirmationNumber,
+		TerminalCode:        a.TerminalCode,
+		MoveType:            string(a.MoveType),
+		SlotTime:            timestamppb.New(a.SlotTime),
+		ContainerNumber:     a.ContainerNumber,
+		ContainerSize:       a.ContainerSize,
+		BookingNumber:       a.BookingNumber,
+		ChassisNumber:       a.ChassisNumber,
+		TruckPlate:          a.TruckPlate,
+		DriverName:          a.DriverName,
+		Status:              string(a.Status),
+		Scac:                a.SCAC,
+		CancelReason:        a.CancelReason,
+		BookedAt:            timestamppb.New(a.BookedAt),
+	}
+	if a.WindowEnd != nil {
+		pa.WindowEnd = timestamppb.New(*a.WindowEnd)
+	}
+	if a.TripID != nil {
+		pa.TripId = a.TripID.String()
+	}
+	if a.StopID != nil {
+		pa.StopId = a.StopID.String()
+	}
+	if a.ModifiedAt != nil {
+		pa.ModifiedAt = timestamppb.New(*a.ModifiedAt)
+	}
+	if a.CancelledAt != nil {
+		pa.CancelledAt = timestamppb.New(*a.CancelledAt)
+	}
+	return pa
+}
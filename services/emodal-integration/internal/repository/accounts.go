@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+	"github.com/draymaster/shared/pkg/crypto"
+)
+
+const accountColumns = `id, scac, name, auth_type, api_key_encrypted, client_id, client_secret_encrypted,
+	requests_per_minute, is_default, active, rotated_at, created_at, updated_at`
+
+// AccountRepository stores eModal accounts, encrypting secrets at rest.
+type AccountRepository struct {
+	pool   *pgxpool.Pool
+	cipher *crypto.Cipher
+}
+
+// NewAccountRepository creates a new AccountRepository.
+func NewAccountRepository(pool *pgxpool.Pool, cipher *crypto.Cipher) *AccountRepository {
+	return &AccountRepository{pool: pool, cipher: cipher}
+}
+
+// GetAccountBySCAC returns the account for a SCAC with secrets decrypted,
+// or nil if none exists.
+func (r *AccountRepository) GetAccountBySCAC(ctx context.Context, scac string) (*domain.EModalAccount, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+accountColumns+` FROM emodal_accounts WHERE scac = $1`, scac)
+	return r.scanAccount(row)
+}
+
+// GetDefaultAccount returns the active default account with secrets
+// decrypted, or nil if none is marked default.
+func (r *AccountRepository) GetDefaultAccount(ctx context.Context) (*domain.EModalAccount, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+accountColumns+` FROM emodal_accounts WHERE is_default AND active`)
+	return r.scanAccount(row)
+}
+
+// ListAccounts returns all accounts without their secrets.
+func (r *AccountRepository) ListAccounts(ctx context.Context) ([]domain.EModalAccount, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, scac, name, auth_type, client_id, requests_per_minute, is_default, active, rotated_at, created_at, updated_at
+		 FROM emodal_accounts ORDER BY scac`)
+	if err != nil {
+		return nil, fmt.Errorf("query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []domain.EModalAccount
+	for rows.Next() {
+		var a domain.EModalAccount
+		var authType string
+		var clientID *string
+		if err := rows.Scan(
+			&a.ID, &a.SCAC, &a.Name, &authType, &clientID, &a.RequestsPerMinute,
+			&a.IsDefault, &a.Active, &a.RotatedAt, &a.CreatedAt, &a.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		a.AuthType = domain.AuthType(authType)
+		if clientID != nil {
+			a.ClientID = *clientID
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// CreateAccount inserts a new account, encrypting its secrets.
+func (r *AccountRepository) CreateAccount(ctx context.Context, a *domain.EModalAccount) error {
+	apiKey, err := r.encrypt(a.APIKey)
+	if err != nil {
+		return err
+	}
+	clientSecret, err := r.encrypt(a.ClientSecret)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO emodal_accounts (id, scac, name, auth_type, api_key_encrypted, client_id, client_secret_encrypted,
+			requests_per_minute, is_default, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		a.ID, a.SCAC, a.Name, string(a.AuthType), apiKey, nilIfEmpty(a.ClientID), clientSecret,
+		a.RequestsPerMinute, a.IsDefault, a.Active, a.CreatedAt, a.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateCredentials replaces an account's secrets and records the rotation time.
+func (r *AccountRepository) UpdateCredentials(ctx context.Context, a *domain.EModalAccount, rotatedAt time.Time) error {
+	apiKey, err := r.encrypt(a.APIKey)
+	if err != nil {
+		return err
+	}
+	clientSecret, err := r.encrypt(a.ClientSecret)
+	if err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE emodal_accounts
+		 SET auth_type = $1, api_key_encrypted = $2, client_id = $3, client_secret_encrypted = $4, rotated_at = $5
+		 WHERE scac = $6`,
+		string(a.AuthType), apiKey, nilIfEmpty(a.ClientID), clientSecret, rotatedAt, a.SCAC,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("eModal account %s not found", a.SCAC)
+	}
+	return nil
+}
+
+func (r *AccountRepository) scanAccount(row pgx.Row) (*domain.EModalAccount, error) {
+	var a domain.EModalAccount
+	var authType string
+	var apiKey, clientID, clientSecret *string
+	err := row.Scan(
+		&a.ID, &a.SCAC, &a.Name, &authType, &apiKey, &clientID, &clientSecret,
+		&a.RequestsPerMinute, &a.IsDefault, &a.Active, &a.RotatedAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan account: %w", err)
+	}
+
+	a.AuthType = domain.AuthType(authType)
+	if clientID != nil {
+		a.ClientID = *clientID
+	}
+	if a.APIKey, err = r.decrypt(apiKey); err != nil {
+		return nil, fmt.Errorf("decrypt api key for %s: %w", a.SCAC, err)
+	}
+	if a.ClientSecret, err = r.decrypt(clientSecret); err != nil {
+		return nil, fmt.Errorf("decrypt client secret for %s: %w", a.SCAC, err)
+	}
+	return &a, nil
+}
+
+func (r *AccountRepository) encrypt(secret string) (*string, error) {
+	if secret == "" {
+		return nil, nil
+	}
+	sealed, err := r.cipher.EncryptString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt credential: %w", err)
+	}
+	return &sealed, nil
+}
+
+func (r *AccountRepository) decrypt(sealed *string) (string, error) {
+	if sealed == nil || *sealed == "" {
+		return "", nil
+	}
+	return r.cipher.DecryptString(*sealed)
+}
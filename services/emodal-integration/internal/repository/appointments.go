@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+)
+
+const appointmentColumns = `id, emodal_appointment_id, confirmation_number, terminal_code, move_type, slot_time, window_end,
+	container_number, container_size, booking_number, chassis_number, truck_plate, driver_name, status, scac,
+	trip_id, stop_id, cancel_reason, booked_at, modified_at, cancelled_at`
+
+// AppointmentFilter selects persisted PreGate appointments. Empty fields
+// match everything; cancelled appointments are left out unless asked for.
+type AppointmentFilter struct {
+	TripID           *uuid.UUID
+	StopID           *uuid.UUID
+	ContainerNumber  string
+	IncludeCancelled bool
+}
+
+// InsertAppointment persists a booked PreGate appointment.
+func (r *Repository) InsertAppointment(ctx context.Context, a *domain.Appointment) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO pregate_appointments (`+appointmentColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
+		a.ID, a.EModalAppointmentID, a.ConfirmationNumber, a.TerminalCode, string(a.MoveType), a.SlotTime, a.WindowEnd,
+		nilIfEmpty(a.ContainerNumber), nilIfEmpty(a.ContainerSize), nilIfEmpty(a.BookingNumber),
+		nilIfEmpty(a.ChassisNumber), nilIfEmpty(a.TruckPlate), nilIfEmpty(a.DriverName),
+		string(a.Status), nilIfEmpty(a.SCAC), a.TripID, a.StopID, nilIfEmpty(a.CancelReason),
+		a.BookedAt, a.ModifiedAt, a.CancelledAt,
+	)
+	return err
+}
+
+// UpdateAppointment saves a modified or cancelled appointment.
+func (r *Repository) UpdateAppointment(ctx context.Context, a *domain.Appointment) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE pregate_appointments
+		 SET emodal_appointment_id = $1, confirmation_number = $2, slot_time = $3, window_end = $4,
+		     container_number = $5, container_size = $6, booking_number = $7, chassis_number = $8,
+		     truck_plate = $9, driver_name = $10, status = $11, cancel_reason = $12,
+		     modified_at = $13, cancelled_at = $14
+		 WHERE id = $15`,
+		a.EModalAppointmentID, a.ConfirmationNumber, a.SlotTime, a.WindowEnd,
+		nilIfEmpty(a.ContainerNumber), nilIfEmpty(a.ContainerSize), nilIfEmpty(a.BookingNumber), nilIfEmpty(a.ChassisNumber),
+		nilIfEmpty(a.TruckPlate), nilIfEmpty(a.DriverName), string(a.Status), nilIfEmpty(a.CancelReason),
+		a.ModifiedAt, a.CancelledAt, a.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("appointment %s not found", a.ID)
+	}
+	return nil
+}
+
+// GetAppointment returns an appointment, or nil if none exists.
+func (r *Repository) GetAppointment(ctx context.Context, id uuid.UUID) (*domain.Appointment, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+appointmentColumns+` FROM pregate_appointments WHERE id = $1`, id)
+	a, err := scanAppointment(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan appointment: %w", err)
+	}
+	return a, nil
+}
+
+// ListAppointments returns the appointments matching the filter, earliest
+// slot first.
+func (r *Repository) ListAppointments(ctx context.Context, filter AppointmentFilter) ([]domain.Appointment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+appointmentColumns+` FROM pregate_appointments
+		 WHERE ($1::uuid IS NULL OR trip_id = $1)
+		   AND ($2::uuid IS NULL OR stop_id = $2)
+		   AND ($3 = '' OR container_number = $3)
+		   AND ($4 OR status <> 'CANCELLED')
+		 ORDER BY slot_time ASC`,
+		filter.TripID, filter.StopID, filter.ContainerNumber, filter.IncludeCancelled,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	var appointments []domain.Appointment
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		appointments = append(appointments, *a)
+	}
+	return appointments, rows.Err()
+}
+
+func scanAppointment(row pgx.Row) (*domain.Appointment, error) {
+	var a domain.Appointment
+	var moveType, status string
+	var containerNumber, containerSize, bookingNumber, chassisNumber, truckPlate, driverName, scac, cancelReason *string
+	if err := row.Scan(
+		&a.ID, &a.EModalAppointmentID, &a.ConfirmationNumber, &a.TerminalCode, &moveType, &a.SlotTime, &a.WindowEnd,
+		&containerNumber, &containerSize, &bookingNumber, &chassisNumber, &truckPlate, &driverName, &status, &scac,
+		&a.TripID, &a.StopID, &cancelReason, &a.BookedAt, &a.ModifiedAt, &a.CancelledAt,
+	); err != nil {
+		return nil, err
+	}
+	a.MoveType = domain.MoveType(moveType)
+	a.Status = domain.AppointmentStatus(status)
+	a.ContainerNumber = derefString(containerNumber)
+	a.ContainerSize = derefString(containerSize)
+	a.BookingNumber = derefString(bookingNumber)
+	a.ChassisNumber = derefString(chassisNumber)
+	a.TruckPlate = derefString(truckPlate)
+	a.DriverName = derefString(driverName)
+	a.SCAC = derefString(scac)
+	a.CancelReason = derefString(cancelReason)
+	return &a, nil
+}
@@ -25,13 +25,14 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 // UpsertPublishedContainer inserts or updates a published container record.
 func (r *Repository) UpsertPublishedContainer(ctx context.Context, pc domain.PublishedContainer) error {
 	_, err := r.pool.Exec(ctx,
-		`INSERT INTO published_containers (container_number, terminal_code, port_code, published_at, current_status)
-		 VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO published_containers (container_number, terminal_code, port_code, published_at, current_status, scac)
+		 VALUES ($1, $2, $3, $4, $5, $6)
 		 ON CONFLICT (container_number) DO UPDATE SET
 			 terminal_code   = EXCLUDED.terminal_code,
 			 port_code       = COALESCE(EXCLUDED.port_code, published_containers.port_code),
-			 current_status  = COALESCE(EXCLUDED.current_status, published_containers.current_status)`,
-		pc.ContainerNumber, pc.TerminalCode, pc.PortCode, pc.PublishedAt, nilIfEmpty(string(pc.CurrentStatus)),
+			 current_status  = COALESCE(EXCLUDED.current_status, published_containers.current_status),
+			 scac            = EXCLUDED.scac`,
+		pc.ContainerNumber, pc.TerminalCode, pc.PortCode, pc.PublishedAt, nilIfEmpty(string(pc.CurrentStatus)), nilIfEmpty(pc.SCAC),
 	)
 	return err
 }
@@ -47,11 +48,25 @@ func (r *Repository) UpdateContainerStatus(ctx context.Context, containerNumber
 	return err
 }
 
+// UpdateYardPosition records where a tracked container sits in the terminal
+// yard. A zero position clears it, e.g. once the container has left the terminal.
+func (r *Repository) UpdateYardPosition(ctx context.Context, containerNumber string, pos domain.YardPosition, at time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE published_containers
+		 SET mount_status = $1, yard_block = $2, yard_row = $3, yard_bay = $4, yard_tier = $5, yard_updated_at = $6
+		 WHERE container_number = $7`,
+		nilIfEmpty(string(pos.MountStatus)), nilIfEmpty(pos.Block), nilIfEmpty(pos.Row),
+		nilIfEmpty(pos.Bay), nilIfEmpty(pos.Tier), at, containerNumber,
+	)
+	return err
+}
+
 // GetContainerStatuses returns the tracked state of the requested containers.
 // Containers not found in the table are simply omitted from the result.
 func (r *Repository) GetContainerStatuses(ctx context.Context, containerNumbers []string) ([]domain.PublishedContainer, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT container_number, terminal_code, port_code, published_at, last_status_at, current_status
+		`SELECT container_number, terminal_code, port_code, published_at, last_status_at, current_status,
+		        mount_status, yard_block, yard_row, yard_bay, yard_tier, yard_updated_at
 		 FROM published_containers
 		 WHERE container_number = ANY($1)`,
 		containerNumbers,
@@ -64,13 +79,59 @@ func (r *Repository) GetContainerStatuses(ctx context.Context, containerNumbers
 	var results []domain.PublishedContainer
 	for rows.Next() {
 		var pc domain.PublishedContainer
-		var status *string
+		var status, mount, block, row, bay, tier *string
 		if err := rows.Scan(
 			&pc.ContainerNumber, &pc.TerminalCode, &pc.PortCode,
 			&pc.PublishedAt, &pc.LastStatusAt, &status,
+			&mount, &block, &row, &bay, &tier, &pc.YardUpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		if status != nil {
+			pc.CurrentStatus = domain.ContainerStatus(*status)
+		}
+		pc.Yard = domain.YardPosition{
+			MountStatus: domain.MountStatus(derefString(mount)),
+			Block:       derefString(block),
+			Row:         derefString(row),
+			Bay:         derefString(bay),
+			Tier:        derefString(tier),
+		}
+		results = append(results, pc)
+	}
+	return results, rows.Err()
+}
+
+// GetWatchedContainers returns published containers that have not yet reached a
+// terminal status and therefore still need status updates.
+func (r *Repository) GetWatchedContainers(ctx context.Context) ([]domain.PublishedContainer, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT container_number, terminal_code, port_code, published_at, last_status_at, current_status, scac
+		 FROM published_containers
+		 WHERE current_status IS NULL OR current_status NOT IN ('GATE_OUT', 'LOADED')
+		 ORDER BY last_status_at ASC NULLS FIRST`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query watched containers: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.PublishedContainer
+	for rows.Next() {
+		var pc domain.PublishedContainer
+		var portCode, status, scac *string
+		if err := rows.Scan(
+			&pc.ContainerNumber, &pc.TerminalCode, &portCode,
+			&pc.PublishedAt, &pc.LastStatusAt, &status, &scac,
 		); err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
+		if portCode != nil {
+			pc.PortCode = *portCode
+		}
+		if scac != nil {
+			pc.SCAC = *scac
+		}
 		if status != nil {
 			pc.CurrentStatus = domain.ContainerStatus(*status)
 		}
@@ -149,3 +210,10 @@ func nilIfEmpty(s string) *string {
 	}
 	return &s
 }
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
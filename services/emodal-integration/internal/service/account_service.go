@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/emodal-integration/internal/client"
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+	"github.com/draymaster/services/emodal-integration/internal/repository"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// AccountService manages the eModal accounts the client authenticates with.
+type AccountService struct {
+	accountRepo  *repository.AccountRepository
+	eModalClient *client.EModalClient
+	log          *logger.Logger
+}
+
+// NewAccountService creates a new AccountService.
+func NewAccountService(accountRepo *repository.AccountRepository, eModalClient *client.EModalClient, log *logger.Logger) *AccountService {
+	return &AccountService{
+		accountRepo:  accountRepo,
+		eModalClient: eModalClient,
+		log:          log,
+	}
+}
+
+// AccountCredentials are the secrets for an eModal account.
+type AccountCredentials struct {
+	AuthType     domain.AuthType
+	APIKey       string
+	ClientID     string
+	ClientSecret string
+}
+
+// CreateAccountInput contains input for registering an eModal account.
+type CreateAccountInput struct {
+	SCAC              string
+	Name              string
+	Credentials       AccountCredentials
+	RequestsPerMinute int
+	IsDefault         bool
+}
+
+// CreateAccount registers an eModal account for a SCAC.
+func (s *AccountService) CreateAccount(ctx context.Context, input CreateAccountInput) (*domain.EModalAccount, error) {
+	scac := strings.ToUpper(strings.TrimSpace(input.SCAC))
+	if len(scac) < 2 || len(scac) > 4 {
+		return nil, fmt.Errorf("invalid SCAC %q", input.SCAC)
+	}
+	if err := validateCredentials(&input.Credentials); err != nil {
+		return nil, err
+	}
+	if input.RequestsPerMinute <= 0 {
+		input.RequestsPerMinute = 60
+	}
+
+	now := time.Now()
+	account := &domain.EModalAccount{
+		ID:                uuid.New(),
+		SCAC:              scac,
+		Name:              input.Name,
+		AuthType:          input.Credentials.AuthType,
+		APIKey:            input.Credentials.APIKey,
+		ClientID:          input.Credentials.ClientID,
+		ClientSecret:      input.Credentials.ClientSecret,
+		RequestsPerMinute: input.RequestsPerMinute,
+		IsDefault:         input.IsDefault,
+		Active:            true,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.accountRepo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("create eModal account: %w", err)
+	}
+
+	s.log.Infow("eModal account created", "scac", scac, "auth_type", account.AuthType, "default", account.IsDefault)
+	return redactAccount(account), nil
+}
+
+// RotateCredentials replaces an account's secrets. Cached credentials and
+// tokens are dropped so the next request uses the new ones.
+func (s *AccountService) RotateCredentials(ctx context.Context, scac string, creds AccountCredentials) error {
+	scac = strings.ToUpper(strings.TrimSpace(scac))
+	account, err := s.accountRepo.GetAccountBySCAC(ctx, scac)
+	if err != nil {
+		return fmt.Errorf("get eModal account: %w", err)
+	}
+	if account == nil {
+		return fmt.Errorf("eModal account %s not found", scac)
+	}
+	if err := validateCredentials(&creds); err != nil {
+		return err
+	}
+
+	account.AuthType = creds.AuthType
+	account.APIKey = creds.APIKey
+	account.ClientID = creds.ClientID
+	account.ClientSecret = creds.ClientSecret
+	if err := s.accountRepo.UpdateCredentials(ctx, account, time.Now()); err != nil {
+		return fmt.Errorf("rotate eModal credentials: %w", err)
+	}
+
+	s.eModalClient.InvalidateAccount(scac)
+	if account.IsDefault {
+		s.eModalClient.InvalidateAccount("")
+	}
+
+	s.log.Infow("eModal credentials rotated", "scac", scac, "auth_type", account.AuthType)
+	return nil
+}
+
+// ListAccounts returns all eModal accounts without their secrets.
+func (s *AccountService) ListAccounts(ctx context.Context) ([]domain.EModalAccount, error) {
+	return s.accountRepo.ListAccounts(ctx)
+}
+
+// RateLimitStats returns request pacing for each account in use.
+func (s *AccountService) RateLimitStats() []domain.RateLimitStats {
+	return s.eModalClient.RateLimitStats()
+}
+
+func validateCredentials(creds *AccountCredentials) error {
+	if creds.AuthType == "" {
+		creds.AuthType = domain.AuthTypeAPIKey
+	}
+	switch creds.AuthType {
+	case domain.AuthTypeAPIKey:
+		if creds.APIKey == "" {
+			return fmt.Errorf("api key is required")
+		}
+		creds.ClientID, creds.ClientSecret = "", ""
+	case domain.AuthTypeOAuth:
+		if creds.ClientID == "" || creds.ClientSecret == "" {
+			return fmt.Errorf("client id and secret are required")
+		}
+		creds.APIKey = ""
+	default:
+		return fmt.Errorf("unsupported auth type %q", creds.AuthType)
+	}
+	return nil
+}
+
+func redactAccount(a *domain.EModalAccount) *domain.EModalAccount {
+	redacted := *a
+	redacted.APIKey = ""
+	redacted.ClientSecret = ""
+	return &redacted
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/emodal-integration/internal/client"
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+	"github.com/draymaster/services/emodal-integration/internal/repository"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// ErrAppointmentNotFound is returned for an appointment this service has no
+// record of.
+var ErrAppointmentNotFound = errors.New("appointment not found")
+
+// ErrAppointmentCancelled is returned when modifying a cancelled appointment.
+var ErrAppointmentCancelled = errors.New("appointment is cancelled")
+
+// BookAppointmentInput contains input for booking a PreGate appointment.
+// TripID and StopID name the dispatch trip stop it is for, if any.
+type BookAppointmentInput struct {
+	Request domain.AppointmentRequest
+	TripID  *uuid.UUID
+	StopID  *uuid.UUID
+}
+
+// BookAppointment books a PreGate appointment under the account selected on
+// ctx and persists the confirmation. If it cannot be saved, the booking is
+// cancelled again so the caller can retry without holding two slots.
+func (s *EModalService) BookAppointment(ctx context.Context, input BookAppointmentInput) (*domain.Appointment, error) {
+	req := input.Request
+	req.ContainerNumber = strings.ToUpper(strings.TrimSpace(req.ContainerNumber))
+	if err := validateAppointmentRequest(req); err != nil {
+		return nil, err
+	}
+
+	appt, err := s.eModalClient.BookAppointment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	appt.ID = uuid.New()
+	appt.SCAC = client.SCACFromContext(ctx)
+	appt.TripID = input.TripID
+	appt.StopID = input.StopID
+	appt.BookedAt = time.Now()
+
+	if err := s.repo.InsertAppointment(ctx, appt); err != nil {
+		s.log.Errorw("Failed to persist booked appointment, cancelling it",
+			"error", err,
+			"confirmation", appt.ConfirmationNumber,
+			"stop_id", appt.StopID,
+		)
+		if cancelErr := s.eModalClient.CancelAppointment(ctx, appt.EModalAppointmentID, "booking could not be recorded"); cancelErr != nil {
+			s.log.Errorw("Failed to cancel unrecorded appointment; cancel it in eModal by hand",
+				"error", cancelErr,
+				"confirmation", appt.ConfirmationNumber,
+			)
+		}
+		return nil, fmt.Errorf("persist appointment: %w", err)
+	}
+
+	s.publishAppointment(ctx, appt)
+	return appt, nil
+}
+
+// ModifyAppointment changes a booked appointment's slot, truck, chassis or
+// container. Empty fields of changes keep their booked values; the terminal
+// and move type cannot change.
+func (s *EModalService) ModifyAppointment(ctx context.Context, id uuid.UUID, changes domain.AppointmentRequest) (*domain.Appointment, error) {
+	appt, err := s.getAppointment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appt.Status == domain.AppointmentCancelled {
+		return nil, ErrAppointmentCancelled
+	}
+
+	req := domain.AppointmentRequest{
+		TerminalCode:    appt.TerminalCode,
+		MoveType:        appt.MoveType,
+		SlotTime:        appt.SlotTime,
+		ContainerNumber: appt.ContainerNumber,
+		ContainerSize:   appt.ContainerSize,
+		BookingNumber:   appt.BookingNumber,
+		ChassisNumber:   appt.ChassisNumber,
+		TruckPlate:      appt.TruckPlate,
+		DriverName:      appt.DriverName,
+	}
+	if !changes.SlotTime.IsZero() {
+		req.SlotTime = changes.SlotTime
+	}
+	override(&req.ContainerNumber, strings.ToUpper(strings.TrimSpace(changes.ContainerNumber)))
+	override(&req.ContainerSize, changes.ContainerSize)
+	override(&req.BookingNumber, changes.BookingNumber)
+	override(&req.ChassisNumber, changes.ChassisNumber)
+	override(&req.TruckPlate, changes.TruckPlate)
+	override(&req.DriverName, changes.DriverName)
+
+	modified, err := s.eModalClient.ModifyAppointment(client.WithSCAC(ctx, appt.SCAC), appt.EModalAppointmentID, req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	modified.ID = appt.ID
+	modified.SCAC = appt.SCAC
+	modified.TripID = appt.TripID
+	modified.StopID = appt.StopID
+	modified.BookedAt = appt.BookedAt
+	modified.ModifiedAt = &now
+
+	if err := s.repo.UpdateAppointment(ctx, modified); err != nil {
+		// eModal has the change; the next modify or cancel still works
+		// against the same eModal ID, so report it rather than undo it
+		s.log.Errorw("Failed to persist modified appointment",
+			"error", err,
+			"appointment_id", appt.ID,
+			"confirmation", modified.ConfirmationNumber,
+		)
+		return nil, fmt.Errorf("persist appointment: %w", err)
+	}
+
+	s.publishAppointment(ctx, modified)
+	return modified, nil
+}
+
+// CancelAppointment cancels an appointment, releasing its slot. Cancelling an
+// appointment the terminal already dropped just records it as cancelled;
+// cancelling one already cancelled is a no-op.
+func (s *EModalService) CancelAppointment(ctx context.Context, id uuid.UUID, reason string) (*domain.Appointment, error) {
+	appt, err := s.getAppointment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appt.Status == domain.AppointmentCancelled {
+		return appt, nil
+	}
+
+	err = s.eModalClient.CancelAppointment(client.WithSCAC(ctx, appt.SCAC), appt.EModalAppointmentID, reason)
+	if err != nil && !errors.Is(err, client.ErrAppointmentNotFound) {
+		return nil, err
+	}
+	now := time.Now()
+	appt.Status = domain.AppointmentCancelled
+	appt.CancelReason = reason
+	appt.CancelledAt = &now
+
+	if err := s.repo.UpdateAppointment(ctx, appt); err != nil {
+		return nil, fmt.Errorf("persist appointment: %w", err)
+	}
+
+	s.publishAppointment(ctx, appt)
+	return appt, nil
+}
+
+// GetAppointments returns the persisted appointments matching the filter,
+// so dispatch can attach confirmations to its trip stops.
+func (s *EModalService) GetAppointments(ctx context.Context, filter repository.AppointmentFilter) ([]domain.Appointment, error) {
+	filter.ContainerNumber = strings.ToUpper(strings.TrimSpace(filter.ContainerNumber))
+	return s.repo.ListAppointments(ctx, filter)
+}
+
+func (s *EModalService) getAppointment(ctx context.Context, id uuid.UUID) (*domain.Appointment, error) {
+	appt, err := s.repo.GetAppointment(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get appointment: %w", err)
+	}
+	if appt == nil {
+		return nil, ErrAppointmentNotFound
+	}
+	return appt, nil
+}
+
+// publishAppointment announces a booked, modified or cancelled appointment.
+// The appointment is already saved, so a failed publish is only logged.
+func (s *EModalService) publishAppointment(ctx context.Context, appt *domain.Appointment) {
+	payload := kafka.EModalAppointmentEvent{
+		AppointmentID:       appt.ID.String(),
+		EModalAppointmentID: appt.EModalAppointmentID,
+		ConfirmationNumber:  appt.ConfirmationNumber,
+		Status:              string(appt.Status),
+		TerminalCode:        appt.TerminalCode,
+		MoveType:            string(appt.MoveType),
+		ContainerNumber:     appt.ContainerNumber,
+		SlotTime:            appt.SlotTime.UTC(),
+		WindowEnd:           appt.WindowEnd,
+		CancelReason:        appt.CancelReason,
+		At:                  time.Now().UTC(),
+	}
+	if appt.TripID != nil {
+		payload.TripID = appt.TripID.String()
+	}
+	if appt.StopID != nil {
+		payload.StopID = appt.StopID.String()
+	}
+
+	event := kafka.NewEvent(kafka.Topics.EModalAppointmentUpdated, "emodal-integration", payload)
+	if err := s.kafkaProducer.Publish(ctx, kafka.Topics.EModalAppointmentUpdated, event); err != nil {
+		s.log.Errorw("Failed to publish appointment event", "error", err, "appointment_id", appt.ID)
+	}
+}
+
+// override replaces *dst with value unless value is empty.
+func override(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// validateAppointmentRequest checks a booking names a terminal, move, slot
+// and the container or booking it is for.
+func validateAppointmentRequest(req domain.AppointmentRequest) error {
+	switch {
+	case req.TerminalCode == "":
+		return fmt.Errorf("terminal code is required")
+	case req.MoveType == "":
+		return fmt.Errorf("move type is required")
+	case req.SlotTime.IsZero():
+		return fmt.Errorf("slot time is required")
+	case req.ContainerNumber == "" && req.BookingNumber == "":
+		return fmt.Errorf("container number or booking number is required")
+	}
+	return nil
+}
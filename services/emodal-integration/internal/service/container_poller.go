@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/client"
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+	"github.com/draymaster/services/emodal-integration/internal/repository"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// PollerConfig holds configuration for the container status polling fallback.
+type PollerConfig struct {
+	Interval          time.Duration // time between polling sweeps
+	BatchSize         int           // containers per EDS status request
+	RequestsPerMinute int           // upper bound on EDS status requests
+}
+
+// ContainerPoller periodically queries eModal EDS for the status of watched
+// containers. It is the fallback when Service Bus push events are not configured:
+// statuses are diffed against the last known state and changes are handed to the
+// same handler used for Service Bus events, so downstream Kafka events are identical.
+type ContainerPoller struct {
+	eModalClient *client.EModalClient
+	repo         *repository.Repository
+	outages      *OutageTracker
+	interval     time.Duration
+	batchSize    int
+	minGap       time.Duration
+	log          *logger.Logger
+}
+
+// NewContainerPoller creates a new ContainerPoller. Status request outcomes
+// are reported to outages, which may be nil.
+func NewContainerPoller(eModalClient *client.EModalClient, repo *repository.Repository, outages *OutageTracker, cfg PollerConfig, log *logger.Logger) *ContainerPoller {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	rpm := cfg.RequestsPerMinute
+	if rpm <= 0 {
+		rpm = 30
+	}
+	return &ContainerPoller{
+		eModalClient: eModalClient,
+		repo:         repo,
+		outages:      outages,
+		interval:     interval,
+		batchSize:    batchSize,
+		minGap:       time.Minute / time.Duration(rpm),
+		log:          log,
+	}
+}
+
+// Start begins polling. Blocks until ctx is cancelled.
+// handler is called for each container whose status changed since the last sweep.
+func (p *ContainerPoller) Start(ctx context.Context, handler func(event domain.ContainerStatusEvent) error) error {
+	p.log.Infow("eModal polling fallback started",
+		"interval", p.interval,
+		"batchSize", p.batchSize,
+		"minRequestGap", p.minGap,
+	)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.poll(ctx, handler); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			p.log.Warnw("Polling sweep failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.log.Info("eModal polling fallback shutting down")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll runs a single sweep over all watched containers.
+func (p *ContainerPoller) poll(ctx context.Context, handler func(event domain.ContainerStatusEvent) error) error {
+	watched, err := p.repo.GetWatchedContainers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(watched) == 0 {
+		return nil
+	}
+
+	limiter := time.NewTicker(p.minGap)
+	defer limiter.Stop()
+
+	// Each container is queried under the eModal account that published it
+	var scacs []string
+	byAccount := make(map[string][]domain.PublishedContainer)
+	for _, pc := range watched {
+		if _, ok := byAccount[pc.SCAC]; !ok {
+			scacs = append(scacs, pc.SCAC)
+		}
+		byAccount[pc.SCAC] = append(byAccount[pc.SCAC], pc)
+	}
+
+	var changed, requests int
+	for _, scac := range scacs {
+		accountCtx := client.WithSCAC(ctx, scac)
+		containers := byAccount[scac]
+
+		for start := 0; start < len(containers); start += p.batchSize {
+			end := start + p.batchSize
+			if end > len(containers) {
+				end = len(containers)
+			}
+			batch := containers[start:end]
+
+			// Rate limit: wait for the next request slot (first request goes immediately)
+			if requests > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-limiter.C:
+				}
+			}
+			requests++
+
+			numbers := make([]string, len(batch))
+			for i, pc := range batch {
+				numbers[i] = pc.ContainerNumber
+			}
+
+			polled, err := p.eModalClient.GetContainerStatuses(accountCtx, numbers)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.outages.RecordFailure(ctx, "GetContainerStatuses", err)
+				}
+				p.log.Warnw("Container status poll failed", "error", err, "batchSize", len(batch), "scac", scac)
+				continue
+			}
+			p.outages.RecordSuccess(ctx, "GetContainerStatuses")
+
+			for _, event := range diffContainerStatuses(batch, polled) {
+				changed++
+				if err := handler(event); err != nil {
+					p.log.Errorw("Handler failed for polled container event",
+						"error", err,
+						"container", event.ContainerNumber,
+					)
+				}
+			}
+		}
+	}
+
+	p.log.Infow("Polling sweep complete", "watched", len(watched), "changed", changed)
+	return nil
+}
+
+// diffContainerStatuses returns the polled events whose status differs from the
+// last known status, with PreviousStatus populated. Events for containers that
+// are not being watched are ignored.
+func diffContainerStatuses(watched []domain.PublishedContainer, polled []domain.ContainerStatusEvent) []domain.ContainerStatusEvent {
+	known := make(map[string]domain.PublishedContainer, len(watched))
+	for _, pc := range watched {
+		known[pc.ContainerNumber] = pc
+	}
+
+	var changes []domain.ContainerStatusEvent
+	for _, event := range polled {
+		pc, ok := known[event.ContainerNumber]
+		if !ok || event.Status == pc.CurrentStatus {
+			continue
+		}
+		// Skip results older than what we already have
+		if pc.LastStatusAt != nil && !event.OccurredAt.IsZero() && event.OccurredAt.Before(*pc.LastStatusAt) {
+			continue
+		}
+		event.PreviousStatus = pc.CurrentStatus
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now().UTC()
+		}
+		if event.TerminalCode == "" {
+			event.TerminalCode = pc.TerminalCode
+		}
+		changes = append(changes, event)
+	}
+	return changes
+}
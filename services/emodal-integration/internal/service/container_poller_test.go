@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/domain"
+)
+
+func TestDiffContainerStatuses(t *testing.T) {
+	lastSeen := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	watched := []domain.PublishedContainer{
+		{ContainerNumber: "MSCU1234567", TerminalCode: "POLA", CurrentStatus: domain.StatusInYard, LastStatusAt: &lastSeen},
+		{ContainerNumber: "TGHU7654321", TerminalCode: "LBCT", CurrentStatus: domain.StatusAvailable},
+		{ContainerNumber: "CMAU1111111", TerminalCode: "POLA"},
+	}
+
+	polled := []domain.ContainerStatusEvent{
+		// changed
+		{ContainerNumber: "MSCU1234567", Status: domain.StatusAvailable, OccurredAt: lastSeen.Add(time.Hour)},
+		// unchanged
+		{ContainerNumber: "TGHU7654321", Status: domain.StatusAvailable, OccurredAt: lastSeen},
+		// first status for a newly published container, no terminal code in response
+		{ContainerNumber: "CMAU1111111", Status: domain.StatusDischarged},
+		// not watched
+		{ContainerNumber: "ZZZU0000000", Status: domain.StatusGateOut},
+	}
+
+	changes := diffContainerStatuses(watched, polled)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	if changes[0].ContainerNumber != "MSCU1234567" {
+		t.Errorf("changes[0].ContainerNumber = %q, want %q", changes[0].ContainerNumber, "MSCU1234567")
+	}
+	if changes[0].PreviousStatus != domain.StatusInYard {
+		t.Errorf("changes[0].PreviousStatus = %q, want %q", changes[0].PreviousStatus, domain.StatusInYard)
+	}
+
+	if changes[1].ContainerNumber != "CMAU1111111" {
+		t.Errorf("changes[1].ContainerNumber = %q, want %q", changes[1].ContainerNumber, "CMAU1111111")
+	}
+	if changes[1].TerminalCode != "POLA" {
+		t.Errorf("changes[1].TerminalCode = %q, want %q", changes[1].TerminalCode, "POLA")
+	}
+	if changes[1].OccurredAt.IsZero() {
+		t.Error("changes[1].OccurredAt should default to now when missing")
+	}
+}
+
+func TestDiffContainerStatuses_SkipsStaleResults(t *testing.T) {
+	lastSeen := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	watched := []domain.PublishedContainer{
+		{ContainerNumber: "MSCU1234567", CurrentStatus: domain.StatusGateIn, LastStatusAt: &lastSeen},
+	}
+	polled := []domain.ContainerStatusEvent{
+		{ContainerNumber: "MSCU1234567", Status: domain.StatusInYard, OccurredAt: lastSeen.Add(-time.Hour)},
+	}
+
+	if changes := diffContainerStatuses(watched, polled); len(changes) != 0 {
+		t.Errorf("expected stale result to be ignored, got %d changes", len(changes))
+	}
+}
+
+func TestNewContainerPoller_Defaults(t *testing.T) {
+	p := NewContainerPoller(nil, nil, nil, PollerConfig{}, newTestLogger(t))
+
+	if p.interval != 5*time.Minute {
+		t.Errorf("interval = %v, want %v", p.interval, 5*time.Minute)
+	}
+	if p.batchSize != 50 {
+		t.Errorf("batchSize = %d, want 50", p.batchSize)
+	}
+	if p.minGap != 2*time.Second {
+		t.Errorf("minGap = %v, want %v", p.minGap, 2*time.Second)
+	}
+}
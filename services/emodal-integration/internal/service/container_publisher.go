@@ -19,6 +19,7 @@ type containerAddedEvent struct {
 	TerminalCode    string `json:"terminalCode"`
 	PortCode        string `json:"portCode"`
 	ShipmentID      string `json:"shipmentId"`
+	SCAC            string `json:"scac"` // optional; selects the eModal account
 }
 
 // ContainerPublisher listens for container.added Kafka events from order-service
@@ -38,15 +39,17 @@ func NewContainerPublisher(eModalClient *client.EModalClient, log *logger.Logger
 
 // HandleEvent processes a container.added Kafka event.
 // Extracts the container details and publishes them to eModal for tracking.
+// A malformed event is dead-lettered straight away; eModal failures are
+// retried by the consumer.
 func (p *ContainerPublisher) HandleEvent(ctx context.Context, event *kafka.Event) error {
 	data, err := json.Marshal(event.Data)
 	if err != nil {
-		return fmt.Errorf("marshal event data: %w", err)
+		return kafka.Permanent(fmt.Errorf("marshal event data: %w", err))
 	}
 
 	var added containerAddedEvent
 	if err := json.Unmarshal(data, &added); err != nil {
-		return fmt.Errorf("unmarshal container event: %w", err)
+		return kafka.Permanent(fmt.Errorf("unmarshal container event: %w", err))
 	}
 
 	if added.ContainerNumber == "" || added.TerminalCode == "" {
@@ -66,7 +69,7 @@ func (p *ContainerPublisher) HandleEvent(ctx context.Context, event *kafka.Event
 		},
 	}
 
-	published, err := p.eModalClient.PublishContainers(ctx, containers)
+	published, err := p.eModalClient.PublishContainers(client.WithSCAC(ctx, added.SCAC), containers)
 	if err != nil {
 		return fmt.Errorf("publish container %s: %w", added.ContainerNumber, err)
 	}
@@ -74,6 +77,7 @@ func (p *ContainerPublisher) HandleEvent(ctx context.Context, event *kafka.Event
 	p.log.Infow("Auto-published container to eModal",
 		"containerNumber", added.ContainerNumber,
 		"shipmentId", added.ShipmentID,
+		"scac", added.SCAC,
 		"published", published,
 	)
 	return nil
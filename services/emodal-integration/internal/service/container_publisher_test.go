@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+func TestContainerPublisher_MalformedEventIsPermanent(t *testing.T) {
+	publisher := NewContainerPublisher(nil, newTestLogger(t))
+	event := kafka.NewEvent(kafka.Topics.ContainerAdded, "order-service", "not a container")
+
+	err := publisher.HandleEvent(context.Background(), event)
+	if !errors.Is(err, kafka.ErrPermanent) {
+		t.Fatalf("expected a permanent error so the event is dead-lettered, got %v", err)
+	}
+}
+
+func TestContainerPublisher_SkipsIncompleteEvent(t *testing.T) {
+	publisher := NewContainerPublisher(nil, newTestLogger(t))
+	event := kafka.NewEvent(kafka.Topics.ContainerAdded, "order-service", map[string]string{"containerNumber": "MSCU1234565"})
+
+	if err := publisher.HandleEvent(context.Background(), event); err != nil {
+		t.Fatalf("expected incomplete event to be skipped, got %v", err)
+	}
+}
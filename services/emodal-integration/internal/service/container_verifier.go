@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/draymaster/services/emodal-integration/internal/client"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// verificationRequestedEvent matches the payload order-service publishes on
+// orders.container.verification_requested when an order is keyed with a container
+type verificationRequestedEvent struct {
+	ContainerID     string `json:"container_id"`
+	ContainerNumber string `json:"container_number"`
+	ShipmentID      string `json:"shipment_id"`
+	SCAC            string `json:"scac"` // optional; selects the eModal account
+}
+
+// ContainerVerifier answers order-service verification requests by looking the
+// container up in eModal EDS and publishing what the terminal reports. The
+// comparison against the order is left to order-service.
+type ContainerVerifier struct {
+	eModalClient  *client.EModalClient
+	kafkaProducer *kafka.Producer
+	outages       *OutageTracker
+	timeout       time.Duration
+	log           *logger.Logger
+}
+
+// NewContainerVerifier creates a new ContainerVerifier. Each lookup is
+// bounded by timeout so a slow EDS response cannot stall the consumer.
+// Lookup outcomes are reported to outages, which may be nil.
+func NewContainerVerifier(eModalClient *client.EModalClient, kafkaProducer *kafka.Producer, outages *OutageTracker, timeout time.Duration, log *logger.Logger) *ContainerVerifier {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &ContainerVerifier{
+		eModalClient:  eModalClient,
+		kafkaProducer: kafkaProducer,
+		outages:       outages,
+		timeout:       timeout,
+		log:           log,
+	}
+}
+
+// HandleEvent processes a container verification request. A failed lookup is
+// still answered, with lookupError set, so the order is not left pending.
+func (v *ContainerVerifier) HandleEvent(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return kafka.Permanent(fmt.Errorf("marshal event data: %w", err))
+	}
+
+	var req verificationRequestedEvent
+	if err := json.Unmarshal(data, &req); err != nil {
+		return kafka.Permanent(fmt.Errorf("unmarshal verification request: %w", err))
+	}
+	if req.ContainerNumber == "" {
+		v.log.Warnw("Skipping verification request without container number", "containerId", req.ContainerID)
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(client.WithSCAC(ctx, req.SCAC), v.timeout)
+	defer cancel()
+
+	payload := map[string]interface{}{
+		"containerId":     req.ContainerID,
+		"containerNumber": req.ContainerNumber,
+		"checkedAt":       time.Now().UTC(),
+	}
+
+	details, err := v.eModalClient.GetContainerDetails(lookupCtx, req.ContainerNumber)
+	if err != nil {
+		v.outages.RecordFailure(ctx, "GetContainerDetails", err)
+		v.log.Errorw("Container verification lookup failed",
+			"container", req.ContainerNumber,
+			"error", err,
+		)
+		payload["lookupError"] = err.Error()
+	} else {
+		v.outages.RecordSuccess(ctx, "GetContainerDetails")
+		size, containerType := details.SizeAndType()
+		payload["found"] = details.Found
+		payload["terminalCode"] = details.TerminalCode
+		payload["terminalName"] = details.TerminalName
+		payload["vesselName"] = details.VesselName
+		payload["voyageNumber"] = details.VoyageNumber
+		payload["isoSizeType"] = details.ISOSizeType
+		payload["size"] = size
+		payload["type"] = containerType
+		payload["lineOperator"] = details.LineOperator
+	}
+
+	verified := kafka.NewEvent(kafka.Topics.EModalContainerVerified, "emodal-integration", payload)
+	if err := v.kafkaProducer.Publish(ctx, kafka.Topics.EModalContainerVerified, verified); err != nil {
+		return fmt.Errorf("publish verification for %s: %w", req.ContainerNumber, err)
+	}
+
+	v.log.Infow("Container verified against eModal",
+		"container", req.ContainerNumber,
+		"found", payload["found"],
+		"terminal", payload["terminalCode"],
+	)
+	return nil
+}
@@ -55,10 +55,18 @@ func (s *EModalService) ProcessContainerEvent(ctx context.Context, event domain.
 		"locationDescription": event.LocationDescription,
 		"occurredAt":          event.OccurredAt.UTC(),
 	}
+	if !event.Yard.IsZero() {
+		payload["mountStatus"] = string(event.Yard.MountStatus)
+		payload["yardBlock"] = event.Yard.Block
+		payload["yardRow"] = event.Yard.Row
+		payload["yardBay"] = event.Yard.Bay
+		payload["yardTier"] = event.Yard.Tier
+	}
 
-	// Always publish general status update
+	// Always publish general status update. Written synchronously so a failed
+	// publish is returned and the eModal event is redelivered.
 	statusEvent := kafka.NewEvent("emodal.container.status_updated", "emodal-integration", payload)
-	if err := s.kafkaProducer.Publish(ctx, kafka.Topics.EModalContainerStatusUpdated, statusEvent); err != nil {
+	if err := s.kafkaProducer.PublishSync(ctx, kafka.Topics.EModalContainerStatusUpdated, statusEvent); err != nil {
 		return fmt.Errorf("publish status event: %w", err)
 	}
 
@@ -90,6 +98,18 @@ func (s *EModalService) ProcessContainerEvent(ctx context.Context, event domain.
 		s.log.Errorw("Failed to update container status in DB", "error", err, "container", event.ContainerNumber)
 	}
 
+	// Keep the yard position while the box is in the terminal; once it has
+	// left, the last reported position no longer means anything
+	yard, updateYard := event.Yard, !event.Yard.IsZero()
+	if event.Status == domain.StatusGateOut || event.Status == domain.StatusLoaded {
+		yard, updateYard = domain.YardPosition{}, true
+	}
+	if updateYard {
+		if err := s.repo.UpdateYardPosition(ctx, event.ContainerNumber, yard, event.OccurredAt); err != nil {
+			s.log.Errorw("Failed to update yard position in DB", "error", err, "container", event.ContainerNumber)
+		}
+	}
+
 	return nil
 }
 
@@ -111,8 +131,11 @@ func (s *EModalService) PublishContainers(ctx context.Context, containers []doma
 		return nil, err
 	}
 
-	// Persist published containers so GetContainerStatus can track them
+	// Persist published containers so GetContainerStatus can track them,
+	// remembering the account so the poller queries under the same one
+	scac := client.SCACFromContext(ctx)
 	for _, pc := range containers {
+		pc.SCAC = scac
 		if dbErr := s.repo.UpsertPublishedContainer(ctx, pc); dbErr != nil {
 			s.log.Errorw("Failed to persist published container", "error", dbErr, "container", pc.ContainerNumber)
 		}
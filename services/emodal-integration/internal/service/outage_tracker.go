@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// eventPublisher is the part of the Kafka producer the outage tracker uses
+type eventPublisher interface {
+	Publish(ctx context.Context, topic string, event *kafka.Event) error
+}
+
+// OutageTracker watches the outcome of eModal calls. After threshold
+// consecutive failures it publishes an outage event, once, and on the first
+// success after that it publishes a recovery event.
+type OutageTracker struct {
+	publisher eventPublisher
+	threshold int
+	log       *logger.Logger
+
+	mu        sync.Mutex
+	failures  int
+	since     time.Time // First failure of the current run
+	lastError string
+	down      bool
+}
+
+// NewOutageTracker creates a new OutageTracker.
+func NewOutageTracker(publisher eventPublisher, threshold int, log *logger.Logger) *OutageTracker {
+	if threshold <= 0 {
+		threshold = 3
+	}
+	return &OutageTracker{
+		publisher: publisher,
+		threshold: threshold,
+		log:       log,
+	}
+}
+
+// RecordFailure counts a failed eModal call. A nil tracker ignores it.
+func (t *OutageTracker) RecordFailure(ctx context.Context, operation string, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	now := time.Now().UTC()
+	if t.failures == 0 {
+		t.since = now
+	}
+	t.failures++
+	t.lastError = err.Error()
+	if t.down || t.failures < t.threshold {
+		t.mu.Unlock()
+		return
+	}
+	t.down = true
+	payload := kafka.EModalServiceEvent{
+		Operation:           operation,
+		ConsecutiveFailures: t.failures,
+		LastError:           t.lastError,
+		Since:               t.since,
+		At:                  now,
+	}
+	t.mu.Unlock()
+
+	t.log.Errorw("eModal outage detected",
+		"operation", operation,
+		"consecutiveFailures", payload.ConsecutiveFailures,
+		"since", payload.Since,
+	)
+	event := kafka.NewEvent(kafka.Topics.EModalOutage, "emodal-integration", payload)
+	if pubErr := t.publisher.Publish(ctx, kafka.Topics.EModalOutage, event); pubErr != nil {
+		t.log.Warnw("Failed to publish eModal outage event", "error", pubErr)
+	}
+}
+
+// RecordSuccess resets the failure count, announcing recovery from an outage.
+func (t *OutageTracker) RecordSuccess(ctx context.Context, operation string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	wasDown := t.down
+	payload := kafka.EModalServiceEvent{
+		Operation:           operation,
+		ConsecutiveFailures: t.failures,
+		LastError:           t.lastError,
+		Since:               t.since,
+		At:                  time.Now().UTC(),
+	}
+	t.failures = 0
+	t.lastError = ""
+	t.down = false
+	t.mu.Unlock()
+
+	if !wasDown {
+		return
+	}
+	t.log.Infow("eModal recovered", "operation", operation, "downSince", payload.Since)
+	event := kafka.NewEvent(kafka.Topics.EModalRecovered, "emodal-integration", payload)
+	if pubErr := t.publisher.Publish(ctx, kafka.Topics.EModalRecovered, event); pubErr != nil {
+		t.log.Warnw("Failed to publish eModal recovery event", "error", pubErr)
+	}
+}
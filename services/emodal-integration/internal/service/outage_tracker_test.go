@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+type recordingPublisher struct {
+	events []*kafka.Event
+}
+
+func (r *recordingPublisher) Publish(_ context.Context, topic string, event *kafka.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestOutageTracker_PublishesOutageOnceAtThreshold(t *testing.T) {
+	pub := &recordingPublisher{}
+	tracker := NewOutageTracker(pub, 3, newTestLogger(t))
+	ctx := context.Background()
+
+	tracker.RecordFailure(ctx, "GetContainerStatuses", errors.New("503"))
+	tracker.RecordFailure(ctx, "GetContainerStatuses", errors.New("503"))
+	if len(pub.events) != 0 {
+		t.Fatalf("expected no events below threshold, got %d", len(pub.events))
+	}
+
+	tracker.RecordFailure(ctx, "GetContainerStatuses", errors.New("timeout"))
+	tracker.RecordFailure(ctx, "GetContainerStatuses", errors.New("timeout"))
+	if len(pub.events) != 1 {
+		t.Fatalf("expected one outage event, got %d", len(pub.events))
+	}
+	if pub.events[0].Type != kafka.Topics.EModalOutage {
+		t.Errorf("event type = %q, want %q", pub.events[0].Type, kafka.Topics.EModalOutage)
+	}
+
+	var payload kafka.EModalServiceEvent
+	if err := pub.events[0].DecodeData(&payload); err != nil {
+		t.Fatalf("decode outage payload: %v", err)
+	}
+	if payload.ConsecutiveFailures != 3 {
+		t.Errorf("ConsecutiveFailures = %d, want 3", payload.ConsecutiveFailures)
+	}
+	if payload.LastError != "timeout" {
+		t.Errorf("LastError = %q, want %q", payload.LastError, "timeout")
+	}
+}
+
+func TestOutageTracker_PublishesRecoveryAfterOutage(t *testing.T) {
+	pub := &recordingPublisher{}
+	tracker := NewOutageTracker(pub, 2, newTestLogger(t))
+	ctx := context.Background()
+
+	// A success before the threshold just resets the count
+	tracker.RecordFailure(ctx, "GetContainerDetails", errors.New("503"))
+	tracker.RecordSuccess(ctx, "GetContainerDetails")
+	tracker.RecordFailure(ctx, "GetContainerDetails", errors.New("503"))
+	if len(pub.events) != 0 {
+		t.Fatalf("expected no events after reset, got %d", len(pub.events))
+	}
+
+	tracker.RecordFailure(ctx, "GetContainerDetails", errors.New("503"))
+	tracker.RecordSuccess(ctx, "GetContainerDetails")
+	tracker.RecordSuccess(ctx, "GetContainerDetails")
+
+	if len(pub.events) != 2 {
+		t.Fatalf("expected outage and recovery events, got %d", len(pub.events))
+	}
+	if pub.events[1].Type != kafka.Topics.EModalRecovered {
+		t.Errorf("event type = %q, want %q", pub.events[1].Type, kafka.Topics.EModalRecovered)
+	}
+}
+
+func TestOutageTracker_NilIsNoop(t *testing.T) {
+	var tracker *OutageTracker
+	tracker.RecordFailure(context.Background(), "GetContainerStatuses", errors.New("503"))
+	tracker.RecordSuccess(context.Background(), "GetContainerStatuses")
+}
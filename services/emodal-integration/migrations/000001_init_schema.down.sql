@@ -0,0 +1,7 @@
+-- 000001_init_schema.down.sql
+
+DROP TABLE IF EXISTS gate_fees;
+DROP TABLE IF EXISTS published_containers;
+
+DROP FUNCTION IF EXISTS update_gate_fees_ts();
+DROP FUNCTION IF EXISTS update_published_containers_ts();
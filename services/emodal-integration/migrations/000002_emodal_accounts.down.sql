@@ -0,0 +1,9 @@
+-- 000002_emodal_accounts.down.sql
+
+DROP INDEX IF EXISTS idx_pc_scac;
+
+ALTER TABLE published_containers DROP COLUMN IF EXISTS scac;
+
+DROP TABLE IF EXISTS emodal_accounts;
+
+DROP FUNCTION IF EXISTS update_emodal_accounts_ts();
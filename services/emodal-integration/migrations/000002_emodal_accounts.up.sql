@@ -0,0 +1,60 @@
+-- ==============================================================================
+-- eModal Integration Service — Multi-Account Credentials
+-- ==============================================================================
+-- Tables:
+--   emodal_accounts       eModal subscriptions per SCAC with encrypted secrets
+-- Changes:
+--   published_containers  Records which account published each container
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- emodal_accounts
+-- ---------------------------------------------------------------------------
+-- Secrets are AES-256-GCM encrypted by the service (EMODAL_CREDENTIAL_KEY)
+-- and stored base64-encoded; the database never sees plaintext credentials.
+-- Exactly one active account may be the default for requests without a SCAC.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS emodal_accounts (
+    id                      UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    scac                    VARCHAR(4)  NOT NULL UNIQUE,
+    name                    VARCHAR(100) NOT NULL,
+    auth_type               VARCHAR(10) NOT NULL DEFAULT 'API_KEY' CHECK (auth_type IN ('API_KEY', 'OAUTH')),
+    api_key_encrypted       TEXT,
+    client_id               VARCHAR(200),
+    client_secret_encrypted TEXT,
+    requests_per_minute     INTEGER     NOT NULL DEFAULT 60 CHECK (requests_per_minute > 0),
+    is_default              BOOLEAN     NOT NULL DEFAULT FALSE,
+    active                  BOOLEAN     NOT NULL DEFAULT TRUE,
+    rotated_at              TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION update_emodal_accounts_ts()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_emodal_accounts_updated_at
+    BEFORE UPDATE ON emodal_accounts
+    FOR EACH ROW EXECUTE FUNCTION update_emodal_accounts_ts();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ea_default
+    ON emodal_accounts(is_default)
+    WHERE is_default AND active;
+
+-- ---------------------------------------------------------------------------
+-- published_containers.scac
+-- ---------------------------------------------------------------------------
+-- NULL means the container was published under the default account.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE published_containers ADD COLUMN IF NOT EXISTS scac VARCHAR(4);
+
+CREATE INDEX IF NOT EXISTS idx_pc_scac
+    ON published_containers(scac)
+    WHERE scac IS NOT NULL;
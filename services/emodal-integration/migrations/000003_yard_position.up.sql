@@ -0,0 +1,16 @@
+-- ==============================================================================
+-- eModal Integration — Yard Position
+-- ==============================================================================
+-- Terminals report whether a container is grounded (stacked, needs a yard
+-- machine to dig it out) or wheeled (on a chassis, ready to hook), and where
+-- it sits in the yard. Grounded boxes take noticeably longer to pull, so
+-- dispatch uses this to pad pickup turn times.
+-- ==============================================================================
+
+ALTER TABLE published_containers
+    ADD COLUMN IF NOT EXISTS mount_status    VARCHAR(10) CHECK (mount_status IN ('GROUNDED', 'WHEELED')),
+    ADD COLUMN IF NOT EXISTS yard_block      VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS yard_row        VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS yard_bay        VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS yard_tier       VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS yard_updated_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- 000004_pregate_appointments.down.sql
+
+DROP TABLE IF EXISTS pregate_appointments;
+
+DROP FUNCTION IF EXISTS update_pregate_appointments_ts();
@@ -0,0 +1,60 @@
+-- ==============================================================================
+-- eModal Integration — PreGate Appointments
+-- ==============================================================================
+-- Tables:
+--   pregate_appointments  Terminal appointments booked through eModal PreGate
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- pregate_appointments
+-- ---------------------------------------------------------------------------
+-- One row per appointment booked through this service, kept after it is
+-- cancelled. trip_id / stop_id are cross-service references to the dispatch
+-- trip stop the appointment was booked for, so dispatch can attach the
+-- confirmation to it. scac records the eModal account it was booked under,
+-- which must also be used to modify or cancel it.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS pregate_appointments (
+    id                    UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    emodal_appointment_id VARCHAR(100) NOT NULL,
+    confirmation_number   VARCHAR(50) NOT NULL,
+    terminal_code         VARCHAR(20) NOT NULL,
+    move_type             VARCHAR(2)  NOT NULL,
+    slot_time             TIMESTAMPTZ NOT NULL,
+    window_end            TIMESTAMPTZ,
+    container_number      VARCHAR(11),
+    container_size        VARCHAR(5),
+    booking_number        VARCHAR(50),
+    chassis_number        VARCHAR(20),
+    truck_plate           VARCHAR(20),
+    driver_name           VARCHAR(100),
+    status                VARCHAR(10) NOT NULL DEFAULT 'BOOKED' CHECK (status IN ('BOOKED', 'CANCELLED')),
+    scac                  VARCHAR(4),
+    trip_id               UUID,                                   -- cross-service ref to trips table
+    stop_id               UUID,                                   -- cross-service ref to trip_stops table
+    cancel_reason         TEXT,
+    booked_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    modified_at           TIMESTAMPTZ,
+    cancelled_at          TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION update_pregate_appointments_ts()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_pregate_appointments_updated_at
+    BEFORE UPDATE ON pregate_appointments
+    FOR EACH ROW EXECUTE FUNCTION update_pregate_appointments_ts();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pa_emodal_id    ON pregate_appointments(emodal_appointment_id);
+CREATE INDEX IF NOT EXISTS idx_pa_trip_id             ON pregate_appointments(trip_id)          WHERE trip_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pa_stop_id             ON pregate_appointments(stop_id)          WHERE stop_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pa_container_number    ON pregate_appointments(container_number) WHERE container_number IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pa_slot_time           ON pregate_appointments(slot_time);
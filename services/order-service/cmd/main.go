@@ -4,20 +4,30 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/chatops"
 	"github.com/draymaster/shared/pkg/config"
 	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/document"
+	"github.com/draymaster/shared/pkg/jobs"
 	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
 	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
 
+	"github.com/draymaster/services/order-service/internal/api"
+	"github.com/draymaster/services/order-service/internal/client"
 	"github.com/draymaster/services/order-service/internal/repository"
 	"github.com/draymaster/services/order-service/internal/service"
 	grpcHandler "github.com/draymaster/services/order-service/internal/grpc"
@@ -61,31 +71,291 @@ func main() {
 	log.Info("Connected to database")
 
 	// Initialize Kafka producer
-	producer := kafka.NewProducer(cfg.Kafka.Brokers, log)
+	producer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
 	defer producer.Close()
 	log.Info("Kafka producer initialized")
 
+	// Events written with the business change are delivered from the outbox
+	outbox := kafka.NewOutbox(db)
+	go kafka.NewOutboxRelay(db, producer, kafka.OutboxRelayConfig{}, log).Run(ctx)
+
 	// Initialize repositories
 	shipmentRepo := repository.NewPostgresShipmentRepository(db.Pool)
 	containerRepo := repository.NewPostgresContainerRepository(db.Pool)
 	orderRepo := repository.NewPostgresOrderRepository(db.Pool)
 	locationRepo := repository.NewPostgresLocationRepository(db.Pool)
 
+	// Contract rates and rate quotes come from billing's rate tables
+	billingClient := client.NewBillingClient(client.BillingClientConfig{BaseURL: getEnv("BILLING_SERVICE_URL", "http://localhost:8084")})
+
+	// Orders quoted off contract are held for a manager's rate approval
+	rateApprovalService := service.NewRateApprovalService(
+		db,
+		repository.NewPostgresRateApprovalRepository(db.Pool),
+		orderRepo,
+		shipmentRepo,
+		containerRepo,
+		billingClient,
+		outbox,
+		config.DefaultBusinessRules().RateApproval,
+		log,
+	)
+
 	// Initialize service
 	orderService := service.NewOrderService(
+		db,
+		shipmentRepo,
+		containerRepo,
+		orderRepo,
+		locationRepo,
+		rateApprovalService,
+		billingClient,
+		producer,
+		outbox,
+		log,
+	)
+
+	// Apply eModal container status events to container records
+	containerConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name, kafka.Topics.EModalContainerStatusUpdated, log)
+	defer containerConsumer.Close()
+	containerEvents := service.NewContainerEventConsumer(containerRepo, shipmentRepo, orderRepo, producer, log)
+	go func() {
+		if err := containerConsumer.Consume(ctx, containerEvents.HandleContainerStatus); err != nil && ctx.Err() == nil {
+			log.Errorw("Container event consumer stopped", "error", err)
+		}
+	}()
+
+	// Flag keyed containers the terminal does not know or reports differently
+	verificationConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name+"-verification", kafka.Topics.EModalContainerVerified, log)
+	defer verificationConsumer.Close()
+	go func() {
+		if err := verificationConsumer.Consume(ctx, containerEvents.HandleContainerVerification); err != nil && ctx.Err() == nil {
+			log.Errorw("Container verification consumer stopped", "error", err)
+		}
+	}()
+
+	// Broker load tenders: intake API, ops review and webhook status updates
+	brokerRepo := repository.NewPostgresBrokerRepository(db.Pool)
+	tenderRepo := repository.NewPostgresLoadTenderRepository(db.Pool)
+	tenderService := service.NewTenderService(
+		db,
+		brokerRepo,
+		tenderRepo,
 		shipmentRepo,
 		containerRepo,
 		orderRepo,
+		repository.NewPostgresSteamshipLineRepository(db.Pool),
+		client.NewBrokerWebhookClient(client.BrokerWebhookConfig{}, log),
+		producer,
+		outbox,
+		log,
+	)
+
+	orderStatusConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name+"-tenders", kafka.Topics.OrderStatusChanged, log)
+	defer orderStatusConsumer.Close()
+	go func() {
+		if err := orderStatusConsumer.Consume(ctx, tenderService.HandleOrderStatusChanged); err != nil && ctx.Err() == nil {
+			log.Errorw("Order status consumer stopped", "error", err)
+		}
+	}()
+
+	// Rate confirmations for brokered loads, signed through the configured provider
+	rateConService := service.NewRateConfirmationService(
+		repository.NewPostgresRateConfirmationRepository(db.Pool),
+		tenderRepo,
+		brokerRepo,
+		orderRepo,
+		locationRepo,
+		document.NewGenerator(nil, nil, document.NewChromiumRenderer(cfg.Documents), log),
+		signatureProvider(log),
+		producer,
+		log,
+	)
+
+	// Terminal appointments, booked against gate hours, closures and slot punctuality
+	calendarService := service.NewCalendarService(repository.NewPostgresCalendarRepository(db.Pool), log)
+	appointmentRepo := repository.NewPostgresAppointmentRepository(db.Pool)
+	appointmentService := service.NewAppointmentService(
+		appointmentRepo,
+		repository.NewPostgresTerminalRepository(db.Pool),
+		orderRepo,
+		calendarService,
+		service.NewPunctualityService(appointmentRepo, repository.NewPostgresBookingPreferenceRepository(db.Pool), producer, log),
+		producer,
+		log,
+	)
+
+	// Pre-arrival planning workspace; queued draft trips go to dispatch on discharge
+	prearrivalService := service.NewPreArrivalService(
+		repository.NewPostgresPreArrivalRepository(db.Pool),
+		appointmentService,
+		producer,
+		log,
+	)
+
+	dischargeConsumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name+"-prearrival", kafka.Topics.EModalContainerStatusUpdated, log)
+	defer dischargeConsumer.Close()
+	go func() {
+		if err := dischargeConsumer.Consume(ctx, prearrivalService.HandleContainerStatus); err != nil && ctx.Err() == nil {
+			log.Errorw("Pre-arrival discharge consumer stopped", "error", err)
+		}
+	}()
+
+	// Address book facility profiles, published for dispatch's driver manifests
+	facilityService := service.NewFacilityService(
+		repository.NewPostgresFacilityProfileRepository(db.Pool),
 		locationRepo,
 		producer,
 		log,
 	)
 
+	// Scheduled jobs below run on one replica at a time; the others stand by
+	// to take over if it stops
+	elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+
+	// Warn ops about imports still at the terminal as free time runs out
+	lfdRiskMonitor := service.NewLFDRiskMonitor(shipmentRepo, containerRepo, producer, config.DefaultBusinessRules().Demurrage, log)
+	go elector.Run(ctx, "order.lfd-risk", func(ctx context.Context) {
+		lfdRiskMonitor.Start(ctx, 30*time.Minute)
+	})
+
+	// Nightly per-diem and demurrage accrual for open imports, with pre-pull warnings for dispatch
+	chargeAccrualService := service.NewChargeAccrualService(
+		shipmentRepo,
+		containerRepo,
+		repository.NewPostgresContainerChargeRepository(db.Pool),
+		calendarService,
+		producer,
+		config.DefaultBusinessRules(),
+		log,
+	)
+	go elector.Run(ctx, "order.charge-accrual", func(ctx context.Context) {
+		chargeAccrualService.Start(ctx, 15*time.Minute)
+	})
+
+	// Inbound vessel ETAs refined from live AIS positions
+	if aisURL := os.Getenv("AIS_PROVIDER_URL"); aisURL != "" {
+		vesselTrackingService := service.NewVesselTrackingService(
+			db,
+			repository.NewPostgresVesselTrackingRepository(db.Pool),
+			client.NewAISClient(client.AISClientConfig{BaseURL: aisURL, APIKey: os.Getenv("AIS_API_KEY")}, log),
+			outbox,
+			config.DefaultBusinessRules(),
+			log,
+		)
+		go elector.Run(ctx, "order.vessel-tracking", func(ctx context.Context) {
+			vesselTrackingService.Start(ctx, 30*time.Minute)
+		})
+	}
+
+	// Chat-ops alerts to Slack and Teams ops channels
+	if notifier, err := chatops.NewNotifier(cfg.ChatOps, log); err != nil {
+		log.Errorw("Chat-ops disabled: invalid configuration", "error", err)
+	} else if notifier.Enabled() {
+		relay := chatops.NewRelay(notifier, cfg.ChatOps.AppURL, log)
+		for _, topic := range relay.Topics() {
+			consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Service.Name+"-chatops", topic, log)
+			defer consumer.Close()
+			go func(topic string) {
+				if err := consumer.Consume(ctx, relay.HandleEvent); err != nil && ctx.Err() == nil {
+					log.Errorw("Chat-ops consumer stopped", "topic", topic, "error", err)
+				}
+			}(topic)
+		}
+	}
+
+	trackingClient := client.NewTrackingClient(client.TrackingClientConfig{BaseURL: getEnv("TRACKING_SERVICE_URL", "http://localhost:8083")})
+
+	// Identifier resolution for support, fanning out to dispatch and tracking
+	resolutionService := service.NewResolutionService(
+		shipmentRepo,
+		containerRepo,
+		orderRepo,
+		client.NewDispatchClient(client.DispatchClientConfig{BaseURL: getEnv("DISPATCH_SERVICE_URL", "http://localhost:8082")}),
+		trackingClient,
+		log,
+	)
+
+	// Missed terminal appointments, their fees and who was at fault
+	noShowService := service.NewNoShowService(
+		repository.NewPostgresNoShowRepository(db.Pool),
+		appointmentRepo,
+		orderRepo,
+		containerRepo,
+		shipmentRepo,
+		trackingClient,
+		producer,
+		config.DefaultBusinessRules().NoShow,
+		log,
+	)
+	go elector.Run(ctx, "order.no-shows", func(ctx context.Context) {
+		noShowService.Start(ctx, 15*time.Minute)
+	})
+
+	// Order tags and each user's saved order filters
+	orderTagService := service.NewOrderTagService(
+		orderRepo,
+		repository.NewPostgresSavedOrderFilterRepository(db.Pool),
+		service.NewOrderCRUDService(db, orderRepo, containerRepo, shipmentRepo, facilityService, rateApprovalService, producer, outbox, log),
+		producer,
+		log,
+	)
+
+	// Order intake mailbox: DO and booking emails become shipment drafts for
+	// clerks to confirm; attachments are parsed on the background job queue
+	mailbox := intakeMailbox(log)
+	jobStore := jobs.NewPostgresStore(db)
+	blobStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Failed to initialize blob storage", "error", err)
+	}
+	intakeService := service.NewEmailIntakeService(
+		db,
+		repository.NewPostgresIntakeRepository(db.Pool),
+		shipmentRepo,
+		repository.NewPostgresSteamshipLineRepository(db.Pool),
+		orderService,
+		mailbox,
+		blobStore,
+		jobs.NewQueue(jobStore),
+		producer,
+		log,
+	)
+	if mailbox != nil {
+		intakeWorkers := jobs.NewWorkerPool(jobStore, jobs.WorkerConfig{Queue: service.IntakeJobQueue, Timeout: 2 * time.Minute}, log)
+		intakeWorkers.Handle(service.ParseAttachmentJob, intakeService.HandleParseAttachment)
+		go intakeWorkers.Run(ctx)
+		go elector.Run(ctx, "order.email-intake", func(ctx context.Context) {
+			intakeService.Start(ctx, time.Minute)
+		})
+		log.Infow("Email intake enabled", "mailbox", mailbox.Address())
+	}
+
+	authn, err := auth.NewAuthenticator(cfg.Auth, auth.DefaultPolicy(), log)
+	if err != nil {
+		log.Fatalw("Invalid auth configuration", "error", err)
+	}
+
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      api.NewHandler(tenderService, rateConService, prearrivalService, facilityService, resolutionService, noShowService, orderTagService, intakeService, chargeAccrualService, rateApprovalService, authn, log).Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
 	// Initialize gRPC server
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			grpcHandler.LoggingInterceptor(log),
 			grpcHandler.RecoveryInterceptor(log),
+			authn.UnaryInterceptor(),
 		),
 	)
 
@@ -126,5 +396,51 @@ func main() {
 	healthServer.SetServingStatus(cfg.Service.Name, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 	grpcServer.GracefulStop()
 
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+
 	log.Info("Service stopped")
 }
+
+// signatureProvider selects the e-signature provider from ESIGN_PROVIDER
+func signatureProvider(log *logger.Logger) service.SignatureProvider {
+	if getEnv("ESIGN_PROVIDER", "docusign") == "dropbox_sign" {
+		return client.NewDropboxSignClient(client.DropboxSignConfig{
+			BaseURL:  os.Getenv("DROPBOX_SIGN_BASE_URL"),
+			APIKey:   os.Getenv("DROPBOX_SIGN_API_KEY"),
+			TestMode: getEnv("DROPBOX_SIGN_TEST_MODE", "false") == "true",
+		}, log)
+	}
+	return client.NewDocuSignClient(client.DocuSignConfig{
+		BaseURL:     getEnv("DOCUSIGN_BASE_URL", "https://demo.docusign.net/restapi"),
+		AccountID:   os.Getenv("DOCUSIGN_ACCOUNT_ID"),
+		AccessToken: os.Getenv("DOCUSIGN_ACCESS_TOKEN"),
+		HMACKey:     os.Getenv("DOCUSIGN_HMAC_KEY"),
+	}, log)
+}
+
+// intakeMailbox returns the Microsoft Graph intake mailbox, or nil when
+// INTAKE_MAILBOX is not set
+func intakeMailbox(log *logger.Logger) *client.GraphMailbox {
+	address := os.Getenv("INTAKE_MAILBOX")
+	if address == "" {
+		return nil
+	}
+	return client.NewGraphMailbox(client.GraphMailboxConfig{
+		BaseURL:      os.Getenv("GRAPH_BASE_URL"),
+		TenantID:     os.Getenv("GRAPH_TENANT_ID"),
+		ClientID:     os.Getenv("GRAPH_CLIENT_ID"),
+		ClientSecret: os.Getenv("GRAPH_CLIENT_SECRET"),
+		Mailbox:      address,
+	}, log)
+}
+
+func getEnv(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
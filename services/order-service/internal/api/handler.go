@@ -0,0 +1,1255 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/services/order-service/internal/service"
+	"github.com/draymaster/shared/pkg/auth"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// userHeader carries the authenticated ops user ID, set by the API gateway
+	userHeader = "X-User-ID"
+	// brokerKeyHeader carries the API key issued to a broker
+	brokerKeyHeader = "X-Broker-Key"
+	// tenantHeader selects the tenant whose templates and branding render documents
+	tenantHeader = "X-Tenant-ID"
+	// maxCallbackBytes caps e-signature provider callback bodies
+	maxCallbackBytes = 1 << 20
+)
+
+// Handler serves the broker intake, tender review, rate confirmation,
+// pre-arrival planning, facility profile, identifier resolution,
+// appointment no-show, order tag, email intake, accrued charge and rate
+// approval HTTP API
+type Handler struct {
+	tenders       *service.TenderService
+	rateCons      *service.RateConfirmationService
+	prearrival    *service.PreArrivalService
+	facilities    *service.FacilityService
+	resolutions   *service.ResolutionService
+	noShows       *service.NoShowService
+	tags          *service.OrderTagService
+	intake        *service.EmailIntakeService
+	charges       *service.ChargeAccrualService
+	rateApprovals *service.RateApprovalService
+	authn         *auth.Authenticator
+	logger        *logger.Logger
+}
+
+// NewHandler creates a new order service HTTP handler
+func NewHandler(
+	tenders *service.TenderService,
+	rateCons *service.RateConfirmationService,
+	prearrival *service.PreArrivalService,
+	facilities *service.FacilityService,
+	resolutions *service.ResolutionService,
+	noShows *service.NoShowService,
+	tags *service.OrderTagService,
+	intake *service.EmailIntakeService,
+	charges *service.ChargeAccrualService,
+	rateApprovals *service.RateApprovalService,
+	authn *auth.Authenticator,
+	log *logger.Logger,
+) *Handler {
+	return &Handler{
+		tenders:       tenders,
+		rateCons:      rateCons,
+		prearrival:    prearrival,
+		facilities:    facilities,
+		resolutions:   resolutions,
+		noShows:       noShows,
+		tags:          tags,
+		intake:        intake,
+		charges:       charges,
+		rateApprovals: rateApprovals,
+		authn:         authn,
+		logger:        log,
+	}
+}
+
+// Routes returns the HTTP routes for the API
+//
+// Broker intake (X-Broker-Key):
+//
+//	GET/POST            /v1/broker/tenders            (?status= to filter)
+//	GET                 /v1/broker/tenders/{id}
+//	POST                /v1/broker/tenders/{id}/withdraw
+//	POST                /v1/broker/tenders/{id}/accept-counter
+//
+// Ops review (X-User-ID):
+//
+//	GET                 /v1/tenders                   (?status=&broker_id=&limit=)
+//	GET                 /v1/tenders/{id}
+//	POST                /v1/tenders/{id}/accept
+//	POST                /v1/tenders/{id}/decline
+//	POST                /v1/tenders/{id}/counter
+//
+// Rate confirmations (X-User-ID, X-Tenant-ID for branding):
+//
+//	GET/POST            /v1/orders/{id}/rate-confirmations
+//	GET                 /v1/rate-confirmations/{id}/document   (signed copy once signed)
+//
+// E-signature provider callbacks (verified by the provider's signature):
+//
+//	POST                /v1/rate-confirmations/callback
+//
+// Pre-arrival planning (X-User-ID):
+//
+//	GET                 /v1/prearrival/workspace      (?terminal_id=&customer_id=&eta_from=&eta_to= as YYYY-MM-DD or RFC 3339)
+//	POST                /v1/prearrival/appointments   (request pickup appointments for a batch of orders)
+//	POST                /v1/prearrival/draft-trips    (stage draft trips, sent to dispatch once discharged)
+//
+// Address book (X-User-ID):
+//
+//	GET/PUT             /v1/facilities/{location_id}/profile   (hours, lunch closures, docks, PPE, check-in)
+//
+// Support (X-User-ID):
+//
+//	GET                 /v1/resolve                   (?q= container, booking, BL, order or trip number)
+//
+// Appointment no-shows (X-User-ID):
+//
+//	GET                 /v1/appointment-no-shows      (?terminal_id=&customer_id=&fault=&from=&to=&limit=)
+//	GET                 /v1/appointment-no-shows/analysis   (?from=&to= required, &terminal_id=&customer_id=)
+//	GET                 /v1/appointment-no-shows/{id}
+//	POST                /v1/appointment-no-shows/{id}/fee           (terminal-assessed fee)
+//	POST                /v1/appointment-no-shows/{id}/attribution   (DRIVER, CUSTOMER, DISPATCH or TERMINAL)
+//	POST                /v1/appointment-no-shows/{id}/pass-through  (bill the fee to the customer)
+//	POST                /v1/appointment-no-shows/{id}/waive
+//
+// Order tags and saved filters (X-User-ID):
+//
+//	PUT                 /v1/orders/{id}/tags          (replace with tags, or add/remove; version optional)
+//	GET/POST            /v1/order-filters             (the user's saved filters, default first; save one)
+//	PUT/DELETE          /v1/order-filters/{id}
+//	GET                 /v1/order-filters/{id}/orders (?page=&page_size=; run a saved filter)
+//
+// Email intake review (X-User-ID):
+//
+//	GET                 /v1/intake/drafts             (?status=&limit=)
+//	GET                 /v1/intake/drafts/{id}        (with the emails it was read from)
+//	POST                /v1/intake/drafts/{id}/confirm   (create the shipment; body fills what the email lacked)
+//	POST                /v1/intake/drafts/{id}/reject
+//	PUT                 /v1/intake/senders            (map an address or @domain to a customer)
+//
+// Accrued per-diem and demurrage (X-User-ID):
+//
+//	GET                 /v1/charges                   (?customer_id=&terminal_id=&charge_type=&min_amount=&limit=)
+//	POST                /v1/charges/accrue            (run the nightly accrual now)
+//	GET                 /v1/containers/{id}/charges
+//
+// Rate exception approvals (X-User-ID; posts need a bearer token, and
+// approve and reject the manager role, when auth is enabled):
+//
+//	GET                 /v1/rate-approvals            (?status=, default PENDING, &customer_id=&limit=)
+//	GET                 /v1/rate-approvals/{id}       (with its comments)
+//	POST                /v1/rate-approvals/{id}/approve    (releases the held order to READY)
+//	POST                /v1/rate-approvals/{id}/reject     (comment required; the order stays on HOLD)
+//	POST                /v1/rate-approvals/{id}/comments
+//	GET                 /v1/orders/{id}/rate-approvals
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	mux.HandleFunc("/v1/broker/tenders", h.brokerTenders)
+	mux.HandleFunc("/v1/broker/tenders/", h.brokerTender)
+	mux.HandleFunc("/v1/tenders", h.tenderQueue)
+	mux.HandleFunc("/v1/tenders/", h.tender)
+	mux.HandleFunc("/v1/orders/", h.order)
+	mux.HandleFunc("/v1/rate-confirmations/callback", h.signatureCallback)
+	mux.HandleFunc("/v1/rate-confirmations/", h.rateConfirmationDocument)
+	mux.HandleFunc("/v1/prearrival/workspace", h.preArrivalWorkspace)
+	mux.HandleFunc("/v1/prearrival/appointments", h.preArrivalAppointments)
+	mux.HandleFunc("/v1/prearrival/draft-trips", h.preArrivalDraftTrips)
+	mux.HandleFunc("/v1/facilities/", h.facilityProfile)
+	mux.HandleFunc("/v1/resolve", h.resolve)
+	mux.HandleFunc("/v1/appointment-no-shows", h.noShowList)
+	mux.HandleFunc("/v1/appointment-no-shows/", h.noShow)
+	mux.HandleFunc("/v1/order-filters", h.orderFilters)
+	mux.HandleFunc("/v1/order-filters/", h.orderFilters)
+	mux.HandleFunc("/v1/intake/drafts", h.intakeDrafts)
+	mux.HandleFunc("/v1/intake/drafts/", h.intakeDraft)
+	mux.HandleFunc("/v1/intake/senders", h.intakeSenders)
+	mux.HandleFunc("/v1/charges", h.chargeList)
+	mux.HandleFunc("/v1/charges/accrue", h.accrueCharges)
+	mux.HandleFunc("/v1/containers/", h.containerCharges)
+	mux.HandleFunc("/v1/rate-approvals", h.rateApprovalQueue)
+	mux.HandleFunc("/v1/rate-approvals/", h.rateApproval)
+
+	return mux
+}
+
+// ============================================================================
+// BROKER INTAKE
+// ============================================================================
+
+func (h *Handler) brokerTenders(w http.ResponseWriter, r *http.Request) {
+	broker, ok := h.broker(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status := domain.TenderStatus(strings.ToUpper(r.URL.Query().Get("status")))
+		tenders, err := h.tenders.ListBrokerTenders(r.Context(), broker, status)
+		h.respond(w, tenders, err)
+	case http.MethodPost:
+		var input service.SubmitTenderInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		tender, err := h.tenders.SubmitTender(r.Context(), broker, input)
+		h.respondCreated(w, tender, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) brokerTender(w http.ResponseWriter, r *http.Request) {
+	broker, ok := h.broker(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/broker/tenders/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		tender, err := h.tenders.GetBrokerTender(r.Context(), broker, id)
+		h.respond(w, tender, err)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[1] {
+	case "withdraw":
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		tender, err := h.tenders.WithdrawTender(r.Context(), broker, id, input.Reason)
+		h.respond(w, tender, err)
+	case "accept-counter":
+		tender, err := h.tenders.AcceptCounterOffer(r.Context(), broker, id)
+		h.respond(w, tender, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// OPS REVIEW
+// ============================================================================
+
+func (h *Handler) tenderQueue(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.TenderFilter{
+		Status: domain.TenderStatus(strings.ToUpper(query.Get("status"))),
+	}
+	if raw := query.Get("broker_id"); raw != "" {
+		brokerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.BrokerID = &brokerID
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			h.writeError(w, apperrors.ValidationError("invalid limit", "limit", raw))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	tenders, err := h.tenders.ListTenders(r.Context(), filter)
+	h.respond(w, tenders, err)
+}
+
+func (h *Handler) tender(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/tenders/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		tender, err := h.tenders.GetTender(r.Context(), id)
+		h.respond(w, tender, err)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[1] {
+	case "accept":
+		var input service.AcceptTenderInput
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		input.AcceptedBy = user
+		tender, err := h.tenders.AcceptTender(r.Context(), id, input)
+		h.respond(w, tender, err)
+	case "decline":
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		tender, err := h.tenders.DeclineTender(r.Context(), id, input.Reason, user)
+		h.respond(w, tender, err)
+	case "counter":
+		var input struct {
+			Rate  float64 `json:"rate"`
+			Notes string  `json:"notes"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		tender, err := h.tenders.CounterTender(r.Context(), id, input.Rate, input.Notes, user)
+		h.respond(w, tender, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ============================================================================
+// RATE CONFIRMATIONS
+// ============================================================================
+
+func (h *Handler) order(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/orders/")
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	orderID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch parts[1] {
+	case "rate-confirmations":
+		h.orderRateConfirmations(w, r, orderID, user)
+	case "tags":
+		h.orderTags(w, r, orderID, user)
+	case "rate-approvals":
+		h.orderRateApprovals(w, r, orderID)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) orderRateConfirmations(w http.ResponseWriter, r *http.Request, orderID uuid.UUID, user string) {
+	switch r.Method {
+	case http.MethodGet:
+		confirmations, err := h.rateCons.GetRateConfirmations(r.Context(), orderID)
+		h.respond(w, confirmations, err)
+	case http.MethodPost:
+		var input service.SendRateConfirmationInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.TenantID = r.Header.Get(tenantHeader)
+		input.SentBy = user
+		rc, err := h.rateCons.SendRateConfirmation(r.Context(), orderID, input)
+		h.respondCreated(w, rc, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) rateConfirmationDocument(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/rate-confirmations/")
+	if len(parts) != 2 || parts[1] != "document" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	doc, err := h.rateCons.GetRateConfirmationDocument(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc.Data)
+}
+
+func (h *Handler) signatureCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBytes))
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return
+	}
+	if err := h.rateCons.HandleSignatureCallback(r.Context(), r.Header, body); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	// Dropbox Sign only counts a callback as delivered on this exact reply
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Hello API Event Received"))
+}
+
+// ============================================================================
+// PRE-ARRIVAL PLANNING
+// ============================================================================
+
+func (h *Handler) preArrivalWorkspace(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var filter repository.PreArrivalFilter
+	if raw := query.Get("terminal_id"); raw != "" {
+		terminalID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.TerminalID = &terminalID
+	}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.CustomerID = &customerID
+	}
+	var ok bool
+	if filter.ETAFrom, ok = h.parseTime(w, "eta_from", query.Get("eta_from")); !ok {
+		return
+	}
+	if filter.ETATo, ok = h.parseTime(w, "eta_to", query.Get("eta_to")); !ok {
+		return
+	}
+
+	vessels, err := h.prearrival.GetWorkspace(r.Context(), filter, time.Now())
+	h.respond(w, vessels, err)
+}
+
+func (h *Handler) preArrivalAppointments(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input service.RequestPreArrivalAppointmentsInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	input.RequestedBy = user
+	results, err := h.prearrival.RequestAppointments(r.Context(), input)
+	h.respond(w, results, err)
+}
+
+func (h *Handler) preArrivalDraftTrips(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input service.GenerateDraftTripsInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	input.RequestedBy = user
+	results, err := h.prearrival.GenerateDraftTrips(r.Context(), input, time.Now())
+	h.respond(w, results, err)
+}
+
+// ============================================================================
+// ADDRESS BOOK
+// ============================================================================
+
+func (h *Handler) facilityProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/facilities/")
+	if len(parts) != 2 || parts[1] != "profile" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	locationID, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, err := h.facilities.GetProfile(r.Context(), locationID)
+		if err == nil && profile == nil {
+			err = apperrors.NotFoundError("facility profile", locationID.String())
+		}
+		h.respond(w, profile, err)
+	case http.MethodPut:
+		var input service.SaveFacilityProfileInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.LocationID = locationID
+		input.UpdatedBy = user
+		profile, err := h.facilities.SaveProfile(r.Context(), input)
+		h.respond(w, profile, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// SUPPORT
+// ============================================================================
+
+func (h *Handler) resolve(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resolution, err := h.resolutions.Resolve(r.Context(), r.URL.Query().Get("q"))
+	h.respond(w, resolution, err)
+}
+
+// ============================================================================
+// APPOINTMENT NO-SHOWS
+// ============================================================================
+
+func (h *Handler) noShowList(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, ok := h.noShowFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Fault = domain.NoShowFault(strings.ToUpper(r.URL.Query().Get("fault")))
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid limit", "limit", raw))
+			return
+		}
+		filter.Limit = limit
+	}
+	noShows, err := h.noShows.ListNoShows(r.Context(), filter)
+	h.respond(w, noShows, err)
+}
+
+func (h *Handler) noShow(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/appointment-no-shows/")
+	if len(parts) == 1 && parts[0] == "analysis" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		filter, ok := h.noShowFilter(w, r)
+		if !ok {
+			return
+		}
+		analysis, err := h.noShows.Analyze(r.Context(), filter)
+		h.respond(w, analysis, err)
+		return
+	}
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		noShow, err := h.noShows.GetNoShow(r.Context(), id)
+		h.respond(w, noShow, err)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch parts[1] {
+	case "fee":
+		var input service.RecordNoShowFeeInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.NoShowID = id
+		input.RecordedBy = user
+		noShow, err := h.noShows.RecordFee(r.Context(), input)
+		h.respond(w, noShow, err)
+	case "attribution":
+		var input service.AttributeNoShowInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		input.NoShowID = id
+		input.AttributedBy = user
+		noShow, err := h.noShows.AttributeFault(r.Context(), input)
+		h.respond(w, noShow, err)
+	case "pass-through":
+		var input service.PassThroughNoShowFeeInput
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		input.NoShowID = id
+		input.BilledBy = user
+		noShow, err := h.noShows.PassThroughFee(r.Context(), input)
+		h.respond(w, noShow, err)
+	case "waive":
+		noShow, err := h.noShows.WaiveFee(r.Context(), id, user)
+		h.respond(w, noShow, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// noShowFilter parses the terminal, customer and window range shared by
+// the no-show list and analysis
+func (h *Handler) noShowFilter(w http.ResponseWriter, r *http.Request) (repository.NoShowFilter, bool) {
+	query := r.URL.Query()
+	var filter repository.NoShowFilter
+	if raw := query.Get("terminal_id"); raw != "" {
+		terminalID, ok := h.parseID(w, raw)
+		if !ok {
+			return filter, false
+		}
+		filter.TerminalID = &terminalID
+	}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return filter, false
+		}
+		filter.CustomerID = &customerID
+	}
+	var ok bool
+	if filter.From, ok = h.parseTime(w, "from", query.Get("from")); !ok {
+		return filter, false
+	}
+	if filter.To, ok = h.parseTime(w, "to", query.Get("to")); !ok {
+		return filter, false
+	}
+	return filter, true
+}
+
+// ============================================================================
+// ORDER TAGS AND SAVED FILTERS
+// ============================================================================
+
+func (h *Handler) orderTags(w http.ResponseWriter, r *http.Request, orderID uuid.UUID, user string) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var input service.UpdateOrderTagsInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	input.UpdatedBy = user
+	order, err := h.tags.UpdateOrderTags(r.Context(), orderID, input)
+	h.respond(w, order, err)
+}
+
+func (h *Handler) orderFilters(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/order-filters")
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		filters, err := h.tags.ListOrderFilters(r.Context(), user)
+		h.respond(w, filters, err)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.saveOrderFilter(w, r, nil, user)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		h.saveOrderFilter(w, r, &id, user)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		if err := h.tags.DeleteOrderFilter(r.Context(), id, user); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "orders" && r.Method == http.MethodGet:
+		id, ok := h.parseID(w, parts[0])
+		if !ok {
+			return
+		}
+		page, ok := h.queryInt(w, r, "page")
+		if !ok {
+			return
+		}
+		pageSize, ok := h.queryInt(w, r, "page_size")
+		if !ok {
+			return
+		}
+		orders, err := h.tags.RunOrderFilter(r.Context(), id, user, page, pageSize)
+		h.respond(w, orders, err)
+	case len(parts) <= 1 || (len(parts) == 2 && parts[1] == "orders"):
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) saveOrderFilter(w http.ResponseWriter, r *http.Request, id *uuid.UUID, user string) {
+	var input service.SaveOrderFilterInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	input.ID = id
+	input.UserID = user
+	filter, err := h.tags.SaveOrderFilter(r.Context(), input)
+	if id == nil {
+		h.respondCreated(w, filter, err)
+		return
+	}
+	h.respond(w, filter, err)
+}
+
+// ============================================================================
+// EMAIL INTAKE
+// ============================================================================
+
+func (h *Handler) intakeDrafts(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	limit, ok := h.queryInt(w, r, "limit")
+	if !ok {
+		return
+	}
+	drafts, err := h.intake.ListDrafts(r.Context(), repository.DraftFilter{
+		Status: domain.DraftStatus(strings.ToUpper(r.URL.Query().Get("status"))),
+		Limit:  limit,
+	})
+	h.respond(w, drafts, err)
+}
+
+func (h *Handler) intakeDraft(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/intake/drafts/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		draft, err := h.intake.GetDraft(r.Context(), id)
+		h.respond(w, draft, err)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[1] {
+	case "confirm":
+		var input service.ConfirmIntakeInput
+		if r.ContentLength != 0 && !h.decode(w, r, &input) {
+			return
+		}
+		input.ConfirmedBy = user
+		draft, err := h.intake.ConfirmIntake(r.Context(), id, input)
+		h.respond(w, draft, err)
+	case "reject":
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if !h.decode(w, r, &input) {
+			return
+		}
+		draft, err := h.intake.RejectIntake(r.Context(), id, input.Reason, user)
+		h.respond(w, draft, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) intakeSenders(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var input domain.IntakeSender
+	if !h.decode(w, r, &input) {
+		return
+	}
+	sender, err := h.intake.SaveSender(r.Context(), input)
+	h.respond(w, sender, err)
+}
+
+// ============================================================================
+// ACCRUED CHARGES
+// ============================================================================
+
+func (h *Handler) chargeList(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.ContainerChargeFilter{
+		ChargeType: domain.ChargeType(strings.ToUpper(query.Get("charge_type"))),
+	}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.CustomerID = &customerID
+	}
+	if raw := query.Get("terminal_id"); raw != "" {
+		terminalID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.TerminalID = &terminalID
+	}
+	if raw := query.Get("min_amount"); raw != "" {
+		minAmount, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			h.writeError(w, apperrors.ValidationError("invalid min_amount", "min_amount", raw))
+			return
+		}
+		filter.MinAmount = minAmount
+	}
+	limit, ok := h.queryInt(w, r, "limit")
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+
+	charges, err := h.charges.ListCharges(r.Context(), filter)
+	h.respond(w, charges, err)
+}
+
+func (h *Handler) accrueCharges(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	run, err := h.charges.Accrue(r.Context(), time.Now())
+	h.respond(w, run, err)
+}
+
+func (h *Handler) containerCharges(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	parts := pathParts(r.URL.Path, "/v1/containers/")
+	if len(parts) != 2 || parts[1] != "charges" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+	charges, err := h.charges.GetContainerCharges(r.Context(), id)
+	h.respond(w, charges, err)
+}
+
+// ============================================================================
+// RATE APPROVALS
+// ============================================================================
+
+func (h *Handler) rateApprovalQueue(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.user(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.RateApprovalFilter{
+		Status: domain.RateApprovalStatus(strings.ToUpper(query.Get("status"))),
+	}
+	if raw := query.Get("customer_id"); raw != "" {
+		customerID, ok := h.parseID(w, raw)
+		if !ok {
+			return
+		}
+		filter.CustomerID = &customerID
+	}
+	limit, ok := h.queryInt(w, r, "limit")
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+
+	approvals, err := h.rateApprovals.ListQueue(r.Context(), filter)
+	h.respond(w, approvals, err)
+}
+
+func (h *Handler) rateApproval(w http.ResponseWriter, r *http.Request) {
+
+	parts := pathParts(r.URL.Path, "/v1/rate-approvals/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if _, ok := h.user(w, r); !ok {
+			return
+		}
+		approval, err := h.rateApprovals.GetApproval(r.Context(), id)
+		h.respond(w, approval, err)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	// Only managers decide rates, and the approver is whoever the token
+	// says, so requesters cannot approve their own rate as someone else
+	var roles []auth.Role
+	if parts[1] == "approve" || parts[1] == "reject" {
+		roles = []auth.Role{auth.RoleManager}
+	}
+	user, ok := h.verifiedUser(w, r, roles...)
+	if !ok {
+		return
+	}
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 && !h.decode(w, r, &input) {
+		return
+	}
+	switch parts[1] {
+	case "approve":
+		approval, err := h.rateApprovals.Approve(r.Context(), id, user, input.Comment)
+		h.respond(w, approval, err)
+	case "reject":
+		approval, err := h.rateApprovals.Reject(r.Context(), id, user, input.Comment)
+		h.respond(w, approval, err)
+	case "comments":
+		comment, err := h.rateApprovals.AddComment(r.Context(), id, user, input.Comment)
+		h.respondCreated(w, comment, err)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) orderRateApprovals(w http.ResponseWriter, r *http.Request, orderID uuid.UUID) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	approvals, err := h.rateApprovals.ListForOrder(r.Context(), orderID)
+	h.respond(w, approvals, err)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func (h *Handler) broker(w http.ResponseWriter, r *http.Request) (*domain.Broker, bool) {
+	broker, err := h.tenders.AuthenticateBroker(r.Context(), strings.TrimSpace(r.Header.Get(brokerKeyHeader)))
+	if err != nil {
+		h.writeError(w, err)
+		return nil, false
+	}
+	return broker, true
+}
+
+func (h *Handler) user(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := strings.TrimSpace(r.Header.Get(userHeader))
+	if user == "" {
+		writeJSON(w, http.StatusUnauthorized, apperrors.New("UNAUTHORIZED", "missing user identity"))
+		return "", false
+	}
+	return user, true
+}
+
+// verifiedUser identifies the caller from their bearer token, requiring one
+// of roles. With auth disabled it falls back to the gateway's user header.
+func (h *Handler) verifiedUser(w http.ResponseWriter, r *http.Request, roles ...auth.Role) (string, bool) {
+	id, err := h.authn.AuthenticateRequest(r, roles...)
+	if err != nil {
+		h.writeError(w, err)
+		return "", false
+	}
+	if id == nil {
+		return h.user(w, r)
+	}
+	return id.Subject, true
+}
+
+// queryInt parses an optional integer query parameter, 0 when absent
+func (h *Handler) queryInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid "+name, name, raw))
+		return 0, false
+	}
+	return n, true
+}
+
+func pathParts(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid id", "id", raw))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseTime parses an optional YYYY-MM-DD or RFC 3339 query value; empty
+// yields the zero time
+func (h *Handler) parseTime(w http.ResponseWriter, field, raw string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid "+field, field, raw))
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) respondCreated(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		status = http.StatusBadRequest
+	case "UNAUTHORIZED":
+		status = http.StatusUnauthorized
+	case "FORBIDDEN":
+		status = http.StatusForbidden
+	case "NOT_FOUND":
+		status = http.StatusNotFound
+	case "CONFLICT", "INVALID_STATE":
+		status = http.StatusConflict
+	case "FACILITY_CLOSED":
+		status = http.StatusUnprocessableEntity
+	case "EXTERNAL_SERVICE_ERROR":
+		status = http.StatusBadGateway
+	case "SERVICE_UNAVAILABLE":
+		status = http.StatusServiceUnavailable
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Order API request failed", "error", err)
+	}
+
+	writeJSON(w, status, appErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// AISClientConfig holds configuration for the AIS position provider
+type AISClientConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// AISClient looks up live vessel positions over HTTP. It implements
+// service.VesselPositionSource.
+type AISClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	log        *logger.Logger
+}
+
+// NewAISClient creates a new AIS provider client.
+func NewAISClient(cfg AISClientConfig, log *logger.Logger) *AISClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	return &AISClient{
+		baseURL:    cfg.BaseURL,
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: timeout},
+		log:        log,
+	}
+}
+
+// VesselPosition returns the vessel's latest position report, looked up by
+// IMO or MMSI when known and by name otherwise. It returns nil when the
+// provider does not know the vessel.
+func (c *AISClient) VesselPosition(ctx context.Context, vessel domain.VesselRef) (*domain.VesselPosition, error) {
+	params := url.Values{}
+	switch {
+	case vessel.IMO != "":
+		params.Set("imo", vessel.IMO)
+	case vessel.MMSI != "":
+		params.Set("mmsi", vessel.MMSI)
+	default:
+		params.Set("name", vessel.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/vessels/position?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build ais request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-API-KEY", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch vessel position: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.log.Debugw("Vessel unknown to AIS provider", "vessel", vessel.Name, "imo", vessel.IMO)
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ais provider returned status %d", resp.StatusCode)
+	}
+
+	var position domain.VesselPosition
+	if err := json.NewDecoder(resp.Body).Decode(&position); err != nil {
+		return nil, fmt.Errorf("decode vessel position: %w", err)
+	}
+	return &position, nil
+}
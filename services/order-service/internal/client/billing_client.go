@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/service"
+)
+
+// BillingClientConfig holds configuration for billing-service's HTTP API
+type BillingClientConfig struct {
+	BaseURL string
+	Timeout time.Duration
+}
+
+// BillingClient looks up customer contract rates and rate quotes in
+// billing-service. It implements service.ContractRateSource and
+// service.RateQuoteSource.
+type BillingClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// billingServiceUser is the staff identity order-service calls billing as
+const billingServiceUser = "order-service"
+
+// NewBillingClient creates a new billing-service client.
+func NewBillingClient(cfg BillingClientConfig) *BillingClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &BillingClient{
+		baseURL:    cfg.BaseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// FindContractRate returns the contract rate billing would price the move
+// with, or nil when the customer has none for it.
+func (c *BillingClient) FindContractRate(ctx context.Context, query service.ContractRateQuery) (*domain.ContractRate, error) {
+	params := url.Values{}
+	params.Set("customer_id", query.CustomerID.String())
+	if query.OriginID != nil {
+		params.Set("origin_id", query.OriginID.String())
+	}
+	if query.DestinationID != nil {
+		params.Set("destination_id", query.DestinationID.String())
+	}
+	if query.ContainerSize != "" {
+		params.Set("container_size", string(query.ContainerSize))
+	}
+	if query.ContainerType != "" {
+		params.Set("container_type", billingContainerType(query.ContainerType))
+	}
+	if !query.At.IsZero() {
+		params.Set("at", query.At.UTC().Format(time.RFC3339))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/rates/contract?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build contract rate request: %w", err)
+	}
+	req.Header.Set("X-User-ID", billingServiceUser)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("find contract rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("find contract rate: status %d", resp.StatusCode)
+	}
+	var rate domain.ContractRate
+	if err := json.NewDecoder(resp.Body).Decode(&rate); err != nil {
+		return nil, fmt.Errorf("decode contract rate response: %w", err)
+	}
+	return &rate, nil
+}
+
+// QuoteRate prices a move from the customer's rate tables, or returns nil
+// when no contract rate covers it.
+func (c *BillingClient) QuoteRate(ctx context.Context, query service.RateQuoteQuery) (*domain.RateQuote, error) {
+	body := map[string]interface{}{
+		"customer_id":  query.CustomerID,
+		"accessorials": query.Accessorials,
+	}
+	if query.OriginID != nil {
+		body["origin_id"] = query.OriginID
+	}
+	if query.DestinationID != nil {
+		body["destination_id"] = query.DestinationID
+	}
+	if query.ContainerSize != "" {
+		body["container_size"] = string(query.ContainerSize)
+	}
+	if query.ContainerType != "" {
+		body["container_type"] = billingContainerType(query.ContainerType)
+	}
+	if !query.At.IsZero() {
+		body["at"] = query.At.UTC()
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encode rate quote request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/rates/quote", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("build rate quote request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", billingServiceUser)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("quote rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("quote rate: status %d", resp.StatusCode)
+	}
+	var quote domain.RateQuote
+	if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+		return nil, fmt.Errorf("decode rate quote response: %w", err)
+	}
+	return &quote, nil
+}
+
+// billingContainerType maps a container type to billing's rate types,
+// which only tell reefers from dry boxes
+func billingContainerType(t domain.ContainerType) string {
+	switch t {
+	case domain.ContainerTypeReefer:
+		return "reefer"
+	case domain.ContainerTypeDry, domain.ContainerTypeHighCube:
+		return "dry"
+	default:
+		return strings.ToLower(string(t))
+	}
+}
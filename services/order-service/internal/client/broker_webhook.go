@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// BrokerWebhookConfig holds configuration for broker webhook delivery.
+type BrokerWebhookConfig struct {
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+// BrokerWebhookClient posts tender and order updates to broker webhooks.
+// Each body is signed with the broker's webhook secret so the broker can
+// verify it came from us. It implements service.BrokerNotifier.
+type BrokerWebhookClient struct {
+	httpClient *http.Client
+	maxRetries int
+	log        *logger.Logger
+}
+
+// NewBrokerWebhookClient creates a new broker webhook client.
+func NewBrokerWebhookClient(cfg BrokerWebhookConfig, log *logger.Logger) *BrokerWebhookClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	return &BrokerWebhookClient{
+		httpClient: &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+		log:        log,
+	}
+}
+
+// NotifyBroker delivers an update to the broker's webhook URL, retrying
+// connection errors and 5xx responses with backoff.
+func (c *BrokerWebhookClient) NotifyBroker(ctx context.Context, broker *domain.Broker, update service.TenderUpdate) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("marshal broker update: %w", err)
+	}
+	timestamp := strconv.FormatInt(update.OccurredAt.Unix(), 10)
+	signature := signWebhook(broker.WebhookSecret, timestamp, body)
+
+	var lastErr error
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, broker.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("build broker webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-DrayMaster-Event", update.Event)
+		req.Header.Set("X-DrayMaster-Timestamp", timestamp)
+		req.Header.Set("X-DrayMaster-Signature", signature)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("post broker webhook: %w", err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 300 {
+			c.log.Debugw("Broker webhook delivered",
+				"broker", broker.Name,
+				"tender_id", update.TenderID,
+				"event", update.Event,
+			)
+			return nil
+		}
+		lastErr = fmt.Errorf("broker webhook returned status %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// signWebhook computes the hex HMAC-SHA256 of "timestamp.body"
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/service"
+)
+
+// DispatchClientConfig holds configuration for dispatch-service's HTTP API
+type DispatchClientConfig struct {
+	BaseURL string
+	Timeout time.Duration
+}
+
+// DispatchClient looks up trips in dispatch-service. It implements
+// service.TripDirectory.
+type DispatchClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewDispatchClient creates a new dispatch-service client.
+func NewDispatchClient(cfg DispatchClientConfig) *DispatchClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &DispatchClient{
+		baseURL:    cfg.BaseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// LookupTrips returns the trips matching query, newest first.
+func (c *DispatchClient) LookupTrips(ctx context.Context, query service.TripQuery) ([]domain.ResolvedTrip, error) {
+	params := url.Values{}
+	if query.ContainerNumber != "" {
+		params.Set("container_number", query.ContainerNumber)
+	}
+	if query.TripNumber != "" {
+		params.Set("trip_number", query.TripNumber)
+	}
+	if query.OrderID != nil {
+		params.Set("order_id", query.OrderID.String())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/trips/lookup?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build trip lookup request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lookup trips: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lookup trips: status %d", resp.StatusCode)
+	}
+	var trips []domain.ResolvedTrip
+	if err := json.NewDecoder(resp.Body).Decode(&trips); err != nil {
+		return nil, fmt.Errorf("decode trip lookup response: %w", err)
+	}
+	return trips, nil
+}
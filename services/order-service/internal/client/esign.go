@@ -0,0 +1,470 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// signatureAnchor is the text on the rate confirmation the signer's
+// signature is placed over
+const signatureAnchor = "Broker signature"
+
+// maxSignedDocumentBytes caps downloaded signed PDFs
+const maxSignedDocumentBytes = 20 << 20
+
+// =============================================================================
+// DOCUSIGN
+// =============================================================================
+
+// DocuSignConfig holds configuration for the DocuSign eSignature REST API.
+type DocuSignConfig struct {
+	BaseURL     string // e.g. https://demo.docusign.net/restapi
+	AccountID   string
+	AccessToken string // OAuth token for the integration user
+	HMACKey     string // Connect HMAC key callbacks are signed with
+	Timeout     time.Duration
+}
+
+// DocuSignClient sends envelopes through DocuSign. It implements
+// service.SignatureProvider.
+type DocuSignClient struct {
+	baseURL     string
+	accountID   string
+	accessToken string
+	hmacKey     string
+	httpClient  *http.Client
+	log         *logger.Logger
+}
+
+// NewDocuSignClient creates a new DocuSign client.
+func NewDocuSignClient(cfg DocuSignConfig, log *logger.Logger) *DocuSignClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return &DocuSignClient{
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+		accountID:   cfg.AccountID,
+		accessToken: cfg.AccessToken,
+		hmacKey:     cfg.HMACKey,
+		httpClient:  &http.Client{Timeout: timeout},
+		log:         log,
+	}
+}
+
+// Name returns the provider name stored on rate confirmations.
+func (c *DocuSignClient) Name() string {
+	return "docusign"
+}
+
+type docuSignEnvelope struct {
+	EmailSubject string             `json:"emailSubject"`
+	EmailBlurb   string             `json:"emailBlurb,omitempty"`
+	Documents    []docuSignDocument `json:"documents"`
+	Recipients   docuSignRecipients `json:"recipients"`
+	Status       string             `json:"status"`
+}
+
+type docuSignDocument struct {
+	DocumentBase64 string `json:"documentBase64"`
+	Name           string `json:"name"`
+	FileExtension  string `json:"fileExtension"`
+	DocumentID     string `json:"documentId"`
+}
+
+type docuSignRecipients struct {
+	Signers []docuSignSigner `json:"signers"`
+}
+
+type docuSignSigner struct {
+	Email        string       `json:"email"`
+	Name         string       `json:"name"`
+	RecipientID  string       `json:"recipientId"`
+	RoutingOrder string       `json:"routingOrder"`
+	Tabs         docuSignTabs `json:"tabs"`
+}
+
+type docuSignTabs struct {
+	SignHereTabs   []docuSignAnchor `json:"signHereTabs"`
+	DateSignedTabs []docuSignAnchor `json:"dateSignedTabs"`
+}
+
+type docuSignAnchor struct {
+	AnchorString  string `json:"anchorString"`
+	AnchorUnits   string `json:"anchorUnits"`
+	AnchorXOffset string `json:"anchorXOffset"`
+	AnchorYOffset string `json:"anchorYOffset"`
+}
+
+// Send creates and sends an envelope with one signer.
+func (c *DocuSignClient) Send(ctx context.Context, req service.SignatureRequest) (string, error) {
+	envelope := docuSignEnvelope{
+		EmailSubject: req.Subject,
+		EmailBlurb:   req.Message,
+		Documents: []docuSignDocument{{
+			DocumentBase64: base64.StdEncoding.EncodeToString(req.Document),
+			Name:           req.Filename,
+			FileExtension:  "pdf",
+			DocumentID:     "1",
+		}},
+		Recipients: docuSignRecipients{Signers: []docuSignSigner{{
+			Email:        req.SignerEmail,
+			Name:         req.SignerName,
+			RecipientID:  "1",
+			RoutingOrder: "1",
+			Tabs: docuSignTabs{
+				SignHereTabs:   []docuSignAnchor{{AnchorString: signatureAnchor, AnchorUnits: "pixels", AnchorXOffset: "0", AnchorYOffset: "-24"}},
+				DateSignedTabs: []docuSignAnchor{{AnchorString: signatureAnchor, AnchorUnits: "pixels", AnchorXOffset: "320", AnchorYOffset: "-24"}},
+			},
+		}}},
+		Status: "sent",
+	}
+
+	var result struct {
+		EnvelopeID string `json:"envelopeId"`
+		Status     string `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/envelopes", envelope, &result); err != nil {
+		return "", fmt.Errorf("create docusign envelope: %w", err)
+	}
+
+	c.log.Infow("DocuSign envelope sent",
+		"reference", req.Reference,
+		"envelope_id", result.EnvelopeID,
+	)
+	return result.EnvelopeID, nil
+}
+
+// DownloadSigned returns the completed document combined with the
+// certificate of completion.
+func (c *DocuSignClient) DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.accountURL("/envelopes/"+envelopeID+"/documents/combined?certificate=true"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build docusign download request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	return downloadPDF(c.httpClient, req)
+}
+
+// Void voids an envelope that is still out for signature.
+func (c *DocuSignClient) Void(ctx context.Context, envelopeID, reason string) error {
+	body := map[string]string{"status": "voided", "voidedReason": reason}
+	if err := c.do(ctx, http.MethodPut, "/envelopes/"+envelopeID, body, nil); err != nil {
+		return fmt.Errorf("void docusign envelope: %w", err)
+	}
+	return nil
+}
+
+// ParseCallback verifies a Connect callback's HMAC signature and decodes its
+// envelope event.
+func (c *DocuSignClient) ParseCallback(header http.Header, body []byte) (*service.SignatureEvent, error) {
+	if c.hmacKey != "" {
+		mac := hmac.New(sha256.New, []byte(c.hmacKey))
+		mac.Write(body)
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(header.Get("X-DocuSign-Signature-1"))) {
+			return nil, fmt.Errorf("docusign callback signature mismatch")
+		}
+	}
+
+	var payload struct {
+		Event             string    `json:"event"`
+		GeneratedDateTime time.Time `json:"generatedDateTime"`
+		Data              struct {
+			EnvelopeID string `json:"envelopeId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode docusign callback: %w", err)
+	}
+
+	event := &service.SignatureEvent{EnvelopeID: payload.Data.EnvelopeID, OccurredAt: payload.GeneratedDateTime}
+	switch payload.Event {
+	case "envelope-completed":
+		event.Status = domain.RateConfirmationSigned
+	case "envelope-declined":
+		event.Status = domain.RateConfirmationDeclined
+		event.Reason = "declined by signer"
+	case "envelope-voided":
+		event.Status = domain.RateConfirmationVoided
+		event.Reason = "voided in DocuSign"
+	}
+	return event, nil
+}
+
+func (c *DocuSignClient) accountURL(path string) string {
+	return c.baseURL + "/v2.1/accounts/" + c.accountID + path
+}
+
+// do sends a JSON request to the account API and decodes the response into out
+func (c *DocuSignClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.accountURL(path), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("status %d: %s %s", resp.StatusCode, apiErr.ErrorCode, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// =============================================================================
+// DROPBOX SIGN
+// =============================================================================
+
+// DropboxSignConfig holds configuration for the Dropbox Sign (HelloSign) API.
+type DropboxSignConfig struct {
+	BaseURL  string // https://api.hellosign.com/v3 when empty
+	APIKey   string // Also the key callback event hashes are computed with
+	TestMode bool   // Requests are not legally binding and not billed
+	Timeout  time.Duration
+}
+
+// DropboxSignClient sends signature requests through Dropbox Sign. It
+// implements service.SignatureProvider.
+type DropboxSignClient struct {
+	baseURL    string
+	apiKey     string
+	testMode   bool
+	httpClient *http.Client
+	log        *logger.Logger
+}
+
+// NewDropboxSignClient creates a new Dropbox Sign client.
+func NewDropboxSignClient(cfg DropboxSignConfig, log *logger.Logger) *DropboxSignClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.hellosign.com/v3"
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return &DropboxSignClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		testMode:   cfg.TestMode,
+		httpClient: &http.Client{Timeout: timeout},
+		log:        log,
+	}
+}
+
+// Name returns the provider name stored on rate confirmations.
+func (c *DropboxSignClient) Name() string {
+	return "dropbox_sign"
+}
+
+// Send creates a signature request with one signer.
+func (c *DropboxSignClient) Send(ctx context.Context, req service.SignatureRequest) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"title":                     req.Reference,
+		"subject":                   req.Subject,
+		"message":                   req.Message,
+		"signers[0][name]":          req.SignerName,
+		"signers[0][email_address]": req.SignerEmail,
+		"test_mode":                 strconv.Itoa(boolInt(c.testMode)),
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("build dropbox sign request: %w", err)
+		}
+	}
+	part, err := form.CreateFormFile("files[0]", req.Filename)
+	if err != nil {
+		return "", fmt.Errorf("build dropbox sign request: %w", err)
+	}
+	if _, err := part.Write(req.Document); err != nil {
+		return "", fmt.Errorf("build dropbox sign request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("build dropbox sign request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/signature_request/send", &body)
+	if err != nil {
+		return "", fmt.Errorf("build dropbox sign request: %w", err)
+	}
+	httpReq.SetBasicAuth(c.apiKey, "")
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+
+	var result struct {
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := c.do(httpReq, &result); err != nil {
+		return "", fmt.Errorf("send dropbox sign request: %w", err)
+	}
+
+	c.log.Infow("Dropbox Sign request sent",
+		"reference", req.Reference,
+		"signature_request_id", result.SignatureRequest.SignatureRequestID,
+	)
+	return result.SignatureRequest.SignatureRequestID, nil
+}
+
+// DownloadSigned returns the signed PDF with its audit trail.
+func (c *DropboxSignClient) DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/signature_request/files/"+envelopeID+"?file_type=pdf", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build dropbox sign download request: %w", err)
+	}
+	req.SetBasicAuth(c.apiKey, "")
+	return downloadPDF(c.httpClient, req)
+}
+
+// Void cancels a signature request that is still out for signature.
+func (c *DropboxSignClient) Void(ctx context.Context, envelopeID, reason string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/signature_request/cancel/"+envelopeID, nil)
+	if err != nil {
+		return fmt.Errorf("build dropbox sign cancel request: %w", err)
+	}
+	req.SetBasicAuth(c.apiKey, "")
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("cancel dropbox sign request: %w", err)
+	}
+	return nil
+}
+
+// ParseCallback decodes an event callback, posted as multipart form data
+// with a "json" field, and verifies its event hash.
+func (c *DropboxSignClient) ParseCallback(header http.Header, body []byte) (*service.SignatureEvent, error) {
+	raw := body
+	if mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			return nil, fmt.Errorf("read dropbox sign callback: %w", err)
+		}
+		defer form.RemoveAll()
+		if len(form.Value["json"]) == 0 {
+			return nil, fmt.Errorf("dropbox sign callback has no json field")
+		}
+		raw = []byte(form.Value["json"][0])
+	}
+
+	var payload struct {
+		Event struct {
+			EventTime string `json:"event_time"`
+			EventType string `json:"event_type"`
+			EventHash string `json:"event_hash"`
+		} `json:"event"`
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("decode dropbox sign callback: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.apiKey))
+	mac.Write([]byte(payload.Event.EventTime + payload.Event.EventType))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(payload.Event.EventHash)) {
+		return nil, fmt.Errorf("dropbox sign callback hash mismatch")
+	}
+
+	event := &service.SignatureEvent{EnvelopeID: payload.SignatureRequest.SignatureRequestID}
+	if secs, err := strconv.ParseInt(payload.Event.EventTime, 10, 64); err == nil {
+		event.OccurredAt = time.Unix(secs, 0)
+	}
+	switch payload.Event.EventType {
+	case "signature_request_all_signed":
+		event.Status = domain.RateConfirmationSigned
+	case "signature_request_declined":
+		event.Status = domain.RateConfirmationDeclined
+		event.Reason = "declined by signer"
+	case "signature_request_canceled":
+		event.Status = domain.RateConfirmationVoided
+		event.Reason = "canceled in Dropbox Sign"
+	}
+	return event, nil
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *DropboxSignClient) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				ErrorMsg  string `json:"error_msg"`
+				ErrorName string `json:"error_name"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("status %d: %s %s", resp.StatusCode, apiErr.Error.ErrorName, apiErr.Error.ErrorMsg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// downloadPDF fetches a signed document
+func downloadPDF(httpClient *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download signed document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("download signed document: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignedDocumentBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read signed document: %w", err)
+	}
+	return data, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
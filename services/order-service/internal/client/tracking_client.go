@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// TrackingClientConfig holds configuration for tracking-service's HTTP API
+type TrackingClientConfig struct {
+	BaseURL string
+	Timeout time.Duration
+}
+
+// TrackingClient reads live locations and milestones from tracking-service.
+// It implements service.LocationTracker.
+type TrackingClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewTrackingClient creates a new tracking-service client.
+func NewTrackingClient(cfg TrackingClientConfig) *TrackingClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &TrackingClient{
+		baseURL:    cfg.BaseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetDriverLocation returns the driver's last reported position, or nil when
+// the driver has never reported one.
+func (c *TrackingClient) GetDriverLocation(ctx context.Context, driverID uuid.UUID) (*domain.LiveLocation, error) {
+	var location domain.LiveLocation
+	found, err := c.get(ctx, "/v1/drivers/"+driverID.String()+"/location", &location)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetTripMilestones returns the milestones recorded for a trip.
+func (c *TrackingClient) GetTripMilestones(ctx context.Context, tripID uuid.UUID) ([]domain.TripMilestone, error) {
+	var milestones []domain.TripMilestone
+	if _, err := c.get(ctx, "/v1/trips/"+tripID.String()+"/milestones", &milestones); err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// get decodes a JSON response into v and reports false on 404
+func (c *TrackingClient) get(ctx context.Context, path string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return false, fmt.Errorf("build tracking request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("tracking request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("tracking request %s: status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("decode tracking response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// VGMGatewayConfig holds configuration for the VGM filing gateway that
+// forwards declarations to steamship lines and terminals.
+type VGMGatewayConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// VGMGatewayClient submits VGM declarations over HTTP. It implements
+// service.VGMSubmitter.
+type VGMGatewayClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	log        *logger.Logger
+}
+
+// NewVGMGatewayClient creates a new VGM gateway client.
+func NewVGMGatewayClient(cfg VGMGatewayConfig, log *logger.Logger) *VGMGatewayClient {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return &VGMGatewayClient{
+		baseURL:    cfg.BaseURL,
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: timeout},
+		log:        log,
+	}
+}
+
+type vgmRequest struct {
+	BookingNumber       string    `json:"bookingNumber"`
+	ContainerNumber     string    `json:"containerNumber"`
+	CarrierID           string    `json:"carrierId"`
+	TerminalID          string    `json:"terminalId"`
+	WeighingMethod      string    `json:"weighingMethod"`
+	VerifiedGrossMass   float64   `json:"verifiedGrossMass"`
+	MassUnit            string    `json:"massUnit"`
+	WeighedAt           time.Time `json:"weighedAt"`
+	AuthorizedSignatory string    `json:"authorizedSignatory"`
+}
+
+type vgmResponse struct {
+	Success   bool   `json:"success"`
+	Reference string `json:"reference"`
+	Message   string `json:"message"`
+}
+
+// SubmitVGM files a VGM declaration and returns the gateway's reference.
+func (c *VGMGatewayClient) SubmitVGM(ctx context.Context, submission service.VGMSubmission) (string, error) {
+	body, err := json.Marshal(vgmRequest{
+		BookingNumber:       submission.BookingNumber,
+		ContainerNumber:     submission.ContainerNumber,
+		CarrierID:           submission.SteamshipLineID.String(),
+		TerminalID:          submission.TerminalID.String(),
+		WeighingMethod:      string(submission.Method),
+		VerifiedGrossMass:   submission.GrossMassKg,
+		MassUnit:            "KGM",
+		WeighedAt:           submission.WeighedAt,
+		AuthorizedSignatory: submission.AuthorizedSignatory,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal vgm request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/vgm/declarations", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build vgm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("submit vgm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result vgmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode vgm response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 || !result.Success {
+		return "", fmt.Errorf("vgm rejected (status %d): %s", resp.StatusCode, result.Message)
+	}
+
+	c.log.Infow("VGM filed with gateway",
+		"container", submission.ContainerNumber,
+		"booking", submission.BookingNumber,
+		"reference", result.Reference,
+	)
+
+	return result.Reference, nil
+}
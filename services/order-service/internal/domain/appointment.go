@@ -66,7 +66,6 @@ type TerminalAppointment struct {
 
 	// Associations
 	Order     *Order    `json:"order,omitempty"`
-	Trip      *Trip     `json:"trip,omitempty"`
 	Container *Container `json:"container,omitempty"`
 }
 
@@ -132,3 +131,36 @@ func (a *TerminalAppointment) TimeUntilAppointment() int {
 	duration := time.Until(a.WindowStartTime)
 	return int(duration.Minutes())
 }
+
+// GateOpenAt checks a terminal's gate hours at t. A special_date row for
+// that day overrides the weekly row for its weekday; a terminal with hours
+// configured but none for that day is closed. ok is false when no hours are
+// configured at all, leaving the caller to apply a default.
+func GateOpenAt(hours []TerminalGateHours, t time.Time) (open bool, ok bool) {
+	if len(hours) == 0 {
+		return false, false
+	}
+
+	var day *TerminalGateHours
+	y, m, d := t.Date()
+	for i := range hours {
+		h := &hours[i]
+		if h.SpecialDate != nil {
+			sy, sm, sd := h.SpecialDate.Date()
+			if sy == y && sm == m && sd == d {
+				day = h
+				break
+			}
+			continue
+		}
+		if h.DayOfWeek == int(t.Weekday()) && day == nil {
+			day = h
+		}
+	}
+	if day == nil || day.IsClosed || day.IsHoliday {
+		return false, true
+	}
+
+	hhmm := t.Format("15:04")
+	return hhmm >= day.OpenTime && hhmm < day.CloseTime, true
+}
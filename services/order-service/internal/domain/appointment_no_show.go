@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoShowFault is the party a missed terminal appointment is attributed to
+type NoShowFault string
+
+const (
+	NoShowFaultUndetermined NoShowFault = "UNDETERMINED"
+	NoShowFaultDriver       NoShowFault = "DRIVER"   // Driver was dispatched but did not make the window
+	NoShowFaultCustomer     NoShowFault = "CUSTOMER" // Freight was on hold or the customer held the move
+	NoShowFaultDispatch     NoShowFault = "DISPATCH" // Nobody was assigned to the appointment in time
+	NoShowFaultTerminal     NoShowFault = "TERMINAL" // Gate outage or closure; the fee should be disputed
+)
+
+// IsValid checks if the fault party is known
+func (f NoShowFault) IsValid() bool {
+	switch f {
+	case NoShowFaultUndetermined, NoShowFaultDriver, NoShowFaultCustomer, NoShowFaultDispatch, NoShowFaultTerminal:
+		return true
+	}
+	return false
+}
+
+// NoShowBillingStatus tracks whether a terminal's no-show fee was passed
+// through to the customer
+type NoShowBillingStatus string
+
+const (
+	NoShowBillingNotBilled NoShowBillingStatus = "NOT_BILLED"
+	NoShowBillingBilled    NoShowBillingStatus = "BILLED"
+	NoShowBillingWaived    NoShowBillingStatus = "WAIVED"
+)
+
+// AppointmentNoShow is a terminal appointment whose window passed with no
+// gate transaction or geofence arrival. Fault starts as the detector's
+// suggestion; AttributedBy is set once ops confirm or override it.
+type AppointmentNoShow struct {
+	ID              uuid.UUID           `json:"id" db:"id"`
+	AppointmentID   uuid.UUID           `json:"appointment_id" db:"appointment_id"`
+	OrderID         uuid.UUID           `json:"order_id" db:"order_id"`
+	OrderNumber     string              `json:"order_number" db:"order_number"`
+	CustomerID      *uuid.UUID          `json:"customer_id,omitempty" db:"customer_id"`
+	TerminalID      uuid.UUID           `json:"terminal_id" db:"terminal_id"`
+	TerminalName    string              `json:"terminal_name,omitempty" db:"-"`
+	ContainerNumber string              `json:"container_number,omitempty" db:"container_number"`
+	DriverID        *uuid.UUID          `json:"driver_id,omitempty" db:"driver_id"`
+	TripID          *uuid.UUID          `json:"trip_id,omitempty" db:"trip_id"`
+	WindowStartTime time.Time           `json:"window_start_time" db:"window_start_time"`
+	WindowEndTime   time.Time           `json:"window_end_time" db:"window_end_time"`
+	DetectedAt      time.Time           `json:"detected_at" db:"detected_at"`
+	SuggestedFault  NoShowFault         `json:"suggested_fault" db:"suggested_fault"`
+	SuggestedReason string              `json:"suggested_reason" db:"suggested_reason"`
+	Fault           NoShowFault         `json:"fault" db:"fault"`
+	FaultNotes      string              `json:"fault_notes,omitempty" db:"fault_notes"`
+	AttributedBy    string              `json:"attributed_by,omitempty" db:"attributed_by"`
+	AttributedAt    *time.Time          `json:"attributed_at,omitempty" db:"attributed_at"`
+	FeeAmount       float64             `json:"fee_amount" db:"fee_amount"`
+	FeeReference    string              `json:"fee_reference,omitempty" db:"fee_reference"` // Terminal invoice or assessment number
+	FeeAssessedAt   *time.Time          `json:"fee_assessed_at,omitempty" db:"fee_assessed_at"`
+	FeeRecordedBy   string              `json:"fee_recorded_by,omitempty" db:"fee_recorded_by"`
+	BillingStatus   NoShowBillingStatus `json:"billing_status" db:"billing_status"`
+	BilledAmount    float64             `json:"billed_amount" db:"billed_amount"`
+	BilledBy        string              `json:"billed_by,omitempty" db:"billed_by"`
+	BilledAt        *time.Time          `json:"billed_at,omitempty" db:"billed_at"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// IsAttributed reports whether ops have confirmed who was at fault
+func (n *AppointmentNoShow) IsAttributed() bool {
+	return n.AttributedBy != ""
+}
+
+// HasFee reports whether the terminal assessed a fee for the no-show
+func (n *AppointmentNoShow) HasFee() bool {
+	return n.FeeAssessedAt != nil
+}
+
+// SuggestNoShowFault proposes who is at fault for a missed appointment from
+// what the order knew at the time. A held order or a container not released
+// for pickup points at the customer; an appointment nobody was assigned to
+// points at dispatch; otherwise the assigned driver did not make it.
+func SuggestNoShowFault(appointment *TerminalAppointment, order *Order, container *Container) (NoShowFault, string) {
+	if order != nil && order.Status == OrderStatusHold {
+		return NoShowFaultCustomer, "order was on hold at the appointment window"
+	}
+	if container != nil && appointment.Type == AppointmentTypePickup {
+		if container.CustomsStatus != CustomsStatusReleased {
+			return NoShowFaultCustomer, "container was not released by customs"
+		}
+		if container.TerminalHold {
+			return NoShowFaultCustomer, "container had a terminal hold"
+		}
+	}
+	if appointment.DriverID == nil && appointment.TripID == nil {
+		return NoShowFaultDispatch, "no driver or trip was assigned to the appointment"
+	}
+	if appointment.DriverID == nil {
+		return NoShowFaultDispatch, "trip had no driver assigned"
+	}
+	return NoShowFaultDriver, "driver was assigned but never reached the gate"
+}
+
+// NoShowFaultSummary totals no-shows attributed to one party
+type NoShowFaultSummary struct {
+	Fault       NoShowFault `json:"fault"`
+	Count       int         `json:"count"`
+	Unconfirmed int         `json:"unconfirmed"` // Still on the detector's suggestion
+	FeeTotal    float64     `json:"fee_total"`
+	BilledTotal float64     `json:"billed_total"`
+}
+
+// NoShowTerminalSummary totals no-shows at one terminal
+type NoShowTerminalSummary struct {
+	TerminalID   uuid.UUID `json:"terminal_id"`
+	TerminalName string    `json:"terminal_name,omitempty"`
+	Count        int       `json:"count"`
+	FeeTotal     float64   `json:"fee_total"`
+}
+
+// NoShowAnalysis breaks down the no-shows detected in a period by who was
+// at fault and where, with how much of the terminals' fees was recovered
+type NoShowAnalysis struct {
+	From        time.Time               `json:"from"`
+	To          time.Time               `json:"to"`
+	Total       int                     `json:"total"`
+	FeeTotal    float64                 `json:"fee_total"`
+	BilledTotal float64                 `json:"billed_total"`
+	Absorbed    float64                 `json:"absorbed"` // Fees not passed through to a customer
+	ByFault     []NoShowFaultSummary    `json:"by_fault"`
+	ByTerminal  []NoShowTerminalSummary `json:"by_terminal"`
+}
+
+// AnalyzeNoShows totals no-shows by fault party and terminal. Fault parties
+// come out in a fixed order and terminals by count, highest first.
+func AnalyzeNoShows(noShows []AppointmentNoShow, from, to time.Time) *NoShowAnalysis {
+	analysis := &NoShowAnalysis{From: from, To: to}
+	faults := []NoShowFault{NoShowFaultDriver, NoShowFaultCustomer, NoShowFaultDispatch, NoShowFaultTerminal, NoShowFaultUndetermined}
+	byFault := make(map[NoShowFault]*NoShowFaultSummary, len(faults))
+	for _, f := range faults {
+		byFault[f] = &NoShowFaultSummary{Fault: f}
+	}
+	byTerminal := make(map[uuid.UUID]*NoShowTerminalSummary)
+	var terminals []uuid.UUID
+
+	for _, n := range noShows {
+		analysis.Total++
+		analysis.FeeTotal += n.FeeAmount
+		analysis.BilledTotal += n.BilledAmount
+
+		fault, ok := byFault[n.Fault]
+		if !ok {
+			fault = byFault[NoShowFaultUndetermined]
+		}
+		fault.Count++
+		if !n.IsAttributed() {
+			fault.Unconfirmed++
+		}
+		fault.FeeTotal += n.FeeAmount
+		fault.BilledTotal += n.BilledAmount
+
+		terminal, ok := byTerminal[n.TerminalID]
+		if !ok {
+			terminal = &NoShowTerminalSummary{TerminalID: n.TerminalID, TerminalName: n.TerminalName}
+			byTerminal[n.TerminalID] = terminal
+			terminals = append(terminals, n.TerminalID)
+		}
+		terminal.Count++
+		terminal.FeeTotal += n.FeeAmount
+	}
+
+	analysis.Absorbed = analysis.FeeTotal - analysis.BilledTotal
+	if analysis.Absorbed < 0 {
+		analysis.Absorbed = 0
+	}
+	for _, f := range faults {
+		analysis.ByFault = append(analysis.ByFault, *byFault[f])
+	}
+	for _, id := range terminals {
+		analysis.ByTerminal = append(analysis.ByTerminal, *byTerminal[id])
+	}
+	sort.SliceStable(analysis.ByTerminal, func(i, j int) bool {
+		return analysis.ByTerminal[i].Count > analysis.ByTerminal[j].Count
+	})
+	return analysis
+}
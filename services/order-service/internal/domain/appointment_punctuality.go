@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlotPunctuality summarizes arrivals against appointment windows for one
+// recurring weekday/hour slot at a terminal
+type SlotPunctuality struct {
+	DayOfWeek            time.Weekday `json:"day_of_week"`
+	Hour                 int          `json:"hour"` // Window start hour in the terminal's timezone
+	Appointments         int          `json:"appointments"`
+	Arrived              int          `json:"arrived"`
+	OnTime               int          `json:"on_time"`
+	Early                int          `json:"early"`
+	Late                 int          `json:"late"`
+	Missed               int          `json:"missed"`
+	OnTimeRate           float64      `json:"on_time_rate"`
+	LateRate             float64      `json:"late_rate"`               // Late and missed share of all appointments
+	AvgArrivalOffsetMins float64      `json:"avg_arrival_offset_mins"` // Positive when drivers arrive after the window opens
+	P90ArrivalOffsetMins float64      `json:"p90_arrival_offset_mins"`
+	AvgTurnMins          float64      `json:"avg_turn_mins"` // Arrival to gate completion
+	Congested            bool         `json:"congested"`
+	CongestionReason     string       `json:"congestion_reason,omitempty"`
+}
+
+// TerminalPunctuality is the punctuality analysis for one terminal over a period
+type TerminalPunctuality struct {
+	TerminalID              uuid.UUID         `json:"terminal_id"`
+	PeriodStart             time.Time         `json:"period_start"`
+	PeriodEnd               time.Time         `json:"period_end"`
+	Timezone                string            `json:"timezone"`
+	Appointments            int               `json:"appointments"`
+	OnTimeRate              float64           `json:"on_time_rate"`
+	AvgArrivalOffsetMins    float64           `json:"avg_arrival_offset_mins"`
+	MedianArrivalOffsetMins float64           `json:"median_arrival_offset_mins"`
+	AvgTurnMins             float64           `json:"avg_turn_mins"`
+	Slots                   []SlotPunctuality `json:"slots"`
+}
+
+// CongestedSlots returns the slots flagged as chronically congested
+func (p *TerminalPunctuality) CongestedSlots() []SlotPunctuality {
+	var congested []SlotPunctuality
+	for _, slot := range p.Slots {
+		if slot.Congested {
+			congested = append(congested, slot)
+		}
+	}
+	return congested
+}
+
+// AppointmentWindow is a recurring weekday/hour appointment slot
+type AppointmentWindow struct {
+	DayOfWeek time.Weekday `json:"day_of_week"`
+	Hour      int          `json:"hour"`
+	Reason    string       `json:"reason,omitempty"`
+}
+
+// Matches reports whether t falls in the window; t must already be in the terminal timezone
+func (w AppointmentWindow) Matches(t time.Time) bool {
+	return t.Weekday() == w.DayOfWeek && t.Hour() == w.Hour
+}
+
+// AppointmentBookingPreference holds the slot recommendations appointment
+// auto-booking uses for a terminal, derived from punctuality history
+type AppointmentBookingPreference struct {
+	TerminalID        uuid.UUID           `json:"terminal_id" db:"terminal_id"`
+	Timezone          string              `json:"timezone" db:"timezone"`
+	PreferredWindows  []AppointmentWindow `json:"preferred_windows" db:"preferred_windows"`
+	AvoidWindows      []AppointmentWindow `json:"avoid_windows" db:"avoid_windows"`
+	ArrivalBufferMins int                 `json:"arrival_buffer_mins" db:"arrival_buffer_mins"` // Plan arrival this far ahead of the window
+	SampleSize        int                 `json:"sample_size" db:"sample_size"`
+	PeriodStart       time.Time           `json:"period_start" db:"period_start"`
+	PeriodEnd         time.Time           `json:"period_end" db:"period_end"`
+	UpdatedAt         time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// location returns the preference's timezone, falling back to UTC
+func (p *AppointmentBookingPreference) location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Avoids returns the congested window t falls in, or nil
+func (p *AppointmentBookingPreference) Avoids(t time.Time) *AppointmentWindow {
+	local := t.In(p.location())
+	for i := range p.AvoidWindows {
+		if p.AvoidWindows[i].Matches(local) {
+			return &p.AvoidWindows[i]
+		}
+	}
+	return nil
+}
+
+// Prefers reports whether t falls in one of the recommended windows
+func (p *AppointmentBookingPreference) Prefers(t time.Time) bool {
+	local := t.In(p.location())
+	for _, w := range p.PreferredWindows {
+		if w.Matches(local) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGateOpenAt(t *testing.T) {
+	holiday := time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC) // Friday
+	weekly := []TerminalGateHours{
+		{DayOfWeek: int(time.Friday), OpenTime: "07:00", CloseTime: "17:00"},
+		{DayOfWeek: int(time.Saturday), OpenTime: "08:00", CloseTime: "12:00"},
+		{SpecialDate: &holiday, IsHoliday: true, OpenTime: "00:00", CloseTime: "00:00"},
+	}
+
+	tests := []struct {
+		name     string
+		hours    []TerminalGateHours
+		at       time.Time
+		wantOpen bool
+		wantOK   bool
+	}{
+		{"no hours configured", nil, time.Date(2026, 7, 10, 9, 0, 0, 0, time.UTC), false, false},
+		{"inside weekday hours", weekly, time.Date(2026, 7, 10, 9, 0, 0, 0, time.UTC), true, true},
+		{"at opening time", weekly, time.Date(2026, 7, 10, 7, 0, 0, 0, time.UTC), true, true},
+		{"at closing time", weekly, time.Date(2026, 7, 10, 17, 0, 0, 0, time.UTC), false, true},
+		{"saturday half day", weekly, time.Date(2026, 7, 11, 11, 59, 0, 0, time.UTC), true, true},
+		{"day without a row", weekly, time.Date(2026, 7, 12, 9, 0, 0, 0, time.UTC), false, true},
+		{"special date overrides weekday", weekly, time.Date(2026, 7, 3, 9, 0, 0, 0, time.UTC), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, ok := GateOpenAt(tt.hours, tt.at)
+			if open != tt.wantOpen || ok != tt.wantOK {
+				t.Errorf("GateOpenAt() = %v, %v; want %v, %v", open, ok, tt.wantOpen, tt.wantOK)
+			}
+		})
+	}
+}
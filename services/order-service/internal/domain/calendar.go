@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClosureType represents the kind of calendar closure
+type ClosureType string
+
+const (
+	ClosureTypeTerminal       ClosureType = "TERMINAL_CLOSURE"
+	ClosureTypePort           ClosureType = "PORT_CLOSURE"
+	ClosureTypeCompanyHoliday ClosureType = "COMPANY_HOLIDAY"
+)
+
+// CalendarClosure represents a day on which a terminal, the port, or the
+// company is closed
+type CalendarClosure struct {
+	ID              uuid.UUID   `json:"id" db:"id"`
+	Type            ClosureType `json:"type" db:"type"`
+	TerminalID      *uuid.UUID  `json:"terminal_id,omitempty" db:"terminal_id"` // nil applies to every terminal
+	ClosureDate     time.Time   `json:"closure_date" db:"closure_date"`
+	Name            string      `json:"name" db:"name"`
+	Reason          string      `json:"reason,omitempty" db:"reason"`
+	ExtendsFreeTime bool        `json:"extends_free_time" db:"extends_free_time"` // Skipped when counting LFD/demurrage days
+	CreatedBy       string      `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// AppliesToTerminal checks if the closure affects gate operations at a terminal
+func (c *CalendarClosure) AppliesToTerminal(terminalID uuid.UUID) bool {
+	if c.Type == ClosureTypeCompanyHoliday {
+		return false
+	}
+	return c.TerminalID == nil || *c.TerminalID == terminalID
+}
+
+// CoversDate checks if the closure falls on the same calendar day as t
+func (c *CalendarClosure) CoversDate(t time.Time) bool {
+	y, m, d := t.Date()
+	cy, cm, cd := c.ClosureDate.Date()
+	return y == cy && m == cm && d == cd
+}
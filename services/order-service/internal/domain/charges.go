@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChargeType is the kind of storage charge accrued against a container
+type ChargeType string
+
+const (
+	ChargeTypePerDiem   ChargeType = "PER_DIEM"  // Equipment held outside the terminal
+	ChargeTypeDemurrage ChargeType = "DEMURRAGE" // Steamship line storage at the terminal
+)
+
+// TierCharge represents charges for a specific tier
+type TierCharge struct {
+	TierName   string  `json:"tier_name"`
+	Days       int     `json:"days"`
+	RatePerDay float64 `json:"rate_per_day"`
+	Amount     float64 `json:"amount"`
+}
+
+// ContainerCharge is a container's per-diem or demurrage as of the last
+// nightly accrual. It stops changing once the charge no longer accrues:
+// demurrage when the container leaves the terminal, per diem when it
+// comes back.
+type ContainerCharge struct {
+	ID              uuid.UUID    `json:"id" db:"id"`
+	ContainerID     uuid.UUID    `json:"container_id" db:"container_id"`
+	ContainerNumber string       `json:"container_number" db:"container_number"`
+	ShipmentID      uuid.UUID    `json:"shipment_id" db:"shipment_id"`
+	CustomerID      uuid.UUID    `json:"customer_id" db:"customer_id"`
+	TerminalID      uuid.UUID    `json:"terminal_id" db:"terminal_id"`
+	ChargeType      ChargeType   `json:"charge_type" db:"charge_type"`
+	LastFreeDay     time.Time    `json:"last_free_day" db:"last_free_day"` // After terminal closures for demurrage
+	StartDate       *time.Time   `json:"start_date,omitempty" db:"start_date"`
+	Days            int          `json:"days" db:"days"`
+	Amount          float64      `json:"amount" db:"amount"`
+	Breakdown       []TierCharge `json:"breakdown" db:"breakdown"`
+	AccruedThrough  time.Time    `json:"accrued_through" db:"accrued_through"` // Night of the last accrual
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+// ContainerVerificationStatus is the outcome of checking a keyed container
+// against the terminal's record in eModal
+type ContainerVerificationStatus string
+
+const (
+	ContainerVerificationPending     ContainerVerificationStatus = "PENDING"
+	ContainerVerificationVerified    ContainerVerificationStatus = "VERIFIED"
+	ContainerVerificationMismatch    ContainerVerificationStatus = "MISMATCH"    // Found, but the terminal disagrees with the order
+	ContainerVerificationNotFound    ContainerVerificationStatus = "NOT_FOUND"   // No terminal has a record of the container
+	ContainerVerificationUnavailable ContainerVerificationStatus = "UNAVAILABLE" // eModal lookup failed or timed out
+)
+
+// ContainerMismatch is a field where the terminal's record disagrees with the order
+type ContainerMismatch struct {
+	Field    string `json:"field"` // size, type, vessel_name or voyage_number
+	Expected string `json:"expected"`
+	Reported string `json:"reported"`
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FacilityWindow is a weekly time range at a shipper or consignee facility,
+// in the facility's timezone
+type FacilityWindow struct {
+	DayOfWeek time.Weekday `json:"day_of_week"`
+	Open      string       `json:"open"`  // HH:MM
+	Close     string       `json:"close"` // HH:MM
+}
+
+// Valid checks that the window is a real day and a forward HH:MM range
+func (w FacilityWindow) Valid() bool {
+	open, err := clockMinutes(w.Open)
+	if err != nil {
+		return false
+	}
+	closeAt, err := clockMinutes(w.Close)
+	if err != nil {
+		return false
+	}
+	return w.DayOfWeek >= time.Sunday && w.DayOfWeek <= time.Saturday && open < closeAt
+}
+
+// Contains reports whether t falls in the window; t must already be in the facility timezone
+func (w FacilityWindow) Contains(t time.Time) bool {
+	if t.Weekday() != w.DayOfWeek {
+		return false
+	}
+	open, err := clockMinutes(w.Open)
+	if err != nil {
+		return false
+	}
+	closeAt, err := clockMinutes(w.Close)
+	if err != nil {
+		return false
+	}
+	mins := t.Hour()*60 + t.Minute()
+	return mins >= open && mins < closeAt
+}
+
+// FacilityProfile is what drivers and planners need to know about a shipper
+// or consignee location: when it receives, how many doors it has, and what
+// a driver must wear and do to get checked in
+type FacilityProfile struct {
+	LocationID       uuid.UUID        `json:"location_id" db:"location_id"`
+	Timezone         string           `json:"timezone" db:"timezone"`
+	Hours            []FacilityWindow `json:"hours" db:"hours"`                   // Receiving hours; days not listed are closed
+	LunchClosures    []FacilityWindow `json:"lunch_closures" db:"lunch_closures"` // No receiving inside these
+	DockCount        int              `json:"dock_count" db:"dock_count"`
+	RequiredPPE      []string         `json:"required_ppe" db:"required_ppe"` // e.g. hard hat, hi-vis vest, steel toes
+	CheckInProcedure string           `json:"check_in_procedure,omitempty" db:"check_in_procedure"`
+	Notes            string           `json:"notes,omitempty" db:"notes"`
+	UpdatedBy        string           `json:"updated_by" db:"updated_by"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// location returns the profile's timezone, falling back to UTC
+func (p *FacilityProfile) location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ClosedReason explains why the facility cannot receive at t, or returns ""
+// when it can. A profile without hours places no limit. A time of exactly
+// midnight is taken as a date without a time and only needs an open day.
+func (p *FacilityProfile) ClosedReason(t time.Time) string {
+	if len(p.Hours) == 0 {
+		return ""
+	}
+
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		for _, w := range p.Hours {
+			if w.DayOfWeek == t.Weekday() {
+				return ""
+			}
+		}
+		return fmt.Sprintf("facility does not receive on %s", t.Weekday())
+	}
+
+	local := t.In(p.location())
+	for _, w := range p.LunchClosures {
+		if w.Contains(local) {
+			return fmt.Sprintf("facility is closed for lunch %s-%s on %s", w.Open, w.Close, w.DayOfWeek)
+		}
+	}
+	for _, w := range p.Hours {
+		if w.Contains(local) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("facility does not receive at %s on %s", local.Format("15:04"), local.Weekday())
+}
+
+// clockMinutes parses HH:MM into minutes after midnight
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
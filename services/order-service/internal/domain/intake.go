@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailClass is what an email to the order intake mailbox is about
+type EmailClass string
+
+const (
+	EmailClassDeliveryOrder EmailClass = "DELIVERY_ORDER" // Import DO or freight release
+	EmailClassBooking       EmailClass = "BOOKING"        // Export booking confirmation
+	EmailClassOther         EmailClass = "OTHER"          // Not an order; recorded but not drafted
+)
+
+// DraftStatus represents where a shipment draft from email is in review
+type DraftStatus string
+
+const (
+	DraftStatusPendingReview DraftStatus = "PENDING_REVIEW"
+	DraftStatusConfirmed     DraftStatus = "CONFIRMED" // Shipment created
+	DraftStatusRejected      DraftStatus = "REJECTED"
+)
+
+// AttachmentParseStatus is how far an email attachment got through DO parsing
+type AttachmentParseStatus string
+
+const (
+	AttachmentParseQueued      AttachmentParseStatus = "QUEUED"
+	AttachmentParseParsed      AttachmentParseStatus = "PARSED"
+	AttachmentParseUnsupported AttachmentParseStatus = "UNSUPPORTED" // Scanned image or format without a text layer
+	AttachmentParseFailed      AttachmentParseStatus = "FAILED"
+)
+
+// IntakeAttachment is a file attached to an intake email, kept in blob storage
+type IntakeAttachment struct {
+	Filename    string                `json:"filename"`
+	ContentType string                `json:"content_type"`
+	Size        int64                 `json:"size"`
+	StorageKey  string                `json:"storage_key"`
+	ParseStatus AttachmentParseStatus `json:"parse_status"`
+}
+
+// IntakeEmail is an email read from the order intake mailbox
+type IntakeEmail struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	Mailbox     string             `json:"mailbox" db:"mailbox"`
+	MessageID   string             `json:"message_id" db:"message_id"` // Internet Message-ID, unique per mailbox
+	ThreadID    string             `json:"thread_id" db:"thread_id"`   // Provider conversation the email belongs to
+	FromAddress string             `json:"from_address" db:"from_address"`
+	Subject     string             `json:"subject" db:"subject"`
+	Body        string             `json:"body" db:"body"` // Plain text
+	ReceivedAt  time.Time          `json:"received_at" db:"received_at"`
+	Class       EmailClass         `json:"class" db:"class"`
+	Attachments []IntakeAttachment `json:"attachments" db:"attachments"`
+	DraftID     *uuid.UUID         `json:"draft_id,omitempty" db:"draft_id"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+}
+
+// IntakeSender maps an email address, or a whole domain written as
+// "@example.com", to the customer whose orders it sends
+type IntakeSender struct {
+	Address    string    `json:"address" db:"address"`
+	CustomerID uuid.UUID `json:"customer_id" db:"customer_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// ShipmentDraft is a shipment read from an email thread, waiting for a
+// clerk to confirm it. Later emails and parsed attachments in the thread
+// fill in fields that are still blank; they never overwrite them.
+type ShipmentDraft struct {
+	ID                uuid.UUID     `json:"id" db:"id"`
+	Mailbox           string        `json:"mailbox" db:"mailbox"`
+	ThreadID          string        `json:"thread_id" db:"thread_id"`
+	Subject           string        `json:"subject" db:"subject"`
+	FromAddress       string        `json:"from_address" db:"from_address"`
+	Type              ShipmentType  `json:"type" db:"type"`
+	CustomerID        *uuid.UUID    `json:"customer_id,omitempty" db:"customer_id"` // From the sender mapping
+	ReferenceNumber   string        `json:"reference_number,omitempty" db:"reference_number"`
+	SteamshipLineCode string        `json:"steamship_line_code,omitempty" db:"steamship_line_code"`
+	VesselName        string        `json:"vessel_name,omitempty" db:"vessel_name"`
+	VoyageNumber      string        `json:"voyage_number,omitempty" db:"voyage_number"`
+	LastFreeDay       *time.Time    `json:"last_free_day,omitempty" db:"last_free_day"`
+	ContainerNumbers  []string      `json:"container_numbers" db:"container_numbers"`
+	ContainerSize     ContainerSize `json:"container_size,omitempty" db:"container_size"`
+	ContainerType     ContainerType `json:"container_type,omitempty" db:"container_type"`
+	Status            DraftStatus   `json:"status" db:"status"`
+	ShipmentID        *uuid.UUID    `json:"shipment_id,omitempty" db:"shipment_id"`
+	ReviewedBy        string        `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt        *time.Time    `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	RejectReason      string        `json:"reject_reason,omitempty" db:"reject_reason"`
+	CreatedAt         time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at" db:"updated_at"`
+
+	Emails []IntakeEmail `json:"emails,omitempty"`
+}
+
+// Apply fills the draft's blank fields from what was extracted from an email
+// or attachment, and adds any containers it does not list yet. It reports
+// whether anything changed.
+func (d *ShipmentDraft) Apply(e DraftExtraction) bool {
+	changed := false
+	fill := func(field *string, value string) {
+		if *field == "" && value != "" {
+			*field = value
+			changed = true
+		}
+	}
+	fill(&d.ReferenceNumber, e.ReferenceNumber)
+	fill(&d.SteamshipLineCode, e.SteamshipLineCode)
+	fill(&d.VesselName, e.VesselName)
+	fill(&d.VoyageNumber, e.VoyageNumber)
+	if d.LastFreeDay == nil && e.LastFreeDay != nil {
+		d.LastFreeDay = e.LastFreeDay
+		changed = true
+	}
+	if d.ContainerSize == "" && e.ContainerSize != "" {
+		d.ContainerSize = e.ContainerSize
+		changed = true
+	}
+	if d.ContainerType == "" && e.ContainerType != "" {
+		d.ContainerType = e.ContainerType
+		changed = true
+	}
+	for _, number := range e.ContainerNumbers {
+		if !containsString(d.ContainerNumbers, number) {
+			d.ContainerNumbers = append(d.ContainerNumbers, number)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// DraftExtraction is what could be read from an email or attachment
+type DraftExtraction struct {
+	ReferenceNumber   string        `json:"reference_number,omitempty"` // Bill of lading or booking number
+	SteamshipLineCode string        `json:"steamship_line_code,omitempty"`
+	VesselName        string        `json:"vessel_name,omitempty"`
+	VoyageNumber      string        `json:"voyage_number,omitempty"`
+	LastFreeDay       *time.Time    `json:"last_free_day,omitempty"`
+	ContainerNumbers  []string      `json:"container_numbers,omitempty"` // ISO 6346 format, check digit not yet verified
+	ContainerSize     ContainerSize `json:"container_size,omitempty"`
+	ContainerType     ContainerType `json:"container_type,omitempty"`
+}
+
+var (
+	containerNumberPattern = regexp.MustCompile(`\b([A-Z]{3}[UJZ])\s?(\d{6})\s?-?(\d)\b`)
+	billOfLadingPattern    = regexp.MustCompile(`\b(?:MASTER\s+)?(?:B/L|BOL|MBL|BILL\s+OF\s+LADING)\s*(?:NO\.?|NUMBER|#)?\s*[:#]?\s*([A-Z]{4}[A-Z0-9]{6,16})\b`)
+	bookingPattern         = regexp.MustCompile(`\bBOOKING\s*(?:NO\.?|NUMBER|#|REF(?:ERENCE)?)?\s*[:#]?\s*([A-Z0-9]{6,20})\b`)
+	scacPattern            = regexp.MustCompile(`\bSCAC\s*(?:CODE)?\s*[:#]?\s*([A-Z]{4})\b`)
+	vesselPattern          = regexp.MustCompile(`(?m)\bVESSEL(?:\s+NAME)?\s*[:#]\s*([A-Z0-9][A-Z0-9 .\-]{1,38}?)\s*(?:/|\bVOY(?:AGE)?\b|$)`)
+	voyagePattern          = regexp.MustCompile(`\bVOY(?:AGE)?\s*(?:NO\.?|#)?\s*[:#]?\s*([A-Z0-9]{2,10})\b`)
+	lastFreeDayPattern     = regexp.MustCompile(`\b(?:LFD|LAST\s+FREE\s+(?:DAY|DATE)|FREE\s+TIME\s+EXPIRES)\s*[:#]?\s*(\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{2,4})`)
+	equipmentPattern       = regexp.MustCompile(`\b(20|40|45)\s*(?:'|FT|FOOT)?\s*(HC|HQ|HIGH\s+CUBE|GP|DV|DC|DRY|RF|RH|REEFER|OT|OPEN\s+TOP|FR|FLAT\s+RACK|TK|TANK)\b`)
+
+	deliveryOrderKeywords = []string{"DELIVERY ORDER", "D/O", "DO#", "DO #", "FREIGHT RELEASE", "ARRIVAL NOTICE", "AVAILABLE FOR PICKUP", "BILL OF LADING", "B/L", "IMPORT"}
+	bookingKeywords       = []string{"BOOKING CONFIRMATION", "BOOKING #", "BOOKING NO", "EMPTY RELEASE", "EXPORT", "CUTOFF", "CUT-OFF", "ERD"}
+)
+
+// ClassifyEmail decides from its subject, body and attachment names whether
+// an email is an import delivery order, an export booking or neither. The
+// subject and file names count double; ties go to the delivery order.
+func ClassifyEmail(subject, body string, filenames []string) EmailClass {
+	heading := strings.ToUpper(subject + " " + strings.Join(filenames, " "))
+	text := strings.ToUpper(body)
+	score := func(keywords []string) int {
+		n := 0
+		for _, k := range keywords {
+			if strings.Contains(heading, k) {
+				n += 2
+			}
+			if strings.Contains(text, k) {
+				n++
+			}
+		}
+		return n
+	}
+
+	do, booking := score(deliveryOrderKeywords), score(bookingKeywords)
+	switch {
+	case do == 0 && booking == 0:
+		return EmailClassOther
+	case booking > do:
+		return EmailClassBooking
+	default:
+		return EmailClassDeliveryOrder
+	}
+}
+
+// ExtractDraft reads shipment references out of free text such as an email
+// body or the text layer of a DO
+func ExtractDraft(text string) DraftExtraction {
+	text = strings.ToUpper(text)
+	var e DraftExtraction
+
+	if m := billOfLadingPattern.FindStringSubmatch(text); m != nil {
+		e.ReferenceNumber = m[1]
+		e.SteamshipLineCode = m[1][:4] // Carrier B/Ls start with the line's SCAC
+	} else if m := bookingPattern.FindStringSubmatch(text); m != nil {
+		e.ReferenceNumber = m[1]
+	}
+	if m := scacPattern.FindStringSubmatch(text); m != nil {
+		e.SteamshipLineCode = m[1]
+	}
+	if m := vesselPattern.FindStringSubmatch(text); m != nil {
+		e.VesselName = strings.TrimSpace(m[1])
+	}
+	if m := voyagePattern.FindStringSubmatch(text); m != nil {
+		e.VoyageNumber = m[1]
+	}
+	if m := lastFreeDayPattern.FindStringSubmatch(text); m != nil {
+		e.LastFreeDay = parseIntakeDate(m[1])
+	}
+	if m := equipmentPattern.FindStringSubmatch(text); m != nil {
+		e.ContainerSize = ContainerSize(m[1])
+		e.ContainerType = equipmentType(m[2])
+	}
+	for _, m := range containerNumberPattern.FindAllStringSubmatch(text, -1) {
+		number := m[1] + m[2] + m[3]
+		if !containsString(e.ContainerNumbers, number) {
+			e.ContainerNumbers = append(e.ContainerNumbers, number)
+		}
+	}
+	return e
+}
+
+// equipmentType maps an ISO or spelled-out equipment code to a container type
+func equipmentType(code string) ContainerType {
+	switch strings.Join(strings.Fields(code), " ") {
+	case "HC", "HQ", "HIGH CUBE":
+		return ContainerTypeHighCube
+	case "RF", "RH", "REEFER":
+		return ContainerTypeReefer
+	case "OT", "OPEN TOP":
+		return ContainerTypeOpenTop
+	case "FR", "FLAT RACK":
+		return ContainerTypeFlatRack
+	case "TK", "TANK":
+		return ContainerTypeTank
+	default:
+		return ContainerTypeDry
+	}
+}
+
+// parseIntakeDate parses a US or ISO date as printed on a DO, or returns nil
+func parseIntakeDate(s string) *time.Time {
+	for _, layout := range []string{"2006-01-02", "1/2/2006", "1/2/06"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
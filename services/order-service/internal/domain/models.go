@@ -73,6 +73,12 @@ const (
 	LocationTypeYard      LocationType = "YARD"
 )
 
+// Container mount statuses reported by the terminal
+const (
+	MountStatusGrounded = "GROUNDED"
+	MountStatusWheeled  = "WHEELED"
+)
+
 // OrderType represents the type of order
 type OrderType string
 
@@ -146,12 +152,17 @@ type Shipment struct {
 
 // DaysUntilLFD calculates days until Last Free Day
 func (s *Shipment) DaysUntilLFD() int {
+	return s.DaysUntilLFDAt(time.Now())
+}
+
+// DaysUntilLFDAt calculates days from now until Last Free Day
+func (s *Shipment) DaysUntilLFDAt(now time.Time) int {
 	if s.LastFreeDay == nil {
 		return -1
 	}
-	now := time.Now().Truncate(24 * time.Hour)
+	today := now.Truncate(24 * time.Hour)
 	lfd := s.LastFreeDay.Truncate(24 * time.Hour)
-	return int(lfd.Sub(now).Hours() / 24)
+	return int(lfd.Sub(today).Hours() / 24)
 }
 
 // Container represents a shipping container
@@ -176,13 +187,40 @@ type Container struct {
 	CurrentState          ContainerState `json:"current_state" db:"current_state"`
 	CurrentLocationType   LocationType   `json:"current_location_type" db:"current_location_type"`
 	CurrentLocationID     *uuid.UUID     `json:"current_location_id,omitempty" db:"current_location_id"`
-	CreatedAt             time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt             time.Time      `json:"updated_at" db:"updated_at"`
+	DischargedAt          *time.Time     `json:"discharged_at,omitempty" db:"discharged_at"`
+	IsReady               bool           `json:"is_ready" db:"is_ready"`
+	ReadyAt               *time.Time     `json:"ready_at,omitempty" db:"ready_at"`
+	TerminalHold          bool           `json:"terminal_hold" db:"terminal_hold"`
+	TerminalStatus        string         `json:"terminal_status,omitempty" db:"terminal_status"` // Last eModal status code
+	TerminalStatusAt      *time.Time     `json:"terminal_status_at,omitempty" db:"terminal_status_at"`
+
+	// Where the container sits in the terminal yard, as last reported by eModal
+	MountStatus   string     `json:"mount_status,omitempty" db:"mount_status"` // GROUNDED or WHEELED
+	YardBlock     string     `json:"yard_block,omitempty" db:"yard_block"`
+	YardRow       string     `json:"yard_row,omitempty" db:"yard_row"`
+	YardBay       string     `json:"yard_bay,omitempty" db:"yard_bay"`
+	YardTier      string     `json:"yard_tier,omitempty" db:"yard_tier"`
+	YardUpdatedAt *time.Time `json:"yard_updated_at,omitempty" db:"yard_updated_at"`
+
+	// Check of the keyed container against the terminal's record in eModal
+	VerificationStatus     ContainerVerificationStatus `json:"verification_status" db:"verification_status"`
+	VerificationMismatches []ContainerMismatch         `json:"verification_mismatches,omitempty" db:"verification_mismatches"`
+	VerifiedAt             *time.Time                  `json:"verified_at,omitempty" db:"verified_at"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IsGrounded reports whether the container is stacked in the yard rather
+// than sitting on a chassis
+func (c *Container) IsGrounded() bool {
+	return c.MountStatus == MountStatusGrounded
 }
 
 // IsAvailable checks if container is available for pickup
 func (c *Container) IsAvailable() bool {
 	return c.CustomsStatus == CustomsStatusReleased &&
+		!c.TerminalHold &&
 		c.TerminalAvailableDate != nil &&
 		c.CurrentLocationType == LocationTypeTerminal
 }
@@ -205,6 +243,10 @@ type Order struct {
 	BillingStatus         BillingStatus `json:"billing_status" db:"billing_status"`
 	LinkedOrderID         *uuid.UUID    `json:"linked_order_id,omitempty" db:"linked_order_id"`
 	SpecialInstructions   string        `json:"special_instructions,omitempty" db:"special_instructions"`
+	TenderID              *uuid.UUID    `json:"tender_id,omitempty" db:"tender_id"`     // Broker tender the order was accepted from
+	AgreedRate            *float64      `json:"agreed_rate,omitempty" db:"agreed_rate"` // Linehaul rate agreed with the broker or quoted to the customer
+	Tags                  []string      `json:"tags,omitempty" db:"tags"`               // Free-form labels such as hot or vip
+	Version               int           `json:"version" db:"version"`                   // Optimistic concurrency, bumped on every update
 	CreatedAt             time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time     `json:"updated_at" db:"updated_at"`
 
@@ -247,6 +289,11 @@ type Customer struct {
 	Email     string    `json:"email,omitempty" db:"email"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Credit hold set by accounting; held customers' freight is not moved
+	CreditHold       bool       `json:"credit_hold" db:"credit_hold"`
+	CreditHoldReason string     `json:"credit_hold_reason,omitempty" db:"credit_hold_reason"`
+	CreditHoldAt     *time.Time `json:"credit_hold_at,omitempty" db:"credit_hold_at"`
 }
 
 // SteamshipLine represents a shipping line
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PreArrivalFlag marks something a planner has to sort out before an import
+// container can move
+type PreArrivalFlag string
+
+const (
+	PreArrivalMissingDeliveryLocation PreArrivalFlag = "MISSING_DELIVERY_LOCATION"
+	PreArrivalMissingAppointment      PreArrivalFlag = "MISSING_APPOINTMENT" // No active pickup appointment
+	PreArrivalCreditHold              PreArrivalFlag = "CREDIT_HOLD"         // Customer is on credit hold
+)
+
+// PreArrivalContainer is an import container on an inbound vessel, with its
+// order and what is still missing to move it
+type PreArrivalContainer struct {
+	ContainerID          uuid.UUID         `json:"container_id" db:"container_id"`
+	ContainerNumber      string            `json:"container_number" db:"container_number"`
+	Size                 ContainerSize     `json:"size" db:"size"`
+	Type                 ContainerType     `json:"type" db:"type"`
+	IsHazmat             bool              `json:"is_hazmat" db:"is_hazmat"`
+	IsOverweight         bool              `json:"is_overweight" db:"is_overweight"`
+	CustomsStatus        CustomsStatus     `json:"customs_status" db:"customs_status"`
+	TerminalHold         bool              `json:"terminal_hold" db:"terminal_hold"`
+	CurrentLocationType  LocationType      `json:"current_location_type" db:"current_location_type"`
+	DischargedAt         *time.Time        `json:"discharged_at,omitempty" db:"discharged_at"`
+	ShipmentID           uuid.UUID         `json:"shipment_id" db:"shipment_id"`
+	ShipmentReference    string            `json:"shipment_reference" db:"shipment_reference"`
+	VesselName           string            `json:"vessel_name" db:"vessel_name"`
+	VoyageNumber         string            `json:"voyage_number" db:"voyage_number"`
+	VesselETA            *time.Time        `json:"vessel_eta,omitempty" db:"vessel_eta"`
+	VesselATA            *time.Time        `json:"vessel_ata,omitempty" db:"vessel_ata"`
+	LastFreeDay          *time.Time        `json:"last_free_day,omitempty" db:"last_free_day"`
+	TerminalID           uuid.UUID         `json:"terminal_id" db:"terminal_id"`
+	TerminalName         string            `json:"terminal_name,omitempty" db:"terminal_name"`
+	CustomerID           uuid.UUID         `json:"customer_id" db:"customer_id"`
+	CustomerName         string            `json:"customer_name,omitempty" db:"customer_name"`
+	CreditHold           bool              `json:"credit_hold" db:"credit_hold"`
+	OrderID              *uuid.UUID        `json:"order_id,omitempty" db:"order_id"`
+	OrderNumber          string            `json:"order_number,omitempty" db:"order_number"`
+	OrderStatus          OrderStatus       `json:"order_status,omitempty" db:"order_status"`
+	PickupLocationID     *uuid.UUID        `json:"pickup_location_id,omitempty" db:"pickup_location_id"`
+	DeliveryLocationID   *uuid.UUID        `json:"delivery_location_id,omitempty" db:"delivery_location_id"`
+	ReturnLocationID     *uuid.UUID        `json:"return_location_id,omitempty" db:"return_location_id"`
+	RequestedPickupDate  *time.Time        `json:"requested_pickup_date,omitempty" db:"requested_pickup_date"`
+	AppointmentID        *uuid.UUID        `json:"appointment_id,omitempty" db:"appointment_id"` // Active pickup appointment
+	AppointmentStatus    AppointmentStatus `json:"appointment_status,omitempty" db:"appointment_status"`
+	AppointmentTime      *time.Time        `json:"appointment_time,omitempty" db:"appointment_time"`
+	DraftTripRequestedAt *time.Time        `json:"draft_trip_requested_at,omitempty" db:"draft_trip_requested_at"`
+	DraftTripSentAt      *time.Time        `json:"draft_trip_sent_at,omitempty" db:"draft_trip_sent_at"` // Handed to dispatch
+	Flags                []PreArrivalFlag  `json:"flags"`
+}
+
+// IsDischarged checks if the container is off the vessel
+func (c *PreArrivalContainer) IsDischarged() bool {
+	return c.DischargedAt != nil || (c.CurrentLocationType != "" && c.CurrentLocationType != LocationTypeVessel)
+}
+
+// PickupLocation is where a draft trip picks the container up: the order's
+// pickup location, or the discharge terminal
+func (c *PreArrivalContainer) PickupLocation() uuid.UUID {
+	if c.PickupLocationID != nil {
+		return *c.PickupLocationID
+	}
+	return c.TerminalID
+}
+
+// SetFlags derives the container's flags from its order, appointment and
+// customer
+func (c *PreArrivalContainer) SetFlags() {
+	c.Flags = []PreArrivalFlag{}
+	if c.DeliveryLocationID == nil {
+		c.Flags = append(c.Flags, PreArrivalMissingDeliveryLocation)
+	}
+	if c.AppointmentID == nil {
+		c.Flags = append(c.Flags, PreArrivalMissingAppointment)
+	}
+	if c.CreditHold {
+		c.Flags = append(c.Flags, PreArrivalCreditHold)
+	}
+}
+
+// HasFlag checks if the container carries flag
+func (c *PreArrivalContainer) HasFlag(flag PreArrivalFlag) bool {
+	for _, f := range c.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// PreArrivalVessel groups the inbound containers of one vessel voyage at one
+// terminal
+type PreArrivalVessel struct {
+	VesselName   string                 `json:"vessel_name"`
+	VoyageNumber string                 `json:"voyage_number"`
+	TerminalID   uuid.UUID              `json:"terminal_id"`
+	TerminalName string                 `json:"terminal_name,omitempty"`
+	VesselETA    *time.Time             `json:"vessel_eta,omitempty"`
+	VesselATA    *time.Time             `json:"vessel_ata,omitempty"`
+	Discharged   int                    `json:"discharged"`   // Containers already off the vessel
+	Ready        int                    `json:"ready"`        // Containers with no flags
+	FlagCounts   map[PreArrivalFlag]int `json:"flag_counts"`
+	Containers   []PreArrivalContainer  `json:"containers"`
+}
+
+// PreArrivalOutcome is what a bulk workspace action did with one order
+type PreArrivalOutcome string
+
+const (
+	PreArrivalRequested PreArrivalOutcome = "REQUESTED" // Appointment requested or draft trip handed to dispatch
+	PreArrivalQueued    PreArrivalOutcome = "QUEUED"    // Draft trip held until the container discharges
+	PreArrivalSkipped   PreArrivalOutcome = "SKIPPED"
+	PreArrivalFailed    PreArrivalOutcome = "FAILED"
+)
+
+// PreArrivalActionResult is the result of a bulk workspace action for one order
+type PreArrivalActionResult struct {
+	OrderID         uuid.UUID         `json:"order_id"`
+	ContainerNumber string            `json:"container_number,omitempty"`
+	Outcome         PreArrivalOutcome `json:"outcome"`
+	Reason          string            `json:"reason,omitempty"`
+	AppointmentID   *uuid.UUID        `json:"appointment_id,omitempty"`
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateApprovalStatus represents where a rate exception is in review
+type RateApprovalStatus string
+
+const (
+	RateApprovalPending    RateApprovalStatus = "PENDING"
+	RateApprovalApproved   RateApprovalStatus = "APPROVED"
+	RateApprovalRejected   RateApprovalStatus = "REJECTED"
+	RateApprovalSuperseded RateApprovalStatus = "SUPERSEDED" // The order was requoted before a decision
+)
+
+// RateExceptionReason is why a quoted rate needs approval
+type RateExceptionReason string
+
+const (
+	RateExceptionBelowContract RateExceptionReason = "BELOW_CONTRACT"
+	RateExceptionAboveContract RateExceptionReason = "ABOVE_CONTRACT"
+	RateExceptionNoContract    RateExceptionReason = "NO_CONTRACT" // No contract rate covers the lane
+)
+
+// RateApproval is a manager's review of an order quoted off its customer's
+// contract rate. The order is held until the review is approved.
+type RateApproval struct {
+	ID               uuid.UUID           `json:"id" db:"id"`
+	OrderID          uuid.UUID           `json:"order_id" db:"order_id"`
+	OrderNumber      string              `json:"order_number" db:"order_number"`
+	CustomerID       uuid.UUID           `json:"customer_id" db:"customer_id"`
+	QuotedRate       float64             `json:"quoted_rate" db:"quoted_rate"`
+	ContractRate     *float64            `json:"contract_rate,omitempty" db:"contract_rate"` // Linehaul of the contract rate, nil without one
+	ContractRateID   *uuid.UUID          `json:"contract_rate_id,omitempty" db:"contract_rate_id"`
+	DeviationAmount  *float64            `json:"deviation_amount,omitempty" db:"deviation_amount"`   // Quoted less contract
+	DeviationPercent *float64            `json:"deviation_percent,omitempty" db:"deviation_percent"` // Of the contract rate
+	Reason           RateExceptionReason `json:"reason" db:"reason"`
+	Status           RateApprovalStatus  `json:"status" db:"status"`
+	RequestedBy      string              `json:"requested_by" db:"requested_by"`
+	RequestedAt      time.Time           `json:"requested_at" db:"requested_at"`
+	DecidedBy        string              `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt        *time.Time          `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt        time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at" db:"updated_at"`
+
+	Comments []RateApprovalComment `json:"comments,omitempty"`
+}
+
+// IsPending checks if the approval is still waiting on a manager
+func (a *RateApproval) IsPending() bool {
+	return a.Status == RateApprovalPending
+}
+
+// Covers reports whether the approval was for the given quoted rate
+func (a *RateApproval) Covers(rate float64) bool {
+	return a.QuotedRate == rate
+}
+
+// RateApprovalComment is a note on a rate approval; approvals and
+// rejections record theirs as one
+type RateApprovalComment struct {
+	ID         uuid.UUID           `json:"id" db:"id"`
+	ApprovalID uuid.UUID           `json:"approval_id" db:"approval_id"`
+	Author     string              `json:"author" db:"author"`
+	Body       string              `json:"body" db:"body"`
+	Decision   *RateApprovalStatus `json:"decision,omitempty" db:"decision"` // Set on the comment made with a decision
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+}
+
+// ContractRate is the customer contract rate billing prices a move with
+type ContractRate struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	BaseRate float64   `json:"base_rate"` // Linehaul
+}
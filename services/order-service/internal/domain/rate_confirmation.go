@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateConfirmationStatus represents where a rate confirmation is in signing
+type RateConfirmationStatus string
+
+const (
+	RateConfirmationSent     RateConfirmationStatus = "SENT"     // Out for signature
+	RateConfirmationSigned   RateConfirmationStatus = "SIGNED"   // Signed copy stored
+	RateConfirmationDeclined RateConfirmationStatus = "DECLINED" // Signer refused
+	RateConfirmationVoided   RateConfirmationStatus = "VOIDED"   // Replaced or withdrawn by us
+)
+
+// RateConfirmation is the agreed rate on a brokered order, generated from the
+// accepted tender and sent to the broker for e-signature
+type RateConfirmation struct {
+	ID             uuid.UUID              `json:"id" db:"id"`
+	OrderID        uuid.UUID              `json:"order_id" db:"order_id"`
+	TenderID       uuid.UUID              `json:"tender_id" db:"tender_id"`
+	Number         string                 `json:"number" db:"number"`
+	Rate           float64                `json:"rate" db:"rate"`
+	Currency       string                 `json:"currency" db:"currency"`
+	Provider       string                 `json:"provider" db:"provider"`       // E-signature provider the envelope was sent through
+	EnvelopeID     string                 `json:"envelope_id" db:"envelope_id"` // Provider's ID for the signature request
+	SignerName     string                 `json:"signer_name" db:"signer_name"`
+	SignerEmail    string                 `json:"signer_email" db:"signer_email"`
+	Status         RateConfirmationStatus `json:"status" db:"status"`
+	StatusReason   string                 `json:"status_reason,omitempty" db:"status_reason"`
+	Filename       string                 `json:"filename" db:"filename"`
+	Document       []byte                 `json:"-" db:"document"`        // PDF as sent
+	SignedDocument []byte                 `json:"-" db:"signed_document"` // PDF as signed, with the provider's audit trail
+	SentBy         string                 `json:"sent_by" db:"sent_by"`
+	SentAt         time.Time              `json:"sent_at" db:"sent_at"`
+	SignedAt       *time.Time             `json:"signed_at,omitempty" db:"signed_at"`
+	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// IsOpen checks if the rate confirmation is still waiting on the signer
+func (r *RateConfirmation) IsOpen() bool {
+	return r.Status == RateConfirmationSent
+}
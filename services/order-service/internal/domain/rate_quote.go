@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuoteAccessorial is an accessorial expected on a move, such as chassis
+// days or a prepull, priced into its rate quote
+type QuoteAccessorial struct {
+	ChargeType string  `json:"charge_type"`
+	Quantity   float64 `json:"quantity"` // Hours, days or units as the rate charges; 1 when zero
+}
+
+// RateQuoteLine is one priced charge of a rate quote
+type RateQuoteLine struct {
+	ChargeType  string  `json:"charge_type"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitRate    float64 `json:"unit_rate"`
+	Amount      float64 `json:"amount"`
+}
+
+// RateQuoteFuel records how the contract's fuel surcharge schedule priced fuel
+type RateQuoteFuel struct {
+	ScheduleName string    `json:"schedule_name"`
+	Region       string    `json:"region"`
+	DieselPrice  float64   `json:"diesel_price"`
+	PriceDate    time.Time `json:"price_date"`
+	Percent      float64   `json:"percent"`
+}
+
+// RateQuote is the revenue billing expects from a move at the customer's
+// contract rates, shown to dispatch while the order is created
+type RateQuote struct {
+	CustomerID     uuid.UUID       `json:"customer_id"`
+	ContractID     *uuid.UUID      `json:"contract_id,omitempty"`
+	ContractRateID uuid.UUID       `json:"contract_rate_id"`
+	RateName       string          `json:"rate_name"`
+	Lane           string          `json:"lane"`
+	Fuel           *RateQuoteFuel  `json:"fuel,omitempty"`
+	Lines          []RateQuoteLine `json:"lines"`
+	LineHaul       float64         `json:"line_haul"`
+	FuelSurcharge  float64         `json:"fuel_surcharge"`
+	Accessorials   float64         `json:"accessorials"`
+	Total          float64         `json:"total"`
+	Currency       string          `json:"currency"`
+	// Requested accessorials the customer's price list has no rate for
+	Unpriced []string `json:"unpriced,omitempty"`
+}
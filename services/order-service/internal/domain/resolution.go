@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentifierKind is what a resolved identifier turned out to be
+type IdentifierKind string
+
+const (
+	IdentifierContainer IdentifierKind = "CONTAINER" // ISO 6346 container number
+	IdentifierShipment  IdentifierKind = "SHIPMENT"  // Reference, booking or bill of lading number
+	IdentifierOrder     IdentifierKind = "ORDER"
+	IdentifierTrip      IdentifierKind = "TRIP"
+)
+
+// Event sources merged into a resolution's recent events
+const (
+	EventSourceOrder    = "order"
+	EventSourceDispatch = "dispatch"
+	EventSourceTracking = "tracking"
+)
+
+// ResolvedTrip is a dispatch trip linked to a resolved identifier. Its JSON
+// matches the trip returned by dispatch-service's lookup API.
+type ResolvedTrip struct {
+	ID                  uuid.UUID       `json:"id"`
+	TripNumber          string          `json:"trip_number"`
+	Type                string          `json:"type"`
+	Status              string          `json:"status"`
+	DriverID            *uuid.UUID      `json:"driver_id,omitempty"`
+	CurrentStopSequence int             `json:"current_stop_sequence"`
+	PlannedStartTime    *time.Time      `json:"planned_start_time,omitempty"`
+	ActualStartTime     *time.Time      `json:"actual_start_time,omitempty"`
+	PlannedEndTime      *time.Time      `json:"planned_end_time,omitempty"`
+	ActualEndTime       *time.Time      `json:"actual_end_time,omitempty"`
+	Stops               []ResolvedStop  `json:"stops,omitempty"`
+	Driver              *ResolvedDriver `json:"driver,omitempty"`
+}
+
+// IsActive reports whether the trip is under way or about to be
+func (t *ResolvedTrip) IsActive() bool {
+	switch t.Status {
+	case "DISPATCHED", "EN_ROUTE", "IN_PROGRESS":
+		return true
+	}
+	return false
+}
+
+// ResolvedStop is a stop on a resolved trip
+type ResolvedStop struct {
+	ID              uuid.UUID  `json:"id"`
+	Sequence        int        `json:"sequence"`
+	Type            string     `json:"type"`
+	Activity        string     `json:"activity"`
+	Status          string     `json:"status"`
+	LocationID      uuid.UUID  `json:"location_id"`
+	ContainerNumber string     `json:"container_number,omitempty"`
+	OrderID         *uuid.UUID `json:"order_id,omitempty"`
+	AppointmentTime *time.Time `json:"appointment_time,omitempty"`
+	ActualArrival   *time.Time `json:"actual_arrival,omitempty"`
+	ActualDeparture *time.Time `json:"actual_departure,omitempty"`
+	FailureReason   string     `json:"failure_reason,omitempty"`
+}
+
+// ResolvedDriver is the driver assigned to a resolved trip
+type ResolvedDriver struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Phone string    `json:"phone"`
+}
+
+// LiveLocation is the last reported position of the driver on the active
+// trip. Its JSON matches tracking-service's current location.
+type LiveLocation struct {
+	DriverID        uuid.UUID `json:"driver_id"`
+	DriverName      string    `json:"driver_name"`
+	TripNumber      string    `json:"trip_number,omitempty"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	SpeedMPH        float64   `json:"speed_mph"`
+	Heading         float64   `json:"heading"`
+	Status          string    `json:"status"`
+	CurrentStopName string    `json:"current_stop_name,omitempty"`
+	LastUpdate      time.Time `json:"last_update"`
+}
+
+// TripMilestone is a milestone recorded by tracking-service for a trip
+type TripMilestone struct {
+	TripID          uuid.UUID `json:"trip_id"`
+	Type            string    `json:"type"`
+	OccurredAt      time.Time `json:"occurred_at"`
+	LocationName    string    `json:"location_name,omitempty"`
+	ContainerNumber string    `json:"container_number,omitempty"`
+	Source          string    `json:"source"`
+}
+
+// ResolvedEvent is one entry in the merged timeline of a resolution
+type ResolvedEvent struct {
+	OccurredAt      time.Time `json:"occurred_at"`
+	Source          string    `json:"source"` // order, dispatch, tracking
+	Type            string    `json:"type"`
+	TripNumber      string    `json:"trip_number,omitempty"`
+	ContainerNumber string    `json:"container_number,omitempty"`
+	LocationName    string    `json:"location_name,omitempty"`
+	Details         string    `json:"details,omitempty"`
+}
+
+// Resolution is everything linked to a container, booking, bill of lading,
+// order or trip number, merged from this service, dispatch and tracking.
+// A source that could not be reached is listed in SourceErrors and the rest
+// of the resolution is still returned.
+type Resolution struct {
+	Query        string            `json:"query"`
+	Kind         IdentifierKind    `json:"kind"`
+	Shipments    []*Shipment       `json:"shipments"`
+	Containers   []*Container      `json:"containers"`
+	Orders       []*Order          `json:"orders"`
+	Trips        []ResolvedTrip    `json:"trips"`
+	ActiveTrip   *ResolvedTrip     `json:"active_trip,omitempty"`
+	LiveLocation *LiveLocation     `json:"live_location,omitempty"`
+	RecentEvents []ResolvedEvent   `json:"recent_events"`
+	SourceErrors map[string]string `json:"source_errors,omitempty"`
+	ResolvedAt   time.Time         `json:"resolved_at"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedOrderFilter is an order search a user has named and kept, such as
+// "vip exports" for the export orders tagged vip
+type SavedOrderFilter struct {
+	ID        uuid.UUID           `json:"id" db:"id"`
+	UserID    string              `json:"user_id" db:"user_id"`
+	Name      string              `json:"name" db:"name"`
+	Criteria  OrderFilterCriteria `json:"criteria" db:"criteria"`
+	IsDefault bool                `json:"is_default" db:"is_default"` // Opened first on the user's order list
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// OrderFilterCriteria is the stored part of an order list filter. Paging is
+// chosen each time the filter is run.
+type OrderFilterCriteria struct {
+	Status        []OrderStatus   `json:"status,omitempty"`
+	Type          []OrderType     `json:"type,omitempty"`
+	BillingStatus []BillingStatus `json:"billing_status,omitempty"`
+	ShipmentID    *uuid.UUID      `json:"shipment_id,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	MatchAllTags  bool            `json:"match_all_tags,omitempty"` // Orders with every tag rather than any
+	SortBy        string          `json:"sort_by,omitempty"`
+	SortOrder     string          `json:"sort_order,omitempty"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Broker is a 3PL or freight broker that tenders loads through the intake API
+type Broker struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	Name          string    `json:"name" db:"name"`
+	MCNumber      string    `json:"mc_number,omitempty" db:"mc_number"`
+	CustomerID    uuid.UUID `json:"customer_id" db:"customer_id"` // Billed for accepted loads
+	APIKeyHash    string    `json:"-" db:"api_key_hash"`
+	WebhookURL    string    `json:"webhook_url,omitempty" db:"webhook_url"`
+	WebhookSecret string    `json:"-" db:"webhook_secret"`
+	Active        bool      `json:"active" db:"active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// Rate confirmations for the broker's loads are sent here for signature;
+	// when required, its orders are not dispatched until one is signed
+	RateConfirmationEmail         string `json:"rate_confirmation_email,omitempty" db:"rate_confirmation_email"`
+	RequireSignedRateConfirmation bool   `json:"require_signed_rate_confirmation" db:"require_signed_rate_confirmation"`
+}
+
+// TenderStatus represents where a load tender is in negotiation
+type TenderStatus string
+
+const (
+	TenderStatusTendered  TenderStatus = "TENDERED"
+	TenderStatusCountered TenderStatus = "COUNTERED"
+	TenderStatusAccepted  TenderStatus = "ACCEPTED"
+	TenderStatusDeclined  TenderStatus = "DECLINED"
+	TenderStatusWithdrawn TenderStatus = "WITHDRAWN"
+	TenderStatusExpired   TenderStatus = "EXPIRED"
+)
+
+// LoadTender is a load offered by a broker with a rate, pending our acceptance
+type LoadTender struct {
+	ID                    uuid.UUID     `json:"id" db:"id"`
+	BrokerID              uuid.UUID     `json:"broker_id" db:"broker_id"`
+	BrokerReference       string        `json:"broker_reference" db:"broker_reference"` // Broker's load number
+	OrderType             OrderType     `json:"order_type" db:"order_type"`
+	ShipmentReference     string        `json:"shipment_reference" db:"shipment_reference"` // BOL or booking number
+	SteamshipLineCode     string        `json:"steamship_line_code" db:"steamship_line_code"`
+	PortID                *uuid.UUID    `json:"port_id,omitempty" db:"port_id"`
+	TerminalID            *uuid.UUID    `json:"terminal_id,omitempty" db:"terminal_id"`
+	ContainerNumber       string        `json:"container_number" db:"container_number"`
+	ContainerSize         ContainerSize `json:"container_size" db:"container_size"`
+	ContainerType         ContainerType `json:"container_type" db:"container_type"`
+	WeightLbs             int           `json:"weight_lbs" db:"weight_lbs"`
+	IsHazmat              bool          `json:"is_hazmat" db:"is_hazmat"`
+	HazmatClass           string        `json:"hazmat_class,omitempty" db:"hazmat_class"`
+	UNNumber              string        `json:"un_number,omitempty" db:"un_number"`
+	PickupLocationID      *uuid.UUID    `json:"pickup_location_id,omitempty" db:"pickup_location_id"`
+	PickupAddress         string        `json:"pickup_address,omitempty" db:"pickup_address"`
+	DeliveryLocationID    *uuid.UUID    `json:"delivery_location_id,omitempty" db:"delivery_location_id"`
+	DeliveryAddress       string        `json:"delivery_address,omitempty" db:"delivery_address"`
+	ReturnLocationID      *uuid.UUID    `json:"return_location_id,omitempty" db:"return_location_id"`
+	RequestedPickupDate   *time.Time    `json:"requested_pickup_date,omitempty" db:"requested_pickup_date"`
+	RequestedDeliveryDate *time.Time    `json:"requested_delivery_date,omitempty" db:"requested_delivery_date"`
+	LastFreeDay           *time.Time    `json:"last_free_day,omitempty" db:"last_free_day"`
+	OfferedRate           float64       `json:"offered_rate" db:"offered_rate"`
+	CounterRate           *float64      `json:"counter_rate,omitempty" db:"counter_rate"`
+	AgreedRate            *float64      `json:"agreed_rate,omitempty" db:"agreed_rate"`
+	Currency              string        `json:"currency" db:"currency"`
+	Status                TenderStatus  `json:"status" db:"status"`
+	StatusReason          string        `json:"status_reason,omitempty" db:"status_reason"`
+	Notes                 string        `json:"notes,omitempty" db:"notes"`
+	ExpiresAt             *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
+	RespondedBy           string        `json:"responded_by,omitempty" db:"responded_by"`
+	RespondedAt           *time.Time    `json:"responded_at,omitempty" db:"responded_at"`
+	OrderID               *uuid.UUID    `json:"order_id,omitempty" db:"order_id"`
+	CreatedAt             time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// IsOpen checks if the tender can still be accepted, declined or countered
+func (t *LoadTender) IsOpen(now time.Time) bool {
+	if t.Status != TenderStatusTendered && t.Status != TenderStatusCountered {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// ShipmentType returns the shipment type an accepted tender is booked under
+func (t *LoadTender) ShipmentType() ShipmentType {
+	if t.OrderType == OrderTypeExport {
+		return ShipmentTypeExport
+	}
+	return ShipmentTypeImport
+}
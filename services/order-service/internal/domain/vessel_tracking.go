@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a refined vessel ETA came from
+const (
+	VesselETASourcePosition = "AIS_POSITION" // Sailing time from the live position and speed
+	VesselETASourceReported = "AIS_REPORTED" // The ETA the vessel's crew broadcasts
+)
+
+// VesselRef identifies a vessel to the AIS provider. IMO and MMSI are
+// unknown until the provider has matched the vessel by name once.
+type VesselRef struct {
+	Name string
+	IMO  string
+	MMSI string
+}
+
+// VesselPosition is an AIS position report
+type VesselPosition struct {
+	IMO           string     `json:"imo"`
+	MMSI          string     `json:"mmsi"`
+	Name          string     `json:"name"`
+	Latitude      float64    `json:"latitude"`
+	Longitude     float64    `json:"longitude"`
+	SpeedKnots    float64    `json:"speed_knots"` // Speed over ground
+	CourseDegrees float64    `json:"course_degrees"`
+	NavStatus     string     `json:"nav_status,omitempty"`  // e.g. "UNDER_WAY", "AT_ANCHOR", "MOORED"
+	Destination   string     `json:"destination,omitempty"` // As the crew entered it
+	ReportedETA   *time.Time `json:"reported_eta,omitempty"`
+	ReportedAt    time.Time  `json:"reported_at"`
+}
+
+// InboundVoyage is a vessel voyage carrying open import shipments to a
+// terminal, with the track kept for it
+type InboundVoyage struct {
+	VesselName   string
+	VoyageNumber string
+	TerminalID   uuid.UUID
+	TerminalName string
+	Latitude     float64 // Terminal
+	Longitude    float64
+	Track        *VesselTrack // Nil until the vessel is first checked
+	Shipments    []InboundShipment
+}
+
+// InboundShipment is an open import shipment on an inbound voyage
+type InboundShipment struct {
+	ID              uuid.UUID
+	ReferenceNumber string
+	CustomerID      uuid.UUID
+	VesselETA       *time.Time
+	LastFreeDay     *time.Time
+	Containers      int
+}
+
+// VesselTrack is the latest AIS position and refined ETA of a vessel on a
+// voyage
+type VesselTrack struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	VesselName    string     `json:"vessel_name" db:"vessel_name"`
+	VoyageNumber  string     `json:"voyage_number" db:"voyage_number"`
+	IMO           string     `json:"imo,omitempty" db:"imo"`
+	MMSI          string     `json:"mmsi,omitempty" db:"mmsi"`
+	TerminalID    uuid.UUID  `json:"terminal_id" db:"terminal_id"`
+	Latitude      *float64   `json:"latitude,omitempty" db:"latitude"`
+	Longitude     *float64   `json:"longitude,omitempty" db:"longitude"`
+	SpeedKnots    *float64   `json:"speed_knots,omitempty" db:"speed_knots"`
+	NavStatus     string     `json:"nav_status,omitempty" db:"nav_status"`
+	PositionAt    *time.Time `json:"position_at,omitempty" db:"position_at"`
+	DistanceNM    *float64   `json:"distance_nm,omitempty" db:"distance_nm"` // To the terminal
+	PredictedETA  *time.Time `json:"predicted_eta,omitempty" db:"predicted_eta"`
+	ETASource     string     `json:"eta_source,omitempty" db:"eta_source"`
+	LastCheckedAt time.Time  `json:"last_checked_at" db:"last_checked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// VesselETAChange records a shipment's vessel ETA moved by a refined ETA
+type VesselETAChange struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ShipmentID   uuid.UUID  `json:"shipment_id" db:"shipment_id"`
+	TrackID      uuid.UUID  `json:"track_id" db:"track_id"`
+	PreviousETA  *time.Time `json:"previous_eta,omitempty" db:"previous_eta"`
+	NewETA       time.Time  `json:"new_eta" db:"new_eta"`
+	DriftMinutes int        `json:"drift_minutes" db:"drift_minutes"` // Negative when early
+	Source       string     `json:"source" db:"source"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// earthRadiusNM is the mean earth radius in nautical miles
+const earthRadiusNM = 3440.065
+
+// NauticalMiles returns the great-circle distance between two points
+func NauticalMiles(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusNM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WeighingMethod is the SOLAS method used to obtain a verified gross mass
+type WeighingMethod string
+
+const (
+	// WeighingMethod1 weighs the packed and sealed container on a certified scale
+	WeighingMethod1 WeighingMethod = "METHOD_1"
+	// WeighingMethod2 sums the weighed cargo, packing, and the container tare
+	WeighingMethod2 WeighingMethod = "METHOD_2"
+)
+
+// VGMStatus represents the lifecycle of a VGM declaration
+type VGMStatus string
+
+const (
+	VGMStatusRecorded  VGMStatus = "RECORDED"
+	VGMStatusSubmitted VGMStatus = "SUBMITTED"
+	VGMStatusAccepted  VGMStatus = "ACCEPTED"
+	VGMStatusRejected  VGMStatus = "REJECTED"
+)
+
+// LbsPerKg converts kilograms to pounds
+const LbsPerKg = 2.20462
+
+// maxGrossMassKg is the ISO 668 maximum gross mass by container size
+var maxGrossMassKg = map[ContainerSize]float64{
+	ContainerSize20: 30480,
+	ContainerSize40: 32500,
+	ContainerSize45: 32500,
+}
+
+// MaxGrossMassKg returns the rated maximum gross mass for a container size
+func MaxGrossMassKg(size ContainerSize) float64 {
+	if max, ok := maxGrossMassKg[size]; ok {
+		return max
+	}
+	return maxGrossMassKg[ContainerSize40]
+}
+
+// VGMRecord is a verified gross mass declaration for an export container
+type VGMRecord struct {
+	ID                  uuid.UUID      `json:"id" db:"id"`
+	ContainerID         uuid.UUID      `json:"container_id" db:"container_id"`
+	ContainerNumber     string         `json:"container_number" db:"container_number"`
+	ShipmentID          uuid.UUID      `json:"shipment_id" db:"shipment_id"`
+	BookingNumber       string         `json:"booking_number" db:"booking_number"`
+	Method              WeighingMethod `json:"method" db:"method"`
+	GrossMassKg         float64        `json:"gross_mass_kg" db:"gross_mass_kg"`
+	TareMassKg          float64        `json:"tare_mass_kg,omitempty" db:"tare_mass_kg"`
+	CargoMassKg         float64        `json:"cargo_mass_kg,omitempty" db:"cargo_mass_kg"` // Method 2 only, includes packing
+	ScaleTicketNumber   string         `json:"scale_ticket_number,omitempty" db:"scale_ticket_number"`
+	ScaleTicketURL      string         `json:"scale_ticket_url,omitempty" db:"scale_ticket_url"`
+	ScaleLocation       string         `json:"scale_location,omitempty" db:"scale_location"`
+	WeighedAt           time.Time      `json:"weighed_at" db:"weighed_at"`
+	AuthorizedSignatory string         `json:"authorized_signatory" db:"authorized_signatory"`
+	Status              VGMStatus      `json:"status" db:"status"`
+	SubmittedAt         *time.Time     `json:"submitted_at,omitempty" db:"submitted_at"`
+	SubmissionReference string         `json:"submission_reference,omitempty" db:"submission_reference"`
+	RejectionReason     string         `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	CreatedBy           string         `json:"created_by" db:"created_by"`
+	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// GrossMassLbs returns the verified gross mass in pounds
+func (v *VGMRecord) GrossMassLbs() int {
+	return int(v.GrossMassKg*LbsPerKg + 0.5)
+}
+
+// IsOnFile checks if the declaration counts as a VGM on file for ingate
+func (v *VGMRecord) IsOnFile() bool {
+	return v.Status == VGMStatusSubmitted || v.Status == VGMStatusAccepted
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresAppointmentRepository implements AppointmentRepository using PostgreSQL
+type PostgresAppointmentRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAppointmentRepository creates a new PostgreSQL terminal appointment repository
+func NewPostgresAppointmentRepository(pool *pgxpool.Pool) *PostgresAppointmentRepository {
+	return &PostgresAppointmentRepository{pool: pool}
+}
+
+const appointmentColumns = `id, order_id, trip_id, terminal_id, COALESCE(terminal_name, ''), type, status,
+	container_id, COALESCE(container_number, ''), chassis_id, driver_id, tractor_id,
+	requested_time, confirmed_time, window_start_time, window_end_time,
+	COALESCE(confirmation_number, ''), COALESCE(gate_number, ''), COALESCE(lane_number, ''),
+	COALESCE(special_instructions, ''), actual_arrival_time, actual_completion_time,
+	COALESCE(gate_ticket_number, ''), COALESCE(cancellation_reason, ''), rescheduled_from,
+	requested_by, requested_by_id, COALESCE(confirmed_by, ''), COALESCE(terminal_reference, ''),
+	metadata, created_at, updated_at`
+
+// Create creates a new appointment
+func (r *PostgresAppointmentRepository) Create(ctx context.Context, a *domain.TerminalAppointment) error {
+	metadata, err := json.Marshal(a.Metadata)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = now
+	}
+	a.UpdatedAt = now
+
+	_, err = conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO terminal_appointments (
+			id, order_id, trip_id, terminal_id, terminal_name, type, status,
+			container_id, container_number, chassis_id, driver_id, tractor_id,
+			requested_time, confirmed_time, window_start_time, window_end_time,
+			confirmation_number, gate_number, lane_number, special_instructions,
+			actual_arrival_time, actual_completion_time, gate_ticket_number, cancellation_reason,
+			rescheduled_from, requested_by, requested_by_id, confirmed_by, terminal_reference,
+			metadata, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
+		)`,
+		a.ID, a.OrderID, a.TripID, a.TerminalID, a.TerminalName, a.Type, a.Status,
+		a.ContainerID, a.ContainerNumber, a.ChassisID, a.DriverID, a.TractorID,
+		a.RequestedTime, a.ConfirmedTime, a.WindowStartTime, a.WindowEndTime,
+		a.ConfirmationNumber, a.GateNumber, a.LaneNumber, a.SpecialInstructions,
+		a.ActualArrivalTime, a.ActualCompletionTime, a.GateTicketNumber, a.CancellationReason,
+		a.RescheduledFrom, a.RequestedBy, a.RequestedByID, a.ConfirmedBy, a.TerminalReference,
+		metadata, a.CreatedAt, a.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an appointment by ID
+func (r *PostgresAppointmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.TerminalAppointment, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+appointmentColumns+` FROM terminal_appointments WHERE id = $1`, id)
+	a, err := scanAppointment(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appointment: %w", err)
+	}
+	return a, nil
+}
+
+// GetByOrderID retrieves an order's appointments, newest window first
+func (r *PostgresAppointmentRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]domain.TerminalAppointment, error) {
+	return r.list(ctx, `WHERE order_id = $1 ORDER BY window_start_time DESC`, orderID)
+}
+
+// GetByTerminalAndTimeRange retrieves a terminal's appointments whose
+// window starts in [start, end)
+func (r *PostgresAppointmentRepository) GetByTerminalAndTimeRange(ctx context.Context, terminalID uuid.UUID, start, end time.Time) ([]domain.TerminalAppointment, error) {
+	return r.list(ctx, `WHERE terminal_id = $1 AND window_start_time >= $2 AND window_start_time < $3
+		ORDER BY window_start_time`, terminalID, start, end)
+}
+
+// GetByTimeRange retrieves every terminal's appointments whose window
+// starts in [start, end)
+func (r *PostgresAppointmentRepository) GetByTimeRange(ctx context.Context, start, end time.Time) ([]domain.TerminalAppointment, error) {
+	return r.list(ctx, `WHERE window_start_time >= $1 AND window_start_time < $2
+		ORDER BY window_start_time`, start, end)
+}
+
+func (r *PostgresAppointmentRepository) list(ctx context.Context, where string, args ...interface{}) ([]domain.TerminalAppointment, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `SELECT `+appointmentColumns+` FROM terminal_appointments `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list appointments: %w", err)
+	}
+	defer rows.Close()
+
+	var appointments []domain.TerminalAppointment
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, *a)
+	}
+	return appointments, rows.Err()
+}
+
+// Update saves an appointment's status, times and gate details
+func (r *PostgresAppointmentRepository) Update(ctx context.Context, a *domain.TerminalAppointment) error {
+	metadata, err := json.Marshal(a.Metadata)
+	if err != nil {
+		return err
+	}
+	a.UpdatedAt = time.Now()
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE terminal_appointments SET
+			trip_id = $2, status = $3, container_id = $4, container_number = $5,
+			chassis_id = $6, driver_id = $7, tractor_id = $8,
+			confirmed_time = $9, window_start_time = $10, window_end_time = $11,
+			confirmation_number = $12, gate_number = $13, lane_number = $14,
+			actual_arrival_time = $15, actual_completion_time = $16, gate_ticket_number = $17,
+			cancellation_reason = $18, confirmed_by = $19, terminal_reference = $20,
+			metadata = $21, updated_at = $22
+		WHERE id = $1`,
+		a.ID, a.TripID, a.Status, a.ContainerID, a.ContainerNumber,
+		a.ChassisID, a.DriverID, a.TractorID,
+		a.ConfirmedTime, a.WindowStartTime, a.WindowEndTime,
+		a.ConfirmationNumber, a.GateNumber, a.LaneNumber,
+		a.ActualArrivalTime, a.ActualCompletionTime, a.GateTicketNumber,
+		a.CancellationReason, a.ConfirmedBy, a.TerminalReference,
+		metadata, a.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update appointment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("appointment %s not found", a.ID)
+	}
+	return nil
+}
+
+func scanAppointment(row pgx.Row) (*domain.TerminalAppointment, error) {
+	var a domain.TerminalAppointment
+	var metadata []byte
+	err := row.Scan(
+		&a.ID, &a.OrderID, &a.TripID, &a.TerminalID, &a.TerminalName, &a.Type, &a.Status,
+		&a.ContainerID, &a.ContainerNumber, &a.ChassisID, &a.DriverID, &a.TractorID,
+		&a.RequestedTime, &a.ConfirmedTime, &a.WindowStartTime, &a.WindowEndTime,
+		&a.ConfirmationNumber, &a.GateNumber, &a.LaneNumber,
+		&a.SpecialInstructions, &a.ActualArrivalTime, &a.ActualCompletionTime,
+		&a.GateTicketNumber, &a.CancellationReason, &a.RescheduledFrom,
+		&a.RequestedBy, &a.RequestedByID, &a.ConfirmedBy, &a.TerminalReference,
+		&metadata, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &a.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode appointment metadata: %w", err)
+		}
+	}
+	return &a, nil
+}
+
+// PostgresTerminalRepository implements TerminalRepository using PostgreSQL
+type PostgresTerminalRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresTerminalRepository creates a new PostgreSQL terminal gate hours repository
+func NewPostgresTerminalRepository(pool *pgxpool.Pool) *PostgresTerminalRepository {
+	return &PostgresTerminalRepository{pool: pool}
+}
+
+// GetGateHours retrieves a terminal's weekly gate hours and special dates
+func (r *PostgresTerminalRepository) GetGateHours(ctx context.Context, terminalID uuid.UUID) ([]domain.TerminalGateHours, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, terminal_id, COALESCE(day_of_week, -1), to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI'),
+			COALESCE(is_holiday, false), COALESCE(is_closed, false), special_date, COALESCE(notes, ''),
+			created_at, updated_at
+		FROM terminal_gate_hours
+		WHERE terminal_id = $1
+		ORDER BY special_date NULLS FIRST, day_of_week`,
+		terminalID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gate hours: %w", err)
+	}
+	defer rows.Close()
+
+	var hours []domain.TerminalGateHours
+	for rows.Next() {
+		var h domain.TerminalGateHours
+		if err := rows.Scan(
+			&h.ID, &h.TerminalID, &h.DayOfWeek, &h.OpenTime, &h.CloseTime,
+			&h.IsHoliday, &h.IsClosed, &h.SpecialDate, &h.Notes,
+			&h.CreatedAt, &h.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan gate hours: %w", err)
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
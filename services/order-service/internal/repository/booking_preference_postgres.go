@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresBookingPreferenceRepository implements BookingPreferenceRepository using PostgreSQL
+type PostgresBookingPreferenceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresBookingPreferenceRepository creates a new PostgreSQL booking preference repository
+func NewPostgresBookingPreferenceRepository(pool *pgxpool.Pool) *PostgresBookingPreferenceRepository {
+	return &PostgresBookingPreferenceRepository{pool: pool}
+}
+
+const bookingPreferenceColumns = `terminal_id, timezone, preferred_windows, avoid_windows,
+	arrival_buffer_mins, sample_size, period_start, period_end, updated_at`
+
+// GetByTerminalID retrieves the booking preference for a terminal
+func (r *PostgresBookingPreferenceRepository) GetByTerminalID(ctx context.Context, terminalID uuid.UUID) (*domain.AppointmentBookingPreference, error) {
+	var p domain.AppointmentBookingPreference
+	var preferred, avoid []byte
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+bookingPreferenceColumns+` FROM appointment_booking_preferences WHERE terminal_id = $1`,
+		terminalID,
+	).Scan(
+		&p.TerminalID, &p.Timezone, &preferred, &avoid,
+		&p.ArrivalBufferMins, &p.SampleSize, &p.PeriodStart, &p.PeriodEnd, &p.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get booking preference: %w", err)
+	}
+	if err := json.Unmarshal(preferred, &p.PreferredWindows); err != nil {
+		return nil, fmt.Errorf("failed to decode preferred windows: %w", err)
+	}
+	if err := json.Unmarshal(avoid, &p.AvoidWindows); err != nil {
+		return nil, fmt.Errorf("failed to decode avoid windows: %w", err)
+	}
+	return &p, nil
+}
+
+// Upsert creates or replaces the booking preference for a terminal
+func (r *PostgresBookingPreferenceRepository) Upsert(ctx context.Context, p *domain.AppointmentBookingPreference) error {
+	preferred, err := json.Marshal(p.PreferredWindows)
+	if err != nil {
+		return err
+	}
+	avoid, err := json.Marshal(p.AvoidWindows)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO appointment_booking_preferences (`+bookingPreferenceColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (terminal_id) DO UPDATE SET
+			timezone = EXCLUDED.timezone,
+			preferred_windows = EXCLUDED.preferred_windows, avoid_windows = EXCLUDED.avoid_windows,
+			arrival_buffer_mins = EXCLUDED.arrival_buffer_mins, sample_size = EXCLUDED.sample_size,
+			period_start = EXCLUDED.period_start, period_end = EXCLUDED.period_end,
+			updated_at = EXCLUDED.updated_at`,
+		p.TerminalID, p.Timezone, preferred, avoid,
+		p.ArrivalBufferMins, p.SampleSize, p.PeriodStart, p.PeriodEnd, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save booking preference: %w", err)
+	}
+	return nil
+}
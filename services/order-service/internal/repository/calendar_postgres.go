@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresCalendarRepository implements CalendarRepository using PostgreSQL
+type PostgresCalendarRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresCalendarRepository creates a new PostgreSQL calendar closure repository
+func NewPostgresCalendarRepository(pool *pgxpool.Pool) *PostgresCalendarRepository {
+	return &PostgresCalendarRepository{pool: pool}
+}
+
+// Create creates a new closure
+func (r *PostgresCalendarRepository) Create(ctx context.Context, c *domain.CalendarClosure) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO calendar_closures (
+			id, type, terminal_id, closure_date, name, reason,
+			extends_free_time, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		c.ID, c.Type, c.TerminalID, c.ClosureDate, c.Name, c.Reason,
+		c.ExtendsFreeTime, c.CreatedBy, c.CreatedAt, c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create closure: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a closure
+func (r *PostgresCalendarRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM calendar_closures WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete closure: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("closure not found: %s", id)
+	}
+	return nil
+}
+
+// GetByDateRange retrieves closures dated from start through end
+func (r *PostgresCalendarRepository) GetByDateRange(ctx context.Context, start, end time.Time) ([]domain.CalendarClosure, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, type, terminal_id, closure_date, name, COALESCE(reason, ''),
+			extends_free_time, COALESCE(created_by, ''), created_at, updated_at
+		FROM calendar_closures
+		WHERE closure_date BETWEEN $1::date AND $2::date
+		ORDER BY closure_date`,
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closures: %w", err)
+	}
+	defer rows.Close()
+
+	var closures []domain.CalendarClosure
+	for rows.Next() {
+		var c domain.CalendarClosure
+		if err := rows.Scan(
+			&c.ID, &c.Type, &c.TerminalID, &c.ClosureDate, &c.Name, &c.Reason,
+			&c.ExtendsFreeTime, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan closure: %w", err)
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
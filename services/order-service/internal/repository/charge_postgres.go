@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresContainerChargeRepository implements ContainerChargeRepository using PostgreSQL
+type PostgresContainerChargeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresContainerChargeRepository creates a new PostgreSQL container charge repository
+func NewPostgresContainerChargeRepository(pool *pgxpool.Pool) *PostgresContainerChargeRepository {
+	return &PostgresContainerChargeRepository{pool: pool}
+}
+
+const containerChargeColumns = `id, container_id, container_number, shipment_id, customer_id, terminal_id,
+	charge_type, last_free_day, start_date, days, amount, breakdown, accrued_through, created_at, updated_at`
+
+// Upsert creates or replaces the container's charge of its type. The row
+// keeps its original ID and created time.
+func (r *PostgresContainerChargeRepository) Upsert(ctx context.Context, c *domain.ContainerCharge) error {
+	breakdown, err := json.Marshal(c.Breakdown)
+	if err != nil {
+		return fmt.Errorf("failed to encode charge breakdown: %w", err)
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO container_charges (`+containerChargeColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		 ON CONFLICT (container_id, charge_type) DO UPDATE SET
+			container_number = EXCLUDED.container_number, shipment_id = EXCLUDED.shipment_id,
+			customer_id = EXCLUDED.customer_id, terminal_id = EXCLUDED.terminal_id,
+			last_free_day = EXCLUDED.last_free_day, start_date = EXCLUDED.start_date,
+			days = EXCLUDED.days, amount = EXCLUDED.amount, breakdown = EXCLUDED.breakdown,
+			accrued_through = EXCLUDED.accrued_through, updated_at = EXCLUDED.updated_at`,
+		c.ID, c.ContainerID, c.ContainerNumber, c.ShipmentID, c.CustomerID, c.TerminalID,
+		c.ChargeType, c.LastFreeDay, c.StartDate, c.Days, c.Amount, breakdown, c.AccruedThrough, c.CreatedAt, c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save container charge: %w", err)
+	}
+	return nil
+}
+
+// GetByContainerID retrieves the charges accrued against a container
+func (r *PostgresContainerChargeRepository) GetByContainerID(ctx context.Context, containerID uuid.UUID) ([]domain.ContainerCharge, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+containerChargeColumns+` FROM container_charges WHERE container_id = $1 ORDER BY charge_type`,
+		containerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container charges: %w", err)
+	}
+	defer rows.Close()
+	return scanContainerCharges(rows)
+}
+
+// List retrieves accrued charges, largest amount first
+func (r *PostgresContainerChargeRepository) List(ctx context.Context, filter ContainerChargeFilter) ([]domain.ContainerCharge, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+	if filter.TerminalID != nil {
+		args = append(args, *filter.TerminalID)
+		conditions = append(conditions, fmt.Sprintf("terminal_id = $%d", len(args)))
+	}
+	if filter.ChargeType != "" {
+		args = append(args, filter.ChargeType)
+		conditions = append(conditions, fmt.Sprintf("charge_type = $%d", len(args)))
+	}
+	if filter.MinAmount > 0 {
+		args = append(args, filter.MinAmount)
+		conditions = append(conditions, fmt.Sprintf("amount >= $%d", len(args)))
+	}
+
+	query := `SELECT ` + containerChargeColumns + ` FROM container_charges`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY amount DESC, last_free_day"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list container charges: %w", err)
+	}
+	defer rows.Close()
+	return scanContainerCharges(rows)
+}
+
+// MarkWarned records a charge warning for the container, LFD and lead
+// time, returning false if it was already sent
+func (r *PostgresContainerChargeRepository) MarkWarned(ctx context.Context, containerID uuid.UUID, warning string, lastFreeDay time.Time, daysOut int) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO container_charge_warnings (container_id, warning, last_free_day, days_out)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING`,
+		containerID, warning, lastFreeDay, daysOut,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record charge warning: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanContainerCharges(rows pgx.Rows) ([]domain.ContainerCharge, error) {
+	var charges []domain.ContainerCharge
+	for rows.Next() {
+		var c domain.ContainerCharge
+		var breakdown []byte
+		if err := rows.Scan(
+			&c.ID, &c.ContainerID, &c.ContainerNumber, &c.ShipmentID, &c.CustomerID, &c.TerminalID,
+			&c.ChargeType, &c.LastFreeDay, &c.StartDate, &c.Days, &c.Amount, &breakdown, &c.AccruedThrough,
+			&c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan container charge: %w", err)
+		}
+		if err := json.Unmarshal(breakdown, &c.Breakdown); err != nil {
+			return nil, fmt.Errorf("failed to decode charge breakdown: %w", err)
+		}
+		charges = append(charges, c)
+	}
+	return charges, rows.Err()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresFacilityProfileRepository implements FacilityProfileRepository using PostgreSQL
+type PostgresFacilityProfileRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresFacilityProfileRepository creates a new PostgreSQL facility profile repository
+func NewPostgresFacilityProfileRepository(pool *pgxpool.Pool) *PostgresFacilityProfileRepository {
+	return &PostgresFacilityProfileRepository{pool: pool}
+}
+
+const facilityProfileColumns = `location_id, timezone, hours, lunch_closures, dock_count,
+	required_ppe, check_in_procedure, notes, updated_by, updated_at`
+
+// GetByLocationID retrieves the facility profile for a location
+func (r *PostgresFacilityProfileRepository) GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.FacilityProfile, error) {
+	var p domain.FacilityProfile
+	var hours, lunch []byte
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+facilityProfileColumns+` FROM facility_profiles WHERE location_id = $1`,
+		locationID,
+	).Scan(
+		&p.LocationID, &p.Timezone, &hours, &lunch, &p.DockCount,
+		&p.RequiredPPE, &p.CheckInProcedure, &p.Notes, &p.UpdatedBy, &p.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get facility profile: %w", err)
+	}
+	if err := json.Unmarshal(hours, &p.Hours); err != nil {
+		return nil, fmt.Errorf("failed to decode facility hours: %w", err)
+	}
+	if err := json.Unmarshal(lunch, &p.LunchClosures); err != nil {
+		return nil, fmt.Errorf("failed to decode lunch closures: %w", err)
+	}
+	return &p, nil
+}
+
+// Upsert creates or replaces the facility profile for a location
+func (r *PostgresFacilityProfileRepository) Upsert(ctx context.Context, p *domain.FacilityProfile) error {
+	hours, err := json.Marshal(p.Hours)
+	if err != nil {
+		return err
+	}
+	lunch, err := json.Marshal(p.LunchClosures)
+	if err != nil {
+		return err
+	}
+	ppe := p.RequiredPPE
+	if ppe == nil {
+		ppe = []string{}
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO facility_profiles (`+facilityProfileColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 ON CONFLICT (location_id) DO UPDATE SET
+			timezone = EXCLUDED.timezone,
+			hours = EXCLUDED.hours, lunch_closures = EXCLUDED.lunch_closures,
+			dock_count = EXCLUDED.dock_count, required_ppe = EXCLUDED.required_ppe,
+			check_in_procedure = EXCLUDED.check_in_procedure, notes = EXCLUDED.notes,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		p.LocationID, p.Timezone, hours, lunch, p.DockCount,
+		ppe, p.CheckInProcedure, p.Notes, p.UpdatedBy, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save facility profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresIntakeRepository implements IntakeRepository using PostgreSQL
+type PostgresIntakeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresIntakeRepository creates a new PostgreSQL intake repository
+func NewPostgresIntakeRepository(pool *pgxpool.Pool) *PostgresIntakeRepository {
+	return &PostgresIntakeRepository{pool: pool}
+}
+
+const intakeEmailColumns = `id, mailbox, message_id, thread_id, from_address, subject, body,
+	received_at, class, attachments, draft_id, created_at`
+
+// CreateEmail records an email read from the mailbox. It returns false
+// without an error when the mailbox's message was already recorded.
+func (r *PostgresIntakeRepository) CreateEmail(ctx context.Context, e *domain.IntakeEmail) (bool, error) {
+	attachments, err := json.Marshal(e.Attachments)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode attachments: %w", err)
+	}
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO intake_emails (`+intakeEmailColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (mailbox, message_id) DO NOTHING`,
+		e.ID, e.Mailbox, e.MessageID, e.ThreadID, e.FromAddress, e.Subject, e.Body,
+		e.ReceivedAt, e.Class, attachments, e.DraftID, e.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create intake email: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateEmail updates an email's attachment parse state and draft
+func (r *PostgresIntakeRepository) UpdateEmail(ctx context.Context, e *domain.IntakeEmail) error {
+	attachments, err := json.Marshal(e.Attachments)
+	if err != nil {
+		return fmt.Errorf("failed to encode attachments: %w", err)
+	}
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE intake_emails SET attachments = $2, draft_id = $3 WHERE id = $1`,
+		e.ID, attachments, e.DraftID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update intake email: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("intake email not found: %s", e.ID)
+	}
+	return nil
+}
+
+// GetEmail retrieves an intake email by ID
+func (r *PostgresIntakeRepository) GetEmail(ctx context.Context, id uuid.UUID) (*domain.IntakeEmail, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+intakeEmailColumns+` FROM intake_emails WHERE id = $1`, id)
+	return scanIntakeEmail(row)
+}
+
+// GetEmailsByDraftID retrieves the emails a draft was built from, oldest first
+func (r *PostgresIntakeRepository) GetEmailsByDraftID(ctx context.Context, draftID uuid.UUID) ([]domain.IntakeEmail, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+intakeEmailColumns+` FROM intake_emails WHERE draft_id = $1 ORDER BY received_at`, draftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get intake emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []domain.IntakeEmail
+	for rows.Next() {
+		e, err := scanIntakeEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, *e)
+	}
+	return emails, rows.Err()
+}
+
+func scanIntakeEmail(row pgx.Row) (*domain.IntakeEmail, error) {
+	var e domain.IntakeEmail
+	var attachments []byte
+	err := row.Scan(
+		&e.ID, &e.Mailbox, &e.MessageID, &e.ThreadID, &e.FromAddress, &e.Subject, &e.Body,
+		&e.ReceivedAt, &e.Class, &attachments, &e.DraftID, &e.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan intake email: %w", err)
+	}
+	if err := json.Unmarshal(attachments, &e.Attachments); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+	return &e, nil
+}
+
+const shipmentDraftColumns = `id, mailbox, thread_id, subject, from_address, type, customer_id,
+	reference_number, steamship_line_code, vessel_name, voyage_number, last_free_day,
+	container_numbers, container_size, container_type, status, shipment_id,
+	reviewed_by, reviewed_at, reject_reason, created_at, updated_at`
+
+// CreateDraft creates a new shipment draft
+func (r *PostgresIntakeRepository) CreateDraft(ctx context.Context, d *domain.ShipmentDraft) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO shipment_drafts (`+shipmentDraftColumns+`) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
+		)`,
+		d.ID, d.Mailbox, d.ThreadID, d.Subject, d.FromAddress, d.Type, d.CustomerID,
+		d.ReferenceNumber, d.SteamshipLineCode, d.VesselName, d.VoyageNumber, d.LastFreeDay,
+		d.ContainerNumbers, d.ContainerSize, d.ContainerType, d.Status, d.ShipmentID,
+		d.ReviewedBy, d.ReviewedAt, d.RejectReason, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create shipment draft: %w", err)
+	}
+	return nil
+}
+
+// GetDraft retrieves a shipment draft by ID
+func (r *PostgresIntakeRepository) GetDraft(ctx context.Context, id uuid.UUID) (*domain.ShipmentDraft, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+shipmentDraftColumns+` FROM shipment_drafts WHERE id = $1`, id)
+	return scanShipmentDraft(row)
+}
+
+// GetDraftForUpdate retrieves a shipment draft and locks it for the rest of the transaction
+func (r *PostgresIntakeRepository) GetDraftForUpdate(ctx context.Context, id uuid.UUID) (*domain.ShipmentDraft, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+shipmentDraftColumns+` FROM shipment_drafts WHERE id = $1 FOR UPDATE`, id)
+	return scanShipmentDraft(row)
+}
+
+// GetPendingDraftByThread retrieves the draft still in review for an email thread
+func (r *PostgresIntakeRepository) GetPendingDraftByThread(ctx context.Context, mailbox, threadID string) (*domain.ShipmentDraft, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+shipmentDraftColumns+` FROM shipment_drafts
+		 WHERE mailbox = $1 AND thread_id = $2 AND status = $3
+		 ORDER BY created_at DESC LIMIT 1`, mailbox, threadID, domain.DraftStatusPendingReview)
+	return scanShipmentDraft(row)
+}
+
+// ListDrafts retrieves shipment drafts, newest first
+func (r *PostgresIntakeRepository) ListDrafts(ctx context.Context, filter DraftFilter) ([]domain.ShipmentDraft, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + shipmentDraftColumns + ` FROM shipment_drafts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipment drafts: %w", err)
+	}
+	defer rows.Close()
+
+	var drafts []domain.ShipmentDraft
+	for rows.Next() {
+		d, err := scanShipmentDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *d)
+	}
+	return drafts, rows.Err()
+}
+
+// UpdateDraft updates a shipment draft's extracted fields and review state
+func (r *PostgresIntakeRepository) UpdateDraft(ctx context.Context, d *domain.ShipmentDraft) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE shipment_drafts SET
+			type = $2, customer_id = $3, reference_number = $4, steamship_line_code = $5,
+			vessel_name = $6, voyage_number = $7, last_free_day = $8, container_numbers = $9,
+			container_size = $10, container_type = $11, status = $12, shipment_id = $13,
+			reviewed_by = $14, reviewed_at = $15, reject_reason = $16, updated_at = $17
+		WHERE id = $1`,
+		d.ID, d.Type, d.CustomerID, d.ReferenceNumber, d.SteamshipLineCode,
+		d.VesselName, d.VoyageNumber, d.LastFreeDay, d.ContainerNumbers,
+		d.ContainerSize, d.ContainerType, d.Status, d.ShipmentID,
+		d.ReviewedBy, d.ReviewedAt, d.RejectReason, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update shipment draft: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shipment draft not found: %s", d.ID)
+	}
+	return nil
+}
+
+func scanShipmentDraft(row pgx.Row) (*domain.ShipmentDraft, error) {
+	var d domain.ShipmentDraft
+	err := row.Scan(
+		&d.ID, &d.Mailbox, &d.ThreadID, &d.Subject, &d.FromAddress, &d.Type, &d.CustomerID,
+		&d.ReferenceNumber, &d.SteamshipLineCode, &d.VesselName, &d.VoyageNumber, &d.LastFreeDay,
+		&d.ContainerNumbers, &d.ContainerSize, &d.ContainerType, &d.Status, &d.ShipmentID,
+		&d.ReviewedBy, &d.ReviewedAt, &d.RejectReason, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan shipment draft: %w", err)
+	}
+	return &d, nil
+}
+
+// FindSenderCustomer returns the customer mapped to a sender's address, or
+// failing that to its domain
+func (r *PostgresIntakeRepository) FindSenderCustomer(ctx context.Context, address string) (*uuid.UUID, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	domainPart := address
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		domainPart = address[at:]
+	}
+
+	var customerID uuid.UUID
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT customer_id FROM intake_senders
+		WHERE address IN ($1, $2)
+		ORDER BY address = $1 DESC
+		LIMIT 1`, address, domainPart).Scan(&customerID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find intake sender: %w", err)
+	}
+	return &customerID, nil
+}
+
+// SaveSender maps a sender address or domain to a customer
+func (r *PostgresIntakeRepository) SaveSender(ctx context.Context, s *domain.IntakeSender) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO intake_senders (address, customer_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (address) DO UPDATE SET customer_id = EXCLUDED.customer_id`,
+		strings.ToLower(s.Address), s.CustomerID, s.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save intake sender: %w", err)
+	}
+	return nil
+}
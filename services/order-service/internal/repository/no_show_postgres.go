@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresNoShowRepository implements NoShowRepository using PostgreSQL
+type PostgresNoShowRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresNoShowRepository creates a new PostgreSQL appointment no-show repository
+func NewPostgresNoShowRepository(pool *pgxpool.Pool) *PostgresNoShowRepository {
+	return &PostgresNoShowRepository{pool: pool}
+}
+
+const noShowColumns = `n.id, n.appointment_id, n.order_id, n.order_number, n.customer_id, n.terminal_id,
+	COALESCE(t.name, ''), n.container_number, n.driver_id, n.trip_id, n.window_start_time, n.window_end_time,
+	n.detected_at, n.suggested_fault, n.suggested_reason, n.fault, n.fault_notes, n.attributed_by, n.attributed_at,
+	n.fee_amount, n.fee_reference, n.fee_assessed_at, n.fee_recorded_by,
+	n.billing_status, n.billed_amount, n.billed_by, n.billed_at, n.created_at, n.updated_at`
+
+const noShowFrom = ` FROM appointment_no_shows n LEFT JOIN locations t ON t.id = n.terminal_id`
+
+// Create records a new no-show
+func (r *PostgresNoShowRepository) Create(ctx context.Context, n *domain.AppointmentNoShow) error {
+	query := `
+		INSERT INTO appointment_no_shows (
+			id, appointment_id, order_id, order_number, customer_id, terminal_id, container_number,
+			driver_id, trip_id, window_start_time, window_end_time, detected_at,
+			suggested_fault, suggested_reason, fault, fault_notes, attributed_by, attributed_at,
+			fee_amount, fee_reference, fee_assessed_at, fee_recorded_by,
+			billing_status, billed_amount, billed_by, billed_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			$15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
+		)`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		n.ID, n.AppointmentID, n.OrderID, n.OrderNumber, n.CustomerID, n.TerminalID, n.ContainerNumber,
+		n.DriverID, n.TripID, n.WindowStartTime, n.WindowEndTime, n.DetectedAt,
+		n.SuggestedFault, n.SuggestedReason, n.Fault, n.FaultNotes, n.AttributedBy, n.AttributedAt,
+		n.FeeAmount, n.FeeReference, n.FeeAssessedAt, n.FeeRecordedBy,
+		n.BillingStatus, n.BilledAmount, n.BilledBy, n.BilledAt, n.CreatedAt, n.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment no-show: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a no-show by ID
+func (r *PostgresNoShowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AppointmentNoShow, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+noShowColumns+noShowFrom+` WHERE n.id = $1`, id)
+	return scanNoShow(row)
+}
+
+// GetByAppointmentID retrieves the no-show recorded for an appointment
+func (r *PostgresNoShowRepository) GetByAppointmentID(ctx context.Context, appointmentID uuid.UUID) (*domain.AppointmentNoShow, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+noShowColumns+noShowFrom+` WHERE n.appointment_id = $1`, appointmentID)
+	return scanNoShow(row)
+}
+
+// List retrieves no-shows by appointment window, newest first
+func (r *PostgresNoShowRepository) List(ctx context.Context, filter NoShowFilter) ([]domain.AppointmentNoShow, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.TerminalID != nil {
+		args = append(args, *filter.TerminalID)
+		conditions = append(conditions, fmt.Sprintf("n.terminal_id = $%d", len(args)))
+	}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("n.customer_id = $%d", len(args)))
+	}
+	if filter.Fault != "" {
+		args = append(args, filter.Fault)
+		conditions = append(conditions, fmt.Sprintf("n.fault = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("n.window_start_time >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("n.window_start_time < $%d", len(args)))
+	}
+
+	query := `SELECT ` + noShowColumns + noShowFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY n.window_start_time DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list appointment no-shows: %w", err)
+	}
+	defer rows.Close()
+
+	var noShows []domain.AppointmentNoShow
+	for rows.Next() {
+		n, err := scanNoShow(rows)
+		if err != nil {
+			return nil, err
+		}
+		noShows = append(noShows, *n)
+	}
+	return noShows, rows.Err()
+}
+
+// Update updates a no-show's attribution, fee and billing
+func (r *PostgresNoShowRepository) Update(ctx context.Context, n *domain.AppointmentNoShow) error {
+	query := `
+		UPDATE appointment_no_shows SET
+			fault = $2, fault_notes = $3, attributed_by = $4, attributed_at = $5,
+			fee_amount = $6, fee_reference = $7, fee_assessed_at = $8, fee_recorded_by = $9,
+			billing_status = $10, billed_amount = $11, billed_by = $12, billed_at = $13, updated_at = $14
+		WHERE id = $1`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query,
+		n.ID, n.Fault, n.FaultNotes, n.AttributedBy, n.AttributedAt,
+		n.FeeAmount, n.FeeReference, n.FeeAssessedAt, n.FeeRecordedBy,
+		n.BillingStatus, n.BilledAmount, n.BilledBy, n.BilledAt, n.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update appointment no-show: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("appointment no-show not found: %s", n.ID)
+	}
+	return nil
+}
+
+func scanNoShow(row pgx.Row) (*domain.AppointmentNoShow, error) {
+	var n domain.AppointmentNoShow
+	err := row.Scan(
+		&n.ID, &n.AppointmentID, &n.OrderID, &n.OrderNumber, &n.CustomerID, &n.TerminalID,
+		&n.TerminalName, &n.ContainerNumber, &n.DriverID, &n.TripID, &n.WindowStartTime, &n.WindowEndTime,
+		&n.DetectedAt, &n.SuggestedFault, &n.SuggestedReason, &n.Fault, &n.FaultNotes, &n.AttributedBy, &n.AttributedAt,
+		&n.FeeAmount, &n.FeeReference, &n.FeeAssessedAt, &n.FeeRecordedBy,
+		&n.BillingStatus, &n.BilledAmount, &n.BilledBy, &n.BilledAt, &n.CreatedAt, &n.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan appointment no-show: %w", err)
+	}
+	return &n, nil
+}
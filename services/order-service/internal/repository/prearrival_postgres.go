@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresPreArrivalRepository implements PreArrivalRepository using PostgreSQL
+type PostgresPreArrivalRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresPreArrivalRepository creates a new PostgreSQL pre-arrival workspace repository
+func NewPostgresPreArrivalRepository(pool *pgxpool.Pool) *PostgresPreArrivalRepository {
+	return &PostgresPreArrivalRepository{pool: pool}
+}
+
+// preArrivalQuery joins each import container to its shipment, customer,
+// open import order and latest active pickup appointment
+const preArrivalQuery = `
+	SELECT c.id, c.container_number, c.size, c.type, c.is_hazmat, c.is_overweight, c.customs_status,
+		c.terminal_hold, c.current_location_type, c.discharged_at,
+		s.id, s.reference_number, s.vessel_name, s.voyage_number, s.vessel_eta, s.vessel_ata, s.last_free_day,
+		s.terminal_id, COALESCE(t.name, ''), s.customer_id, COALESCE(cu.name, ''), COALESCE(cu.credit_hold, false),
+		o.id, COALESCE(o.order_number, ''), COALESCE(o.status::text, ''), o.pickup_location_id,
+		o.delivery_location_id, o.return_location_id, o.requested_pickup_date,
+		a.id, COALESCE(a.status::text, ''), a.window_start_time,
+		o.draft_trip_requested_at, o.draft_trip_sent_at
+	FROM containers c
+	JOIN shipments s ON s.id = c.shipment_id
+	LEFT JOIN locations t ON t.id = s.terminal_id
+	LEFT JOIN customers cu ON cu.id = s.customer_id
+	LEFT JOIN orders o ON o.container_id = c.id AND o.type = 'IMPORT' AND o.status NOT IN ('CANCELLED', 'FAILED')
+	LEFT JOIN LATERAL (
+		SELECT ta.id, ta.status, ta.window_start_time FROM terminal_appointments ta
+		WHERE ta.order_id = o.id AND ta.type = 'PICKUP' AND ta.status IN ('REQUESTED', 'PENDING', 'CONFIRMED')
+		ORDER BY ta.window_start_time DESC LIMIT 1
+	) a ON true
+	WHERE s.type = 'IMPORT'`
+
+// ListContainers lists import containers on vessels due in the filter's ETA
+// range that have not left the terminal, by ETA then vessel
+func (r *PostgresPreArrivalRepository) ListContainers(ctx context.Context, filter PreArrivalFilter) ([]domain.PreArrivalContainer, error) {
+	query := preArrivalQuery + `
+		AND s.status IN ('PENDING', 'IN_PROGRESS')
+		AND c.current_location_type IN ('VESSEL', 'TERMINAL')
+		AND s.vessel_eta >= $1 AND s.vessel_eta < $2`
+	args := []interface{}{filter.ETAFrom, filter.ETATo}
+
+	if filter.TerminalID != nil {
+		args = append(args, *filter.TerminalID)
+		query += fmt.Sprintf(" AND s.terminal_id = $%d", len(args))
+	}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		query += fmt.Sprintf(" AND s.customer_id = $%d", len(args))
+	}
+	query += " ORDER BY s.vessel_eta, s.vessel_name, s.voyage_number, c.container_number"
+
+	return r.query(ctx, query, args...)
+}
+
+// GetByOrderIDs retrieves the workspace rows for the given orders
+func (r *PostgresPreArrivalRepository) GetByOrderIDs(ctx context.Context, orderIDs []uuid.UUID) ([]domain.PreArrivalContainer, error) {
+	return r.query(ctx, preArrivalQuery+` AND o.id = ANY($1) ORDER BY c.container_number`, orderIDs)
+}
+
+// GetQueuedDraftTrips retrieves the container's orders with a draft trip requested but not yet sent
+func (r *PostgresPreArrivalRepository) GetQueuedDraftTrips(ctx context.Context, containerNumber string) ([]domain.PreArrivalContainer, error) {
+	return r.query(ctx, preArrivalQuery+`
+		AND c.container_number = $1 AND o.draft_trip_requested_at IS NOT NULL AND o.draft_trip_sent_at IS NULL`, containerNumber)
+}
+
+// MarkDraftTripRequested records that a planner asked for a draft trip for the order
+func (r *PostgresPreArrivalRepository) MarkDraftTripRequested(ctx context.Context, orderID uuid.UUID, requestedBy string, at time.Time) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE orders SET draft_trip_requested_at = $2, draft_trip_requested_by = $3, updated_at = $2
+		 WHERE id = $1`, orderID, at, requestedBy)
+	if err != nil {
+		return fmt.Errorf("failed to mark draft trip requested: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("order not found: %s", orderID)
+	}
+	return nil
+}
+
+// MarkDraftTripSent records that the order's draft trip request went to dispatch
+func (r *PostgresPreArrivalRepository) MarkDraftTripSent(ctx context.Context, orderID uuid.UUID, at time.Time) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE orders SET draft_trip_sent_at = $2, updated_at = $2 WHERE id = $1`, orderID, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark draft trip sent: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("order not found: %s", orderID)
+	}
+	return nil
+}
+
+func (r *PostgresPreArrivalRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.PreArrivalContainer, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pre-arrival containers: %w", err)
+	}
+	defer rows.Close()
+
+	var containers []domain.PreArrivalContainer
+	for rows.Next() {
+		c, err := scanPreArrivalContainer(rows)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, *c)
+	}
+	return containers, rows.Err()
+}
+
+func scanPreArrivalContainer(row pgx.Row) (*domain.PreArrivalContainer, error) {
+	var c domain.PreArrivalContainer
+	err := row.Scan(
+		&c.ContainerID, &c.ContainerNumber, &c.Size, &c.Type, &c.IsHazmat, &c.IsOverweight, &c.CustomsStatus,
+		&c.TerminalHold, &c.CurrentLocationType, &c.DischargedAt,
+		&c.ShipmentID, &c.ShipmentReference, &c.VesselName, &c.VoyageNumber, &c.VesselETA, &c.VesselATA, &c.LastFreeDay,
+		&c.TerminalID, &c.TerminalName, &c.CustomerID, &c.CustomerName, &c.CreditHold,
+		&c.OrderID, &c.OrderNumber, &c.OrderStatus, &c.PickupLocationID,
+		&c.DeliveryLocationID, &c.ReturnLocationID, &c.RequestedPickupDate,
+		&c.AppointmentID, &c.AppointmentStatus, &c.AppointmentTime,
+		&c.DraftTripRequestedAt, &c.DraftTripSentAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pre-arrival container: %w", err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresRateApprovalRepository implements RateApprovalRepository using PostgreSQL
+type PostgresRateApprovalRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRateApprovalRepository creates a new PostgreSQL rate approval repository
+func NewPostgresRateApprovalRepository(pool *pgxpool.Pool) *PostgresRateApprovalRepository {
+	return &PostgresRateApprovalRepository{pool: pool}
+}
+
+const rateApprovalColumns = `id, order_id, order_number, customer_id, quoted_rate, contract_rate,
+	contract_rate_id, deviation_amount, deviation_percent, reason, status,
+	requested_by, requested_at, decided_by, decided_at, created_at, updated_at`
+
+// Create creates a new rate approval
+func (r *PostgresRateApprovalRepository) Create(ctx context.Context, a *domain.RateApproval) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO order_rate_approvals (`+rateApprovalColumns+`) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+		)`,
+		a.ID, a.OrderID, a.OrderNumber, a.CustomerID, a.QuotedRate, a.ContractRate,
+		a.ContractRateID, a.DeviationAmount, a.DeviationPercent, a.Reason, a.Status,
+		a.RequestedBy, a.RequestedAt, a.DecidedBy, a.DecidedAt, a.CreatedAt, a.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create rate approval: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a rate approval by ID
+func (r *PostgresRateApprovalRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RateApproval, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+rateApprovalColumns+` FROM order_rate_approvals WHERE id = $1`, id)
+	return scanRateApproval(row)
+}
+
+// GetByIDForUpdate retrieves a rate approval and locks it for the rest of the transaction
+func (r *PostgresRateApprovalRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.RateApproval, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+rateApprovalColumns+` FROM order_rate_approvals WHERE id = $1 FOR UPDATE`, id)
+	return scanRateApproval(row)
+}
+
+// GetLatestForOrder retrieves the most recently requested approval for an order
+func (r *PostgresRateApprovalRepository) GetLatestForOrder(ctx context.Context, orderID uuid.UUID) (*domain.RateApproval, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+rateApprovalColumns+` FROM order_rate_approvals
+		 WHERE order_id = $1 ORDER BY requested_at DESC LIMIT 1`, orderID)
+	return scanRateApproval(row)
+}
+
+// ListForOrder retrieves every approval requested for an order, newest first
+func (r *PostgresRateApprovalRepository) ListForOrder(ctx context.Context, orderID uuid.UUID) ([]domain.RateApproval, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+rateApprovalColumns+` FROM order_rate_approvals
+		 WHERE order_id = $1 ORDER BY requested_at DESC`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate approvals: %w", err)
+	}
+	return collectRateApprovals(rows)
+}
+
+// List retrieves rate approvals, oldest request first so the queue is
+// worked in order
+func (r *PostgresRateApprovalRepository) List(ctx context.Context, filter RateApprovalFilter) ([]domain.RateApproval, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+
+	query := `SELECT ` + rateApprovalColumns + ` FROM order_rate_approvals`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY requested_at LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate approvals: %w", err)
+	}
+	return collectRateApprovals(rows)
+}
+
+// Update updates a rate approval's decision
+func (r *PostgresRateApprovalRepository) Update(ctx context.Context, a *domain.RateApproval) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE order_rate_approvals SET
+			status = $2, decided_by = $3, decided_at = $4, updated_at = $5
+		WHERE id = $1`,
+		a.ID, a.Status, a.DecidedBy, a.DecidedAt, a.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update rate approval: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("rate approval not found: %s", a.ID)
+	}
+	return nil
+}
+
+func collectRateApprovals(rows pgx.Rows) ([]domain.RateApproval, error) {
+	defer rows.Close()
+
+	var approvals []domain.RateApproval
+	for rows.Next() {
+		a, err := scanRateApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, *a)
+	}
+	return approvals, rows.Err()
+}
+
+func scanRateApproval(row pgx.Row) (*domain.RateApproval, error) {
+	var a domain.RateApproval
+	err := row.Scan(
+		&a.ID, &a.OrderID, &a.OrderNumber, &a.CustomerID, &a.QuotedRate, &a.ContractRate,
+		&a.ContractRateID, &a.DeviationAmount, &a.DeviationPercent, &a.Reason, &a.Status,
+		&a.RequestedBy, &a.RequestedAt, &a.DecidedBy, &a.DecidedAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan rate approval: %w", err)
+	}
+	return &a, nil
+}
+
+// AddComment adds a comment to a rate approval
+func (r *PostgresRateApprovalRepository) AddComment(ctx context.Context, c *domain.RateApprovalComment) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO order_rate_approval_comments (id, approval_id, author, body, decision, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.ID, c.ApprovalID, c.Author, c.Body, c.Decision, c.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add rate approval comment: %w", err)
+	}
+	return nil
+}
+
+// ListComments retrieves a rate approval's comments, oldest first
+func (r *PostgresRateApprovalRepository) ListComments(ctx context.Context, approvalID uuid.UUID) ([]domain.RateApprovalComment, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, approval_id, author, body, decision, created_at
+		FROM order_rate_approval_comments
+		WHERE approval_id = $1 ORDER BY created_at`, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate approval comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []domain.RateApprovalComment
+	for rows.Next() {
+		var c domain.RateApprovalComment
+		if err := rows.Scan(&c.ID, &c.ApprovalID, &c.Author, &c.Body, &c.Decision, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rate approval comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
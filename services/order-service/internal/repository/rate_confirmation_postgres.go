@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresRateConfirmationRepository implements RateConfirmationRepository using PostgreSQL
+type PostgresRateConfirmationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRateConfirmationRepository creates a new PostgreSQL rate confirmation repository
+func NewPostgresRateConfirmationRepository(pool *pgxpool.Pool) *PostgresRateConfirmationRepository {
+	return &PostgresRateConfirmationRepository{pool: pool}
+}
+
+const rateConfirmationColumns = `id, order_id, tender_id, number, rate, currency, provider, envelope_id,
+	signer_name, signer_email, status, status_reason, filename, sent_by, sent_at, signed_at,
+	created_at, updated_at`
+
+// Create creates a new rate confirmation
+func (r *PostgresRateConfirmationRepository) Create(ctx context.Context, rc *domain.RateConfirmation) error {
+	query := `
+		INSERT INTO rate_confirmations (` + rateConfirmationColumns + `, document, signed_document) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+		)`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		rc.ID, rc.OrderID, rc.TenderID, rc.Number, rc.Rate, rc.Currency, rc.Provider, rc.EnvelopeID,
+		rc.SignerName, rc.SignerEmail, rc.Status, rc.StatusReason, rc.Filename, rc.SentBy, rc.SentAt, rc.SignedAt,
+		rc.CreatedAt, rc.UpdatedAt, rc.Document, rc.SignedDocument,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create rate confirmation: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a rate confirmation with its documents
+func (r *PostgresRateConfirmationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RateConfirmation, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+rateConfirmationColumns+`, document, signed_document FROM rate_confirmations WHERE id = $1`, id)
+	return scanRateConfirmation(row, true)
+}
+
+// GetByEnvelopeID retrieves the rate confirmation sent as a provider envelope, with its documents
+func (r *PostgresRateConfirmationRepository) GetByEnvelopeID(ctx context.Context, provider, envelopeID string) (*domain.RateConfirmation, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+rateConfirmationColumns+`, document, signed_document FROM rate_confirmations
+		 WHERE provider = $1 AND envelope_id = $2`, provider, envelopeID)
+	return scanRateConfirmation(row, true)
+}
+
+// GetByOrderID retrieves an order's rate confirmations, newest first, without documents
+func (r *PostgresRateConfirmationRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]domain.RateConfirmation, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+rateConfirmationColumns+` FROM rate_confirmations
+		 WHERE order_id = $1 ORDER BY created_at DESC`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate confirmations: %w", err)
+	}
+	defer rows.Close()
+
+	var confirmations []domain.RateConfirmation
+	for rows.Next() {
+		rc, err := scanRateConfirmation(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		confirmations = append(confirmations, *rc)
+	}
+	return confirmations, rows.Err()
+}
+
+// Update updates a rate confirmation's signing state and signed copy
+func (r *PostgresRateConfirmationRepository) Update(ctx context.Context, rc *domain.RateConfirmation) error {
+	query := `
+		UPDATE rate_confirmations SET
+			status = $2, status_reason = $3, signed_document = $4, signed_at = $5, updated_at = $6
+		WHERE id = $1`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query,
+		rc.ID, rc.Status, rc.StatusReason, rc.SignedDocument, rc.SignedAt, rc.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update rate confirmation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("rate confirmation not found: %s", rc.ID)
+	}
+	return nil
+}
+
+func scanRateConfirmation(row pgx.Row, withDocuments bool) (*domain.RateConfirmation, error) {
+	var rc domain.RateConfirmation
+	dest := []interface{}{
+		&rc.ID, &rc.OrderID, &rc.TenderID, &rc.Number, &rc.Rate, &rc.Currency, &rc.Provider, &rc.EnvelopeID,
+		&rc.SignerName, &rc.SignerEmail, &rc.Status, &rc.StatusReason, &rc.Filename, &rc.SentBy, &rc.SentAt, &rc.SignedAt,
+		&rc.CreatedAt, &rc.UpdatedAt,
+	}
+	if withDocuments {
+		dest = append(dest, &rc.Document, &rc.SignedDocument)
+	}
+	if err := row.Scan(dest...); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan rate confirmation: %w", err)
+	}
+	return &rc, nil
+}
@@ -14,6 +14,7 @@ type ShipmentRepository interface {
 	Create(ctx context.Context, shipment *domain.Shipment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Shipment, error)
 	GetByReferenceNumber(ctx context.Context, refNum string) (*domain.Shipment, error)
+	FindByIdentifier(ctx context.Context, identifier string) ([]*domain.Shipment, error) // Reference, booking or bill of lading number
 	List(ctx context.Context, filter ShipmentFilter) ([]*domain.Shipment, int64, error)
 	Update(ctx context.Context, shipment *domain.Shipment) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.ShipmentStatus) error
@@ -47,11 +48,16 @@ type ContainerRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
-// OrderRepository defines the interface for order data access
+// OrderRepository defines the interface for order data access.
+// Create starts an order at version 1. Update only applies when order.Version
+// matches the stored row, bumps the version, and returns
+// database.ErrVersionConflict otherwise. Queries run on the transaction
+// carried by ctx (database.WithTx) when there is one.
 type OrderRepository interface {
 	Create(ctx context.Context, order *domain.Order) error
 	CreateBatch(ctx context.Context, orders []*domain.Order) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Order, error) // SELECT ... FOR UPDATE, requires a transaction
 	GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error)
 	GetByContainerID(ctx context.Context, containerID uuid.UUID) (*domain.Order, error)
 	List(ctx context.Context, filter OrderFilter) ([]*domain.Order, int64, error)
@@ -63,14 +69,26 @@ type OrderRepository interface {
 
 // OrderFilter contains filter criteria for listing orders
 type OrderFilter struct {
-	ShipmentID *uuid.UUID
-	CustomerID *uuid.UUID
-	Status     domain.OrderStatus
-	Type       domain.OrderType
-	DateFrom   *time.Time
-	DateTo     *time.Time
-	Page       int
-	PageSize   int
+	ShipmentID   *uuid.UUID
+	CustomerID   *uuid.UUID
+	Status       domain.OrderStatus
+	Type         domain.OrderType
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Tags         []string // Orders with any of these tags
+	MatchAllTags bool     // Orders with every one of Tags instead
+	Page         int
+	PageSize     int
+}
+
+// SavedOrderFilterRepository defines the interface for users' saved order filters
+type SavedOrderFilterRepository interface {
+	Create(ctx context.Context, filter *domain.SavedOrderFilter) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SavedOrderFilter, error)
+	ListByUser(ctx context.Context, userID string) ([]domain.SavedOrderFilter, error) // Default first, then by name
+	Update(ctx context.Context, filter *domain.SavedOrderFilter) error
+	ClearDefault(ctx context.Context, userID string) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 // LocationRepository defines the interface for location data access
@@ -92,6 +110,22 @@ type CustomerRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// CalendarRepository defines the interface for holiday and closure data access
+type CalendarRepository interface {
+	Create(ctx context.Context, closure *domain.CalendarClosure) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByDateRange(ctx context.Context, start, end time.Time) ([]domain.CalendarClosure, error)
+}
+
+// VGMRepository defines the interface for verified gross mass data access
+type VGMRepository interface {
+	Create(ctx context.Context, record *domain.VGMRecord) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.VGMRecord, error)
+	GetLatestByContainerID(ctx context.Context, containerID uuid.UUID) (*domain.VGMRecord, error)
+	GetByStatus(ctx context.Context, status domain.VGMStatus) ([]domain.VGMRecord, error)
+	Update(ctx context.Context, record *domain.VGMRecord) error
+}
+
 // SteamshipLineRepository defines the interface for SSL data access
 type SteamshipLineRepository interface {
 	Create(ctx context.Context, ssl *domain.SteamshipLine) error
@@ -99,3 +133,185 @@ type SteamshipLineRepository interface {
 	GetByCode(ctx context.Context, code string) (*domain.SteamshipLine, error)
 	List(ctx context.Context) ([]*domain.SteamshipLine, error)
 }
+
+// BrokerRepository defines the interface for broker data access
+type BrokerRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Broker, error)
+	GetByAPIKeyHash(ctx context.Context, hash string) (*domain.Broker, error)
+}
+
+// LoadTenderRepository defines the interface for broker load tender data access
+type LoadTenderRepository interface {
+	Create(ctx context.Context, tender *domain.LoadTender) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.LoadTender, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.LoadTender, error) // SELECT ... FOR UPDATE, requires a transaction
+	GetByBrokerReference(ctx context.Context, brokerID uuid.UUID, reference string) (*domain.LoadTender, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*domain.LoadTender, error)
+	List(ctx context.Context, filter TenderFilter) ([]domain.LoadTender, error)
+	Update(ctx context.Context, tender *domain.LoadTender) error
+}
+
+// TenderFilter contains filter criteria for listing load tenders
+type TenderFilter struct {
+	BrokerID *uuid.UUID
+	Status   domain.TenderStatus
+	Limit    int
+}
+
+// RateConfirmationRepository defines the interface for rate confirmation
+// data access. GetByOrderID leaves the PDFs unloaded; the single lookups
+// load them.
+type RateConfirmationRepository interface {
+	Create(ctx context.Context, rc *domain.RateConfirmation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RateConfirmation, error)
+	GetByEnvelopeID(ctx context.Context, provider, envelopeID string) (*domain.RateConfirmation, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]domain.RateConfirmation, error) // Newest first
+	Update(ctx context.Context, rc *domain.RateConfirmation) error
+}
+
+// AppointmentRepository defines the interface for terminal appointment data access
+type AppointmentRepository interface {
+	Create(ctx context.Context, appointment *domain.TerminalAppointment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.TerminalAppointment, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]domain.TerminalAppointment, error)
+	GetByTerminalAndTimeRange(ctx context.Context, terminalID uuid.UUID, start, end time.Time) ([]domain.TerminalAppointment, error)
+	GetByTimeRange(ctx context.Context, start, end time.Time) ([]domain.TerminalAppointment, error) // By window start, all terminals
+	Update(ctx context.Context, appointment *domain.TerminalAppointment) error
+}
+
+// TerminalRepository defines the interface for terminal gate hours data access
+type TerminalRepository interface {
+	GetGateHours(ctx context.Context, terminalID uuid.UUID) ([]domain.TerminalGateHours, error) // Weekly hours and special dates
+}
+
+// NoShowRepository defines the interface for missed terminal appointment
+// data access. Each appointment has at most one no-show; lookups come back
+// with the terminal's name and return nil when missing.
+type NoShowRepository interface {
+	Create(ctx context.Context, noShow *domain.AppointmentNoShow) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.AppointmentNoShow, error)
+	GetByAppointmentID(ctx context.Context, appointmentID uuid.UUID) (*domain.AppointmentNoShow, error)
+	List(ctx context.Context, filter NoShowFilter) ([]domain.AppointmentNoShow, error) // By window start, newest first
+	Update(ctx context.Context, noShow *domain.AppointmentNoShow) error
+}
+
+// NoShowFilter contains filter criteria for listing no-shows. From and To
+// bound the appointment window start; zero leaves that side open.
+type NoShowFilter struct {
+	TerminalID *uuid.UUID
+	CustomerID *uuid.UUID
+	Fault      domain.NoShowFault
+	From       time.Time
+	To         time.Time
+	Limit      int // 0 lists every match
+}
+
+// BookingPreferenceRepository defines the interface for appointment booking preference data access
+type BookingPreferenceRepository interface {
+	GetByTerminalID(ctx context.Context, terminalID uuid.UUID) (*domain.AppointmentBookingPreference, error)
+	Upsert(ctx context.Context, preference *domain.AppointmentBookingPreference) error
+}
+
+// FacilityProfileRepository defines the interface for shipper and consignee facility profile data access
+type FacilityProfileRepository interface {
+	GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.FacilityProfile, error)
+	Upsert(ctx context.Context, profile *domain.FacilityProfile) error
+}
+
+// PreArrivalRepository defines the interface for the pre-arrival planning
+// workspace. Containers come back with their order, active pickup
+// appointment and customer credit hold; flags are left to the caller.
+type PreArrivalRepository interface {
+	ListContainers(ctx context.Context, filter PreArrivalFilter) ([]domain.PreArrivalContainer, error)
+	GetByOrderIDs(ctx context.Context, orderIDs []uuid.UUID) ([]domain.PreArrivalContainer, error)
+	GetQueuedDraftTrips(ctx context.Context, containerNumber string) ([]domain.PreArrivalContainer, error) // Draft trips requested but not yet sent
+	MarkDraftTripRequested(ctx context.Context, orderID uuid.UUID, requestedBy string, at time.Time) error
+	MarkDraftTripSent(ctx context.Context, orderID uuid.UUID, at time.Time) error
+}
+
+// PreArrivalFilter contains filter criteria for the pre-arrival workspace
+type PreArrivalFilter struct {
+	TerminalID *uuid.UUID
+	CustomerID *uuid.UUID
+	ETAFrom    time.Time
+	ETATo      time.Time
+}
+
+// IntakeRepository defines the interface for the order intake mailbox:
+// the emails read from it, the shipment drafts built from them and the
+// sender addresses mapped to customers. Lookups return nil when missing.
+type IntakeRepository interface {
+	CreateEmail(ctx context.Context, email *domain.IntakeEmail) (bool, error) // False when the message was already read
+	UpdateEmail(ctx context.Context, email *domain.IntakeEmail) error
+	GetEmail(ctx context.Context, id uuid.UUID) (*domain.IntakeEmail, error)
+	GetEmailsByDraftID(ctx context.Context, draftID uuid.UUID) ([]domain.IntakeEmail, error) // Oldest first
+
+	CreateDraft(ctx context.Context, draft *domain.ShipmentDraft) error
+	GetDraft(ctx context.Context, id uuid.UUID) (*domain.ShipmentDraft, error)
+	GetDraftForUpdate(ctx context.Context, id uuid.UUID) (*domain.ShipmentDraft, error) // SELECT ... FOR UPDATE, requires a transaction
+	GetPendingDraftByThread(ctx context.Context, mailbox, threadID string) (*domain.ShipmentDraft, error)
+	ListDrafts(ctx context.Context, filter DraftFilter) ([]domain.ShipmentDraft, error) // Newest first
+	UpdateDraft(ctx context.Context, draft *domain.ShipmentDraft) error
+
+	FindSenderCustomer(ctx context.Context, address string) (*uuid.UUID, error) // Exact address first, then its domain
+	SaveSender(ctx context.Context, sender *domain.IntakeSender) error
+}
+
+// DraftFilter contains filter criteria for listing shipment drafts
+type DraftFilter struct {
+	Status domain.DraftStatus
+	Limit  int
+}
+
+// ContainerChargeRepository defines the interface for the per-diem and
+// demurrage materialized by the nightly accrual, one row per container and
+// charge type, and the warnings already sent for them
+type ContainerChargeRepository interface {
+	Upsert(ctx context.Context, charge *domain.ContainerCharge) error
+	GetByContainerID(ctx context.Context, containerID uuid.UUID) ([]domain.ContainerCharge, error)
+	List(ctx context.Context, filter ContainerChargeFilter) ([]domain.ContainerCharge, error)                                // Largest amount first
+	MarkWarned(ctx context.Context, containerID uuid.UUID, warning string, lastFreeDay time.Time, daysOut int) (bool, error) // False when already sent
+}
+
+// ContainerChargeFilter contains filter criteria for listing accrued charges
+type ContainerChargeFilter struct {
+	CustomerID *uuid.UUID
+	TerminalID *uuid.UUID
+	ChargeType domain.ChargeType
+	MinAmount  float64
+	Limit      int
+}
+
+// RateApprovalRepository defines the interface for rate exception approvals
+// and their comments. Lookups return nil when missing.
+type RateApprovalRepository interface {
+	Create(ctx context.Context, approval *domain.RateApproval) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RateApproval, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.RateApproval, error) // SELECT ... FOR UPDATE, requires a transaction
+	GetLatestForOrder(ctx context.Context, orderID uuid.UUID) (*domain.RateApproval, error)
+	ListForOrder(ctx context.Context, orderID uuid.UUID) ([]domain.RateApproval, error) // Newest first
+	List(ctx context.Context, filter RateApprovalFilter) ([]domain.RateApproval, error) // Oldest request first
+	Update(ctx context.Context, approval *domain.RateApproval) error
+
+	AddComment(ctx context.Context, comment *domain.RateApprovalComment) error
+	ListComments(ctx context.Context, approvalID uuid.UUID) ([]domain.RateApprovalComment, error) // Oldest first
+}
+
+// RateApprovalFilter contains filter criteria for the rate approval queue
+type RateApprovalFilter struct {
+	Status     domain.RateApprovalStatus
+	CustomerID *uuid.UUID
+	Limit      int
+}
+
+// VesselTrackingRepository defines the interface for AIS tracks of inbound
+// vessels and the shipment ETA changes made from them
+type VesselTrackingRepository interface {
+	// ListInboundVoyages returns the voyages of open import shipments not yet
+	// arrived, due before etaBefore or with no ETA, with their tracks
+	ListInboundVoyages(ctx context.Context, etaBefore time.Time) ([]domain.InboundVoyage, error)
+	SaveTrack(ctx context.Context, track *domain.VesselTrack) error                             // Insert or update by ID
+	ListTracks(ctx context.Context, checkedSince time.Time) ([]domain.VesselTrack, error)       // By predicted ETA
+	RecordETAChange(ctx context.Context, change *domain.VesselETAChange) error                  // Sets the shipment's vessel ETA too
+	ListETAChanges(ctx context.Context, shipmentID uuid.UUID) ([]domain.VesselETAChange, error) // Newest first
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresSavedOrderFilterRepository implements SavedOrderFilterRepository using PostgreSQL
+type PostgresSavedOrderFilterRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSavedOrderFilterRepository creates a new PostgreSQL saved order filter repository
+func NewPostgresSavedOrderFilterRepository(pool *pgxpool.Pool) *PostgresSavedOrderFilterRepository {
+	return &PostgresSavedOrderFilterRepository{pool: pool}
+}
+
+const savedOrderFilterColumns = `id, user_id, name, criteria, is_default, created_at, updated_at`
+
+// Create creates a new saved filter
+func (r *PostgresSavedOrderFilterRepository) Create(ctx context.Context, f *domain.SavedOrderFilter) error {
+	criteria, err := json.Marshal(f.Criteria)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO saved_order_filters (`+savedOrderFilterColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		f.ID, f.UserID, f.Name, criteria, f.IsDefault, f.CreatedAt, f.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create saved filter: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a saved filter by ID
+func (r *PostgresSavedOrderFilterRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SavedOrderFilter, error) {
+	f, err := scanSavedOrderFilter(conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+savedOrderFilterColumns+` FROM saved_order_filters WHERE id = $1`, id,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get saved filter: %w", err)
+	}
+	return f, nil
+}
+
+// ListByUser retrieves a user's saved filters, default first, then by name
+func (r *PostgresSavedOrderFilterRepository) ListByUser(ctx context.Context, userID string) ([]domain.SavedOrderFilter, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+savedOrderFilterColumns+` FROM saved_order_filters
+		 WHERE user_id = $1
+		 ORDER BY is_default DESC, LOWER(name)`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	var filters []domain.SavedOrderFilter
+	for rows.Next() {
+		f, err := scanSavedOrderFilter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+		filters = append(filters, *f)
+	}
+	return filters, rows.Err()
+}
+
+// Update saves a filter's name, criteria and default flag
+func (r *PostgresSavedOrderFilterRepository) Update(ctx context.Context, f *domain.SavedOrderFilter) error {
+	criteria, err := json.Marshal(f.Criteria)
+	if err != nil {
+		return err
+	}
+
+	result, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE saved_order_filters
+		 SET name = $2, criteria = $3, is_default = $4, updated_at = $5
+		 WHERE id = $1`,
+		f.ID, f.Name, criteria, f.IsDefault, f.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update saved filter: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("saved filter not found: %s", f.ID)
+	}
+	return nil
+}
+
+// ClearDefault unmarks a user's default filter, if any
+func (r *PostgresSavedOrderFilterRepository) ClearDefault(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE saved_order_filters SET is_default = FALSE, updated_at = NOW()
+		 WHERE user_id = $1 AND is_default`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear default filter: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a saved filter
+func (r *PostgresSavedOrderFilterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM saved_order_filters WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("saved filter not found: %s", id)
+	}
+	return nil
+}
+
+func scanSavedOrderFilter(row pgx.Row) (*domain.SavedOrderFilter, error) {
+	var f domain.SavedOrderFilter
+	var criteria []byte
+	if err := row.Scan(&f.ID, &f.UserID, &f.Name, &criteria, &f.IsDefault, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(criteria, &f.Criteria); err != nil {
+		return nil, fmt.Errorf("failed to decode filter criteria: %w", err)
+	}
+	return &f, nil
+}
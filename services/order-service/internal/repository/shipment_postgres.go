@@ -157,6 +157,46 @@ func (r *PostgresShipmentRepository) GetByReferenceNumber(ctx context.Context, r
 	return r.GetByID(ctx, id)
 }
 
+// FindByIdentifier retrieves shipments whose reference, booking or bill of
+// lading number matches identifier, ignoring case
+func (r *PostgresShipmentRepository) FindByIdentifier(ctx context.Context, identifier string) ([]*domain.Shipment, error) {
+	query := `
+		SELECT id FROM shipments
+		WHERE UPPER(reference_number) = UPPER($1)
+		   OR UPPER(booking_number) = UPPER($1)
+		   OR UPPER(bill_of_lading) = UPPER($1)
+		ORDER BY created_at DESC
+		LIMIT 10`
+
+	rows, err := r.pool.Query(ctx, query, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shipments: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan shipment: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find shipments: %w", err)
+	}
+
+	shipments := make([]*domain.Shipment, 0, len(ids))
+	for _, id := range ids {
+		shipment, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	return shipments, nil
+}
+
 // List retrieves shipments based on filter criteria
 func (r *PostgresShipmentRepository) List(ctx context.Context, filter ShipmentFilter) ([]*domain.Shipment, int64, error) {
 	var conditions []string
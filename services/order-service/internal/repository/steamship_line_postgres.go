@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresSteamshipLineRepository implements SteamshipLineRepository using
+// PostgreSQL. Shipments reference steamship_lines by foreign key, so lookups
+// read the order-service table rather than the reference data service.
+type PostgresSteamshipLineRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSteamshipLineRepository creates a new PostgreSQL steamship line repository
+func NewPostgresSteamshipLineRepository(pool *pgxpool.Pool) *PostgresSteamshipLineRepository {
+	return &PostgresSteamshipLineRepository{pool: pool}
+}
+
+const steamshipLineColumns = `id, name, code, created_at, updated_at`
+
+// Create creates a new steamship line
+func (r *PostgresSteamshipLineRepository) Create(ctx context.Context, ssl *domain.SteamshipLine) error {
+	now := time.Now()
+	if ssl.CreatedAt.IsZero() {
+		ssl.CreatedAt = now
+	}
+	ssl.UpdatedAt = now
+	ssl.Code = strings.ToUpper(strings.TrimSpace(ssl.Code))
+
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO steamship_lines (id, name, code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		ssl.ID, ssl.Name, ssl.Code, ssl.CreatedAt, ssl.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create steamship line: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a steamship line by ID
+func (r *PostgresSteamshipLineRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SteamshipLine, error) {
+	return r.get(ctx, `WHERE id = $1`, id)
+}
+
+// GetByCode retrieves a steamship line by SCAC code, ignoring case
+func (r *PostgresSteamshipLineRepository) GetByCode(ctx context.Context, code string) (*domain.SteamshipLine, error) {
+	return r.get(ctx, `WHERE code = $1`, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func (r *PostgresSteamshipLineRepository) get(ctx context.Context, where string, arg interface{}) (*domain.SteamshipLine, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+steamshipLineColumns+` FROM steamship_lines `+where, arg)
+	ssl, err := scanSteamshipLine(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get steamship line: %w", err)
+	}
+	return ssl, nil
+}
+
+// List retrieves all steamship lines ordered by name
+func (r *PostgresSteamshipLineRepository) List(ctx context.Context) ([]*domain.SteamshipLine, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `SELECT `+steamshipLineColumns+` FROM steamship_lines ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list steamship lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.SteamshipLine
+	for rows.Next() {
+		ssl, err := scanSteamshipLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan steamship line: %w", err)
+		}
+		lines = append(lines, ssl)
+	}
+	return lines, rows.Err()
+}
+
+func scanSteamshipLine(row pgx.Row) (*domain.SteamshipLine, error) {
+	var ssl domain.SteamshipLine
+	if err := row.Scan(&ssl.ID, &ssl.Name, &ssl.Code, &ssl.CreatedAt, &ssl.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &ssl, nil
+}
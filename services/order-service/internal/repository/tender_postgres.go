@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// querier is the subset of pgxpool.Pool and pgx.Tx used by the tender repositories
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// conn returns the transaction carried by ctx, or the pool
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+	if tx, ok := database.TxFromContext(ctx); ok {
+		return tx
+	}
+	return pool
+}
+
+// PostgresBrokerRepository implements BrokerRepository using PostgreSQL
+type PostgresBrokerRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresBrokerRepository creates a new PostgreSQL broker repository
+func NewPostgresBrokerRepository(pool *pgxpool.Pool) *PostgresBrokerRepository {
+	return &PostgresBrokerRepository{pool: pool}
+}
+
+const brokerColumns = `id, name, mc_number, customer_id, api_key_hash, webhook_url, webhook_secret,
+	active, created_at, updated_at, rate_confirmation_email, require_signed_rate_confirmation`
+
+// GetByID retrieves a broker by ID
+func (r *PostgresBrokerRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Broker, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+brokerColumns+` FROM brokers WHERE id = $1`, id)
+	return scanBroker(row)
+}
+
+// GetByAPIKeyHash retrieves the broker that owns an API key
+func (r *PostgresBrokerRepository) GetByAPIKeyHash(ctx context.Context, hash string) (*domain.Broker, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+brokerColumns+` FROM brokers WHERE api_key_hash = $1`, hash)
+	return scanBroker(row)
+}
+
+func scanBroker(row pgx.Row) (*domain.Broker, error) {
+	var b domain.Broker
+	var mcNumber, webhookURL, webhookSecret, rateConfirmationEmail *string
+	err := row.Scan(
+		&b.ID, &b.Name, &mcNumber, &b.CustomerID, &b.APIKeyHash, &webhookURL, &webhookSecret,
+		&b.Active, &b.CreatedAt, &b.UpdatedAt, &rateConfirmationEmail, &b.RequireSignedRateConfirmation,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get broker: %w", err)
+	}
+	b.MCNumber = deref(mcNumber)
+	b.WebhookURL = deref(webhookURL)
+	b.WebhookSecret = deref(webhookSecret)
+	b.RateConfirmationEmail = deref(rateConfirmationEmail)
+	return &b, nil
+}
+
+// PostgresLoadTenderRepository implements LoadTenderRepository using PostgreSQL
+type PostgresLoadTenderRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresLoadTenderRepository creates a new PostgreSQL load tender repository
+func NewPostgresLoadTenderRepository(pool *pgxpool.Pool) *PostgresLoadTenderRepository {
+	return &PostgresLoadTenderRepository{pool: pool}
+}
+
+const tenderColumns = `id, broker_id, broker_reference, order_type, shipment_reference, steamship_line_code,
+	port_id, terminal_id, container_number, container_size, container_type, weight_lbs,
+	is_hazmat, hazmat_class, un_number, pickup_location_id, pickup_address,
+	delivery_location_id, delivery_address, return_location_id,
+	requested_pickup_date, requested_delivery_date, last_free_day,
+	offered_rate, counter_rate, agreed_rate, currency, status, status_reason, notes,
+	expires_at, responded_by, responded_at, order_id, created_at, updated_at`
+
+// Create creates a new load tender
+func (r *PostgresLoadTenderRepository) Create(ctx context.Context, t *domain.LoadTender) error {
+	query := `
+		INSERT INTO load_tenders (` + tenderColumns + `) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36
+		)`
+
+	_, err := conn(ctx, r.pool).Exec(ctx, query,
+		t.ID, t.BrokerID, t.BrokerReference, t.OrderType, t.ShipmentReference, t.SteamshipLineCode,
+		t.PortID, t.TerminalID, t.ContainerNumber, t.ContainerSize, t.ContainerType, t.WeightLbs,
+		t.IsHazmat, t.HazmatClass, t.UNNumber, t.PickupLocationID, t.PickupAddress,
+		t.DeliveryLocationID, t.DeliveryAddress, t.ReturnLocationID,
+		t.RequestedPickupDate, t.RequestedDeliveryDate, t.LastFreeDay,
+		t.OfferedRate, t.CounterRate, t.AgreedRate, t.Currency, t.Status, t.StatusReason, t.Notes,
+		t.ExpiresAt, t.RespondedBy, t.RespondedAt, t.OrderID, t.CreatedAt, t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create load tender: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a load tender by ID
+func (r *PostgresLoadTenderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LoadTender, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+tenderColumns+` FROM load_tenders WHERE id = $1`, id)
+	return scanTender(row)
+}
+
+// GetByIDForUpdate retrieves a load tender and locks it for the rest of the transaction
+func (r *PostgresLoadTenderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.LoadTender, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+tenderColumns+` FROM load_tenders WHERE id = $1 FOR UPDATE`, id)
+	return scanTender(row)
+}
+
+// GetByBrokerReference retrieves the most recent tender for a broker's load number
+func (r *PostgresLoadTenderRepository) GetByBrokerReference(ctx context.Context, brokerID uuid.UUID, reference string) (*domain.LoadTender, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT `+tenderColumns+` FROM load_tenders
+		 WHERE broker_id = $1 AND broker_reference = $2
+		 ORDER BY created_at DESC LIMIT 1`, brokerID, reference)
+	return scanTender(row)
+}
+
+// GetByOrderID retrieves the tender an order was created from
+func (r *PostgresLoadTenderRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*domain.LoadTender, error) {
+	row := conn(ctx, r.pool).QueryRow(ctx, `SELECT `+tenderColumns+` FROM load_tenders WHERE order_id = $1`, orderID)
+	return scanTender(row)
+}
+
+// List retrieves load tenders, newest first
+func (r *PostgresLoadTenderRepository) List(ctx context.Context, filter TenderFilter) ([]domain.LoadTender, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.BrokerID != nil {
+		args = append(args, *filter.BrokerID)
+		conditions = append(conditions, fmt.Sprintf("broker_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + tenderColumns + ` FROM load_tenders`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list load tenders: %w", err)
+	}
+	defer rows.Close()
+
+	var tenders []domain.LoadTender
+	for rows.Next() {
+		t, err := scanTender(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenders = append(tenders, *t)
+	}
+	return tenders, rows.Err()
+}
+
+// Update updates a load tender's negotiation state
+func (r *PostgresLoadTenderRepository) Update(ctx context.Context, t *domain.LoadTender) error {
+	query := `
+		UPDATE load_tenders SET
+			counter_rate = $2, agreed_rate = $3, status = $4, status_reason = $5,
+			responded_by = $6, responded_at = $7, order_id = $8, updated_at = $9
+		WHERE id = $1`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query,
+		t.ID, t.CounterRate, t.AgreedRate, t.Status, t.StatusReason,
+		t.RespondedBy, t.RespondedAt, t.OrderID, t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update load tender: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("load tender not found: %s", t.ID)
+	}
+	return nil
+}
+
+func scanTender(row pgx.Row) (*domain.LoadTender, error) {
+	var t domain.LoadTender
+	err := row.Scan(
+		&t.ID, &t.BrokerID, &t.BrokerReference, &t.OrderType, &t.ShipmentReference, &t.SteamshipLineCode,
+		&t.PortID, &t.TerminalID, &t.ContainerNumber, &t.ContainerSize, &t.ContainerType, &t.WeightLbs,
+		&t.IsHazmat, &t.HazmatClass, &t.UNNumber, &t.PickupLocationID, &t.PickupAddress,
+		&t.DeliveryLocationID, &t.DeliveryAddress, &t.ReturnLocationID,
+		&t.RequestedPickupDate, &t.RequestedDeliveryDate, &t.LastFreeDay,
+		&t.OfferedRate, &t.CounterRate, &t.AgreedRate, &t.Currency, &t.Status, &t.StatusReason, &t.Notes,
+		&t.ExpiresAt, &t.RespondedBy, &t.RespondedAt, &t.OrderID, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan load tender: %w", err)
+	}
+	return &t, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
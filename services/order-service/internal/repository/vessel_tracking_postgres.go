@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+)
+
+// PostgresVesselTrackingRepository implements VesselTrackingRepository using PostgreSQL
+type PostgresVesselTrackingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresVesselTrackingRepository creates a new PostgreSQL vessel tracking repository
+func NewPostgresVesselTrackingRepository(pool *pgxpool.Pool) *PostgresVesselTrackingRepository {
+	return &PostgresVesselTrackingRepository{pool: pool}
+}
+
+const vesselTrackColumns = `vt.id, vt.vessel_name, vt.voyage_number, vt.imo, vt.mmsi, vt.terminal_id,
+	vt.latitude, vt.longitude, vt.speed_knots, vt.nav_status, vt.position_at, vt.distance_nm,
+	vt.predicted_eta, vt.eta_source, vt.last_checked_at, vt.created_at, vt.updated_at`
+
+// ListInboundVoyages lists open import shipments not yet arrived by vessel
+// voyage and terminal. Terminals without coordinates are left out since no
+// ETA can be worked out for them.
+func (r *PostgresVesselTrackingRepository) ListInboundVoyages(ctx context.Context, etaBefore time.Time) ([]domain.InboundVoyage, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT s.vessel_name, COALESCE(s.voyage_number, ''), s.terminal_id, COALESCE(t.name, ''),
+			t.latitude, t.longitude,
+			s.id, s.reference_number, s.customer_id, s.vessel_eta, s.last_free_day,
+			(SELECT COUNT(*) FROM containers c WHERE c.shipment_id = s.id),
+			vt.id, COALESCE(vt.imo, ''), COALESCE(vt.mmsi, '')
+		FROM shipments s
+		JOIN locations t ON t.id = s.terminal_id
+		LEFT JOIN vessel_tracks vt ON vt.vessel_name = s.vessel_name
+			AND vt.voyage_number = COALESCE(s.voyage_number, '') AND vt.terminal_id = s.terminal_id
+		WHERE s.type = 'IMPORT'
+			AND s.status IN ('PENDING', 'IN_PROGRESS')
+			AND s.vessel_ata IS NULL
+			AND COALESCE(s.vessel_name, '') <> ''
+			AND t.latitude IS NOT NULL AND t.longitude IS NOT NULL
+			AND (s.vessel_eta IS NULL OR s.vessel_eta < $1)
+		ORDER BY s.vessel_name, s.voyage_number, s.terminal_id, s.reference_number`, etaBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbound voyages: %w", err)
+	}
+	defer rows.Close()
+
+	var voyages []domain.InboundVoyage
+	for rows.Next() {
+		var v domain.InboundVoyage
+		var s domain.InboundShipment
+		var trackID *uuid.UUID
+		var imo, mmsi string
+		if err := rows.Scan(
+			&v.VesselName, &v.VoyageNumber, &v.TerminalID, &v.TerminalName,
+			&v.Latitude, &v.Longitude,
+			&s.ID, &s.ReferenceNumber, &s.CustomerID, &s.VesselETA, &s.LastFreeDay,
+			&s.Containers,
+			&trackID, &imo, &mmsi,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan inbound shipment: %w", err)
+		}
+
+		if n := len(voyages); n > 0 {
+			last := &voyages[n-1]
+			if last.VesselName == v.VesselName && last.VoyageNumber == v.VoyageNumber && last.TerminalID == v.TerminalID {
+				last.Shipments = append(last.Shipments, s)
+				continue
+			}
+		}
+		if trackID != nil {
+			v.Track = &domain.VesselTrack{ID: *trackID, VesselName: v.VesselName, VoyageNumber: v.VoyageNumber, TerminalID: v.TerminalID, IMO: imo, MMSI: mmsi}
+		}
+		v.Shipments = []domain.InboundShipment{s}
+		voyages = append(voyages, v)
+	}
+	return voyages, rows.Err()
+}
+
+// SaveTrack inserts a track or updates it with the latest position
+func (r *PostgresVesselTrackingRepository) SaveTrack(ctx context.Context, t *domain.VesselTrack) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO vessel_tracks (id, vessel_name, voyage_number, imo, mmsi, terminal_id,
+			latitude, longitude, speed_knots, nav_status, position_at, distance_nm,
+			predicted_eta, eta_source, last_checked_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (id) DO UPDATE SET
+			imo = EXCLUDED.imo, mmsi = EXCLUDED.mmsi,
+			latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude,
+			speed_knots = EXCLUDED.speed_knots, nav_status = EXCLUDED.nav_status,
+			position_at = EXCLUDED.position_at, distance_nm = EXCLUDED.distance_nm,
+			predicted_eta = EXCLUDED.predicted_eta, eta_source = EXCLUDED.eta_source,
+			last_checked_at = EXCLUDED.last_checked_at, updated_at = EXCLUDED.updated_at`,
+		t.ID, t.VesselName, t.VoyageNumber, t.IMO, t.MMSI, t.TerminalID,
+		t.Latitude, t.Longitude, t.SpeedKnots, t.NavStatus, t.PositionAt, t.DistanceNM,
+		t.PredictedETA, t.ETASource, t.LastCheckedAt, t.CreatedAt, t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save vessel track: %w", err)
+	}
+	return nil
+}
+
+// ListTracks lists the tracks checked since the given time, soonest
+// predicted arrival first
+func (r *PostgresVesselTrackingRepository) ListTracks(ctx context.Context, checkedSince time.Time) ([]domain.VesselTrack, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+vesselTrackColumns+` FROM vessel_tracks vt
+		 WHERE vt.last_checked_at >= $1
+		 ORDER BY vt.predicted_eta NULLS LAST, vt.vessel_name`, checkedSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vessel tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []domain.VesselTrack
+	for rows.Next() {
+		t, err := scanVesselTrack(rows)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, *t)
+	}
+	return tracks, rows.Err()
+}
+
+func scanVesselTrack(row pgx.Row) (*domain.VesselTrack, error) {
+	var t domain.VesselTrack
+	err := row.Scan(
+		&t.ID, &t.VesselName, &t.VoyageNumber, &t.IMO, &t.MMSI, &t.TerminalID,
+		&t.Latitude, &t.Longitude, &t.SpeedKnots, &t.NavStatus, &t.PositionAt, &t.DistanceNM,
+		&t.PredictedETA, &t.ETASource, &t.LastCheckedAt, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan vessel track: %w", err)
+	}
+	return &t, nil
+}
+
+// RecordETAChange moves the shipment's vessel ETA and keeps the change
+func (r *PostgresVesselTrackingRepository) RecordETAChange(ctx context.Context, c *domain.VesselETAChange) error {
+	q := conn(ctx, r.pool)
+	tag, err := q.Exec(ctx,
+		`UPDATE shipments SET vessel_eta = $2, updated_at = $3 WHERE id = $1`,
+		c.ShipmentID, c.NewETA, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update vessel eta: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shipment not found: %s", c.ShipmentID)
+	}
+	if _, err := q.Exec(ctx, `
+		INSERT INTO vessel_eta_changes (id, shipment_id, track_id, previous_eta, new_eta, drift_minutes, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		c.ID, c.ShipmentID, c.TrackID, c.PreviousETA, c.NewETA, c.DriftMinutes, c.Source, c.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record vessel eta change: %w", err)
+	}
+	return nil
+}
+
+// ListETAChanges lists a shipment's vessel ETA changes, newest first
+func (r *PostgresVesselTrackingRepository) ListETAChanges(ctx context.Context, shipmentID uuid.UUID) ([]domain.VesselETAChange, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, shipment_id, track_id, previous_eta, new_eta, drift_minutes, source, created_at
+		FROM vessel_eta_changes WHERE shipment_id = $1
+		ORDER BY created_at DESC`, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vessel eta changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []domain.VesselETAChange
+	for rows.Next() {
+		var c domain.VesselETAChange
+		if err := rows.Scan(&c.ID, &c.ShipmentID, &c.TrackID, &c.PreviousETA, &c.NewETA, &c.DriftMinutes, &c.Source, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vessel eta change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
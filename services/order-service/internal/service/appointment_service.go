@@ -21,6 +21,8 @@ type AppointmentService struct {
 	appointmentRepo  repository.AppointmentRepository
 	terminalRepo     repository.TerminalRepository
 	orderRepo        repository.OrderRepository
+	calendar         *CalendarService
+	punctuality      *PunctualityService
 	eventProducer    *kafka.Producer
 	logger           *logger.Logger
 	dateValidator    *validation.DateValidator
@@ -32,6 +34,8 @@ func NewAppointmentService(
 	appointmentRepo repository.AppointmentRepository,
 	terminalRepo repository.TerminalRepository,
 	orderRepo repository.OrderRepository,
+	calendar *CalendarService,
+	punctuality *PunctualityService,
 	eventProducer *kafka.Producer,
 	log *logger.Logger,
 ) *AppointmentService {
@@ -39,6 +43,8 @@ func NewAppointmentService(
 		appointmentRepo: appointmentRepo,
 		terminalRepo:    terminalRepo,
 		orderRepo:       orderRepo,
+		calendar:        calendar,
+		punctuality:     punctuality,
 		eventProducer:   eventProducer,
 		logger:          log,
 		dateValidator:   validation.NewDateValidator(),
@@ -69,7 +75,7 @@ func (s *AppointmentService) RequestAppointment(ctx context.Context, input Reque
 
 	// Validate order exists
 	order, err := s.orderRepo.GetByID(ctx, input.OrderID)
-	if err != nil {
+	if err != nil || order == nil {
 		return nil, apperrors.NotFoundError("order", input.OrderID.String())
 	}
 
@@ -107,6 +113,12 @@ func (s *AppointmentService) RequestAppointment(ctx context.Context, input Reque
 		return nil, apperrors.New("SLOT_UNAVAILABLE", "No available slots at requested time")
 	}
 
+	// Congested windows are still bookable, but flagged so dispatch can plan around them
+	var congested *domain.AppointmentWindow
+	if s.punctuality != nil {
+		congested = s.punctuality.congestedWindow(ctx, input.TerminalID, input.RequestedTime)
+	}
+
 	// Calculate appointment window
 	windowMins := s.businessRules.Time.AppointmentWindowMins
 	windowStart := input.RequestedTime
@@ -136,6 +148,16 @@ func (s *AppointmentService) RequestAppointment(ctx context.Context, input Reque
 		appointment.ContainerNumber = "CONTAINER_NUMBER" // Placeholder
 	}
 
+	if congested != nil {
+		appointment.Metadata = map[string]string{"congested_window": congested.Reason}
+		s.logger.Warnw("Appointment requested in congested window",
+			"order_id", input.OrderID,
+			"terminal_id", input.TerminalID,
+			"requested_time", input.RequestedTime,
+			"reason", congested.Reason,
+		)
+	}
+
 	if err := s.appointmentRepo.Create(ctx, appointment); err != nil {
 		return nil, apperrors.DatabaseError("create appointment", err)
 	}
@@ -151,6 +173,7 @@ func (s *AppointmentService) RequestAppointment(ctx context.Context, input Reque
 		"terminal_id":    input.TerminalID.String(),
 		"requested_time": input.RequestedTime,
 		"type":           input.Type,
+		"congested":      congested != nil,
 	})
 	_ = s.eventProducer.Publish(ctx, kafka.Topics.AppointmentRequested, event)
 
@@ -330,7 +353,8 @@ func (s *AppointmentService) RecordArrival(ctx context.Context, appointmentID uu
 	}
 
 	// Check if arrival was on time
-	onTime := arrivalTime.Before(appointment.WindowEndTime) && arrivalTime.After(appointment.WindowStartTime.Add(-15*time.Minute))
+	earlyTolerance := time.Duration(s.businessRules.Punctuality.EarlyToleranceMins) * time.Minute
+	onTime := arrivalTime.Before(appointment.WindowEndTime) && arrivalTime.After(appointment.WindowStartTime.Add(-earlyTolerance))
 
 	// Publish event
 	event := kafka.NewEvent(kafka.Topics.AppointmentArrival, "order-service", map[string]interface{}{
@@ -347,7 +371,7 @@ func (s *AppointmentService) RecordArrival(ctx context.Context, appointmentID uu
 func (s *AppointmentService) CompleteAppointment(ctx context.Context, appointmentID uuid.UUID, completionTime time.Time, gateTicketNumber string) error {
 	appointment, err := s.appointmentRepo.GetByID(ctx, appointmentID)
 	if err != nil {
-		return nil, apperrors.NotFoundError("appointment", appointmentID.String())
+		return apperrors.NotFoundError("appointment", appointmentID.String())
 	}
 
 	appointment.Status = domain.AppointmentStatusCompleted
@@ -378,9 +402,26 @@ func (s *AppointmentService) CompleteAppointment(ctx context.Context, appointmen
 // Helper methods
 
 func (s *AppointmentService) isTerminalOpen(ctx context.Context, terminalID uuid.UUID, requestedTime time.Time) (bool, error) {
-	// In real implementation, would check terminal gate hours from database
-	// For now, assume terminals are open 6 AM - 6 PM Mon-Fri
+	// Check port/terminal closure calendar
+	if s.calendar != nil {
+		closed, err := s.calendar.IsTerminalClosed(ctx, terminalID, requestedTime)
+		if err != nil {
+			return false, err
+		}
+		if closed {
+			return false, nil
+		}
+	}
+
+	hours, err := s.terminalRepo.GetGateHours(ctx, terminalID)
+	if err != nil {
+		return false, apperrors.DatabaseError("get gate hours", err)
+	}
+	if open, ok := domain.GateOpenAt(hours, requestedTime); ok {
+		return open, nil
+	}
 
+	// No gate hours configured: assume 6 AM - 6 PM Mon-Fri
 	hour := requestedTime.Hour()
 	weekday := requestedTime.Weekday()
 
@@ -412,6 +453,57 @@ func (s *AppointmentService) simulateTerminalConfirmation(ctx context.Context, a
 	_ = s.ConfirmAppointment(ctx, appointmentID, confirmationNumber, "terminal-system")
 }
 
+// SuggestAppointmentTimes returns up to limit hourly appointment times between
+// from and to for auto-booking. Times in the terminal's preferred windows come
+// first, congested windows are skipped, and each group is in time order.
+func (s *AppointmentService) SuggestAppointmentTimes(ctx context.Context, terminalID uuid.UUID, from, to time.Time, limit int) ([]time.Time, error) {
+	earliest := time.Now().Add(time.Duration(s.businessRules.Time.MinAppointmentAdvanceHours) * time.Hour)
+	if from.Before(earliest) {
+		from = earliest
+	}
+	if !to.After(from) {
+		return nil, apperrors.ValidationError("no bookable time before end of range", "to", to)
+	}
+
+	var preference *domain.AppointmentBookingPreference
+	if s.punctuality != nil {
+		p, err := s.punctuality.GetBookingPreference(ctx, terminalID)
+		if err != nil {
+			return nil, err
+		}
+		preference = p
+	}
+
+	var preferred, others []time.Time
+	for t := from.Truncate(time.Hour); t.Before(to); t = t.Add(time.Hour) {
+		if t.Before(from) {
+			continue
+		}
+		open, err := s.isTerminalOpen(ctx, terminalID, t)
+		if err != nil {
+			return nil, err
+		}
+		if !open {
+			continue
+		}
+		switch {
+		case preference == nil:
+			others = append(others, t)
+		case preference.Avoids(t) != nil:
+		case preference.Prefers(t):
+			preferred = append(preferred, t)
+		default:
+			others = append(others, t)
+		}
+	}
+
+	suggestions := append(preferred, others...)
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
 // GetUpcomingAppointments retrieves upcoming appointments for a terminal
 func (s *AppointmentService) GetUpcomingAppointments(ctx context.Context, terminalID uuid.UUID, startTime, endTime time.Time) ([]domain.TerminalAppointment, error) {
 	appointments, err := s.appointmentRepo.GetByTerminalAndTimeRange(ctx, terminalID, startTime, endTime)
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxLFDExtensionDays bounds how far consecutive closures can push out a Last Free Day
+const maxLFDExtensionDays = 30
+
+// CalendarService tracks port/terminal closures and company holidays
+type CalendarService struct {
+	calendarRepo repository.CalendarRepository
+	logger       *logger.Logger
+}
+
+// NewCalendarService creates a new calendar service
+func NewCalendarService(calendarRepo repository.CalendarRepository, log *logger.Logger) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		logger:       log,
+	}
+}
+
+// AddClosureInput contains input for adding a closure
+type AddClosureInput struct {
+	Type            domain.ClosureType
+	TerminalID      *uuid.UUID
+	Date            time.Time
+	Name            string
+	Reason          string
+	ExtendsFreeTime *bool // Defaults to true for terminal/port closures, false for company holidays
+	CreatedBy       string
+}
+
+// AddClosure records a terminal closure, port closure, or company holiday
+func (s *CalendarService) AddClosure(ctx context.Context, input AddClosureInput) (*domain.CalendarClosure, error) {
+	switch input.Type {
+	case domain.ClosureTypeTerminal:
+		if input.TerminalID == nil {
+			return nil, apperrors.ValidationError("terminal closure requires a terminal", "terminal_id", nil)
+		}
+	case domain.ClosureTypePort, domain.ClosureTypeCompanyHoliday:
+	default:
+		return nil, apperrors.ValidationError("invalid closure type", "type", input.Type)
+	}
+
+	if input.Date.IsZero() {
+		return nil, apperrors.ValidationError("closure date is required", "date", input.Date)
+	}
+
+	extendsFreeTime := input.Type != domain.ClosureTypeCompanyHoliday
+	if input.ExtendsFreeTime != nil {
+		extendsFreeTime = *input.ExtendsFreeTime
+	}
+
+	closure := &domain.CalendarClosure{
+		ID:              uuid.New(),
+		Type:            input.Type,
+		TerminalID:      input.TerminalID,
+		ClosureDate:     startOfDay(input.Date),
+		Name:            input.Name,
+		Reason:          input.Reason,
+		ExtendsFreeTime: extendsFreeTime,
+		CreatedBy:       input.CreatedBy,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	if err := s.calendarRepo.Create(ctx, closure); err != nil {
+		return nil, apperrors.DatabaseError("create closure", err)
+	}
+
+	s.logger.Infow("Calendar closure added",
+		"closure_id", closure.ID,
+		"type", closure.Type,
+		"date", closure.ClosureDate.Format("2006-01-02"),
+	)
+
+	return closure, nil
+}
+
+// RemoveClosure deletes a closure
+func (s *CalendarService) RemoveClosure(ctx context.Context, closureID uuid.UUID) error {
+	if err := s.calendarRepo.Delete(ctx, closureID); err != nil {
+		return apperrors.DatabaseError("delete closure", err)
+	}
+	return nil
+}
+
+// GetClosures returns all closures between start and end (inclusive)
+func (s *CalendarService) GetClosures(ctx context.Context, start, end time.Time) ([]domain.CalendarClosure, error) {
+	closures, err := s.calendarRepo.GetByDateRange(ctx, startOfDay(start), startOfDay(end))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get closures", err)
+	}
+	return closures, nil
+}
+
+// IsTerminalClosed checks if a terminal has a closure on the given day
+func (s *CalendarService) IsTerminalClosed(ctx context.Context, terminalID uuid.UUID, t time.Time) (bool, error) {
+	closures, err := s.GetClosures(ctx, t, t)
+	if err != nil {
+		return false, err
+	}
+	return terminalClosedOn(closures, terminalID, t, false), nil
+}
+
+// AdjustLastFreeDay rolls a Last Free Day forward past terminal closure days,
+// since containers cannot be picked up while the terminal is closed
+func (s *CalendarService) AdjustLastFreeDay(ctx context.Context, terminalID uuid.UUID, lastFreeDay time.Time) (time.Time, error) {
+	lfd := startOfDay(lastFreeDay)
+	closures, err := s.GetClosures(ctx, lfd, lfd.AddDate(0, 0, maxLFDExtensionDays))
+	if err != nil {
+		return lastFreeDay, err
+	}
+
+	for i := 0; i < maxLFDExtensionDays && terminalClosedOn(closures, terminalID, lfd, true); i++ {
+		lfd = lfd.AddDate(0, 0, 1)
+	}
+
+	return lfd, nil
+}
+
+// ChargeableDays counts the days after from up to and including to, skipping
+// terminal closure days that extend free time
+func (s *CalendarService) ChargeableDays(ctx context.Context, terminalID uuid.UUID, from, to time.Time) (int, error) {
+	start := startOfDay(from).AddDate(0, 0, 1)
+	end := startOfDay(to)
+	if end.Before(start) {
+		return 0, nil
+	}
+
+	closures, err := s.GetClosures(ctx, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	days := 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !terminalClosedOn(closures, terminalID, day, true) {
+			days++
+		}
+	}
+	return days, nil
+}
+
+// DailyCapacity is the forecast working capacity for a terminal on one day
+type DailyCapacity struct {
+	Date           time.Time `json:"date"`
+	TerminalClosed bool      `json:"terminal_closed"`
+	CompanyHoliday bool      `json:"company_holiday"`
+	Capacity       int       `json:"capacity"`
+}
+
+// ForecastCapacity projects daily move capacity at a terminal, zeroing days
+// when the terminal is closed or the company is on holiday
+func (s *CalendarService) ForecastCapacity(ctx context.Context, terminalID uuid.UUID, start, end time.Time, dailyCapacity int) ([]DailyCapacity, error) {
+	closures, err := s.GetClosures(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var forecast []DailyCapacity
+	for day := startOfDay(start); !day.After(startOfDay(end)); day = day.AddDate(0, 0, 1) {
+		entry := DailyCapacity{
+			Date:           day,
+			TerminalClosed: terminalClosedOn(closures, terminalID, day, false),
+			CompanyHoliday: companyHolidayOn(closures, day),
+			Capacity:       dailyCapacity,
+		}
+		if entry.TerminalClosed || entry.CompanyHoliday {
+			entry.Capacity = 0
+		}
+		forecast = append(forecast, entry)
+	}
+
+	return forecast, nil
+}
+
+// terminalClosedOn checks closures for a terminal on a day. When freeTimeOnly
+// is set, only closures that extend free time are considered.
+func terminalClosedOn(closures []domain.CalendarClosure, terminalID uuid.UUID, day time.Time, freeTimeOnly bool) bool {
+	for i := range closures {
+		c := &closures[i]
+		if freeTimeOnly && !c.ExtendsFreeTime {
+			continue
+		}
+		if c.AppliesToTerminal(terminalID) && c.CoversDate(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// companyHolidayOn checks if the company is closed on a day
+func companyHolidayOn(closures []domain.CalendarClosure, day time.Time) bool {
+	for i := range closures {
+		if closures[i].Type == domain.ClosureTypeCompanyHoliday && closures[i].CoversDate(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// startOfDay truncates a time to midnight in its own location
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// chargeAccrualPageSize is the shipment page size the accrual scans with
+const chargeAccrualPageSize = 100
+
+// ChargeAccrualService recomputes per-diem and demurrage every night for
+// open import containers and materializes them in the charges table.
+// Demurrage accrues while the loaded container sits at the terminal, per
+// diem once it has left until it comes back. Dispatch is warned as the LFD
+// approaches and when charges start running up, so pre-pulls can be
+// prioritized.
+type ChargeAccrualService struct {
+	shipmentRepo  repository.ShipmentRepository
+	containerRepo repository.ContainerRepository
+	chargeRepo    repository.ContainerChargeRepository
+	calendar      *CalendarService
+	eventProducer *kafka.Producer
+	rules         *config.BusinessRules
+	logger        *logger.Logger
+
+	mu      sync.Mutex
+	lastRun time.Time // Night of the last completed scheduled accrual
+}
+
+// ChargeAccrualRun summarizes one accrual
+type ChargeAccrualRun struct {
+	AccruedThrough time.Time `json:"accrued_through"`
+	Containers     int       `json:"containers"`
+	PerDiem        float64   `json:"per_diem"`
+	Demurrage      float64   `json:"demurrage"`
+	Warnings       int       `json:"warnings"`
+	Failed         int       `json:"failed"`
+}
+
+// NewChargeAccrualService creates a new charge accrual service. calendar
+// may be nil, in which case terminal closures do not extend free time.
+func NewChargeAccrualService(
+	shipmentRepo repository.ShipmentRepository,
+	containerRepo repository.ContainerRepository,
+	chargeRepo repository.ContainerChargeRepository,
+	calendar *CalendarService,
+	eventProducer *kafka.Producer,
+	rules *config.BusinessRules,
+	log *logger.Logger,
+) *ChargeAccrualService {
+	return &ChargeAccrualService{
+		shipmentRepo:  shipmentRepo,
+		containerRepo: containerRepo,
+		chargeRepo:    chargeRepo,
+		calendar:      calendar,
+		eventProducer: eventProducer,
+		rules:         rules,
+		logger:        log,
+	}
+}
+
+// ============================================================================
+// ACCRUAL
+// ============================================================================
+
+// Accrue recomputes charges as of now for the containers of every pending
+// or in-progress import with a last free day. A container that fails is
+// logged and counted, and left for the next run.
+func (s *ChargeAccrualService) Accrue(ctx context.Context, now time.Time) (*ChargeAccrualRun, error) {
+	run := &ChargeAccrualRun{
+		AccruedThrough: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+	}
+
+	for _, status := range []domain.ShipmentStatus{domain.ShipmentStatusPending, domain.ShipmentStatusInProgress} {
+		filter := repository.ShipmentFilter{
+			Type:      domain.ShipmentTypeImport,
+			Status:    status,
+			PageSize:  chargeAccrualPageSize,
+			SortBy:    "last_free_day",
+			SortOrder: "asc",
+		}
+		for filter.Page = 1; ; filter.Page++ {
+			shipments, total, err := s.shipmentRepo.List(ctx, filter)
+			if err != nil {
+				return nil, apperrors.DatabaseError("list open imports for charge accrual", err)
+			}
+			for _, shipment := range shipments {
+				if shipment.LastFreeDay != nil {
+					s.accrueShipment(ctx, shipment, now, run)
+				}
+			}
+			if len(shipments) < chargeAccrualPageSize || int64(filter.Page*chargeAccrualPageSize) >= total {
+				break
+			}
+		}
+	}
+
+	s.logger.Infow("Charges accrued",
+		"accrued_through", run.AccruedThrough,
+		"containers", run.Containers,
+		"per_diem", run.PerDiem,
+		"demurrage", run.Demurrage,
+		"warnings", run.Warnings,
+		"failed", run.Failed,
+	)
+	return run, nil
+}
+
+func (s *ChargeAccrualService) accrueShipment(ctx context.Context, shipment *domain.Shipment, now time.Time, run *ChargeAccrualRun) {
+	containers, err := s.containerRepo.GetByShipmentID(ctx, shipment.ID)
+	if err != nil {
+		s.logger.Warnw("Failed to load containers for charge accrual", "shipment_id", shipment.ID, "error", err)
+		run.Failed++
+		return
+	}
+	for _, container := range containers {
+		if err := s.accrueContainer(ctx, shipment, container, now, run); err != nil {
+			s.logger.Warnw("Failed to accrue container charges",
+				"container_number", container.ContainerNumber,
+				"shipment_id", shipment.ID,
+				"error", err,
+			)
+			run.Failed++
+		}
+	}
+}
+
+// accrueContainer updates the charge the container is running up at its
+// current location. Containers still on the vessel, and empties back at
+// the terminal, have nothing accruing and keep their last charges.
+func (s *ChargeAccrualService) accrueContainer(ctx context.Context, shipment *domain.Shipment, container *domain.Container, now time.Time, run *ChargeAccrualRun) error {
+	switch container.CurrentLocationType {
+	case domain.LocationTypeTerminal:
+		if container.CurrentState == domain.ContainerStateEmpty {
+			return nil
+		}
+		charges, err := demurrageAt(ctx, s.rules, s.calendar, container, shipment, now)
+		if err != nil {
+			return err
+		}
+		if err := s.save(ctx, shipment, container, domain.ChargeTypeDemurrage, charges.LastFreeDay,
+			charges.StartDate, charges.Days, charges.Amount, charges.Breakdown, run.AccruedThrough); err != nil {
+			return err
+		}
+		run.Containers++
+		run.Demurrage += charges.Amount
+
+		daysOut := daysUntil(charges.LastFreeDay, now)
+		for _, lead := range s.rules.Demurrage.LFDWarningDays {
+			if daysOut == lead {
+				s.warn(ctx, kafka.ChargeWarningLFDApproaching, shipment, container, charges.LastFreeDay, daysOut, 0, 0, run)
+			}
+		}
+		if charges.Days > 0 {
+			s.warn(ctx, kafka.ChargeWarningDemurrageAccruing, shipment, container, charges.LastFreeDay, daysOut, charges.Days, charges.Amount, run)
+		}
+
+	case domain.LocationTypeInTransit, domain.LocationTypeCustomer, domain.LocationTypeYard:
+		charges, err := perDiemAt(s.rules, container, shipment, now)
+		if err != nil {
+			return err
+		}
+		lfd := *shipment.LastFreeDay
+		if err := s.save(ctx, shipment, container, domain.ChargeTypePerDiem, lfd,
+			charges.StartDate, charges.Days, charges.Amount, charges.Breakdown, run.AccruedThrough); err != nil {
+			return err
+		}
+		run.Containers++
+		run.PerDiem += charges.Amount
+
+		if charges.Amount > 0 {
+			s.warn(ctx, kafka.ChargeWarningPerDiemAccruing, shipment, container, lfd, daysUntil(lfd, now), charges.Days, charges.Amount, run)
+		}
+	}
+	return nil
+}
+
+func (s *ChargeAccrualService) save(
+	ctx context.Context,
+	shipment *domain.Shipment,
+	container *domain.Container,
+	chargeType domain.ChargeType,
+	lastFreeDay, startDate time.Time,
+	days int,
+	amount float64,
+	breakdown []TierCharge,
+	accruedThrough time.Time,
+) error {
+	now := time.Now()
+	charge := &domain.ContainerCharge{
+		ID:              uuid.New(),
+		ContainerID:     container.ID,
+		ContainerNumber: container.ContainerNumber,
+		ShipmentID:      shipment.ID,
+		CustomerID:      shipment.CustomerID,
+		TerminalID:      shipment.TerminalID,
+		ChargeType:      chargeType,
+		LastFreeDay:     lastFreeDay,
+		Days:            days,
+		Amount:          amount,
+		Breakdown:       breakdown,
+		AccruedThrough:  accruedThrough,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if !startDate.IsZero() {
+		charge.StartDate = &startDate
+	}
+	if charge.Breakdown == nil {
+		charge.Breakdown = []TierCharge{}
+	}
+	if err := s.chargeRepo.Upsert(ctx, charge); err != nil {
+		return apperrors.DatabaseError("save container charge", err)
+	}
+	return nil
+}
+
+// warn publishes a charge warning once per container, LFD and lead time.
+// Accruing warnings are sent once per LFD.
+func (s *ChargeAccrualService) warn(
+	ctx context.Context,
+	warning string,
+	shipment *domain.Shipment,
+	container *domain.Container,
+	lastFreeDay time.Time,
+	daysOut, chargeDays int,
+	amount float64,
+	run *ChargeAccrualRun,
+) {
+	key := daysOut
+	if warning != kafka.ChargeWarningLFDApproaching {
+		key = 0
+	}
+	fresh, err := s.chargeRepo.MarkWarned(ctx, container.ID, warning, lastFreeDay, key)
+	if err != nil {
+		s.logger.Warnw("Failed to record charge warning", "container_number", container.ContainerNumber, "warning", warning, "error", err)
+		return
+	}
+	if !fresh {
+		return
+	}
+
+	event := kafka.NewEvent(kafka.Topics.ContainerChargeWarning, "order-service", kafka.ContainerChargeWarningEvent{
+		Warning:         warning,
+		ContainerID:     container.ID.String(),
+		ContainerNumber: container.ContainerNumber,
+		ShipmentID:      shipment.ID.String(),
+		ReferenceNumber: shipment.ReferenceNumber,
+		CustomerID:      shipment.CustomerID.String(),
+		TerminalID:      shipment.TerminalID.String(),
+		LastFreeDay:     lastFreeDay,
+		DaysUntilLFD:    daysOut,
+		ChargeDays:      chargeDays,
+		AccruedAmount:   amount,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.ContainerChargeWarning, event)
+	run.Warnings++
+
+	s.logger.Warnw("Container charge warning",
+		"warning", warning,
+		"container_number", container.ContainerNumber,
+		"shipment_id", shipment.ID,
+		"last_free_day", lastFreeDay,
+		"days_until_lfd", daysOut,
+	)
+}
+
+// daysUntil counts calendar days from now to the last free day, negative
+// once it has passed
+func daysUntil(lastFreeDay, now time.Time) int {
+	today := now.Truncate(24 * time.Hour)
+	lfd := lastFreeDay.Truncate(24 * time.Hour)
+	return int(lfd.Sub(today).Hours() / 24)
+}
+
+// Start runs the accrual once a night, on the first tick after the
+// configured hour, until ctx is cancelled
+func (s *ChargeAccrualService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !s.due(now) {
+				continue
+			}
+			run, err := s.Accrue(ctx, now)
+			if err != nil {
+				s.logger.Errorw("Charge accrual failed", "error", err)
+				continue
+			}
+			s.mu.Lock()
+			s.lastRun = run.AccruedThrough
+			s.mu.Unlock()
+		}
+	}
+}
+
+// due reports whether tonight's accrual has yet to run
+func (s *ChargeAccrualService) due(now time.Time) bool {
+	if now.Hour() < s.rules.Demurrage.AccrualHour {
+		return false
+	}
+	night := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRun.Before(night)
+}
+
+// ============================================================================
+// QUERIES
+// ============================================================================
+
+// GetContainerCharges returns the charges accrued against a container
+func (s *ChargeAccrualService) GetContainerCharges(ctx context.Context, containerID uuid.UUID) ([]domain.ContainerCharge, error) {
+	if _, err := s.containerRepo.GetByID(ctx, containerID); err != nil {
+		return nil, apperrors.NotFoundError("container", containerID.String())
+	}
+	charges, err := s.chargeRepo.GetByContainerID(ctx, containerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get container charges", err)
+	}
+	return charges, nil
+}
+
+// ListCharges returns accrued charges, largest first
+func (s *ChargeAccrualService) ListCharges(ctx context.Context, filter repository.ContainerChargeFilter) ([]domain.ContainerCharge, error) {
+	if filter.ChargeType != "" && filter.ChargeType != domain.ChargeTypePerDiem && filter.ChargeType != domain.ChargeTypeDemurrage {
+		return nil, apperrors.ValidationError("charge type must be PER_DIEM or DEMURRAGE", "charge_type", string(filter.ChargeType))
+	}
+	charges, err := s.chargeRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list container charges", err)
+	}
+	return charges, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// eModal container status codes published by emodal-integration
+const (
+	emodalStatusManifested    = "MANIFESTED"
+	emodalStatusDischarged    = "DISCHARGED"
+	emodalStatusInYard        = "IN_YARD"
+	emodalStatusAvailable     = "AVAILABLE"
+	emodalStatusOnHold        = "ON_HOLD"
+	emodalStatusCustomsHold   = "CUSTOMS_HOLD"
+	emodalStatusGateIn        = "GATE_IN"
+	emodalStatusGateOut       = "GATE_OUT"
+	emodalStatusReleased      = "RELEASED"
+	emodalStatusLoaded        = "LOADED"
+	emodalStatusNotManifested = "NOT_MANIFESTED"
+)
+
+// ContainerEventConsumer applies eModal container status and verification
+// events to order-service container records
+type ContainerEventConsumer struct {
+	containerRepo repository.ContainerRepository
+	shipmentRepo  repository.ShipmentRepository
+	orderRepo     repository.OrderRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewContainerEventConsumer creates a new container event consumer
+func NewContainerEventConsumer(
+	containerRepo repository.ContainerRepository,
+	shipmentRepo repository.ShipmentRepository,
+	orderRepo repository.OrderRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *ContainerEventConsumer {
+	return &ContainerEventConsumer{
+		containerRepo: containerRepo,
+		shipmentRepo:  shipmentRepo,
+		orderRepo:     orderRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// emodalStatusPayload is the data of an emodal.container.status_updated event
+type emodalStatusPayload struct {
+	ContainerNumber     string    `json:"containerNumber"`
+	Status              string    `json:"status"`
+	TerminalCode        string    `json:"terminalCode"`
+	TerminalName        string    `json:"terminalName"`
+	LocationDescription string    `json:"locationDescription"`
+	MountStatus         string    `json:"mountStatus"`
+	YardBlock           string    `json:"yardBlock"`
+	YardRow             string    `json:"yardRow"`
+	YardBay             string    `json:"yardBay"`
+	YardTier            string    `json:"yardTier"`
+	OccurredAt          time.Time `json:"occurredAt"`
+}
+
+// HandleContainerStatus is a kafka.Handler for eModal container status updates
+func (c *ContainerEventConsumer) HandleContainerStatus(ctx context.Context, event *kafka.Event) error {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload emodalStatusPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("unmarshal container status: %w", err)
+	}
+	if payload.ContainerNumber == "" {
+		return apperrors.ValidationError("container number is required", "containerNumber", payload.ContainerNumber)
+	}
+	if payload.OccurredAt.IsZero() {
+		payload.OccurredAt = event.Time
+	}
+
+	container, err := c.containerRepo.GetByNumber(ctx, payload.ContainerNumber)
+	if err != nil || container == nil {
+		// Not every container eModal reports on belongs to one of our shipments
+		c.logger.Debugw("Ignoring status for unknown container",
+			"container", payload.ContainerNumber,
+			"status", payload.Status,
+		)
+		return nil
+	}
+
+	// Drop events that arrive out of order
+	if container.TerminalStatusAt != nil && payload.OccurredAt.Before(*container.TerminalStatusAt) {
+		c.logger.Debugw("Ignoring stale container status",
+			"container", payload.ContainerNumber,
+			"status", payload.Status,
+			"occurred_at", payload.OccurredAt,
+		)
+		return nil
+	}
+
+	wasReady, wasGrounded := container.IsReady, container.IsGrounded()
+	applyEModalStatus(container, payload.Status, payload.OccurredAt)
+	applyYardPosition(container, payload)
+
+	if err := c.containerRepo.Update(ctx, container); err != nil {
+		return apperrors.DatabaseError("update container status", err)
+	}
+
+	c.logger.Infow("Container updated from eModal",
+		"container", container.ContainerNumber,
+		"status", payload.Status,
+		"state", container.CurrentState,
+		"location_type", container.CurrentLocationType,
+		"ready", container.IsReady,
+	)
+
+	if container.IsReady && !wasReady {
+		readyEvent := kafka.NewEvent(kafka.Topics.ContainerReady, "order-service", map[string]interface{}{
+			"container_id":     container.ID.String(),
+			"container_number": container.ContainerNumber,
+			"shipment_id":      container.ShipmentID.String(),
+			"terminal_code":    payload.TerminalCode,
+			"ready_at":         container.ReadyAt,
+		})
+		_ = c.eventProducer.Publish(ctx, kafka.Topics.ContainerReady, readyEvent)
+	}
+
+	// A grounded box takes a yard machine to dig out, so dispatch pads the
+	// pickup. Only the change is published; repeats of the same position are not.
+	if container.IsGrounded() && !wasGrounded && container.CurrentLocationType == domain.LocationTypeTerminal {
+		groundedEvent := kafka.NewEvent(kafka.Topics.ContainerGrounded, "order-service", map[string]interface{}{
+			"container_id":     container.ID.String(),
+			"container_number": container.ContainerNumber,
+			"shipment_id":      container.ShipmentID.String(),
+			"terminal_code":    payload.TerminalCode,
+			"yard_block":       container.YardBlock,
+			"yard_row":         container.YardRow,
+			"yard_bay":         container.YardBay,
+			"yard_tier":        container.YardTier,
+			"grounded_at":      container.YardUpdatedAt,
+		})
+		_ = c.eventProducer.Publish(ctx, kafka.Topics.ContainerGrounded, groundedEvent)
+	}
+
+	return nil
+}
+
+// applyYardPosition records the reported yard position. A container that
+// has left the terminal no longer has one; an event without yard detail
+// leaves the last known position alone.
+func applyYardPosition(container *domain.Container, payload emodalStatusPayload) {
+	if container.CurrentLocationType != domain.LocationTypeTerminal {
+		if container.YardUpdatedAt != nil {
+			container.MountStatus, container.YardBlock, container.YardRow = "", "", ""
+			container.YardBay, container.YardTier = "", ""
+			container.YardUpdatedAt = &payload.OccurredAt
+		}
+		return
+	}
+	if payload.MountStatus == "" && payload.YardBlock == "" {
+		return
+	}
+	container.MountStatus = payload.MountStatus
+	container.YardBlock = payload.YardBlock
+	container.YardRow = payload.YardRow
+	container.YardBay = payload.YardBay
+	container.YardTier = payload.YardTier
+	container.YardUpdatedAt = &payload.OccurredAt
+}
+
+// applyEModalStatus maps an eModal status code onto the container's state,
+// location, hold flags, and milestone timestamps
+func applyEModalStatus(container *domain.Container, status string, occurredAt time.Time) {
+	switch status {
+	case emodalStatusManifested:
+		container.CurrentLocationType = domain.LocationTypeVessel
+		container.CurrentState = domain.ContainerStateLoaded
+	case emodalStatusDischarged:
+		container.CurrentLocationType = domain.LocationTypeTerminal
+		if container.DischargedAt == nil {
+			container.DischargedAt = &occurredAt
+		}
+	case emodalStatusInYard, emodalStatusGateIn:
+		container.CurrentLocationType = domain.LocationTypeTerminal
+	case emodalStatusAvailable:
+		container.CurrentLocationType = domain.LocationTypeTerminal
+		container.TerminalHold = false
+		if container.TerminalAvailableDate == nil {
+			container.TerminalAvailableDate = &occurredAt
+		}
+	case emodalStatusOnHold:
+		container.CurrentLocationType = domain.LocationTypeTerminal
+		container.TerminalHold = true
+	case emodalStatusCustomsHold:
+		container.CustomsStatus = domain.CustomsStatusHold
+	case emodalStatusReleased:
+		container.CustomsStatus = domain.CustomsStatusReleased
+		container.CustomsHoldType = ""
+		container.TerminalHold = false
+	case emodalStatusGateOut:
+		container.CurrentLocationType = domain.LocationTypeInTransit
+	case emodalStatusLoaded:
+		container.CurrentLocationType = domain.LocationTypeVessel
+	case emodalStatusNotManifested:
+		// Informational only
+	}
+
+	container.TerminalStatus = status
+	container.TerminalStatusAt = &occurredAt
+	container.UpdatedAt = time.Now()
+
+	ready := container.IsAvailable()
+	if ready && !container.IsReady {
+		container.ReadyAt = &occurredAt
+	}
+	container.IsReady = ready
+}
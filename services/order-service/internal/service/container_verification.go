@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// requestContainerVerification asks emodal-integration to check a newly keyed
+// container against the terminal. The answer arrives asynchronously on
+// emodal.container.verified; until then the container stays PENDING.
+func requestContainerVerification(ctx context.Context, producer *kafka.Producer, container *domain.Container) {
+	if container.ContainerNumber == "" {
+		return
+	}
+	event := kafka.NewEvent(kafka.Topics.ContainerVerificationRequested, "order-service", map[string]interface{}{
+		"container_id":     container.ID.String(),
+		"container_number": container.ContainerNumber,
+		"shipment_id":      container.ShipmentID.String(),
+	})
+	_ = producer.Publish(ctx, kafka.Topics.ContainerVerificationRequested, event)
+}
+
+// emodalVerificationPayload is the data of an emodal.container.verified event
+type emodalVerificationPayload struct {
+	ContainerID     string    `json:"containerId"`
+	ContainerNumber string    `json:"containerNumber"`
+	Found           bool      `json:"found"`
+	LookupError     string    `json:"lookupError"`
+	TerminalCode    string    `json:"terminalCode"`
+	TerminalName    string    `json:"terminalName"`
+	VesselName      string    `json:"vesselName"`
+	VoyageNumber    string    `json:"voyageNumber"`
+	ISOSizeType     string    `json:"isoSizeType"`
+	Size            string    `json:"size"`
+	Type            string    `json:"type"`
+	CheckedAt       time.Time `json:"checkedAt"`
+}
+
+// HandleContainerVerification is a kafka.Handler for eModal verification
+// results. Containers the terminal does not know, or knows with a different
+// size, type or vessel, are flagged and announced on
+// orders.container.verification_failed.
+func (c *ContainerEventConsumer) HandleContainerVerification(ctx context.Context, event *kafka.Event) error {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload emodalVerificationPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("unmarshal container verification: %w", err)
+	}
+	if payload.ContainerNumber == "" {
+		return apperrors.ValidationError("container number is required", "containerNumber", payload.ContainerNumber)
+	}
+	if payload.CheckedAt.IsZero() {
+		payload.CheckedAt = event.Time
+	}
+
+	container, err := c.containerRepo.GetByNumber(ctx, payload.ContainerNumber)
+	if err != nil || container == nil {
+		c.logger.Debugw("Ignoring verification for unknown container", "container", payload.ContainerNumber)
+		return nil
+	}
+
+	var mismatches []domain.ContainerMismatch
+	switch {
+	case payload.LookupError != "":
+		container.VerificationStatus = domain.ContainerVerificationUnavailable
+	case !payload.Found:
+		container.VerificationStatus = domain.ContainerVerificationNotFound
+	default:
+		shipment, err := c.shipmentRepo.GetByID(ctx, container.ShipmentID)
+		if err != nil {
+			return apperrors.DatabaseError("get shipment", err)
+		}
+		mismatches = compareWithTerminal(container, shipment, payload)
+		container.VerificationStatus = domain.ContainerVerificationVerified
+		if len(mismatches) > 0 {
+			container.VerificationStatus = domain.ContainerVerificationMismatch
+		}
+	}
+	container.VerificationMismatches = mismatches
+	container.VerifiedAt = &payload.CheckedAt
+	container.UpdatedAt = time.Now()
+
+	if err := c.containerRepo.Update(ctx, container); err != nil {
+		return apperrors.DatabaseError("update container verification", err)
+	}
+
+	if container.VerificationStatus == domain.ContainerVerificationVerified {
+		c.logger.Infow("Container verified against terminal",
+			"container", container.ContainerNumber,
+			"terminal", payload.TerminalCode,
+		)
+		return nil
+	}
+
+	c.logger.Warnw("Container failed terminal verification",
+		"container", container.ContainerNumber,
+		"status", container.VerificationStatus,
+		"mismatches", len(mismatches),
+		"lookup_error", payload.LookupError,
+	)
+
+	data := map[string]interface{}{
+		"container_id":        container.ID.String(),
+		"container_number":    container.ContainerNumber,
+		"shipment_id":         container.ShipmentID.String(),
+		"verification_status": container.VerificationStatus,
+		"mismatches":          mismatches,
+		"terminal_code":       payload.TerminalCode,
+		"iso_size_type":       payload.ISOSizeType,
+	}
+	if order, _ := c.orderRepo.GetByContainerID(ctx, container.ID); order != nil {
+		data["order_id"] = order.ID.String()
+		data["order_number"] = order.OrderNumber
+	}
+	failed := kafka.NewEvent(kafka.Topics.ContainerVerificationFailed, "order-service", data)
+	_ = c.eventProducer.Publish(ctx, kafka.Topics.ContainerVerificationFailed, failed)
+
+	return nil
+}
+
+// compareWithTerminal lists the fields where the terminal's record disagrees
+// with what was keyed. Fields either side left blank are not compared.
+func compareWithTerminal(container *domain.Container, shipment *domain.Shipment, terminal emodalVerificationPayload) []domain.ContainerMismatch {
+	var mismatches []domain.ContainerMismatch
+	check := func(field, expected, reported string) {
+		expected, reported = normalizeReference(expected), normalizeReference(reported)
+		if expected != "" && reported != "" && expected != reported {
+			mismatches = append(mismatches, domain.ContainerMismatch{Field: field, Expected: expected, Reported: reported})
+		}
+	}
+
+	check("size", string(container.Size), terminal.Size)
+	check("type", string(container.Type), terminal.Type)
+	if shipment != nil {
+		check("vessel_name", shipment.VesselName, terminal.VesselName)
+		check("voyage_number", shipment.VoyageNumber, terminal.VoyageNumber)
+	}
+	return mismatches
+}
+
+// normalizeReference upper-cases and collapses whitespace so "Msc  Aurora"
+// matches "MSC AURORA"
+func normalizeReference(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
@@ -0,0 +1,714 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/jobs"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+	"github.com/draymaster/shared/pkg/validation"
+)
+
+// Intake attachments are parsed on their own job queue by ParseAttachmentJob
+// jobs, which read a stored attachment into its email's shipment draft
+const (
+	IntakeJobQueue     = "order-intake"
+	ParseAttachmentJob = "intake.parse_attachment"
+)
+
+// Intake polling limits
+const (
+	intakeBatchSize        = 25
+	maxIntakeAttachment    = 20 << 20
+	maxIntakeBodyChars     = 100000
+	intakeAttachmentPrefix = "intake"
+)
+
+// MailMessage is an unread email in the intake mailbox
+type MailMessage struct {
+	ID          string // Provider ID, used to mark the message read
+	MessageID   string // Internet Message-ID
+	ThreadID    string // Provider conversation ID; the Message-ID when the provider has none
+	From        string
+	Subject     string
+	Body        string // Plain text, or HTML when BodyIsHTML
+	BodyIsHTML  bool
+	ReceivedAt  time.Time
+	Attachments []MailAttachment
+}
+
+// MailAttachment is a file attached to a MailMessage
+type MailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Mailbox reads the order intake mailbox
+type Mailbox interface {
+	Address() string
+	Unread(ctx context.Context, limit int) ([]MailMessage, error) // Oldest first
+	MarkRead(ctx context.Context, id string) error
+}
+
+// parseAttachmentPayload is the payload of a ParseAttachmentJob
+type parseAttachmentPayload struct {
+	EmailID uuid.UUID `json:"email_id"`
+	Index   int       `json:"index"`
+}
+
+// errAlreadyIngested rolls back an ingest whose email was recorded before
+var errAlreadyIngested = errors.New("email already ingested")
+
+// EmailIntakeService reads delivery orders and booking confirmations from
+// the order intake mailbox into shipment drafts, one per email thread, for
+// clerks to confirm into shipments
+type EmailIntakeService struct {
+	db                 *database.DB
+	intakeRepo         repository.IntakeRepository
+	shipmentRepo       repository.ShipmentRepository
+	sslRepo            repository.SteamshipLineRepository
+	orders             *OrderService
+	mailbox            Mailbox
+	store              storage.Store
+	queue              *jobs.Queue
+	eventProducer      *kafka.Producer
+	logger             *logger.Logger
+	containerValidator *validation.ContainerNumberValidator
+}
+
+// NewEmailIntakeService creates a new email intake service
+func NewEmailIntakeService(
+	db *database.DB,
+	intakeRepo repository.IntakeRepository,
+	shipmentRepo repository.ShipmentRepository,
+	sslRepo repository.SteamshipLineRepository,
+	orders *OrderService,
+	mailbox Mailbox,
+	store storage.Store,
+	queue *jobs.Queue,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *EmailIntakeService {
+	return &EmailIntakeService{
+		db:                 db,
+		intakeRepo:         intakeRepo,
+		shipmentRepo:       shipmentRepo,
+		sslRepo:            sslRepo,
+		orders:             orders,
+		mailbox:            mailbox,
+		store:              store,
+		queue:              queue,
+		eventProducer:      eventProducer,
+		logger:             log,
+		containerValidator: validation.NewContainerNumberValidator(),
+	}
+}
+
+// =============================================================================
+// MAILBOX
+// =============================================================================
+
+// Start polls the mailbox at the given interval until ctx is cancelled
+func (s *EmailIntakeService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Poll(ctx); err != nil {
+				s.logger.Errorw("Intake mailbox poll failed", "mailbox", s.mailbox.Address(), "error", err)
+			}
+		}
+	}
+}
+
+// Poll ingests the mailbox's unread messages and marks each read once it is
+// recorded. A message that fails stays unread and is tried again next poll.
+func (s *EmailIntakeService) Poll(ctx context.Context) (int, error) {
+	messages, err := s.mailbox.Unread(ctx, intakeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("read mailbox: %w", err)
+	}
+
+	ingested := 0
+	for i := range messages {
+		msg := &messages[i]
+		if err := s.ingest(ctx, msg); err != nil {
+			s.logger.Errorw("Failed to ingest intake email",
+				"message_id", msg.MessageID,
+				"subject", msg.Subject,
+				"error", err,
+			)
+			continue
+		}
+		if err := s.mailbox.MarkRead(ctx, msg.ID); err != nil {
+			s.logger.Warnw("Failed to mark intake email read", "message_id", msg.MessageID, "error", err)
+		}
+		ingested++
+	}
+	return ingested, nil
+}
+
+// ingest records an email, stores its attachments, and adds it to its
+// thread's pending draft or starts one. Emails that are not orders are
+// recorded without a draft.
+func (s *EmailIntakeService) ingest(ctx context.Context, msg *MailMessage) error {
+	mailbox := s.mailbox.Address()
+	body := msg.Body
+	if msg.BodyIsHTML {
+		body = htmlText(body)
+	}
+	if len(body) > maxIntakeBodyChars {
+		body = body[:maxIntakeBodyChars]
+	}
+	threadID := msg.ThreadID
+	if threadID == "" {
+		threadID = msg.MessageID
+	}
+
+	now := time.Now()
+	email := &domain.IntakeEmail{
+		ID:          uuid.New(),
+		Mailbox:     mailbox,
+		MessageID:   msg.MessageID,
+		ThreadID:    threadID,
+		FromAddress: strings.ToLower(msg.From),
+		Subject:     msg.Subject,
+		Body:        body,
+		ReceivedAt:  msg.ReceivedAt,
+		Attachments: []domain.IntakeAttachment{},
+		CreatedAt:   now,
+	}
+
+	// Keyed by message so a retried ingest overwrites rather than duplicates
+	sum := sha256.Sum256([]byte(mailbox + "\x00" + msg.MessageID))
+	folder := hex.EncodeToString(sum[:12])
+	filenames := make([]string, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		filenames = append(filenames, a.Filename)
+		attachment := domain.IntakeAttachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Size:        int64(len(a.Content)),
+			StorageKey:  storage.Key(intakeAttachmentPrefix, folder, path.Base("/"+a.Filename)),
+			ParseStatus: domain.AttachmentParseQueued,
+		}
+		if _, err := s.store.Put(ctx, attachment.StorageKey, bytes.NewReader(a.Content), storage.PutOptions{
+			ContentType: a.ContentType,
+			Size:        attachment.Size,
+		}); err != nil {
+			return fmt.Errorf("store attachment %s: %w", a.Filename, err)
+		}
+		email.Attachments = append(email.Attachments, attachment)
+	}
+	email.Class = domain.ClassifyEmail(msg.Subject, body, filenames)
+
+	var draft *domain.ShipmentDraft
+	created := false
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		if email.Class != domain.EmailClassOther {
+			var err error
+			draft, err = s.intakeRepo.GetPendingDraftByThread(txCtx, mailbox, threadID)
+			if err != nil {
+				return apperrors.DatabaseError("get draft by thread", err)
+			}
+			if draft == nil {
+				draft, err = s.newDraft(txCtx, email)
+				if err != nil {
+					return err
+				}
+				created = true
+			}
+			email.DraftID = &draft.ID
+
+			// The draft goes in first so the email can reference it
+			extraction := s.extract(msg.Subject + "\n" + body)
+			if created {
+				draft.Apply(extraction)
+				if err := s.intakeRepo.CreateDraft(txCtx, draft); err != nil {
+					return apperrors.DatabaseError("create draft", err)
+				}
+			} else if draft.Apply(extraction) {
+				draft.UpdatedAt = now
+				if err := s.intakeRepo.UpdateDraft(txCtx, draft); err != nil {
+					return apperrors.DatabaseError("update draft", err)
+				}
+			}
+		}
+
+		ok, err := s.intakeRepo.CreateEmail(txCtx, email)
+		if err != nil {
+			return apperrors.DatabaseError("create intake email", err)
+		}
+		if !ok {
+			return errAlreadyIngested
+		}
+		return nil
+	})
+	if errors.Is(err, errAlreadyIngested) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if draft != nil {
+		for i, a := range email.Attachments {
+			if _, err := s.queue.Enqueue(ctx, ParseAttachmentJob, parseAttachmentPayload{EmailID: email.ID, Index: i}, jobs.EnqueueOptions{
+				UniqueKey: a.StorageKey,
+			}); err != nil {
+				s.logger.Warnw("Failed to queue intake attachment", "email_id", email.ID, "filename", a.Filename, "error", err)
+			}
+		}
+	}
+	if created {
+		s.publishDraft(ctx, draft, email)
+	}
+
+	s.logger.Infow("Intake email ingested",
+		"email_id", email.ID,
+		"class", email.Class,
+		"from", email.FromAddress,
+		"attachments", len(email.Attachments),
+		"new_draft", created,
+	)
+	return nil
+}
+
+// newDraft starts a draft for an email, with the customer its sender is mapped to
+func (s *EmailIntakeService) newDraft(ctx context.Context, email *domain.IntakeEmail) (*domain.ShipmentDraft, error) {
+	customerID, err := s.intakeRepo.FindSenderCustomer(ctx, email.FromAddress)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find sender customer", err)
+	}
+	shipmentType := domain.ShipmentTypeImport
+	if email.Class == domain.EmailClassBooking {
+		shipmentType = domain.ShipmentTypeExport
+	}
+	return &domain.ShipmentDraft{
+		ID:               uuid.New(),
+		Mailbox:          email.Mailbox,
+		ThreadID:         email.ThreadID,
+		Subject:          email.Subject,
+		FromAddress:      email.FromAddress,
+		Type:             shipmentType,
+		CustomerID:       customerID,
+		ContainerNumbers: []string{},
+		Status:           domain.DraftStatusPendingReview,
+		CreatedAt:        email.CreatedAt,
+		UpdatedAt:        email.CreatedAt,
+	}, nil
+}
+
+// extract reads shipment references from text, dropping container numbers
+// whose check digit is wrong
+func (s *EmailIntakeService) extract(text string) domain.DraftExtraction {
+	e := domain.ExtractDraft(text)
+	valid := e.ContainerNumbers[:0]
+	for _, number := range e.ContainerNumbers {
+		if s.containerValidator.Validate(number) == nil {
+			valid = append(valid, number)
+		}
+	}
+	e.ContainerNumbers = valid
+	return e
+}
+
+// =============================================================================
+// ATTACHMENT PARSING
+// =============================================================================
+
+// HandleParseAttachment is a jobs.Handler that reads a stored attachment's
+// text into its email's draft. Attachments without a text layer, such as
+// scans, are marked unsupported for the clerk to read.
+func (s *EmailIntakeService) HandleParseAttachment(ctx context.Context, job *jobs.Job) error {
+	var payload parseAttachmentPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("decode payload: %w", err))
+	}
+	email, err := s.intakeRepo.GetEmail(ctx, payload.EmailID)
+	if err != nil {
+		return apperrors.DatabaseError("get intake email", err)
+	}
+	if email == nil || payload.Index < 0 || payload.Index >= len(email.Attachments) {
+		return jobs.Permanent(fmt.Errorf("intake attachment %s/%d not found", payload.EmailID, payload.Index))
+	}
+	attachment := &email.Attachments[payload.Index]
+
+	body, _, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return jobs.Permanent(err)
+		}
+		return fmt.Errorf("read attachment: %w", err)
+	}
+	content, err := io.ReadAll(io.LimitReader(body, maxIntakeAttachment))
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("read attachment: %w", err)
+	}
+
+	text, ok := attachmentText(attachment.Filename, attachment.ContentType, content)
+	extraction := s.extract(text)
+	switch {
+	case !ok:
+		attachment.ParseStatus = domain.AttachmentParseUnsupported
+	case strings.TrimSpace(text) == "":
+		attachment.ParseStatus = domain.AttachmentParseFailed
+	default:
+		attachment.ParseStatus = domain.AttachmentParseParsed
+	}
+
+	return s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		if email.DraftID != nil && attachment.ParseStatus == domain.AttachmentParseParsed {
+			draft, err := s.intakeRepo.GetDraftForUpdate(txCtx, *email.DraftID)
+			if err != nil {
+				return apperrors.DatabaseError("get draft", err)
+			}
+			if draft != nil && draft.Status == domain.DraftStatusPendingReview && draft.Apply(extraction) {
+				draft.UpdatedAt = time.Now()
+				if err := s.intakeRepo.UpdateDraft(txCtx, draft); err != nil {
+					return apperrors.DatabaseError("update draft", err)
+				}
+			}
+		}
+		if err := s.intakeRepo.UpdateEmail(txCtx, email); err != nil {
+			return apperrors.DatabaseError("update intake email", err)
+		}
+		return nil
+	})
+}
+
+// attachmentText returns the text of an attachment, or false when the
+// format has no text to read
+func attachmentText(filename, contentType string, content []byte) (string, bool) {
+	ext := strings.ToLower(path.Ext(filename))
+	contentType = strings.ToLower(contentType)
+	switch {
+	case ext == ".pdf" || strings.Contains(contentType, "pdf"):
+		text := pdfText(content)
+		return text, strings.TrimSpace(text) != ""
+	case ext == ".htm" || ext == ".html" || strings.HasPrefix(contentType, "text/html"):
+		return htmlText(string(content)), true
+	case ext == ".txt" || ext == ".csv" || ext == ".edi" || strings.HasPrefix(contentType, "text/"):
+		return string(content), true
+	default:
+		return "", false
+	}
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+	htmlBreakPattern  = regexp.MustCompile(`(?i)<(br|/p|/div|/tr|/li|/h[1-6])[^>]*>`)
+	pdfStreamPattern  = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfTextOpPattern  = regexp.MustCompile(`(?s)(\((?:\\.|[^\\)])*\)|\[(?:\\.|[^\]])*\])\s*(Tj|TJ|'|")|T\*|Td|TD|ET`)
+	pdfStringPattern  = regexp.MustCompile(`\((?:\\.|[^\\)])*\)`)
+	pdfStringReplacer = strings.NewReplacer(`\n`, "\n", `\r`, "\n", `\t`, " ", `\(`, "(", `\)`, ")", `\\`, `\`)
+)
+
+// htmlText reduces an HTML body to its text, one line per block
+func htmlText(s string) string {
+	s = htmlBreakPattern.ReplaceAllString(s, "\n$0")
+	return html.UnescapeString(htmlTagPattern.ReplaceAllString(s, " "))
+}
+
+// pdfText reads the text drawn by a PDF's content streams. It handles the
+// plain and Flate-compressed streams carrier and forwarder systems produce;
+// scanned DOs have no text and come back empty.
+func pdfText(content []byte) string {
+	var out strings.Builder
+	for _, m := range pdfStreamPattern.FindAllSubmatch(content, -1) {
+		stream := m[1]
+		if r, err := zlib.NewReader(bytes.NewReader(stream)); err == nil {
+			if inflated, err := io.ReadAll(io.LimitReader(r, maxIntakeAttachment)); err == nil {
+				stream = inflated
+			}
+			r.Close()
+		}
+		for _, op := range pdfTextOpPattern.FindAllSubmatch(stream, -1) {
+			if len(op[1]) == 0 {
+				out.WriteByte('\n') // Line move or end of text block
+				continue
+			}
+			for _, str := range pdfStringPattern.FindAll(op[1], -1) {
+				out.WriteString(pdfStringReplacer.Replace(string(str[1 : len(str)-1])))
+			}
+			if string(op[2]) != "TJ" && string(op[2]) != "Tj" {
+				out.WriteByte('\n')
+			}
+		}
+	}
+	return out.String()
+}
+
+// =============================================================================
+// REVIEW
+// =============================================================================
+
+// ListDrafts returns drafts for the clerks' review queue
+func (s *EmailIntakeService) ListDrafts(ctx context.Context, filter repository.DraftFilter) ([]domain.ShipmentDraft, error) {
+	drafts, err := s.intakeRepo.ListDrafts(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list drafts", err)
+	}
+	return drafts, nil
+}
+
+// GetDraft returns a draft with the emails it was built from
+func (s *EmailIntakeService) GetDraft(ctx context.Context, id uuid.UUID) (*domain.ShipmentDraft, error) {
+	draft, err := s.intakeRepo.GetDraft(ctx, id)
+	if err != nil || draft == nil {
+		return nil, apperrors.NotFoundError("shipment draft", id.String())
+	}
+	draft.Emails, err = s.intakeRepo.GetEmailsByDraftID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get draft emails", err)
+	}
+	return draft, nil
+}
+
+// ConfirmIntakeInput fills in what the email could not tell us. A draft
+// whose fields are all read from the thread confirms with only PortID,
+// TerminalID and ConfirmedBy; anything else set here overrides the draft.
+type ConfirmIntakeInput struct {
+	CustomerID       *uuid.UUID           `json:"customer_id"`
+	SteamshipLineID  *uuid.UUID           `json:"steamship_line_id"`
+	PortID           *uuid.UUID           `json:"port_id"`
+	TerminalID       *uuid.UUID           `json:"terminal_id"`
+	ReferenceNumber  string               `json:"reference_number"`
+	ContainerNumbers []string             `json:"container_numbers"`
+	ContainerSize    domain.ContainerSize `json:"container_size"`
+	ContainerType    domain.ContainerType `json:"container_type"`
+	LastFreeDay      *time.Time           `json:"last_free_day"`
+	RememberSender   bool                 `json:"remember_sender"` // Map the sender's address to the customer for later emails
+	ConfirmedBy      string               `json:"confirmed_by"`
+}
+
+// ConfirmIntake creates the draft's shipment and containers
+func (s *EmailIntakeService) ConfirmIntake(ctx context.Context, id uuid.UUID, input ConfirmIntakeInput) (*domain.ShipmentDraft, error) {
+	var draft *domain.ShipmentDraft
+	var shipment *domain.Shipment
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		// The row lock holds a second confirm of the same draft until this one commits
+		var err error
+		draft, err = s.intakeRepo.GetDraftForUpdate(txCtx, id)
+		if err != nil || draft == nil {
+			return apperrors.NotFoundError("shipment draft", id.String())
+		}
+		if draft.Status != domain.DraftStatusPendingReview {
+			return apperrors.InvalidStateError(string(draft.Status), string(domain.DraftStatusPendingReview))
+		}
+
+		shipmentInput, err := s.shipmentInput(txCtx, draft, input)
+		if err != nil {
+			return err
+		}
+		if existing, _ := s.shipmentRepo.GetByReferenceNumber(txCtx, shipmentInput.ReferenceNumber); existing != nil {
+			return apperrors.ConflictError(fmt.Sprintf("shipment %s already exists", shipmentInput.ReferenceNumber))
+		}
+		shipment, err = s.orders.CreateShipment(txCtx, shipmentInput)
+		if err != nil {
+			return apperrors.DatabaseError("create shipment", err)
+		}
+
+		now := time.Now()
+		draft.CustomerID = &shipmentInput.CustomerID
+		draft.ReferenceNumber = shipmentInput.ReferenceNumber
+		draft.Status = domain.DraftStatusConfirmed
+		draft.ShipmentID = &shipment.ID
+		draft.ReviewedBy = input.ConfirmedBy
+		draft.ReviewedAt = &now
+		draft.UpdatedAt = now
+		if err := s.intakeRepo.UpdateDraft(txCtx, draft); err != nil {
+			return apperrors.DatabaseError("update draft", err)
+		}
+
+		if input.RememberSender && draft.FromAddress != "" {
+			if err := s.intakeRepo.SaveSender(txCtx, &domain.IntakeSender{
+				Address:    draft.FromAddress,
+				CustomerID: shipmentInput.CustomerID,
+				CreatedAt:  now,
+			}); err != nil {
+				return apperrors.DatabaseError("save intake sender", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Intake draft confirmed",
+		"draft_id", draft.ID,
+		"shipment_id", shipment.ID,
+		"reference", shipment.ReferenceNumber,
+		"containers", len(shipment.Containers),
+		"by", input.ConfirmedBy,
+	)
+	return draft, nil
+}
+
+// shipmentInput merges a clerk's confirm input over a draft and checks
+// the shipment can be created from it
+func (s *EmailIntakeService) shipmentInput(ctx context.Context, draft *domain.ShipmentDraft, input ConfirmIntakeInput) (CreateShipmentInput, error) {
+	reference := strings.ToUpper(strings.TrimSpace(input.ReferenceNumber))
+	if reference == "" {
+		reference = draft.ReferenceNumber
+	}
+	if reference == "" {
+		return CreateShipmentInput{}, apperrors.ValidationError("reference number is required", "reference_number", nil)
+	}
+	customerID := firstID(input.CustomerID, draft.CustomerID)
+	if customerID == nil {
+		return CreateShipmentInput{}, apperrors.ValidationError("customer is required; the sender is not mapped to one", "customer_id", nil)
+	}
+	if input.PortID == nil || input.TerminalID == nil {
+		return CreateShipmentInput{}, apperrors.ValidationError("port and terminal are required", "terminal_id", nil)
+	}
+
+	sslID := input.SteamshipLineID
+	if sslID == nil {
+		ssl, err := s.sslRepo.GetByCode(ctx, draft.SteamshipLineCode)
+		if err != nil || ssl == nil {
+			return CreateShipmentInput{}, apperrors.ValidationError("unknown steamship line", "steamship_line_code", draft.SteamshipLineCode)
+		}
+		sslID = &ssl.ID
+	}
+
+	numbers := draft.ContainerNumbers
+	if len(input.ContainerNumbers) > 0 {
+		numbers = input.ContainerNumbers
+	}
+	if len(numbers) == 0 {
+		return CreateShipmentInput{}, apperrors.ValidationError("at least one container is required", "container_numbers", nil)
+	}
+	size, containerType := draft.ContainerSize, draft.ContainerType
+	if input.ContainerSize != "" {
+		size = input.ContainerSize
+	}
+	if input.ContainerType != "" {
+		containerType = input.ContainerType
+	}
+	if size == "" {
+		return CreateShipmentInput{}, apperrors.ValidationError("container size is required", "container_size", nil)
+	}
+	if containerType == "" {
+		containerType = domain.ContainerTypeDry
+	}
+
+	containers := make([]CreateContainerInput, 0, len(numbers))
+	for _, number := range numbers {
+		number = strings.ToUpper(strings.TrimSpace(number))
+		if err := s.containerValidator.Validate(number); err != nil {
+			return CreateShipmentInput{}, apperrors.ValidationError(err.Error(), "container_numbers", number)
+		}
+		containers = append(containers, CreateContainerInput{ContainerNumber: number, Size: size, Type: containerType})
+	}
+
+	lastFreeDay := draft.LastFreeDay
+	if input.LastFreeDay != nil {
+		lastFreeDay = input.LastFreeDay
+	}
+	return CreateShipmentInput{
+		Type:                draft.Type,
+		ReferenceNumber:     reference,
+		CustomerID:          *customerID,
+		SteamshipLineID:     *sslID,
+		PortID:              *input.PortID,
+		TerminalID:          *input.TerminalID,
+		VesselName:          draft.VesselName,
+		VoyageNumber:        draft.VoyageNumber,
+		LastFreeDay:         lastFreeDay,
+		SpecialInstructions: fmt.Sprintf("From email %q (%s)", draft.Subject, draft.FromAddress),
+		Containers:          containers,
+	}, nil
+}
+
+// RejectIntake closes a draft that should not become a shipment, such as a
+// duplicate or an email misread as an order
+func (s *EmailIntakeService) RejectIntake(ctx context.Context, id uuid.UUID, reason, rejectedBy string) (*domain.ShipmentDraft, error) {
+	if reason == "" {
+		return nil, apperrors.ValidationError("reject reason is required", "reason", reason)
+	}
+	draft, err := s.intakeRepo.GetDraft(ctx, id)
+	if err != nil || draft == nil {
+		return nil, apperrors.NotFoundError("shipment draft", id.String())
+	}
+	if draft.Status != domain.DraftStatusPendingReview {
+		return nil, apperrors.InvalidStateError(string(draft.Status), string(domain.DraftStatusPendingReview))
+	}
+
+	now := time.Now()
+	draft.Status = domain.DraftStatusRejected
+	draft.RejectReason = reason
+	draft.ReviewedBy = rejectedBy
+	draft.ReviewedAt = &now
+	draft.UpdatedAt = now
+	if err := s.intakeRepo.UpdateDraft(ctx, draft); err != nil {
+		return nil, apperrors.DatabaseError("update draft", err)
+	}
+	return draft, nil
+}
+
+// SaveSender maps a sender address, or a domain written as "@example.com",
+// to the customer new drafts from it are booked for
+func (s *EmailIntakeService) SaveSender(ctx context.Context, sender domain.IntakeSender) (*domain.IntakeSender, error) {
+	sender.Address = strings.ToLower(strings.TrimSpace(sender.Address))
+	if !strings.Contains(sender.Address, "@") {
+		return nil, apperrors.ValidationError("address must be an email address or @domain", "address", sender.Address)
+	}
+	if sender.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer is required", "customer_id", nil)
+	}
+	sender.CreatedAt = time.Now()
+	if err := s.intakeRepo.SaveSender(ctx, &sender); err != nil {
+		return nil, apperrors.DatabaseError("save intake sender", err)
+	}
+	return &sender, nil
+}
+
+// publishDraft announces a new draft for the clerks' review queue
+func (s *EmailIntakeService) publishDraft(ctx context.Context, draft *domain.ShipmentDraft, email *domain.IntakeEmail) {
+	event := kafka.NewEvent(kafka.Topics.IntakeDraftCreated, "order-service", map[string]interface{}{
+		"draft_id":          draft.ID.String(),
+		"email_id":          email.ID.String(),
+		"class":             email.Class,
+		"from":              email.FromAddress,
+		"subject":           email.Subject,
+		"reference_number":  draft.ReferenceNumber,
+		"container_count":   len(draft.ContainerNumbers),
+		"customer_resolved": draft.CustomerID != nil,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.IntakeDraftCreated, event)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// FacilityService keeps the address book's shipper and consignee facility
+// profiles and checks requested delivery times against their hours. Saved
+// profiles are published so dispatch can print them on driver manifests.
+type FacilityService struct {
+	profileRepo   repository.FacilityProfileRepository
+	locationRepo  repository.LocationRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewFacilityService creates a new facility service
+func NewFacilityService(
+	profileRepo repository.FacilityProfileRepository,
+	locationRepo repository.LocationRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *FacilityService {
+	return &FacilityService{
+		profileRepo:   profileRepo,
+		locationRepo:  locationRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// SaveFacilityProfileInput contains input for saving a facility profile
+type SaveFacilityProfileInput struct {
+	LocationID       uuid.UUID               `json:"-"`
+	Timezone         string                  `json:"timezone"`
+	Hours            []domain.FacilityWindow `json:"hours"`
+	LunchClosures    []domain.FacilityWindow `json:"lunch_closures"`
+	DockCount        int                     `json:"dock_count"`
+	RequiredPPE      []string                `json:"required_ppe"`
+	CheckInProcedure string                  `json:"check_in_procedure"`
+	Notes            string                  `json:"notes"`
+	UpdatedBy        string                  `json:"-"`
+}
+
+// SaveProfile creates or replaces a location's facility profile
+func (s *FacilityService) SaveProfile(ctx context.Context, input SaveFacilityProfileInput) (*domain.FacilityProfile, error) {
+	location, err := s.locationRepo.GetByID(ctx, input.LocationID)
+	if err != nil || location == nil {
+		return nil, apperrors.NotFoundError("location", input.LocationID.String())
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil || input.Timezone == "" {
+		return nil, apperrors.ValidationError("invalid timezone", "timezone", input.Timezone)
+	}
+	for _, w := range input.Hours {
+		if !w.Valid() {
+			return nil, apperrors.ValidationError("hours must be a weekday with open before close as HH:MM", "hours", w)
+		}
+	}
+	for _, w := range input.LunchClosures {
+		if !w.Valid() {
+			return nil, apperrors.ValidationError("lunch closures must be a weekday with open before close as HH:MM", "lunch_closures", w)
+		}
+	}
+	if input.DockCount < 0 {
+		return nil, apperrors.ValidationError("dock count cannot be negative", "dock_count", input.DockCount)
+	}
+
+	var ppe []string
+	for _, item := range input.RequiredPPE {
+		if item = strings.TrimSpace(item); item != "" {
+			ppe = append(ppe, item)
+		}
+	}
+
+	profile := &domain.FacilityProfile{
+		LocationID:       input.LocationID,
+		Timezone:         input.Timezone,
+		Hours:            input.Hours,
+		LunchClosures:    input.LunchClosures,
+		DockCount:        input.DockCount,
+		RequiredPPE:      ppe,
+		CheckInProcedure: strings.TrimSpace(input.CheckInProcedure),
+		Notes:            strings.TrimSpace(input.Notes),
+		UpdatedBy:        input.UpdatedBy,
+		UpdatedAt:        time.Now(),
+	}
+	if err := s.profileRepo.Upsert(ctx, profile); err != nil {
+		return nil, apperrors.DatabaseError("save facility profile", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.FacilityProfileUpdated, "order-service", profile)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.FacilityProfileUpdated, event)
+
+	s.logger.Infow("Facility profile saved",
+		"location_id", profile.LocationID,
+		"location", location.Name,
+		"updated_by", profile.UpdatedBy,
+	)
+	return profile, nil
+}
+
+// GetProfile returns a location's facility profile, or nil if it has none
+func (s *FacilityService) GetProfile(ctx context.Context, locationID uuid.UUID) (*domain.FacilityProfile, error) {
+	profile, err := s.profileRepo.GetByLocationID(ctx, locationID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get facility profile", err)
+	}
+	return profile, nil
+}
+
+// CheckDeliveryTime fails with FACILITY_CLOSED when the location does not
+// receive at the requested time. Locations without a profile are not checked.
+func (s *FacilityService) CheckDeliveryTime(ctx context.Context, locationID uuid.UUID, at time.Time) error {
+	profile, err := s.GetProfile(ctx, locationID)
+	if err != nil || profile == nil {
+		return err
+	}
+	if reason := profile.ClosedReason(at); reason != "" {
+		return apperrors.New("FACILITY_CLOSED", reason).
+			WithDetail("location_id", locationID.String()).
+			WithDetail("requested_delivery_date", at).
+			WithDetail("hours", profile.Hours)
+	}
+	return nil
+}
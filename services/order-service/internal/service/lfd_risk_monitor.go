@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// lfdRiskPageSize is the shipment page size the monitor scans with
+const lfdRiskPageSize = 100
+
+// LFDRiskMonitor warns ops about import containers still sitting at the
+// terminal as their last free day runs out. Each container is reported
+// once per LFD; a new LFD from the steamship line reports it again.
+type LFDRiskMonitor struct {
+	shipmentRepo  repository.ShipmentRepository
+	containerRepo repository.ContainerRepository
+	eventProducer *kafka.Producer
+	leadTime      time.Duration
+	logger        *logger.Logger
+
+	mu       sync.Mutex
+	reported map[uuid.UUID]time.Time // Container ID to the LFD it was reported for
+}
+
+// NewLFDRiskMonitor creates a new LFD risk monitor
+func NewLFDRiskMonitor(
+	shipmentRepo repository.ShipmentRepository,
+	containerRepo repository.ContainerRepository,
+	eventProducer *kafka.Producer,
+	rules config.DemurrageRules,
+	log *logger.Logger,
+) *LFDRiskMonitor {
+	return &LFDRiskMonitor{
+		shipmentRepo:  shipmentRepo,
+		containerRepo: containerRepo,
+		eventProducer: eventProducer,
+		leadTime:      time.Duration(rules.LFDRiskLeadHours) * time.Hour,
+		logger:        log,
+		reported:      make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Check publishes ContainerLFDRisk for every import container still at the
+// terminal whose last free day ends within the lead time. The LFD is a
+// date, so free time runs to the end of that day.
+func (m *LFDRiskMonitor) Check(ctx context.Context, now time.Time) error {
+	after := now.Add(-24 * time.Hour)
+	before := now.Add(m.leadTime)
+	filter := repository.ShipmentFilter{
+		Type:      domain.ShipmentTypeImport,
+		LFDAfter:  &after,
+		LFDBefore: &before,
+		PageSize:  lfdRiskPageSize,
+		SortBy:    "last_free_day",
+		SortOrder: "asc",
+	}
+
+	for filter.Page = 1; ; filter.Page++ {
+		shipments, total, err := m.shipmentRepo.List(ctx, filter)
+		if err != nil {
+			return apperrors.DatabaseError("list shipments near LFD", err)
+		}
+		for _, shipment := range shipments {
+			m.checkShipment(ctx, shipment, now)
+		}
+		if len(shipments) < lfdRiskPageSize || int64(filter.Page*lfdRiskPageSize) >= total {
+			break
+		}
+	}
+
+	m.forget(now)
+	return nil
+}
+
+func (m *LFDRiskMonitor) checkShipment(ctx context.Context, shipment *domain.Shipment, now time.Time) {
+	if shipment.LastFreeDay == nil {
+		return
+	}
+	lfd := *shipment.LastFreeDay
+	ends := time.Date(lfd.Year(), lfd.Month(), lfd.Day(), 0, 0, 0, 0, lfd.Location()).Add(24 * time.Hour)
+	if !ends.After(now) || ends.Sub(now) > m.leadTime {
+		return
+	}
+
+	containers, err := m.containerRepo.GetByShipmentID(ctx, shipment.ID)
+	if err != nil {
+		m.logger.Warnw("Failed to load containers for LFD check", "shipment_id", shipment.ID, "error", err)
+		return
+	}
+	for _, container := range containers {
+		if container.CurrentLocationType != domain.LocationTypeTerminal || !m.markReported(container.ID, lfd) {
+			continue
+		}
+
+		event := kafka.NewEvent(kafka.Topics.ContainerLFDRisk, "order-service", kafka.ContainerLFDRiskEvent{
+			ContainerID:     container.ID.String(),
+			ContainerNumber: container.ContainerNumber,
+			ShipmentID:      shipment.ID.String(),
+			ReferenceNumber: shipment.ReferenceNumber,
+			CustomerID:      shipment.CustomerID.String(),
+			TerminalID:      shipment.TerminalID.String(),
+			LastFreeDay:     lfd,
+			HoursRemaining:  int(ends.Sub(now).Hours()),
+		})
+		_ = m.eventProducer.Publish(ctx, kafka.Topics.ContainerLFDRisk, event)
+
+		m.logger.Warnw("Container at risk of missing last free day",
+			"container_number", container.ContainerNumber,
+			"shipment_id", shipment.ID,
+			"last_free_day", lfd,
+		)
+	}
+}
+
+// markReported records the container as reported for lfd, returning false
+// if it already was
+func (m *LFDRiskMonitor) markReported(containerID uuid.UUID, lfd time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.reported[containerID]; ok && prev.Equal(lfd) {
+		return false
+	}
+	m.reported[containerID] = lfd
+	return true
+}
+
+// forget drops containers whose reported LFD has passed
+func (m *LFDRiskMonitor) forget(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, lfd := range m.reported {
+		if now.Sub(lfd) > 48*time.Hour {
+			delete(m.reported, id)
+		}
+	}
+}
+
+// Start checks on every tick until ctx is cancelled
+func (m *LFDRiskMonitor) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.Check(ctx, now); err != nil {
+				m.logger.Errorw("LFD risk check failed", "error", err)
+			}
+		}
+	}
+}
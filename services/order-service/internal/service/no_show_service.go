@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Tracking milestones that put a truck at the terminal
+const (
+	milestoneGateIn      = "GATE_IN"
+	milestoneArrivedStop = "ARRIVED_STOP"
+)
+
+// NoShowService detects confirmed terminal appointments nobody turned up
+// for, records the fee the terminal assessed, and attributes each no-show
+// to the driver, customer, dispatch or terminal. Fees attributed to the
+// customer can be passed through to billing.
+type NoShowService struct {
+	noShowRepo      repository.NoShowRepository
+	appointmentRepo repository.AppointmentRepository
+	orderRepo       repository.OrderRepository
+	containerRepo   repository.ContainerRepository
+	shipmentRepo    repository.ShipmentRepository
+	tracker         LocationTracker
+	eventProducer   *kafka.Producer
+	rules           config.NoShowRules
+	logger          *logger.Logger
+}
+
+// NewNoShowService creates a new appointment no-show service. tracker may
+// be nil, in which case only arrivals recorded on the appointment count.
+func NewNoShowService(
+	noShowRepo repository.NoShowRepository,
+	appointmentRepo repository.AppointmentRepository,
+	orderRepo repository.OrderRepository,
+	containerRepo repository.ContainerRepository,
+	shipmentRepo repository.ShipmentRepository,
+	tracker LocationTracker,
+	eventProducer *kafka.Producer,
+	rules config.NoShowRules,
+	log *logger.Logger,
+) *NoShowService {
+	return &NoShowService{
+		noShowRepo:      noShowRepo,
+		appointmentRepo: appointmentRepo,
+		orderRepo:       orderRepo,
+		containerRepo:   containerRepo,
+		shipmentRepo:    shipmentRepo,
+		tracker:         tracker,
+		eventProducer:   eventProducer,
+		rules:           rules,
+		logger:          log,
+	}
+}
+
+// ============================================================================
+// DETECTION
+// ============================================================================
+
+// Check marks confirmed appointments whose window closed more than the grace
+// period ago as missed, unless the truck's trip shows a gate or geofence
+// arrival at the terminal around the window. An arrival found that way is
+// backfilled onto the appointment instead.
+func (s *NoShowService) Check(ctx context.Context, now time.Time) error {
+	cutoff := now.Add(-time.Duration(s.rules.GraceMins) * time.Minute)
+	since := cutoff.Add(-time.Duration(s.rules.LookbackHours) * time.Hour)
+
+	appointments, err := s.appointmentRepo.GetByTimeRange(ctx, since, cutoff)
+	if err != nil {
+		return apperrors.DatabaseError("list appointments for no-show check", err)
+	}
+	for i := range appointments {
+		appointment := &appointments[i]
+		if appointment.Status != domain.AppointmentStatusConfirmed ||
+			appointment.ActualArrivalTime != nil ||
+			appointment.WindowEndTime.After(cutoff) {
+			continue
+		}
+
+		arrival, ok := s.trackedArrival(ctx, appointment)
+		if !ok {
+			continue
+		}
+		if arrival != nil {
+			s.backfillArrival(ctx, appointment, *arrival, now)
+			continue
+		}
+		if err := s.recordNoShow(ctx, appointment, now); err != nil {
+			s.logger.Errorw("Failed to record appointment no-show", "appointment_id", appointment.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Start checks on every tick until ctx is cancelled
+func (s *NoShowService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.Check(ctx, now); err != nil {
+				s.logger.Errorw("Appointment no-show check failed", "error", err)
+			}
+		}
+	}
+}
+
+// trackedArrival looks for a gate-in, or a geofence arrival at the terminal,
+// on the appointment's trip from shortly before the window to the end of the
+// grace period. ok is false when tracking could not be read, so the
+// appointment is left for the next check rather than flagged on no evidence.
+func (s *NoShowService) trackedArrival(ctx context.Context, appointment *domain.TerminalAppointment) (arrival *time.Time, ok bool) {
+	if s.tracker == nil || appointment.TripID == nil {
+		return nil, true
+	}
+	milestones, err := s.tracker.GetTripMilestones(ctx, *appointment.TripID)
+	if err != nil {
+		s.logger.Warnw("Trip milestone lookup failed for no-show check",
+			"appointment_id", appointment.ID,
+			"trip_id", *appointment.TripID,
+			"error", err,
+		)
+		return nil, false
+	}
+
+	from := appointment.WindowStartTime.Add(-time.Duration(s.rules.EarlyArrivalMins) * time.Minute)
+	to := appointment.WindowEndTime.Add(time.Duration(s.rules.GraceMins) * time.Minute)
+	for _, m := range milestones {
+		if m.OccurredAt.Before(from) || m.OccurredAt.After(to) {
+			continue
+		}
+		atTerminal := m.Type == milestoneGateIn ||
+			(m.Type == milestoneArrivedStop && appointment.TerminalName != "" && strings.EqualFold(m.LocationName, appointment.TerminalName))
+		if atTerminal && (arrival == nil || m.OccurredAt.Before(*arrival)) {
+			at := m.OccurredAt
+			arrival = &at
+		}
+	}
+	return arrival, true
+}
+
+// backfillArrival records an arrival tracking saw but the terminal never
+// reported, so the appointment is not checked again
+func (s *NoShowService) backfillArrival(ctx context.Context, appointment *domain.TerminalAppointment, arrival, now time.Time) {
+	appointment.ActualArrivalTime = &arrival
+	appointment.UpdatedAt = now
+	if err := s.appointmentRepo.Update(ctx, appointment); err != nil {
+		s.logger.Warnw("Failed to backfill appointment arrival", "appointment_id", appointment.ID, "error", err)
+		return
+	}
+	s.logger.Infow("Appointment arrival backfilled from tracking",
+		"appointment_id", appointment.ID,
+		"arrival_time", arrival,
+	)
+}
+
+// recordNoShow stores the no-show with a suggested fault, then marks the
+// appointment missed. A no-show already on file from an earlier check that
+// failed part way is reused.
+func (s *NoShowService) recordNoShow(ctx context.Context, appointment *domain.TerminalAppointment, now time.Time) error {
+	noShow, err := s.noShowRepo.GetByAppointmentID(ctx, appointment.ID)
+	if err != nil {
+		return apperrors.DatabaseError("get appointment no-show", err)
+	}
+	if noShow == nil {
+		noShow = s.newNoShow(ctx, appointment, now)
+		if err := s.noShowRepo.Create(ctx, noShow); err != nil {
+			return apperrors.DatabaseError("create appointment no-show", err)
+		}
+	}
+
+	appointment.Status = domain.AppointmentStatusMissed
+	appointment.UpdatedAt = now
+	if err := s.appointmentRepo.Update(ctx, appointment); err != nil {
+		return apperrors.DatabaseError("mark appointment missed", err)
+	}
+
+	data := map[string]interface{}{
+		"no_show_id":        noShow.ID.String(),
+		"appointment_id":    appointment.ID.String(),
+		"order_id":          noShow.OrderID.String(),
+		"order_number":      noShow.OrderNumber,
+		"terminal_id":       noShow.TerminalID.String(),
+		"container_number":  noShow.ContainerNumber,
+		"window_start_time": noShow.WindowStartTime,
+		"window_end_time":   noShow.WindowEndTime,
+		"suggested_fault":   noShow.SuggestedFault,
+		"suggested_reason":  noShow.SuggestedReason,
+	}
+	if noShow.DriverID != nil {
+		data["driver_id"] = noShow.DriverID.String()
+	}
+	if noShow.TripID != nil {
+		data["trip_id"] = noShow.TripID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.AppointmentNoShow, "order-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.AppointmentNoShow, event)
+
+	s.logger.Warnw("Terminal appointment missed",
+		"appointment_id", appointment.ID,
+		"order_number", noShow.OrderNumber,
+		"container_number", noShow.ContainerNumber,
+		"suggested_fault", noShow.SuggestedFault,
+	)
+	return nil
+}
+
+// newNoShow builds the no-show record, suggesting a fault from the order
+// and container as they stand
+func (s *NoShowService) newNoShow(ctx context.Context, appointment *domain.TerminalAppointment, now time.Time) *domain.AppointmentNoShow {
+	noShow := &domain.AppointmentNoShow{
+		ID:              uuid.New(),
+		AppointmentID:   appointment.ID,
+		OrderID:         appointment.OrderID,
+		TerminalID:      appointment.TerminalID,
+		TerminalName:    appointment.TerminalName,
+		ContainerNumber: appointment.ContainerNumber,
+		DriverID:        appointment.DriverID,
+		TripID:          appointment.TripID,
+		WindowStartTime: appointment.WindowStartTime,
+		WindowEndTime:   appointment.WindowEndTime,
+		DetectedAt:      now,
+		BillingStatus:   domain.NoShowBillingNotBilled,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, appointment.OrderID)
+	if err != nil {
+		s.logger.Warnw("Failed to load order for no-show", "order_id", appointment.OrderID, "error", err)
+		order = nil
+	}
+	var container *domain.Container
+	if order != nil {
+		noShow.OrderNumber = order.OrderNumber
+		if shipment, err := s.shipmentRepo.GetByID(ctx, order.ShipmentID); err == nil && shipment != nil {
+			customerID := shipment.CustomerID
+			noShow.CustomerID = &customerID
+		}
+		containerID := order.ContainerID
+		if appointment.ContainerID != nil {
+			containerID = *appointment.ContainerID
+		}
+		if c, err := s.containerRepo.GetByID(ctx, containerID); err == nil && c != nil {
+			container = c
+			if noShow.ContainerNumber == "" {
+				noShow.ContainerNumber = c.ContainerNumber
+			}
+		}
+	}
+
+	noShow.SuggestedFault, noShow.SuggestedReason = domain.SuggestNoShowFault(appointment, order, container)
+	noShow.Fault = noShow.SuggestedFault
+	return noShow
+}
+
+// ============================================================================
+// FEES AND ATTRIBUTION
+// ============================================================================
+
+// GetNoShow retrieves a no-show by ID
+func (s *NoShowService) GetNoShow(ctx context.Context, id uuid.UUID) (*domain.AppointmentNoShow, error) {
+	noShow, err := s.noShowRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get appointment no-show", err)
+	}
+	if noShow == nil {
+		return nil, apperrors.NotFoundError("appointment no-show", id.String())
+	}
+	return noShow, nil
+}
+
+// ListNoShows lists no-shows matching the filter, newest window first
+func (s *NoShowService) ListNoShows(ctx context.Context, filter repository.NoShowFilter) ([]domain.AppointmentNoShow, error) {
+	if filter.Fault != "" && !filter.Fault.IsValid() {
+		return nil, apperrors.ValidationError("unknown fault party", "fault", filter.Fault)
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	noShows, err := s.noShowRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list appointment no-shows", err)
+	}
+	return noShows, nil
+}
+
+// RecordNoShowFeeInput is the fee a terminal assessed for a no-show
+type RecordNoShowFeeInput struct {
+	NoShowID   uuid.UUID  `json:"-"`
+	Amount     float64    `json:"amount"`
+	Reference  string     `json:"reference"`             // Terminal invoice or assessment number
+	AssessedAt *time.Time `json:"assessed_at,omitempty"` // Defaults to now
+	RecordedBy string     `json:"-"`
+}
+
+// RecordFee records or corrects the terminal's fee. It is fixed once the
+// fee has been passed through to the customer.
+func (s *NoShowService) RecordFee(ctx context.Context, input RecordNoShowFeeInput) (*domain.AppointmentNoShow, error) {
+	if input.Amount <= 0 {
+		return nil, apperrors.ValidationError("fee amount must be positive", "amount", input.Amount)
+	}
+	noShow, err := s.GetNoShow(ctx, input.NoShowID)
+	if err != nil {
+		return nil, err
+	}
+	if noShow.BillingStatus == domain.NoShowBillingBilled {
+		return nil, apperrors.InvalidStateError(string(noShow.BillingStatus), string(domain.NoShowBillingNotBilled))
+	}
+
+	now := time.Now()
+	assessedAt := now
+	if input.AssessedAt != nil {
+		assessedAt = *input.AssessedAt
+	}
+	noShow.FeeAmount = input.Amount
+	noShow.FeeReference = strings.TrimSpace(input.Reference)
+	noShow.FeeAssessedAt = &assessedAt
+	noShow.FeeRecordedBy = input.RecordedBy
+	noShow.UpdatedAt = now
+	if err := s.noShowRepo.Update(ctx, noShow); err != nil {
+		return nil, apperrors.DatabaseError("record no-show fee", err)
+	}
+
+	s.logger.Infow("Terminal no-show fee recorded",
+		"no_show_id", noShow.ID,
+		"amount", noShow.FeeAmount,
+		"reference", noShow.FeeReference,
+		"by", input.RecordedBy,
+	)
+	return noShow, nil
+}
+
+// AttributeNoShowInput confirms or overrides who was at fault
+type AttributeNoShowInput struct {
+	NoShowID     uuid.UUID          `json:"-"`
+	Fault        domain.NoShowFault `json:"fault"`
+	Notes        string             `json:"notes"`
+	AttributedBy string             `json:"-"`
+}
+
+// AttributeFault records who ops hold responsible for the no-show. A fee
+// already passed through stays with the customer.
+func (s *NoShowService) AttributeFault(ctx context.Context, input AttributeNoShowInput) (*domain.AppointmentNoShow, error) {
+	input.Fault = domain.NoShowFault(strings.ToUpper(string(input.Fault)))
+	if !input.Fault.IsValid() || input.Fault == domain.NoShowFaultUndetermined {
+		return nil, apperrors.ValidationError("fault must be DRIVER, CUSTOMER, DISPATCH or TERMINAL", "fault", input.Fault)
+	}
+	noShow, err := s.GetNoShow(ctx, input.NoShowID)
+	if err != nil {
+		return nil, err
+	}
+	if noShow.BillingStatus == domain.NoShowBillingBilled && input.Fault != domain.NoShowFaultCustomer {
+		return nil, apperrors.New("INVALID_STATE", "fee was already billed to the customer; credit it before re-attributing")
+	}
+
+	now := time.Now()
+	noShow.Fault = input.Fault
+	noShow.FaultNotes = strings.TrimSpace(input.Notes)
+	noShow.AttributedBy = input.AttributedBy
+	noShow.AttributedAt = &now
+	noShow.UpdatedAt = now
+	if err := s.noShowRepo.Update(ctx, noShow); err != nil {
+		return nil, apperrors.DatabaseError("attribute no-show", err)
+	}
+
+	s.logger.Infow("Appointment no-show attributed",
+		"no_show_id", noShow.ID,
+		"fault", noShow.Fault,
+		"suggested_fault", noShow.SuggestedFault,
+		"by", input.AttributedBy,
+	)
+	return noShow, nil
+}
+
+// PassThroughNoShowFeeInput bills a no-show fee to the customer
+type PassThroughNoShowFeeInput struct {
+	NoShowID uuid.UUID `json:"-"`
+	Amount   *float64  `json:"amount,omitempty"` // Defaults to the terminal's fee
+	BilledBy string    `json:"-"`
+}
+
+// PassThroughFee bills the terminal's fee to the customer. The no-show must
+// be attributed to the customer by ops, not just suggested, and billing
+// picks the charge up from NoShowFeeBilled.
+func (s *NoShowService) PassThroughFee(ctx context.Context, input PassThroughNoShowFeeInput) (*domain.AppointmentNoShow, error) {
+	noShow, err := s.GetNoShow(ctx, input.NoShowID)
+	if err != nil {
+		return nil, err
+	}
+	if noShow.BillingStatus != domain.NoShowBillingNotBilled {
+		return nil, apperrors.InvalidStateError(string(noShow.BillingStatus), string(domain.NoShowBillingNotBilled))
+	}
+	if !noShow.HasFee() {
+		return nil, apperrors.New("INVALID_STATE", "no terminal fee has been recorded for this no-show")
+	}
+	if !noShow.IsAttributed() || noShow.Fault != domain.NoShowFaultCustomer {
+		return nil, apperrors.New("INVALID_STATE", "only no-shows attributed to the customer can be passed through").
+			WithDetail("fault", noShow.Fault)
+	}
+	if noShow.CustomerID == nil {
+		return nil, apperrors.New("INVALID_STATE", "no-show has no customer to bill")
+	}
+	amount := noShow.FeeAmount
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	if amount <= 0 {
+		return nil, apperrors.ValidationError("amount must be positive", "amount", amount)
+	}
+
+	now := time.Now()
+	noShow.BillingStatus = domain.NoShowBillingBilled
+	noShow.BilledAmount = amount
+	noShow.BilledBy = input.BilledBy
+	noShow.BilledAt = &now
+	noShow.UpdatedAt = now
+	if err := s.noShowRepo.Update(ctx, noShow); err != nil {
+		return nil, apperrors.DatabaseError("bill no-show fee", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.NoShowFeeBilled, "order-service", map[string]interface{}{
+		"no_show_id":     noShow.ID.String(),
+		"appointment_id": noShow.AppointmentID.String(),
+		"order_id":       noShow.OrderID.String(),
+		"order_number":   noShow.OrderNumber,
+		"customer_id":    noShow.CustomerID.String(),
+		"charge_type":    "OTHER",
+		"description":    "Terminal appointment no-show fee " + noShow.FeeReference,
+		"amount":         amount,
+		"terminal_fee":   noShow.FeeAmount,
+		"billed_by":      input.BilledBy,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.NoShowFeeBilled, event)
+
+	s.logger.Infow("No-show fee passed through to customer",
+		"no_show_id", noShow.ID,
+		"customer_id", *noShow.CustomerID,
+		"amount", amount,
+		"by", input.BilledBy,
+	)
+	return noShow, nil
+}
+
+// WaiveFee records that the fee will not be passed through, leaving the
+// carrier to absorb it
+func (s *NoShowService) WaiveFee(ctx context.Context, id uuid.UUID, waivedBy string) (*domain.AppointmentNoShow, error) {
+	noShow, err := s.GetNoShow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if noShow.BillingStatus != domain.NoShowBillingNotBilled {
+		return nil, apperrors.InvalidStateError(string(noShow.BillingStatus), string(domain.NoShowBillingNotBilled))
+	}
+
+	now := time.Now()
+	noShow.BillingStatus = domain.NoShowBillingWaived
+	noShow.BilledBy = waivedBy
+	noShow.UpdatedAt = now
+	if err := s.noShowRepo.Update(ctx, noShow); err != nil {
+		return nil, apperrors.DatabaseError("waive no-show fee", err)
+	}
+	return noShow, nil
+}
+
+// Analyze breaks down no-shows with a window starting in [from, to) by
+// fault party and terminal
+func (s *NoShowService) Analyze(ctx context.Context, filter repository.NoShowFilter) (*domain.NoShowAnalysis, error) {
+	if filter.From.IsZero() || filter.To.IsZero() || !filter.To.After(filter.From) {
+		return nil, apperrors.ValidationError("from and to are required, with to after from", "to", filter.To)
+	}
+	filter.Fault = ""
+	filter.Limit = 0
+	noShows, err := s.noShowRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list appointment no-shows", err)
+	}
+	return domain.AnalyzeNoShows(noShows, filter.From, filter.To), nil
+}
@@ -2,14 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/draymaster/services/order-service/internal/domain"
 	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
 	apperrors "github.com/draymaster/shared/pkg/errors"
 	"github.com/draymaster/shared/pkg/validation"
 	"github.com/draymaster/shared/pkg/database"
@@ -23,7 +26,10 @@ type OrderCRUDService struct {
 	orderRepo     repository.OrderRepository
 	containerRepo repository.ContainerRepository
 	shipmentRepo  repository.ShipmentRepository
+	facilities    *FacilityService
+	rateApprovals *RateApprovalService
 	eventProducer *kafka.Producer
+	outbox        *kafka.Outbox
 	logger        *logger.Logger
 	validator     *validation.StringValidator
 }
@@ -34,7 +40,10 @@ func NewOrderCRUDService(
 	orderRepo repository.OrderRepository,
 	containerRepo repository.ContainerRepository,
 	shipmentRepo repository.ShipmentRepository,
+	facilities *FacilityService,
+	rateApprovals *RateApprovalService,
 	eventProducer *kafka.Producer,
+	outbox *kafka.Outbox,
 	log *logger.Logger,
 ) *OrderCRUDService {
 	return &OrderCRUDService{
@@ -42,7 +51,10 @@ func NewOrderCRUDService(
 		orderRepo:     orderRepo,
 		containerRepo: containerRepo,
 		shipmentRepo:  shipmentRepo,
+		facilities:    facilities,
+		rateApprovals: rateApprovals,
 		eventProducer: eventProducer,
+		outbox:        outbox,
 		logger:        log,
 		validator:     validation.NewStringValidator(),
 	}
@@ -62,6 +74,8 @@ type CreateOrderInput struct {
 	RequestedPickupDate   *time.Time
 	RequestedDeliveryDate *time.Time
 	SpecialInstructions   string
+	AgreedRate            *float64 // Quoted or spot linehaul; checked against contract before the order is made ready
+	Tags                  []string
 	CreatedBy             string
 }
 
@@ -77,6 +91,16 @@ func (s *OrderCRUDService) CreateOrder(ctx context.Context, input CreateOrderInp
 		return nil, err
 	}
 
+	// Delivery must be requested while the consignee is receiving
+	if err := s.checkDeliveryTime(ctx, input.DeliveryLocationID, input.RequestedDeliveryDate); err != nil {
+		return nil, err
+	}
+
+	tags, err := validation.NewTagValidator().Normalize(input.Tags)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "tags", input.Tags)
+	}
+
 	// Verify container exists and is not already assigned
 	container, err := s.containerRepo.GetByID(ctx, input.ContainerID)
 	if err != nil {
@@ -117,26 +141,37 @@ func (s *OrderCRUDService) CreateOrder(ctx context.Context, input CreateOrderInp
 		Status:                domain.OrderStatusPending,
 		BillingStatus:         domain.BillingStatusUnbilled,
 		SpecialInstructions:   input.SpecialInstructions,
+		AgreedRate:            input.AgreedRate,
+		Tags:                  tags,
 		CreatedAt:             time.Now(),
 		UpdatedAt:             time.Now(),
 	}
 
-	if err := s.orderRepo.Create(ctx, order); err != nil {
-		return nil, apperrors.DatabaseError("create order", err)
+	// The order and its OrderCreated event commit together
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+		if err := s.orderRepo.Create(txCtx, order); err != nil {
+			return apperrors.DatabaseError("create order", err)
+		}
+
+		event := kafka.NewEvent(kafka.Topics.OrderCreated, "order-service", map[string]interface{}{
+			"order_id":         order.ID.String(),
+			"order_number":     order.OrderNumber,
+			"container_number": container.ContainerNumber,
+			"type":             order.Type,
+		}).WithTags(order.Tags)
+		if err := s.outbox.Publish(txCtx, kafka.Topics.OrderCreated, event); err != nil {
+			return apperrors.DatabaseError("queue order created event", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Attach container
 	order.Container = container
 
-	// Publish event
-	event := kafka.NewEvent(kafka.Topics.OrderCreated, "order-service", map[string]interface{}{
-		"order_id":         order.ID.String(),
-		"order_number":     order.OrderNumber,
-		"container_number": container.ContainerNumber,
-		"type":             order.Type,
-	})
-	_ = s.eventProducer.Publish(ctx, kafka.Topics.OrderCreated, event)
-
 	s.logger.Infow("Order created",
 		"order_id", order.ID,
 		"order_number", order.OrderNumber,
@@ -154,6 +189,8 @@ type UpdateOrderInput struct {
 	RequestedPickupDate   *time.Time
 	RequestedDeliveryDate *time.Time
 	SpecialInstructions   *string
+	AgreedRate            *float64
+	Version               int // Order version the edit was made against; 0 skips the check
 	UpdatedBy             string
 }
 
@@ -174,6 +211,11 @@ func (s *OrderCRUDService) UpdateOrder(ctx context.Context, orderID uuid.UUID, i
 		)
 	}
 
+	// Reject edits made against a stale copy of the order
+	if input.Version != 0 && input.Version != order.Version {
+		return nil, apperrors.VersionConflictError("order", orderID.String(), order)
+	}
+
 	// Apply updates
 	updated := false
 	if input.CustomerReference != nil {
@@ -204,15 +246,28 @@ func (s *OrderCRUDService) UpdateOrder(ctx context.Context, orderID uuid.UUID, i
 		order.SpecialInstructions = *input.SpecialInstructions
 		updated = true
 	}
+	if input.AgreedRate != nil {
+		if *input.AgreedRate <= 0 {
+			return nil, apperrors.ValidationError("agreed rate must be positive", "agreed_rate", *input.AgreedRate)
+		}
+		order.AgreedRate = input.AgreedRate
+		updated = true
+	}
 
 	if !updated {
 		return order, nil // No changes
 	}
 
+	if input.DeliveryLocationID != nil || input.RequestedDeliveryDate != nil {
+		if err := s.checkDeliveryTime(ctx, order.DeliveryLocationID, order.RequestedDeliveryDate); err != nil {
+			return nil, err
+		}
+	}
+
 	order.UpdatedAt = time.Now()
 
 	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return nil, apperrors.DatabaseError("update order", err)
+		return nil, s.orderUpdateError(ctx, orderID, "update order", err)
 	}
 
 	s.logger.Infow("Order updated",
@@ -223,9 +278,17 @@ func (s *OrderCRUDService) UpdateOrder(ctx context.Context, orderID uuid.UUID, i
 	return order, nil
 }
 
-// CancelOrder cancels an order
-func (s *OrderCRUDService) CancelOrder(ctx context.Context, orderID uuid.UUID, reason, cancelledBy string) error {
-	s.logger.Infow("Cancelling order", "order_id", orderID)
+// CancelOrderInput contains input for cancelling an order
+type CancelOrderInput struct {
+	Reason      string
+	CancelledBy string
+	Origin      config.CancellationOrigin // Dispatch charges customer cancellations of dispatched trips
+}
+
+// CancelOrder cancels an order. Dispatch cancels the order's trips from the
+// event and assesses any contract cancellation fee.
+func (s *OrderCRUDService) CancelOrder(ctx context.Context, orderID uuid.UUID, input CancelOrderInput) error {
+	s.logger.Infow("Cancelling order", "order_id", orderID, "origin", input.Origin)
 
 	order, err := s.orderRepo.GetByID(ctx, orderID)
 	if err != nil {
@@ -249,21 +312,22 @@ func (s *OrderCRUDService) CancelOrder(ctx context.Context, orderID uuid.UUID, r
 	order.UpdatedAt = time.Now()
 
 	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return apperrors.DatabaseError("cancel order", err)
+		return s.orderUpdateError(ctx, orderID, "cancel order", err)
 	}
 
 	// Publish event
 	event := kafka.NewEvent("orders.order.cancelled", "order-service", map[string]interface{}{
 		"order_id":     orderID.String(),
 		"order_number": order.OrderNumber,
-		"reason":       reason,
-		"cancelled_by": cancelledBy,
-	})
+		"reason":       input.Reason,
+		"cancelled_by": input.CancelledBy,
+		"origin":       string(input.Origin),
+	}).WithTags(order.Tags)
 	_ = s.eventProducer.Publish(ctx, "orders.order.cancelled", event)
 
 	s.logger.Infow("Order cancelled",
 		"order_id", orderID,
-		"reason", reason,
+		"reason", input.Reason,
 	)
 
 	return nil
@@ -308,6 +372,8 @@ type ListOrdersFilter struct {
 	CreatedBefore     *time.Time
 	PickupAfter       *time.Time
 	PickupBefore      *time.Time
+	Tags              []string
+	MatchAllTags      bool // Orders with every one of Tags instead of any
 	Page              int
 	PageSize          int
 	SortBy            string // "created_at", "order_number", "pickup_date"
@@ -348,6 +414,8 @@ func (s *OrderCRUDService) ListOrders(ctx context.Context, filter ListOrdersFilt
 		CreatedBefore:     filter.CreatedBefore,
 		PickupAfter:       filter.PickupAfter,
 		PickupBefore:      filter.PickupBefore,
+		Tags:              filter.Tags,
+		MatchAllTags:      filter.MatchAllTags,
 		Page:              filter.Page,
 		PageSize:          filter.PageSize,
 		SortBy:            filter.SortBy,
@@ -439,6 +507,17 @@ func (s *OrderCRUDService) BulkUpdateOrderStatus(ctx context.Context, orderIDs [
 				continue
 			}
 
+			// Orders quoted off contract wait for rate approval
+			if status == domain.OrderStatusReady {
+				if err := s.rateApprovals.CheckRelease(ctx, order, updatedBy); err != nil {
+					s.logger.Warnw("Order held for rate approval in bulk update",
+						"order_id", orderID,
+						"error", err,
+					)
+					continue
+				}
+			}
+
 			// Update status
 			if err := s.orderRepo.UpdateStatus(ctx, orderID, status); err != nil {
 				return apperrors.DatabaseError("update order status", err)
@@ -451,7 +530,7 @@ func (s *OrderCRUDService) BulkUpdateOrderStatus(ctx context.Context, orderIDs [
 				"old_status": order.Status,
 				"reason":     reason,
 				"updated_by": updatedBy,
-			})
+			}).WithTags(order.Tags)
 			_ = s.eventProducer.Publish(ctx, kafka.Topics.OrderStatusChanged, event)
 		}
 		return nil
@@ -554,9 +633,22 @@ func (s *OrderCRUDService) validateCreateOrderInput(input CreateOrderInput) erro
 		}
 	}
 
+	if input.AgreedRate != nil && *input.AgreedRate <= 0 {
+		return apperrors.ValidationError("agreed rate must be positive", "agreed_rate", *input.AgreedRate)
+	}
+
 	return nil
 }
 
+// checkDeliveryTime checks a requested delivery against the delivery
+// location's facility hours, when both are set
+func (s *OrderCRUDService) checkDeliveryTime(ctx context.Context, locationID *uuid.UUID, at *time.Time) error {
+	if s.facilities == nil || locationID == nil || at == nil {
+		return nil
+	}
+	return s.facilities.CheckDeliveryTime(ctx, *locationID, *at)
+}
+
 func (s *OrderCRUDService) isValidStatusTransition(from, to domain.OrderStatus) bool {
 	// Define valid state transitions
 	validTransitions := map[domain.OrderStatus][]domain.OrderStatus{
@@ -581,3 +673,16 @@ func (s *OrderCRUDService) isValidStatusTransition(from, to domain.OrderStatus)
 
 	return false
 }
+
+// orderUpdateError converts a failed order update into an app error, returning
+// the latest order state when another user saved first
+func (s *OrderCRUDService) orderUpdateError(ctx context.Context, orderID uuid.UUID, operation string, err error) error {
+	if !errors.Is(err, database.ErrVersionConflict) {
+		return apperrors.DatabaseError(operation, err)
+	}
+	latest, getErr := s.orderRepo.GetByID(ctx, orderID)
+	if getErr != nil {
+		latest = nil
+	}
+	return apperrors.VersionConflictError("order", orderID.String(), latest)
+}
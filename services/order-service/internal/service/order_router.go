@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/shared/pkg/cutover"
+)
+
+// OpCreateShipment is the cutover operation name for shipment creation
+const OpCreateShipment = "order.create_shipment"
+
+// OrderRouter serves the operations implemented by both OrderService and
+// EnhancedOrderService, routing each call by its cutover mode
+type OrderRouter struct {
+	legacy   *OrderService
+	enhanced *EnhancedOrderService
+	router   *cutover.Router
+}
+
+// NewOrderRouter creates a new order cutover facade
+func NewOrderRouter(legacy *OrderService, enhanced *EnhancedOrderService, router *cutover.Router) *OrderRouter {
+	return &OrderRouter{legacy: legacy, enhanced: enhanced, router: router}
+}
+
+// CreateShipment creates a shipment. The shadow runs the enhanced validation
+// and overweight rules without writing, and compares them with the created
+// shipment.
+func (r *OrderRouter) CreateShipment(ctx context.Context, input CreateShipmentInput) (*domain.Shipment, error) {
+	return cutover.Run(ctx, r.router, OpCreateShipment, input.ReferenceNumber, cutover.Paths[*domain.Shipment]{
+		Legacy: func(ctx context.Context) (*domain.Shipment, error) {
+			return r.legacy.CreateShipment(ctx, input)
+		},
+		Enhanced: func(ctx context.Context) (*domain.Shipment, error) {
+			return r.enhanced.CreateShipmentEnhanced(ctx, input)
+		},
+		Shadow: func(ctx context.Context) (*domain.Shipment, error) {
+			return r.enhanced.planShipment(input)
+		},
+		Diff: diffShipments,
+	})
+}
+
+// CutoverMetrics returns the routing and shadow comparison counters
+func (r *OrderRouter) CutoverMetrics() []cutover.OperationMetrics {
+	return r.router.Metrics()
+}
+
+// planShipment applies the enhanced checks and rules to a shipment input
+// without writing it
+func (s *EnhancedOrderService) planShipment(input CreateShipmentInput) (*domain.Shipment, error) {
+	if err := s.validateShipmentInput(input); err != nil {
+		return nil, err
+	}
+	shipment := &domain.Shipment{
+		Type:            input.Type,
+		ReferenceNumber: input.ReferenceNumber,
+		Status:          domain.ShipmentStatusPending,
+		Containers:      make([]domain.Container, len(input.Containers)),
+	}
+	for i, c := range input.Containers {
+		if err := s.validateContainerInput(c); err != nil {
+			return nil, err
+		}
+		shipment.Containers[i] = domain.Container{
+			ContainerNumber: c.ContainerNumber,
+			IsOverweight: s.businessRules.Weight.OverweightThresholdLbs > 0 &&
+				c.WeightLbs > s.businessRules.Weight.OverweightThresholdLbs,
+		}
+	}
+	return shipment, nil
+}
+
+// diffShipments compares a created legacy shipment with the enhanced plan
+func diffShipments(legacy, enhanced *domain.Shipment) []string {
+	var diffs []string
+	if legacy.Status != enhanced.Status {
+		diffs = append(diffs, fmt.Sprintf("status %s vs %s", legacy.Status, enhanced.Status))
+	}
+	if len(legacy.Containers) != len(enhanced.Containers) {
+		return append(diffs, fmt.Sprintf("%d containers vs %d", len(legacy.Containers), len(enhanced.Containers)))
+	}
+	for i := range legacy.Containers {
+		if legacy.Containers[i].IsOverweight != enhanced.Containers[i].IsOverweight {
+			diffs = append(diffs, fmt.Sprintf("container %s overweight %t vs %t",
+				legacy.Containers[i].ContainerNumber, legacy.Containers[i].IsOverweight, enhanced.Containers[i].IsOverweight))
+		}
+	}
+	return diffs
+}
@@ -6,38 +6,53 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/draymaster/services/order-service/internal/domain"
 	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/auth"
+	"github.com/draymaster/shared/pkg/database"
 	"github.com/draymaster/shared/pkg/kafka"
 	"github.com/draymaster/shared/pkg/logger"
 )
 
 // OrderService handles business logic for orders
 type OrderService struct {
+	db            *database.DB
 	shipmentRepo  repository.ShipmentRepository
 	containerRepo repository.ContainerRepository
 	orderRepo     repository.OrderRepository
 	locationRepo  repository.LocationRepository
+	rateApprovals *RateApprovalService
+	rateQuotes    RateQuoteSource
 	eventProducer *kafka.Producer
+	outbox        *kafka.Outbox
 	logger        *logger.Logger
 }
 
 // NewOrderService creates a new order service
 func NewOrderService(
+	db *database.DB,
 	shipmentRepo repository.ShipmentRepository,
 	containerRepo repository.ContainerRepository,
 	orderRepo repository.OrderRepository,
 	locationRepo repository.LocationRepository,
+	rateApprovals *RateApprovalService,
+	rateQuotes RateQuoteSource,
 	eventProducer *kafka.Producer,
+	outbox *kafka.Outbox,
 	log *logger.Logger,
 ) *OrderService {
 	return &OrderService{
+		db:            db,
 		shipmentRepo:  shipmentRepo,
 		containerRepo: containerRepo,
 		orderRepo:     orderRepo,
 		locationRepo:  locationRepo,
+		rateApprovals: rateApprovals,
+		rateQuotes:    rateQuotes,
 		eventProducer: eventProducer,
+		outbox:        outbox,
 		logger:        log,
 	}
 }
@@ -142,6 +157,7 @@ func (s *OrderService) CreateShipment(ctx context.Context, input CreateShipmentI
 				CustomsStatus:      domain.CustomsStatusPending,
 				CurrentState:       domain.ContainerStateLoaded,
 				CurrentLocationType: domain.LocationTypeVessel,
+				VerificationStatus: domain.ContainerVerificationPending,
 			}
 		}
 
@@ -152,6 +168,7 @@ func (s *OrderService) CreateShipment(ctx context.Context, input CreateShipmentI
 		shipment.Containers = make([]domain.Container, len(containers))
 		for i, c := range containers {
 			shipment.Containers[i] = *c
+			requestContainerVerification(ctx, s.eventProducer, c)
 		}
 	}
 
@@ -229,12 +246,16 @@ func (s *OrderService) AddContainers(ctx context.Context, shipmentID uuid.UUID,
 			CustomsStatus:      domain.CustomsStatusPending,
 			CurrentState:       domain.ContainerStateLoaded,
 			CurrentLocationType: domain.LocationTypeVessel,
+			VerificationStatus: domain.ContainerVerificationPending,
 		}
 	}
 
 	if err := s.containerRepo.CreateBatch(ctx, containers); err != nil {
 		return nil, fmt.Errorf("failed to create containers: %w", err)
 	}
+	for _, c := range containers {
+		requestContainerVerification(ctx, s.eventProducer, c)
+	}
 
 	// Publish event
 	event := kafka.NewEvent(kafka.Topics.ContainerAdded, "order-service", map[string]interface{}{
@@ -288,20 +309,29 @@ func (s *OrderService) GenerateOrdersFromShipment(ctx context.Context, shipmentI
 			SpecialInstructions: shipment.SpecialInstructions,
 		}
 
-		if err := s.orderRepo.Create(ctx, order); err != nil {
-			return nil, fmt.Errorf("failed to create order: %w", err)
+		// Each order commits with its OrderCreated event
+		err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+			txCtx := database.WithTx(ctx, tx)
+			if err := s.orderRepo.Create(txCtx, order); err != nil {
+				return fmt.Errorf("failed to create order: %w", err)
+			}
+
+			event := kafka.NewEvent(kafka.Topics.OrderCreated, "order-service", map[string]interface{}{
+				"order_id":         order.ID.String(),
+				"order_number":     order.OrderNumber,
+				"container_number": container.ContainerNumber,
+				"shipment_id":      shipmentID.String(),
+			})
+			if err := s.outbox.Publish(txCtx, kafka.Topics.OrderCreated, event); err != nil {
+				return fmt.Errorf("failed to queue order created event: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		orders = append(orders, order)
-
-		// Publish event
-		event := kafka.NewEvent(kafka.Topics.OrderCreated, "order-service", map[string]interface{}{
-			"order_id":         order.ID.String(),
-			"order_number":     order.OrderNumber,
-			"container_number": container.ContainerNumber,
-			"shipment_id":      shipmentID.String(),
-		})
-		_ = s.eventProducer.Publish(ctx, kafka.Topics.OrderCreated, event)
 	}
 
 	s.logger.Infow("Orders generated",
@@ -330,8 +360,23 @@ func (s *OrderService) GetOrder(ctx context.Context, id uuid.UUID) (*domain.Orde
 	return order, nil
 }
 
-// UpdateOrderStatus updates the status of an order
+// UpdateOrderStatus updates the status of an order. An order quoted off
+// its contract rate is held for rate approval instead of being made READY.
 func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status domain.OrderStatus, reason string) error {
+	if status == domain.OrderStatusReady {
+		order, err := s.orderRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		requestedBy := ""
+		if identity, ok := auth.FromContext(ctx); ok {
+			requestedBy = identity.Subject
+		}
+		if err := s.rateApprovals.CheckRelease(ctx, order, requestedBy); err != nil {
+			return err
+		}
+	}
+
 	if err := s.orderRepo.UpdateStatus(ctx, id, status); err != nil {
 		return err
 	}
@@ -25,6 +25,7 @@ type EnhancedOrderService struct {
 	containerRepo repository.ContainerRepository
 	orderRepo     repository.OrderRepository
 	locationRepo  repository.LocationRepository
+	calendar      *CalendarService
 	eventProducer *kafka.Producer
 	logger        *logger.Logger
 
@@ -48,6 +49,7 @@ func NewEnhancedOrderService(
 	containerRepo repository.ContainerRepository,
 	orderRepo repository.OrderRepository,
 	locationRepo repository.LocationRepository,
+	calendar *CalendarService,
 	eventProducer *kafka.Producer,
 	log *logger.Logger,
 ) *EnhancedOrderService {
@@ -57,6 +59,7 @@ func NewEnhancedOrderService(
 		containerRepo: containerRepo,
 		orderRepo:     orderRepo,
 		locationRepo:  locationRepo,
+		calendar:      calendar,
 		eventProducer: eventProducer,
 		logger:        log,
 		containerValidator: validation.NewContainerNumberValidator(),
@@ -146,6 +149,7 @@ func (s *EnhancedOrderService) CreateShipmentEnhanced(ctx context.Context, input
 					CustomsStatus:      domain.CustomsStatusPending,
 					CurrentState:       domain.ContainerStateLoaded,
 					CurrentLocationType: domain.LocationTypeVessel,
+					VerificationStatus: domain.ContainerVerificationPending,
 				}
 			}
 
@@ -179,6 +183,9 @@ func (s *EnhancedOrderService) CreateShipmentEnhanced(ctx context.Context, input
 		s.logger.Warnw("Failed to publish shipment created event", "error", err)
 		// Don't fail the operation if event publishing fails
 	}
+	for i := range shipment.Containers {
+		requestContainerVerification(ctx, s.eventProducer, &shipment.Containers[i])
+	}
 
 	s.logger.Infow("Shipment created successfully",
 		"shipment_id", shipment.ID,
@@ -298,12 +305,7 @@ type PerDiemCharges struct {
 }
 
 // TierCharge represents charges for a specific tier
-type TierCharge struct {
-	TierName  string  `json:"tier_name"`
-	Days      int     `json:"days"`
-	RatePerDay float64 `json:"rate_per_day"`
-	Amount    float64 `json:"amount"`
-}
+type TierCharge = domain.TierCharge
 
 // CalculatePerDiem calculates per-diem storage charges for a container
 func (s *EnhancedOrderService) CalculatePerDiem(ctx context.Context, containerID uuid.UUID) (*PerDiemCharges, error) {
@@ -317,6 +319,13 @@ func (s *EnhancedOrderService) CalculatePerDiem(ctx context.Context, containerID
 		return nil, apperrors.NotFoundError("shipment", container.ShipmentID.String())
 	}
 
+	return perDiemAt(s.businessRules, container, shipment, time.Now())
+}
+
+// perDiemAt calculates a container's per-diem charges as of now
+func perDiemAt(rules *config.BusinessRules, container *domain.Container, shipment *domain.Shipment, now time.Time) (*PerDiemCharges, error) {
+	containerID := container.ID
+
 	// Per-diem only applies to imports
 	if shipment.Type != domain.ShipmentTypeImport {
 		return &PerDiemCharges{
@@ -335,7 +344,6 @@ func (s *EnhancedOrderService) CalculatePerDiem(ctx context.Context, containerID
 		}, nil
 	}
 
-	now := time.Now()
 	if now.Before(*shipment.LastFreeDay) {
 		return &PerDiemCharges{
 			ContainerID: containerID,
@@ -350,13 +358,13 @@ func (s *EnhancedOrderService) CalculatePerDiem(ctx context.Context, containerID
 
 	// Get applicable rates for container size
 	sizeKey := string(container.Size)
-	rates, ok := s.businessRules.PerDiem.Rates[sizeKey]
+	rates, ok := rules.PerDiem.Rates[sizeKey]
 	if !ok {
 		return nil, apperrors.New("INVALID_CONTAINER_SIZE", fmt.Sprintf("no per-diem rates for size %s", sizeKey))
 	}
 
 	// Subtract free days
-	chargeableDays := daysPastLFD - s.businessRules.PerDiem.FreeDays
+	chargeableDays := daysPastLFD - rules.PerDiem.FreeDays
 	if chargeableDays <= 0 {
 		return &PerDiemCharges{
 			ContainerID:  containerID,
@@ -368,42 +376,13 @@ func (s *EnhancedOrderService) CalculatePerDiem(ctx context.Context, containerID
 	}
 
 	// Calculate tiered charges with breakdown
-	totalAmount := 0.0
-	breakdown := []TierCharge{}
-
-	for _, tier := range rates {
-		if chargeableDays < tier.FromDay {
-			break
-		}
-
-		endDay := tier.ToDay
-		if endDay == 0 || endDay > chargeableDays {
-			endDay = chargeableDays
-		}
-
-		daysInTier := endDay - tier.FromDay + 1
-		if daysInTier > 0 {
-			tierAmount := float64(daysInTier) * tier.Rate
-			totalAmount += tierAmount
-
-			breakdown = append(breakdown, TierCharge{
-				TierName:   fmt.Sprintf("Days %d-%d", tier.FromDay, endDay),
-				Days:       daysInTier,
-				RatePerDay: tier.Rate,
-				Amount:     tierAmount,
-			})
-		}
-
-		if tier.ToDay != 0 && chargeableDays <= tier.ToDay {
-			break
-		}
-	}
+	totalAmount, breakdown := tieredCharges(rates, chargeableDays)
 
 	return &PerDiemCharges{
 		ContainerID:  containerID,
 		Days:         chargeableDays,
 		Amount:       totalAmount,
-		StartDate:    shipment.LastFreeDay.Add(time.Duration(s.businessRules.PerDiem.FreeDays) * 24 * time.Hour),
+		StartDate:    shipment.LastFreeDay.Add(time.Duration(rules.PerDiem.FreeDays) * 24 * time.Hour),
 		CalculatedAt: now,
 		Breakdown:    breakdown,
 	}, nil
@@ -414,6 +393,7 @@ type DemurrageCharges struct {
 	ContainerID   uuid.UUID    `json:"container_id"`
 	Days          int          `json:"days"`
 	Amount        float64      `json:"amount"`
+	LastFreeDay   time.Time    `json:"last_free_day"` // After terminal closures
 	StartDate     time.Time    `json:"start_date"`
 	CalculatedAt  time.Time    `json:"calculated_at"`
 	Breakdown     []TierCharge `json:"breakdown"`
@@ -431,6 +411,14 @@ func (s *EnhancedOrderService) CalculateDemurrage(ctx context.Context, container
 		return nil, apperrors.NotFoundError("shipment", container.ShipmentID.String())
 	}
 
+	return demurrageAt(ctx, s.businessRules, s.calendar, container, shipment, time.Now())
+}
+
+// demurrageAt calculates a container's demurrage charges as of now. Without
+// a calendar, terminal closures are not taken into account.
+func demurrageAt(ctx context.Context, rules *config.BusinessRules, calendar *CalendarService, container *domain.Container, shipment *domain.Shipment, now time.Time) (*DemurrageCharges, error) {
+	containerID := container.ID
+
 	// Demurrage only applies to imports
 	if shipment.Type != domain.ShipmentTypeImport {
 		return &DemurrageCharges{
@@ -449,41 +437,68 @@ func (s *EnhancedOrderService) CalculateDemurrage(ctx context.Context, container
 		}, nil
 	}
 
-	now := time.Now()
-	daysPastLFD := shipment.DaysUntilLFD()
+	lastFreeDay := *shipment.LastFreeDay
+	overdueDays := -shipment.DaysUntilLFDAt(now) // Convert negative to positive
+
+	// Terminal closures push out the LFD and are not counted as demurrage days
+	if calendar != nil {
+		var err error
+		lastFreeDay, err = calendar.AdjustLastFreeDay(ctx, shipment.TerminalID, lastFreeDay)
+		if err != nil {
+			return nil, err
+		}
+		overdueDays, err = calendar.ChargeableDays(ctx, shipment.TerminalID, lastFreeDay, now)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// If not past LFD, no demurrage
-	if daysPastLFD >= 0 {
+	if overdueDays <= 0 {
 		return &DemurrageCharges{
 			ContainerID:  containerID,
 			Days:         0,
 			Amount:       0,
-			StartDate:    *shipment.LastFreeDay,
+			LastFreeDay:  lastFreeDay,
+			StartDate:    lastFreeDay,
 			CalculatedAt: now,
 		}, nil
 	}
 
-	overdueDays := -daysPastLFD // Convert negative to positive
-
 	// Get applicable rates for container size
 	sizeKey := string(container.Size)
-	rates, ok := s.businessRules.Demurrage.Rates[sizeKey]
+	rates, ok := rules.Demurrage.Rates[sizeKey]
 	if !ok {
 		return nil, apperrors.New("INVALID_CONTAINER_SIZE", fmt.Sprintf("no demurrage rates for size %s", sizeKey))
 	}
 
 	// Calculate tiered demurrage with breakdown
+	totalAmount, breakdown := tieredCharges(rates, overdueDays)
+
+	return &DemurrageCharges{
+		ContainerID:  containerID,
+		Days:         overdueDays,
+		Amount:       totalAmount,
+		LastFreeDay:  lastFreeDay,
+		StartDate:    lastFreeDay.Add(24 * time.Hour), // Day after LFD
+		CalculatedAt: now,
+		Breakdown:    breakdown,
+	}, nil
+}
+
+// tieredCharges prices chargeable days against day-range rate tiers
+func tieredCharges(rates []config.TierRate, days int) (float64, []TierCharge) {
 	totalAmount := 0.0
 	breakdown := []TierCharge{}
 
 	for _, tier := range rates {
-		if overdueDays < tier.FromDay {
+		if days < tier.FromDay {
 			break
 		}
 
 		endDay := tier.ToDay
-		if endDay == 0 || endDay > overdueDays {
-			endDay = overdueDays
+		if endDay == 0 || endDay > days {
+			endDay = days
 		}
 
 		daysInTier := endDay - tier.FromDay + 1
@@ -499,17 +514,10 @@ func (s *EnhancedOrderService) CalculateDemurrage(ctx context.Context, container
 			})
 		}
 
-		if tier.ToDay != 0 && overdueDays <= tier.ToDay {
+		if tier.ToDay != 0 && days <= tier.ToDay {
 			break
 		}
 	}
 
-	return &DemurrageCharges{
-		ContainerID:  containerID,
-		Days:         overdueDays,
-		Amount:       totalAmount,
-		StartDate:    shipment.LastFreeDay.Add(24 * time.Hour), // Day after LFD
-		CalculatedAt: now,
-		Breakdown:    breakdown,
-	}, nil
+	return totalAmount, breakdown
 }
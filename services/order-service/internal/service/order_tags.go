@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/validation"
+)
+
+// maxSavedOrderFilters caps the filters one user can keep
+const maxSavedOrderFilters = 50
+
+// OrderTagService labels orders with free-form tags and keeps each user's
+// saved order filters
+type OrderTagService struct {
+	orderRepo     repository.OrderRepository
+	filterRepo    repository.SavedOrderFilterRepository
+	crud          *OrderCRUDService
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	validator     *validation.TagValidator
+}
+
+// NewOrderTagService creates a new order tag service
+func NewOrderTagService(
+	orderRepo repository.OrderRepository,
+	filterRepo repository.SavedOrderFilterRepository,
+	crud *OrderCRUDService,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *OrderTagService {
+	return &OrderTagService{
+		orderRepo:     orderRepo,
+		filterRepo:    filterRepo,
+		crud:          crud,
+		eventProducer: eventProducer,
+		logger:        log,
+		validator:     validation.NewTagValidator(),
+	}
+}
+
+// UpdateOrderTagsInput changes an order's tags. Tags replaces the whole set
+// when given; otherwise Add and Remove are applied to the current tags.
+type UpdateOrderTagsInput struct {
+	Tags      []string `json:"tags"`
+	Add       []string `json:"add"`
+	Remove    []string `json:"remove"`
+	Version   int      `json:"version"` // Order version the edit was made against; 0 skips the check
+	UpdatedBy string   `json:"-"`
+}
+
+// UpdateOrderTags changes an order's tags and publishes the change with the
+// added and removed tags. Closed orders can still be relabelled, since
+// tags drive reporting as well as the boards.
+func (s *OrderTagService) UpdateOrderTags(ctx context.Context, orderID uuid.UUID, input UpdateOrderTagsInput) (*domain.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order == nil {
+		return nil, apperrors.NotFoundError("order", orderID.String())
+	}
+	if input.Version != 0 && input.Version != order.Version {
+		return nil, apperrors.VersionConflictError("order", orderID.String(), order)
+	}
+
+	next := input.Tags
+	if next == nil {
+		next = append(append([]string{}, order.Tags...), input.Add...)
+	}
+	tags, err := s.validator.Normalize(next)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "tags", next)
+	}
+	if input.Tags == nil && len(input.Remove) > 0 {
+		remove, err := s.validator.Normalize(input.Remove)
+		if err != nil {
+			return nil, apperrors.ValidationError(err.Error(), "remove", input.Remove)
+		}
+		tags = tagDifference(tags, remove)
+	}
+
+	added, removed := tagDifference(tags, order.Tags), tagDifference(order.Tags, tags)
+	if len(added) == 0 && len(removed) == 0 {
+		return order, nil
+	}
+
+	order.Tags = tags
+	order.UpdatedAt = time.Now()
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return nil, s.crud.orderUpdateError(ctx, orderID, "update order tags", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.OrderTagsChanged, "order-service", map[string]interface{}{
+		"order_id":     order.ID.String(),
+		"order_number": order.OrderNumber,
+		"status":       order.Status,
+		"tags":         order.Tags,
+		"added":        added,
+		"removed":      removed,
+		"changed_by":   input.UpdatedBy,
+	}).WithTags(order.Tags)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.OrderTagsChanged, event)
+
+	s.logger.Infow("Order tags changed",
+		"order_id", orderID,
+		"added", added,
+		"removed", removed,
+		"changed_by", input.UpdatedBy,
+	)
+	return order, nil
+}
+
+// SaveOrderFilterInput creates or replaces a saved filter
+type SaveOrderFilterInput struct {
+	ID        *uuid.UUID                 `json:"-"` // Existing filter to replace; nil creates one
+	UserID    string                     `json:"-"`
+	Name      string                     `json:"name"`
+	Criteria  domain.OrderFilterCriteria `json:"criteria"`
+	IsDefault bool                       `json:"is_default"`
+}
+
+// SaveOrderFilter creates or replaces one of a user's saved filters. Making
+// a filter the default clears the user's previous default.
+func (s *OrderTagService) SaveOrderFilter(ctx context.Context, input SaveOrderFilterInput) (*domain.SavedOrderFilter, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, apperrors.ValidationError("name is required", "name", input.Name)
+	}
+	tags, err := s.validator.Normalize(input.Criteria.Tags)
+	if err != nil {
+		return nil, apperrors.ValidationError(err.Error(), "criteria.tags", input.Criteria.Tags)
+	}
+	input.Criteria.Tags = tags
+
+	existing, err := s.filterRepo.ListByUser(ctx, input.UserID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list saved filters", err)
+	}
+	var filter *domain.SavedOrderFilter
+	for i := range existing {
+		if input.ID != nil && existing[i].ID == *input.ID {
+			filter = &existing[i]
+			continue
+		}
+		if strings.EqualFold(existing[i].Name, name) {
+			return nil, apperrors.ConflictError("a saved filter named " + name + " already exists")
+		}
+	}
+	if input.ID != nil && filter == nil {
+		return nil, apperrors.NotFoundError("saved filter", input.ID.String())
+	}
+	if filter == nil && len(existing) >= maxSavedOrderFilters {
+		return nil, apperrors.ValidationError("too many saved filters", "name", name)
+	}
+
+	if input.IsDefault {
+		if err := s.filterRepo.ClearDefault(ctx, input.UserID); err != nil {
+			return nil, apperrors.DatabaseError("clear default filter", err)
+		}
+	}
+
+	now := time.Now()
+	if filter == nil {
+		filter = &domain.SavedOrderFilter{
+			ID:        uuid.New(),
+			UserID:    input.UserID,
+			Name:      name,
+			Criteria:  input.Criteria,
+			IsDefault: input.IsDefault,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.filterRepo.Create(ctx, filter); err != nil {
+			return nil, apperrors.DatabaseError("create saved filter", err)
+		}
+		return filter, nil
+	}
+
+	filter.Name = name
+	filter.Criteria = input.Criteria
+	filter.IsDefault = input.IsDefault
+	filter.UpdatedAt = now
+	if err := s.filterRepo.Update(ctx, filter); err != nil {
+		return nil, apperrors.DatabaseError("update saved filter", err)
+	}
+	return filter, nil
+}
+
+// ListOrderFilters returns a user's saved filters, default first
+func (s *OrderTagService) ListOrderFilters(ctx context.Context, userID string) ([]domain.SavedOrderFilter, error) {
+	filters, err := s.filterRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list saved filters", err)
+	}
+	return filters, nil
+}
+
+// DeleteOrderFilter deletes one of a user's saved filters
+func (s *OrderTagService) DeleteOrderFilter(ctx context.Context, id uuid.UUID, userID string) error {
+	if _, err := s.ownFilter(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := s.filterRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete saved filter", err)
+	}
+	return nil
+}
+
+// RunOrderFilter lists the orders matching one of a user's saved filters
+func (s *OrderTagService) RunOrderFilter(ctx context.Context, id uuid.UUID, userID string, page, pageSize int) (*OrderListResult, error) {
+	filter, err := s.ownFilter(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	c := filter.Criteria
+	return s.crud.ListOrders(ctx, ListOrdersFilter{
+		Status:        c.Status,
+		Type:          c.Type,
+		BillingStatus: c.BillingStatus,
+		ShipmentID:    c.ShipmentID,
+		Tags:          c.Tags,
+		MatchAllTags:  c.MatchAllTags,
+		Page:          page,
+		PageSize:      pageSize,
+		SortBy:        c.SortBy,
+		SortOrder:     c.SortOrder,
+	})
+}
+
+// ownFilter loads a saved filter, hiding other users' filters as not found
+func (s *OrderTagService) ownFilter(ctx context.Context, id uuid.UUID, userID string) (*domain.SavedOrderFilter, error) {
+	filter, err := s.filterRepo.GetByID(ctx, id)
+	if err != nil || filter == nil || filter.UserID != userID {
+		return nil, apperrors.NotFoundError("saved filter", id.String())
+	}
+	return filter, nil
+}
+
+// tagDifference returns the tags in a that are not in b
+func tagDifference(a, b []string) []string {
+	drop := make(map[string]bool, len(b))
+	for _, tag := range b {
+		drop[tag] = true
+	}
+	var out []string
+	for _, tag := range a {
+		if !drop[tag] {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
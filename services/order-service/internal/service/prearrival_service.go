@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// preArrivalLookback and preArrivalHorizon bound the default workspace:
+	// vessels that arrived in the last few days through the next two weeks
+	preArrivalLookback = 3 * 24 * time.Hour
+	preArrivalHorizon  = 14 * 24 * time.Hour
+	// maxPreArrivalRange caps a requested ETA range
+	maxPreArrivalRange = 60 * 24 * time.Hour
+	// maxPreArrivalBatch caps the orders in one bulk action
+	maxPreArrivalBatch = 200
+)
+
+// dischargeStatuses are the eModal statuses that put an import container on
+// the terminal, releasing any draft trips queued for it
+var dischargeStatuses = map[string]bool{
+	emodalStatusDischarged: true,
+	emodalStatusInYard:     true,
+	emodalStatusAvailable:  true,
+	emodalStatusOnHold:     true,
+}
+
+// AppointmentRequester books terminal appointments. It is implemented by
+// AppointmentService.
+type AppointmentRequester interface {
+	RequestAppointment(ctx context.Context, input RequestAppointmentInput) (*domain.TerminalAppointment, error)
+}
+
+// PreArrivalService is the planners' workspace for import containers on
+// inbound vessels: containers grouped by vessel and ETA with what is still
+// missing to move them, and bulk actions to request appointments and stage
+// draft trips that go to dispatch once the containers discharge.
+type PreArrivalService struct {
+	prearrivalRepo repository.PreArrivalRepository
+	appointments   AppointmentRequester
+	eventProducer  *kafka.Producer
+	logger         *logger.Logger
+}
+
+// NewPreArrivalService creates a new pre-arrival workspace service
+func NewPreArrivalService(
+	prearrivalRepo repository.PreArrivalRepository,
+	appointments AppointmentRequester,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *PreArrivalService {
+	return &PreArrivalService{
+		prearrivalRepo: prearrivalRepo,
+		appointments:   appointments,
+		eventProducer:  eventProducer,
+		logger:         log,
+	}
+}
+
+// GetWorkspace returns the inbound import containers in the filter's ETA
+// range grouped by vessel voyage and terminal, earliest ETA first. A zero
+// range defaults to the last three days through the next two weeks.
+func (s *PreArrivalService) GetWorkspace(ctx context.Context, filter repository.PreArrivalFilter, now time.Time) ([]domain.PreArrivalVessel, error) {
+	if filter.ETAFrom.IsZero() {
+		filter.ETAFrom = now.Add(-preArrivalLookback)
+	}
+	if filter.ETATo.IsZero() {
+		filter.ETATo = filter.ETAFrom.Add(preArrivalLookback + preArrivalHorizon)
+	}
+	if !filter.ETATo.After(filter.ETAFrom) {
+		return nil, apperrors.ValidationError("eta_to must be after eta_from", "eta_to", filter.ETATo)
+	}
+	if filter.ETATo.Sub(filter.ETAFrom) > maxPreArrivalRange {
+		return nil, apperrors.ValidationError("ETA range cannot exceed 60 days", "eta_to", filter.ETATo)
+	}
+
+	containers, err := s.prearrivalRepo.ListContainers(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list pre-arrival containers", err)
+	}
+	return groupByVessel(containers), nil
+}
+
+// groupByVessel flags each container and groups them by vessel voyage and
+// terminal, keeping the order they were listed in
+func groupByVessel(containers []domain.PreArrivalContainer) []domain.PreArrivalVessel {
+	type vesselKey struct {
+		vessel, voyage string
+		terminalID     uuid.UUID
+	}
+
+	vessels := []domain.PreArrivalVessel{}
+	index := make(map[vesselKey]int)
+	for _, c := range containers {
+		c.SetFlags()
+
+		key := vesselKey{c.VesselName, c.VoyageNumber, c.TerminalID}
+		i, ok := index[key]
+		if !ok {
+			i = len(vessels)
+			index[key] = i
+			vessels = append(vessels, domain.PreArrivalVessel{
+				VesselName:   c.VesselName,
+				VoyageNumber: c.VoyageNumber,
+				TerminalID:   c.TerminalID,
+				TerminalName: c.TerminalName,
+				VesselETA:    c.VesselETA,
+				VesselATA:    c.VesselATA,
+				FlagCounts:   make(map[domain.PreArrivalFlag]int),
+			})
+		}
+
+		v := &vessels[i]
+		if c.IsDischarged() {
+			v.Discharged++
+		}
+		if len(c.Flags) == 0 {
+			v.Ready++
+		}
+		for _, flag := range c.Flags {
+			v.FlagCounts[flag]++
+		}
+		v.Containers = append(v.Containers, c)
+	}
+	return vessels
+}
+
+// RequestPreArrivalAppointmentsInput contains input for requesting pickup
+// appointments in bulk
+type RequestPreArrivalAppointmentsInput struct {
+	OrderIDs      []uuid.UUID `json:"order_ids"`
+	RequestedTime *time.Time  `json:"requested_time"` // Applied to every order; otherwise each order's requested pickup date
+	RequestedBy   string      `json:"-"`
+}
+
+// RequestAppointments requests a terminal pickup appointment for each order.
+// Orders on credit hold, with an active appointment, or without a time to
+// book are skipped; each order's outcome is returned in input order.
+func (s *PreArrivalService) RequestAppointments(ctx context.Context, input RequestPreArrivalAppointmentsInput) ([]domain.PreArrivalActionResult, error) {
+	rows, err := s.loadOrders(ctx, input.OrderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.PreArrivalActionResult, 0, len(input.OrderIDs))
+	for _, orderID := range input.OrderIDs {
+		result := domain.PreArrivalActionResult{OrderID: orderID, Outcome: domain.PreArrivalSkipped}
+		c, ok := rows[orderID]
+		if !ok {
+			result.Reason = "order not found"
+			results = append(results, result)
+			continue
+		}
+		result.ContainerNumber = c.ContainerNumber
+
+		requestedTime := input.RequestedTime
+		if requestedTime == nil {
+			requestedTime = c.RequestedPickupDate
+		}
+		switch {
+		case c.CreditHold:
+			result.Reason = "customer is on credit hold"
+		case c.AppointmentID != nil:
+			result.Reason = "order already has an active appointment"
+			result.AppointmentID = c.AppointmentID
+		case requestedTime == nil:
+			result.Reason = "no requested time and the order has no requested pickup date"
+		default:
+			containerID := c.ContainerID
+			appointment, err := s.appointments.RequestAppointment(ctx, RequestAppointmentInput{
+				OrderID:       orderID,
+				TerminalID:    c.TerminalID,
+				Type:          domain.AppointmentTypePickup,
+				ContainerID:   &containerID,
+				RequestedTime: *requestedTime,
+				RequestedBy:   input.RequestedBy,
+			})
+			if err != nil {
+				result.Outcome = domain.PreArrivalFailed
+				result.Reason = err.Error()
+				break
+			}
+			result.Outcome = domain.PreArrivalRequested
+			result.AppointmentID = &appointment.ID
+		}
+		results = append(results, result)
+	}
+
+	s.logger.Infow("Pre-arrival appointments requested",
+		"orders", len(input.OrderIDs),
+		"requested_by", input.RequestedBy,
+	)
+	return results, nil
+}
+
+// GenerateDraftTripsInput contains input for staging draft trips in bulk
+type GenerateDraftTripsInput struct {
+	OrderIDs    []uuid.UUID `json:"order_ids"`
+	RequestedBy string      `json:"-"`
+}
+
+// GenerateDraftTrips stages a draft trip for each order. Discharged
+// containers go to dispatch now; the rest are queued and sent when eModal
+// reports them on the terminal. Orders on credit hold, without a delivery
+// location, or already staged are skipped.
+func (s *PreArrivalService) GenerateDraftTrips(ctx context.Context, input GenerateDraftTripsInput, now time.Time) ([]domain.PreArrivalActionResult, error) {
+	rows, err := s.loadOrders(ctx, input.OrderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.PreArrivalActionResult, 0, len(input.OrderIDs))
+	for _, orderID := range input.OrderIDs {
+		result := domain.PreArrivalActionResult{OrderID: orderID, Outcome: domain.PreArrivalSkipped}
+		c, ok := rows[orderID]
+		if !ok {
+			result.Reason = "order not found"
+			results = append(results, result)
+			continue
+		}
+		result.ContainerNumber = c.ContainerNumber
+
+		switch {
+		case c.CreditHold:
+			result.Reason = "customer is on credit hold"
+		case c.DeliveryLocationID == nil:
+			result.Reason = "order has no delivery location"
+		case c.DraftTripRequestedAt != nil:
+			result.Reason = "draft trip already requested"
+		default:
+			if err := s.prearrivalRepo.MarkDraftTripRequested(ctx, orderID, input.RequestedBy, now); err != nil {
+				result.Outcome = domain.PreArrivalFailed
+				result.Reason = err.Error()
+				break
+			}
+			if !c.IsDischarged() {
+				result.Outcome = domain.PreArrivalQueued
+				break
+			}
+			if err := s.sendDraftTrip(ctx, &c, input.RequestedBy, now); err != nil {
+				result.Outcome = domain.PreArrivalFailed
+				result.Reason = err.Error()
+				break
+			}
+			result.Outcome = domain.PreArrivalRequested
+		}
+		results = append(results, result)
+	}
+
+	s.logger.Infow("Pre-arrival draft trips generated",
+		"orders", len(input.OrderIDs),
+		"requested_by", input.RequestedBy,
+	)
+	return results, nil
+}
+
+// HandleContainerStatus is a kafka.Handler for eModal container status
+// updates that sends the draft trips queued for a container once it
+// discharges
+func (s *PreArrivalService) HandleContainerStatus(ctx context.Context, event *kafka.Event) error {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload emodalStatusPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("unmarshal container status: %w", err)
+	}
+	if payload.ContainerNumber == "" || !dischargeStatuses[payload.Status] {
+		return nil
+	}
+
+	queued, err := s.prearrivalRepo.GetQueuedDraftTrips(ctx, payload.ContainerNumber)
+	if err != nil {
+		return apperrors.DatabaseError("get queued draft trips", err)
+	}
+	for i := range queued {
+		c := &queued[i]
+		// A hold placed after the trip was staged keeps it queued until lifted
+		if c.CreditHold {
+			s.logger.Warnw("Holding queued draft trip for customer on credit hold",
+				"order_id", c.OrderID,
+				"container", c.ContainerNumber,
+				"customer_id", c.CustomerID,
+			)
+			continue
+		}
+		if err := s.sendDraftTrip(ctx, c, "", time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDraftTrip asks dispatch for a draft trip: pickup at the terminal,
+// delivery, and the empty return when the order has one
+func (s *PreArrivalService) sendDraftTrip(ctx context.Context, c *domain.PreArrivalContainer, requestedBy string, now time.Time) error {
+	data := map[string]interface{}{
+		"order_id":             c.OrderID.String(),
+		"order_number":         c.OrderNumber,
+		"container_id":         c.ContainerID.String(),
+		"container_number":     c.ContainerNumber,
+		"customer_id":          c.CustomerID.String(),
+		"pickup_location_id":   c.PickupLocation().String(),
+		"delivery_location_id": c.DeliveryLocationID.String(),
+		"requested_by":         requestedBy,
+	}
+	if c.ReturnLocationID != nil {
+		data["return_location_id"] = c.ReturnLocationID.String()
+	}
+	if c.AppointmentTime != nil {
+		data["pickup_appointment_time"] = c.AppointmentTime
+	}
+	event := kafka.NewEvent(kafka.Topics.DraftTripRequested, "order-service", data)
+	if err := s.eventProducer.Publish(ctx, kafka.Topics.DraftTripRequested, event); err != nil {
+		return apperrors.ExternalServiceError("kafka", err)
+	}
+
+	if err := s.prearrivalRepo.MarkDraftTripSent(ctx, *c.OrderID, now); err != nil {
+		return apperrors.DatabaseError("mark draft trip sent", err)
+	}
+
+	s.logger.Infow("Draft trip requested from dispatch",
+		"order_id", c.OrderID,
+		"container", c.ContainerNumber,
+	)
+	return nil
+}
+
+// loadOrders validates a bulk action's orders and loads their workspace rows
+func (s *PreArrivalService) loadOrders(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID]domain.PreArrivalContainer, error) {
+	if len(orderIDs) == 0 {
+		return nil, apperrors.ValidationError("at least one order is required", "order_ids", orderIDs)
+	}
+	if len(orderIDs) > maxPreArrivalBatch {
+		return nil, apperrors.ValidationError(fmt.Sprintf("at most %d orders per request", maxPreArrivalBatch), "order_ids", len(orderIDs))
+	}
+
+	containers, err := s.prearrivalRepo.GetByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get pre-arrival orders", err)
+	}
+	rows := make(map[uuid.UUID]domain.PreArrivalContainer, len(containers))
+	for _, c := range containers {
+		if c.OrderID != nil {
+			rows[*c.OrderID] = c
+		}
+	}
+	return rows, nil
+}
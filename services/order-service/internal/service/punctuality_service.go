@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// PunctualityService measures driver arrivals against terminal appointment
+// windows and turns chronically congested slots into booking preferences
+type PunctualityService struct {
+	appointmentRepo repository.AppointmentRepository
+	preferenceRepo  repository.BookingPreferenceRepository
+	eventProducer   *kafka.Producer
+	logger          *logger.Logger
+	businessRules   *config.BusinessRules
+}
+
+// NewPunctualityService creates a new punctuality service
+func NewPunctualityService(
+	appointmentRepo repository.AppointmentRepository,
+	preferenceRepo repository.BookingPreferenceRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *PunctualityService {
+	return &PunctualityService{
+		appointmentRepo: appointmentRepo,
+		preferenceRepo:  preferenceRepo,
+		eventProducer:   eventProducer,
+		logger:          log,
+		businessRules:   config.DefaultBusinessRules(),
+	}
+}
+
+// AnalyzeTerminal measures arrival offsets and turn times per weekday/hour slot
+// for appointments whose window started between start and end
+func (s *PunctualityService) AnalyzeTerminal(ctx context.Context, terminalID uuid.UUID, start, end time.Time) (*domain.TerminalPunctuality, error) {
+	if !end.After(start) {
+		return nil, apperrors.ValidationError("end must be after start", "end", end)
+	}
+
+	appointments, err := s.appointmentRepo.GetByTerminalAndTimeRange(ctx, terminalID, start, end)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal appointments", err)
+	}
+
+	return analyzePunctuality(terminalID, start, end, appointments, &s.businessRules.Punctuality), nil
+}
+
+// GetBookingPreference returns the booking preference for a terminal, or nil
+// if none has been computed yet
+func (s *PunctualityService) GetBookingPreference(ctx context.Context, terminalID uuid.UUID) (*domain.AppointmentBookingPreference, error) {
+	preference, err := s.preferenceRepo.GetByTerminalID(ctx, terminalID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get booking preference", err)
+	}
+	return preference, nil
+}
+
+// RefreshBookingPreference re-analyses a terminal over the lookback period and
+// stores the resulting booking preference
+func (s *PunctualityService) RefreshBookingPreference(ctx context.Context, terminalID uuid.UUID) (*domain.AppointmentBookingPreference, error) {
+	end := time.Now()
+	start := end.AddDate(0, 0, -s.businessRules.Punctuality.LookbackDays)
+
+	analysis, err := s.AnalyzeTerminal(ctx, terminalID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return s.savePreference(ctx, analysis)
+}
+
+// RefreshAllBookingPreferences refreshes the preference of every terminal with
+// appointments in the lookback period and returns how many were updated
+func (s *PunctualityService) RefreshAllBookingPreferences(ctx context.Context) (int, error) {
+	rules := &s.businessRules.Punctuality
+	end := time.Now()
+	start := end.AddDate(0, 0, -rules.LookbackDays)
+
+	appointments, err := s.appointmentRepo.GetByTimeRange(ctx, start, end)
+	if err != nil {
+		return 0, apperrors.DatabaseError("get appointments", err)
+	}
+
+	byTerminal := make(map[uuid.UUID][]domain.TerminalAppointment)
+	for _, appt := range appointments {
+		byTerminal[appt.TerminalID] = append(byTerminal[appt.TerminalID], appt)
+	}
+
+	updated := 0
+	for terminalID, terminalAppointments := range byTerminal {
+		analysis := analyzePunctuality(terminalID, start, end, terminalAppointments, rules)
+		if _, err := s.savePreference(ctx, analysis); err != nil {
+			s.logger.Errorw("Failed to refresh booking preference",
+				"terminal_id", terminalID,
+				"error", err,
+			)
+			continue
+		}
+		updated++
+	}
+
+	s.logger.Infow("Booking preferences refreshed",
+		"terminals", updated,
+		"appointments", len(appointments),
+	)
+
+	return updated, nil
+}
+
+// Start refreshes booking preferences for all terminals until ctx is cancelled
+func (s *PunctualityService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RefreshAllBookingPreferences(ctx); err != nil {
+			s.logger.Errorw("Booking preference refresh failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// congestedWindow returns the avoided window t falls in at a terminal, or nil.
+// Preferences only advise booking, so lookup failures are logged, not returned.
+func (s *PunctualityService) congestedWindow(ctx context.Context, terminalID uuid.UUID, t time.Time) *domain.AppointmentWindow {
+	preference, err := s.preferenceRepo.GetByTerminalID(ctx, terminalID)
+	if err != nil {
+		s.logger.Warnw("Failed to get booking preference",
+			"terminal_id", terminalID,
+			"error", err,
+		)
+		return nil
+	}
+	if preference == nil {
+		return nil
+	}
+	return preference.Avoids(t)
+}
+
+func (s *PunctualityService) savePreference(ctx context.Context, analysis *domain.TerminalPunctuality) (*domain.AppointmentBookingPreference, error) {
+	preference := bookingPreference(analysis, &s.businessRules.Punctuality)
+	if err := s.preferenceRepo.Upsert(ctx, preference); err != nil {
+		return nil, apperrors.DatabaseError("save booking preference", err)
+	}
+
+	event := kafka.NewEvent(kafka.Topics.BookingPreferencesUpdated, "order-service", map[string]interface{}{
+		"terminal_id":         preference.TerminalID.String(),
+		"timezone":            preference.Timezone,
+		"preferred_windows":   preference.PreferredWindows,
+		"avoid_windows":       preference.AvoidWindows,
+		"arrival_buffer_mins": preference.ArrivalBufferMins,
+		"sample_size":         preference.SampleSize,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.BookingPreferencesUpdated, event)
+
+	if len(preference.AvoidWindows) > 0 {
+		s.logger.Infow("Congested appointment windows identified",
+			"terminal_id", preference.TerminalID,
+			"avoid_windows", len(preference.AvoidWindows),
+		)
+	}
+
+	return preference, nil
+}
+
+// slotKey identifies a recurring weekday/hour appointment slot
+type slotKey struct {
+	day  time.Weekday
+	hour int
+}
+
+// slotSamples accumulates one slot's counts and per-appointment measurements
+type slotSamples struct {
+	stats   domain.SlotPunctuality
+	offsets []float64
+	turns   []float64
+}
+
+// analyzePunctuality buckets appointments by the weekday and hour their window
+// opens in the terminal timezone. Cancelled and rescheduled appointments, and
+// those not yet due, are ignored; an appointment marked missed counts against
+// its slot without an arrival offset.
+func analyzePunctuality(terminalID uuid.UUID, start, end time.Time, appointments []domain.TerminalAppointment, rules *config.PunctualityRules) *domain.TerminalPunctuality {
+	loc, err := time.LoadLocation(rules.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	earlyTolerance := time.Duration(rules.EarlyToleranceMins) * time.Minute
+
+	result := &domain.TerminalPunctuality{
+		TerminalID:  terminalID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Timezone:    loc.String(),
+		Slots:       []domain.SlotPunctuality{},
+	}
+
+	slots := make(map[slotKey]*slotSamples)
+	var offsets, turns []float64
+	onTime := 0
+	for _, appt := range appointments {
+		if appt.ActualArrivalTime == nil && appt.Status != domain.AppointmentStatusMissed {
+			continue
+		}
+
+		window := appt.WindowStartTime.In(loc)
+		key := slotKey{day: window.Weekday(), hour: window.Hour()}
+		slot, ok := slots[key]
+		if !ok {
+			slot = &slotSamples{stats: domain.SlotPunctuality{DayOfWeek: key.day, Hour: key.hour}}
+			slots[key] = slot
+		}
+		slot.stats.Appointments++
+		result.Appointments++
+
+		if appt.ActualArrivalTime == nil {
+			slot.stats.Missed++
+			continue
+		}
+
+		arrival := *appt.ActualArrivalTime
+		slot.stats.Arrived++
+		offset := arrival.Sub(appt.WindowStartTime).Minutes()
+		slot.offsets = append(slot.offsets, offset)
+		offsets = append(offsets, offset)
+
+		switch {
+		case arrival.Before(appt.WindowStartTime.Add(-earlyTolerance)):
+			slot.stats.Early++
+		case arrival.After(appt.WindowEndTime):
+			slot.stats.Late++
+		default:
+			slot.stats.OnTime++
+			onTime++
+		}
+
+		if appt.ActualCompletionTime != nil && appt.ActualCompletionTime.After(arrival) {
+			turn := appt.ActualCompletionTime.Sub(arrival).Minutes()
+			slot.turns = append(slot.turns, turn)
+			turns = append(turns, turn)
+		}
+	}
+
+	for _, slot := range slots {
+		stats := slot.stats
+		stats.OnTimeRate = float64(stats.OnTime) / float64(stats.Appointments)
+		stats.LateRate = float64(stats.Late+stats.Missed) / float64(stats.Appointments)
+		stats.AvgArrivalOffsetMins = mean(slot.offsets)
+		stats.P90ArrivalOffsetMins = percentile(slot.offsets, 0.9)
+		stats.AvgTurnMins = mean(slot.turns)
+
+		if stats.Appointments >= rules.MinSlotSamples {
+			switch {
+			case stats.LateRate >= rules.CongestedLateRate:
+				stats.Congested = true
+				stats.CongestionReason = fmt.Sprintf("%.0f%% of appointments late or missed", stats.LateRate*100)
+			case rules.CongestedTurnMins > 0 && stats.AvgTurnMins >= float64(rules.CongestedTurnMins):
+				stats.Congested = true
+				stats.CongestionReason = fmt.Sprintf("average turn time %.0f minutes", stats.AvgTurnMins)
+			}
+		}
+		result.Slots = append(result.Slots, stats)
+	}
+	sort.Slice(result.Slots, func(i, j int) bool {
+		if result.Slots[i].DayOfWeek != result.Slots[j].DayOfWeek {
+			return result.Slots[i].DayOfWeek < result.Slots[j].DayOfWeek
+		}
+		return result.Slots[i].Hour < result.Slots[j].Hour
+	})
+
+	if result.Appointments > 0 {
+		result.OnTimeRate = float64(onTime) / float64(result.Appointments)
+	}
+	result.AvgArrivalOffsetMins = mean(offsets)
+	result.MedianArrivalOffsetMins = percentile(offsets, 0.5)
+	result.AvgTurnMins = mean(turns)
+
+	return result
+}
+
+// bookingPreference derives auto-booking preferences from an analysis:
+// congested slots are avoided, and the best-performing slots with enough
+// history are preferred, ranked by on-time rate and then turn time
+func bookingPreference(analysis *domain.TerminalPunctuality, rules *config.PunctualityRules) *domain.AppointmentBookingPreference {
+	preference := &domain.AppointmentBookingPreference{
+		TerminalID:       analysis.TerminalID,
+		Timezone:         analysis.Timezone,
+		PreferredWindows: []domain.AppointmentWindow{},
+		AvoidWindows:     []domain.AppointmentWindow{},
+		SampleSize:       analysis.Appointments,
+		PeriodStart:      analysis.PeriodStart,
+		PeriodEnd:        analysis.PeriodEnd,
+		UpdatedAt:        time.Now(),
+	}
+
+	var candidates []domain.SlotPunctuality
+	for _, slot := range analysis.Slots {
+		switch {
+		case slot.Congested:
+			preference.AvoidWindows = append(preference.AvoidWindows, domain.AppointmentWindow{
+				DayOfWeek: slot.DayOfWeek,
+				Hour:      slot.Hour,
+				Reason:    slot.CongestionReason,
+			})
+		case slot.Appointments >= rules.MinSlotSamples:
+			candidates = append(candidates, slot)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].OnTimeRate != candidates[j].OnTimeRate {
+			return candidates[i].OnTimeRate > candidates[j].OnTimeRate
+		}
+		return candidates[i].AvgTurnMins < candidates[j].AvgTurnMins
+	})
+	for i, slot := range candidates {
+		if i == rules.PreferredSlots {
+			break
+		}
+		preference.PreferredWindows = append(preference.PreferredWindows, domain.AppointmentWindow{
+			DayOfWeek: slot.DayOfWeek,
+			Hour:      slot.Hour,
+			Reason:    fmt.Sprintf("%.0f%% on time", slot.OnTimeRate*100),
+		})
+	}
+
+	// Drivers who typically reach this terminal after the window opens should
+	// be planned to arrive that much earlier, rounded up to 5 minutes
+	if analysis.MedianArrivalOffsetMins > 0 {
+		preference.ArrivalBufferMins = int(math.Ceil(analysis.MedianArrivalOffsetMins/5)) * 5
+	}
+
+	return preference
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// percentile returns the nearest-rank percentile p (0-1) of values
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ContractRateQuery describes the move a contract rate is looked up for
+type ContractRateQuery struct {
+	CustomerID    uuid.UUID
+	OriginID      *uuid.UUID
+	DestinationID *uuid.UUID
+	ContainerSize domain.ContainerSize
+	ContainerType domain.ContainerType
+	At            time.Time
+}
+
+// ContractRateSource finds the customer contract rate that prices a move.
+// FindContractRate returns nil, nil when no contract rate covers it.
+type ContractRateSource interface {
+	FindContractRate(ctx context.Context, query ContractRateQuery) (*domain.ContractRate, error)
+}
+
+// RateApprovalService holds orders quoted off their customer's contract
+// rate until a manager approves the rate. An order on its way to READY is
+// checked against the contract linehaul; beyond the allowed deviation it is
+// put on HOLD with an approval in the queue, and approving it releases the
+// order to READY.
+type RateApprovalService struct {
+	db            *database.DB
+	approvalRepo  repository.RateApprovalRepository
+	orderRepo     repository.OrderRepository
+	shipmentRepo  repository.ShipmentRepository
+	containerRepo repository.ContainerRepository
+	rates         ContractRateSource
+	outbox        *kafka.Outbox
+	rules         config.RateApprovalRules
+	logger        *logger.Logger
+}
+
+// NewRateApprovalService creates a new rate approval service
+func NewRateApprovalService(
+	db *database.DB,
+	approvalRepo repository.RateApprovalRepository,
+	orderRepo repository.OrderRepository,
+	shipmentRepo repository.ShipmentRepository,
+	containerRepo repository.ContainerRepository,
+	rates ContractRateSource,
+	outbox *kafka.Outbox,
+	rules config.RateApprovalRules,
+	log *logger.Logger,
+) *RateApprovalService {
+	return &RateApprovalService{
+		db:            db,
+		approvalRepo:  approvalRepo,
+		orderRepo:     orderRepo,
+		shipmentRepo:  shipmentRepo,
+		containerRepo: containerRepo,
+		rates:         rates,
+		outbox:        outbox,
+		rules:         rules,
+		logger:        log,
+	}
+}
+
+// ============================================================================
+// RELEASE CHECK
+// ============================================================================
+
+// CheckRelease decides whether an order may become READY at its agreed
+// rate. Orders without one, at an approved rate, or within the allowed
+// deviation from contract pass. Otherwise the order is put on HOLD, an
+// approval is queued unless one is already pending for the rate, and a
+// RATE_APPROVAL_REQUIRED error names it.
+func (s *RateApprovalService) CheckRelease(ctx context.Context, order *domain.Order, requestedBy string) error {
+	if order.AgreedRate == nil {
+		return nil
+	}
+	rate := *order.AgreedRate
+
+	latest, err := s.approvalRepo.GetLatestForOrder(ctx, order.ID)
+	if err != nil {
+		return apperrors.DatabaseError("get rate approval", err)
+	}
+	if latest != nil && latest.Covers(rate) {
+		switch latest.Status {
+		case domain.RateApprovalApproved:
+			return nil
+		case domain.RateApprovalPending:
+			return approvalRequired(latest)
+		case domain.RateApprovalRejected:
+			return approvalRequired(latest).WithDetail("message", "the rate was rejected; requote the order")
+		}
+	}
+
+	approval, err := s.evaluate(ctx, order, rate)
+	if err != nil {
+		return err
+	}
+	if approval == nil {
+		return nil
+	}
+	approval.RequestedBy = requestedBy
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		// A pending approval for an earlier rate no longer applies
+		if latest != nil && latest.IsPending() {
+			latest.Status = domain.RateApprovalSuperseded
+			latest.UpdatedAt = approval.RequestedAt
+			if err := s.approvalRepo.Update(txCtx, latest); err != nil {
+				return apperrors.DatabaseError("supersede rate approval", err)
+			}
+		}
+		if err := s.approvalRepo.Create(txCtx, approval); err != nil {
+			return apperrors.DatabaseError("create rate approval", err)
+		}
+		if order.Status != domain.OrderStatusHold {
+			if err := s.orderRepo.UpdateStatus(txCtx, order.ID, domain.OrderStatusHold); err != nil {
+				return apperrors.DatabaseError("hold order", err)
+			}
+			if err := s.publishStatusChange(txCtx, order.ID, domain.OrderStatusHold, "rate approval required"); err != nil {
+				return err
+			}
+		}
+		return s.publish(txCtx, kafka.Topics.RateApprovalRequested, approval, nil)
+	})
+	if err != nil {
+		return err
+	}
+	order.Status = domain.OrderStatusHold
+
+	s.logger.Infow("Order held for rate approval",
+		"order_id", order.ID,
+		"approval_id", approval.ID,
+		"reason", approval.Reason,
+		"quoted_rate", approval.QuotedRate,
+	)
+	return approvalRequired(approval)
+}
+
+// evaluate compares an order's rate with its contract rate and returns the
+// approval it needs, or nil when it needs none
+func (s *RateApprovalService) evaluate(ctx context.Context, order *domain.Order, rate float64) (*domain.RateApproval, error) {
+	shipment, err := s.shipmentRepo.GetByID(ctx, order.ShipmentID)
+	if err != nil || shipment == nil {
+		return nil, apperrors.NotFoundError("shipment", order.ShipmentID.String())
+	}
+	query := ContractRateQuery{
+		CustomerID:    shipment.CustomerID,
+		OriginID:      order.PickupLocationID,
+		DestinationID: order.DeliveryLocationID,
+		At:            time.Now(),
+	}
+	if order.ContainerID != uuid.Nil {
+		container, err := s.containerRepo.GetByID(ctx, order.ContainerID)
+		if err == nil && container != nil {
+			query.ContainerSize = container.Size
+			query.ContainerType = container.Type
+		}
+	}
+
+	contract, err := s.rates.FindContractRate(ctx, query)
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("billing-service", err)
+	}
+
+	now := time.Now()
+	approval := &domain.RateApproval{
+		ID:          uuid.New(),
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		CustomerID:  shipment.CustomerID,
+		QuotedRate:  rate,
+		Status:      domain.RateApprovalPending,
+		RequestedAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if contract == nil {
+		if !s.rules.RequireForNoContract {
+			return nil, nil
+		}
+		approval.Reason = domain.RateExceptionNoContract
+		return approval, nil
+	}
+
+	deviation := rate - contract.BaseRate
+	approval.ContractRate = &contract.BaseRate
+	approval.ContractRateID = &contract.ID
+	approval.DeviationAmount = &deviation
+	if contract.BaseRate > 0 {
+		percent := math.Round(deviation/contract.BaseRate*10000) / 100
+		approval.DeviationPercent = &percent
+	}
+
+	if math.Abs(deviation) < s.rules.MinDeviationAmount {
+		return nil, nil
+	}
+	if approval.DeviationPercent != nil && math.Abs(*approval.DeviationPercent) <= s.rules.MaxDeviationPercent {
+		return nil, nil
+	}
+	approval.Reason = domain.RateExceptionAboveContract
+	if deviation < 0 {
+		approval.Reason = domain.RateExceptionBelowContract
+	}
+	return approval, nil
+}
+
+// approvalRequired is the error returned for an order held on an approval
+func approvalRequired(approval *domain.RateApproval) *apperrors.AppError {
+	return apperrors.New("RATE_APPROVAL_REQUIRED", "the order's rate needs a manager's approval before it can be made ready").
+		WithDetail("approval_id", approval.ID.String()).
+		WithDetail("status", approval.Status).
+		WithDetail("reason", approval.Reason)
+}
+
+// ============================================================================
+// QUEUE
+// ============================================================================
+
+// ListQueue lists rate approvals, oldest request first. Without a status
+// filter it lists the pending queue.
+func (s *RateApprovalService) ListQueue(ctx context.Context, filter repository.RateApprovalFilter) ([]domain.RateApproval, error) {
+	if filter.Status == "" {
+		filter.Status = domain.RateApprovalPending
+	}
+	approvals, err := s.approvalRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate approvals", err)
+	}
+	return approvals, nil
+}
+
+// GetApproval retrieves a rate approval with its comments
+func (s *RateApprovalService) GetApproval(ctx context.Context, id uuid.UUID) (*domain.RateApproval, error) {
+	approval, err := s.approvalRepo.GetByID(ctx, id)
+	if err != nil || approval == nil {
+		return nil, apperrors.NotFoundError("rate approval", id.String())
+	}
+	approval.Comments, err = s.approvalRepo.ListComments(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate approval comments", err)
+	}
+	return approval, nil
+}
+
+// ListForOrder lists the rate approvals requested for an order, newest first
+func (s *RateApprovalService) ListForOrder(ctx context.Context, orderID uuid.UUID) ([]domain.RateApproval, error) {
+	approvals, err := s.approvalRepo.ListForOrder(ctx, orderID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list rate approvals", err)
+	}
+	return approvals, nil
+}
+
+// AddComment adds a comment to a rate approval
+func (s *RateApprovalService) AddComment(ctx context.Context, id uuid.UUID, author, body string) (*domain.RateApprovalComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, apperrors.ValidationError("comment is required", "body", body)
+	}
+	approval, err := s.approvalRepo.GetByID(ctx, id)
+	if err != nil || approval == nil {
+		return nil, apperrors.NotFoundError("rate approval", id.String())
+	}
+
+	comment := &domain.RateApprovalComment{
+		ID:         uuid.New(),
+		ApprovalID: id,
+		Author:     author,
+		Body:       body,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.approvalRepo.AddComment(ctx, comment); err != nil {
+		return nil, apperrors.DatabaseError("add rate approval comment", err)
+	}
+	return comment, nil
+}
+
+// ============================================================================
+// DECISIONS
+// ============================================================================
+
+// Approve approves a pending rate and releases its order to READY if it
+// is still held at that rate. Managers cannot approve a rate they
+// requested themselves.
+func (s *RateApprovalService) Approve(ctx context.Context, id uuid.UUID, approvedBy, comment string) (*domain.RateApproval, error) {
+	return s.decide(ctx, id, domain.RateApprovalApproved, approvedBy, comment)
+}
+
+// Reject rejects a pending rate. The order stays on HOLD until it is
+// requoted or cancelled.
+func (s *RateApprovalService) Reject(ctx context.Context, id uuid.UUID, rejectedBy, comment string) (*domain.RateApproval, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, apperrors.ValidationError("a comment is required to reject a rate", "comment", comment)
+	}
+	return s.decide(ctx, id, domain.RateApprovalRejected, rejectedBy, comment)
+}
+
+func (s *RateApprovalService) decide(ctx context.Context, id uuid.UUID, decision domain.RateApprovalStatus, decidedBy, comment string) (*domain.RateApproval, error) {
+	var approval *domain.RateApproval
+	released := false
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		var err error
+		approval, err = s.approvalRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return apperrors.DatabaseError("get rate approval", err)
+		}
+		if approval == nil {
+			return apperrors.NotFoundError("rate approval", id.String())
+		}
+		if !approval.IsPending() {
+			return apperrors.InvalidStateError(string(approval.Status), string(domain.RateApprovalPending))
+		}
+		if decision == domain.RateApprovalApproved && approval.RequestedBy != "" && approval.RequestedBy == decidedBy {
+			return apperrors.New("FORBIDDEN", "a rate exception must be approved by someone other than its requester")
+		}
+
+		now := time.Now()
+		approval.Status = decision
+		approval.DecidedBy = decidedBy
+		approval.DecidedAt = &now
+		approval.UpdatedAt = now
+		if err := s.approvalRepo.Update(txCtx, approval); err != nil {
+			return apperrors.DatabaseError("update rate approval", err)
+		}
+		if comment = strings.TrimSpace(comment); comment != "" {
+			if err := s.approvalRepo.AddComment(txCtx, &domain.RateApprovalComment{
+				ID:         uuid.New(),
+				ApprovalID: approval.ID,
+				Author:     decidedBy,
+				Body:       comment,
+				Decision:   &decision,
+				CreatedAt:  now,
+			}); err != nil {
+				return apperrors.DatabaseError("add rate approval comment", err)
+			}
+		}
+
+		if decision == domain.RateApprovalApproved {
+			order, err := s.orderRepo.GetByIDForUpdate(txCtx, approval.OrderID)
+			if err != nil {
+				return apperrors.DatabaseError("get order", err)
+			}
+			// An order requoted, cancelled or released by hand since stays as it is
+			if order != nil && order.Status == domain.OrderStatusHold &&
+				order.AgreedRate != nil && approval.Covers(*order.AgreedRate) {
+				if err := s.orderRepo.UpdateStatus(txCtx, order.ID, domain.OrderStatusReady); err != nil {
+					return apperrors.DatabaseError("release order", err)
+				}
+				if err := s.publishStatusChange(txCtx, order.ID, domain.OrderStatusReady, "rate approved"); err != nil {
+					return err
+				}
+				released = true
+			}
+		}
+		return s.publish(txCtx, kafka.Topics.RateApprovalDecided, approval, map[string]interface{}{
+			"comment":        comment,
+			"order_released": released,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("Rate approval decided",
+		"approval_id", approval.ID,
+		"order_id", approval.OrderID,
+		"decision", decision,
+		"decided_by", decidedBy,
+		"order_released", released,
+	)
+	return s.GetApproval(ctx, approval.ID)
+}
+
+// ============================================================================
+// EVENTS
+// ============================================================================
+
+func (s *RateApprovalService) publish(ctx context.Context, topic string, approval *domain.RateApproval, extra map[string]interface{}) error {
+	data := map[string]interface{}{
+		"approval_id":  approval.ID.String(),
+		"order_id":     approval.OrderID.String(),
+		"order_number": approval.OrderNumber,
+		"customer_id":  approval.CustomerID.String(),
+		"quoted_rate":  approval.QuotedRate,
+		"reason":       approval.Reason,
+		"status":       approval.Status,
+		"requested_by": approval.RequestedBy,
+	}
+	if approval.ContractRate != nil {
+		data["contract_rate"] = *approval.ContractRate
+	}
+	if approval.DeviationPercent != nil {
+		data["deviation_percent"] = *approval.DeviationPercent
+	}
+	if approval.DecidedBy != "" {
+		data["decided_by"] = approval.DecidedBy
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	event := kafka.NewEvent(topic, "order-service", data)
+	if err := s.outbox.Publish(ctx, topic, event); err != nil {
+		return apperrors.DatabaseError("queue rate approval event", err)
+	}
+	return nil
+}
+
+func (s *RateApprovalService) publishStatusChange(ctx context.Context, orderID uuid.UUID, status domain.OrderStatus, reason string) error {
+	event := kafka.NewEvent(kafka.Topics.OrderStatusChanged, "order-service", map[string]interface{}{
+		"order_id":   orderID.String(),
+		"new_status": status,
+		"reason":     reason,
+	})
+	if err := s.outbox.Publish(ctx, kafka.Topics.OrderStatusChanged, event); err != nil {
+		return apperrors.DatabaseError("queue order status event", err)
+	}
+	return nil
+}
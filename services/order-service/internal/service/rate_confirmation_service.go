@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/document"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// SignatureRequest is a PDF sent to one signer through an e-signature provider
+type SignatureRequest struct {
+	Reference   string // Our document number, shown to the signer
+	Subject     string
+	Message     string
+	SignerName  string
+	SignerEmail string
+	Filename    string
+	Document    []byte
+}
+
+// SignatureEvent is a provider status callback in our terms
+type SignatureEvent struct {
+	EnvelopeID string
+	Status     domain.RateConfirmationStatus // SIGNED, DECLINED or VOIDED; empty for events we do not track
+	Reason     string
+	OccurredAt time.Time
+}
+
+// SignatureProvider sends documents for e-signature and reports back on them
+// (DocuSign, Dropbox Sign)
+type SignatureProvider interface {
+	Name() string
+	Send(ctx context.Context, req SignatureRequest) (envelopeID string, err error)
+	// DownloadSigned returns the completed PDF with the provider's audit trail
+	DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error)
+	Void(ctx context.Context, envelopeID, reason string) error
+	// ParseCallback verifies a status callback posted by the provider and decodes it
+	ParseCallback(header http.Header, body []byte) (*SignatureEvent, error)
+}
+
+// RateConfirmationService generates rate confirmations for brokered orders
+// from the agreed tender rate, sends them for e-signature and stores the
+// signed copies against the order
+type RateConfirmationService struct {
+	rateConRepo   repository.RateConfirmationRepository
+	tenderRepo    repository.LoadTenderRepository
+	brokerRepo    repository.BrokerRepository
+	orderRepo     repository.OrderRepository
+	locationRepo  repository.LocationRepository
+	documents     *document.Generator
+	signer        SignatureProvider
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewRateConfirmationService creates a new rate confirmation service
+func NewRateConfirmationService(
+	rateConRepo repository.RateConfirmationRepository,
+	tenderRepo repository.LoadTenderRepository,
+	brokerRepo repository.BrokerRepository,
+	orderRepo repository.OrderRepository,
+	locationRepo repository.LocationRepository,
+	documents *document.Generator,
+	signer SignatureProvider,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *RateConfirmationService {
+	return &RateConfirmationService{
+		rateConRepo:   rateConRepo,
+		tenderRepo:    tenderRepo,
+		brokerRepo:    brokerRepo,
+		orderRepo:     orderRepo,
+		locationRepo:  locationRepo,
+		documents:     documents,
+		signer:        signer,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// SendRateConfirmationInput contains input for sending a rate confirmation
+type SendRateConfirmationInput struct {
+	TenantID    string         `json:"-"`
+	Carrier     document.Party `json:"carrier"` // Our company as it signs the load
+	MCNumber    string         `json:"mc_number"`
+	DOTNumber   string         `json:"dot_number"`
+	SignerName  string         `json:"signer_name"`  // Defaults to the broker
+	SignerEmail string         `json:"signer_email"` // Defaults to the broker's rate confirmation address
+	SentBy      string         `json:"-"`
+}
+
+// SendRateConfirmation renders the rate confirmation for a brokered order at
+// its agreed rate and sends it to the broker for signature. A confirmation
+// still out for signature is voided and replaced.
+func (s *RateConfirmationService) SendRateConfirmation(ctx context.Context, orderID uuid.UUID, input SendRateConfirmationInput) (*domain.RateConfirmation, error) {
+	if input.Carrier.Name == "" {
+		return nil, apperrors.ValidationError("carrier is required", "carrier", nil)
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order == nil {
+		return nil, apperrors.NotFoundError("order", orderID.String())
+	}
+	if order.TenderID == nil || order.AgreedRate == nil {
+		return nil, apperrors.ValidationError("order was not booked from a broker tender", "order_id", orderID)
+	}
+
+	tender, err := s.tenderRepo.GetByID(ctx, *order.TenderID)
+	if err != nil || tender == nil {
+		return nil, apperrors.NotFoundError("tender", order.TenderID.String())
+	}
+	broker, err := s.brokerRepo.GetByID(ctx, tender.BrokerID)
+	if err != nil || broker == nil {
+		return nil, apperrors.NotFoundError("broker", tender.BrokerID.String())
+	}
+
+	signerName := firstNonEmpty(input.SignerName, broker.Name)
+	signerEmail := firstNonEmpty(input.SignerEmail, broker.RateConfirmationEmail)
+	if signerEmail == "" {
+		return nil, apperrors.ValidationError("signer email is required when the broker has no rate confirmation address", "signer_email", nil)
+	}
+
+	existing, err := s.rateConRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate confirmations", err)
+	}
+	number := "RC-" + order.OrderNumber
+	if len(existing) > 0 {
+		number = fmt.Sprintf("%s-%d", number, len(existing)+1)
+	}
+
+	rules := &s.businessRules.RateConfirmation
+	rate := *order.AgreedRate
+	doc, err := s.documents.Render(ctx, document.Request{
+		TenantID: input.TenantID,
+		Kind:     document.KindRateConfirmation,
+		Title:    "Rate Confirmation",
+		Filename: strings.ToLower(number),
+		Data: document.RateConfirmationData{
+			Number:       number,
+			Date:         time.Now(),
+			Reference:    tender.BrokerReference,
+			Carrier:      input.Carrier,
+			Broker:       document.Party{Name: broker.Name, Email: signerEmail},
+			MCNumber:     input.MCNumber,
+			DOTNumber:    input.DOTNumber,
+			Equipment:    strings.TrimSpace(fmt.Sprintf("%s' %s", tender.ContainerSize, tender.ContainerType)),
+			Stops:        s.tenderStops(ctx, order, tender),
+			Charges:      []document.RateCharge{{Description: rules.ChargeDescription, Amount: rate}},
+			Total:        rate,
+			PaymentTerms: rules.PaymentTerms,
+			Terms:        rules.Terms,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one confirmation may be out for signature at a time
+	for i := range existing {
+		if existing[i].IsOpen() {
+			s.void(ctx, &existing[i], "replaced by "+number)
+		}
+	}
+
+	envelopeID, err := s.signer.Send(ctx, SignatureRequest{
+		Reference:   number,
+		Subject:     fmt.Sprintf(rules.SignatureSubject, number),
+		Message:     fmt.Sprintf("Please sign to confirm the agreed rate for load %s.", tender.BrokerReference),
+		SignerName:  signerName,
+		SignerEmail: signerEmail,
+		Filename:    doc.Filename,
+		Document:    doc.Data,
+	})
+	if err != nil {
+		return nil, apperrors.ExternalServiceError(s.signer.Name(), err)
+	}
+
+	now := time.Now()
+	rc := &domain.RateConfirmation{
+		ID:          uuid.New(),
+		OrderID:     order.ID,
+		TenderID:    tender.ID,
+		Number:      number,
+		Rate:        rate,
+		Currency:    tender.Currency,
+		Provider:    s.signer.Name(),
+		EnvelopeID:  envelopeID,
+		SignerName:  signerName,
+		SignerEmail: signerEmail,
+		Status:      domain.RateConfirmationSent,
+		Filename:    doc.Filename,
+		Document:    doc.Data,
+		SentBy:      input.SentBy,
+		SentAt:      now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.rateConRepo.Create(ctx, rc); err != nil {
+		return nil, apperrors.DatabaseError("create rate confirmation", err)
+	}
+
+	s.publish(ctx, kafka.Topics.RateConfirmationSent, rc, order)
+	s.logger.Infow("Rate confirmation sent for signature",
+		"order_number", order.OrderNumber,
+		"number", number,
+		"provider", rc.Provider,
+		"signer", signerEmail,
+		"rate", rate,
+	)
+	return rc, nil
+}
+
+// tenderStops lists the pickup and delivery of a brokered order, preferring
+// our location records over the addresses the broker sent
+func (s *RateConfirmationService) tenderStops(ctx context.Context, order *domain.Order, tender *domain.LoadTender) []document.Stop {
+	stops := []document.Stop{
+		{Sequence: 1, Activity: "PICKUP", Address: tender.PickupAddress, AppointmentTime: order.RequestedPickupDate, ContainerNumber: tender.ContainerNumber},
+		{Sequence: 2, Activity: "DELIVERY", Address: tender.DeliveryAddress, AppointmentTime: order.RequestedDeliveryDate, ContainerNumber: tender.ContainerNumber},
+	}
+	for i, locationID := range []*uuid.UUID{order.PickupLocationID, order.DeliveryLocationID} {
+		if locationID == nil {
+			continue
+		}
+		if location, _ := s.locationRepo.GetByID(ctx, *locationID); location != nil {
+			stops[i].LocationName = location.Name
+			stops[i].Address = strings.Trim(fmt.Sprintf("%s, %s, %s %s", location.Address, location.City, location.State, location.Zip), ", ")
+		}
+	}
+	return stops
+}
+
+// HandleSignatureCallback records a status callback posted by the e-signature provider
+func (s *RateConfirmationService) HandleSignatureCallback(ctx context.Context, header http.Header, body []byte) error {
+	event, err := s.signer.ParseCallback(header, body)
+	if err != nil {
+		return apperrors.ValidationError("invalid signature callback", "body", err.Error())
+	}
+	if event == nil || event.Status == "" {
+		return nil
+	}
+	_, err = s.RecordSignatureEvent(ctx, *event)
+	return err
+}
+
+// RecordSignatureEvent applies a signature status to the rate confirmation
+// sent as the envelope. Signing stores the signed copy. Events for
+// confirmations no longer out for signature are ignored, so provider
+// retries are harmless.
+func (s *RateConfirmationService) RecordSignatureEvent(ctx context.Context, event SignatureEvent) (*domain.RateConfirmation, error) {
+	rc, err := s.rateConRepo.GetByEnvelopeID(ctx, s.signer.Name(), event.EnvelopeID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate confirmation", err)
+	}
+	if rc == nil {
+		return nil, apperrors.NotFoundError("rate confirmation envelope", event.EnvelopeID)
+	}
+	if !rc.IsOpen() {
+		return rc, nil
+	}
+
+	now := time.Now()
+	switch event.Status {
+	case domain.RateConfirmationSigned:
+		signed, err := s.signer.DownloadSigned(ctx, rc.EnvelopeID)
+		if err != nil {
+			return nil, apperrors.ExternalServiceError(s.signer.Name(), err)
+		}
+		signedAt := event.OccurredAt
+		if signedAt.IsZero() {
+			signedAt = now
+		}
+		rc.SignedDocument = signed
+		rc.SignedAt = &signedAt
+	case domain.RateConfirmationDeclined, domain.RateConfirmationVoided:
+	default:
+		return nil, apperrors.ValidationError("unsupported signature status", "status", event.Status)
+	}
+	rc.Status = event.Status
+	rc.StatusReason = event.Reason
+	rc.UpdatedAt = now
+
+	if err := s.rateConRepo.Update(ctx, rc); err != nil {
+		return nil, apperrors.DatabaseError("update rate confirmation", err)
+	}
+
+	order, _ := s.orderRepo.GetByID(ctx, rc.OrderID)
+	if rc.Status == domain.RateConfirmationSigned {
+		s.publish(ctx, kafka.Topics.RateConfirmationSigned, rc, order)
+	}
+	s.logger.Infow("Rate confirmation updated",
+		"number", rc.Number,
+		"status", rc.Status,
+		"reason", rc.StatusReason,
+	)
+	return rc, nil
+}
+
+// GetRateConfirmations returns an order's rate confirmations, newest first
+func (s *RateConfirmationService) GetRateConfirmations(ctx context.Context, orderID uuid.UUID) ([]domain.RateConfirmation, error) {
+	confirmations, err := s.rateConRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get rate confirmations", err)
+	}
+	return confirmations, nil
+}
+
+// GetRateConfirmationDocument returns the signed copy of a rate confirmation,
+// or the PDF as sent while it is unsigned
+func (s *RateConfirmationService) GetRateConfirmationDocument(ctx context.Context, id uuid.UUID) (*document.Document, error) {
+	rc, err := s.rateConRepo.GetByID(ctx, id)
+	if err != nil || rc == nil {
+		return nil, apperrors.NotFoundError("rate confirmation", id.String())
+	}
+
+	doc := &document.Document{
+		Kind:        document.KindRateConfirmation,
+		Filename:    rc.Filename,
+		ContentType: "application/pdf",
+		Data:        rc.Document,
+		GeneratedAt: rc.SentAt,
+	}
+	if rc.SignedDocument != nil {
+		doc.Filename = strings.TrimSuffix(rc.Filename, ".pdf") + "-signed.pdf"
+		doc.Data = rc.SignedDocument
+		doc.GeneratedAt = *rc.SignedAt
+	}
+	return doc, nil
+}
+
+// void withdraws an open confirmation from the provider; failures are logged
+// since the replacement supersedes it either way
+func (s *RateConfirmationService) void(ctx context.Context, rc *domain.RateConfirmation, reason string) {
+	if err := s.signer.Void(ctx, rc.EnvelopeID, reason); err != nil {
+		s.logger.Warnw("Failed to void rate confirmation envelope",
+			"number", rc.Number,
+			"envelope_id", rc.EnvelopeID,
+			"error", err,
+		)
+	}
+	rc.Status = domain.RateConfirmationVoided
+	rc.StatusReason = reason
+	rc.UpdatedAt = time.Now()
+	if err := s.rateConRepo.Update(ctx, rc); err != nil {
+		s.logger.Warnw("Failed to mark rate confirmation voided", "number", rc.Number, "error", err)
+	}
+}
+
+func (s *RateConfirmationService) publish(ctx context.Context, topic string, rc *domain.RateConfirmation, order *domain.Order) {
+	data := map[string]interface{}{
+		"rate_confirmation_id": rc.ID.String(),
+		"order_id":             rc.OrderID.String(),
+		"tender_id":            rc.TenderID.String(),
+		"number":               rc.Number,
+		"rate":                 rc.Rate,
+		"status":               string(rc.Status),
+		"provider":             rc.Provider,
+	}
+	if order != nil {
+		data["order_number"] = order.OrderNumber
+	}
+	event := kafka.NewEvent(topic, "order-service", data)
+	_ = s.eventProducer.Publish(ctx, topic, event)
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
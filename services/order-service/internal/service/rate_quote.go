@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// RateQuoteQuery describes a move to quote, with the accessorials expected on it
+type RateQuoteQuery struct {
+	ContractRateQuery
+	Accessorials []domain.QuoteAccessorial
+}
+
+// RateQuoteSource prices a move from the customer's rate tables. QuoteRate
+// returns nil, nil when no contract rate covers the move.
+type RateQuoteSource interface {
+	QuoteRate(ctx context.Context, query RateQuoteQuery) (*domain.RateQuote, error)
+}
+
+// GetRateQuoteInput describes an order about to be created. The customer
+// and equipment come from the container when one is given.
+type GetRateQuoteInput struct {
+	CustomerID         uuid.UUID
+	ContainerID        uuid.UUID
+	PickupLocationID   *uuid.UUID
+	DeliveryLocationID *uuid.UUID
+	ContainerSize      domain.ContainerSize
+	ContainerType      domain.ContainerType
+	PickupDate         time.Time // When the move is priced; default now
+	Accessorials       []domain.QuoteAccessorial
+}
+
+// GetRateQuote returns the revenue the customer's contract rates put on a
+// move, so dispatch sees what an order is expected to bill as it is created
+func (s *OrderService) GetRateQuote(ctx context.Context, input GetRateQuoteInput) (*domain.RateQuote, error) {
+	query := RateQuoteQuery{
+		ContractRateQuery: ContractRateQuery{
+			CustomerID:    input.CustomerID,
+			OriginID:      input.PickupLocationID,
+			DestinationID: input.DeliveryLocationID,
+			ContainerSize: input.ContainerSize,
+			ContainerType: input.ContainerType,
+			At:            input.PickupDate,
+		},
+		Accessorials: input.Accessorials,
+	}
+	if query.At.IsZero() {
+		query.At = time.Now()
+	}
+
+	if input.ContainerID != uuid.Nil {
+		container, err := s.containerRepo.GetByID(ctx, input.ContainerID)
+		if err != nil || container == nil {
+			return nil, apperrors.NotFoundError("container", input.ContainerID.String())
+		}
+		if query.ContainerSize == "" {
+			query.ContainerSize = container.Size
+		}
+		if query.ContainerType == "" {
+			query.ContainerType = container.Type
+		}
+		if query.CustomerID == uuid.Nil {
+			shipment, err := s.shipmentRepo.GetByID(ctx, container.ShipmentID)
+			if err != nil || shipment == nil {
+				return nil, apperrors.NotFoundError("shipment", container.ShipmentID.String())
+			}
+			query.CustomerID = shipment.CustomerID
+		}
+	}
+	if query.CustomerID == uuid.Nil {
+		return nil, apperrors.ValidationError("customer_id or container_id is required", "customer_id", nil)
+	}
+
+	quote, err := s.rateQuotes.QuoteRate(ctx, query)
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("billing-service", err)
+	}
+	if quote == nil {
+		return nil, apperrors.NotFoundError("contract rate", query.CustomerID.String())
+	}
+	return quote, nil
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// maxEventTrips is how many of the most recent trips contribute
+	// tracking milestones to a resolution's event timeline
+	maxEventTrips = 3
+	// maxRecentEvents caps the merged event timeline
+	maxRecentEvents = 50
+)
+
+// containerNumberPattern matches the ISO 6346 layout without checking the
+// check digit, so a mistyped number still resolves to the trips that used it
+var containerNumberPattern = regexp.MustCompile(`^[A-Z]{4}[UJZ][0-9]{7}$`)
+
+// TripQuery identifies the dispatch trips to look up. One field is set.
+type TripQuery struct {
+	ContainerNumber string
+	TripNumber      string
+	OrderID         *uuid.UUID
+}
+
+// TripDirectory finds dispatch trips, with stops and driver, newest first
+type TripDirectory interface {
+	LookupTrips(ctx context.Context, query TripQuery) ([]domain.ResolvedTrip, error)
+}
+
+// LocationTracker reads live driver positions and trip milestones.
+// GetDriverLocation returns nil, nil when the driver has never reported.
+type LocationTracker interface {
+	GetDriverLocation(ctx context.Context, driverID uuid.UUID) (*domain.LiveLocation, error)
+	GetTripMilestones(ctx context.Context, tripID uuid.UUID) ([]domain.TripMilestone, error)
+}
+
+// ResolutionService answers "where is this container?" by resolving a
+// container, booking, bill of lading, order or trip number to everything
+// linked to it, fanning out to dispatch and tracking for trips, live
+// location and milestones.
+type ResolutionService struct {
+	shipmentRepo  repository.ShipmentRepository
+	containerRepo repository.ContainerRepository
+	orderRepo     repository.OrderRepository
+	trips         TripDirectory
+	tracker       LocationTracker
+	logger        *logger.Logger
+}
+
+// NewResolutionService creates a new identifier resolution service
+func NewResolutionService(
+	shipmentRepo repository.ShipmentRepository,
+	containerRepo repository.ContainerRepository,
+	orderRepo repository.OrderRepository,
+	trips TripDirectory,
+	tracker LocationTracker,
+	log *logger.Logger,
+) *ResolutionService {
+	return &ResolutionService{
+		shipmentRepo:  shipmentRepo,
+		containerRepo: containerRepo,
+		orderRepo:     orderRepo,
+		trips:         trips,
+		tracker:       tracker,
+		logger:        log,
+	}
+}
+
+// resolution accumulates linked records while an identifier is resolved,
+// dropping duplicates reached along different links
+type resolution struct {
+	mu         sync.Mutex
+	result     *domain.Resolution
+	shipments  map[uuid.UUID]bool
+	containers map[uuid.UUID]bool
+	orders     map[uuid.UUID]bool
+	trips      map[uuid.UUID]bool
+}
+
+// Resolve returns the shipments, containers, orders and trips linked to
+// identifier together with the active trip, live location and recent
+// events. Container numbers are recognised by format; anything else is
+// tried as an order number, then a shipment reference, booking or bill of
+// lading, then a trip number. Dispatch or tracking failures are reported in
+// the resolution's SourceErrors rather than failing the request.
+func (s *ResolutionService) Resolve(ctx context.Context, identifier string) (*domain.Resolution, error) {
+	query := strings.TrimSpace(identifier)
+	if query == "" {
+		return nil, apperrors.ValidationError("identifier is required", "q", identifier)
+	}
+
+	r := &resolution{
+		result: &domain.Resolution{
+			Query:        query,
+			Shipments:    []*domain.Shipment{},
+			Containers:   []*domain.Container{},
+			Orders:       []*domain.Order{},
+			Trips:        []domain.ResolvedTrip{},
+			RecentEvents: []domain.ResolvedEvent{},
+			SourceErrors: map[string]string{},
+		},
+		shipments:  map[uuid.UUID]bool{},
+		containers: map[uuid.UUID]bool{},
+		orders:     map[uuid.UUID]bool{},
+		trips:      map[uuid.UUID]bool{},
+	}
+
+	if upper := strings.ToUpper(query); containerNumberPattern.MatchString(upper) {
+		r.result.Kind = domain.IdentifierContainer
+		r.result.Query = upper
+		s.resolveContainer(ctx, r, upper)
+	} else if order, err := s.orderRepo.GetByOrderNumber(ctx, query); err == nil && order != nil {
+		r.result.Kind = domain.IdentifierOrder
+		s.resolveOrder(ctx, r, order)
+	} else if shipments, err := s.shipmentRepo.FindByIdentifier(ctx, query); err != nil {
+		return nil, apperrors.DatabaseError("find shipments", err)
+	} else if len(shipments) > 0 {
+		r.result.Kind = domain.IdentifierShipment
+		s.resolveShipments(ctx, r, shipments)
+	} else {
+		r.result.Kind = domain.IdentifierTrip
+		s.resolveTrip(ctx, r, query)
+	}
+
+	res := r.result
+	if len(res.Shipments) == 0 && len(res.Containers) == 0 && len(res.Orders) == 0 && len(res.Trips) == 0 {
+		if len(res.SourceErrors) > 0 {
+			return nil, apperrors.ExternalServiceError(domain.EventSourceDispatch, errors.New(joinSourceErrors(res.SourceErrors)))
+		}
+		return nil, apperrors.NotFoundError("identifier", query)
+	}
+
+	s.attachLiveState(ctx, r)
+	res.ResolvedAt = time.Now()
+	if len(res.SourceErrors) == 0 {
+		res.SourceErrors = nil
+	}
+	return res, nil
+}
+
+// resolveContainer links a container with its shipment, order and trips.
+// Trips are looked up even when the container is unknown here, since a
+// dispatcher may have keyed it straight onto a trip.
+func (s *ResolutionService) resolveContainer(ctx context.Context, r *resolution, containerNumber string) {
+	if container, err := s.containerRepo.GetByNumber(ctx, containerNumber); err == nil && container != nil {
+		s.linkContainer(ctx, r, container)
+	}
+	s.lookupTrips(ctx, r, []TripQuery{{ContainerNumber: containerNumber}})
+}
+
+func (s *ResolutionService) resolveOrder(ctx context.Context, r *resolution, order *domain.Order) {
+	r.addOrder(order)
+	if container, err := s.containerRepo.GetByID(ctx, order.ContainerID); err == nil && container != nil {
+		s.linkContainer(ctx, r, container)
+	} else {
+		s.linkShipment(ctx, r, order.ShipmentID)
+	}
+	id := order.ID
+	s.lookupTrips(ctx, r, []TripQuery{{OrderID: &id}})
+}
+
+func (s *ResolutionService) resolveShipments(ctx context.Context, r *resolution, shipments []*domain.Shipment) {
+	var queries []TripQuery
+	for _, shipment := range shipments {
+		r.addShipment(shipment)
+		containers, err := s.containerRepo.GetByShipmentID(ctx, shipment.ID)
+		if err != nil {
+			s.logger.Warnw("Failed to load containers for resolution", "shipment_id", shipment.ID, "error", err)
+			continue
+		}
+		for _, container := range containers {
+			s.linkContainer(ctx, r, container)
+			queries = append(queries, TripQuery{ContainerNumber: container.ContainerNumber})
+		}
+	}
+	s.lookupTrips(ctx, r, queries)
+}
+
+// resolveTrip links a trip with the containers and orders on its stops
+func (s *ResolutionService) resolveTrip(ctx context.Context, r *resolution, tripNumber string) {
+	s.lookupTrips(ctx, r, []TripQuery{{TripNumber: tripNumber}})
+
+	seen := map[string]bool{}
+	for _, trip := range r.result.Trips {
+		for _, stop := range trip.Stops {
+			if stop.OrderID != nil {
+				if order, err := s.orderRepo.GetByID(ctx, *stop.OrderID); err == nil && order != nil {
+					r.addOrder(order)
+				}
+			}
+			if stop.ContainerNumber == "" || seen[stop.ContainerNumber] {
+				continue
+			}
+			seen[stop.ContainerNumber] = true
+			if container, err := s.containerRepo.GetByNumber(ctx, stop.ContainerNumber); err == nil && container != nil {
+				s.linkContainer(ctx, r, container)
+			}
+		}
+	}
+}
+
+// linkContainer adds a container with its order and shipment
+func (s *ResolutionService) linkContainer(ctx context.Context, r *resolution, container *domain.Container) {
+	if !r.addContainer(container) {
+		return
+	}
+	if order, err := s.orderRepo.GetByContainerID(ctx, container.ID); err == nil && order != nil {
+		r.addOrder(order)
+	}
+	s.linkShipment(ctx, r, container.ShipmentID)
+}
+
+func (s *ResolutionService) linkShipment(ctx context.Context, r *resolution, shipmentID uuid.UUID) {
+	if r.shipments[shipmentID] {
+		return
+	}
+	if shipment, err := s.shipmentRepo.GetByID(ctx, shipmentID); err == nil && shipment != nil {
+		r.addShipment(shipment)
+	}
+}
+
+// lookupTrips queries dispatch once per query in parallel and merges the
+// trips, newest first
+func (s *ResolutionService) lookupTrips(ctx context.Context, r *resolution, queries []TripQuery) {
+	var wg sync.WaitGroup
+	for _, query := range queries {
+		wg.Add(1)
+		go func(query TripQuery) {
+			defer wg.Done()
+			trips, err := s.trips.LookupTrips(ctx, query)
+			if err != nil {
+				r.sourceError(domain.EventSourceDispatch, err)
+				s.logger.Warnw("Dispatch trip lookup failed", "query", query, "error", err)
+				return
+			}
+			r.addTrips(trips)
+		}(query)
+	}
+	wg.Wait()
+
+	sort.SliceStable(r.result.Trips, func(i, j int) bool {
+		return tripTime(r.result.Trips[i]).After(tripTime(r.result.Trips[j]))
+	})
+}
+
+// attachLiveState sets the active trip and its driver's live location, and
+// merges order, dispatch and tracking events into the recent timeline
+func (s *ResolutionService) attachLiveState(ctx context.Context, r *resolution) {
+	res := r.result
+	for i := range res.Trips {
+		if res.Trips[i].IsActive() {
+			res.ActiveTrip = &res.Trips[i]
+			break
+		}
+	}
+
+	var wg sync.WaitGroup
+	if res.ActiveTrip != nil && res.ActiveTrip.DriverID != nil {
+		wg.Add(1)
+		go func(trip *domain.ResolvedTrip) {
+			defer wg.Done()
+			location, err := s.tracker.GetDriverLocation(ctx, *trip.DriverID)
+			if err != nil {
+				r.sourceError(domain.EventSourceTracking, err)
+				s.logger.Warnw("Live location lookup failed", "driver_id", *trip.DriverID, "error", err)
+				return
+			}
+			if location != nil && location.TripNumber == "" {
+				location.TripNumber = trip.TripNumber
+			}
+			r.mu.Lock()
+			res.LiveLocation = location
+			r.mu.Unlock()
+		}(res.ActiveTrip)
+	}
+
+	for i := 0; i < len(res.Trips) && i < maxEventTrips; i++ {
+		wg.Add(1)
+		go func(trip domain.ResolvedTrip) {
+			defer wg.Done()
+			milestones, err := s.tracker.GetTripMilestones(ctx, trip.ID)
+			if err != nil {
+				r.sourceError(domain.EventSourceTracking, err)
+				s.logger.Warnw("Trip milestone lookup failed", "trip_id", trip.ID, "error", err)
+				return
+			}
+			events := make([]domain.ResolvedEvent, 0, len(milestones))
+			for _, m := range milestones {
+				events = append(events, domain.ResolvedEvent{
+					OccurredAt:      m.OccurredAt,
+					Source:          domain.EventSourceTracking,
+					Type:            m.Type,
+					TripNumber:      trip.TripNumber,
+					ContainerNumber: m.ContainerNumber,
+					LocationName:    m.LocationName,
+					Details:         m.Source,
+				})
+			}
+			r.addEvents(events)
+		}(res.Trips[i])
+	}
+
+	r.addEvents(orderEvents(res))
+	r.addEvents(dispatchEvents(res.Trips))
+	wg.Wait()
+
+	sort.SliceStable(res.RecentEvents, func(i, j int) bool {
+		return res.RecentEvents[i].OccurredAt.After(res.RecentEvents[j].OccurredAt)
+	})
+	if len(res.RecentEvents) > maxRecentEvents {
+		res.RecentEvents = res.RecentEvents[:maxRecentEvents]
+	}
+}
+
+// orderEvents derives timeline entries from container and order timestamps
+func orderEvents(res *domain.Resolution) []domain.ResolvedEvent {
+	var events []domain.ResolvedEvent
+	add := func(at *time.Time, eventType, containerNumber, details string) {
+		if at == nil || at.IsZero() {
+			return
+		}
+		events = append(events, domain.ResolvedEvent{
+			OccurredAt:      *at,
+			Source:          domain.EventSourceOrder,
+			Type:            eventType,
+			ContainerNumber: containerNumber,
+			Details:         details,
+		})
+	}
+
+	containerNumbers := make(map[uuid.UUID]string, len(res.Containers))
+	for _, c := range res.Containers {
+		containerNumbers[c.ID] = c.ContainerNumber
+		add(c.DischargedAt, "CONTAINER_DISCHARGED", c.ContainerNumber, "")
+		add(c.ReadyAt, "CONTAINER_AVAILABLE", c.ContainerNumber, "")
+		add(c.TerminalStatusAt, "TERMINAL_STATUS", c.ContainerNumber, c.TerminalStatus)
+		add(c.VerifiedAt, "CONTAINER_VERIFIED", c.ContainerNumber, string(c.VerificationStatus))
+	}
+	for _, o := range res.Orders {
+		created := o.CreatedAt
+		add(&created, "ORDER_CREATED", containerNumbers[o.ContainerID], o.OrderNumber)
+	}
+	return events
+}
+
+// dispatchEvents derives timeline entries from trip and stop actuals
+func dispatchEvents(trips []domain.ResolvedTrip) []domain.ResolvedEvent {
+	var events []domain.ResolvedEvent
+	add := func(at *time.Time, eventType, tripNumber, containerNumber, details string) {
+		if at == nil || at.IsZero() {
+			return
+		}
+		events = append(events, domain.ResolvedEvent{
+			OccurredAt:      *at,
+			Source:          domain.EventSourceDispatch,
+			Type:            eventType,
+			TripNumber:      tripNumber,
+			ContainerNumber: containerNumber,
+			Details:         details,
+		})
+	}
+
+	for _, trip := range trips {
+		add(trip.ActualStartTime, "TRIP_STARTED", trip.TripNumber, "", "")
+		add(trip.ActualEndTime, "TRIP_ENDED", trip.TripNumber, "", trip.Status)
+		for _, stop := range trip.Stops {
+			add(stop.ActualArrival, "STOP_ARRIVED", trip.TripNumber, stop.ContainerNumber, stop.Activity)
+			details := stop.Activity
+			if stop.FailureReason != "" {
+				details = stop.FailureReason
+			}
+			add(stop.ActualDeparture, "STOP_DEPARTED", trip.TripNumber, stop.ContainerNumber, details)
+		}
+	}
+	return events
+}
+
+// tripTime orders trips by when they ran, or were planned to
+func tripTime(trip domain.ResolvedTrip) time.Time {
+	if trip.ActualStartTime != nil {
+		return *trip.ActualStartTime
+	}
+	if trip.PlannedStartTime != nil {
+		return *trip.PlannedStartTime
+	}
+	return time.Time{}
+}
+
+func joinSourceErrors(errs map[string]string) string {
+	parts := make([]string, 0, len(errs))
+	for source, msg := range errs {
+		parts = append(parts, source+": "+msg)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+func (r *resolution) addShipment(shipment *domain.Shipment) {
+	if r.shipments[shipment.ID] {
+		return
+	}
+	r.shipments[shipment.ID] = true
+	r.result.Shipments = append(r.result.Shipments, shipment)
+}
+
+// addContainer reports whether the container was not already linked
+func (r *resolution) addContainer(container *domain.Container) bool {
+	if r.containers[container.ID] {
+		return false
+	}
+	r.containers[container.ID] = true
+	r.result.Containers = append(r.result.Containers, container)
+	return true
+}
+
+func (r *resolution) addOrder(order *domain.Order) {
+	if r.orders[order.ID] {
+		return
+	}
+	r.orders[order.ID] = true
+	r.result.Orders = append(r.result.Orders, order)
+}
+
+func (r *resolution) addTrips(trips []domain.ResolvedTrip) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, trip := range trips {
+		if r.trips[trip.ID] {
+			continue
+		}
+		r.trips[trip.ID] = true
+		r.result.Trips = append(r.result.Trips, trip)
+	}
+}
+
+func (r *resolution) addEvents(events []domain.ResolvedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.RecentEvents = append(r.result.RecentEvents, events...)
+}
+
+func (r *resolution) sourceError(source string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.SourceErrors[source] = err.Error()
+}
@@ -0,0 +1,693 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/validation"
+)
+
+// TenderUpdate is the status change sent back to a broker's webhook
+type TenderUpdate struct {
+	Event           string              `json:"event"` // tender.status_changed or order.status_changed
+	TenderID        uuid.UUID           `json:"tender_id"`
+	BrokerReference string              `json:"broker_reference"`
+	TenderStatus    domain.TenderStatus `json:"tender_status"`
+	OfferedRate     float64             `json:"offered_rate"`
+	CounterRate     *float64            `json:"counter_rate,omitempty"`
+	AgreedRate      *float64            `json:"agreed_rate,omitempty"`
+	Reason          string              `json:"reason,omitempty"`
+	OrderNumber     string              `json:"order_number,omitempty"`
+	OrderStatus     domain.OrderStatus  `json:"order_status,omitempty"`
+	OccurredAt      time.Time           `json:"occurred_at"`
+}
+
+// BrokerNotifier delivers tender and order status updates to a broker
+type BrokerNotifier interface {
+	NotifyBroker(ctx context.Context, broker *domain.Broker, update TenderUpdate) error
+}
+
+// TenderService handles broker load tenders from intake through order creation
+type TenderService struct {
+	db                 *database.DB
+	brokerRepo         repository.BrokerRepository
+	tenderRepo         repository.LoadTenderRepository
+	shipmentRepo       repository.ShipmentRepository
+	containerRepo      repository.ContainerRepository
+	orderRepo          repository.OrderRepository
+	sslRepo            repository.SteamshipLineRepository
+	notifier           BrokerNotifier
+	eventProducer      *kafka.Producer
+	outbox             *kafka.Outbox
+	logger             *logger.Logger
+	containerValidator *validation.ContainerNumberValidator
+	businessRules      *config.BusinessRules
+}
+
+// NewTenderService creates a new tender service
+func NewTenderService(
+	db *database.DB,
+	brokerRepo repository.BrokerRepository,
+	tenderRepo repository.LoadTenderRepository,
+	shipmentRepo repository.ShipmentRepository,
+	containerRepo repository.ContainerRepository,
+	orderRepo repository.OrderRepository,
+	sslRepo repository.SteamshipLineRepository,
+	notifier BrokerNotifier,
+	eventProducer *kafka.Producer,
+	outbox *kafka.Outbox,
+	log *logger.Logger,
+) *TenderService {
+	return &TenderService{
+		db:                 db,
+		brokerRepo:         brokerRepo,
+		tenderRepo:         tenderRepo,
+		shipmentRepo:       shipmentRepo,
+		containerRepo:      containerRepo,
+		orderRepo:          orderRepo,
+		sslRepo:            sslRepo,
+		notifier:           notifier,
+		eventProducer:      eventProducer,
+		outbox:             outbox,
+		logger:             log,
+		containerValidator: validation.NewContainerNumberValidator(),
+		businessRules:      config.DefaultBusinessRules(),
+	}
+}
+
+// HashBrokerAPIKey returns the stored form of a broker API key
+func HashBrokerAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateBroker resolves the broker that owns an API key
+func (s *TenderService) AuthenticateBroker(ctx context.Context, apiKey string) (*domain.Broker, error) {
+	if apiKey == "" {
+		return nil, apperrors.New("UNAUTHORIZED", "missing broker API key")
+	}
+	broker, err := s.brokerRepo.GetByAPIKeyHash(ctx, HashBrokerAPIKey(apiKey))
+	if err != nil || broker == nil || !broker.Active {
+		return nil, apperrors.New("UNAUTHORIZED", "invalid broker API key")
+	}
+	return broker, nil
+}
+
+// =============================================================================
+// BROKER SIDE
+// =============================================================================
+
+// SubmitTenderInput contains a load tendered by a broker
+type SubmitTenderInput struct {
+	BrokerReference       string               `json:"broker_reference"`
+	OrderType             domain.OrderType     `json:"order_type"`
+	ShipmentReference     string               `json:"shipment_reference"`
+	SteamshipLineCode     string               `json:"steamship_line_code"`
+	PortID                *uuid.UUID           `json:"port_id"`
+	TerminalID            *uuid.UUID           `json:"terminal_id"`
+	ContainerNumber       string               `json:"container_number"`
+	ContainerSize         domain.ContainerSize `json:"container_size"`
+	ContainerType         domain.ContainerType `json:"container_type"`
+	WeightLbs             int                  `json:"weight_lbs"`
+	IsHazmat              bool                 `json:"is_hazmat"`
+	HazmatClass           string               `json:"hazmat_class"`
+	UNNumber              string               `json:"un_number"`
+	PickupLocationID      *uuid.UUID           `json:"pickup_location_id"`
+	PickupAddress         string               `json:"pickup_address"`
+	DeliveryLocationID    *uuid.UUID           `json:"delivery_location_id"`
+	DeliveryAddress       string               `json:"delivery_address"`
+	ReturnLocationID      *uuid.UUID           `json:"return_location_id"`
+	RequestedPickupDate   *time.Time           `json:"requested_pickup_date"`
+	RequestedDeliveryDate *time.Time           `json:"requested_delivery_date"`
+	LastFreeDay           *time.Time           `json:"last_free_day"`
+	OfferedRate           float64              `json:"offered_rate"`
+	Currency              string               `json:"currency"`
+	Notes                 string               `json:"notes"`
+	ExpiresAt             *time.Time           `json:"expires_at"`
+}
+
+// SubmitTender records a load tendered by a broker for the ops team to review
+func (s *TenderService) SubmitTender(ctx context.Context, broker *domain.Broker, input SubmitTenderInput) (*domain.LoadTender, error) {
+	input.ContainerNumber = strings.ToUpper(strings.TrimSpace(input.ContainerNumber))
+	if err := s.validateTenderInput(input); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.tenderRepo.GetByBrokerReference(ctx, broker.ID, input.BrokerReference)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get tender", err)
+	}
+	if existing != nil && existing.Status != domain.TenderStatusDeclined &&
+		existing.Status != domain.TenderStatusWithdrawn && existing.Status != domain.TenderStatusExpired {
+		return nil, apperrors.ConflictError(fmt.Sprintf("load %s was already tendered (status %s)", input.BrokerReference, existing.Status))
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	now := time.Now()
+	tender := &domain.LoadTender{
+		ID:                    uuid.New(),
+		BrokerID:              broker.ID,
+		BrokerReference:       input.BrokerReference,
+		OrderType:             input.OrderType,
+		ShipmentReference:     input.ShipmentReference,
+		SteamshipLineCode:     strings.ToUpper(input.SteamshipLineCode),
+		PortID:                input.PortID,
+		TerminalID:            input.TerminalID,
+		ContainerNumber:       input.ContainerNumber,
+		ContainerSize:         input.ContainerSize,
+		ContainerType:         input.ContainerType,
+		WeightLbs:             input.WeightLbs,
+		IsHazmat:              input.IsHazmat,
+		HazmatClass:           input.HazmatClass,
+		UNNumber:              input.UNNumber,
+		PickupLocationID:      input.PickupLocationID,
+		PickupAddress:         input.PickupAddress,
+		DeliveryLocationID:    input.DeliveryLocationID,
+		DeliveryAddress:       input.DeliveryAddress,
+		ReturnLocationID:      input.ReturnLocationID,
+		RequestedPickupDate:   input.RequestedPickupDate,
+		RequestedDeliveryDate: input.RequestedDeliveryDate,
+		LastFreeDay:           input.LastFreeDay,
+		OfferedRate:           input.OfferedRate,
+		Currency:              currency,
+		Status:                domain.TenderStatusTendered,
+		Notes:                 input.Notes,
+		ExpiresAt:             input.ExpiresAt,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+
+	if err := s.tenderRepo.Create(ctx, tender); err != nil {
+		return nil, apperrors.DatabaseError("create tender", err)
+	}
+
+	s.publishTender(ctx, tender)
+	s.logger.Infow("Load tender received",
+		"tender_id", tender.ID,
+		"broker", broker.Name,
+		"broker_reference", tender.BrokerReference,
+		"container", tender.ContainerNumber,
+		"offered_rate", tender.OfferedRate,
+	)
+
+	return tender, nil
+}
+
+// GetBrokerTender returns a tender owned by the broker
+func (s *TenderService) GetBrokerTender(ctx context.Context, broker *domain.Broker, tenderID uuid.UUID) (*domain.LoadTender, error) {
+	tender, err := s.tenderRepo.GetByID(ctx, tenderID)
+	if err != nil || tender == nil || tender.BrokerID != broker.ID {
+		return nil, apperrors.NotFoundError("tender", tenderID.String())
+	}
+	return tender, nil
+}
+
+// ListBrokerTenders returns the broker's tenders, optionally by status
+func (s *TenderService) ListBrokerTenders(ctx context.Context, broker *domain.Broker, status domain.TenderStatus) ([]domain.LoadTender, error) {
+	tenders, err := s.tenderRepo.List(ctx, repository.TenderFilter{BrokerID: &broker.ID, Status: status})
+	if err != nil {
+		return nil, apperrors.DatabaseError("list tenders", err)
+	}
+	return tenders, nil
+}
+
+// WithdrawTender lets a broker pull back a tender that has not been accepted
+func (s *TenderService) WithdrawTender(ctx context.Context, broker *domain.Broker, tenderID uuid.UUID, reason string) (*domain.LoadTender, error) {
+	tender, err := s.GetBrokerTender(ctx, broker, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, tender, domain.TenderStatusWithdrawn, reason, broker.Name)
+}
+
+// AcceptCounterOffer books a tender at the rate the ops team countered with
+func (s *TenderService) AcceptCounterOffer(ctx context.Context, broker *domain.Broker, tenderID uuid.UUID) (*domain.LoadTender, error) {
+	tender, err := s.GetBrokerTender(ctx, broker, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if tender.Status != domain.TenderStatusCountered || tender.CounterRate == nil {
+		return nil, apperrors.InvalidStateError(string(tender.Status), string(domain.TenderStatusCountered))
+	}
+	return s.accept(ctx, tenderID, *tender.CounterRate, AcceptTenderInput{AcceptedBy: broker.Name})
+}
+
+// =============================================================================
+// OPS SIDE
+// =============================================================================
+
+// ListTenders returns tenders for the ops review queue
+func (s *TenderService) ListTenders(ctx context.Context, filter repository.TenderFilter) ([]domain.LoadTender, error) {
+	tenders, err := s.tenderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list tenders", err)
+	}
+	return tenders, nil
+}
+
+// GetTender returns a tender by ID
+func (s *TenderService) GetTender(ctx context.Context, tenderID uuid.UUID) (*domain.LoadTender, error) {
+	tender, err := s.tenderRepo.GetByID(ctx, tenderID)
+	if err != nil || tender == nil {
+		return nil, apperrors.NotFoundError("tender", tenderID.String())
+	}
+	return tender, nil
+}
+
+// AcceptTenderInput fills in booking details the broker could not know,
+// such as our location and customer records
+type AcceptTenderInput struct {
+	CustomerID         *uuid.UUID `json:"customer_id"` // Defaults to the broker's billing customer
+	SteamshipLineID    *uuid.UUID `json:"steamship_line_id"`
+	PortID             *uuid.UUID `json:"port_id"`
+	TerminalID         *uuid.UUID `json:"terminal_id"`
+	PickupLocationID   *uuid.UUID `json:"pickup_location_id"`
+	DeliveryLocationID *uuid.UUID `json:"delivery_location_id"`
+	ReturnLocationID   *uuid.UUID `json:"return_location_id"`
+	AcceptedBy         string     `json:"accepted_by"`
+}
+
+// AcceptTender accepts a tender at the broker's offered rate and creates the order
+func (s *TenderService) AcceptTender(ctx context.Context, tenderID uuid.UUID, input AcceptTenderInput) (*domain.LoadTender, error) {
+	tender, err := s.GetTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.accept(ctx, tenderID, tender.OfferedRate, input)
+}
+
+// DeclineTender turns a tender down
+func (s *TenderService) DeclineTender(ctx context.Context, tenderID uuid.UUID, reason, declinedBy string) (*domain.LoadTender, error) {
+	if reason == "" {
+		return nil, apperrors.ValidationError("decline reason is required", "reason", reason)
+	}
+	tender, err := s.GetTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, tender, domain.TenderStatusDeclined, reason, declinedBy)
+}
+
+// CounterTender proposes a different rate back to the broker
+func (s *TenderService) CounterTender(ctx context.Context, tenderID uuid.UUID, rate float64, notes, counteredBy string) (*domain.LoadTender, error) {
+	if rate <= 0 {
+		return nil, apperrors.ValidationError("counter rate must be positive", "rate", rate)
+	}
+	tender, err := s.GetTender(ctx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	tender.CounterRate = &rate
+	return s.respond(ctx, tender, domain.TenderStatusCountered, notes, counteredBy)
+}
+
+// respond moves an open tender to a status that does not create an order
+func (s *TenderService) respond(ctx context.Context, tender *domain.LoadTender, status domain.TenderStatus, reason, by string) (*domain.LoadTender, error) {
+	now := time.Now()
+	if !tender.IsOpen(now) {
+		return nil, apperrors.InvalidStateError(string(tender.Status), "TENDERED or COUNTERED")
+	}
+
+	tender.Status = status
+	tender.StatusReason = reason
+	tender.RespondedBy = by
+	tender.RespondedAt = &now
+	tender.UpdatedAt = now
+	if err := s.tenderRepo.Update(ctx, tender); err != nil {
+		return nil, apperrors.DatabaseError("update tender", err)
+	}
+
+	s.publishTender(ctx, tender)
+	s.notify(ctx, tender, TenderUpdate{Event: "tender.status_changed", Reason: reason})
+
+	s.logger.Infow("Load tender updated",
+		"tender_id", tender.ID,
+		"status", tender.Status,
+		"by", by,
+	)
+	return tender, nil
+}
+
+// accept books the tender's shipment, container and order at the agreed rate
+func (s *TenderService) accept(ctx context.Context, tenderID uuid.UUID, rate float64, input AcceptTenderInput) (*domain.LoadTender, error) {
+	var tender *domain.LoadTender
+	var order *domain.Order
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+
+		var err error
+		tender, err = s.tenderRepo.GetByIDForUpdate(txCtx, tenderID)
+		if err != nil || tender == nil {
+			return apperrors.NotFoundError("tender", tenderID.String())
+		}
+		now := time.Now()
+		if !tender.IsOpen(now) {
+			return apperrors.InvalidStateError(string(tender.Status), "TENDERED or COUNTERED")
+		}
+
+		broker, err := s.brokerRepo.GetByID(txCtx, tender.BrokerID)
+		if err != nil || broker == nil {
+			return apperrors.NotFoundError("broker", tender.BrokerID.String())
+		}
+
+		shipment, err := s.bookShipment(txCtx, tender, broker, input)
+		if err != nil {
+			return err
+		}
+		container, err := s.bookContainer(txCtx, tender, shipment)
+		if err != nil {
+			return err
+		}
+
+		existing, _ := s.orderRepo.GetByContainerID(txCtx, container.ID)
+		if existing != nil {
+			return apperrors.ConflictError(fmt.Sprintf("container already has order: %s", existing.OrderNumber))
+		}
+
+		orderNumber, err := s.orderRepo.GetNextOrderNumber(txCtx)
+		if err != nil {
+			return apperrors.DatabaseError("generate order number", err)
+		}
+
+		agreed := rate
+		order = &domain.Order{
+			ID:                    uuid.New(),
+			OrderNumber:           orderNumber,
+			ContainerID:           container.ID,
+			ShipmentID:            shipment.ID,
+			Type:                  tender.OrderType,
+			CustomerReference:     tender.BrokerReference,
+			PickupLocationID:      firstID(input.PickupLocationID, tender.PickupLocationID),
+			DeliveryLocationID:    firstID(input.DeliveryLocationID, tender.DeliveryLocationID),
+			ReturnLocationID:      firstID(input.ReturnLocationID, tender.ReturnLocationID),
+			RequestedPickupDate:   tender.RequestedPickupDate,
+			RequestedDeliveryDate: tender.RequestedDeliveryDate,
+			Status:                domain.OrderStatusPending,
+			BillingStatus:         domain.BillingStatusUnbilled,
+			SpecialInstructions:   tender.Notes,
+			TenderID:              &tender.ID,
+			AgreedRate:            &agreed,
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		}
+		if err := s.orderRepo.Create(txCtx, order); err != nil {
+			return apperrors.DatabaseError("create order", err)
+		}
+		order.Container = container
+
+		tender.Status = domain.TenderStatusAccepted
+		tender.AgreedRate = &agreed
+		tender.OrderID = &order.ID
+		tender.RespondedBy = input.AcceptedBy
+		tender.RespondedAt = &now
+		tender.UpdatedAt = now
+		if err := s.tenderRepo.Update(txCtx, tender); err != nil {
+			return apperrors.DatabaseError("update tender", err)
+		}
+
+		event := kafka.NewEvent(kafka.Topics.OrderCreated, "order-service", map[string]interface{}{
+			"order_id":         order.ID.String(),
+			"order_number":     order.OrderNumber,
+			"container_number": order.Container.ContainerNumber,
+			"type":             order.Type,
+			"tender_id":        tender.ID.String(),
+			"agreed_rate":      rate,
+		})
+		if err := s.outbox.Publish(txCtx, kafka.Topics.OrderCreated, event); err != nil {
+			return apperrors.DatabaseError("queue order created event", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Container.VerificationStatus == domain.ContainerVerificationPending && order.Container.VerifiedAt == nil {
+		requestContainerVerification(ctx, s.eventProducer, order.Container)
+	}
+
+	s.publishTender(ctx, tender)
+	s.notify(ctx, tender, TenderUpdate{
+		Event:       "tender.status_changed",
+		OrderNumber: order.OrderNumber,
+		OrderStatus: order.Status,
+	})
+
+	s.logger.Infow("Load tender accepted",
+		"tender_id", tender.ID,
+		"order_number", order.OrderNumber,
+		"agreed_rate", rate,
+	)
+	return tender, nil
+}
+
+// bookShipment finds the tender's BOL/booking or creates it
+func (s *TenderService) bookShipment(ctx context.Context, tender *domain.LoadTender, broker *domain.Broker, input AcceptTenderInput) (*domain.Shipment, error) {
+	shipment, err := s.shipmentRepo.GetByReferenceNumber(ctx, tender.ShipmentReference)
+	if err == nil && shipment != nil {
+		return shipment, nil
+	}
+
+	terminalID := firstID(input.TerminalID, tender.TerminalID)
+	portID := firstID(input.PortID, tender.PortID)
+	if terminalID == nil || portID == nil {
+		return nil, apperrors.ValidationError("terminal and port are required to book a new shipment", "terminal_id", nil)
+	}
+
+	sslID := input.SteamshipLineID
+	if sslID == nil {
+		ssl, err := s.sslRepo.GetByCode(ctx, tender.SteamshipLineCode)
+		if err != nil || ssl == nil {
+			return nil, apperrors.ValidationError("unknown steamship line", "steamship_line_code", tender.SteamshipLineCode)
+		}
+		sslID = &ssl.ID
+	}
+
+	customerID := broker.CustomerID
+	if input.CustomerID != nil {
+		customerID = *input.CustomerID
+	}
+
+	now := time.Now()
+	shipment = &domain.Shipment{
+		ID:                    uuid.New(),
+		Type:                  tender.ShipmentType(),
+		ReferenceNumber:       tender.ShipmentReference,
+		CustomerID:            customerID,
+		SteamshipLineID:       *sslID,
+		PortID:                *portID,
+		TerminalID:            *terminalID,
+		LastFreeDay:           tender.LastFreeDay,
+		EmptyReturnLocationID: firstID(input.ReturnLocationID, tender.ReturnLocationID),
+		Status:                domain.ShipmentStatusPending,
+		SpecialInstructions:   fmt.Sprintf("Tendered by %s, load %s", broker.Name, tender.BrokerReference),
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if err := s.shipmentRepo.Create(ctx, shipment); err != nil {
+		return nil, apperrors.DatabaseError("create shipment", err)
+	}
+	return shipment, nil
+}
+
+// bookContainer finds the tendered container or adds it to the shipment
+func (s *TenderService) bookContainer(ctx context.Context, tender *domain.LoadTender, shipment *domain.Shipment) (*domain.Container, error) {
+	container, err := s.containerRepo.GetByNumber(ctx, tender.ContainerNumber)
+	if err == nil && container != nil {
+		if container.ShipmentID != shipment.ID {
+			return nil, apperrors.ConflictError(fmt.Sprintf("container %s belongs to another shipment", tender.ContainerNumber))
+		}
+		return container, nil
+	}
+
+	state, locationType := domain.ContainerStateLoaded, domain.LocationTypeVessel
+	if tender.OrderType == domain.OrderTypeExport {
+		state, locationType = domain.ContainerStateEmpty, domain.LocationTypeYard
+	}
+
+	now := time.Now()
+	container = &domain.Container{
+		ID:                  uuid.New(),
+		ShipmentID:          shipment.ID,
+		ContainerNumber:     tender.ContainerNumber,
+		Size:                tender.ContainerSize,
+		Type:                tender.ContainerType,
+		WeightLbs:           tender.WeightLbs,
+		IsHazmat:            tender.IsHazmat,
+		HazmatClass:         tender.HazmatClass,
+		UNNumber:            tender.UNNumber,
+		IsOverweight:        tender.WeightLbs > s.businessRules.Weight.OverweightThresholdLbs,
+		IsReefer:            tender.ContainerType == domain.ContainerTypeReefer,
+		CustomsStatus:       domain.CustomsStatusPending,
+		CurrentState:        state,
+		CurrentLocationType: locationType,
+		VerificationStatus:  domain.ContainerVerificationPending,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.containerRepo.Create(ctx, container); err != nil {
+		return nil, apperrors.DatabaseError("create container", err)
+	}
+	return container, nil
+}
+
+// =============================================================================
+// STATUS FEEDBACK
+// =============================================================================
+
+// orderStatusPayload is the data of an orders.order.status_changed event
+type orderStatusPayload struct {
+	OrderID   string `json:"order_id"`
+	NewStatus string `json:"new_status"`
+	Reason    string `json:"reason"`
+}
+
+// HandleOrderStatusChanged is a kafka.Handler that forwards status changes
+// on tendered orders to the broker
+func (s *TenderService) HandleOrderStatusChanged(ctx context.Context, event *kafka.Event) error {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload orderStatusPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("unmarshal order status: %w", err)
+	}
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return apperrors.ValidationError("invalid order id", "order_id", payload.OrderID)
+	}
+
+	tender, err := s.tenderRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return apperrors.DatabaseError("get tender by order", err)
+	}
+	if tender == nil {
+		return nil // Not a brokered load
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order == nil {
+		return apperrors.NotFoundError("order", payload.OrderID)
+	}
+
+	s.notify(ctx, tender, TenderUpdate{
+		Event:       "order.status_changed",
+		Reason:      payload.Reason,
+		OrderNumber: order.OrderNumber,
+		OrderStatus: domain.OrderStatus(payload.NewStatus),
+		OccurredAt:  event.Time,
+	})
+	return nil
+}
+
+// notify sends an update to the tender's broker; failures are logged, not returned
+func (s *TenderService) notify(ctx context.Context, tender *domain.LoadTender, update TenderUpdate) {
+	broker, err := s.brokerRepo.GetByID(ctx, tender.BrokerID)
+	if err != nil || broker == nil || broker.WebhookURL == "" {
+		return
+	}
+
+	update.TenderID = tender.ID
+	update.BrokerReference = tender.BrokerReference
+	update.TenderStatus = tender.Status
+	update.OfferedRate = tender.OfferedRate
+	update.CounterRate = tender.CounterRate
+	update.AgreedRate = tender.AgreedRate
+	if update.OccurredAt.IsZero() {
+		update.OccurredAt = time.Now()
+	}
+
+	if err := s.notifier.NotifyBroker(ctx, broker, update); err != nil {
+		s.logger.Warnw("Broker webhook delivery failed",
+			"broker", broker.Name,
+			"tender_id", tender.ID,
+			"event", update.Event,
+			"error", err,
+		)
+	}
+}
+
+func (s *TenderService) publishTender(ctx context.Context, tender *domain.LoadTender) {
+	data := map[string]interface{}{
+		"tender_id":        tender.ID.String(),
+		"broker_id":        tender.BrokerID.String(),
+		"broker_reference": tender.BrokerReference,
+		"container_number": tender.ContainerNumber,
+		"status":           string(tender.Status),
+		"offered_rate":     tender.OfferedRate,
+	}
+	if tender.AgreedRate != nil {
+		data["agreed_rate"] = *tender.AgreedRate
+	}
+	if tender.OrderID != nil {
+		data["order_id"] = tender.OrderID.String()
+	}
+	event := kafka.NewEvent(kafka.Topics.TenderStatusChanged, "order-service", data)
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.TenderStatusChanged, event)
+}
+
+func (s *TenderService) validateTenderInput(input SubmitTenderInput) error {
+	if input.BrokerReference == "" {
+		return apperrors.ValidationError("broker reference is required", "broker_reference", input.BrokerReference)
+	}
+	if input.ShipmentReference == "" {
+		return apperrors.ValidationError("BOL or booking number is required", "shipment_reference", input.ShipmentReference)
+	}
+	switch input.OrderType {
+	case domain.OrderTypeImport, domain.OrderTypeExport:
+	default:
+		return apperrors.ValidationError("order type must be IMPORT or EXPORT", "order_type", input.OrderType)
+	}
+	if err := s.containerValidator.Validate(input.ContainerNumber); err != nil {
+		return err
+	}
+	switch input.ContainerSize {
+	case domain.ContainerSize20, domain.ContainerSize40, domain.ContainerSize45:
+	default:
+		return apperrors.ValidationError("invalid container size", "container_size", input.ContainerSize)
+	}
+	if input.OfferedRate <= 0 {
+		return apperrors.ValidationError("offered rate must be positive", "offered_rate", input.OfferedRate)
+	}
+	if input.PickupLocationID == nil && input.PickupAddress == "" {
+		return apperrors.ValidationError("pickup location or address is required", "pickup_address", nil)
+	}
+	if input.DeliveryLocationID == nil && input.DeliveryAddress == "" {
+		return apperrors.ValidationError("delivery location or address is required", "delivery_address", nil)
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return apperrors.ValidationError("expiry must be in the future", "expires_at", input.ExpiresAt)
+	}
+	return nil
+}
+
+// firstID returns the first non-nil ID
+func firstID(ids ...*uuid.UUID) *uuid.UUID {
+	for _, id := range ids {
+		if id != nil {
+			return id
+		}
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// VesselPositionSource looks up live AIS positions. VesselPosition returns
+// nil, nil when the provider has no position for the vessel.
+type VesselPositionSource interface {
+	VesselPosition(ctx context.Context, vessel domain.VesselRef) (*domain.VesselPosition, error)
+}
+
+// VesselTrackingService follows the vessels carrying open imports on AIS.
+// It refines each vessel's ETA from its live position and speed, moves the
+// shipments' vessel ETA when the refined one drifts past the threshold, and
+// alerts planners, since free time and so the last free day follow the
+// vessel's arrival.
+type VesselTrackingService struct {
+	db        *database.DB
+	trackRepo repository.VesselTrackingRepository
+	positions VesselPositionSource
+	outbox    *kafka.Outbox
+	rules     config.VesselTrackingRules
+	freeDays  int
+	logger    *logger.Logger
+}
+
+// NewVesselTrackingService creates a new vessel tracking service
+func NewVesselTrackingService(
+	db *database.DB,
+	trackRepo repository.VesselTrackingRepository,
+	positions VesselPositionSource,
+	outbox *kafka.Outbox,
+	rules *config.BusinessRules,
+	log *logger.Logger,
+) *VesselTrackingService {
+	return &VesselTrackingService{
+		db:        db,
+		trackRepo: trackRepo,
+		positions: positions,
+		outbox:    outbox,
+		rules:     rules.VesselTracking,
+		freeDays:  rules.Demurrage.FreeDays,
+		logger:    log,
+	}
+}
+
+// Check refines the ETA of every vessel due within the lookahead. A vessel
+// whose position cannot be fetched is skipped until the next check.
+func (s *VesselTrackingService) Check(ctx context.Context, now time.Time) error {
+	voyages, err := s.trackRepo.ListInboundVoyages(ctx, now.AddDate(0, 0, s.rules.LookaheadDays))
+	if err != nil {
+		return apperrors.DatabaseError("list inbound voyages", err)
+	}
+
+	changed := 0
+	for i := range voyages {
+		n, err := s.checkVoyage(ctx, &voyages[i], now)
+		if err != nil {
+			s.logger.Warnw("Failed to refine vessel ETA",
+				"vessel", voyages[i].VesselName,
+				"voyage", voyages[i].VoyageNumber,
+				"error", err,
+			)
+			continue
+		}
+		changed += n
+	}
+
+	s.logger.Infow("Vessel ETAs checked", "voyages", len(voyages), "shipments_updated", changed)
+	return nil
+}
+
+// checkVoyage records the vessel's position and moves the ETA of shipments
+// it has drifted from, returning how many it moved
+func (s *VesselTrackingService) checkVoyage(ctx context.Context, voyage *domain.InboundVoyage, now time.Time) (int, error) {
+	track := voyage.Track
+	if track == nil {
+		track = &domain.VesselTrack{
+			ID:           uuid.New(),
+			VesselName:   voyage.VesselName,
+			VoyageNumber: voyage.VoyageNumber,
+			TerminalID:   voyage.TerminalID,
+			CreatedAt:    now,
+		}
+	}
+	track.LastCheckedAt = now
+	track.UpdatedAt = now
+
+	position, err := s.positions.VesselPosition(ctx, domain.VesselRef{Name: track.VesselName, IMO: track.IMO, MMSI: track.MMSI})
+	if err != nil {
+		return 0, apperrors.ExternalServiceError("ais", err)
+	}
+	maxAge := time.Duration(s.rules.MaxPositionAgeHours) * time.Hour
+	if position == nil || now.Sub(position.ReportedAt) > maxAge {
+		// Keep the last known position; the ETA is not refined from stale data
+		if err := s.trackRepo.SaveTrack(ctx, track); err != nil {
+			return 0, apperrors.DatabaseError("save vessel track", err)
+		}
+		return 0, nil
+	}
+
+	eta, source, distance, ok := s.refineETA(position, voyage.Latitude, voyage.Longitude, now)
+	if position.IMO != "" {
+		track.IMO = position.IMO
+	}
+	if position.MMSI != "" {
+		track.MMSI = position.MMSI
+	}
+	track.Latitude = &position.Latitude
+	track.Longitude = &position.Longitude
+	track.SpeedKnots = &position.SpeedKnots
+	track.NavStatus = position.NavStatus
+	track.PositionAt = &position.ReportedAt
+	track.DistanceNM = &distance
+	if ok {
+		track.PredictedETA = &eta
+		track.ETASource = source
+	}
+
+	var changes []domain.VesselETAChange
+	if ok {
+		threshold := time.Duration(s.rules.ETADriftHours * float64(time.Hour))
+		for _, shipment := range voyage.Shipments {
+			change := domain.VesselETAChange{
+				ID:          uuid.New(),
+				ShipmentID:  shipment.ID,
+				TrackID:     track.ID,
+				PreviousETA: shipment.VesselETA,
+				NewETA:      eta,
+				Source:      source,
+				CreatedAt:   now,
+			}
+			if shipment.VesselETA != nil {
+				drift := eta.Sub(*shipment.VesselETA)
+				if drift.Abs() < threshold {
+					continue
+				}
+				change.DriftMinutes = int(drift.Minutes())
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		txCtx := database.WithTx(ctx, tx)
+		if err := s.trackRepo.SaveTrack(txCtx, track); err != nil {
+			return apperrors.DatabaseError("save vessel track", err)
+		}
+		for i := range changes {
+			if err := s.trackRepo.RecordETAChange(txCtx, &changes[i]); err != nil {
+				return apperrors.DatabaseError("update vessel eta", err)
+			}
+		}
+		return s.publishChanges(txCtx, voyage, track, changes)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(changes) > 0 {
+		s.logger.Infow("Vessel ETA refined",
+			"vessel", voyage.VesselName,
+			"voyage", voyage.VoyageNumber,
+			"eta", eta,
+			"source", source,
+			"distance_nm", math.Round(distance),
+			"shipments", len(changes),
+		)
+	}
+	return len(changes), nil
+}
+
+// refineETA predicts when the vessel will be alongside at the terminal. A
+// vessel making way is timed from its position and speed; one at anchor or
+// drifting is taken at its broadcast ETA; one already inside the arrival
+// radius is arriving.
+func (s *VesselTrackingService) refineETA(p *domain.VesselPosition, lat, lon float64, now time.Time) (time.Time, string, float64, bool) {
+	distance := domain.NauticalMiles(p.Latitude, p.Longitude, lat, lon)
+	approach := time.Duration(s.rules.PortApproachHours * float64(time.Hour))
+
+	var eta time.Time
+	var source string
+	switch {
+	case distance > s.rules.ArrivalRadiusNM && p.SpeedKnots >= s.rules.MinSpeedKnots:
+		sailing := time.Duration(distance / p.SpeedKnots * float64(time.Hour))
+		eta, source = p.ReportedAt.Add(sailing+approach), domain.VesselETASourcePosition
+	case p.ReportedETA != nil && p.ReportedETA.After(now):
+		eta, source = *p.ReportedETA, domain.VesselETASourceReported
+	case distance <= s.rules.ArrivalRadiusNM:
+		eta, source = p.ReportedAt.Add(approach), domain.VesselETASourcePosition
+	default:
+		return time.Time{}, "", distance, false
+	}
+	if eta.Before(now) {
+		eta = now
+	}
+	return eta.Truncate(time.Minute), source, distance, true
+}
+
+// publishChanges alerts planners to the shipments whose ETA drifted. ETAs
+// filled in for shipments that had none are recorded without an alert.
+func (s *VesselTrackingService) publishChanges(ctx context.Context, voyage *domain.InboundVoyage, track *domain.VesselTrack, changes []domain.VesselETAChange) error {
+	data := kafka.VesselETAChangedEvent{
+		VesselName:   voyage.VesselName,
+		VoyageNumber: voyage.VoyageNumber,
+		IMO:          track.IMO,
+		TerminalID:   voyage.TerminalID.String(),
+		TerminalName: voyage.TerminalName,
+		Source:       track.ETASource,
+	}
+	shipments := make(map[uuid.UUID]domain.InboundShipment, len(voyage.Shipments))
+	for _, shipment := range voyage.Shipments {
+		shipments[shipment.ID] = shipment
+	}
+	for _, change := range changes {
+		if change.PreviousETA == nil {
+			continue
+		}
+		shipment := shipments[change.ShipmentID]
+		data.NewETA = change.NewETA
+		if drift := float64(change.DriftMinutes) / 60; math.Abs(drift) > math.Abs(data.DriftHours) {
+			data.DriftHours = math.Round(drift*10) / 10
+			data.PreviousETA = change.PreviousETA
+		}
+		data.Shipments = append(data.Shipments, kafka.VesselETAShipment{
+			ShipmentID:      shipment.ID.String(),
+			ReferenceNumber: shipment.ReferenceNumber,
+			CustomerID:      shipment.CustomerID.String(),
+			Containers:      shipment.Containers,
+			PreviousETA:     change.PreviousETA,
+			LastFreeDay:     shipment.LastFreeDay,
+			ProjectedLFD:    s.projectedLFD(change.NewETA),
+		})
+	}
+	if len(data.Shipments) == 0 {
+		return nil
+	}
+
+	event := kafka.NewEvent(kafka.Topics.VesselETAChanged, "order-service", data)
+	if err := s.outbox.Publish(ctx, kafka.Topics.VesselETAChanged, event); err != nil {
+		return apperrors.DatabaseError("queue vessel eta event", err)
+	}
+	return nil
+}
+
+// projectedLFD is the last free day if the containers discharge on arrival
+func (s *VesselTrackingService) projectedLFD(eta time.Time) time.Time {
+	day := time.Date(eta.Year(), eta.Month(), eta.Day(), 0, 0, 0, 0, eta.Location())
+	return day.AddDate(0, 0, s.freeDays)
+}
+
+// ListTracks returns the vessels checked in the last day, soonest arrival first
+func (s *VesselTrackingService) ListTracks(ctx context.Context) ([]domain.VesselTrack, error) {
+	tracks, err := s.trackRepo.ListTracks(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, apperrors.DatabaseError("list vessel tracks", err)
+	}
+	return tracks, nil
+}
+
+// ListETAChanges returns the vessel ETA changes made to a shipment, newest first
+func (s *VesselTrackingService) ListETAChanges(ctx context.Context, shipmentID uuid.UUID) ([]domain.VesselETAChange, error) {
+	changes, err := s.trackRepo.ListETAChanges(ctx, shipmentID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list vessel eta changes", err)
+	}
+	return changes, nil
+}
+
+// Start checks on every tick until ctx is cancelled
+func (s *VesselTrackingService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.Check(ctx, now); err != nil {
+				s.logger.Errorw("Vessel ETA check failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/order-service/internal/domain"
+	"github.com/draymaster/services/order-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// VGMSubmission is a VGM declaration as sent to a steamship line or terminal
+type VGMSubmission struct {
+	BookingNumber       string
+	ContainerNumber     string
+	SteamshipLineID     uuid.UUID
+	TerminalID          uuid.UUID
+	Method              domain.WeighingMethod
+	GrossMassKg         float64
+	WeighedAt           time.Time
+	AuthorizedSignatory string
+}
+
+// VGMSubmitter transmits VGM declarations to an SSL or terminal system
+type VGMSubmitter interface {
+	SubmitVGM(ctx context.Context, submission VGMSubmission) (reference string, err error)
+}
+
+// VGMService records and submits verified gross mass declarations for exports
+type VGMService struct {
+	vgmRepo       repository.VGMRepository
+	containerRepo repository.ContainerRepository
+	shipmentRepo  repository.ShipmentRepository
+	submitter     VGMSubmitter
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+}
+
+// NewVGMService creates a new VGM service
+func NewVGMService(
+	vgmRepo repository.VGMRepository,
+	containerRepo repository.ContainerRepository,
+	shipmentRepo repository.ShipmentRepository,
+	submitter VGMSubmitter,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *VGMService {
+	return &VGMService{
+		vgmRepo:       vgmRepo,
+		containerRepo: containerRepo,
+		shipmentRepo:  shipmentRepo,
+		submitter:     submitter,
+		eventProducer: eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+	}
+}
+
+// RecordVGMInput contains input for recording a container weight
+type RecordVGMInput struct {
+	ContainerID         uuid.UUID
+	Method              domain.WeighingMethod
+	GrossMassKg         float64 // Method 1: scale reading
+	TareMassKg          float64 // Method 2: container tare; defaults from business rules
+	CargoMassKg         float64 // Method 2: cargo plus packing
+	ScaleTicketNumber   string
+	ScaleTicketURL      string
+	ScaleLocation       string
+	WeighedAt           time.Time
+	AuthorizedSignatory string
+	SubmitImmediately   bool
+	RecordedBy          string
+}
+
+// RecordVGM validates and records a verified gross mass for an export container
+func (s *VGMService) RecordVGM(ctx context.Context, input RecordVGMInput) (*domain.VGMRecord, error) {
+	container, err := s.containerRepo.GetByID(ctx, input.ContainerID)
+	if err != nil || container == nil {
+		return nil, apperrors.NotFoundError("container", input.ContainerID.String())
+	}
+
+	shipment, err := s.shipmentRepo.GetByID(ctx, container.ShipmentID)
+	if err != nil || shipment == nil {
+		return nil, apperrors.NotFoundError("shipment", container.ShipmentID.String())
+	}
+	if shipment.Type != domain.ShipmentTypeExport {
+		return nil, apperrors.ValidationError("VGM is only required for export containers", "container_id", input.ContainerID)
+	}
+
+	if input.AuthorizedSignatory == "" {
+		return nil, apperrors.ValidationError("authorized signatory is required", "authorized_signatory", input.AuthorizedSignatory)
+	}
+	if input.WeighedAt.IsZero() {
+		input.WeighedAt = time.Now()
+	}
+	if input.WeighedAt.After(time.Now()) {
+		return nil, apperrors.ValidationError("weighing time cannot be in the future", "weighed_at", input.WeighedAt)
+	}
+
+	gross, tare, err := s.grossMass(container, input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &domain.VGMRecord{
+		ID:                  uuid.New(),
+		ContainerID:         container.ID,
+		ContainerNumber:     container.ContainerNumber,
+		ShipmentID:          shipment.ID,
+		BookingNumber:       shipment.ReferenceNumber,
+		Method:              input.Method,
+		GrossMassKg:         gross,
+		TareMassKg:          tare,
+		CargoMassKg:         input.CargoMassKg,
+		ScaleTicketNumber:   input.ScaleTicketNumber,
+		ScaleTicketURL:      input.ScaleTicketURL,
+		ScaleLocation:       input.ScaleLocation,
+		WeighedAt:           input.WeighedAt,
+		AuthorizedSignatory: input.AuthorizedSignatory,
+		Status:              domain.VGMStatusRecorded,
+		CreatedBy:           input.RecordedBy,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	if err := s.vgmRepo.Create(ctx, record); err != nil {
+		return nil, apperrors.DatabaseError("create vgm", err)
+	}
+
+	// Keep the container's weight in sync with the declared mass
+	container.WeightLbs = record.GrossMassLbs()
+	container.IsOverweight = container.WeightLbs > s.businessRules.Weight.OverweightThresholdLbs
+	container.UpdatedAt = now
+	if err := s.containerRepo.Update(ctx, container); err != nil {
+		return nil, apperrors.DatabaseError("update container weight", err)
+	}
+
+	s.logger.Infow("VGM recorded",
+		"container", container.ContainerNumber,
+		"method", record.Method,
+		"gross_mass_kg", record.GrossMassKg,
+	)
+
+	if input.SubmitImmediately {
+		return s.SubmitVGM(ctx, record.ID)
+	}
+	return record, nil
+}
+
+// grossMass validates the weighing method inputs and returns gross and tare mass
+func (s *VGMService) grossMass(container *domain.Container, input RecordVGMInput) (float64, float64, error) {
+	tare := input.TareMassKg
+	if tare == 0 {
+		tare = s.defaultTareKg(container.Size)
+	}
+
+	var gross float64
+	switch input.Method {
+	case domain.WeighingMethod1:
+		if input.ScaleTicketNumber == "" && input.ScaleTicketURL == "" {
+			return 0, 0, apperrors.ValidationError("method 1 requires a scale ticket", "scale_ticket_number", nil)
+		}
+		gross = input.GrossMassKg
+	case domain.WeighingMethod2:
+		if input.CargoMassKg <= 0 {
+			return 0, 0, apperrors.ValidationError("method 2 requires cargo mass", "cargo_mass_kg", input.CargoMassKg)
+		}
+		gross = input.CargoMassKg + tare
+	default:
+		return 0, 0, apperrors.ValidationError("invalid weighing method", "method", input.Method)
+	}
+
+	if gross <= tare {
+		return 0, 0, apperrors.ValidationError("gross mass must exceed container tare", "gross_mass_kg", gross)
+	}
+	if max := domain.MaxGrossMassKg(container.Size); gross > max {
+		return 0, 0, apperrors.ValidationError(
+			fmt.Sprintf("gross mass %.0f kg exceeds %s' container maximum of %.0f kg", gross, container.Size, max),
+			"gross_mass_kg", gross)
+	}
+
+	return gross, tare, nil
+}
+
+// defaultTareKg returns the configured tare weight for a container size
+func (s *VGMService) defaultTareKg(size domain.ContainerSize) float64 {
+	lbs := s.businessRules.Weight.TareWeight40ftLbs
+	switch size {
+	case domain.ContainerSize20:
+		lbs = s.businessRules.Weight.TareWeight20ftLbs
+	case domain.ContainerSize45:
+		lbs = s.businessRules.Weight.TareWeight45ftLbs
+	}
+	return float64(lbs) / domain.LbsPerKg
+}
+
+// SubmitVGM transmits a recorded VGM to the steamship line / terminal
+func (s *VGMService) SubmitVGM(ctx context.Context, vgmID uuid.UUID) (*domain.VGMRecord, error) {
+	record, err := s.vgmRepo.GetByID(ctx, vgmID)
+	if err != nil || record == nil {
+		return nil, apperrors.NotFoundError("vgm", vgmID.String())
+	}
+	if record.Status != domain.VGMStatusRecorded && record.Status != domain.VGMStatusRejected {
+		return nil, apperrors.InvalidStateError(string(record.Status), "RECORDED or REJECTED")
+	}
+
+	shipment, err := s.shipmentRepo.GetByID(ctx, record.ShipmentID)
+	if err != nil || shipment == nil {
+		return nil, apperrors.NotFoundError("shipment", record.ShipmentID.String())
+	}
+	if shipment.PortCutoff != nil && time.Now().After(*shipment.PortCutoff) {
+		s.logger.Warnw("Submitting VGM after port cutoff",
+			"container", record.ContainerNumber,
+			"port_cutoff", shipment.PortCutoff,
+		)
+	}
+
+	reference, err := s.submitter.SubmitVGM(ctx, VGMSubmission{
+		BookingNumber:       record.BookingNumber,
+		ContainerNumber:     record.ContainerNumber,
+		SteamshipLineID:     shipment.SteamshipLineID,
+		TerminalID:          shipment.TerminalID,
+		Method:              record.Method,
+		GrossMassKg:         record.GrossMassKg,
+		WeighedAt:           record.WeighedAt,
+		AuthorizedSignatory: record.AuthorizedSignatory,
+	})
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("vgm submission", err)
+	}
+
+	now := time.Now()
+	record.Status = domain.VGMStatusSubmitted
+	record.SubmittedAt = &now
+	record.SubmissionReference = reference
+	record.RejectionReason = ""
+	record.UpdatedAt = now
+	if err := s.vgmRepo.Update(ctx, record); err != nil {
+		return nil, apperrors.DatabaseError("update vgm", err)
+	}
+
+	s.publishVGM(ctx, record)
+
+	s.logger.Infow("VGM submitted",
+		"container", record.ContainerNumber,
+		"booking", record.BookingNumber,
+		"reference", reference,
+	)
+
+	return record, nil
+}
+
+// RecordSubmissionResult applies the SSL/terminal's acceptance or rejection
+func (s *VGMService) RecordSubmissionResult(ctx context.Context, vgmID uuid.UUID, accepted bool, reason string) (*domain.VGMRecord, error) {
+	record, err := s.vgmRepo.GetByID(ctx, vgmID)
+	if err != nil || record == nil {
+		return nil, apperrors.NotFoundError("vgm", vgmID.String())
+	}
+	if record.Status != domain.VGMStatusSubmitted {
+		return nil, apperrors.InvalidStateError(string(record.Status), string(domain.VGMStatusSubmitted))
+	}
+
+	record.Status = domain.VGMStatusAccepted
+	if !accepted {
+		record.Status = domain.VGMStatusRejected
+		record.RejectionReason = reason
+	}
+	record.UpdatedAt = time.Now()
+	if err := s.vgmRepo.Update(ctx, record); err != nil {
+		return nil, apperrors.DatabaseError("update vgm", err)
+	}
+
+	s.publishVGM(ctx, record)
+	return record, nil
+}
+
+// GetContainerVGM returns the latest VGM on file for a container
+func (s *VGMService) GetContainerVGM(ctx context.Context, containerID uuid.UUID) (*domain.VGMRecord, error) {
+	record, err := s.vgmRepo.GetLatestByContainerID(ctx, containerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get vgm", err)
+	}
+	if record == nil {
+		return nil, apperrors.NotFoundError("vgm", containerID.String())
+	}
+	return record, nil
+}
+
+// publishVGM announces a VGM status change so dispatch can release ingate trips
+func (s *VGMService) publishVGM(ctx context.Context, record *domain.VGMRecord) {
+	event := kafka.NewEvent(kafka.Topics.ContainerVGMUpdated, "order-service", map[string]interface{}{
+		"vgm_id":           record.ID.String(),
+		"container_id":     record.ContainerID.String(),
+		"container_number": record.ContainerNumber,
+		"booking_number":   record.BookingNumber,
+		"status":           string(record.Status),
+		"on_file":          record.IsOnFile(),
+		"gross_mass_kg":    record.GrossMassKg,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.ContainerVGMUpdated, event)
+}
@@ -0,0 +1,25 @@
+-- 000001_init_schema.down.sql
+
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS containers;
+DROP TABLE IF EXISTS shipments;
+DROP TABLE IF EXISTS ports;
+DROP TABLE IF EXISTS locations;
+DROP TABLE IF EXISTS steamship_lines;
+DROP TABLE IF EXISTS customers;
+
+-- update_updated_at_column() is shared by the order and dispatch schemas and
+-- is left in place.
+
+DROP TYPE IF EXISTS billing_status;
+DROP TYPE IF EXISTS order_status;
+DROP TYPE IF EXISTS order_type;
+DROP TYPE IF EXISTS location_type;
+DROP TYPE IF EXISTS customs_status;
+DROP TYPE IF EXISTS container_state;
+DROP TYPE IF EXISTS container_type;
+DROP TYPE IF EXISTS container_size;
+DROP TYPE IF EXISTS shipment_status;
+DROP TYPE IF EXISTS shipment_type;
+
+DROP SEQUENCE IF EXISTS order_number_seq;
@@ -0,0 +1,3 @@
+-- 000002_calendar_closures.down.sql
+
+DROP TABLE IF EXISTS calendar_closures;
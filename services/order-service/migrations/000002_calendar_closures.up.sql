@@ -0,0 +1,18 @@
+-- 000002_calendar_closures.up.sql
+-- Port/terminal closures and company holidays
+
+CREATE TABLE calendar_closures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    type VARCHAR(30) NOT NULL CHECK (type IN ('TERMINAL_CLOSURE', 'PORT_CLOSURE', 'COMPANY_HOLIDAY')),
+    terminal_id UUID REFERENCES locations(id),
+    closure_date DATE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    reason TEXT,
+    extends_free_time BOOLEAN NOT NULL DEFAULT true,
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_calendar_closures_date ON calendar_closures(closure_date);
+CREATE INDEX idx_calendar_closures_terminal ON calendar_closures(terminal_id, closure_date);
@@ -0,0 +1,11 @@
+-- 000003_container_terminal_status.down.sql
+
+DROP INDEX IF EXISTS idx_containers_ready;
+
+ALTER TABLE containers
+    DROP COLUMN IF EXISTS terminal_status_at,
+    DROP COLUMN IF EXISTS terminal_status,
+    DROP COLUMN IF EXISTS terminal_hold,
+    DROP COLUMN IF EXISTS ready_at,
+    DROP COLUMN IF EXISTS is_ready,
+    DROP COLUMN IF EXISTS discharged_at;
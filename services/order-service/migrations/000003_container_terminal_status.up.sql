@@ -0,0 +1,12 @@
+-- 000003_container_terminal_status.up.sql
+-- Container milestones and readiness driven by eModal status events
+
+ALTER TABLE containers
+    ADD COLUMN discharged_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN is_ready BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN ready_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN terminal_hold BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN terminal_status VARCHAR(30),
+    ADD COLUMN terminal_status_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_containers_ready ON containers(is_ready) WHERE is_ready;
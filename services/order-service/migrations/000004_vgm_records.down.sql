@@ -0,0 +1,3 @@
+-- 000004_vgm_records.down.sql
+
+DROP TABLE IF EXISTS vgm_records;
@@ -0,0 +1,30 @@
+-- 000004_vgm_records.up.sql
+-- Verified gross mass declarations for export containers
+
+CREATE TABLE vgm_records (
+    id                   UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    container_id         UUID NOT NULL REFERENCES containers(id),
+    container_number     VARCHAR(11) NOT NULL,
+    shipment_id          UUID NOT NULL REFERENCES shipments(id),
+    booking_number       VARCHAR(100) NOT NULL,
+    method               VARCHAR(10) NOT NULL CHECK (method IN ('METHOD_1', 'METHOD_2')),
+    gross_mass_kg        DECIMAL(10,2) NOT NULL,
+    tare_mass_kg         DECIMAL(10,2),
+    cargo_mass_kg        DECIMAL(10,2),
+    scale_ticket_number  VARCHAR(100),
+    scale_ticket_url     TEXT,
+    scale_location       VARCHAR(255),
+    weighed_at           TIMESTAMP WITH TIME ZONE NOT NULL,
+    authorized_signatory VARCHAR(255) NOT NULL,
+    status               VARCHAR(20) NOT NULL DEFAULT 'RECORDED'
+                         CHECK (status IN ('RECORDED', 'SUBMITTED', 'ACCEPTED', 'REJECTED')),
+    submitted_at         TIMESTAMP WITH TIME ZONE,
+    submission_reference VARCHAR(100),
+    rejection_reason     TEXT,
+    created_by           VARCHAR(255),
+    created_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_vgm_records_container ON vgm_records(container_id, created_at DESC);
+CREATE INDEX idx_vgm_records_status ON vgm_records(status);
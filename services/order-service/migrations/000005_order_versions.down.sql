@@ -0,0 +1,3 @@
+-- 000005_order_versions.down.sql
+
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+-- 000005_order_versions.up.sql
+-- Version column for optimistic concurrency on order edits
+
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
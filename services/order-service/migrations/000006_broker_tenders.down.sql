@@ -0,0 +1,7 @@
+-- 000006_broker_tenders.down.sql
+
+ALTER TABLE orders DROP COLUMN IF EXISTS agreed_rate;
+ALTER TABLE orders DROP COLUMN IF EXISTS tender_id;
+
+DROP TABLE IF EXISTS load_tenders;
+DROP TABLE IF EXISTS brokers;
@@ -0,0 +1,64 @@
+-- 000006_broker_tenders.up.sql
+-- Broker/3PL load tenders and the orders they become
+
+CREATE TABLE brokers (
+    id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name           VARCHAR(255) NOT NULL,
+    mc_number      VARCHAR(20),
+    customer_id    UUID NOT NULL REFERENCES customers(id),
+    api_key_hash   VARCHAR(64) UNIQUE NOT NULL,
+    webhook_url    TEXT,
+    webhook_secret VARCHAR(255),
+    active         BOOLEAN NOT NULL DEFAULT true,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE load_tenders (
+    id                      UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    broker_id               UUID NOT NULL REFERENCES brokers(id),
+    broker_reference        VARCHAR(100) NOT NULL,
+    order_type              order_type NOT NULL,
+    shipment_reference      VARCHAR(100) NOT NULL,
+    steamship_line_code     VARCHAR(10) NOT NULL DEFAULT '',
+    port_id                 UUID,
+    terminal_id             UUID,
+    container_number        VARCHAR(11) NOT NULL,
+    container_size          VARCHAR(5) NOT NULL,
+    container_type          VARCHAR(20) NOT NULL DEFAULT '',
+    weight_lbs              INTEGER NOT NULL DEFAULT 0,
+    is_hazmat               BOOLEAN NOT NULL DEFAULT false,
+    hazmat_class            VARCHAR(10) NOT NULL DEFAULT '',
+    un_number               VARCHAR(10) NOT NULL DEFAULT '',
+    pickup_location_id      UUID REFERENCES locations(id),
+    pickup_address          TEXT NOT NULL DEFAULT '',
+    delivery_location_id    UUID REFERENCES locations(id),
+    delivery_address        TEXT NOT NULL DEFAULT '',
+    return_location_id      UUID REFERENCES locations(id),
+    requested_pickup_date   TIMESTAMP WITH TIME ZONE,
+    requested_delivery_date TIMESTAMP WITH TIME ZONE,
+    last_free_day           TIMESTAMP WITH TIME ZONE,
+    offered_rate            DECIMAL(10,2) NOT NULL,
+    counter_rate            DECIMAL(10,2),
+    agreed_rate             DECIMAL(10,2),
+    currency                VARCHAR(3) NOT NULL DEFAULT 'USD',
+    status                  VARCHAR(20) NOT NULL DEFAULT 'TENDERED'
+                            CHECK (status IN ('TENDERED', 'COUNTERED', 'ACCEPTED', 'DECLINED', 'WITHDRAWN', 'EXPIRED')),
+    status_reason           TEXT NOT NULL DEFAULT '',
+    notes                   TEXT NOT NULL DEFAULT '',
+    expires_at              TIMESTAMP WITH TIME ZONE,
+    responded_by            VARCHAR(255) NOT NULL DEFAULT '',
+    responded_at            TIMESTAMP WITH TIME ZONE,
+    order_id                UUID,
+    created_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_load_tenders_broker_ref ON load_tenders(broker_id, broker_reference, created_at DESC);
+CREATE INDEX idx_load_tenders_status ON load_tenders(status, created_at DESC);
+CREATE UNIQUE INDEX idx_load_tenders_order ON load_tenders(order_id) WHERE order_id IS NOT NULL;
+
+ALTER TABLE orders ADD COLUMN tender_id UUID REFERENCES load_tenders(id);
+ALTER TABLE orders ADD COLUMN agreed_rate DECIMAL(10,2);
+
+ALTER TABLE load_tenders ADD CONSTRAINT fk_load_tenders_order FOREIGN KEY (order_id) REFERENCES orders(id);
@@ -0,0 +1,3 @@
+-- 000007_appointment_booking_preferences.down.sql
+
+DROP TABLE IF EXISTS appointment_booking_preferences;
@@ -0,0 +1,14 @@
+-- 000007_appointment_booking_preferences.up.sql
+-- Per-terminal appointment slot recommendations derived from punctuality history
+
+CREATE TABLE appointment_booking_preferences (
+    terminal_id         UUID PRIMARY KEY REFERENCES locations(id),
+    timezone            VARCHAR(50) NOT NULL,
+    preferred_windows   JSONB NOT NULL DEFAULT '[]',
+    avoid_windows       JSONB NOT NULL DEFAULT '[]',
+    arrival_buffer_mins INTEGER NOT NULL DEFAULT 0,
+    sample_size         INTEGER NOT NULL DEFAULT 0,
+    period_start        TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end          TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at          TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- 000008_container_verification.down.sql
+
+DROP INDEX IF EXISTS idx_containers_verification;
+
+ALTER TABLE containers
+    DROP COLUMN IF EXISTS verified_at,
+    DROP COLUMN IF EXISTS verification_mismatches,
+    DROP COLUMN IF EXISTS verification_status;
@@ -0,0 +1,11 @@
+-- 000008_container_verification.up.sql
+-- Keyed containers checked against the terminal's record in eModal
+
+ALTER TABLE containers
+    ADD COLUMN verification_status VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+        CHECK (verification_status IN ('PENDING', 'VERIFIED', 'MISMATCH', 'NOT_FOUND', 'UNAVAILABLE')),
+    ADD COLUMN verification_mismatches JSONB,
+    ADD COLUMN verified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_containers_verification ON containers(verification_status)
+    WHERE verification_status IN ('MISMATCH', 'NOT_FOUND');
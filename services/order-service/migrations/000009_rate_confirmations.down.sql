@@ -0,0 +1,6 @@
+-- 000009_rate_confirmations.down.sql
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS require_signed_rate_confirmation;
+ALTER TABLE brokers DROP COLUMN IF EXISTS rate_confirmation_email;
+
+DROP TABLE IF EXISTS rate_confirmations;
@@ -0,0 +1,33 @@
+-- 000009_rate_confirmations.up.sql
+-- Rate confirmations for brokered loads and their e-signed copies
+
+ALTER TABLE brokers ADD COLUMN rate_confirmation_email VARCHAR(255);
+ALTER TABLE brokers ADD COLUMN require_signed_rate_confirmation BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE rate_confirmations (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id        UUID NOT NULL REFERENCES orders(id),
+    tender_id       UUID NOT NULL REFERENCES load_tenders(id),
+    number          VARCHAR(50) NOT NULL,
+    rate            DECIMAL(10,2) NOT NULL,
+    currency        VARCHAR(3) NOT NULL DEFAULT 'USD',
+    provider        VARCHAR(30) NOT NULL,
+    envelope_id     VARCHAR(100) NOT NULL,
+    signer_name     VARCHAR(255) NOT NULL,
+    signer_email    VARCHAR(255) NOT NULL,
+    status          VARCHAR(20) NOT NULL,
+    status_reason   TEXT NOT NULL DEFAULT '',
+    filename        VARCHAR(255) NOT NULL,
+    document        BYTEA NOT NULL,
+    signed_document BYTEA,
+    sent_by         VARCHAR(100) NOT NULL,
+    sent_at         TIMESTAMP WITH TIME ZONE NOT NULL,
+    signed_at       TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, envelope_id)
+);
+
+CREATE INDEX idx_rate_confirmations_order ON rate_confirmations(order_id, created_at DESC);
+-- Dispatch checks brokered orders for a signed copy before releasing a trip
+CREATE INDEX idx_rate_confirmations_signed ON rate_confirmations(order_id) WHERE status = 'SIGNED';
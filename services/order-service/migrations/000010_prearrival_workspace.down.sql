@@ -0,0 +1,11 @@
+-- 000010_prearrival_workspace.down.sql
+
+DROP INDEX IF EXISTS idx_shipments_vessel_eta;
+DROP INDEX IF EXISTS idx_orders_draft_trip_pending;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS draft_trip_sent_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS draft_trip_requested_by;
+ALTER TABLE orders DROP COLUMN IF EXISTS draft_trip_requested_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS credit_hold_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS credit_hold_reason;
+ALTER TABLE customers DROP COLUMN IF EXISTS credit_hold;
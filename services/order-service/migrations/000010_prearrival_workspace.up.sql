@@ -0,0 +1,16 @@
+-- 000010_prearrival_workspace.up.sql
+-- Customer credit holds and draft trips staged from the pre-arrival workspace
+
+ALTER TABLE customers ADD COLUMN credit_hold BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE customers ADD COLUMN credit_hold_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN credit_hold_at TIMESTAMP WITH TIME ZONE;
+
+-- Set when a planner asks for a draft trip; sent once the container has
+-- discharged and the trip request has gone to dispatch
+ALTER TABLE orders ADD COLUMN draft_trip_requested_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN draft_trip_requested_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN draft_trip_sent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_orders_draft_trip_pending ON orders(container_id)
+    WHERE draft_trip_requested_at IS NOT NULL AND draft_trip_sent_at IS NULL;
+CREATE INDEX idx_shipments_vessel_eta ON shipments(vessel_eta) WHERE type = 'IMPORT';
@@ -0,0 +1,3 @@
+-- 000011_facility_profiles.down.sql
+
+DROP TABLE IF EXISTS facility_profiles;
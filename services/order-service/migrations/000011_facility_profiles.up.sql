@@ -0,0 +1,15 @@
+-- 000011_facility_profiles.up.sql
+-- Shipper and consignee facility profiles: receiving hours, docks, PPE and check-in
+
+CREATE TABLE facility_profiles (
+    location_id        UUID PRIMARY KEY REFERENCES locations(id),
+    timezone           VARCHAR(50) NOT NULL,
+    hours              JSONB NOT NULL DEFAULT '[]',
+    lunch_closures     JSONB NOT NULL DEFAULT '[]',
+    dock_count         INTEGER NOT NULL DEFAULT 0,
+    required_ppe       TEXT[] NOT NULL DEFAULT '{}',
+    check_in_procedure TEXT NOT NULL DEFAULT '',
+    notes              TEXT NOT NULL DEFAULT '',
+    updated_by         VARCHAR(100) NOT NULL,
+    updated_at         TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- 000012_order_tags.down.sql
+
+DROP INDEX IF EXISTS idx_orders_tags;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS tags;
+
+DROP TABLE IF EXISTS saved_order_filters;
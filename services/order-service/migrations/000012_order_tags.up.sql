@@ -0,0 +1,20 @@
+-- 000012_order_tags.up.sql
+-- Free-form order tags such as "hot" or "vip", and the order filters each
+-- user has saved
+
+ALTER TABLE orders ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_orders_tags ON orders USING GIN (tags);
+
+CREATE TABLE saved_order_filters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(100) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    criteria JSONB NOT NULL DEFAULT '{}',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_saved_order_filters_name ON saved_order_filters(user_id, LOWER(name));
+CREATE UNIQUE INDEX idx_saved_order_filters_default ON saved_order_filters(user_id) WHERE is_default;
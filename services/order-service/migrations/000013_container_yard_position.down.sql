@@ -0,0 +1,9 @@
+-- 000013_container_yard_position.down.sql
+
+ALTER TABLE containers
+    DROP COLUMN IF EXISTS yard_updated_at,
+    DROP COLUMN IF EXISTS yard_tier,
+    DROP COLUMN IF EXISTS yard_bay,
+    DROP COLUMN IF EXISTS yard_row,
+    DROP COLUMN IF EXISTS yard_block,
+    DROP COLUMN IF EXISTS mount_status;
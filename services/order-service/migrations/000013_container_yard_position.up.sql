@@ -0,0 +1,10 @@
+-- 000013_container_yard_position.up.sql
+-- Grounded/wheeled status and yard position reported by eModal
+
+ALTER TABLE containers
+    ADD COLUMN mount_status VARCHAR(10),
+    ADD COLUMN yard_block VARCHAR(20),
+    ADD COLUMN yard_row VARCHAR(10),
+    ADD COLUMN yard_bay VARCHAR(10),
+    ADD COLUMN yard_tier VARCHAR(10),
+    ADD COLUMN yard_updated_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,5 @@
+-- 000014_email_intake.down.sql
+
+DROP TABLE IF EXISTS intake_senders;
+DROP TABLE IF EXISTS intake_emails;
+DROP TABLE IF EXISTS shipment_drafts;
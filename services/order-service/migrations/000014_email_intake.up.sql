@@ -0,0 +1,58 @@
+-- 000014_email_intake.up.sql
+-- Order intake mailbox: emails read from it, the shipment drafts clerks
+-- confirm, and sender addresses mapped to customers
+
+CREATE TABLE shipment_drafts (
+    id                  UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mailbox             VARCHAR(255) NOT NULL,
+    thread_id           VARCHAR(255) NOT NULL,
+    subject             TEXT NOT NULL DEFAULT '',
+    from_address        VARCHAR(255) NOT NULL DEFAULT '',
+    type                shipment_type NOT NULL,
+    customer_id         UUID REFERENCES customers(id),
+    reference_number    VARCHAR(100) NOT NULL DEFAULT '',
+    steamship_line_code VARCHAR(10) NOT NULL DEFAULT '',
+    vessel_name         VARCHAR(100) NOT NULL DEFAULT '',
+    voyage_number       VARCHAR(20) NOT NULL DEFAULT '',
+    last_free_day       TIMESTAMP WITH TIME ZONE,
+    container_numbers   TEXT[] NOT NULL DEFAULT '{}',
+    container_size      VARCHAR(5) NOT NULL DEFAULT '',
+    container_type      VARCHAR(20) NOT NULL DEFAULT '',
+    status              VARCHAR(20) NOT NULL DEFAULT 'PENDING_REVIEW'
+                        CHECK (status IN ('PENDING_REVIEW', 'CONFIRMED', 'REJECTED')),
+    shipment_id         UUID REFERENCES shipments(id),
+    reviewed_by         VARCHAR(255) NOT NULL DEFAULT '',
+    reviewed_at         TIMESTAMP WITH TIME ZONE,
+    reject_reason       TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_shipment_drafts_status ON shipment_drafts(status, created_at DESC);
+CREATE INDEX idx_shipment_drafts_thread ON shipment_drafts(mailbox, thread_id) WHERE status = 'PENDING_REVIEW';
+
+CREATE TABLE intake_emails (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    mailbox      VARCHAR(255) NOT NULL,
+    message_id   VARCHAR(998) NOT NULL,
+    thread_id    VARCHAR(255) NOT NULL,
+    from_address VARCHAR(255) NOT NULL DEFAULT '',
+    subject      TEXT NOT NULL DEFAULT '',
+    body         TEXT NOT NULL DEFAULT '',
+    received_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+    class        VARCHAR(20) NOT NULL
+                 CHECK (class IN ('DELIVERY_ORDER', 'BOOKING', 'OTHER')),
+    attachments  JSONB NOT NULL DEFAULT '[]',
+    draft_id     UUID REFERENCES shipment_drafts(id),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (mailbox, message_id)
+);
+
+CREATE INDEX idx_intake_emails_draft ON intake_emails(draft_id, received_at) WHERE draft_id IS NOT NULL;
+
+-- address is a full email address or a domain written as "@example.com"
+CREATE TABLE intake_senders (
+    address     VARCHAR(255) PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
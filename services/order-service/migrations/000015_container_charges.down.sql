@@ -0,0 +1,4 @@
+-- 000015_container_charges.down.sql
+
+DROP TABLE IF EXISTS container_charge_warnings;
+DROP TABLE IF EXISTS container_charges;
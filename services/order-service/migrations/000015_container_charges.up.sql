@@ -0,0 +1,35 @@
+-- 000015_container_charges.up.sql
+-- Per-diem and demurrage materialized by the nightly accrual for open
+-- import containers, and the LFD and charge warnings sent to dispatch
+
+CREATE TABLE container_charges (
+    id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    container_id     UUID NOT NULL REFERENCES containers(id) ON DELETE CASCADE,
+    container_number VARCHAR(15) NOT NULL,
+    shipment_id      UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    customer_id      UUID NOT NULL,
+    terminal_id      UUID NOT NULL,
+    charge_type      VARCHAR(20) NOT NULL CHECK (charge_type IN ('PER_DIEM', 'DEMURRAGE')),
+    last_free_day    TIMESTAMP WITH TIME ZONE NOT NULL,
+    start_date       TIMESTAMP WITH TIME ZONE,
+    days             INTEGER NOT NULL DEFAULT 0,
+    amount           DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    breakdown        JSONB NOT NULL DEFAULT '[]',
+    accrued_through  TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (container_id, charge_type)
+);
+
+CREATE INDEX idx_container_charges_customer ON container_charges(customer_id, amount DESC);
+CREATE INDEX idx_container_charges_terminal ON container_charges(terminal_id, amount DESC);
+
+-- One warning per container, LFD and lead time; a new LFD warns again
+CREATE TABLE container_charge_warnings (
+    container_id  UUID NOT NULL REFERENCES containers(id) ON DELETE CASCADE,
+    warning       VARCHAR(30) NOT NULL,
+    last_free_day TIMESTAMP WITH TIME ZONE NOT NULL,
+    days_out      INTEGER NOT NULL,
+    sent_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (container_id, warning, last_free_day, days_out)
+);
@@ -0,0 +1,4 @@
+-- 000016_rate_approvals.down.sql
+
+DROP TABLE IF EXISTS order_rate_approval_comments;
+DROP TABLE IF EXISTS order_rate_approvals;
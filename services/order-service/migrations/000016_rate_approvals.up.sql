@@ -0,0 +1,42 @@
+-- 000016_rate_approvals.up.sql
+-- Manager approval of orders quoted off their customer's contract rate.
+-- An order waiting on approval is held and released when it is approved.
+
+CREATE TABLE order_rate_approvals (
+    id                UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id          UUID NOT NULL REFERENCES orders(id),
+    order_number      VARCHAR(20) NOT NULL,
+    customer_id       UUID NOT NULL REFERENCES customers(id),
+    quoted_rate       DECIMAL(10,2) NOT NULL,
+    contract_rate     DECIMAL(10,2),
+    contract_rate_id  UUID,
+    deviation_amount  DECIMAL(10,2),
+    deviation_percent DECIMAL(7,2),
+    reason            VARCHAR(20) NOT NULL
+                      CHECK (reason IN ('BELOW_CONTRACT', 'ABOVE_CONTRACT', 'NO_CONTRACT')),
+    status            VARCHAR(20) NOT NULL DEFAULT 'PENDING'
+                      CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'SUPERSEDED')),
+    requested_by      VARCHAR(255) NOT NULL DEFAULT '',
+    requested_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    decided_by        VARCHAR(255) NOT NULL DEFAULT '',
+    decided_at        TIMESTAMP WITH TIME ZONE,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_rate_approvals_order ON order_rate_approvals(order_id, requested_at DESC);
+CREATE INDEX idx_order_rate_approvals_queue ON order_rate_approvals(status, requested_at);
+
+-- One open approval per order
+CREATE UNIQUE INDEX idx_order_rate_approvals_pending ON order_rate_approvals(order_id) WHERE status = 'PENDING';
+
+CREATE TABLE order_rate_approval_comments (
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    approval_id UUID NOT NULL REFERENCES order_rate_approvals(id),
+    author      VARCHAR(255) NOT NULL,
+    body        TEXT NOT NULL,
+    decision    VARCHAR(20) CHECK (decision IN ('APPROVED', 'REJECTED')),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_rate_approval_comments_approval ON order_rate_approval_comments(approval_id, created_at);
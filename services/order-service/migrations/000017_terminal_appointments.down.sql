@@ -0,0 +1,6 @@
+-- 000017_terminal_appointments.down.sql
+
+DROP TABLE IF EXISTS terminal_gate_hours;
+DROP TABLE IF EXISTS terminal_appointments;
+DROP TYPE IF EXISTS appointment_status;
+DROP TYPE IF EXISTS appointment_type;
@@ -0,0 +1,75 @@
+-- 000017_terminal_appointments.up.sql
+-- Terminal gate appointments and gate hours. The pre-arrival workspace,
+-- no-show detection and punctuality analytics all read appointments; the
+-- legacy schema may already have created both tables.
+
+DO $$ BEGIN
+    CREATE TYPE appointment_type AS ENUM ('PICKUP', 'RETURN', 'DROP_OFF', 'DUAL');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+DO $$ BEGIN
+    CREATE TYPE appointment_status AS ENUM (
+        'REQUESTED', 'PENDING', 'CONFIRMED', 'CANCELLED', 'COMPLETED', 'MISSED', 'RESCHEDULED'
+    );
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+CREATE TABLE IF NOT EXISTS terminal_appointments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    trip_id UUID, -- dispatch-service trip
+    terminal_id UUID NOT NULL,
+    terminal_name VARCHAR(200),
+    type appointment_type NOT NULL,
+    status appointment_status NOT NULL DEFAULT 'REQUESTED',
+    container_id UUID,
+    container_number VARCHAR(11),
+    chassis_id UUID,
+    driver_id UUID,
+    tractor_id UUID,
+    requested_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_time TIMESTAMP WITH TIME ZONE,
+    window_start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmation_number VARCHAR(50),
+    gate_number VARCHAR(20),
+    lane_number VARCHAR(20),
+    special_instructions TEXT,
+    actual_arrival_time TIMESTAMP WITH TIME ZONE,
+    actual_completion_time TIMESTAMP WITH TIME ZONE,
+    gate_ticket_number VARCHAR(50),
+    cancellation_reason TEXT,
+    rescheduled_from UUID REFERENCES terminal_appointments(id),
+    requested_by VARCHAR(100) NOT NULL,
+    requested_by_id UUID,
+    confirmed_by VARCHAR(100),
+    terminal_reference VARCHAR(100),
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_order_id ON terminal_appointments(order_id);
+CREATE INDEX IF NOT EXISTS idx_appointments_terminal_id ON terminal_appointments(terminal_id);
+CREATE INDEX IF NOT EXISTS idx_appointments_window_start ON terminal_appointments(window_start_time);
+
+-- Weekly hours per day of week (0 = Sunday), and special_date rows that
+-- override them for one day
+CREATE TABLE IF NOT EXISTS terminal_gate_hours (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    terminal_id UUID NOT NULL,
+    day_of_week INTEGER CHECK (day_of_week >= 0 AND day_of_week <= 6),
+    open_time TIME NOT NULL,
+    close_time TIME NOT NULL,
+    is_holiday BOOLEAN DEFAULT false,
+    is_closed BOOLEAN DEFAULT false,
+    special_date DATE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gate_hours_terminal ON terminal_gate_hours(terminal_id);
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+
+	"github.com/draymaster/services/reference-data-service/internal/api"
+	"github.com/draymaster/services/reference-data-service/internal/repository"
+	"github.com/draymaster/services/reference-data-service/internal/service"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "reference-data-service"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting reference data service",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Database
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+	log.Info("Database connected")
+
+	// Kafka producer — publishes change events so caching clients can invalidate
+	kafkaProducer := kafka.NewProducerWithConfig(cfg.Kafka.Brokers, kafka.ProducerConfigFrom(cfg.Kafka), log)
+	defer kafkaProducer.Close()
+	log.Info("Kafka producer initialized")
+
+	refService := service.NewReferenceDataService(
+		repository.NewPostgresSteamshipLineRepository(db.Pool),
+		repository.NewPostgresPortRepository(db.Pool),
+		repository.NewPostgresTerminalRepository(db.Pool),
+		repository.NewPostgresAliasRepository(db.Pool),
+		kafkaProducer,
+		log,
+	)
+
+	// HTTP API
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      api.NewHandler(refService, log).Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("Reference data service stopped")
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reference-data-service/internal/domain"
+	"github.com/draymaster/services/reference-data-service/internal/service"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Handler serves the reference data HTTP API
+type Handler struct {
+	svc    *service.ReferenceDataService
+	logger *logger.Logger
+}
+
+// NewHandler creates a new reference data HTTP handler
+func NewHandler(svc *service.ReferenceDataService, log *logger.Logger) *Handler {
+	return &Handler{svc: svc, logger: log}
+}
+
+// Routes returns the HTTP routes for the API
+//
+//	GET/POST            /v1/steamship-lines
+//	GET/PUT/DELETE      /v1/steamship-lines/{id}
+//	GET                 /v1/steamship-lines/scac/{scac}
+//	GET/POST            /v1/ports
+//	GET/PUT/DELETE      /v1/ports/{id}
+//	GET                 /v1/ports/{id}/terminals
+//	GET/POST            /v1/terminals
+//	GET/PUT/DELETE      /v1/terminals/{id}
+//	GET                 /v1/terminals/firms/{code}
+//	POST                /v1/aliases
+//	DELETE              /v1/aliases/{id}
+//	GET                 /v1/aliases/resolve?entity_type=&source=&alias=
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	mux.HandleFunc("/v1/steamship-lines", h.steamshipLines)
+	mux.HandleFunc("/v1/steamship-lines/", h.steamshipLine)
+	mux.HandleFunc("/v1/ports", h.ports)
+	mux.HandleFunc("/v1/ports/", h.port)
+	mux.HandleFunc("/v1/terminals", h.terminals)
+	mux.HandleFunc("/v1/terminals/", h.terminal)
+	mux.HandleFunc("/v1/aliases", h.aliases)
+	mux.HandleFunc("/v1/aliases/", h.alias)
+
+	return mux
+}
+
+// ============================================================================
+// STEAMSHIP LINES
+// ============================================================================
+
+func (h *Handler) steamshipLines(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		lines, err := h.svc.ListSteamshipLines(r.Context(), r.URL.Query().Get("include_inactive") != "true")
+		h.respond(w, lines, err)
+	case http.MethodPost:
+		var input service.SteamshipLineInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		line, err := h.svc.CreateSteamshipLine(r.Context(), input)
+		h.respondCreated(w, line, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) steamshipLine(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/steamship-lines/")
+	if len(parts) == 2 && parts[0] == "scac" && r.Method == http.MethodGet {
+		line, err := h.svc.GetSteamshipLineBySCAC(r.Context(), parts[1])
+		h.respond(w, line, err)
+		return
+	}
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		line, err := h.svc.GetSteamshipLine(r.Context(), id)
+		h.respond(w, line, err)
+	case http.MethodPut:
+		var input service.SteamshipLineInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		line, err := h.svc.UpdateSteamshipLine(r.Context(), id, input)
+		h.respond(w, line, err)
+	case http.MethodDelete:
+		h.respondNoContent(w, h.svc.DeleteSteamshipLine(r.Context(), id))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// PORTS
+// ============================================================================
+
+func (h *Handler) ports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ports, err := h.svc.ListPorts(r.Context(), r.URL.Query().Get("include_inactive") != "true")
+		h.respond(w, ports, err)
+	case http.MethodPost:
+		var input service.PortInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		port, err := h.svc.CreatePort(r.Context(), input)
+		h.respondCreated(w, port, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) port(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/ports/")
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 2 {
+		if parts[1] != "terminals" || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		terminals, err := h.svc.GetPortTerminals(r.Context(), id)
+		h.respond(w, terminals, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		port, err := h.svc.GetPort(r.Context(), id)
+		h.respond(w, port, err)
+	case http.MethodPut:
+		var input service.PortInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		port, err := h.svc.UpdatePort(r.Context(), id, input)
+		h.respond(w, port, err)
+	case http.MethodDelete:
+		h.respondNoContent(w, h.svc.DeletePort(r.Context(), id))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// TERMINALS
+// ============================================================================
+
+func (h *Handler) terminals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		terminals, err := h.svc.ListTerminals(r.Context(), r.URL.Query().Get("include_inactive") != "true")
+		h.respond(w, terminals, err)
+	case http.MethodPost:
+		var input service.TerminalInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		terminal, err := h.svc.CreateTerminal(r.Context(), input)
+		h.respondCreated(w, terminal, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) terminal(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/terminals/")
+	if len(parts) == 2 && parts[0] == "firms" && r.Method == http.MethodGet {
+		terminal, err := h.svc.GetTerminalByFIRMSCode(r.Context(), parts[1])
+		h.respond(w, terminal, err)
+		return
+	}
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		terminal, err := h.svc.GetTerminal(r.Context(), id)
+		h.respond(w, terminal, err)
+	case http.MethodPut:
+		var input service.TerminalInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		terminal, err := h.svc.UpdateTerminal(r.Context(), id, input)
+		h.respond(w, terminal, err)
+	case http.MethodDelete:
+		h.respondNoContent(w, h.svc.DeleteTerminal(r.Context(), id))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ============================================================================
+// ALIASES
+// ============================================================================
+
+func (h *Handler) aliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var input service.AddAliasInput
+	if !h.decode(w, r, &input) {
+		return
+	}
+	alias, err := h.svc.AddAlias(r.Context(), input)
+	h.respondCreated(w, alias, err)
+}
+
+func (h *Handler) alias(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/v1/aliases/")
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if parts[0] == "resolve" && r.Method == http.MethodGet {
+		q := r.URL.Query()
+		source := domain.AliasSource(strings.ToUpper(q.Get("source")))
+		if source == "" {
+			source = domain.AliasSourceInternal
+		}
+		resolved, err := h.svc.ResolveAlias(r.Context(),
+			domain.EntityType(strings.ToUpper(q.Get("entity_type"))), source, q.Get("alias"))
+		h.respond(w, resolved, err)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+	h.respondNoContent(w, h.svc.RemoveAlias(r.Context(), id))
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func pathParts(path, prefix string) []string {
+	rest := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if rest == "" {
+		return nil
+	}
+	return strings.Split(rest, "/")
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid id", "id", raw))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) respondCreated(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (h *Handler) respondNoContent(w http.ResponseWriter, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		status = http.StatusBadRequest
+	case "NOT_FOUND":
+		status = http.StatusNotFound
+	case "CONFLICT":
+		status = http.StatusConflict
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Reference data request failed", "error", err)
+	}
+
+	writeJSON(w, status, appErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EntityType identifies the kind of reference record an alias points to
+type EntityType string
+
+const (
+	EntityTypeSteamshipLine EntityType = "STEAMSHIP_LINE"
+	EntityTypePort          EntityType = "PORT"
+	EntityTypeTerminal      EntityType = "TERMINAL"
+)
+
+// IsValid checks if the entity type is known
+func (t EntityType) IsValid() bool {
+	switch t {
+	case EntityTypeSteamshipLine, EntityTypePort, EntityTypeTerminal:
+		return true
+	}
+	return false
+}
+
+// AliasSource identifies the system whose naming an alias comes from
+type AliasSource string
+
+const (
+	AliasSourceInternal AliasSource = "INTERNAL"
+	AliasSourceEModal   AliasSource = "EMODAL"
+	AliasSourceEDI      AliasSource = "EDI"
+	AliasSourceCustomer AliasSource = "CUSTOMER"
+)
+
+// SteamshipLine represents an ocean carrier
+type SteamshipLine struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Code      string    `json:"code" db:"code"`
+	SCAC      string    `json:"scac" db:"scac"`
+	Website   string    `json:"website,omitempty" db:"website"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Port represents a seaport or inland port
+type Port struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Code      string    `json:"code" db:"code"`
+	UNLocode  string    `json:"unlocode" db:"unlocode"`
+	City      string    `json:"city" db:"city"`
+	State     string    `json:"state" db:"state"`
+	Country   string    `json:"country" db:"country"`
+	Timezone  string    `json:"timezone" db:"timezone"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Terminal represents a marine terminal or rail ramp within a port
+type Terminal struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	PortID     uuid.UUID  `json:"port_id" db:"port_id"`
+	LocationID *uuid.UUID `json:"location_id,omitempty" db:"location_id"`
+	Name       string     `json:"name" db:"name"`
+	Code       string     `json:"code" db:"code"`
+	FIRMSCode  string     `json:"firms_code" db:"firms_code"`
+	Address    string     `json:"address" db:"address"`
+	City       string     `json:"city" db:"city"`
+	State      string     `json:"state" db:"state"`
+	Latitude   float64    `json:"latitude" db:"latitude"`
+	Longitude  float64    `json:"longitude" db:"longitude"`
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Loaded relation
+	Port *Port `json:"port,omitempty" db:"-"`
+}
+
+// Alias maps an external system's name or code for a record to its internal ID
+type Alias struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	EntityType EntityType  `json:"entity_type" db:"entity_type"`
+	EntityID   uuid.UUID   `json:"entity_id" db:"entity_id"`
+	Source     AliasSource `json:"source" db:"source"`
+	Alias      string      `json:"alias" db:"alias"`
+	CreatedBy  string      `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+// ResolvedAlias is the result of resolving an external name to an internal record
+type ResolvedAlias struct {
+	EntityType EntityType  `json:"entity_type"`
+	EntityID   uuid.UUID   `json:"entity_id"`
+	Source     AliasSource `json:"source"`
+	Alias      string      `json:"alias"`
+	MatchedBy  string      `json:"matched_by"` // alias, code, scac, firms_code, unlocode, name
+}
+
+// NormalizeCode uppercases and trims a code or alias for comparison
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
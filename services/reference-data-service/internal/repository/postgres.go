@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/reference-data-service/internal/domain"
+)
+
+// Lookups return (nil, nil) when no row matches so the service can decide
+// whether a miss is an error.
+
+// ============================================================================
+// STEAMSHIP LINES
+// ============================================================================
+
+const steamshipLineColumns = `id, name, code, COALESCE(scac, ''), COALESCE(website, ''), is_active, created_at, updated_at`
+
+// PostgresSteamshipLineRepository implements SteamshipLineRepository
+type PostgresSteamshipLineRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSteamshipLineRepository creates a new PostgreSQL steamship line repository
+func NewPostgresSteamshipLineRepository(pool *pgxpool.Pool) *PostgresSteamshipLineRepository {
+	return &PostgresSteamshipLineRepository{pool: pool}
+}
+
+func (r *PostgresSteamshipLineRepository) Create(ctx context.Context, line *domain.SteamshipLine) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO steamship_lines (id, name, code, scac, website, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8)`,
+		line.ID, line.Name, line.Code, line.SCAC, line.Website, line.IsActive, line.CreatedAt, line.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresSteamshipLineRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SteamshipLine, error) {
+	return r.getOne(ctx, `WHERE id = $1`, id)
+}
+
+func (r *PostgresSteamshipLineRepository) GetBySCAC(ctx context.Context, scac string) (*domain.SteamshipLine, error) {
+	return r.getOne(ctx, `WHERE UPPER(scac) = UPPER($1)`, scac)
+}
+
+func (r *PostgresSteamshipLineRepository) GetByCode(ctx context.Context, code string) (*domain.SteamshipLine, error) {
+	return r.getOne(ctx, `WHERE UPPER(code) = UPPER($1)`, code)
+}
+
+func (r *PostgresSteamshipLineRepository) List(ctx context.Context, activeOnly bool) ([]domain.SteamshipLine, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+steamshipLineColumns+` FROM steamship_lines
+		 WHERE is_active OR NOT $1
+		 ORDER BY name`,
+		activeOnly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query steamship lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []domain.SteamshipLine
+	for rows.Next() {
+		var line domain.SteamshipLine
+		if err := scanSteamshipLine(rows, &line); err != nil {
+			return nil, fmt.Errorf("scan steamship line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *PostgresSteamshipLineRepository) Update(ctx context.Context, line *domain.SteamshipLine) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE steamship_lines
+		 SET name = $2, code = $3, scac = NULLIF($4, ''), website = NULLIF($5, ''), is_active = $6, updated_at = $7
+		 WHERE id = $1`,
+		line.ID, line.Name, line.Code, line.SCAC, line.Website, line.IsActive, line.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresSteamshipLineRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE steamship_lines SET is_active = FALSE, updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresSteamshipLineRepository) getOne(ctx context.Context, where string, arg interface{}) (*domain.SteamshipLine, error) {
+	var line domain.SteamshipLine
+	row := r.pool.QueryRow(ctx, `SELECT `+steamshipLineColumns+` FROM steamship_lines `+where, arg)
+	if err := scanSteamshipLine(row, &line); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &line, nil
+}
+
+func scanSteamshipLine(row pgx.Row, line *domain.SteamshipLine) error {
+	return row.Scan(&line.ID, &line.Name, &line.Code, &line.SCAC, &line.Website,
+		&line.IsActive, &line.CreatedAt, &line.UpdatedAt)
+}
+
+// ============================================================================
+// PORTS
+// ============================================================================
+
+const portColumns = `id, name, code, COALESCE(unlocode, ''), COALESCE(city, ''), COALESCE(state, ''),
+	COALESCE(country, ''), COALESCE(timezone, ''), is_active, created_at, updated_at`
+
+// PostgresPortRepository implements PortRepository
+type PostgresPortRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresPortRepository creates a new PostgreSQL port repository
+func NewPostgresPortRepository(pool *pgxpool.Pool) *PostgresPortRepository {
+	return &PostgresPortRepository{pool: pool}
+}
+
+func (r *PostgresPortRepository) Create(ctx context.Context, port *domain.Port) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO ports (id, name, code, unlocode, city, state, country, timezone, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10, $11)`,
+		port.ID, port.Name, port.Code, port.UNLocode, port.City, port.State, port.Country, port.Timezone,
+		port.IsActive, port.CreatedAt, port.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresPortRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Port, error) {
+	return r.getOne(ctx, `WHERE id = $1`, id)
+}
+
+func (r *PostgresPortRepository) GetByCode(ctx context.Context, code string) (*domain.Port, error) {
+	return r.getOne(ctx, `WHERE UPPER(code) = UPPER($1)`, code)
+}
+
+func (r *PostgresPortRepository) GetByUNLocode(ctx context.Context, unlocode string) (*domain.Port, error) {
+	return r.getOne(ctx, `WHERE UPPER(unlocode) = UPPER($1)`, unlocode)
+}
+
+func (r *PostgresPortRepository) List(ctx context.Context, activeOnly bool) ([]domain.Port, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+portColumns+` FROM ports
+		 WHERE is_active OR NOT $1
+		 ORDER BY name`,
+		activeOnly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query ports: %w", err)
+	}
+	defer rows.Close()
+
+	var ports []domain.Port
+	for rows.Next() {
+		var port domain.Port
+		if err := scanPort(rows, &port); err != nil {
+			return nil, fmt.Errorf("scan port: %w", err)
+		}
+		ports = append(ports, port)
+	}
+	return ports, rows.Err()
+}
+
+func (r *PostgresPortRepository) Update(ctx context.Context, port *domain.Port) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE ports
+		 SET name = $2, code = $3, unlocode = NULLIF($4, ''), city = $5, state = $6, country = $7,
+		     timezone = NULLIF($8, ''), is_active = $9, updated_at = $10
+		 WHERE id = $1`,
+		port.ID, port.Name, port.Code, port.UNLocode, port.City, port.State, port.Country, port.Timezone,
+		port.IsActive, port.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresPortRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE ports SET is_active = FALSE, updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresPortRepository) getOne(ctx context.Context, where string, arg interface{}) (*domain.Port, error) {
+	var port domain.Port
+	row := r.pool.QueryRow(ctx, `SELECT `+portColumns+` FROM ports `+where, arg)
+	if err := scanPort(row, &port); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &port, nil
+}
+
+func scanPort(row pgx.Row, port *domain.Port) error {
+	return row.Scan(&port.ID, &port.Name, &port.Code, &port.UNLocode, &port.City, &port.State,
+		&port.Country, &port.Timezone, &port.IsActive, &port.CreatedAt, &port.UpdatedAt)
+}
+
+// ============================================================================
+// TERMINALS
+// ============================================================================
+
+const terminalColumns = `id, port_id, location_id, name, code, COALESCE(firms_code, ''), COALESCE(address, ''),
+	COALESCE(city, ''), COALESCE(state, ''), COALESCE(latitude, 0), COALESCE(longitude, 0),
+	is_active, created_at, updated_at`
+
+// PostgresTerminalRepository implements TerminalRepository
+type PostgresTerminalRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresTerminalRepository creates a new PostgreSQL terminal repository
+func NewPostgresTerminalRepository(pool *pgxpool.Pool) *PostgresTerminalRepository {
+	return &PostgresTerminalRepository{pool: pool}
+}
+
+func (r *PostgresTerminalRepository) Create(ctx context.Context, t *domain.Terminal) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO terminals (id, port_id, location_id, name, code, firms_code, address, city, state,
+		                        latitude, longitude, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14)`,
+		t.ID, t.PortID, t.LocationID, t.Name, t.Code, t.FIRMSCode, t.Address, t.City, t.State,
+		t.Latitude, t.Longitude, t.IsActive, t.CreatedAt, t.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresTerminalRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Terminal, error) {
+	return r.getOne(ctx, `WHERE id = $1`, id)
+}
+
+func (r *PostgresTerminalRepository) GetByCode(ctx context.Context, code string) (*domain.Terminal, error) {
+	return r.getOne(ctx, `WHERE UPPER(code) = UPPER($1)`, code)
+}
+
+func (r *PostgresTerminalRepository) GetByFIRMSCode(ctx context.Context, firmsCode string) (*domain.Terminal, error) {
+	return r.getOne(ctx, `WHERE UPPER(firms_code) = UPPER($1)`, firmsCode)
+}
+
+func (r *PostgresTerminalRepository) GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.Terminal, error) {
+	return r.getOne(ctx, `WHERE location_id = $1`, locationID)
+}
+
+func (r *PostgresTerminalRepository) GetByPortID(ctx context.Context, portID uuid.UUID) ([]domain.Terminal, error) {
+	return r.query(ctx, `WHERE port_id = $1 AND is_active ORDER BY name`, portID)
+}
+
+func (r *PostgresTerminalRepository) List(ctx context.Context, activeOnly bool) ([]domain.Terminal, error) {
+	return r.query(ctx, `WHERE is_active OR NOT $1 ORDER BY name`, activeOnly)
+}
+
+func (r *PostgresTerminalRepository) Update(ctx context.Context, t *domain.Terminal) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE terminals
+		 SET port_id = $2, location_id = $3, name = $4, code = $5, firms_code = NULLIF($6, ''), address = $7,
+		     city = $8, state = $9, latitude = $10, longitude = $11, is_active = $12, updated_at = $13
+		 WHERE id = $1`,
+		t.ID, t.PortID, t.LocationID, t.Name, t.Code, t.FIRMSCode, t.Address, t.City, t.State,
+		t.Latitude, t.Longitude, t.IsActive, t.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresTerminalRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE terminals SET is_active = FALSE, updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresTerminalRepository) getOne(ctx context.Context, where string, arg interface{}) (*domain.Terminal, error) {
+	var t domain.Terminal
+	row := r.pool.QueryRow(ctx, `SELECT `+terminalColumns+` FROM terminals `+where, arg)
+	if err := scanTerminal(row, &t); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PostgresTerminalRepository) query(ctx context.Context, where string, arg interface{}) ([]domain.Terminal, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+terminalColumns+` FROM terminals `+where, arg)
+	if err != nil {
+		return nil, fmt.Errorf("query terminals: %w", err)
+	}
+	defer rows.Close()
+
+	var terminals []domain.Terminal
+	for rows.Next() {
+		var t domain.Terminal
+		if err := scanTerminal(rows, &t); err != nil {
+			return nil, fmt.Errorf("scan terminal: %w", err)
+		}
+		terminals = append(terminals, t)
+	}
+	return terminals, rows.Err()
+}
+
+func scanTerminal(row pgx.Row, t *domain.Terminal) error {
+	return row.Scan(&t.ID, &t.PortID, &t.LocationID, &t.Name, &t.Code, &t.FIRMSCode, &t.Address,
+		&t.City, &t.State, &t.Latitude, &t.Longitude, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// ============================================================================
+// ALIASES
+// ============================================================================
+
+// PostgresAliasRepository implements AliasRepository
+type PostgresAliasRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAliasRepository creates a new PostgreSQL alias repository
+func NewPostgresAliasRepository(pool *pgxpool.Pool) *PostgresAliasRepository {
+	return &PostgresAliasRepository{pool: pool}
+}
+
+func (r *PostgresAliasRepository) Create(ctx context.Context, a *domain.Alias) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO reference_aliases (id, entity_type, entity_id, source, alias, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		a.ID, string(a.EntityType), a.EntityID, string(a.Source), a.Alias, a.CreatedBy, a.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresAliasRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM reference_aliases WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresAliasRepository) Find(ctx context.Context, entityType domain.EntityType, source domain.AliasSource, alias string) (*domain.Alias, error) {
+	var a domain.Alias
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, entity_type, entity_id, source, alias, COALESCE(created_by, ''), created_at
+		 FROM reference_aliases
+		 WHERE entity_type = $1 AND source = $2 AND UPPER(alias) = UPPER($3)`,
+		string(entityType), string(source), alias,
+	).Scan(&a.ID, &a.EntityType, &a.EntityID, &a.Source, &a.Alias, &a.CreatedBy, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresAliasRepository) GetByEntity(ctx context.Context, entityType domain.EntityType, entityID uuid.UUID) ([]domain.Alias, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, entity_type, entity_id, source, alias, COALESCE(created_by, ''), created_at
+		 FROM reference_aliases
+		 WHERE entity_type = $1 AND entity_id = $2
+		 ORDER BY source, alias`,
+		string(entityType), entityID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []domain.Alias
+	for rows.Next() {
+		var a domain.Alias
+		if err := rows.Scan(&a.ID, &a.EntityType, &a.EntityID, &a.Source, &a.Alias, &a.CreatedBy, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reference-data-service/internal/domain"
+)
+
+// SteamshipLineRepository defines steamship line data access
+type SteamshipLineRepository interface {
+	Create(ctx context.Context, line *domain.SteamshipLine) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SteamshipLine, error)
+	GetBySCAC(ctx context.Context, scac string) (*domain.SteamshipLine, error)
+	GetByCode(ctx context.Context, code string) (*domain.SteamshipLine, error)
+	List(ctx context.Context, activeOnly bool) ([]domain.SteamshipLine, error)
+	Update(ctx context.Context, line *domain.SteamshipLine) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// PortRepository defines port data access
+type PortRepository interface {
+	Create(ctx context.Context, port *domain.Port) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Port, error)
+	GetByCode(ctx context.Context, code string) (*domain.Port, error)
+	GetByUNLocode(ctx context.Context, unlocode string) (*domain.Port, error)
+	List(ctx context.Context, activeOnly bool) ([]domain.Port, error)
+	Update(ctx context.Context, port *domain.Port) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// TerminalRepository defines terminal data access
+type TerminalRepository interface {
+	Create(ctx context.Context, terminal *domain.Terminal) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Terminal, error)
+	GetByCode(ctx context.Context, code string) (*domain.Terminal, error)
+	GetByFIRMSCode(ctx context.Context, firmsCode string) (*domain.Terminal, error)
+	GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.Terminal, error)
+	GetByPortID(ctx context.Context, portID uuid.UUID) ([]domain.Terminal, error)
+	List(ctx context.Context, activeOnly bool) ([]domain.Terminal, error)
+	Update(ctx context.Context, terminal *domain.Terminal) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// AliasRepository defines alias data access
+type AliasRepository interface {
+	Create(ctx context.Context, alias *domain.Alias) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Find(ctx context.Context, entityType domain.EntityType, source domain.AliasSource, alias string) (*domain.Alias, error)
+	GetByEntity(ctx context.Context, entityType domain.EntityType, entityID uuid.UUID) ([]domain.Alias, error)
+}
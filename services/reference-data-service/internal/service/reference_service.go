@@ -0,0 +1,751 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reference-data-service/internal/domain"
+	"github.com/draymaster/services/reference-data-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ReferenceDataService owns steamship lines, ports, terminals, and the aliases
+// other systems use for them
+type ReferenceDataService struct {
+	lineRepo      repository.SteamshipLineRepository
+	portRepo      repository.PortRepository
+	terminalRepo  repository.TerminalRepository
+	aliasRepo     repository.AliasRepository
+	eventProducer *kafka.Producer
+	logger        *logger.Logger
+}
+
+// NewReferenceDataService creates a new reference data service
+func NewReferenceDataService(
+	lineRepo repository.SteamshipLineRepository,
+	portRepo repository.PortRepository,
+	terminalRepo repository.TerminalRepository,
+	aliasRepo repository.AliasRepository,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *ReferenceDataService {
+	return &ReferenceDataService{
+		lineRepo:      lineRepo,
+		portRepo:      portRepo,
+		terminalRepo:  terminalRepo,
+		aliasRepo:     aliasRepo,
+		eventProducer: eventProducer,
+		logger:        log,
+	}
+}
+
+// ============================================================================
+// STEAMSHIP LINES
+// ============================================================================
+
+// SteamshipLineInput contains input for creating or updating a steamship line
+type SteamshipLineInput struct {
+	Name     string `json:"name"`
+	Code     string `json:"code"`
+	SCAC     string `json:"scac"`
+	Website  string `json:"website"`
+	IsActive *bool  `json:"is_active"`
+}
+
+func (in *SteamshipLineInput) validate() error {
+	if in.Name == "" {
+		return apperrors.ValidationError("name is required", "name", in.Name)
+	}
+	if in.Code == "" {
+		return apperrors.ValidationError("code is required", "code", in.Code)
+	}
+	if in.SCAC != "" && len(domain.NormalizeCode(in.SCAC)) != 4 {
+		return apperrors.ValidationError("SCAC must be 4 characters", "scac", in.SCAC)
+	}
+	return nil
+}
+
+// CreateSteamshipLine creates a steamship line
+func (s *ReferenceDataService) CreateSteamshipLine(ctx context.Context, input SteamshipLineInput) (*domain.SteamshipLine, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	if input.SCAC != "" {
+		if existing, err := s.lineRepo.GetBySCAC(ctx, input.SCAC); err != nil {
+			return nil, apperrors.DatabaseError("check scac", err)
+		} else if existing != nil {
+			return nil, apperrors.ConflictError("steamship line with SCAC " + existing.SCAC + " already exists")
+		}
+	}
+
+	now := time.Now()
+	line := &domain.SteamshipLine{
+		ID:        uuid.New(),
+		Name:      input.Name,
+		Code:      domain.NormalizeCode(input.Code),
+		SCAC:      domain.NormalizeCode(input.SCAC),
+		Website:   input.Website,
+		IsActive:  input.IsActive == nil || *input.IsActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.lineRepo.Create(ctx, line); err != nil {
+		return nil, apperrors.DatabaseError("create steamship line", err)
+	}
+
+	s.publishChange(ctx, domain.EntityTypeSteamshipLine, line.ID, "created")
+	return line, nil
+}
+
+// GetSteamshipLine returns a steamship line by ID
+func (s *ReferenceDataService) GetSteamshipLine(ctx context.Context, id uuid.UUID) (*domain.SteamshipLine, error) {
+	line, err := s.lineRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get steamship line", err)
+	}
+	if line == nil {
+		return nil, apperrors.NotFoundError("steamship line", id.String())
+	}
+	return line, nil
+}
+
+// GetSteamshipLineBySCAC returns a steamship line by its Standard Carrier Alpha Code
+func (s *ReferenceDataService) GetSteamshipLineBySCAC(ctx context.Context, scac string) (*domain.SteamshipLine, error) {
+	line, err := s.lineRepo.GetBySCAC(ctx, domain.NormalizeCode(scac))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get steamship line", err)
+	}
+	if line == nil {
+		return nil, apperrors.NotFoundError("steamship line", scac)
+	}
+	return line, nil
+}
+
+// ListSteamshipLines lists steamship lines
+func (s *ReferenceDataService) ListSteamshipLines(ctx context.Context, activeOnly bool) ([]domain.SteamshipLine, error) {
+	lines, err := s.lineRepo.List(ctx, activeOnly)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list steamship lines", err)
+	}
+	return lines, nil
+}
+
+// UpdateSteamshipLine replaces a steamship line's attributes
+func (s *ReferenceDataService) UpdateSteamshipLine(ctx context.Context, id uuid.UUID, input SteamshipLineInput) (*domain.SteamshipLine, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	line, err := s.GetSteamshipLine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.SCAC != "" {
+		if existing, err := s.lineRepo.GetBySCAC(ctx, input.SCAC); err != nil {
+			return nil, apperrors.DatabaseError("check scac", err)
+		} else if existing != nil && existing.ID != id {
+			return nil, apperrors.ConflictError("steamship line with SCAC " + existing.SCAC + " already exists")
+		}
+	}
+
+	line.Name = input.Name
+	line.Code = domain.NormalizeCode(input.Code)
+	line.SCAC = domain.NormalizeCode(input.SCAC)
+	line.Website = input.Website
+	if input.IsActive != nil {
+		line.IsActive = *input.IsActive
+	}
+	line.UpdatedAt = time.Now()
+
+	if err := s.lineRepo.Update(ctx, line); err != nil {
+		return nil, apperrors.DatabaseError("update steamship line", err)
+	}
+
+	s.publishChange(ctx, domain.EntityTypeSteamshipLine, line.ID, "updated")
+	return line, nil
+}
+
+// DeleteSteamshipLine deactivates a steamship line. Records are never hard
+// deleted because shipments keep referencing them.
+func (s *ReferenceDataService) DeleteSteamshipLine(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetSteamshipLine(ctx, id); err != nil {
+		return err
+	}
+	if err := s.lineRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete steamship line", err)
+	}
+	s.publishChange(ctx, domain.EntityTypeSteamshipLine, id, "deleted")
+	return nil
+}
+
+// ============================================================================
+// PORTS
+// ============================================================================
+
+// PortInput contains input for creating or updating a port
+type PortInput struct {
+	Name     string `json:"name"`
+	Code     string `json:"code"`
+	UNLocode string `json:"unlocode"`
+	City     string `json:"city"`
+	State    string `json:"state"`
+	Country  string `json:"country"`
+	Timezone string `json:"timezone"`
+	IsActive *bool  `json:"is_active"`
+}
+
+func (in *PortInput) validate() error {
+	if in.Name == "" {
+		return apperrors.ValidationError("name is required", "name", in.Name)
+	}
+	if in.Code == "" {
+		return apperrors.ValidationError("code is required", "code", in.Code)
+	}
+	if in.UNLocode != "" && len(domain.NormalizeCode(in.UNLocode)) != 5 {
+		return apperrors.ValidationError("UN/LOCODE must be 5 characters", "unlocode", in.UNLocode)
+	}
+	if in.Timezone != "" {
+		if _, err := time.LoadLocation(in.Timezone); err != nil {
+			return apperrors.ValidationError("invalid timezone", "timezone", in.Timezone)
+		}
+	}
+	return nil
+}
+
+// CreatePort creates a port
+func (s *ReferenceDataService) CreatePort(ctx context.Context, input PortInput) (*domain.Port, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.portRepo.GetByCode(ctx, input.Code); err != nil {
+		return nil, apperrors.DatabaseError("check port code", err)
+	} else if existing != nil {
+		return nil, apperrors.ConflictError("port with code " + existing.Code + " already exists")
+	}
+
+	country := input.Country
+	if country == "" {
+		country = "USA"
+	}
+
+	now := time.Now()
+	port := &domain.Port{
+		ID:        uuid.New(),
+		Name:      input.Name,
+		Code:      domain.NormalizeCode(input.Code),
+		UNLocode:  domain.NormalizeCode(input.UNLocode),
+		City:      input.City,
+		State:     input.State,
+		Country:   country,
+		Timezone:  input.Timezone,
+		IsActive:  input.IsActive == nil || *input.IsActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.portRepo.Create(ctx, port); err != nil {
+		return nil, apperrors.DatabaseError("create port", err)
+	}
+
+	s.publishChange(ctx, domain.EntityTypePort, port.ID, "created")
+	return port, nil
+}
+
+// GetPort returns a port by ID
+func (s *ReferenceDataService) GetPort(ctx context.Context, id uuid.UUID) (*domain.Port, error) {
+	port, err := s.portRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get port", err)
+	}
+	if port == nil {
+		return nil, apperrors.NotFoundError("port", id.String())
+	}
+	return port, nil
+}
+
+// ListPorts lists ports
+func (s *ReferenceDataService) ListPorts(ctx context.Context, activeOnly bool) ([]domain.Port, error) {
+	ports, err := s.portRepo.List(ctx, activeOnly)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list ports", err)
+	}
+	return ports, nil
+}
+
+// UpdatePort replaces a port's attributes
+func (s *ReferenceDataService) UpdatePort(ctx context.Context, id uuid.UUID, input PortInput) (*domain.Port, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	port, err := s.GetPort(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	port.Name = input.Name
+	port.Code = domain.NormalizeCode(input.Code)
+	port.UNLocode = domain.NormalizeCode(input.UNLocode)
+	port.City = input.City
+	port.State = input.State
+	if input.Country != "" {
+		port.Country = input.Country
+	}
+	port.Timezone = input.Timezone
+	if input.IsActive != nil {
+		port.IsActive = *input.IsActive
+	}
+	port.UpdatedAt = time.Now()
+
+	if err := s.portRepo.Update(ctx, port); err != nil {
+		return nil, apperrors.DatabaseError("update port", err)
+	}
+
+	s.publishChange(ctx, domain.EntityTypePort, port.ID, "updated")
+	return port, nil
+}
+
+// DeletePort deactivates a port
+func (s *ReferenceDataService) DeletePort(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetPort(ctx, id); err != nil {
+		return err
+	}
+
+	terminals, err := s.terminalRepo.GetByPortID(ctx, id)
+	if err != nil {
+		return apperrors.DatabaseError("get port terminals", err)
+	}
+	if len(terminals) > 0 {
+		return apperrors.ConflictError("port still has active terminals")
+	}
+
+	if err := s.portRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete port", err)
+	}
+	s.publishChange(ctx, domain.EntityTypePort, id, "deleted")
+	return nil
+}
+
+// GetPortTerminals returns the active terminals within a port
+func (s *ReferenceDataService) GetPortTerminals(ctx context.Context, portID uuid.UUID) ([]domain.Terminal, error) {
+	if _, err := s.GetPort(ctx, portID); err != nil {
+		return nil, err
+	}
+	terminals, err := s.terminalRepo.GetByPortID(ctx, portID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get port terminals", err)
+	}
+	return terminals, nil
+}
+
+// ============================================================================
+// TERMINALS
+// ============================================================================
+
+// TerminalInput contains input for creating or updating a terminal
+type TerminalInput struct {
+	PortID     uuid.UUID  `json:"port_id"`
+	LocationID *uuid.UUID `json:"location_id"`
+	Name       string     `json:"name"`
+	Code       string     `json:"code"`
+	FIRMSCode  string     `json:"firms_code"`
+	Address    string     `json:"address"`
+	City       string     `json:"city"`
+	State      string     `json:"state"`
+	Latitude   float64    `json:"latitude"`
+	Longitude  float64    `json:"longitude"`
+	IsActive   *bool      `json:"is_active"`
+}
+
+func (in *TerminalInput) validate() error {
+	if in.PortID == uuid.Nil {
+		return apperrors.ValidationError("port is required", "port_id", in.PortID)
+	}
+	if in.Name == "" {
+		return apperrors.ValidationError("name is required", "name", in.Name)
+	}
+	if in.Code == "" {
+		return apperrors.ValidationError("code is required", "code", in.Code)
+	}
+	if in.FIRMSCode != "" && len(domain.NormalizeCode(in.FIRMSCode)) != 4 {
+		return apperrors.ValidationError("FIRMS code must be 4 characters", "firms_code", in.FIRMSCode)
+	}
+	return nil
+}
+
+// CreateTerminal creates a terminal within a port
+func (s *ReferenceDataService) CreateTerminal(ctx context.Context, input TerminalInput) (*domain.Terminal, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	port, err := s.GetPort(ctx, input.PortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.FIRMSCode != "" {
+		if existing, err := s.terminalRepo.GetByFIRMSCode(ctx, input.FIRMSCode); err != nil {
+			return nil, apperrors.DatabaseError("check firms code", err)
+		} else if existing != nil {
+			return nil, apperrors.ConflictError("terminal with FIRMS code " + existing.FIRMSCode + " already exists")
+		}
+	}
+
+	now := time.Now()
+	terminal := &domain.Terminal{
+		ID:         uuid.New(),
+		PortID:     port.ID,
+		LocationID: input.LocationID,
+		Name:       input.Name,
+		Code:       domain.NormalizeCode(input.Code),
+		FIRMSCode:  domain.NormalizeCode(input.FIRMSCode),
+		Address:    input.Address,
+		City:       input.City,
+		State:      input.State,
+		Latitude:   input.Latitude,
+		Longitude:  input.Longitude,
+		IsActive:   input.IsActive == nil || *input.IsActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.terminalRepo.Create(ctx, terminal); err != nil {
+		return nil, apperrors.DatabaseError("create terminal", err)
+	}
+
+	terminal.Port = port
+	s.publishChange(ctx, domain.EntityTypeTerminal, terminal.ID, "created")
+	return terminal, nil
+}
+
+// GetTerminal returns a terminal with its port
+func (s *ReferenceDataService) GetTerminal(ctx context.Context, id uuid.UUID) (*domain.Terminal, error) {
+	terminal, err := s.terminalRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal", err)
+	}
+	if terminal == nil {
+		// Older records reference terminals by their location ID
+		terminal, err = s.terminalRepo.GetByLocationID(ctx, id)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get terminal", err)
+		}
+	}
+	if terminal == nil {
+		return nil, apperrors.NotFoundError("terminal", id.String())
+	}
+	return s.withPort(ctx, terminal)
+}
+
+// GetTerminalByFIRMSCode returns a terminal by its CBP FIRMS code
+func (s *ReferenceDataService) GetTerminalByFIRMSCode(ctx context.Context, firmsCode string) (*domain.Terminal, error) {
+	terminal, err := s.terminalRepo.GetByFIRMSCode(ctx, domain.NormalizeCode(firmsCode))
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal", err)
+	}
+	if terminal == nil {
+		return nil, apperrors.NotFoundError("terminal", firmsCode)
+	}
+	return s.withPort(ctx, terminal)
+}
+
+// ListTerminals lists terminals
+func (s *ReferenceDataService) ListTerminals(ctx context.Context, activeOnly bool) ([]domain.Terminal, error) {
+	terminals, err := s.terminalRepo.List(ctx, activeOnly)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list terminals", err)
+	}
+	return terminals, nil
+}
+
+// UpdateTerminal replaces a terminal's attributes
+func (s *ReferenceDataService) UpdateTerminal(ctx context.Context, id uuid.UUID, input TerminalInput) (*domain.Terminal, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	terminal, err := s.terminalRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal", err)
+	}
+	if terminal == nil {
+		return nil, apperrors.NotFoundError("terminal", id.String())
+	}
+
+	port, err := s.GetPort(ctx, input.PortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.FIRMSCode != "" {
+		if existing, err := s.terminalRepo.GetByFIRMSCode(ctx, input.FIRMSCode); err != nil {
+			return nil, apperrors.DatabaseError("check firms code", err)
+		} else if existing != nil && existing.ID != id {
+			return nil, apperrors.ConflictError("terminal with FIRMS code " + existing.FIRMSCode + " already exists")
+		}
+	}
+
+	terminal.PortID = port.ID
+	terminal.LocationID = input.LocationID
+	terminal.Name = input.Name
+	terminal.Code = domain.NormalizeCode(input.Code)
+	terminal.FIRMSCode = domain.NormalizeCode(input.FIRMSCode)
+	terminal.Address = input.Address
+	terminal.City = input.City
+	terminal.State = input.State
+	terminal.Latitude = input.Latitude
+	terminal.Longitude = input.Longitude
+	if input.IsActive != nil {
+		terminal.IsActive = *input.IsActive
+	}
+	terminal.UpdatedAt = time.Now()
+
+	if err := s.terminalRepo.Update(ctx, terminal); err != nil {
+		return nil, apperrors.DatabaseError("update terminal", err)
+	}
+
+	terminal.Port = port
+	s.publishChange(ctx, domain.EntityTypeTerminal, terminal.ID, "updated")
+	return terminal, nil
+}
+
+// DeleteTerminal deactivates a terminal
+func (s *ReferenceDataService) DeleteTerminal(ctx context.Context, id uuid.UUID) error {
+	terminal, err := s.terminalRepo.GetByID(ctx, id)
+	if err != nil {
+		return apperrors.DatabaseError("get terminal", err)
+	}
+	if terminal == nil {
+		return apperrors.NotFoundError("terminal", id.String())
+	}
+	if err := s.terminalRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete terminal", err)
+	}
+	s.publishChange(ctx, domain.EntityTypeTerminal, id, "deleted")
+	return nil
+}
+
+func (s *ReferenceDataService) withPort(ctx context.Context, terminal *domain.Terminal) (*domain.Terminal, error) {
+	port, err := s.portRepo.GetByID(ctx, terminal.PortID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get terminal port", err)
+	}
+	terminal.Port = port
+	return terminal, nil
+}
+
+// ============================================================================
+// ALIASES
+// ============================================================================
+
+// AddAliasInput contains input for registering an alias
+type AddAliasInput struct {
+	EntityType domain.EntityType  `json:"entity_type"`
+	EntityID   uuid.UUID          `json:"entity_id"`
+	Source     domain.AliasSource `json:"source"`
+	Alias      string             `json:"alias"`
+	CreatedBy  string             `json:"created_by"`
+}
+
+// AddAlias maps an external name or code to a reference record
+func (s *ReferenceDataService) AddAlias(ctx context.Context, input AddAliasInput) (*domain.Alias, error) {
+	if !input.EntityType.IsValid() {
+		return nil, apperrors.ValidationError("invalid entity type", "entity_type", input.EntityType)
+	}
+	if input.Source == "" {
+		return nil, apperrors.ValidationError("source is required", "source", input.Source)
+	}
+	if domain.NormalizeCode(input.Alias) == "" {
+		return nil, apperrors.ValidationError("alias is required", "alias", input.Alias)
+	}
+	if err := s.entityExists(ctx, input.EntityType, input.EntityID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.aliasRepo.Find(ctx, input.EntityType, input.Source, input.Alias)
+	if err != nil {
+		return nil, apperrors.DatabaseError("check alias", err)
+	}
+	if existing != nil {
+		if existing.EntityID == input.EntityID {
+			return existing, nil
+		}
+		return nil, apperrors.ConflictError("alias already maps to another record")
+	}
+
+	alias := &domain.Alias{
+		ID:         uuid.New(),
+		EntityType: input.EntityType,
+		EntityID:   input.EntityID,
+		Source:     input.Source,
+		Alias:      domain.NormalizeCode(input.Alias),
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.aliasRepo.Create(ctx, alias); err != nil {
+		return nil, apperrors.DatabaseError("create alias", err)
+	}
+
+	s.logger.Infow("Reference alias added",
+		"entity_type", alias.EntityType,
+		"entity_id", alias.EntityID,
+		"source", alias.Source,
+		"alias", alias.Alias,
+	)
+
+	s.publishChange(ctx, input.EntityType, input.EntityID, "alias_added")
+	return alias, nil
+}
+
+// RemoveAlias deletes an alias
+func (s *ReferenceDataService) RemoveAlias(ctx context.Context, id uuid.UUID) error {
+	if err := s.aliasRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete alias", err)
+	}
+	return nil
+}
+
+// GetAliases lists the aliases registered for a record
+func (s *ReferenceDataService) GetAliases(ctx context.Context, entityType domain.EntityType, entityID uuid.UUID) ([]domain.Alias, error) {
+	aliases, err := s.aliasRepo.GetByEntity(ctx, entityType, entityID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get aliases", err)
+	}
+	return aliases, nil
+}
+
+// ResolveAlias maps a name or code from an external system to an internal
+// record. Explicit aliases for the source win, then internal aliases, then the
+// record's own identifying codes.
+func (s *ReferenceDataService) ResolveAlias(ctx context.Context, entityType domain.EntityType, source domain.AliasSource, name string) (*domain.ResolvedAlias, error) {
+	if !entityType.IsValid() {
+		return nil, apperrors.ValidationError("invalid entity type", "entity_type", entityType)
+	}
+	code := domain.NormalizeCode(name)
+	if code == "" {
+		return nil, apperrors.ValidationError("alias is required", "alias", name)
+	}
+
+	sources := []domain.AliasSource{source}
+	if source != domain.AliasSourceInternal {
+		sources = append(sources, domain.AliasSourceInternal)
+	}
+	for _, src := range sources {
+		alias, err := s.aliasRepo.Find(ctx, entityType, src, code)
+		if err != nil {
+			return nil, apperrors.DatabaseError("find alias", err)
+		}
+		if alias != nil {
+			return &domain.ResolvedAlias{
+				EntityType: entityType,
+				EntityID:   alias.EntityID,
+				Source:     source,
+				Alias:      code,
+				MatchedBy:  "alias",
+			}, nil
+		}
+	}
+
+	id, matchedBy, err := s.matchCode(ctx, entityType, code)
+	if err != nil {
+		return nil, err
+	}
+	if id == uuid.Nil {
+		return nil, apperrors.NotFoundError(string(entityType), name)
+	}
+
+	return &domain.ResolvedAlias{
+		EntityType: entityType,
+		EntityID:   id,
+		Source:     source,
+		Alias:      code,
+		MatchedBy:  matchedBy,
+	}, nil
+}
+
+// matchCode looks a code up against the record's own identifiers
+func (s *ReferenceDataService) matchCode(ctx context.Context, entityType domain.EntityType, code string) (uuid.UUID, string, error) {
+	switch entityType {
+	case domain.EntityTypeSteamshipLine:
+		if line, err := s.lineRepo.GetBySCAC(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match scac", err)
+		} else if line != nil {
+			return line.ID, "scac", nil
+		}
+		if line, err := s.lineRepo.GetByCode(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match code", err)
+		} else if line != nil {
+			return line.ID, "code", nil
+		}
+	case domain.EntityTypePort:
+		if port, err := s.portRepo.GetByUNLocode(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match unlocode", err)
+		} else if port != nil {
+			return port.ID, "unlocode", nil
+		}
+		if port, err := s.portRepo.GetByCode(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match code", err)
+		} else if port != nil {
+			return port.ID, "code", nil
+		}
+	case domain.EntityTypeTerminal:
+		if terminal, err := s.terminalRepo.GetByFIRMSCode(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match firms code", err)
+		} else if terminal != nil {
+			return terminal.ID, "firms_code", nil
+		}
+		if terminal, err := s.terminalRepo.GetByCode(ctx, code); err != nil {
+			return uuid.Nil, "", apperrors.DatabaseError("match code", err)
+		} else if terminal != nil {
+			return terminal.ID, "code", nil
+		}
+	}
+	return uuid.Nil, "", nil
+}
+
+// entityExists checks that an alias target exists
+func (s *ReferenceDataService) entityExists(ctx context.Context, entityType domain.EntityType, id uuid.UUID) error {
+	var err error
+	switch entityType {
+	case domain.EntityTypeSteamshipLine:
+		_, err = s.GetSteamshipLine(ctx, id)
+	case domain.EntityTypePort:
+		_, err = s.GetPort(ctx, id)
+	case domain.EntityTypeTerminal:
+		var terminal *domain.Terminal
+		terminal, err = s.terminalRepo.GetByID(ctx, id)
+		if err != nil {
+			return apperrors.DatabaseError("get terminal", err)
+		}
+		if terminal == nil {
+			return apperrors.NotFoundError("terminal", id.String())
+		}
+	}
+	return err
+}
+
+// publishChange notifies caching clients that a record changed
+func (s *ReferenceDataService) publishChange(ctx context.Context, entityType domain.EntityType, id uuid.UUID, action string) {
+	event := kafka.NewEvent(kafka.Topics.ReferenceDataUpdated, "reference-data-service", map[string]interface{}{
+		"entity_type": string(entityType),
+		"entity_id":   id.String(),
+		"action":      action,
+	})
+	_ = s.eventProducer.Publish(ctx, kafka.Topics.ReferenceDataUpdated, event)
+
+	s.logger.Infow("Reference data changed",
+		"entity_type", entityType,
+		"entity_id", id,
+		"action", action,
+	)
+}
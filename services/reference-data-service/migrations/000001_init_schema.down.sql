@@ -0,0 +1,6 @@
+-- 000001_init_schema.down.sql
+-- steamship_lines and ports are shared with the order-service schema, which
+-- created them first, and are left in place.
+
+DROP TABLE IF EXISTS reference_aliases;
+DROP TABLE IF EXISTS terminals;
@@ -0,0 +1,135 @@
+-- ==============================================================================
+-- Reference Data Service — Initial Schema
+-- ==============================================================================
+-- Tables:
+--   steamship_lines    Ocean carriers, keyed by SCAC
+--   ports              Seaports and inland ports, keyed by UN/LOCODE
+--   terminals          Marine terminals / rail ramps, mapped to their port
+--   reference_aliases  External names/codes (eModal, EDI, customer) per record
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- steamship_lines
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS steamship_lines (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL,
+    code        VARCHAR(10)  UNIQUE NOT NULL,
+    scac        VARCHAR(4),
+    website     VARCHAR(255),
+    is_active   BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_steamship_lines_scac ON steamship_lines(UPPER(scac)) WHERE scac IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- ports
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS ports (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL,
+    code        VARCHAR(10)  UNIQUE NOT NULL,
+    unlocode    VARCHAR(5),
+    city        VARCHAR(100),
+    state       VARCHAR(50),
+    country     VARCHAR(100) DEFAULT 'USA',
+    timezone    VARCHAR(64),
+    is_active   BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ports_unlocode ON ports(UPPER(unlocode)) WHERE unlocode IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- terminals
+-- ---------------------------------------------------------------------------
+-- location_id links a terminal to its row in the shared locations table, which
+-- shipments and appointments still reference as terminal_id.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS terminals (
+    id          UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    port_id     UUID          NOT NULL REFERENCES ports(id),
+    location_id UUID,
+    name        VARCHAR(255)  NOT NULL,
+    code        VARCHAR(20)   UNIQUE NOT NULL,
+    firms_code  VARCHAR(4),
+    address     VARCHAR(500),
+    city        VARCHAR(100),
+    state       VARCHAR(50),
+    latitude    DECIMAL(10,8),
+    longitude   DECIMAL(11,8),
+    is_active   BOOLEAN       NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_terminals_firms ON terminals(UPPER(firms_code)) WHERE firms_code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_terminals_port ON terminals(port_id);
+CREATE INDEX IF NOT EXISTS idx_terminals_location ON terminals(location_id) WHERE location_id IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- reference_aliases
+-- ---------------------------------------------------------------------------
+-- One alias per (entity type, source, normalized name). Aliases are stored
+-- uppercased so lookups are case-insensitive.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS reference_aliases (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type VARCHAR(20)  NOT NULL CHECK (entity_type IN ('STEAMSHIP_LINE', 'PORT', 'TERMINAL')),
+    entity_id   UUID         NOT NULL,
+    source      VARCHAR(20)  NOT NULL,
+    alias       VARCHAR(255) NOT NULL,
+    created_by  VARCHAR(255),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, source, alias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reference_aliases_entity ON reference_aliases(entity_type, entity_id);
+
+-- ---------------------------------------------------------------------------
+-- Seed data — same IDs as the monolith schema so existing references resolve
+-- ---------------------------------------------------------------------------
+
+INSERT INTO steamship_lines (id, name, code, scac) VALUES
+    ('550e8400-e29b-41d4-a716-446655440001', 'Maersk',       'MAEU', 'MAEU'),
+    ('550e8400-e29b-41d4-a716-446655440002', 'MSC',          'MSCU', 'MSCU'),
+    ('550e8400-e29b-41d4-a716-446655440003', 'CMA CGM',      'CMDU', 'CMDU'),
+    ('550e8400-e29b-41d4-a716-446655440004', 'COSCO',        'COSU', 'COSU'),
+    ('550e8400-e29b-41d4-a716-446655440005', 'Hapag-Lloyd',  'HLCU', 'HLCU'),
+    ('550e8400-e29b-41d4-a716-446655440006', 'ONE',          'ONEY', 'ONEY'),
+    ('550e8400-e29b-41d4-a716-446655440007', 'Evergreen',    'EGLV', 'EGLV'),
+    ('550e8400-e29b-41d4-a716-446655440008', 'Yang Ming',    'YMLU', 'YMLU'),
+    ('550e8400-e29b-41d4-a716-446655440009', 'HMM',          'HDMU', 'HDMU'),
+    ('550e8400-e29b-41d4-a716-446655440010', 'ZIM',          'ZIMU', 'ZIMU')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO ports (id, name, code, unlocode, city, state, timezone) VALUES
+    ('660e8400-e29b-41d4-a716-446655440001', 'Port of Los Angeles',  'USLAX', 'USLAX', 'Los Angeles', 'CA', 'America/Los_Angeles'),
+    ('660e8400-e29b-41d4-a716-446655440002', 'Port of Long Beach',   'USLGB', 'USLGB', 'Long Beach',  'CA', 'America/Los_Angeles'),
+    ('660e8400-e29b-41d4-a716-446655440003', 'Port of Oakland',      'USOAK', 'USOAK', 'Oakland',     'CA', 'America/Los_Angeles'),
+    ('660e8400-e29b-41d4-a716-446655440004', 'Port of Seattle',      'USSEA', 'USSEA', 'Seattle',     'WA', 'America/Los_Angeles'),
+    ('660e8400-e29b-41d4-a716-446655440005', 'Port of Tacoma',       'USTAC', 'USTIW', 'Tacoma',      'WA', 'America/Los_Angeles'),
+    ('660e8400-e29b-41d4-a716-446655440006', 'Port of Houston',      'USHST', 'USHOU', 'Houston',     'TX', 'America/Chicago'),
+    ('660e8400-e29b-41d4-a716-446655440007', 'Port of Savannah',     'USSAV', 'USSAV', 'Savannah',    'GA', 'America/New_York'),
+    ('660e8400-e29b-41d4-a716-446655440008', 'Port of Charleston',   'USCHA', 'USCHS', 'Charleston',  'SC', 'America/New_York'),
+    ('660e8400-e29b-41d4-a716-446655440009', 'Port of Norfolk',      'USNFK', 'USORF', 'Norfolk',     'VA', 'America/New_York'),
+    ('660e8400-e29b-41d4-a716-446655440010', 'Port of New York',     'USNYC', 'USNYC', 'New York',    'NY', 'America/New_York')
+ON CONFLICT (code) DO NOTHING;
+
+-- Terminal IDs match their locations rows
+INSERT INTO terminals (id, port_id, location_id, name, code, city, state, latitude, longitude) VALUES
+    ('770e8400-e29b-41d4-a716-446655440001', '660e8400-e29b-41d4-a716-446655440001', '770e8400-e29b-41d4-a716-446655440001', 'APM Terminals (POLA)', 'APMT',  'Los Angeles', 'CA', 33.73980, -118.26140),
+    ('770e8400-e29b-41d4-a716-446655440002', '660e8400-e29b-41d4-a716-446655440001', '770e8400-e29b-41d4-a716-446655440002', 'TraPac (POLA)',        'TRAPAC', 'Los Angeles', 'CA', 33.75120, -118.27010),
+    ('770e8400-e29b-41d4-a716-446655440003', '660e8400-e29b-41d4-a716-446655440001', '770e8400-e29b-41d4-a716-446655440003', 'Fenix Marine (POLA)',  'FMS',   'Los Angeles', 'CA', 33.74560, -118.25890),
+    ('770e8400-e29b-41d4-a716-446655440004', '660e8400-e29b-41d4-a716-446655440002', '770e8400-e29b-41d4-a716-446655440004', 'LBCT (POLB)',          'LBCT',  'Long Beach',  'CA', 33.76540, -118.21340),
+    ('770e8400-e29b-41d4-a716-446655440005', '660e8400-e29b-41d4-a716-446655440002', '770e8400-e29b-41d4-a716-446655440005', 'PCT (POLB)',           'PCT',   'Long Beach',  'CA', 33.75890, -118.20450),
+    ('770e8400-e29b-41d4-a716-446655440006', '660e8400-e29b-41d4-a716-446655440002', '770e8400-e29b-41d4-a716-446655440006', 'TTI (POLB)',           'TTI',   'Long Beach',  'CA', 33.76230, -118.21870),
+    ('770e8400-e29b-41d4-a716-446655440007', '660e8400-e29b-41d4-a716-446655440002', '770e8400-e29b-41d4-a716-446655440007', 'ITS (POLB)',           'ITS',   'Long Beach',  'CA', 33.75980, -118.20980)
+ON CONFLICT (code) DO NOTHING;
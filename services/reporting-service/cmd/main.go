@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/email"
+	"github.com/draymaster/shared/pkg/leader"
+	"github.com/draymaster/shared/pkg/logger"
+
+	"github.com/draymaster/services/reporting-service/internal/api"
+	"github.com/draymaster/services/reporting-service/internal/repository"
+	"github.com/draymaster/services/reporting-service/internal/service"
+)
+
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
+// schedulerInterval is how often due scheduled reports are checked
+const schedulerInterval = time.Minute
+
+func main() {
+	cfg := config.Load()
+	cfg.Service.Name = "reporting-service"
+
+	log, err := logger.New(cfg.Service.Name, cfg.Service.Environment, cfg.Service.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	log.Infow("Starting reporting service",
+		"service", cfg.Service.Name,
+		"version", Version,
+		"buildTime", BuildTime,
+		"environment", cfg.Service.Environment,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Database — point DB_HOST at a read replica in production; report
+	// queries run read-only with a statement timeout either way
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalw("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+	log.Info("Database connected")
+
+	reportService := service.NewReportService(
+		repository.NewPostgresReportRepository(db.Pool),
+		repository.NewPostgresQueryExecutor(db.Pool),
+		email.NewSMTPSender(cfg.SMTP),
+		log,
+	)
+
+	// One replica sends the scheduled reports. Advisory locks are local to the
+	// server they are taken on, so every replica must share DB_HOST.
+	elector := leader.NewElector(leader.NewPostgresLocker(db), leader.Config{}, log)
+	go elector.Run(ctx, "reporting.scheduler", func(ctx context.Context) {
+		reportService.StartScheduler(ctx, schedulerInterval)
+	})
+	log.Infow("Report scheduler started", "interval", schedulerInterval)
+
+	// HTTP API
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler:      api.NewHandler(reportService, log).Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	go func() {
+		log.Infow("HTTP server starting", "port", cfg.Server.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalw("HTTP server failed", "error", err)
+		}
+	}()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Info("Shutting down...")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("HTTP server shutdown error", "error", err)
+	}
+	log.Info("Reporting service stopped")
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reporting-service/internal/service"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// userHeader carries the authenticated user ID, set by the API gateway
+const userHeader = "X-User-ID"
+
+// Handler serves the report builder HTTP API
+type Handler struct {
+	svc    *service.ReportService
+	logger *logger.Logger
+}
+
+// NewHandler creates a new report builder HTTP handler
+func NewHandler(svc *service.ReportService, log *logger.Logger) *Handler {
+	return &Handler{svc: svc, logger: log}
+}
+
+// Routes returns the HTTP routes for the API
+//
+//	GET                 /v1/datasets
+//	POST                /v1/reports/preview
+//	GET/POST            /v1/reports
+//	GET/PUT/DELETE      /v1/reports/{id}
+//	POST                /v1/reports/{id}/run          (?format=csv for a download)
+//	GET                 /v1/reports/{id}/runs
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	mux.HandleFunc("/v1/datasets", h.datasets)
+	mux.HandleFunc("/v1/reports", h.reports)
+	mux.HandleFunc("/v1/reports/", h.report)
+
+	return mux
+}
+
+func (h *Handler) datasets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.svc.ListDatasets())
+}
+
+func (h *Handler) reports(w http.ResponseWriter, r *http.Request) {
+	owner, ok := h.owner(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		reports, err := h.svc.ListReports(r.Context(), owner)
+		h.respond(w, reports, err)
+	case http.MethodPost:
+		var input service.ReportInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		report, err := h.svc.CreateReport(r.Context(), owner, input)
+		h.respondCreated(w, report, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) report(w http.ResponseWriter, r *http.Request) {
+	owner, ok := h.owner(w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/v1/reports/")
+	if len(parts) == 1 && parts[0] == "preview" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var input service.ReportInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		result, err := h.svc.Preview(r.Context(), input)
+		h.respond(w, result, err)
+		return
+	}
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	id, ok := h.parseID(w, parts[0])
+	if !ok {
+		return
+	}
+
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "run" && r.Method == http.MethodPost:
+			h.run(w, r, id, owner)
+		case parts[1] == "runs" && r.Method == http.MethodGet:
+			runs, err := h.svc.ListRuns(r.Context(), id, owner)
+			h.respond(w, runs, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		report, err := h.svc.GetReport(r.Context(), id, owner)
+		h.respond(w, report, err)
+	case http.MethodPut:
+		var input service.ReportInput
+		if !h.decode(w, r, &input) {
+			return
+		}
+		report, err := h.svc.UpdateReport(r.Context(), id, owner, input)
+		h.respond(w, report, err)
+	case http.MethodDelete:
+		h.respondNoContent(w, h.svc.DeleteReport(r.Context(), id, owner))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) run(w http.ResponseWriter, r *http.Request, id uuid.UUID, owner string) {
+	result, err := h.svc.RunReport(r.Context(), id, owner)
+	if err != nil || r.URL.Query().Get("format") != "csv" {
+		h.respond(w, result, err)
+		return
+	}
+
+	data, err := service.ExportCSV(result)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "report-"+id.String()+".csv"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func (h *Handler) owner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	owner := strings.TrimSpace(r.Header.Get(userHeader))
+	if owner == "" {
+		writeJSON(w, http.StatusUnauthorized, apperrors.New("UNAUTHORIZED", "missing user identity"))
+		return "", false
+	}
+	return owner, true
+}
+
+func pathParts(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid id", "id", raw))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.writeError(w, apperrors.ValidationError("invalid request body", "body", nil))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) respondCreated(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (h *Handler) respondNoContent(w http.ResponseWriter, err error) {
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	status := http.StatusInternalServerError
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		status = http.StatusBadRequest
+	case "NOT_FOUND":
+		status = http.StatusNotFound
+	case "CONFLICT":
+		status = http.StatusConflict
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Report request failed", "error", err)
+	}
+
+	writeJSON(w, status, appErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
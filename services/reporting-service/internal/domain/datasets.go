@@ -0,0 +1,113 @@
+package domain
+
+// Field is a dimension or measure a report may select. Expr is trusted SQL
+// from this catalog; user input never becomes part of the query text.
+type Field struct {
+	Name  string   `json:"name"`
+	Label string   `json:"label"`
+	Type  DataType `json:"type"`
+	Expr  string   `json:"-"`
+}
+
+// Dataset is a predefined source that reports are built on
+type Dataset struct {
+	Name       string  `json:"name"`
+	Label      string  `json:"label"`
+	Dimensions []Field `json:"dimensions"`
+	Measures   []Field `json:"measures"`
+	From       string  `json:"-"`
+	Where      string  `json:"-"` // Always applied
+}
+
+// Dimension returns the named dimension, if the dataset has it
+func (d *Dataset) Dimension(name string) (Field, bool) {
+	for _, f := range d.Dimensions {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Measure returns the named measure, if the dataset has it
+func (d *Dataset) Measure(name string) (Field, bool) {
+	for _, f := range d.Measures {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Datasets is the catalog of reportable datasets keyed by name
+var Datasets = map[string]*Dataset{
+	"trips": {
+		Name:  "trips",
+		Label: "Trips",
+		From:  "trips t LEFT JOIN drivers d ON d.id = t.driver_id",
+		Where: "t.deleted_at IS NULL",
+		Dimensions: []Field{
+			{Name: "status", Label: "Status", Type: DataTypeString, Expr: "t.status::text"},
+			{Name: "type", Label: "Trip Type", Type: DataTypeString, Expr: "t.type::text"},
+			{Name: "driver_name", Label: "Driver", Type: DataTypeString, Expr: "COALESCE(d.first_name || ' ' || d.last_name, 'Unassigned')"},
+			{Name: "planned_date", Label: "Planned Date", Type: DataTypeTime, Expr: "date_trunc('day', t.planned_start_time)"},
+			{Name: "completed_date", Label: "Completed Date", Type: DataTypeTime, Expr: "date_trunc('day', t.actual_end_time)"},
+			{Name: "is_street_turn", Label: "Street Turn", Type: DataTypeBool, Expr: "t.is_street_turn"},
+		},
+		Measures: []Field{
+			{Name: "trip_count", Label: "Trips", Type: DataTypeNumber, Expr: "COUNT(*)"},
+			{Name: "total_miles", Label: "Total Miles", Type: DataTypeNumber, Expr: "COALESCE(SUM(t.total_miles), 0)"},
+			{Name: "revenue", Label: "Revenue", Type: DataTypeNumber, Expr: "COALESCE(SUM(t.revenue), 0)"},
+			{Name: "avg_duration_mins", Label: "Avg Duration (mins)", Type: DataTypeNumber, Expr: "ROUND(AVG(t.estimated_duration_mins), 1)"},
+		},
+	},
+	"orders": {
+		Name:  "orders",
+		Label: "Orders",
+		From:  "orders o",
+		Where: "o.deleted_at IS NULL",
+		Dimensions: []Field{
+			{Name: "status", Label: "Status", Type: DataTypeString, Expr: "o.status::text"},
+			{Name: "type", Label: "Order Type", Type: DataTypeString, Expr: "o.type::text"},
+			{Name: "move_type", Label: "Move Type", Type: DataTypeString, Expr: "o.move_type"},
+			{Name: "billing_status", Label: "Billing Status", Type: DataTypeString, Expr: "o.billing_status::text"},
+			{Name: "created_date", Label: "Created Date", Type: DataTypeTime, Expr: "date_trunc('day', o.created_at)"},
+		},
+		Measures: []Field{
+			{Name: "order_count", Label: "Orders", Type: DataTypeNumber, Expr: "COUNT(*)"},
+		},
+	},
+	"detention": {
+		Name:  "detention",
+		Label: "Detention",
+		From:  "trip_stops s JOIN trips t ON t.id = s.trip_id LEFT JOIN locations l ON l.id = s.location_id",
+		Where: "s.detention_mins > 0",
+		Dimensions: []Field{
+			{Name: "location_name", Label: "Location", Type: DataTypeString, Expr: "l.name"},
+			{Name: "activity", Label: "Activity", Type: DataTypeString, Expr: "s.activity::text"},
+			{Name: "trip_number", Label: "Trip", Type: DataTypeString, Expr: "t.trip_number"},
+			{Name: "departure_date", Label: "Departure Date", Type: DataTypeTime, Expr: "date_trunc('day', s.actual_departure)"},
+		},
+		Measures: []Field{
+			{Name: "stop_count", Label: "Stops", Type: DataTypeNumber, Expr: "COUNT(*)"},
+			{Name: "detention_mins", Label: "Detention (mins)", Type: DataTypeNumber, Expr: "SUM(s.detention_mins)"},
+			{Name: "detention_charge", Label: "Detention Charge", Type: DataTypeNumber, Expr: "COALESCE(SUM(s.detention_charge), 0)"},
+		},
+	},
+	"hos": {
+		Name:  "hos",
+		Label: "Hours of Service",
+		From:  "hos_logs h JOIN drivers d ON d.id = h.driver_id",
+		Dimensions: []Field{
+			{Name: "driver_name", Label: "Driver", Type: DataTypeString, Expr: "d.first_name || ' ' || d.last_name"},
+			{Name: "status", Label: "Duty Status", Type: DataTypeString, Expr: "h.status"},
+			{Name: "source", Label: "Source", Type: DataTypeString, Expr: "h.source"},
+			{Name: "log_date", Label: "Log Date", Type: DataTypeTime, Expr: "date_trunc('day', h.start_time)"},
+		},
+		Measures: []Field{
+			{Name: "log_count", Label: "Log Entries", Type: DataTypeNumber, Expr: "COUNT(*)"},
+			{Name: "duty_hours", Label: "Hours", Type: DataTypeNumber, Expr: "ROUND(SUM(h.duration_mins) / 60.0, 2)"},
+			{Name: "edited_count", Label: "Edited Entries", Type: DataTypeNumber, Expr: "COUNT(h.original_log_id)"},
+		},
+	},
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataType is the type of a report field, used to cast filter values
+type DataType string
+
+const (
+	DataTypeString DataType = "STRING"
+	DataTypeNumber DataType = "NUMBER"
+	DataTypeTime   DataType = "TIME"
+	DataTypeBool   DataType = "BOOL"
+)
+
+// FilterOperator is a comparison allowed in report filters
+type FilterOperator string
+
+const (
+	FilterEquals      FilterOperator = "eq"
+	FilterNotEquals   FilterOperator = "neq"
+	FilterGreater     FilterOperator = "gt"
+	FilterGreaterOrEq FilterOperator = "gte"
+	FilterLess        FilterOperator = "lt"
+	FilterLessOrEq    FilterOperator = "lte"
+	FilterIn          FilterOperator = "in"
+	FilterBetween     FilterOperator = "between"
+	FilterContains    FilterOperator = "contains"
+)
+
+// ScheduleFrequency is how often a saved report runs
+type ScheduleFrequency string
+
+const (
+	FrequencyDaily   ScheduleFrequency = "DAILY"
+	FrequencyWeekly  ScheduleFrequency = "WEEKLY"
+	FrequencyMonthly ScheduleFrequency = "MONTHLY"
+)
+
+// RunStatus is the outcome of a report execution
+type RunStatus string
+
+const (
+	RunStatusSucceeded RunStatus = "SUCCEEDED"
+	RunStatusFailed    RunStatus = "FAILED"
+)
+
+// Filter restricts a report to rows matching a field condition. Values are
+// strings and cast to the field's data type in the generated SQL.
+type Filter struct {
+	Field    string         `json:"field"`
+	Operator FilterOperator `json:"operator"`
+	Values   []string       `json:"values"`
+}
+
+// ReportSchedule configures scheduled execution and email delivery
+type ReportSchedule struct {
+	Frequency  ScheduleFrequency `json:"frequency"`
+	HourUTC    int               `json:"hour_utc"`
+	Weekday    time.Weekday      `json:"weekday,omitempty"`      // WEEKLY only
+	DayOfMonth int               `json:"day_of_month,omitempty"` // MONTHLY only, 1-28
+	Recipients []string          `json:"recipients"`
+}
+
+// NextRun returns the first scheduled time strictly after the given time
+func (s *ReportSchedule) NextRun(after time.Time) time.Time {
+	after = after.UTC()
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.HourUTC, 0, 0, 0, time.UTC)
+
+	switch s.Frequency {
+	case FrequencyWeekly:
+		next = next.AddDate(0, 0, (int(s.Weekday)-int(next.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+	case FrequencyMonthly:
+		next = time.Date(after.Year(), after.Month(), s.DayOfMonth, s.HourUTC, 0, 0, 0, time.UTC)
+		if !next.After(after) {
+			next = next.AddDate(0, 1, 0)
+		}
+	default:
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// ReportDefinition is a saved report owned by a user
+type ReportDefinition struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	OwnerID     string          `json:"owner_id" db:"owner_id"`
+	Name        string          `json:"name" db:"name"`
+	Description string          `json:"description,omitempty" db:"description"`
+	Dataset     string          `json:"dataset" db:"dataset"`
+	Dimensions  []string        `json:"dimensions" db:"dimensions"`
+	Measures    []string        `json:"measures" db:"measures"`
+	Filters     []Filter        `json:"filters,omitempty" db:"filters"`
+	SortBy      string          `json:"sort_by,omitempty" db:"sort_by"`
+	SortDesc    bool            `json:"sort_desc" db:"sort_desc"`
+	Limit       int             `json:"limit,omitempty" db:"row_limit"`
+	Schedule    *ReportSchedule `json:"schedule,omitempty" db:"schedule"`
+	NextRunAt   *time.Time      `json:"next_run_at,omitempty" db:"next_run_at"`
+	LastRunAt   *time.Time      `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ResultColumn describes a column of a report result
+type ResultColumn struct {
+	Name  string   `json:"name"`
+	Label string   `json:"label"`
+	Type  DataType `json:"type"`
+}
+
+// ReportResult is the output of running a report
+type ReportResult struct {
+	Columns     []ResultColumn  `json:"columns"`
+	Rows        [][]interface{} `json:"rows"`
+	RowCount    int             `json:"row_count"`
+	Truncated   bool            `json:"truncated"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// ReportRun is the audit record of a scheduled or on-demand execution
+type ReportRun struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	ReportID    uuid.UUID `json:"report_id" db:"report_id"`
+	TriggeredBy string    `json:"triggered_by" db:"triggered_by"` // user ID or "scheduler"
+	Status      RunStatus `json:"status" db:"status"`
+	RowCount    int       `json:"row_count" db:"row_count"`
+	DurationMs  int64     `json:"duration_ms" db:"duration_ms"`
+	DeliveredTo []string  `json:"delivered_to,omitempty" db:"delivered_to"`
+	Error       string    `json:"error,omitempty" db:"error"`
+	StartedAt   time.Time `json:"started_at" db:"started_at"`
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/reporting-service/internal/domain"
+)
+
+// ============================================================================
+// REPORT DEFINITIONS
+// ============================================================================
+
+const reportColumns = `id, owner_id, name, COALESCE(description, ''), dataset, dimensions, measures, filters,
+	COALESCE(sort_by, ''), sort_desc, row_limit, schedule, next_run_at, last_run_at, created_at, updated_at`
+
+// PostgresReportRepository implements ReportRepository
+type PostgresReportRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresReportRepository creates a new PostgreSQL report repository
+func NewPostgresReportRepository(pool *pgxpool.Pool) *PostgresReportRepository {
+	return &PostgresReportRepository{pool: pool}
+}
+
+func (r *PostgresReportRepository) Create(ctx context.Context, def *domain.ReportDefinition) error {
+	dims, measures, filters, schedule, err := marshalDefinition(def)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO report_definitions (id, owner_id, name, description, dataset, dimensions, measures, filters,
+			sort_by, sort_desc, row_limit, schedule, next_run_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13, $14, $15)`,
+		def.ID, def.OwnerID, def.Name, def.Description, def.Dataset, dims, measures, filters,
+		def.SortBy, def.SortDesc, def.Limit, schedule, def.NextRunAt, def.CreatedAt, def.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresReportRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ReportDefinition, error) {
+	var def domain.ReportDefinition
+	row := r.pool.QueryRow(ctx, `SELECT `+reportColumns+` FROM report_definitions WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err := scanReport(row, &def); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &def, nil
+}
+
+func (r *PostgresReportRepository) ListByOwner(ctx context.Context, ownerID string) ([]domain.ReportDefinition, error) {
+	return r.list(ctx, `WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY name`, ownerID)
+}
+
+func (r *PostgresReportRepository) ListDue(ctx context.Context, now time.Time) ([]domain.ReportDefinition, error) {
+	return r.list(ctx, `WHERE next_run_at <= $1 AND deleted_at IS NULL ORDER BY next_run_at`, now)
+}
+
+func (r *PostgresReportRepository) Update(ctx context.Context, def *domain.ReportDefinition) error {
+	dims, measures, filters, schedule, err := marshalDefinition(def)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`UPDATE report_definitions
+		 SET name = $2, description = NULLIF($3, ''), dataset = $4, dimensions = $5, measures = $6, filters = $7,
+			sort_by = NULLIF($8, ''), sort_desc = $9, row_limit = $10, schedule = $11, next_run_at = $12,
+			last_run_at = $13, updated_at = $14
+		 WHERE id = $1`,
+		def.ID, def.Name, def.Description, def.Dataset, dims, measures, filters,
+		def.SortBy, def.SortDesc, def.Limit, schedule, def.NextRunAt, def.LastRunAt, def.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresReportRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE report_definitions SET deleted_at = NOW(), next_run_at = NULL WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresReportRepository) CreateRun(ctx context.Context, run *domain.ReportRun) error {
+	deliveredTo, err := json.Marshal(run.DeliveredTo)
+	if err != nil {
+		return fmt.Errorf("marshal recipients: %w", err)
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO report_runs (id, report_id, triggered_by, status, row_count, duration_ms, delivered_to, error, started_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)`,
+		run.ID, run.ReportID, run.TriggeredBy, run.Status, run.RowCount, run.DurationMs, deliveredTo, run.Error, run.StartedAt,
+	)
+	return err
+}
+
+func (r *PostgresReportRepository) ListRuns(ctx context.Context, reportID uuid.UUID, limit int) ([]domain.ReportRun, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, report_id, triggered_by, status, row_count, duration_ms, delivered_to, COALESCE(error, ''), started_at
+		 FROM report_runs WHERE report_id = $1 ORDER BY started_at DESC LIMIT $2`,
+		reportID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query report runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.ReportRun
+	for rows.Next() {
+		var run domain.ReportRun
+		var deliveredTo []byte
+		if err := rows.Scan(&run.ID, &run.ReportID, &run.TriggeredBy, &run.Status, &run.RowCount,
+			&run.DurationMs, &deliveredTo, &run.Error, &run.StartedAt); err != nil {
+			return nil, fmt.Errorf("scan report run: %w", err)
+		}
+		if len(deliveredTo) > 0 {
+			if err := json.Unmarshal(deliveredTo, &run.DeliveredTo); err != nil {
+				return nil, fmt.Errorf("decode recipients: %w", err)
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *PostgresReportRepository) list(ctx context.Context, where string, arg interface{}) ([]domain.ReportDefinition, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+reportColumns+` FROM report_definitions `+where, arg)
+	if err != nil {
+		return nil, fmt.Errorf("query report definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []domain.ReportDefinition
+	for rows.Next() {
+		var def domain.ReportDefinition
+		if err := scanReport(rows, &def); err != nil {
+			return nil, fmt.Errorf("scan report definition: %w", err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, rows.Err()
+}
+
+func marshalDefinition(def *domain.ReportDefinition) (dims, measures, filters, schedule []byte, err error) {
+	if dims, err = json.Marshal(def.Dimensions); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("marshal dimensions: %w", err)
+	}
+	if measures, err = json.Marshal(def.Measures); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("marshal measures: %w", err)
+	}
+	if filters, err = json.Marshal(def.Filters); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("marshal filters: %w", err)
+	}
+	if def.Schedule != nil {
+		if schedule, err = json.Marshal(def.Schedule); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("marshal schedule: %w", err)
+		}
+	}
+	return dims, measures, filters, schedule, nil
+}
+
+func scanReport(row pgx.Row, def *domain.ReportDefinition) error {
+	var dims, measures, filters, schedule []byte
+	if err := row.Scan(&def.ID, &def.OwnerID, &def.Name, &def.Description, &def.Dataset,
+		&dims, &measures, &filters, &def.SortBy, &def.SortDesc, &def.Limit, &schedule,
+		&def.NextRunAt, &def.LastRunAt, &def.CreatedAt, &def.UpdatedAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(dims, &def.Dimensions); err != nil {
+		return fmt.Errorf("decode dimensions: %w", err)
+	}
+	if err := json.Unmarshal(measures, &def.Measures); err != nil {
+		return fmt.Errorf("decode measures: %w", err)
+	}
+	if len(filters) > 0 {
+		if err := json.Unmarshal(filters, &def.Filters); err != nil {
+			return fmt.Errorf("decode filters: %w", err)
+		}
+	}
+	if len(schedule) > 0 {
+		def.Schedule = &domain.ReportSchedule{}
+		if err := json.Unmarshal(schedule, def.Schedule); err != nil {
+			return fmt.Errorf("decode schedule: %w", err)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// QUERY EXECUTION
+// ============================================================================
+
+// PostgresQueryExecutor implements QueryExecutor, ideally against a read replica
+type PostgresQueryExecutor struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresQueryExecutor creates a new PostgreSQL report query executor
+func NewPostgresQueryExecutor(pool *pgxpool.Pool) *PostgresQueryExecutor {
+	return &PostgresQueryExecutor{pool: pool}
+}
+
+// Query runs the statement read-only with a statement timeout, so even a
+// generated query can neither write nor hold the database
+func (e *PostgresQueryExecutor) Query(ctx context.Context, sql string, args []interface{}, timeout time.Duration) ([][]interface{}, error) {
+	tx, err := e.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("set statement timeout: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("run report query: %w", err)
+	}
+	defer rows.Close()
+
+	var result [][]interface{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("read report row: %w", err)
+		}
+		result = append(result, values)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reporting-service/internal/domain"
+)
+
+// ReportRepository defines saved report definition and run history access.
+// GetByID returns (nil, nil) when no report matches.
+type ReportRepository interface {
+	Create(ctx context.Context, def *domain.ReportDefinition) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ReportDefinition, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]domain.ReportDefinition, error)
+	Update(ctx context.Context, def *domain.ReportDefinition) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListDue(ctx context.Context, now time.Time) ([]domain.ReportDefinition, error)
+	CreateRun(ctx context.Context, run *domain.ReportRun) error
+	ListRuns(ctx context.Context, reportID uuid.UUID, limit int) ([]domain.ReportRun, error)
+}
+
+// QueryExecutor runs generated report SQL in a read-only transaction
+type QueryExecutor interface {
+	Query(ctx context.Context, sql string, args []interface{}, timeout time.Duration) ([][]interface{}, error)
+}
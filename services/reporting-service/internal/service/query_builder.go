@@ -0,0 +1,226 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draymaster/services/reporting-service/internal/domain"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+const (
+	defaultRowLimit = 1000
+	maxRowLimit     = 10000
+	maxFilterValues = 100
+)
+
+// builtQuery is a guarded SQL statement produced from a report definition
+type builtQuery struct {
+	SQL     string
+	Args    []interface{}
+	Columns []domain.ResultColumn
+	Limit   int
+}
+
+// buildQuery turns a report definition into parameterized SQL. Only
+// expressions from the dataset catalog reach the query text; every user
+// supplied value is bound as a parameter and cast to the field type.
+func buildQuery(def *domain.ReportDefinition) (*builtQuery, error) {
+	dataset, ok := domain.Datasets[def.Dataset]
+	if !ok {
+		return nil, apperrors.ValidationError("unknown dataset", "dataset", def.Dataset)
+	}
+	if len(def.Measures) == 0 && len(def.Dimensions) == 0 {
+		return nil, apperrors.ValidationError("report must select at least one dimension or measure", "measures", nil)
+	}
+
+	q := &builtQuery{}
+	selected := make(map[string]int)
+	var selects, groupBy []string
+
+	for _, name := range def.Dimensions {
+		field, ok := dataset.Dimension(name)
+		if !ok {
+			return nil, apperrors.ValidationError("unknown dimension for dataset "+dataset.Name, "dimensions", name)
+		}
+		if _, dup := selected[name]; dup {
+			return nil, apperrors.ValidationError("field selected twice", "dimensions", name)
+		}
+		selects = append(selects, fmt.Sprintf("%s AS %q", field.Expr, field.Name))
+		q.Columns = append(q.Columns, domain.ResultColumn{Name: field.Name, Label: field.Label, Type: field.Type})
+		selected[name] = len(selects)
+		groupBy = append(groupBy, strconv.Itoa(len(selects)))
+	}
+
+	for _, name := range def.Measures {
+		field, ok := dataset.Measure(name)
+		if !ok {
+			return nil, apperrors.ValidationError("unknown measure for dataset "+dataset.Name, "measures", name)
+		}
+		if _, dup := selected[name]; dup {
+			return nil, apperrors.ValidationError("field selected twice", "measures", name)
+		}
+		selects = append(selects, fmt.Sprintf("%s AS %q", field.Expr, field.Name))
+		q.Columns = append(q.Columns, domain.ResultColumn{Name: field.Name, Label: field.Label, Type: field.Type})
+		selected[name] = len(selects)
+	}
+
+	var where []string
+	if dataset.Where != "" {
+		where = append(where, dataset.Where)
+	}
+	for _, filter := range def.Filters {
+		field, ok := dataset.Dimension(filter.Field)
+		if !ok {
+			return nil, apperrors.ValidationError("filters are only allowed on dimensions of dataset "+dataset.Name, "filters", filter.Field)
+		}
+		clause, args, err := buildFilter(field, filter, len(q.Args))
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, clause)
+		q.Args = append(q.Args, args...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(strings.Join(selects, ", "))
+	sb.WriteString(" FROM ")
+	sb.WriteString(dataset.From)
+	if len(where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+	}
+	if len(groupBy) > 0 && len(def.Measures) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(groupBy, ", "))
+	}
+
+	if def.SortBy != "" {
+		pos, ok := selected[def.SortBy]
+		if !ok {
+			return nil, apperrors.ValidationError("sort field must be a selected column", "sort_by", def.SortBy)
+		}
+		direction := "ASC"
+		if def.SortDesc {
+			direction = "DESC"
+		}
+		fmt.Fprintf(&sb, " ORDER BY %d %s NULLS LAST", pos, direction)
+	} else if len(groupBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(groupBy, ", "))
+	}
+
+	q.Limit = def.Limit
+	if q.Limit <= 0 {
+		q.Limit = defaultRowLimit
+	}
+	if q.Limit > maxRowLimit {
+		q.Limit = maxRowLimit
+	}
+	// Fetch one extra row to detect truncation
+	fmt.Fprintf(&sb, " LIMIT %d", q.Limit+1)
+
+	q.SQL = sb.String()
+	return q, nil
+}
+
+// buildFilter renders one filter condition with bind parameters numbered after offset
+func buildFilter(field domain.Field, filter domain.Filter, offset int) (string, []interface{}, error) {
+	if len(filter.Values) == 0 || len(filter.Values) > maxFilterValues {
+		return "", nil, apperrors.ValidationError(
+			fmt.Sprintf("filter needs between 1 and %d values", maxFilterValues), "filters", filter.Field)
+	}
+
+	args := make([]interface{}, 0, len(filter.Values))
+	for _, v := range filter.Values {
+		if err := validateValue(field.Type, v); err != nil {
+			return "", nil, apperrors.ValidationError(err.Error(), "filters", filter.Field)
+		}
+		args = append(args, v)
+	}
+
+	cast := castFor(field.Type)
+	param := func(i int) string {
+		return fmt.Sprintf("$%d%s", offset+i+1, cast)
+	}
+
+	var op string
+	switch filter.Operator {
+	case domain.FilterEquals:
+		op = "="
+	case domain.FilterNotEquals:
+		op = "<>"
+	case domain.FilterGreater:
+		op = ">"
+	case domain.FilterGreaterOrEq:
+		op = ">="
+	case domain.FilterLess:
+		op = "<"
+	case domain.FilterLessOrEq:
+		op = "<="
+	case domain.FilterIn:
+		placeholders := make([]string, len(args))
+		for i := range args {
+			placeholders[i] = param(i)
+		}
+		return fmt.Sprintf("(%s) IN (%s)", field.Expr, strings.Join(placeholders, ", ")), args, nil
+	case domain.FilterBetween:
+		if len(args) != 2 {
+			return "", nil, apperrors.ValidationError("between filter needs exactly 2 values", "filters", filter.Field)
+		}
+		return fmt.Sprintf("(%s) BETWEEN %s AND %s", field.Expr, param(0), param(1)), args, nil
+	case domain.FilterContains:
+		if field.Type != domain.DataTypeString {
+			return "", nil, apperrors.ValidationError("contains filter only applies to text fields", "filters", filter.Field)
+		}
+		if len(args) != 1 {
+			return "", nil, apperrors.ValidationError("contains filter needs exactly 1 value", "filters", filter.Field)
+		}
+		return fmt.Sprintf("(%s) ILIKE '%%' || %s || '%%'", field.Expr, param(0)), args, nil
+	default:
+		return "", nil, apperrors.ValidationError("unsupported filter operator", "filters", filter.Operator)
+	}
+
+	if len(args) != 1 {
+		return "", nil, apperrors.ValidationError(fmt.Sprintf("%s filter needs exactly 1 value", filter.Operator), "filters", filter.Field)
+	}
+	return fmt.Sprintf("(%s) %s %s", field.Expr, op, param(0)), args, nil
+}
+
+// castFor returns the parameter cast for a field type
+func castFor(t domain.DataType) string {
+	switch t {
+	case domain.DataTypeNumber:
+		return "::numeric"
+	case domain.DataTypeTime:
+		return "::timestamptz"
+	case domain.DataTypeBool:
+		return "::boolean"
+	}
+	return "::text"
+}
+
+// validateValue rejects values that would fail the cast, so users get a
+// validation error rather than a database error
+func validateValue(t domain.DataType, v string) error {
+	switch t {
+	case domain.DataTypeNumber:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+	case domain.DataTypeTime:
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				return fmt.Errorf("%q is not a date (YYYY-MM-DD or RFC 3339)", v)
+			}
+		}
+	case domain.DataTypeBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/draymaster/services/reporting-service/internal/domain"
+)
+
+func TestBuildQuery(t *testing.T) {
+	def := &domain.ReportDefinition{
+		Dataset:    "trips",
+		Dimensions: []string{"driver_name"},
+		Measures:   []string{"trip_count", "total_miles"},
+		Filters: []domain.Filter{
+			{Field: "status", Operator: domain.FilterIn, Values: []string{"COMPLETED", "FAILED"}},
+			{Field: "planned_date", Operator: domain.FilterGreaterOrEq, Values: []string{"2024-01-01"}},
+		},
+		SortBy:   "total_miles",
+		SortDesc: true,
+		Limit:    50,
+	}
+
+	q, err := buildQuery(def)
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`AS "driver_name"`,
+		"GROUP BY 1",
+		"(t.status::text) IN ($1::text, $2::text)",
+		"(date_trunc('day', t.planned_start_time)) >= $3::timestamptz",
+		"ORDER BY 3 DESC",
+		"LIMIT 51",
+	} {
+		if !strings.Contains(q.SQL, want) {
+			t.Errorf("SQL missing %q\n%s", want, q.SQL)
+		}
+	}
+	if len(q.Args) != 3 {
+		t.Errorf("len(Args) = %d, want 3", len(q.Args))
+	}
+	if len(q.Columns) != 3 {
+		t.Errorf("len(Columns) = %d, want 3", len(q.Columns))
+	}
+}
+
+func TestBuildQueryRejectsUnknownIdentifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		def  domain.ReportDefinition
+	}{
+		{"unknown dataset", domain.ReportDefinition{Dataset: "users", Measures: []string{"trip_count"}}},
+		{"injected dimension", domain.ReportDefinition{Dataset: "trips", Dimensions: []string{"status; DROP TABLE trips"}}},
+		{"measure from other dataset", domain.ReportDefinition{Dataset: "orders", Measures: []string{"trip_count"}}},
+		{"filter on measure", domain.ReportDefinition{Dataset: "trips", Measures: []string{"trip_count"},
+			Filters: []domain.Filter{{Field: "trip_count", Operator: domain.FilterGreater, Values: []string{"1"}}}}},
+		{"unknown operator", domain.ReportDefinition{Dataset: "trips", Measures: []string{"trip_count"},
+			Filters: []domain.Filter{{Field: "status", Operator: "OR 1=1 --", Values: []string{"x"}}}}},
+		{"bad date", domain.ReportDefinition{Dataset: "trips", Measures: []string{"trip_count"},
+			Filters: []domain.Filter{{Field: "planned_date", Operator: domain.FilterEquals, Values: []string{"yesterday"}}}}},
+		{"sort on unselected", domain.ReportDefinition{Dataset: "trips", Measures: []string{"trip_count"}, SortBy: "revenue"}},
+		{"nothing selected", domain.ReportDefinition{Dataset: "trips"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildQuery(&tt.def); err == nil {
+				t.Errorf("buildQuery() expected error")
+			}
+		})
+	}
+}
+
+func TestBuildQueryClampsLimit(t *testing.T) {
+	q, err := buildQuery(&domain.ReportDefinition{Dataset: "orders", Measures: []string{"order_count"}, Limit: 1000000})
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+	if q.Limit != maxRowLimit {
+		t.Errorf("Limit = %d, want %d", q.Limit, maxRowLimit)
+	}
+}
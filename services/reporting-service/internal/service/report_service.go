@@ -0,0 +1,451 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/reporting-service/internal/domain"
+	"github.com/draymaster/services/reporting-service/internal/repository"
+	"github.com/draymaster/shared/pkg/email"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	queryTimeout    = 30 * time.Second
+	maxRecipients   = 20
+	schedulerActor  = "scheduler"
+	runHistoryLimit = 50
+)
+
+// ReportService builds, saves, runs, and delivers custom reports
+type ReportService struct {
+	reportRepo repository.ReportRepository
+	executor   repository.QueryExecutor
+	mailer     email.Sender
+	logger     *logger.Logger
+}
+
+// NewReportService creates a new report service
+func NewReportService(
+	reportRepo repository.ReportRepository,
+	executor repository.QueryExecutor,
+	mailer email.Sender,
+	log *logger.Logger,
+) *ReportService {
+	return &ReportService{
+		reportRepo: reportRepo,
+		executor:   executor,
+		mailer:     mailer,
+		logger:     log,
+	}
+}
+
+// ListDatasets returns the report catalog sorted by name
+func (s *ReportService) ListDatasets() []*domain.Dataset {
+	datasets := make([]*domain.Dataset, 0, len(domain.Datasets))
+	for _, d := range domain.Datasets {
+		datasets = append(datasets, d)
+	}
+	sort.Slice(datasets, func(i, j int) bool {
+		return datasets[i].Name < datasets[j].Name
+	})
+	return datasets
+}
+
+// ReportInput contains input for creating or updating a saved report
+type ReportInput struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Dataset     string                 `json:"dataset"`
+	Dimensions  []string               `json:"dimensions"`
+	Measures    []string               `json:"measures"`
+	Filters     []domain.Filter        `json:"filters"`
+	SortBy      string                 `json:"sort_by"`
+	SortDesc    bool                   `json:"sort_desc"`
+	Limit       int                    `json:"limit"`
+	Schedule    *domain.ReportSchedule `json:"schedule"`
+}
+
+// apply copies the input onto a definition and validates the result
+func (in *ReportInput) apply(def *domain.ReportDefinition) error {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return apperrors.ValidationError("name is required", "name", in.Name)
+	}
+	def.Name = name
+	def.Description = strings.TrimSpace(in.Description)
+	def.Dataset = in.Dataset
+	def.Dimensions = in.Dimensions
+	def.Measures = in.Measures
+	def.Filters = in.Filters
+	def.SortBy = in.SortBy
+	def.SortDesc = in.SortDesc
+	def.Limit = in.Limit
+	def.Schedule = in.Schedule
+
+	if _, err := buildQuery(def); err != nil {
+		return err
+	}
+
+	def.NextRunAt = nil
+	if def.Schedule != nil {
+		if err := validateSchedule(def.Schedule); err != nil {
+			return err
+		}
+		next := def.Schedule.NextRun(time.Now())
+		def.NextRunAt = &next
+	}
+	return nil
+}
+
+// validateSchedule checks the frequency fields and recipient addresses
+func validateSchedule(sched *domain.ReportSchedule) error {
+	switch sched.Frequency {
+	case domain.FrequencyDaily:
+	case domain.FrequencyWeekly:
+		if sched.Weekday < time.Sunday || sched.Weekday > time.Saturday {
+			return apperrors.ValidationError("weekday must be 0-6", "schedule.weekday", sched.Weekday)
+		}
+	case domain.FrequencyMonthly:
+		if sched.DayOfMonth < 1 || sched.DayOfMonth > 28 {
+			return apperrors.ValidationError("day_of_month must be 1-28", "schedule.day_of_month", sched.DayOfMonth)
+		}
+	default:
+		return apperrors.ValidationError("frequency must be DAILY, WEEKLY, or MONTHLY", "schedule.frequency", sched.Frequency)
+	}
+	if sched.HourUTC < 0 || sched.HourUTC > 23 {
+		return apperrors.ValidationError("hour_utc must be 0-23", "schedule.hour_utc", sched.HourUTC)
+	}
+	if len(sched.Recipients) == 0 || len(sched.Recipients) > maxRecipients {
+		return apperrors.ValidationError(
+			fmt.Sprintf("scheduled reports need 1 to %d recipients", maxRecipients), "schedule.recipients", len(sched.Recipients))
+	}
+	for _, r := range sched.Recipients {
+		if _, err := mail.ParseAddress(r); err != nil {
+			return apperrors.ValidationError("invalid recipient address", "schedule.recipients", r)
+		}
+	}
+	return nil
+}
+
+// CreateReport saves a new report definition for a user
+func (s *ReportService) CreateReport(ctx context.Context, ownerID string, input ReportInput) (*domain.ReportDefinition, error) {
+	if ownerID == "" {
+		return nil, apperrors.ValidationError("owner is required", "owner_id", ownerID)
+	}
+
+	now := time.Now()
+	def := &domain.ReportDefinition{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := input.apply(def); err != nil {
+		return nil, err
+	}
+
+	if err := s.reportRepo.Create(ctx, def); err != nil {
+		return nil, apperrors.DatabaseError("create report", err)
+	}
+
+	s.logger.Infow("Report saved", "report_id", def.ID, "owner_id", ownerID, "dataset", def.Dataset)
+	return def, nil
+}
+
+// GetReport returns a saved report owned by the user
+func (s *ReportService) GetReport(ctx context.Context, id uuid.UUID, ownerID string) (*domain.ReportDefinition, error) {
+	def, err := s.reportRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get report", err)
+	}
+	// Other users' reports are reported as missing rather than forbidden
+	if def == nil || def.OwnerID != ownerID {
+		return nil, apperrors.NotFoundError("report", id.String())
+	}
+	return def, nil
+}
+
+// ListReports returns the user's saved reports
+func (s *ReportService) ListReports(ctx context.Context, ownerID string) ([]domain.ReportDefinition, error) {
+	defs, err := s.reportRepo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list reports", err)
+	}
+	return defs, nil
+}
+
+// UpdateReport replaces a saved report definition
+func (s *ReportService) UpdateReport(ctx context.Context, id uuid.UUID, ownerID string, input ReportInput) (*domain.ReportDefinition, error) {
+	def, err := s.GetReport(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.apply(def); err != nil {
+		return nil, err
+	}
+	def.UpdatedAt = time.Now()
+
+	if err := s.reportRepo.Update(ctx, def); err != nil {
+		return nil, apperrors.DatabaseError("update report", err)
+	}
+	return def, nil
+}
+
+// DeleteReport removes a saved report and cancels its schedule
+func (s *ReportService) DeleteReport(ctx context.Context, id uuid.UUID, ownerID string) error {
+	if _, err := s.GetReport(ctx, id, ownerID); err != nil {
+		return err
+	}
+	if err := s.reportRepo.Delete(ctx, id); err != nil {
+		return apperrors.DatabaseError("delete report", err)
+	}
+	return nil
+}
+
+// Preview runs an unsaved definition
+func (s *ReportService) Preview(ctx context.Context, input ReportInput) (*domain.ReportResult, error) {
+	def := &domain.ReportDefinition{}
+	input.Schedule = nil
+	if input.Name == "" {
+		input.Name = "preview"
+	}
+	if err := input.apply(def); err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, def)
+}
+
+// RunReport runs a saved report on demand and records the run
+func (s *ReportService) RunReport(ctx context.Context, id uuid.UUID, ownerID string) (*domain.ReportResult, error) {
+	def, err := s.GetReport(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	result, err := s.execute(ctx, def)
+	s.recordRun(ctx, def, ownerID, started, result, nil, err)
+	return result, err
+}
+
+// ListRuns returns the recent run history of a saved report
+func (s *ReportService) ListRuns(ctx context.Context, id uuid.UUID, ownerID string) ([]domain.ReportRun, error) {
+	if _, err := s.GetReport(ctx, id, ownerID); err != nil {
+		return nil, err
+	}
+	runs, err := s.reportRepo.ListRuns(ctx, id, runHistoryLimit)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list report runs", err)
+	}
+	return runs, nil
+}
+
+// ExportCSV renders a report result as CSV with column labels as the header
+func ExportCSV(result *domain.ReportResult) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := make([]string, len(result.Columns))
+	for i, c := range result.Columns {
+		header[i] = c.Label
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Rows {
+		for i, v := range row {
+			record[i] = formatCell(v)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// execute builds and runs the guarded query for a definition
+func (s *ReportService) execute(ctx context.Context, def *domain.ReportDefinition) (*domain.ReportResult, error) {
+	q, err := buildQuery(def)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.executor.Query(ctx, q.SQL, q.Args, queryTimeout)
+	if err != nil {
+		return nil, apperrors.DatabaseError("run report", err)
+	}
+
+	result := &domain.ReportResult{
+		Columns:     q.Columns,
+		GeneratedAt: time.Now(),
+	}
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		result.Truncated = true
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			row[i] = normalizeValue(v)
+		}
+	}
+	result.Rows = rows
+	result.RowCount = len(rows)
+	return result, nil
+}
+
+// RunDueReports executes every scheduled report that is due and emails the
+// result as a CSV attachment to its recipients
+func (s *ReportService) RunDueReports(ctx context.Context, now time.Time) error {
+	due, err := s.reportRepo.ListDue(ctx, now)
+	if err != nil {
+		return apperrors.DatabaseError("list due reports", err)
+	}
+
+	for i := range due {
+		def := &due[i]
+		if def.Schedule == nil {
+			continue
+		}
+
+		started := time.Now()
+		result, runErr := s.execute(ctx, def)
+		var delivered []string
+		if runErr == nil {
+			runErr = s.deliver(ctx, def, result)
+			if runErr == nil {
+				delivered = def.Schedule.Recipients
+			}
+		}
+		s.recordRun(ctx, def, schedulerActor, started, result, delivered, runErr)
+
+		// Advance even on failure so a broken report doesn't retry every tick
+		next := def.Schedule.NextRun(now)
+		def.NextRunAt = &next
+		def.LastRunAt = &started
+		def.UpdatedAt = time.Now()
+		if err := s.reportRepo.Update(ctx, def); err != nil {
+			s.logger.Errorw("Failed to advance report schedule", "report_id", def.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// deliver emails a report result to the schedule's recipients
+func (s *ReportService) deliver(ctx context.Context, def *domain.ReportDefinition, result *domain.ReportResult) error {
+	data, err := ExportCSV(result)
+	if err != nil {
+		return fmt.Errorf("render csv: %w", err)
+	}
+
+	body := fmt.Sprintf("%s\n\n%d rows generated %s UTC.",
+		def.Name, result.RowCount, result.GeneratedAt.UTC().Format("2006-01-02 15:04"))
+	if result.Truncated {
+		body += fmt.Sprintf("\nOnly the first %d rows are included; narrow the filters to see more.", result.RowCount)
+	}
+
+	return s.mailer.Send(ctx, email.Message{
+		To:      def.Schedule.Recipients,
+		Subject: fmt.Sprintf("[DrayMaster] %s - %s", def.Name, result.GeneratedAt.UTC().Format("Jan 2, 2006")),
+		Body:    body,
+		Attachments: []email.Attachment{{
+			Filename:    fmt.Sprintf("%s-%s.csv", slug(def.Name), result.GeneratedAt.UTC().Format("20060102")),
+			ContentType: "text/csv",
+			Data:        data,
+		}},
+	})
+}
+
+// StartScheduler runs due reports on every tick until ctx is cancelled
+func (s *ReportService) StartScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.RunDueReports(ctx, now); err != nil {
+				s.logger.Errorw("Scheduled report run failed", "error", err)
+			}
+		}
+	}
+}
+
+// recordRun writes the run audit record; failures are logged, not returned
+func (s *ReportService) recordRun(ctx context.Context, def *domain.ReportDefinition, actor string, started time.Time,
+	result *domain.ReportResult, delivered []string, runErr error) {
+	run := &domain.ReportRun{
+		ID:          uuid.New(),
+		ReportID:    def.ID,
+		TriggeredBy: actor,
+		Status:      domain.RunStatusSucceeded,
+		DurationMs:  time.Since(started).Milliseconds(),
+		DeliveredTo: delivered,
+		StartedAt:   started,
+	}
+	if result != nil {
+		run.RowCount = result.RowCount
+	}
+	if runErr != nil {
+		run.Status = domain.RunStatusFailed
+		run.Error = runErr.Error()
+		s.logger.Warnw("Report run failed", "report_id", def.ID, "triggered_by", actor, "error", runErr)
+	}
+
+	if err := s.reportRepo.CreateRun(ctx, run); err != nil {
+		s.logger.Errorw("Failed to record report run", "report_id", def.ID, "error", err)
+	}
+}
+
+// normalizeValue converts driver types (e.g. numerics) to JSON friendly values
+func normalizeValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if converted, err := valuer.Value(); err == nil {
+			return converted
+		}
+	}
+	return v
+}
+
+// formatCell renders a result value for CSV
+func formatCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 {
+			return val.Format("2006-01-02")
+		}
+		return val.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// slug makes a filename-safe version of a report name
+func slug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
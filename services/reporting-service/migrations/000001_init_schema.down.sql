@@ -0,0 +1,4 @@
+-- 000001_init_schema.down.sql
+
+DROP TABLE IF EXISTS report_runs;
+DROP TABLE IF EXISTS report_definitions;
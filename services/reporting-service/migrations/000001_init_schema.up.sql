@@ -0,0 +1,56 @@
+-- ==============================================================================
+-- Reporting Service — Initial Schema
+-- ==============================================================================
+-- Tables:
+--   report_definitions  Saved report builder definitions, per user
+--   report_runs         On-demand and scheduled execution history
+--
+-- Report queries themselves read the operational tables (trips, orders,
+-- trip_stops, hos_logs) and never write to them.
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- report_definitions
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS report_definitions (
+    id           UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id     VARCHAR(100) NOT NULL,
+    name         VARCHAR(200) NOT NULL,
+    description  TEXT,
+    dataset      VARCHAR(50)  NOT NULL,
+    dimensions   JSONB        NOT NULL DEFAULT '[]',
+    measures     JSONB        NOT NULL DEFAULT '[]',
+    filters      JSONB        NOT NULL DEFAULT '[]',
+    sort_by      VARCHAR(50),
+    sort_desc    BOOLEAN      NOT NULL DEFAULT FALSE,
+    row_limit    INTEGER      NOT NULL DEFAULT 0,
+    schedule     JSONB,
+    next_run_at  TIMESTAMPTZ,
+    last_run_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_definitions_owner ON report_definitions(owner_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_report_definitions_due ON report_definitions(next_run_at)
+    WHERE next_run_at IS NOT NULL AND deleted_at IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- report_runs
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS report_runs (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id     UUID         NOT NULL REFERENCES report_definitions(id),
+    triggered_by  VARCHAR(100) NOT NULL,
+    status        VARCHAR(20)  NOT NULL,
+    row_count     INTEGER      NOT NULL DEFAULT 0,
+    duration_ms   BIGINT       NOT NULL DEFAULT 0,
+    delivered_to  JSONB,
+    error         TEXT,
+    started_at    TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_runs_report ON report_runs(report_id, started_at DESC);
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scale sizes a demo dataset
+type Scale string
+
+const (
+	ScaleSmall  Scale = "SMALL"  // A single dispatcher's board, quick to load for a demo
+	ScaleMedium Scale = "MEDIUM" // A mid-size drayage carrier, the QA default
+	ScaleLarge  Scale = "LARGE"  // Enough volume to exercise paging, reports and the map
+)
+
+// ScaleSize is how many of each entity a scale generates
+type ScaleSize struct {
+	Terminals int
+	Customers int
+	Drivers   int
+	Shipments int
+	HOSDays   int // Days of duty status history per driver
+}
+
+var scaleSizes = map[Scale]ScaleSize{
+	ScaleSmall:  {Terminals: 3, Customers: 4, Drivers: 8, Shipments: 12, HOSDays: 8},
+	ScaleMedium: {Terminals: 5, Customers: 10, Drivers: 25, Shipments: 60, HOSDays: 8},
+	ScaleLarge:  {Terminals: 8, Customers: 25, Drivers: 80, Shipments: 300, HOSDays: 14},
+}
+
+// Size returns the entity counts for the scale
+func (s Scale) Size() (ScaleSize, bool) {
+	size, ok := scaleSizes[s]
+	return size, ok
+}
+
+// Seed bounds. Seeds are embedded in the reference, order and trip numbers
+// the dataset generates, so they are kept short enough for those columns.
+const (
+	MinSeed int64 = 1
+	MaxSeed int64 = 99999
+)
+
+// SeedTables are the operational tables a dataset writes, parents first.
+// Teardown deletes in the reverse order and refuses any other table.
+var SeedTables = []string{
+	"locations",
+	"customers",
+	"drivers",
+	"hos_logs",
+	"shipments",
+	"containers",
+	"orders",
+	"trips",
+	"trip_stops",
+	"location_records",
+	"milestones",
+}
+
+// IsSeedTable checks if a table is one datasets write to
+func IsSeedTable(table string) bool {
+	for _, t := range SeedTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// Row is one generated row. Columns and Values line up.
+type Row struct {
+	Table   string
+	ID      uuid.UUID
+	Columns []string
+	Values  []interface{}
+}
+
+// Dataset is a generated demo environment. Rows are in insert order, so
+// every row only references rows before it.
+type Dataset struct {
+	Seed   int64
+	Scale  Scale
+	Anchor time.Time // "Now" for the dataset; history is before it, plans after
+	Rows   []Row
+}
+
+// Counts returns how many rows the dataset writes to each table
+func (d *Dataset) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range d.Rows {
+		counts[r.Table]++
+	}
+	return counts
+}
+
+// SeedRun is a dataset written to the database. Every row it wrote is
+// registered against it so teardown removes exactly those rows.
+type SeedRun struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	Seed       int64          `json:"seed" db:"seed"`
+	Scale      Scale          `json:"scale" db:"scale"`
+	Anchor     time.Time      `json:"anchor" db:"anchor"`
+	RowCount   int            `json:"row_count" db:"row_count"`
+	Counts     map[string]int `json:"counts" db:"counts"`
+	CreatedBy  string         `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	TornDownAt *time.Time     `json:"torn_down_at,omitempty" db:"torn_down_at"`
+}
+
+// IsActive checks if the run's rows are still in the database
+func (r *SeedRun) IsActive() bool {
+	return r.TornDownAt == nil
+}
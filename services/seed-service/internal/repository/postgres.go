@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/services/seed-service/internal/domain"
+)
+
+// insertBatchSize caps the rows queued per round trip
+const insertBatchSize = 500
+
+const runColumns = `id, seed, scale, anchor, row_count, counts, created_by, created_at, torn_down_at`
+
+// PostgresSeedRepository implements SeedRepository
+type PostgresSeedRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresSeedRepository creates a new PostgreSQL seed repository
+func NewPostgresSeedRepository(pool *pgxpool.Pool) *PostgresSeedRepository {
+	return &PostgresSeedRepository{pool: pool}
+}
+
+// Apply writes the run and every dataset row in one transaction, so a
+// failed seed leaves nothing behind
+func (r *PostgresSeedRepository) Apply(ctx context.Context, run *domain.SeedRun, dataset *domain.Dataset) error {
+	counts, err := json.Marshal(run.Counts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal counts: %w", err)
+	}
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO seed_runs (id, seed, scale, anchor, row_count, counts, created_by, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			run.ID, run.Seed, run.Scale, run.Anchor, run.RowCount, counts, run.CreatedBy, run.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create seed run: %w", err)
+		}
+
+		for start := 0; start < len(dataset.Rows); start += insertBatchSize {
+			end := start + insertBatchSize
+			if end > len(dataset.Rows) {
+				end = len(dataset.Rows)
+			}
+			batch := &pgx.Batch{}
+			for i, row := range dataset.Rows[start:end] {
+				if !domain.IsSeedTable(row.Table) {
+					return fmt.Errorf("refusing to seed table %q", row.Table)
+				}
+				batch.Queue(insertSQL(row), row.Values...)
+				batch.Queue(`INSERT INTO seed_rows (run_id, ordinal, table_name, row_id) VALUES ($1, $2, $3, $4)`,
+					run.ID, start+i, row.Table, row.ID)
+			}
+			results := tx.SendBatch(ctx, batch)
+			for i := 0; i < batch.Len(); i++ {
+				if _, err := results.Exec(); err != nil {
+					results.Close()
+					row := dataset.Rows[start+i/2]
+					return fmt.Errorf("failed to insert %s %s: %w", row.Table, row.ID, err)
+				}
+			}
+			if err := results.Close(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func insertSQL(row domain.Row) string {
+	placeholders := make([]string, len(row.Columns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		row.Table, strings.Join(row.Columns, ", "), strings.Join(placeholders, ", "))
+}
+
+func (r *PostgresSeedRepository) GetRun(ctx context.Context, id uuid.UUID) (*domain.SeedRun, error) {
+	return r.getRun(ctx, `WHERE id = $1`, id)
+}
+
+func (r *PostgresSeedRepository) GetActiveRunBySeed(ctx context.Context, seed int64) (*domain.SeedRun, error) {
+	return r.getRun(ctx, `WHERE seed = $1 AND torn_down_at IS NULL`, seed)
+}
+
+func (r *PostgresSeedRepository) getRun(ctx context.Context, where string, arg interface{}) (*domain.SeedRun, error) {
+	run, err := scanRun(r.pool.QueryRow(ctx, `SELECT `+runColumns+` FROM seed_runs `+where, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+func (r *PostgresSeedRepository) ListRuns(ctx context.Context) ([]domain.SeedRun, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+runColumns+` FROM seed_runs ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []domain.SeedRun
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// Teardown deletes the rows registered against the run, children first,
+// and marks it torn down. Rows already gone are skipped.
+func (r *PostgresSeedRepository) Teardown(ctx context.Context, run *domain.SeedRun) (int, error) {
+	deleted := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for i := len(domain.SeedTables) - 1; i >= 0; i-- {
+			table := domain.SeedTables[i]
+			tag, err := tx.Exec(ctx, fmt.Sprintf(
+				`DELETE FROM %s WHERE id IN (SELECT row_id FROM seed_rows WHERE run_id = $1 AND table_name = $2)`, table),
+				run.ID, table)
+			if err != nil {
+				return fmt.Errorf("failed to delete seeded %s: %w", table, err)
+			}
+			deleted += int(tag.RowsAffected())
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM seed_rows WHERE run_id = $1`, run.ID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE seed_runs SET torn_down_at = $2 WHERE id = $1`, run.ID, time.Now())
+		return err
+	})
+	return deleted, err
+}
+
+func scanRun(row pgx.Row) (*domain.SeedRun, error) {
+	var run domain.SeedRun
+	var counts []byte
+	err := row.Scan(&run.ID, &run.Seed, &run.Scale, &run.Anchor, &run.RowCount, &counts,
+		&run.CreatedBy, &run.CreatedAt, &run.TornDownAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(counts, &run.Counts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal counts: %w", err)
+	}
+	return &run, nil
+}
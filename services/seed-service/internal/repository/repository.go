@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/seed-service/internal/domain"
+)
+
+// SeedRepository writes datasets to the operational tables and removes
+// them again. GetRun and GetActiveRunBySeed return (nil, nil) when no run
+// matches.
+type SeedRepository interface {
+	Apply(ctx context.Context, run *domain.SeedRun, dataset *domain.Dataset) error
+	GetRun(ctx context.Context, id uuid.UUID) (*domain.SeedRun, error)
+	GetActiveRunBySeed(ctx context.Context, seed int64) (*domain.SeedRun, error)
+	ListRuns(ctx context.Context) ([]domain.SeedRun, error)
+	Teardown(ctx context.Context, run *domain.SeedRun) (int, error)
+}
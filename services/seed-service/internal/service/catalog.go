@@ -0,0 +1,90 @@
+package service
+
+// Fixed reference data the generator draws from. Names, coordinates and
+// carriers are realistic for the LA/Long Beach port complex; phone numbers
+// are in the reserved 555-01xx range and email domains use .example.
+
+type site struct {
+	Name    string
+	Address string
+	City    string
+	Zip     string
+	Lat     float64
+	Lon     float64
+}
+
+var terminalCatalog = []site{
+	{"APM Terminals Pier 400", "2500 Navy Way", "San Pedro", "90731", 33.7366, -118.2470},
+	{"TraPac Los Angeles", "630 W Harry Bridges Blvd", "Wilmington", "90744", 33.7672, -118.2682},
+	{"Yusen Terminals", "701 New Dock St", "San Pedro", "90731", 33.7523, -118.2665},
+	{"Long Beach Container Terminal", "1171 Pier F Ave", "Long Beach", "90802", 33.7550, -118.2150},
+	{"Total Terminals International", "301 Mediterranean Way", "Long Beach", "90802", 33.7480, -118.2380},
+	{"Fenix Marine Services", "614 Terminal Way", "San Pedro", "90731", 33.7470, -118.2600},
+	{"SSA Marine Pier A", "700 Pier A Plaza", "Long Beach", "90813", 33.7730, -118.2350},
+	{"International Transportation Service", "1281 Pier G Way", "Long Beach", "90802", 33.7600, -118.1990},
+}
+
+var warehouseCities = []site{
+	{City: "Ontario", Zip: "91761", Lat: 34.0633, Lon: -117.6509},
+	{City: "Fontana", Zip: "92335", Lat: 34.0922, Lon: -117.4350},
+	{City: "Rancho Cucamonga", Zip: "91730", Lat: 34.1064, Lon: -117.5931},
+	{City: "Riverside", Zip: "92507", Lat: 33.9533, Lon: -117.3962},
+	{City: "Carson", Zip: "90745", Lat: 33.8317, Lon: -118.2820},
+	{City: "Commerce", Zip: "90040", Lat: 33.9967, Lon: -118.1598},
+	{City: "Compton", Zip: "90220", Lat: 33.8958, Lon: -118.2201},
+	{City: "Mira Loma", Zip: "91752", Lat: 33.9845, Lon: -117.5162},
+	{City: "Chino", Zip: "91710", Lat: 34.0122, Lon: -117.6889},
+	{City: "City of Industry", Zip: "91748", Lat: 34.0198, Lon: -117.9587},
+	{City: "Moreno Valley", Zip: "92551", Lat: 33.9425, Lon: -117.2297},
+	{City: "Santa Fe Springs", Zip: "90670", Lat: 33.9472, Lon: -118.0854},
+}
+
+var warehouseStreets = []string{
+	"Commerce Center Dr", "Industrial Pkwy", "Logistics Way", "Distribution Ave",
+	"Jurupa St", "Etiwanda Ave", "Slover Ave", "Harvill Ave",
+}
+
+var customerNames = []string{
+	"Pacific Rim Home Goods", "Harbor Point Apparel", "Golden State Furniture", "Inland Empire Electronics",
+	"Sunset Beverage Co", "Mojave Building Supply", "Redlands Toy Distribution", "Coastal Auto Parts",
+	"Vista Outdoor Gear", "Cascade Kitchenware", "Orion Sporting Goods", "Summit Tire Wholesale",
+	"Meridian Pet Supply", "Bayline Textiles", "Canyon Solar Components", "Evergreen Garden Supply",
+	"Northstar Appliances", "Silverline Cosmetics", "Tidewater Foods", "Ironwood Hardware",
+	"Bluewave Fitness", "Keystone Office Products", "Lumen Lighting", "Redwood Paper Goods",
+	"Altair Medical Supply",
+}
+
+var driverFirstNames = []string{
+	"Miguel", "James", "Luis", "Robert", "Carlos", "David", "Jose", "Michael", "Angela", "Daniel",
+	"Maria", "Kevin", "Juan", "Brian", "Sandra", "Anthony", "Hector", "Marcus", "Tanya", "Victor",
+}
+
+var driverLastNames = []string{
+	"Garcia", "Johnson", "Hernandez", "Smith", "Lopez", "Williams", "Martinez", "Brown", "Nguyen", "Davis",
+	"Rodriguez", "Wilson", "Ramirez", "Anderson", "Torres", "Thomas", "Flores", "Jackson", "Rivera", "Lee",
+}
+
+var areaCodes = []string{"310", "562", "909", "951", "213", "714"}
+
+type steamshipLine struct {
+	Name       string
+	SCAC       string
+	OwnerCode  string // ISO 6346 owner prefix, with the U category
+	VesselName string
+}
+
+var steamshipLines = []steamshipLine{
+	{"Maersk", "MAEU", "MSKU", "MAERSK SELETAR"},
+	{"MSC", "MSCU", "MSCU", "MSC AURORA"},
+	{"CMA CGM", "CMDU", "CMAU", "CMA CGM ORFEO"},
+	{"Evergreen", "EGLV", "EGHU", "EVER LEGEND"},
+	{"ONE", "ONEY", "ONEU", "ONE HARMONY"},
+	{"COSCO", "COSU", "CSNU", "COSCO PRIDE"},
+	{"HMM", "HDMU", "HDMU", "HMM ALGECIRAS"},
+	{"Yang Ming", "YMLU", "YMLU", "YM WONDROUS"},
+}
+
+var commodities = []string{
+	"Furniture", "Consumer electronics", "Apparel", "Auto parts", "Toys", "Housewares",
+	"Tires", "Sporting goods", "Canned foods", "Building materials", "Solar panels", "Paper products",
+}
@@ -0,0 +1,766 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/seed-service/internal/domain"
+)
+
+// demoNamespace roots the deterministic IDs of every generated row, so a
+// seed always produces the same IDs
+var demoNamespace = uuid.MustParse("8f1d2c3a-5b7e-4c10-9a6d-3e2f1b0c9d84")
+
+// createdBy marks trips and notes written by the seeder
+const createdBy = "demo-seed"
+
+// Generation tuning
+const (
+	gpsIntervalMins   = 5  // Between points while driving
+	dwellIntervalMins = 15 // Between points while parked at a stop
+	averageSpeedMPH   = 30 // Port and freeway traffic, door to door
+	roadFactor        = 1.3
+)
+
+// containerStage is how far a container's move has got at the anchor
+type containerStage int
+
+const (
+	stageOnVessel containerStage = iota
+	stageAtTerminal
+	stageAssigned
+	stageInProgress
+	stageCompleted
+)
+
+type location struct {
+	ID   uuid.UUID
+	Site site
+}
+
+type customer struct {
+	ID        uuid.UUID
+	Name      string
+	Warehouse location
+}
+
+type driver struct {
+	ID           uuid.UUID
+	Number       int
+	HomeTerminal location
+	CurrentTrip  *uuid.UUID
+	TripStart    time.Time
+	Lat, Lon     float64
+}
+
+// stopPlan is one stop of a generated trip with its timeline
+type stopPlan struct {
+	ID        uuid.UUID
+	Seq       int
+	Type      string
+	Activity  string
+	Location  location
+	Arrival   time.Time
+	Departure time.Time
+}
+
+// generator builds a dataset from a seeded random source. Rows are
+// collected per table and assembled in domain.SeedTables order.
+type generator struct {
+	seed   int64
+	size   domain.ScaleSize
+	anchor time.Time
+	rnd    *rand.Rand
+	rows   map[string][]domain.Row
+
+	terminals []location
+	customers []customer
+	drivers   []*driver
+	trips     int
+}
+
+// Generate builds the demo dataset for a seed. The same seed, scale and
+// anchor always give the same rows.
+func Generate(seed int64, scale domain.Scale, anchor time.Time) (*domain.Dataset, error) {
+	size, ok := scale.Size()
+	if !ok {
+		return nil, fmt.Errorf("unknown scale %q", scale)
+	}
+	g := &generator{
+		seed:   seed,
+		size:   size,
+		anchor: anchor.UTC().Truncate(time.Minute),
+		rnd:    rand.New(rand.NewSource(seed)),
+		rows:   make(map[string][]domain.Row),
+	}
+
+	g.genTerminals()
+	g.genCustomers()
+	g.genDrivers()
+	for i := 1; i <= size.Shipments; i++ {
+		g.genShipment(i)
+	}
+	for _, d := range g.drivers {
+		g.emitDriver(d)
+	}
+
+	dataset := &domain.Dataset{Seed: seed, Scale: scale, Anchor: g.anchor}
+	for _, table := range domain.SeedTables {
+		dataset.Rows = append(dataset.Rows, g.rows[table]...)
+	}
+	return dataset, nil
+}
+
+// id derives a stable ID for the nth entity of a kind
+func (g *generator) id(kind string, n int) uuid.UUID {
+	return uuid.NewSHA1(demoNamespace, []byte(fmt.Sprintf("%d/%s/%d", g.seed, kind, n)))
+}
+
+// add records a row from alternating column names and values
+func (g *generator) add(table string, id uuid.UUID, pairs ...interface{}) {
+	row := domain.Row{Table: table, ID: id, Columns: []string{"id"}, Values: []interface{}{id}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		row.Columns = append(row.Columns, pairs[i].(string))
+		row.Values = append(row.Values, pairs[i+1])
+	}
+	g.rows[table] = append(g.rows[table], row)
+}
+
+func (g *generator) between(min, max int) int {
+	return min + g.rnd.Intn(max-min+1)
+}
+
+func (g *generator) minutes(min, max int) time.Duration {
+	return time.Duration(g.between(min, max)) * time.Minute
+}
+
+func (g *generator) phone(n int) string {
+	return fmt.Sprintf("(%s) 555-01%02d", areaCodes[n%len(areaCodes)], n%100)
+}
+
+func (g *generator) notes() string {
+	return fmt.Sprintf("Demo data (seed %d)", g.seed)
+}
+
+// ============================================================================
+// REFERENCE DATA
+// ============================================================================
+
+func (g *generator) genTerminals() {
+	count := g.size.Terminals
+	if count > len(terminalCatalog) {
+		count = len(terminalCatalog)
+	}
+	for i, s := range terminalCatalog[:count] {
+		loc := location{ID: g.id("terminal", i), Site: s}
+		g.terminals = append(g.terminals, loc)
+		g.add("locations", loc.ID,
+			"name", s.Name, "type", "TERMINAL", "address", s.Address, "city", s.City, "state", "CA", "zip", s.Zip,
+			"latitude", s.Lat, "longitude", s.Lon, "contact_name", "Gate Operations", "contact_phone", g.phone(i),
+			"notes", g.notes(),
+		)
+	}
+}
+
+func (g *generator) genCustomers() {
+	count := g.size.Customers
+	if count > len(customerNames) {
+		count = len(customerNames)
+	}
+	for i, name := range customerNames[:count] {
+		city := warehouseCities[g.rnd.Intn(len(warehouseCities))]
+		wh := site{
+			Name:    name + " DC",
+			Address: fmt.Sprintf("%d %s", g.between(1000, 14999), warehouseStreets[g.rnd.Intn(len(warehouseStreets))]),
+			City:    city.City,
+			Zip:     city.Zip,
+			Lat:     city.Lat + (g.rnd.Float64()-0.5)*0.04,
+			Lon:     city.Lon + (g.rnd.Float64()-0.5)*0.04,
+		}
+		c := customer{
+			ID:        g.id("customer", i),
+			Name:      name,
+			Warehouse: location{ID: g.id("warehouse", i), Site: wh},
+		}
+		g.customers = append(g.customers, c)
+
+		slug := strings.ToLower(strings.NewReplacer(" ", "", "&", "").Replace(name))
+		g.add("locations", c.Warehouse.ID,
+			"name", wh.Name, "type", "WAREHOUSE", "address", wh.Address, "city", wh.City, "state", "CA", "zip", wh.Zip,
+			"latitude", round(wh.Lat, 6), "longitude", round(wh.Lon, 6), "contact_name", "Receiving", "contact_phone", g.phone(40+i),
+			"notes", g.notes(),
+		)
+		g.add("customers", c.ID,
+			"company_name", name, "code", fmt.Sprintf("D%d-C%02d", g.seed, i+1), "type", "both",
+			"address", wh.Address, "city", wh.City, "state", "CA", "zip", wh.Zip,
+			"phone", g.phone(40+i), "email", "logistics@"+slug+".example",
+		)
+	}
+}
+
+func (g *generator) genDrivers() {
+	for i := 0; i < g.size.Drivers; i++ {
+		home := g.terminals[i%len(g.terminals)]
+		g.drivers = append(g.drivers, &driver{
+			ID:           g.id("driver", i),
+			Number:       i + 1,
+			HomeTerminal: home,
+			Lat:          home.Site.Lat,
+			Lon:          home.Site.Lon,
+		})
+	}
+}
+
+// ============================================================================
+// SHIPMENTS, ORDERS AND TRIPS
+// ============================================================================
+
+func (g *generator) genShipment(n int) {
+	cust := g.customers[g.rnd.Intn(len(g.customers))]
+	terminal := g.terminals[g.rnd.Intn(len(g.terminals))]
+	line := steamshipLines[g.rnd.Intn(len(steamshipLines))]
+	shipmentID := g.id("shipment", n)
+
+	eta := g.anchor.Add(time.Duration(g.between(-10*24, 4*24)) * time.Hour).Truncate(time.Hour)
+	arrived := !eta.After(g.anchor)
+	var ata, lfd, available interface{}
+	if arrived {
+		ata = eta
+		lfd = eta.AddDate(0, 0, 4).Truncate(24 * time.Hour)
+		available = eta.Add(g.minutes(8*60, 30*60))
+	}
+
+	containers := g.between(1, 3)
+	status := "CONFIRMED"
+	active, completed := 0, 0
+	for c := 1; c <= containers; c++ {
+		stage := stageOnVessel
+		if arrived {
+			stage = g.pickStage()
+		}
+		switch stage {
+		case stageCompleted:
+			completed++
+		case stageAssigned, stageInProgress:
+			active++
+		}
+		g.genContainer(shipmentID, n, c, stage, cust, terminal, line, available)
+	}
+	switch {
+	case completed == containers:
+		status = "COMPLETED"
+	case active > 0 || completed > 0:
+		status = "IN_PROGRESS"
+	}
+
+	g.add("shipments", shipmentID,
+		"type", "IMPORT", "reference_number", fmt.Sprintf("D%d-S%04d", g.seed, n),
+		"customer_id", cust.ID, "terminal_id", terminal.ID,
+		"vessel_name", line.VesselName, "voyage_number", fmt.Sprintf("%03dE", g.between(100, 999)),
+		"vessel_eta", eta, "vessel_ata", ata, "last_free_day", lfd, "consignee_id", cust.Warehouse.ID,
+		"status", status, "customer_name", cust.Name, "steamship_line", line.Name,
+		"bill_of_lading", fmt.Sprintf("%s%09d", line.SCAC, g.rnd.Intn(1e9)), "terminal_name", terminal.Site.Name,
+		"trip_type", "LIVE_UNLOAD", "delivery_address", cust.Warehouse.Site.Address,
+		"delivery_city", cust.Warehouse.Site.City, "delivery_state", "CA", "delivery_zip", cust.Warehouse.Site.Zip,
+		"special_instructions", g.notes(),
+	)
+}
+
+func (g *generator) pickStage() containerStage {
+	switch r := g.rnd.Float64(); {
+	case r < 0.25:
+		return stageAtTerminal
+	case r < 0.45:
+		return stageAssigned
+	case r < 0.65:
+		return stageInProgress
+	default:
+		return stageCompleted
+	}
+}
+
+func (g *generator) genContainer(shipmentID uuid.UUID, shipmentNo, n int, stage containerStage, cust customer, terminal location, line steamshipLine, available interface{}) {
+	key := shipmentNo*10 + n
+	containerID := g.id("container", key)
+	orderID := g.id("order", key)
+	number := containerNumber(line.OwnerCode, g.rnd.Intn(1e6))
+	size := []string{"40", "40HC", "40HC", "20", "45"}[g.rnd.Intn(5)]
+	ctype, reefer := "DRY", g.rnd.Float64() < 0.08
+	var setpoint interface{}
+	if reefer {
+		ctype, setpoint = "REEFER", float64(g.between(-10, 4))
+	}
+
+	var trip *tripPlan
+	if stage == stageInProgress || stage == stageAssigned || stage == stageCompleted {
+		trip = g.planTrip(key, stage, terminal, cust, containerID, number, orderID)
+		if trip == nil {
+			stage = stageAssigned
+			trip = g.planTrip(key, stage, terminal, cust, containerID, number, orderID)
+		}
+	}
+
+	customs, holdType, orderStatus := "RELEASED", "", "READY"
+	state, locType := "LOADED", "TERMINAL"
+	var locID interface{} = terminal.ID
+	switch stage {
+	case stageOnVessel:
+		customs, orderStatus, locType, locID = "PENDING", "PENDING", "VESSEL", nil
+	case stageAtTerminal:
+		if g.rnd.Float64() < 0.15 {
+			customs, holdType, orderStatus = "HOLD", "CBP EXAM", "HOLD"
+		}
+	case stageAssigned:
+		orderStatus = "DISPATCHED"
+	case stageInProgress:
+		orderStatus = "IN_PROGRESS"
+		state, locType, locID = trip.containerPosition(g.anchor)
+	case stageCompleted:
+		orderStatus, state = "COMPLETED", "EMPTY"
+	}
+
+	g.add("containers", containerID,
+		"shipment_id", shipmentID, "container_number", number, "size", size, "type", ctype,
+		"seal_number", fmt.Sprintf("%s%07d", line.SCAC[:2], g.rnd.Intn(1e7)),
+		"weight_lbs", g.between(12000, 42000), "is_reefer", reefer, "reefer_temp_setpoint", setpoint,
+		"commodity", commodities[g.rnd.Intn(len(commodities))],
+		"customs_status", customs, "customs_hold_type", nullable(holdType), "terminal_available_date", available,
+		"current_state", state, "current_location_type", locType, "current_location_id", locID,
+	)
+	g.add("orders", orderID,
+		"order_number", fmt.Sprintf("D%d-O%05d", g.seed, key), "container_id", containerID, "shipment_id", shipmentID,
+		"type", "IMPORT", "move_type", "LIVE_UNLOAD", "customer_reference", fmt.Sprintf("PO-%06d", g.rnd.Intn(1e6)),
+		"pickup_location_id", terminal.ID, "delivery_location_id", cust.Warehouse.ID, "return_location_id", terminal.ID,
+		"requested_delivery_date", g.anchor.Add(time.Duration(g.between(-48, 72))*time.Hour).Truncate(time.Hour),
+		"status", orderStatus, "billing_status", "UNBILLED",
+	)
+	if trip != nil {
+		g.emitTrip(trip, shipmentID)
+	}
+}
+
+// tripPlan is a generated terminal → warehouse → terminal live unload
+type tripPlan struct {
+	ID              uuid.UUID
+	Number          string
+	Stage           containerStage
+	Driver          *driver
+	ContainerID     uuid.UUID
+	ContainerNumber string
+	OrderID         uuid.UUID
+	Stops           []stopPlan
+	Miles           float64
+}
+
+func (t *tripPlan) start() time.Time { return t.Stops[0].Arrival }
+func (t *tripPlan) end() time.Time   { return t.Stops[len(t.Stops)-1].Departure }
+
+// containerPosition is where the container is at the given time during the trip
+func (t *tripPlan) containerPosition(at time.Time) (state, locType string, locID interface{}) {
+	pickup, delivery := t.Stops[0], t.Stops[1]
+	switch {
+	case at.Before(pickup.Departure):
+		return "LOADED", "TERMINAL", pickup.Location.ID
+	case at.Before(delivery.Arrival):
+		return "LOADED", "IN_TRANSIT", nil
+	case at.Before(delivery.Departure):
+		return "LOADED", "CUSTOMER", delivery.Location.ID
+	default:
+		return "EMPTY", "IN_TRANSIT", nil
+	}
+}
+
+// planTrip lays out the trip's stops and picks a driver. An in-progress
+// trip needs a driver not already on one; nil means none was free.
+func (g *generator) planTrip(key int, stage containerStage, terminal location, cust customer, containerID uuid.UUID, number string, orderID uuid.UUID) *tripPlan {
+	var drv *driver
+	if stage == stageInProgress {
+		for _, i := range g.rnd.Perm(len(g.drivers)) {
+			if g.drivers[i].CurrentTrip == nil {
+				drv = g.drivers[i]
+				break
+			}
+		}
+		if drv == nil {
+			return nil
+		}
+	} else {
+		drv = g.drivers[g.rnd.Intn(len(g.drivers))]
+	}
+
+	g.trips++
+	trip := &tripPlan{
+		ID:              g.id("trip", key),
+		Number:          fmt.Sprintf("D%d-T%05d", g.seed, g.trips),
+		Stage:           stage,
+		Driver:          drv,
+		ContainerID:     containerID,
+		ContainerNumber: number,
+		OrderID:         orderID,
+	}
+
+	legs := []struct {
+		typ, activity string
+		loc           location
+		dwell         [2]int
+	}{
+		{"PICKUP", "PICKUP_LOADED", terminal, [2]int{45, 110}},
+		{"DELIVERY", "LIVE_UNLOAD", cust.Warehouse, [2]int{60, 150}},
+		{"RETURN", "DROP_EMPTY", terminal, [2]int{20, 50}},
+	}
+	dwells := make([]time.Duration, len(legs))
+	drives := make([]time.Duration, len(legs))
+	var total time.Duration
+	for i, leg := range legs {
+		dwells[i] = g.minutes(leg.dwell[0], leg.dwell[1])
+		if i > 0 {
+			miles := roadMiles(legs[i-1].loc.Site, leg.loc.Site)
+			trip.Miles += miles
+			drives[i] = time.Duration(miles/averageSpeedMPH*60+10) * time.Minute
+		}
+		total += dwells[i] + drives[i]
+	}
+
+	var start time.Time
+	switch stage {
+	case stageAssigned:
+		start = g.anchor.Add(g.minutes(60, 20*60)).Truncate(15 * time.Minute)
+	case stageInProgress:
+		start = g.anchor.Add(-g.minutes(20, int(total.Minutes())-10))
+	default:
+		days := g.between(1, g.size.HOSDays-1)
+		start = g.anchor.AddDate(0, 0, -days).Truncate(24 * time.Hour).Add(g.minutes(6*60, 10*60))
+	}
+
+	at := start
+	for i, leg := range legs {
+		at = at.Add(drives[i])
+		stop := stopPlan{
+			ID:        g.id("stop", key*10+i),
+			Seq:       i + 1,
+			Type:      leg.typ,
+			Activity:  leg.activity,
+			Location:  leg.loc,
+			Arrival:   at,
+			Departure: at.Add(dwells[i]),
+		}
+		trip.Stops = append(trip.Stops, stop)
+		at = stop.Departure
+	}
+
+	if stage == stageInProgress {
+		id := trip.ID
+		drv.CurrentTrip, drv.TripStart = &id, start
+	}
+	return trip
+}
+
+func (g *generator) emitTrip(trip *tripPlan, shipmentID uuid.UUID) {
+	status, seq := "ASSIGNED", 1
+	var actualStart, actualEnd interface{}
+	completedMiles := 0.0
+	switch trip.Stage {
+	case stageInProgress:
+		status, actualStart = "IN_PROGRESS", trip.start()
+		seq = len(trip.Stops)
+		for _, s := range trip.Stops {
+			if g.anchor.Before(s.Departure) {
+				seq = s.Seq
+				break
+			}
+		}
+		elapsed := g.anchor.Sub(trip.start()).Minutes() / trip.end().Sub(trip.start()).Minutes()
+		completedMiles = round(trip.Miles*elapsed, 1)
+	case stageCompleted:
+		status, actualStart, actualEnd = "COMPLETED", trip.start(), trip.end()
+		seq, completedMiles = len(trip.Stops), round(trip.Miles, 1)
+	}
+	duration := int(trip.end().Sub(trip.start()).Minutes())
+	revenue := round(325+trip.Miles*4.25, 2)
+
+	g.add("trips", trip.ID,
+		"trip_number", trip.Number, "type", "LIVE_UNLOAD", "status", status, "driver_id", trip.Driver.ID,
+		"current_stop_sequence", seq, "planned_start_time", trip.start(), "actual_start_time", actualStart,
+		"planned_end_time", trip.end(), "actual_end_time", actualEnd, "estimated_duration_mins", duration,
+		"total_miles", round(trip.Miles, 1), "completed_miles", completedMiles,
+		"revenue", revenue, "cost", round(revenue*0.62, 2),
+		"shipment_id", shipmentID, "container_id", trip.ContainerID, "created_by", createdBy,
+	)
+
+	nextPending := true
+	for _, s := range trip.Stops {
+		stopStatus := "PENDING"
+		var arrival, departure interface{}
+		actualMins := 0
+		switch {
+		case trip.Stage == stageCompleted || (trip.Stage == stageInProgress && !g.anchor.Before(s.Departure)):
+			stopStatus, arrival, departure = "COMPLETED", s.Arrival, s.Departure
+			actualMins = int(s.Departure.Sub(s.Arrival).Minutes())
+		case trip.Stage == stageInProgress && !g.anchor.Before(s.Arrival):
+			stopStatus, arrival = "ARRIVED", s.Arrival
+			nextPending = false
+		case trip.Stage == stageInProgress && nextPending:
+			stopStatus = "EN_ROUTE"
+			nextPending = false
+		}
+		var appointment interface{}
+		if s.Seq == 1 {
+			appointment = s.Arrival.Truncate(30 * time.Minute)
+		}
+		g.add("trip_stops", s.ID,
+			"trip_id", trip.ID, "sequence", s.Seq, "type", s.Type, "activity", s.Activity, "status", stopStatus,
+			"location_id", s.Location.ID, "container_id", trip.ContainerID, "container_number", trip.ContainerNumber,
+			"order_id", trip.OrderID, "appointment_time", appointment, "planned_arrival", s.Arrival,
+			"estimated_arrival", s.Arrival, "actual_arrival", arrival, "actual_departure", departure,
+			"estimated_duration_mins", int(s.Departure.Sub(s.Arrival).Minutes()), "actual_duration_mins", actualMins,
+		)
+	}
+
+	if trip.Stage != stageAssigned {
+		g.emitTrack(trip)
+		g.emitMilestones(trip)
+	}
+}
+
+// emitTrack writes the trip's GPS breadcrumbs up to the anchor: a point
+// every few minutes while driving and a few while parked at each stop
+func (g *generator) emitTrack(trip *tripPlan) {
+	var points int
+	point := func(at time.Time, lat, lon, speed, heading float64) {
+		if at.After(g.anchor) {
+			return
+		}
+		points++
+		g.add("location_records", g.id("gps/"+trip.ID.String(), points),
+			"driver_id", trip.Driver.ID, "trip_id", trip.ID,
+			"latitude", round(lat, 6), "longitude", round(lon, 6),
+			"speed_mph", speed, "heading", heading, "accuracy_meters", float64(g.between(3, 15)),
+			"source", "eld", "recorded_at", at, "received_at", at.Add(time.Duration(g.between(1, 20))*time.Second),
+		)
+		trip.Driver.Lat, trip.Driver.Lon = round(lat, 6), round(lon, 6)
+	}
+
+	for i, s := range trip.Stops {
+		if i > 0 {
+			from, to := trip.Stops[i-1], s
+			heading := bearing(from.Location.Site, to.Location.Site)
+			span := to.Arrival.Sub(from.Departure)
+			for t := gpsIntervalMins * time.Minute; t < span; t += gpsIntervalMins * time.Minute {
+				f := float64(t) / float64(span)
+				lat := from.Location.Site.Lat + (to.Location.Site.Lat-from.Location.Site.Lat)*f + (g.rnd.Float64()-0.5)*0.002
+				lon := from.Location.Site.Lon + (to.Location.Site.Lon-from.Location.Site.Lon)*f + (g.rnd.Float64()-0.5)*0.002
+				point(from.Departure.Add(t), lat, lon, float64(g.between(18, 58)), heading)
+			}
+		}
+		for t := time.Duration(0); t < s.Departure.Sub(s.Arrival); t += dwellIntervalMins * time.Minute {
+			point(s.Arrival.Add(t), s.Location.Site.Lat, s.Location.Site.Lon, 0, 0)
+		}
+	}
+}
+
+// emitMilestones writes the milestones tracking would have recorded up to the anchor
+func (g *generator) emitMilestones(trip *tripPlan) {
+	var n int
+	milestone := func(typ string, at time.Time, stop *stopPlan) {
+		if at.After(g.anchor) {
+			return
+		}
+		n++
+		var stopID, locID interface{}
+		var lat, lon interface{}
+		locName := ""
+		if stop != nil {
+			stopID, locID, locName = stop.ID, stop.Location.ID, stop.Location.Site.Name
+			lat, lon = stop.Location.Site.Lat, stop.Location.Site.Lon
+		}
+		g.add("milestones", g.id("milestone/"+trip.ID.String(), n),
+			"trip_id", trip.ID, "stop_id", stopID, "type", typ, "occurred_at", at,
+			"latitude", lat, "longitude", lon, "location_id", locID, "location_name", nullable(locName),
+			"container_id", trip.ContainerID, "container_number", trip.ContainerNumber,
+			"source", "auto", "recorded_by", createdBy,
+		)
+	}
+
+	milestone("TRIP_STARTED", trip.start(), &trip.Stops[0])
+	for i := range trip.Stops {
+		s := &trip.Stops[i]
+		milestone("ARRIVED_STOP", s.Arrival, s)
+		if s.Type != "DELIVERY" {
+			milestone("GATE_IN", s.Arrival, s)
+			milestone("GATE_OUT", s.Departure, s)
+		} else {
+			milestone("UNLOADED", s.Departure.Add(-5*time.Minute), s)
+			milestone("DELIVERED", s.Departure, s)
+		}
+		milestone("DEPARTED_STOP", s.Departure, s)
+	}
+	milestone("TRIP_COMPLETED", trip.end(), nil)
+}
+
+// ============================================================================
+// DRIVERS AND HOURS OF SERVICE
+// ============================================================================
+
+// emitDriver writes the driver and their duty status history: a shift on
+// most of the past days, and today's shift when they are on a trip
+func (g *generator) emitDriver(d *driver) {
+	first := driverFirstNames[g.rnd.Intn(len(driverFirstNames))]
+	last := driverLastNames[g.rnd.Intn(len(driverLastNames))]
+	odometer := g.between(120000, 480000)
+	cycleStart := g.anchor.AddDate(0, 0, -8)
+	onDutyMins := 0
+
+	var logs int
+	log := func(status string, start time.Time, end *time.Time, odo int) {
+		logs++
+		duration := 0
+		var endValue interface{}
+		if end != nil {
+			duration, endValue = int(end.Sub(start).Minutes()), *end
+			if status != "OFF_DUTY" && !start.Before(cycleStart) {
+				onDutyMins += duration
+			}
+		}
+		g.add("hos_logs", g.id("hos/"+d.ID.String(), logs),
+			"driver_id", d.ID, "status", status, "start_time", start, "end_time", endValue, "duration_mins", duration,
+			"location", d.HomeTerminal.Site.City+", CA", "latitude", d.HomeTerminal.Site.Lat, "longitude", d.HomeTerminal.Site.Lon,
+			"odometer", odo, "source", "eld",
+		)
+	}
+
+	// A driver on a trip started their shift shortly before it; earlier
+	// shifts must leave a full 10 hour break before that
+	var shiftStart time.Time
+	if d.CurrentTrip != nil {
+		shiftStart = d.TripStart.Add(-g.minutes(20, 45))
+	}
+
+	offSince := g.anchor.AddDate(0, 0, -g.size.HOSDays).Truncate(24 * time.Hour)
+	for day := g.size.HOSDays; day >= 1; day-- {
+		if g.rnd.Float64() < 0.2 {
+			continue // Day off
+		}
+		at := g.anchor.AddDate(0, 0, -day).Truncate(24 * time.Hour).Add(g.minutes(5*60, 7*60+30))
+		if !shiftStart.IsZero() && at.Add(24*time.Hour).After(shiftStart) {
+			continue
+		}
+		log("OFF_DUTY", offSince, &at, odometer)
+		segments := []struct {
+			status   string
+			min, max int
+		}{
+			{"ON_DUTY_NOT_DRIVING", 15, 30},  // Pre-trip inspection
+			{"DRIVING", 45, 120},             // To the terminal
+			{"ON_DUTY_NOT_DRIVING", 45, 150}, // Gate queue and load
+			{"DRIVING", 60, 150},             // To the customer
+			{"ON_DUTY_NOT_DRIVING", 45, 120}, // Live unload
+			{"DRIVING", 60, 150},             // Empty back to the port
+			{"ON_DUTY_NOT_DRIVING", 15, 30},  // Drop and post-trip
+		}
+		for _, seg := range segments {
+			end := at.Add(g.minutes(seg.min, seg.max))
+			log(seg.status, at, &end, odometer)
+			if seg.status == "DRIVING" {
+				odometer += int(end.Sub(at).Hours() * averageSpeedMPH)
+			}
+			at = end
+		}
+		offSince = at
+	}
+
+	status := []string{"AVAILABLE", "AVAILABLE", "OFF_DUTY"}[g.rnd.Intn(3)]
+	var currentTrip interface{}
+	if d.CurrentTrip != nil {
+		status, currentTrip = "DRIVING", *d.CurrentTrip
+		preTrip := shiftStart.Add(15 * time.Minute)
+		log("OFF_DUTY", offSince, &shiftStart, odometer)
+		log("ON_DUTY_NOT_DRIVING", shiftStart, &preTrip, odometer)
+		log("DRIVING", preTrip, nil, odometer)
+	} else {
+		log("OFF_DUTY", offSince, nil, odometer)
+	}
+
+	cycleMins := 70*60 - onDutyMins
+	if cycleMins < 0 {
+		cycleMins = 0
+	}
+	hired := g.anchor.AddDate(-g.between(0, 12), -g.between(1, 11), 0).Truncate(24 * time.Hour)
+	g.add("drivers", d.ID,
+		"employee_number", fmt.Sprintf("D%d-%03d", g.seed, d.Number), "first_name", first, "last_name", last,
+		"email", fmt.Sprintf("%s.%s%d@drivers.example", strings.ToLower(first), strings.ToLower(last), d.Number),
+		"phone", g.phone(d.Number), "status", status,
+		"license_number", fmt.Sprintf("D%07d", g.rnd.Intn(1e7)), "license_state", "CA", "license_class", "A",
+		"license_expiration", g.anchor.AddDate(g.between(1, 4), 0, 0).Truncate(24*time.Hour),
+		"has_twic", true, "twic_expiration", g.anchor.AddDate(g.between(0, 4), g.between(1, 11), 0).Truncate(24*time.Hour),
+		"has_hazmat_endorsement", g.rnd.Float64() < 0.3,
+		"medical_card_expiration", g.anchor.AddDate(0, g.between(1, 24), 0).Truncate(24*time.Hour),
+		"current_latitude", d.Lat, "current_longitude", d.Lon, "current_trip_id", currentTrip,
+		"available_cycle_mins", cycleMins, "last_hos_update", g.anchor,
+		"home_terminal_id", d.HomeTerminal.ID, "hire_date", hired, "is_active", true,
+	)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// containerNumber builds an ISO 6346 container number with its check digit
+func containerNumber(owner string, serial int) string {
+	base := fmt.Sprintf("%s%06d", owner, serial)
+	return base + fmt.Sprint(checkDigit(base))
+}
+
+// checkDigit computes the ISO 6346 check digit of a 10-character owner code
+// and serial. Letters count from A=10, skipping multiples of 11.
+func checkDigit(base string) int {
+	sum := 0
+	for i, r := range base {
+		v := int(r - '0')
+		if r >= 'A' && r <= 'Z' {
+			v = 10
+			for c := 'A'; c < r; c++ {
+				if v++; v%11 == 0 {
+					v++
+				}
+			}
+		}
+		sum += v << uint(i)
+	}
+	return sum % 11 % 10
+}
+
+// roadMiles estimates driving distance from the straight-line distance
+func roadMiles(a, b site) float64 {
+	const earthRadiusMiles = 3958.8
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon-a.Lon)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(h)) * roadFactor
+}
+
+// bearing is the initial compass heading from a to b, in degrees
+func bearing(a, b site) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return round(math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360), 1)
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+// nullable stores an empty string as NULL
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/seed-service/internal/domain"
+)
+
+var testAnchor = time.Date(2024, 3, 12, 14, 30, 0, 0, time.UTC)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a, err := Generate(42, domain.ScaleSmall, testAnchor)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, _ := Generate(42, domain.ScaleSmall, testAnchor)
+	if !reflect.DeepEqual(a.Rows, b.Rows) {
+		t.Fatal("same seed generated different rows")
+	}
+
+	c, _ := Generate(43, domain.ScaleSmall, testAnchor)
+	if reflect.DeepEqual(a.Rows, c.Rows) {
+		t.Fatal("different seeds generated the same rows")
+	}
+	ids := make(map[uuid.UUID]bool)
+	for _, row := range a.Rows {
+		ids[row.ID] = true
+	}
+	for _, row := range c.Rows {
+		if ids[row.ID] {
+			t.Fatalf("seeds 42 and 43 share %s id %s", row.Table, row.ID)
+		}
+	}
+}
+
+func TestGenerateRowsReferenceEarlierRows(t *testing.T) {
+	dataset, err := Generate(7, domain.ScaleMedium, testAnchor)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, row := range dataset.Rows {
+		if !domain.IsSeedTable(row.Table) {
+			t.Fatalf("row for unexpected table %q", row.Table)
+		}
+		if seen[row.ID] {
+			t.Fatalf("duplicate %s id %s", row.Table, row.ID)
+		}
+		for i, col := range row.Columns {
+			switch col {
+			case "customer_id", "terminal_id", "shipment_id", "container_id", "driver_id", "trip_id", "location_id":
+				if id, ok := row.Values[i].(uuid.UUID); ok && !seen[id] {
+					t.Fatalf("%s.%s references %s before it is written", row.Table, col, id)
+				}
+			}
+		}
+		seen[row.ID] = true
+	}
+
+	counts := dataset.Counts()
+	size, _ := domain.ScaleMedium.Size()
+	if counts["drivers"] != size.Drivers || counts["shipments"] != size.Shipments {
+		t.Errorf("counts = %v, want %d drivers and %d shipments", counts, size.Drivers, size.Shipments)
+	}
+	for _, table := range []string{"hos_logs", "trips", "trip_stops", "location_records", "milestones"} {
+		if counts[table] == 0 {
+			t.Errorf("no %s generated", table)
+		}
+	}
+}
+
+func TestGenerateInProgressTrips(t *testing.T) {
+	dataset, err := Generate(2024, domain.ScaleLarge, testAnchor)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	drivers := make(map[interface{}]bool)
+	inProgress := 0
+	for _, row := range dataset.Rows {
+		if row.Table != "trips" || value(row, "status") != "IN_PROGRESS" {
+			continue
+		}
+		inProgress++
+		if drivers[value(row, "driver_id")] {
+			t.Fatalf("driver %v has two trips in progress", value(row, "driver_id"))
+		}
+		drivers[value(row, "driver_id")] = true
+		if start := value(row, "actual_start_time").(time.Time); start.After(testAnchor) {
+			t.Errorf("in-progress trip started at %v, after the anchor", start)
+		}
+	}
+	if inProgress == 0 {
+		t.Fatal("no trips in progress")
+	}
+
+	for _, row := range dataset.Rows {
+		if row.Table == "location_records" && value(row, "recorded_at").(time.Time).After(testAnchor) {
+			t.Fatalf("GPS point recorded after the anchor")
+		}
+	}
+}
+
+func TestGenerateUnknownScale(t *testing.T) {
+	if _, err := Generate(1, domain.Scale("HUGE"), testAnchor); err == nil {
+		t.Fatal("expected an error for an unknown scale")
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		base string
+		want int
+	}{
+		{"CSQU305438", 3},
+		{"MSKU907032", 3},
+	}
+	for _, tt := range tests {
+		if got := checkDigit(tt.base); got != tt.want {
+			t.Errorf("checkDigit(%q) = %d, want %d", tt.base, got, tt.want)
+		}
+	}
+}
+
+func value(row domain.Row, column string) interface{} {
+	for i, col := range row.Columns {
+		if col == column {
+			return row.Values[i]
+		}
+	}
+	return nil
+}
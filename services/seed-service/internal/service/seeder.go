@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/seed-service/internal/domain"
+	"github.com/draymaster/services/seed-service/internal/repository"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Seeder writes demo datasets and tears them down again
+type Seeder struct {
+	seedRepo repository.SeedRepository
+	logger   *logger.Logger
+}
+
+// NewSeeder creates a new seeder
+func NewSeeder(seedRepo repository.SeedRepository, log *logger.Logger) *Seeder {
+	return &Seeder{
+		seedRepo: seedRepo,
+		logger:   log,
+	}
+}
+
+// SeedInput contains input for seeding a dataset
+type SeedInput struct {
+	Seed      int64
+	Scale     domain.Scale
+	Anchor    time.Time // Defaults to now
+	CreatedBy string
+}
+
+// Seed generates the dataset for a seed and writes it. A seed can only be
+// active once; tear it down before seeding it again.
+func (s *Seeder) Seed(ctx context.Context, input SeedInput) (*domain.SeedRun, error) {
+	if input.Seed < domain.MinSeed || input.Seed > domain.MaxSeed {
+		return nil, apperrors.ValidationError(
+			fmt.Sprintf("seed must be between %d and %d", domain.MinSeed, domain.MaxSeed), "seed", input.Seed)
+	}
+	if input.Scale == "" {
+		input.Scale = domain.ScaleMedium
+	}
+	if _, ok := input.Scale.Size(); !ok {
+		return nil, apperrors.ValidationError("unknown scale", "scale", input.Scale)
+	}
+	if input.Anchor.IsZero() {
+		input.Anchor = time.Now()
+	}
+
+	existing, err := s.seedRepo.GetActiveRunBySeed(ctx, input.Seed)
+	if err != nil {
+		return nil, apperrors.DatabaseError("get seed run", err)
+	}
+	if existing != nil {
+		return nil, apperrors.ConflictError(fmt.Sprintf("seed %d is already loaded", input.Seed)).
+			WithDetail("run_id", existing.ID.String())
+	}
+
+	dataset, err := Generate(input.Seed, input.Scale, input.Anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &domain.SeedRun{
+		ID:        uuid.New(),
+		Seed:      dataset.Seed,
+		Scale:     dataset.Scale,
+		Anchor:    dataset.Anchor,
+		RowCount:  len(dataset.Rows),
+		Counts:    dataset.Counts(),
+		CreatedBy: input.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	if err := s.seedRepo.Apply(ctx, run, dataset); err != nil {
+		return nil, apperrors.DatabaseError("apply seed", err)
+	}
+
+	s.logger.Infow("Demo data seeded",
+		"run_id", run.ID,
+		"seed", run.Seed,
+		"scale", run.Scale,
+		"rows", run.RowCount,
+	)
+	return run, nil
+}
+
+// Teardown removes every row a run wrote
+func (s *Seeder) Teardown(ctx context.Context, runID uuid.UUID) (*domain.SeedRun, int, error) {
+	run, err := s.seedRepo.GetRun(ctx, runID)
+	if err != nil {
+		return nil, 0, apperrors.DatabaseError("get seed run", err)
+	}
+	if run == nil {
+		return nil, 0, apperrors.NotFoundError("seed run", runID.String())
+	}
+	return s.teardown(ctx, run)
+}
+
+// TeardownSeed removes the active run for a seed
+func (s *Seeder) TeardownSeed(ctx context.Context, seed int64) (*domain.SeedRun, int, error) {
+	run, err := s.seedRepo.GetActiveRunBySeed(ctx, seed)
+	if err != nil {
+		return nil, 0, apperrors.DatabaseError("get seed run", err)
+	}
+	if run == nil {
+		return nil, 0, apperrors.NotFoundError("active seed run", fmt.Sprint(seed))
+	}
+	return s.teardown(ctx, run)
+}
+
+func (s *Seeder) teardown(ctx context.Context, run *domain.SeedRun) (*domain.SeedRun, int, error) {
+	if !run.IsActive() {
+		return nil, 0, apperrors.InvalidStateError("TORN_DOWN", "ACTIVE")
+	}
+	deleted, err := s.seedRepo.Teardown(ctx, run)
+	if err != nil {
+		return nil, 0, apperrors.DatabaseError("teardown seed", err)
+	}
+	now := time.Now()
+	run.TornDownAt = &now
+
+	s.logger.Infow("Demo data torn down",
+		"run_id", run.ID,
+		"seed", run.Seed,
+		"rows", deleted,
+	)
+	return run, deleted, nil
+}
+
+// ListRuns returns every seed run, newest first
+func (s *Seeder) ListRuns(ctx context.Context) ([]domain.SeedRun, error) {
+	runs, err := s.seedRepo.ListRuns(ctx)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list seed runs", err)
+	}
+	return runs, nil
+}
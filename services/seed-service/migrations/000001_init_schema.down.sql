@@ -0,0 +1,4 @@
+-- 000001_init_schema.down.sql
+
+DROP TABLE IF EXISTS seed_rows;
+DROP TABLE IF EXISTS seed_runs;
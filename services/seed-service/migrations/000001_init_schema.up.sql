@@ -0,0 +1,43 @@
+-- ==============================================================================
+-- Seed Service — Initial Schema
+-- ==============================================================================
+-- Tables:
+--   seed_runs   Demo datasets loaded into this environment, one active per seed
+--   seed_rows   Every operational row a run wrote, so teardown removes exactly those
+--
+-- The datasets themselves go into the operational tables (locations,
+-- customers, drivers, hos_logs, shipments, containers, orders, trips,
+-- trip_stops, location_records, milestones).
+-- ==============================================================================
+
+-- ---------------------------------------------------------------------------
+-- seed_runs
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS seed_runs (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    seed          BIGINT       NOT NULL,
+    scale         VARCHAR(20)  NOT NULL,
+    anchor        TIMESTAMPTZ  NOT NULL,
+    row_count     INTEGER      NOT NULL DEFAULT 0,
+    counts        JSONB        NOT NULL DEFAULT '{}',
+    created_by    VARCHAR(100) NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    torn_down_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seed_runs_active_seed ON seed_runs(seed) WHERE torn_down_at IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- seed_rows
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS seed_rows (
+    run_id      UUID        NOT NULL REFERENCES seed_runs(id) ON DELETE CASCADE,
+    ordinal     INTEGER     NOT NULL,
+    table_name  VARCHAR(50) NOT NULL,
+    row_id      UUID        NOT NULL,
+    PRIMARY KEY (run_id, ordinal)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seed_rows_table ON seed_rows(run_id, table_name);
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
@@ -18,11 +23,16 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	grpcHandler "github.com/draymaster/services/tracking-service/internal/grpc"
 	"github.com/draymaster/services/tracking-service/internal/repository"
 	"github.com/draymaster/services/tracking-service/internal/service"
+	"github.com/draymaster/shared/pkg/auth"
 	"github.com/draymaster/shared/pkg/config"
 	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/leader"
 	"github.com/draymaster/shared/pkg/logger"
+	pb "github.com/draymaster/shared/proto/tracking/v1"
 )
 
 func main() {
@@ -79,24 +89,84 @@ func main() {
 	locationRepo := repository.NewPostgresLocationRepository(db)
 	milestoneRepo := repository.NewPostgresMilestoneRepository(db)
 	geofenceRepo := repository.NewPostgresGeofenceRepository(db)
+	corridorRepo := repository.NewPostgresCorridorRepository(db)
+	exportRepo := repository.NewPostgresDataExportRepository(db)
 
 	// Initialize service
 	trackingService := service.NewTrackingService(
 		locationRepo,
 		milestoneRepo,
 		geofenceRepo,
+		corridorRepo,
 		redisClient,
 		eventProducer,
 		log,
 	)
 
+	// Stream order telemetry to customer data lakes
+	exportService := service.NewDataExportService(exportRepo, cfg.Storage, eventProducer, log)
+
+	// Watch Redis for restarts and rebuild derived tracking state, from one
+	// replica at a time so a restart is not rebuilt by every replica at once.
+	// The others still trip their circuit breakers on failed Redis calls.
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	elector := leader.NewElector(leader.NewSQLLocker(db.DB), leader.Config{}, log)
+	go elector.Run(monitorCtx, "tracking.cache-rebuild", func(ctx context.Context) {
+		trackingService.StartCacheMonitor(ctx, 15*time.Second)
+	})
+
+	// Evaluate geofence entries and exits on a bounded worker pool
+	go trackingService.StartGeofenceWorkers(monitorCtx)
+
+	// Relay fleet locations recorded by other instances to stream subscribers
+	go trackingService.StartFleetRelay(monitorCtx)
+
+	// Export new positions and milestones for customers with exports enabled,
+	// from one replica at a time so records are not delivered twice
+	go elector.Run(monitorCtx, "tracking.data-export", func(ctx context.Context) {
+		exportService.Start(ctx, time.Minute)
+	})
+
+	// Stop corridor monitoring when trips complete
+	tripConsumer := kafka.NewConsumer(cfg.KafkaBrokers, "tracking-service-corridors", kafka.Topics.TripCompleted, log)
+	defer tripConsumer.Close()
+	go func() {
+		if err := tripConsumer.Consume(monitorCtx, trackingService.HandleTripCompleted); err != nil && monitorCtx.Err() == nil {
+			log.Errorw("Trip completed consumer stopped", "error", err)
+		}
+	}()
+
+	// Vehicle positions from the ELD providers
+	eldConsumer := kafka.NewConsumer(cfg.KafkaBrokers, "tracking-service-eld", kafka.Topics.ELDLocationRecorded, log)
+	defer eldConsumer.Close()
+	go func() {
+		if err := eldConsumer.Consume(monitorCtx, trackingService.HandleELDLocation); err != nil && monitorCtx.Err() == nil {
+			log.Errorw("ELD location consumer stopped", "error", err)
+		}
+	}()
+
+	// Geofences accepted from locations learned by dispatch
+	geofenceConsumer := kafka.NewConsumer(cfg.KafkaBrokers, "tracking-service-learned-geofences", kafka.Topics.LocationGeofenceLearned, log)
+	defer geofenceConsumer.Close()
+	go func() {
+		if err := geofenceConsumer.Consume(monitorCtx, trackingService.HandleGeofenceLearned); err != nil && monitorCtx.Err() == nil {
+			log.Errorw("Learned geofence consumer stopped", "error", err)
+		}
+	}()
+
 	// Create gRPC server
+	authn, err := auth.NewAuthenticator(cfg.Auth, auth.DefaultPolicy(), log)
+	if err != nil {
+		log.Fatalw("Invalid auth configuration", "error", err)
+	}
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(loggingInterceptor(log)),
+		grpc.ChainUnaryInterceptor(loggingInterceptor(log), authn.UnaryInterceptor()),
+		grpc.StreamInterceptor(authn.StreamInterceptor()),
 	)
 
 	// Register gRPC services
-	// pb.RegisterTrackingServiceServer(grpcServer, grpcHandler.NewTrackingHandler(trackingService))
+	pb.RegisterTrackingServiceServer(grpcServer, grpcHandler.NewServer(trackingService, log))
 
 	// Register health check
 	healthServer := health.NewServer()
@@ -122,7 +192,7 @@ func main() {
 	// Start HTTP health/metrics server
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
-		Handler:      httpHandler(trackingService, log),
+		Handler:      httpHandler(trackingService, exportService, log),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
@@ -153,7 +223,7 @@ func main() {
 	log.Info("Tracking-service stopped")
 }
 
-func httpHandler(svc *service.TrackingService, log *logger.Logger) http.Handler {
+func httpHandler(svc *service.TrackingService, exports *service.DataExportService, log *logger.Logger) http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check endpoint
@@ -170,13 +240,243 @@ func httpHandler(svc *service.TrackingService, log *logger.Logger) http.Handler
 
 	// Metrics endpoint (would integrate with Prometheus)
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		m := svc.CacheMetrics()
+		circuitOpen := 0
+		if m.CircuitOpen {
+			circuitOpen = 1
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 		w.WriteHeader(http.StatusOK)
-		// Prometheus metrics would be exposed here
+		fmt.Fprintf(w, "tracking_cache_hits_total %d\n", m.Hits)
+		fmt.Fprintf(w, "tracking_cache_misses_total %d\n", m.Misses)
+		fmt.Fprintf(w, "tracking_cache_errors_total %d\n", m.Errors)
+		fmt.Fprintf(w, "tracking_cache_db_fallbacks_total %d\n", m.DBFallbacks)
+		fmt.Fprintf(w, "tracking_cache_state_rebuilds_total %d\n", m.StateRebuilds)
+		fmt.Fprintf(w, "tracking_cache_circuit_open %d\n", circuitOpen)
+
+		g := svc.GeofenceMetrics()
+		for _, b := range g.LatencyBuckets {
+			fmt.Fprintf(w, "tracking_geofence_evaluation_seconds_bucket{le=\"%g\"} %d\n", b.UpperBound, b.Count)
+		}
+		fmt.Fprintf(w, "tracking_geofence_evaluation_seconds_bucket{le=\"+Inf\"} %d\n", g.Evaluations)
+		fmt.Fprintf(w, "tracking_geofence_evaluation_seconds_sum %g\n", g.LatencySumSeconds)
+		fmt.Fprintf(w, "tracking_geofence_evaluation_seconds_count %d\n", g.Evaluations)
+		fmt.Fprintf(w, "tracking_geofence_candidates_total %d\n", g.Candidates)
+		fmt.Fprintf(w, "tracking_geofence_points_dropped_total %d\n", g.Dropped)
+		fmt.Fprintf(w, "tracking_geofence_queue_depth %d\n", g.QueueDepth)
+		fmt.Fprintf(w, "tracking_geofences_indexed %d\n", g.Indexed)
+		fmt.Fprintf(w, "tracking_fleet_stream_subscribers %d\n", svc.FleetSubscribers())
+	})
+
+	// Admin: recompute geofence membership after a boundary edit
+	mux.HandleFunc("/admin/geofences/recompute", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		geofenceID, err := uuid.Parse(r.URL.Query().Get("geofence_id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid geofence_id"}`))
+			return
+		}
+
+		drivers, err := svc.RecomputeGeofenceState(r.Context(), geofenceID)
+		if err != nil {
+			log.Errorw("Geofence recompute failed", "geofence_id", geofenceID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"recompute failed"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"geofence_id":"%s","drivers_recomputed":%d}`, geofenceID, drivers)
+	})
+
+	// Report: entries and dwell per geofenced location, e.g. to check contract
+	// free time. from/to are YYYY-MM-DD, both inclusive, defaulting to the
+	// last 30 days; location_id and free_time_mins are optional.
+	mux.HandleFunc("/v1/geofences/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		input := service.GeofenceStatsInput{From: today.AddDate(0, 0, -29), To: today.AddDate(0, 0, 1)}
+		if raw := query.Get("from"); raw != "" {
+			from, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"from must be YYYY-MM-DD"}`))
+				return
+			}
+			input.From = from
+		}
+		if raw := query.Get("to"); raw != "" {
+			to, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"to must be YYYY-MM-DD"}`))
+				return
+			}
+			input.To = to.AddDate(0, 0, 1)
+		}
+		if raw := query.Get("location_id"); raw != "" {
+			locationID, err := uuid.Parse(raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid location_id"}`))
+				return
+			}
+			input.LocationID = &locationID
+		}
+		if raw := query.Get("free_time_mins"); raw != "" {
+			mins, err := strconv.Atoi(raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid free_time_mins"}`))
+				return
+			}
+			input.FreeTimeMins = mins
+		}
+
+		stats, err := svc.GetGeofenceStats(r.Context(), input)
+		if errors.Is(err, service.ErrInvalidStatsRequest) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Errorw("Geofence stats failed", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"stats failed"}`))
+			return
+		}
+		writeJSON(w, stats)
+	})
+
+	// Lookups used by identifier resolution in order-service
+	mux.HandleFunc("/v1/drivers/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/drivers/"), "/"), "/")
+		if r.Method != http.MethodGet || len(parts) != 2 || parts[1] != "location" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		driverID, err := uuid.Parse(parts[0])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid driver_id"}`))
+			return
+		}
+
+		location, err := svc.GetCurrentLocation(r.Context(), driverID)
+		if errors.Is(err, service.ErrNoLocation) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"no location for driver"}`))
+			return
+		}
+		if err != nil {
+			log.Errorw("Driver location lookup failed", "driver_id", driverID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"lookup failed"}`))
+			return
+		}
+		writeJSON(w, location)
+	})
+
+	mux.HandleFunc("/v1/trips/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/trips/"), "/"), "/")
+		if r.Method != http.MethodGet || len(parts) != 2 || parts[1] != "milestones" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		tripID, err := uuid.Parse(parts[0])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid trip_id"}`))
+			return
+		}
+
+		milestones, err := svc.GetTripMilestones(r.Context(), tripID)
+		if err != nil {
+			log.Errorw("Trip milestone lookup failed", "trip_id", tripID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"lookup failed"}`))
+			return
+		}
+		if milestones == nil {
+			milestones = []domain.Milestone{}
+		}
+		writeJSON(w, milestones)
+	})
+
+	// Customer telemetry export settings; see docs/customer-data-export.md
+	mux.HandleFunc("/v1/customers/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/customers/"), "/"), "/")
+		if len(parts) < 2 || parts[1] != "data-export" || len(parts) > 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		customerID, err := uuid.Parse(parts[0])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid customer_id"}`))
+			return
+		}
+
+		var export *domain.CustomerDataExport
+		switch {
+		case len(parts) == 3 && r.Method == http.MethodPost && (parts[2] == "enable" || parts[2] == "disable"):
+			err = exports.SetDataExportEnabled(r.Context(), customerID, parts[2] == "enable")
+			if err == nil {
+				export, err = exports.GetDataExport(r.Context(), customerID)
+			}
+		case len(parts) == 2 && r.Method == http.MethodGet:
+			export, err = exports.GetDataExport(r.Context(), customerID)
+		case len(parts) == 2 && r.Method == http.MethodPut:
+			var input service.ConfigureDataExportInput
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid request body"}`))
+				return
+			}
+			input.CustomerID = customerID
+			input.ConfiguredBy = r.Header.Get("X-User-ID")
+			export, err = exports.ConfigureDataExport(r.Context(), input)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch {
+		case errors.Is(err, service.ErrDataExportNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"no data export for customer"}`))
+		case errors.Is(err, service.ErrInvalidDataExport):
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		case err != nil:
+			log.Errorw("Data export request failed", "customer_id", customerID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"data export request failed"}`))
+		default:
+			writeJSON(w, export)
+		}
 	})
 
 	return mux
 }
 
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
 func loggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
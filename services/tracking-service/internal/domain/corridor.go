@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Corridor monitoring reasons
+const (
+	CorridorReasonHazmat    = "hazmat"
+	CorridorReasonHighValue = "high_value"
+)
+
+// RouteCorridor is the planned route of a monitored trip and how far the
+// driver may stray from it before dispatch is alerted
+type RouteCorridor struct {
+	ID               uuid.UUID    `json:"id" db:"id"`
+	TripID           uuid.UUID    `json:"trip_id" db:"trip_id"`
+	DriverID         *uuid.UUID   `json:"driver_id,omitempty" db:"driver_id"`
+	Reason           string       `json:"reason" db:"reason"` // hazmat, high_value
+	Polyline         []Coordinate `json:"polyline" db:"-"`
+	BufferMeters     float64      `json:"buffer_meters" db:"buffer_meters"`
+	MaxDeviationMins int          `json:"max_deviation_mins" db:"max_deviation_mins"`
+	IsActive         bool         `json:"is_active" db:"is_active"`
+	OffRouteSince    *time.Time   `json:"off_route_since,omitempty" db:"off_route_since"` // First position outside the buffer in the current deviation
+	AlertedAt        *time.Time   `json:"alerted_at,omitempty" db:"alerted_at"`           // Dispatch alerted for the current deviation
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataExportSchemaVersion is stamped on every exported record. Bump it when
+// a field is removed or changes meaning; adding a field does not.
+const DataExportSchemaVersion = "draymaster.telemetry.v1"
+
+// DataExportDestination is where a customer's telemetry is delivered
+type DataExportDestination string
+
+const (
+	DataExportS3    DataExportDestination = "S3"
+	DataExportKafka DataExportDestination = "KAFKA"
+)
+
+// DataExportRecordType is the kind of exported record
+type DataExportRecordType string
+
+const (
+	DataExportLocation  DataExportRecordType = "location"
+	DataExportMilestone DataExportRecordType = "milestone"
+)
+
+// CustomerDataExport is a customer's telemetry export. Only positions and
+// milestones of trips moving the customer's orders are exported, with
+// driver and equipment removed and the trip replaced by a pseudonym keyed
+// on the export's salt. The cursors are the last row exported of each type.
+type CustomerDataExport struct {
+	ID                uuid.UUID             `json:"id" db:"id"`
+	CustomerID        uuid.UUID             `json:"customer_id" db:"customer_id"`
+	Destination       DataExportDestination `json:"destination" db:"destination"`
+	Bucket            string                `json:"bucket,omitempty" db:"bucket"`
+	Region            string                `json:"region,omitempty" db:"region"`
+	Prefix            string                `json:"prefix,omitempty" db:"prefix"`
+	Topic             string                `json:"topic,omitempty" db:"topic"`
+	Enabled           bool                  `json:"enabled" db:"enabled"`
+	Salt              string                `json:"-" db:"salt"`
+	LocationCursorAt  *time.Time            `json:"location_cursor_at,omitempty" db:"location_cursor_at"`
+	LocationCursorID  *uuid.UUID            `json:"-" db:"location_cursor_id"`
+	MilestoneCursorAt *time.Time            `json:"milestone_cursor_at,omitempty" db:"milestone_cursor_at"`
+	MilestoneCursorID *uuid.UUID            `json:"-" db:"milestone_cursor_id"`
+	LastExportedAt    *time.Time            `json:"last_exported_at,omitempty" db:"last_exported_at"`
+	LastError         string                `json:"last_error,omitempty" db:"last_error"`
+	RecordsExported   int64                 `json:"records_exported" db:"records_exported"`
+	CreatedBy         string                `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// Pseudonym returns a stable identifier for id that is only meaningful
+// within this export, so records cannot be joined across customers
+func (e *CustomerDataExport) Pseudonym(id uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(e.Salt))
+	mac.Write(id[:])
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// OrderLocation is a merged-track position on a trip moving one of a
+// customer's orders. A point on a trip carrying several of the customer's
+// orders is read once per order.
+type OrderLocation struct {
+	ID              uuid.UUID `db:"id"`
+	TripID          uuid.UUID `db:"trip_id"`
+	OrderID         uuid.UUID `db:"order_id"`
+	OrderNumber     string    `db:"order_number"`
+	CustomerRef     string    `db:"customer_reference"`
+	ContainerNumber string    `db:"container_number"`
+	Latitude        float64   `db:"latitude"`
+	Longitude       float64   `db:"longitude"`
+	SpeedMPH        float64   `db:"speed_mph"`
+	Heading         float64   `db:"heading"`
+	RecordedAt      time.Time `db:"recorded_at"`
+	ReceivedAt      time.Time `db:"received_at"` // Cursor column
+}
+
+// OrderMilestone is a milestone on a trip moving one of a customer's orders
+type OrderMilestone struct {
+	ID              uuid.UUID     `db:"id"`
+	TripID          uuid.UUID     `db:"trip_id"`
+	OrderID         uuid.UUID     `db:"order_id"`
+	OrderNumber     string        `db:"order_number"`
+	CustomerRef     string        `db:"customer_reference"`
+	ContainerNumber string        `db:"container_number"`
+	Type            MilestoneType `db:"type"`
+	OccurredAt      time.Time     `db:"occurred_at"`
+	Latitude        float64       `db:"latitude"`
+	Longitude       float64       `db:"longitude"`
+	LocationName    string        `db:"location_name"`
+	CreatedAt       time.Time     `db:"created_at"` // Cursor column
+}
+
+// DataExportRecord is one exported line. See docs/customer-data-export.md
+// for the published schema.
+type DataExportRecord struct {
+	Schema          string               `json:"schema"`
+	Type            DataExportRecordType `json:"type"`
+	RecordID        string               `json:"record_id"` // Unique per record, for de-duplicating redelivery
+	OrderID         uuid.UUID            `json:"order_id"`
+	OrderNumber     string               `json:"order_number"`
+	CustomerRef     string               `json:"customer_reference,omitempty"`
+	ContainerNumber string               `json:"container_number,omitempty"`
+	MoveRef         string               `json:"move_ref"` // Pseudonymous trip identifier
+	Latitude        float64              `json:"latitude"`
+	Longitude       float64              `json:"longitude"`
+	SpeedMPH        *float64             `json:"speed_mph,omitempty"`
+	Heading         *float64             `json:"heading,omitempty"`
+	Milestone       MilestoneType        `json:"milestone,omitempty"`
+	LocationName    string               `json:"location_name,omitempty"`
+	Timestamp       time.Time            `json:"timestamp"`
+}
+
+// exportPrecision rounds exported coordinates to about 11m, enough for
+// yard-level visibility without pinpointing a driver
+const exportPrecision = 1e4
+
+func roundCoordinate(v float64) float64 {
+	return math.Round(v*exportPrecision) / exportPrecision
+}
+
+// LocationExportRecord anonymizes an order position for export
+func (e *CustomerDataExport) LocationExportRecord(l *OrderLocation) DataExportRecord {
+	speed, heading := math.Round(l.SpeedMPH), math.Round(l.Heading)
+	return DataExportRecord{
+		Schema:          DataExportSchemaVersion,
+		Type:            DataExportLocation,
+		RecordID:        e.Pseudonym(l.ID) + ":" + l.OrderID.String(),
+		OrderID:         l.OrderID,
+		OrderNumber:     l.OrderNumber,
+		CustomerRef:     l.CustomerRef,
+		ContainerNumber: l.ContainerNumber,
+		MoveRef:         e.Pseudonym(l.TripID),
+		Latitude:        roundCoordinate(l.Latitude),
+		Longitude:       roundCoordinate(l.Longitude),
+		SpeedMPH:        &speed,
+		Heading:         &heading,
+		Timestamp:       l.RecordedAt.UTC(),
+	}
+}
+
+// MilestoneExportRecord anonymizes an order milestone for export
+func (e *CustomerDataExport) MilestoneExportRecord(m *OrderMilestone) DataExportRecord {
+	return DataExportRecord{
+		Schema:          DataExportSchemaVersion,
+		Type:            DataExportMilestone,
+		RecordID:        e.Pseudonym(m.ID) + ":" + m.OrderID.String(),
+		OrderID:         m.OrderID,
+		OrderNumber:     m.OrderNumber,
+		CustomerRef:     m.CustomerRef,
+		ContainerNumber: m.ContainerNumber,
+		MoveRef:         e.Pseudonym(m.TripID),
+		Latitude:        roundCoordinate(m.Latitude),
+		Longitude:       roundCoordinate(m.Longitude),
+		Milestone:       m.Type,
+		LocationName:    m.LocationName,
+		Timestamp:       m.OccurredAt.UTC(),
+	}
+}
@@ -0,0 +1,19 @@
+package domain
+
+// Location sources
+const (
+	SourceELD        = "eld"
+	SourceTelematics = "telematics"
+	SourceMobile     = "mobile"
+	SourceGPS        = "gps"
+)
+
+// FusionStatus is how a point was treated when positions from several
+// sources for the same driver and tractor were merged into one track
+type FusionStatus string
+
+const (
+	FusionPrimary    FusionStatus = "PRIMARY"    // Part of the track downstream consumers see
+	FusionDuplicate  FusionStatus = "DUPLICATE"  // Near-identical to a point already on the track
+	FusionSuppressed FusionStatus = "SUPPRESSED" // From a source that lost to a better or fresher one
+)
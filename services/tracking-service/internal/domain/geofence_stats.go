@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GeofenceVisit is a driver's stay inside a geofence, from an enter event to
+// the matching exit. ExitedAt is nil while the driver is still inside.
+type GeofenceVisit struct {
+	GeofenceID uuid.UUID  `json:"geofence_id"`
+	DriverID   uuid.UUID  `json:"driver_id"`
+	TripID     *uuid.UUID `json:"trip_id,omitempty"`
+	EnteredAt  time.Time  `json:"entered_at"`
+	ExitedAt   *time.Time `json:"exited_at,omitempty"`
+}
+
+// DwellMins returns the minutes spent inside, or -1 for an open visit
+func (v *GeofenceVisit) DwellMins() float64 {
+	if v.ExitedAt == nil {
+		return -1
+	}
+	return v.ExitedAt.Sub(v.EnteredAt).Minutes()
+}
+
+// GeofenceDayStats is one day of activity at a geofence. Visits count on the
+// day they were entered.
+type GeofenceDayStats struct {
+	Date            string  `json:"date"` // YYYY-MM-DD, UTC
+	Entries         int     `json:"entries"`
+	UniqueDrivers   int     `json:"unique_drivers"`
+	CompletedVisits int     `json:"completed_visits"`
+	AvgDwellMins    float64 `json:"avg_dwell_mins"`
+	MedianDwellMins float64 `json:"median_dwell_mins"`
+}
+
+// GeofenceStats summarizes entries and dwell at a location's geofence over a
+// period. When a free time is given, visits that outlasted it are counted so
+// a contract's free time can be checked against how long drivers really wait.
+type GeofenceStats struct {
+	GeofenceID      uuid.UUID          `json:"geofence_id"`
+	GeofenceName    string             `json:"geofence_name"`
+	LocationID      uuid.UUID          `json:"location_id"`
+	From            time.Time          `json:"from"`
+	To              time.Time          `json:"to"`
+	Entries         int                `json:"entries"`
+	UniqueDrivers   int                `json:"unique_drivers"`
+	CompletedVisits int                `json:"completed_visits"`
+	OpenVisits      int                `json:"open_visits"` // Entered with no exit yet
+	AvgDwellMins    float64            `json:"avg_dwell_mins"`
+	MedianDwellMins float64            `json:"median_dwell_mins"`
+	P90DwellMins    float64            `json:"p90_dwell_mins"`
+	FreeTimeMins    int                `json:"free_time_mins,omitempty"`
+	OverFreeTime    int                `json:"over_free_time,omitempty"`     // Completed visits longer than free time
+	OverFreeTimePct float64            `json:"over_free_time_pct,omitempty"` // Share of completed visits, 0-100
+	Daily           []GeofenceDayStats `json:"daily"`
+}
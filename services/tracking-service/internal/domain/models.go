@@ -34,7 +34,8 @@ type LocationRecord struct {
 	SpeedMPH       float64   `json:"speed_mph" db:"speed_mph"`
 	Heading        float64   `json:"heading" db:"heading"`
 	AccuracyMeters float64   `json:"accuracy_meters" db:"accuracy_meters"`
-	Source         string    `json:"source" db:"source"` // eld, mobile, gps
+	Source         string    `json:"source" db:"source"` // eld, telematics, mobile, gps
+	FusionStatus   FusionStatus `json:"fusion_status" db:"fusion_status"`
 	RecordedAt     time.Time `json:"recorded_at" db:"recorded_at"`
 	ReceivedAt     time.Time `json:"received_at" db:"received_at"`
 }
@@ -51,6 +52,7 @@ type CurrentLocation struct {
 	Longitude           float64    `json:"longitude"`
 	SpeedMPH            float64    `json:"speed_mph"`
 	Heading             float64    `json:"heading"`
+	Source              string     `json:"source,omitempty"` // Source currently leading the driver's track
 	Status              string     `json:"status"` // moving, stopped, idle
 	CurrentStopName     string     `json:"current_stop_name,omitempty"`
 	CurrentStopSequence int        `json:"current_stop_sequence"`
@@ -87,10 +89,37 @@ type Geofence struct {
 	RadiusMeters    float64      `json:"radius_meters" db:"radius_meters"`
 	Polygon         []Coordinate `json:"polygon,omitempty"`
 	IsActive        bool         `json:"is_active" db:"is_active"`
+	Version         int          `json:"version" db:"version"`
 	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
 }
 
+// GeofenceVersion is an immutable snapshot of a geofence boundary
+type GeofenceVersion struct {
+	GeofenceID      uuid.UUID    `json:"geofence_id" db:"geofence_id"`
+	Version         int          `json:"version" db:"version"`
+	Type            string       `json:"type" db:"type"`
+	CenterLatitude  float64      `json:"center_latitude" db:"center_latitude"`
+	CenterLongitude float64      `json:"center_longitude" db:"center_longitude"`
+	RadiusMeters    float64      `json:"radius_meters" db:"radius_meters"`
+	Polygon         []Coordinate `json:"polygon,omitempty" db:"-"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+}
+
+// Boundary returns a geofence carrying this version's boundary for containment checks
+func (v *GeofenceVersion) Boundary() *Geofence {
+	return &Geofence{
+		ID:              v.GeofenceID,
+		Type:            v.Type,
+		CenterLatitude:  v.CenterLatitude,
+		CenterLongitude: v.CenterLongitude,
+		RadiusMeters:    v.RadiusMeters,
+		Polygon:         v.Polygon,
+		IsActive:        true,
+		Version:         v.Version,
+	}
+}
+
 // Coordinate represents a lat/lon point
 type Coordinate struct {
 	Latitude  float64 `json:"latitude"`
@@ -146,13 +175,15 @@ type ContainerEvent struct {
 
 // GeofenceEvent represents an entry/exit event
 type GeofenceEvent struct {
-	GeofenceID   uuid.UUID `json:"geofence_id"`
-	GeofenceName string    `json:"geofence_name"`
-	LocationID   uuid.UUID `json:"location_id"`
-	DriverID     uuid.UUID `json:"driver_id"`
-	TripID       *uuid.UUID `json:"trip_id,omitempty"`
-	EventType    string    `json:"event_type"` // enter, exit
-	OccurredAt   time.Time `json:"occurred_at"`
-	Latitude     float64   `json:"latitude"`
-	Longitude    float64   `json:"longitude"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	GeofenceID      uuid.UUID  `json:"geofence_id" db:"geofence_id"`
+	GeofenceName    string     `json:"geofence_name" db:"geofence_name"`
+	LocationID      uuid.UUID  `json:"location_id" db:"location_id"`
+	DriverID        uuid.UUID  `json:"driver_id" db:"driver_id"`
+	TripID          *uuid.UUID `json:"trip_id,omitempty" db:"trip_id"`
+	GeofenceVersion int        `json:"geofence_version" db:"geofence_version"`
+	EventType       string     `json:"event_type" db:"event_type"` // enter, exit
+	OccurredAt      time.Time  `json:"occurred_at" db:"occurred_at"`
+	Latitude        float64    `json:"latitude" db:"latitude"`
+	Longitude       float64    `json:"longitude" db:"longitude"`
 }
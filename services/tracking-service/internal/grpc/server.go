@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/draymaster/shared/proto/tracking/v1"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/services/tracking-service/internal/service"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// maxGeofenceLookup caps the geofences asked for in one GetGeofences call
+const maxGeofenceLookup = 500
+
+// Fleet streams coalesce each driver's updates over defaultFleetInterval
+// unless the caller asks otherwise, and never over less than minFleetInterval.
+const (
+	defaultFleetInterval = time.Second
+	minFleetInterval     = 250 * time.Millisecond
+)
+
+// Server implements the TrackingService gRPC API.
+type Server struct {
+	pb.UnimplementedTrackingServiceServer
+	svc *service.TrackingService
+	log *logger.Logger
+}
+
+// NewServer creates a new TrackingService gRPC server.
+func NewServer(svc *service.TrackingService, log *logger.Logger) *Server {
+	return &Server{svc: svc, log: log}
+}
+
+// StreamFleetLocations sends the current location of the requested drivers
+// straight away, then each driver's new location as points are recorded,
+// at most once per interval per driver, until the caller goes away.
+func (s *Server) StreamFleetLocations(req *pb.StreamFleetLocationsRequest, stream pb.TrackingService_StreamFleetLocationsServer) error {
+	ctx := stream.Context()
+	filter := service.FleetFilter{}
+	for _, raw := range req.GetDriverIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid driver_id %q", raw)
+		}
+		filter.DriverIDs = append(filter.DriverIDs, id)
+	}
+	for _, raw := range req.GetTripIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid trip_id %q", raw)
+		}
+		filter.TripIDs = append(filter.TripIDs, id)
+	}
+	interval := defaultFleetInterval
+	if req.GetMinIntervalMs() > 0 {
+		interval = time.Duration(req.GetMinIntervalMs()) * time.Millisecond
+	}
+	if interval < minFleetInterval {
+		interval = minFleetInterval
+	}
+
+	// Subscribe before reading the snapshot so no update falls between them
+	sub := s.svc.SubscribeFleetLocations(filter)
+	defer sub.Close()
+
+	if !req.GetSkipSnapshot() {
+		locations, err := s.svc.FleetSnapshot(ctx, filter)
+		if err != nil {
+			s.log.Errorw("Fleet snapshot failed", "error", err)
+			return status.Error(codes.Unavailable, "fleet snapshot failed")
+		}
+		for i := range locations {
+			if err := stream.Send(currentLocationToProto(&locations[i])); err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		updates, err := sub.Next(ctx)
+		if err != nil {
+			return nil
+		}
+		for i := range updates {
+			if err := stream.Send(currentLocationToProto(&updates[i])); err != nil {
+				return err
+			}
+		}
+
+		// Let further updates coalesce before the next send
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetGeofences returns several geofences at once, so a gateway resolving
+// the geofences of many locations makes one call
+func (s *Server) GetGeofences(ctx context.Context, req *pb.GetGeofencesRequest) (*pb.GetGeofencesResponse, error) {
+	if len(req.GetIds()) > maxGeofenceLookup {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot look up more than %d geofences at once", maxGeofenceLookup)
+	}
+	ids := make([]uuid.UUID, len(req.GetIds()))
+	for i, raw := range req.GetIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid id %q", raw)
+		}
+		ids[i] = id
+	}
+
+	geofences, err := s.svc.GetGeofences(ctx, ids)
+	if err != nil {
+		s.log.Errorw("Geofence lookup failed", "error", err)
+		return nil, status.Error(codes.Unavailable, "geofence lookup failed")
+	}
+	resp := &pb.GetGeofencesResponse{Geofences: make([]*pb.Geofence, len(geofences))}
+	for i, geofence := range geofences {
+		resp.Geofences[i] = geofenceToProto(geofence)
+	}
+	return resp, nil
+}
+
+func geofenceToProto(g *domain.Geofence) *pb.Geofence {
+	out := &pb.Geofence{
+		Id:              g.ID.String(),
+		LocationId:      g.LocationID.String(),
+		Name:            g.Name,
+		Type:            pb.GeofenceType(pb.GeofenceType_value[fmt.Sprintf("GEOFENCE_TYPE_%s", strings.ToUpper(g.Type))]),
+		CenterLatitude:  g.CenterLatitude,
+		CenterLongitude: g.CenterLongitude,
+		RadiusMeters:    g.RadiusMeters,
+		IsActive:        g.IsActive,
+	}
+	for _, point := range g.Polygon {
+		out.Polygon = append(out.Polygon, &pb.Coordinate{Latitude: point.Latitude, Longitude: point.Longitude})
+	}
+	return out
+}
+
+func currentLocationToProto(l *domain.CurrentLocation) *pb.CurrentLocation {
+	out := &pb.CurrentLocation{
+		DriverId:            l.DriverID.String(),
+		DriverName:          l.DriverName,
+		TractorUnit:         l.TractorUnit,
+		TripNumber:          l.TripNumber,
+		Latitude:            l.Latitude,
+		Longitude:           l.Longitude,
+		SpeedMph:            l.SpeedMPH,
+		Heading:             l.Heading,
+		Status:              l.Status,
+		CurrentStopName:     l.CurrentStopName,
+		CurrentStopSequence: int32(l.CurrentStopSequence),
+		Source:              l.Source,
+	}
+	if l.TractorID != nil {
+		out.TractorId = l.TractorID.String()
+	}
+	if l.TripID != nil {
+		out.TripId = l.TripID.String()
+	}
+	if !l.LastUpdate.IsZero() {
+		out.LastUpdate = timestamppb.New(l.LastUpdate)
+	}
+	return out
+}
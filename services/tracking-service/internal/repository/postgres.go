@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -26,13 +27,15 @@ func (r *PostgresLocationRepository) Create(ctx context.Context, record *domain.
 	query := `
 		INSERT INTO location_records (
 			id, driver_id, tractor_id, trip_id, latitude, longitude,
-			speed_mph, heading, accuracy_meters, source, recorded_at, received_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+			speed_mph, heading, accuracy_meters, source, recorded_at, received_at,
+			fusion_status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		record.ID, record.DriverID, record.TractorID, record.TripID,
 		record.Latitude, record.Longitude, record.SpeedMPH, record.Heading,
 		record.AccuracyMeters, record.Source, record.RecordedAt, record.ReceivedAt,
+		record.FusionStatus,
 	)
 	return err
 }
@@ -68,10 +71,12 @@ func (r *PostgresLocationRepository) GetHistory(ctx context.Context, driverID uu
 				avg(accuracy_meters) as accuracy_meters,
 				first(source, recorded_at) as source,
 				time_bucket($1::interval, recorded_at) as recorded_at,
-				first(received_at, recorded_at) as received_at
+				first(received_at, recorded_at) as received_at,
+				'PRIMARY' as fusion_status
 			FROM location_records
 			WHERE driver_id = $2
-			  AND recorded_at BETWEEN $3 AND $4`
+			  AND recorded_at BETWEEN $3 AND $4
+			  AND fusion_status = 'PRIMARY'`
 		args = append(args, fmt.Sprintf("%d seconds", intervalSecs), driverID, startTime, endTime)
 
 		if tripID != nil {
@@ -84,7 +89,8 @@ func (r *PostgresLocationRepository) GetHistory(ctx context.Context, driverID uu
 		query = `
 			SELECT * FROM location_records
 			WHERE driver_id = $1
-			  AND recorded_at BETWEEN $2 AND $3`
+			  AND recorded_at BETWEEN $2 AND $3
+			  AND fusion_status = 'PRIMARY'`
 		args = append(args, driverID, startTime, endTime)
 
 		if tripID != nil {
@@ -103,7 +109,7 @@ func (r *PostgresLocationRepository) GetLatest(ctx context.Context, driverID uui
 	var record domain.LocationRecord
 	query := `
 		SELECT * FROM location_records
-		WHERE driver_id = $1
+		WHERE driver_id = $1 AND fusion_status = 'PRIMARY'
 		ORDER BY recorded_at DESC
 		LIMIT 1`
 	err := r.db.GetContext(ctx, &record, query, driverID)
@@ -113,9 +119,20 @@ func (r *PostgresLocationRepository) GetLatest(ctx context.Context, driverID uui
 	return &record, err
 }
 
+// GetLatestSince returns the most recent point for every driver that reported after since
+func (r *PostgresLocationRepository) GetLatestSince(ctx context.Context, since time.Time) ([]domain.LocationRecord, error) {
+	var records []domain.LocationRecord
+	query := `
+		SELECT DISTINCT ON (driver_id) * FROM location_records
+		WHERE recorded_at >= $1 AND fusion_status = 'PRIMARY'
+		ORDER BY driver_id, recorded_at DESC`
+	err := r.db.SelectContext(ctx, &records, query, since)
+	return records, err
+}
+
 func (r *PostgresLocationRepository) GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.LocationRecord, error) {
 	var records []domain.LocationRecord
-	query := `SELECT * FROM location_records WHERE trip_id = $1 ORDER BY recorded_at`
+	query := `SELECT * FROM location_records WHERE trip_id = $1 AND fusion_status = 'PRIMARY' ORDER BY recorded_at`
 	err := r.db.SelectContext(ctx, &records, query, tripID)
 	return records, err
 }
@@ -223,13 +240,13 @@ func (r *PostgresGeofenceRepository) Create(ctx context.Context, geofence *domai
 	query := `
 		INSERT INTO geofences (
 			id, location_id, name, type, center_latitude, center_longitude,
-			radius_meters, is_active, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+			radius_meters, is_active, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		geofence.ID, geofence.LocationID, geofence.Name, geofence.Type,
 		geofence.CenterLatitude, geofence.CenterLongitude, geofence.RadiusMeters,
-		geofence.IsActive, geofence.CreatedAt, geofence.UpdatedAt,
+		geofence.IsActive, geofence.Version, geofence.CreatedAt, geofence.UpdatedAt,
 	)
 	return err
 }
@@ -244,6 +261,19 @@ func (r *PostgresGeofenceRepository) GetByID(ctx context.Context, id uuid.UUID)
 	return &geofence, err
 }
 
+func (r *PostgresGeofenceRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Geofence, error) {
+	var geofences []*domain.Geofence
+	if len(ids) == 0 {
+		return geofences, nil
+	}
+	query, args, err := sqlx.In(`SELECT * FROM geofences WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &geofences, r.db.Rebind(query), args...)
+	return geofences, err
+}
+
 func (r *PostgresGeofenceRepository) GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.Geofence, error) {
 	var geofence domain.Geofence
 	query := `SELECT * FROM geofences WHERE location_id = $1`
@@ -272,12 +302,13 @@ func (r *PostgresGeofenceRepository) Update(ctx context.Context, geofence *domai
 	query := `
 		UPDATE geofences SET
 			name = $2, type = $3, center_latitude = $4, center_longitude = $5,
-			radius_meters = $6, is_active = $7, updated_at = $8
+			radius_meters = $6, is_active = $7, version = $8, updated_at = $9
 		WHERE id = $1`
 
 	_, err := r.db.ExecContext(ctx, query,
 		geofence.ID, geofence.Name, geofence.Type, geofence.CenterLatitude,
-		geofence.CenterLongitude, geofence.RadiusMeters, geofence.IsActive, time.Now(),
+		geofence.CenterLongitude, geofence.RadiusMeters, geofence.IsActive,
+		geofence.Version, time.Now(),
 	)
 	return err
 }
@@ -293,3 +324,320 @@ func (r *PostgresGeofenceRepository) SetActive(ctx context.Context, id uuid.UUID
 	_, err := r.db.ExecContext(ctx, query, id, isActive, time.Now())
 	return err
 }
+
+// CreateVersion stores an immutable boundary snapshot for a geofence version
+func (r *PostgresGeofenceRepository) CreateVersion(ctx context.Context, version *domain.GeofenceVersion) error {
+	polygon, err := json.Marshal(version.Polygon)
+	if err != nil {
+		return fmt.Errorf("failed to marshal polygon: %w", err)
+	}
+
+	query := `
+		INSERT INTO geofence_versions (
+			geofence_id, version, type, center_latitude, center_longitude,
+			radius_meters, polygon, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (geofence_id, version) DO NOTHING`
+
+	_, err = r.db.ExecContext(ctx, query,
+		version.GeofenceID, version.Version, version.Type, version.CenterLatitude,
+		version.CenterLongitude, version.RadiusMeters, polygon, version.CreatedAt,
+	)
+	return err
+}
+
+// GetVersion returns the boundary snapshot for a specific geofence version
+func (r *PostgresGeofenceRepository) GetVersion(ctx context.Context, id uuid.UUID, version int) (*domain.GeofenceVersion, error) {
+	var row struct {
+		domain.GeofenceVersion
+		PolygonJSON []byte `db:"polygon"`
+	}
+	query := `
+		SELECT geofence_id, version, type, center_latitude, center_longitude,
+			radius_meters, polygon, created_at
+		FROM geofence_versions
+		WHERE geofence_id = $1 AND version = $2`
+	err := r.db.GetContext(ctx, &row, query, id, version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(row.PolygonJSON) > 0 {
+		if err := json.Unmarshal(row.PolygonJSON, &row.GeofenceVersion.Polygon); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal polygon: %w", err)
+		}
+	}
+	return &row.GeofenceVersion, nil
+}
+
+// CreateEvent stores a geofence enter or exit event
+func (r *PostgresGeofenceRepository) CreateEvent(ctx context.Context, event *domain.GeofenceEvent) error {
+	query := `
+		INSERT INTO geofence_events (
+			id, geofence_id, geofence_version, location_id, driver_id, trip_id,
+			event_type, occurred_at, latitude, longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.GeofenceID, event.GeofenceVersion, event.LocationID, event.DriverID, event.TripID,
+		event.EventType, event.OccurredAt, event.Latitude, event.Longitude,
+	)
+	return err
+}
+
+// GetEvents returns geofence events in a time range, oldest first
+func (r *PostgresGeofenceRepository) GetEvents(ctx context.Context, from, to time.Time, locationID *uuid.UUID) ([]domain.GeofenceEvent, error) {
+	var events []domain.GeofenceEvent
+	query := `
+		SELECT e.id, e.geofence_id, g.name AS geofence_name, e.geofence_version, e.location_id,
+			e.driver_id, e.trip_id, e.event_type, e.occurred_at, e.latitude, e.longitude
+		FROM geofence_events e
+		JOIN geofences g ON g.id = e.geofence_id
+		WHERE e.occurred_at >= $1 AND e.occurred_at < $2
+			AND ($3::uuid IS NULL OR e.location_id = $3)
+		ORDER BY e.occurred_at, e.id`
+	err := r.db.SelectContext(ctx, &events, query, from, to, locationID)
+	return events, err
+}
+
+// PostgresCorridorRepository implements CorridorRepository using PostgreSQL
+type PostgresCorridorRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresCorridorRepository creates a new PostgreSQL corridor repository
+func NewPostgresCorridorRepository(db *sqlx.DB) *PostgresCorridorRepository {
+	return &PostgresCorridorRepository{db: db}
+}
+
+// corridorRow scans a route corridor with its JSON polyline
+type corridorRow struct {
+	domain.RouteCorridor
+	PolylineJSON []byte `db:"polyline"`
+}
+
+func (row *corridorRow) corridor() (*domain.RouteCorridor, error) {
+	if len(row.PolylineJSON) > 0 {
+		if err := json.Unmarshal(row.PolylineJSON, &row.RouteCorridor.Polyline); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal polyline: %w", err)
+		}
+	}
+	return &row.RouteCorridor, nil
+}
+
+const corridorColumns = `id, trip_id, driver_id, reason, polyline, buffer_meters, max_deviation_mins,
+			is_active, off_route_since, alerted_at, created_at, updated_at`
+
+// Create stores a corridor, replacing any earlier corridor for the same trip
+func (r *PostgresCorridorRepository) Create(ctx context.Context, corridor *domain.RouteCorridor) error {
+	polyline, err := json.Marshal(corridor.Polyline)
+	if err != nil {
+		return fmt.Errorf("failed to marshal polyline: %w", err)
+	}
+
+	query := `
+		INSERT INTO route_corridors (
+			id, trip_id, driver_id, reason, polyline, buffer_meters, max_deviation_mins,
+			is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (trip_id) DO UPDATE SET
+			id = EXCLUDED.id, driver_id = EXCLUDED.driver_id, reason = EXCLUDED.reason,
+			polyline = EXCLUDED.polyline, buffer_meters = EXCLUDED.buffer_meters,
+			max_deviation_mins = EXCLUDED.max_deviation_mins, is_active = EXCLUDED.is_active,
+			off_route_since = NULL, alerted_at = NULL, updated_at = EXCLUDED.updated_at`
+
+	_, err = r.db.ExecContext(ctx, query,
+		corridor.ID, corridor.TripID, corridor.DriverID, corridor.Reason, polyline,
+		corridor.BufferMeters, corridor.MaxDeviationMins, corridor.IsActive,
+		corridor.CreatedAt, corridor.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresCorridorRepository) GetByTripID(ctx context.Context, tripID uuid.UUID) (*domain.RouteCorridor, error) {
+	var row corridorRow
+	query := `SELECT ` + corridorColumns + ` FROM route_corridors WHERE trip_id = $1`
+	err := r.db.GetContext(ctx, &row, query, tripID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.corridor()
+}
+
+func (r *PostgresCorridorRepository) GetActive(ctx context.Context) ([]*domain.RouteCorridor, error) {
+	var rows []corridorRow
+	query := `SELECT ` + corridorColumns + ` FROM route_corridors WHERE is_active = true`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+
+	corridors := make([]*domain.RouteCorridor, 0, len(rows))
+	for i := range rows {
+		corridor, err := rows[i].corridor()
+		if err != nil {
+			return nil, err
+		}
+		corridors = append(corridors, corridor)
+	}
+	return corridors, nil
+}
+
+// UpdateState saves the deviation state of a corridor
+func (r *PostgresCorridorRepository) UpdateState(ctx context.Context, corridor *domain.RouteCorridor) error {
+	query := `
+		UPDATE route_corridors SET
+			off_route_since = $2, alerted_at = $3, updated_at = $4
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		corridor.ID, corridor.OffRouteSince, corridor.AlertedAt, time.Now(),
+	)
+	return err
+}
+
+func (r *PostgresCorridorRepository) Deactivate(ctx context.Context, tripID uuid.UUID) error {
+	query := `UPDATE route_corridors SET is_active = false, updated_at = $2 WHERE trip_id = $1`
+	_, err := r.db.ExecContext(ctx, query, tripID, time.Now())
+	return err
+}
+
+// PostgresDataExportRepository implements DataExportRepository
+type PostgresDataExportRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresDataExportRepository creates a new PostgreSQL data export repository
+func NewPostgresDataExportRepository(db *sqlx.DB) *PostgresDataExportRepository {
+	return &PostgresDataExportRepository{db: db}
+}
+
+const dataExportColumns = `id, customer_id, destination, bucket, region, prefix, topic, enabled, salt,
+			location_cursor_at, location_cursor_id, milestone_cursor_at, milestone_cursor_id,
+			last_exported_at, last_error, records_exported, created_by, created_at, updated_at`
+
+// Save creates or replaces a customer's export settings. Cursors, salt and
+// progress are kept when the settings change so nothing is sent twice.
+func (r *PostgresDataExportRepository) Save(ctx context.Context, export *domain.CustomerDataExport) error {
+	query := `
+		INSERT INTO customer_data_exports (
+			id, customer_id, destination, bucket, region, prefix, topic, enabled, salt,
+			created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (customer_id) DO UPDATE SET
+			destination = EXCLUDED.destination, bucket = EXCLUDED.bucket, region = EXCLUDED.region,
+			prefix = EXCLUDED.prefix, topic = EXCLUDED.topic, enabled = EXCLUDED.enabled,
+			last_error = '', updated_at = EXCLUDED.updated_at
+		RETURNING id, salt, location_cursor_at, location_cursor_id, milestone_cursor_at,
+			milestone_cursor_id, last_exported_at, records_exported, created_by, created_at`
+
+	return r.db.QueryRowxContext(ctx, query,
+		export.ID, export.CustomerID, export.Destination, export.Bucket, export.Region,
+		export.Prefix, export.Topic, export.Enabled, export.Salt,
+		export.CreatedBy, export.CreatedAt, export.UpdatedAt,
+	).Scan(
+		&export.ID, &export.Salt, &export.LocationCursorAt, &export.LocationCursorID,
+		&export.MilestoneCursorAt, &export.MilestoneCursorID, &export.LastExportedAt,
+		&export.RecordsExported, &export.CreatedBy, &export.CreatedAt,
+	)
+}
+
+func (r *PostgresDataExportRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID) (*domain.CustomerDataExport, error) {
+	var export domain.CustomerDataExport
+	query := `SELECT ` + dataExportColumns + ` FROM customer_data_exports WHERE customer_id = $1`
+	err := r.db.GetContext(ctx, &export, query, customerID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *PostgresDataExportRepository) GetEnabled(ctx context.Context) ([]*domain.CustomerDataExport, error) {
+	var exports []*domain.CustomerDataExport
+	query := `SELECT ` + dataExportColumns + ` FROM customer_data_exports WHERE enabled = true ORDER BY customer_id`
+	err := r.db.SelectContext(ctx, &exports, query)
+	return exports, err
+}
+
+func (r *PostgresDataExportRepository) SetEnabled(ctx context.Context, customerID uuid.UUID, enabled bool) error {
+	query := `UPDATE customer_data_exports SET enabled = $2, last_error = '', updated_at = $3 WHERE customer_id = $1`
+	result, err := r.db.ExecContext(ctx, query, customerID, enabled, time.Now())
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PostgresDataExportRepository) SaveProgress(ctx context.Context, export *domain.CustomerDataExport) error {
+	query := `
+		UPDATE customer_data_exports SET
+			location_cursor_at = $2, location_cursor_id = $3,
+			milestone_cursor_at = $4, milestone_cursor_id = $5,
+			last_exported_at = $6, last_error = $7, records_exported = $8, updated_at = $9
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		export.ID, export.LocationCursorAt, export.LocationCursorID,
+		export.MilestoneCursorAt, export.MilestoneCursorID,
+		export.LastExportedAt, export.LastError, export.RecordsExported, time.Now(),
+	)
+	return err
+}
+
+// customerOrders joins trips to the orders of customer $1 they move
+const customerOrders = `
+			JOIN trip_orders tor ON tor.trip_id = %s.trip_id
+			JOIN orders o ON o.id = tor.order_id AND o.deleted_at IS NULL
+			JOIN shipments sh ON sh.id = o.shipment_id AND sh.customer_id = $1
+			LEFT JOIN containers c ON c.id = o.container_id`
+
+// GetOrderLocations reads merged-track points by the time they were received,
+// so points that arrive late are still exported
+func (r *PostgresDataExportRepository) GetOrderLocations(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderLocation, error) {
+	var locations []domain.OrderLocation
+	query := `
+		SELECT lr.id, lr.trip_id, o.id AS order_id, o.order_number,
+			COALESCE(o.customer_reference, '') AS customer_reference,
+			COALESCE(c.container_number, '') AS container_number,
+			lr.latitude, lr.longitude, lr.speed_mph, lr.heading, lr.recorded_at, lr.received_at
+		FROM location_records lr` + fmt.Sprintf(customerOrders, "lr") + `
+		WHERE lr.fusion_status = 'PRIMARY'
+		  AND lr.received_at < $2
+		  AND ($3::timestamptz IS NULL OR (lr.received_at, lr.id) > ($3, $4))
+		ORDER BY lr.received_at, lr.id, o.id
+		LIMIT $5`
+	err := r.db.SelectContext(ctx, &locations, query, customerID, until, afterAt, afterID, limit)
+	return locations, err
+}
+
+// GetOrderMilestones reads milestones by the time they were recorded. A
+// milestone for a specific container only goes to that container's order.
+func (r *PostgresDataExportRepository) GetOrderMilestones(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderMilestone, error) {
+	var milestones []domain.OrderMilestone
+	query := `
+		SELECT m.id, m.trip_id, o.id AS order_id, o.order_number,
+			COALESCE(o.customer_reference, '') AS customer_reference,
+			COALESCE(c.container_number, m.container_number, '') AS container_number,
+			m.type, m.occurred_at, COALESCE(m.latitude, 0) AS latitude,
+			COALESCE(m.longitude, 0) AS longitude, COALESCE(m.location_name, '') AS location_name,
+			m.created_at
+		FROM milestones m` + fmt.Sprintf(customerOrders, "m") + `
+		WHERE (m.container_id IS NULL OR m.container_id = o.container_id)
+		  AND m.created_at < $2
+		  AND ($3::timestamptz IS NULL OR (m.created_at, m.id) > ($3, $4))
+		ORDER BY m.created_at, m.id, o.id
+		LIMIT $5`
+	err := r.db.SelectContext(ctx, &milestones, query, customerID, until, afterAt, afterID, limit)
+	return milestones, err
+}
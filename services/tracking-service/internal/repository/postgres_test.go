@@ -43,6 +43,7 @@ func TestPostgresLocationRepository_Create(t *testing.T) {
 		Heading:        180,
 		AccuracyMeters: 10.0,
 		Source:         "GPS",
+		FusionStatus:   domain.FusionPrimary,
 		RecordedAt:     time.Now(),
 		ReceivedAt:     time.Now(),
 	}
@@ -52,6 +53,7 @@ func TestPostgresLocationRepository_Create(t *testing.T) {
 			record.ID, record.DriverID, record.TractorID, record.TripID,
 			record.Latitude, record.Longitude, record.SpeedMPH, record.Heading,
 			record.AccuracyMeters, record.Source, record.RecordedAt, record.ReceivedAt,
+			record.FusionStatus,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -161,6 +163,33 @@ func TestPostgresLocationRepository_GetLatest_NotFound(t *testing.T) {
 	}
 }
 
+func TestPostgresLocationRepository_GetLatestSince(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresLocationRepository(db)
+	since := time.Now().Add(-30 * time.Minute)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "driver_id", "latitude", "longitude", "recorded_at",
+	}).
+		AddRow(uuid.New(), uuid.New(), 33.7397, -118.2628, time.Now().Add(-5*time.Minute)).
+		AddRow(uuid.New(), uuid.New(), 33.9425, -118.4081, time.Now())
+
+	mock.ExpectQuery("SELECT DISTINCT ON \\(driver_id\\) \\* FROM location_records").
+		WithArgs(since).
+		WillReturnRows(rows)
+
+	records, err := repo.GetLatestSince(context.Background(), since)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("expected 2 records, got %d", len(records))
+	}
+}
+
 func TestPostgresLocationRepository_GetByTripID(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
@@ -481,6 +510,7 @@ func TestPostgresGeofenceRepository_Create(t *testing.T) {
 		CenterLongitude: -118.2628,
 		RadiusMeters:    500,
 		IsActive:        true,
+		Version:         1,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -489,7 +519,7 @@ func TestPostgresGeofenceRepository_Create(t *testing.T) {
 		WithArgs(
 			geofence.ID, geofence.LocationID, geofence.Name, geofence.Type,
 			geofence.CenterLatitude, geofence.CenterLongitude, geofence.RadiusMeters,
-			geofence.IsActive, geofence.CreatedAt, geofence.UpdatedAt,
+			geofence.IsActive, geofence.Version, geofence.CreatedAt, geofence.UpdatedAt,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -549,6 +579,31 @@ func TestPostgresGeofenceRepository_GetByID_NotFound(t *testing.T) {
 	}
 }
 
+func TestPostgresGeofenceRepository_GetByIDs(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresGeofenceRepository(db)
+	first, second := uuid.New(), uuid.New()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "name", "type", "is_active",
+	}).AddRow(first, "Port of LA", "circle", true)
+
+	mock.ExpectQuery("SELECT \\* FROM geofences WHERE id IN \\(\\$1, \\$2\\)").
+		WithArgs(first, second).
+		WillReturnRows(rows)
+
+	geofences, err := repo.GetByIDs(context.Background(), []uuid.UUID{first, second})
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(geofences) != 1 || geofences[0].ID != first {
+		t.Errorf("expected only the known geofence, got %+v", geofences)
+	}
+}
+
 func TestPostgresGeofenceRepository_GetByLocationID(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
@@ -639,12 +694,14 @@ func TestPostgresGeofenceRepository_Update(t *testing.T) {
 		CenterLongitude: -118.2700,
 		RadiusMeters:    600,
 		IsActive:        true,
+		Version:         2,
 	}
 
 	mock.ExpectExec("UPDATE geofences SET").
 		WithArgs(
 			geofence.ID, geofence.Name, geofence.Type, geofence.CenterLatitude,
-			geofence.CenterLongitude, geofence.RadiusMeters, geofence.IsActive, sqlmock.AnyArg(),
+			geofence.CenterLongitude, geofence.RadiusMeters, geofence.IsActive,
+			geofence.Version, sqlmock.AnyArg(),
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -655,6 +712,36 @@ func TestPostgresGeofenceRepository_Update(t *testing.T) {
 	}
 }
 
+func TestPostgresGeofenceRepository_GetVersion(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresGeofenceRepository(db)
+	geofenceID := uuid.New()
+
+	rows := sqlmock.NewRows([]string{
+		"geofence_id", "version", "type", "center_latitude", "center_longitude",
+		"radius_meters", "polygon", "created_at",
+	}).AddRow(geofenceID, 2, "polygon", 0.0, 0.0, 0.0,
+		[]byte(`[{"latitude":33.78,"longitude":-118.25},{"latitude":33.70,"longitude":-118.15}]`), time.Now())
+
+	mock.ExpectQuery("FROM geofence_versions").
+		WithArgs(geofenceID, 2).
+		WillReturnRows(rows)
+
+	version, err := repo.GetVersion(context.Background(), geofenceID, 2)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if version == nil {
+		t.Fatal("expected version, got nil")
+	}
+	if len(version.Polygon) != 2 {
+		t.Errorf("expected 2 polygon points, got %d", len(version.Polygon))
+	}
+}
+
 func TestPostgresGeofenceRepository_Delete(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
@@ -703,6 +790,145 @@ func TestPostgresGeofenceRepository_SetActive(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// PostgresCorridorRepository Tests
+// ============================================================================
+
+func TestPostgresCorridorRepository_Create(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCorridorRepository(db)
+
+	corridor := &domain.RouteCorridor{
+		ID:     uuid.New(),
+		TripID: uuid.New(),
+		Reason: domain.CorridorReasonHazmat,
+		Polyline: []domain.Coordinate{
+			{Latitude: 33.7397, Longitude: -118.2628},
+			{Latitude: 33.9425, Longitude: -118.2551},
+		},
+		BufferMeters:     1000,
+		MaxDeviationMins: 5,
+		IsActive:         true,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	mock.ExpectExec("INSERT INTO route_corridors").
+		WithArgs(
+			corridor.ID, corridor.TripID, corridor.DriverID, corridor.Reason, sqlmock.AnyArg(),
+			corridor.BufferMeters, corridor.MaxDeviationMins, corridor.IsActive,
+			corridor.CreatedAt, corridor.UpdatedAt,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), corridor)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresCorridorRepository_GetByTripID(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCorridorRepository(db)
+	tripID := uuid.New()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "trip_id", "reason", "polyline", "buffer_meters", "max_deviation_mins", "is_active",
+	}).AddRow(uuid.New(), tripID, "high_value",
+		[]byte(`[{"latitude":33.7397,"longitude":-118.2628},{"latitude":33.9425,"longitude":-118.2551}]`),
+		1000.0, 5, true)
+
+	mock.ExpectQuery("SELECT (.+) FROM route_corridors WHERE trip_id = \\$1").
+		WithArgs(tripID).
+		WillReturnRows(rows)
+
+	corridor, err := repo.GetByTripID(context.Background(), tripID)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if corridor == nil {
+		t.Fatal("expected corridor, got nil")
+	}
+	if len(corridor.Polyline) != 2 {
+		t.Errorf("expected 2 polyline points, got %d", len(corridor.Polyline))
+	}
+	if corridor.Polyline[1].Latitude != 33.9425 {
+		t.Errorf("expected second point latitude 33.9425, got %f", corridor.Polyline[1].Latitude)
+	}
+}
+
+func TestPostgresCorridorRepository_GetByTripID_NotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCorridorRepository(db)
+	tripID := uuid.New()
+
+	mock.ExpectQuery("SELECT (.+) FROM route_corridors WHERE trip_id = \\$1").
+		WithArgs(tripID).
+		WillReturnError(sql.ErrNoRows)
+
+	corridor, err := repo.GetByTripID(context.Background(), tripID)
+
+	if err != nil {
+		t.Errorf("expected no error for not found, got %v", err)
+	}
+	if corridor != nil {
+		t.Error("expected nil corridor for not found")
+	}
+}
+
+func TestPostgresCorridorRepository_UpdateState(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCorridorRepository(db)
+	offRouteSince := time.Now().Add(-6 * time.Minute)
+	alertedAt := time.Now()
+	corridor := &domain.RouteCorridor{
+		ID:            uuid.New(),
+		OffRouteSince: &offRouteSince,
+		AlertedAt:     &alertedAt,
+	}
+
+	mock.ExpectExec("UPDATE route_corridors SET").
+		WithArgs(corridor.ID, corridor.OffRouteSince, corridor.AlertedAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.UpdateState(context.Background(), corridor)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPostgresCorridorRepository_Deactivate(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCorridorRepository(db)
+	tripID := uuid.New()
+
+	mock.ExpectExec("UPDATE route_corridors SET is_active = false").
+		WithArgs(tripID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Deactivate(context.Background(), tripID)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
 // ============================================================================
 // Repository Constructor Tests
 // ============================================================================
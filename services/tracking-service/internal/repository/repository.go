@@ -9,12 +9,15 @@ import (
 	"github.com/draymaster/services/tracking-service/internal/domain"
 )
 
-// LocationRepository defines location data access methods
+// LocationRepository defines location data access methods. Every point is
+// stored; history, latest and trip reads return only points on the merged
+// track (FusionPrimary).
 type LocationRepository interface {
 	Create(ctx context.Context, record *domain.LocationRecord) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.LocationRecord, error)
 	GetHistory(ctx context.Context, driverID uuid.UUID, tripID *uuid.UUID, startTime, endTime time.Time, intervalSecs int) ([]domain.LocationRecord, error)
 	GetLatest(ctx context.Context, driverID uuid.UUID) (*domain.LocationRecord, error)
+	GetLatestSince(ctx context.Context, since time.Time) ([]domain.LocationRecord, error)
 	GetByTripID(ctx context.Context, tripID uuid.UUID) ([]domain.LocationRecord, error)
 	DeleteOlderThan(ctx context.Context, olderThan time.Time) (int64, error)
 }
@@ -34,10 +37,40 @@ type MilestoneRepository interface {
 type GeofenceRepository interface {
 	Create(ctx context.Context, geofence *domain.Geofence) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Geofence, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Geofence, error) // Unknown IDs are left out
 	GetByLocationID(ctx context.Context, locationID uuid.UUID) (*domain.Geofence, error)
 	GetAll(ctx context.Context) ([]*domain.Geofence, error)
 	GetActive(ctx context.Context) ([]*domain.Geofence, error)
 	Update(ctx context.Context, geofence *domain.Geofence) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	SetActive(ctx context.Context, id uuid.UUID, isActive bool) error
+	CreateVersion(ctx context.Context, version *domain.GeofenceVersion) error
+	GetVersion(ctx context.Context, id uuid.UUID, version int) (*domain.GeofenceVersion, error)
+	CreateEvent(ctx context.Context, event *domain.GeofenceEvent) error
+	// GetEvents returns enter and exit events in [from, to) in the order they
+	// occurred, at one location when locationID is set
+	GetEvents(ctx context.Context, from, to time.Time, locationID *uuid.UUID) ([]domain.GeofenceEvent, error)
+}
+
+// CorridorRepository defines route corridor data access methods
+type CorridorRepository interface {
+	Create(ctx context.Context, corridor *domain.RouteCorridor) error
+	GetByTripID(ctx context.Context, tripID uuid.UUID) (*domain.RouteCorridor, error)
+	GetActive(ctx context.Context) ([]*domain.RouteCorridor, error)
+	UpdateState(ctx context.Context, corridor *domain.RouteCorridor) error
+	Deactivate(ctx context.Context, tripID uuid.UUID) error
+}
+
+// DataExportRepository defines customer telemetry export data access methods.
+// The order reads return rows after the cursor (exclusive) and before until,
+// in cursor order.
+type DataExportRepository interface {
+	Save(ctx context.Context, export *domain.CustomerDataExport) error
+	GetByCustomerID(ctx context.Context, customerID uuid.UUID) (*domain.CustomerDataExport, error)
+	GetEnabled(ctx context.Context) ([]*domain.CustomerDataExport, error)
+	SetEnabled(ctx context.Context, customerID uuid.UUID, enabled bool) error
+	// SaveProgress stores the cursors, the count exported and the last error
+	SaveProgress(ctx context.Context, export *domain.CustomerDataExport) error
+	GetOrderLocations(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderLocation, error)
+	GetOrderMilestones(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderMilestone, error)
 }
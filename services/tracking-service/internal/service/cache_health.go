@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+const (
+	// cacheEpochKey is written once per Redis lifetime; if it disappears the
+	// instance was restarted or flushed and derived state must be rebuilt
+	cacheEpochKey = "tracking:cache:epoch"
+
+	circuitFailureThreshold = 5
+	circuitCooldown         = 30 * time.Second
+
+	// stateRebuildWindow bounds how far back we look for driver positions
+	// when warming geofence state after a Redis restart
+	stateRebuildWindow = 30 * time.Minute
+)
+
+// CacheMetrics tracks Redis cache effectiveness and degradation
+type CacheMetrics struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Errors        uint64 `json:"errors"`
+	DBFallbacks   uint64 `json:"db_fallbacks"`
+	StateRebuilds uint64 `json:"state_rebuilds"`
+	CircuitOpen   bool   `json:"circuit_open"`
+}
+
+// cacheCounters holds the live counters behind CacheMetrics
+type cacheCounters struct {
+	hits          uint64
+	misses        uint64
+	errors        uint64
+	dbFallbacks   uint64
+	stateRebuilds uint64
+}
+
+// redisCircuit is a simple consecutive-failure circuit breaker for Redis calls
+type redisCircuit struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow reports whether a Redis call should be attempted
+func (c *redisCircuit) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.openUntil.IsZero() || time.Now().After(c.openUntil)
+}
+
+// isOpen reports whether the breaker is currently rejecting calls
+func (c *redisCircuit) isOpen() bool {
+	return !c.allow()
+}
+
+// recordSuccess closes the breaker
+func (c *redisCircuit) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+	c.openUntil = time.Time{}
+}
+
+// recordFailure counts a failure and opens the breaker once the threshold is hit
+func (c *redisCircuit) recordFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if c.failures >= circuitFailureThreshold {
+		c.openUntil = time.Now().Add(circuitCooldown)
+	}
+}
+
+// CacheMetrics returns a snapshot of cache hit/miss and degradation counters
+func (s *TrackingService) CacheMetrics() CacheMetrics {
+	return CacheMetrics{
+		Hits:          atomic.LoadUint64(&s.cacheStats.hits),
+		Misses:        atomic.LoadUint64(&s.cacheStats.misses),
+		Errors:        atomic.LoadUint64(&s.cacheStats.errors),
+		DBFallbacks:   atomic.LoadUint64(&s.cacheStats.dbFallbacks),
+		StateRebuilds: atomic.LoadUint64(&s.cacheStats.stateRebuilds),
+		CircuitOpen:   s.circuit.isOpen(),
+	}
+}
+
+// StartCacheMonitor periodically checks Redis health and rebuilds derived
+// state (current locations and geofence membership) after a restart
+func (s *TrackingService) StartCacheMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.checkCacheEpoch(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkCacheEpoch(ctx)
+		}
+	}
+}
+
+// checkCacheEpoch detects a Redis restart via the epoch marker and triggers a rebuild
+func (s *TrackingService) checkCacheEpoch(ctx context.Context) {
+	exists, err := s.redis.Exists(ctx, cacheEpochKey).Result()
+	if err != nil {
+		s.circuit.recordFailure()
+		atomic.AddUint64(&s.cacheStats.errors, 1)
+		s.logger.Warnw("Redis health check failed", "error", err)
+		return
+	}
+	s.circuit.recordSuccess()
+
+	if exists > 0 {
+		return
+	}
+
+	s.logger.Warnw("Redis cache epoch missing, rebuilding tracking state")
+	if err := s.rebuildCacheState(ctx); err != nil {
+		s.logger.Errorw("Failed to rebuild tracking state", "error", err)
+		return
+	}
+
+	s.redis.Set(ctx, cacheEpochKey, time.Now().Unix(), 0)
+}
+
+// rebuildCacheState warms current locations and geofence membership from
+// recent DB points without emitting enter/exit events
+func (s *TrackingService) rebuildCacheState(ctx context.Context) error {
+	records, err := s.locationRepo.GetLatestSince(ctx, time.Now().Add(-stateRebuildWindow))
+	if err != nil {
+		return fmt.Errorf("failed to load recent locations: %w", err)
+	}
+
+	// Make sure membership is computed against the current geofence set
+	s.loadGeofenceCache(ctx)
+
+	s.cacheMu.RLock()
+	geofences := make([]*domain.Geofence, 0, len(s.geofenceCache))
+	for _, gf := range s.geofenceCache {
+		if gf.IsActive {
+			geofences = append(geofences, gf)
+		}
+	}
+	s.cacheMu.RUnlock()
+
+	for i := range records {
+		record := &records[i]
+
+		if err := s.updateCurrentLocation(ctx, record); err != nil {
+			return fmt.Errorf("failed to restore current location: %w", err)
+		}
+
+		states := s.geofenceStates(ctx, geofences, record.Latitude, record.Longitude)
+		if len(states) == 0 {
+			continue
+		}
+		stateKey := fmt.Sprintf("geofence:state:%s", record.DriverID.String())
+		if err := s.redis.HSet(ctx, stateKey, states).Err(); err != nil {
+			return fmt.Errorf("failed to restore geofence state: %w", err)
+		}
+	}
+
+	atomic.AddUint64(&s.cacheStats.stateRebuilds, 1)
+	s.logger.Infow("Tracking state rebuilt", "drivers", len(records), "geofences", len(geofences))
+
+	return nil
+}
+
+// geofenceStates computes inside/outside membership for a point
+func (s *TrackingService) geofenceStates(ctx context.Context, geofences []*domain.Geofence, lat, lon float64) map[string]interface{} {
+	states := make(map[string]interface{}, len(geofences))
+	for _, gf := range geofences {
+		isInside, _ := s.containsPoint(gf, lat, lon)
+		states[gf.ID.String()] = formatGeofenceState(isInside, gf.Version)
+	}
+	return states
+}
+
+// ErrNoLocation is returned when a driver has never reported a location
+var ErrNoLocation = errors.New("no location found for driver")
+
+// getCurrentLocationFromDB falls back to the latest persisted point
+func (s *TrackingService) getCurrentLocationFromDB(ctx context.Context, driverID uuid.UUID) (*domain.CurrentLocation, error) {
+	atomic.AddUint64(&s.cacheStats.dbFallbacks, 1)
+
+	record, err := s.locationRepo.GetLatest(ctx, driverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current location: %w", err)
+	}
+	if record == nil {
+		return nil, ErrNoLocation
+	}
+
+	// Repopulate the cache so subsequent reads are served from Redis
+	if !s.circuit.isOpen() {
+		if err := s.updateCurrentLocation(ctx, record); err != nil {
+			s.circuit.recordFailure()
+		}
+	}
+
+	location := currentLocationFromRecord(record)
+	return &location, nil
+}
+
+// parseCurrentLocation converts the Redis location hash into a CurrentLocation
+func parseCurrentLocation(driverID uuid.UUID, data map[string]string) *domain.CurrentLocation {
+	location := &domain.CurrentLocation{
+		DriverID: driverID,
+	}
+
+	location.Latitude, _ = strconv.ParseFloat(data["latitude"], 64)
+	location.Longitude, _ = strconv.ParseFloat(data["longitude"], 64)
+	location.SpeedMPH, _ = strconv.ParseFloat(data["speed"], 64)
+	location.Heading, _ = strconv.ParseFloat(data["heading"], 64)
+	location.Source = data["source"]
+
+	if ts, err := strconv.ParseInt(data["recorded_at"], 10, 64); err == nil {
+		location.LastUpdate = time.Unix(ts, 0)
+	}
+	if tripID, err := uuid.Parse(data["trip_id"]); err == nil {
+		location.TripID = &tripID
+	}
+
+	return location
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// CreateCorridorInput contains input for monitoring a trip against its planned route
+type CreateCorridorInput struct {
+	TripID           uuid.UUID
+	DriverID         *uuid.UUID
+	Reason           string              // hazmat, high_value
+	Stops            []domain.Coordinate // Stop locations in order, used when no routed polyline is supplied
+	Polyline         []domain.Coordinate // Routed path from the routing provider, optional
+	BufferMeters     float64             // 0 uses the configured default
+	MaxDeviationMins int                 // 0 uses the configured default
+}
+
+// CreateCorridor starts monitoring a trip's live GPS against its planned
+// route. The route is the routed polyline when one is supplied, otherwise
+// straight legs between the stops. Creating a corridor for a trip that
+// already has one replaces it.
+func (s *TrackingService) CreateCorridor(ctx context.Context, input CreateCorridorInput) (*domain.RouteCorridor, error) {
+	polyline := input.Polyline
+	if len(polyline) == 0 {
+		polyline = input.Stops
+	}
+	if len(polyline) < 2 {
+		return nil, fmt.Errorf("corridor needs at least 2 route points")
+	}
+	switch input.Reason {
+	case domain.CorridorReasonHazmat, domain.CorridorReasonHighValue:
+	default:
+		return nil, fmt.Errorf("invalid corridor reason %q", input.Reason)
+	}
+
+	rules := s.businessRules.Corridor
+	now := time.Now()
+	corridor := &domain.RouteCorridor{
+		ID:               uuid.New(),
+		TripID:           input.TripID,
+		DriverID:         input.DriverID,
+		Reason:           input.Reason,
+		Polyline:         polyline,
+		BufferMeters:     input.BufferMeters,
+		MaxDeviationMins: input.MaxDeviationMins,
+		IsActive:         true,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if corridor.BufferMeters <= 0 {
+		corridor.BufferMeters = rules.BufferMeters
+	}
+	if corridor.MaxDeviationMins <= 0 {
+		corridor.MaxDeviationMins = rules.MaxDeviationMins
+	}
+
+	if err := s.corridorRepo.Create(ctx, corridor); err != nil {
+		return nil, fmt.Errorf("failed to create corridor: %w", err)
+	}
+
+	s.corridorMu.Lock()
+	s.corridorCache[corridor.TripID] = corridor
+	s.corridorMu.Unlock()
+
+	s.logger.Infow("Corridor monitoring started",
+		"trip_id", corridor.TripID,
+		"reason", corridor.Reason,
+		"points", len(corridor.Polyline),
+		"buffer_meters", corridor.BufferMeters,
+	)
+	return corridor, nil
+}
+
+// CloseCorridor stops monitoring a trip, typically once it completes
+func (s *TrackingService) CloseCorridor(ctx context.Context, tripID uuid.UUID) error {
+	if err := s.corridorRepo.Deactivate(ctx, tripID); err != nil {
+		return fmt.Errorf("failed to close corridor: %w", err)
+	}
+	s.corridorMu.Lock()
+	delete(s.corridorCache, tripID)
+	s.corridorMu.Unlock()
+	return nil
+}
+
+// HandleTripCompleted is a kafka.Handler that closes the corridor of a completed trip
+func (s *TrackingService) HandleTripCompleted(ctx context.Context, event *kafka.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	var payload struct {
+		TripID string `json:"trip_id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("unmarshal trip completed: %w", err)
+	}
+	tripID, err := uuid.Parse(payload.TripID)
+	if err != nil {
+		return nil
+	}
+
+	s.corridorMu.Lock()
+	_, monitored := s.corridorCache[tripID]
+	s.corridorMu.Unlock()
+	if !monitored {
+		return nil
+	}
+	return s.CloseCorridor(ctx, tripID)
+}
+
+// corridorTransition is a change in a corridor's deviation state caused by one position
+type corridorTransition int
+
+const (
+	corridorUnchanged corridorTransition = iota
+	corridorLeft                         // First position outside the buffer
+	corridorAlert                        // Outside the buffer for longer than allowed
+	corridorReturned                     // Back inside the buffer after an alert
+	corridorCleared                      // Back inside the buffer before an alert was due
+)
+
+// checkCorridor evaluates a position against the trip's corridor and alerts
+// dispatch once the driver has been outside the buffer for MaxDeviationMins
+func (s *TrackingService) checkCorridor(ctx context.Context, record *domain.LocationRecord) {
+	if record.TripID == nil {
+		return
+	}
+
+	s.corridorMu.Lock()
+	corridor, ok := s.corridorCache[*record.TripID]
+	if !ok {
+		s.corridorMu.Unlock()
+		return
+	}
+	offsetMeters := distanceToPolylineMeters(record.Latitude, record.Longitude, corridor.Polyline)
+	transition, deviatedSince := advanceCorridor(corridor, offsetMeters, record.RecordedAt)
+	snapshot := *corridor
+	s.corridorMu.Unlock()
+
+	if transition == corridorUnchanged {
+		return
+	}
+	if err := s.corridorRepo.UpdateState(ctx, &snapshot); err != nil {
+		s.logger.Warnw("Failed to save corridor state", "trip_id", snapshot.TripID, "error", err)
+	}
+
+	switch transition {
+	case corridorAlert:
+		s.publishCorridorEvent(ctx, kafka.Topics.CorridorDeviation, &snapshot, record, offsetMeters, deviatedSince)
+		s.logger.Warnw("Driver outside route corridor",
+			"trip_id", snapshot.TripID,
+			"driver_id", record.DriverID,
+			"offset_meters", math.Round(offsetMeters),
+			"off_route_since", deviatedSince,
+		)
+	case corridorReturned:
+		s.publishCorridorEvent(ctx, kafka.Topics.CorridorReturned, &snapshot, record, offsetMeters, deviatedSince)
+	}
+}
+
+// advanceCorridor applies one position to the corridor's deviation state. It
+// returns the transition and, for alerts and returns, when the deviation began.
+// A return is only reported for deviations dispatch was alerted about.
+func advanceCorridor(corridor *domain.RouteCorridor, offsetMeters float64, at time.Time) (corridorTransition, *time.Time) {
+	if offsetMeters <= corridor.BufferMeters {
+		if corridor.OffRouteSince == nil {
+			return corridorUnchanged, nil
+		}
+		since, alerted := corridor.OffRouteSince, corridor.AlertedAt != nil
+		corridor.OffRouteSince = nil
+		corridor.AlertedAt = nil
+		if alerted {
+			return corridorReturned, since
+		}
+		return corridorCleared, nil
+	}
+
+	if corridor.OffRouteSince == nil {
+		corridor.OffRouteSince = &at
+		return corridorLeft, nil
+	}
+	if corridor.AlertedAt == nil && at.Sub(*corridor.OffRouteSince) >= time.Duration(corridor.MaxDeviationMins)*time.Minute {
+		corridor.AlertedAt = &at
+		return corridorAlert, corridor.OffRouteSince
+	}
+	return corridorUnchanged, nil
+}
+
+func (s *TrackingService) publishCorridorEvent(ctx context.Context, topic string, corridor *domain.RouteCorridor, record *domain.LocationRecord, offsetMeters float64, since *time.Time) {
+	data := map[string]interface{}{
+		"trip_id":         corridor.TripID.String(),
+		"driver_id":       record.DriverID.String(),
+		"reason":          corridor.Reason,
+		"latitude":        record.Latitude,
+		"longitude":       record.Longitude,
+		"offset_meters":   math.Round(offsetMeters),
+		"buffer_meters":   corridor.BufferMeters,
+		"recorded_at":     record.RecordedAt,
+		"off_route_since": since,
+	}
+	if since != nil {
+		data["off_route_mins"] = int(record.RecordedAt.Sub(*since).Minutes())
+	}
+	event := kafka.NewEvent(topic, "tracking-service", data)
+	_ = s.eventProducer.Publish(ctx, topic, event)
+}
+
+func (s *TrackingService) loadCorridorCache(ctx context.Context) {
+	corridors, err := s.corridorRepo.GetActive(ctx)
+	if err != nil {
+		s.logger.Errorw("Failed to load corridor cache", "error", err)
+		return
+	}
+
+	s.corridorMu.Lock()
+	for _, c := range corridors {
+		s.corridorCache[c.TripID] = c
+	}
+	s.corridorMu.Unlock()
+
+	s.logger.Infow("Corridor cache loaded", "count", len(corridors))
+}
+
+// distanceToPolylineMeters returns the shortest distance from a point to any
+// segment of the polyline. Segments are projected onto a plane centred on the
+// point, which is accurate to well under a percent at corridor distances.
+func distanceToPolylineMeters(lat, lon float64, polyline []domain.Coordinate) float64 {
+	if len(polyline) == 0 {
+		return math.Inf(1)
+	}
+
+	const metersPerDegree = 111320.0
+	cosLat := math.Cos(lat * math.Pi / 180)
+	project := func(c domain.Coordinate) (float64, float64) {
+		return (c.Longitude - lon) * metersPerDegree * cosLat, (c.Latitude - lat) * metersPerDegree
+	}
+
+	best := math.Inf(1)
+	ax, ay := project(polyline[0])
+	if len(polyline) == 1 {
+		return math.Hypot(ax, ay)
+	}
+	for _, next := range polyline[1:] {
+		bx, by := project(next)
+		// Closest point to the origin on segment a-b
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+		}
+		if d := math.Hypot(ax+t*dx, ay+t*dy); d < best {
+			best = d
+		}
+		ax, ay = bx, by
+	}
+	return best
+}
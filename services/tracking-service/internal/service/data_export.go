@@ -0,0 +1,388 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/services/tracking-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+// ErrInvalidDataExport is returned when export settings are incomplete or unsafe
+var ErrInvalidDataExport = errors.New("invalid data export")
+
+// ErrDataExportNotFound is returned when a customer has no export configured
+var ErrDataExportNotFound = errors.New("data export not found")
+
+// exportPublisher delivers exported records to a customer topic
+type exportPublisher interface {
+	PublishSync(ctx context.Context, topic string, event *kafka.Event) error
+}
+
+// DataExportService streams anonymized, order-scoped positions and
+// milestones to customers' S3 buckets or Kafka topics
+type DataExportService struct {
+	exportRepo    repository.DataExportRepository
+	publisher     exportPublisher
+	logger        *logger.Logger
+	businessRules *config.BusinessRules
+
+	// stores opens the customer's bucket
+	stores func(export *domain.CustomerDataExport) (storage.Store, error)
+}
+
+// NewDataExportService creates a data export service. Customer buckets are
+// written with the platform's S3 credentials, which customers grant access
+// to in their bucket policy.
+func NewDataExportService(
+	exportRepo repository.DataExportRepository,
+	storageCfg config.StorageConfig,
+	eventProducer *kafka.Producer,
+	log *logger.Logger,
+) *DataExportService {
+	return &DataExportService{
+		exportRepo:    exportRepo,
+		publisher:     eventProducer,
+		logger:        log,
+		businessRules: config.DefaultBusinessRules(),
+		stores: func(export *domain.CustomerDataExport) (storage.Store, error) {
+			cfg := storageCfg
+			cfg.Driver = "s3"
+			cfg.Bucket = export.Bucket
+			cfg.Region = export.Region
+			cfg.Encryption = string(storage.EncryptionManaged)
+			cfg.KMSKeyID = ""
+			return storage.NewS3Store(cfg)
+		},
+	}
+}
+
+// ConfigureDataExportInput contains a customer's export settings
+type ConfigureDataExportInput struct {
+	CustomerID   uuid.UUID
+	Destination  domain.DataExportDestination
+	Bucket       string // S3 only
+	Region       string // S3 only
+	Prefix       string // S3 only, optional
+	Topic        string // Kafka only
+	Enabled      bool
+	ConfiguredBy string
+}
+
+// ConfigureDataExport creates or replaces a customer's export settings.
+// Changing the destination carries on from where the export left off.
+func (s *DataExportService) ConfigureDataExport(ctx context.Context, input ConfigureDataExportInput) (*domain.CustomerDataExport, error) {
+	if input.CustomerID == uuid.Nil {
+		return nil, fmt.Errorf("%w: customer_id is required", ErrInvalidDataExport)
+	}
+	switch input.Destination {
+	case domain.DataExportS3:
+		if input.Bucket == "" || input.Region == "" {
+			return nil, fmt.Errorf("%w: bucket and region are required for S3", ErrInvalidDataExport)
+		}
+		input.Topic = ""
+	case domain.DataExportKafka:
+		prefix := s.businessRules.DataExport.TopicPrefix
+		if !strings.HasPrefix(input.Topic, prefix) || len(input.Topic) == len(prefix) {
+			return nil, fmt.Errorf("%w: topic must start with %q", ErrInvalidDataExport, prefix)
+		}
+		input.Bucket, input.Region, input.Prefix = "", "", ""
+	default:
+		return nil, fmt.Errorf("%w: destination must be S3 or KAFKA", ErrInvalidDataExport)
+	}
+
+	salt, err := newExportSalt()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	export := &domain.CustomerDataExport{
+		ID:          uuid.New(),
+		CustomerID:  input.CustomerID,
+		Destination: input.Destination,
+		Bucket:      input.Bucket,
+		Region:      input.Region,
+		Prefix:      strings.Trim(input.Prefix, "/"),
+		Topic:       input.Topic,
+		Enabled:     input.Enabled,
+		Salt:        salt, // Kept from the existing export on update
+		CreatedBy:   input.ConfiguredBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.exportRepo.Save(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to save data export: %w", err)
+	}
+
+	s.logger.Infow("Customer data export configured",
+		"customer_id", export.CustomerID,
+		"destination", export.Destination,
+		"enabled", export.Enabled,
+	)
+	return export, nil
+}
+
+// GetDataExport returns a customer's export settings and progress
+func (s *DataExportService) GetDataExport(ctx context.Context, customerID uuid.UUID) (*domain.CustomerDataExport, error) {
+	export, err := s.exportRepo.GetByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	if export == nil {
+		return nil, ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// SetDataExportEnabled pauses or resumes a customer's export. A resumed
+// export catches up from where it was paused.
+func (s *DataExportService) SetDataExportEnabled(ctx context.Context, customerID uuid.UUID, enabled bool) error {
+	err := s.exportRepo.SetEnabled(ctx, customerID, enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDataExportNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update data export: %w", err)
+	}
+
+	s.logger.Infow("Customer data export toggled", "customer_id", customerID, "enabled", enabled)
+	return nil
+}
+
+// RunDataExports exports new records for every enabled customer. A failing
+// customer is recorded on its export and does not hold up the others.
+func (s *DataExportService) RunDataExports(ctx context.Context, now time.Time) (int, error) {
+	exports, err := s.exportRepo.GetEnabled(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list data exports: %w", err)
+	}
+
+	total := 0
+	for _, export := range exports {
+		n, err := s.ExportCustomer(ctx, export, now)
+		total += n
+		if err != nil {
+			s.logger.Errorw("Customer data export failed",
+				"customer_id", export.CustomerID,
+				"destination", export.Destination,
+				"error", err,
+			)
+		}
+	}
+	return total, nil
+}
+
+// ExportCustomer sends a customer's positions and milestones recorded since
+// the last run, a batch at a time. Progress is saved after every batch, so
+// a failed batch is retried on the next run; consumers de-duplicate on
+// record_id.
+func (s *DataExportService) ExportCustomer(ctx context.Context, export *domain.CustomerDataExport, now time.Time) (int, error) {
+	rules := &s.businessRules.DataExport
+	until := now.Add(-time.Duration(rules.SettleSeconds) * time.Second)
+
+	var store storage.Store
+	if export.Destination == domain.DataExportS3 {
+		var err error
+		if store, err = s.stores(export); err != nil {
+			return 0, s.saveProgress(ctx, export, now, 0, fmt.Errorf("open bucket: %w", err))
+		}
+	}
+
+	exported := 0
+	for batch := 0; batch < rules.MaxBatchesPerRun; batch++ {
+		rows, err := s.exportRepo.GetOrderLocations(ctx, export.CustomerID, export.LocationCursorAt, export.LocationCursorID, until, rules.BatchSize)
+		if err != nil {
+			return exported, s.saveProgress(ctx, export, now, 0, fmt.Errorf("read locations: %w", err))
+		}
+		full := len(rows) == rules.BatchSize
+		rows = wholeLocations(rows, full)
+		if len(rows) == 0 {
+			break
+		}
+
+		records := make([]domain.DataExportRecord, len(rows))
+		for i := range rows {
+			records[i] = export.LocationExportRecord(&rows[i])
+		}
+		if err := s.deliver(ctx, export, store, domain.DataExportLocation, records, now); err != nil {
+			return exported, s.saveProgress(ctx, export, now, 0, err)
+		}
+		last := rows[len(rows)-1]
+		export.LocationCursorAt, export.LocationCursorID = &last.ReceivedAt, &last.ID
+		exported += len(records)
+		if err := s.saveProgress(ctx, export, now, len(records), nil); err != nil {
+			return exported, err
+		}
+		if !full {
+			break
+		}
+	}
+
+	for batch := 0; batch < rules.MaxBatchesPerRun; batch++ {
+		rows, err := s.exportRepo.GetOrderMilestones(ctx, export.CustomerID, export.MilestoneCursorAt, export.MilestoneCursorID, until, rules.BatchSize)
+		if err != nil {
+			return exported, s.saveProgress(ctx, export, now, 0, fmt.Errorf("read milestones: %w", err))
+		}
+		full := len(rows) == rules.BatchSize
+		rows = wholeMilestones(rows, full)
+		if len(rows) == 0 {
+			break
+		}
+
+		records := make([]domain.DataExportRecord, len(rows))
+		for i := range rows {
+			records[i] = export.MilestoneExportRecord(&rows[i])
+		}
+		if err := s.deliver(ctx, export, store, domain.DataExportMilestone, records, now); err != nil {
+			return exported, s.saveProgress(ctx, export, now, 0, err)
+		}
+		last := rows[len(rows)-1]
+		export.MilestoneCursorAt, export.MilestoneCursorID = &last.CreatedAt, &last.ID
+		exported += len(records)
+		if err := s.saveProgress(ctx, export, now, len(records), nil); err != nil {
+			return exported, err
+		}
+		if !full {
+			break
+		}
+	}
+
+	if exported > 0 {
+		s.logger.Infow("Customer data exported",
+			"customer_id", export.CustomerID,
+			"destination", export.Destination,
+			"records", exported,
+		)
+	}
+	return exported, nil
+}
+
+// Start exports new records for enabled customers every interval until ctx
+// is cancelled
+func (s *DataExportService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.RunDataExports(ctx, now); err != nil {
+				s.logger.Errorw("Data export run failed", "error", err)
+			}
+		}
+	}
+}
+
+// deliver writes a batch as one NDJSON object partitioned by export date and
+// hour, or publishes each record to the customer's topic keyed by order
+func (s *DataExportService) deliver(ctx context.Context, export *domain.CustomerDataExport, store storage.Store, recordType domain.DataExportRecordType, records []domain.DataExportRecord, now time.Time) error {
+	if export.Destination == domain.DataExportKafka {
+		for _, record := range records {
+			event := kafka.NewEvent("telemetry."+string(recordType), "tracking-service", record).
+				WithCorrelationID(record.OrderID.String())
+			if err := s.publisher.PublishSync(ctx, export.Topic, event); err != nil {
+				return fmt.Errorf("publish to %s: %w", export.Topic, err)
+			}
+		}
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return fmt.Errorf("encode %s record: %w", recordType, err)
+		}
+	}
+	utc := now.UTC()
+	key := storage.Key(export.Prefix, string(recordType)+"s",
+		"dt="+utc.Format("2006-01-02"), "hour="+utc.Format("15"),
+		fmt.Sprintf("%s-%s.ndjson", utc.Format("20060102T150405Z"), uuid.New()))
+	_, err := store.Put(ctx, key, &body, storage.PutOptions{
+		ContentType: "application/x-ndjson",
+		Size:        int64(body.Len()),
+		Metadata:    map[string]string{"schema": domain.DataExportSchemaVersion, "records": fmt.Sprint(len(records))},
+	})
+	if err != nil {
+		return fmt.Errorf("write s3://%s/%s: %w", export.Bucket, key, err)
+	}
+	return nil
+}
+
+// saveProgress records the cursors and outcome of a batch, returning cause
+// when the batch failed
+func (s *DataExportService) saveProgress(ctx context.Context, export *domain.CustomerDataExport, now time.Time, records int, cause error) error {
+	export.LastError = ""
+	if cause != nil {
+		export.LastError = cause.Error()
+	} else {
+		export.LastExportedAt = &now
+		export.RecordsExported += int64(records)
+	}
+	if err := s.exportRepo.SaveProgress(ctx, export); err != nil {
+		if cause != nil {
+			return cause
+		}
+		return fmt.Errorf("failed to save export progress: %w", err)
+	}
+	return cause
+}
+
+// wholeLocations drops the trailing point of a full batch, which may have
+// been cut off part way through its orders; the next batch reads it again
+func wholeLocations(rows []domain.OrderLocation, full bool) []domain.OrderLocation {
+	if !full {
+		return rows
+	}
+	last := rows[len(rows)-1].ID
+	n := len(rows)
+	for n > 0 && rows[n-1].ID == last {
+		n--
+	}
+	if n == 0 {
+		return rows
+	}
+	return rows[:n]
+}
+
+// wholeMilestones drops the trailing milestone of a full batch, as
+// wholeLocations does
+func wholeMilestones(rows []domain.OrderMilestone, full bool) []domain.OrderMilestone {
+	if !full {
+		return rows
+	}
+	last := rows[len(rows)-1].ID
+	n := len(rows)
+	for n > 0 && rows[n-1].ID == last {
+		n--
+	}
+	if n == 0 {
+		return rows
+	}
+	return rows[:n]
+}
+
+// newExportSalt returns the secret an export's pseudonyms are keyed on
+func newExportSalt() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate export salt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
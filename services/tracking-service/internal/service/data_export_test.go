@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+	"github.com/draymaster/shared/pkg/storage"
+)
+
+type fakeExportRepo struct {
+	locations  []domain.OrderLocation
+	milestones []domain.OrderMilestone
+	saved      []domain.CustomerDataExport
+}
+
+func (r *fakeExportRepo) Save(ctx context.Context, export *domain.CustomerDataExport) error {
+	r.saved = append(r.saved, *export)
+	return nil
+}
+
+func (r *fakeExportRepo) GetByCustomerID(ctx context.Context, customerID uuid.UUID) (*domain.CustomerDataExport, error) {
+	return nil, nil
+}
+
+func (r *fakeExportRepo) GetEnabled(ctx context.Context) ([]*domain.CustomerDataExport, error) {
+	return nil, nil
+}
+
+func (r *fakeExportRepo) SetEnabled(ctx context.Context, customerID uuid.UUID, enabled bool) error {
+	return nil
+}
+
+func (r *fakeExportRepo) SaveProgress(ctx context.Context, export *domain.CustomerDataExport) error {
+	r.saved = append(r.saved, *export)
+	return nil
+}
+
+// GetOrderLocations pages through locations by position, standing in for
+// the (received_at, id) cursor
+func (r *fakeExportRepo) GetOrderLocations(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderLocation, error) {
+	start := 0
+	if afterID != nil {
+		for i := range r.locations {
+			if r.locations[i].ID == *afterID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(r.locations) {
+		end = len(r.locations)
+	}
+	return r.locations[start:end], nil
+}
+
+func (r *fakeExportRepo) GetOrderMilestones(ctx context.Context, customerID uuid.UUID, afterAt *time.Time, afterID *uuid.UUID, until time.Time, limit int) ([]domain.OrderMilestone, error) {
+	if afterID != nil {
+		return nil, nil
+	}
+	return r.milestones, nil
+}
+
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) (*storage.Object, error) {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	s.objects[key] = b
+	return &storage.Object{Key: key, Size: int64(len(b))}, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (io.ReadCloser, *storage.Object, error) {
+	return nil, nil, storage.ErrNotFound
+}
+
+func (s *memoryStore) Stat(ctx context.Context, key string) (*storage.Object, error) {
+	return nil, storage.ErrNotFound
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error { return nil }
+
+func (s *memoryStore) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	return nil, nil
+}
+
+func newTestExportService(repo *fakeExportRepo, store storage.Store) *DataExportService {
+	rules := config.DefaultBusinessRules()
+	rules.DataExport.BatchSize = 3
+	return &DataExportService{
+		exportRepo:    repo,
+		logger:        logger.Default(),
+		businessRules: rules,
+		stores: func(export *domain.CustomerDataExport) (storage.Store, error) {
+			return store, nil
+		},
+	}
+}
+
+func TestExportCustomerWritesAnonymizedBatches(t *testing.T) {
+	trip := uuid.New()
+	orderA, orderB := uuid.New(), uuid.New()
+	base := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	point := func(id uuid.UUID, order uuid.UUID, mins int) domain.OrderLocation {
+		return domain.OrderLocation{
+			ID: id, TripID: trip, OrderID: order, OrderNumber: "ORD-1",
+			Latitude: 33.754213, Longitude: -118.216349, SpeedMPH: 38.4, Heading: 270.6,
+			RecordedAt: base.Add(time.Duration(mins) * time.Minute),
+			ReceivedAt: base.Add(time.Duration(mins) * time.Minute),
+		}
+	}
+	p1, p2, p3 := uuid.New(), uuid.New(), uuid.New()
+	// The trip carries two of the customer's orders, so each point is read
+	// once per order; the first batch of 3 cuts p2 off after one order
+	repo := &fakeExportRepo{
+		locations: []domain.OrderLocation{
+			point(p1, orderA, 0), point(p1, orderB, 0),
+			point(p2, orderA, 1), point(p2, orderB, 1),
+			point(p3, orderA, 2),
+		},
+		milestones: []domain.OrderMilestone{{
+			ID: uuid.New(), TripID: trip, OrderID: orderA, OrderNumber: "ORD-1",
+			Type: domain.MilestoneGateOut, OccurredAt: base, CreatedAt: base,
+		}},
+	}
+	store := &memoryStore{objects: make(map[string][]byte)}
+	svc := newTestExportService(repo, store)
+
+	export := &domain.CustomerDataExport{
+		ID: uuid.New(), CustomerID: uuid.New(), Destination: domain.DataExportS3,
+		Bucket: "bco-lake", Region: "us-west-2", Prefix: "draymaster", Enabled: true, Salt: "s3cret",
+	}
+	n, err := svc.ExportCustomer(context.Background(), export, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ExportCustomer() error = %v", err)
+	}
+	if n != 6 {
+		t.Fatalf("exported %d records, want 6", n)
+	}
+	if export.LocationCursorID == nil || *export.LocationCursorID != p3 {
+		t.Errorf("location cursor = %v, want %v", export.LocationCursorID, p3)
+	}
+	if export.RecordsExported != 6 || export.LastError != "" {
+		t.Errorf("progress = %d records, error %q", export.RecordsExported, export.LastError)
+	}
+
+	seen := make(map[string]bool)
+	var locations, milestones int
+	for key, body := range store.objects {
+		if !strings.HasPrefix(key, "draymaster/") || !strings.Contains(key, "/dt=2026-10-16/hour=15/") {
+			t.Errorf("unexpected object key %s", key)
+		}
+		if bytes.Contains(body, []byte(trip.String())) || bytes.Contains(body, []byte("driver")) {
+			t.Errorf("object %s leaks trip or driver identity: %s", key, body)
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		for dec.More() {
+			var record domain.DataExportRecord
+			if err := dec.Decode(&record); err != nil {
+				t.Fatalf("decode %s: %v", key, err)
+			}
+			if seen[record.RecordID] {
+				t.Errorf("record %s exported twice", record.RecordID)
+			}
+			seen[record.RecordID] = true
+			if record.MoveRef != export.Pseudonym(trip) {
+				t.Errorf("move_ref = %s, want the trip pseudonym", record.MoveRef)
+			}
+			switch record.Type {
+			case domain.DataExportLocation:
+				locations++
+				if record.Latitude != 33.7542 || *record.SpeedMPH != 38 {
+					t.Errorf("location not rounded: %+v", record)
+				}
+			case domain.DataExportMilestone:
+				milestones++
+			}
+		}
+	}
+	if locations != 5 || milestones != 1 {
+		t.Errorf("exported %d locations and %d milestones, want 5 and 1", locations, milestones)
+	}
+}
+
+func TestPseudonymIsScopedToExport(t *testing.T) {
+	id := uuid.New()
+	a := &domain.CustomerDataExport{Salt: "a"}
+	b := &domain.CustomerDataExport{Salt: "b"}
+	if a.Pseudonym(id) != a.Pseudonym(id) {
+		t.Error("pseudonym is not stable")
+	}
+	if a.Pseudonym(id) == b.Pseudonym(id) {
+		t.Error("pseudonyms match across exports")
+	}
+}
+
+func TestConfigureDataExportValidatesDestination(t *testing.T) {
+	repo := &fakeExportRepo{}
+	svc := newTestExportService(repo, nil)
+	customer := uuid.New()
+
+	tests := []struct {
+		name  string
+		input ConfigureDataExportInput
+		valid bool
+	}{
+		{"s3", ConfigureDataExportInput{CustomerID: customer, Destination: domain.DataExportS3, Bucket: "lake", Region: "us-west-2"}, true},
+		{"s3 without bucket", ConfigureDataExportInput{CustomerID: customer, Destination: domain.DataExportS3, Region: "us-west-2"}, false},
+		{"customer topic", ConfigureDataExportInput{CustomerID: customer, Destination: domain.DataExportKafka, Topic: "customer-export.acme"}, true},
+		{"internal topic", ConfigureDataExportInput{CustomerID: customer, Destination: domain.DataExportKafka, Topic: "tracking.location.updated"}, false},
+		{"bare prefix", ConfigureDataExportInput{CustomerID: customer, Destination: domain.DataExportKafka, Topic: "customer-export."}, false},
+		{"unknown", ConfigureDataExportInput{CustomerID: customer, Destination: "SFTP"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := svc.ConfigureDataExport(context.Background(), tt.input)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("ConfigureDataExport() error = %v", err)
+				}
+				if export.Salt == "" {
+					t.Error("export has no salt")
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidDataExport) {
+				t.Errorf("ConfigureDataExport() error = %v, want ErrInvalidDataExport", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// eldLocationEvent is a vehicle position the eld-integration service received
+// from a provider and matched to a tractor and the driver at its wheel
+type eldLocationEvent struct {
+	DriverID   string    `json:"driver_id"`
+	TractorID  string    `json:"tractor_id"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	SpeedMPH   float64   `json:"speed_mph"`
+	Heading    float64   `json:"heading"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// HandleELDLocation records a vehicle position reported by an ELD provider.
+// Positions for a tractor nobody is logged on to are dropped; source fusion
+// weighs the rest against the driver's phone.
+func (s *TrackingService) HandleELDLocation(ctx context.Context, event *kafka.Event) error {
+	var payload eldLocationEvent
+	if err := event.DecodeData(&payload); err != nil {
+		return kafka.Permanent(fmt.Errorf("decode ELD location: %w", err))
+	}
+	driverID, err := uuid.Parse(payload.DriverID)
+	if err != nil {
+		return nil
+	}
+	if payload.Latitude == 0 && payload.Longitude == 0 {
+		return nil
+	}
+
+	input := RecordLocationInput{
+		DriverID:   driverID,
+		Latitude:   payload.Latitude,
+		Longitude:  payload.Longitude,
+		SpeedMPH:   payload.SpeedMPH,
+		Heading:    payload.Heading,
+		Source:     domain.SourceELD,
+		RecordedAt: payload.RecordedAt,
+	}
+	if tractorID, err := uuid.Parse(payload.TractorID); err == nil {
+		input.TractorID = &tractorID
+	}
+	if input.RecordedAt.IsZero() {
+		input.RecordedAt = event.Time
+	}
+
+	_, err = s.RecordLocation(ctx, input)
+	return err
+}
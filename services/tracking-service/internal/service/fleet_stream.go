@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+const (
+	// fleetChannel relays current locations between tracking-service
+	// instances, so a subscriber sees drivers reporting to any of them
+	fleetChannel = "tracking:fleet:locations"
+
+	// fleetSnapshotWindow bounds which drivers are in a whole-fleet snapshot
+	fleetSnapshotWindow = 30 * time.Minute
+)
+
+// FleetFilter selects the drivers a fleet subscription receives. Empty
+// filters match the whole fleet; a location matches when its driver or its
+// trip is listed.
+type FleetFilter struct {
+	DriverIDs []uuid.UUID
+	TripIDs   []uuid.UUID
+}
+
+// FleetSubscription receives current locations as they are recorded.
+// Updates for a driver that arrive before the subscriber reads them are
+// coalesced to the latest, so a slow subscriber never holds up ingestion.
+type FleetSubscription struct {
+	drivers map[uuid.UUID]bool
+	trips   map[uuid.UUID]bool
+	hub     *fleetHub
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]domain.CurrentLocation
+	order   []uuid.UUID
+	notify  chan struct{}
+}
+
+// matches reports whether the subscription wants a location
+func (f *FleetSubscription) matches(location *domain.CurrentLocation) bool {
+	if len(f.drivers) == 0 && len(f.trips) == 0 {
+		return true
+	}
+	if f.drivers[location.DriverID] {
+		return true
+	}
+	return location.TripID != nil && f.trips[*location.TripID]
+}
+
+// offer queues a location, replacing any unread update for the driver
+func (f *FleetSubscription) offer(location domain.CurrentLocation) {
+	f.mu.Lock()
+	if current, ok := f.pending[location.DriverID]; ok && location.LastUpdate.Before(current.LastUpdate) {
+		f.mu.Unlock()
+		return
+	}
+	if _, ok := f.pending[location.DriverID]; !ok {
+		f.order = append(f.order, location.DriverID)
+	}
+	f.pending[location.DriverID] = location
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next waits for updates and returns them, oldest driver first. It returns
+// ctx's error once ctx is done.
+func (f *FleetSubscription) Next(ctx context.Context) ([]domain.CurrentLocation, error) {
+	for {
+		f.mu.Lock()
+		if len(f.order) > 0 {
+			updates := make([]domain.CurrentLocation, len(f.order))
+			for i, driverID := range f.order {
+				updates[i] = f.pending[driverID]
+			}
+			f.pending = make(map[uuid.UUID]domain.CurrentLocation)
+			f.order = nil
+			f.mu.Unlock()
+			return updates, nil
+		}
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.notify:
+		}
+	}
+}
+
+// Close stops the subscription
+func (f *FleetSubscription) Close() {
+	f.hub.remove(f)
+}
+
+// fleetHub fans current locations out to this instance's subscribers
+type fleetHub struct {
+	mu          sync.RWMutex
+	subscribers map[*FleetSubscription]struct{}
+}
+
+func newFleetHub() *fleetHub {
+	return &fleetHub{subscribers: make(map[*FleetSubscription]struct{})}
+}
+
+// newFleetSubscription creates a subscription for filter, not yet
+// registered with a hub
+func newFleetSubscription(filter FleetFilter, hub *fleetHub) *FleetSubscription {
+	sub := &FleetSubscription{
+		drivers: make(map[uuid.UUID]bool, len(filter.DriverIDs)),
+		trips:   make(map[uuid.UUID]bool, len(filter.TripIDs)),
+		hub:     hub,
+		pending: make(map[uuid.UUID]domain.CurrentLocation),
+		notify:  make(chan struct{}, 1),
+	}
+	for _, id := range filter.DriverIDs {
+		sub.drivers[id] = true
+	}
+	for _, id := range filter.TripIDs {
+		sub.trips[id] = true
+	}
+	return sub
+}
+
+func (h *fleetHub) add(filter FleetFilter) *FleetSubscription {
+	sub := newFleetSubscription(filter, h)
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *fleetHub) remove(sub *FleetSubscription) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+func (h *fleetHub) broadcast(location domain.CurrentLocation) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if sub.matches(&location) {
+			sub.offer(location)
+		}
+	}
+}
+
+func (h *fleetHub) size() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// fleetMessage is a current location relayed between instances
+type fleetMessage struct {
+	Origin   string                 `json:"origin"`
+	Location domain.CurrentLocation `json:"location"`
+}
+
+// SubscribeFleetLocations subscribes to current locations matching filter.
+// Callers must Close the subscription when done.
+func (s *TrackingService) SubscribeFleetLocations(filter FleetFilter) *FleetSubscription {
+	return s.fleet.add(filter)
+}
+
+// FleetSnapshot returns the current locations matching filter, for a new
+// subscriber to start from. Listed drivers are read from the cache; the
+// whole fleet is read once from the database rather than per driver.
+func (s *TrackingService) FleetSnapshot(ctx context.Context, filter FleetFilter) ([]domain.CurrentLocation, error) {
+	if len(filter.DriverIDs) > 0 && len(filter.TripIDs) == 0 {
+		return s.GetFleetLocations(ctx, filter.DriverIDs)
+	}
+
+	records, err := s.locationRepo.GetLatestSince(ctx, time.Now().Add(-fleetSnapshotWindow))
+	if err != nil {
+		return nil, err
+	}
+	match := newFleetSubscription(filter, nil)
+	locations := make([]domain.CurrentLocation, 0, len(records))
+	for i := range records {
+		location := currentLocationFromRecord(&records[i])
+		if match.matches(&location) {
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+// FleetSubscribers returns the number of open fleet subscriptions on this instance
+func (s *TrackingService) FleetSubscribers() int {
+	return s.fleet.size()
+}
+
+// publishFleetLocation pushes a driver's new current location to this
+// instance's subscribers and relays it to the other instances
+func (s *TrackingService) publishFleetLocation(ctx context.Context, record *domain.LocationRecord) {
+	location := currentLocationFromRecord(record)
+	s.fleet.broadcast(location)
+
+	if !s.circuit.allow() {
+		return
+	}
+	payload, err := json.Marshal(fleetMessage{Origin: s.instanceID, Location: location})
+	if err != nil {
+		return
+	}
+	if err := s.redis.Publish(ctx, fleetChannel, payload).Err(); err != nil {
+		s.circuit.recordFailure()
+		atomic.AddUint64(&s.cacheStats.errors, 1)
+		s.logger.Warnw("Failed to relay fleet location", "driver_id", record.DriverID, "error", err)
+	}
+}
+
+// StartFleetRelay delivers locations recorded by other instances to this
+// instance's subscribers until ctx is cancelled
+func (s *TrackingService) StartFleetRelay(ctx context.Context) {
+	pubsub := s.redis.Subscribe(ctx, fleetChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var relayed fleetMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &relayed); err != nil {
+				s.logger.Warnw("Invalid fleet location relayed", "error", err)
+				continue
+			}
+			if relayed.Origin == s.instanceID {
+				continue
+			}
+			s.fleet.broadcast(relayed.Location)
+		}
+	}
+}
+
+// currentLocationFromRecord builds a current location from a merged-track point
+func currentLocationFromRecord(record *domain.LocationRecord) domain.CurrentLocation {
+	return domain.CurrentLocation{
+		DriverID:   record.DriverID,
+		TractorID:  record.TractorID,
+		TripID:     record.TripID,
+		Latitude:   record.Latitude,
+		Longitude:  record.Longitude,
+		SpeedMPH:   record.SpeedMPH,
+		Heading:    record.Heading,
+		Source:     record.Source,
+		LastUpdate: record.RecordedAt,
+	}
+}
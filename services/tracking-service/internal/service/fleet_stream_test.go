@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+func TestFleetSubscriptionFiltersAndCoalesces(t *testing.T) {
+	// Redis is down, so locations only reach this instance's subscribers
+	svc := &TrackingService{fleet: newFleetHub()}
+	svc.circuit.openUntil = time.Now().Add(time.Hour)
+
+	driverA, driverB, driverC := uuid.New(), uuid.New(), uuid.New()
+	trip := uuid.New()
+	all := svc.SubscribeFleetLocations(FleetFilter{})
+	defer all.Close()
+	some := svc.SubscribeFleetLocations(FleetFilter{DriverIDs: []uuid.UUID{driverA}, TripIDs: []uuid.UUID{trip}})
+	defer some.Close()
+
+	base := time.Now()
+	record := func(driver uuid.UUID, tripID *uuid.UUID, secs int, lat float64) {
+		svc.publishFleetLocation(context.Background(), &domain.LocationRecord{
+			DriverID: driver, TripID: tripID, Latitude: lat,
+			RecordedAt: base.Add(time.Duration(secs) * time.Second),
+		})
+	}
+	record(driverA, nil, 0, 33.70)
+	record(driverB, nil, 0, 33.80)
+	record(driverA, nil, 1, 33.71)
+	record(driverC, &trip, 1, 33.90)
+	record(driverA, nil, -5, 33.60) // Late point, older than the pending update
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	updates, err := all.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("whole fleet got %d updates, want one per driver (3)", len(updates))
+	}
+	if updates[0].DriverID != driverA || updates[0].Latitude != 33.71 {
+		t.Errorf("first update = %+v, want driver A's latest point", updates[0])
+	}
+
+	updates, err = some.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(updates) != 2 || updates[0].DriverID != driverA || updates[1].DriverID != driverC {
+		t.Errorf("filtered updates = %+v, want driver A and the trip's driver", updates)
+	}
+
+	// Nothing pending: Next waits until the caller gives up
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := some.Next(short); err == nil {
+		t.Error("Next() returned without updates")
+	}
+
+	some.Close()
+	if n := svc.FleetSubscribers(); n != 1 {
+		t.Errorf("FleetSubscribers() = %d after close, want 1", n)
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+const (
+	// maxGeofenceStatsDays caps the period one stats request covers
+	maxGeofenceStatsDays = 93
+	// geofenceVisitLookahead is how far past the period events are read so
+	// visits entered near its end still pair with their exits
+	geofenceVisitLookahead = 24 * time.Hour
+)
+
+// ErrInvalidStatsRequest is returned for a geofence stats request with a bad
+// period or free time
+var ErrInvalidStatsRequest = errors.New("invalid geofence stats request")
+
+// GeofenceStatsInput selects the geofence activity to summarize
+type GeofenceStatsInput struct {
+	From         time.Time
+	To           time.Time
+	LocationID   *uuid.UUID // All locations when nil
+	FreeTimeMins int        // Contract free time to check dwell against; 0 skips the check
+}
+
+// GetGeofenceStats aggregates geofence enter and exit events into
+// per-location entry counts, unique drivers and dwell time for visits
+// entered in [From, To)
+func (s *TrackingService) GetGeofenceStats(ctx context.Context, input GeofenceStatsInput) ([]domain.GeofenceStats, error) {
+	if !input.To.After(input.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidStatsRequest)
+	}
+	if input.To.Sub(input.From) > maxGeofenceStatsDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: period cannot exceed %d days", ErrInvalidStatsRequest, maxGeofenceStatsDays)
+	}
+	if input.FreeTimeMins < 0 {
+		return nil, fmt.Errorf("%w: free_time_mins cannot be negative", ErrInvalidStatsRequest)
+	}
+
+	events, err := s.geofenceRepo.GetEvents(ctx, input.From, input.To.Add(geofenceVisitLookahead), input.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence events: %w", err)
+	}
+	return buildGeofenceStats(events, input.From, input.To, input.FreeTimeMins), nil
+}
+
+// recordGeofenceEvent stores an enter or exit event for analytics
+func (s *TrackingService) recordGeofenceEvent(ctx context.Context, geofence *domain.Geofence, version int, record *domain.LocationRecord, eventType string) {
+	event := &domain.GeofenceEvent{
+		ID:              uuid.New(),
+		GeofenceID:      geofence.ID,
+		GeofenceName:    geofence.Name,
+		LocationID:      geofence.LocationID,
+		DriverID:        record.DriverID,
+		TripID:          record.TripID,
+		GeofenceVersion: version,
+		EventType:       eventType,
+		OccurredAt:      record.RecordedAt,
+		Latitude:        record.Latitude,
+		Longitude:       record.Longitude,
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if err := s.geofenceRepo.CreateEvent(ctx, event); err != nil {
+		s.logger.Warnw("Failed to store geofence event", "geofence_id", geofence.ID, "driver_id", record.DriverID, "error", err)
+	}
+}
+
+// pairGeofenceVisits matches each enter event with the driver's next exit
+// from the same geofence. Events must be in the order they occurred. An exit
+// with no enter before it is dropped; an enter followed by another enter is
+// left open since its exit was missed.
+func pairGeofenceVisits(events []domain.GeofenceEvent) []domain.GeofenceVisit {
+	type key struct{ geofence, driver uuid.UUID }
+	open := make(map[key]int) // Index into visits
+	var visits []domain.GeofenceVisit
+
+	for _, e := range events {
+		k := key{e.GeofenceID, e.DriverID}
+		switch e.EventType {
+		case "enter":
+			open[k] = len(visits)
+			visits = append(visits, domain.GeofenceVisit{
+				GeofenceID: e.GeofenceID,
+				DriverID:   e.DriverID,
+				TripID:     e.TripID,
+				EnteredAt:  e.OccurredAt,
+			})
+		case "exit":
+			i, ok := open[k]
+			if !ok {
+				continue
+			}
+			exited := e.OccurredAt
+			visits[i].ExitedAt = &exited
+			delete(open, k)
+		}
+	}
+	return visits
+}
+
+// buildGeofenceStats summarizes the visits entered in [from, to) by geofence,
+// busiest first, with a breakdown per UTC day
+func buildGeofenceStats(events []domain.GeofenceEvent, from, to time.Time, freeTimeMins int) []domain.GeofenceStats {
+	type geofenceInfo struct {
+		name       string
+		locationID uuid.UUID
+	}
+	info := make(map[uuid.UUID]geofenceInfo)
+	for _, e := range events {
+		info[e.GeofenceID] = geofenceInfo{name: e.GeofenceName, locationID: e.LocationID}
+	}
+
+	byGeofence := make(map[uuid.UUID][]domain.GeofenceVisit)
+	for _, v := range pairGeofenceVisits(events) {
+		if v.EnteredAt.Before(from) || !v.EnteredAt.Before(to) {
+			continue
+		}
+		byGeofence[v.GeofenceID] = append(byGeofence[v.GeofenceID], v)
+	}
+
+	stats := make([]domain.GeofenceStats, 0, len(byGeofence))
+	for geofenceID, visits := range byGeofence {
+		st := domain.GeofenceStats{
+			GeofenceID:   geofenceID,
+			GeofenceName: info[geofenceID].name,
+			LocationID:   info[geofenceID].locationID,
+			From:         from,
+			To:           to,
+			FreeTimeMins: freeTimeMins,
+		}
+
+		drivers := make(map[uuid.UUID]bool)
+		var dwells []float64
+		days := make(map[string][]domain.GeofenceVisit)
+		for _, v := range visits {
+			drivers[v.DriverID] = true
+			if dwell := v.DwellMins(); dwell >= 0 {
+				dwells = append(dwells, dwell)
+				if freeTimeMins > 0 && dwell > float64(freeTimeMins) {
+					st.OverFreeTime++
+				}
+			} else {
+				st.OpenVisits++
+			}
+			date := v.EnteredAt.UTC().Format("2006-01-02")
+			days[date] = append(days[date], v)
+		}
+		st.Entries = len(visits)
+		st.UniqueDrivers = len(drivers)
+		st.CompletedVisits = len(dwells)
+		st.AvgDwellMins, st.MedianDwellMins = dwellSummary(dwells)
+		st.P90DwellMins = roundTenth(percentile(dwells, 90))
+		if freeTimeMins > 0 && len(dwells) > 0 {
+			st.OverFreeTimePct = roundTenth(float64(st.OverFreeTime) / float64(len(dwells)) * 100)
+		}
+
+		st.Daily = make([]domain.GeofenceDayStats, 0, len(days))
+		for date, dayVisits := range days {
+			day := domain.GeofenceDayStats{Date: date, Entries: len(dayVisits)}
+			dayDrivers := make(map[uuid.UUID]bool)
+			var dayDwells []float64
+			for _, v := range dayVisits {
+				dayDrivers[v.DriverID] = true
+				if dwell := v.DwellMins(); dwell >= 0 {
+					dayDwells = append(dayDwells, dwell)
+				}
+			}
+			day.UniqueDrivers = len(dayDrivers)
+			day.CompletedVisits = len(dayDwells)
+			day.AvgDwellMins, day.MedianDwellMins = dwellSummary(dayDwells)
+			st.Daily = append(st.Daily, day)
+		}
+		sort.Slice(st.Daily, func(i, j int) bool { return st.Daily[i].Date < st.Daily[j].Date })
+
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Entries != stats[j].Entries {
+			return stats[i].Entries > stats[j].Entries
+		}
+		return stats[i].GeofenceName < stats[j].GeofenceName
+	})
+	return stats
+}
+
+// dwellSummary returns the average and median dwell, rounded to a tenth of a minute
+func dwellSummary(dwells []float64) (avg, median float64) {
+	if len(dwells) == 0 {
+		return 0, 0
+	}
+	var total float64
+	for _, d := range dwells {
+		total += d
+	}
+	return roundTenth(total / float64(len(dwells))), roundTenth(percentile(dwells, 50))
+}
+
+// percentile returns the p-th percentile of values, interpolating between
+// the closest ranks
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+func TestBuildGeofenceStats(t *testing.T) {
+	terminal, warehouse := uuid.New(), uuid.New()
+	locationID := uuid.New()
+	driverA, driverB := uuid.New(), uuid.New()
+	day1 := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	event := func(geofenceID, driverID uuid.UUID, eventType string, at time.Time) domain.GeofenceEvent {
+		name := "Warehouse"
+		if geofenceID == terminal {
+			name = "Pier 400"
+		}
+		return domain.GeofenceEvent{
+			GeofenceID:   geofenceID,
+			GeofenceName: name,
+			LocationID:   locationID,
+			DriverID:     driverID,
+			EventType:    eventType,
+			OccurredAt:   at,
+		}
+	}
+
+	events := []domain.GeofenceEvent{
+		// Exit of a visit entered before the period is ignored
+		event(terminal, driverA, "exit", day1.Add(1*time.Hour)),
+		// Day 1: driver A 60 mins, driver B 90 mins
+		event(terminal, driverA, "enter", day1.Add(8*time.Hour)),
+		event(terminal, driverB, "enter", day1.Add(8*time.Hour+30*time.Minute)),
+		event(terminal, driverA, "exit", day1.Add(9*time.Hour)),
+		event(terminal, driverB, "exit", day1.Add(10*time.Hour)),
+		// Day 2: driver A 180 mins, exiting after the period ends
+		event(terminal, driverA, "enter", day2.Add(23*time.Hour)),
+		event(terminal, driverA, "exit", day2.Add(26*time.Hour)),
+		// Day 2: driver B's exit was missed, then a 30 min visit
+		event(terminal, driverB, "enter", day2.Add(6*time.Hour)),
+		event(terminal, driverB, "enter", day2.Add(12*time.Hour)),
+		event(terminal, driverB, "exit", day2.Add(12*time.Hour+30*time.Minute)),
+		// Warehouse: one visit still in progress
+		event(warehouse, driverA, "enter", day2.Add(14*time.Hour)),
+		// Entered after the period
+		event(terminal, driverA, "enter", day2.Add(27*time.Hour)),
+	}
+
+	stats := buildGeofenceStats(events, day1, day2.AddDate(0, 0, 1), 120)
+	if len(stats) != 2 {
+		t.Fatalf("got %d geofences, want 2", len(stats))
+	}
+
+	pier := stats[0]
+	if pier.GeofenceID != terminal || pier.GeofenceName != "Pier 400" {
+		t.Fatalf("busiest geofence = %s, want Pier 400", pier.GeofenceName)
+	}
+	if pier.Entries != 5 || pier.UniqueDrivers != 2 || pier.CompletedVisits != 4 || pier.OpenVisits != 1 {
+		t.Errorf("entries/drivers/completed/open = %d/%d/%d/%d, want 5/2/4/1",
+			pier.Entries, pier.UniqueDrivers, pier.CompletedVisits, pier.OpenVisits)
+	}
+	// Dwells 30, 60, 90, 180
+	if pier.AvgDwellMins != 90 || pier.MedianDwellMins != 75 {
+		t.Errorf("avg/median = %v/%v, want 90/75", pier.AvgDwellMins, pier.MedianDwellMins)
+	}
+	if pier.P90DwellMins != 153 {
+		t.Errorf("p90 = %v, want 153", pier.P90DwellMins)
+	}
+	if pier.OverFreeTime != 1 || pier.OverFreeTimePct != 25 {
+		t.Errorf("over free time = %d (%v%%), want 1 (25%%)", pier.OverFreeTime, pier.OverFreeTimePct)
+	}
+
+	if len(pier.Daily) != 2 {
+		t.Fatalf("got %d days, want 2", len(pier.Daily))
+	}
+	if d := pier.Daily[0]; d.Date != "2024-06-03" || d.Entries != 2 || d.UniqueDrivers != 2 || d.AvgDwellMins != 75 {
+		t.Errorf("day 1 = %+v", d)
+	}
+	if d := pier.Daily[1]; d.Date != "2024-06-04" || d.Entries != 3 || d.UniqueDrivers != 2 || d.CompletedVisits != 2 || d.MedianDwellMins != 105 {
+		t.Errorf("day 2 = %+v", d)
+	}
+
+	if wh := stats[1]; wh.Entries != 1 || wh.OpenVisits != 1 || wh.AvgDwellMins != 0 || wh.OverFreeTimePct != 0 {
+		t.Errorf("warehouse = %+v", wh)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{nil, 50, 0},
+		{[]float64{42}, 90, 42},
+		{[]float64{30, 10, 20}, 50, 20},
+		{[]float64{10, 20, 30, 40}, 50, 25},
+		{[]float64{10, 20, 30, 40}, 100, 40},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+const (
+	// rtreeNodeCapacity is the fan-out of the geofence R-tree
+	rtreeNodeCapacity = 16
+
+	// metersPerDegree matches the earth radius haversineDistance uses, so a
+	// circle's bounding box is never smaller than the circle containsPoint
+	// tests against
+	metersPerDegree = 3959 * 1609.34 * math.Pi / 180
+
+	// boundsPadding widens every box slightly to absorb rounding at the edge
+	boundsPadding = 1.01
+)
+
+// boundingBox is a latitude/longitude rectangle
+type boundingBox struct {
+	minLat, minLon float64
+	maxLat, maxLon float64
+}
+
+func (b boundingBox) contains(lat, lon float64) bool {
+	return lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon
+}
+
+func (b boundingBox) union(o boundingBox) boundingBox {
+	return boundingBox{
+		minLat: math.Min(b.minLat, o.minLat),
+		minLon: math.Min(b.minLon, o.minLon),
+		maxLat: math.Max(b.maxLat, o.maxLat),
+		maxLon: math.Max(b.maxLon, o.maxLon),
+	}
+}
+
+func (b boundingBox) center() (float64, float64) {
+	return (b.minLat + b.maxLat) / 2, (b.minLon + b.maxLon) / 2
+}
+
+// rtreeNode is an R-tree node; leaves hold a single geofence
+type rtreeNode struct {
+	box      boundingBox
+	children []*rtreeNode
+	geofence *domain.Geofence
+}
+
+// geofenceIndex is a static R-tree over geofence bounding boxes, packed with
+// Sort-Tile-Recursive, so a location point is only tested against the
+// geofences near it. Geofences change rarely and points arrive constantly,
+// so the index is rebuilt on every geofence change rather than updated.
+type geofenceIndex struct {
+	root *rtreeNode
+	size int
+}
+
+// newGeofenceIndex indexes the active geofences that have a usable boundary
+func newGeofenceIndex(geofences []*domain.Geofence) *geofenceIndex {
+	nodes := make([]*rtreeNode, 0, len(geofences))
+	for _, gf := range geofences {
+		if !gf.IsActive {
+			continue
+		}
+		box, ok := geofenceBounds(gf)
+		if !ok {
+			continue
+		}
+		nodes = append(nodes, &rtreeNode{box: box, geofence: gf})
+	}
+
+	idx := &geofenceIndex{size: len(nodes)}
+	if len(nodes) == 0 {
+		return idx
+	}
+	for len(nodes) > 1 {
+		nodes = packLevel(nodes)
+	}
+	idx.root = nodes[0]
+	return idx
+}
+
+// packLevel groups one level of nodes into parents: sort by latitude, cut
+// into vertical slices, sort each slice by longitude and fill parents in order
+func packLevel(nodes []*rtreeNode) []*rtreeNode {
+	parentCount := (len(nodes) + rtreeNodeCapacity - 1) / rtreeNodeCapacity
+	sliceCount := int(math.Ceil(math.Sqrt(float64(parentCount))))
+	sliceSize := sliceCount * rtreeNodeCapacity
+
+	sort.Slice(nodes, func(i, j int) bool {
+		li, _ := nodes[i].box.center()
+		lj, _ := nodes[j].box.center()
+		return li < lj
+	})
+
+	parents := make([]*rtreeNode, 0, parentCount)
+	for start := 0; start < len(nodes); start += sliceSize {
+		slice := nodes[start:minInt(start+sliceSize, len(nodes))]
+		sort.Slice(slice, func(i, j int) bool {
+			_, li := slice[i].box.center()
+			_, lj := slice[j].box.center()
+			return li < lj
+		})
+		for i := 0; i < len(slice); i += rtreeNodeCapacity {
+			children := slice[i:minInt(i+rtreeNodeCapacity, len(slice))]
+			parent := &rtreeNode{box: children[0].box, children: children}
+			for _, child := range children[1:] {
+				parent.box = parent.box.union(child.box)
+			}
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// search returns the geofences whose bounding box contains the point. Each
+// still needs containsPoint; the box only rules the rest out.
+func (idx *geofenceIndex) search(lat, lon float64) []*domain.Geofence {
+	if idx == nil || idx.root == nil {
+		return nil
+	}
+	var found []*domain.Geofence
+	stack := []*rtreeNode{idx.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !node.box.contains(lat, lon) {
+			continue
+		}
+		if node.geofence != nil {
+			found = append(found, node.geofence)
+			continue
+		}
+		stack = append(stack, node.children...)
+	}
+	return found
+}
+
+// len returns the number of indexed geofences
+func (idx *geofenceIndex) len() int {
+	if idx == nil {
+		return 0
+	}
+	return idx.size
+}
+
+// geofenceBounds returns the box enclosing a geofence boundary
+func geofenceBounds(gf *domain.Geofence) (boundingBox, bool) {
+	switch {
+	case gf.Type == "circle":
+		dLat := gf.RadiusMeters / metersPerDegree * boundsPadding
+		box := boundingBox{
+			minLat: gf.CenterLatitude - dLat,
+			maxLat: gf.CenterLatitude + dLat,
+			minLon: -180,
+			maxLon: 180,
+		}
+		// Longitude degrees shrink toward the poles; size the box by the
+		// latitude nearest the pole so it covers the whole circle
+		cos := math.Cos(math.Max(math.Abs(box.minLat), math.Abs(box.maxLat)) * math.Pi / 180)
+		if cos > 0.01 {
+			dLon := gf.RadiusMeters / (metersPerDegree * cos) * boundsPadding
+			if gf.CenterLongitude-dLon >= -180 && gf.CenterLongitude+dLon <= 180 {
+				box.minLon = gf.CenterLongitude - dLon
+				box.maxLon = gf.CenterLongitude + dLon
+			}
+		}
+		return box, true
+	case gf.Type == "polygon" && len(gf.Polygon) > 0:
+		box := boundingBox{
+			minLat: gf.Polygon[0].Latitude,
+			maxLat: gf.Polygon[0].Latitude,
+			minLon: gf.Polygon[0].Longitude,
+			maxLon: gf.Polygon[0].Longitude,
+		}
+		for _, c := range gf.Polygon[1:] {
+			box = box.union(boundingBox{minLat: c.Latitude, maxLat: c.Latitude, minLon: c.Longitude, maxLon: c.Longitude})
+		}
+		return box, true
+	default:
+		return boundingBox{}, false
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+func TestGeofenceIndexSearch(t *testing.T) {
+	pier400 := &domain.Geofence{ID: uuid.New(), Name: "Pier 400", Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 2000, IsActive: true}
+	yard := &domain.Geofence{ID: uuid.New(), Name: "Carson yard", Type: "polygon", IsActive: true, Polygon: []domain.Coordinate{
+		{Latitude: 33.84, Longitude: -118.26},
+		{Latitude: 33.84, Longitude: -118.24},
+		{Latitude: 33.82, Longitude: -118.24},
+		{Latitude: 33.82, Longitude: -118.26},
+	}}
+	oakland := &domain.Geofence{ID: uuid.New(), Name: "Oakland", Type: "circle", CenterLatitude: 37.7953, CenterLongitude: -122.2779, RadiusMeters: 1500, IsActive: true}
+	retired := &domain.Geofence{ID: uuid.New(), Name: "Retired", Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 5000}
+
+	idx := newGeofenceIndex([]*domain.Geofence{pier400, yard, oakland, retired})
+	if idx.len() != 3 {
+		t.Fatalf("len() = %d, want 3 active geofences", idx.len())
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     []*domain.Geofence
+	}{
+		{name: "inside the circle", lat: 33.7487, lon: -118.2628, want: []*domain.Geofence{pier400}},
+		{name: "inside the polygon", lat: 33.83, lon: -118.25, want: []*domain.Geofence{yard}},
+		{name: "another port", lat: 37.7953, lon: -122.2779, want: []*domain.Geofence{oakland}},
+		{name: "open road", lat: 34.0522, lon: -118.2437, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.search(tt.lat, tt.lon)
+			if len(got) != len(tt.want) {
+				t.Fatalf("search() returned %d geofences, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("search() = %s, want %s", got[i].Name, tt.want[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestGeofenceIndexMatchesContainsPoint(t *testing.T) {
+	svc := &TrackingService{}
+	rng := rand.New(rand.NewSource(1))
+
+	// Enough geofences around the LA/Long Beach ports for a multi-level tree
+	geofences := make([]*domain.Geofence, 0, 500)
+	for i := 0; i < 500; i++ {
+		gf := &domain.Geofence{
+			ID:              uuid.New(),
+			Type:            "circle",
+			CenterLatitude:  33.5 + rng.Float64(),
+			CenterLongitude: -118.8 + rng.Float64(),
+			RadiusMeters:    100 + rng.Float64()*3000,
+			IsActive:        true,
+		}
+		geofences = append(geofences, gf)
+	}
+	idx := newGeofenceIndex(geofences)
+
+	// Every geofence containing a point must come back from the index
+	for i := 0; i < 2000; i++ {
+		lat, lon := 33.5+rng.Float64(), -118.8+rng.Float64()
+		found := make(map[uuid.UUID]bool)
+		for _, gf := range idx.search(lat, lon) {
+			found[gf.ID] = true
+		}
+		for _, gf := range geofences {
+			if inside, _ := svc.containsPoint(gf, lat, lon); inside && !found[gf.ID] {
+				t.Fatalf("point (%v, %v) is inside geofence %s but the index missed it", lat, lon, gf.ID)
+			}
+		}
+	}
+}
+
+func TestGeofenceShardIsStablePerDriver(t *testing.T) {
+	record := &domain.LocationRecord{DriverID: uuid.New()}
+	shard := geofenceShard(record, geofenceWorkers)
+	for i := 0; i < 10; i++ {
+		if got := geofenceShard(&domain.LocationRecord{DriverID: record.DriverID}, geofenceWorkers); got != shard {
+			t.Fatalf("geofenceShard() = %d, want %d for the same driver", got, shard)
+		}
+	}
+}
+
+func TestGeofenceLatencyHistogram(t *testing.T) {
+	var c geofenceCounters
+	c.observe(500 * time.Microsecond)
+	c.observe(20 * time.Millisecond)
+	c.observe(2 * time.Second)
+
+	svc := &TrackingService{geofenceStats: c}
+	m := svc.GeofenceMetrics()
+
+	if m.Evaluations != 3 {
+		t.Errorf("Evaluations = %d, want 3", m.Evaluations)
+	}
+	want := map[float64]uint64{0.001: 1, 0.01: 1, 0.025: 2, 1: 2}
+	for _, b := range m.LatencyBuckets {
+		if n, ok := want[b.UpperBound]; ok && b.Count != n {
+			t.Errorf("bucket le=%v = %d, want %d", b.UpperBound, b.Count, n)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/kafka"
+)
+
+// learnedGeofenceEvent is a circular geofence an admin accepted from the
+// dispatch service's learned location proposals
+type learnedGeofenceEvent struct {
+	ProposalID   string  `json:"proposal_id"`
+	LocationID   string  `json:"location_id"`
+	LocationName string  `json:"location_name"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radius_meters"`
+}
+
+// HandleGeofenceLearned creates the location's geofence, or moves and
+// resizes its circle as a new version. A polygon drawn by hand is kept.
+func (s *TrackingService) HandleGeofenceLearned(ctx context.Context, event *kafka.Event) error {
+	var payload learnedGeofenceEvent
+	if err := event.DecodeData(&payload); err != nil {
+		return kafka.Permanent(fmt.Errorf("decode learned geofence: %w", err))
+	}
+	locationID, err := uuid.Parse(payload.LocationID)
+	if err != nil || payload.RadiusMeters <= 0 {
+		return nil
+	}
+
+	geofence, err := s.geofenceRepo.GetByLocationID(ctx, locationID)
+	if err != nil {
+		return fmt.Errorf("failed to get geofence: %w", err)
+	}
+
+	if geofence == nil {
+		created, err := s.CreateGeofence(ctx, CreateGeofenceInput{
+			LocationID:      locationID,
+			Name:            payload.LocationName,
+			Type:            "circle",
+			CenterLatitude:  payload.Latitude,
+			CenterLongitude: payload.Longitude,
+			RadiusMeters:    payload.RadiusMeters,
+		})
+		if err != nil {
+			return err
+		}
+		s.logger.Infow("Learned geofence created",
+			"geofence_id", created.ID,
+			"location_id", locationID,
+			"proposal_id", payload.ProposalID,
+			"radius_meters", payload.RadiusMeters,
+		)
+		return nil
+	}
+
+	if geofence.Type != "circle" {
+		s.logger.Infow("Learned geofence skipped for polygon geofence",
+			"geofence_id", geofence.ID,
+			"location_id", locationID,
+			"proposal_id", payload.ProposalID,
+		)
+		return nil
+	}
+	if geofence.CenterLatitude == payload.Latitude && geofence.CenterLongitude == payload.Longitude &&
+		geofence.RadiusMeters == payload.RadiusMeters {
+		return nil
+	}
+
+	_, err = s.UpdateGeofence(ctx, geofence.ID, UpdateGeofenceInput{
+		Name:            geofence.Name,
+		Type:            geofence.Type,
+		CenterLatitude:  payload.Latitude,
+		CenterLongitude: payload.Longitude,
+		RadiusMeters:    payload.RadiusMeters,
+	})
+	return err
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+const (
+	// geofenceWorkers is the number of goroutines evaluating geofences
+	geofenceWorkers = 8
+
+	// geofenceQueueSize bounds each worker's backlog of location points
+	geofenceQueueSize = 256
+)
+
+// geofenceLatencyBuckets are the upper bounds of the evaluation latency
+// histogram, in seconds
+var geofenceLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// GeofenceMetrics reports geofence evaluation throughput and latency
+type GeofenceMetrics struct {
+	Evaluations       uint64          `json:"evaluations"`
+	Dropped           uint64          `json:"dropped"`    // Points not evaluated because their worker's queue was full
+	Candidates        uint64          `json:"candidates"` // Geofences tested after the spatial index lookup
+	QueueDepth        int             `json:"queue_depth"`
+	Indexed           int             `json:"indexed"`
+	LatencyBuckets    []LatencyBucket `json:"latency_buckets"` // Cumulative, like a Prometheus histogram
+	LatencySumSeconds float64         `json:"latency_sum_seconds"`
+}
+
+// LatencyBucket counts evaluations that finished within UpperBound seconds
+type LatencyBucket struct {
+	UpperBound float64 `json:"upper_bound"`
+	Count      uint64  `json:"count"`
+}
+
+// geofenceCounters holds the live counters behind GeofenceMetrics
+type geofenceCounters struct {
+	evaluations  uint64
+	dropped      uint64
+	candidates   uint64
+	latencyNanos uint64
+	buckets      [10]uint64 // One per geofenceLatencyBuckets entry, then +Inf
+}
+
+// observe records one evaluation's latency
+func (c *geofenceCounters) observe(d time.Duration) {
+	atomic.AddUint64(&c.evaluations, 1)
+	atomic.AddUint64(&c.latencyNanos, uint64(d))
+	seconds := d.Seconds()
+	for i, bound := range geofenceLatencyBuckets {
+		if seconds <= bound {
+			atomic.AddUint64(&c.buckets[i], 1)
+			return
+		}
+	}
+	atomic.AddUint64(&c.buckets[len(geofenceLatencyBuckets)], 1)
+}
+
+// newGeofenceQueues creates one bounded queue per worker
+func newGeofenceQueues(workers, size int) []chan *domain.LocationRecord {
+	queues := make([]chan *domain.LocationRecord, workers)
+	for i := range queues {
+		queues[i] = make(chan *domain.LocationRecord, size)
+	}
+	return queues
+}
+
+// StartGeofenceWorkers evaluates queued location points against geofences
+// until ctx is cancelled. Points left in the queues at shutdown are dropped;
+// the driver's next point is compared against the stored state, so a missed
+// point delays an enter or exit rather than losing it.
+func (s *TrackingService) StartGeofenceWorkers(ctx context.Context) {
+	done := make(chan struct{}, len(s.geofenceQueues))
+	for _, queue := range s.geofenceQueues {
+		go func(queue <-chan *domain.LocationRecord) {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case record := <-queue:
+					start := time.Now()
+					s.checkGeofences(ctx, record)
+					s.geofenceStats.observe(time.Since(start))
+				}
+			}
+		}(queue)
+	}
+	for range s.geofenceQueues {
+		<-done
+	}
+}
+
+// enqueueGeofenceCheck hands a point to its driver's worker without blocking
+// the caller. A driver's points always go to the same worker so they are
+// evaluated in order.
+func (s *TrackingService) enqueueGeofenceCheck(record *domain.LocationRecord) {
+	if len(s.geofenceQueues) == 0 {
+		return
+	}
+	select {
+	case s.geofenceQueues[geofenceShard(record, len(s.geofenceQueues))] <- record:
+	default:
+		if atomic.AddUint64(&s.geofenceStats.dropped, 1)%100 == 1 {
+			s.logger.Warnw("Geofence queue full, dropping location point",
+				"driver_id", record.DriverID,
+				"dropped_total", atomic.LoadUint64(&s.geofenceStats.dropped),
+			)
+		}
+	}
+}
+
+// geofenceShard picks the worker for a driver
+func geofenceShard(record *domain.LocationRecord, workers int) int {
+	h := fnv.New32a()
+	h.Write(record.DriverID[:])
+	return int(h.Sum32() % uint32(workers))
+}
+
+// GeofenceMetrics returns a snapshot of geofence evaluation counters
+func (s *TrackingService) GeofenceMetrics() GeofenceMetrics {
+	m := GeofenceMetrics{
+		Evaluations:       atomic.LoadUint64(&s.geofenceStats.evaluations),
+		Dropped:           atomic.LoadUint64(&s.geofenceStats.dropped),
+		Candidates:        atomic.LoadUint64(&s.geofenceStats.candidates),
+		LatencySumSeconds: time.Duration(atomic.LoadUint64(&s.geofenceStats.latencyNanos)).Seconds(),
+	}
+	for _, queue := range s.geofenceQueues {
+		m.QueueDepth += len(queue)
+	}
+
+	s.cacheMu.RLock()
+	m.Indexed = s.geofenceIndex.len()
+	s.cacheMu.RUnlock()
+
+	var cumulative uint64
+	for i, bound := range geofenceLatencyBuckets {
+		cumulative += atomic.LoadUint64(&s.geofenceStats.buckets[i])
+		m.LatencyBuckets = append(m.LatencyBuckets, LatencyBucket{UpperBound: bound, Count: cumulative})
+	}
+	return m
+}
+
+// geofenceCandidates returns the active geofences a point must be tested
+// against: those whose bounds contain it, plus those the driver was inside,
+// which must be tested to detect the exit
+func (s *TrackingService) geofenceCandidates(record *domain.LocationRecord, previousStates map[string]string) []*domain.Geofence {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	candidates := s.geofenceIndex.search(record.Latitude, record.Longitude)
+	seen := make(map[uuid.UUID]bool, len(candidates))
+	for _, gf := range candidates {
+		seen[gf.ID] = true
+	}
+	for key, state := range previousStates {
+		if wasInside, _ := parseGeofenceState(state); !wasInside {
+			continue
+		}
+		id, err := uuid.Parse(key)
+		if err != nil || seen[id] {
+			continue
+		}
+		if gf, ok := s.geofenceCache[id]; ok && gf.IsActive {
+			candidates = append(candidates, gf)
+		}
+	}
+	return candidates
+}
+
+// cacheGeofence stores a geofence in the cache and rebuilds the index
+func (s *TrackingService) cacheGeofence(geofence *domain.Geofence) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.geofenceCache[geofence.ID] = geofence
+	s.rebuildGeofenceIndex()
+}
+
+// rebuildGeofenceIndex re-indexes the cached geofences; callers hold cacheMu
+func (s *TrackingService) rebuildGeofenceIndex() {
+	geofences := make([]*domain.Geofence, 0, len(s.geofenceCache))
+	for _, gf := range s.geofenceCache {
+		geofences = append(geofences, gf)
+	}
+	s.geofenceIndex = newGeofenceIndex(geofences)
+}
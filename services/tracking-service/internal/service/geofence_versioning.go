@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+// UpdateGeofenceInput contains input for editing a geofence boundary
+type UpdateGeofenceInput struct {
+	Name            string
+	Type            string // circle, polygon
+	CenterLatitude  float64
+	CenterLongitude float64
+	RadiusMeters    float64
+	Polygon         []domain.Coordinate
+}
+
+// UpdateGeofence edits a geofence and bumps its version so drivers already
+// inside are evaluated against the boundary they entered
+func (s *TrackingService) UpdateGeofence(ctx context.Context, geofenceID uuid.UUID, input UpdateGeofenceInput) (*domain.Geofence, error) {
+	geofence, err := s.geofenceRepo.GetByID(ctx, geofenceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence: %w", err)
+	}
+	if geofence == nil {
+		return nil, fmt.Errorf("geofence not found")
+	}
+
+	// Preserve the outgoing boundary in case it predates versioning
+	if err := s.geofenceRepo.CreateVersion(ctx, snapshotGeofence(geofence)); err != nil {
+		return nil, fmt.Errorf("failed to store geofence version: %w", err)
+	}
+
+	geofence.Name = input.Name
+	geofence.Type = input.Type
+	geofence.CenterLatitude = input.CenterLatitude
+	geofence.CenterLongitude = input.CenterLongitude
+	geofence.RadiusMeters = input.RadiusMeters
+	geofence.Polygon = input.Polygon
+	geofence.Version++
+	geofence.UpdatedAt = time.Now()
+
+	if err := s.geofenceRepo.Update(ctx, geofence); err != nil {
+		return nil, fmt.Errorf("failed to update geofence: %w", err)
+	}
+	if err := s.geofenceRepo.CreateVersion(ctx, snapshotGeofence(geofence)); err != nil {
+		return nil, fmt.Errorf("failed to store geofence version: %w", err)
+	}
+
+	s.cacheGeofence(geofence)
+
+	s.logger.Infow("Geofence updated",
+		"geofence_id", geofence.ID,
+		"version", geofence.Version,
+	)
+
+	return geofence, nil
+}
+
+// RecomputeGeofenceState re-evaluates driver membership for a geofence
+// against its current boundary without emitting enter/exit events. It is
+// intended for admins to repair state after boundary edits.
+func (s *TrackingService) RecomputeGeofenceState(ctx context.Context, geofenceID uuid.UUID) (int, error) {
+	geofence, err := s.geofenceRepo.GetByID(ctx, geofenceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get geofence: %w", err)
+	}
+	if geofence == nil {
+		return 0, fmt.Errorf("geofence not found")
+	}
+
+	s.cacheGeofence(geofence)
+
+	records, err := s.locationRepo.GetLatestSince(ctx, time.Now().Add(-stateRebuildWindow))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load recent locations: %w", err)
+	}
+
+	for _, record := range records {
+		isInside, _ := s.containsPoint(geofence, record.Latitude, record.Longitude)
+		stateKey := fmt.Sprintf("geofence:state:%s", record.DriverID.String())
+		if err := s.redis.HSet(ctx, stateKey, geofence.ID.String(), formatGeofenceState(isInside, geofence.Version)).Err(); err != nil {
+			return 0, fmt.Errorf("failed to write geofence state: %w", err)
+		}
+	}
+
+	s.logger.Infow("Geofence state recomputed",
+		"geofence_id", geofence.ID,
+		"version", geofence.Version,
+		"drivers", len(records),
+	)
+
+	return len(records), nil
+}
+
+// insideVersion reports whether a point is inside a historical version of a
+// geofence. If the version cannot be loaded, or the state predates
+// versioning, the current boundary decides.
+func (s *TrackingService) insideVersion(ctx context.Context, geofence *domain.Geofence, version int, lat, lon float64) bool {
+	if version > 0 {
+		snapshot, err := s.geofenceRepo.GetVersion(ctx, geofence.ID, version)
+		if err == nil && snapshot != nil {
+			isInside, _ := s.containsPoint(snapshot.Boundary(), lat, lon)
+			return isInside
+		}
+		s.logger.Warnw("Geofence version unavailable, using the current boundary",
+			"geofence_id", geofence.ID,
+			"version", version,
+			"error", err,
+		)
+	}
+
+	isInside, _ := s.containsPoint(geofence, lat, lon)
+	return isInside
+}
+
+// snapshotGeofence captures the current boundary of a geofence
+func snapshotGeofence(geofence *domain.Geofence) *domain.GeofenceVersion {
+	version := geofence.Version
+	if version == 0 {
+		version = 1
+	}
+	return &domain.GeofenceVersion{
+		GeofenceID:      geofence.ID,
+		Version:         version,
+		Type:            geofence.Type,
+		CenterLatitude:  geofence.CenterLatitude,
+		CenterLongitude: geofence.CenterLongitude,
+		RadiusMeters:    geofence.RadiusMeters,
+		Polygon:         geofence.Polygon,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// formatGeofenceState encodes membership and the version it was evaluated against
+func formatGeofenceState(inside bool, version int) string {
+	state := "outside"
+	if inside {
+		state = "inside"
+	}
+	return fmt.Sprintf("%s:%d", state, version)
+}
+
+// parseGeofenceState decodes a stored membership value. Values written before
+// versioning ("inside"/"outside") report version 0.
+func parseGeofenceState(value string) (bool, int) {
+	state, versionStr, _ := strings.Cut(value, ":")
+	version, _ := strconv.Atoi(versionStr)
+	return state == "inside", version
+}
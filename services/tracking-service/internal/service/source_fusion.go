@@ -0,0 +1,103 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/services/tracking-service/internal/domain"
+)
+
+// fusionKey identifies one track: a driver in a tractor. Drivers reporting
+// without a tractor get their own track.
+type fusionKey struct {
+	driverID  uuid.UUID
+	tractorID uuid.UUID
+}
+
+// fusionTrack is the merged track of one driver-tractor pair
+type fusionTrack struct {
+	source   string                // Source currently leading the track
+	accuracy float64               // Accuracy of its latest point, in meters
+	last     domain.LocationRecord // Last point accepted onto the track
+}
+
+// fuseLocation decides whether a point joins the driver's merged track. The
+// leading source keeps the track until it goes quiet for SourceStaleSecs or
+// another source reports more accurately by SwitchMarginMeters; points close
+// in time and space to the last accepted one are duplicates whatever their
+// source.
+func (s *TrackingService) fuseLocation(record *domain.LocationRecord) domain.FusionStatus {
+	rules := &s.businessRules.GPSFusion
+	source := record.Source
+	accuracy := s.pointAccuracy(source, record.AccuracyMeters)
+
+	key := fusionKey{driverID: record.DriverID}
+	if record.TractorID != nil {
+		key.tractorID = *record.TractorID
+	}
+
+	s.fusionMu.Lock()
+	defer s.fusionMu.Unlock()
+
+	track, ok := s.fusionTracks[key]
+	if !ok {
+		// A driver moves to one tractor at a time; drop their other tracks
+		for other := range s.fusionTracks {
+			if other.driverID == key.driverID {
+				delete(s.fusionTracks, other)
+			}
+		}
+		s.fusionTracks[key] = &fusionTrack{source: source, accuracy: accuracy, last: *record}
+		return domain.FusionPrimary
+	}
+
+	gap := record.RecordedAt.Sub(track.last.RecordedAt)
+	if gap.Abs() <= time.Duration(rules.DuplicateSecs)*time.Second &&
+		s.haversineDistance(track.last.Latitude, track.last.Longitude, record.Latitude, record.Longitude)*1609.34 <= rules.DuplicateMeters {
+		return domain.FusionDuplicate
+	}
+	if gap < 0 {
+		// Late points would make the track jump backwards
+		return domain.FusionSuppressed
+	}
+
+	if source != track.source {
+		stale := gap > time.Duration(rules.SourceStaleSecs)*time.Second
+		better := accuracy+rules.SwitchMarginMeters <= track.accuracy
+		if !stale && !better {
+			return domain.FusionSuppressed
+		}
+		s.logger.Infow("Location source switched",
+			"driver_id", record.DriverID,
+			"tractor_id", record.TractorID,
+			"from", track.source,
+			"to", source,
+			"stale", stale,
+		)
+		track.source = source
+	}
+
+	track.accuracy = accuracy
+	track.last = *record
+	return domain.FusionPrimary
+}
+
+// pointAccuracy returns the reported accuracy, or the source's assumed
+// accuracy when the point carries none
+func (s *TrackingService) pointAccuracy(source string, reported float64) float64 {
+	if reported > 0 {
+		return reported
+	}
+	if assumed, ok := s.businessRules.GPSFusion.SourceAccuracyMeters[source]; ok {
+		return assumed
+	}
+	// Unknown sources rank behind every configured one
+	worst := 0.0
+	for _, assumed := range s.businessRules.GPSFusion.SourceAccuracyMeters {
+		if assumed > worst {
+			worst = assumed
+		}
+	}
+	return worst * 2
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/draymaster/services/tracking-service/internal/domain"
 	"github.com/draymaster/services/tracking-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
 	"github.com/draymaster/shared/pkg/kafka"
 	"github.com/draymaster/shared/pkg/logger"
 )
@@ -21,13 +24,37 @@ type TrackingService struct {
 	locationRepo  repository.LocationRepository
 	milestoneRepo repository.MilestoneRepository
 	geofenceRepo  repository.GeofenceRepository
+	corridorRepo  repository.CorridorRepository
 	redis         *redis.Client
 	eventProducer *kafka.Producer
 	logger        *logger.Logger
+	businessRules *config.BusinessRules
 	
-	// In-memory geofence cache
+	// In-memory geofence cache, spatially indexed for point lookups
 	geofenceCache map[uuid.UUID]*domain.Geofence
+	geofenceIndex *geofenceIndex
 	cacheMu       sync.RWMutex
+
+	// Location points waiting for geofence evaluation, one queue per worker
+	geofenceQueues []chan *domain.LocationRecord
+	geofenceStats  geofenceCounters
+
+	// Active route corridors by trip, with their deviation state
+	corridorCache map[uuid.UUID]*domain.RouteCorridor
+	corridorMu    sync.Mutex
+
+	// Merged track per driver-tractor pair across location sources
+	fusionTracks map[fusionKey]*fusionTrack
+	fusionMu     sync.Mutex
+
+	// Fleet location subscribers on this instance; instanceID tags the
+	// locations it relays to the others
+	fleet      *fleetHub
+	instanceID string
+
+	// Redis degradation handling
+	circuit    redisCircuit
+	cacheStats cacheCounters
 }
 
 // NewTrackingService creates a new tracking service
@@ -35,6 +62,7 @@ func NewTrackingService(
 	locationRepo repository.LocationRepository,
 	milestoneRepo repository.MilestoneRepository,
 	geofenceRepo repository.GeofenceRepository,
+	corridorRepo repository.CorridorRepository,
 	redisClient *redis.Client,
 	eventProducer *kafka.Producer,
 	log *logger.Logger,
@@ -43,19 +71,29 @@ func NewTrackingService(
 		locationRepo:  locationRepo,
 		milestoneRepo: milestoneRepo,
 		geofenceRepo:  geofenceRepo,
+		corridorRepo:  corridorRepo,
 		redis:         redisClient,
 		eventProducer: eventProducer,
 		logger:        log,
-		geofenceCache: make(map[uuid.UUID]*domain.Geofence),
+		businessRules: config.DefaultBusinessRules(),
+		geofenceCache:  make(map[uuid.UUID]*domain.Geofence),
+		corridorCache:  make(map[uuid.UUID]*domain.RouteCorridor),
+		fusionTracks:   make(map[fusionKey]*fusionTrack),
+		fleet:          newFleetHub(),
+		instanceID:     uuid.New().String(),
+		geofenceQueues: newGeofenceQueues(geofenceWorkers, geofenceQueueSize),
 	}
 	
-	// Load geofences into cache
+	// Load geofences and corridors into cache
 	go svc.loadGeofenceCache(context.Background())
+	go svc.loadCorridorCache(context.Background())
 	
 	return svc
 }
 
-// RecordLocation records a GPS location and checks geofences
+// RecordLocation records a GPS location and checks geofences. Every point
+// is stored, but only points on the driver's merged track update the
+// current location, geofences, corridors and subscribers.
 func (s *TrackingService) RecordLocation(ctx context.Context, input RecordLocationInput) (*domain.LocationRecord, error) {
 	record := &domain.LocationRecord{
 		ID:             uuid.New(),
@@ -67,23 +105,37 @@ func (s *TrackingService) RecordLocation(ctx context.Context, input RecordLocati
 		SpeedMPH:       input.SpeedMPH,
 		Heading:        input.Heading,
 		AccuracyMeters: input.AccuracyMeters,
-		Source:         input.Source,
+		Source:         strings.ToLower(input.Source),
 		RecordedAt:     input.RecordedAt,
 		ReceivedAt:     time.Now(),
 	}
+	record.FusionStatus = s.fuseLocation(record)
 
 	// Store in TimescaleDB
 	if err := s.locationRepo.Create(ctx, record); err != nil {
 		return nil, fmt.Errorf("failed to store location: %w", err)
 	}
+	if record.FusionStatus != domain.FusionPrimary {
+		return record, nil
+	}
 
 	// Update current location in Redis for real-time queries
-	if err := s.updateCurrentLocation(ctx, record); err != nil {
-		s.logger.Warnw("Failed to update Redis location", "error", err)
+	if s.circuit.allow() {
+		if err := s.updateCurrentLocation(ctx, record); err != nil {
+			s.circuit.recordFailure()
+			atomic.AddUint64(&s.cacheStats.errors, 1)
+			s.logger.Warnw("Failed to update Redis location", "error", err)
+		} else {
+			s.circuit.recordSuccess()
+		}
 	}
 
-	// Check geofences asynchronously
-	go s.checkGeofences(context.Background(), record)
+	// Push the new position to fleet subscribers
+	s.publishFleetLocation(ctx, record)
+
+	// Check geofences on the worker pool and the route corridor asynchronously
+	s.enqueueGeofenceCheck(record)
+	go s.checkCorridor(context.Background(), record)
 
 	// Publish location update event
 	event := kafka.NewEvent(kafka.Topics.LocationUpdated, "tracking-service", map[string]interface{}{
@@ -92,6 +144,7 @@ func (s *TrackingService) RecordLocation(ctx context.Context, input RecordLocati
 		"latitude":  input.Latitude,
 		"longitude": input.Longitude,
 		"speed":     input.SpeedMPH,
+		"source":    record.Source,
 	})
 	_ = s.eventProducer.Publish(ctx, kafka.Topics.LocationUpdated, event)
 
@@ -112,28 +165,31 @@ type RecordLocationInput struct {
 	RecordedAt     time.Time
 }
 
-// GetCurrentLocation retrieves current location from Redis
+// GetCurrentLocation retrieves current location from Redis, falling back to
+// the database when the cache misses or Redis is unavailable
 func (s *TrackingService) GetCurrentLocation(ctx context.Context, driverID uuid.UUID) (*domain.CurrentLocation, error) {
+	if !s.circuit.allow() {
+		return s.getCurrentLocationFromDB(ctx, driverID)
+	}
+
 	key := fmt.Sprintf("location:current:%s", driverID.String())
 	
 	data, err := s.redis.HGetAll(ctx, key).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current location: %w", err)
+		s.circuit.recordFailure()
+		atomic.AddUint64(&s.cacheStats.errors, 1)
+		s.logger.Warnw("Redis read failed, falling back to database", "driver_id", driverID, "error", err)
+		return s.getCurrentLocationFromDB(ctx, driverID)
 	}
+	s.circuit.recordSuccess()
 
 	if len(data) == 0 {
-		return nil, fmt.Errorf("no location found for driver")
+		atomic.AddUint64(&s.cacheStats.misses, 1)
+		return s.getCurrentLocationFromDB(ctx, driverID)
 	}
 
-	// Parse Redis hash to CurrentLocation
-	location := &domain.CurrentLocation{
-		DriverID: driverID,
-	}
-	
-	// Would parse data map to struct
-	// Simplified for brevity
-	
-	return location, nil
+	atomic.AddUint64(&s.cacheStats.hits, 1)
+	return parseCurrentLocation(driverID, data), nil
 }
 
 // GetFleetLocations retrieves all active driver locations
@@ -289,6 +345,7 @@ func (s *TrackingService) CreateGeofence(ctx context.Context, input CreateGeofen
 		RadiusMeters:    input.RadiusMeters,
 		Polygon:         input.Polygon,
 		IsActive:        true,
+		Version:         1,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -297,10 +354,12 @@ func (s *TrackingService) CreateGeofence(ctx context.Context, input CreateGeofen
 		return nil, fmt.Errorf("failed to create geofence: %w", err)
 	}
 
+	if err := s.geofenceRepo.CreateVersion(ctx, snapshotGeofence(geofence)); err != nil {
+		return nil, fmt.Errorf("failed to store geofence version: %w", err)
+	}
+
 	// Update cache
-	s.cacheMu.Lock()
-	s.geofenceCache[geofence.ID] = geofence
-	s.cacheMu.Unlock()
+	s.cacheGeofence(geofence)
 
 	return geofence, nil
 }
@@ -330,20 +389,35 @@ func (s *TrackingService) CheckGeofence(ctx context.Context, geofenceID uuid.UUI
 		geofence = gf
 	}
 
+	isInside, distanceMeters := s.containsPoint(geofence, lat, lon)
+	return isInside, distanceMeters, nil
+}
+
+// GetGeofences returns several geofences at once, leaving out unknown IDs
+func (s *TrackingService) GetGeofences(ctx context.Context, ids []uuid.UUID) ([]*domain.Geofence, error) {
+	geofences, err := s.geofenceRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+	return geofences, nil
+}
+
+// containsPoint evaluates a point against a geofence boundary
+func (s *TrackingService) containsPoint(geofence *domain.Geofence, lat, lon float64) (bool, float64) {
 	if geofence.Type == "circle" {
 		distance := s.haversineDistance(lat, lon, geofence.CenterLatitude, geofence.CenterLongitude)
 		distanceMeters := distance * 1609.34 // Convert miles to meters
 		isInside := distanceMeters <= geofence.RadiusMeters
-		return isInside, distanceMeters, nil
+		return isInside, distanceMeters
 	}
 
 	// Polygon check using ray casting algorithm
 	if geofence.Type == "polygon" && len(geofence.Polygon) > 0 {
 		isInside := s.pointInPolygon(lat, lon, geofence.Polygon)
-		return isInside, 0, nil
+		return isInside, 0
 	}
 
-	return false, 0, nil
+	return false, 0
 }
 
 // GetContainerLocation retrieves current container location
@@ -379,6 +453,7 @@ func (s *TrackingService) updateCurrentLocation(ctx context.Context, record *dom
 		"speed":       record.SpeedMPH,
 		"heading":     record.Heading,
 		"recorded_at": record.RecordedAt.Unix(),
+		"source":      record.Source,
 		"trip_id":     "",
 	}
 	
@@ -389,7 +464,6 @@ func (s *TrackingService) updateCurrentLocation(ctx context.Context, record *dom
 	pipe := s.redis.Pipeline()
 	pipe.HSet(ctx, key, data)
 	pipe.Expire(ctx, key, 24*time.Hour)
-	_, err := pipe.Exec(ctx)
 	
 	// Also add to geo index for proximity queries
 	geoKey := "location:geo"
@@ -399,40 +473,55 @@ func (s *TrackingService) updateCurrentLocation(ctx context.Context, record *dom
 		Longitude: record.Longitude,
 	})
 	
+	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// checkGeofences detects geofence entries and exits for a point, testing
+// only the geofences near it and those the driver was last inside
 func (s *TrackingService) checkGeofences(ctx context.Context, record *domain.LocationRecord) {
-	s.cacheMu.RLock()
-	geofences := make([]*domain.Geofence, 0, len(s.geofenceCache))
-	for _, gf := range s.geofenceCache {
-		if gf.IsActive {
-			geofences = append(geofences, gf)
-		}
+	// Without reliable previous state we cannot tell enter from exit;
+	// skip detection rather than emit spurious events
+	if !s.circuit.allow() {
+		return
 	}
-	s.cacheMu.RUnlock()
 
 	previousKey := fmt.Sprintf("geofence:state:%s", record.DriverID.String())
-	previousStates, _ := s.redis.HGetAll(ctx, previousKey).Result()
+	previousStates, err := s.redis.HGetAll(ctx, previousKey).Result()
+	if err != nil {
+		s.circuit.recordFailure()
+		atomic.AddUint64(&s.cacheStats.errors, 1)
+		s.logger.Warnw("Failed to read geofence state", "driver_id", record.DriverID, "error", err)
+		return
+	}
+
+	geofences := s.geofenceCandidates(record, previousStates)
+	atomic.AddUint64(&s.geofenceStats.candidates, uint64(len(geofences)))
 
 	for _, geofence := range geofences {
-		isInside, _, _ := s.CheckGeofence(ctx, geofence.ID, record.Latitude, record.Longitude)
+		isInside, _ := s.containsPoint(geofence, record.Latitude, record.Longitude)
 		
-		wasInside := previousStates[geofence.ID.String()] == "inside"
+		wasInside, enteredVersion := parseGeofenceState(previousStates[geofence.ID.String()])
 		
 		if isInside && !wasInside {
 			// Entered geofence
-			s.handleGeofenceEvent(ctx, geofence, record, "enter")
-			s.redis.HSet(ctx, previousKey, geofence.ID.String(), "inside")
+			s.handleGeofenceEvent(ctx, geofence, geofence.Version, record, "enter")
+			s.redis.HSet(ctx, previousKey, geofence.ID.String(), formatGeofenceState(true, geofence.Version))
+		} else if isInside && wasInside && enteredVersion != geofence.Version {
+			// Still inside after a boundary edit; adopt the current version silently
+			s.redis.HSet(ctx, previousKey, geofence.ID.String(), formatGeofenceState(true, geofence.Version))
 		} else if !isInside && wasInside {
-			// Exited geofence
-			s.handleGeofenceEvent(ctx, geofence, record, "exit")
-			s.redis.HSet(ctx, previousKey, geofence.ID.String(), "outside")
+			// Only exit once the driver has left the boundary they entered
+			if enteredVersion != geofence.Version && s.insideVersion(ctx, geofence, enteredVersion, record.Latitude, record.Longitude) {
+				continue
+			}
+			s.handleGeofenceEvent(ctx, geofence, enteredVersion, record, "exit")
+			s.redis.HSet(ctx, previousKey, geofence.ID.String(), formatGeofenceState(false, geofence.Version))
 		}
 	}
 }
 
-func (s *TrackingService) handleGeofenceEvent(ctx context.Context, geofence *domain.Geofence, record *domain.LocationRecord, eventType string) {
+func (s *TrackingService) handleGeofenceEvent(ctx context.Context, geofence *domain.Geofence, version int, record *domain.LocationRecord, eventType string) {
 	topic := kafka.Topics.GeofenceEntered
 	if eventType == "exit" {
 		topic = kafka.Topics.GeofenceExited
@@ -441,6 +530,7 @@ func (s *TrackingService) handleGeofenceEvent(ctx context.Context, geofence *dom
 	event := kafka.NewEvent(topic, "tracking-service", map[string]interface{}{
 		"geofence_id":   geofence.ID.String(),
 		"geofence_name": geofence.Name,
+		"geofence_version": version,
 		"location_id":   geofence.LocationID.String(),
 		"driver_id":     record.DriverID.String(),
 		"trip_id":       record.TripID,
@@ -450,10 +540,12 @@ func (s *TrackingService) handleGeofenceEvent(ctx context.Context, geofence *dom
 	})
 	
 	_ = s.eventProducer.Publish(ctx, topic, event)
+	s.recordGeofenceEvent(ctx, geofence, version, record, eventType)
 
 	s.logger.Infow("Geofence event",
 		"type", eventType,
 		"geofence", geofence.Name,
+		"version", version,
 		"driver_id", record.DriverID,
 	)
 }
@@ -469,6 +561,7 @@ func (s *TrackingService) loadGeofenceCache(ctx context.Context) {
 	for _, gf := range geofences {
 		s.geofenceCache[gf.ID] = gf
 	}
+	s.rebuildGeofenceIndex()
 	s.cacheMu.Unlock()
 
 	s.logger.Infow("Geofence cache loaded", "count", len(geofences))
@@ -1,10 +1,17 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/draymaster/services/tracking-service/internal/domain"
+	"github.com/draymaster/services/tracking-service/internal/repository"
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
 )
 
 func TestHaversineDistance(t *testing.T) {
@@ -319,6 +326,179 @@ func TestRecordMilestoneInput_Structure(t *testing.T) {
 	}
 }
 
+func TestRedisCircuit(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		success   bool
+		wantAllow bool
+	}{
+		{name: "No failures", failures: 0, wantAllow: true},
+		{name: "Below threshold", failures: circuitFailureThreshold - 1, wantAllow: true},
+		{name: "At threshold opens", failures: circuitFailureThreshold, wantAllow: false},
+		{name: "Success closes", failures: circuitFailureThreshold, success: true, wantAllow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c redisCircuit
+			for i := 0; i < tt.failures; i++ {
+				c.recordFailure()
+			}
+			if tt.success {
+				c.recordSuccess()
+			}
+			if got := c.allow(); got != tt.wantAllow {
+				t.Errorf("allow() = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestParseCurrentLocation(t *testing.T) {
+	driverID := uuid.New()
+	tripID := uuid.New()
+
+	tests := []struct {
+		name     string
+		data     map[string]string
+		wantLat  float64
+		wantLon  float64
+		wantTrip bool
+	}{
+		{
+			name: "Full hash",
+			data: map[string]string{
+				"latitude":    "33.7397",
+				"longitude":   "-118.2628",
+				"speed":       "45.5",
+				"heading":     "90",
+				"recorded_at": "1705312800",
+				"trip_id":     tripID.String(),
+			},
+			wantLat:  33.7397,
+			wantLon:  -118.2628,
+			wantTrip: true,
+		},
+		{
+			name: "No trip",
+			data: map[string]string{
+				"latitude":  "33.9425",
+				"longitude": "-118.4081",
+				"trip_id":   "",
+			},
+			wantLat:  33.9425,
+			wantLon:  -118.4081,
+			wantTrip: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := parseCurrentLocation(driverID, tt.data)
+			if loc.DriverID != driverID {
+				t.Errorf("parseCurrentLocation().DriverID = %v, want %v", loc.DriverID, driverID)
+			}
+			if loc.Latitude != tt.wantLat || loc.Longitude != tt.wantLon {
+				t.Errorf("parseCurrentLocation() = (%v, %v), want (%v, %v)",
+					loc.Latitude, loc.Longitude, tt.wantLat, tt.wantLon)
+			}
+			if (loc.TripID != nil) != tt.wantTrip {
+				t.Errorf("parseCurrentLocation().TripID set = %v, want %v", loc.TripID != nil, tt.wantTrip)
+			}
+		})
+	}
+}
+
+func TestGeofenceStateEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		wantInside  bool
+		wantVersion int
+	}{
+		{name: "Versioned inside", value: formatGeofenceState(true, 3), wantInside: true, wantVersion: 3},
+		{name: "Versioned outside", value: formatGeofenceState(false, 2), wantInside: false, wantVersion: 2},
+		{name: "Legacy inside", value: "inside", wantInside: true, wantVersion: 0},
+		{name: "Legacy outside", value: "outside", wantInside: false, wantVersion: 0},
+		{name: "Missing state", value: "", wantInside: false, wantVersion: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inside, version := parseGeofenceState(tt.value)
+			if inside != tt.wantInside || version != tt.wantVersion {
+				t.Errorf("parseGeofenceState(%q) = (%v, %v), want (%v, %v)",
+					tt.value, inside, version, tt.wantInside, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestContainsPointVersionedBoundary(t *testing.T) {
+	svc := &TrackingService{}
+
+	// Original boundary and a shrunken edit of the same geofence
+	v1 := &domain.GeofenceVersion{Version: 1, Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 2000}
+	v2 := &domain.GeofenceVersion{Version: 2, Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 500}
+
+	// ~1km north of center: inside v1, outside v2
+	lat, lon := 33.7487, -118.2628
+
+	if inside, _ := svc.containsPoint(v1.Boundary(), lat, lon); !inside {
+		t.Errorf("containsPoint(v1) = false, want true")
+	}
+	if inside, _ := svc.containsPoint(v2.Boundary(), lat, lon); inside {
+		t.Errorf("containsPoint(v2) = true, want false")
+	}
+}
+
+// versionRepo serves stored geofence versions; a missing version is not found
+type versionRepo struct {
+	repository.GeofenceRepository
+	versions map[int]*domain.GeofenceVersion
+	err      error
+}
+
+func (r *versionRepo) GetVersion(ctx context.Context, id uuid.UUID, version int) (*domain.GeofenceVersion, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.versions[version], nil
+}
+
+func TestInsideVersion(t *testing.T) {
+	// The terminal gate was shrunk from 2km (v1) to 500m (v2)
+	geofence := &domain.Geofence{ID: uuid.New(), Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 500, Version: 2}
+	v1 := &domain.GeofenceVersion{GeofenceID: geofence.ID, Version: 1, Type: "circle", CenterLatitude: 33.7397, CenterLongitude: -118.2628, RadiusMeters: 2000}
+
+	// ~1km north of center: inside v1, outside v2; ~100m north is inside both
+	farLat, nearLat, lon := 33.7487, 33.7406, -118.2628
+
+	tests := []struct {
+		name    string
+		repo    *versionRepo
+		version int
+		lat     float64
+		want    bool
+	}{
+		{name: "inside the entered version", repo: &versionRepo{versions: map[int]*domain.GeofenceVersion{1: v1}}, version: 1, lat: farLat, want: true},
+		{name: "missing version falls back to the current boundary", repo: &versionRepo{}, version: 1, lat: farLat, want: false},
+		{name: "missing version, inside the current boundary", repo: &versionRepo{}, version: 1, lat: nearLat, want: true},
+		{name: "lookup error falls back to the current boundary", repo: &versionRepo{err: errors.New("connection reset")}, version: 1, lat: nearLat, want: true},
+		{name: "state from before versioning", repo: &versionRepo{}, version: 0, lat: nearLat, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &TrackingService{geofenceRepo: tt.repo, logger: logger.Default()}
+			if got := svc.insideVersion(context.Background(), geofence, tt.version, tt.lat, lon); got != tt.want {
+				t.Errorf("insideVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // Benchmark tests for performance-critical functions
 
 func BenchmarkHaversineDistance(b *testing.B) {
@@ -353,3 +533,127 @@ func BenchmarkGetTrafficFactor(b *testing.B) {
 		svc.getTrafficFactor(testTime)
 	}
 }
+
+func TestDistanceToPolylineMeters(t *testing.T) {
+	// East-west leg along the 34th parallel
+	polyline := []domain.Coordinate{
+		{Latitude: 34.0, Longitude: -118.3},
+		{Latitude: 34.0, Longitude: -118.2},
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		wantMin  float64
+		wantMax  float64
+	}{
+		{name: "On the route", lat: 34.0, lon: -118.25, wantMin: 0, wantMax: 1},
+		{name: "North of the leg", lat: 34.01, lon: -118.25, wantMin: 1100, wantMax: 1130},
+		{name: "Past the end of the leg", lat: 34.0, lon: -118.19, wantMin: 910, wantMax: 940},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distanceToPolylineMeters(tt.lat, tt.lon, polyline)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("distanceToPolylineMeters() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestAdvanceCorridor(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	corridor := &domain.RouteCorridor{BufferMeters: 1000, MaxDeviationMins: 5}
+
+	steps := []struct {
+		name   string
+		offset float64
+		at     time.Time
+		want   corridorTransition
+	}{
+		{"Inside buffer", 200, start, corridorUnchanged},
+		{"Leaves corridor", 1500, start.Add(1 * time.Minute), corridorLeft},
+		{"Still off route", 1800, start.Add(3 * time.Minute), corridorUnchanged},
+		{"Off route past limit", 2000, start.Add(6 * time.Minute), corridorAlert},
+		{"Alert not repeated", 2500, start.Add(8 * time.Minute), corridorUnchanged},
+		{"Returns to corridor", 400, start.Add(9 * time.Minute), corridorReturned},
+		{"Brief excursion", 1200, start.Add(10 * time.Minute), corridorLeft},
+		{"Back before limit", 300, start.Add(12 * time.Minute), corridorCleared},
+	}
+
+	for _, step := range steps {
+		got, _ := advanceCorridor(corridor, step.offset, step.at)
+		if got != step.want {
+			t.Fatalf("%s: advanceCorridor() = %v, want %v", step.name, got, step.want)
+		}
+	}
+	if corridor.OffRouteSince != nil || corridor.AlertedAt != nil {
+		t.Error("expected deviation state to be cleared after returning")
+	}
+}
+
+func TestFuseLocation(t *testing.T) {
+	svc := &TrackingService{
+		logger:        logger.Default(),
+		businessRules: config.DefaultBusinessRules(),
+		fusionTracks:  make(map[fusionKey]*fusionTrack),
+	}
+	driverID := uuid.New()
+	tractorID := uuid.New()
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	point := func(source string, accuracy, lat float64, at time.Time) *domain.LocationRecord {
+		return &domain.LocationRecord{
+			DriverID:       driverID,
+			TractorID:      &tractorID,
+			Latitude:       lat,
+			Longitude:      -118.25,
+			AccuracyMeters: accuracy,
+			Source:         source,
+			RecordedAt:     at,
+		}
+	}
+
+	steps := []struct {
+		name   string
+		record *domain.LocationRecord
+		want   domain.FusionStatus
+	}{
+		{"First point starts the track", point(domain.SourceMobile, 25, 34.000, start), domain.FusionPrimary},
+		{"Same spot from ELD is a duplicate", point(domain.SourceELD, 5, 34.0001, start.Add(3*time.Second)), domain.FusionDuplicate},
+		{"More accurate ELD takes over", point(domain.SourceELD, 5, 34.002, start.Add(30*time.Second)), domain.FusionPrimary},
+		{"Phone loses to the ELD", point(domain.SourceMobile, 25, 34.004, start.Add(40*time.Second)), domain.FusionSuppressed},
+		{"ELD continues the track", point(domain.SourceELD, 5, 34.006, start.Add(60*time.Second)), domain.FusionPrimary},
+		{"Late ELD point is dropped", point(domain.SourceELD, 5, 34.003, start.Add(45*time.Second)), domain.FusionSuppressed},
+		{"Phone takes over once the ELD goes quiet", point(domain.SourceMobile, 0, 34.020, start.Add(4*time.Minute)), domain.FusionPrimary},
+		{"Phone keeps a fresh track against equal telematics", point(domain.SourceTelematics, 25, 34.022, start.Add(4*time.Minute+20*time.Second)), domain.FusionSuppressed},
+	}
+
+	for _, step := range steps {
+		if got := svc.fuseLocation(step.record); got != step.want {
+			t.Fatalf("%s: fuseLocation() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestFuseLocationNewTractorReplacesTrack(t *testing.T) {
+	svc := &TrackingService{
+		logger:        logger.Default(),
+		businessRules: config.DefaultBusinessRules(),
+		fusionTracks:  make(map[fusionKey]*fusionTrack),
+	}
+	driverID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	now := time.Now()
+
+	svc.fuseLocation(&domain.LocationRecord{DriverID: driverID, TractorID: &first, Source: domain.SourceELD, RecordedAt: now})
+	status := svc.fuseLocation(&domain.LocationRecord{DriverID: driverID, TractorID: &second, Source: domain.SourceMobile, Latitude: 1, RecordedAt: now.Add(time.Minute)})
+
+	if status != domain.FusionPrimary {
+		t.Errorf("fuseLocation() = %v, want %v", status, domain.FusionPrimary)
+	}
+	if len(svc.fusionTracks) != 1 {
+		t.Errorf("expected one track for the driver, got %d", len(svc.fusionTracks))
+	}
+}
@@ -0,0 +1,83 @@
+// Package auth authenticates gRPC calls with OIDC bearer tokens and
+// authorizes them by the caller's roles. Every service installs the same
+// interceptors with the same policy, so a method's permissions do not
+// depend on which service happens to serve it.
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Role is a permission group granted by the identity provider
+type Role string
+
+const (
+	RoleDispatcher Role = "dispatcher"
+	RoleDriver     Role = "driver"
+	RoleBilling    Role = "billing"
+	RoleManager    Role = "manager" // Signs off exceptions such as below-margin rates
+	RoleAdmin      Role = "admin"   // Allowed every method
+)
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	Subject string // The token's sub claim; services record it as the acting user
+	Email   string
+	Name    string
+	Roles   []Role
+}
+
+// HasRole reports whether the identity holds any of roles. Admins hold
+// every role.
+func (i *Identity) HasRole(roles ...Role) bool {
+	for _, held := range i.Roles {
+		if held == RoleAdmin {
+			return true
+		}
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a context carrying the caller's identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity of the caller, if the call was
+// authenticated
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// parseRoles reads a roles claim, either a list or a space- or
+// comma-separated string. Names are matched without regard to case.
+func parseRoles(claim interface{}) []Role {
+	var names []string
+	switch v := claim.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case string:
+		names = strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+
+	roles := make([]Role, 0, len(names))
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			roles = append(roles, Role(name))
+		}
+	}
+	return roles
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+func TestAuthenticateRequest(t *testing.T) {
+	log := newTestLogger(t)
+	secret := []byte("a-real-development-secret")
+	hs256 := map[string]interface{}{"alg": "HS256"}
+	ops := signToken(t, hs256, claims(nil), secret)
+	manager := signToken(t, hs256, claims(map[string]interface{}{"sub": "manager-3", "roles": "dispatcher manager"}), secret)
+	admin := signToken(t, hs256, claims(map[string]interface{}{"sub": "admin-1", "roles": "admin"}), secret)
+
+	enabled := &Authenticator{
+		verifier: newTestVerifier(config.AuthConfig{JWTSecret: string(secret), ClockSkew: time.Minute}),
+		policy:   DefaultPolicy(),
+		enabled:  true,
+		logger:   log,
+	}
+	disabled, err := NewAuthenticator(config.AuthConfig{}, DefaultPolicy(), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		authn       *Authenticator
+		header      string
+		roles       []Role
+		wantCode    string
+		wantSubject string
+	}{
+		{"disabled trusts the caller", disabled, "", []Role{RoleManager}, "", ""},
+		{"missing token", enabled, "", nil, "UNAUTHORIZED", ""},
+		{"not a bearer token", enabled, "Basic " + ops, nil, "UNAUTHORIZED", ""},
+		{"invalid token", enabled, "Bearer " + ops + "x", nil, "UNAUTHORIZED", ""},
+		{"any role", enabled, "Bearer " + ops, nil, "", "user-1"},
+		{"missing role", enabled, "Bearer " + ops, []Role{RoleManager}, "FORBIDDEN", ""},
+		{"holds role", enabled, "bearer " + manager, []Role{RoleManager}, "", "manager-3"},
+		{"admin holds every role", enabled, "Bearer " + admin, []Role{RoleManager}, "", "admin-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/rate-approvals/1/approve", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			id, err := tt.authn.AuthenticateRequest(r, tt.roles...)
+			if tt.wantCode != "" {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var subject string
+			if id != nil {
+				subject = id.Subject
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+}
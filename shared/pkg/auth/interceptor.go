@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/draymaster/shared/pkg/config"
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/grpcerrors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// UserMetadataKey is the metadata key services read the acting user from.
+// Once a call is authenticated it holds the token's subject, whatever the
+// client sent.
+const UserMetadataKey = "x-user-id"
+
+// Authenticator checks the bearer token on incoming calls against a
+// policy
+type Authenticator struct {
+	verifier *Verifier
+	policy   *Policy
+	enabled  bool
+	logger   *logger.Logger
+}
+
+// NewAuthenticator creates an authenticator enforcing policy. With auth
+// disabled in cfg every call passes unchecked, as before auth existed; with
+// it enabled an OIDC issuer is required, so a service never falls back to
+// trusting tokens signed with a shared secret.
+func NewAuthenticator(cfg config.AuthConfig, policy *Policy, log *logger.Logger) (*Authenticator, error) {
+	if !cfg.Enabled {
+		log.Warn("gRPC authentication is disabled; callers are trusted as the user they claim")
+	} else if cfg.OIDCIssuer == "" {
+		return nil, errors.New("auth: OIDC_ISSUER_URL is required when AUTH_ENABLED is true")
+	}
+	return &Authenticator{verifier: NewVerifier(cfg), policy: policy, enabled: cfg.Enabled, logger: log}, nil
+}
+
+// UnaryInterceptor returns a gRPC unary interceptor that authenticates and
+// authorizes each call. It should run after logging, so rejected calls are
+// logged, and before the handler.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate verifies the call's token and returns a context carrying
+// the caller's identity
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if !a.enabled || a.policy.IsPublic(method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	id, err := a.verify(ctx, bearerToken(md), "method", method)
+	if err != nil {
+		return ctx, grpcerrors.ToStatus(err)
+	}
+	if err := a.policy.Authorize(method, id); err != nil {
+		return ctx, grpcerrors.ToStatus(err)
+	}
+
+	md = md.Copy()
+	md.Set(UserMetadataKey, id.Subject)
+	ctx = metadata.NewIncomingContext(ctx, md)
+	return WithIdentity(ctx, id), nil
+}
+
+// verify checks a bearer token, logging why it was rejected under the
+// given key and value
+func (a *Authenticator) verify(ctx context.Context, token, key, value string) (*Identity, error) {
+	if token == "" {
+		return nil, apperrors.New("UNAUTHORIZED", "missing bearer token")
+	}
+	id, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidToken) {
+			// The token may be fine; the issuer's keys could not be fetched
+			a.logger.Errorw("Token verification failed", key, value, "error", err)
+			return nil, apperrors.New("SERVICE_UNAVAILABLE", "cannot verify token")
+		}
+		a.logger.Infow("Rejected token", key, value, "reason", err.Error())
+		return nil, apperrors.New("UNAUTHORIZED", "invalid or expired token")
+	}
+	return id, nil
+}
+
+func bearerToken(md metadata.MD) string {
+	for _, value := range md.Get("authorization") {
+		if scheme, token, ok := strings.Cut(strings.TrimSpace(value), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// authenticatedStream is a server stream whose context carries the
+// caller's identity
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
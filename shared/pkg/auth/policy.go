@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"strings"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// Policy names the roles allowed to call each gRPC method. Methods are
+// full gRPC method names, /package.Service/Method, or /package.Service/*
+// for every method of a service not listed on its own. A method the policy
+// does not mention is open to admins only.
+type Policy struct {
+	methods map[string][]Role
+	public  map[string]bool
+}
+
+// NewPolicy creates an empty policy
+func NewPolicy() *Policy {
+	return &Policy{methods: make(map[string][]Role), public: make(map[string]bool)}
+}
+
+// Allow lets callers holding any of roles call the methods
+func (p *Policy) Allow(roles []Role, methods ...string) *Policy {
+	for _, method := range methods {
+		p.methods[method] = append(p.methods[method], roles...)
+	}
+	return p
+}
+
+// Public lets the methods be called without a token
+func (p *Policy) Public(methods ...string) *Policy {
+	for _, method := range methods {
+		p.public[method] = true
+	}
+	return p
+}
+
+// IsPublic reports whether a method can be called without a token
+func (p *Policy) IsPublic(method string) bool {
+	return p.public[method] || p.public[serviceWildcard(method)]
+}
+
+// Authorize checks that id may call method
+func (p *Policy) Authorize(method string, id *Identity) error {
+	roles, ok := p.methods[method]
+	if !ok {
+		roles = p.methods[serviceWildcard(method)]
+	}
+	if id.HasRole(roles...) {
+		return nil
+	}
+	if len(roles) == 0 {
+		roles = []Role{RoleAdmin}
+	}
+	return apperrors.New("FORBIDDEN", "not permitted to call "+method).
+		WithDetail("method", method).
+		WithDetail("required_roles", roles)
+}
+
+// serviceWildcard turns /package.Service/Method into /package.Service/*
+func serviceWildcard(method string) string {
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		return method[:i] + "/*"
+	}
+	return method
+}
+
+var (
+	ops           = []Role{RoleDispatcher}
+	opsAndBilling = []Role{RoleDispatcher, RoleBilling}
+	opsAndDrivers = []Role{RoleDispatcher, RoleDriver}
+)
+
+// DefaultPolicy is the permission policy shared by every service.
+// Dispatchers run operations; billing reads what it needs to invoice and
+// settle; drivers see their trips and report stops, positions and
+// milestones. Which trips a driver may touch is the service's to check.
+func DefaultPolicy() *Policy {
+	return NewPolicy().
+		Public(
+			"/grpc.health.v1.Health/*",
+		).
+		// Orders
+		Allow(ops, "/order.v1.OrderService/*").
+		Allow(opsAndBilling,
+			"/order.v1.OrderService/GetShipment",
+			"/order.v1.OrderService/ListShipments",
+			"/order.v1.OrderService/GetContainer",
+			"/order.v1.OrderService/GetOrder",
+			"/order.v1.OrderService/ListOrders",
+			"/order.v1.OrderService/GetRateQuote",
+		).
+		// Dispatch
+		Allow(ops,
+			"/dispatch.v1.DispatchService/*",
+			"/dispatch.v1.DispatchCRUDService/*",
+			"/dispatch.v1.ChassisService/*",
+		).
+		Allow(opsAndBilling,
+			"/dispatch.v1.DispatchService/ListTrips",
+			"/dispatch.v1.DispatchService/GetTripCost",
+			"/dispatch.v1.DispatchCRUDService/GetTripWithDetails",
+			"/dispatch.v1.DispatchCRUDService/SearchTrips",
+			"/dispatch.v1.DispatchCRUDService/GetTripStatistics",
+			"/dispatch.v1.DispatchCRUDService/GetTripStops",
+			"/dispatch.v1.DispatchCRUDService/GetLocations",
+			"/dispatch.v1.DispatchCRUDService/GetDrivers",
+			"/dispatch.v1.ChassisService/GetChassisPerDiem",
+			"/dispatch.v1.ChassisService/GetTripChassisSplit",
+		).
+		Allow(opsAndDrivers,
+			"/dispatch.v1.DispatchService/UpdateStopStatus",
+			"/dispatch.v1.DispatchService/RecordStopArrival",
+			"/dispatch.v1.DispatchService/CompleteStop",
+			"/dispatch.v1.DispatchCRUDService/GetTripsByDriver",
+		).
+		Allow([]Role{RoleDispatcher, RoleBilling, RoleDriver},
+			"/dispatch.v1.DispatchService/GetTrip",
+		).
+		// Tracking
+		Allow(ops, "/tracking.v1.TrackingService/*").
+		Allow(opsAndDrivers,
+			"/tracking.v1.TrackingService/RecordLocation",
+			"/tracking.v1.TrackingService/GetTripETA",
+			"/tracking.v1.TrackingService/RecordMilestone",
+			"/tracking.v1.TrackingService/GetTripMilestones",
+			"/tracking.v1.TrackingService/CheckGeofence",
+		).
+		Allow(opsAndBilling,
+			"/tracking.v1.TrackingService/GetContainerLocation",
+			"/tracking.v1.TrackingService/GetContainerHistory",
+		).
+		// eModal
+		Allow(ops, "/emodal.v1.EModalIntegrationService/*").
+		Allow(opsAndBilling,
+			"/emodal.v1.EModalIntegrationService/GetGateFees",
+		)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import "testing"
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy := NewPolicy().
+		Allow([]Role{RoleDispatcher}, "/order.v1.OrderService/*").
+		Allow([]Role{RoleDispatcher, RoleBilling}, "/order.v1.OrderService/GetOrder").
+		Allow([]Role{RoleDriver}, "/tracking.v1.TrackingService/RecordLocation")
+
+	tests := []struct {
+		name    string
+		method  string
+		roles   []Role
+		allowed bool
+	}{
+		{"exact method", "/order.v1.OrderService/GetOrder", []Role{RoleBilling}, true},
+		{"wildcard", "/order.v1.OrderService/CreateOrder", []Role{RoleDispatcher}, true},
+		{"exact method overrides wildcard", "/order.v1.OrderService/CreateOrder", []Role{RoleBilling}, false},
+		{"unlisted method", "/tracking.v1.TrackingService/GetTripETA", []Role{RoleDriver}, false},
+		{"unlisted method as admin", "/tracking.v1.TrackingService/GetTripETA", []Role{RoleAdmin}, true},
+		{"admin on listed method", "/tracking.v1.TrackingService/RecordLocation", []Role{RoleAdmin}, true},
+		{"no roles", "/order.v1.OrderService/GetOrder", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.method, &Identity{Subject: "user-1", Roles: tt.roles})
+			if tt.allowed && err != nil {
+				t.Errorf("Authorize() error = %v, want allowed", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("Authorize() allowed, want denied")
+			}
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name    string
+		method  string
+		role    Role
+		allowed bool
+	}{
+		{"dispatcher creates orders", "/order.v1.OrderService/CreateOrder", RoleDispatcher, true},
+		{"billing reads orders", "/order.v1.OrderService/ListOrders", RoleBilling, true},
+		{"billing cannot create orders", "/order.v1.OrderService/CreateOrder", RoleBilling, false},
+		{"driver completes stops", "/dispatch.v1.DispatchService/CompleteStop", RoleDriver, true},
+		{"driver cannot assign trips", "/dispatch.v1.DispatchService/AssignTrip", RoleDriver, false},
+		{"driver reports location", "/tracking.v1.TrackingService/RecordLocation", RoleDriver, true},
+		{"every role reads a trip", "/dispatch.v1.DispatchService/GetTrip", RoleBilling, true},
+		{"unknown service", "/billing.v1.BillingService/CreateInvoice", RoleDispatcher, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.method, &Identity{Subject: "user-1", Roles: []Role{tt.role}})
+			if tt.allowed != (err == nil) {
+				t.Errorf("Authorize(%s, %s) error = %v, want allowed = %v", tt.method, tt.role, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestPolicyIsPublic(t *testing.T) {
+	policy := DefaultPolicy()
+	if !policy.IsPublic("/grpc.health.v1.Health/Check") {
+		t.Error("health checks should be public")
+	}
+	if policy.IsPublic("/order.v1.OrderService/GetOrder") {
+		t.Error("order methods should not be public")
+	}
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 for HS256, RS256 and ES256
+	_ "crypto/sha512" // SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// ErrInvalidToken is returned for a token that is malformed, badly signed,
+// expired or not meant for this audience
+var ErrInvalidToken = errors.New("auth: invalid token")
+
+// minKeyRefetch bounds how often an unknown key ID sends the verifier back
+// to the issuer's JWKS
+const minKeyRefetch = time.Minute
+
+// Verifier checks bearer tokens. With an OIDC issuer configured it accepts
+// RS and ES tokens signed by a key from the issuer's JWKS; without one it
+// accepts HS tokens signed with the shared JWT secret, for tooling and tests,
+// unless the secret is unset or the public default. Authenticators always
+// have an issuer.
+type Verifier struct {
+	cfg    config.AuthConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewVerifier creates a token verifier
+func NewVerifier(cfg config.AuthConfig) *Verifier {
+	return &Verifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks a token's signature, issuer, audience and lifetime and
+// returns the identity it carries
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	id := &Identity{Roles: parseRoles(lookupClaim(claims, v.cfg.RolesClaim))}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	if id.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	return id, nil
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, header tokenHeader, signed string, signature []byte) error {
+	h, ok := hashFor(header.Alg)
+	if !ok {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	if strings.HasPrefix(header.Alg, "HS") {
+		// A shared secret only stands in for an identity provider outside
+		// services; once an issuer is set its keys are the only ones, and
+		// anyone can sign with the default secret
+		if v.cfg.OIDCIssuer != "" || v.cfg.JWTSecret == "" || v.cfg.JWTSecret == config.DefaultJWTSecret {
+			return fmt.Errorf("%w: %s tokens are not accepted", ErrInvalidToken, header.Alg)
+		}
+		mac := hmac.New(h.New, []byte(v.cfg.JWTSecret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+
+	if v.cfg.OIDCIssuer == "" {
+		return fmt.Errorf("%w: no issuer configured for %s tokens", ErrInvalidToken, header.Alg)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	digest := h.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(header.Alg, "RS") {
+			return fmt.Errorf("%w: %s token signed with an RSA key", ErrInvalidToken, header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, h, sum, signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(header.Alg, "ES") || len(signature) != 2*size {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, sum, r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type", ErrInvalidToken)
+	}
+	return nil
+}
+
+func hashFor(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "HS256", "RS256", "ES256":
+		return crypto.SHA256, true
+	case "HS384", "RS384", "ES384":
+		return crypto.SHA384, true
+	case "HS512", "RS512", "ES512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func (v *Verifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("%w: no expiry", ErrInvalidToken)
+	}
+	if now.After(exp.Add(v.cfg.ClockSkew)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.cfg.ClockSkew).Before(nbf) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+
+	if v.cfg.OIDCIssuer != "" {
+		if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != strings.TrimSuffix(v.cfg.OIDCIssuer, "/") {
+			return fmt.Errorf("%w: issuer %q", ErrInvalidToken, iss)
+		}
+	}
+	if v.cfg.OIDCAudience != "" && !hasAudience(claims["aud"], v.cfg.OIDCAudience) {
+		return fmt.Errorf("%w: audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	secs, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(secs), 0), true
+}
+
+func hasAudience(aud interface{}, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupClaim walks a dotted path such as realm_access.roles into the
+// token's claims
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	var current interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[name]
+	}
+	return current
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+// key returns the issuer's signing key with the given ID, fetching the
+// JWKS when the cache is stale or the key is new
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetchedAt)
+	key, ok := v.keys[kid]
+	if ok && age < v.cfg.JWKSRefresh {
+		return key, nil
+	}
+	if v.keys == nil || age >= minKeyRefetch {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if ok {
+				// Keep verifying with the cached key while the issuer is unreachable
+				return key, nil
+			}
+			return nil, fmt.Errorf("auth: fetch signing keys: %w", err)
+		}
+		v.keys, v.fetchedAt = keys, v.now()
+		key, ok = keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if v.jwksURI == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		url := strings.TrimSuffix(v.cfg.OIDCIssuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, url, &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("issuer has no jwks_uri")
+		}
+		v.jwksURI = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.jwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,458 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+var testNow = time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+// testIssuer serves OIDC discovery and a JWKS whose keys can be swapped
+type testIssuer struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	keys    []jsonWebKey
+	fetches int
+	down    bool
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	iss := &testIssuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"jwks_uri": iss.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+		iss.fetches++
+		if iss.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": iss.keys})
+	})
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+func (i *testIssuer) setKeys(keys ...jsonWebKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys = keys
+}
+
+func (i *testIssuer) setDown(down bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.down = down
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jsonWebKey {
+	return jsonWebKey{Kid: kid, Kty: "RSA", Use: "sig", N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) jsonWebKey {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return jsonWebKey{Kid: kid, Kty: "EC", Use: "sig", Crv: "P-256",
+		X: b64(key.X.FillBytes(make([]byte, size))), Y: b64(key.Y.FillBytes(make([]byte, size)))}
+}
+
+// signToken builds a token with the given header and claims, signed by
+// key: an HMAC secret, an RSA key or an ECDSA key
+func signToken(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := b64(h) + "." + b64(c)
+
+	alg, _ := header["alg"].(string)
+	hash, ok := hashFor(alg)
+	if !ok {
+		return signed + "." + b64([]byte("sig"))
+	}
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := hash.New()
+		digest.Write([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest.Sum(nil)); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		digest := hash.New()
+		digest.Write([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest.Sum(nil))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	default:
+		t.Fatalf("unsupported signing key %T", key)
+	}
+	return signed + "." + b64(sig)
+}
+
+func claims(overrides map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"sub":   "user-1",
+		"email": "ops@example.com",
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"roles": []string{"Dispatcher"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func newTestVerifier(cfg config.AuthConfig) *Verifier {
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.JWKSRefresh == 0 {
+		cfg.JWKSRefresh = time.Hour
+	}
+	v := NewVerifier(cfg)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerifySharedSecret(t *testing.T) {
+	secret := []byte("a-real-development-secret")
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name  string
+		cfg   config.AuthConfig
+		token string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: signToken(t, hs256, claims(nil), secret),
+			valid: true,
+		},
+		{
+			name:  "HS512",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: signToken(t, map[string]interface{}{"alg": "HS512"}, claims(nil), secret),
+			valid: true,
+		},
+		{
+			name:  "wrong secret",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: signToken(t, hs256, claims(nil), []byte("guessed")),
+		},
+		{
+			name:  "default secret",
+			cfg:   config.AuthConfig{JWTSecret: config.DefaultJWTSecret},
+			token: signToken(t, hs256, claims(nil), []byte(config.DefaultJWTSecret)),
+		},
+		{
+			name:  "empty secret",
+			cfg:   config.AuthConfig{},
+			token: signToken(t, hs256, claims(nil), []byte("")),
+		},
+		{
+			name:  "issuer configured",
+			cfg:   config.AuthConfig{JWTSecret: string(secret), OIDCIssuer: "https://issuer.example"},
+			token: signToken(t, hs256, claims(map[string]interface{}{"iss": "https://issuer.example"}), secret),
+		},
+		{
+			name:  "alg none",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: signToken(t, map[string]interface{}{"alg": "none"}, claims(nil), nil),
+		},
+		{
+			name:  "RS256 without issuer",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: signToken(t, map[string]interface{}{"alg": "RS256"}, claims(nil), secret[:0]),
+		},
+		{
+			name:  "tampered claims",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: tamper(signToken(t, hs256, claims(nil), secret), claims(map[string]interface{}{"roles": []string{"admin"}})),
+		},
+		{
+			name:  "malformed",
+			cfg:   config.AuthConfig{JWTSecret: string(secret)},
+			token: "not-a-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := newTestVerifier(tt.cfg).Verify(context.Background(), tt.token)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Verify() error = %v, want valid", err)
+				}
+				if id.Subject != "user-1" || id.Email != "ops@example.com" || !id.HasRole(RoleDispatcher) {
+					t.Errorf("identity = %+v", id)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+// tamper swaps a signed token's claims, keeping its header and signature
+func tamper(token string, claims map[string]interface{}) string {
+	parts := strings.Split(token, ".")
+	c, _ := json.Marshal(claims)
+	return parts[0] + "." + b64(c) + "." + parts[2]
+}
+
+func TestVerifyClaims(t *testing.T) {
+	secret := []byte("a-real-development-secret")
+	hs256 := map[string]interface{}{"alg": "HS256"}
+
+	tests := []struct {
+		name     string
+		audience string
+		claims   map[string]interface{}
+		valid    bool
+	}{
+		{"valid", "", nil, true},
+		{"no expiry", "", map[string]interface{}{"exp": nil}, false},
+		{"expired", "", map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()}, false},
+		{"expired within skew", "", map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()}, true},
+		{"not yet valid", "", map[string]interface{}{"nbf": testNow.Add(2 * time.Minute).Unix()}, false},
+		{"nbf within skew", "", map[string]interface{}{"nbf": testNow.Add(30 * time.Second).Unix()}, true},
+		{"no subject", "", map[string]interface{}{"sub": nil}, false},
+		{"audience string", "draymaster", map[string]interface{}{"aud": "draymaster"}, true},
+		{"audience list", "draymaster", map[string]interface{}{"aud": []string{"portal", "draymaster"}}, true},
+		{"wrong audience", "draymaster", map[string]interface{}{"aud": "portal"}, false},
+		{"missing audience", "draymaster", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(config.AuthConfig{JWTSecret: string(secret), OIDCAudience: tt.audience, ClockSkew: time.Minute})
+			_, err := v.Verify(context.Background(), signToken(t, hs256, claims(tt.claims), secret))
+			if tt.valid && err != nil {
+				t.Errorf("Verify() error = %v, want valid", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIssuerKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iss := newTestIssuer(t)
+	iss.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+	issued := claims(map[string]interface{}{"iss": iss.server.URL, "aud": "draymaster"})
+	rsaPublic, _ := json.Marshal(rsaJWK("rsa-1", rsaKey))
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, issued, rsaKey), true},
+		{"ES256", signToken(t, map[string]interface{}{"alg": "ES256", "kid": "ec-1"}, issued, ecKey), true},
+		{"signed by another key", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, issued, otherRSA), false},
+		{"unknown kid", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-9"}, issued, rsaKey), false},
+		{"ES alg with RSA key", signToken(t, map[string]interface{}{"alg": "ES256", "kid": "rsa-1"}, issued, ecKey), false},
+		{"RS alg with EC key", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "ec-1"}, issued, rsaKey), false},
+		{"HS256 keyed with the public key", signToken(t, map[string]interface{}{"alg": "HS256", "kid": "rsa-1"}, issued, rsaPublic), false},
+		{"other issuer", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"},
+			claims(map[string]interface{}{"iss": "https://evil.example", "aud": "draymaster"}), rsaKey), false},
+	}
+
+	v := newTestVerifier(config.AuthConfig{OIDCIssuer: iss.server.URL, OIDCAudience: "draymaster", JWTSecret: string(rsaPublic)})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if tt.valid && err != nil {
+				t.Errorf("Verify() error = %v, want valid", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iss := newTestIssuer(t)
+	iss.setKeys(rsaJWK("old", oldKey))
+	issued := claims(map[string]interface{}{"iss": iss.server.URL, "exp": testNow.Add(24 * time.Hour).Unix()})
+	oldToken := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "old"}, issued, oldKey)
+	newToken := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "new"}, issued, newKey)
+
+	now := testNow
+	v := newTestVerifier(config.AuthConfig{OIDCIssuer: iss.server.URL})
+	v.now = func() time.Time { return now }
+	verify := func(token string) error {
+		_, err := v.Verify(context.Background(), token)
+		return err
+	}
+
+	if err := verify(oldToken); err != nil {
+		t.Fatalf("old key before rotation: %v", err)
+	}
+
+	// The issuer rotates; an unknown kid is not refetched more than once a minute
+	iss.setKeys(rsaJWK("old", oldKey), rsaJWK("new", newKey))
+	if err := verify(newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("new key within a minute of the last fetch: error = %v, want ErrInvalidToken", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := verify(newToken); err != nil {
+		t.Errorf("new key after refetch: %v", err)
+	}
+
+	// The old key is retired; cached keys are kept until JWKSRefresh
+	iss.setKeys(rsaJWK("new", newKey))
+	if err := verify(oldToken); err != nil {
+		t.Errorf("old key still cached: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := verify(oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("retired key after refresh: error = %v, want ErrInvalidToken", err)
+	}
+
+	// A cached key keeps working while the issuer is down; a new one cannot be fetched
+	iss.setDown(true)
+	now = now.Add(2 * time.Hour)
+	if err := verify(newToken); err != nil {
+		t.Errorf("cached key with issuer down: %v", err)
+	}
+	if err := verify(oldToken); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown key with issuer down: error = %v, want a fetch error", err)
+	}
+}
+
+func TestJSONWebKeyPublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := ecJWK("ec", ecKey)
+	offCurve.Y = b64(big.NewInt(7).Bytes())
+
+	tests := []struct {
+		name string
+		jwk  jsonWebKey
+		ok   bool
+	}{
+		{"EC P-256", ecJWK("ec", ecKey), true},
+		{"point off curve", offCurve, false},
+		{"unsupported curve", jsonWebKey{Kty: "EC", Crv: "secp256k1"}, false},
+		{"unsupported type", jsonWebKey{Kty: "oct"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.jwk.publicKey()
+			if tt.ok && (err != nil || key == nil) {
+				t.Errorf("publicKey() = %v, %v, want a key", key, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("publicKey() = %v, want error", key)
+			}
+		})
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		claim interface{}
+		want  []Role
+	}{
+		{"list", []interface{}{"Dispatcher", "billing"}, []Role{RoleDispatcher, RoleBilling}},
+		{"space separated", "driver admin", []Role{RoleDriver, RoleAdmin}},
+		{"comma separated", "dispatcher, billing", []Role{RoleDispatcher, RoleBilling}},
+		{"missing", nil, []Role{}},
+		{"wrong type", json.Number("1"), []Role{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRoles(tt.claim)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseRoles(%v) = %v, want %v", tt.claim, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseRoles(%v) = %v, want %v", tt.claim, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupClaimNested(t *testing.T) {
+	c := map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"dispatcher"}}}
+	got := parseRoles(lookupClaim(c, "realm_access.roles"))
+	if len(got) != 1 || got[0] != RoleDispatcher {
+		t.Errorf("roles = %v, want [dispatcher]", got)
+	}
+	if lookupClaim(c, "realm_access.roles.name") != nil {
+		t.Error("walking past a list should find nothing")
+	}
+}
@@ -0,0 +1,244 @@
+// Package backfill reprocesses historical rows into new tables - turn times,
+// KPIs, emissions - a chunk at a time. Each chunk is checkpointed, so a run
+// that fails or is interrupted resumes where it stopped. Chunks are sized to
+// a target duration and spaced out, and a run waits while replicas fall
+// behind, to keep the load on the primary database down. Runs execute as
+// background jobs (shared/pkg/jobs), so any replica can pick one up.
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status represents the lifecycle state of a backfill run
+type Status string
+
+const (
+	StatusRunning   Status = "RUNNING"   // Queued or processing chunks
+	StatusPaused    Status = "PAUSED"    // Stopped by an operator; resumable
+	StatusFailed    Status = "FAILED"    // Stopped after repeated chunk failures; resumable
+	StatusCompleted Status = "COMPLETED" // Every row has been processed
+	StatusCancelled Status = "CANCELLED" // Abandoned; a new run starts from the beginning
+)
+
+// Chunk reports what one step of a backfill did
+type Chunk struct {
+	Next string // Cursor after the last row processed, where the next chunk starts
+	Rows int    // Rows processed
+	Done bool   // Nothing remains after Next
+}
+
+// StepFunc processes up to limit rows after cursor, which is empty for the
+// first chunk. A chunk with no rows ends the run. Steps must be idempotent:
+// a chunk whose checkpoint was not saved is processed again on resume.
+type StepFunc func(ctx context.Context, cursor string, limit int) (Chunk, error)
+
+// Definition describes a backfill. Services register their definitions with
+// a Runner at startup.
+type Definition struct {
+	Name        string // Stable identifier, e.g. "trip_turn_times"
+	Description string
+	Step        StepFunc
+	// Count estimates the rows the backfill will process, for progress
+	// reporting. Optional.
+	Count    func(ctx context.Context) (int64, error)
+	Throttle Throttle // DefaultThrottle for any zero field
+}
+
+// Throttle limits how hard a backfill works the primary database. Chunks
+// that take longer than TargetChunkTime are halved down to MinChunkSize;
+// quick ones grow back towards ChunkSize.
+type Throttle struct {
+	ChunkSize         int           // Rows in the first chunk and the most any chunk grows to
+	MinChunkSize      int           // Smallest chunk when the database is slow
+	TargetChunkTime   time.Duration // How long a chunk should take
+	Pause             time.Duration // Wait between chunks
+	MaxReplicationLag time.Duration // Wait while replicas are further behind than this; negative disables
+}
+
+// DefaultThrottle suits backfills that read and write a few tables per row
+func DefaultThrottle() Throttle {
+	return Throttle{
+		ChunkSize:         1000,
+		MinChunkSize:      50,
+		TargetChunkTime:   2 * time.Second,
+		Pause:             250 * time.Millisecond,
+		MaxReplicationLag: 30 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields from DefaultThrottle
+func (t Throttle) withDefaults() Throttle {
+	d := DefaultThrottle()
+	if t.ChunkSize <= 0 {
+		t.ChunkSize = d.ChunkSize
+	}
+	if t.MinChunkSize <= 0 {
+		t.MinChunkSize = d.MinChunkSize
+	}
+	if t.MinChunkSize > t.ChunkSize {
+		t.MinChunkSize = t.ChunkSize
+	}
+	if t.TargetChunkTime <= 0 {
+		t.TargetChunkTime = d.TargetChunkTime
+	}
+	if t.Pause <= 0 {
+		t.Pause = d.Pause
+	}
+	if t.MaxReplicationLag == 0 {
+		t.MaxReplicationLag = d.MaxReplicationLag
+	}
+	return t
+}
+
+// nextChunkSize sizes the next chunk from how long the last one took
+func (t Throttle) nextChunkSize(size int, took time.Duration) int {
+	switch {
+	case took > t.TargetChunkTime:
+		size /= 2
+	case took < t.TargetChunkTime/2:
+		size += size/4 + 1
+	}
+	if size < t.MinChunkSize {
+		size = t.MinChunkSize
+	}
+	if size > t.ChunkSize {
+		size = t.ChunkSize
+	}
+	return size
+}
+
+// Run is one pass of a backfill over the historical data
+type Run struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Status          Status     `json:"status"`
+	Cursor          string     `json:"cursor"` // Checkpoint; the next chunk starts after it
+	RowsProcessed   int64      `json:"rows_processed"`
+	ChunksProcessed int        `json:"chunks_processed"`
+	ChunkSize       int        `json:"chunk_size"` // Size of the next chunk
+	TotalRows       *int64     `json:"total_rows,omitempty"`
+	ActiveTime      Duration   `json:"active_time"` // Spent processing chunks, excluding pauses and waits
+	Failures        int        `json:"failures"`    // Consecutive failed chunks
+	LastError       string     `json:"last_error,omitempty"`
+	StartedBy       string     `json:"started_by"`
+	StartedAt       time.Time  `json:"started_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// Duration is a time.Duration that marshals as seconds
+type Duration time.Duration
+
+// MarshalJSON encodes the duration in seconds
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%.3f", time.Duration(d).Seconds())), nil
+}
+
+// Progress is a run with its completion and pace
+type Progress struct {
+	Run
+	Percent       *float64   `json:"percent,omitempty"` // Of TotalRows, when it is known
+	RowsPerSecond float64    `json:"rows_per_second"`
+	EstimatedEnd  *time.Time `json:"estimated_end,omitempty"`
+}
+
+// progressOf works out a run's completion and pace at now
+func progressOf(run *Run, now time.Time) Progress {
+	p := Progress{Run: *run}
+	if secs := time.Duration(run.ActiveTime).Seconds(); secs > 0 {
+		p.RowsPerSecond = float64(run.RowsProcessed) / secs
+	}
+	if run.Status == StatusCompleted {
+		pct := 100.0
+		p.Percent = &pct
+		return p
+	}
+	if run.TotalRows == nil || *run.TotalRows <= 0 {
+		return p
+	}
+	pct := float64(run.RowsProcessed) / float64(*run.TotalRows) * 100
+	if pct > 100 {
+		pct = 100
+	}
+	p.Percent = &pct
+	if run.Status == StatusRunning && p.RowsPerSecond > 0 && run.RowsProcessed < *run.TotalRows {
+		remaining := float64(*run.TotalRows-run.RowsProcessed) / p.RowsPerSecond
+		end := now.Add(time.Duration(remaining * float64(time.Second)))
+		p.EstimatedEnd = &end
+	}
+	return p
+}
+
+// KeysetCursor builds a cursor for backfills that walk a table in
+// (timestamp, id) order, the usual keyset for historical rows
+func KeysetCursor(at time.Time, id uuid.UUID) string {
+	return at.UTC().Format(time.RFC3339Nano) + "/" + id.String()
+}
+
+// ParseKeysetCursor reads a cursor built by KeysetCursor. The empty cursor
+// of a first chunk parses to zero values.
+func ParseKeysetCursor(cursor string) (time.Time, uuid.UUID, error) {
+	if cursor == "" {
+		return time.Time{}, uuid.Nil, nil
+	}
+	rawTime, rawID, ok := strings.Cut(cursor, "/")
+	if !ok {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid keyset cursor %q", cursor)
+	}
+	at, err := time.Parse(time.RFC3339Nano, rawTime)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid keyset cursor %q: %w", cursor, err)
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid keyset cursor %q: %w", cursor, err)
+	}
+	return at, id, nil
+}
+
+var (
+	// ErrUnknownBackfill is returned for a name no definition is registered under
+	ErrUnknownBackfill = errors.New("unknown backfill")
+	// ErrRunInProgress is returned when starting a backfill that already has
+	// a running, paused or failed run; resume or cancel that one instead
+	ErrRunInProgress = errors.New("backfill already has an unfinished run")
+	// ErrRunNotFound is returned for a run that does not exist
+	ErrRunNotFound = errors.New("backfill run not found")
+	// ErrNotRunning is returned by Store.Checkpoint when the run was paused
+	// or cancelled while the chunk was processed
+	ErrNotRunning = errors.New("backfill run is not running")
+	// ErrInvalidTransition is returned for a status change the run's current
+	// status does not allow
+	ErrInvalidTransition = errors.New("invalid backfill status transition")
+)
+
+// Store persists backfill runs and their checkpoints
+type Store interface {
+	// Create stores a new run, or returns ErrRunInProgress when the
+	// backfill already has an unfinished one
+	Create(ctx context.Context, run *Run) error
+	// Get returns a run, or nil if it does not exist
+	Get(ctx context.Context, id uuid.UUID) (*Run, error)
+	// List returns runs most recent first, for one backfill when name is set
+	List(ctx context.Context, name string, limit int) ([]Run, error)
+	// Checkpoint records a processed chunk and the size of the next one,
+	// completing the run when the chunk is done. It returns ErrNotRunning
+	// without recording anything when the run is no longer running.
+	Checkpoint(ctx context.Context, id uuid.UUID, chunk Chunk, nextChunkSize int, took time.Duration) error
+	// Transition moves a run from one of from to to, returning the updated
+	// run, ErrRunNotFound or ErrInvalidTransition
+	Transition(ctx context.Context, id uuid.UUID, from []Status, to Status) (*Run, error)
+	// RecordFailure counts a failed chunk
+	RecordFailure(ctx context.Context, id uuid.UUID, errMsg string) error
+	// SetTotal records the estimated row count
+	SetTotal(ctx context.Context, id uuid.UUID, total int64) error
+	// ReplicationLag returns how far the furthest replica is behind
+	ReplicationLag(ctx context.Context) (time.Duration, error)
+}
@@ -0,0 +1,187 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// PostgresStore implements Store using PostgreSQL
+type PostgresStore struct {
+	db *database.DB
+}
+
+// NewPostgresStore creates a new PostgreSQL backfill store
+func NewPostgresStore(db *database.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const runColumns = `id, name, status, last_cursor, rows_processed, chunks_processed, chunk_size, total_rows,
+	active_ms, failures, last_error, started_by, started_at, updated_at, completed_at`
+
+// Create stores a new run. The partial unique index on name allows one
+// unfinished run per backfill.
+func (s *PostgresStore) Create(ctx context.Context, run *Run) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`INSERT INTO backfill_runs (
+			id, name, status, last_cursor, rows_processed, chunks_processed, chunk_size,
+			active_ms, failures, last_error, started_by, started_at, updated_at
+		) VALUES ($1, $2, $3, '', 0, 0, $4, 0, 0, '', $5, $6, $6)`,
+		run.ID, run.Name, run.Status, run.ChunkSize, run.StartedBy, run.StartedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrRunInProgress
+		}
+		return fmt.Errorf("failed to create backfill run: %w", err)
+	}
+	return nil
+}
+
+// Get returns a run, or nil if it does not exist
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*Run, error) {
+	run, err := scanRun(s.db.Pool.QueryRow(ctx,
+		`SELECT `+runColumns+` FROM backfill_runs WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill run: %w", err)
+	}
+	return run, nil
+}
+
+// List returns runs most recent first
+func (s *PostgresStore) List(ctx context.Context, name string, limit int) ([]Run, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT `+runColumns+` FROM backfill_runs
+		 WHERE ($1 = '' OR name = $1)
+		 ORDER BY started_at DESC
+		 LIMIT $2`, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backfill runs: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// Checkpoint records a processed chunk while the run is still running
+func (s *PostgresStore) Checkpoint(ctx context.Context, id uuid.UUID, chunk Chunk, nextChunkSize int, took time.Duration) error {
+	status := StatusRunning
+	if chunk.Done {
+		status = StatusCompleted
+	}
+	now := time.Now()
+	tag, err := s.db.Pool.Exec(ctx,
+		`UPDATE backfill_runs SET
+			last_cursor = $2, rows_processed = rows_processed + $3, chunks_processed = chunks_processed + 1,
+			chunk_size = $4, active_ms = active_ms + $5, failures = 0, last_error = '',
+			status = $6, updated_at = $7,
+			completed_at = CASE WHEN $6 = 'COMPLETED' THEN $7 END
+		 WHERE id = $1 AND status = 'RUNNING'`,
+		id, chunk.Next, chunk.Rows, nextChunkSize, took.Milliseconds(), status, now)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint backfill run: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// Transition moves a run between statuses
+func (s *PostgresStore) Transition(ctx context.Context, id uuid.UUID, from []Status, to Status) (*Run, error) {
+	allowed := make([]string, len(from))
+	for i, status := range from {
+		allowed[i] = string(status)
+	}
+	run, err := scanRun(s.db.Pool.QueryRow(ctx,
+		`UPDATE backfill_runs SET status = $3, updated_at = $4,
+			failures = CASE WHEN $3 = 'RUNNING' THEN 0 ELSE failures END
+		 WHERE id = $1 AND status = ANY($2)
+		 RETURNING `+runColumns,
+		id, allowed, to, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update backfill run: %w", err)
+	}
+	if run != nil {
+		return run, nil
+	}
+
+	var exists bool
+	if err := s.db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM backfill_runs WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get backfill run: %w", err)
+	}
+	if !exists {
+		return nil, ErrRunNotFound
+	}
+	return nil, ErrInvalidTransition
+}
+
+// RecordFailure counts a failed chunk
+func (s *PostgresStore) RecordFailure(ctx context.Context, id uuid.UUID, errMsg string) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`UPDATE backfill_runs SET failures = failures + 1, last_error = $2, updated_at = $3
+		 WHERE id = $1`, id, errMsg, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record backfill failure: %w", err)
+	}
+	return nil
+}
+
+// SetTotal records the estimated row count
+func (s *PostgresStore) SetTotal(ctx context.Context, id uuid.UUID, total int64) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`UPDATE backfill_runs SET total_rows = $2, updated_at = $3 WHERE id = $1`, id, total, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set backfill total: %w", err)
+	}
+	return nil
+}
+
+// ReplicationLag reads the replay lag of the furthest streaming replica from
+// the primary. It is zero when there are no replicas.
+func (s *PostgresStore) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT COALESCE(MAX(EXTRACT(EPOCH FROM replay_lag)), 0)::float8 FROM pg_stat_replication`,
+	).Scan(&seconds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read replication lag: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func scanRun(row pgx.Row) (*Run, error) {
+	var r Run
+	var activeMs int64
+	err := row.Scan(
+		&r.ID, &r.Name, &r.Status, &r.Cursor, &r.RowsProcessed, &r.ChunksProcessed, &r.ChunkSize, &r.TotalRows,
+		&activeMs, &r.Failures, &r.LastError, &r.StartedBy, &r.StartedAt, &r.UpdatedAt, &r.CompletedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r.ActiveTime = Duration(time.Duration(activeMs) * time.Millisecond)
+	return &r, nil
+}
@@ -0,0 +1,333 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/jobs"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+const (
+	// JobQueue is the job queue backfill runs are processed on. Give it its
+	// own worker pool with low concurrency and no timeout.
+	JobQueue = "backfill"
+	// JobKind is the job that processes a run's chunks
+	JobKind = "backfill.run"
+	// jobMaxAttempts is how many times a failing run is retried, with the
+	// job queue's backoff, before it is marked failed
+	jobMaxAttempts = 8
+	// lagCheckInterval is how often replication lag is checked while waiting
+	// for replicas to catch up
+	lagCheckInterval = 10 * time.Second
+)
+
+// runPayload is the payload of a backfill job
+type runPayload struct {
+	RunID uuid.UUID `json:"run_id"`
+}
+
+// Runner starts, pauses and resumes backfill runs and processes them as
+// background jobs
+type Runner struct {
+	store  Store
+	queue  *jobs.Queue
+	logger *logger.Logger
+
+	mu          sync.RWMutex
+	definitions map[string]Definition
+}
+
+// NewRunner creates a backfill runner
+func NewRunner(store Store, queue *jobs.Queue, log *logger.Logger) *Runner {
+	return &Runner{
+		store:       store,
+		queue:       queue,
+		logger:      log,
+		definitions: make(map[string]Definition),
+	}
+}
+
+// Register adds a backfill definition
+func (r *Runner) Register(def Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.definitions[def.Name] = def
+}
+
+// Definitions returns the registered backfills by name
+func (r *Runner) Definitions() []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]Definition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Handle registers the runner's job handler with a worker pool for JobQueue
+func (r *Runner) Handle(pool *jobs.WorkerPool) {
+	pool.Handle(JobKind, r.process)
+}
+
+// Start begins a run of the named backfill from the first row
+func (r *Runner) Start(ctx context.Context, name, startedBy string) (*Run, error) {
+	def, ok := r.definition(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackfill, name)
+	}
+
+	now := time.Now()
+	run := &Run{
+		ID:        uuid.New(),
+		Name:      name,
+		Status:    StatusRunning,
+		ChunkSize: def.Throttle.withDefaults().ChunkSize,
+		StartedBy: startedBy,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.store.Create(ctx, run); err != nil {
+		return nil, err
+	}
+	if err := r.enqueue(ctx, run.ID); err != nil {
+		return nil, err
+	}
+
+	r.logger.Infow("Backfill started", "backfill", name, "run_id", run.ID, "started_by", startedBy)
+	return run, nil
+}
+
+// Pause stops a run after the chunk in progress. Resume picks it up from
+// the checkpoint.
+func (r *Runner) Pause(ctx context.Context, runID uuid.UUID) (*Run, error) {
+	run, err := r.store.Transition(ctx, runID, []Status{StatusRunning}, StatusPaused)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Infow("Backfill paused", "backfill", run.Name, "run_id", run.ID, "rows_processed", run.RowsProcessed)
+	return run, nil
+}
+
+// Resume restarts a paused or failed run from its last checkpoint
+func (r *Runner) Resume(ctx context.Context, runID uuid.UUID) (*Run, error) {
+	run, err := r.store.Get(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, ErrRunNotFound
+	}
+	if _, ok := r.definition(run.Name); !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackfill, run.Name)
+	}
+
+	if run, err = r.store.Transition(ctx, runID, []Status{StatusPaused, StatusFailed}, StatusRunning); err != nil {
+		return nil, err
+	}
+	if err := r.enqueue(ctx, run.ID); err != nil {
+		return nil, err
+	}
+	r.logger.Infow("Backfill resumed", "backfill", run.Name, "run_id", run.ID, "cursor", run.Cursor)
+	return run, nil
+}
+
+// Cancel abandons an unfinished run
+func (r *Runner) Cancel(ctx context.Context, runID uuid.UUID) (*Run, error) {
+	run, err := r.store.Transition(ctx, runID, []Status{StatusRunning, StatusPaused, StatusFailed}, StatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Infow("Backfill cancelled", "backfill", run.Name, "run_id", run.ID, "rows_processed", run.RowsProcessed)
+	return run, nil
+}
+
+// Progress returns a run with its completion and pace, or nil if it does
+// not exist
+func (r *Runner) Progress(ctx context.Context, runID uuid.UUID) (*Progress, error) {
+	run, err := r.store.Get(ctx, runID)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	p := progressOf(run, time.Now())
+	return &p, nil
+}
+
+// List returns runs with their progress, most recent first, for one
+// backfill when name is set
+func (r *Runner) List(ctx context.Context, name string, limit int) ([]Progress, error) {
+	runs, err := r.store.List(ctx, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	progress := make([]Progress, len(runs))
+	for i := range runs {
+		progress[i] = progressOf(&runs[i], now)
+	}
+	return progress, nil
+}
+
+func (r *Runner) definition(name string) (Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.definitions[name]
+	return def, ok
+}
+
+// enqueue schedules the job that processes a run. The unique key keeps a
+// run to one job, so resuming a run whose job has not yet noticed the pause
+// leaves that job to carry on.
+func (r *Runner) enqueue(ctx context.Context, runID uuid.UUID) error {
+	_, err := r.queue.Enqueue(ctx, JobKind, runPayload{RunID: runID}, jobs.EnqueueOptions{
+		Queue:       JobQueue,
+		Priority:    jobs.PriorityLow,
+		MaxAttempts: jobMaxAttempts,
+		UniqueKey:   "backfill:" + runID.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue backfill run: %w", err)
+	}
+	return nil
+}
+
+// process is the job handler. It works through chunks from the run's
+// checkpoint until the run completes, is paused or cancelled, or a chunk
+// fails; a failed chunk is retried by the job queue from the checkpoint.
+func (r *Runner) process(ctx context.Context, job *jobs.Job) error {
+	var payload runPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid backfill payload: %w", err))
+	}
+	run, err := r.store.Get(ctx, payload.RunID)
+	if err != nil {
+		return err
+	}
+	if run == nil || run.Status != StatusRunning {
+		return nil
+	}
+	def, ok := r.definition(run.Name)
+	if !ok {
+		err := fmt.Errorf("%w: %q", ErrUnknownBackfill, run.Name)
+		return r.fail(ctx, run, jobs.Permanent(err))
+	}
+	throttle := def.Throttle.withDefaults()
+
+	if run.TotalRows == nil && def.Count != nil {
+		if total, err := def.Count(ctx); err != nil {
+			r.logger.Warnw("Failed to estimate backfill size", "backfill", run.Name, "run_id", run.ID, "error", err)
+		} else if err := r.store.SetTotal(ctx, run.ID, total); err != nil {
+			r.logger.Warnw("Failed to record backfill size", "backfill", run.Name, "run_id", run.ID, "error", err)
+		}
+	}
+
+	cursor, size := run.Cursor, run.ChunkSize
+	if size <= 0 {
+		size = throttle.ChunkSize
+	}
+	for {
+		if err := r.waitForReplicas(ctx, run, throttle); err != nil {
+			return err
+		}
+
+		started := time.Now()
+		chunk, err := def.Step(ctx, cursor, size)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Shutting down or the lease was lost; resume from the checkpoint
+				return err
+			}
+			r.logger.Warnw("Backfill chunk failed",
+				"backfill", run.Name,
+				"run_id", run.ID,
+				"cursor", cursor,
+				"chunk_size", size,
+				"attempt", job.Attempts,
+				"error", err,
+			)
+			if recErr := r.store.RecordFailure(ctx, run.ID, err.Error()); recErr != nil {
+				r.logger.Warnw("Failed to record backfill failure", "run_id", run.ID, "error", recErr)
+			}
+			if job.Attempts >= job.MaxAttempts {
+				return r.fail(ctx, run, err)
+			}
+			return err
+		}
+		took := time.Since(started)
+		if chunk.Rows == 0 {
+			chunk.Done = true
+			chunk.Next = cursor
+		}
+
+		next := throttle.nextChunkSize(size, took)
+		if err := r.store.Checkpoint(ctx, run.ID, chunk, next, took); err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				r.logger.Infow("Backfill stopped", "backfill", run.Name, "run_id", run.ID, "cursor", cursor)
+				return nil
+			}
+			return err
+		}
+		if chunk.Done {
+			r.logger.Infow("Backfill completed", "backfill", run.Name, "run_id", run.ID)
+			return nil
+		}
+		cursor, size = chunk.Next, next
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(throttle.Pause):
+		}
+	}
+}
+
+// fail marks a run failed so it waits for an operator to resume it
+func (r *Runner) fail(ctx context.Context, run *Run, cause error) error {
+	if _, err := r.store.Transition(ctx, run.ID, []Status{StatusRunning}, StatusFailed); err != nil && !errors.Is(err, ErrInvalidTransition) {
+		r.logger.Errorw("Failed to mark backfill failed", "run_id", run.ID, "error", err)
+	}
+	r.logger.Errorw("Backfill failed", "backfill", run.Name, "run_id", run.ID, "error", cause)
+	return jobs.Permanent(cause)
+}
+
+// waitForReplicas holds off the next chunk while replicas are further
+// behind than the throttle allows. A lag that cannot be read does not block
+// the backfill.
+func (r *Runner) waitForReplicas(ctx context.Context, run *Run, throttle Throttle) error {
+	if throttle.MaxReplicationLag < 0 {
+		return nil
+	}
+	for {
+		lag, err := r.store.ReplicationLag(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.logger.Warnw("Failed to check replication lag", "backfill", run.Name, "error", err)
+			return nil
+		}
+		if lag <= throttle.MaxReplicationLag {
+			return nil
+		}
+
+		r.logger.Infow("Backfill waiting for replicas",
+			"backfill", run.Name,
+			"run_id", run.ID,
+			"replication_lag", lag.String(),
+			"max_lag", throttle.MaxReplicationLag.String(),
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lagCheckInterval):
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package chatops posts operational alerts - failed trips, containers at
+// risk of going past their last free day, inbound vessels running early or
+// late, HOS violations and eModal outages - to Slack and Microsoft Teams channels through incoming webhooks. Each
+// event type is routed to its own channels and rate limited per channel so
+// an incident does not flood the ops room.
+package chatops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// EventType is the kind of alert, used to route messages to channels
+type EventType string
+
+const (
+	EventTripFailed   EventType = "trip_failed"
+	EventLFDRisk      EventType = "lfd_risk"
+	EventHOSViolation EventType = "hos_violation"
+	EventEModalOutage EventType = "emodal_outage" // Outages and recoveries
+	EventVesselETA    EventType = "vessel_eta"
+)
+
+// Severity colours the message
+type Severity string
+
+const (
+	SeverityInfo     Severity = "INFO"
+	SeverityWarning  Severity = "WARNING"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+// Provider is the chat service a channel posts to
+type Provider string
+
+const (
+	ProviderSlack Provider = "slack"
+	ProviderTeams Provider = "teams"
+)
+
+// Field is a labelled value shown with the message
+type Field struct {
+	Label string
+	Value string
+}
+
+// Link is a button that opens the app where ops can act on the alert
+type Link struct {
+	Label string
+	URL   string
+}
+
+// Message is an alert to post
+type Message struct {
+	Event    EventType
+	Severity Severity
+	Title    string
+	Text     string
+	Fields   []Field
+	Links    []Link
+	Key      string // Identifies the subject, e.g. a trip or container; repeats inside the rate window are dropped
+}
+
+// Channel is a Slack or Teams incoming webhook
+type Channel struct {
+	Name       string
+	Provider   Provider
+	WebhookURL string
+}
+
+// poster delivers a message to one channel
+type poster interface {
+	post(ctx context.Context, ch Channel, msg Message) error
+}
+
+// ParseChannels reads channels as "name=slack:URL,name=teams:URL"
+func ParseChannels(spec string) ([]Channel, error) {
+	var channels []Channel
+	for _, entry := range splitList(spec) {
+		name, target, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("chatops: channel %q is not name=provider:url", entry)
+		}
+		provider, url, ok := strings.Cut(target, ":")
+		if !ok || url == "" {
+			return nil, fmt.Errorf("chatops: channel %q is not name=provider:url", entry)
+		}
+		p := Provider(strings.ToLower(strings.TrimSpace(provider)))
+		if p != ProviderSlack && p != ProviderTeams {
+			return nil, fmt.Errorf("chatops: channel %q has unknown provider %q", name, provider)
+		}
+		channels = append(channels, Channel{
+			Name:       strings.TrimSpace(name),
+			Provider:   p,
+			WebhookURL: strings.TrimSpace(url),
+		})
+	}
+	return channels, nil
+}
+
+// ParseRoutes reads routes as "event=channel|channel,event=channel"
+func ParseRoutes(spec string) (map[EventType][]string, error) {
+	routes := make(map[EventType][]string)
+	for _, entry := range splitList(spec) {
+		event, targets, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("chatops: route %q is not event=channel", entry)
+		}
+		for _, name := range strings.Split(targets, "|") {
+			if name = strings.TrimSpace(name); name != "" {
+				et := EventType(strings.TrimSpace(event))
+				routes[et] = append(routes[et], name)
+			}
+		}
+	}
+	return routes, nil
+}
+
+func splitList(spec string) []string {
+	var out []string
+	for _, s := range strings.Split(spec, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Notifier routes messages to channels and applies rate limits
+type Notifier struct {
+	channels       map[string]Channel
+	routes         map[EventType][]string
+	defaultChannel string
+	limit          int
+	window         time.Duration
+	posters        map[Provider]poster
+	logger         *logger.Logger
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket counts one event type's messages to one channel in the current window
+type bucket struct {
+	start      time.Time
+	sent       int
+	suppressed int
+	keys       map[string]bool
+}
+
+// NewNotifier creates a notifier from config. It returns an error when a
+// route names a channel that is not configured.
+func NewNotifier(cfg config.ChatOpsConfig, log *logger.Logger) (*Notifier, error) {
+	channels, err := ParseChannels(cfg.Channels)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := ParseRoutes(cfg.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		channels:       make(map[string]Channel, len(channels)),
+		routes:         routes,
+		defaultChannel: cfg.DefaultChannel,
+		limit:          cfg.RateLimit,
+		window:         cfg.RateWindow,
+		posters: map[Provider]poster{
+			ProviderSlack: newSlackPoster(),
+			ProviderTeams: newTeamsPoster(),
+		},
+		logger:  log,
+		buckets: make(map[string]*bucket),
+	}
+	for _, ch := range channels {
+		n.channels[ch.Name] = ch
+	}
+	for event, names := range routes {
+		for _, name := range names {
+			if _, ok := n.channels[name]; !ok {
+				return nil, fmt.Errorf("chatops: route %s names unknown channel %q", event, name)
+			}
+		}
+	}
+	if n.defaultChannel != "" {
+		if _, ok := n.channels[n.defaultChannel]; !ok {
+			return nil, fmt.Errorf("chatops: unknown default channel %q", n.defaultChannel)
+		}
+	}
+	return n, nil
+}
+
+// Enabled reports whether any channel is configured
+func (n *Notifier) Enabled() bool {
+	return len(n.channels) > 0
+}
+
+// Notify posts the message to every channel routed for its event type.
+// Messages over a channel's rate limit, and repeats of a key inside the
+// window, are dropped and counted on the next message that goes out. A
+// failed post is logged and does not stop the other channels.
+func (n *Notifier) Notify(ctx context.Context, msg Message) error {
+	names := n.routes[msg.Event]
+	if len(names) == 0 && n.defaultChannel != "" {
+		names = []string{n.defaultChannel}
+	}
+
+	var failed []string
+	for _, name := range names {
+		ch := n.channels[name]
+		out, ok := n.allow(msg, ch.Name)
+		if !ok {
+			continue
+		}
+		if err := n.posters[ch.Provider].post(ctx, ch, out); err != nil {
+			n.logger.Warnw("Failed to post chat-ops message",
+				"channel", ch.Name,
+				"event", msg.Event,
+				"error", err,
+			)
+			failed = append(failed, ch.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("chatops: post to %s failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// allow applies the rate limit for the message's event type on a channel,
+// returning the message to send with any suppressed count noted
+func (n *Notifier) allow(msg Message, channel string) (Message, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	id := string(msg.Event) + "/" + channel
+	b := n.buckets[id]
+	if b == nil || now.Sub(b.start) >= n.window {
+		suppressed := 0
+		if b != nil {
+			suppressed = b.suppressed
+		}
+		b = &bucket{start: now, keys: make(map[string]bool), suppressed: suppressed}
+		n.buckets[id] = b
+	}
+
+	if msg.Key != "" && b.keys[msg.Key] {
+		return msg, false
+	}
+	if n.limit > 0 && b.sent >= n.limit {
+		b.suppressed++
+		return msg, false
+	}
+
+	b.sent++
+	if msg.Key != "" {
+		b.keys[msg.Key] = true
+	}
+	if b.suppressed > 0 {
+		msg.Text += fmt.Sprintf("\n_%d more %s alerts were suppressed by the rate limit._", b.suppressed, msg.Event)
+		b.suppressed = 0
+	}
+	return msg, true
+}
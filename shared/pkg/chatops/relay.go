@@ -0,0 +1,269 @@
+package chatops
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Exception types that mean a trip failed, as published by dispatch-service
+var tripFailedExceptions = map[string]bool{
+	"FAILED_PICKUP":   true,
+	"FAILED_DELIVERY": true,
+}
+
+// Relay turns alert events from the other services into chat-ops messages
+type Relay struct {
+	notifier *Notifier
+	appURL   string
+	logger   *logger.Logger
+}
+
+// NewRelay creates a relay that links messages to the web app at appURL
+func NewRelay(notifier *Notifier, appURL string, log *logger.Logger) *Relay {
+	return &Relay{
+		notifier: notifier,
+		appURL:   strings.TrimRight(appURL, "/"),
+		logger:   log,
+	}
+}
+
+// Topics lists the topics the relay consumes
+func (r *Relay) Topics() []string {
+	return []string{
+		kafka.Topics.ExceptionCreated,
+		kafka.Topics.ContainerLFDRisk,
+		kafka.Topics.VesselETAChanged,
+		kafka.Topics.HOSViolation,
+		kafka.Topics.EModalOutage,
+		kafka.Topics.EModalRecovered,
+	}
+}
+
+// HandleEvent posts the event to its channels. Post failures are logged by
+// the notifier and not returned, so a chat outage does not stall consumers.
+func (r *Relay) HandleEvent(ctx context.Context, event *kafka.Event) error {
+	var msg *Message
+	var err error
+	switch event.Type {
+	case kafka.Topics.ExceptionCreated:
+		msg, err = r.tripFailed(event)
+	case kafka.Topics.ContainerLFDRisk:
+		msg, err = r.lfdRisk(event)
+	case kafka.Topics.VesselETAChanged:
+		msg, err = r.vesselETA(event)
+	case kafka.Topics.HOSViolation:
+		msg, err = r.hosViolation(event)
+	case kafka.Topics.EModalOutage, kafka.Topics.EModalRecovered:
+		msg, err = r.emodalStatus(event)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decode %s event: %w", event.Type, err)
+	}
+	if msg == nil {
+		return nil
+	}
+
+	_ = r.notifier.Notify(ctx, *msg)
+	return nil
+}
+
+func (r *Relay) tripFailed(event *kafka.Event) (*Message, error) {
+	var data struct {
+		ExceptionID string `json:"exception_id"`
+		TripID      string `json:"trip_id"`
+		Type        string `json:"type"`
+		Severity    string `json:"severity"`
+	}
+	if err := event.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	if !tripFailedExceptions[data.Type] {
+		return nil, nil
+	}
+
+	action := "pickup"
+	if data.Type == "FAILED_DELIVERY" {
+		action = "delivery"
+	}
+	return &Message{
+		Event:    EventTripFailed,
+		Severity: SeverityCritical,
+		Title:    "Trip failed: " + action + " not completed",
+		Text:     "A driver reported a failed " + action + ". The trip needs to be rescheduled or recovered.",
+		Fields: []Field{
+			{Label: "Exception", Value: data.Type},
+			{Label: "Severity", Value: data.Severity},
+		},
+		Links: []Link{
+			{Label: "Open trip", URL: r.link("/trips", "id", data.TripID)},
+			{Label: "Open incident", URL: r.link("/incidents", "id", data.ExceptionID)},
+		},
+		Key: data.TripID,
+	}, nil
+}
+
+func (r *Relay) lfdRisk(event *kafka.Event) (*Message, error) {
+	var data kafka.ContainerLFDRiskEvent
+	if err := event.DecodeData(&data); err != nil {
+		return nil, err
+	}
+
+	severity := SeverityWarning
+	if data.HoursRemaining < 12 {
+		severity = SeverityCritical
+	}
+	fields := []Field{
+		{Label: "Container", Value: data.ContainerNumber},
+		{Label: "Last free day", Value: data.LastFreeDay.Format("Mon Jan 2")},
+	}
+	if data.ReferenceNumber != "" {
+		fields = append(fields, Field{Label: "Reference", Value: data.ReferenceNumber})
+	}
+	return &Message{
+		Event:    EventLFDRisk,
+		Severity: severity,
+		Title:    fmt.Sprintf("Container %s runs out of free time in %dh", data.ContainerNumber, data.HoursRemaining),
+		Text:     "The container is still at the terminal. Dispatch it before demurrage starts.",
+		Fields:   fields,
+		Links: []Link{
+			{Label: "Open shipment", URL: r.link("/shipments", "id", data.ShipmentID)},
+			{Label: "Dispatch board", URL: r.link("/dispatch", "", "")},
+		},
+		Key: data.ContainerID + "/" + data.LastFreeDay.Format("2006-01-02"),
+	}, nil
+}
+
+func (r *Relay) vesselETA(event *kafka.Event) (*Message, error) {
+	var data kafka.VesselETAChangedEvent
+	if err := event.DecodeData(&data); err != nil {
+		return nil, err
+	}
+
+	direction, text := "late", "Free time starts later than planned. Review appointments and draft trips booked for the old ETA."
+	if data.DriftHours < 0 {
+		direction, text = "early", "Free time starts sooner than planned. Pull the containers forward before the projected last free day."
+	}
+	containers := 0
+	var earliestLFD time.Time
+	for _, s := range data.Shipments {
+		containers += s.Containers
+		if earliestLFD.IsZero() || s.ProjectedLFD.Before(earliestLFD) {
+			earliestLFD = s.ProjectedLFD
+		}
+	}
+
+	fields := []Field{
+		{Label: "New ETA", Value: data.NewETA.Format("Mon Jan 2 15:04 MST")},
+	}
+	if data.PreviousETA != nil {
+		fields = append(fields, Field{Label: "Was", Value: data.PreviousETA.Format("Mon Jan 2 15:04 MST")})
+	}
+	if data.TerminalName != "" {
+		fields = append(fields, Field{Label: "Terminal", Value: data.TerminalName})
+	}
+	fields = append(fields, Field{Label: "Shipments", Value: fmt.Sprintf("%d (%d containers)", len(data.Shipments), containers)})
+	if !earliestLFD.IsZero() {
+		fields = append(fields, Field{Label: "Projected LFD", Value: earliestLFD.Format("Mon Jan 2")})
+	}
+
+	vessel := data.VesselName
+	if data.VoyageNumber != "" {
+		vessel += " " + data.VoyageNumber
+	}
+	return &Message{
+		Event:    EventVesselETA,
+		Severity: SeverityWarning,
+		Title:    fmt.Sprintf("Vessel %s running %.0fh %s", vessel, math.Abs(data.DriftHours), direction),
+		Text:     text,
+		Fields:   fields,
+		Links: []Link{
+			{Label: "Open shipments", URL: r.link("/shipments", "", "")},
+			{Label: "Demurrage board", URL: r.link("/demurrage", "", "")},
+		},
+		Key: data.VesselName + "/" + data.VoyageNumber + "/" + data.NewETA.Format(time.RFC3339),
+	}, nil
+}
+
+func (r *Relay) hosViolation(event *kafka.Event) (*Message, error) {
+	var data struct {
+		DriverID     string    `json:"driver_id"`
+		ViolationID  string    `json:"violation_id"`
+		Type         string    `json:"type"`
+		OccurredAt   time.Time `json:"occurred_at"`
+		DurationMins int       `json:"duration_mins"`
+		Description  string    `json:"description"`
+	}
+	if err := event.DecodeData(&data); err != nil {
+		return nil, err
+	}
+
+	fields := []Field{
+		{Label: "Violation", Value: data.Type},
+		{Label: "Occurred", Value: data.OccurredAt.Format(time.RFC1123)},
+	}
+	if data.DurationMins > 0 {
+		fields = append(fields, Field{Label: "Over by", Value: fmt.Sprintf("%d min", data.DurationMins)})
+	}
+	return &Message{
+		Event:    EventHOSViolation,
+		Severity: SeverityCritical,
+		Title:    "HOS violation: " + data.Type,
+		Text:     data.Description,
+		Fields:   fields,
+		Links: []Link{
+			{Label: "Open driver", URL: r.link("/drivers", "id", data.DriverID)},
+		},
+		Key: data.ViolationID,
+	}, nil
+}
+
+func (r *Relay) emodalStatus(event *kafka.Event) (*Message, error) {
+	var data kafka.EModalServiceEvent
+	if err := event.DecodeData(&data); err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		Event: EventEModalOutage,
+		Fields: []Field{
+			{Label: "Operation", Value: data.Operation},
+			{Label: "Since", Value: data.Since.Format(time.RFC1123)},
+		},
+		Links: []Link{
+			{Label: "eModal status", URL: r.link("/emodal", "", "")},
+		},
+	}
+	if event.Type == kafka.Topics.EModalRecovered {
+		msg.Severity = SeverityInfo
+		msg.Title = "eModal recovered"
+		msg.Text = fmt.Sprintf("eModal calls are succeeding again after %s.", data.At.Sub(data.Since).Round(time.Minute))
+		msg.Key = "recovered/" + data.Since.Format(time.RFC3339)
+		return msg, nil
+	}
+
+	msg.Severity = SeverityCritical
+	msg.Title = "eModal outage"
+	msg.Text = fmt.Sprintf("%d consecutive eModal calls failed. Container availability and appointments are not updating.", data.ConsecutiveFailures)
+	if data.LastError != "" {
+		msg.Fields = append(msg.Fields, Field{Label: "Last error", Value: data.LastError})
+	}
+	msg.Key = "outage/" + data.Since.Format(time.RFC3339)
+	return msg, nil
+}
+
+// link builds a web app URL, with an optional query parameter
+func (r *Relay) link(path, param, value string) string {
+	if param == "" || value == "" {
+		return r.appURL + path
+	}
+	return r.appURL + path + "?" + url.Values{param: {value}}.Encode()
+}
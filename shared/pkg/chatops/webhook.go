@@ -0,0 +1,133 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds a single post to a chat webhook
+const webhookTimeout = 10 * time.Second
+
+// postJSON sends body to a webhook URL and maps a non-2xx reply to an error
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("webhook status %d: %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	return nil
+}
+
+// slackPoster posts Block Kit messages to Slack incoming webhooks
+type slackPoster struct {
+	client *http.Client
+}
+
+func newSlackPoster() *slackPoster {
+	return &slackPoster{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+var slackEmoji = map[Severity]string{
+	SeverityInfo:     ":large_green_circle:",
+	SeverityWarning:  ":warning:",
+	SeverityCritical: ":rotating_light:",
+}
+
+func (p *slackPoster) post(ctx context.Context, ch Channel, msg Message) error {
+	title := strings.TrimSpace(slackEmoji[msg.Severity] + " *" + msg.Title + "*")
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": title + "\n" + msg.Text}},
+	}
+	if len(msg.Fields) > 0 {
+		var fields []map[string]string
+		for _, f := range msg.Fields {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": "*" + f.Label + "*\n" + f.Value})
+		}
+		// Slack allows at most 10 fields in a section
+		if len(fields) > 10 {
+			fields = fields[:10]
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	if len(msg.Links) > 0 {
+		var buttons []map[string]interface{}
+		for _, l := range msg.Links {
+			buttons = append(buttons, map[string]interface{}{
+				"type": "button",
+				"text": map[string]string{"type": "plain_text", "text": l.Label},
+				"url":  l.URL,
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons})
+	}
+
+	return postJSON(ctx, p.client, ch.WebhookURL, map[string]interface{}{
+		"text":   msg.Title, // Notification fallback
+		"blocks": blocks,
+	})
+}
+
+// teamsPoster posts MessageCards to Microsoft Teams incoming webhooks
+type teamsPoster struct {
+	client *http.Client
+}
+
+func newTeamsPoster() *teamsPoster {
+	return &teamsPoster{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+var teamsColor = map[Severity]string{
+	SeverityInfo:     "2EB886",
+	SeverityWarning:  "DAA038",
+	SeverityCritical: "D40E0D",
+}
+
+func (p *teamsPoster) post(ctx context.Context, ch Channel, msg Message) error {
+	var facts []map[string]string
+	for _, f := range msg.Fields {
+		facts = append(facts, map[string]string{"name": f.Label, "value": f.Value})
+	}
+	var actions []map[string]interface{}
+	for _, l := range msg.Links {
+		actions = append(actions, map[string]interface{}{
+			"@type":   "OpenUri",
+			"name":    l.Label,
+			"targets": []map[string]string{{"os": "default", "uri": l.URL}},
+		})
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Title,
+		"themeColor": teamsColor[msg.Severity],
+		"title":      msg.Title,
+		"sections": []map[string]interface{}{
+			{"text": msg.Text, "facts": facts},
+		},
+	}
+	if len(actions) > 0 {
+		card["potentialAction"] = actions
+	}
+	return postJSON(ctx, p.client, ch.WebhookURL, card)
+}
@@ -1,16 +1,53 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 // BusinessRules contains configurable business rules for the TMS
 type BusinessRules struct {
-	Weight     WeightRules
-	Distance   DistanceRules
-	Time       TimeRules
-	Rates      RateRules
-	Detention  DetentionRules
-	PerDiem    PerDiemRules
-	Demurrage  DemurrageRules
+	Weight       WeightRules
+	Distance     DistanceRules
+	Time         TimeRules
+	Rates        RateRules
+	Detention    DetentionRules
+	PerDiem      PerDiemRules
+	Demurrage    DemurrageRules
+	Emissions    EmissionsRules
+	Scale        ScaleRules
+	Documents    DocumentRules
+	StopLocation StopLocationRules
+	Punctuality  PunctualityRules
+	NoShow       NoShowRules
+	TripWatchdog TripWatchdogRules
+	Dispatchers  DispatcherRules
+	Corridor     CorridorRules
+	Seal         SealRules
+	HOSWarnings  HOSWarningRules
+	PersonalConveyance PersonalConveyanceRules
+	Schedule     ScheduleRules
+	DriverSync   DriverSyncRules
+	YardGate     YardGateRules
+	NextTrip     NextTripRules
+	GPSFusion    GPSFusionRules
+	Cancellation CancellationFeeRule
+	RateConfirmation RateConfirmationRules
+	EmptyReturn  EmptyReturnRules
+	GateDemand   GateDemandRules
+	DataExport   DataExportRules
+	BreakPlanning BreakPlanningRules
+	AxleWeight   AxleWeightRules
+	TripCost     TripCostRules
+	OwnerOperators OwnerOperatorRules
+	GeofenceLearning GeofenceLearningRules
+	PTO          PTORules
+	RateApproval RateApprovalRules
+	VesselTracking VesselTrackingRules
+	TripPlanning TripPlanningRules
+	DualTransaction DualTransactionRules
 }
 
 // WeightRules contains weight-related configuration
@@ -41,6 +78,7 @@ type TimeRules struct {
 	LiveUnloadFreeTimeMins     int           // Free time for live unload
 	DropHookFreeTimeMins       int           // Free time for drop and hook
 	TerminalFreeTimeMins       int           // Free time at terminal gate
+	GroundedPickupExtraMins    int           // Extra terminal turn time when the box is grounded
 }
 
 // RateRules contains rate calculation configuration
@@ -52,6 +90,7 @@ type RateRules struct {
 	OverweightCharge        float64 // Additional charge for overweight
 	ReeferCharge            float64 // Additional charge for reefer
 	TWICRequiredCharge      float64 // Additional charge for TWIC required
+	TWICEscortCharge        float64 // Terminal escort fee for a non-TWIC driver, when the terminal's policy sets none
 	PrePullDiscount         float64 // Discount for pre-pull
 	StreetTurnDiscount      float64 // Discount for street turn
 }
@@ -62,6 +101,8 @@ type DetentionRules struct {
 	RatePerHour             float64 // Detention rate per hour
 	MaxDailyCharge          float64 // Maximum detention charge per day
 	GracePeriodMins         int     // Grace period before charges start
+	WarningLeadMins         int     // Driver and dispatcher are alerted this long before free time runs out
+	ThresholdPcts           []int   // Shares of free time used at which dispatch is alerted, ascending
 }
 
 // PerDiemRules contains per-diem storage charge configuration
@@ -74,6 +115,501 @@ type PerDiemRules struct {
 type DemurrageRules struct {
 	FreeDays                int               // Free days before demurrage starts
 	Rates                   map[string][]TierRate // Rates by container size
+	LFDRiskLeadHours        int                   // Alert ops when an import is still at the terminal this close to its LFD
+	LFDWarningDays          []int                 // Days before the LFD the nightly accrual warns dispatch to pre-pull
+	AccrualHour             int                   // Local hour after which the nightly charge accrual runs
+}
+
+// EmissionsRules contains CO2e emission factors by equipment class
+type EmissionsRules struct {
+	DefaultEquipmentClass string                    // Class used when the tractor fuel type is unknown
+	Factors               map[string]EmissionFactor // Factors keyed by tractor fuel type
+	Methodology           string                    // Source of the factors, included in customer reports
+}
+
+// EmissionFactor represents kilograms of CO2e emitted per mile by load status
+type EmissionFactor struct {
+	LoadedKgPerMile  float64 // Pulling a loaded container
+	EmptyKgPerMile   float64 // Pulling an empty container
+	BobtailKgPerMile float64 // Tractor only or bare chassis
+}
+
+// ScaleRules contains configuration for mandatory scale stops
+type ScaleRules struct {
+	DefaultThresholdLbs         int            // Container gross weight above which a scale stop is required
+	StateThresholdLbs           map[string]int // Stricter thresholds keyed by route state
+	HazardousWasteRequiresScale bool           // Hazardous waste manifests need a certified weight
+	MaxScaleDistanceMiles       float64        // Search radius for a certified scale
+	ScaleStopDurationMins       int            // Planned time at the scale
+}
+
+// ThresholdLbs returns the strictest scale threshold across the route's states
+func (r *ScaleRules) ThresholdLbs(states []string) int {
+	threshold := r.DefaultThresholdLbs
+	for _, state := range states {
+		if t, ok := r.StateThresholdLbs[state]; ok && t < threshold {
+			threshold = t
+		}
+	}
+	return threshold
+}
+
+// StopLocationRules contains tolerances for checking where drivers report
+// arriving at and completing stops
+type StopLocationRules struct {
+	DefaultToleranceMeters float64 // Allowed offset when the location has no geofence
+	GPSBufferMeters        float64 // Added to the geofence radius to absorb GPS drift
+}
+
+// ToleranceMeters returns the allowed offset from a stop location with the
+// given geofence radius (0 when the location has no geofence)
+func (r *StopLocationRules) ToleranceMeters(geofenceRadiusMeters float64) float64 {
+	if geofenceRadiusMeters <= 0 {
+		return r.DefaultToleranceMeters
+	}
+	return geofenceRadiusMeters + r.GPSBufferMeters
+}
+
+// Driver document types subject to expiration enforcement
+const (
+	DocumentTypeCDL         = "CDL"
+	DocumentTypeMedicalCard = "MEDICAL_CARD"
+	DocumentTypeTWIC        = "TWIC"
+	DocumentTypeHazmat      = "HAZMAT"
+)
+
+// DutyRestriction is the limit placed on a driver whose document has expired
+type DutyRestriction string
+
+const (
+	DutyRestrictionWarn         DutyRestriction = "WARN"           // Alert only, driver stays dispatchable
+	DutyRestrictionNonPort      DutyRestriction = "NON_PORT_ONLY"  // No trips into a port terminal
+	DutyRestrictionNoHazmat     DutyRestriction = "NO_HAZMAT"      // No hazmat loads
+	DutyRestrictionOutOfService DutyRestriction = "OUT_OF_SERVICE" // No trips at all
+)
+
+// DocumentEnforcement is the enforcement tier for one document type
+type DocumentEnforcement struct {
+	GraceDays   int             // Days after expiration that only warn before the restriction applies
+	Restriction DutyRestriction // Restriction once the grace period has passed
+}
+
+// DocumentRules contains driver document expiration enforcement tiers
+type DocumentRules struct {
+	Enforcement map[string]DocumentEnforcement // Keyed by document type; unlisted types only warn
+}
+
+// Restrictions evaluates document expirations and returns the document types
+// responsible for each restriction in effect
+func (r *DocumentRules) Restrictions(expirations map[string]*time.Time, now time.Time) map[DutyRestriction][]string {
+	restrictions := make(map[DutyRestriction][]string)
+	for docType, expiration := range expirations {
+		if expiration == nil || !expiration.Before(now) {
+			continue
+		}
+		tier, ok := r.Enforcement[docType]
+		if !ok {
+			tier.Restriction = DutyRestrictionWarn
+		}
+		restriction := tier.Restriction
+		if now.Before(expiration.AddDate(0, 0, tier.GraceDays)) {
+			restriction = DutyRestrictionWarn
+		}
+		restrictions[restriction] = append(restrictions[restriction], docType)
+	}
+	for _, docTypes := range restrictions {
+		sort.Strings(docTypes)
+	}
+	return restrictions
+}
+
+// GeofenceLearningRules contains thresholds for learning stop locations'
+// coordinates and geofence radii from where drivers reported arriving at and
+// completing stops
+type GeofenceLearningRules struct {
+	LookbackDays        int     // Stop positions read for each location
+	ClusterRadiusMeters float64 // Positions this close to one another count as the same spot
+	MinFixes            int     // Positions a location needs before anything is proposed
+	MinClusterShare     float64 // Share of positions the main cluster must hold; below it the location is ambiguous
+	MinShiftMeters      float64 // Coordinates are only corrected when the cluster center is this far off
+	RadiusPercentile    float64 // Share of clustered positions the proposed geofence must contain
+	RadiusBufferMeters  float64 // Added to the percentile distance to absorb GPS drift
+	MinRadiusMeters     float64
+	MaxRadiusMeters     float64
+	MinRadiusChange     float64 // Radius is only proposed when it differs from the current one by this share
+}
+
+// PTORules contains company drivers' paid time off accrual and payout
+// configuration. Owner-operators do not accrue PTO.
+type PTORules struct {
+	AccrualPerHourWorked float64 // PTO hours earned per hour on completed trips
+	MaxBalanceHours      float64 // Accrual stops once the balance reaches this
+	HoursPerDay          float64 // PTO hours drawn for each weekday off
+	PayRatePerHour       float64 // Paid out per PTO hour unless approval sets the driver's rate
+	MinNoticeDays        int     // Vacation must be requested this far ahead; sick time is exempt
+}
+
+// RateApprovalRules contains the thresholds at which a quoted or spot rate
+// needs a manager's approval before its order can be made ready
+type RateApprovalRules struct {
+	MaxDeviationPercent float64 // Deviation from the contract linehaul, either way, allowed without approval
+	MinDeviationAmount  float64 // Smaller differences never need approval, whatever the percentage
+	RequireForNoContract bool   // Quoted rates for lanes without a contract rate need approval
+}
+
+// VesselTrackingRules contains thresholds for refining inbound vessel ETAs
+// from live AIS positions
+type VesselTrackingRules struct {
+	ETADriftHours       float64 // Refined ETA must differ from the shipment's by this much before it is updated and planners alerted
+	MinSpeedKnots       float64 // Slower vessels are at anchor or drifting; their broadcast ETA is used instead
+	ArrivalRadiusNM     float64 // Vessels this close to the terminal are arriving
+	PortApproachHours   float64 // Pilotage and berthing added to the sailing time
+	MaxPositionAgeHours int     // Older AIS positions are not used
+	LookaheadDays       int     // Vessels due within this many days are tracked
+}
+
+// PunctualityRules contains terminal appointment punctuality analytics thresholds
+type PunctualityRules struct {
+	Timezone           string  // IANA zone appointment slots are bucketed in
+	EarlyToleranceMins int     // Arrivals this far before the window still count as on time
+	LookbackDays       int     // History analysed when refreshing booking preferences
+	MinSlotSamples     int     // Appointments needed before a slot is judged
+	CongestedLateRate  float64 // Share of late or missed appointments that marks a slot congested
+	CongestedTurnMins  int     // Average arrival-to-completion time that marks a slot congested
+	PreferredSlots     int     // Best-performing slots recommended per terminal
+}
+
+// NoShowRules contains thresholds for detecting missed terminal appointments
+type NoShowRules struct {
+	GraceMins        int // Minutes after the window closes before an appointment with no arrival is a no-show
+	EarlyArrivalMins int // Gate or geofence arrivals this far before the window still count as keeping it
+	LookbackHours    int // How far back closed windows are scanned, covering monitor downtime
+}
+
+// TripWatchdogRules contains thresholds for detecting in-progress trips that
+// have gone quiet, usually because the driver never completed a stop
+type TripWatchdogRules struct {
+	StaleAfterMins      int  // Quiet minutes before the driver and dispatcher are prompted
+	ReviewAfterMins     int  // Minutes after the prompt before the trip is auto-completed or flagged for review
+	AutoCompleteArrived bool // Auto-complete trips whose remaining stops were all arrived at but never departed
+}
+
+// DispatcherAssignmentMode selects how new trips are spread across dispatchers
+type DispatcherAssignmentMode string
+
+const (
+	DispatcherAssignmentRoundRobin DispatcherAssignmentMode = "ROUND_ROBIN" // Every active dispatcher in turn
+	DispatcherAssignmentTerminal   DispatcherAssignmentMode = "TERMINAL"    // In turn among dispatchers covering the pickup terminal
+)
+
+// DispatcherRules contains configuration for assigning trips to dispatchers
+type DispatcherRules struct {
+	AssignmentMode        DispatcherAssignmentMode
+	DefaultMaxActiveTrips int // Capacity for dispatchers without their own limit
+}
+
+// CorridorRules contains defaults for monitoring high-value and hazmat loads
+// against their planned route
+type CorridorRules struct {
+	BufferMeters     float64 // Allowed distance from the planned route
+	MaxDeviationMins int     // Minutes outside the buffer before dispatch is alerted
+}
+
+// SealRules contains configuration for verifying container seals at loaded pickups
+type SealRules struct {
+	RequireVerification bool // Loaded pickups cannot be completed until the seal is verified or a mismatch is cleared
+	RequirePhoto        bool // Drivers must attach a photo of the seal they observed
+}
+
+// HOSWarningRules contains configuration for warning drivers before their
+// driving or duty window closes
+type HOSWarningRules struct {
+	ThresholdsMins []int // Minutes remaining at which a warning is pushed, each sent once per window
+}
+
+// PersonalConveyanceRules contains the company's personal conveyance policy.
+// The FMCSA sets no time limit, so going over the allowance is a policy
+// warning, not an HOS violation.
+type PersonalConveyanceRules struct {
+	MaxMinsPerDay int // Daily allowance before the driver and safety are warned; 0 turns the check off
+}
+
+// ScheduleRules contains configuration for detecting overlapping driver
+// assignments and holding drivers for future trips
+type ScheduleRules struct {
+	ConflictBufferMins    int // Minimum gap between the end of one trip and the start of the next
+	DefaultTripMins       int // Window assumed for trips planned without an end time or duration
+	AllocationHorizonDays int // How many days ahead a driver may be soft-allocated
+}
+
+// DriverSyncRules contains limits for actions the driver app queues while
+// offline and uploads later
+type DriverSyncRules struct {
+	MaxClockSkewMins int // Client times further ahead of the server are replaced with the receive time
+	MaxOfflineHours  int // Actions performed longer ago than this are rejected
+	MaxBatchActions  int // Actions accepted in one upload
+}
+
+// YardGateRules contains configuration for gate events recorded at company yards
+type YardGateRules struct {
+	MaxBackdateHours    int  // How long after the fact a gate clerk may record an event
+	RequireDamagePhotos bool // Damaged equipment must be photographed at the gate
+}
+
+// NextTripRules contains configuration for suggesting a driver's next trip
+type NextTripRules struct {
+	MaxSuggestions   int     // Trips returned per driver, nearest first
+	MaxDeadheadMiles float64 // Trips picking up farther away are not suggested
+	LookaheadHours   int     // Unassigned trips planned to start within this are considered
+	PushOnCompletion bool    // Push suggestions to dispatchers when a driver finishes a trip
+}
+
+// TripPlanningRules contains the limits and scoring weights for planning the
+// day's unassigned trips across the available drivers
+type TripPlanningRules struct {
+	MaxDeadheadMiles      float64 // Drivers are not sent farther than this empty to a pickup
+	MaxTripsPerDriver     int     // Trips chained onto one driver in a plan
+	EmptyMileCost         float64 // Score per empty mile driven to a pickup
+	LateMinuteCost        float64 // Score per minute arriving past an appointment window
+	MissedAppointmentCost float64 // Score for each appointment window missed, on top of the late minutes
+	WaitMinuteCost        float64 // Score per minute waiting for an appointment to open
+}
+
+// DualTransactionRules contains configuration for pairing empty returns
+// with import pickups at the same terminal, so one gate visit drops the
+// empty and takes the load out on the same chassis
+type DualTransactionRules struct {
+	WindowMins         int     // Empty return and pickup planned at most this far apart
+	LookaheadHours     int     // Moves planned this far ahead are matched
+	GateVisitSavings   float64 // Turn time, gate fees and bobtail miles saved by one visit instead of two
+	ChassisFlipSavings float64 // Saved when the empty's chassis can carry the load out
+	MaxResults         int
+}
+
+// GPSFusionRules contains configuration for merging positions reported by
+// more than one source (driver app, ELD, tractor telematics) into one track
+type GPSFusionRules struct {
+	DuplicateMeters      float64            // Points this close to the last accepted one...
+	DuplicateSecs        int                // ...and this close in time are duplicates
+	SourceStaleSecs      int                // A source silent this long hands the track to the next one reporting
+	SwitchMarginMeters   float64            // How much more accurate a source must be to take over a live track
+	SourceAccuracyMeters map[string]float64 // Assumed accuracy for points reported without one
+}
+
+// EmptyReturnRules contains the cost model for deciding what to do with an
+// empty import: return it now, hold it for a street turn or stage it in a yard
+type EmptyReturnRules struct {
+	PerDiemFreeDays        int                   // Steamship line free days from the terminal out-gate
+	PerDiemRates           map[string][]TierRate // Steamship line per-diem by container size, days counted from out-gate
+	CostPerMile            float64               // Truck operating cost, loaded or empty
+	ChassisDailyRate       float64               // Chassis rent while the empty stays mounted
+	YardLiftFee            float64               // Per lift; staging grounds the empty and remounts it later
+	YardStorageDailyRate   float64
+	StreetTurnFee          float64 // Steamship line fee to approve a street turn
+	MaxStreetTurnHoldDays  int     // Export bookings picking up later than this are not worth holding for
+	AppointmentHorizonDays int     // Return appointments are searched this far ahead
+}
+
+// GateDemandRules contains thresholds for forecasting which terminal gate
+// slots will sell out and warning planners to book them early
+type GateDemandRules struct {
+	Timezone         string  // IANA zone slots are bucketed in by weekday and hour
+	LookbackDays     int     // Closed slots analysed for each forecast
+	MinSamples       int     // Past slots of a weekday/hour needed before it is forecast
+	HighDemandRate   float64 // Share of past slots that sold out marking a window high demand
+	BookEarlyHours   int     // Planners are warned this long before a window usually sells out
+	WarnHorizonHours int     // Unbooked terminal stops are checked this far ahead
+}
+
+// DataExportRules contains configuration for streaming order-scoped
+// telemetry to customer data lakes
+type DataExportRules struct {
+	BatchSize        int    // Records written per S3 object or Kafka batch
+	MaxBatchesPerRun int    // Batches per customer and record type on each run
+	SettleSeconds    int    // Rows newer than this are left for the next run so late commits are not skipped
+	TopicPrefix      string // Customer Kafka topics must start with this
+}
+
+// BreakPlanningRules contains configuration for predicting when a driver's
+// 30-minute break falls due on a trip and suggesting where to take it
+type BreakPlanningRules struct {
+	DailyDriveLimitMins int     // Driving allowed per shift, to estimate how much the driver has done
+	BreakAfterDriveMins int     // Driving allowed before a 30-minute break is required
+	BreakDurationMins   int     // Consecutive time off the wheel that counts as the break
+	LeadMins            int     // Suggestions are searched this much driving before the break falls due
+	SearchRadiusMiles   float64 // Search radius for truck stops and rest areas
+	MaxSuggestions      int     // Locations offered for each break
+	TakenRadiusMiles    float64 // A stop this close to a suggestion counts as taken there
+}
+
+// AxleLimits are the legal loads for each axle group of a tractor and chassis
+type AxleLimits struct {
+	SteerLbs  int // Tractor steer axle
+	SingleLbs int // Single drive or chassis axle
+	TandemLbs int // Two-axle group
+	TridemLbs int // Three-axle group
+	GrossLbs  int // Whole combination
+}
+
+// GroupLbs returns the limit for a group of the given number of axles
+func (l AxleLimits) GroupLbs(axles int) int {
+	switch axles {
+	case 1:
+		return l.SingleLbs
+	case 2:
+		return l.TandemLbs
+	default:
+		return l.TridemLbs
+	}
+}
+
+// ChassisProfile describes a chassis type for axle weight estimation.
+// Distances are measured rearward from the kingpin.
+type ChassisProfile struct {
+	TareLbs        int                // Empty chassis weight
+	KingpinTareLbs int                // Part of the tare carried on the kingpin
+	Axles          int                // Axles in the rear group
+	AxleCenterFt   float64            // Kingpin to the center of the rear axle group
+	LoadCenterFt   map[string]float64 // Kingpin to the container's center, by the container sizes it carries
+}
+
+// TractorProfile describes a tractor axle configuration for axle weight estimation
+type TractorProfile struct {
+	SteerTareLbs int     // Bobtail weight on the steer axle
+	DriveTareLbs int     // Bobtail weight on the drive axles
+	DriveAxles   int     // 1 for a single drive axle, 2 for tandem drives
+	WheelbaseFt  float64 // Steer axle to the center of the drive group
+	FifthWheelFt float64 // Fifth wheel ahead of the drive group center
+}
+
+// AxleWeightRules contains the tractor and chassis profiles used to estimate
+// axle group loads and the legal limits they are checked against
+type AxleWeightRules struct {
+	DefaultLimits  AxleLimits                // Federal limits
+	StateLimits    map[string]AxleLimits     // Keyed by route state; zero fields fall back to the default
+	Chassis        map[string]ChassisProfile // Keyed by "<size>_<type>" as recorded on the chassis
+	DefaultChassis map[string]string         // Chassis assumed by container size when none is assigned
+	Tractors       map[string]TractorProfile // Keyed by tractor axle config
+	DefaultTractor string                    // Axle config assumed when no tractor is assigned
+}
+
+// Limits returns the axle limits in a state
+func (r *AxleWeightRules) Limits(state string) AxleLimits {
+	limits := r.DefaultLimits
+	override, ok := r.StateLimits[state]
+	if !ok {
+		return limits
+	}
+	if override.SteerLbs > 0 {
+		limits.SteerLbs = override.SteerLbs
+	}
+	if override.SingleLbs > 0 {
+		limits.SingleLbs = override.SingleLbs
+	}
+	if override.TandemLbs > 0 {
+		limits.TandemLbs = override.TandemLbs
+	}
+	if override.TridemLbs > 0 {
+		limits.TridemLbs = override.TridemLbs
+	}
+	if override.GrossLbs > 0 {
+		limits.GrossLbs = override.GrossLbs
+	}
+	return limits
+}
+
+// TripCostRules contains the cost model used to accrue a trip's cost while
+// it runs and grade its margin on the dispatch board
+type TripCostRules struct {
+	DriverRatePerHour  float64 // Driver pay and burden per hour on the trip, waiting included
+	FuelPricePerGallon float64
+	MilesPerGallon     float64
+	OtherCostPerMile   float64 // Tires, maintenance and insurance
+	ThinMarginPct      float64 // Margins below this share of revenue are flagged thin
+}
+
+// FuelCostPerMile returns the fuel estimate for one mile
+func (r *TripCostRules) FuelCostPerMile() float64 {
+	if r.MilesPerGallon <= 0 {
+		return 0
+	}
+	return r.FuelPricePerGallon / r.MilesPerGallon
+}
+
+// Insurance coverage types carried by owner-operators
+const (
+	CoverageAutoLiability        = "AUTO_LIABILITY"
+	CoverageCargo                = "CARGO"
+	CoveragePhysicalDamage       = "PHYSICAL_DAMAGE"
+	CoverageNonTruckingLiability = "NON_TRUCKING_LIABILITY" // Bobtail; a leased-on truck off dispatch
+	CoverageOccupationalAccident = "OCCUPATIONAL_ACCIDENT"
+)
+
+// OwnerOperatorRules contains the insurance owner-operators must carry to be
+// dispatched and when their authority and certificates are rechecked
+type OwnerOperatorRules struct {
+	OwnAuthorityMinimums map[string]float64 // Required coverage and minimum limit for carriers running under their own authority
+	LeasedOnMinimums     map[string]float64 // Required coverage and minimum limit for trucks leased on to our authority
+	ExpirationAlertDays  []int              // Alerts go out this many days before a certificate expires, largest first
+	ReverifyAfterHours   int                // Provider verifications older than this are refreshed
+}
+
+// RateConfirmationRules contains the defaults printed on rate confirmations
+// for brokered loads
+type RateConfirmationRules struct {
+	ChargeDescription string // Line the agreed rate is printed under
+	PaymentTerms      string
+	Terms             string // Conditions printed above the signature lines
+	SignatureSubject  string // E-signature email subject, %s is the confirmation number
+}
+
+// CancellationOrigin records who called off an order or trip
+type CancellationOrigin string
+
+const (
+	CancellationByCustomer CancellationOrigin = "CUSTOMER"
+	CancellationByCarrier  CancellationOrigin = "CARRIER"
+)
+
+// CancellationFeeType selects how a customer cancellation is charged
+type CancellationFeeType string
+
+const (
+	CancellationFeeFlat   CancellationFeeType = "FLAT"    // One amount whenever the trip was dispatched
+	CancellationFeeTiered CancellationFeeType = "TIERED"  // Amount grows as the appointment gets closer
+	CancellationFeeDryRun CancellationFeeType = "DRY_RUN" // Charged as a dry run
+)
+
+// CancellationFeeRule contains the fee charged when a customer cancels a
+// trip after it was dispatched
+type CancellationFeeRule struct {
+	Type         CancellationFeeType `json:"type"`
+	FlatAmount   float64             `json:"flat_amount"`    // FLAT, and TIERED when there is no appointment
+	Tiers        []CancellationTier  `json:"tiers"`          // TIERED, tightest window first
+	DryRunAmount float64             `json:"dry_run_amount"` // DRY_RUN, and any rule once the driver is on site
+}
+
+// CancellationTier charges Amount when the cancellation comes within
+// WithinHours of the appointment
+type CancellationTier struct {
+	WithinHours float64 `json:"within_hours"`
+	Amount      float64 `json:"amount"`
+}
+
+// CancellationContext is the state of a trip when it was cancelled
+type CancellationContext struct {
+	Dispatched      bool       // The trip had been sent to a driver
+	DriverOnSite    bool       // The driver had arrived at a stop
+	AppointmentTime *time.Time // Next appointment on the trip
+	CancelledAt     time.Time
+}
+
+// CancellationFee is the fee a cancellation incurs and why
+type CancellationFee struct {
+	Amount     float64
+	ChargeType string   // CANCELLATION or DRY_RUN, as billed
+	Basis      string   // What the amount was based on
+	HoursAhead *float64 // Hours between the cancellation and the appointment
 }
 
 // TierRate represents a tiered pricing structure
@@ -109,6 +645,7 @@ func DefaultBusinessRules() *BusinessRules {
 			LiveUnloadFreeTimeMins:     120, // 2 hours for live unload
 			DropHookFreeTimeMins:       30,  // 30 minutes for drop/hook
 			TerminalFreeTimeMins:       60,  // 1 hour at terminal
+			GroundedPickupExtraMins:    45,  // Yard machine has to dig the box out
 		},
 		Rates: RateRules{
 			BaseRatePerMile:      3.50,  // $3.50 per mile base
@@ -118,6 +655,7 @@ func DefaultBusinessRules() *BusinessRules {
 			OverweightCharge:     100.00, // $100 overweight fee
 			ReeferCharge:         75.00,  // $75 reefer fee
 			TWICRequiredCharge:   50.00,  // $50 TWIC required fee
+			TWICEscortCharge:     125.00, // $125 terminal escort per visit
 			PrePullDiscount:      25.00,  // $25 pre-pull discount
 			StreetTurnDiscount:   50.00,  // $50 street turn discount
 		},
@@ -126,6 +664,8 @@ func DefaultBusinessRules() *BusinessRules {
 			RatePerHour:    75.00, // $75 per hour
 			MaxDailyCharge: 600.00, // $600 max per day
 			GracePeriodMins: 15,   // 15-minute grace period
+			WarningLeadMins: 15,   // Alert 15 minutes before detention starts
+			ThresholdPcts:   []int{75, 100}, // Alert at 75% and 100% of free time
 		},
 		PerDiem: PerDiemRules{
 			FreeDays: 5, // 5 free days (typical for drayage company storage)
@@ -148,7 +688,10 @@ func DefaultBusinessRules() *BusinessRules {
 			},
 		},
 		Demurrage: DemurrageRules{
-			FreeDays: 0, // Demurrage starts after Last Free Day (set by SSL)
+			FreeDays:         0, // Demurrage starts after Last Free Day (set by SSL)
+			LFDRiskLeadHours: 24,
+			LFDWarningDays:   []int{2, 1},
+			AccrualHour:      1,
 			Rates: map[string][]TierRate{
 				"20": {
 					{FromDay: 1, ToDay: 5, Rate: 75.00},
@@ -170,6 +713,265 @@ func DefaultBusinessRules() *BusinessRules {
 				},
 			},
 		},
+		Emissions: EmissionsRules{
+			DefaultEquipmentClass: "diesel",
+			Factors: map[string]EmissionFactor{
+				"diesel":      {LoadedKgPerMile: 1.72, EmptyKgPerMile: 1.38, BobtailKgPerMile: 1.18}, // Class 8 day cab
+				"natural_gas": {LoadedKgPerMile: 1.49, EmptyKgPerMile: 1.20, BobtailKgPerMile: 1.03}, // CNG/LNG, well-to-wheel
+				"electric":    {LoadedKgPerMile: 0.78, EmptyKgPerMile: 0.62, BobtailKgPerMile: 0.53}, // Battery electric, grid average
+			},
+			Methodology: "Well-to-wheel CO2e per mile for Class 8 tractors, EPA SmartWay-aligned factors",
+		},
+		Scale: ScaleRules{
+			DefaultThresholdLbs: 44000, // Matches the overweight permit threshold
+			StateThresholdLbs: map[string]int{
+				"CA": 40000, // CHP enforces tighter checks on port drayage
+				"NJ": 42000,
+				"NY": 42000,
+			},
+			HazardousWasteRequiresScale: true,
+			MaxScaleDistanceMiles:       25.0, // Keep the detour inside the drayage zone
+			ScaleStopDurationMins:       20,   // Weigh, print ticket, and re-enter traffic
+		},
+		Documents: DocumentRules{
+			Enforcement: map[string]DocumentEnforcement{
+				DocumentTypeCDL:         {GraceDays: 0, Restriction: DutyRestrictionOutOfService},
+				DocumentTypeMedicalCard: {GraceDays: 0, Restriction: DutyRestrictionOutOfService},
+				DocumentTypeTWIC:        {GraceDays: 0, Restriction: DutyRestrictionNonPort}, // Can still run rail and warehouse moves
+				DocumentTypeHazmat:      {GraceDays: 0, Restriction: DutyRestrictionNoHazmat},
+			},
+		},
+		StopLocation: StopLocationRules{
+			DefaultToleranceMeters: 800, // Roughly half a mile covers large terminals and yards
+			GPSBufferMeters:        150, // Urban canyon and cold-start drift
+		},
+		GeofenceLearning: GeofenceLearningRules{
+			LookbackDays:        180,
+			ClusterRadiusMeters: 75,
+			MinFixes:            5,
+			MinClusterShare:     0.6,
+			MinShiftMeters:      100,
+			RadiusPercentile:    0.9,
+			RadiusBufferMeters:  50,
+			MinRadiusMeters:     100,
+			MaxRadiusMeters:     1500, // Large terminals
+			MinRadiusChange:     0.25,
+		},
+		PTO: PTORules{
+			AccrualPerHourWorked: 0.0385, // About 80 hours a year full time
+			MaxBalanceHours:      120,
+			HoursPerDay:          8,
+			PayRatePerHour:       28.00,
+			MinNoticeDays:        14,
+		},
+		RateApproval: RateApprovalRules{
+			MaxDeviationPercent:  10,
+			MinDeviationAmount:   25.00,
+			RequireForNoContract: true,
+		},
+		VesselTracking: VesselTrackingRules{
+			ETADriftHours:       6,
+			MinSpeedKnots:       3,
+			ArrivalRadiusNM:     15, // Inside the pilot station
+			PortApproachHours:   3,  // Pilot boarding to all fast
+			MaxPositionAgeHours: 12, // Satellite AIS can lag mid-ocean
+			LookaheadDays:       21, // Trans-Pacific sailings
+		},
+		Punctuality: PunctualityRules{
+			Timezone:           "America/Los_Angeles",
+			EarlyToleranceMins: 15,
+			LookbackDays:       56, // Eight weeks of each weekday
+			MinSlotSamples:     8,
+			CongestedLateRate:  0.35,
+			CongestedTurnMins:  90,
+			PreferredSlots:     5,
+		},
+		NoShow: NoShowRules{
+			GraceMins:        60, // Gate transactions can post to the terminal system late
+			EarlyArrivalMins: 120,
+			LookbackHours:    48,
+		},
+		TripWatchdog: TripWatchdogRules{
+			StaleAfterMins:      180, // Longer than a slow terminal turn plus detention
+			ReviewAfterMins:     60,
+			AutoCompleteArrived: true,
+		},
+		Dispatchers: DispatcherRules{
+			AssignmentMode:        DispatcherAssignmentTerminal,
+			DefaultMaxActiveTrips: 40,
+		},
+		Corridor: CorridorRules{
+			BufferMeters:     1000, // Covers parallel surface streets and GPS drift
+			MaxDeviationMins: 5,
+		},
+		Seal: SealRules{
+			RequireVerification: true,
+			RequirePhoto:        true,
+		},
+		HOSWarnings: HOSWarningRules{
+			ThresholdsMins: []int{120, 60, 30},
+		},
+		PersonalConveyance: PersonalConveyanceRules{
+			MaxMinsPerDay: 60,
+		},
+		Schedule: ScheduleRules{
+			ConflictBufferMins:    15,
+			DefaultTripMins:       240, // Typical local dray turn
+			AllocationHorizonDays: 14,
+		},
+		DriverSync: DriverSyncRules{
+			MaxClockSkewMins: 5,
+			MaxOfflineHours:  72, // Covers a weekend without coverage
+			MaxBatchActions:  200,
+		},
+		YardGate: YardGateRules{
+			MaxBackdateHours:    24,
+			RequireDamagePhotos: true,
+		},
+		NextTrip: NextTripRules{
+			MaxSuggestions:   5,
+			MaxDeadheadMiles: 50,
+			LookaheadHours:   8,
+			PushOnCompletion: true,
+		},
+		TripPlanning: TripPlanningRules{
+			MaxDeadheadMiles:      60,
+			MaxTripsPerDriver:     6,
+			EmptyMileCost:         1.0,
+			LateMinuteCost:        0.5,
+			MissedAppointmentCost: 150, // About the empty miles worth driving to save a reschedule
+			WaitMinuteCost:        0.05,
+		},
+		DualTransaction: DualTransactionRules{
+			WindowMins:         120, // Terminals hold a dual appointment pair to about two hours
+			LookaheadHours:     48,
+			GateVisitSavings:   85.00,
+			ChassisFlipSavings: 35.00,
+			MaxResults:         50,
+		},
+		GPSFusion: GPSFusionRules{
+			DuplicateMeters:    15,
+			DuplicateSecs:      10,
+			SourceStaleSecs:    120, // Two missed ELD pings
+			SwitchMarginMeters: 10,
+			SourceAccuracyMeters: map[string]float64{
+				"eld":        10,
+				"telematics": 15,
+				"gps":        20,
+				"mobile":     30, // Phones indoors and in cabs report poorly
+			},
+		},
+		Cancellation: CancellationFeeRule{
+			Type:       CancellationFeeTiered,
+			FlatAmount: 100.00,
+			Tiers: []CancellationTier{
+				{WithinHours: 2, Amount: 175.00},
+				{WithinHours: 24, Amount: 100.00},
+			},
+			DryRunAmount: 250.00,
+		},
+		RateConfirmation: RateConfirmationRules{
+			ChargeDescription: "Drayage linehaul, all-in",
+			PaymentTerms:      "Net 30",
+			Terms:             "Accessorials not listed require written approval before they are incurred.",
+			SignatureSubject:  "Rate confirmation %s for signature",
+		},
+		EmptyReturn: EmptyReturnRules{
+			PerDiemFreeDays: 4,
+			PerDiemRates: map[string][]TierRate{
+				"20": {
+					{FromDay: 5, ToDay: 9, Rate: 75.00},
+					{FromDay: 10, ToDay: 0, Rate: 125.00},
+				},
+				"40": {
+					{FromDay: 5, ToDay: 9, Rate: 100.00},
+					{FromDay: 10, ToDay: 0, Rate: 150.00},
+				},
+				"45": {
+					{FromDay: 5, ToDay: 9, Rate: 125.00},
+					{FromDay: 10, ToDay: 0, Rate: 175.00},
+				},
+			},
+			CostPerMile:            2.25,
+			ChassisDailyRate:       30.00,
+			YardLiftFee:            45.00,
+			YardStorageDailyRate:   20.00,
+			StreetTurnFee:          50.00,
+			MaxStreetTurnHoldDays:  3,
+			AppointmentHorizonDays: 5,
+		},
+		GateDemand: GateDemandRules{
+			Timezone:         "America/Los_Angeles",
+			LookbackDays:     56, // Eight weeks of each weekday
+			MinSamples:       4,
+			HighDemandRate:   0.6,
+			BookEarlyHours:   12,
+			WarnHorizonHours: 72,
+		},
+		DataExport: DataExportRules{
+			BatchSize:        5000,
+			MaxBatchesPerRun: 20,
+			SettleSeconds:    60,
+			TopicPrefix:      "customer-export.",
+		},
+		BreakPlanning: BreakPlanningRules{
+			DailyDriveLimitMins: 660, // 11-hour driving limit
+			BreakAfterDriveMins: 480, // FMCSA 395.3(a)(3)(ii)
+			BreakDurationMins:   30,
+			LeadMins:            30, // Leave room to exit and park before the limit
+			SearchRadiusMiles:   10.0,
+			MaxSuggestions:      3,
+			TakenRadiusMiles:    0.25,
+		},
+		AxleWeight: AxleWeightRules{
+			DefaultLimits: AxleLimits{
+				SteerLbs:  12000, // Typical steer tire rating
+				SingleLbs: 20000, // 23 CFR 658.17
+				TandemLbs: 34000,
+				TridemLbs: 42000, // Bridge formula for a 9 ft spread
+				GrossLbs:  80000,
+			},
+			StateLimits: map[string]AxleLimits{
+				"MI": {SingleLbs: 18000, TandemLbs: 32000}, // Axles spaced under 9 ft
+			},
+			Chassis: map[string]ChassisProfile{
+				"20_standard":   {TareLbs: 5200, KingpinTareLbs: 2100, Axles: 2, AxleCenterFt: 16.5, LoadCenterFt: map[string]float64{"20": 9.0}},
+				"20_tri_axle":   {TareLbs: 6600, KingpinTareLbs: 2300, Axles: 3, AxleCenterFt: 15.0, LoadCenterFt: map[string]float64{"20": 10.5}},
+				"40_standard":   {TareLbs: 6500, KingpinTareLbs: 2600, Axles: 2, AxleCenterFt: 34.5, LoadCenterFt: map[string]float64{"40": 18.5}},
+				"40_tri_axle":   {TareLbs: 7900, KingpinTareLbs: 2800, Axles: 3, AxleCenterFt: 33.5, LoadCenterFt: map[string]float64{"40": 18.5}},
+				"40_gooseneck":  {TareLbs: 6900, KingpinTareLbs: 2700, Axles: 2, AxleCenterFt: 34.5, LoadCenterFt: map[string]float64{"40": 19.0}},
+				"45_extendable": {TareLbs: 8200, KingpinTareLbs: 3100, Axles: 2, AxleCenterFt: 38.0, LoadCenterFt: map[string]float64{"40": 20.0, "45": 21.0}},
+			},
+			DefaultChassis: map[string]string{
+				"20": "20_standard",
+				"40": "40_standard",
+				"45": "45_extendable",
+			},
+			Tractors: map[string]TractorProfile{
+				"tandem": {SteerTareLbs: 10000, DriveTareLbs: 8000, DriveAxles: 2, WheelbaseFt: 15.5, FifthWheelFt: 1.0}, // 6x4 day cab
+				"single": {SteerTareLbs: 8500, DriveTareLbs: 5500, DriveAxles: 1, WheelbaseFt: 12.5, FifthWheelFt: 0.5},  // 4x2 day cab
+			},
+			DefaultTractor: "tandem",
+		},
+		TripCost: TripCostRules{
+			DriverRatePerHour:  32.00,
+			FuelPricePerGallon: 4.10,
+			MilesPerGallon:     6.0, // Loaded day cab in port traffic
+			OtherCostPerMile:   0.45,
+			ThinMarginPct:      15.0,
+		},
+		OwnerOperators: OwnerOperatorRules{
+			OwnAuthorityMinimums: map[string]float64{
+				CoverageAutoLiability: 750000, // FMCSA minimum for general freight
+				CoverageCargo:         100000,
+			},
+			LeasedOnMinimums: map[string]float64{
+				CoverageNonTruckingLiability: 1000000,
+				CoverageOccupationalAccident: 0,
+			},
+			ExpirationAlertDays: []int{30, 14, 7, 1},
+			ReverifyAfterHours:  24,
+		},
 	}
 }
 
@@ -228,6 +1030,52 @@ func (r *DetentionRules) Calculate(actualMins, freeMins int) (float64, int) {
 	return charge, detentionMins
 }
 
+// Calculate returns the fee for a customer cancellation. Nothing is charged
+// before dispatch; once the driver is on site every rule charges a dry run.
+func (r *CancellationFeeRule) Calculate(c CancellationContext) CancellationFee {
+	fee := CancellationFee{ChargeType: "CANCELLATION"}
+	if c.AppointmentTime != nil {
+		hours := c.AppointmentTime.Sub(c.CancelledAt).Hours()
+		fee.HoursAhead = &hours
+	}
+
+	switch {
+	case !c.Dispatched:
+		fee.Basis = "cancelled before dispatch"
+	case c.DriverOnSite || r.Type == CancellationFeeDryRun:
+		fee.ChargeType = "DRY_RUN"
+		fee.Amount = r.DryRunAmount
+		if c.DriverOnSite {
+			fee.Basis = "driver on site"
+		} else {
+			fee.Basis = "dry run rate"
+		}
+	case r.Type == CancellationFeeTiered && fee.HoursAhead != nil:
+		fee.Basis = fmt.Sprintf("%.1f hours before appointment, outside every tier", *fee.HoursAhead)
+		for _, tier := range r.Tiers {
+			if *fee.HoursAhead <= tier.WithinHours {
+				fee.Amount = tier.Amount
+				fee.Basis = fmt.Sprintf("%.1f hours before appointment, within %g hours", *fee.HoursAhead, tier.WithinHours)
+				break
+			}
+		}
+	default:
+		fee.Amount = r.FlatAmount
+		fee.Basis = "flat rate"
+		if r.Type == CancellationFeeTiered {
+			fee.Basis = "flat rate, no appointment"
+		}
+	}
+
+	return fee
+}
+
+// PerDiem returns the steamship line per-diem owed on an empty out the
+// given number of days. High cubes are charged as their base size.
+func (r *EmptyReturnRules) PerDiem(containerSize string, days int) float64 {
+	return CalculateTieredRate(days, r.PerDiemRates[strings.TrimSuffix(strings.ToUpper(containerSize), "HC")])
+}
+
 // GetFreeTime returns appropriate free time based on activity
 func (r *TimeRules) GetFreeTime(activityType string) int {
 	switch activityType {
@@ -244,6 +1092,15 @@ func (r *TimeRules) GetFreeTime(activityType string) int {
 	}
 }
 
+// GetFactor returns the emission factor for an equipment class, falling back
+// to the default class when the class is unknown
+func (r *EmissionsRules) GetFactor(equipmentClass string) EmissionFactor {
+	if factor, ok := r.Factors[equipmentClass]; ok {
+		return factor
+	}
+	return r.Factors[r.DefaultEquipmentClass]
+}
+
 // CalculateETA calculates estimated arrival time
 func (r *DistanceRules) CalculateETA(distanceMiles float64, trafficFactor float64) time.Duration {
 	if trafficFactor == 0 {
@@ -15,6 +15,11 @@ type Config struct {
 	Kafka     KafkaConfig
 	Tracing   TracingConfig
 	Auth      AuthConfig
+	SMTP      SMTPConfig
+	Documents DocumentConfig
+	Storage   StorageConfig
+	Cutover   CutoverConfig
+	ChatOps   ChatOpsConfig
 }
 
 type ServiceConfig struct {
@@ -53,6 +58,14 @@ type RedisConfig struct {
 type KafkaConfig struct {
 	Brokers       []string
 	ConsumerGroup string
+	ProducerAsync bool          // Queue and batch published events instead of writing per call; off by default
+	BatchSize     int           // Max events per producer batch
+	Linger        time.Duration // How long a producer batch waits to fill
+	QueueSize     int           // Bounded producer queue; Publish returns an error when full
+	MaxRetries    int           // Consumer retries of a failed event before it is dead-lettered
+	RetryBackoff  time.Duration // First consumer retry delay, doubling on each retry
+	MaxBackoff    time.Duration // Cap on the consumer retry delay
+	DeadLetter    bool          // Send events that still fail to the topic's .dlq topic
 }
 
 type TracingConfig struct {
@@ -61,10 +74,62 @@ type TracingConfig struct {
 	Endpoint    string
 }
 
+// DefaultJWTSecret is the JWT secret used when JWT_SECRET is unset. It is
+// public, so nothing signed with it is trusted.
+const DefaultJWTSecret = "your-secret-key"
+
 type AuthConfig struct {
 	JWTSecret     string
 	TokenExpiry   time.Duration
 	RefreshExpiry time.Duration
+	Enabled       bool          // Require a bearer token on gRPC calls
+	OIDCIssuer    string        // Issuer whose JWKS signs tokens; required when Enabled
+	OIDCAudience  string        // Required aud claim, unchecked when empty
+	RolesClaim    string        // Claim holding the user's roles; dots walk into nested objects
+	ClockSkew     time.Duration // Leeway on exp and nbf
+	JWKSRefresh   time.Duration // How long signing keys are cached
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type DocumentConfig struct {
+	RendererURL   string        // Headless Chromium HTML-to-PDF endpoint (Gotenberg API)
+	RenderTimeout time.Duration
+}
+
+type StorageConfig struct {
+	Driver     string // local, s3, azure or gcs
+	Bucket     string // Bucket, or container for azure
+	Endpoint   string // Overrides the provider endpoint, e.g. for MinIO or Azurite
+	Region     string // s3 only
+	AccessKey  string // s3 access key ID, or azure account name
+	SecretKey  string // s3 secret access key, or base64 azure account key
+	CredsFile  string // gcs service account key file; the metadata server is used when empty
+	LocalRoot  string // local only
+	Encryption string // NONE, MANAGED or KMS; the default for every upload
+	KMSKeyID   string // KMS key ARN, GCS key name or azure encryption scope
+}
+
+type CutoverConfig struct {
+	Mode               string        // LEGACY, SHADOW, CANARY or ENHANCED for services with duplicate implementations
+	CanaryPercent      int           // Share of calls served by the enhanced path in CANARY mode
+	ShadowTimeout      time.Duration // Limit on a shadowed enhanced call
+	MaxShadowsInFlight int
+}
+
+type ChatOpsConfig struct {
+	Channels       string        // name=slack:URL or name=teams:URL incoming webhooks, comma separated
+	Routes         string        // event=channel, or event=channel|channel, comma separated
+	DefaultChannel string        // Channel for events without a route; empty drops them
+	RateLimit      int           // Messages per event type and channel in each window
+	RateWindow     time.Duration // Window the rate limit and duplicate suppression apply to
+	AppURL         string        // Base URL of the ops web app, for links in messages
 }
 
 // Load loads configuration from environment variables
@@ -102,6 +167,14 @@ func Load() *Config {
 		Kafka: KafkaConfig{
 			Brokers:       getEnvSlice("KAFKA_BROKERS", []string{"localhost:9092"}),
 			ConsumerGroup: getEnv("KAFKA_CONSUMER_GROUP", "draymaster"),
+			ProducerAsync: getEnvBool("KAFKA_PRODUCER_ASYNC", false),
+			BatchSize:     getEnvInt("KAFKA_BATCH_SIZE", 100),
+			Linger:        getEnvDuration("KAFKA_LINGER", 10*time.Millisecond),
+			QueueSize:     getEnvInt("KAFKA_QUEUE_SIZE", 10000),
+			MaxRetries:    getEnvInt("KAFKA_CONSUMER_MAX_RETRIES", 3),
+			RetryBackoff:  getEnvDuration("KAFKA_CONSUMER_RETRY_BACKOFF", 500*time.Millisecond),
+			MaxBackoff:    getEnvDuration("KAFKA_CONSUMER_MAX_BACKOFF", 30*time.Second),
+			DeadLetter:    getEnvBool("KAFKA_CONSUMER_DEAD_LETTER", true),
 		},
 		Tracing: TracingConfig{
 			Enabled:     getEnvBool("TRACING_ENABLED", false),
@@ -109,9 +182,52 @@ func Load() *Config {
 			Endpoint:    getEnv("TRACING_ENDPOINT", "http://localhost:14268/api/traces"),
 		},
 		Auth: AuthConfig{
-			JWTSecret:     getEnv("JWT_SECRET", "your-secret-key"),
+			JWTSecret:     getEnv("JWT_SECRET", DefaultJWTSecret),
 			TokenExpiry:   getEnvDuration("TOKEN_EXPIRY", 1*time.Hour),
 			RefreshExpiry: getEnvDuration("REFRESH_EXPIRY", 7*24*time.Hour),
+			Enabled:       getEnvBool("AUTH_ENABLED", true),
+			OIDCIssuer:    getEnv("OIDC_ISSUER_URL", ""),
+			OIDCAudience:  getEnv("OIDC_AUDIENCE", ""),
+			RolesClaim:    getEnv("AUTH_ROLES_CLAIM", "roles"),
+			ClockSkew:     getEnvDuration("AUTH_CLOCK_SKEW", time.Minute),
+			JWKSRefresh:   getEnvDuration("AUTH_JWKS_REFRESH", time.Hour),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     getEnvInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "noreply@draymaster.local"),
+		},
+		Documents: DocumentConfig{
+			RendererURL:   getEnv("DOCUMENT_RENDERER_URL", "http://localhost:3000"),
+			RenderTimeout: getEnvDuration("DOCUMENT_RENDER_TIMEOUT", 30*time.Second),
+		},
+		Storage: StorageConfig{
+			Driver:     getEnv("STORAGE_DRIVER", "local"),
+			Bucket:     getEnv("STORAGE_BUCKET", "draymaster-files"),
+			Endpoint:   getEnv("STORAGE_ENDPOINT", ""),
+			Region:     getEnv("STORAGE_REGION", "us-east-1"),
+			AccessKey:  getEnv("STORAGE_ACCESS_KEY", ""),
+			SecretKey:  getEnv("STORAGE_SECRET_KEY", ""),
+			CredsFile:  getEnv("STORAGE_CREDENTIALS_FILE", ""),
+			LocalRoot:  getEnv("STORAGE_LOCAL_ROOT", "./data/files"),
+			Encryption: getEnv("STORAGE_ENCRYPTION", "NONE"),
+			KMSKeyID:   getEnv("STORAGE_KMS_KEY_ID", ""),
+		},
+		Cutover: CutoverConfig{
+			Mode:               getEnv("CUTOVER_MODE", "LEGACY"),
+			CanaryPercent:      getEnvInt("CUTOVER_CANARY_PERCENT", 5),
+			ShadowTimeout:      getEnvDuration("CUTOVER_SHADOW_TIMEOUT", 5*time.Second),
+			MaxShadowsInFlight: getEnvInt("CUTOVER_MAX_SHADOWS_IN_FLIGHT", 20),
+		},
+		ChatOps: ChatOpsConfig{
+			Channels:       getEnv("CHATOPS_CHANNELS", ""),
+			Routes:         getEnv("CHATOPS_ROUTES", ""),
+			DefaultChannel: getEnv("CHATOPS_DEFAULT_CHANNEL", ""),
+			RateLimit:      getEnvInt("CHATOPS_RATE_LIMIT", 5),
+			RateWindow:     getEnvDuration("CHATOPS_RATE_WINDOW", 10*time.Minute),
+			AppURL:         getEnv("CHATOPS_APP_URL", "http://localhost:3000"),
 		},
 	}
 }
@@ -0,0 +1,163 @@
+package config
+
+import "time"
+
+// CustomerProfile contains contract-specific free time and detention rules.
+// Any value left unset falls back to the global BusinessRules.
+type CustomerProfile struct {
+	CustomerID      string
+	Name            string
+	FreeTimeMins    map[string]int       // Free time by activity type
+	DetentionTiers  []DetentionTier      // Hourly detention tiers
+	GracePeriodMins *int                 // Grace period override
+	MaxDailyCharge  float64              // Daily detention cap, 0 uses global rule
+	CountWeekends   bool                 // Whether Saturday/Sunday minutes count toward free time and detention
+	CountHolidays   bool                 // Whether holiday minutes count toward free time and detention
+	Holidays        []time.Time          // Contract holidays (date only)
+	CancellationFee *CancellationFeeRule // Cancellation fee override
+}
+
+// DetentionTier represents a tiered hourly detention rate
+type DetentionTier struct {
+	FromHour    int     `json:"from_hour"`     // Starting billable hour (inclusive, 1-based)
+	ToHour      int     `json:"to_hour"`       // Ending billable hour (inclusive), 0 means unlimited
+	RatePerHour float64 `json:"rate_per_hour"` // Rate per hour for this tier
+}
+
+// GetFreeTime returns the contract free time for an activity, falling back
+// to the global rules when the profile has no override
+func (p *CustomerProfile) GetFreeTime(activityType string, defaults *TimeRules) int {
+	if p != nil {
+		if mins, ok := p.FreeTimeMins[activityType]; ok {
+			return mins
+		}
+	}
+	return defaults.GetFreeTime(activityType)
+}
+
+// CancellationRule returns the contract cancellation fee rule, falling back
+// to the global rule when the profile has no override
+func (p *CustomerProfile) CancellationRule(defaults *CancellationFeeRule) *CancellationFeeRule {
+	if p != nil && p.CancellationFee != nil {
+		return p.CancellationFee
+	}
+	return defaults
+}
+
+// CalculateDetention calculates detention for a stay using the contract
+// tiers and weekend/holiday counting rules
+func (p *CustomerProfile) CalculateDetention(arrival, departure time.Time, freeMins int, defaults *DetentionRules) (float64, int) {
+	if p == nil {
+		return defaults.Calculate(int(departure.Sub(arrival).Minutes()), freeMins)
+	}
+
+	if freeMins == 0 {
+		freeMins = defaults.FreeTimeMins
+	}
+	grace := defaults.GracePeriodMins
+	if p.GracePeriodMins != nil {
+		grace = *p.GracePeriodMins
+	}
+
+	actualMins := p.CountableMinutes(arrival, departure)
+	totalFreeTime := freeMins + grace
+	if actualMins <= totalFreeTime {
+		return 0, 0
+	}
+	detentionMins := actualMins - totalFreeTime
+
+	var charge float64
+	if len(p.DetentionTiers) > 0 {
+		charge = CalculateDetentionTiers(detentionMins, p.DetentionTiers)
+	} else {
+		charge = float64(detentionMins) / 60.0 * defaults.RatePerHour
+	}
+
+	// Apply daily cap for each started day of detention
+	maxDaily := p.MaxDailyCharge
+	if maxDaily == 0 {
+		maxDaily = defaults.MaxDailyCharge
+	}
+	if maxDaily > 0 {
+		days := (detentionMins + 24*60 - 1) / (24 * 60)
+		if limit := maxDaily * float64(days); charge > limit {
+			charge = limit
+		}
+	}
+
+	return charge, detentionMins
+}
+
+// CountableMinutes returns the minutes between start and end that count
+// toward free time and detention under the profile's calendar rules
+func (p *CustomerProfile) CountableMinutes(start, end time.Time) int {
+	if !end.After(start) {
+		return 0
+	}
+	if p == nil || (p.CountWeekends && p.CountHolidays) {
+		return int(end.Sub(start).Minutes())
+	}
+
+	var counted time.Duration
+	for cursor := start; cursor.Before(end); {
+		y, m, d := cursor.Date()
+		nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, cursor.Location())
+		if nextDay.After(end) {
+			nextDay = end
+		}
+		if p.countsDay(cursor) {
+			counted += nextDay.Sub(cursor)
+		}
+		cursor = nextDay
+	}
+
+	return int(counted.Minutes())
+}
+
+// countsDay reports whether minutes on the given day are counted
+func (p *CustomerProfile) countsDay(day time.Time) bool {
+	if !p.CountWeekends {
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			return false
+		}
+	}
+	if !p.CountHolidays {
+		y, m, d := day.Date()
+		for _, h := range p.Holidays {
+			hy, hm, hd := h.Date()
+			if y == hy && m == hm && d == hd {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CalculateDetentionTiers calculates detention charges from hourly tiers.
+// Partial hours are prorated within the tier they fall in.
+func CalculateDetentionTiers(detentionMins int, tiers []DetentionTier) float64 {
+	if detentionMins <= 0 {
+		return 0
+	}
+
+	hours := float64(detentionMins) / 60.0
+	var totalCharge float64
+
+	for _, tier := range tiers {
+		from := float64(tier.FromHour - 1)
+		if hours <= from {
+			break
+		}
+
+		to := hours
+		if tier.ToHour != 0 && float64(tier.ToHour) < hours {
+			to = float64(tier.ToHour)
+		}
+
+		if to > from {
+			totalCharge += (to - from) * tier.RatePerHour
+		}
+	}
+
+	return totalCharge
+}
@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// BlindIndex computes keyed hashes of sensitive values so an encrypted
+// column can still be searched by exact match. Each value is sealed with a
+// fresh data key and nonce, so the ciphertext cannot be compared; the
+// index column holds HMAC-SHA256(value) instead, which reveals only
+// whether two rows hold the same value. Normalize values before indexing
+// them, since "(555) 123-4567" and "5551234567" hash differently.
+type BlindIndex struct {
+	key []byte
+}
+
+// NewBlindIndex creates a blind index from a key of at least 32 bytes. The
+// key must differ from the encryption keys and cannot be rotated without
+// recomputing every index value.
+func NewBlindIndex(key []byte) (*BlindIndex, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("blind index key must be at least 32 bytes, got %d", len(key))
+	}
+	return &BlindIndex{key: append([]byte(nil), key...)}, nil
+}
+
+// NewBlindIndexFromBase64 creates a blind index from a base64-encoded key,
+// the form keys take in environment variables
+func NewBlindIndexFromBase64(encoded string) (*BlindIndex, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode blind index key: %w", err)
+	}
+	return NewBlindIndex(key)
+}
+
+// Sum returns the index value for value. Empty values index as empty so
+// optional columns keep their meaning.
+func (b *BlindIndex) Sum(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBlindIndex(t *testing.T) {
+	idx, err := NewBlindIndexFromBase64(testKey('i'))
+	if err != nil {
+		t.Fatalf("NewBlindIndexFromBase64() error = %v", err)
+	}
+	other, _ := NewBlindIndexFromBase64(testKey('j'))
+
+	a := idx.Sum("+15551234567")
+	if a == "" || strings.Contains(a, "5551234567") {
+		t.Fatalf("Sum() = %q, want an opaque digest", a)
+	}
+	if idx.Sum("+15551234567") != a {
+		t.Error("Sum() is not deterministic")
+	}
+	if idx.Sum("+15551234568") == a {
+		t.Error("Sum() collided for different values")
+	}
+	if other.Sum("+15551234567") == a {
+		t.Error("Sum() matched across keys")
+	}
+	if idx.Sum("") != "" {
+		t.Error("Sum(\"\") should be empty")
+	}
+}
+
+func TestNewBlindIndexRejectsShortKeys(t *testing.T) {
+	if _, err := NewBlindIndex(make([]byte, 16)); err == nil {
+		t.Error("NewBlindIndex() accepted a 16-byte key")
+	}
+	if _, err := NewBlindIndexFromBase64(base64.StdEncoding.EncodeToString(make([]byte, 31))); err == nil {
+		t.Error("NewBlindIndexFromBase64() accepted a 31-byte key")
+	}
+	if _, err := NewBlindIndexFromBase64("%%%"); err == nil {
+		t.Error("NewBlindIndexFromBase64() accepted invalid base64")
+	}
+}
@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrCiphertextTooShort is returned when decrypting data shorter than the nonce
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// Cipher encrypts secrets at rest with AES-256-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create block cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// NewCipherFromBase64 creates a cipher from a base64-encoded 32-byte key,
+// the form keys take in environment variables
+func NewCipherFromBase64(encoded string) (*Cipher, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+	return NewCipher(key)
+}
+
+// Encrypt seals plaintext, returning the random nonce followed by the ciphertext
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens data produced by Encrypt
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return nil, ErrCiphertextTooShort
+	}
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts a string and returns it base64-encoded for text columns
+func (c *Cipher) EncryptString(plaintext string) (string, error) {
+	sealed, err := c.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString decrypts a value produced by EncryptString
+func (c *Cipher) DecryptString(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+	plaintext, err := c.Decrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
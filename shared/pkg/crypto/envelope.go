@@ -0,0 +1,254 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// envelopePrefix marks a value sealed by FieldEncryptor. Values without it
+// are treated as legacy plaintext so columns can be encrypted in place.
+const envelopePrefix = "enc:v1:"
+
+// ErrUnknownKey is returned when a value was sealed under a master key the
+// provider does not hold
+var ErrUnknownKey = errors.New("unknown master key")
+
+// KeyProvider wraps and unwraps data keys with a master key held by a key
+// management service. KMS adapters implement this; LocalKeyProvider covers
+// development and self-hosted deployments.
+type KeyProvider interface {
+	// CurrentKeyID is the master key new data keys are wrapped with
+	CurrentKeyID() string
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyProvider holds master keys in process, loaded from configuration
+type LocalKeyProvider struct {
+	current string
+	keys    map[string]*Cipher
+}
+
+// ParseKeyRing builds a LocalKeyProvider from "id:base64key,id:base64key".
+// The first key is current; the rest are kept to decrypt older values
+// until they are rotated.
+func ParseKeyRing(spec string) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{keys: make(map[string]*Cipher)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key ring entry must be id:base64key")
+		}
+		if _, dup := p.keys[id]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", id)
+		}
+		c, err := NewCipherFromBase64(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if p.current == "" {
+			p.current = id
+		}
+		p.keys[id] = c
+	}
+	if p.current == "" {
+		return nil, fmt.Errorf("key ring is empty")
+	}
+	return p, nil
+}
+
+// CurrentKeyID returns the ID of the first key in the ring
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+// WrapKey encrypts a data key with the current master key
+func (p *LocalKeyProvider) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := p.keys[p.current].Encrypt(dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.current, wrapped, nil
+}
+
+// UnwrapKey decrypts a data key with the master key it was wrapped under
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	c, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return c.Decrypt(wrapped)
+}
+
+// dataKey is a data key and its wrapped form as stored alongside values
+type dataKey struct {
+	keyID   string
+	wrapped string
+	cipher  *Cipher
+}
+
+// FieldEncryptor seals individual column values with envelope encryption:
+// each value is encrypted with a data key, and the data key is stored
+// wrapped by a master key from the KeyProvider. Unwrapped data keys are
+// cached so the KMS is called once per data key, not once per value.
+type FieldEncryptor struct {
+	provider KeyProvider
+
+	mu      sync.Mutex
+	current *dataKey
+	cache   map[string]*Cipher // wrapped data key -> cipher
+}
+
+// NewFieldEncryptor creates a field encryptor backed by provider
+func NewFieldEncryptor(provider KeyProvider) *FieldEncryptor {
+	return &FieldEncryptor{
+		provider: provider,
+		cache:    make(map[string]*Cipher),
+	}
+}
+
+// CurrentKeyID returns the master key new values are sealed under
+func (e *FieldEncryptor) CurrentKeyID() string {
+	return e.provider.CurrentKeyID()
+}
+
+// Encrypt seals a value. Empty values stay empty so optional columns keep
+// their meaning.
+func (e *FieldEncryptor) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	dk, err := e.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := dk.cipher.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return envelopePrefix + dk.keyID + ":" + dk.wrapped + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Legacy plaintext is returned as is.
+func (e *FieldEncryptor) Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, envelopePrefix), ":", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	keyID, wrapped, body := parts[0], parts[1], parts[2]
+
+	c, err := e.unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+	plaintext, err := c.Decrypt(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether a value is plaintext or sealed under a
+// master key other than the current one
+func (e *FieldEncryptor) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, envelopePrefix), ":")
+	return keyID != e.provider.CurrentKeyID()
+}
+
+// Rotate re-seals a value under the current master key
+func (e *FieldEncryptor) Rotate(ctx context.Context, value string) (string, error) {
+	if !e.NeedsRotation(value) {
+		return value, nil
+	}
+	plaintext, err := e.Decrypt(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	return e.Encrypt(ctx, plaintext)
+}
+
+// IsEncrypted reports whether a value was sealed by a FieldEncryptor
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// dataKey returns the data key for new values, generating one when there
+// is none yet or the master key has been rotated
+func (e *FieldEncryptor) dataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current != nil && e.current.keyID == e.provider.CurrentKeyID() {
+		return e.current, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := e.provider.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+
+	e.current = &dataKey{
+		keyID:   keyID,
+		wrapped: base64.RawURLEncoding.EncodeToString(wrapped),
+		cipher:  c,
+	}
+	e.cache[e.current.wrapped] = c
+	return e.current, nil
+}
+
+func (e *FieldEncryptor) unwrap(ctx context.Context, keyID, wrapped string) (*Cipher, error) {
+	e.mu.Lock()
+	c, ok := e.cache[wrapped]
+	e.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("decode data key: %w", err)
+	}
+	key, err := e.provider.UnwrapKey(ctx, keyID, raw)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	if c, err = NewCipher(key); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.cache[wrapped] = c
+	e.mu.Unlock()
+	return c, nil
+}
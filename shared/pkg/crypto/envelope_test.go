@@ -0,0 +1,157 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := NewCipherFromBase64(testKey('a'))
+	if err != nil {
+		t.Fatalf("NewCipherFromBase64() error = %v", err)
+	}
+
+	sealed, err := c.EncryptString("555-123-4567")
+	if err != nil {
+		t.Fatalf("EncryptString() error = %v", err)
+	}
+	got, err := c.DecryptString(sealed)
+	if err != nil {
+		t.Fatalf("DecryptString() error = %v", err)
+	}
+	if got != "555-123-4567" {
+		t.Errorf("DecryptString() = %q, want %q", got, "555-123-4567")
+	}
+
+	if _, err := c.Decrypt([]byte{1, 2}); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("Decrypt(short) error = %v, want ErrCiphertextTooShort", err)
+	}
+	if _, err := NewCipher([]byte("short")); err == nil {
+		t.Error("NewCipher() accepted a 5-byte key")
+	}
+}
+
+func TestParseKeyRing(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		current string
+		wantErr bool
+	}{
+		{"single key", "k1:" + testKey('a'), "k1", false},
+		{"first key is current", "k2:" + testKey('b') + ", k1:" + testKey('a'), "k2", false},
+		{"empty", " , ", "", true},
+		{"missing id", ":" + testKey('a'), "", true},
+		{"duplicate id", "k1:" + testKey('a') + ",k1:" + testKey('b'), "", true},
+		{"bad key", "k1:not-base64!", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseKeyRing(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyRing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.CurrentKeyID() != tt.current {
+				t.Errorf("CurrentKeyID() = %q, want %q", p.CurrentKeyID(), tt.current)
+			}
+		})
+	}
+}
+
+func TestFieldEncryptorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ring, _ := ParseKeyRing("k1:" + testKey('a'))
+	enc := NewFieldEncryptor(ring)
+
+	sealed, err := enc.Encrypt(ctx, "D1234567")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(sealed, "D1234567") {
+		t.Fatalf("Encrypt() = %q, want a sealed value", sealed)
+	}
+
+	again, _ := enc.Encrypt(ctx, "D1234567")
+	if again == sealed {
+		t.Error("Encrypt() produced identical ciphertext for the same value")
+	}
+
+	got, err := enc.Decrypt(ctx, sealed)
+	if err != nil || got != "D1234567" {
+		t.Errorf("Decrypt() = %q, %v; want %q", got, err, "D1234567")
+	}
+
+	// A fresh encryptor has to unwrap the data key through the provider
+	got, err = NewFieldEncryptor(ring).Decrypt(ctx, sealed)
+	if err != nil || got != "D1234567" {
+		t.Errorf("Decrypt() with a cold cache = %q, %v; want %q", got, err, "D1234567")
+	}
+}
+
+func TestFieldEncryptorPlaintextAndEmpty(t *testing.T) {
+	ctx := context.Background()
+	ring, _ := ParseKeyRing("k1:" + testKey('a'))
+	enc := NewFieldEncryptor(ring)
+
+	if got, err := enc.Encrypt(ctx, ""); err != nil || got != "" {
+		t.Errorf("Encrypt(\"\") = %q, %v; want empty", got, err)
+	}
+	if got, err := enc.Decrypt(ctx, "legacy plaintext"); err != nil || got != "legacy plaintext" {
+		t.Errorf("Decrypt(plaintext) = %q, %v; want it unchanged", got, err)
+	}
+	if _, err := enc.Decrypt(ctx, envelopePrefix+"k1:only-two"); err == nil {
+		t.Error("Decrypt() accepted a malformed value")
+	}
+	if enc.NeedsRotation("") {
+		t.Error("NeedsRotation(\"\") = true, want false")
+	}
+	if !enc.NeedsRotation("legacy plaintext") {
+		t.Error("NeedsRotation(plaintext) = false, want true")
+	}
+}
+
+func TestFieldEncryptorRotation(t *testing.T) {
+	ctx := context.Background()
+	oldRing, _ := ParseKeyRing("k1:" + testKey('a'))
+	sealed, err := NewFieldEncryptor(oldRing).Encrypt(ctx, "123-45-6789")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	newRing, _ := ParseKeyRing("k2:" + testKey('b') + ",k1:" + testKey('a'))
+	enc := NewFieldEncryptor(newRing)
+	if !enc.NeedsRotation(sealed) {
+		t.Fatal("NeedsRotation() = false for a value sealed under a retired key")
+	}
+
+	rotated, err := enc.Rotate(ctx, sealed)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if !strings.HasPrefix(rotated, envelopePrefix+"k2:") {
+		t.Errorf("Rotate() = %q, want a value sealed under k2", rotated)
+	}
+	if enc.NeedsRotation(rotated) {
+		t.Error("NeedsRotation() = true after rotation")
+	}
+	if again, _ := enc.Rotate(ctx, rotated); again != rotated {
+		t.Error("Rotate() re-sealed a current value")
+	}
+
+	// Once k1 is dropped from the ring only rotated values stay readable
+	current, _ := ParseKeyRing("k2:" + testKey('b'))
+	if got, err := NewFieldEncryptor(current).Decrypt(ctx, rotated); err != nil || got != "123-45-6789" {
+		t.Errorf("Decrypt(rotated) = %q, %v; want %q", got, err, "123-45-6789")
+	}
+	if _, err := NewFieldEncryptor(current).Decrypt(ctx, sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt(stale) error = %v, want ErrUnknownKey", err)
+	}
+}
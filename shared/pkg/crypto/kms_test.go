@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault implements the transit encrypt and decrypt endpoints. Ciphertext
+// is the plaintext tagged with the key name, which is enough to check the
+// adapter passes keys and payloads through intact.
+type fakeVault struct {
+	mu      sync.Mutex
+	keys    map[string]bool
+	decrypt int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "s.test" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if r.Method != http.MethodPost || len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	op, key := parts[0], parts[1]
+
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.keys[key] {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {"encryption key not found"}})
+		return
+	}
+
+	switch op {
+	case "encrypt":
+		json.NewEncoder(w).Encode(map[string]map[string]string{
+			"data": {"ciphertext": "vault:v1:" + key + ":" + body["plaintext"]},
+		})
+	case "decrypt":
+		f.decrypt++
+		plaintext, ok := strings.CutPrefix(body["ciphertext"], "vault:v1:"+key+":")
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {"cipher: message authentication failed"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]map[string]string{"data": {"plaintext": plaintext}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultTransitRoundTrip(t *testing.T) {
+	vault := &fakeVault{keys: map[string]bool{"driver-pii": true}}
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	kms := NewVaultTransit(VaultConfig{Address: srv.URL + "/", Token: "s.test"})
+	ctx := context.Background()
+
+	ct, err := kms.Encrypt(ctx, "driver-pii", []byte("data key"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if want := "vault:v1:driver-pii:" + base64.StdEncoding.EncodeToString([]byte("data key")); string(ct) != want {
+		t.Errorf("Encrypt() = %q, want %q", ct, want)
+	}
+	pt, err := kms.Decrypt(ctx, "driver-pii", ct)
+	if err != nil || string(pt) != "data key" {
+		t.Errorf("Decrypt() = %q, %v; want %q", pt, err, "data key")
+	}
+
+	if _, err := kms.Decrypt(ctx, "retired", ct); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with an unknown key error = %v, want ErrUnknownKey", err)
+	}
+
+	denied := NewVaultTransit(VaultConfig{Address: srv.URL, Token: "wrong"})
+	if _, err := denied.Encrypt(ctx, "driver-pii", []byte("x")); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Encrypt() with a bad token error = %v, want a 403", err)
+	}
+}
+
+func TestKMSKeyProviderWithFieldEncryptor(t *testing.T) {
+	vault := &fakeVault{keys: map[string]bool{"pii-2025": true, "pii-2026": true}}
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	kms := NewVaultTransit(VaultConfig{Address: srv.URL, Token: "s.test"})
+	ctx := context.Background()
+
+	old := NewFieldEncryptor(NewKMSKeyProvider(kms, "pii-2025"))
+	sealed, err := old.Encrypt(ctx, "555-0100")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !strings.HasPrefix(sealed, envelopePrefix+"pii-2025:") {
+		t.Errorf("Encrypt() = %q, want it sealed under pii-2025", sealed)
+	}
+
+	enc := NewFieldEncryptor(NewKMSKeyProvider(kms, "pii-2026"))
+	for i := 0; i < 3; i++ {
+		got, err := enc.Decrypt(ctx, sealed)
+		if err != nil || got != "555-0100" {
+			t.Fatalf("Decrypt() = %q, %v; want %q", got, err, "555-0100")
+		}
+	}
+	if vault.decrypt != 1 {
+		t.Errorf("vault decrypt calls = %d, want 1 (data keys are cached)", vault.decrypt)
+	}
+
+	rotated, err := enc.Rotate(ctx, sealed)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if !strings.HasPrefix(rotated, envelopePrefix+"pii-2026:") {
+		t.Errorf("Rotate() = %q, want it sealed under pii-2026", rotated)
+	}
+}
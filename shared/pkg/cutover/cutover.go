@@ -0,0 +1,348 @@
+// Package cutover routes calls between a legacy and an enhanced
+// implementation of the same operation while the enhanced one is proven
+// out. In shadow mode the legacy path serves the caller and the enhanced
+// path runs alongside it; results are compared off the request path and
+// differences are logged and counted, so duplicate code can be removed once
+// an operation runs clean.
+package cutover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Mode selects how an operation is served
+type Mode string
+
+const (
+	ModeLegacy   Mode = "LEGACY"   // Legacy only
+	ModeShadow   Mode = "SHADOW"   // Legacy serves; enhanced runs alongside and is compared
+	ModeCanary   Mode = "CANARY"   // CanaryPercent of calls served by enhanced, the rest shadowed
+	ModeEnhanced Mode = "ENHANCED" // Enhanced only
+)
+
+// ParseMode parses a mode name, falling back to ModeLegacy
+func ParseMode(s string) Mode {
+	switch mode := Mode(strings.ToUpper(strings.TrimSpace(s))); mode {
+	case ModeShadow, ModeCanary, ModeEnhanced:
+		return mode
+	default:
+		return ModeLegacy
+	}
+}
+
+// Config configures a Router
+type Config struct {
+	Mode               Mode
+	CanaryPercent      int           // Share of calls served by enhanced in canary mode
+	ShadowTimeout      time.Duration // Limit on a shadow run, detached from the request deadline
+	MaxShadowsInFlight int           // Shadow runs beyond this are skipped rather than queued
+}
+
+// DefaultConfig keeps every operation on the legacy path
+func DefaultConfig() Config {
+	return Config{
+		Mode:               ModeLegacy,
+		CanaryPercent:      5,
+		ShadowTimeout:      5 * time.Second,
+		MaxShadowsInFlight: 20,
+	}
+}
+
+// FromConfig builds a router config from the environment settings
+func FromConfig(cfg config.CutoverConfig) Config {
+	return Config{
+		Mode:               ParseMode(cfg.Mode),
+		CanaryPercent:      cfg.CanaryPercent,
+		ShadowTimeout:      cfg.ShadowTimeout,
+		MaxShadowsInFlight: cfg.MaxShadowsInFlight,
+	}
+}
+
+// Path is the implementation that served a call
+type Path string
+
+const (
+	PathLegacy   Path = "LEGACY"
+	PathEnhanced Path = "ENHANCED"
+)
+
+// OperationMetrics counts calls and shadow comparisons for one operation
+type OperationMetrics struct {
+	Operation      string        `json:"operation"`
+	Mode           Mode          `json:"mode"`
+	Legacy         uint64        `json:"legacy"`   // Calls served by legacy
+	Enhanced       uint64        `json:"enhanced"` // Calls served by enhanced
+	Matches        uint64        `json:"matches"`
+	Mismatches     uint64        `json:"mismatches"`
+	Timeouts       uint64        `json:"timeouts"`       // Shadow runs that hit ShadowTimeout
+	Skipped        uint64        `json:"skipped"`        // Shadow runs dropped at MaxShadowsInFlight
+	LegacyLatency  time.Duration `json:"legacy_latency"` // Average, shadowed calls only
+	ShadowLatency  time.Duration `json:"shadow_latency"` // Average
+	LastMismatch   string        `json:"last_mismatch,omitempty"`
+	LastMismatchAt time.Time     `json:"last_mismatch_at,omitempty"`
+
+	legacyTotal time.Duration
+	shadowTotal time.Duration
+	compared    uint64
+}
+
+// MatchRate returns the share of completed comparisons that matched
+func (m *OperationMetrics) MatchRate() float64 {
+	if m.Matches+m.Mismatches == 0 {
+		return 0
+	}
+	return float64(m.Matches) / float64(m.Matches+m.Mismatches)
+}
+
+// Router decides which implementation serves each call and meters the
+// shadow comparisons
+type Router struct {
+	cfg    Config
+	logger *logger.Logger
+	slots  chan struct{}
+
+	mu      sync.Mutex
+	modes   map[string]Mode // Per-operation overrides of cfg.Mode
+	metrics map[string]*OperationMetrics
+}
+
+// NewRouter creates a new cutover router
+func NewRouter(cfg Config, log *logger.Logger) *Router {
+	defaults := DefaultConfig()
+	if cfg.Mode == "" {
+		cfg.Mode = defaults.Mode
+	}
+	if cfg.CanaryPercent < 0 || cfg.CanaryPercent > 100 {
+		cfg.CanaryPercent = defaults.CanaryPercent
+	}
+	if cfg.ShadowTimeout <= 0 {
+		cfg.ShadowTimeout = defaults.ShadowTimeout
+	}
+	if cfg.MaxShadowsInFlight <= 0 {
+		cfg.MaxShadowsInFlight = defaults.MaxShadowsInFlight
+	}
+	return &Router{
+		cfg:     cfg,
+		logger:  log,
+		slots:   make(chan struct{}, cfg.MaxShadowsInFlight),
+		modes:   make(map[string]Mode),
+		metrics: make(map[string]*OperationMetrics),
+	}
+}
+
+// SetMode overrides the mode for one operation, so operations can be cut
+// over one at a time
+func (r *Router) SetMode(operation string, mode Mode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modes[operation] = mode
+}
+
+// Mode returns the mode an operation runs in
+func (r *Router) Mode(operation string) Mode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mode(operation)
+}
+
+func (r *Router) mode(operation string) Mode {
+	if mode, ok := r.modes[operation]; ok {
+		return mode
+	}
+	return r.cfg.Mode
+}
+
+// Route picks the path for a call and whether to shadow it. Canary calls
+// with the same key always take the same path, so retries of one entity do
+// not flip between implementations; an empty key is routed at random.
+func (r *Router) Route(operation, key string) (Path, bool) {
+	switch r.Mode(operation) {
+	case ModeShadow:
+		return PathLegacy, true
+	case ModeCanary:
+		if canaryBucket(key) < r.cfg.CanaryPercent {
+			return PathEnhanced, false
+		}
+		return PathLegacy, true
+	case ModeEnhanced:
+		return PathEnhanced, false
+	default:
+		return PathLegacy, false
+	}
+}
+
+func canaryBucket(key string) int {
+	if key == "" {
+		return rand.Intn(100)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// Paths are the implementations of one operation. Shadow must be free of
+// side effects; it defaults to Enhanced, which is only safe for reads. Diff
+// lists the differences between two successful results.
+type Paths[T any] struct {
+	Legacy   func(ctx context.Context) (T, error)
+	Enhanced func(ctx context.Context) (T, error)
+	Shadow   func(ctx context.Context) (T, error)
+	Diff     func(legacy, enhanced T) []string
+}
+
+// Run serves a call through the routed path. When the call is shadowed the
+// shadow starts alongside the legacy call on a detached context, and the
+// caller gets the legacy result without waiting for the comparison.
+func Run[T any](ctx context.Context, r *Router, operation, key string, paths Paths[T]) (result T, err error) {
+	path, shadow := r.Route(operation, key)
+	if path == PathEnhanced {
+		r.record(operation, func(m *OperationMetrics) { m.Enhanced++ })
+		return paths.Enhanced(ctx)
+	}
+	r.record(operation, func(m *OperationMetrics) { m.Legacy++ })
+	if !shadow {
+		return paths.Legacy(ctx)
+	}
+
+	shadowFn := paths.Shadow
+	if shadowFn == nil {
+		shadowFn = paths.Enhanced
+	}
+	legacyDone := make(chan outcome[T], 1)
+	if !startShadow(ctx, r, operation, shadowFn, paths.Diff, legacyDone) {
+		return paths.Legacy(ctx)
+	}
+
+	// Deferred so a panicking legacy call still releases the shadow
+	started := time.Now()
+	defer func() {
+		legacyDone <- outcome[T]{result: result, err: err, latency: time.Since(started)}
+	}()
+	return paths.Legacy(ctx)
+}
+
+// outcome is the result of one path
+type outcome[T any] struct {
+	result  T
+	err     error
+	latency time.Duration
+}
+
+// startShadow runs the shadow in the background and compares it with the
+// legacy outcome once that arrives. It returns false when no slot is free.
+func startShadow[T any](ctx context.Context, r *Router, operation string, shadow func(context.Context) (T, error), diff func(legacy, enhanced T) []string, legacyDone <-chan outcome[T]) bool {
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		r.record(operation, func(m *OperationMetrics) { m.Skipped++ })
+		return false
+	}
+
+	shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.cfg.ShadowTimeout)
+	go func() {
+		defer func() { <-r.slots }()
+		defer cancel()
+		// A broken enhanced path must never take the service down
+		defer func() {
+			if p := recover(); p != nil {
+				r.compare(operation, []string{fmt.Sprintf("enhanced panicked: %v", p)}, 0, 0)
+			}
+		}()
+
+		started := time.Now()
+		result, err := shadow(shadowCtx)
+		enhanced := outcome[T]{result: result, err: err, latency: time.Since(started)}
+		timedOut := err != nil && errors.Is(shadowCtx.Err(), context.DeadlineExceeded)
+		legacy := <-legacyDone
+
+		if timedOut {
+			r.record(operation, func(m *OperationMetrics) { m.Timeouts++ })
+			return
+		}
+		r.compare(operation, compareOutcomes(legacy, enhanced, diff), legacy.latency, enhanced.latency)
+	}()
+	return true
+}
+
+// compareOutcomes lists the differences between the legacy and enhanced
+// outcomes. Two failures count as a match: the paths return different
+// error types, and only whether they accept the call is compared.
+func compareOutcomes[T any](legacy, enhanced outcome[T], diff func(legacy, enhanced T) []string) []string {
+	switch {
+	case legacy.err != nil && enhanced.err != nil:
+		return nil
+	case legacy.err != nil:
+		return []string{fmt.Sprintf("legacy failed, enhanced succeeded: %v", legacy.err)}
+	case enhanced.err != nil:
+		return []string{fmt.Sprintf("legacy succeeded, enhanced failed: %v", enhanced.err)}
+	case diff == nil:
+		return nil
+	default:
+		return diff(legacy.result, enhanced.result)
+	}
+}
+
+// compare records a finished comparison and logs any differences
+func (r *Router) compare(operation string, diffs []string, legacyLatency, shadowLatency time.Duration) {
+	r.record(operation, func(m *OperationMetrics) {
+		m.compared++
+		m.legacyTotal += legacyLatency
+		m.shadowTotal += shadowLatency
+		if len(diffs) == 0 {
+			m.Matches++
+			return
+		}
+		m.Mismatches++
+		m.LastMismatch = strings.Join(diffs, "; ")
+		m.LastMismatchAt = time.Now()
+	})
+	if len(diffs) > 0 {
+		r.logger.Warnw("Cutover shadow mismatch",
+			"operation", operation,
+			"differences", diffs,
+			"legacy_latency", legacyLatency,
+			"shadow_latency", shadowLatency,
+		)
+	}
+}
+
+func (r *Router) record(operation string, update func(m *OperationMetrics)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.metrics[operation]
+	if !ok {
+		m = &OperationMetrics{Operation: operation}
+		r.metrics[operation] = m
+	}
+	update(m)
+}
+
+// Metrics returns the counters per operation, sorted by operation
+func (r *Router) Metrics() []OperationMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]OperationMetrics, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		snapshot := *m
+		snapshot.Mode = r.mode(m.Operation)
+		if m.compared > 0 {
+			snapshot.LegacyLatency = m.legacyTotal / time.Duration(m.compared)
+			snapshot.ShadowLatency = m.shadowTotal / time.Duration(m.compared)
+		}
+		out = append(out, snapshot)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Operation < out[j].Operation })
+	return out
+}
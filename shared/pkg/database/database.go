@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +12,10 @@ import (
 	"github.com/draymaster/shared/pkg/config"
 )
 
+// ErrVersionConflict is returned by repository updates when the row's version
+// no longer matches the one that was read, i.e. someone else saved first
+var ErrVersionConflict = errors.New("row version conflict")
+
 // DB wraps the PostgreSQL connection pool
 type DB struct {
 	Pool *pgxpool.Pool
@@ -84,6 +89,20 @@ func (db *DB) Transaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
 	return tx.Commit(ctx)
 }
 
+type txContextKey struct{}
+
+// WithTx returns a context carrying the transaction so repositories called
+// from inside Transaction run their queries (and row locks) on it
+func WithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
+
 // Paginate applies pagination to a query
 type Pagination struct {
 	Page     int
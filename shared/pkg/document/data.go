@@ -0,0 +1,175 @@
+package document
+
+import "time"
+
+// The types below are the data the built-in templates expect in
+// Request.Data. Tenant templates for the same kind receive the same data.
+
+// Party is a company or person named on a document
+type Party struct {
+	Name         string
+	AddressLine1 string
+	AddressLine2 string
+	Phone        string
+	Email        string
+}
+
+// InvoiceData is the data for KindInvoice
+type InvoiceData struct {
+	Number       string
+	Date         time.Time
+	DueDate      time.Time
+	PaymentTerms string
+	Currency     string
+	BillTo       Party
+	PONumber     string
+	BOLNumber    string
+	Lines        []InvoiceLine
+	Subtotal     float64
+	TaxRate      float64 // Percent
+	TaxAmount    float64
+	Total        float64
+	AmountPaid   float64
+	BalanceDue   float64
+	Notes        string
+}
+
+// InvoiceLine is one charge on an invoice
+type InvoiceLine struct {
+	Description     string
+	ContainerNumber string
+	Quantity        float64
+	UnitPrice       float64
+	Amount          float64
+}
+
+// Stop is a stop on a manifest or rate confirmation
+type Stop struct {
+	Sequence          int
+	Type              string
+	Activity          string
+	LocationName      string
+	Address           string
+	AppointmentTime   *time.Time
+	AppointmentNumber string
+	ContainerNumber   string
+	Instructions      string
+
+	// From the shipper or consignee facility profile, shown on manifests
+	ReceivingHours   string // Hours on the appointment day, or the week when unscheduled
+	DockCount        int
+	RequiredPPE      []string
+	CheckInProcedure string
+}
+
+// ManifestData is the data for KindDriverManifest
+type ManifestData struct {
+	TripNumber    string
+	Date          time.Time
+	DriverName    string
+	DriverPhone   string
+	TractorNumber string
+	ChassisNumber string
+	Stops         []Stop
+	OptionalStops []Stop // Suggested stops off the run order, such as places to take a required break
+	Notes         string
+}
+
+// RateCharge is one agreed charge on a rate confirmation
+type RateCharge struct {
+	Description string
+	Amount      float64
+}
+
+// RateConfirmationData is the data for KindRateConfirmation
+type RateConfirmationData struct {
+	Number       string
+	Date         time.Time
+	Reference    string // Trip or order number the carrier quotes when invoicing
+	Carrier      Party
+	Broker       Party // Broker the load was tendered by, when there is one
+	MCNumber     string
+	DOTNumber    string
+	Equipment    string
+	Stops        []Stop
+	Charges      []RateCharge
+	Total        float64
+	PaymentTerms string
+	Terms        string // Additional conditions printed above the signature lines
+}
+
+// HOSLogEntry is one duty status period on a daily log
+type HOSLogEntry struct {
+	Status       string
+	Start        time.Time
+	End          *time.Time
+	DurationMins int
+	Location     string
+	Odometer     int
+	Source       string
+	Notes        string
+}
+
+// HOSLogData is the data for KindHOSLog, one driver for one day
+type HOSLogData struct {
+	DriverName    string
+	LicenseNumber string
+	LicenseState  string
+	Date          time.Time
+	TractorNumber string
+	Entries       []HOSLogEntry
+	OffDutyMins   int
+	SleeperMins   int
+	DrivingMins   int
+	OnDutyMins    int
+	Violations    []string
+}
+
+// StatementInvoice is one open invoice on a customer statement
+type StatementInvoice struct {
+	Number         string
+	Date           time.Time
+	DueDate        time.Time
+	Reference      string // PO or BOL number
+	Total          float64
+	Paid           float64
+	BalanceDue     float64
+	DisputedAmount float64
+	DaysPastDue    int
+}
+
+// StatementCredit is an unapplied credit on a customer statement
+type StatementCredit struct {
+	Number string
+	Date   time.Time
+	Reason string
+	Amount float64 // Remaining credit, positive
+}
+
+// StatementAging is the open balance by days past due
+type StatementAging struct {
+	Current    float64
+	Days1To30  float64
+	Days31To60 float64
+	Days61To90 float64
+	Over90     float64
+}
+
+// StatementData is the data for KindStatement
+type StatementData struct {
+	Number           string
+	Date             time.Time
+	PeriodStart      time.Time
+	PeriodEnd        time.Time
+	Currency         string
+	BillTo           Party
+	Invoices         []StatementInvoice
+	Credits          []StatementCredit
+	Aging            StatementAging
+	TotalOpen        float64
+	TotalCredits     float64
+	TotalDisputed    float64
+	AmountDue        float64 // Open invoices less unapplied credits
+	PaymentsInPeriod float64
+	Notes            string
+}
@@ -0,0 +1,119 @@
+// Package document renders business documents - invoices, customer
+// statements, driver manifests, rate confirmations and HOS logs - from
+// versioned HTML templates with per-tenant branding, and converts them to PDF.
+package document
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kind identifies what a document is and which template renders it
+type Kind string
+
+const (
+	KindInvoice          Kind = "INVOICE"
+	KindDriverManifest   Kind = "DRIVER_MANIFEST"
+	KindRateConfirmation Kind = "RATE_CONFIRMATION"
+	KindHOSLog           Kind = "HOS_LOG"
+	KindStatement        Kind = "CUSTOMER_STATEMENT"
+)
+
+// DefaultTenant owns the templates and branding used by tenants that have
+// not set up their own
+const DefaultTenant = "default"
+
+// Page sizes understood by the renderer
+const (
+	PageSizeLetter = "LETTER"
+	PageSizeLegal  = "LEGAL"
+	PageSizeA4     = "A4"
+)
+
+// PageOptions controls the PDF page layout
+type PageOptions struct {
+	Size         string  `json:"size"` // PageSizeLetter when empty
+	Landscape    bool    `json:"landscape"`
+	MarginInches float64 `json:"margin_inches"` // 0.5 when zero
+}
+
+// Template is one immutable version of a document template. Body is parsed
+// with html/template after the shared layout and must define "content"; it
+// may also redefine "styles" or "layout".
+type Template struct {
+	ID        uuid.UUID   `json:"id"`
+	TenantID  string      `json:"tenant_id"`
+	Kind      Kind        `json:"kind"`
+	Version   int         `json:"version"` // 0 for the built-in template
+	Body      string      `json:"body"`
+	Page      PageOptions `json:"page"`
+	Active    bool        `json:"active"`
+	CreatedBy string      `json:"created_by,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Branding is the letterhead printed on a tenant's documents
+type Branding struct {
+	TenantID     string    `json:"tenant_id"`
+	CompanyName  string    `json:"company_name"`
+	LogoURL      string    `json:"logo_url,omitempty"` // https or data: URL reachable by the renderer
+	PrimaryColor string    `json:"primary_color"`      // CSS color for headings and rules
+	AccentColor  string    `json:"accent_color"`       // CSS color for table headers
+	AddressLine1 string    `json:"address_line1,omitempty"`
+	AddressLine2 string    `json:"address_line2,omitempty"`
+	Phone        string    `json:"phone,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	MCNumber     string    `json:"mc_number,omitempty"`
+	DOTNumber    string    `json:"dot_number,omitempty"`
+	FooterText   string    `json:"footer_text,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DefaultBranding is used when neither the tenant nor DefaultTenant has branding
+func DefaultBranding() *Branding {
+	return &Branding{
+		TenantID:     DefaultTenant,
+		CompanyName:  "DrayMaster",
+		PrimaryColor: "#1f3a5f",
+		AccentColor:  "#e8eef5",
+	}
+}
+
+// Document is a rendered PDF
+type Document struct {
+	Kind            Kind      `json:"kind"`
+	TenantID        string    `json:"tenant_id"`
+	TemplateVersion int       `json:"template_version"`
+	Filename        string    `json:"filename"`
+	ContentType     string    `json:"content_type"`
+	Data            []byte    `json:"-"`
+	GeneratedAt     time.Time `json:"generated_at"`
+}
+
+// TemplateStore persists template versions
+type TemplateStore interface {
+	// Active returns the tenant's active template for kind, or nil if it has none
+	Active(ctx context.Context, tenantID string, kind Kind) (*Template, error)
+	// Get returns a specific version, or nil if it does not exist
+	Get(ctx context.Context, tenantID string, kind Kind, version int) (*Template, error)
+	// List returns every version of the tenant's template for kind, newest first
+	List(ctx context.Context, tenantID string, kind Kind) ([]Template, error)
+	// Publish stores t as the next version and makes it the active one
+	Publish(ctx context.Context, t *Template) error
+	// Activate makes an existing version the active one, e.g. to roll back
+	Activate(ctx context.Context, tenantID string, kind Kind, version int) error
+}
+
+// BrandingStore persists tenant branding
+type BrandingStore interface {
+	// Get returns the tenant's branding, or nil if it has none
+	Get(ctx context.Context, tenantID string) (*Branding, error)
+	Save(ctx context.Context, b *Branding) error
+}
+
+// Renderer converts a rendered HTML page to PDF
+type Renderer interface {
+	RenderPDF(ctx context.Context, html []byte, page PageOptions) ([]byte, error)
+}
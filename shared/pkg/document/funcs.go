@@ -0,0 +1,67 @@
+package document
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+// funcs are the helpers available to every template
+var funcs = template.FuncMap{
+	"money":    money,
+	"date":     func(v interface{}) string { return formatTime(v, "Jan 2, 2006") },
+	"datetime": func(v interface{}) string { return formatTime(v, "Jan 2, 2006 15:04 MST") },
+	"clock":    func(v interface{}) string { return formatTime(v, "15:04") },
+	"duration": duration,
+	"upper":    strings.ToUpper,
+	"join":     strings.Join,
+	"add":      func(a, b int) int { return a + b },
+	"default": func(fallback string, v interface{}) string {
+		if s := fmt.Sprint(v); v != nil && s != "" && s != "<nil>" {
+			return s
+		}
+		return fallback
+	},
+}
+
+// money formats an amount with thousands separators, e.g. 1234.5 -> "$1,234.50"
+func money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := fmt.Sprint(cents / 100)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, whole, cents%100)
+}
+
+// formatTime formats a time.Time or *time.Time, returning "" for nil or zero
+func formatTime(v interface{}, layout string) string {
+	switch t := v.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	}
+	return ""
+}
+
+// duration formats minutes as hours and minutes, e.g. 330 -> "5h 30m"
+func duration(mins int) string {
+	if mins <= 0 {
+		return "0h 00m"
+	}
+	return fmt.Sprintf("%dh %02dm", mins/60, mins%60)
+}
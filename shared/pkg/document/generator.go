@@ -0,0 +1,289 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+//go:embed templates/*.html
+var builtinFS embed.FS
+
+// builtinFiles maps each kind to its built-in template, used when neither
+// the tenant nor DefaultTenant has published one
+var builtinFiles = map[Kind]string{
+	KindInvoice:          "templates/invoice.html",
+	KindDriverManifest:   "templates/driver_manifest.html",
+	KindRateConfirmation: "templates/rate_confirmation.html",
+	KindHOSLog:           "templates/hos_log.html",
+	KindStatement:        "templates/statement.html",
+}
+
+// builtinPages are the page layouts of the built-in templates
+var builtinPages = map[Kind]PageOptions{
+	KindHOSLog: {Size: PageSizeLetter, Landscape: true},
+}
+
+// Request describes a document to render
+type Request struct {
+	TenantID string // DefaultTenant when empty
+	Kind     Kind
+	Version  int         // Template version; 0 renders the active template
+	Title    string      // Shown in the letterhead and used as the PDF title
+	Filename string      // Suggested download name, ".pdf" is appended if missing
+	Data     interface{} // Exposed to the template as .Data
+}
+
+// page is the value templates are executed with
+type page struct {
+	Title           string
+	Brand           *Branding
+	Data            interface{}
+	TemplateVersion int
+	GeneratedAt     time.Time
+}
+
+// Generator renders documents from tenant templates and branding
+type Generator struct {
+	templates TemplateStore
+	branding  BrandingStore
+	renderer  Renderer
+	logger    *logger.Logger
+
+	// Template versions are immutable, so parsed templates are cached by
+	// tenant, kind and version
+	mu     sync.Mutex
+	parsed map[string]*template.Template
+}
+
+// NewGenerator creates a document generator. templates and branding may be
+// nil, in which case the built-in templates and default branding are used.
+func NewGenerator(templates TemplateStore, branding BrandingStore, renderer Renderer, log *logger.Logger) *Generator {
+	return &Generator{
+		templates: templates,
+		branding:  branding,
+		renderer:  renderer,
+		logger:    log,
+		parsed:    make(map[string]*template.Template),
+	}
+}
+
+// Render renders a document to PDF
+func (g *Generator) Render(ctx context.Context, req Request) (*Document, error) {
+	html, tmpl, err := g.render(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := g.renderer.RenderPDF(ctx, html, tmpl.Page)
+	if err != nil {
+		g.logger.Errorw("Failed to render document",
+			"tenant_id", tmpl.TenantID,
+			"kind", req.Kind,
+			"template_version", tmpl.Version,
+			"error", err,
+		)
+		return nil, apperrors.Wrap(err, "RENDER_FAILED", "failed to render document")
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = strings.ToLower(string(req.Kind))
+	}
+	if !strings.HasSuffix(strings.ToLower(filename), ".pdf") {
+		filename += ".pdf"
+	}
+
+	return &Document{
+		Kind:            req.Kind,
+		TenantID:        tenantOrDefault(req.TenantID),
+		TemplateVersion: tmpl.Version,
+		Filename:        filename,
+		ContentType:     "application/pdf",
+		Data:            pdf,
+		GeneratedAt:     time.Now(),
+	}, nil
+}
+
+// RenderHTML renders a document's HTML without converting it, for previews
+func (g *Generator) RenderHTML(ctx context.Context, req Request) ([]byte, error) {
+	html, _, err := g.render(ctx, req)
+	return html, err
+}
+
+// PublishTemplate validates a template body and stores it as the tenant's
+// next active version
+func (g *Generator) PublishTemplate(ctx context.Context, t *Template) error {
+	if g.templates == nil {
+		return apperrors.New("NOT_SUPPORTED", "template store not configured")
+	}
+	if _, ok := builtinFiles[t.Kind]; !ok {
+		return apperrors.ValidationError("unknown document kind", "kind", t.Kind)
+	}
+	if _, err := parse(t.Body); err != nil {
+		return apperrors.ValidationError(fmt.Sprintf("invalid template: %v", err), "body", nil)
+	}
+
+	t.TenantID = tenantOrDefault(t.TenantID)
+	if err := g.templates.Publish(ctx, t); err != nil {
+		return apperrors.DatabaseError("publish document template", err)
+	}
+
+	g.logger.Infow("Document template published",
+		"tenant_id", t.TenantID,
+		"kind", t.Kind,
+		"version", t.Version,
+	)
+	return nil
+}
+
+func (g *Generator) render(ctx context.Context, req Request) ([]byte, *Template, error) {
+	tenantID := tenantOrDefault(req.TenantID)
+
+	tmpl, err := g.template(ctx, tenantID, req.Kind, req.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := g.compile(tmpl)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "TEMPLATE_ERROR", "invalid document template")
+	}
+	brand, err := g.brand(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	err = parsed.ExecuteTemplate(&buf, "layout", page{
+		Title:           req.Title,
+		Brand:           brand,
+		Data:            req.Data,
+		TemplateVersion: tmpl.Version,
+		GeneratedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "TEMPLATE_ERROR", "failed to execute document template")
+	}
+	return buf.Bytes(), tmpl, nil
+}
+
+// template resolves the template to render: the requested version, or the
+// tenant's active template falling back to DefaultTenant's and then the
+// built-in one
+func (g *Generator) template(ctx context.Context, tenantID string, kind Kind, version int) (*Template, error) {
+	file, ok := builtinFiles[kind]
+	if !ok {
+		return nil, apperrors.ValidationError("unknown document kind", "kind", kind)
+	}
+
+	if g.templates != nil {
+		if version > 0 {
+			t, err := g.templates.Get(ctx, tenantID, kind, version)
+			if err != nil {
+				return nil, apperrors.DatabaseError("get document template", err)
+			}
+			if t == nil {
+				return nil, apperrors.NotFoundError("document template", fmt.Sprintf("%s/%s/v%d", tenantID, kind, version))
+			}
+			return t, nil
+		}
+
+		for _, owner := range owners(tenantID) {
+			t, err := g.templates.Active(ctx, owner, kind)
+			if err != nil {
+				return nil, apperrors.DatabaseError("get document template", err)
+			}
+			if t != nil {
+				return t, nil
+			}
+		}
+	}
+
+	body, err := builtinFS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{
+		TenantID: DefaultTenant,
+		Kind:     kind,
+		Body:     string(body),
+		Page:     builtinPages[kind],
+		Active:   true,
+	}, nil
+}
+
+func (g *Generator) brand(ctx context.Context, tenantID string) (*Branding, error) {
+	if g.branding == nil {
+		return DefaultBranding(), nil
+	}
+	for _, owner := range owners(tenantID) {
+		b, err := g.branding.Get(ctx, owner)
+		if err != nil {
+			return nil, apperrors.DatabaseError("get branding", err)
+		}
+		if b != nil {
+			return b, nil
+		}
+	}
+	return DefaultBranding(), nil
+}
+
+func (g *Generator) compile(t *Template) (*template.Template, error) {
+	key := t.TenantID + "/" + string(t.Kind) + "/" + strconv.Itoa(t.Version)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p, ok := g.parsed[key]; ok {
+		return p, nil
+	}
+	p, err := parse(t.Body)
+	if err != nil {
+		return nil, err
+	}
+	g.parsed[key] = p
+	return p, nil
+}
+
+// parse parses a template body on top of the shared layout
+func parse(body string) (*template.Template, error) {
+	layout, err := builtinFS.ReadFile("templates/layout.html")
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New("layout").Funcs(funcs).Parse(string(layout))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.New("body").Parse(body); err != nil {
+		return nil, err
+	}
+	if t.Lookup("content") == nil {
+		return nil, fmt.Errorf(`template must define "content"`)
+	}
+	return t, nil
+}
+
+// owners lists the tenants whose templates and branding apply to tenantID,
+// most specific first
+func owners(tenantID string) []string {
+	if tenantID == DefaultTenant {
+		return []string{DefaultTenant}
+	}
+	return []string{tenantID, DefaultTenant}
+}
+
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return DefaultTenant
+	}
+	return tenantID
+}
@@ -0,0 +1,197 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// PostgresTemplateStore implements TemplateStore using PostgreSQL
+type PostgresTemplateStore struct {
+	db *database.DB
+}
+
+// NewPostgresTemplateStore creates a new PostgreSQL template store
+func NewPostgresTemplateStore(db *database.DB) *PostgresTemplateStore {
+	return &PostgresTemplateStore{db: db}
+}
+
+const templateColumns = `id, tenant_id, kind, version, body, page_options, active, created_by, created_at`
+
+// Active returns the tenant's active template for kind
+func (s *PostgresTemplateStore) Active(ctx context.Context, tenantID string, kind Kind) (*Template, error) {
+	row := s.db.Pool.QueryRow(ctx,
+		`SELECT `+templateColumns+` FROM document_templates
+		 WHERE tenant_id = $1 AND kind = $2 AND active`, tenantID, kind)
+	return scanTemplate(row)
+}
+
+// Get returns a specific template version
+func (s *PostgresTemplateStore) Get(ctx context.Context, tenantID string, kind Kind, version int) (*Template, error) {
+	row := s.db.Pool.QueryRow(ctx,
+		`SELECT `+templateColumns+` FROM document_templates
+		 WHERE tenant_id = $1 AND kind = $2 AND version = $3`, tenantID, kind, version)
+	return scanTemplate(row)
+}
+
+// List returns every version of the tenant's template for kind, newest first
+func (s *PostgresTemplateStore) List(ctx context.Context, tenantID string, kind Kind) ([]Template, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT `+templateColumns+` FROM document_templates
+		 WHERE tenant_id = $1 AND kind = $2
+		 ORDER BY version DESC`, tenantID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+// Publish stores t as the next version and makes it active. The previous
+// active version is kept for audit and rollback.
+func (s *PostgresTemplateStore) Publish(ctx context.Context, t *Template) error {
+	page, err := json.Marshal(t.Page)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		// Serialize publishers of the same template so versions stay gapless
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, t.TenantID+"/"+string(t.Kind)); err != nil {
+			return fmt.Errorf("failed to lock document template: %w", err)
+		}
+
+		var version int
+		err := tx.QueryRow(ctx,
+			`SELECT COALESCE(MAX(version), 0) + 1 FROM document_templates
+			 WHERE tenant_id = $1 AND kind = $2`, t.TenantID, t.Kind).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("failed to get next template version: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			`UPDATE document_templates SET active = FALSE
+			 WHERE tenant_id = $1 AND kind = $2 AND active`, t.TenantID, t.Kind); err != nil {
+			return fmt.Errorf("failed to deactivate document template: %w", err)
+		}
+
+		t.ID = uuid.New()
+		t.Version = version
+		t.Active = true
+		t.CreatedAt = time.Now()
+		_, err = tx.Exec(ctx,
+			`INSERT INTO document_templates (`+templateColumns+`)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			t.ID, t.TenantID, t.Kind, t.Version, t.Body, page, t.Active, t.CreatedBy, t.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create document template: %w", err)
+		}
+		return nil
+	})
+}
+
+// Activate makes an existing version the active one
+func (s *PostgresTemplateStore) Activate(ctx context.Context, tenantID string, kind Kind, version int) error {
+	return s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx,
+			`UPDATE document_templates SET active = FALSE
+			 WHERE tenant_id = $1 AND kind = $2 AND active`, tenantID, kind); err != nil {
+			return fmt.Errorf("failed to deactivate document template: %w", err)
+		}
+		tag, err := tx.Exec(ctx,
+			`UPDATE document_templates SET active = TRUE
+			 WHERE tenant_id = $1 AND kind = $2 AND version = $3`, tenantID, kind, version)
+		if err != nil {
+			return fmt.Errorf("failed to activate document template: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("document template not found: %s/%s/v%d", tenantID, kind, version)
+		}
+		return nil
+	})
+}
+
+func scanTemplate(row pgx.Row) (*Template, error) {
+	var t Template
+	var page []byte
+	err := row.Scan(&t.ID, &t.TenantID, &t.Kind, &t.Version, &t.Body, &page, &t.Active, &t.CreatedBy, &t.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan document template: %w", err)
+	}
+	if len(page) > 0 {
+		if err := json.Unmarshal(page, &t.Page); err != nil {
+			return nil, fmt.Errorf("failed to decode page options: %w", err)
+		}
+	}
+	return &t, nil
+}
+
+// PostgresBrandingStore implements BrandingStore using PostgreSQL
+type PostgresBrandingStore struct {
+	db *database.DB
+}
+
+// NewPostgresBrandingStore creates a new PostgreSQL branding store
+func NewPostgresBrandingStore(db *database.DB) *PostgresBrandingStore {
+	return &PostgresBrandingStore{db: db}
+}
+
+const brandingColumns = `tenant_id, company_name, logo_url, primary_color, accent_color,
+	address_line1, address_line2, phone, email, mc_number, dot_number, footer_text, updated_at`
+
+// Get returns the tenant's branding
+func (s *PostgresBrandingStore) Get(ctx context.Context, tenantID string) (*Branding, error) {
+	var b Branding
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT `+brandingColumns+` FROM tenant_branding WHERE tenant_id = $1`, tenantID).Scan(
+		&b.TenantID, &b.CompanyName, &b.LogoURL, &b.PrimaryColor, &b.AccentColor,
+		&b.AddressLine1, &b.AddressLine2, &b.Phone, &b.Email, &b.MCNumber, &b.DOTNumber, &b.FooterText, &b.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get branding: %w", err)
+	}
+	return &b, nil
+}
+
+// Save creates or replaces the tenant's branding
+func (s *PostgresBrandingStore) Save(ctx context.Context, b *Branding) error {
+	b.UpdatedAt = time.Now()
+	_, err := s.db.Pool.Exec(ctx,
+		`INSERT INTO tenant_branding (`+brandingColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 ON CONFLICT (tenant_id) DO UPDATE SET
+			company_name = EXCLUDED.company_name, logo_url = EXCLUDED.logo_url,
+			primary_color = EXCLUDED.primary_color, accent_color = EXCLUDED.accent_color,
+			address_line1 = EXCLUDED.address_line1, address_line2 = EXCLUDED.address_line2,
+			phone = EXCLUDED.phone, email = EXCLUDED.email,
+			mc_number = EXCLUDED.mc_number, dot_number = EXCLUDED.dot_number,
+			footer_text = EXCLUDED.footer_text, updated_at = EXCLUDED.updated_at`,
+		b.TenantID, b.CompanyName, b.LogoURL, b.PrimaryColor, b.AccentColor,
+		b.AddressLine1, b.AddressLine2, b.Phone, b.Email, b.MCNumber, b.DOTNumber, b.FooterText, b.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save branding: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// paperSizes are page dimensions in inches, width then height
+var paperSizes = map[string][2]float64{
+	PageSizeLetter: {8.5, 11},
+	PageSizeLegal:  {8.5, 14},
+	PageSizeA4:     {8.27, 11.7},
+}
+
+// ChromiumRenderer prints HTML to PDF with headless Chromium running behind
+// a Gotenberg-compatible HTTP API, so services do not bundle a browser
+type ChromiumRenderer struct {
+	url    string
+	client *http.Client
+}
+
+// NewChromiumRenderer creates a renderer for the configured endpoint
+func NewChromiumRenderer(cfg config.DocumentConfig) *ChromiumRenderer {
+	return &ChromiumRenderer{
+		url:    strings.TrimRight(cfg.RendererURL, "/") + "/forms/chromium/convert/html",
+		client: &http.Client{Timeout: cfg.RenderTimeout},
+	}
+}
+
+// RenderPDF converts a complete HTML page to PDF
+func (r *ChromiumRenderer) RenderPDF(ctx context.Context, html []byte, page PageOptions) ([]byte, error) {
+	size, ok := paperSizes[strings.ToUpper(page.Size)]
+	if !ok {
+		size = paperSizes[PageSizeLetter]
+	}
+	margin := page.MarginInches
+	if margin <= 0 {
+		margin = 0.5
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("files", "index.html")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(html); err != nil {
+		return nil, err
+	}
+
+	inches := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	fields := map[string]string{
+		"paperWidth":      inches(size[0]),
+		"paperHeight":     inches(size[1]),
+		"marginTop":       inches(margin),
+		"marginBottom":    inches(margin),
+		"marginLeft":      inches(margin),
+		"marginRight":     inches(margin),
+		"landscape":       strconv.FormatBool(page.Landscape),
+		"printBackground": "true",
+	}
+	for k, v := range fields {
+		if err := form.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("render pdf: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("render pdf: renderer returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	pdf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("render pdf: read response: %w", err)
+	}
+	return pdf, nil
+}
@@ -0,0 +1,113 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email to send
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string // Plain text
+	Attachments []Attachment
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPSender sends email through an SMTP relay
+type SMTPSender struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPSender creates a new SMTP sender
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send sends the message. The context is only checked before dialing since
+// net/smtp does not support cancellation.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)
+	if err := smtp.SendMail(addr, auth, s.cfg.From, msg.To, s.build(msg)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// build renders the message as MIME, multipart when there are attachments
+func (s *SMTPSender) build(msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(msg.Attachments) == 0 {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		b.WriteString(msg.Body)
+		return b.Bytes()
+	}
+
+	boundary := "draymaster-" + uuid.NewString()
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+	b.WriteString("\r\n")
+
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n")
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n\r\n", a.Filename)
+
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes()
+}
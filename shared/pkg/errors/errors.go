@@ -108,6 +108,21 @@ func ConflictError(message string) *AppError {
 	}
 }
 
+// VersionConflictError creates a conflict error for an update made against a
+// stale copy, carrying the latest state so the caller can merge and retry
+func VersionConflictError(resourceType string, identifier string, latest interface{}) *AppError {
+	return &AppError{
+		Code:    "CONFLICT",
+		Message: fmt.Sprintf("%s was modified by another user", resourceType),
+		Err:     ErrConflict,
+		Details: map[string]interface{}{
+			"resource_type": resourceType,
+			"identifier":    identifier,
+			"latest":        latest,
+		},
+	}
+}
+
 // InvalidStateError creates an invalid state error
 func InvalidStateError(currentState, requiredState string) *AppError {
 	return &AppError{
@@ -0,0 +1,34 @@
+package errors
+
+import "net/http"
+
+// HTTPStatus returns the HTTP status the REST APIs answer an application
+// error code with. Codes without a mapping are internal errors.
+func HTTPStatus(code string) int {
+	switch code {
+	case "VALIDATION_ERROR", "INVALID_TRIP":
+		return http.StatusBadRequest
+	case "UNAUTHORIZED":
+		return http.StatusUnauthorized
+	case "FORBIDDEN":
+		return http.StatusForbidden
+	case "NOT_FOUND":
+		return http.StatusNotFound
+	case "CONFLICT", "INVALID_STATE", "ESCORT_REQUIRED", "SLOT_FULL", "GATE_WINDOW_CONFLICT", "DRIVER_HOUR_CONFLICT":
+		return http.StatusConflict
+	case "INSUFFICIENT_RESOURCE", "ESCORT_NOT_ALLOWED", "GATE_APPOINTMENT_REQUIRED", "CARRIER_NOT_COMPLIANT",
+		"INSUFFICIENT_PTO", "FACILITY_CLOSED", "RATE_APPROVAL_REQUIRED":
+		return http.StatusUnprocessableEntity
+	case "RATE_LIMITED":
+		return http.StatusTooManyRequests
+	case "EXTERNAL_SERVICE_ERROR":
+		return http.StatusBadGateway
+	case "NOT_IMPLEMENTED":
+		return http.StatusNotImplemented
+	case "SERVICE_UNAVAILABLE":
+		return http.StatusServiceUnavailable
+	case "TIMEOUT":
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
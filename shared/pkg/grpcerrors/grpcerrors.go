@@ -0,0 +1,146 @@
+// Package grpcerrors carries application errors across gRPC calls, so a
+// caller gets back the same error code and details the service raised.
+package grpcerrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+)
+
+// ErrorDomain is the ErrorInfo domain under which gRPC status errors carry
+// an AppError's code and details
+const ErrorDomain = "draymaster"
+
+// Code returns the gRPC code matching the HTTP status of an
+// application error code
+func Code(code string) codes.Code {
+	switch code {
+	case "VALIDATION_ERROR", "INVALID_TRIP":
+		return codes.InvalidArgument
+	case "UNAUTHORIZED":
+		return codes.Unauthenticated
+	case "FORBIDDEN":
+		return codes.PermissionDenied
+	case "NOT_FOUND":
+		return codes.NotFound
+	case "CONFLICT":
+		return codes.Aborted
+	case "RATE_LIMITED":
+		return codes.ResourceExhausted
+	case "EXTERNAL_SERVICE_ERROR", "SERVICE_UNAVAILABLE":
+		return codes.Unavailable
+	case "NOT_IMPLEMENTED":
+		return codes.Unimplemented
+	case "TIMEOUT":
+		return codes.DeadlineExceeded
+	}
+	switch apperrors.HTTPStatus(code) {
+	case http.StatusConflict, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// ToStatus converts a service error into a gRPC status error. An
+// AppError keeps its code and details in an ErrorInfo, so a gateway can
+// answer with the same status and body as the service's REST API. Internal
+// errors hide their cause. Errors that already carry a status pass through.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return status.Error(codes.Internal, "internal error")
+	}
+	code := Code(appErr.Code)
+	if code == codes.Internal {
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	info := &errdetails.ErrorInfo{Reason: appErr.Code, Domain: ErrorDomain}
+	if len(appErr.Details) > 0 {
+		info.Metadata = make(map[string]string, len(appErr.Details))
+		for key, value := range appErr.Details {
+			if encoded, err := json.Marshal(value); err == nil {
+				info.Metadata[key] = string(encoded)
+			}
+		}
+	}
+	st, err := status.New(code, appErr.Message).WithDetails(info)
+	if err != nil {
+		return status.Error(code, appErr.Message)
+	}
+	return st.Err()
+}
+
+// FromStatus turns an error returned by a gRPC call back into an
+// AppError. The code and details a service attached with ToStatus are
+// restored; other statuses are given the code matching their gRPC code.
+func FromStatus(err error) *apperrors.AppError {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorDomain {
+			continue
+		}
+		appErr := apperrors.New(info.Reason, st.Message())
+		for key, raw := range info.Metadata {
+			var value interface{}
+			if json.Unmarshal([]byte(raw), &value) != nil {
+				value = raw
+			}
+			appErr.Details[key] = value
+		}
+		return appErr
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return apperrors.New("VALIDATION_ERROR", st.Message())
+	case codes.Unauthenticated:
+		return apperrors.New("UNAUTHORIZED", st.Message())
+	case codes.PermissionDenied:
+		return apperrors.New("FORBIDDEN", st.Message())
+	case codes.NotFound:
+		return apperrors.New("NOT_FOUND", st.Message())
+	case codes.AlreadyExists, codes.Aborted:
+		return apperrors.New("CONFLICT", st.Message())
+	case codes.FailedPrecondition:
+		return apperrors.New("INVALID_STATE", st.Message())
+	case codes.ResourceExhausted:
+		return apperrors.New("RATE_LIMITED", st.Message())
+	case codes.Unimplemented:
+		return apperrors.New("NOT_IMPLEMENTED", st.Message())
+	case codes.Unavailable:
+		return apperrors.New("SERVICE_UNAVAILABLE", "service unavailable")
+	case codes.DeadlineExceeded:
+		return apperrors.New("TIMEOUT", "upstream request timed out")
+	}
+	return apperrors.Wrap(err, "INTERNAL_ERROR", "internal error")
+}
@@ -0,0 +1,180 @@
+// Package jobs is a Postgres-backed background job queue. Services enqueue
+// jobs - OCR, exports, report generation, invoice rendering - and run a
+// WorkerPool that claims them with FOR UPDATE SKIP LOCKED, so any number of
+// replicas can share a queue without double-processing.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status represents the lifecycle state of a job
+type Status string
+
+const (
+	StatusQueued    Status = "QUEUED"    // Waiting for RunAt, or for a free worker
+	StatusRunning   Status = "RUNNING"   // Claimed by a worker until LockedUntil
+	StatusSucceeded Status = "SUCCEEDED" // Handler returned nil
+	StatusDead      Status = "DEAD"      // Out of attempts or failed permanently
+	StatusCancelled Status = "CANCELLED" // Cancelled before it ran
+)
+
+// Job priorities; higher runs first among jobs that are due
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0
+	PriorityHigh   = 10
+)
+
+// DefaultQueue is used when a job is enqueued without a queue name
+const DefaultQueue = "default"
+
+// Job is one unit of background work
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Queue       string          `json:"queue"`
+	Kind        string          `json:"kind"` // Selects the handler, e.g. "invoice.render"
+	Payload     json.RawMessage `json:"payload"`
+	Priority    int             `json:"priority"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"` // Times the job has been claimed
+	MaxAttempts int             `json:"max_attempts"`
+	UniqueKey   string          `json:"unique_key,omitempty"` // At most one queued or running job per key
+	RunAt       time.Time       `json:"run_at"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// EnqueueOptions controls how and when a job runs
+type EnqueueOptions struct {
+	Queue       string    // DefaultQueue when empty
+	Priority    int       // PriorityNormal when zero
+	RunAt       time.Time // Runs as soon as possible when zero
+	MaxAttempts int       // DefaultMaxAttempts when zero
+	UniqueKey   string    // Skips the enqueue when a job with this key is already queued or running
+}
+
+// DefaultMaxAttempts is how many times a job is tried before it is marked dead
+const DefaultMaxAttempts = 5
+
+// Store persists jobs and hands them out to workers
+type Store interface {
+	// Enqueue stores a new job. It returns the existing job instead when
+	// UniqueKey matches a job that is still queued or running.
+	Enqueue(ctx context.Context, job *Job) (*Job, error)
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	// Claim locks up to limit due jobs from queue for workerID until the
+	// lease expires, highest priority first. Running jobs whose lease has
+	// expired are claimed again, or marked dead when they have used all
+	// their attempts.
+	Claim(ctx context.Context, queue, workerID string, limit int, lease time.Duration) ([]*Job, error)
+	// Extend pushes out the lease of a job the worker still holds
+	Extend(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error
+	Complete(ctx context.Context, id uuid.UUID, workerID string) error
+	// Retry releases a failed job to run again at runAt
+	Retry(ctx context.Context, id uuid.UUID, workerID string, runAt time.Time, errMsg string) error
+	// Kill marks a failed job dead
+	Kill(ctx context.Context, id uuid.UUID, workerID string, errMsg string) error
+	// Cancel cancels a job that has not started, returning false if it already had
+	Cancel(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// ErrLeaseLost is returned by Store updates when the job is no longer held
+// by the worker, usually because its lease expired and another worker took it
+var ErrLeaseLost = errors.New("job lease lost")
+
+// permanentError marks a handler error that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a handler error so the job is marked dead without retrying,
+// e.g. when its payload is invalid
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent checks if a handler error was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Queue enqueues jobs on behalf of a service
+type Queue struct {
+	store Store
+}
+
+// NewQueue creates a job queue client
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store}
+}
+
+// Enqueue schedules a job of kind with payload marshalled to JSON
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, opts EnqueueOptions) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          uuid.New(),
+		Queue:       opts.Queue,
+		Kind:        kind,
+		Payload:     data,
+		Priority:    opts.Priority,
+		Status:      StatusQueued,
+		MaxAttempts: opts.MaxAttempts,
+		UniqueKey:   opts.UniqueKey,
+		RunAt:       opts.RunAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	return q.store.Enqueue(ctx, job)
+}
+
+// EnqueueIn schedules a job to run after delay
+func (q *Queue) EnqueueIn(ctx context.Context, delay time.Duration, kind string, payload interface{}, opts EnqueueOptions) (*Job, error) {
+	opts.RunAt = time.Now().Add(delay)
+	return q.Enqueue(ctx, kind, payload, opts)
+}
+
+// Get returns a job, or nil if it does not exist
+func (q *Queue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// Cancel cancels a job that has not started yet
+func (q *Queue) Cancel(ctx context.Context, id uuid.UUID) (bool, error) {
+	return q.store.Cancel(ctx, id)
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// PostgresStore implements Store using PostgreSQL
+type PostgresStore struct {
+	db *database.DB
+}
+
+// NewPostgresStore creates a new PostgreSQL job store
+func NewPostgresStore(db *database.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const jobColumns = `id, queue, kind, payload, priority, status, attempts, max_attempts, unique_key,
+	run_at, locked_by, locked_until, last_error, created_at, updated_at, finished_at`
+
+// Enqueue stores a new job, or returns the live job with the same unique key
+func (s *PostgresStore) Enqueue(ctx context.Context, job *Job) (*Job, error) {
+	row := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO background_jobs (
+			id, queue, kind, payload, priority, status, attempts, max_attempts, unique_key,
+			run_at, last_error, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, 0, $7, NULLIF($8, ''), $9, '', $10, $11)
+		ON CONFLICT (unique_key) WHERE status IN ('QUEUED', 'RUNNING') DO NOTHING
+		RETURNING `+jobColumns,
+		job.ID, job.Queue, job.Kind, job.Payload, job.Priority, job.Status, job.MaxAttempts, job.UniqueKey,
+		job.RunAt, job.CreatedAt, job.UpdatedAt,
+	)
+	created, err := scanJob(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if created != nil {
+		return created, nil
+	}
+
+	// Lost to a live job with the same unique key
+	existing, err := scanJob(s.db.Pool.QueryRow(ctx,
+		`SELECT `+jobColumns+` FROM background_jobs
+		 WHERE unique_key = $1 AND status IN ('QUEUED', 'RUNNING')`, job.UniqueKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing job: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("job %q finished while enqueueing a duplicate, retry the enqueue", job.UniqueKey)
+	}
+	return existing, nil
+}
+
+// Get returns a job, or nil if it does not exist
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, err := scanJob(s.db.Pool.QueryRow(ctx,
+		`SELECT `+jobColumns+` FROM background_jobs WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim locks due jobs for a worker. SKIP LOCKED lets concurrent workers
+// claim disjoint sets of jobs without waiting on each other. A job whose
+// worker died on its last attempt is marked dead rather than run again.
+func (s *PostgresStore) Claim(ctx context.Context, queue, workerID string, limit int, lease time.Duration) ([]*Job, error) {
+	now := time.Now()
+	rows, err := s.db.Pool.Query(ctx,
+		`WITH exhausted AS (
+			UPDATE background_jobs SET
+				status = 'DEAD', locked_by = NULL, locked_until = NULL,
+				last_error = format('lease expired on attempt %s of %s', attempts, max_attempts),
+				updated_at = $5, finished_at = $5
+			WHERE id IN (
+				SELECT id FROM background_jobs
+				WHERE queue = $1 AND status = 'RUNNING' AND locked_until < $5 AND attempts >= max_attempts
+				FOR UPDATE SKIP LOCKED
+			)
+		)
+		UPDATE background_jobs SET
+			status = 'RUNNING', attempts = attempts + 1,
+			locked_by = $3, locked_until = $4, updated_at = $5
+		WHERE id IN (
+			SELECT id FROM background_jobs
+			WHERE queue = $1 AND run_at <= $5
+			  AND (status = 'QUEUED' OR (status = 'RUNNING' AND locked_until < $5 AND attempts < max_attempts))
+			ORDER BY priority DESC, run_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		queue, limit, workerID, now.Add(lease), now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim jobs: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Extend pushes out the lease of a running job
+func (s *PostgresStore) Extend(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error {
+	now := time.Now()
+	return s.release(ctx, "extend job lease",
+		`UPDATE background_jobs SET locked_until = $3, updated_at = $4
+		 WHERE id = $1 AND locked_by = $2 AND status = 'RUNNING'`,
+		id, workerID, now.Add(lease), now)
+}
+
+// Complete marks a job succeeded
+func (s *PostgresStore) Complete(ctx context.Context, id uuid.UUID, workerID string) error {
+	now := time.Now()
+	return s.release(ctx, "complete job",
+		`UPDATE background_jobs SET
+			status = 'SUCCEEDED', locked_by = NULL, locked_until = NULL,
+			last_error = '', updated_at = $3, finished_at = $3
+		 WHERE id = $1 AND locked_by = $2 AND status = 'RUNNING'`,
+		id, workerID, now)
+}
+
+// Retry queues a failed job to run again
+func (s *PostgresStore) Retry(ctx context.Context, id uuid.UUID, workerID string, runAt time.Time, errMsg string) error {
+	return s.release(ctx, "retry job",
+		`UPDATE background_jobs SET
+			status = 'QUEUED', locked_by = NULL, locked_until = NULL,
+			run_at = $3, last_error = $4, updated_at = $5
+		 WHERE id = $1 AND locked_by = $2 AND status = 'RUNNING'`,
+		id, workerID, runAt, errMsg, time.Now())
+}
+
+// Kill marks a failed job dead
+func (s *PostgresStore) Kill(ctx context.Context, id uuid.UUID, workerID string, errMsg string) error {
+	now := time.Now()
+	return s.release(ctx, "kill job",
+		`UPDATE background_jobs SET
+			status = 'DEAD', locked_by = NULL, locked_until = NULL,
+			last_error = $3, updated_at = $4, finished_at = $4
+		 WHERE id = $1 AND locked_by = $2 AND status = 'RUNNING'`,
+		id, workerID, errMsg, now)
+}
+
+// Cancel cancels a queued job
+func (s *PostgresStore) Cancel(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	tag, err := s.db.Pool.Exec(ctx,
+		`UPDATE background_jobs SET status = 'CANCELLED', updated_at = $2, finished_at = $2
+		 WHERE id = $1 AND status = 'QUEUED'`, id, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Prune deletes succeeded, dead and cancelled jobs that finished before cutoff
+func (s *PostgresStore) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`DELETE FROM background_jobs WHERE finished_at IS NOT NULL AND finished_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// release runs an update guarded by the worker's lease
+func (s *PostgresStore) release(ctx context.Context, operation, query string, args ...interface{}) error {
+	tag, err := s.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func scanJob(row pgx.Row) (*Job, error) {
+	var j Job
+	var uniqueKey, lockedBy *string
+	err := row.Scan(
+		&j.ID, &j.Queue, &j.Kind, &j.Payload, &j.Priority, &j.Status, &j.Attempts, &j.MaxAttempts, &uniqueKey,
+		&j.RunAt, &lockedBy, &j.LockedUntil, &j.LastError, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if uniqueKey != nil {
+		j.UniqueKey = *uniqueKey
+	}
+	if lockedBy != nil {
+		j.LockedBy = *lockedBy
+	}
+	return &j, nil
+}
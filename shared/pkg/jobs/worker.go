@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Handler runs one job. Returning an error retries the job with backoff
+// until MaxAttempts; wrap it with Permanent to give up immediately.
+type Handler func(ctx context.Context, job *Job) error
+
+// WorkerConfig controls a worker pool
+type WorkerConfig struct {
+	Queue        string                          // DefaultQueue when empty
+	Concurrency  int                             // Jobs run at once, 4 when zero
+	PollInterval time.Duration                   // Wait between claims when the queue is empty, 1s when zero
+	Lease        time.Duration                   // How long a claimed job is held without a heartbeat, 5m when zero
+	Timeout      time.Duration                   // Per-job deadline, none when zero
+	Backoff      func(attempt int) time.Duration // Delay before retrying after the given attempt, ExponentialBackoff when nil
+}
+
+// ExponentialBackoff waits 30s after the first failed attempt, doubling up to an hour
+func ExponentialBackoff(attempt int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// WorkerPool claims jobs from one queue and runs them with registered handlers
+type WorkerPool struct {
+	store    Store
+	config   WorkerConfig
+	workerID string
+	logger   *logger.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewWorkerPool creates a worker pool for cfg.Queue
+func NewWorkerPool(store Store, cfg WorkerConfig, log *logger.Logger) *WorkerPool {
+	if cfg.Queue == "" {
+		cfg.Queue = DefaultQueue
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = ExponentialBackoff
+	}
+
+	host, _ := os.Hostname()
+	return &WorkerPool{
+		store:    store,
+		config:   cfg,
+		workerID: fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.New().String()[:8]),
+		logger:   log,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle registers the handler for a job kind
+func (p *WorkerPool) Handle(kind string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[kind] = handler
+}
+
+// Run claims and runs jobs until ctx is cancelled, then waits for running
+// jobs to return. Jobs interrupted by shutdown are retried later.
+func (p *WorkerPool) Run(ctx context.Context) {
+	p.logger.Infow("Job worker pool started",
+		"queue", p.config.Queue,
+		"worker_id", p.workerID,
+		"concurrency", p.config.Concurrency,
+	)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, p.config.Concurrency)
+	done := make(chan struct{}, p.config.Concurrency)
+
+	for {
+		free := p.config.Concurrency - len(slots)
+		claimed := 0
+		if free > 0 {
+			jobs, err := p.store.Claim(ctx, p.config.Queue, p.workerID, free, p.config.Lease)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Errorw("Failed to claim jobs", "queue", p.config.Queue, "error", err)
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func(job *Job) {
+					defer wg.Done()
+					p.run(ctx, job)
+					<-slots
+					select {
+					case done <- struct{}{}:
+					default:
+					}
+				}(job)
+			}
+			claimed = len(jobs)
+		}
+
+		// A full batch means more jobs are probably due, so claim again
+		// straight away while there are free workers
+		if claimed > 0 && claimed == free {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			p.logger.Infow("Job worker pool stopped", "queue", p.config.Queue, "worker_id", p.workerID)
+			return
+		case <-done:
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// run executes one claimed job and records the outcome
+func (p *WorkerPool) run(ctx context.Context, job *Job) {
+	p.mu.RLock()
+	handler, ok := p.handlers[job.Kind]
+	p.mu.RUnlock()
+
+	// Outcomes are saved even while shutting down
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancelSave()
+
+	if !ok {
+		p.finish(saveCtx, job, Permanent(fmt.Errorf("no handler registered for job kind %q", job.Kind)))
+		return
+	}
+
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if p.config.Timeout > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, p.config.Timeout)
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		p.heartbeat(jobCtx, job, cancel)
+	}()
+
+	started := time.Now()
+	err := p.invoke(jobCtx, handler, job)
+	cancel()
+	<-heartbeatDone
+
+	p.finish(saveCtx, job, err)
+	if err == nil {
+		p.logger.Infow("Job succeeded",
+			"job_id", job.ID,
+			"kind", job.Kind,
+			"attempt", job.Attempts,
+			"duration_ms", time.Since(started).Milliseconds(),
+		)
+	}
+}
+
+// invoke calls the handler, turning a panic into a job failure
+func (p *WorkerPool) invoke(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// heartbeat extends the job's lease while the handler runs and cancels the
+// handler if another worker has taken the job over
+func (p *WorkerPool) heartbeat(ctx context.Context, job *Job, cancel context.CancelFunc) {
+	ticker := time.NewTicker(p.config.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.store.Extend(ctx, job.ID, p.workerID, p.config.Lease)
+			if errors.Is(err, ErrLeaseLost) {
+				p.logger.Warnw("Job lease lost, stopping handler", "job_id", job.ID, "kind", job.Kind)
+				cancel()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				p.logger.Warnw("Failed to extend job lease", "job_id", job.ID, "error", err)
+			}
+		}
+	}
+}
+
+// finish records a job's outcome: succeeded, retried with backoff, or dead
+func (p *WorkerPool) finish(ctx context.Context, job *Job, handlerErr error) {
+	var err error
+	switch {
+	case handlerErr == nil:
+		err = p.store.Complete(ctx, job.ID, p.workerID)
+	case IsPermanent(handlerErr) || job.Attempts >= job.MaxAttempts:
+		p.logger.Errorw("Job failed permanently",
+			"job_id", job.ID,
+			"kind", job.Kind,
+			"attempt", job.Attempts,
+			"error", handlerErr,
+		)
+		err = p.store.Kill(ctx, job.ID, p.workerID, handlerErr.Error())
+	default:
+		retryAt := time.Now().Add(p.config.Backoff(job.Attempts))
+		p.logger.Warnw("Job failed, will retry",
+			"job_id", job.ID,
+			"kind", job.Kind,
+			"attempt", job.Attempts,
+			"retry_at", retryAt,
+			"error", handlerErr,
+		)
+		err = p.store.Retry(ctx, job.ID, p.workerID, retryAt, handlerErr.Error())
+	}
+
+	if errors.Is(err, ErrLeaseLost) {
+		p.logger.Warnw("Job outcome discarded, lease was lost", "job_id", job.ID, "kind", job.Kind)
+	} else if err != nil {
+		p.logger.Errorw("Failed to record job outcome", "job_id", job.ID, "kind", job.Kind, "error", err)
+	}
+}
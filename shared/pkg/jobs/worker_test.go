@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// outcomeStore records how a worker finished each job
+type outcomeStore struct {
+	Store
+
+	mu       sync.Mutex
+	outcome  string
+	errMsg   string
+	retryAt  time.Time
+	leaseErr error
+}
+
+func (s *outcomeStore) record(outcome, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcome, s.errMsg = outcome, errMsg
+	return s.leaseErr
+}
+
+func (s *outcomeStore) Complete(ctx context.Context, id uuid.UUID, workerID string) error {
+	return s.record("complete", "")
+}
+
+func (s *outcomeStore) Retry(ctx context.Context, id uuid.UUID, workerID string, runAt time.Time, errMsg string) error {
+	s.mu.Lock()
+	s.retryAt = runAt
+	s.mu.Unlock()
+	return s.record("retry", errMsg)
+}
+
+func (s *outcomeStore) Kill(ctx context.Context, id uuid.UUID, workerID string, errMsg string) error {
+	return s.record("kill", errMsg)
+}
+
+func (s *outcomeStore) Extend(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error {
+	return nil
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return log
+}
+
+func TestWorkerPoolOutcomes(t *testing.T) {
+	failed := errors.New("carrier API timed out")
+	tests := []struct {
+		name        string
+		kind        string
+		attempts    int
+		handler     Handler
+		wantOutcome string
+		wantErr     string
+		wantDelay   time.Duration
+	}{
+		{
+			name:        "success",
+			kind:        "render",
+			attempts:    1,
+			handler:     func(ctx context.Context, job *Job) error { return nil },
+			wantOutcome: "complete",
+		},
+		{
+			name:        "failure retries with backoff",
+			kind:        "render",
+			attempts:    2,
+			handler:     func(ctx context.Context, job *Job) error { return failed },
+			wantOutcome: "retry",
+			wantErr:     failed.Error(),
+			wantDelay:   2 * time.Minute,
+		},
+		{
+			name:        "failure on the last attempt",
+			kind:        "render",
+			attempts:    3,
+			handler:     func(ctx context.Context, job *Job) error { return failed },
+			wantOutcome: "kill",
+			wantErr:     failed.Error(),
+		},
+		{
+			name:        "permanent failure",
+			kind:        "render",
+			attempts:    1,
+			handler:     func(ctx context.Context, job *Job) error { return Permanent(errors.New("bad payload")) },
+			wantOutcome: "kill",
+			wantErr:     "bad payload",
+		},
+		{
+			name:        "panic is a retryable failure",
+			kind:        "render",
+			attempts:    1,
+			handler:     func(ctx context.Context, job *Job) error { panic("nil pointer") },
+			wantOutcome: "retry",
+			wantErr:     "job panicked: nil pointer",
+			wantDelay:   time.Minute,
+		},
+		{
+			name:        "unknown kind",
+			kind:        "unregistered",
+			attempts:    1,
+			wantOutcome: "kill",
+			wantErr:     `no handler registered for job kind "unregistered"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &outcomeStore{}
+			pool := NewWorkerPool(store, WorkerConfig{
+				Backoff: func(attempt int) time.Duration { return time.Duration(attempt) * time.Minute },
+			}, testLogger(t))
+			if tt.handler != nil {
+				pool.Handle("render", tt.handler)
+			}
+
+			before := time.Now()
+			pool.run(context.Background(), &Job{ID: uuid.New(), Kind: tt.kind, Attempts: tt.attempts, MaxAttempts: 3})
+
+			if store.outcome != tt.wantOutcome || store.errMsg != tt.wantErr {
+				t.Errorf("outcome = %s %q, want %s %q", store.outcome, store.errMsg, tt.wantOutcome, tt.wantErr)
+			}
+			if tt.wantDelay > 0 {
+				if delay := store.retryAt.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+					t.Errorf("retry delay = %v, want %v", delay, tt.wantDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerPoolTimeoutRetries(t *testing.T) {
+	store := &outcomeStore{}
+	pool := NewWorkerPool(store, WorkerConfig{Timeout: 10 * time.Millisecond}, testLogger(t))
+	pool.Handle("ocr", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	pool.run(context.Background(), &Job{ID: uuid.New(), Kind: "ocr", Attempts: 1, MaxAttempts: 5})
+	if store.outcome != "retry" || !strings.Contains(store.errMsg, "deadline exceeded") {
+		t.Errorf("outcome = %s %q, want a retry after the deadline", store.outcome, store.errMsg)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := ExponentialBackoff(tt.attempt); got != tt.want {
+			t.Errorf("ExponentialBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -3,12 +3,17 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
 	
+	"github.com/draymaster/shared/pkg/config"
 	"github.com/draymaster/shared/pkg/logger"
 )
 
@@ -49,84 +54,156 @@ func (e *Event) WithMetadata(key, value string) *Event {
 	return e
 }
 
-// Producer handles publishing events to Kafka
-type Producer struct {
-	writer *kafka.Writer
-	logger *logger.Logger
-}
+// MetadataTags is the event metadata key carrying the tags of the trip or
+// order an event is about, comma separated
+const MetadataTags = "tags"
 
-// NewProducer creates a new Kafka producer
-func NewProducer(brokers []string, log *logger.Logger) *Producer {
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(brokers...),
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-		RequiredAcks: kafka.RequireAll,
-		Async:        false,
+// WithTags adds the subject's tags to event metadata, so boards can
+// highlight tagged work without knowing each payload. No tags adds nothing.
+func (e *Event) WithTags(tags []string) *Event {
+	if len(tags) == 0 {
+		return e
 	}
+	return e.WithMetadata(MetadataTags, strings.Join(tags, ","))
+}
 
-	return &Producer{
-		writer: writer,
-		logger: log,
+// Tags returns the tags carried in event metadata, if any
+func (e *Event) Tags() []string {
+	if e.Metadata[MetadataTags] == "" {
+		return nil
 	}
+	return strings.Split(e.Metadata[MetadataTags], ",")
 }
 
-// Publish publishes an event to a topic
-func (p *Producer) Publish(ctx context.Context, topic string, event *Event) error {
-	data, err := json.Marshal(event)
+// DecodeData decodes the event payload into v. Consumed events carry their
+// payload as generic JSON, so typed consumers decode it again.
+func (e *Event) DecodeData(v interface{}) error {
+	raw, err := json.Marshal(e.Data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return err
 	}
+	return json.Unmarshal(raw, v)
+}
 
-	msg := kafka.Message{
-		Topic: topic,
-		Key:   []byte(event.ID),
-		Value: data,
-		Time:  event.Time,
-		Headers: []kafka.Header{
-			{Key: "event_type", Value: []byte(event.Type)},
-			{Key: "source", Value: []byte(event.Source)},
-		},
-	}
+// ErrPermanent marks a handler error that retrying cannot fix, such as a
+// malformed payload. The event is dead-lettered straight away.
+var ErrPermanent = errors.New("permanent event failure")
 
-	if event.CorrelationID != "" {
-		msg.Headers = append(msg.Headers, kafka.Header{
-			Key:   "correlation_id",
-			Value: []byte(event.CorrelationID),
-		})
-	}
+// Permanent wraps err so the consumer dead-letters the event without retrying
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
 
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
-		p.logger.Errorw("Failed to publish event",
-			"topic", topic,
-			"event_type", event.Type,
-			"error", err,
-		)
-		return fmt.Errorf("failed to publish event: %w", err)
+// DeadLetterSuffix is appended to a topic to name its dead-letter topic
+const DeadLetterSuffix = ".dlq"
+
+// DeadLetterTopic returns the topic events that cannot be handled from
+// topic are sent to, e.g. containers.added.dlq
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// Headers added to dead-lettered messages, alongside the original headers
+const (
+	HeaderDLQTopic     = "dlq-original-topic"
+	HeaderDLQPartition = "dlq-original-partition"
+	HeaderDLQOffset    = "dlq-original-offset"
+	HeaderDLQGroup     = "dlq-consumer-group"
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQAttempts  = "dlq-attempts"
+	HeaderDLQFailedAt  = "dlq-failed-at"
+)
+
+// ConsumerConfig controls how failed events are retried and dead-lettered
+type ConsumerConfig struct {
+	MaxRetries     int           // Retries after the first attempt; 0 dead-letters on the first failure
+	InitialBackoff time.Duration // Delay before the first retry, doubling on each retry
+	MaxBackoff     time.Duration // Cap on the retry delay
+	DeadLetter     bool          // Send events that still fail to the dead-letter topic; otherwise they are logged and skipped
+}
+
+// DefaultConsumerConfig returns the consumer settings used by NewConsumer
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		MaxRetries:     3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		DeadLetter:     true,
 	}
+}
 
-	p.logger.Debugw("Event published",
-		"topic", topic,
-		"event_id", event.ID,
-		"event_type", event.Type,
-	)
+// ConsumerConfigFrom builds consumer settings from service configuration
+func ConsumerConfigFrom(cfg config.KafkaConfig) ConsumerConfig {
+	cc := DefaultConsumerConfig()
+	cc.DeadLetter = cfg.DeadLetter
+	if cfg.MaxRetries >= 0 {
+		cc.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.RetryBackoff > 0 {
+		cc.InitialBackoff = cfg.RetryBackoff
+	}
+	if cfg.MaxBackoff > 0 {
+		cc.MaxBackoff = cfg.MaxBackoff
+	}
+	return cc
+}
 
-	return nil
+// backoff returns the delay before retry n (1-based)
+func (c ConsumerConfig) backoff(n int) time.Duration {
+	d := c.InitialBackoff
+	for i := 1; i < n && d < c.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > c.MaxBackoff {
+		d = c.MaxBackoff
+	}
+	return d
 }
 
-// Close closes the producer
-func (p *Producer) Close() error {
-	return p.writer.Close()
+// ConsumerMetrics counts how a consumer's events were handled
+type ConsumerMetrics struct {
+	Topic        string `json:"topic"`
+	Handled      uint64 `json:"handled"`
+	Retries      uint64 `json:"retries"`
+	DeadLettered uint64 `json:"dead_lettered"`
+	Dropped      uint64 `json:"dropped"` // Failed with dead-lettering off
 }
 
-// Consumer handles consuming events from Kafka
+// Consumer handles consuming events from Kafka. A failing event is retried
+// with exponential backoff and then sent to the topic's dead-letter topic,
+// so one bad event cannot stall the partition or crash the service.
 type Consumer struct {
-	reader *kafka.Reader
-	logger *logger.Logger
+	reader  *kafka.Reader
+	dlq     messageWriter
+	groupID string
+	topic   string
+	cfg     ConsumerConfig
+	logger  *logger.Logger
+
+	handled      uint64
+	retries      uint64
+	deadLettered uint64
+	dropped      uint64
 }
 
-// NewConsumer creates a new Kafka consumer
+// NewConsumer creates a new Kafka consumer with DefaultConsumerConfig
 func NewConsumer(brokers []string, groupID, topic string, log *logger.Logger) *Consumer {
+	return NewConsumerWithConfig(brokers, groupID, topic, DefaultConsumerConfig(), log)
+}
+
+// NewConsumerWithConfig creates a new Kafka consumer
+func NewConsumerWithConfig(brokers []string, groupID, topic string, cfg ConsumerConfig, log *logger.Logger) *Consumer {
+	defaults := DefaultConsumerConfig()
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaults.InitialBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        brokers,
 		GroupID:        groupID,
@@ -138,16 +215,31 @@ func NewConsumer(brokers []string, groupID, topic string, log *logger.Logger) *C
 		CommitInterval: time.Second,
 	})
 
-	return &Consumer{
-		reader: reader,
-		logger: log,
+	c := &Consumer{
+		reader:  reader,
+		groupID: groupID,
+		topic:   topic,
+		cfg:     cfg,
+		logger:  log,
+	}
+	if cfg.DeadLetter {
+		c.dlq = &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  DeadLetterTopic(topic),
+			Balancer:               &kafka.Hash{}, // Keep an entity's failures in order
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		}
 	}
+	return c
 }
 
 // Handler is a function that handles events
 type Handler func(ctx context.Context, event *Event) error
 
-// Consume starts consuming events and calls the handler for each
+// Consume starts consuming events and calls the handler for each. A message
+// is committed once it is handled or dead-lettered; if the dead-letter topic
+// cannot be written the message is retried rather than lost.
 func (c *Consumer) Consume(ctx context.Context, handler Handler) error {
 	for {
 		select {
@@ -163,30 +255,9 @@ func (c *Consumer) Consume(ctx context.Context, handler Handler) error {
 				continue
 			}
 
-			var event Event
-			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				c.logger.Errorw("Failed to unmarshal event",
-					"error", err,
-					"topic", msg.Topic,
-				)
-				// Commit anyway to avoid stuck consumer
-				_ = c.reader.CommitMessages(ctx, msg)
-				continue
-			}
-
-			c.logger.Debugw("Event received",
-				"topic", msg.Topic,
-				"event_id", event.ID,
-				"event_type", event.Type,
-			)
-
-			if err := handler(ctx, &event); err != nil {
-				c.logger.Errorw("Failed to handle event",
-					"error", err,
-					"event_id", event.ID,
-					"event_type", event.Type,
-				)
-				// Could implement retry logic or dead letter queue here
+			if err := c.process(ctx, msg, handler); err != nil {
+				// Only cancellation gets here; leave the message uncommitted
+				return err
 			}
 
 			if err := c.reader.CommitMessages(ctx, msg); err != nil {
@@ -196,8 +267,151 @@ func (c *Consumer) Consume(ctx context.Context, handler Handler) error {
 	}
 }
 
+// process handles one message, retrying and dead-lettering it as needed.
+// It returns an error only when ctx is cancelled first.
+func (c *Consumer) process(ctx context.Context, msg kafka.Message, handler Handler) error {
+	var event Event
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.Errorw("Failed to unmarshal event",
+			"error", err,
+			"topic", msg.Topic,
+			"offset", msg.Offset,
+		)
+		return c.deadLetter(ctx, msg, fmt.Errorf("unmarshal event: %w", err), 1)
+	}
+
+	c.logger.Debugw("Event received",
+		"topic", msg.Topic,
+		"event_id", event.ID,
+		"event_type", event.Type,
+	)
+
+	attempts := 0
+	for {
+		attempts++
+		err := c.handle(ctx, handler, &event)
+		if err == nil {
+			atomic.AddUint64(&c.handled, 1)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		c.logger.Errorw("Failed to handle event",
+			"error", err,
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"attempt", attempts,
+		)
+		if errors.Is(err, ErrPermanent) || attempts > c.cfg.MaxRetries {
+			return c.deadLetter(ctx, msg, err, attempts)
+		}
+
+		atomic.AddUint64(&c.retries, 1)
+		if err := sleep(ctx, c.cfg.backoff(attempts)); err != nil {
+			return err
+		}
+	}
+}
+
+// handle calls the handler, turning a panic into a permanent failure
+func (c *Consumer) handle(ctx context.Context, handler Handler, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("handler panic: %v", r))
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// deadLetter sends a message that cannot be handled to the dead-letter
+// topic, retrying the write until it succeeds or ctx is cancelled. With
+// dead-lettering off the message is logged and skipped.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	if c.dlq == nil {
+		atomic.AddUint64(&c.dropped, 1)
+		c.logger.Errorw("Dropping event that could not be handled",
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset,
+			"error", cause,
+		)
+		return nil
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQGroup, Value: []byte(c.groupID)},
+		kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+	dead := kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers}
+
+	for retry := 1; ; retry++ {
+		err := c.dlq.WriteMessages(ctx, dead)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.logger.Errorw("Failed to dead-letter event, retrying",
+			"topic", DeadLetterTopic(msg.Topic),
+			"offset", msg.Offset,
+			"error", err,
+		)
+		if err := sleep(ctx, c.cfg.backoff(retry)); err != nil {
+			return err
+		}
+	}
+
+	atomic.AddUint64(&c.deadLettered, 1)
+	c.logger.Warnw("Event dead-lettered",
+		"topic", msg.Topic,
+		"dlq_topic", DeadLetterTopic(msg.Topic),
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+		"attempts", attempts,
+		"error", cause,
+	)
+	return nil
+}
+
+// Metrics returns how the consumer's events have been handled so far
+func (c *Consumer) Metrics() ConsumerMetrics {
+	return ConsumerMetrics{
+		Topic:        c.topic,
+		Handled:      atomic.LoadUint64(&c.handled),
+		Retries:      atomic.LoadUint64(&c.retries),
+		DeadLettered: atomic.LoadUint64(&c.deadLettered),
+		Dropped:      atomic.LoadUint64(&c.dropped),
+	}
+}
+
 // Close closes the consumer
 func (c *Consumer) Close() error {
-	return c.reader.Close()
+	err := c.reader.Close()
+	if c.dlq != nil {
+		if dlqErr := c.dlq.Close(); err == nil {
+			err = dlqErr
+		}
+	}
+	return err
 }
 
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
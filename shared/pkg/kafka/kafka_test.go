@@ -0,0 +1,325 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// testConsumer returns a consumer that dead-letters to w, or drops failed
+// events when w is nil. process and deadLetter never touch the reader.
+func testConsumer(t *testing.T, w *fakeWriter, cfg ConsumerConfig) *Consumer {
+	c := &Consumer{groupID: "billing-service", topic: "orders.completed", cfg: cfg, logger: testLogger(t)}
+	if w != nil {
+		c.dlq = w
+	}
+	return c
+}
+
+func testMessage(t *testing.T) kafka.Message {
+	t.Helper()
+	value, err := json.Marshal(testEvent())
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	return kafka.Message{
+		Topic:     "orders.completed",
+		Partition: 3,
+		Offset:    1042,
+		Key:       []byte("order-1001"),
+		Value:     value,
+		Headers:   []kafka.Header{{Key: "correlation-id", Value: []byte("req-7")}},
+	}
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestConsumerProcess(t *testing.T) {
+	failed := errors.New("database unavailable")
+	tests := []struct {
+		name         string
+		value        string // Replaces the event when set
+		handler      func(attempt int) error
+		deadLetter   bool
+		wantCalls    int
+		wantMetrics  ConsumerMetrics
+		wantDLQError string // Empty means nothing is dead-lettered
+		wantAttempts string
+	}{
+		{
+			name:        "handled first time",
+			handler:     func(int) error { return nil },
+			deadLetter:  true,
+			wantCalls:   1,
+			wantMetrics: ConsumerMetrics{Handled: 1},
+		},
+		{
+			name: "handled after retries",
+			handler: func(attempt int) error {
+				if attempt < 3 {
+					return failed
+				}
+				return nil
+			},
+			deadLetter:  true,
+			wantCalls:   3,
+			wantMetrics: ConsumerMetrics{Handled: 1, Retries: 2},
+		},
+		{
+			name:         "dead-lettered once retries run out",
+			handler:      func(int) error { return failed },
+			deadLetter:   true,
+			wantCalls:    4,
+			wantMetrics:  ConsumerMetrics{Retries: 3, DeadLettered: 1},
+			wantDLQError: failed.Error(),
+			wantAttempts: "4",
+		},
+		{
+			name:         "permanent failure is not retried",
+			handler:      func(int) error { return Permanent(errors.New("unknown container size")) },
+			deadLetter:   true,
+			wantCalls:    1,
+			wantMetrics:  ConsumerMetrics{DeadLettered: 1},
+			wantDLQError: "permanent event failure: unknown container size",
+			wantAttempts: "1",
+		},
+		{
+			name:         "panic is a permanent failure",
+			handler:      func(int) error { panic("nil map") },
+			deadLetter:   true,
+			wantCalls:    1,
+			wantMetrics:  ConsumerMetrics{DeadLettered: 1},
+			wantDLQError: "permanent event failure: handler panic: nil map",
+			wantAttempts: "1",
+		},
+		{
+			name:         "malformed event is dead-lettered unhandled",
+			value:        "{not json",
+			handler:      func(int) error { return nil },
+			deadLetter:   true,
+			wantMetrics:  ConsumerMetrics{DeadLettered: 1},
+			wantDLQError: "unmarshal event",
+			wantAttempts: "1",
+		},
+		{
+			name:        "dropped with dead-lettering off",
+			handler:     func(int) error { return failed },
+			wantCalls:   4,
+			wantMetrics: ConsumerMetrics{Retries: 3, Dropped: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dlq *fakeWriter
+			if tt.deadLetter {
+				dlq = newFakeWriter()
+			}
+			c := testConsumer(t, dlq, ConsumerConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, DeadLetter: tt.deadLetter})
+			msg := testMessage(t)
+			if tt.value != "" {
+				msg.Value = []byte(tt.value)
+			}
+
+			calls := 0
+			err := c.process(context.Background(), msg, func(ctx context.Context, event *Event) error {
+				calls++
+				if event.Type != "test.event" {
+					t.Errorf("event type = %q, want test.event", event.Type)
+				}
+				return tt.handler(calls)
+			})
+			if err != nil {
+				t.Fatalf("process() error = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			tt.wantMetrics.Topic = "orders.completed"
+			if got := c.Metrics(); got != tt.wantMetrics {
+				t.Errorf("Metrics() = %+v, want %+v", got, tt.wantMetrics)
+			}
+
+			if dlq == nil {
+				return
+			}
+			if tt.wantDLQError == "" {
+				if len(dlq.batches) != 0 {
+					t.Errorf("dead-lettered %+v, want nothing", dlq.batches)
+				}
+				return
+			}
+			if len(dlq.batches) != 1 || len(dlq.batches[0]) != 1 {
+				t.Fatalf("dead-letter writes = %v, want one message", dlq.batchSizes())
+			}
+			dead := dlq.batches[0][0]
+			if !strings.Contains(header(dead, HeaderDLQError), tt.wantDLQError) {
+				t.Errorf("%s = %q, want it to contain %q", HeaderDLQError, header(dead, HeaderDLQError), tt.wantDLQError)
+			}
+			if got := header(dead, HeaderDLQAttempts); got != tt.wantAttempts {
+				t.Errorf("%s = %q, want %q", HeaderDLQAttempts, got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestConsumerDeadLetterKeepsMessage(t *testing.T) {
+	dlq := newFakeWriter()
+	c := testConsumer(t, dlq, ConsumerConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, DeadLetter: true})
+	msg := testMessage(t)
+
+	if err := c.deadLetter(context.Background(), msg, errors.New("bad payload"), 2); err != nil {
+		t.Fatalf("deadLetter() error = %v", err)
+	}
+	dead := dlq.batches[0][0]
+	if string(dead.Key) != "order-1001" || string(dead.Value) != string(msg.Value) {
+		t.Errorf("dead-lettered key %q value %q, want the original message", dead.Key, dead.Value)
+	}
+	if dead.Topic != "" {
+		t.Errorf("message topic = %q, want the writer's dead-letter topic", dead.Topic)
+	}
+	want := map[string]string{
+		"correlation-id":   "req-7",
+		HeaderDLQTopic:     "orders.completed",
+		HeaderDLQPartition: "3",
+		HeaderDLQOffset:    "1042",
+		HeaderDLQGroup:     "billing-service",
+		HeaderDLQError:     "bad payload",
+		HeaderDLQAttempts:  "2",
+	}
+	for key, value := range want {
+		if got := header(dead, key); got != value {
+			t.Errorf("header %s = %q, want %q", key, got, value)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, header(dead, HeaderDLQFailedAt)); err != nil {
+		t.Errorf("header %s = %q, want an RFC 3339 time", HeaderDLQFailedAt, header(dead, HeaderDLQFailedAt))
+	}
+	if len(msg.Headers) != 1 {
+		t.Errorf("original message headers changed to %d", len(msg.Headers))
+	}
+}
+
+func TestConsumerDeadLetterRetriesWrites(t *testing.T) {
+	dlq := newFakeWriter()
+	dlq.err = errors.New("leader not available")
+	c := testConsumer(t, dlq, ConsumerConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, DeadLetter: true})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.deadLetter(context.Background(), testMessage(t), errors.New("bad payload"), 1)
+	}()
+
+	// The broker comes back after two failed writes
+	waitWrite(t, dlq, time.Second)
+	waitWrite(t, dlq, time.Second)
+	dlq.mu.Lock()
+	dlq.err = nil
+	dlq.mu.Unlock()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("deadLetter() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("deadLetter() did not return once the write succeeded")
+	}
+	if got := c.Metrics().DeadLettered; got != 1 {
+		t.Errorf("dead-lettered = %d, want 1", got)
+	}
+}
+
+func TestConsumerProcessStopsOnCancel(t *testing.T) {
+	t.Run("during backoff", func(t *testing.T) {
+		dlq := newFakeWriter()
+		c := testConsumer(t, dlq, ConsumerConfig{MaxRetries: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour, DeadLetter: true})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var once sync.Once
+		err := c.process(ctx, testMessage(t), func(ctx context.Context, event *Event) error {
+			once.Do(func() { time.AfterFunc(10*time.Millisecond, cancel) })
+			return errors.New("timeout")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("process() error = %v, want context.Canceled", err)
+		}
+		if len(dlq.batches) != 0 {
+			t.Error("a cancelled event was dead-lettered; it should be left uncommitted")
+		}
+	})
+
+	t.Run("while the dead-letter topic is down", func(t *testing.T) {
+		dlq := newFakeWriter()
+		dlq.err = errors.New("leader not available")
+		c := testConsumer(t, dlq, ConsumerConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, DeadLetter: true})
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := c.process(ctx, testMessage(t), func(ctx context.Context, event *Event) error {
+			return Permanent(errors.New("bad payload"))
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("process() error = %v, want context.DeadlineExceeded", err)
+		}
+		if got := c.Metrics().DeadLettered; got != 0 {
+			t.Errorf("dead-lettered = %d, want 0 while writes fail", got)
+		}
+	})
+}
+
+func TestConsumerBackoff(t *testing.T) {
+	cfg := ConsumerConfig{InitialBackoff: 500 * time.Millisecond, MaxBackoff: 3 * time.Second}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 3 * time.Second},
+		{10, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := cfg.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestConsumerProcessWaitsBetweenRetries(t *testing.T) {
+	c := testConsumer(t, newFakeWriter(), ConsumerConfig{MaxRetries: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond, DeadLetter: true})
+
+	var attempts []time.Time
+	start := time.Now()
+	if err := c.process(context.Background(), testMessage(t), func(ctx context.Context, event *Event) error {
+		attempts = append(attempts, time.Now())
+		return errors.New("timeout")
+	}); err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+
+	// 10ms, then 20ms, then 20ms at the cap
+	want := []time.Duration{0, 10 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond}
+	if len(attempts) != len(want) {
+		t.Fatalf("attempts = %d, want %d", len(attempts), len(want))
+	}
+	for i, at := range attempts {
+		if elapsed := at.Sub(start); elapsed < want[i] {
+			t.Errorf("attempt %d after %v, want at least %v", i+1, elapsed, want[i])
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import "time"
+
+// Operational alert payloads. The chat-ops relay decodes them with
+// Event.DecodeData and posts them to ops channels.
+
+// ContainerLFDRiskEvent is published on Topics.ContainerLFDRisk when an
+// import container is still at the terminal close to its last free day
+type ContainerLFDRiskEvent struct {
+	ContainerID     string    `json:"container_id"`
+	ContainerNumber string    `json:"container_number"`
+	ShipmentID      string    `json:"shipment_id"`
+	ReferenceNumber string    `json:"reference_number,omitempty"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	TerminalID      string    `json:"terminal_id,omitempty"`
+	LastFreeDay     time.Time `json:"last_free_day"`
+	HoursRemaining  int       `json:"hours_remaining"` // Until the end of the last free day
+}
+
+// VesselETAChangedEvent is published on Topics.VesselETAChanged when live
+// AIS positions move an inbound vessel's ETA past the drift threshold and
+// its shipments' ETAs are updated
+type VesselETAChangedEvent struct {
+	VesselName   string              `json:"vessel_name"`
+	VoyageNumber string              `json:"voyage_number,omitempty"`
+	IMO          string              `json:"imo,omitempty"`
+	TerminalID   string              `json:"terminal_id,omitempty"`
+	TerminalName string              `json:"terminal_name,omitempty"`
+	PreviousETA  *time.Time          `json:"previous_eta,omitempty"` // Of the shipment that moved most
+	NewETA       time.Time           `json:"new_eta"`
+	DriftHours   float64             `json:"drift_hours"` // Largest move; negative when early
+	Source       string              `json:"source"`      // AIS_POSITION or AIS_REPORTED
+	Shipments    []VesselETAShipment `json:"shipments"`
+}
+
+// VesselETAShipment is a shipment whose vessel ETA changed
+type VesselETAShipment struct {
+	ShipmentID      string     `json:"shipment_id"`
+	ReferenceNumber string     `json:"reference_number,omitempty"`
+	CustomerID      string     `json:"customer_id,omitempty"`
+	Containers      int        `json:"containers"`
+	PreviousETA     *time.Time `json:"previous_eta,omitempty"`
+	LastFreeDay     *time.Time `json:"last_free_day,omitempty"` // As the steamship line set it
+	ProjectedLFD    time.Time  `json:"projected_lfd"`           // Free time counted from the new ETA
+}
+
+// Charge warnings published on Topics.ContainerChargeWarning
+const (
+	ChargeWarningLFDApproaching    = "LFD_APPROACHING"
+	ChargeWarningDemurrageAccruing = "DEMURRAGE_ACCRUING"
+	ChargeWarningPerDiemAccruing   = "PER_DIEM_ACCRUING"
+)
+
+// ContainerChargeWarningEvent is published on Topics.ContainerChargeWarning
+// by the nightly charge accrual when an open import is days from its last
+// free day, or starts running up demurrage or per diem, so dispatch can
+// prioritize pre-pulls
+type ContainerChargeWarningEvent struct {
+	Warning         string    `json:"warning"`
+	ContainerID     string    `json:"container_id"`
+	ContainerNumber string    `json:"container_number"`
+	ShipmentID      string    `json:"shipment_id"`
+	ReferenceNumber string    `json:"reference_number,omitempty"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	TerminalID      string    `json:"terminal_id,omitempty"`
+	LastFreeDay     time.Time `json:"last_free_day"`  // After terminal closures
+	DaysUntilLFD    int       `json:"days_until_lfd"` // Negative once past it
+	ChargeDays      int       `json:"charge_days,omitempty"`
+	AccruedAmount   float64   `json:"accrued_amount,omitempty"`
+}
+
+// EModalServiceEvent is published on Topics.EModalOutage when eModal calls
+// keep failing, and on Topics.EModalRecovered once they succeed again
+type EModalServiceEvent struct {
+	Operation           string    `json:"operation"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	Since               time.Time `json:"since"` // First failure of the outage
+	At                  time.Time `json:"at"`
+}
+
+// EModalAppointmentEvent is published on Topics.EModalAppointmentUpdated when
+// a PreGate appointment is booked, modified or cancelled, so dispatch can
+// attach the confirmation to the trip stop it was booked for
+type EModalAppointmentEvent struct {
+	AppointmentID       string     `json:"appointment_id"`
+	EModalAppointmentID string     `json:"emodal_appointment_id"`
+	ConfirmationNumber  string     `json:"confirmation_number"`
+	Status              string     `json:"status"` // BOOKED or CANCELLED
+	TerminalCode        string     `json:"terminal_code"`
+	MoveType            string     `json:"move_type"`
+	ContainerNumber     string     `json:"container_number,omitempty"`
+	SlotTime            time.Time  `json:"slot_time"`
+	WindowEnd           *time.Time `json:"window_end,omitempty"`
+	TripID              string     `json:"trip_id,omitempty"`
+	StopID              string     `json:"stop_id,omitempty"`
+	CancelReason        string     `json:"cancel_reason,omitempty"`
+	At                  time.Time  `json:"at"`
+}
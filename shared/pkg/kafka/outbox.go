@@ -0,0 +1,226 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/draymaster/shared/pkg/database"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Outbox stores events in the service's database instead of sending them
+// to Kafka directly. Called with a context from database.WithTx, the event
+// commits or rolls back with the business write; an OutboxRelay then
+// delivers it at least once. Consumers should tolerate duplicates by event ID.
+type Outbox struct {
+	db *database.DB
+}
+
+// NewOutbox creates an outbox writing to the event_outbox table
+func NewOutbox(db *database.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+// Publish stores an event for a topic. It has the same signature as
+// Producer.Publish so a call site moves to the outbox by switching receiver
+// and passing the transaction's context.
+func (o *Outbox) Publish(ctx context.Context, topic string, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := `INSERT INTO event_outbox (topic, event_id, event_type, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`
+	now := time.Now()
+	if tx, ok := database.TxFromContext(ctx); ok {
+		_, err = tx.Exec(ctx, query, topic, event.ID, event.Type, payload, now)
+	} else {
+		_, err = o.db.Pool.Exec(ctx, query, topic, event.ID, event.Type, payload, now)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxRelayConfig controls how an OutboxRelay delivers stored events
+type OutboxRelayConfig struct {
+	BatchSize    int           // Events claimed per pass, 100 when zero
+	PollInterval time.Duration // Wait between passes when the outbox is drained, 1s when zero
+	MaxBackoff   time.Duration // Longest wait before retrying a failed event, 5m when zero
+	Retention    time.Duration // How long delivered events are kept, 7 days when zero
+}
+
+// OutboxRelay delivers events stored by an Outbox to Kafka. Every replica
+// can run one: a pass only proceeds while it holds the outbox's advisory
+// lock, so one relay is active at a time and the rest stand by. Events of
+// a topic go out in the order they were stored, and a failed event holds
+// back the rest of its topic until it is delivered.
+type OutboxRelay struct {
+	db       *database.DB
+	producer *Producer
+	cfg      OutboxRelayConfig
+	logger   *logger.Logger
+}
+
+// NewOutboxRelay creates a relay from the event_outbox table to the producer
+func NewOutboxRelay(db *database.DB, producer *Producer, cfg OutboxRelayConfig, log *logger.Logger) *OutboxRelay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	return &OutboxRelay{db: db, producer: producer, cfg: cfg, logger: log}
+}
+
+// Run relays events until ctx is cancelled, pruning delivered events hourly
+func (r *OutboxRelay) Run(ctx context.Context) {
+	lastPrune := time.Time{}
+	for {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Errorw("Outbox relay pass failed", "error", err)
+		}
+
+		if time.Since(lastPrune) > time.Hour {
+			if _, err := r.Prune(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Warnw("Failed to prune event outbox", "error", err)
+			}
+			lastPrune = time.Now()
+		}
+
+		// A full batch means more are probably waiting
+		if sent == r.cfg.BatchSize && err == nil {
+			continue
+		}
+		if sleep(ctx, r.cfg.PollInterval) != nil {
+			return
+		}
+	}
+}
+
+// outboxLockKey is the advisory lock a relay holds for the length of a pass
+const outboxLockKey int64 = 0x6f7574626f78 // "outbox"
+
+// outboxRow is an undelivered event claimed by a relay pass
+type outboxRow struct {
+	id       int64
+	topic    string
+	attempts int
+	event    Event
+}
+
+// RelayOnce claims one batch of due events and publishes them, returning
+// how many were delivered
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	sent := 0
+	err := r.db.Transaction(ctx, func(tx pgx.Tx) error {
+		var leader bool
+		if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&leader); err != nil {
+			return fmt.Errorf("failed to take outbox lock: %w", err)
+		}
+		if !leader {
+			return nil // Another relay is delivering
+		}
+
+		// Events queued behind one waiting to be retried stay put, keeping topic order
+		rows, err := tx.Query(ctx, `
+			SELECT o.id, o.topic, o.attempts, o.payload FROM event_outbox o
+			WHERE o.published_at IS NULL AND o.next_attempt_at <= $1
+			  AND NOT EXISTS (
+				SELECT 1 FROM event_outbox e
+				WHERE e.topic = o.topic AND e.published_at IS NULL AND e.id < o.id AND e.next_attempt_at > $1
+			  )
+			ORDER BY o.id
+			LIMIT $2`, time.Now(), r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+		var batch []outboxRow
+		for rows.Next() {
+			var row outboxRow
+			var payload []byte
+			if err := rows.Scan(&row.id, &row.topic, &row.attempts, &payload); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan outbox event: %w", err)
+			}
+			if err := json.Unmarshal(payload, &row.event); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode outbox event %d: %w", row.id, err)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+
+		held := make(map[string]bool) // Topics with an earlier event that failed
+		for _, row := range batch {
+			if held[row.topic] {
+				continue
+			}
+			if pubErr := r.producer.PublishSync(ctx, row.topic, &row.event); pubErr != nil {
+				held[row.topic] = true
+				retryAt := time.Now().Add(r.backoff(row.attempts + 1))
+				if _, err := tx.Exec(ctx,
+					`UPDATE event_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+					row.id, pubErr.Error(), retryAt); err != nil {
+					return fmt.Errorf("failed to record outbox failure: %w", err)
+				}
+				r.logger.Warnw("Outbox event not delivered, will retry",
+					"topic", row.topic,
+					"event_id", row.event.ID,
+					"attempts", row.attempts+1,
+					"retry_at", retryAt,
+				)
+				continue
+			}
+			if _, err := tx.Exec(ctx,
+				`UPDATE event_outbox SET attempts = attempts + 1, published_at = $2 WHERE id = $1`,
+				row.id, time.Now()); err != nil {
+				return fmt.Errorf("failed to mark outbox event published: %w", err)
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sent, nil
+}
+
+// Prune deletes delivered events older than the retention period
+func (r *OutboxRelay) Prune(ctx context.Context) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx,
+		`DELETE FROM event_outbox WHERE published_at < $1`, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune event outbox: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// backoff waits a second after the first failure, doubling up to MaxBackoff
+func (r *OutboxRelay) backoff(attempt int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempt && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.cfg.MaxBackoff {
+		d = r.cfg.MaxBackoff
+	}
+	return d
+}
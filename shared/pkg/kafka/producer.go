@@ -0,0 +1,414 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// ErrQueueFull is returned by Publish when the async queue stays full for
+// longer than the enqueue timeout. Callers should shed load or fall back to
+// PublishSync for events that must not be lost.
+var ErrQueueFull = errors.New("kafka producer queue full")
+
+// ErrProducerClosed is returned when publishing after Close
+var ErrProducerClosed = errors.New("kafka producer closed")
+
+// ProducerConfig controls how events are batched and queued
+type ProducerConfig struct {
+	Async          bool          // Publish enqueues and returns before delivery; PublishSync always waits for acks
+	BatchSize      int           // Max events per write to the brokers
+	Linger         time.Duration // How long to wait for a batch to fill before writing
+	QueueSize      int           // Bounded in-memory queue for async publishing
+	EnqueueTimeout time.Duration // How long Publish blocks on a full queue before ErrQueueFull
+	WriteTimeout   time.Duration // Per-batch broker write timeout
+}
+
+// DefaultProducerConfig returns the producer settings used by NewProducer.
+// Publishing is synchronous: queued events are lost if the process dies
+// before a batch is written, so services opt in to Async only for
+// high-volume events they can afford to drop.
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{
+		Async:          false,
+		BatchSize:      100,
+		Linger:         10 * time.Millisecond,
+		QueueSize:      10000,
+		EnqueueTimeout: 100 * time.Millisecond,
+		WriteTimeout:   10 * time.Second,
+	}
+}
+
+// ProducerConfigFrom builds producer settings from service configuration
+func ProducerConfigFrom(cfg config.KafkaConfig) ProducerConfig {
+	pc := DefaultProducerConfig()
+	pc.Async = cfg.ProducerAsync
+	if cfg.BatchSize > 0 {
+		pc.BatchSize = cfg.BatchSize
+	}
+	if cfg.Linger > 0 {
+		pc.Linger = cfg.Linger
+	}
+	if cfg.QueueSize > 0 {
+		pc.QueueSize = cfg.QueueSize
+	}
+	return pc
+}
+
+// backpressureThreshold is the queue fill ratio above which Backpressure reports true
+const backpressureThreshold = 0.8
+
+// messageWriter is the subset of kafka.Writer the producer uses
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// TopicMetrics counts deliveries for one topic
+type TopicMetrics struct {
+	Topic           string        `json:"topic"`
+	Enqueued        uint64        `json:"enqueued"`
+	Delivered       uint64        `json:"delivered"`
+	Failed          uint64        `json:"failed"`
+	Rejected        uint64        `json:"rejected"` // Turned away by a full queue
+	Batches         uint64        `json:"batches"`
+	LastLatency     time.Duration `json:"last_latency"` // Enqueue to ack for the last async batch
+	LastError       string        `json:"last_error,omitempty"`
+	LastDeliveredAt time.Time     `json:"last_delivered_at,omitempty"`
+}
+
+// pending is an event waiting in the async queue
+type pending struct {
+	msg      kafka.Message
+	enqueued time.Time
+}
+
+// Producer handles publishing events to Kafka. In async mode events are
+// queued in memory and written in batches by a background worker.
+type Producer struct {
+	writer messageWriter
+	cfg    ProducerConfig
+	logger *logger.Logger
+
+	queue chan pending
+	done  chan struct{}
+
+	mu     sync.RWMutex // guards closed against sends on queue
+	closed bool
+
+	metricsMu sync.Mutex
+	metrics   map[string]*TopicMetrics
+}
+
+// NewProducer creates a new Kafka producer with DefaultProducerConfig
+func NewProducer(brokers []string, log *logger.Logger) *Producer {
+	return NewProducerWithConfig(brokers, DefaultProducerConfig(), log)
+}
+
+// NewProducerWithConfig creates a new Kafka producer
+func NewProducerWithConfig(brokers []string, cfg ProducerConfig, log *logger.Logger) *Producer {
+	defaults := DefaultProducerConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.Linger <= 0 {
+		cfg.Linger = defaults.Linger
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaults.WriteTimeout
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.LeastBytes{},
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: cfg.Linger,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	return newProducer(writer, cfg, log)
+}
+
+func newProducer(writer messageWriter, cfg ProducerConfig, log *logger.Logger) *Producer {
+	p := &Producer{
+		writer:  writer,
+		cfg:     cfg,
+		logger:  log,
+		metrics: make(map[string]*TopicMetrics),
+	}
+	if cfg.Async {
+		p.queue = make(chan pending, cfg.QueueSize)
+		p.done = make(chan struct{})
+		go p.run()
+	}
+	return p
+}
+
+// Publish publishes an event to a topic. In async mode it returns once the
+// event is queued; delivery failures are logged and counted in Metrics.
+func (p *Producer) Publish(ctx context.Context, topic string, event *Event) error {
+	if !p.cfg.Async {
+		return p.PublishSync(ctx, topic, event)
+	}
+
+	msg, err := buildMessage(topic, event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	item := pending{msg: msg, enqueued: time.Now()}
+	select {
+	case p.queue <- item:
+		p.record(topic, func(m *TopicMetrics) { m.Enqueued++ })
+		return nil
+	default:
+	}
+
+	// Queue is full: wait briefly for the worker to drain it
+	timer := time.NewTimer(p.cfg.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case p.queue <- item:
+		p.record(topic, func(m *TopicMetrics) { m.Enqueued++ })
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	p.record(topic, func(m *TopicMetrics) { m.Rejected++ })
+	p.logger.Warnw("Kafka producer queue full, event rejected",
+		"topic", topic,
+		"event_type", event.Type,
+		"queue_size", p.cfg.QueueSize,
+	)
+	return ErrQueueFull
+}
+
+// PublishSync publishes an event and waits for the brokers to acknowledge
+// it. Use it for events that must not be lost or reordered behind the queue.
+func (p *Producer) PublishSync(ctx context.Context, topic string, event *Event) error {
+	msg, err := buildMessage(topic, event)
+	if err != nil {
+		return err
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		p.record(topic, func(m *TopicMetrics) {
+			m.Failed++
+			m.LastError = err.Error()
+		})
+		p.logger.Errorw("Failed to publish event",
+			"topic", topic,
+			"event_type", event.Type,
+			"error", err,
+		)
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	p.record(topic, func(m *TopicMetrics) {
+		m.Delivered++
+		m.LastDeliveredAt = time.Now()
+	})
+	p.logger.Debugw("Event published",
+		"topic", topic,
+		"event_id", event.ID,
+		"event_type", event.Type,
+	)
+	return nil
+}
+
+// QueueDepth returns the number of events waiting to be written
+func (p *Producer) QueueDepth() int {
+	if p.queue == nil {
+		return 0
+	}
+	return len(p.queue)
+}
+
+// Backpressure reports whether the async queue is nearly full. Request
+// paths can check it to slow down or reject work before Publish starts
+// returning ErrQueueFull.
+func (p *Producer) Backpressure() bool {
+	if p.queue == nil {
+		return false
+	}
+	return float64(len(p.queue)) >= backpressureThreshold*float64(cap(p.queue))
+}
+
+// Metrics returns delivery counters per topic, sorted by topic
+func (p *Producer) Metrics() []TopicMetrics {
+	p.metricsMu.Lock()
+	defer p.metricsMu.Unlock()
+
+	out := make([]TopicMetrics, 0, len(p.metrics))
+	for _, m := range p.metrics {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Topic < out[j].Topic })
+	return out
+}
+
+// Close stops accepting events, flushes the queue and closes the writer
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	if p.queue != nil {
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	if p.done != nil {
+		<-p.done
+	}
+	return p.writer.Close()
+}
+
+// run collects queued events into batches, writing each batch when it is
+// full or the linger time since its first event has passed
+func (p *Producer) run() {
+	defer close(p.done)
+
+	batch := make([]pending, 0, p.cfg.BatchSize)
+	linger := time.NewTimer(p.cfg.Linger)
+	linger.Stop()
+
+	for {
+		select {
+		case item, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			if len(batch) == 0 {
+				linger.Reset(p.cfg.Linger)
+			}
+			batch = append(batch, item)
+			if len(batch) >= p.cfg.BatchSize {
+				if !linger.Stop() {
+					select {
+					case <-linger.C:
+					default:
+					}
+				}
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-linger.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (p *Producer) flush(batch []pending) {
+	if len(batch) == 0 {
+		return
+	}
+
+	msgs := make([]kafka.Message, len(batch))
+	for i, item := range batch {
+		msgs[i] = item.msg
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.WriteTimeout)
+	err := p.writer.WriteMessages(ctx, msgs...)
+	cancel()
+	now := time.Now()
+
+	// Attribute each message's outcome to its topic
+	var writeErrs kafka.WriteErrors
+	errors.As(err, &writeErrs)
+	batches := make(map[string]bool)
+	for i, item := range batch {
+		msgErr := err
+		if writeErrs != nil && len(writeErrs) == len(batch) {
+			msgErr = writeErrs[i]
+		}
+		topic := item.msg.Topic
+		first := !batches[topic]
+		batches[topic] = true
+		p.record(topic, func(m *TopicMetrics) {
+			if first {
+				m.Batches++
+			}
+			if msgErr != nil {
+				m.Failed++
+				m.LastError = msgErr.Error()
+				return
+			}
+			m.Delivered++
+			m.LastDeliveredAt = now
+			m.LastLatency = now.Sub(item.enqueued)
+		})
+	}
+
+	if err != nil {
+		p.logger.Errorw("Failed to publish event batch",
+			"events", len(batch),
+			"error", err,
+		)
+		return
+	}
+	p.logger.Debugw("Event batch published", "events", len(batch))
+}
+
+func (p *Producer) record(topic string, update func(m *TopicMetrics)) {
+	p.metricsMu.Lock()
+	defer p.metricsMu.Unlock()
+	m, ok := p.metrics[topic]
+	if !ok {
+		m = &TopicMetrics{Topic: topic}
+		p.metrics[topic] = m
+	}
+	update(m)
+}
+
+// buildMessage encodes an event as a Kafka message
+func buildMessage(topic string, event *Event) (kafka.Message, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Topic: topic,
+		Key:   []byte(event.ID),
+		Value: data,
+		Time:  event.Time,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "source", Value: []byte(event.Source)},
+		},
+	}
+
+	if event.CorrelationID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{
+			Key:   "correlation_id",
+			Value: []byte(event.CorrelationID),
+		})
+	}
+	return msg, nil
+}
@@ -0,0 +1,267 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/draymaster/shared/pkg/config"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// fakeWriter records each WriteMessages call as one batch. A non-nil gate
+// blocks writes until it is closed.
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches [][]kafka.Message
+	err     error
+	gate    chan struct{}
+	closed  bool
+	written chan int
+}
+
+func newFakeWriter() *fakeWriter {
+	return &fakeWriter{written: make(chan int, 100)}
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.gate != nil {
+		<-w.gate
+	}
+	w.mu.Lock()
+	w.batches = append(w.batches, append([]kafka.Message(nil), msgs...))
+	err := w.err
+	w.mu.Unlock()
+	w.written <- len(msgs)
+	return err
+}
+
+func (w *fakeWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *fakeWriter) batchSizes() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sizes := make([]int, len(w.batches))
+	for i, b := range w.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func waitWrite(t *testing.T, w *fakeWriter, timeout time.Duration) int {
+	t.Helper()
+	select {
+	case n := <-w.written:
+		return n
+	case <-time.After(timeout):
+		t.Fatal("timed out waiting for a batch write")
+		return 0
+	}
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return log
+}
+
+func testEvent() *Event {
+	return NewEvent("test.event", "test", map[string]string{"k": "v"})
+}
+
+func metricsFor(p *Producer, topic string) TopicMetrics {
+	for _, m := range p.Metrics() {
+		if m.Topic == topic {
+			return m
+		}
+	}
+	return TopicMetrics{Topic: topic}
+}
+
+func TestDefaultProducerConfigIsSync(t *testing.T) {
+	if DefaultProducerConfig().Async {
+		t.Error("DefaultProducerConfig().Async = true, want publishing to be synchronous by default")
+	}
+	if ProducerConfigFrom(config.KafkaConfig{}).Async {
+		t.Error("ProducerConfigFrom() enabled async without ProducerAsync")
+	}
+	if !ProducerConfigFrom(config.KafkaConfig{ProducerAsync: true}).Async {
+		t.Error("ProducerConfigFrom() ignored ProducerAsync")
+	}
+}
+
+func TestProducerSyncPublish(t *testing.T) {
+	w := newFakeWriter()
+	p := newProducer(w, DefaultProducerConfig(), testLogger(t))
+	defer p.Close()
+
+	event := testEvent().WithCorrelationID("corr-1")
+	if err := p.Publish(context.Background(), "orders", event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if sizes := w.batchSizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Fatalf("batches = %v, want one write of one event before Publish returns", sizes)
+	}
+
+	msg := w.batches[0][0]
+	if msg.Topic != "orders" || string(msg.Key) != event.ID {
+		t.Errorf("message topic/key = %q/%q, want orders/%s", msg.Topic, msg.Key, event.ID)
+	}
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers["event_type"] != "test.event" || headers["correlation_id"] != "corr-1" {
+		t.Errorf("headers = %v, want event_type and correlation_id", headers)
+	}
+
+	w.err = errors.New("broker down")
+	if err := p.Publish(context.Background(), "orders", testEvent()); err == nil {
+		t.Error("Publish() error = nil, want the write error in sync mode")
+	}
+	if m := metricsFor(p, "orders"); m.Delivered != 1 || m.Failed != 1 || m.LastError != "broker down" {
+		t.Errorf("metrics = %+v, want 1 delivered and 1 failed", m)
+	}
+}
+
+func TestProducerAsyncBatchesBySize(t *testing.T) {
+	w := newFakeWriter()
+	cfg := ProducerConfig{Async: true, BatchSize: 3, Linger: time.Hour, QueueSize: 10, WriteTimeout: time.Second}
+	p := newProducer(w, cfg, testLogger(t))
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := p.Publish(context.Background(), "locations", testEvent()); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// A full batch is written without waiting out the linger
+	if n := waitWrite(t, w, time.Second); n != 3 {
+		t.Errorf("batch size = %d, want 3", n)
+	}
+	m := metricsFor(p, "locations")
+	if m.Enqueued != 3 || m.Delivered != 3 || m.Batches != 1 {
+		t.Errorf("metrics = %+v, want 3 enqueued and delivered in 1 batch", m)
+	}
+}
+
+func TestProducerAsyncFlushesOnLinger(t *testing.T) {
+	w := newFakeWriter()
+	cfg := ProducerConfig{Async: true, BatchSize: 100, Linger: 20 * time.Millisecond, QueueSize: 10, WriteTimeout: time.Second}
+	p := newProducer(w, cfg, testLogger(t))
+	defer p.Close()
+
+	start := time.Now()
+	p.Publish(context.Background(), "locations", testEvent())
+	p.Publish(context.Background(), "locations", testEvent())
+
+	if n := waitWrite(t, w, time.Second); n != 2 {
+		t.Errorf("batch size = %d, want the 2 queued events", n)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.Linger {
+		t.Errorf("partial batch written after %v, before the %v linger", elapsed, cfg.Linger)
+	}
+	if m := metricsFor(p, "locations"); m.LastLatency <= 0 {
+		t.Errorf("LastLatency = %v, want the enqueue-to-ack latency", m.LastLatency)
+	}
+}
+
+func TestProducerAsyncQueueFull(t *testing.T) {
+	w := newFakeWriter()
+	w.gate = make(chan struct{})
+	cfg := ProducerConfig{
+		Async: true, BatchSize: 1, Linger: time.Millisecond, QueueSize: 2,
+		EnqueueTimeout: 10 * time.Millisecond, WriteTimeout: time.Second,
+	}
+	p := newProducer(w, cfg, testLogger(t))
+
+	// The worker takes the first event and blocks writing it; two more fill the queue
+	p.Publish(context.Background(), "locations", testEvent())
+	deadline := time.Now().Add(time.Second)
+	for p.QueueDepth() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		if err := p.Publish(context.Background(), "locations", testEvent()); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if !p.Backpressure() {
+		t.Error("Backpressure() = false with a full queue")
+	}
+
+	if err := p.Publish(context.Background(), "locations", testEvent()); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Publish() error = %v, want ErrQueueFull", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Publish(ctx, "locations", testEvent()); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Publish() with a cancelled context error = %v, want ErrQueueFull", err)
+	}
+	if m := metricsFor(p, "locations"); m.Rejected != 2 || m.Enqueued != 3 {
+		t.Errorf("metrics = %+v, want 3 enqueued and 2 rejected", m)
+	}
+
+	close(w.gate)
+	p.Close()
+	if m := metricsFor(p, "locations"); m.Delivered != 3 {
+		t.Errorf("delivered = %d after Close, want every queued event", m.Delivered)
+	}
+}
+
+func TestProducerCloseFlushesQueue(t *testing.T) {
+	w := newFakeWriter()
+	cfg := ProducerConfig{Async: true, BatchSize: 100, Linger: time.Hour, QueueSize: 10, WriteTimeout: time.Second}
+	p := newProducer(w, cfg, testLogger(t))
+
+	for i := 0; i < 5; i++ {
+		p.Publish(context.Background(), "locations", testEvent())
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if sizes := w.batchSizes(); len(sizes) != 1 || sizes[0] != 5 {
+		t.Errorf("batches = %v, want the 5 lingering events flushed on Close", sizes)
+	}
+	if !w.closed {
+		t.Error("Close() did not close the writer")
+	}
+	if err := p.Publish(context.Background(), "locations", testEvent()); !errors.Is(err, ErrProducerClosed) {
+		t.Errorf("Publish() after Close error = %v, want ErrProducerClosed", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
+
+func TestProducerAsyncAttributesWriteErrors(t *testing.T) {
+	w := newFakeWriter()
+	w.err = kafka.WriteErrors{nil, errors.New("message too large")}
+	cfg := ProducerConfig{Async: true, BatchSize: 2, Linger: time.Hour, QueueSize: 10, WriteTimeout: time.Second}
+	p := newProducer(w, cfg, testLogger(t))
+
+	p.Publish(context.Background(), "orders", testEvent())
+	p.Publish(context.Background(), "locations", testEvent())
+	p.Close()
+
+	if m := metricsFor(p, "orders"); m.Delivered != 1 || m.Failed != 0 {
+		t.Errorf("orders metrics = %+v, want delivered", m)
+	}
+	if m := metricsFor(p, "locations"); m.Failed != 1 || m.LastError != "message too large" {
+		t.Errorf("locations metrics = %+v, want the per-message error", m)
+	}
+}
@@ -0,0 +1,112 @@
+package kafka
+
+import "time"
+
+// Stop-level lifecycle payloads published by dispatch-service. Consumers
+// decode them with Event.DecodeData.
+
+// Container states reported when a stop is departed
+const (
+	ContainerStateLoadedOnChassis = "LOADED_ON_CHASSIS"
+	ContainerStateEmptyOnChassis  = "EMPTY_ON_CHASSIS"
+	ContainerStateDroppedLoaded   = "DROPPED_LOADED"
+	ContainerStateDroppedEmpty    = "DROPPED_EMPTY"
+	ContainerStateDelivered       = "DELIVERED"
+	ContainerStateReturned        = "RETURNED"
+)
+
+// StopRef identifies the stop an event is about and the container it handles
+type StopRef struct {
+	TripID          string `json:"trip_id"`
+	TripNumber      string `json:"trip_number,omitempty"`
+	StopID          string `json:"stop_id"`
+	Sequence        int    `json:"sequence"`
+	StopType        string `json:"stop_type"`
+	Activity        string `json:"activity"`
+	LocationID      string `json:"location_id"`
+	OrderID         string `json:"order_id,omitempty"`
+	DriverID        string `json:"driver_id,omitempty"`
+	ContainerID     string `json:"container_id,omitempty"`
+	ContainerNumber string `json:"container_number,omitempty"`
+}
+
+// StopArrivedEvent is published on Topics.StopArrived
+type StopArrivedEvent struct {
+	StopRef
+	ArrivedAt        time.Time  `json:"arrived_at"`
+	AppointmentTime  *time.Time `json:"appointment_time,omitempty"`
+	MinutesLate      int        `json:"minutes_late"` // Past the appointment window; 0 when on time or unscheduled
+	FreeTimeMins     int        `json:"free_time_mins"`
+	Latitude         *float64   `json:"latitude,omitempty"`
+	Longitude        *float64   `json:"longitude,omitempty"`
+	LocationMismatch bool       `json:"location_mismatch"`
+}
+
+// StopDepartedEvent is published on Topics.StopDeparted
+type StopDepartedEvent struct {
+	StopRef
+	ArrivedAt          *time.Time `json:"arrived_at,omitempty"`
+	DepartedAt         time.Time  `json:"departed_at"`
+	DwellMins          int        `json:"dwell_mins"`
+	FreeTimeMins       int        `json:"free_time_mins"`
+	DetentionMins      int        `json:"detention_mins"`
+	DetentionStartedAt *time.Time `json:"detention_started_at,omitempty"` // Exact start of billable detention, for invoice evidence
+	GateTicketNumber   string     `json:"gate_ticket_number,omitempty"`
+	SealNumber         string     `json:"seal_number,omitempty"`
+	ChassisInID        string     `json:"chassis_in_id,omitempty"` // Chassis the container arrived on
+	ChassisID          string     `json:"chassis_id,omitempty"`
+	ContainerState     string     `json:"container_state,omitempty"` // One of the ContainerState values, after the stop
+	LocationMismatch   bool       `json:"location_mismatch"`
+}
+
+// StopDetentionStartedEvent is published on Topics.StopDetentionStarted when
+// a driver is still at a stop after its free time has run out
+type StopDetentionStartedEvent struct {
+	StopRef
+	ArrivedAt          time.Time `json:"arrived_at"`
+	FreeTimeMins       int       `json:"free_time_mins"`
+	DetentionStartedAt time.Time `json:"detention_started_at"`
+}
+
+// StopDetentionWarningEvent is published on Topics.StopDetentionWarning when
+// a stop's free time is about to run out, for the driver app and the
+// dispatcher who owns the trip
+type StopDetentionWarningEvent struct {
+	StopRef
+	DispatcherID   string    `json:"dispatcher_id,omitempty"`
+	ArrivedAt      time.Time `json:"arrived_at"`
+	FreeTimeMins   int       `json:"free_time_mins"`
+	FreeTimeEndsAt time.Time `json:"free_time_ends_at"`
+	RemainingMins  int       `json:"remaining_mins"`
+}
+
+// StopDetentionThresholdEvent is published on Topics.StopDetentionThreshold
+// each time a driver still on site passes one of the free time thresholds,
+// so the dispatcher can call the facility before detention accrues
+type StopDetentionThresholdEvent struct {
+	StopRef
+	DispatcherID   string    `json:"dispatcher_id,omitempty"`
+	ThresholdPct   int       `json:"threshold_pct"` // Share of free time used, e.g. 75 or 100
+	ArrivedAt      time.Time `json:"arrived_at"`
+	ArrivalSource  string    `json:"arrival_source"` // DRIVER or GEOFENCE
+	FreeTimeMins   int       `json:"free_time_mins"`
+	FreeTimeEndsAt time.Time `json:"free_time_ends_at"`
+	ElapsedMins    int       `json:"elapsed_mins"`
+	RemainingMins  int       `json:"remaining_mins"` // 0 once free time has run out
+}
+
+// ShipmentStatusEvent is published on Topics.ShipmentStatus for each stop
+// milestone that has an industry status code. EDI 214 and webhook senders
+// forward the codes as they are; the mapping lives in package shipmentstatus.
+type ShipmentStatusEvent struct {
+	StopRef
+	Milestone          string    `json:"milestone"`
+	X12Code            string    `json:"x12_code"`   // AT701 shipment status code, e.g. X3, AF, X1, D1
+	X12Reason          string    `json:"x12_reason"` // AT702 status reason code
+	X12Description     string    `json:"x12_description"`
+	DCSAEventType      string    `json:"dcsa_event_type,omitempty"` // TRANSPORT or EQUIPMENT; empty when DCSA has no event
+	DCSAEventTypeCode  string    `json:"dcsa_event_type_code,omitempty"`
+	DCSAClassifierCode string    `json:"dcsa_event_classifier_code,omitempty"`
+	DCSAEmptyIndicator string    `json:"dcsa_empty_indicator_code,omitempty"`
+	OccurredAt         time.Time `json:"occurred_at"`
+}
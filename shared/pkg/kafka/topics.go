@@ -5,48 +5,124 @@ type TopicRegistry struct {
 	// Order Service topics
 	ShipmentCreated      string
 	ContainerAdded       string
+	ContainerReady       string
+	ContainerVGMUpdated  string
+	ContainerVerificationRequested string
+	ContainerVerificationFailed    string
+	ContainerLFDRisk               string
+	ContainerGrounded              string
+	ContainerChargeWarning         string
 	OrderCreated         string
 	OrderStatusChanged   string
+	OrderTagsChanged     string
 	AppointmentRequested string
 	AppointmentConfirmed string
 	AppointmentCancelled string
 	AppointmentRescheduled string
 	AppointmentArrival   string
 	AppointmentCompleted string
+	AppointmentNoShow    string
+	NoShowFeeBilled      string
+	BookingPreferencesUpdated string
+	TenderStatusChanged  string
+	RateConfirmationSent   string
+	RateConfirmationSigned string
+	RateApprovalRequested  string
+	RateApprovalDecided    string
+	DraftTripRequested     string
+	FacilityProfileUpdated string
+	IntakeDraftCreated     string
+	VesselETAChanged       string
 
 	// Dispatch Service topics
 	TripCreated         string
 	TripAssigned        string
 	TripDispatched      string
 	TripCompleted       string
+	TripRolledOver      string
+	TripTagsChanged     string
+	TripStale           string
+	TripAutoClosed      string
+	TripDispatcherAssigned string
+	SealMismatch        string
+	DriverAllocated     string
+	DriverAllocationReleased string
+	DriverTimeOffChanged     string
+	StopArrived         string
+	StopDeparted        string
+	StopDetentionStarted string
+	StopDetentionWarning string
+	StopDetentionThreshold string
 	StopCompleted       string
 	StreetTurnMatched   string
+	EmptyReturnRecommended string
 	ExceptionCreated    string
 	ExceptionUpdated    string
 	ExceptionResolved   string
+	AutoDispatchProposed string
+	YardGateIn          string
+	YardGateOut         string
+	ChassisUsageClosed  string
+	ChassisSplitFlagged string
+	OwnerOperatorComplianceChanged string
+	OwnerOperatorInsuranceExpiring string
+	LocationGeofenceLearned string
+	NextTripSuggested   string
+	CancellationFeeAssessed string
+	EscortFeeAssessed   string
+	EscortFeeVoided     string
+	ShipmentStatus      string
+	EmergencyRaised     string
+	EmergencyLocation   string
+	EmergencyClosed     string
+	GateAppointmentBooked string
+	GateAppointmentCancelled string
+	GateAppointmentRescheduled string
+	GateSlotHighDemand  string
+	TripAxleWeightWarning string
+	DeliveryPODRecorded string
 
 	// Tracking Service topics
 	LocationUpdated     string
 	MilestoneRecorded   string
 	GeofenceEntered     string
 	GeofenceExited      string
+	CorridorDeviation   string
+	CorridorReturned    string
 
 	// Driver Service topics
 	HOSViolation        string
 	DriverAvailable     string
 	DriverUnavailable   string
+	HOSWarning          string
 	DocumentExpiring    string
+	TractorSessionChanged string
+	DrivingSegmentReview  string
 
 	// Billing Service topics
 	InvoiceCreated      string
 	PaymentReceived     string
 	SettlementGenerated string
+	RateExpiring        string
+	RateAmended         string
+	BillingReconciliationIssue string
 
 	// eModal Integration Service topics
 	EModalContainerStatusUpdated string
 	EModalGateIn                 string
 	EModalGateOut                string
 	EModalContainerPublished     string
+	EModalContainerVerified      string
+	EModalOutage                 string
+	EModalRecovered              string
+	EModalAppointmentUpdated     string
+
+	// ELD Integration Service topics
+	ELDDutyStatusRecorded string
+	ELDLocationRecorded   string
+
+	// Reference Data Service topics
+	ReferenceDataUpdated string
 
 	// System topics
 	NotificationSent    string
@@ -58,48 +134,124 @@ var Topics = TopicRegistry{
 	// Order Service
 	ShipmentCreated:      "orders.shipment.created",
 	ContainerAdded:       "orders.container.added",
+	ContainerReady:       "orders.container.ready",
+	ContainerVGMUpdated:  "orders.container.vgm_updated",
+	ContainerVerificationRequested: "orders.container.verification_requested",
+	ContainerVerificationFailed:    "orders.container.verification_failed",
+	ContainerLFDRisk:               "orders.container.lfd_risk",
+	ContainerGrounded:              "orders.container.grounded",
+	ContainerChargeWarning:         "orders.container.charge_warning",
 	OrderCreated:         "orders.order.created",
 	OrderStatusChanged:   "orders.order.status_changed",
+	OrderTagsChanged:     "orders.order.tags_changed",
 	AppointmentRequested: "orders.appointment.requested",
 	AppointmentConfirmed: "orders.appointment.confirmed",
 	AppointmentCancelled: "orders.appointment.cancelled",
 	AppointmentRescheduled: "orders.appointment.rescheduled",
 	AppointmentArrival:   "orders.appointment.arrival",
 	AppointmentCompleted: "orders.appointment.completed",
+	AppointmentNoShow:    "orders.appointment.no_show",
+	NoShowFeeBilled:      "orders.appointment.no_show_fee_billed",
+	BookingPreferencesUpdated: "orders.appointment.preferences_updated",
+	TenderStatusChanged:  "orders.tender.status_changed",
+	RateConfirmationSent:   "orders.rate_confirmation.sent",
+	RateConfirmationSigned: "orders.rate_confirmation.signed",
+	RateApprovalRequested:  "orders.rate_approval.requested",
+	RateApprovalDecided:    "orders.rate_approval.decided",
+	DraftTripRequested:     "orders.prearrival.draft_trip_requested",
+	FacilityProfileUpdated: "orders.facility.profile_updated",
+	IntakeDraftCreated:     "orders.intake.draft_created",
+	VesselETAChanged:       "orders.vessel.eta_changed",
 
 	// Dispatch Service
 	TripCreated:       "dispatch.trip.created",
 	TripAssigned:      "dispatch.trip.assigned",
 	TripDispatched:    "dispatch.trip.dispatched",
 	TripCompleted:     "dispatch.trip.completed",
+	TripRolledOver:    "dispatch.trip.rolled_over",
+	TripTagsChanged:   "dispatch.trip.tags_changed",
+	TripStale:         "dispatch.trip.stale",
+	TripAutoClosed:    "dispatch.trip.auto_closed",
+	TripDispatcherAssigned: "dispatch.trip.dispatcher_assigned",
+	SealMismatch:      "dispatch.seal.mismatch",
+	DriverAllocated:   "dispatch.driver.allocated",
+	DriverAllocationReleased: "dispatch.driver.allocation_released",
+	DriverTimeOffChanged:     "dispatch.driver.time_off_changed",
+	StopArrived:       "dispatch.stop.arrived",
+	StopDeparted:      "dispatch.stop.departed",
+	StopDetentionStarted: "dispatch.stop.detention_started",
+	StopDetentionWarning: "dispatch.stop.detention_warning",
+	StopDetentionThreshold: "dispatch.stop.detention_threshold_exceeded",
 	StopCompleted:     "dispatch.stop.completed",
 	StreetTurnMatched: "dispatch.street_turn.matched",
+	EmptyReturnRecommended: "dispatch.empty_return.recommended",
 	ExceptionCreated:  "dispatch.exception.created",
 	ExceptionUpdated:  "dispatch.exception.updated",
 	ExceptionResolved: "dispatch.exception.resolved",
+	AutoDispatchProposed: "dispatch.auto_dispatch.proposed",
+	YardGateIn:        "dispatch.yard.gate_in",
+	YardGateOut:       "dispatch.yard.gate_out",
+	ChassisUsageClosed: "dispatch.chassis.usage_closed",
+	ChassisSplitFlagged: "dispatch.chassis.split_flagged",
+	OwnerOperatorComplianceChanged: "dispatch.owner_operator.compliance_changed",
+	OwnerOperatorInsuranceExpiring: "dispatch.owner_operator.insurance_expiring",
+	LocationGeofenceLearned: "dispatch.location.geofence_learned",
+	NextTripSuggested:  "dispatch.driver.next_trip_suggested",
+	CancellationFeeAssessed: "dispatch.trip.cancellation_fee_assessed",
+	EscortFeeAssessed:  "dispatch.trip.escort_fee_assessed",
+	EscortFeeVoided:    "dispatch.trip.escort_fee_voided",
+	ShipmentStatus:     "dispatch.shipment.status",
+	EmergencyRaised:    "dispatch.emergency.raised",
+	EmergencyLocation:  "dispatch.emergency.location",
+	EmergencyClosed:    "dispatch.emergency.closed",
+	GateAppointmentBooked: "dispatch.gate_appointment.booked",
+	GateAppointmentCancelled: "dispatch.gate_appointment.cancelled",
+	GateAppointmentRescheduled: "dispatch.gate_appointment.rescheduled",
+	GateSlotHighDemand: "dispatch.gate_slot.high_demand",
+	TripAxleWeightWarning: "dispatch.trip.axle_weight_warning",
+	DeliveryPODRecorded: "dispatch.delivery.pod_recorded",
 
 	// Tracking Service
 	LocationUpdated:   "tracking.location.updated",
 	MilestoneRecorded: "tracking.milestone.recorded",
 	GeofenceEntered:   "tracking.geofence.entered",
 	GeofenceExited:    "tracking.geofence.exited",
+	CorridorDeviation: "tracking.corridor.deviation",
+	CorridorReturned:  "tracking.corridor.returned",
 
 	// Driver Service
 	HOSViolation:      "drivers.hos.violation",
 	DriverAvailable:   "drivers.driver.available",
 	DriverUnavailable: "drivers.driver.unavailable",
+	HOSWarning:        "drivers.hos.warning",
 	DocumentExpiring:  "drivers.document.expiring",
+	TractorSessionChanged: "drivers.tractor.session_changed",
+	DrivingSegmentReview:  "drivers.hos.segment_review",
 
 	// Billing Service
 	InvoiceCreated:      "billing.invoice.created",
 	PaymentReceived:     "billing.payment.received",
 	SettlementGenerated: "billing.settlement.generated",
+	RateExpiring:        "billing.rate.expiring",
+	RateAmended:         "billing.rate.amended",
+	BillingReconciliationIssue: "billing.reconciliation.issue_opened",
 
 	// eModal Integration Service
 	EModalContainerStatusUpdated: "emodal.container.status_updated",
 	EModalGateIn:                 "emodal.container.gate_in",
 	EModalGateOut:                "emodal.container.gate_out",
 	EModalContainerPublished:     "emodal.container.published",
+	EModalContainerVerified:      "emodal.container.verified",
+	EModalOutage:                 "emodal.service.outage",
+	EModalRecovered:              "emodal.service.recovered",
+	EModalAppointmentUpdated:     "emodal.appointment.updated",
+
+	// ELD Integration Service
+	ELDDutyStatusRecorded: "eld.duty_status.recorded",
+	ELDLocationRecorded:   "eld.location.recorded",
+
+	// Reference Data Service
+	ReferenceDataUpdated: "reference.data.updated",
 
 	// System
 	NotificationSent: "system.notification.sent",
@@ -112,48 +264,124 @@ func (t *TopicRegistry) GetAllTopics() []string {
 		// Order Service
 		t.ShipmentCreated,
 		t.ContainerAdded,
+		t.ContainerReady,
+		t.ContainerVGMUpdated,
+		t.ContainerVerificationRequested,
+		t.ContainerVerificationFailed,
+		t.ContainerLFDRisk,
+		t.ContainerGrounded,
+		t.ContainerChargeWarning,
 		t.OrderCreated,
 		t.OrderStatusChanged,
+		t.OrderTagsChanged,
 		t.AppointmentRequested,
 		t.AppointmentConfirmed,
 		t.AppointmentCancelled,
 		t.AppointmentRescheduled,
 		t.AppointmentArrival,
 		t.AppointmentCompleted,
+		t.AppointmentNoShow,
+		t.NoShowFeeBilled,
+		t.BookingPreferencesUpdated,
+		t.TenderStatusChanged,
+		t.RateConfirmationSent,
+		t.RateConfirmationSigned,
+		t.RateApprovalRequested,
+		t.RateApprovalDecided,
+		t.DraftTripRequested,
+		t.FacilityProfileUpdated,
+		t.IntakeDraftCreated,
+		t.VesselETAChanged,
 
 		// Dispatch Service
 		t.TripCreated,
 		t.TripAssigned,
 		t.TripDispatched,
 		t.TripCompleted,
+		t.TripRolledOver,
+		t.TripTagsChanged,
+		t.TripStale,
+		t.TripAutoClosed,
+		t.TripDispatcherAssigned,
+		t.SealMismatch,
+		t.DriverAllocated,
+		t.DriverAllocationReleased,
+		t.DriverTimeOffChanged,
+		t.StopArrived,
+		t.StopDeparted,
+		t.StopDetentionStarted,
+		t.StopDetentionWarning,
+		t.StopDetentionThreshold,
 		t.StopCompleted,
 		t.StreetTurnMatched,
+		t.EmptyReturnRecommended,
 		t.ExceptionCreated,
 		t.ExceptionUpdated,
 		t.ExceptionResolved,
+		t.AutoDispatchProposed,
+		t.YardGateIn,
+		t.YardGateOut,
+		t.ChassisUsageClosed,
+		t.ChassisSplitFlagged,
+		t.OwnerOperatorComplianceChanged,
+		t.OwnerOperatorInsuranceExpiring,
+		t.LocationGeofenceLearned,
+		t.NextTripSuggested,
+		t.CancellationFeeAssessed,
+		t.EscortFeeAssessed,
+		t.EscortFeeVoided,
+		t.ShipmentStatus,
+		t.EmergencyRaised,
+		t.EmergencyLocation,
+		t.EmergencyClosed,
+		t.GateAppointmentBooked,
+		t.GateAppointmentCancelled,
+		t.GateAppointmentRescheduled,
+		t.GateSlotHighDemand,
+		t.TripAxleWeightWarning,
+		t.DeliveryPODRecorded,
 
 		// Tracking Service
 		t.LocationUpdated,
 		t.MilestoneRecorded,
 		t.GeofenceEntered,
 		t.GeofenceExited,
+		t.CorridorDeviation,
+		t.CorridorReturned,
 
 		// Driver Service
 		t.HOSViolation,
 		t.DriverAvailable,
 		t.DriverUnavailable,
+		t.HOSWarning,
 		t.DocumentExpiring,
+		t.TractorSessionChanged,
+		t.DrivingSegmentReview,
 
 		// Billing Service
 		t.InvoiceCreated,
 		t.PaymentReceived,
 		t.SettlementGenerated,
+		t.RateExpiring,
+		t.RateAmended,
+		t.BillingReconciliationIssue,
 
 		// eModal Integration Service
 		t.EModalContainerStatusUpdated,
 		t.EModalGateIn,
 		t.EModalGateOut,
 		t.EModalContainerPublished,
+		t.EModalContainerVerified,
+		t.EModalOutage,
+		t.EModalRecovered,
+		t.EModalAppointmentUpdated,
+
+		// ELD Integration Service
+		t.ELDDutyStatusRecorded,
+		t.ELDLocationRecorded,
+
+		// Reference Data Service
+		t.ReferenceDataUpdated,
 
 		// System
 		t.NotificationSent,
@@ -0,0 +1,143 @@
+// Package leader runs singleton background jobs - compliance checks,
+// retention sweeps, reconciliation and polling loops - on one replica of a
+// service at a time. Each job is guarded by a named lock; the replica
+// holding it runs the job and the others keep trying to take it over, so
+// the job moves to another replica when its holder stops or loses its
+// database connection.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Locker takes named locks that are held until released or until the
+// holder's connection to the lock service is lost
+type Locker interface {
+	// TryLock takes the lock on name without waiting, returning nil when
+	// another process holds it
+	TryLock(ctx context.Context, name string) (Lock, error)
+}
+
+// Lock is a held lock
+type Lock interface {
+	// Check returns an error once the lock can no longer be relied on
+	Check(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// Config controls how often an elector tries for and checks its locks
+type Config struct {
+	RetryInterval time.Duration // Wait between attempts to take a held lock, 15s when zero
+	CheckInterval time.Duration // Wait between checks that a lock is still held, 10s when zero
+}
+
+// Elector runs each job it is given only while this replica holds the job's lock
+type Elector struct {
+	locker Locker
+	config Config
+	id     string
+	logger *logger.Logger
+}
+
+// NewElector creates an elector taking locks from locker
+func NewElector(locker Locker, cfg Config, log *logger.Logger) *Elector {
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 15 * time.Second
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 10 * time.Second
+	}
+
+	host, _ := os.Hostname()
+	return &Elector{
+		locker: locker,
+		config: cfg,
+		id:     fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.New().String()[:8]),
+		logger: log,
+	}
+}
+
+// Run runs job while this replica holds the lock on name, until ctx is
+// cancelled. job should run until its context is cancelled, as the Start
+// loops of scheduled services do: its context is also cancelled when the
+// lock is lost, and Run waits for it to return before trying to win the
+// lock back. A lost lock is only noticed at the next check, so another
+// replica may already be running job for up to one CheckInterval. If job
+// returns on its own, Run releases the lock and returns.
+func (e *Elector) Run(ctx context.Context, name string, job func(ctx context.Context)) {
+	for {
+		lock, err := e.locker.TryLock(ctx, name)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Warnw("Failed to take job lock", "job", name, "error", err)
+		}
+		if lock != nil && e.lead(ctx, name, lock, job) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.config.RetryInterval):
+		}
+	}
+}
+
+// lead runs job under a held lock, returning true when job returned on its
+// own rather than because the lock was lost
+func (e *Elector) lead(ctx context.Context, name string, lock Lock, job func(ctx context.Context)) bool {
+	e.logger.Infow("Took job lock, running job", "job", name, "holder", e.id)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		job(jobCtx)
+	}()
+
+	ticker := time.NewTicker(e.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			e.unlock(name, lock)
+			return ctx.Err() == nil
+		case <-ticker.C:
+			if err := e.check(ctx, lock); err != nil && ctx.Err() == nil {
+				e.logger.Warnw("Lost job lock, stopping job", "job", name, "holder", e.id, "error", err)
+				cancel()
+				<-done
+				e.unlock(name, lock)
+				return false
+			}
+		}
+	}
+}
+
+// check checks a lock within one check interval, so a connection that has
+// silently gone away counts as lost rather than stalling the run
+func (e *Elector) check(ctx context.Context, lock Lock) error {
+	ctx, cancel := context.WithTimeout(ctx, e.config.CheckInterval)
+	defer cancel()
+	return lock.Check(ctx)
+}
+
+// unlock releases a lock so another replica can take the job over straight
+// away. The run's context may already be cancelled, so it gets its own.
+func (e *Elector) unlock(name string, lock Lock) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lock.Unlock(ctx); err != nil {
+		e.logger.Warnw("Failed to release job lock", "job", name, "error", err)
+		return
+	}
+	e.logger.Infow("Released job lock", "job", name, "holder", e.id)
+}
@@ -0,0 +1,280 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// memLocker hands out in-process locks. Dropping a holder's lock frees it
+// the way Postgres frees an advisory lock when the session dies.
+type memLocker struct {
+	mu      sync.Mutex
+	held    map[string]*memLock
+	unlocks int
+}
+
+func newMemLocker() *memLocker {
+	return &memLocker{held: make(map[string]*memLock)}
+}
+
+func (l *memLocker) TryLock(ctx context.Context, name string) (Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] != nil {
+		return nil, nil
+	}
+	lock := &memLock{locker: l, name: name}
+	l.held[name] = lock
+	return lock, nil
+}
+
+// drop loses the current holder's session and its lock with it
+func (l *memLocker) drop(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lock := l.held[name]; lock != nil {
+		lock.lost = true
+		delete(l.held, name)
+	}
+}
+
+func (l *memLocker) holder(name string) *memLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held[name]
+}
+
+type memLock struct {
+	locker *memLocker
+	name   string
+	lost   bool
+}
+
+func (k *memLock) Check(ctx context.Context) error {
+	k.locker.mu.Lock()
+	defer k.locker.mu.Unlock()
+	if k.lost {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (k *memLock) Unlock(ctx context.Context) error {
+	k.locker.mu.Lock()
+	defer k.locker.mu.Unlock()
+	k.locker.unlocks++
+	if k.locker.held[k.name] == k {
+		delete(k.locker.held, k.name)
+	}
+	return nil
+}
+
+// runs tracks which elector is running the job
+type runs struct {
+	mu      sync.Mutex
+	current []string
+	started chan string
+	overlap bool
+}
+
+func newRuns() *runs {
+	return &runs{started: make(chan string, 10)}
+}
+
+// job runs until its context is cancelled, as scheduled service loops do
+func (r *runs) job(id string) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		r.mu.Lock()
+		r.current = append(r.current, id)
+		r.overlap = r.overlap || len(r.current) > 1
+		r.mu.Unlock()
+		r.started <- id
+
+		<-ctx.Done()
+
+		r.mu.Lock()
+		for i, c := range r.current {
+			if c == id {
+				r.current = append(r.current[:i], r.current[i+1:]...)
+				break
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *runs) overlapped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.overlap
+}
+
+func (r *runs) waitStart(t *testing.T) string {
+	t.Helper()
+	select {
+	case id := <-r.started:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not start")
+		return ""
+	}
+}
+
+func testElector(t *testing.T, locker Locker) *Elector {
+	t.Helper()
+	log, err := logger.New("test", "development", "debug")
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return NewElector(locker, Config{RetryInterval: 5 * time.Millisecond, CheckInterval: 5 * time.Millisecond}, log)
+}
+
+// startElector runs an elector in the background, returning a function that
+// stops it and waits for Run to return
+func startElector(t *testing.T, e *Elector, r *runs, id string) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx, "retention-sweep", r.job(id))
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("elector %s did not stop", id)
+		}
+	}
+}
+
+func TestElectorHandsOverOnShutdown(t *testing.T) {
+	locker := newMemLocker()
+	r := newRuns()
+
+	stopA := startElector(t, testElector(t, locker), r, "a")
+	if got := r.waitStart(t); got != "a" {
+		t.Fatalf("first runner = %s, want a", got)
+	}
+	stopB := startElector(t, testElector(t, locker), r, "b")
+	defer stopB()
+
+	// b waits while a holds the lock
+	select {
+	case id := <-r.started:
+		t.Fatalf("%s started while a held the lock", id)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	// a stopping releases the lock and b takes the job over
+	stopA()
+	if got := r.waitStart(t); got != "b" {
+		t.Fatalf("runner after handover = %s, want b", got)
+	}
+	if r.overlapped() {
+		t.Error("job ran on two electors at once")
+	}
+}
+
+func TestElectorStopsJobWhenLockIsLost(t *testing.T) {
+	locker := newMemLocker()
+	r := newRuns()
+
+	stop := startElector(t, testElector(t, locker), r, "a")
+	defer stop()
+	r.waitStart(t)
+	first := locker.holder("retention-sweep")
+
+	// The session dies; the job is stopped, then restarted once the lock
+	// is taken again
+	locker.drop("retention-sweep")
+	if got := r.waitStart(t); got != "a" {
+		t.Fatalf("runner after lock loss = %s, want a", got)
+	}
+	if r.overlapped() {
+		t.Error("job kept running after its lock was lost")
+	}
+	if second := locker.holder("retention-sweep"); second == nil || second == first {
+		t.Error("job restarted without taking a new lock")
+	}
+}
+
+func TestElectorLockLossHandsOverToAnotherReplica(t *testing.T) {
+	locker := newMemLocker()
+	r := newRuns()
+
+	stopA := startElector(t, testElector(t, locker), r, "a")
+	defer stopA()
+	r.waitStart(t)
+
+	// b is already trying for the lock when a's session drops. b can take
+	// it before a's next check notices, so the two may overlap for up to
+	// one check interval; after that exactly one runs.
+	stopB := startElector(t, testElector(t, locker), r, "b")
+	defer stopB()
+	time.Sleep(20 * time.Millisecond)
+	locker.drop("retention-sweep")
+	r.waitStart(t)
+
+	time.Sleep(30 * time.Millisecond)
+	r.mu.Lock()
+	running := append([]string(nil), r.current...)
+	r.mu.Unlock()
+	if len(running) != 1 {
+		t.Errorf("runners after lock loss = %v, want exactly one", running)
+	}
+	if holder := locker.holder("retention-sweep"); holder == nil {
+		t.Error("nobody holds the lock after the handover")
+	}
+}
+
+func TestElectorReleasesLockWhenJobReturns(t *testing.T) {
+	locker := newMemLocker()
+	e := testElector(t, locker)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(context.Background(), "backfill", func(ctx context.Context) {})
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after the job returned")
+	}
+	if locker.holder("backfill") != nil || locker.unlocks != 1 {
+		t.Errorf("lock still held or not released once (unlocks = %d)", locker.unlocks)
+	}
+}
+
+func TestElectorRetriesLockErrors(t *testing.T) {
+	locker := &flakyLocker{memLocker: newMemLocker(), failures: 2}
+	r := newRuns()
+
+	stop := startElector(t, testElector(t, locker), r, "a")
+	defer stop()
+	r.waitStart(t)
+	if locker.calls < 3 {
+		t.Errorf("TryLock called %d times, want the failures retried", locker.calls)
+	}
+}
+
+// flakyLocker fails its first TryLock calls, as when the database is down
+type flakyLocker struct {
+	*memLocker
+	failures int
+	calls    int
+}
+
+func (l *flakyLocker) TryLock(ctx context.Context, name string) (Lock, error) {
+	l.calls++
+	if l.calls <= l.failures {
+		return nil, errors.New("connection refused")
+	}
+	return l.memLocker.TryLock(ctx, name)
+}
@@ -0,0 +1,81 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/draymaster/shared/pkg/database"
+)
+
+// PostgresLocker implements Locker with Postgres session advisory locks.
+// Each held lock keeps one pooled connection out of the pool; if that
+// connection drops, Postgres releases the lock with the session. Advisory
+// locks need no table, so every service can use its own database. Session
+// locks need a direct or session-pooled connection, not transaction pooling.
+type PostgresLocker struct {
+	db *database.DB
+}
+
+// NewPostgresLocker creates a new PostgreSQL locker
+func NewPostgresLocker(db *database.DB) *PostgresLocker {
+	return &PostgresLocker{db: db}
+}
+
+// TryLock takes the advisory lock for name on a connection of its own
+func (l *PostgresLocker) TryLock(ctx context.Context, name string) (Lock, error) {
+	conn, err := l.db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	key := lockKey(name)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("failed to take lock %q: %w", name, err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, nil
+	}
+	return &postgresLock{conn: conn, key: key}, nil
+}
+
+// postgresLock is an advisory lock held by the session of conn
+type postgresLock struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+// Check pings the holding session; the lock lives exactly as long as it
+func (l *postgresLock) Check(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+// Unlock releases the lock and returns the connection to the pool. If the
+// unlock fails the connection is closed instead, which ends the session and
+// the lock with it.
+func (l *postgresLock) Unlock(ctx context.Context) error {
+	var unlocked bool
+	err := l.conn.QueryRow(ctx, `SELECT pg_advisory_unlock($1)`, l.key).Scan(&unlocked)
+	if err != nil || !unlocked {
+		conn := l.conn.Hijack()
+		conn.Close(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to release lock, closed its connection: %w", err)
+		}
+		return nil
+	}
+	l.conn.Release()
+	return nil
+}
+
+// lockKey maps a job name onto the 64-bit advisory lock key space
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("leader:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,70 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// SQLLocker implements Locker with Postgres session advisory locks for
+// services that reach Postgres through database/sql rather than the shared
+// pool. Each held lock keeps one connection of db out of its pool, as with
+// PostgresLocker.
+type SQLLocker struct {
+	db *sql.DB
+}
+
+// NewSQLLocker creates a new database/sql locker
+func NewSQLLocker(db *sql.DB) *SQLLocker {
+	return &SQLLocker{db: db}
+}
+
+// TryLock takes the advisory lock for name on a connection of its own
+func (l *SQLLocker) TryLock(ctx context.Context, name string) (Lock, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	key := lockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take lock %q: %w", name, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+	return &sqlLock{conn: conn, key: key}, nil
+}
+
+// sqlLock is an advisory lock held by the session of conn
+type sqlLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Check pings the holding session; the lock lives exactly as long as it
+func (l *sqlLock) Check(ctx context.Context) error {
+	return l.conn.PingContext(ctx)
+}
+
+// Unlock releases the lock and returns the connection to the pool. If the
+// unlock fails the connection is discarded instead, which ends the session
+// and the lock with it.
+func (l *sqlLock) Unlock(ctx context.Context) error {
+	var unlocked bool
+	err := l.conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key).Scan(&unlocked)
+	if err != nil || !unlocked {
+		// Returning ErrBadConn from Raw makes database/sql drop the connection
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		l.conn.Close()
+		if err != nil {
+			return fmt.Errorf("failed to release lock, closed its connection: %w", err)
+		}
+		return nil
+	}
+	return l.conn.Close()
+}
@@ -46,6 +46,7 @@ func New(serviceName, environment, level string) (*Logger, error) {
 
 	zapLogger, err := config.Build(
 		zap.AddCallerSkip(1),
+		zap.WrapCore(newRedactingCore),
 		zap.Fields(
 			zap.String("service", serviceName),
 			zap.String("environment", environment),
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sensitiveKeys are log field keys whose values are masked before they are
+// written. Services add their own with RegisterSensitiveKeys.
+var (
+	sensitiveMu   sync.RWMutex
+	sensitiveKeys = map[string]bool{
+		"ssn":                  true,
+		"tax_id":               true,
+		"recipient_tax_id":     true,
+		"date_of_birth":        true,
+		"email":                true,
+		"phone":                true,
+		"license_number":       true,
+		"medical_card_number":  true,
+		"medical_restrictions": true,
+	}
+)
+
+// RegisterSensitiveKeys marks additional log field keys for redaction
+func RegisterSensitiveKeys(keys ...string) {
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	for _, k := range keys {
+		sensitiveKeys[strings.ToLower(k)] = true
+	}
+}
+
+func isSensitive(key string) bool {
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Redact masks all but the last four characters of a sensitive value, or
+// all of it when the value is short
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	runes := []rune(value)
+	if len(runes) <= 6 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
+
+// redactingCore masks sensitive fields on their way to the wrapped core
+type redactingCore struct {
+	zapcore.Core
+}
+
+func newRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if !isSensitive(f.Key) {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: Redact(fieldString(f))}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	if f.Interface != nil {
+		return fmt.Sprint(f.Interface)
+	}
+	return fmt.Sprint(f.Integer)
+}
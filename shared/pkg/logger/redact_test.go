@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"12345", "*****"},
+		{"123456", "******"},
+		{"5551234567", "******4567"},
+		{"driver@example.com", "**************.com"},
+		{"José María", "******aría"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := Redact(tt.value); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+type stringerID string
+
+func (s stringerID) String() string { return string(s) }
+
+func TestRedactingCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(newRedactingCore(core)).With(zap.String("SSN", "123-45-6789"))
+
+	log.Info("driver updated",
+		zap.String("driver_id", "drv-1"),
+		zap.String("phone", "5551234567"),
+		zap.Stringer("license_number", stringerID("D12345678")),
+		zap.Int64("tax_id", 123456789),
+		zap.String("recipient_tax_id", "12-3456789"),
+	)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	want := map[string]string{
+		"driver_id":        "drv-1",
+		"phone":            "******4567",
+		"license_number":   "*****5678",
+		"tax_id":           "*****6789",
+		"recipient_tax_id": "******6789",
+		"SSN":              "*******6789",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("field %s = %v, want %q", k, fields[k], v)
+		}
+	}
+}
+
+func TestRegisterSensitiveKeys(t *testing.T) {
+	if isSensitive("bank_account") {
+		t.Fatal("bank_account is sensitive before registration")
+	}
+	RegisterSensitiveKeys("Bank_Account")
+	defer func() {
+		sensitiveMu.Lock()
+		delete(sensitiveKeys, "bank_account")
+		sensitiveMu.Unlock()
+	}()
+
+	if !isSensitive("bank_account") || !isSensitive("BANK_ACCOUNT") {
+		t.Error("registered key is not matched case-insensitively")
+	}
+
+	fields := []zapcore.Field{zap.String("order_id", "o-1"), zap.String("bank_account", "000123456789")}
+	out := redactFields(fields)
+	if out[1].String != "********6789" {
+		t.Errorf("bank_account = %q, want it masked", out[1].String)
+	}
+	if fields[1].String != "000123456789" {
+		t.Error("redactFields modified the caller's fields")
+	}
+	if got := redactFields(fields[:1]); &got[0] != &fields[0] {
+		t.Error("redactFields copied fields with nothing to redact")
+	}
+}
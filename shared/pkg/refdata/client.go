@@ -0,0 +1,273 @@
+// Package refdata provides a caching client for the reference data service
+package refdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	apperrors "github.com/draymaster/shared/pkg/errors"
+	"github.com/draymaster/shared/pkg/kafka"
+	"github.com/draymaster/shared/pkg/logger"
+)
+
+// Entity types understood by the reference data service
+const (
+	EntityTypeSteamshipLine = "STEAMSHIP_LINE"
+	EntityTypePort          = "PORT"
+	EntityTypeTerminal      = "TERMINAL"
+)
+
+// Alias sources understood by the reference data service
+const (
+	SourceInternal = "INTERNAL"
+	SourceEModal   = "EMODAL"
+	SourceEDI      = "EDI"
+	SourceCustomer = "CUSTOMER"
+)
+
+// SteamshipLine is an ocean carrier
+type SteamshipLine struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Code     string    `json:"code"`
+	SCAC     string    `json:"scac"`
+	IsActive bool      `json:"is_active"`
+}
+
+// Port is a seaport or inland port
+type Port struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Code     string    `json:"code"`
+	UNLocode string    `json:"unlocode"`
+	City     string    `json:"city"`
+	State    string    `json:"state"`
+	Country  string    `json:"country"`
+	Timezone string    `json:"timezone"`
+	IsActive bool      `json:"is_active"`
+}
+
+// Terminal is a marine terminal or rail ramp
+type Terminal struct {
+	ID         uuid.UUID  `json:"id"`
+	PortID     uuid.UUID  `json:"port_id"`
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
+	Name       string     `json:"name"`
+	Code       string     `json:"code"`
+	FIRMSCode  string     `json:"firms_code"`
+	Latitude   float64    `json:"latitude"`
+	Longitude  float64    `json:"longitude"`
+	IsActive   bool       `json:"is_active"`
+	Port       *Port      `json:"port,omitempty"`
+}
+
+// ResolvedAlias is an external name resolved to an internal record
+type ResolvedAlias struct {
+	EntityType string    `json:"entity_type"`
+	EntityID   uuid.UUID `json:"entity_id"`
+	Source     string    `json:"source"`
+	Alias      string    `json:"alias"`
+	MatchedBy  string    `json:"matched_by"`
+}
+
+// ClientConfig holds reference data client configuration
+type ClientConfig struct {
+	BaseURL  string
+	Timeout  time.Duration
+	CacheTTL time.Duration
+}
+
+type cacheEntry struct {
+	value     json.RawMessage
+	expiresAt time.Time
+}
+
+// Client reads reference data over HTTP and caches results in memory.
+// Reference data changes rarely, so entries live for CacheTTL and are
+// dropped early when a reference.data.updated event arrives.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	ttl        time.Duration
+	logger     *logger.Logger
+
+	mu    sync.RWMutex
+	cache map[string]cacheEntry
+}
+
+// NewClient creates a new reference data client
+func NewClient(cfg ClientConfig, log *logger.Logger) *Client {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = 15 * time.Minute
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		ttl:        cfg.CacheTTL,
+		logger:     log,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// GetSteamshipLine returns a steamship line by ID
+func (c *Client) GetSteamshipLine(ctx context.Context, id uuid.UUID) (*SteamshipLine, error) {
+	var line SteamshipLine
+	if err := c.get(ctx, "/v1/steamship-lines/"+id.String(), &line); err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// GetSteamshipLineBySCAC returns a steamship line by SCAC
+func (c *Client) GetSteamshipLineBySCAC(ctx context.Context, scac string) (*SteamshipLine, error) {
+	var line SteamshipLine
+	if err := c.get(ctx, "/v1/steamship-lines/scac/"+url.PathEscape(normalize(scac)), &line); err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// GetPort returns a port by ID
+func (c *Client) GetPort(ctx context.Context, id uuid.UUID) (*Port, error) {
+	var port Port
+	if err := c.get(ctx, "/v1/ports/"+id.String(), &port); err != nil {
+		return nil, err
+	}
+	return &port, nil
+}
+
+// GetPortTerminals returns the active terminals within a port
+func (c *Client) GetPortTerminals(ctx context.Context, portID uuid.UUID) ([]Terminal, error) {
+	var terminals []Terminal
+	if err := c.get(ctx, "/v1/ports/"+portID.String()+"/terminals", &terminals); err != nil {
+		return nil, err
+	}
+	return terminals, nil
+}
+
+// GetTerminal returns a terminal, with its port, by terminal or location ID
+func (c *Client) GetTerminal(ctx context.Context, id uuid.UUID) (*Terminal, error) {
+	var terminal Terminal
+	if err := c.get(ctx, "/v1/terminals/"+id.String(), &terminal); err != nil {
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+// GetTerminalByFIRMSCode returns a terminal by its CBP FIRMS code
+func (c *Client) GetTerminalByFIRMSCode(ctx context.Context, firmsCode string) (*Terminal, error) {
+	var terminal Terminal
+	if err := c.get(ctx, "/v1/terminals/firms/"+url.PathEscape(normalize(firmsCode)), &terminal); err != nil {
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+// GetTerminalPort returns the port a terminal belongs to
+func (c *Client) GetTerminalPort(ctx context.Context, terminalID uuid.UUID) (*Port, error) {
+	terminal, err := c.GetTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if terminal.Port != nil {
+		return terminal.Port, nil
+	}
+	return c.GetPort(ctx, terminal.PortID)
+}
+
+// Resolve maps an external name or code (e.g. an eModal terminal code) to an
+// internal record ID
+func (c *Client) Resolve(ctx context.Context, entityType, source, alias string) (uuid.UUID, error) {
+	q := url.Values{}
+	q.Set("entity_type", entityType)
+	q.Set("source", source)
+	q.Set("alias", normalize(alias))
+
+	var resolved ResolvedAlias
+	if err := c.get(ctx, "/v1/aliases/resolve?"+q.Encode(), &resolved); err != nil {
+		return uuid.Nil, err
+	}
+	return resolved.EntityID, nil
+}
+
+// Invalidate drops every cached entry
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	c.cache = make(map[string]cacheEntry)
+	c.mu.Unlock()
+}
+
+// HandleEvent is a kafka.Handler that invalidates the cache when reference
+// data changes. Aliases can point at any record, so the whole cache is dropped.
+func (c *Client) HandleEvent(ctx context.Context, event *kafka.Event) error {
+	c.Invalidate()
+	c.logger.Debugw("Reference data cache invalidated", "event_id", event.ID)
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	if c.lookup(path, out) {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apperrors.ExternalServiceError("reference-data-service", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var appErr apperrors.AppError
+		if json.NewDecoder(resp.Body).Decode(&appErr) == nil && appErr.Code != "" {
+			return &appErr
+		}
+		return apperrors.ExternalServiceError("reference-data-service",
+			fmt.Errorf("unexpected status %d for %s", resp.StatusCode, path))
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[path] = cacheEntry{value: raw, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// lookup decodes a cached response into out. Raw JSON is cached rather than
+// the decoded value so callers never share mutable structs.
+func (c *Client) lookup(path string, out interface{}) bool {
+	c.mu.RLock()
+	entry, ok := c.cache[path]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false
+	}
+	return json.Unmarshal(entry.value, out) == nil
+}
+
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
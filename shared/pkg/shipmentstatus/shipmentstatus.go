@@ -0,0 +1,115 @@
+// Package shipmentstatus translates internal shipment milestones into the
+// industry status codes customers expect: X12 214 shipment status codes
+// (AT701) and DCSA track and trace events. EDI and webhook senders read the
+// translated codes off the shipment status event instead of each keeping its
+// own mapping.
+package shipmentstatus
+
+// Milestone is an internal shipment milestone that has a standard status code
+type Milestone string
+
+const (
+	MilestoneArrivedPickup     Milestone = "ARRIVED_PICKUP"
+	MilestoneLoaded            Milestone = "LOADED"   // Completed loading at the shipper
+	MilestoneGateOut           Milestone = "GATE_OUT" // Out-gated from a port terminal or rail ramp
+	MilestoneDepartedPickup    Milestone = "DEPARTED_PICKUP"
+	MilestoneArrivedDelivery   Milestone = "ARRIVED_DELIVERY"
+	MilestoneUnloaded          Milestone = "UNLOADED" // Completed unloading at the consignee
+	MilestoneSpotted           Milestone = "SPOTTED"  // Loaded container dropped at the consignee
+	MilestoneGateIn            Milestone = "GATE_IN"  // In-gated at a port terminal or rail ramp
+	MilestoneDepartedDelivery  Milestone = "DEPARTED_DELIVERY"
+	MilestoneEnRouteToDelivery Milestone = "EN_ROUTE_TO_DELIVERY"
+	MilestoneDelayed           Milestone = "DELAYED"
+)
+
+// X12 214 status reason codes (AT702)
+const (
+	ReasonNormal = "NS" // Normal status
+)
+
+// DCSA event types
+const (
+	DCSATransport = "TRANSPORT"
+	DCSAEquipment = "EQUIPMENT"
+)
+
+// DCSA event classifier codes
+const (
+	DCSAActual    = "ACT"
+	DCSAEstimated = "EST"
+)
+
+// DCSA empty indicator codes, sent with equipment events
+const (
+	DCSALaden = "LADEN"
+	DCSAEmpty = "EMPTY"
+)
+
+// DCSAEvent is the DCSA track and trace form of a milestone
+type DCSAEvent struct {
+	EventType           string `json:"event_type"`                     // TRANSPORT or EQUIPMENT
+	EventTypeCode       string `json:"event_type_code"`                // e.g. ARRI, DEPA, GTIN, GTOT
+	EventClassifierCode string `json:"event_classifier_code"`          // ACT or EST
+	EmptyIndicatorCode  string `json:"empty_indicator_code,omitempty"` // Equipment events only
+}
+
+// Status is a milestone with its standard codes
+type Status struct {
+	Milestone      Milestone  `json:"milestone"`
+	X12Code        string     `json:"x12_code"`
+	X12Reason      string     `json:"x12_reason"`
+	X12Description string     `json:"x12_description"`
+	DCSA           *DCSAEvent `json:"dcsa,omitempty"` // Nil for milestones DCSA has no event for
+}
+
+type mapping struct {
+	x12         string
+	description string
+	dcsaType    string
+	dcsaCode    string
+}
+
+var mappings = map[Milestone]mapping{
+	MilestoneArrivedPickup:     {"X3", "Arrived at Pick-up Location", DCSATransport, "ARRI"},
+	MilestoneLoaded:            {"CP", "Completed Loading at Pick-up Location", DCSAEquipment, "STUF"},
+	MilestoneGateOut:           {"OA", "Out-Gate", DCSAEquipment, "GTOT"},
+	MilestoneDepartedPickup:    {"AF", "Carrier Departed Pick-up Location with Shipment", DCSATransport, "DEPA"},
+	MilestoneArrivedDelivery:   {"X1", "Arrived at Delivery Location", DCSATransport, "ARRI"},
+	MilestoneUnloaded:          {"D1", "Completed Unloading at Delivery Location", DCSAEquipment, "STRP"},
+	MilestoneSpotted:           {"S1", "Trailer Spotted at Consignee's Location", DCSAEquipment, "DROP"},
+	MilestoneGateIn:            {"I1", "In-Gate", DCSAEquipment, "GTIN"},
+	MilestoneDepartedDelivery:  {"CD", "Carrier Departed Delivery Location", DCSATransport, "DEPA"},
+	MilestoneEnRouteToDelivery: {"X6", "En Route to Delivery Location", "", ""},
+	MilestoneDelayed:           {"SD", "Shipment Delayed", "", ""},
+}
+
+// Translate returns the standard codes for an actual milestone. Empty says
+// whether the container was empty, which DCSA equipment events report. It
+// returns false for milestones without a standard code.
+func Translate(m Milestone, empty bool) (Status, bool) {
+	mp, ok := mappings[m]
+	if !ok {
+		return Status{}, false
+	}
+
+	status := Status{
+		Milestone:      m,
+		X12Code:        mp.x12,
+		X12Reason:      ReasonNormal,
+		X12Description: mp.description,
+	}
+	if mp.dcsaType != "" {
+		status.DCSA = &DCSAEvent{
+			EventType:           mp.dcsaType,
+			EventTypeCode:       mp.dcsaCode,
+			EventClassifierCode: DCSAActual,
+		}
+		if mp.dcsaType == DCSAEquipment {
+			status.DCSA.EmptyIndicatorCode = DCSALaden
+			if empty {
+				status.DCSA.EmptyIndicatorCode = DCSAEmpty
+			}
+		}
+	}
+	return status, true
+}
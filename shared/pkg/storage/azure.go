@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+const (
+	// azureAPIVersion is the Blob service REST version requests are made against
+	azureAPIVersion = "2021-08-06"
+	// azureBlockSize is how much of an upload is sent per block; bodies that
+	// fit in one block are sent as a single Put Blob
+	azureBlockSize = 4 << 20
+)
+
+// AzureStore keeps objects as block blobs in an Azure Storage container,
+// authenticated with the account's shared key. Azure encrypts every blob;
+// KMS selects an encryption scope backed by a customer-managed key.
+// Lifecycle management policies are an account-level ARM setting, so
+// ApplyLifecycle sweeps expired blobs instead.
+type AzureStore struct {
+	account    string
+	key        []byte
+	container  string
+	endpoint   *url.URL
+	encryption Encryption
+	scope      string
+	client     *http.Client
+}
+
+// NewAzureStore creates a store for container cfg.Bucket in account cfg.AccessKey
+func NewAzureStore(cfg config.StorageConfig) (*AzureStore, error) {
+	if cfg.AccessKey == "" || cfg.SecretKey == "" || cfg.Bucket == "" {
+		return nil, errors.New("azure storage needs an account, account key and container")
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode azure account key: %w", err)
+	}
+	raw := fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccessKey)
+	if cfg.Endpoint != "" {
+		raw = cfg.Endpoint
+	}
+	endpoint, err := url.Parse(strings.TrimRight(raw, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse azure endpoint: %w", err)
+	}
+	return &AzureStore{
+		account:    cfg.AccessKey,
+		key:        key,
+		container:  cfg.Bucket,
+		endpoint:   endpoint,
+		encryption: Encryption(strings.ToUpper(cfg.Encryption)),
+		scope:      cfg.KMSKeyID,
+		client:     newHTTPClient(),
+	}, nil
+}
+
+// Put uploads the object, block by block when it is larger than one block,
+// so bodies of any length stream without being spooled
+func (s *AzureStore) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if opts.ContentType != "" {
+		header.Set("x-ms-blob-content-type", opts.ContentType)
+	}
+	for k, v := range opts.Metadata {
+		header.Set("x-ms-meta-"+strings.ToLower(k), v)
+	}
+	if mode, scope := encryption(opts, s.encryption, s.scope); mode == EncryptionKMS {
+		if scope == "" {
+			return nil, errors.New("azure KMS encryption needs an encryption scope")
+		}
+		header.Set("x-ms-encryption-scope", scope)
+	}
+
+	buf := make([]byte, azureBlockSize)
+	var blockIDs []string
+	var size int64
+	for {
+		n, readErr := io.ReadFull(body, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("read upload: %w", readErr)
+		}
+		last := readErr != nil
+
+		// Small bodies go up in one request
+		if last && len(blockIDs) == 0 {
+			h := header.Clone()
+			h.Set("x-ms-blob-type", "BlockBlob")
+			if err := s.send(ctx, http.MethodPut, key, nil, h, buf[:n], "azure put "+key); err != nil {
+				return nil, err
+			}
+			size = int64(n)
+			break
+		}
+
+		if n > 0 {
+			id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blockIDs))))
+			query := url.Values{"comp": {"block"}, "blockid": {id}}
+			h := http.Header{}
+			if scope := header.Get("x-ms-encryption-scope"); scope != "" {
+				h.Set("x-ms-encryption-scope", scope)
+			}
+			if err := s.send(ctx, http.MethodPut, key, query, h, buf[:n], "azure put block "+key); err != nil {
+				return nil, err
+			}
+			blockIDs = append(blockIDs, id)
+			size += int64(n)
+		}
+		if last {
+			if err := s.commitBlocks(ctx, key, blockIDs, header); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         size,
+		ContentType:  opts.ContentType,
+		LastModified: time.Now(),
+		Metadata:     opts.Metadata,
+	}, nil
+}
+
+// commitBlocks writes the block list that assembles the uploaded blocks
+func (s *AzureStore) commitBlocks(ctx context.Context, key string, blockIDs []string, header http.Header) error {
+	var list bytes.Buffer
+	list.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range blockIDs {
+		list.WriteString("<Latest>" + id + "</Latest>")
+	}
+	list.WriteString("</BlockList>")
+	return s.send(ctx, http.MethodPut, key, url.Values{"comp": {"blocklist"}}, header, list.Bytes(), "azure put block list "+key)
+}
+
+// Get streams the object
+func (s *AzureStore) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	resp, err := s.objectRequest(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(resp, "azure get "+key); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	return resp.Body, objectFromHeaders(key, resp.Header, "x-ms-meta-"), nil
+}
+
+// Stat describes the object
+func (s *AzureStore) Stat(ctx context.Context, key string) (*Object, error) {
+	resp, err := s.objectRequest(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "azure head "+key); err != nil {
+		return nil, err
+	}
+	return objectFromHeaders(key, resp.Header, "x-ms-meta-"), nil
+}
+
+// Delete removes the object
+func (s *AzureStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.objectRequest(ctx, http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "azure delete "+key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// azureListResult is a List Blobs response page
+type azureListResult struct {
+	NextMarker string `xml:"NextMarker"`
+	Blobs      []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			LastModified  string `xml:"Last-Modified"`
+			ETag          string `xml:"Etag"`
+			ContentLength int64  `xml:"Content-Length"`
+			ContentType   string `xml:"Content-Type"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+}
+
+// List returns the objects whose keys start with prefix, following pages
+func (s *AzureStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req, 0)
+		if err != nil {
+			return nil, err
+		}
+		var page azureListResult
+		err = checkResponse(resp, "azure list "+prefix)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range page.Blobs {
+			obj := Object{
+				Key:         b.Name,
+				Size:        b.Properties.ContentLength,
+				ContentType: b.Properties.ContentType,
+				ETag:        strings.Trim(b.Properties.ETag, `"`),
+			}
+			if t, err := http.ParseTime(b.Properties.LastModified); err == nil {
+				obj.LastModified = t
+			}
+			objects = append(objects, obj)
+		}
+		if page.NextMarker == "" {
+			return objects, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+func (s *AzureStore) objectRequest(ctx context.Context, method, key string) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, method, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req, 0)
+}
+
+// send makes a request with a small in-memory body and checks the response
+func (s *AzureStore) send(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte, op string) error {
+	req, err := s.newRequest(ctx, method, key, query, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := s.do(req, int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, op)
+}
+
+// newRequest builds a request for key in the container, or the container
+// itself when key is empty
+func (s *AzureStore) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	p := u.Path + "/" + s.container
+	if key != "" {
+		p += "/" + key
+	}
+	u.Path = p
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build azure request: %w", err)
+	}
+	return req, nil
+}
+
+// do signs and sends the request
+func (s *AzureStore) do(req *http.Request, contentLength int64) (*http.Response, error) {
+	req.ContentLength = contentLength
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+s.signature(req, contentLength))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// signature computes the Shared Key signature over the request
+func (s *AzureStore) signature(req *http.Request, contentLength int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(s.stringToSign(req, contentLength)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stringToSign lays out the request as the Shared Key scheme signs it for
+// service versions 2015-02-21 and later
+func (s *AzureStore) stringToSign(req *http.Request, contentLength int64) string {
+	length := ""
+	if contentLength > 0 {
+		length = strconv.FormatInt(contentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		length,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date; x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalHeaders.String() + s.canonicalizedResource(req.URL),
+	}, "\n")
+}
+
+// canonicalizedResource is the account, path and sorted query parameters,
+// one per line, as the Shared Key scheme signs them
+func (s *AzureStore) canonicalizedResource(u *url.URL) string {
+	var resource strings.Builder
+	resource.WriteString("/" + s.account + u.EscapedPath())
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		resource.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+	return resource.String()
+}
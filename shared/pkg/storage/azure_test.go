@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// The storage emulator's well-known development account
+const (
+	azuriteAccount = "devstoreaccount1"
+	azuriteKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IfsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+func TestAzureCanonicalizedResourceMatchesDocs(t *testing.T) {
+	// Examples from "Authorize with Shared Key", Constructing the
+	// canonicalized resource string
+	s := &AzureStore{account: "myaccount"}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			url:  "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=metadata",
+			want: "/myaccount/mycontainer\ncomp:metadata\nrestype:container",
+		},
+		{
+			url:  "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=list&include=snapshots&include=metadata&include=uncommittedblobs",
+			want: "/myaccount/mycontainer\ncomp:list\ninclude:metadata,snapshots,uncommittedblobs\nrestype:container",
+		},
+		{
+			url:  "https://myaccount-secondary.blob.core.windows.net/mycontainer/myblob",
+			want: "/myaccount/mycontainer/myblob",
+		},
+		{
+			// Emulator paths repeat the account
+			url:  "http://127.0.0.1:10000/myaccount/mycontainer?restype=container&comp=metadata&timeout=20",
+			want: "/myaccount/myaccount/mycontainer\ncomp:metadata\nrestype:container\ntimeout:20",
+		},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := s.canonicalizedResource(u); got != tt.want {
+			t.Errorf("canonicalizedResource(%s) =\n%q\nwant\n%q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestAzureStringToSignLayout(t *testing.T) {
+	// The Set Container Metadata example from "Authorize with Shared Key"
+	s := &AzureStore{account: "myaccount"}
+	req, _ := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/myaccount/mycontainer?restype=container&comp=metadata&timeout=20", nil)
+	req.Header.Set("x-ms-date", "Sun, 11 Oct 2009 21:49:13 GMT")
+	req.Header.Set("x-ms-version", "2009-09-19")
+	req.Header.Set("x-ms-meta-m1", "v1")
+	req.Header.Set("x-ms-meta-m2", " v2 ")
+
+	want := "PUT\n\n\n\n\n\n\n\n\n\n\n\n" +
+		"x-ms-date:Sun, 11 Oct 2009 21:49:13 GMT\nx-ms-meta-m1:v1\nx-ms-meta-m2:v2\nx-ms-version:2009-09-19\n" +
+		"/myaccount/myaccount/mycontainer\ncomp:metadata\nrestype:container\ntimeout:20"
+	if got := s.stringToSign(req, 0); got != want {
+		t.Errorf("stringToSign() =\n%q\nwant\n%q", got, want)
+	}
+
+	// A body's length is signed; a zero length is left blank
+	req.Header.Set("Content-Type", "application/pdf")
+	req.Header.Set("Range", "bytes=0-99")
+	got := strings.Split(s.stringToSign(req, 1024), "\n")
+	if got[3] != "1024" || got[5] != "application/pdf" || got[11] != "bytes=0-99" {
+		t.Errorf("stringToSign() fields = %q", got[:12])
+	}
+}
+
+// fakeAzure is an in-memory Blob service container that checks each
+// request's Shared Key signature
+type fakeAzure struct {
+	t     *testing.T
+	store *AzureStore
+	key   []byte
+
+	mu       sync.Mutex
+	blobs    map[string][]byte
+	headers  map[string]http.Header
+	blocks   map[string][]byte
+	pageSize int
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, int64(len(body))); err != nil {
+		f.t.Errorf("%s %s: %v", r.Method, r.URL, err)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, f.store.endpoint.Path+"/"+f.store.container), "/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodGet && query.Get("comp") == "list":
+		f.list(w, query)
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		f.blocks[key+"/"+query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.Unmarshal(body, &list); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var blob []byte
+		for _, id := range list.Latest {
+			blob = append(blob, f.blocks[key+"/"+id]...)
+		}
+		f.put(key, blob, r.Header)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.put(key, body, r.Header)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		blob, ok := f.blobs[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for name, values := range f.headers[key] {
+			if strings.HasPrefix(strings.ToLower(name), "x-ms-meta-") {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set("Content-Type", f.headers[key].Get("x-ms-blob-content-type"))
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+		if r.Method == http.MethodGet {
+			w.Write(blob)
+		}
+	case r.Method == http.MethodDelete:
+		if _, ok := f.blobs[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, key)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeAzure) put(key string, blob []byte, header http.Header) {
+	f.blobs[key] = blob
+	f.headers[key] = header.Clone()
+}
+
+func (f *fakeAzure) list(w http.ResponseWriter, query url.Values) {
+	type blob struct {
+		Name          string `xml:"Name"`
+		ContentLength int    `xml:"Properties>Content-Length"`
+	}
+	var result struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []blob   `xml:"Blobs>Blob"`
+		NextMarker string   `xml:"NextMarker"`
+	}
+	after := query.Get("marker")
+	for _, name := range sortedKeys(f.blobs) {
+		if !strings.HasPrefix(name, query.Get("prefix")) || name <= after {
+			continue
+		}
+		if len(result.Blobs) == f.pageSize {
+			result.NextMarker = result.Blobs[len(result.Blobs)-1].Name
+			break
+		}
+		result.Blobs = append(result.Blobs, blob{Name: name, ContentLength: len(f.blobs[name])})
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+// verify recomputes the Shared Key signature for the request as received
+func (f *fakeAzure) verify(r *http.Request, contentLength int64) error {
+	want := "SharedKey " + azuriteAccount + ":"
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(f.store.stringToSign(r, contentLength)))
+	want += base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if got := r.Header.Get("Authorization"); got != want {
+		return errors.New("signature mismatch: got " + got + ", want " + want)
+	}
+	if r.Header.Get("x-ms-date") == "" || r.Header.Get("x-ms-version") != azureAPIVersion {
+		return errors.New("missing x-ms-date or x-ms-version")
+	}
+	return nil
+}
+
+func TestAzureStoreRoundTrip(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString(azuriteKey)
+	fake := &fakeAzure{t: t, key: key, pageSize: 1,
+		blobs: map[string][]byte{}, headers: map[string]http.Header{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewAzureStore(config.StorageConfig{
+		Driver: "azure", AccessKey: azuriteAccount, SecretKey: azuriteKey, Bucket: "documents",
+		Endpoint: server.URL + "/" + azuriteAccount, Encryption: "kms", KMSKeyID: "cmk-scope",
+	})
+	if err != nil {
+		t.Fatalf("NewAzureStore() error = %v", err)
+	}
+	fake.store = store
+	ctx := context.Background()
+
+	small := "pod/trip 42/photo(1).jpg"
+	if _, err := store.Put(ctx, small, strings.NewReader("signature"), PutOptions{
+		ContentType: "image/jpeg", Metadata: map[string]string{"Trip": "42"},
+	}); err != nil {
+		t.Fatalf("Put(small) error = %v", err)
+	}
+	if scope := fake.headers[small].Get("x-ms-encryption-scope"); scope != "cmk-scope" {
+		t.Errorf("encryption scope = %q, want cmk-scope", scope)
+	}
+
+	// Larger than a block, so it goes up as blocks and a block list
+	large := bytes.Repeat([]byte("0123456789abcdef"), azureBlockSize/16+1000)
+	obj, err := store.Put(ctx, "pod/trip 43/scan.pdf", bytes.NewReader(large), PutOptions{ContentType: "application/pdf"})
+	if err != nil {
+		t.Fatalf("Put(large) error = %v", err)
+	}
+	if obj.Size != int64(len(large)) || len(fake.blocks) != 2 {
+		t.Errorf("Put(large) size = %d in %d blocks, want %d in 2", obj.Size, len(fake.blocks), len(large))
+	}
+	if !bytes.Equal(fake.blobs["pod/trip 43/scan.pdf"], large) {
+		t.Error("committed blob does not match the upload")
+	}
+
+	body, got, err := store.Get(ctx, small)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "signature" || got.ContentType != "image/jpeg" || got.Metadata["trip"] != "42" {
+		t.Errorf("Get() = %q, %+v", data, got)
+	}
+
+	stat, err := store.Stat(ctx, "pod/trip 43/scan.pdf")
+	if err != nil || stat.Size != int64(len(large)) {
+		t.Errorf("Stat() = %+v, %v", stat, err)
+	}
+
+	// One blob per page
+	listed, err := store.List(ctx, "pod/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(listed) != 2 || listed[0].Key != small || listed[1].Size != int64(len(large)) {
+		t.Errorf("List() = %+v", listed)
+	}
+
+	if err := store.Delete(ctx, small); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	// Deleting a missing blob succeeds
+	if err := store.Delete(ctx, small); err != nil {
+		t.Errorf("Delete() of missing blob error = %v", err)
+	}
+	if _, err := store.Stat(ctx, small); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat() after delete error = %v, want ErrNotFound", err)
+	}
+}
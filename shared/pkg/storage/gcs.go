@@ -0,0 +1,440 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	// gcsMetadataTokenURL serves tokens for the attached service account on GCE/GKE
+	gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCSStore keeps objects in a Google Cloud Storage bucket through the JSON
+// API. It authenticates with the service account key in cfg.CredsFile, or
+// the instance's service account when no file is set. GCS encrypts every
+// object; KMS names a Cloud KMS key.
+type GCSStore struct {
+	bucket     string
+	endpoint   string
+	encryption Encryption
+	kmsKey     string
+	client     *http.Client
+	creds      *gcsCredentials
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// gcsCredentials is the part of a service account key file used to mint tokens
+type gcsCredentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// NewGCSStore creates a store for bucket cfg.Bucket
+func NewGCSStore(cfg config.StorageConfig) (*GCSStore, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("gcs storage needs a bucket")
+	}
+	s := &GCSStore{
+		bucket:     cfg.Bucket,
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		encryption: Encryption(strings.ToUpper(cfg.Encryption)),
+		kmsKey:     cfg.KMSKeyID,
+		client:     newHTTPClient(),
+	}
+	if s.endpoint == "" {
+		s.endpoint = gcsDefaultEndpoint
+	}
+	if cfg.CredsFile != "" {
+		creds, err := loadGCSCredentials(cfg.CredsFile)
+		if err != nil {
+			return nil, err
+		}
+		s.creds = creds
+	}
+	return s, nil
+}
+
+func loadGCSCredentials(path string) (*gcsCredentials, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gcs credentials: %w", err)
+	}
+	var creds gcsCredentials
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return nil, fmt.Errorf("parse gcs credentials: %w", err)
+	}
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("gcs credentials have no private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse gcs private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("gcs private key is not RSA")
+	}
+	creds.key = key
+	if creds.TokenURI == "" {
+		creds.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &creds, nil
+}
+
+// gcsObject is the JSON API object resource
+type gcsObject struct {
+	Name        string            `json:"name"`
+	Size        string            `json:"size"`
+	ContentType string            `json:"contentType"`
+	ETag        string            `json:"etag"`
+	Updated     time.Time         `json:"updated"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	KMSKeyName  string            `json:"kmsKeyName,omitempty"`
+}
+
+func (o gcsObject) object() *Object {
+	size, _ := strconv.ParseInt(o.Size, 10, 64)
+	return &Object{
+		Key:          o.Name,
+		Size:         size,
+		ContentType:  o.ContentType,
+		ETag:         o.ETag,
+		LastModified: o.Updated,
+		Metadata:     o.Metadata,
+	}
+}
+
+// Put uploads the object as a multipart upload, streaming the body through
+// a pipe
+func (s *GCSStore) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	resource := gcsObject{Name: key, ContentType: opts.ContentType, Metadata: opts.Metadata}
+	query := url.Values{"uploadType": {"multipart"}}
+	if mode, kmsKey := encryption(opts, s.encryption, s.kmsKey); mode == EncryptionKMS {
+		if kmsKey == "" {
+			return nil, errors.New("gcs KMS encryption needs a key name")
+		}
+		query.Set("kmsKeyName", kmsKey)
+	}
+	meta, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("encode gcs object: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeGCSMultipart(mw, meta, opts.ContentType, body))
+	}()
+	defer pr.Close()
+
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", s.endpoint, url.PathEscape(s.bucket), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, pr)
+	if err != nil {
+		return nil, fmt.Errorf("build gcs request: %w", err)
+	}
+	req.Header.Set("Content-Type", "multipart/related; boundary="+mw.Boundary())
+
+	var out gcsObject
+	if err := s.doJSON(req, "gcs put "+key, &out); err != nil {
+		return nil, err
+	}
+	return out.object(), nil
+}
+
+func writeGCSMultipart(mw *multipart.Writer, meta []byte, contentType string, body io.Reader) error {
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(meta); err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, body); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// Get streams the object
+func (s *GCSStore) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key)+"?alt=media", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build gcs request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(resp, "gcs get "+key); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	return resp.Body, obj, nil
+}
+
+// Stat describes the object
+func (s *GCSStore) Stat(ctx context.Context, key string) (*Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build gcs request: %w", err)
+	}
+	var out gcsObject
+	if err := s.doJSON(req, "gcs stat "+key, &out); err != nil {
+		return nil, err
+	}
+	return out.object(), nil
+}
+
+// Delete removes the object
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("build gcs request: %w", err)
+	}
+	if err := s.doJSON(req, "gcs delete "+key, nil); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// List returns the objects whose keys start with prefix, following pages
+func (s *GCSStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {prefix}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		u := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", s.endpoint, url.PathEscape(s.bucket), query.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build gcs request: %w", err)
+		}
+		var page struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := s.doJSON(req, "gcs list "+prefix, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			objects = append(objects, *item.object())
+		}
+		if page.NextPageToken == "" {
+			return objects, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// SetLifecycle replaces the bucket's lifecycle configuration with rules
+func (s *GCSStore) SetLifecycle(ctx context.Context, rules []LifecycleRule) error {
+	type condition struct {
+		Age           int      `json:"age"`
+		MatchesPrefix []string `json:"matchesPrefix,omitempty"`
+	}
+	type action struct {
+		Type         string `json:"type"`
+		StorageClass string `json:"storageClass,omitempty"`
+	}
+	type rule struct {
+		Action    action    `json:"action"`
+		Condition condition `json:"condition"`
+	}
+
+	lifecycle := []rule{}
+	for _, r := range rules {
+		var prefixes []string
+		if r.Prefix != "" {
+			prefixes = []string{r.Prefix}
+		}
+		if r.ArchiveAfterDays > 0 {
+			lifecycle = append(lifecycle, rule{
+				Action:    action{Type: "SetStorageClass", StorageClass: "ARCHIVE"},
+				Condition: condition{Age: r.ArchiveAfterDays, MatchesPrefix: prefixes},
+			})
+		}
+		if r.ExpireAfterDays > 0 {
+			lifecycle = append(lifecycle, rule{
+				Action:    action{Type: "Delete"},
+				Condition: condition{Age: r.ExpireAfterDays, MatchesPrefix: prefixes},
+			})
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"lifecycle": map[string]interface{}{"rule": lifecycle},
+	})
+	if err != nil {
+		return fmt.Errorf("encode gcs lifecycle: %w", err)
+	}
+	u := fmt.Sprintf("%s/storage/v1/b/%s?fields=lifecycle", s.endpoint, url.PathEscape(s.bucket))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build gcs request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return s.doJSON(req, "gcs set lifecycle", nil)
+}
+
+func (s *GCSStore) objectURL(key string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", s.endpoint, url.PathEscape(s.bucket), url.PathEscape(key))
+}
+
+// doJSON sends the request and decodes a JSON response into out, if given
+func (s *GCSStore) doJSON(req *http.Request, op string, out interface{}) error {
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, op); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", op, err)
+	}
+	return nil
+}
+
+// do authorizes and sends the request
+func (s *GCSStore) do(req *http.Request) (*http.Response, error) {
+	token, err := s.accessToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcs %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// accessToken returns a cached OAuth token, fetching a new one shortly
+// before the current one expires
+func (s *GCSStore) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.tokenExpiry.Add(-time.Minute)) {
+		return s.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	if s.creds != nil {
+		req, err = s.jwtTokenRequest(ctx)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, gcsMetadataTokenURL, nil)
+		if err == nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("build gcs token request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch gcs token: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "fetch gcs token"); err != nil {
+		return "", err
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("decode gcs token: %w", err)
+	}
+	s.token = tok.AccessToken
+	s.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// jwtTokenRequest builds the JWT bearer grant for the service account
+func (s *GCSStore) jwtTokenRequest(ctx context.Context) (*http.Request, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.creds.ClientEmail,
+		"scope": gcsScope,
+		"aud":   s.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.creds.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign gcs token request: %w", err)
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.creds.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+const gcsTestToken = "ya29.test-token"
+
+// writeGCSCredentials writes a service account key file whose tokens come
+// from tokenURI
+func writeGCSCredentials(t *testing.T, key *rsa.PrivateKey, tokenURI string) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	raw, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "documents@draymaster-test.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "creds.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write credentials: %v", err)
+	}
+	return path
+}
+
+// verifyGCSAssertion checks the JWT bearer grant against the service
+// account's public key and returns its claims
+func verifyGCSAssertion(pub *rsa.PublicKey, assertion string) (map[string]interface{}, error) {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("assertion is not a JWT")
+	}
+	var header map[string]string
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if err := json.Unmarshal(raw, &header); err != nil || header["alg"] != "RS256" || header["typ"] != "JWT" {
+		return nil, errors.New("bad JWT header " + string(raw))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	raw, _ = base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// fakeGCS is an in-memory JSON API bucket with an OAuth token endpoint
+type fakeGCS struct {
+	t        *testing.T
+	bucket   string
+	pub      *rsa.PublicKey
+	tokenURI string
+
+	mu          sync.Mutex
+	tokenGrants int
+	objects     map[string]gcsObject
+	data        map[string][]byte
+	uploadQuery url.Values
+	pageSize    int
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		f.token(w, r)
+		return
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer "+gcsTestToken {
+		f.t.Errorf("%s %s: Authorization = %q", r.Method, r.URL, got)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	bucketPath := "/storage/v1/b/" + f.bucket + "/o"
+	path := r.URL.EscapedPath()
+	switch {
+	case r.Method == http.MethodPost && path == "/upload"+bucketPath:
+		f.upload(w, r)
+	case r.Method == http.MethodGet && path == bucketPath:
+		f.list(w, r.URL.Query())
+	case strings.HasPrefix(path, bucketPath+"/"):
+		// Object names are a single escaped path segment
+		name, err := url.PathUnescape(strings.TrimPrefix(path, bucketPath+"/"))
+		if err != nil || strings.Contains(strings.TrimPrefix(path, bucketPath+"/"), "/") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		obj, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("alt") == "media":
+			w.Write(f.data[name])
+		default:
+			json.NewEncoder(w).Encode(obj)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGCS) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	claims, err := verifyGCSAssertion(f.pub, r.PostForm.Get("assertion"))
+	if err != nil {
+		f.t.Errorf("token assertion: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if claims["iss"] != "documents@draymaster-test.iam.gserviceaccount.com" || claims["aud"] != f.tokenURI ||
+		claims["scope"] != gcsScope {
+		f.t.Errorf("token claims = %v", claims)
+	}
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+	if exp-iat != 3600 || time.Since(time.Unix(int64(iat), 0)) > time.Minute {
+		f.t.Errorf("token lifetime iat=%v exp=%v", iat, exp)
+	}
+	f.tokenGrants++
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": gcsTestToken, "expires_in": 3599, "token_type": "Bearer",
+	})
+}
+
+func (f *fakeGCS) upload(w http.ResponseWriter, r *http.Request) {
+	f.uploadQuery = r.URL.Query()
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" || f.uploadQuery.Get("uploadType") != "multipart" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	if err != nil || !strings.HasPrefix(part.Header.Get("Content-Type"), "application/json") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var obj gcsObject
+	if err := json.NewDecoder(part).Decode(&obj); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, _ := io.ReadAll(part)
+	if part.Header.Get("Content-Type") != obj.ContentType {
+		f.t.Errorf("media Content-Type = %q, metadata says %q", part.Header.Get("Content-Type"), obj.ContentType)
+	}
+
+	obj.Size = strconv.Itoa(len(data))
+	obj.ETag = "etag-" + obj.Name
+	obj.Updated = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	f.objects[obj.Name] = obj
+	f.data[obj.Name] = data
+	json.NewEncoder(w).Encode(obj)
+}
+
+func (f *fakeGCS) list(w http.ResponseWriter, query url.Values) {
+	var page struct {
+		Items         []gcsObject `json:"items,omitempty"`
+		NextPageToken string      `json:"nextPageToken,omitempty"`
+	}
+	for _, name := range sortedKeys(f.objects) {
+		if !strings.HasPrefix(name, query.Get("prefix")) || name <= query.Get("pageToken") {
+			continue
+		}
+		if len(page.Items) == f.pageSize {
+			page.NextPageToken = page.Items[len(page.Items)-1].Name
+			break
+		}
+		page.Items = append(page.Items, f.objects[name])
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func TestGCSStoreRoundTrip(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	fake := &fakeGCS{t: t, bucket: "draymaster-docs", pub: &key.PublicKey, pageSize: 2,
+		objects: map[string]gcsObject{}, data: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	fake.tokenURI = server.URL + "/token"
+
+	store, err := NewGCSStore(config.StorageConfig{
+		Driver: "gcs", Bucket: "draymaster-docs", Endpoint: server.URL,
+		CredsFile:  writeGCSCredentials(t, key, fake.tokenURI),
+		Encryption: "kms", KMSKeyID: "projects/p/locations/us/keyRings/docs/cryptoKeys/pod",
+	})
+	if err != nil {
+		t.Fatalf("NewGCSStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	keys := []string{"pod/trip 42/photo(1).jpg", "pod/trip 42/signature.png", "pod/trip 43/bol.pdf", "rates/2026.csv"}
+	for _, k := range keys {
+		if _, err := store.Put(ctx, k, strings.NewReader("contents of "+k), PutOptions{
+			ContentType: "image/jpeg", Metadata: map[string]string{"trip": "42"},
+		}); err != nil {
+			t.Fatalf("Put(%q) error = %v", k, err)
+		}
+	}
+	if got := fake.uploadQuery.Get("kmsKeyName"); got != "projects/p/locations/us/keyRings/docs/cryptoKeys/pod" {
+		t.Errorf("upload kmsKeyName = %q", got)
+	}
+
+	body, obj, err := store.Get(ctx, keys[0])
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "contents of "+keys[0] {
+		t.Errorf("Get() body = %q", data)
+	}
+	if obj.Size != int64(len(data)) || obj.ContentType != "image/jpeg" || obj.Metadata["trip"] != "42" || obj.ETag != "etag-"+keys[0] {
+		t.Errorf("Get() object = %+v", obj)
+	}
+
+	// Three matches over two pages
+	listed, err := store.List(ctx, "pod/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(listed) != 3 || listed[0].Key != keys[0] || listed[2].Key != keys[2] {
+		t.Errorf("List() = %+v", listed)
+	}
+
+	if err := store.Delete(ctx, keys[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	// Deleting a missing object succeeds
+	if err := store.Delete(ctx, keys[0]); err != nil {
+		t.Errorf("Delete() of missing object error = %v", err)
+	}
+	if _, _, err := store.Get(ctx, keys[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+
+	// One token served every request
+	if fake.tokenGrants != 1 {
+		t.Errorf("token grants = %d, want 1", fake.tokenGrants)
+	}
+}
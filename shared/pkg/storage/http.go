@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHTTPTimeout bounds provider calls that do not stream a body
+const defaultHTTPTimeout = 30 * time.Second
+
+// newHTTPClient returns the client the cloud drivers share. It has no
+// overall timeout, since uploads and downloads stream for as long as they
+// take; callers bound them with their context.
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = defaultHTTPTimeout
+	return &http.Client{Transport: transport}
+}
+
+// checkResponse maps a provider response to an error, reading a bounded
+// amount of the body for the message. 404 becomes ErrNotFound.
+func checkResponse(resp *http.Response, op string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("%s: status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// objectFromHeaders reads an object's description from a GET or HEAD
+// response, collecting user metadata from headers with metaPrefix
+func objectFromHeaders(key string, header http.Header, metaPrefix string) *Object {
+	obj := &Object{
+		Key:         key,
+		ContentType: header.Get("Content-Type"),
+		ETag:        strings.Trim(header.Get("ETag"), `"`),
+	}
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		obj.Size = n
+	}
+	if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		obj.LastModified = t
+	}
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, metaPrefix) && len(values) > 0 {
+			if obj.Metadata == nil {
+				obj.Metadata = make(map[string]string)
+			}
+			obj.Metadata[strings.TrimPrefix(lower, metaPrefix)] = values[0]
+		}
+	}
+	return obj
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// localMetaDir holds each object's content type and metadata, beside the
+// objects under the root
+const localMetaDir = ".meta"
+
+// LocalStore keeps objects as files under a root directory, for development
+// and single-node installs. It has no server-side encryption.
+type LocalStore struct {
+	root string
+}
+
+// localMeta is the sidecar stored for each object
+type localMeta struct {
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// NewLocalStore creates a store rooted at cfg.LocalRoot, creating it if needed
+func NewLocalStore(cfg config.StorageConfig) (*LocalStore, error) {
+	if cfg.LocalRoot == "" {
+		return nil, errors.New("local storage root is required")
+	}
+	if err := os.MkdirAll(cfg.LocalRoot, 0o750); err != nil {
+		return nil, fmt.Errorf("create storage root: %w", err)
+	}
+	return &LocalStore{root: cfg.LocalRoot}, nil
+}
+
+// Put writes the object to a temp file and renames it into place, so
+// readers never see a partial upload
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if mode, _ := encryption(opts, EncryptionNone, ""); mode != EncryptionNone {
+		return nil, fmt.Errorf("%w: local storage does not encrypt", ErrUnsupported)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("write object: %w", err)
+	}
+
+	meta, err := json.Marshal(localMeta{ContentType: opts.ContentType, Metadata: opts.Metadata})
+	if err != nil {
+		return nil, fmt.Errorf("encode object metadata: %w", err)
+	}
+	metaPath := s.metaPath(key)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0o750); err != nil {
+		return nil, fmt.Errorf("create metadata directory: %w", err)
+	}
+	if err := os.WriteFile(metaPath, meta, 0o640); err != nil {
+		return nil, fmt.Errorf("write object metadata: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("store object: %w", err)
+	}
+	return s.Stat(ctx, key)
+}
+
+// Get opens the object for reading
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, _ := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("open object: %w", err)
+	}
+	return f, obj, nil
+}
+
+// Stat describes the object
+func (s *LocalStore) Stat(ctx context.Context, key string) (*Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("stat object: %w", err)
+	}
+	obj := &Object{Key: key, Size: info.Size(), LastModified: info.ModTime()}
+	if raw, err := os.ReadFile(s.metaPath(key)); err == nil {
+		var meta localMeta
+		if json.Unmarshal(raw, &meta) == nil {
+			obj.ContentType = meta.ContentType
+			obj.Metadata = meta.Metadata
+		}
+	}
+	return obj, nil
+}
+
+// Delete removes the object and its metadata
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete object: %w", err)
+	}
+	if err := os.Remove(s.metaPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete object metadata: %w", err)
+	}
+	return nil
+}
+
+// List returns the objects whose keys start with prefix, in key order
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == localMetaDir && filepath.Dir(path) == filepath.Clean(s.root) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		obj, err := s.Stat(ctx, key)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *obj)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+	return objects, nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	if key == localMetaDir || strings.HasPrefix(key, localMetaDir+"/") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) metaPath(key string) string {
+	return filepath.Join(s.root, localMetaDir, filepath.FromSlash(key)+".json")
+}
@@ -0,0 +1,414 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// s3UnsignedPayload lets object bodies stream without hashing them first;
+// the request is still signed and goes over TLS
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Store keeps objects in an S3 bucket, or any S3-compatible store such as
+// MinIO when an endpoint is configured. Requests are signed with AWS
+// Signature Version 4. Single-request uploads cap objects at 5 GiB.
+type S3Store struct {
+	bucket     string
+	region     string
+	endpoint   *url.URL
+	pathStyle  bool // Bucket in the path rather than the host, for custom endpoints
+	accessKey  string
+	secretKey  string
+	encryption Encryption
+	kmsKeyID   string
+	client     *http.Client
+}
+
+// NewS3Store creates a store for cfg.Bucket
+func NewS3Store(cfg config.StorageConfig) (*S3Store, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3 storage needs a bucket and region")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("s3 storage needs an access key and secret key")
+	}
+
+	s := &S3Store{
+		bucket:     cfg.Bucket,
+		region:     cfg.Region,
+		accessKey:  cfg.AccessKey,
+		secretKey:  cfg.SecretKey,
+		encryption: Encryption(strings.ToUpper(cfg.Encryption)),
+		kmsKeyID:   cfg.KMSKeyID,
+		client:     newHTTPClient(),
+	}
+	raw := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", cfg.Bucket, cfg.Region)
+	if cfg.Endpoint != "" {
+		raw = cfg.Endpoint
+		s.pathStyle = true
+	}
+	endpoint, err := url.Parse(strings.TrimRight(raw, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse s3 endpoint: %w", err)
+	}
+	s.endpoint = endpoint
+	return s, nil
+}
+
+// Put uploads the object in one request, spooling it first when its size is unknown
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	size := opts.Size
+	if size <= 0 {
+		spooled, err := spool(body)
+		if err != nil {
+			return nil, err
+		}
+		defer spooled.Close()
+		body, size = spooled, spooled.size
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	for k, v := range opts.Metadata {
+		req.Header.Set("x-amz-meta-"+strings.ToLower(k), v)
+	}
+	switch mode, keyID := encryption(opts, s.encryption, s.kmsKeyID); mode {
+	case EncryptionManaged:
+		req.Header.Set("x-amz-server-side-encryption", "AES256")
+	case EncryptionKMS:
+		req.Header.Set("x-amz-server-side-encryption", "aws:kms")
+		if keyID != "" {
+			req.Header.Set("x-amz-server-side-encryption-aws-kms-key-id", keyID)
+		}
+	}
+
+	resp, err := s.do(req, s3UnsignedPayload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "s3 put "+key); err != nil {
+		return nil, err
+	}
+	return &Object{
+		Key:          key,
+		Size:         size,
+		ContentType:  opts.ContentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: time.Now(),
+		Metadata:     opts.Metadata,
+	}, nil
+}
+
+// Get streams the object
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	resp, err := s.objectRequest(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(resp, "s3 get "+key); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	return resp.Body, objectFromHeaders(key, resp.Header, "x-amz-meta-"), nil
+}
+
+// Stat describes the object
+func (s *S3Store) Stat(ctx context.Context, key string) (*Object, error) {
+	resp, err := s.objectRequest(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "s3 head "+key); err != nil {
+		return nil, err
+	}
+	return objectFromHeaders(key, resp.Header, "x-amz-meta-"), nil
+}
+
+// Delete removes the object
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.objectRequest(ctx, http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "s3 delete "+key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// s3ListResult is a ListObjectsV2 response page
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// List returns the objects whose keys start with prefix, following pages
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req, emptySHA256)
+		if err != nil {
+			return nil, err
+		}
+		var page s3ListResult
+		err = checkResponse(resp, "s3 list "+prefix)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range page.Contents {
+			objects = append(objects, Object{
+				Key:          c.Key,
+				Size:         c.Size,
+				ETag:         strings.Trim(c.ETag, `"`),
+				LastModified: c.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// s3Lifecycle is a bucket lifecycle configuration
+type s3Lifecycle struct {
+	XMLName xml.Name          `xml:"LifecycleConfiguration"`
+	Rules   []s3LifecycleRule `xml:"Rule"`
+}
+
+type s3LifecycleRule struct {
+	ID         string        `xml:"ID"`
+	Prefix     string        `xml:"Filter>Prefix"`
+	Status     string        `xml:"Status"`
+	Transition *s3Transition `xml:"Transition,omitempty"`
+	Expiration *s3Days       `xml:"Expiration,omitempty"`
+}
+
+type s3Transition struct {
+	Days         int    `xml:"Days"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3Days struct {
+	Days int `xml:"Days"`
+}
+
+// SetLifecycle replaces the bucket's lifecycle configuration with rules;
+// archived objects move to Glacier
+func (s *S3Store) SetLifecycle(ctx context.Context, rules []LifecycleRule) error {
+	lifecycle := s3Lifecycle{}
+	for _, rule := range rules {
+		r := s3LifecycleRule{ID: rule.ID, Prefix: rule.Prefix, Status: "Enabled"}
+		if rule.ArchiveAfterDays > 0 {
+			r.Transition = &s3Transition{Days: rule.ArchiveAfterDays, StorageClass: "GLACIER"}
+		}
+		if rule.ExpireAfterDays > 0 {
+			r.Expiration = &s3Days{Days: rule.ExpireAfterDays}
+		}
+		lifecycle.Rules = append(lifecycle.Rules, r)
+	}
+	body, err := xml.Marshal(lifecycle)
+	if err != nil {
+		return fmt.Errorf("encode lifecycle: %w", err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, "", url.Values{"lifecycle": {""}}, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(body)
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	req.Header.Set("Content-Type", "application/xml")
+	payload := sha256.Sum256(body)
+
+	resp, err := s.do(req, hex.EncodeToString(payload[:]))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, "s3 put lifecycle")
+}
+
+func (s *S3Store) objectRequest(ctx context.Context, method, key string) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, method, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req, emptySHA256)
+}
+
+// newRequest builds a request for key in the bucket, or the bucket itself
+// when key is empty
+func (s *S3Store) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	p := "/"
+	if s.pathStyle {
+		p += s.bucket + "/"
+	}
+	p += key
+	u.Path = p
+	u.RawPath = awsURIEncode(p, false)
+	u.RawQuery = awsCanonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build s3 request: %w", err)
+	}
+	return req, nil
+}
+
+// do signs and sends the request
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// emptySHA256 is the payload hash of a request without a body
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds an AWS Signature Version 4 Authorization header
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode percent-encodes everything but RFC 3986 unreserved
+// characters, and slashes unless encodeSlash is set
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// awsCanonicalQuery encodes query sorted by name, as the signature expects
+func awsCanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsURIEncode(name, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,192 @@
+// Package storage keeps file artifacts - PODs, driver documents, OCR inputs
+// and exports - in a blob store behind one interface, with drivers for S3,
+// Azure Blob Storage, Google Cloud Storage and the local filesystem.
+// Uploads and downloads stream; nothing is held whole in memory.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/draymaster/shared/pkg/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrUnsupported is returned when a driver cannot honour an option
+var ErrUnsupported = errors.New("storage: not supported by driver")
+
+// Encryption selects server-side encryption for an upload
+type Encryption string
+
+const (
+	EncryptionNone    Encryption = "NONE"
+	EncryptionManaged Encryption = "MANAGED" // Provider-managed keys: S3 AES256, GCS and Azure defaults
+	EncryptionKMS     Encryption = "KMS"     // Customer-managed key: S3 aws:kms, GCS kmsKeyName, Azure encryption scope
+)
+
+// PutOptions controls an upload
+type PutOptions struct {
+	ContentType string
+	Size        int64             // Body length when known; drivers that need one spool the body to a temp file otherwise
+	Encryption  Encryption        // The store's default when empty
+	KMSKeyID    string            // The store's key when empty
+	Metadata    map[string]string // Stored with the object; keys are lower-cased by some providers
+}
+
+// Object describes a stored object
+type Object struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Store is a blob store. Keys are slash-separated paths without a leading
+// slash. Get returns ErrNotFound for a missing key; Delete of a missing key
+// succeeds.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*Object, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// LifecycleRule expires, and optionally archives, objects under a prefix
+type LifecycleRule struct {
+	ID               string `json:"id"`
+	Prefix           string `json:"prefix"`
+	ExpireAfterDays  int    `json:"expire_after_days"`  // 0 keeps objects forever
+	ArchiveAfterDays int    `json:"archive_after_days"` // Move to cold storage; 0 never archives
+}
+
+// LifecycleManager is implemented by drivers whose provider enforces
+// lifecycle rules itself
+type LifecycleManager interface {
+	SetLifecycle(ctx context.Context, rules []LifecycleRule) error
+}
+
+// New creates the store selected by cfg.Driver
+func New(cfg config.StorageConfig) (Store, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "local":
+		return NewLocalStore(cfg)
+	case "s3":
+		return NewS3Store(cfg)
+	case "azure":
+		return NewAzureStore(cfg)
+	case "gcs":
+		return NewGCSStore(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// ApplyLifecycle puts rules into effect. Providers that enforce lifecycle
+// rules themselves get the rules installed; otherwise objects past a rule's
+// expiry are deleted now, so callers run it on a schedule. Archiving needs
+// provider support and is skipped when swept.
+func ApplyLifecycle(ctx context.Context, store Store, rules []LifecycleRule, now time.Time) error {
+	if manager, ok := store.(LifecycleManager); ok {
+		return manager.SetLifecycle(ctx, rules)
+	}
+
+	for _, rule := range rules {
+		if rule.ExpireAfterDays <= 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -rule.ExpireAfterDays)
+		objects, err := store.List(ctx, rule.Prefix)
+		if err != nil {
+			return fmt.Errorf("list %q for lifecycle rule %s: %w", rule.Prefix, rule.ID, err)
+		}
+		for _, obj := range objects {
+			if obj.LastModified.Before(cutoff) {
+				if err := store.Delete(ctx, obj.Key); err != nil {
+					return fmt.Errorf("expire %s: %w", obj.Key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Key joins path elements into an object key, dropping empty elements and
+// any attempt to climb out of the prefix
+func Key(elem ...string) string {
+	parts := make([]string, 0, len(elem))
+	for _, e := range elem {
+		e = strings.Trim(e, "/")
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	return strings.TrimLeft(path.Clean("/"+strings.Join(parts, "/")), "/")
+}
+
+// validKey rejects keys that are empty or not already clean
+func validKey(key string) error {
+	if key == "" || key != Key(key) {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	return nil
+}
+
+// encryption resolves an upload's encryption and key against the store's defaults
+func encryption(opts PutOptions, defaultMode Encryption, defaultKey string) (Encryption, string) {
+	mode := opts.Encryption
+	if mode == "" {
+		mode = defaultMode
+	}
+	if mode == "" {
+		mode = EncryptionNone
+	}
+	key := opts.KMSKeyID
+	if key == "" {
+		key = defaultKey
+	}
+	return mode, key
+}
+
+// spool copies a body of unknown length to a temp file so its length can be
+// sent up front. The caller closes the file, which removes it.
+func spool(body io.Reader) (*spooled, error) {
+	f, err := os.CreateTemp("", "storage-upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("create spool file: %w", err)
+	}
+	s := &spooled{File: f}
+	n, err := io.Copy(f, body)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("spool upload: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("rewind spool file: %w", err)
+	}
+	s.size = n
+	return s, nil
+}
+
+type spooled struct {
+	*os.File
+	size int64
+}
+
+// Close closes and removes the spool file
+func (s *spooled) Close() error {
+	err := s.File.Close()
+	os.Remove(s.Name())
+	return err
+}
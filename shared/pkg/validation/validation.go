@@ -3,6 +3,8 @@ package validation
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -279,3 +281,46 @@ func (v *StringValidator) ValidateFormat(value, fieldName, pattern string) error
 	}
 	return nil
 }
+
+// Tags are short free-form labels on trips and orders such as "hot" or
+// "vip". They are compared in their normalized form.
+const (
+	MaxTags      = 20
+	MaxTagLength = 32
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TagValidator validates and normalizes tags
+type TagValidator struct{}
+
+func NewTagValidator() *TagValidator {
+	return &TagValidator{}
+}
+
+// Normalize lowercases tags, turns inner spaces into hyphens, drops blanks
+// and duplicates and sorts the rest. It fails on a tag with other than
+// letters, digits, hyphens and underscores, or on too many or too long tags.
+func (v *TagValidator) Normalize(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q must be at most %d characters", tag, MaxTagLength)
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q may only contain letters, digits, hyphens and underscores", tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed, got %d", MaxTags, len(normalized))
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}